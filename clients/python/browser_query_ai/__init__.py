@@ -0,0 +1,6 @@
+"""Python client for Browser Query AI."""
+
+from ._generated import *  # noqa: F401,F403  (request and response types)
+from .client import APIError, Client, Page, Session
+
+__all__ = ["APIError", "Client", "Page", "Session"]
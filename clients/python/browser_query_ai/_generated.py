@@ -0,0 +1,1721 @@
+# Code generated by cmd/clientgen from internal/api/endpoints.go. DO NOT EDIT.
+
+from __future__ import annotations
+
+from typing import Any, Iterator, NotRequired, TypedDict
+from urllib.parse import quote
+
+
+class CreateSessionRequest(TypedDict):
+    agent_id: str
+    session_name: NotRequired[str]
+    browser_port: NotRequired[int]
+    template: NotRequired[str]
+    options: NotRequired[SessionOptions | None]
+
+
+class SessionOptions(TypedDict):
+    viewport: NotRequired[Viewport | None]
+    user_agent: NotRequired[str]
+    proxy: NotRequired[str]
+    proxy_bypass: NotRequired[str]
+    blocked_urls: NotRequired[list[str]]
+    init_scripts: NotRequired[list[str]]
+    cookies: NotRequired[list[Cookie]]
+    navigation_timeout_ms: NotRequired[int]
+    idle_timeout_ms: NotRequired[int]
+    pipeline: NotRequired[str]
+    labels: NotRequired[dict[str, str]]
+    profile: NotRequired[str]
+    extensions: NotRequired[list[str]]
+    engine: NotRequired[str]
+    locale: NotRequired[str]
+    timezone: NotRequired[str]
+    disable_animations: NotRequired[bool]
+    websockets: NotRequired[WebSocketCapture | None]
+    streams: NotRequired[StreamCapture | None]
+    ignore_certificate_errors: NotRequired[bool]
+    client_certificates: NotRequired[list[ClientCertificate]]
+    egress: NotRequired[EgressPolicy | None]
+
+
+class Viewport(TypedDict):
+    width: int
+    height: int
+    device_scale_factor: NotRequired[float]
+    mobile: NotRequired[bool]
+
+
+class Cookie(TypedDict):
+    name: str
+    value: str
+    domain: str
+    path: str
+    expires: float
+    secure: bool
+    httpOnly: bool
+    sameSite: str
+
+
+class WebSocketCapture(TypedDict):
+    max_frames: NotRequired[int]
+    max_payload_bytes: NotRequired[int]
+    masks: NotRequired[list[PayloadMask]]
+
+
+class PayloadMask(TypedDict):
+    pattern: str
+    replacement: NotRequired[str]
+
+
+class StreamCapture(TypedDict):
+    max_messages: NotRequired[int]
+    max_payload_bytes: NotRequired[int]
+    mime_types: NotRequired[list[str]]
+    masks: NotRequired[list[PayloadMask]]
+
+
+class ClientCertificate(TypedDict):
+    credential: str
+    hosts: list[str]
+
+
+class EgressPolicy(TypedDict):
+    allow: NotRequired[list[str]]
+    deny: NotRequired[list[str]]
+    headers: NotRequired[dict[str, str]]
+
+
+class CreateSessionResponse(TypedDict):
+    session_id: str
+    session_name: str
+    agent_id: str
+    context_id: str
+    created_at: str
+
+
+class ListSessionsResponse(TypedDict):
+    sessions: list[SessionInfo]
+    count: int
+
+
+class SessionInfo(TypedDict):
+    session_id: str
+    session_name: str
+    agent_id: str
+    context_id: str
+    page_count: int
+    created_at: str
+    last_activity: str
+    status: str
+    labels: NotRequired[dict[str, str]]
+
+
+class DestroySessionsResponse(TypedDict):
+    results: list[DestroyOutcome]
+    destroyed: int
+    failed: int
+
+
+class DestroyOutcome(TypedDict):
+    session_id: str
+    destroyed: bool
+    error: NotRequired[str]
+
+
+class DestroySessionBatchRequest(TypedDict):
+    session_ids: list[str]
+
+
+class ResumeSessionRequest(TypedDict):
+    agent_id: str
+    session_name: str
+
+
+class ResumeSessionResponse(TypedDict):
+    session_id: str
+    session_name: str
+    resumed: bool
+    created_at: str
+
+
+class GetSessionResponse(TypedDict):
+    session_id: str
+    session_name: str
+    agent_id: str
+    context_id: str
+    page_ids: list[str]
+    page_count: int
+    created_at: str
+    last_activity: str
+    status: str
+    connection: NotRequired[str]
+
+
+class RenameSessionRequest(TypedDict):
+    session_name: str
+
+
+class MemoryResponse(TypedDict):
+    session_id: str
+    turns: list[MemoryTurn]
+    extracts: list[MemoryExtract]
+    bytes: int
+
+
+class MemoryTurn(TypedDict):
+    question: str
+    answer: str
+    page_id: str
+    url: NotRequired[str]
+    elements: list[ElementMark]
+    at: str
+
+
+class ElementMark(TypedDict):
+    mark: int
+    tag: str
+    role: NotRequired[str]
+    text: NotRequired[str]
+    selector: str
+    box: ElementBox
+
+
+class ElementBox(TypedDict):
+    x: float
+    y: float
+    width: float
+    height: float
+
+
+class MemoryExtract(TypedDict):
+    page_id: str
+    url: NotRequired[str]
+    text: str
+    at: str
+
+
+class StorageResponse(TypedDict):
+    session_id: str
+    page_id: str
+    origin: str
+    local: dict[str, str]
+    session: dict[str, str]
+    indexed_db: list[IndexedDatabase]
+
+
+class IndexedDatabase(TypedDict):
+    name: str
+    version: float
+    object_stores: list[IndexedDBStore]
+
+
+class IndexedDBStore(TypedDict):
+    name: str
+    key_path: NotRequired[str]
+    auto_increment: NotRequired[bool]
+    entries: int
+    indexes: NotRequired[list[str]]
+
+
+class WriteStorageRequest(TypedDict):
+    page_id: str
+    origin: NotRequired[str]
+    area: str
+    set: NotRequired[dict[str, str]]
+    remove: NotRequired[list[str]]
+    clear: NotRequired[bool]
+
+
+class ServiceWorkersResponse(TypedDict):
+    session_id: str
+    page_id: str
+    service_workers: list[ServiceWorker]
+    count: int
+
+
+class ServiceWorker(TypedDict):
+    registration_id: str
+    scope_url: str
+    script_url: NotRequired[str]
+    status: NotRequired[str]
+    running_status: NotRequired[str]
+
+
+class UnregisterServiceWorkersResponse(TypedDict):
+    session_id: str
+    page_id: str
+    unregistered: list[ServiceWorker]
+    count: int
+
+
+class CacheStorageResponse(TypedDict):
+    session_id: str
+    page_id: str
+    origin: str
+    caches: list[StorageCache]
+
+
+class StorageCache(TypedDict):
+    name: str
+    entries: int
+
+
+class CreateCheckpointRequest(TypedDict):
+    label: NotRequired[str]
+
+
+class Checkpoint(TypedDict):
+    checkpoint_id: str
+    session_id: str
+    label: NotRequired[str]
+    created_at: str
+    urls: list[str]
+    cookies: int
+
+
+class ListCheckpointsResponse(TypedDict):
+    session_id: str
+    checkpoints: list[Checkpoint]
+    count: int
+
+
+class BranchSessionRequest(TypedDict):
+    session_name: NotRequired[str]
+    browser_port: NotRequired[int]
+
+
+class BranchSessionResponse(TypedDict):
+    session_id: str
+    session_name: str
+    agent_id: str
+    context_id: str
+    created_at: str
+    from_session: str
+    checkpoint_id: str
+    page_ids: list[str]
+
+
+class ShareSessionRequest(TypedDict):
+    mode: NotRequired[str]
+    tenant_id: NotRequired[str]
+    agent_id: NotRequired[str]
+
+
+class ShareSessionResponse(TypedDict):
+    session_id: str
+    mode: NotRequired[str]
+    tenant_id: NotRequired[str]
+    agent_id: str
+    shares: list[SessionShare]
+
+
+class SessionShare(TypedDict):
+    tenant_id: str
+    shared_at: str
+
+
+class CreateObserverRequest(TypedDict):
+    label: NotRequired[str]
+
+
+class ObserverResponse(TypedDict):
+    observer_id: str
+    session_id: str
+    label: NotRequired[str]
+    created_at: str
+
+
+class ListObserversResponse(TypedDict):
+    session_id: str
+    observers: list[ObserverResponse]
+    count: int
+
+
+class TakeoverStatusResponse(TypedDict):
+    session_id: str
+    active: bool
+    takeover: NotRequired[Takeover | None]
+
+
+class Takeover(TypedDict):
+    takeover_id: str
+    session_id: str
+    page_id: str
+    operator: NotRequired[str]
+    started_at: str
+
+
+class SessionLogsResponse(TypedDict):
+    session_id: str
+    lines: list[Line]
+    count: int
+
+
+class Line(TypedDict):
+    time: str
+    level: str
+    message: str
+    page_id: NotRequired[str]
+    attrs: NotRequired[dict[str, str]]
+
+
+class EgressResponse(TypedDict):
+    session_id: str
+    policy: NotRequired[EgressPolicy | None]
+    decrypted: bool
+    usage: Usage
+
+
+class Usage(TypedDict):
+    requests: int
+    blocked: int
+    bytes_sent: int
+    bytes_received: int
+
+
+class BandwidthResponse(TypedDict):
+    session_id: str
+    requests: int
+    bytes: int
+    pages: list[PageBandwidth]
+    egress: NotRequired[Usage | None]
+
+
+class PageBandwidth(TypedDict):
+    page_id: str
+    requests: int
+    bytes: int
+
+
+class WorkFilesResponse(TypedDict):
+    session_id: str
+    used_bytes: int
+    quota_bytes: int
+    over_quota: bool
+    files: list[WorkFile]
+
+
+class WorkFile(TypedDict):
+    path: str
+    size: int
+    modified_at: str
+
+
+class NavigateRequest(TypedDict):
+    url: str
+    bypass_service_worker: NotRequired[bool]
+
+
+class NavigateResponse(TypedDict):
+    session_id: str
+    page_id: str
+    url: str
+    requested_url: str
+    status: NotRequired[int]
+    status_text: NotRequired[str]
+    redirects: list[RedirectHop]
+    failure: NotRequired[str]
+    captcha: NotRequired[CaptchaInfo | None]
+    security: NotRequired[SecurityDetails | None]
+
+
+class RedirectHop(TypedDict):
+    url: str
+    status: int
+    location: str
+
+
+class CaptchaInfo(TypedDict):
+    detected: bool
+    provider: NotRequired[str]
+    site_key: NotRequired[str]
+    url: str
+    signals: NotRequired[list[str]]
+    detected_at: str
+
+
+class SecurityDetails(TypedDict):
+    state: str
+    protocol: NotRequired[str]
+    key_exchange: NotRequired[str]
+    key_exchange_group: NotRequired[str]
+    cipher: NotRequired[str]
+    subject_name: NotRequired[str]
+    issuer: NotRequired[str]
+    sans: NotRequired[list[str]]
+    valid_from: NotRequired[str]
+    valid_to: NotRequired[str]
+    expired: NotRequired[bool]
+    certificate_transparency: NotRequired[str]
+
+
+class LoginRequest(TypedDict):
+    page_id: str
+    credential: str
+    form_index: NotRequired[int | None]
+    success_url_contains: NotRequired[str]
+    success_selector: NotRequired[str]
+    success_engine: NotRequired[str]
+    success_match: NotRequired[str]
+    timeout_ms: NotRequired[int]
+
+
+class LoginResponse(TypedDict):
+    session_id: str
+    page_id: str
+    credential: str
+    success: bool
+    reason: str
+    url: str
+    navigated: bool
+    username_field: NotRequired[str]
+    page_change: NotRequired[PageDelta | None]
+
+
+class PageDelta(TypedDict):
+    changed: bool
+    url_changed: bool
+    title_changed: bool
+    dom_changed: bool
+    text_changed: bool
+    node_count_delta: int
+    screenshot_changed: NotRequired[bool | None]
+
+
+class ExecuteJSRequest(TypedDict):
+    page_id: str
+    script: str
+    args: NotRequired[list[Any]]
+    serialization: NotRequired[str]
+    max_depth: NotRequired[int]
+    verify_change: NotRequired[bool]
+    verify_screenshot: NotRequired[bool]
+
+
+class ExecuteJSResponse(TypedDict):
+    session_id: str
+    page_id: str
+    result: Any
+    page_change: NotRequired[PageDelta | None]
+
+
+class ExtractRequest(TypedDict):
+    page_id: str
+    script: str
+    pipeline: NotRequired[str]
+
+
+class PipelineResult(TypedDict):
+    pipeline: str
+    input: int
+    output: int
+    duplicates: int
+    invalid: int
+    errors: NotRequired[list[str]]
+    records: list[Any]
+
+
+class RunRequest(TypedDict):
+    page_id: NotRequired[str]
+    steps: list[RunStep]
+
+
+RunStep = TypedDict("RunStep", {
+    "action": "str",
+    "url": "NotRequired[str]",
+    "new_page": "NotRequired[bool]",
+    "selector": "NotRequired[str]",
+    "state": "NotRequired[str]",
+    "function": "NotRequired[str]",
+    "x": "NotRequired[float]",
+    "y": "NotRequired[float]",
+    "text": "NotRequired[str]",
+    "clear": "NotRequired[bool]",
+    "submit": "NotRequired[bool]",
+    "script": "NotRequired[str]",
+    "as": "NotRequired[str]",
+    "visible": "NotRequired[bool]",
+    "text_matches": "NotRequired[str]",
+    "cookie": "NotRequired[str]",
+    "status": "NotRequired[int]",
+    "baseline": "NotRequired[str]",
+    "max_diff_percent": "NotRequired[float]",
+    "ignore": "NotRequired[list[Region]]",
+    "engine": "NotRequired[str]",
+    "match": "NotRequired[str]",
+    "fingerprint": "NotRequired[ElementFingerprint | None]",
+    "timeout_ms": "NotRequired[int]",
+    "optional": "NotRequired[bool]",
+})
+
+
+class Region(TypedDict):
+    x: int
+    y: int
+    width: int
+    height: int
+
+
+class ElementFingerprint(TypedDict):
+    tag: str
+    text: NotRequired[str]
+    attributes: NotRequired[dict[str, str]]
+    role: NotRequired[str]
+    name: NotRequired[str]
+    path: NotRequired[str]
+    context: NotRequired[str]
+    selector: NotRequired[str]
+    box: NotRequired[ElementBox | None]
+
+
+class RunResponse(TypedDict):
+    session_id: str
+    success: bool
+    page_id: NotRequired[str]
+    failed_step: NotRequired[int | None]
+    steps: list[StepResult]
+    extracted: dict[str, Any]
+    duration: str
+
+
+class StepResult(TypedDict):
+    index: int
+    action: str
+    status: str
+    page_id: NotRequired[str]
+    value: NotRequired[Any]
+    error: NotRequired[str]
+    duration: NotRequired[str]
+    healed: NotRequired[HealResult | None]
+    element: NotRequired[ElementFingerprint | None]
+
+
+class HealResult(TypedDict):
+    matched: bool
+    healed: bool
+    original: str
+    selector: NotRequired[str]
+    score: NotRequired[float]
+    confirmed_by: NotRequired[str]
+    cached: NotRequired[bool]
+    reason: NotRequired[str]
+    candidates: list[HealCandidate]
+
+
+class HealCandidate(TypedDict):
+    selector: str
+    score: float
+    fingerprint: ElementFingerprint
+
+
+class ScreenshotRequest(TypedDict):
+    page_id: str
+    format: NotRequired[str]
+    annotate: NotRequired[bool]
+
+
+class ScreenshotResponse(TypedDict):
+    session_id: str
+    page_id: str
+    screenshot: str
+    format: str
+    size: int
+    legend: NotRequired[list[ElementMark]]
+    device_pixel_ratio: NotRequired[float]
+
+
+class AnalyzePageRequest(TypedDict):
+    page_id: str
+    instruction_language: NotRequired[str]
+
+
+class AnalyzePageResponse(TypedDict):
+    session_id: str
+    page_id: str
+    analysis: PageStructure | None
+    instruction_language: NotRequired[str]
+    translate_labels: NotRequired[bool]
+
+
+class PageStructure(TypedDict):
+    page_id: str
+    url: str
+    title: str
+    language: NotRequired[str]
+    structure: StructureDetail
+
+
+class StructureDetail(TypedDict):
+    classes: list[str]
+    ids: list[str]
+    headings: dict[str, list[str]]
+    interactive: InteractiveDetail
+    semantic_sections: list[SemanticSection]
+    data_attributes: list[str]
+    text_snippets: list[str]
+
+
+class InteractiveDetail(TypedDict):
+    buttons: list[str]
+    links: list[str]
+    forms: list[str]
+
+
+SemanticSection = TypedDict("SemanticSection", {
+    "type": "str",
+    "class": "NotRequired[str]",
+    "count": "int",
+    "children": "NotRequired[list[str]]",
+})
+
+
+class AccessibilityTreeRequest(TypedDict):
+    page_id: str
+
+
+class AccessibilityTreeResponse(TypedDict):
+    session_id: str
+    page_id: str
+    nodes: list[AXNode | None]
+
+
+class AXNode(TypedDict):
+    role: str
+    name: NotRequired[str]
+    level: NotRequired[int]
+    value: NotRequired[str]
+    focusable: NotRequired[bool]
+    children: list[AXNode | None]
+
+
+class ListPagesResponse(TypedDict):
+    session_id: str
+    pages: list[TabInfo]
+    count: int
+
+
+class TabInfo(TypedDict):
+    page_id: str
+    title: str
+    url: str
+    opener_id: NotRequired[str]
+    adopted: NotRequired[bool]
+    route: NotRequired[RouteInfo | None]
+    security: NotRequired[SecurityDetails | None]
+
+
+class RouteInfo(TypedDict):
+    url: str
+    kind: str
+    changes: int
+    changed_at: str
+
+
+class GetPageContentResponse(TypedDict):
+    session_id: str
+    page_id: str
+    content: str
+    length: int
+
+
+class DuplicatePageResponse(TypedDict):
+    session_id: str
+    page_id: str
+    source_page_id: str
+    url: str
+
+
+class VisionQueryRequest(TypedDict):
+    question: str
+    overlay: NotRequired[bool]
+    timeout_ms: NotRequired[int]
+    stream: NotRequired[bool]
+    fresh: NotRequired[bool]
+    no_cache: NotRequired[bool]
+
+
+class VisionQueryResponse(TypedDict):
+    session_id: str
+    page_id: str
+    answer: str
+    points: list[Point]
+    elements: list[ElementMark]
+    marks: NotRequired[list[ElementMark]]
+    model: str
+    usage: VisionUsage
+    cached: bool
+    duration: str
+
+
+class Point(TypedDict):
+    x: float
+    y: float
+
+
+class VisionUsage(TypedDict):
+    prompt_tokens: int
+    completion_tokens: int
+
+
+class ClickRequest(TypedDict):
+    x: float
+    y: float
+    button: NotRequired[str]
+    click_count: NotRequired[int]
+    modifiers: NotRequired[int]
+    scroll_into_view: NotRequired[bool]
+    verify_change: NotRequired[bool]
+
+
+class ClickResponse(TypedDict):
+    session_id: str
+    page_id: str
+    x: float
+    y: float
+    scroll_x: float
+    scroll_y: float
+    target: NotRequired[ClickTarget | None]
+    element: NotRequired[ElementFingerprint | None]
+    page_change: NotRequired[PageDelta | None]
+
+
+class ClickTarget(TypedDict):
+    tag: str
+    text: NotRequired[str]
+    selector: str
+
+
+class ElementBoxResponse(TypedDict):
+    session_id: str
+    page_id: str
+    selector: str
+    tag: str
+    box: ElementBox | None
+    content: NotRequired[ElementBox | None]
+    padding: NotRequired[ElementBox | None]
+    margin: NotRequired[ElementBox | None]
+    visible: bool
+    in_viewport: bool
+    occluded: bool
+    occluded_by: NotRequired[ClickTarget | None]
+    clickable: bool
+    click_point: NotRequired[Point | None]
+    styles: dict[str, str]
+
+
+class ElementFingerprintResponse(TypedDict):
+    session_id: str
+    page_id: str
+    tag: str
+    text: NotRequired[str]
+    attributes: NotRequired[dict[str, str]]
+    role: NotRequired[str]
+    name: NotRequired[str]
+    path: NotRequired[str]
+    context: NotRequired[str]
+    selector: NotRequired[str]
+    box: NotRequired[ElementBox | None]
+
+
+class HealSelectorRequest(TypedDict):
+    selector: str
+    fingerprint: ElementFingerprint | None
+    min_score: NotRequired[float]
+    confirm: NotRequired[bool]
+    no_cache: NotRequired[bool]
+    engine: NotRequired[str]
+    match: NotRequired[str]
+
+
+class HealSelectorResponse(TypedDict):
+    session_id: str
+    page_id: str
+    matched: bool
+    healed: bool
+    original: str
+    selector: NotRequired[str]
+    score: NotRequired[float]
+    confirmed_by: NotRequired[str]
+    cached: NotRequired[bool]
+    reason: NotRequired[str]
+    candidates: list[HealCandidate]
+
+
+class CaptureCanvasRequest(TypedDict):
+    selector: NotRequired[str]
+    method: NotRequired[str]
+    format: NotRequired[str]
+    quality: NotRequired[int]
+    engine: NotRequired[str]
+    match: NotRequired[str]
+
+
+class CaptureCanvasResponse(TypedDict):
+    session_id: str
+    page_id: str
+    canvases: list[CanvasCapture]
+    count: int
+    total: int
+
+
+class CanvasCapture(TypedDict):
+    index: int
+    selector: str
+    width: int
+    height: int
+    box: NotRequired[ElementBox | None]
+    label: NotRequired[str]
+    method: NotRequired[str]
+    mime_type: NotRequired[str]
+    data: NotRequired[str]
+    size: NotRequired[int]
+    tainted: NotRequired[bool]
+    blank: NotRequired[bool]
+    error: NotRequired[str]
+    chart: NotRequired[ChartData | None]
+
+
+class ChartData(TypedDict):
+    library: str
+    type: NotRequired[str]
+    title: NotRequired[str]
+    labels: NotRequired[list[Any]]
+    series: list[ChartSeries]
+    truncated: NotRequired[bool]
+
+
+class ChartSeries(TypedDict):
+    name: NotRequired[str]
+    type: NotRequired[str]
+    data: list[Any]
+
+
+class PageMediaResponse(TypedDict):
+    session_id: str
+    page_id: str
+    media: NotRequired[str]
+    color_scheme: NotRequired[str]
+    reduced_motion: NotRequired[str]
+
+
+class PageMediaRequest(TypedDict):
+    media: NotRequired[str]
+    color_scheme: NotRequired[str]
+    reduced_motion: NotRequired[str]
+
+
+class WebSocketTrafficResponse(TypedDict):
+    session_id: str
+    page_id: str
+    sockets: list[WebSocketInfo]
+    frames: list[WebSocketFrame]
+    total: int
+    dropped: int
+
+
+class WebSocketInfo(TypedDict):
+    id: str
+    url: str
+    frames: int
+    opened_at: str
+    closed_at: NotRequired[str]
+    error: NotRequired[str]
+
+
+class WebSocketFrame(TypedDict):
+    socket: str
+    url: str
+    direction: str
+    opcode: int
+    payload: str
+    size: int
+    truncated: NotRequired[bool]
+    masked: NotRequired[bool]
+    time: str
+
+
+class StreamTrafficResponse(TypedDict):
+    session_id: str
+    page_id: str
+    streams: list[StreamInfo]
+    messages: list[StreamMessage]
+    total: int
+    dropped: int
+
+
+class StreamInfo(TypedDict):
+    id: str
+    url: str
+    type: str
+    mime_type: str
+    messages: int
+    opened_at: str
+    closed_at: NotRequired[str]
+    error: NotRequired[str]
+
+
+class StreamMessage(TypedDict):
+    stream: str
+    url: str
+    kind: str
+    event: NotRequired[str]
+    event_id: NotRequired[str]
+    data: str
+    size: int
+    binary: NotRequired[bool]
+    truncated: NotRequired[bool]
+    masked: NotRequired[bool]
+    time: str
+
+
+class PageSecurityResponse(TypedDict):
+    session_id: str
+    page_id: str
+    url: str
+    state: str
+    issues: list[str]
+    mixed_content: list[str]
+    certificate_error: NotRequired[str]
+    navigation: NotRequired[SecurityDetails | None]
+
+
+class CreateHandleRequest(TypedDict):
+    selector: str
+    engine: NotRequired[str]
+    match: NotRequired[str]
+
+
+class HandleResponse(TypedDict):
+    session_id: str
+    handle_id: str
+    page_id: str
+    selector: str
+    engine: NotRequired[str]
+    match: NotRequired[str]
+    tag: str
+    created_at: str
+
+
+class ListHandlesResponse(TypedDict):
+    session_id: str
+    page_id: str
+    handles: list[ElementHandle]
+    count: int
+
+
+class ElementHandle(TypedDict):
+    handle_id: str
+    page_id: str
+    selector: str
+    engine: NotRequired[str]
+    match: NotRequired[str]
+    tag: str
+    created_at: str
+
+
+class HandleClickRequest(TypedDict):
+    button: NotRequired[str]
+    click_count: NotRequired[int]
+    modifiers: NotRequired[int]
+    verify_change: NotRequired[bool]
+
+
+class HandleTextResponse(TypedDict):
+    session_id: str
+    page_id: str
+    handle_id: str
+    text: str
+
+
+class HandleAttributeResponse(TypedDict):
+    session_id: str
+    page_id: str
+    handle_id: str
+    name: str
+    value: str | None
+
+
+class WatchRequest(TypedDict):
+    selector: str
+    debounce_ms: NotRequired[int]
+    attributes: NotRequired[bool]
+    engine: NotRequired[str]
+    match: NotRequired[str]
+
+
+class WatchResponse(TypedDict):
+    session_id: str
+    watch_id: str
+    page_id: str
+    selector: str
+    engine: NotRequired[str]
+    match: NotRequired[str]
+    debounce_ms: int
+    attributes: bool
+    created_at: str
+
+
+class ListWatchesResponse(TypedDict):
+    session_id: str
+    page_id: str
+    watches: list[DOMWatch]
+    count: int
+
+
+class DOMWatch(TypedDict):
+    watch_id: str
+    page_id: str
+    selector: str
+    engine: NotRequired[str]
+    match: NotRequired[str]
+    debounce_ms: int
+    attributes: bool
+    created_at: str
+
+
+class WaitRequest(TypedDict):
+    selector: NotRequired[str]
+    state: NotRequired[str]
+    url: NotRequired[str]
+    function: NotRequired[str]
+    timeout_ms: NotRequired[int]
+    polling_ms: NotRequired[int]
+    engine: NotRequired[str]
+    match: NotRequired[str]
+
+
+class WaitResponse(TypedDict):
+    session_id: str
+    page_id: str
+    satisfied: bool
+    condition: str
+    url: str
+    value: NotRequired[Any]
+    last_error: NotRequired[str]
+    polls: int
+    duration: str
+
+
+class AssertRequest(TypedDict):
+    selector: NotRequired[str]
+    visible: NotRequired[bool]
+    text: NotRequired[str]
+    text_matches: NotRequired[str]
+    url: NotRequired[str]
+    cookie: NotRequired[str]
+    status: NotRequired[int]
+    function: NotRequired[str]
+    engine: NotRequired[str]
+    match: NotRequired[str]
+
+
+class AssertResponse(TypedDict):
+    session_id: str
+    page_id: str
+    passed: bool
+    url: NotRequired[str]
+    checks: list[AssertionCheck]
+
+
+class AssertionCheck(TypedDict):
+    check: str
+    passed: bool
+    expected: NotRequired[Any]
+    actual: NotRequired[Any]
+    error: NotRequired[str]
+
+
+class VisualDiffRequest(TypedDict):
+    baseline: str
+    threshold: NotRequired[float]
+    max_diff_percent: NotRequired[float]
+    ignore: NotRequired[list[Region]]
+    update: NotRequired[bool]
+
+
+class VisualDiffResponse(TypedDict):
+    session_id: str
+    page_id: str
+    baseline: str
+    created: NotRequired[bool]
+    updated: NotRequired[bool]
+    passed: bool
+    width: int
+    height: int
+    size_changed: bool
+    changed_pixels: int
+    compared_pixels: int
+    change_percent: float
+    ssim: float
+    diff_image: NotRequired[str]
+
+
+class ContentHashRequest(TypedDict):
+    ignore: NotRequired[list[str]]
+    mask_numbers: NotRequired[bool]
+    previous: NotRequired[ContentHash | None]
+
+
+class ContentHash(TypedDict):
+    url: str
+    text_hash: str
+    structure_hash: str
+    text_simhash: str
+    structure_simhash: str
+    words: int
+    elements: int
+
+
+class ContentHashResponse(TypedDict):
+    session_id: str
+    page_id: str
+    url: str
+    text_hash: str
+    structure_hash: str
+    text_simhash: str
+    structure_simhash: str
+    words: int
+    elements: int
+    change: NotRequired[ContentChange | None]
+
+
+class ContentChange(TypedDict):
+    text_changed: bool
+    structure_changed: bool
+    text_score: float
+    structure_score: float
+    score: float
+
+
+class ListFormsResponse(TypedDict):
+    session_id: str
+    page_id: str
+    forms: list[FormInfo]
+    count: int
+
+
+class FormInfo(TypedDict):
+    index: int
+    id: NotRequired[str]
+    name: NotRequired[str]
+    action: NotRequired[str]
+    method: str
+    fields: list[FormField]
+
+
+class FormField(TypedDict):
+    name: str
+    type: str
+    label: NotRequired[str]
+    required: bool
+    options: NotRequired[list[str]]
+
+
+class FillFormRequest(TypedDict):
+    values: dict[str, Any]
+    submit: NotRequired[bool]
+    timeout_ms: NotRequired[int]
+
+
+class FillFormResponse(TypedDict):
+    session_id: str
+    page_id: str
+    form_index: int
+    filled: list[str]
+    missing: list[str]
+    submitted: bool
+    navigated: bool
+    url: NotRequired[str]
+    page_change: NotRequired[PageDelta | None]
+
+
+class CaptchaResponse(TypedDict):
+    session_id: str
+    page_id: str
+    captcha: CaptchaInfo | None
+
+
+class SolveCaptchaRequest(TypedDict):
+    solver: NotRequired[str]
+    manual: NotRequired[bool]
+    timeout_ms: NotRequired[int]
+
+
+class SolveCaptchaResponse(TypedDict):
+    session_id: str
+    page_id: str
+    solved: bool
+    method: str
+    provider: str
+    injection: NotRequired[CaptchaInjection | None]
+    url: NotRequired[str]
+    duration: str
+
+
+class CaptchaInjection(TypedDict):
+    fields: int
+    callback: bool
+
+
+class ListResourcesResponse(TypedDict):
+    session_id: str
+    page_id: str
+    resources: list[ResourceInfo]
+    count: int
+
+
+class ResourceInfo(TypedDict):
+    url: str
+    type: str
+    mime_type: str
+    content_size: NotRequired[float]
+    frame_id: str
+    mixed_content: NotRequired[bool]
+
+
+class DownloadResourceRequest(TypedDict):
+    url: str
+    source: NotRequired[str]
+    max_bytes: NotRequired[int]
+    raw: NotRequired[bool]
+
+
+class DownloadResourceResponse(TypedDict):
+    session_id: str
+    page_id: str
+    url: str
+    mime_type: str
+    source: str
+    status: NotRequired[int]
+    data: str
+    size: int
+
+
+class PrintPDFRequest(TypedDict):
+    landscape: NotRequired[bool]
+    print_background: NotRequired[bool]
+    scale: NotRequired[float]
+    paper_width: NotRequired[float]
+    paper_height: NotRequired[float]
+    page_ranges: NotRequired[str]
+
+
+class SearchRequest(TypedDict):
+    query: str
+    count: NotRequired[int]
+    language: NotRequired[str]
+    region: NotRequired[str]
+
+
+class SearchResponse(TypedDict):
+    query: str
+    backend: str
+    results: list[Result]
+    count: int
+
+
+class Result(TypedDict):
+    position: int
+    title: str
+    url: str
+    domain: str
+    snippet: NotRequired[str]
+
+
+class ResearchRequest(TypedDict):
+    question: str
+    sources: NotRequired[int]
+    language: NotRequired[str]
+    region: NotRequired[str]
+    timeout_ms: NotRequired[int]
+    stream: NotRequired[bool]
+    no_cache: NotRequired[bool]
+
+
+class ResearchResponse(TypedDict):
+    question: str
+    answer: str
+    claims: list[Claim]
+    sources: list[ResearchSource]
+    model: NotRequired[str]
+    usage: VisionUsage
+    cached: bool
+    duration: str
+
+
+class Claim(TypedDict):
+    text: str
+    sources: list[str]
+
+
+class ResearchSource(TypedDict):
+    url: str
+    title: str
+    position: int
+    passages: list[Passage]
+    error: NotRequired[str]
+    duration: NotRequired[str]
+
+
+class Passage(TypedDict):
+    text: str
+    score: float
+
+
+class SessionEvent(TypedDict):
+    id: int
+    session_id: str
+    page_id: NotRequired[str]
+    type: str
+    time: str
+    data: NotRequired[Any]
+
+
+class GeneratedClient:
+    """One method per endpoint. Subclasses implement the transport."""
+
+    def _request(self, method: str, path: str, body: Any = None, query: dict[str, Any] | None = None) -> Any:
+        raise NotImplementedError
+
+    def _request_bytes(self, method: str, path: str, body: Any = None, query: dict[str, Any] | None = None) -> bytes:
+        raise NotImplementedError
+
+    def _stream(self, path: str, query: dict[str, Any]) -> Iterator[Any]:
+        raise NotImplementedError
+
+    def create_session(self, body: CreateSessionRequest) -> CreateSessionResponse:
+        """Create a session for an agent"""
+        return self._request("POST", "/sessions", body)
+
+    def list_sessions(self) -> ListSessionsResponse:
+        """List active sessions"""
+        return self._request("GET", "/sessions")
+
+    def destroy_sessions_by_filter(self, label: str | int | None = None, older_than: str | int | None = None, agent_id: str | int | None = None) -> DestroySessionsResponse:
+        """Destroy the sessions matching a label, age or agent filter"""
+        return self._request("DELETE", "/sessions", query={"label": label, "older_than": older_than, "agent_id": agent_id})
+
+    def destroy_session_batch(self, body: DestroySessionBatchRequest) -> DestroySessionsResponse:
+        """Destroy a list of sessions"""
+        return self._request("POST", "/sessions/destroy-batch", body)
+
+    def resume_session(self, body: ResumeSessionRequest) -> ResumeSessionResponse:
+        """Resume a session by agent and name, creating it if needed"""
+        return self._request("POST", "/sessions/resume", body)
+
+    def get_session(self, session_id: str) -> GetSessionResponse:
+        """Get session details"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}")
+
+    def destroy_session(self, session_id: str) -> None:
+        """Destroy a session and its browser context"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}")
+
+    def resume_session_by_id(self, session_id: str) -> ResumeSessionResponse:
+        """Resume a session by ID, restoring it if it was closed"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/resume")
+
+    def close_session(self, session_id: str) -> dict[str, Any]:
+        """Close a session's pages, keeping it resumable by name"""
+        return self._request("PUT", f"/sessions/{quote(session_id, safe='')}/close")
+
+    def rename_session(self, session_id: str, body: RenameSessionRequest) -> dict[str, Any]:
+        """Rename a session"""
+        return self._request("PUT", f"/sessions/{quote(session_id, safe='')}/rename", body)
+
+    def hibernate_session(self, session_id: str) -> dict[str, Any]:
+        """Free a session's browser context until its next call"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/hibernate")
+
+    def wake_session(self, session_id: str) -> dict[str, Any]:
+        """Wake a hibernated session ahead of its next call"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/wake")
+
+    def get_memory(self, session_id: str) -> MemoryResponse:
+        """Show the earlier questions and page content follow-up vision queries draw on"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/memory")
+
+    def clear_memory(self, session_id: str) -> None:
+        """Forget a session's earlier questions and page content"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/memory")
+
+    def get_storage(self, session_id: str, page_id: str | int | None = None, origin: str | int | None = None) -> StorageResponse:
+        """Read an origin's localStorage, sessionStorage and IndexedDB summary through a page"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/storage", query={"page_id": page_id, "origin": origin})
+
+    def write_storage(self, session_id: str, body: WriteStorageRequest) -> StorageResponse:
+        """Set, remove or clear an origin's localStorage or sessionStorage items"""
+        return self._request("PUT", f"/sessions/{quote(session_id, safe='')}/storage", body)
+
+    def list_service_workers(self, session_id: str, page_id: str | int | None = None) -> ServiceWorkersResponse:
+        """List the service worker registrations of a session's browser context"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/service-workers", query={"page_id": page_id})
+
+    def unregister_service_workers(self, session_id: str, page_id: str | int | None = None, scope_url: str | int | None = None) -> UnregisterServiceWorkersResponse:
+        """Unregister the service worker for a scope, or every one"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/service-workers", query={"page_id": page_id, "scope_url": scope_url})
+
+    def get_cache_storage(self, session_id: str, page_id: str | int | None = None, origin: str | int | None = None) -> CacheStorageResponse:
+        """List an origin's CacheStorage caches and how many responses each holds"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/cache-storage", query={"page_id": page_id, "origin": origin})
+
+    def clear_cache_storage(self, session_id: str, page_id: str | int | None = None, origin: str | int | None = None, cache: str | int | None = None) -> CacheStorageResponse:
+        """Delete one of an origin's CacheStorage caches, or all of them"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/cache-storage", query={"page_id": page_id, "origin": origin, "cache": cache})
+
+    def create_checkpoint(self, session_id: str, body: CreateCheckpointRequest) -> Checkpoint:
+        """Save a session's cookies, page URLs and web storage as a checkpoint"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/checkpoints", body)
+
+    def list_checkpoints(self, session_id: str) -> ListCheckpointsResponse:
+        """List a session's checkpoints"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/checkpoints")
+
+    def delete_checkpoint(self, session_id: str, checkpoint_id: str) -> None:
+        """Delete a checkpoint"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/checkpoints/{quote(checkpoint_id, safe='')}")
+
+    def branch_session(self, session_id: str, checkpoint_id: str, body: BranchSessionRequest) -> BranchSessionResponse:
+        """Create a new session from a checkpoint"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/checkpoints/{quote(checkpoint_id, safe='')}/branch", body)
+
+    def share_session(self, session_id: str, body: ShareSessionRequest) -> ShareSessionResponse:
+        """Share a session read-only with another tenant, or transfer it to a new owner"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/share", body)
+
+    def list_shares(self, session_id: str) -> ShareSessionResponse:
+        """Show a session's owner and read-only shares"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/share")
+
+    def revoke_share(self, session_id: str, tenant_id: str) -> None:
+        """Revoke a tenant's read-only access to a session"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/share/{quote(tenant_id, safe='')}")
+
+    def create_observer(self, session_id: str, body: CreateObserverRequest) -> ObserverResponse:
+        """Create a read-only link for watching a session"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/observers", body)
+
+    def list_observers(self, session_id: str) -> ListObserversResponse:
+        """List a session's observer links"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/observers")
+
+    def revoke_observer(self, session_id: str, observer_id: str) -> None:
+        """Revoke an observer link"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/observers/{quote(observer_id, safe='')}")
+
+    def get_takeover(self, session_id: str) -> TakeoverStatusResponse:
+        """Show whether a human has taken over a session"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/takeover")
+
+    def end_takeover(self, session_id: str) -> None:
+        """Hand a taken-over session back to the agent"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/takeover")
+
+    def get_session_logs(self, session_id: str, level: str | int | None = None, page_id: str | int | None = None, limit: str | int | None = None) -> SessionLogsResponse:
+        """Show the recent server log lines of a session"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/logs", query={"level": level, "page_id": page_id, "limit": limit})
+
+    def get_egress_usage(self, session_id: str) -> EgressResponse:
+        """Show a session's egress policy and the requests and bytes it sent through the egress proxy"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/egress")
+
+    def get_session_bandwidth(self, session_id: str) -> BandwidthResponse:
+        """Show the requests and bytes a session's pages downloaded, in total and per open page"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/bandwidth")
+
+    def list_work_files(self, session_id: str) -> WorkFilesResponse:
+        """List the downloads and other files in a session's work directory"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/files")
+
+    def navigate(self, session_id: str, body: NavigateRequest) -> NavigateResponse:
+        """Open a new page at a URL"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/navigate", body)
+
+    def login(self, session_id: str, body: LoginRequest) -> LoginResponse:
+        """Fill and submit a page's login form with a stored credential"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/login", body)
+
+    def execute_js(self, session_id: str, body: ExecuteJSRequest) -> ExecuteJSResponse:
+        """Run JavaScript on a page"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/execute", body)
+
+    def extract(self, session_id: str, body: ExtractRequest) -> PipelineResult:
+        """Run a script on a page and pass its result through a pipeline"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/extract", body)
+
+    def run(self, session_id: str, body: RunRequest) -> RunResponse:
+        """Run a script of navigate, wait, click, type, extract, assert and compare steps"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/run", body)
+
+    def capture_screenshot(self, session_id: str, body: ScreenshotRequest) -> ScreenshotResponse:
+        """Capture a screenshot of a page"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/screenshot", body)
+
+    def analyze_page(self, session_id: str, body: AnalyzePageRequest) -> AnalyzePageResponse:
+        """Summarize a page's structure"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/analyze", body)
+
+    def get_accessibility_tree(self, session_id: str, body: AccessibilityTreeRequest) -> AccessibilityTreeResponse:
+        """Get a page's accessibility tree"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/accessibility-tree", body)
+
+    def list_pages(self, session_id: str) -> ListPagesResponse:
+        """List the live pages of a session"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages")
+
+    def get_page_content(self, session_id: str, page_id: str) -> GetPageContentResponse:
+        """Get a page's HTML"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/content")
+
+    def close_page(self, session_id: str, page_id: str) -> None:
+        """Close a page"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}")
+
+    def activate_page(self, session_id: str, page_id: str) -> None:
+        """Bring a page to the front"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/activate")
+
+    def duplicate_page(self, session_id: str, page_id: str) -> DuplicatePageResponse:
+        """Open a copy of a page"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/duplicate")
+
+    def vision_query(self, session_id: str, page_id: str, body: VisionQueryRequest) -> VisionQueryResponse:
+        """Ask a vision model about a page's screenshot"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/vision-query", body)
+
+    def click(self, session_id: str, page_id: str, body: ClickRequest) -> ClickResponse:
+        """Click at a point on a page"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/click", body)
+
+    def get_element_box(self, session_id: str, page_id: str, selector: str | int | None = None, engine: str | int | None = None, match: str | int | None = None, object_id: str | int | None = None) -> ElementBoxResponse:
+        """Get an element's box, visibility and occlusion"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/element/box", query={"selector": selector, "engine": engine, "match": match, "object_id": object_id})
+
+    def get_element_fingerprint(self, session_id: str, page_id: str, selector: str | int | None = None, engine: str | int | None = None, match: str | int | None = None) -> ElementFingerprintResponse:
+        """Fingerprint an element so its selector can be healed later"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/element/fingerprint", query={"selector": selector, "engine": engine, "match": match})
+
+    def heal_selector(self, session_id: str, page_id: str, body: HealSelectorRequest) -> HealSelectorResponse:
+        """Find the element a selector that stopped matching meant, by its fingerprint"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/element/heal", body)
+
+    def capture_canvas(self, session_id: str, page_id: str, body: CaptureCanvasRequest) -> CaptureCanvasResponse:
+        """Capture a page's canvases as images, with the data of charts drawn on them"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/canvas", body)
+
+    def get_page_media(self, session_id: str, page_id: str) -> PageMediaResponse:
+        """Get the CSS media a page is rendered for"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/media")
+
+    def set_page_media(self, session_id: str, page_id: str, body: PageMediaRequest) -> PageMediaResponse:
+        """Render a page for print media, a color scheme or reduced motion"""
+        return self._request("PUT", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/media", body)
+
+    def get_web_socket_traffic(self, session_id: str, page_id: str, direction: str | int | None = None, url: str | int | None = None, min_size: str | int | None = None, max_size: str | int | None = None, since: str | int | None = None, until: str | int | None = None, limit: str | int | None = None) -> WebSocketTrafficResponse:
+        """List a page's WebSockets and the frames they sent and received"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/network/websockets", query={"direction": direction, "url": url, "min_size": min_size, "max_size": max_size, "since": since, "until": until, "limit": limit})
+
+    def get_stream_traffic(self, session_id: str, page_id: str, kind: str | int | None = None, event: str | int | None = None, url: str | int | None = None, min_size: str | int | None = None, max_size: str | int | None = None, since: str | int | None = None, until: str | int | None = None, limit: str | int | None = None) -> StreamTrafficResponse:
+        """List a page's event streams and streamed responses and the messages they received"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/network/streams", query={"kind": kind, "event": event, "url": url, "min_size": min_size, "max_size": max_size, "since": since, "until": until, "limit": limit})
+
+    def get_page_security(self, session_id: str, page_id: str) -> PageSecurityResponse:
+        """Get a page's security state, mixed content and certificate"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/security")
+
+    def create_handle(self, session_id: str, page_id: str, body: CreateHandleRequest) -> HandleResponse:
+        """Find an element once and keep a handle to it until the page navigates"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/handles", body)
+
+    def list_handles(self, session_id: str, page_id: str) -> ListHandlesResponse:
+        """List the element handles of a page"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/handles")
+
+    def release_handle(self, session_id: str, page_id: str, handle_id: str) -> None:
+        """Release an element handle"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/handles/{quote(handle_id, safe='')}")
+
+    def click_handle(self, session_id: str, page_id: str, handle_id: str, body: HandleClickRequest) -> ClickResponse:
+        """Scroll a handle's element into view and click it"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/handles/{quote(handle_id, safe='')}/click", body)
+
+    def get_handle_text(self, session_id: str, page_id: str, handle_id: str) -> HandleTextResponse:
+        """Get the rendered text of a handle's element"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/handles/{quote(handle_id, safe='')}/text")
+
+    def get_handle_attribute(self, session_id: str, page_id: str, handle_id: str, name: str | int | None = None) -> HandleAttributeResponse:
+        """Read an attribute of a handle's element"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/handles/{quote(handle_id, safe='')}/attribute", query={"name": name})
+
+    def get_handle_box(self, session_id: str, page_id: str, handle_id: str) -> ElementBoxResponse:
+        """Get the box, visibility and occlusion of a handle's element"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/handles/{quote(handle_id, safe='')}/box")
+
+    def watch_dom(self, session_id: str, page_id: str, body: WatchRequest) -> WatchResponse:
+        """Report changes to matching content as dom_changed events"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/watch", body)
+
+    def list_watches(self, session_id: str, page_id: str) -> ListWatchesResponse:
+        """List the DOM watches of a page"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/watch")
+
+    def unwatch(self, session_id: str, page_id: str, watch_id: str) -> None:
+        """Remove a DOM watch"""
+        return self._request("DELETE", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/watch/{quote(watch_id, safe='')}")
+
+    def wait(self, session_id: str, page_id: str, body: WaitRequest) -> WaitResponse:
+        """Wait for a selector, URL or predicate"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/wait", body)
+
+    def assert_(self, session_id: str, page_id: str, body: AssertRequest) -> AssertResponse:
+        """Check elements, text, URL, cookies and navigation status of a page"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/assert", body)
+
+    def visual_diff(self, session_id: str, page_id: str, body: VisualDiffRequest) -> VisualDiffResponse:
+        """Compare a screenshot of a page with a stored baseline"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/visual-diff", body)
+
+    def content_hash(self, session_id: str, page_id: str, body: ContentHashRequest) -> ContentHashResponse:
+        """Hash a page's text and structure, scoring the change since earlier hashes"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/content-hash", body)
+
+    def list_forms(self, session_id: str, page_id: str) -> ListFormsResponse:
+        """List a page's forms and their fields"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/forms")
+
+    def fill_form(self, session_id: str, page_id: str, form_index: str, body: FillFormRequest) -> FillFormResponse:
+        """Fill a form's fields by name, optionally submitting it"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/forms/{quote(form_index, safe='')}/fill", body)
+
+    def detect_captcha(self, session_id: str, page_id: str) -> CaptchaResponse:
+        """Detect a CAPTCHA on a page"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/captcha")
+
+    def solve_captcha(self, session_id: str, page_id: str, body: SolveCaptchaRequest) -> SolveCaptchaResponse:
+        """Solve a page's CAPTCHA through the configured solver"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/captcha/solve", body)
+
+    def list_resources(self, session_id: str, page_id: str) -> ListResourcesResponse:
+        """List the resources a page loaded"""
+        return self._request("GET", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/resources")
+
+    def download_resource(self, session_id: str, page_id: str, body: DownloadResourceRequest) -> DownloadResourceResponse:
+        """Download a resource a page loaded, base64 encoded"""
+        return self._request("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/resources/download", body)
+
+    def print_pdf(self, session_id: str, page_id: str, body: PrintPDFRequest) -> bytes:
+        """Print a page to PDF"""
+        return self._request_bytes("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/pdf", body)
+
+    def search(self, body: SearchRequest) -> SearchResponse:
+        """Search the web for pages to navigate to"""
+        return self._request("POST", "/search", body)
+
+    def research(self, body: ResearchRequest) -> ResearchResponse:
+        """Answer a question from the top search results, citing a source for each claim"""
+        return self._request("POST", "/research", body)
+
+    def stream_events(self, session_id: str, after: str | int | None = None) -> Iterator[SessionEvent]:
+        """Stream session events, replaying those after an event ID"""
+        return self._stream(f"/sessions/{quote(session_id, safe='')}/events/ws", {"after": after})
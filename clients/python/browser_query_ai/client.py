@@ -0,0 +1,351 @@
+"""Session and page abstractions over the generated endpoint methods."""
+
+from __future__ import annotations
+
+import base64
+import json
+import urllib.error
+import urllib.parse
+import urllib.request
+from typing import Any, Iterator
+
+from ._generated import (
+    ClickResponse,
+    ElementBoxResponse,
+    ElementMark,
+    GeneratedClient,
+    PipelineResult,
+    SessionEvent,
+    SessionOptions,
+    VisionQueryResponse,
+    WaitResponse,
+    WatchResponse,
+)
+
+
+class APIError(Exception):
+    """An error response from the service.
+
+    VALIDATION_FAILED errors list each broken rule in violations, as dicts with
+    field, rule, param and message.
+    """
+
+    def __init__(self, status: int, code: str, message: str, violations: list | None = None):
+        super().__init__(f"{status} {code}: {message}")
+        self.status = status
+        self.code = code
+        self.message = message
+        self.violations = violations or []
+
+
+class Client(GeneratedClient):
+    """HTTP client for a Browser Query AI server.
+
+    The endpoint methods (navigate, execute_js, ...) are generated; session() and
+    attach() return Session objects that carry the IDs for you.
+    """
+
+    def __init__(self, base_url: str = "http://localhost:8080", timeout: float = 60.0,
+                 headers: dict[str, str] | None = None, api_key: str | None = None):
+        self.base_url = base_url.rstrip("/")
+        self.timeout = timeout
+        self.headers = dict(headers or {})
+        if api_key:
+            # Authenticates as a tenant on multi-tenant servers
+            self.headers["X-API-Key"] = api_key
+
+    def session(self, agent_id: str, name: str | None = None, template: str | None = None,
+                options: SessionOptions | None = None) -> Session:
+        """Create a session."""
+        body: dict[str, Any] = {"agent_id": agent_id}
+        if name:
+            body["session_name"] = name
+        if template:
+            body["template"] = template
+        if options:
+            body["options"] = options
+        created = self.create_session(body)  # type: ignore[arg-type]
+        return Session(self, created["session_id"])
+
+    def resume(self, agent_id: str, name: str) -> Session:
+        """Resume the agent's session with this name, creating it if it doesn't exist."""
+        resumed = self.resume_session({"agent_id": agent_id, "session_name": name})
+        return Session(self, resumed["session_id"])
+
+    def attach(self, session_id: str) -> Session:
+        """Wrap an existing session ID without calling the server."""
+        return Session(self, session_id)
+
+    def _request(self, method: str, path: str, body: Any = None, query: dict[str, Any] | None = None) -> Any:
+        payload = self._send(method, path, body, query, "application/json")
+        # 204 No Content
+        if not payload:
+            return None
+        return json.loads(payload)
+
+    def _request_bytes(self, method: str, path: str, body: Any = None, query: dict[str, Any] | None = None) -> bytes:
+        return self._send(method, path, body, query, "*/*")
+
+    def _send(self, method: str, path: str, body: Any, query: dict[str, Any] | None, accept: str) -> bytes:
+        data = None
+        headers = {"Accept": accept, **self.headers}
+        if body is not None:
+            data = json.dumps(body).encode()
+            headers["Content-Type"] = "application/json"
+
+        url = self.base_url + path
+        params = {key: value for key, value in (query or {}).items() if value is not None}
+        if params:
+            url += "?" + urllib.parse.urlencode(params)
+
+        request = urllib.request.Request(url, data=data, method=method, headers=headers)
+        try:
+            with urllib.request.urlopen(request, timeout=self.timeout) as response:
+                return response.read()
+        except urllib.error.HTTPError as err:
+            raise _api_error(err) from None
+
+    def _stream(self, path: str, query: dict[str, Any]) -> Iterator[Any]:
+        try:
+            import websocket  # websocket-client, only needed for streaming
+        except ImportError as err:
+            raise ImportError("event streaming requires websocket-client: pip install browser-query-ai[stream]") from err
+
+        params = {key: value for key, value in query.items() if value is not None}
+        url = self.base_url.replace("http", "ws", 1) + path
+        if params:
+            url += "?" + urllib.parse.urlencode(params)
+
+        try:
+            conn = websocket.create_connection(url, header=[f"{k}: {v}" for k, v in self.headers.items()])
+        except websocket.WebSocketBadStatusException as err:
+            raise APIError(err.status_code, "STREAM_FAILED", str(err)) from None
+
+        try:
+            while True:
+                try:
+                    message = conn.recv()
+                except websocket.WebSocketConnectionClosedException:
+                    return
+                if not message:
+                    # Close frame: the session ended
+                    return
+                yield json.loads(message)
+        finally:
+            conn.close()
+
+
+class Session:
+    """A browser session: an isolated browser context with its own pages."""
+
+    def __init__(self, client: Client, session_id: str):
+        self.client = client
+        self.id = session_id
+
+    def __repr__(self) -> str:
+        return f"Session({self.id!r})"
+
+    def __enter__(self) -> Session:
+        return self
+
+    def __exit__(self, *exc: Any) -> None:
+        self.destroy()
+
+    def info(self) -> dict[str, Any]:
+        """Current details: name, pages, status and activity times."""
+        return dict(self.client.get_session(self.id))
+
+    def navigate(self, url: str) -> Page:
+        """Open a new page at url."""
+        navigated = self.client.navigate(self.id, {"url": url})
+        return Page(self, navigated["page_id"], navigated["url"])
+
+    def page(self, page_id: str) -> Page:
+        """Wrap an existing page ID."""
+        return Page(self, page_id)
+
+    def pages(self) -> list[Page]:
+        """The live pages, including ones the site opened itself."""
+        listed = self.client.list_pages(self.id)
+        return [Page(self, tab["page_id"], tab["url"]) for tab in listed["pages"]]
+
+    def events(self, after: int | None = None) -> Iterator[SessionEvent]:
+        """Yield session events as they happen until the session ends.
+
+        Pass the ID of the last event you handled as after to replay what you missed
+        after a disconnect.
+        """
+        return self.client.stream_events(self.id, after)
+
+    def rename(self, name: str) -> None:
+        self.client.rename_session(self.id, {"session_name": name})
+
+    def close(self) -> None:
+        """Dispose of the pages but keep the session resumable by name."""
+        self.client.close_session(self.id)
+
+    def destroy(self) -> None:
+        """Destroy the session and its browser context."""
+        self.client.destroy_session(self.id)
+
+
+class Page:
+    """A page (tab) in a session."""
+
+    def __init__(self, session: Session, page_id: str, url: str | None = None):
+        self.session = session
+        self.id = page_id
+        self.url = url
+
+    def __repr__(self) -> str:
+        return f"Page({self.id!r}, url={self.url!r})"
+
+    @property
+    def _client(self) -> Client:
+        return self.session.client
+
+    def execute(self, script: str, verify_change: bool = False) -> Any:
+        """Run script on the page and return its result."""
+        return self.execute_verbose(script, verify_change)["result"]
+
+    def execute_verbose(self, script: str, verify_change: bool = False,
+                        verify_screenshot: bool = False) -> dict[str, Any]:
+        """Run script and return the full response, including page_change when verifying."""
+        body = {"page_id": self.id, "script": script}
+        if verify_change:
+            body["verify_change"] = True
+        if verify_screenshot:
+            body["verify_screenshot"] = True
+        return dict(self._client.execute_js(self.session.id, body))  # type: ignore[arg-type]
+
+    def extract(self, script: str, pipeline: str | None = None) -> PipelineResult:
+        """Run script and pass its result through a pipeline (default: the session's)."""
+        body = {"page_id": self.id, "script": script}
+        if pipeline:
+            body["pipeline"] = pipeline
+        return self._client.extract(self.session.id, body)  # type: ignore[arg-type]
+
+    def ask(self, question: str, overlay: bool = False) -> VisionQueryResponse:
+        """Ask the vision model about what the page looks like.
+
+        With overlay, interactive elements are numbered on the screenshot and the
+        ones the model picks come back in "elements"; "points" are clickable
+        viewport coordinates either way.
+        """
+        body: dict[str, Any] = {"question": question}
+        if overlay:
+            body["overlay"] = True
+        return self._client.vision_query(self.session.id, self.id, body)  # type: ignore[arg-type]
+
+    def click(self, x: float, y: float, button: str = "left", double: bool = False,
+              scroll_into_view: bool = False, verify_change: bool = False) -> ClickResponse:
+        """Click at viewport coordinates, such as a point from ask().
+
+        With scroll_into_view, x and y are document coordinates and the page is
+        scrolled to bring them into view first.
+        """
+        body: dict[str, Any] = {"x": x, "y": y, "button": button}
+        if double:
+            body["click_count"] = 2
+        if scroll_into_view:
+            body["scroll_into_view"] = True
+        if verify_change:
+            body["verify_change"] = True
+        return self._client.click(self.session.id, self.id, body)  # type: ignore[arg-type]
+
+    def element_box(self, selector: str) -> ElementBoxResponse:
+        """Where the element matching selector is, and whether a click on it would land."""
+        return self._client.get_element_box(self.session.id, self.id, selector)
+
+    def watch(self, selector: str, debounce_ms: int | None = None, attributes: bool = False) -> WatchResponse:
+        """Report changes to content matching selector as dom_changed session events.
+
+        Read them from session.events(); each event's data names the watch_id.
+        """
+        body: dict[str, Any] = {"selector": selector}
+        if debounce_ms is not None:
+            body["debounce_ms"] = debounce_ms
+        if attributes:
+            body["attributes"] = True
+        return self._client.watch_dom(self.session.id, self.id, body)  # type: ignore[arg-type]
+
+    def unwatch(self, watch_id: str) -> None:
+        """Stop a watch started with watch()."""
+        self._client.unwatch(self.session.id, self.id, watch_id)
+
+    def wait_for_selector(self, selector: str, state: str = "attached",
+                          timeout_ms: int | None = None) -> WaitResponse:
+        """Wait until selector is attached, visible, hidden or detached.
+
+        Check "satisfied" in the result: a timeout is reported, not raised.
+        """
+        return self._wait({"selector": selector, "state": state}, timeout_ms)
+
+    def wait_for_url(self, pattern: str, timeout_ms: int | None = None) -> WaitResponse:
+        """Wait until the page URL matches a glob (*) or a /regular expression/."""
+        return self._wait({"url": pattern}, timeout_ms)
+
+    def wait_for_function(self, predicate: str, timeout_ms: int | None = None,
+                          polling_ms: int | None = None) -> WaitResponse:
+        """Wait until a JavaScript expression or function returns something truthy.
+
+        The value it returned is in "value".
+        """
+        body: dict[str, Any] = {"function": predicate}
+        if polling_ms is not None:
+            body["polling_ms"] = polling_ms
+        return self._wait(body, timeout_ms)
+
+    def _wait(self, body: dict[str, Any], timeout_ms: int | None) -> WaitResponse:
+        if timeout_ms is not None:
+            body["timeout_ms"] = timeout_ms
+        return self._client.wait(self.session.id, self.id, body)  # type: ignore[arg-type]
+
+    def screenshot(self, format: str = "png") -> bytes:
+        """Capture the page as PNG or JPEG bytes."""
+        captured = self._client.capture_screenshot(self.session.id, {"page_id": self.id, "format": format})
+        return base64.b64decode(captured["screenshot"])
+
+    def annotated_screenshot(self) -> tuple[bytes, list[ElementMark]]:
+        """Capture the page as PNG with its interactive elements numbered.
+
+        The legend maps each number to the element's tag, text, selector and box,
+        so a model looking at the image can name what to click.
+        """
+        captured = self._client.capture_screenshot(self.session.id, {"page_id": self.id, "annotate": True})
+        return base64.b64decode(captured["screenshot"]), captured.get("legend", [])
+
+    def content(self) -> str:
+        """The page's HTML."""
+        return self._client.get_page_content(self.session.id, self.id)["content"]
+
+    def analyze(self) -> dict[str, Any] | None:
+        """A summary of the page's structure."""
+        analysis = self._client.analyze_page(self.session.id, {"page_id": self.id})["analysis"]
+        return dict(analysis) if analysis else None
+
+    def accessibility_tree(self) -> list[Any]:
+        return list(self._client.get_accessibility_tree(self.session.id, {"page_id": self.id})["nodes"])
+
+    def activate(self) -> None:
+        self._client.activate_page(self.session.id, self.id)
+
+    def duplicate(self) -> Page:
+        """Open a copy of the page at its current URL."""
+        duplicated = self._client.duplicate_page(self.session.id, self.id)
+        return Page(self.session, duplicated["page_id"], duplicated["url"])
+
+    def close(self) -> None:
+        self._client.close_page(self.session.id, self.id)
+
+
+def _api_error(err: urllib.error.HTTPError) -> APIError:
+    """Convert an HTTP error into an APIError using the service's error body."""
+    code, message, violations = "HTTP_ERROR", err.reason, None
+    try:
+        detail = json.loads(err.read())["error"]
+        code, message = detail["code"], detail["message"]
+        violations = detail.get("violations")
+    except (ValueError, KeyError, TypeError):
+        pass
+    return APIError(err.code, code, str(message), violations)
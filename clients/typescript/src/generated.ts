@@ -0,0 +1,1794 @@
+// Code generated by cmd/clientgen from internal/api/endpoints.go. DO NOT EDIT.
+
+export interface CreateSessionRequest {
+  agent_id: string;
+  session_name?: string;
+  browser_port?: number;
+  template?: string;
+  options?: SessionOptions | null;
+}
+
+export interface SessionOptions {
+  viewport?: Viewport | null;
+  user_agent?: string;
+  proxy?: string;
+  proxy_bypass?: string;
+  blocked_urls?: string[];
+  init_scripts?: string[];
+  cookies?: Cookie[];
+  navigation_timeout_ms?: number;
+  idle_timeout_ms?: number;
+  pipeline?: string;
+  labels?: Record<string, string>;
+  profile?: string;
+  extensions?: string[];
+  engine?: string;
+  locale?: string;
+  timezone?: string;
+  disable_animations?: boolean;
+  websockets?: WebSocketCapture | null;
+  streams?: StreamCapture | null;
+  ignore_certificate_errors?: boolean;
+  client_certificates?: ClientCertificate[];
+  egress?: EgressPolicy | null;
+}
+
+export interface Viewport {
+  width: number;
+  height: number;
+  device_scale_factor?: number;
+  mobile?: boolean;
+}
+
+export interface Cookie {
+  name: string;
+  value: string;
+  domain: string;
+  path: string;
+  expires: number;
+  secure: boolean;
+  httpOnly: boolean;
+  sameSite: string;
+}
+
+export interface WebSocketCapture {
+  max_frames?: number;
+  max_payload_bytes?: number;
+  masks?: PayloadMask[];
+}
+
+export interface PayloadMask {
+  pattern: string;
+  replacement?: string;
+}
+
+export interface StreamCapture {
+  max_messages?: number;
+  max_payload_bytes?: number;
+  mime_types?: string[];
+  masks?: PayloadMask[];
+}
+
+export interface ClientCertificate {
+  credential: string;
+  hosts: string[];
+}
+
+export interface EgressPolicy {
+  allow?: string[];
+  deny?: string[];
+  headers?: Record<string, string>;
+}
+
+export interface CreateSessionResponse {
+  session_id: string;
+  session_name: string;
+  agent_id: string;
+  context_id: string;
+  created_at: string;
+}
+
+export interface ListSessionsResponse {
+  sessions: SessionInfo[];
+  count: number;
+}
+
+export interface SessionInfo {
+  session_id: string;
+  session_name: string;
+  agent_id: string;
+  context_id: string;
+  page_count: number;
+  created_at: string;
+  last_activity: string;
+  status: string;
+  labels?: Record<string, string>;
+}
+
+export interface DestroySessionsResponse {
+  results: DestroyOutcome[];
+  destroyed: number;
+  failed: number;
+}
+
+export interface DestroyOutcome {
+  session_id: string;
+  destroyed: boolean;
+  error?: string;
+}
+
+export interface DestroySessionBatchRequest {
+  session_ids: string[];
+}
+
+export interface ResumeSessionRequest {
+  agent_id: string;
+  session_name: string;
+}
+
+export interface ResumeSessionResponse {
+  session_id: string;
+  session_name: string;
+  resumed: boolean;
+  created_at: string;
+}
+
+export interface GetSessionResponse {
+  session_id: string;
+  session_name: string;
+  agent_id: string;
+  context_id: string;
+  page_ids: string[];
+  page_count: number;
+  created_at: string;
+  last_activity: string;
+  status: string;
+  connection?: string;
+}
+
+export interface RenameSessionRequest {
+  session_name: string;
+}
+
+export interface MemoryResponse {
+  session_id: string;
+  turns: MemoryTurn[];
+  extracts: MemoryExtract[];
+  bytes: number;
+}
+
+export interface MemoryTurn {
+  question: string;
+  answer: string;
+  page_id: string;
+  url?: string;
+  elements: ElementMark[];
+  at: string;
+}
+
+export interface ElementMark {
+  mark: number;
+  tag: string;
+  role?: string;
+  text?: string;
+  selector: string;
+  box: ElementBox;
+}
+
+export interface ElementBox {
+  x: number;
+  y: number;
+  width: number;
+  height: number;
+}
+
+export interface MemoryExtract {
+  page_id: string;
+  url?: string;
+  text: string;
+  at: string;
+}
+
+export interface StorageResponse {
+  session_id: string;
+  page_id: string;
+  origin: string;
+  local: Record<string, string>;
+  session: Record<string, string>;
+  indexed_db: IndexedDatabase[];
+}
+
+export interface IndexedDatabase {
+  name: string;
+  version: number;
+  object_stores: IndexedDBStore[];
+}
+
+export interface IndexedDBStore {
+  name: string;
+  key_path?: string;
+  auto_increment?: boolean;
+  entries: number;
+  indexes?: string[];
+}
+
+export interface WriteStorageRequest {
+  page_id: string;
+  origin?: string;
+  area: string;
+  set?: Record<string, string>;
+  remove?: string[];
+  clear?: boolean;
+}
+
+export interface ServiceWorkersResponse {
+  session_id: string;
+  page_id: string;
+  service_workers: ServiceWorker[];
+  count: number;
+}
+
+export interface ServiceWorker {
+  registration_id: string;
+  scope_url: string;
+  script_url?: string;
+  status?: string;
+  running_status?: string;
+}
+
+export interface UnregisterServiceWorkersResponse {
+  session_id: string;
+  page_id: string;
+  unregistered: ServiceWorker[];
+  count: number;
+}
+
+export interface CacheStorageResponse {
+  session_id: string;
+  page_id: string;
+  origin: string;
+  caches: StorageCache[];
+}
+
+export interface StorageCache {
+  name: string;
+  entries: number;
+}
+
+export interface CreateCheckpointRequest {
+  label?: string;
+}
+
+export interface Checkpoint {
+  checkpoint_id: string;
+  session_id: string;
+  label?: string;
+  created_at: string;
+  urls: string[];
+  cookies: number;
+}
+
+export interface ListCheckpointsResponse {
+  session_id: string;
+  checkpoints: Checkpoint[];
+  count: number;
+}
+
+export interface BranchSessionRequest {
+  session_name?: string;
+  browser_port?: number;
+}
+
+export interface BranchSessionResponse {
+  session_id: string;
+  session_name: string;
+  agent_id: string;
+  context_id: string;
+  created_at: string;
+  from_session: string;
+  checkpoint_id: string;
+  page_ids: string[];
+}
+
+export interface ShareSessionRequest {
+  mode?: string;
+  tenant_id?: string;
+  agent_id?: string;
+}
+
+export interface ShareSessionResponse {
+  session_id: string;
+  mode?: string;
+  tenant_id?: string;
+  agent_id: string;
+  shares: SessionShare[];
+}
+
+export interface SessionShare {
+  tenant_id: string;
+  shared_at: string;
+}
+
+export interface CreateObserverRequest {
+  label?: string;
+}
+
+export interface ObserverResponse {
+  observer_id: string;
+  session_id: string;
+  label?: string;
+  created_at: string;
+}
+
+export interface ListObserversResponse {
+  session_id: string;
+  observers: ObserverResponse[];
+  count: number;
+}
+
+export interface TakeoverStatusResponse {
+  session_id: string;
+  active: boolean;
+  takeover?: Takeover | null;
+}
+
+export interface Takeover {
+  takeover_id: string;
+  session_id: string;
+  page_id: string;
+  operator?: string;
+  started_at: string;
+}
+
+export interface SessionLogsResponse {
+  session_id: string;
+  lines: Line[];
+  count: number;
+}
+
+export interface Line {
+  time: string;
+  level: string;
+  message: string;
+  page_id?: string;
+  attrs?: Record<string, string>;
+}
+
+export interface EgressResponse {
+  session_id: string;
+  policy?: EgressPolicy | null;
+  decrypted: boolean;
+  usage: Usage;
+}
+
+export interface Usage {
+  requests: number;
+  blocked: number;
+  bytes_sent: number;
+  bytes_received: number;
+}
+
+export interface BandwidthResponse {
+  session_id: string;
+  requests: number;
+  bytes: number;
+  pages: PageBandwidth[];
+  egress?: Usage | null;
+}
+
+export interface PageBandwidth {
+  page_id: string;
+  requests: number;
+  bytes: number;
+}
+
+export interface WorkFilesResponse {
+  session_id: string;
+  used_bytes: number;
+  quota_bytes: number;
+  over_quota: boolean;
+  files: WorkFile[];
+}
+
+export interface WorkFile {
+  path: string;
+  size: number;
+  modified_at: string;
+}
+
+export interface NavigateRequest {
+  url: string;
+  bypass_service_worker?: boolean;
+}
+
+export interface NavigateResponse {
+  session_id: string;
+  page_id: string;
+  url: string;
+  requested_url: string;
+  status?: number;
+  status_text?: string;
+  redirects: RedirectHop[];
+  failure?: string;
+  captcha?: CaptchaInfo | null;
+  security?: SecurityDetails | null;
+}
+
+export interface RedirectHop {
+  url: string;
+  status: number;
+  location: string;
+}
+
+export interface CaptchaInfo {
+  detected: boolean;
+  provider?: string;
+  site_key?: string;
+  url: string;
+  signals?: string[];
+  detected_at: string;
+}
+
+export interface SecurityDetails {
+  state: string;
+  protocol?: string;
+  key_exchange?: string;
+  key_exchange_group?: string;
+  cipher?: string;
+  subject_name?: string;
+  issuer?: string;
+  sans?: string[];
+  valid_from?: string;
+  valid_to?: string;
+  expired?: boolean;
+  certificate_transparency?: string;
+}
+
+export interface LoginRequest {
+  page_id: string;
+  credential: string;
+  form_index?: number | null;
+  success_url_contains?: string;
+  success_selector?: string;
+  success_engine?: string;
+  success_match?: string;
+  timeout_ms?: number;
+}
+
+export interface LoginResponse {
+  session_id: string;
+  page_id: string;
+  credential: string;
+  success: boolean;
+  reason: string;
+  url: string;
+  navigated: boolean;
+  username_field?: string;
+  page_change?: PageDelta | null;
+}
+
+export interface PageDelta {
+  changed: boolean;
+  url_changed: boolean;
+  title_changed: boolean;
+  dom_changed: boolean;
+  text_changed: boolean;
+  node_count_delta: number;
+  screenshot_changed?: boolean | null;
+}
+
+export interface ExecuteJSRequest {
+  page_id: string;
+  script: string;
+  args?: unknown[];
+  serialization?: string;
+  max_depth?: number;
+  verify_change?: boolean;
+  verify_screenshot?: boolean;
+}
+
+export interface ExecuteJSResponse {
+  session_id: string;
+  page_id: string;
+  result: unknown;
+  page_change?: PageDelta | null;
+}
+
+export interface ExtractRequest {
+  page_id: string;
+  script: string;
+  pipeline?: string;
+}
+
+export interface PipelineResult {
+  pipeline: string;
+  input: number;
+  output: number;
+  duplicates: number;
+  invalid: number;
+  errors?: string[];
+  records: unknown[];
+}
+
+export interface RunRequest {
+  page_id?: string;
+  steps: RunStep[];
+}
+
+export interface RunStep {
+  action: string;
+  url?: string;
+  new_page?: boolean;
+  selector?: string;
+  state?: string;
+  function?: string;
+  x?: number;
+  y?: number;
+  text?: string;
+  clear?: boolean;
+  submit?: boolean;
+  script?: string;
+  as?: string;
+  visible?: boolean;
+  text_matches?: string;
+  cookie?: string;
+  status?: number;
+  baseline?: string;
+  max_diff_percent?: number;
+  ignore?: Region[];
+  engine?: string;
+  match?: string;
+  fingerprint?: ElementFingerprint | null;
+  timeout_ms?: number;
+  optional?: boolean;
+}
+
+export interface Region {
+  x: number;
+  y: number;
+  width: number;
+  height: number;
+}
+
+export interface ElementFingerprint {
+  tag: string;
+  text?: string;
+  attributes?: Record<string, string>;
+  role?: string;
+  name?: string;
+  path?: string;
+  context?: string;
+  selector?: string;
+  box?: ElementBox | null;
+}
+
+export interface RunResponse {
+  session_id: string;
+  success: boolean;
+  page_id?: string;
+  failed_step?: number | null;
+  steps: StepResult[];
+  extracted: Record<string, unknown>;
+  duration: string;
+}
+
+export interface StepResult {
+  index: number;
+  action: string;
+  status: string;
+  page_id?: string;
+  value?: unknown;
+  error?: string;
+  duration?: string;
+  healed?: HealResult | null;
+  element?: ElementFingerprint | null;
+}
+
+export interface HealResult {
+  matched: boolean;
+  healed: boolean;
+  original: string;
+  selector?: string;
+  score?: number;
+  confirmed_by?: string;
+  cached?: boolean;
+  reason?: string;
+  candidates: HealCandidate[];
+}
+
+export interface HealCandidate {
+  selector: string;
+  score: number;
+  fingerprint: ElementFingerprint;
+}
+
+export interface ScreenshotRequest {
+  page_id: string;
+  format?: string;
+  annotate?: boolean;
+}
+
+export interface ScreenshotResponse {
+  session_id: string;
+  page_id: string;
+  screenshot: string;
+  format: string;
+  size: number;
+  legend?: ElementMark[];
+  device_pixel_ratio?: number;
+}
+
+export interface AnalyzePageRequest {
+  page_id: string;
+  instruction_language?: string;
+}
+
+export interface AnalyzePageResponse {
+  session_id: string;
+  page_id: string;
+  analysis: PageStructure | null;
+  instruction_language?: string;
+  translate_labels?: boolean;
+}
+
+export interface PageStructure {
+  page_id: string;
+  url: string;
+  title: string;
+  language?: string;
+  structure: StructureDetail;
+}
+
+export interface StructureDetail {
+  classes: string[];
+  ids: string[];
+  headings: Record<string, string[]>;
+  interactive: InteractiveDetail;
+  semantic_sections: SemanticSection[];
+  data_attributes: string[];
+  text_snippets: string[];
+}
+
+export interface InteractiveDetail {
+  buttons: string[];
+  links: string[];
+  forms: string[];
+}
+
+export interface SemanticSection {
+  type: string;
+  class?: string;
+  count: number;
+  children?: string[];
+}
+
+export interface AccessibilityTreeRequest {
+  page_id: string;
+}
+
+export interface AccessibilityTreeResponse {
+  session_id: string;
+  page_id: string;
+  nodes: (AXNode | null)[];
+}
+
+export interface AXNode {
+  role: string;
+  name?: string;
+  level?: number;
+  value?: string;
+  focusable?: boolean;
+  children: (AXNode | null)[];
+}
+
+export interface ListPagesResponse {
+  session_id: string;
+  pages: TabInfo[];
+  count: number;
+}
+
+export interface TabInfo {
+  page_id: string;
+  title: string;
+  url: string;
+  opener_id?: string;
+  adopted?: boolean;
+  route?: RouteInfo | null;
+  security?: SecurityDetails | null;
+}
+
+export interface RouteInfo {
+  url: string;
+  kind: string;
+  changes: number;
+  changed_at: string;
+}
+
+export interface GetPageContentResponse {
+  session_id: string;
+  page_id: string;
+  content: string;
+  length: number;
+}
+
+export interface DuplicatePageResponse {
+  session_id: string;
+  page_id: string;
+  source_page_id: string;
+  url: string;
+}
+
+export interface VisionQueryRequest {
+  question: string;
+  overlay?: boolean;
+  timeout_ms?: number;
+  stream?: boolean;
+  fresh?: boolean;
+  no_cache?: boolean;
+}
+
+export interface VisionQueryResponse {
+  session_id: string;
+  page_id: string;
+  answer: string;
+  points: Point[];
+  elements: ElementMark[];
+  marks?: ElementMark[];
+  model: string;
+  usage: VisionUsage;
+  cached: boolean;
+  duration: string;
+}
+
+export interface Point {
+  x: number;
+  y: number;
+}
+
+export interface VisionUsage {
+  prompt_tokens: number;
+  completion_tokens: number;
+}
+
+export interface ClickRequest {
+  x: number;
+  y: number;
+  button?: string;
+  click_count?: number;
+  modifiers?: number;
+  scroll_into_view?: boolean;
+  verify_change?: boolean;
+}
+
+export interface ClickResponse {
+  session_id: string;
+  page_id: string;
+  x: number;
+  y: number;
+  scroll_x: number;
+  scroll_y: number;
+  target?: ClickTarget | null;
+  element?: ElementFingerprint | null;
+  page_change?: PageDelta | null;
+}
+
+export interface ClickTarget {
+  tag: string;
+  text?: string;
+  selector: string;
+}
+
+export interface ElementBoxResponse {
+  session_id: string;
+  page_id: string;
+  selector: string;
+  tag: string;
+  box: ElementBox | null;
+  content?: ElementBox | null;
+  padding?: ElementBox | null;
+  margin?: ElementBox | null;
+  visible: boolean;
+  in_viewport: boolean;
+  occluded: boolean;
+  occluded_by?: ClickTarget | null;
+  clickable: boolean;
+  click_point?: Point | null;
+  styles: Record<string, string>;
+}
+
+export interface ElementFingerprintResponse {
+  session_id: string;
+  page_id: string;
+  tag: string;
+  text?: string;
+  attributes?: Record<string, string>;
+  role?: string;
+  name?: string;
+  path?: string;
+  context?: string;
+  selector?: string;
+  box?: ElementBox | null;
+}
+
+export interface HealSelectorRequest {
+  selector: string;
+  fingerprint: ElementFingerprint | null;
+  min_score?: number;
+  confirm?: boolean;
+  no_cache?: boolean;
+  engine?: string;
+  match?: string;
+}
+
+export interface HealSelectorResponse {
+  session_id: string;
+  page_id: string;
+  matched: boolean;
+  healed: boolean;
+  original: string;
+  selector?: string;
+  score?: number;
+  confirmed_by?: string;
+  cached?: boolean;
+  reason?: string;
+  candidates: HealCandidate[];
+}
+
+export interface CaptureCanvasRequest {
+  selector?: string;
+  method?: string;
+  format?: string;
+  quality?: number;
+  engine?: string;
+  match?: string;
+}
+
+export interface CaptureCanvasResponse {
+  session_id: string;
+  page_id: string;
+  canvases: CanvasCapture[];
+  count: number;
+  total: number;
+}
+
+export interface CanvasCapture {
+  index: number;
+  selector: string;
+  width: number;
+  height: number;
+  box?: ElementBox | null;
+  label?: string;
+  method?: string;
+  mime_type?: string;
+  data?: string;
+  size?: number;
+  tainted?: boolean;
+  blank?: boolean;
+  error?: string;
+  chart?: ChartData | null;
+}
+
+export interface ChartData {
+  library: string;
+  type?: string;
+  title?: string;
+  labels?: unknown[];
+  series: ChartSeries[];
+  truncated?: boolean;
+}
+
+export interface ChartSeries {
+  name?: string;
+  type?: string;
+  data: unknown[];
+}
+
+export interface PageMediaResponse {
+  session_id: string;
+  page_id: string;
+  media?: string;
+  color_scheme?: string;
+  reduced_motion?: string;
+}
+
+export interface PageMediaRequest {
+  media?: string;
+  color_scheme?: string;
+  reduced_motion?: string;
+}
+
+export interface WebSocketTrafficResponse {
+  session_id: string;
+  page_id: string;
+  sockets: WebSocketInfo[];
+  frames: WebSocketFrame[];
+  total: number;
+  dropped: number;
+}
+
+export interface WebSocketInfo {
+  id: string;
+  url: string;
+  frames: number;
+  opened_at: string;
+  closed_at?: string;
+  error?: string;
+}
+
+export interface WebSocketFrame {
+  socket: string;
+  url: string;
+  direction: string;
+  opcode: number;
+  payload: string;
+  size: number;
+  truncated?: boolean;
+  masked?: boolean;
+  time: string;
+}
+
+export interface StreamTrafficResponse {
+  session_id: string;
+  page_id: string;
+  streams: StreamInfo[];
+  messages: StreamMessage[];
+  total: number;
+  dropped: number;
+}
+
+export interface StreamInfo {
+  id: string;
+  url: string;
+  type: string;
+  mime_type: string;
+  messages: number;
+  opened_at: string;
+  closed_at?: string;
+  error?: string;
+}
+
+export interface StreamMessage {
+  stream: string;
+  url: string;
+  kind: string;
+  event?: string;
+  event_id?: string;
+  data: string;
+  size: number;
+  binary?: boolean;
+  truncated?: boolean;
+  masked?: boolean;
+  time: string;
+}
+
+export interface PageSecurityResponse {
+  session_id: string;
+  page_id: string;
+  url: string;
+  state: string;
+  issues: string[];
+  mixed_content: string[];
+  certificate_error?: string;
+  navigation?: SecurityDetails | null;
+}
+
+export interface CreateHandleRequest {
+  selector: string;
+  engine?: string;
+  match?: string;
+}
+
+export interface HandleResponse {
+  session_id: string;
+  handle_id: string;
+  page_id: string;
+  selector: string;
+  engine?: string;
+  match?: string;
+  tag: string;
+  created_at: string;
+}
+
+export interface ListHandlesResponse {
+  session_id: string;
+  page_id: string;
+  handles: ElementHandle[];
+  count: number;
+}
+
+export interface ElementHandle {
+  handle_id: string;
+  page_id: string;
+  selector: string;
+  engine?: string;
+  match?: string;
+  tag: string;
+  created_at: string;
+}
+
+export interface HandleClickRequest {
+  button?: string;
+  click_count?: number;
+  modifiers?: number;
+  verify_change?: boolean;
+}
+
+export interface HandleTextResponse {
+  session_id: string;
+  page_id: string;
+  handle_id: string;
+  text: string;
+}
+
+export interface HandleAttributeResponse {
+  session_id: string;
+  page_id: string;
+  handle_id: string;
+  name: string;
+  value: string | null;
+}
+
+export interface WatchRequest {
+  selector: string;
+  debounce_ms?: number;
+  attributes?: boolean;
+  engine?: string;
+  match?: string;
+}
+
+export interface WatchResponse {
+  session_id: string;
+  watch_id: string;
+  page_id: string;
+  selector: string;
+  engine?: string;
+  match?: string;
+  debounce_ms: number;
+  attributes: boolean;
+  created_at: string;
+}
+
+export interface ListWatchesResponse {
+  session_id: string;
+  page_id: string;
+  watches: DOMWatch[];
+  count: number;
+}
+
+export interface DOMWatch {
+  watch_id: string;
+  page_id: string;
+  selector: string;
+  engine?: string;
+  match?: string;
+  debounce_ms: number;
+  attributes: boolean;
+  created_at: string;
+}
+
+export interface WaitRequest {
+  selector?: string;
+  state?: string;
+  url?: string;
+  function?: string;
+  timeout_ms?: number;
+  polling_ms?: number;
+  engine?: string;
+  match?: string;
+}
+
+export interface WaitResponse {
+  session_id: string;
+  page_id: string;
+  satisfied: boolean;
+  condition: string;
+  url: string;
+  value?: unknown;
+  last_error?: string;
+  polls: number;
+  duration: string;
+}
+
+export interface AssertRequest {
+  selector?: string;
+  visible?: boolean;
+  text?: string;
+  text_matches?: string;
+  url?: string;
+  cookie?: string;
+  status?: number;
+  function?: string;
+  engine?: string;
+  match?: string;
+}
+
+export interface AssertResponse {
+  session_id: string;
+  page_id: string;
+  passed: boolean;
+  url?: string;
+  checks: AssertionCheck[];
+}
+
+export interface AssertionCheck {
+  check: string;
+  passed: boolean;
+  expected?: unknown;
+  actual?: unknown;
+  error?: string;
+}
+
+export interface VisualDiffRequest {
+  baseline: string;
+  threshold?: number;
+  max_diff_percent?: number;
+  ignore?: Region[];
+  update?: boolean;
+}
+
+export interface VisualDiffResponse {
+  session_id: string;
+  page_id: string;
+  baseline: string;
+  created?: boolean;
+  updated?: boolean;
+  passed: boolean;
+  width: number;
+  height: number;
+  size_changed: boolean;
+  changed_pixels: number;
+  compared_pixels: number;
+  change_percent: number;
+  ssim: number;
+  diff_image?: string;
+}
+
+export interface ContentHashRequest {
+  ignore?: string[];
+  mask_numbers?: boolean;
+  previous?: ContentHash | null;
+}
+
+export interface ContentHash {
+  url: string;
+  text_hash: string;
+  structure_hash: string;
+  text_simhash: string;
+  structure_simhash: string;
+  words: number;
+  elements: number;
+}
+
+export interface ContentHashResponse {
+  session_id: string;
+  page_id: string;
+  url: string;
+  text_hash: string;
+  structure_hash: string;
+  text_simhash: string;
+  structure_simhash: string;
+  words: number;
+  elements: number;
+  change?: ContentChange | null;
+}
+
+export interface ContentChange {
+  text_changed: boolean;
+  structure_changed: boolean;
+  text_score: number;
+  structure_score: number;
+  score: number;
+}
+
+export interface ListFormsResponse {
+  session_id: string;
+  page_id: string;
+  forms: FormInfo[];
+  count: number;
+}
+
+export interface FormInfo {
+  index: number;
+  id?: string;
+  name?: string;
+  action?: string;
+  method: string;
+  fields: FormField[];
+}
+
+export interface FormField {
+  name: string;
+  type: string;
+  label?: string;
+  required: boolean;
+  options?: string[];
+}
+
+export interface FillFormRequest {
+  values: Record<string, unknown>;
+  submit?: boolean;
+  timeout_ms?: number;
+}
+
+export interface FillFormResponse {
+  session_id: string;
+  page_id: string;
+  form_index: number;
+  filled: string[];
+  missing: string[];
+  submitted: boolean;
+  navigated: boolean;
+  url?: string;
+  page_change?: PageDelta | null;
+}
+
+export interface CaptchaResponse {
+  session_id: string;
+  page_id: string;
+  captcha: CaptchaInfo | null;
+}
+
+export interface SolveCaptchaRequest {
+  solver?: string;
+  manual?: boolean;
+  timeout_ms?: number;
+}
+
+export interface SolveCaptchaResponse {
+  session_id: string;
+  page_id: string;
+  solved: boolean;
+  method: string;
+  provider: string;
+  injection?: CaptchaInjection | null;
+  url?: string;
+  duration: string;
+}
+
+export interface CaptchaInjection {
+  fields: number;
+  callback: boolean;
+}
+
+export interface ListResourcesResponse {
+  session_id: string;
+  page_id: string;
+  resources: ResourceInfo[];
+  count: number;
+}
+
+export interface ResourceInfo {
+  url: string;
+  type: string;
+  mime_type: string;
+  content_size?: number;
+  frame_id: string;
+  mixed_content?: boolean;
+}
+
+export interface DownloadResourceRequest {
+  url: string;
+  source?: string;
+  max_bytes?: number;
+  raw?: boolean;
+}
+
+export interface DownloadResourceResponse {
+  session_id: string;
+  page_id: string;
+  url: string;
+  mime_type: string;
+  source: string;
+  status?: number;
+  data: string;
+  size: number;
+}
+
+export interface PrintPDFRequest {
+  landscape?: boolean;
+  print_background?: boolean;
+  scale?: number;
+  paper_width?: number;
+  paper_height?: number;
+  page_ranges?: string;
+}
+
+export interface SearchRequest {
+  query: string;
+  count?: number;
+  language?: string;
+  region?: string;
+}
+
+export interface SearchResponse {
+  query: string;
+  backend: string;
+  results: Result[];
+  count: number;
+}
+
+export interface Result {
+  position: number;
+  title: string;
+  url: string;
+  domain: string;
+  snippet?: string;
+}
+
+export interface ResearchRequest {
+  question: string;
+  sources?: number;
+  language?: string;
+  region?: string;
+  timeout_ms?: number;
+  stream?: boolean;
+  no_cache?: boolean;
+}
+
+export interface ResearchResponse {
+  question: string;
+  answer: string;
+  claims: Claim[];
+  sources: ResearchSource[];
+  model?: string;
+  usage: VisionUsage;
+  cached: boolean;
+  duration: string;
+}
+
+export interface Claim {
+  text: string;
+  sources: string[];
+}
+
+export interface ResearchSource {
+  url: string;
+  title: string;
+  position: number;
+  passages: Passage[];
+  error?: string;
+  duration?: string;
+}
+
+export interface Passage {
+  text: string;
+  score: number;
+}
+
+export interface SessionEvent {
+  id: number;
+  session_id: string;
+  page_id?: string;
+  type: string;
+  time: string;
+  data?: unknown;
+}
+
+export type Query = Record<string, string | number | undefined>;
+
+/** One method per endpoint. Subclasses implement the transport. */
+export abstract class GeneratedClient {
+  protected abstract request<T>(method: string, path: string, body?: unknown, query?: Query): Promise<T>;
+  protected abstract requestBytes(method: string, path: string, body?: unknown, query?: Query): Promise<ArrayBuffer>;
+  protected abstract stream<T>(path: string, query: Query): AsyncIterable<T>;
+
+  /** Create a session for an agent */
+  createSession(body: CreateSessionRequest): Promise<CreateSessionResponse> {
+    return this.request("POST", `/sessions`, body);
+  }
+
+  /** List active sessions */
+  listSessions(): Promise<ListSessionsResponse> {
+    return this.request("GET", `/sessions`);
+  }
+
+  /** Destroy the sessions matching a label, age or agent filter */
+  destroySessionsByFilter(query: { label?: string | number; older_than?: string | number; agent_id?: string | number } = {}): Promise<DestroySessionsResponse> {
+    return this.request("DELETE", `/sessions`, undefined, query);
+  }
+
+  /** Destroy a list of sessions */
+  destroySessionBatch(body: DestroySessionBatchRequest): Promise<DestroySessionsResponse> {
+    return this.request("POST", `/sessions/destroy-batch`, body);
+  }
+
+  /** Resume a session by agent and name, creating it if needed */
+  resumeSession(body: ResumeSessionRequest): Promise<ResumeSessionResponse> {
+    return this.request("POST", `/sessions/resume`, body);
+  }
+
+  /** Get session details */
+  getSession(sessionId: string): Promise<GetSessionResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}`);
+  }
+
+  /** Destroy a session and its browser context */
+  destroySession(sessionId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}`);
+  }
+
+  /** Resume a session by ID, restoring it if it was closed */
+  resumeSessionByID(sessionId: string): Promise<ResumeSessionResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/resume`);
+  }
+
+  /** Close a session's pages, keeping it resumable by name */
+  closeSession(sessionId: string): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/sessions/${encodeURIComponent(sessionId)}/close`);
+  }
+
+  /** Rename a session */
+  renameSession(sessionId: string, body: RenameSessionRequest): Promise<Record<string, unknown>> {
+    return this.request("PUT", `/sessions/${encodeURIComponent(sessionId)}/rename`, body);
+  }
+
+  /** Free a session's browser context until its next call */
+  hibernateSession(sessionId: string): Promise<Record<string, unknown>> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/hibernate`);
+  }
+
+  /** Wake a hibernated session ahead of its next call */
+  wakeSession(sessionId: string): Promise<Record<string, unknown>> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/wake`);
+  }
+
+  /** Show the earlier questions and page content follow-up vision queries draw on */
+  getMemory(sessionId: string): Promise<MemoryResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/memory`);
+  }
+
+  /** Forget a session's earlier questions and page content */
+  clearMemory(sessionId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/memory`);
+  }
+
+  /** Read an origin's localStorage, sessionStorage and IndexedDB summary through a page */
+  getStorage(sessionId: string, query: { page_id?: string | number; origin?: string | number } = {}): Promise<StorageResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/storage`, undefined, query);
+  }
+
+  /** Set, remove or clear an origin's localStorage or sessionStorage items */
+  writeStorage(sessionId: string, body: WriteStorageRequest): Promise<StorageResponse> {
+    return this.request("PUT", `/sessions/${encodeURIComponent(sessionId)}/storage`, body);
+  }
+
+  /** List the service worker registrations of a session's browser context */
+  listServiceWorkers(sessionId: string, query: { page_id?: string | number } = {}): Promise<ServiceWorkersResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/service-workers`, undefined, query);
+  }
+
+  /** Unregister the service worker for a scope, or every one */
+  unregisterServiceWorkers(sessionId: string, query: { page_id?: string | number; scope_url?: string | number } = {}): Promise<UnregisterServiceWorkersResponse> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/service-workers`, undefined, query);
+  }
+
+  /** List an origin's CacheStorage caches and how many responses each holds */
+  getCacheStorage(sessionId: string, query: { page_id?: string | number; origin?: string | number } = {}): Promise<CacheStorageResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/cache-storage`, undefined, query);
+  }
+
+  /** Delete one of an origin's CacheStorage caches, or all of them */
+  clearCacheStorage(sessionId: string, query: { page_id?: string | number; origin?: string | number; cache?: string | number } = {}): Promise<CacheStorageResponse> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/cache-storage`, undefined, query);
+  }
+
+  /** Save a session's cookies, page URLs and web storage as a checkpoint */
+  createCheckpoint(sessionId: string, body: CreateCheckpointRequest): Promise<Checkpoint> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/checkpoints`, body);
+  }
+
+  /** List a session's checkpoints */
+  listCheckpoints(sessionId: string): Promise<ListCheckpointsResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/checkpoints`);
+  }
+
+  /** Delete a checkpoint */
+  deleteCheckpoint(sessionId: string, checkpointId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/checkpoints/${encodeURIComponent(checkpointId)}`);
+  }
+
+  /** Create a new session from a checkpoint */
+  branchSession(sessionId: string, checkpointId: string, body: BranchSessionRequest): Promise<BranchSessionResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/checkpoints/${encodeURIComponent(checkpointId)}/branch`, body);
+  }
+
+  /** Share a session read-only with another tenant, or transfer it to a new owner */
+  shareSession(sessionId: string, body: ShareSessionRequest): Promise<ShareSessionResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/share`, body);
+  }
+
+  /** Show a session's owner and read-only shares */
+  listShares(sessionId: string): Promise<ShareSessionResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/share`);
+  }
+
+  /** Revoke a tenant's read-only access to a session */
+  revokeShare(sessionId: string, tenantId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/share/${encodeURIComponent(tenantId)}`);
+  }
+
+  /** Create a read-only link for watching a session */
+  createObserver(sessionId: string, body: CreateObserverRequest): Promise<ObserverResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/observers`, body);
+  }
+
+  /** List a session's observer links */
+  listObservers(sessionId: string): Promise<ListObserversResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/observers`);
+  }
+
+  /** Revoke an observer link */
+  revokeObserver(sessionId: string, observerId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/observers/${encodeURIComponent(observerId)}`);
+  }
+
+  /** Show whether a human has taken over a session */
+  getTakeover(sessionId: string): Promise<TakeoverStatusResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/takeover`);
+  }
+
+  /** Hand a taken-over session back to the agent */
+  endTakeover(sessionId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/takeover`);
+  }
+
+  /** Show the recent server log lines of a session */
+  getSessionLogs(sessionId: string, query: { level?: string | number; page_id?: string | number; limit?: string | number } = {}): Promise<SessionLogsResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/logs`, undefined, query);
+  }
+
+  /** Show a session's egress policy and the requests and bytes it sent through the egress proxy */
+  getEgressUsage(sessionId: string): Promise<EgressResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/egress`);
+  }
+
+  /** Show the requests and bytes a session's pages downloaded, in total and per open page */
+  getSessionBandwidth(sessionId: string): Promise<BandwidthResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/bandwidth`);
+  }
+
+  /** List the downloads and other files in a session's work directory */
+  listWorkFiles(sessionId: string): Promise<WorkFilesResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/files`);
+  }
+
+  /** Open a new page at a URL */
+  navigate(sessionId: string, body: NavigateRequest): Promise<NavigateResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/navigate`, body);
+  }
+
+  /** Fill and submit a page's login form with a stored credential */
+  login(sessionId: string, body: LoginRequest): Promise<LoginResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/login`, body);
+  }
+
+  /** Run JavaScript on a page */
+  executeJS(sessionId: string, body: ExecuteJSRequest): Promise<ExecuteJSResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/execute`, body);
+  }
+
+  /** Run a script on a page and pass its result through a pipeline */
+  extract(sessionId: string, body: ExtractRequest): Promise<PipelineResult> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/extract`, body);
+  }
+
+  /** Run a script of navigate, wait, click, type, extract, assert and compare steps */
+  run(sessionId: string, body: RunRequest): Promise<RunResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/run`, body);
+  }
+
+  /** Capture a screenshot of a page */
+  captureScreenshot(sessionId: string, body: ScreenshotRequest): Promise<ScreenshotResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/screenshot`, body);
+  }
+
+  /** Summarize a page's structure */
+  analyzePage(sessionId: string, body: AnalyzePageRequest): Promise<AnalyzePageResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/analyze`, body);
+  }
+
+  /** Get a page's accessibility tree */
+  getAccessibilityTree(sessionId: string, body: AccessibilityTreeRequest): Promise<AccessibilityTreeResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/accessibility-tree`, body);
+  }
+
+  /** List the live pages of a session */
+  listPages(sessionId: string): Promise<ListPagesResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages`);
+  }
+
+  /** Get a page's HTML */
+  getPageContent(sessionId: string, pageId: string): Promise<GetPageContentResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/content`);
+  }
+
+  /** Close a page */
+  closePage(sessionId: string, pageId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}`);
+  }
+
+  /** Bring a page to the front */
+  activatePage(sessionId: string, pageId: string): Promise<void> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/activate`);
+  }
+
+  /** Open a copy of a page */
+  duplicatePage(sessionId: string, pageId: string): Promise<DuplicatePageResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/duplicate`);
+  }
+
+  /** Ask a vision model about a page's screenshot */
+  visionQuery(sessionId: string, pageId: string, body: VisionQueryRequest): Promise<VisionQueryResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/vision-query`, body);
+  }
+
+  /** Click at a point on a page */
+  click(sessionId: string, pageId: string, body: ClickRequest): Promise<ClickResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/click`, body);
+  }
+
+  /** Get an element's box, visibility and occlusion */
+  getElementBox(sessionId: string, pageId: string, query: { selector?: string | number; engine?: string | number; match?: string | number; object_id?: string | number } = {}): Promise<ElementBoxResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/element/box`, undefined, query);
+  }
+
+  /** Fingerprint an element so its selector can be healed later */
+  getElementFingerprint(sessionId: string, pageId: string, query: { selector?: string | number; engine?: string | number; match?: string | number } = {}): Promise<ElementFingerprintResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/element/fingerprint`, undefined, query);
+  }
+
+  /** Find the element a selector that stopped matching meant, by its fingerprint */
+  healSelector(sessionId: string, pageId: string, body: HealSelectorRequest): Promise<HealSelectorResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/element/heal`, body);
+  }
+
+  /** Capture a page's canvases as images, with the data of charts drawn on them */
+  captureCanvas(sessionId: string, pageId: string, body: CaptureCanvasRequest): Promise<CaptureCanvasResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/canvas`, body);
+  }
+
+  /** Get the CSS media a page is rendered for */
+  getPageMedia(sessionId: string, pageId: string): Promise<PageMediaResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/media`);
+  }
+
+  /** Render a page for print media, a color scheme or reduced motion */
+  setPageMedia(sessionId: string, pageId: string, body: PageMediaRequest): Promise<PageMediaResponse> {
+    return this.request("PUT", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/media`, body);
+  }
+
+  /** List a page's WebSockets and the frames they sent and received */
+  getWebSocketTraffic(sessionId: string, pageId: string, query: { direction?: string | number; url?: string | number; min_size?: string | number; max_size?: string | number; since?: string | number; until?: string | number; limit?: string | number } = {}): Promise<WebSocketTrafficResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/network/websockets`, undefined, query);
+  }
+
+  /** List a page's event streams and streamed responses and the messages they received */
+  getStreamTraffic(sessionId: string, pageId: string, query: { kind?: string | number; event?: string | number; url?: string | number; min_size?: string | number; max_size?: string | number; since?: string | number; until?: string | number; limit?: string | number } = {}): Promise<StreamTrafficResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/network/streams`, undefined, query);
+  }
+
+  /** Get a page's security state, mixed content and certificate */
+  getPageSecurity(sessionId: string, pageId: string): Promise<PageSecurityResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/security`);
+  }
+
+  /** Find an element once and keep a handle to it until the page navigates */
+  createHandle(sessionId: string, pageId: string, body: CreateHandleRequest): Promise<HandleResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/handles`, body);
+  }
+
+  /** List the element handles of a page */
+  listHandles(sessionId: string, pageId: string): Promise<ListHandlesResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/handles`);
+  }
+
+  /** Release an element handle */
+  releaseHandle(sessionId: string, pageId: string, handleId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/handles/${encodeURIComponent(handleId)}`);
+  }
+
+  /** Scroll a handle's element into view and click it */
+  clickHandle(sessionId: string, pageId: string, handleId: string, body: HandleClickRequest): Promise<ClickResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/handles/${encodeURIComponent(handleId)}/click`, body);
+  }
+
+  /** Get the rendered text of a handle's element */
+  getHandleText(sessionId: string, pageId: string, handleId: string): Promise<HandleTextResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/handles/${encodeURIComponent(handleId)}/text`);
+  }
+
+  /** Read an attribute of a handle's element */
+  getHandleAttribute(sessionId: string, pageId: string, handleId: string, query: { name?: string | number } = {}): Promise<HandleAttributeResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/handles/${encodeURIComponent(handleId)}/attribute`, undefined, query);
+  }
+
+  /** Get the box, visibility and occlusion of a handle's element */
+  getHandleBox(sessionId: string, pageId: string, handleId: string): Promise<ElementBoxResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/handles/${encodeURIComponent(handleId)}/box`);
+  }
+
+  /** Report changes to matching content as dom_changed events */
+  watchDOM(sessionId: string, pageId: string, body: WatchRequest): Promise<WatchResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/watch`, body);
+  }
+
+  /** List the DOM watches of a page */
+  listWatches(sessionId: string, pageId: string): Promise<ListWatchesResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/watch`);
+  }
+
+  /** Remove a DOM watch */
+  unwatch(sessionId: string, pageId: string, watchId: string): Promise<void> {
+    return this.request("DELETE", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/watch/${encodeURIComponent(watchId)}`);
+  }
+
+  /** Wait for a selector, URL or predicate */
+  wait(sessionId: string, pageId: string, body: WaitRequest): Promise<WaitResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/wait`, body);
+  }
+
+  /** Check elements, text, URL, cookies and navigation status of a page */
+  assert(sessionId: string, pageId: string, body: AssertRequest): Promise<AssertResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/assert`, body);
+  }
+
+  /** Compare a screenshot of a page with a stored baseline */
+  visualDiff(sessionId: string, pageId: string, body: VisualDiffRequest): Promise<VisualDiffResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/visual-diff`, body);
+  }
+
+  /** Hash a page's text and structure, scoring the change since earlier hashes */
+  contentHash(sessionId: string, pageId: string, body: ContentHashRequest): Promise<ContentHashResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/content-hash`, body);
+  }
+
+  /** List a page's forms and their fields */
+  listForms(sessionId: string, pageId: string): Promise<ListFormsResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/forms`);
+  }
+
+  /** Fill a form's fields by name, optionally submitting it */
+  fillForm(sessionId: string, pageId: string, formIndex: string, body: FillFormRequest): Promise<FillFormResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/forms/${encodeURIComponent(formIndex)}/fill`, body);
+  }
+
+  /** Detect a CAPTCHA on a page */
+  detectCaptcha(sessionId: string, pageId: string): Promise<CaptchaResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/captcha`);
+  }
+
+  /** Solve a page's CAPTCHA through the configured solver */
+  solveCaptcha(sessionId: string, pageId: string, body: SolveCaptchaRequest): Promise<SolveCaptchaResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/captcha/solve`, body);
+  }
+
+  /** List the resources a page loaded */
+  listResources(sessionId: string, pageId: string): Promise<ListResourcesResponse> {
+    return this.request("GET", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/resources`);
+  }
+
+  /** Download a resource a page loaded, base64 encoded */
+  downloadResource(sessionId: string, pageId: string, body: DownloadResourceRequest): Promise<DownloadResourceResponse> {
+    return this.request("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/resources/download`, body);
+  }
+
+  /** Print a page to PDF */
+  printPDF(sessionId: string, pageId: string, body: PrintPDFRequest): Promise<ArrayBuffer> {
+    return this.requestBytes("POST", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/pdf`, body);
+  }
+
+  /** Search the web for pages to navigate to */
+  search(body: SearchRequest): Promise<SearchResponse> {
+    return this.request("POST", `/search`, body);
+  }
+
+  /** Answer a question from the top search results, citing a source for each claim */
+  research(body: ResearchRequest): Promise<ResearchResponse> {
+    return this.request("POST", `/research`, body);
+  }
+
+  /** Stream session events, replaying those after an event ID */
+  streamEvents(sessionId: string, query: { after?: string | number } = {}): AsyncIterable<SessionEvent> {
+    return this.stream(`/sessions/${encodeURIComponent(sessionId)}/events/ws`, query);
+  }
+}
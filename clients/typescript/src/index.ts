@@ -0,0 +1,412 @@
+// Session and page abstractions over the generated endpoint methods.
+
+import { GeneratedClient } from "./generated.js";
+import type {
+  AXNode,
+  ClickRequest,
+  ClickResponse,
+  ElementBoxResponse,
+  ElementMark,
+  ExecuteJSResponse,
+  GetSessionResponse,
+  PageStructure,
+  PipelineResult,
+  Query,
+  SessionEvent,
+  SessionOptions,
+  VisionQueryResponse,
+  WaitResponse,
+  WatchRequest,
+  WatchResponse,
+} from "./generated.js";
+
+export * from "./generated.js";
+
+/** An error response from the service. */
+/** One request field that broke a validation rule (VALIDATION_FAILED errors). */
+export interface Violation {
+  field: string;
+  rule: string;
+  param?: string;
+  message: string;
+}
+
+export class APIError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: string,
+    message: string,
+    readonly violations: Violation[] = [],
+  ) {
+    super(`${status} ${code}: ${message}`);
+    this.name = "APIError";
+  }
+}
+
+export interface ClientOptions {
+  baseUrl?: string; // Default http://localhost:8080
+  headers?: Record<string, string>;
+  timeoutMs?: number; // Per request, default 60s
+  apiKey?: string; // Tenant API key on multi-tenant servers
+}
+
+export interface CreateSessionOptions {
+  name?: string;
+  template?: string;
+  options?: SessionOptions;
+}
+
+/**
+ * HTTP client for a Browser Query AI server. The endpoint methods (navigate,
+ * executeJS, ...) are generated; session() and attach() return Session objects
+ * that carry the IDs for you.
+ */
+export class Client extends GeneratedClient {
+  readonly baseUrl: string;
+  private readonly headers: Record<string, string>;
+  private readonly timeoutMs: number;
+  private readonly apiKey?: string;
+
+  constructor(options: ClientOptions = {}) {
+    super();
+    this.baseUrl = (options.baseUrl ?? "http://localhost:8080").replace(/\/+$/, "");
+    this.headers = options.headers ?? {};
+    this.timeoutMs = options.timeoutMs ?? 60_000;
+    this.apiKey = options.apiKey;
+    if (this.apiKey) {
+      this.headers = { ...this.headers, "X-API-Key": this.apiKey };
+    }
+  }
+
+  /** Create a session. */
+  async session(agentId: string, options: CreateSessionOptions = {}): Promise<Session> {
+    const created = await this.createSession({
+      agent_id: agentId,
+      session_name: options.name,
+      template: options.template,
+      options: options.options,
+    });
+    return new Session(this, created.session_id);
+  }
+
+  /** Resume the agent's session with this name, creating it if it doesn't exist. */
+  async resume(agentId: string, name: string): Promise<Session> {
+    const resumed = await this.resumeSession({ agent_id: agentId, session_name: name });
+    return new Session(this, resumed.session_id);
+  }
+
+  /** Wrap an existing session ID without calling the server. */
+  attach(sessionId: string): Session {
+    return new Session(this, sessionId);
+  }
+
+  protected async request<T>(method: string, path: string, body?: unknown, query: Query = {}): Promise<T> {
+    const text = await (await this.send(method, path, body, query, "application/json")).text();
+    // 204 No Content
+    return (text ? JSON.parse(text) : undefined) as T;
+  }
+
+  protected async requestBytes(method: string, path: string, body?: unknown, query: Query = {}): Promise<ArrayBuffer> {
+    return (await this.send(method, path, body, query, "*/*")).arrayBuffer();
+  }
+
+  private async send(method: string, path: string, body: unknown, query: Query, accept: string): Promise<Response> {
+    const headers: Record<string, string> = { Accept: accept, ...this.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+
+    const search = searchParams(query).toString();
+    const response = await fetch(this.baseUrl + path + (search ? `?${search}` : ""), {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+      signal: AbortSignal.timeout(this.timeoutMs),
+    });
+
+    if (!response.ok) {
+      throw apiError(response.status, response.statusText, await response.text());
+    }
+    return response;
+  }
+
+  protected stream<T>(path: string, query: Query): AsyncIterable<T> {
+    const params = searchParams(query);
+    // Browser WebSockets can't send headers, so the key goes in the URL
+    if (this.apiKey) {
+      params.set("api_key", this.apiKey);
+    }
+    const search = params.toString();
+    const url = this.baseUrl.replace(/^http/, "ws") + path + (search ? `?${search}` : "");
+    return streamMessages<T>(url);
+  }
+}
+
+/** A browser session: an isolated browser context with its own pages. */
+export class Session {
+  constructor(
+    readonly client: Client,
+    readonly id: string,
+  ) {}
+
+  /** Current details: name, pages, status and activity times. */
+  info(): Promise<GetSessionResponse> {
+    return this.client.getSession(this.id);
+  }
+
+  /** Open a new page at url. */
+  async navigate(url: string): Promise<Page> {
+    const navigated = await this.client.navigate(this.id, { url });
+    return new Page(this, navigated.page_id, navigated.url);
+  }
+
+  /** Wrap an existing page ID. */
+  page(pageId: string): Page {
+    return new Page(this, pageId);
+  }
+
+  /** The live pages, including ones the site opened itself. */
+  async pages(): Promise<Page[]> {
+    const listed = await this.client.listPages(this.id);
+    return listed.pages.map((tab) => new Page(this, tab.page_id, tab.url));
+  }
+
+  /**
+   * Session events as they happen, until the session ends. Pass the ID of the last
+   * event you handled as after to replay what you missed after a disconnect.
+   */
+  events(after?: number): AsyncIterable<SessionEvent> {
+    return this.client.streamEvents(this.id, { after });
+  }
+
+  async rename(name: string): Promise<void> {
+    await this.client.renameSession(this.id, { session_name: name });
+  }
+
+  /** Dispose of the pages but keep the session resumable by name. */
+  async close(): Promise<void> {
+    await this.client.closeSession(this.id);
+  }
+
+  /** Destroy the session and its browser context. */
+  destroy(): Promise<void> {
+    return this.client.destroySession(this.id);
+  }
+}
+
+/** A page (tab) in a session. */
+export class Page {
+  constructor(
+    readonly session: Session,
+    readonly id: string,
+    readonly url?: string,
+  ) {}
+
+  private get client(): Client {
+    return this.session.client;
+  }
+
+  /** Run script on the page and return its result. */
+  async execute<T = unknown>(script: string): Promise<T> {
+    const executed = await this.executeVerbose(script);
+    return executed.result as T;
+  }
+
+  /** Run script and return the full response, including page_change when verifying. */
+  executeVerbose(
+    script: string,
+    verify: { change?: boolean; screenshot?: boolean } = {},
+  ): Promise<ExecuteJSResponse> {
+    return this.client.executeJS(this.session.id, {
+      page_id: this.id,
+      script,
+      verify_change: verify.change,
+      verify_screenshot: verify.screenshot,
+    });
+  }
+
+  /** Run script and pass its result through a pipeline (default: the session's). */
+  extract(script: string, pipeline?: string): Promise<PipelineResult> {
+    return this.client.extract(this.session.id, { page_id: this.id, script, pipeline });
+  }
+
+  /**
+   * Ask the vision model about what the page looks like. With overlay, interactive
+   * elements are numbered on the screenshot and the ones the model picks come back in
+   * elements; points are clickable viewport coordinates either way.
+   */
+  ask(question: string, overlay = false): Promise<VisionQueryResponse> {
+    return this.client.visionQuery(this.session.id, this.id, { question, overlay: overlay || undefined });
+  }
+
+  /**
+   * Click at viewport coordinates, such as a point from ask(). With scroll_into_view,
+   * x and y are document coordinates and the page is scrolled to them first.
+   */
+  click(x: number, y: number, options: Omit<ClickRequest, "x" | "y"> = {}): Promise<ClickResponse> {
+    return this.client.click(this.session.id, this.id, { x, y, ...options });
+  }
+
+  /** Where the element matching selector is, and whether a click on it would land. */
+  elementBox(selector: string): Promise<ElementBoxResponse> {
+    return this.client.getElementBox(this.session.id, this.id, { selector });
+  }
+
+  /**
+   * Report changes to content matching selector as dom_changed session events. Read
+   * them from session.events(); each event's data names the watch_id.
+   */
+  watch(selector: string, options: Omit<WatchRequest, "selector"> = {}): Promise<WatchResponse> {
+    return this.client.watchDOM(this.session.id, this.id, { selector, ...options });
+  }
+
+  /** Stop a watch started with watch(). */
+  unwatch(watchId: string): Promise<void> {
+    return this.client.unwatch(this.session.id, this.id, watchId);
+  }
+
+  /**
+   * Wait until selector is attached, visible, hidden or detached. Check `satisfied`
+   * in the result: a timeout is reported, not thrown.
+   */
+  waitForSelector(
+    selector: string,
+    options: { state?: "attached" | "visible" | "hidden" | "detached"; timeout_ms?: number } = {},
+  ): Promise<WaitResponse> {
+    return this.client.wait(this.session.id, this.id, { selector, ...options });
+  }
+
+  /** Wait until the page URL matches a glob (*) or a /regular expression/. */
+  waitForURL(pattern: string, options: { timeout_ms?: number } = {}): Promise<WaitResponse> {
+    return this.client.wait(this.session.id, this.id, { url: pattern, ...options });
+  }
+
+  /**
+   * Wait until a JavaScript expression or function returns something truthy. The
+   * value it returned is in `value`.
+   */
+  waitForFunction(predicate: string, options: { timeout_ms?: number; polling_ms?: number } = {}): Promise<WaitResponse> {
+    return this.client.wait(this.session.id, this.id, { function: predicate, ...options });
+  }
+
+  /** Capture the page as PNG or JPEG bytes. */
+  async screenshot(format: "png" | "jpeg" = "png"): Promise<Uint8Array> {
+    const captured = await this.client.captureScreenshot(this.session.id, { page_id: this.id, format });
+    return Uint8Array.from(atob(captured.screenshot), (c) => c.charCodeAt(0));
+  }
+
+  /**
+   * Capture the page as PNG with its interactive elements numbered. The legend maps
+   * each number to the element's tag, text, selector and box.
+   */
+  async annotatedScreenshot(): Promise<{ image: Uint8Array; legend: ElementMark[] }> {
+    const captured = await this.client.captureScreenshot(this.session.id, { page_id: this.id, annotate: true });
+    return {
+      image: Uint8Array.from(atob(captured.screenshot), (c) => c.charCodeAt(0)),
+      legend: captured.legend ?? [],
+    };
+  }
+
+  /** The page's HTML. */
+  async content(): Promise<string> {
+    const page = await this.client.getPageContent(this.session.id, this.id);
+    return page.content;
+  }
+
+  /** A summary of the page's structure. */
+  async analyze(): Promise<PageStructure | null> {
+    const analyzed = await this.client.analyzePage(this.session.id, { page_id: this.id });
+    return analyzed.analysis;
+  }
+
+  async accessibilityTree(): Promise<(AXNode | null)[]> {
+    const tree = await this.client.getAccessibilityTree(this.session.id, { page_id: this.id });
+    return tree.nodes;
+  }
+
+  activate(): Promise<void> {
+    return this.client.activatePage(this.session.id, this.id);
+  }
+
+  /** Open a copy of the page at its current URL. */
+  async duplicate(): Promise<Page> {
+    const duplicated = await this.client.duplicatePage(this.session.id, this.id);
+    return new Page(this.session, duplicated.page_id, duplicated.url);
+  }
+
+  close(): Promise<void> {
+    return this.client.closePage(this.session.id, this.id);
+  }
+}
+
+/** Encode the set query parameters, skipping undefined ones. */
+function searchParams(query: Query): URLSearchParams {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value !== undefined) {
+      params.set(key, String(value));
+    }
+  }
+  return params;
+}
+
+/** Convert an error response into an APIError using the service's error body. */
+function apiError(status: number, statusText: string, body: string): APIError {
+  try {
+    const detail = JSON.parse(body).error;
+    if (detail?.code) {
+      return new APIError(status, detail.code, detail.message ?? "", detail.violations ?? []);
+    }
+  } catch {
+    // Not a JSON error body
+  }
+  return new APIError(status, "HTTP_ERROR", statusText);
+}
+
+/**
+ * Yield each JSON text frame of a WebSocket until it closes. Uses the global
+ * WebSocket (browsers, Node 22+, Deno, Bun).
+ */
+async function* streamMessages<T>(url: string): AsyncGenerator<T> {
+  const socket = new WebSocket(url);
+  const queue: T[] = [];
+  let closed = false;
+  let failure: Error | undefined;
+  let wake: (() => void) | undefined;
+
+  const notify = () => {
+    wake?.();
+    wake = undefined;
+  };
+  socket.onmessage = (message) => {
+    queue.push(JSON.parse(String(message.data)) as T);
+    notify();
+  };
+  socket.onerror = () => {
+    failure = new APIError(0, "STREAM_FAILED", `event stream ${url} failed`);
+    notify();
+  };
+  socket.onclose = () => {
+    closed = true;
+    notify();
+  };
+
+  try {
+    while (true) {
+      if (queue.length > 0) {
+        yield queue.shift()!;
+        continue;
+      }
+      if (failure) {
+        throw failure;
+      }
+      if (closed) {
+        return;
+      }
+      await new Promise<void>((resolve) => (wake = resolve));
+    }
+  } finally {
+    // Breaking out of a for await loop lands here too
+    socket.close();
+  }
+}
@@ -0,0 +1,67 @@
+// Command clientgen generates the Python and TypeScript client SDKs from the API
+// endpoint table (internal/api/endpoints.go). It writes only the generated layer;
+// the session/page abstractions in clients/ are hand-written on top of it.
+//
+// Run it with go generate ./internal/api, or directly:
+//
+//	go run ./cmd/clientgen -out clients
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+)
+
+// Generated files, relative to the output directory
+const (
+	pythonFile     = "python/browser_query_ai/_generated.py"
+	typescriptFile = "typescript/src/generated.ts"
+)
+
+func main() {
+	out := flag.String("out", "clients", "Directory containing the python/ and typescript/ clients")
+	flag.Parse()
+
+	files, err := generate(api.Endpoints)
+	if err != nil {
+		slog.Error("failed to generate clients", "error", err)
+		os.Exit(1)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(*out, name)
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			slog.Error("failed to write client", "path", path, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("client generated", "path", path)
+	}
+}
+
+// generate renders every generated file, keyed by its path under the output directory
+func generate(endpoints []api.Endpoint) (map[string][]byte, error) {
+	schema, err := buildSchema(endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	python, err := renderPython(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render Python client: %w", err)
+	}
+
+	typescript, err := renderTypeScript(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render TypeScript client: %w", err)
+	}
+
+	return map[string][]byte{
+		pythonFile:     python,
+		typescriptFile: typescript,
+	}, nil
+}
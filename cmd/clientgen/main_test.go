@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+)
+
+// TestGeneratedClientsUpToDate fails when the committed clients no longer match the
+// endpoint table; run go generate ./internal/api to refresh them.
+func TestGeneratedClientsUpToDate(t *testing.T) {
+	files, err := generate(api.Endpoints)
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join("..", "..", "clients", name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run go generate ./internal/api", name)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"ExecuteJS":            "execute_js",
+		"GetAccessibilityTree": "get_accessibility_tree",
+		"sessionId":            "session_id",
+		"pageId":               "page_id",
+		"CDPClient":            "cdp_client",
+	}
+	for in, want := range cases {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestBinaryEndpoint tests that endpoints answering with raw bytes get byte-returning
+// methods in both clients
+func TestBinaryEndpoint(t *testing.T) {
+	files, err := generate([]api.Endpoint{
+		{Name: "PrintPDF", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/pdf", Doc: "Print a page to PDF", Binary: true},
+	})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"python/browser_query_ai/_generated.py": `def print_pdf(self, session_id: str, page_id: str) -> bytes:
+        """Print a page to PDF"""
+        return self._request_bytes("POST", f"/sessions/{quote(session_id, safe='')}/pages/{quote(page_id, safe='')}/pdf")`,
+		"typescript/src/generated.ts": "printPDF(sessionId: string, pageId: string): Promise<ArrayBuffer> {\n    return this.requestBytes(\"POST\", `/sessions/${encodeURIComponent(sessionId)}/pages/${encodeURIComponent(pageId)}/pdf`);",
+	} {
+		if !bytes.Contains(files[name], []byte(want)) {
+			t.Errorf("%s: expected\n%s\nin\n%s", name, want, files[name])
+		}
+	}
+}
+
+// TestPythonKeywordMethod tests that endpoints named after a Python keyword still get a
+// method Python can parse
+func TestPythonKeywordMethod(t *testing.T) {
+	files, err := generate([]api.Endpoint{{Name: "Assert", Method: "POST", Path: "/sessions/{id}/assert", Doc: "Check a page"}})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if python := files["python/browser_query_ai/_generated.py"]; !bytes.Contains(python, []byte("def assert_(self, session_id: str) -> None:")) {
+		t.Errorf("expected an assert_ method, got\n%s", python)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+)
+
+// pythonKeywords can't be TypedDict class attributes; objects using them fall back
+// to the functional TypedDict syntax
+var pythonKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class",
+	"continue", "def", "del", "elif", "else", "except", "finally", "for", "from", "global",
+	"if", "import", "in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise",
+	"return", "try", "while", "with", "yield",
+}
+
+// renderPython renders browser_query_ai/_generated.py
+func renderPython(s *schema) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("# Code generated by cmd/clientgen from internal/api/endpoints.go. DO NOT EDIT.\n\n")
+	b.WriteString("from __future__ import annotations\n\n")
+	b.WriteString("from typing import Any, Iterator, NotRequired, TypedDict\n")
+	b.WriteString("from urllib.parse import quote\n")
+
+	for _, obj := range s.types {
+		b.WriteString("\n\n")
+		writePythonObject(&b, obj)
+	}
+
+	b.WriteString(`
+
+class GeneratedClient:
+    """One method per endpoint. Subclasses implement the transport."""
+
+    def _request(self, method: str, path: str, body: Any = None, query: dict[str, Any] | None = None) -> Any:
+        raise NotImplementedError
+
+    def _request_bytes(self, method: str, path: str, body: Any = None, query: dict[str, Any] | None = None) -> bytes:
+        raise NotImplementedError
+
+    def _stream(self, path: str, query: dict[str, Any]) -> Iterator[Any]:
+        raise NotImplementedError
+`)
+
+	for _, endpoint := range s.endpoints {
+		b.WriteString("\n")
+		if err := writePythonMethod(&b, endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// writePythonObject renders a struct as a TypedDict
+func writePythonObject(b *bytes.Buffer, obj *object) {
+	functional := slices.ContainsFunc(obj.fields, func(f field) bool {
+		return slices.Contains(pythonKeywords, f.name) || !isIdentifier(f.name)
+	})
+
+	if functional {
+		fmt.Fprintf(b, "%s = TypedDict(%q, {\n", obj.name, obj.name)
+		for _, f := range obj.fields {
+			fmt.Fprintf(b, "    %q: %q,\n", f.name, pythonFieldType(f)) // Evaluated eagerly, so forward references are quoted
+		}
+		b.WriteString("})\n")
+		return
+	}
+
+	fmt.Fprintf(b, "class %s(TypedDict):\n", obj.name)
+	if len(obj.fields) == 0 {
+		b.WriteString("    pass\n")
+		return
+	}
+	for _, f := range obj.fields {
+		fmt.Fprintf(b, "    %s: %s\n", f.name, pythonFieldType(f))
+	}
+}
+
+// pythonFieldType is the annotation of a TypedDict property
+func pythonFieldType(f field) string {
+	annotation := pythonType(f.goType)
+	if f.optional {
+		return "NotRequired[" + annotation + "]"
+	}
+	return annotation
+}
+
+// pythonType maps a Go type to a Python annotation
+func pythonType(t reflect.Type) string {
+	if isOpaque(t) {
+		if t == rawType {
+			return "Any"
+		}
+		return "str"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return pythonType(t.Elem()) + " | None"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "str"
+	case reflect.Slice, reflect.Array:
+		return "list[" + pythonType(t.Elem()) + "]"
+	case reflect.Map:
+		return "dict[str, " + pythonType(t.Elem()) + "]"
+	case reflect.Struct:
+		return typeName(t)
+	}
+	return "Any"
+}
+
+// writePythonMethod renders the GeneratedClient method for an endpoint
+func writePythonMethod(b *bytes.Buffer, endpoint api.Endpoint) error {
+	if endpoint.Method == "" || endpoint.Path == "" {
+		return fmt.Errorf("endpoint %s has no method or path", endpoint.Name)
+	}
+
+	params := []string{"self"}
+	path := endpoint.Path
+	formatted := false
+	for _, param := range pathParams(endpoint.Path) {
+		name := snakeCase(paramName(param))
+		params = append(params, name+": str")
+		path = strings.Replace(path, "{"+param+"}", "{quote("+name+", safe='')}", 1)
+		formatted = true
+	}
+	if endpoint.Request != nil {
+		params = append(params, "body: "+pythonType(endpoint.Request))
+	}
+	for _, query := range endpoint.Query {
+		params = append(params, snakeCase(query)+": str | int | None = None")
+	}
+
+	pathLiteral := fmt.Sprintf("%q", path)
+	if formatted {
+		pathLiteral = "f" + pathLiteral
+	}
+
+	query := make([]string, 0, len(endpoint.Query))
+	for _, q := range endpoint.Query {
+		query = append(query, fmt.Sprintf("%q: %s", q, snakeCase(q)))
+	}
+
+	name := snakeCase(endpoint.Name)
+	if slices.Contains(pythonKeywords, name) {
+		name += "_" // assert → assert_, as PEP 8 suggests
+	}
+	switch {
+	case endpoint.Stream != nil:
+		fmt.Fprintf(b, "    def %s(%s) -> Iterator[%s]:\n", name, strings.Join(params, ", "), pythonType(endpoint.Stream))
+		fmt.Fprintf(b, "        \"\"\"%s\"\"\"\n", endpoint.Doc)
+		fmt.Fprintf(b, "        return self._stream(%s, {%s})\n", pathLiteral, strings.Join(query, ", "))
+
+	default:
+		returns, call := "None", "_request"
+		if endpoint.Response != nil {
+			returns = pythonType(endpoint.Response)
+		}
+		if endpoint.Binary {
+			returns, call = "bytes", "_request_bytes"
+		}
+		args := fmt.Sprintf("%q, %s", endpoint.Method, pathLiteral)
+		if endpoint.Request != nil {
+			args += ", body"
+		}
+		if len(query) > 0 {
+			args += ", query={" + strings.Join(query, ", ") + "}"
+		}
+		fmt.Fprintf(b, "    def %s(%s) -> %s:\n", name, strings.Join(params, ", "), returns)
+		fmt.Fprintf(b, "        \"\"\"%s\"\"\"\n", endpoint.Doc)
+		fmt.Fprintf(b, "        return self.%s(%s)\n", call, args)
+	}
+	return nil
+}
+
+// isIdentifier reports whether name is a valid Python and TypeScript identifier
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		digit := r >= '0' && r <= '9'
+		if !letter && !(digit && i > 0) {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+)
+
+// renames gives a generated type a different name than its Go type
+var renames = map[string]string{
+	"events.Event":    "SessionEvent",   // "Event" would shadow the DOM type in TypeScript
+	"pipeline.Result": "PipelineResult", // Too generic on its own
+	"vision.Usage":    "VisionUsage",
+	"session.Box":     "ElementBox",
+}
+
+// schema is everything the renderers need: the endpoints and the named types they reference
+type schema struct {
+	endpoints []api.Endpoint
+	types     []*object // In first-reference order, so output is stable
+}
+
+// object is a Go struct rendered as a named type
+type object struct {
+	name   string
+	goType reflect.Type
+	fields []field
+}
+
+// field is one JSON property of an object
+type field struct {
+	name     string       // JSON name
+	goType   reflect.Type // Pointers are kept so renderers can mark the field nullable
+	optional bool         // omitempty: may be absent from the JSON
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	rawType       = reflect.TypeFor[json.RawMessage]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+	pathParam     = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+// buildSchema collects the named types reachable from the endpoints' bodies
+func buildSchema(endpoints []api.Endpoint) (*schema, error) {
+	builder := &schemaBuilder{byType: make(map[reflect.Type]*object), byName: make(map[string]reflect.Type)}
+
+	for _, endpoint := range endpoints {
+		for _, t := range []reflect.Type{endpoint.Request, endpoint.Response, endpoint.Stream} {
+			if t == nil {
+				continue
+			}
+			if err := builder.collect(t); err != nil {
+				return nil, fmt.Errorf("endpoint %s: %w", endpoint.Name, err)
+			}
+		}
+	}
+
+	return &schema{endpoints: endpoints, types: builder.ordered}, nil
+}
+
+// schemaBuilder walks Go types and records the structs it finds
+type schemaBuilder struct {
+	byType  map[reflect.Type]*object
+	byName  map[string]reflect.Type
+	ordered []*object
+}
+
+// collect records t and every struct it references
+func (b *schemaBuilder) collect(t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		if isOpaque(t) {
+			return nil
+		}
+		return b.collect(t.Elem())
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	if isOpaque(t) {
+		return nil
+	}
+	if _, seen := b.byType[t]; seen {
+		return nil
+	}
+	if t.Name() == "" {
+		return fmt.Errorf("anonymous struct types are not supported")
+	}
+
+	name := typeName(t)
+	if other, taken := b.byName[name]; taken {
+		return fmt.Errorf("types %s and %s would both be named %s", other, t, name)
+	}
+
+	obj := &object{name: name, goType: t}
+	b.byType[t] = obj
+	b.byName[name] = t
+	b.ordered = append(b.ordered, obj)
+
+	obj.fields = jsonFields(t)
+	for _, f := range obj.fields {
+		if err := b.collect(f.goType); err != nil {
+			return fmt.Errorf("%s.%s: %w", name, f.name, err)
+		}
+	}
+	return nil
+}
+
+// typeName is the generated name of a named Go type
+func typeName(t reflect.Type) string {
+	qualified := t.String()
+	if renamed, ok := renames[qualified]; ok {
+		return renamed
+	}
+	return t.Name()
+}
+
+// isOpaque reports whether t has no structure worth describing to a client, either
+// because it encodes as a plain string (time.Time, []byte) or marshals itself.
+func isOpaque(t reflect.Type) bool {
+	if t == timeType || t == rawType {
+		return true
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return true
+	}
+	return t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)
+}
+
+// jsonFields lists the properties encoding/json would write for struct t
+func jsonFields(t reflect.Type) []field {
+	fields := make([]field, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened into the parent
+		if sf.Anonymous && name == "" {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{
+			name:     name,
+			goType:   sf.Type,
+			optional: strings.Contains(options, "omitempty") || strings.Contains(options, "omitzero"),
+		})
+	}
+
+	return fields
+}
+
+// pathParams returns the parameter names of a route pattern in order
+func pathParams(path string) []string {
+	matches := pathParam.FindAllStringSubmatch(path, -1)
+	params := make([]string, 0, len(matches))
+	for _, match := range matches {
+		params = append(params, match[1])
+	}
+	return params
+}
+
+// paramName is the client-side name of a path parameter. Every generated route is
+// under /sessions/{id}, so "id" is always a session ID.
+func paramName(param string) string {
+	if param == "id" {
+		return "sessionId"
+	}
+	return param
+}
+
+// snakeCase converts a Go or camelCase name to snake_case ("ExecuteJS" → "execute_js")
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			prevUpper := runes[i-1] >= 'A' && runes[i-1] <= 'Z'
+			if prevLower || (prevUpper && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		if upper {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// camelCase lowercases the leading word of a Go name ("ExecuteJS" → "executeJS")
+func camelCase(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
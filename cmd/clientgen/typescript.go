@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+)
+
+// renderTypeScript renders src/generated.ts
+func renderTypeScript(s *schema) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("// Code generated by cmd/clientgen from internal/api/endpoints.go. DO NOT EDIT.\n")
+
+	for _, obj := range s.types {
+		b.WriteString("\n")
+		writeTypeScriptObject(&b, obj)
+	}
+
+	b.WriteString(`
+export type Query = Record<string, string | number | undefined>;
+
+/** One method per endpoint. Subclasses implement the transport. */
+export abstract class GeneratedClient {
+  protected abstract request<T>(method: string, path: string, body?: unknown, query?: Query): Promise<T>;
+  protected abstract requestBytes(method: string, path: string, body?: unknown, query?: Query): Promise<ArrayBuffer>;
+  protected abstract stream<T>(path: string, query: Query): AsyncIterable<T>;
+`)
+
+	for _, endpoint := range s.endpoints {
+		b.WriteString("\n")
+		if err := writeTypeScriptMethod(&b, endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+// writeTypeScriptObject renders a struct as an interface
+func writeTypeScriptObject(b *bytes.Buffer, obj *object) {
+	fmt.Fprintf(b, "export interface %s {\n", obj.name)
+	for _, f := range obj.fields {
+		name := f.name
+		if !isIdentifier(name) {
+			name = fmt.Sprintf("%q", name)
+		}
+		if f.optional {
+			name += "?"
+		}
+		fmt.Fprintf(b, "  %s: %s;\n", name, typeScriptType(f.goType))
+	}
+	b.WriteString("}\n")
+}
+
+// typeScriptType maps a Go type to a TypeScript type
+func typeScriptType(t reflect.Type) string {
+	if isOpaque(t) {
+		if t == rawType {
+			return "unknown"
+		}
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeScriptType(t.Elem()) + " | null"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		elem := typeScriptType(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return "Record<string, " + typeScriptType(t.Elem()) + ">"
+	case reflect.Struct:
+		return typeName(t)
+	}
+	return "unknown"
+}
+
+// writeTypeScriptMethod renders the GeneratedClient method for an endpoint
+func writeTypeScriptMethod(b *bytes.Buffer, endpoint api.Endpoint) error {
+	if endpoint.Method == "" || endpoint.Path == "" {
+		return fmt.Errorf("endpoint %s has no method or path", endpoint.Name)
+	}
+
+	params := make([]string, 0)
+	path := endpoint.Path
+	for _, param := range pathParams(endpoint.Path) {
+		name := paramName(param)
+		params = append(params, name+": string")
+		path = strings.Replace(path, "{"+param+"}", "${encodeURIComponent("+name+")}", 1)
+	}
+	if endpoint.Request != nil {
+		params = append(params, "body: "+typeScriptType(endpoint.Request))
+	}
+	if len(endpoint.Query) > 0 {
+		fields := make([]string, 0, len(endpoint.Query))
+		for _, q := range endpoint.Query {
+			fields = append(fields, q+"?: string | number") // Wire names, passed through as-is
+		}
+		params = append(params, "query: { "+strings.Join(fields, "; ")+" } = {}")
+	}
+
+	name := camelCase(endpoint.Name)
+	fmt.Fprintf(b, "  /** %s */\n", endpoint.Doc)
+
+	if endpoint.Stream != nil {
+		fmt.Fprintf(b, "  %s(%s): AsyncIterable<%s> {\n", name, strings.Join(params, ", "), typeScriptType(endpoint.Stream))
+		fmt.Fprintf(b, "    return this.stream(`%s`, query);\n", path)
+		b.WriteString("  }\n")
+		return nil
+	}
+
+	returns, call := "void", "request"
+	if endpoint.Response != nil {
+		returns = typeScriptType(endpoint.Response)
+	}
+	if endpoint.Binary {
+		returns, call = "ArrayBuffer", "requestBytes"
+	}
+	args := fmt.Sprintf("%q, `%s`", endpoint.Method, path)
+	if endpoint.Request != nil {
+		args += ", body"
+	}
+	if len(endpoint.Query) > 0 {
+		if endpoint.Request == nil {
+			args += ", undefined"
+		}
+		args += ", query"
+	}
+	fmt.Fprintf(b, "  %s(%s): Promise<%s> {\n", name, strings.Join(params, ", "), returns)
+	fmt.Fprintf(b, "    return this.%s(%s);\n", call, args)
+	b.WriteString("  }\n")
+	return nil
+}
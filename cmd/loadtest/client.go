@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+)
+
+// apiError is a request the server answered with an error status
+type apiError struct {
+	Status  int
+	Code    string // Machine-readable code from the error body, when there is one
+	Message string
+}
+
+func (e *apiError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("HTTP %d", e.Status)
+	}
+	return fmt.Sprintf("HTTP %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// client calls the server's session API
+type client struct {
+	base   string // Server URL, without a trailing slash
+	apiKey string
+	http   *http.Client
+}
+
+func newClient(base, apiKey string, httpClient *http.Client) *client {
+	return &client{base: strings.TrimRight(base, "/"), apiKey: apiKey, http: httpClient}
+}
+
+// createSession creates a session for agentID and returns its ID
+func (c *client) createSession(ctx context.Context, agentID string) (string, error) {
+	var response api.CreateSessionResponse
+	if err := c.do(ctx, http.MethodPost, "/sessions", api.CreateSessionRequest{AgentID: agentID}, "", &response); err != nil {
+		return "", err
+	}
+	return response.SessionID, nil
+}
+
+// navigate opens target in a new page of the session and returns the page's ID
+func (c *client) navigate(ctx context.Context, sessionID, target string) (string, error) {
+	var response api.NavigateResponse
+	if err := c.do(ctx, http.MethodPost, sessionPath(sessionID)+"/navigate", api.NavigateRequest{URL: target}, "", &response); err != nil {
+		return "", err
+	}
+	return response.PageID, nil
+}
+
+// extract reads the page's content
+func (c *client) extract(ctx context.Context, sessionID, pageID string) error {
+	return c.do(ctx, http.MethodGet, pagePath(sessionID, pageID)+"/content", nil, "", nil)
+}
+
+// screenshot captures the page as a PNG
+func (c *client) screenshot(ctx context.Context, sessionID, pageID string) error {
+	return c.do(ctx, http.MethodPost, sessionPath(sessionID)+"/screenshot", api.ScreenshotRequest{PageID: pageID}, "image/png", nil)
+}
+
+// closePage closes a page of the session
+func (c *client) closePage(ctx context.Context, sessionID, pageID string) error {
+	return c.do(ctx, http.MethodDelete, pagePath(sessionID, pageID), nil, "", nil)
+}
+
+// destroySession deletes the session and its pages
+func (c *client) destroySession(ctx context.Context, sessionID string) error {
+	return c.do(ctx, http.MethodDelete, sessionPath(sessionID), nil, "", nil)
+}
+
+// do sends a request and decodes a successful JSON response into out. Other responses
+// are read to the end and discarded, so each operation is timed until its last byte.
+func (c *client) do(ctx context.Context, method, path string, body interface{}, accept string, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	if c.apiKey != "" {
+		request.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		failure := &apiError{Status: response.StatusCode}
+		var errorBody api.ErrorResponse
+		if json.NewDecoder(response.Body).Decode(&errorBody) == nil {
+			failure.Code = errorBody.Error.Code
+			failure.Message = errorBody.Error.Message
+		}
+		io.Copy(io.Discard, response.Body)
+		return failure
+	}
+	if out != nil {
+		if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	_, err = io.Copy(io.Discard, response.Body)
+	return err
+}
+
+func sessionPath(sessionID string) string {
+	return "/sessions/" + url.PathEscape(sessionID)
+}
+
+func pagePath(sessionID, pageID string) string {
+	return sessionPath(sessionID) + "/pages/" + url.PathEscape(pageID)
+}
@@ -0,0 +1,181 @@
+// Command loadtest drives a running server with concurrent synthetic sessions. Each
+// session loops over navigate, extract (the page's content) and screenshot, closing the
+// page after each loop, and the run reports latency percentiles and error rates per
+// operation. Use it to size browser pools and catch regressions before a deploy:
+//
+//	go run ./cmd/loadtest -server http://localhost:8080 -sessions 20 -duration 2m
+//
+// It exits with status 1 when the overall error rate is above -max-error-rate.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// failurePause is how long a session waits after a failed navigation before looping again
+const failurePause = time.Second
+
+// options configure a load test
+type options struct {
+	sessions   int
+	duration   time.Duration // How long sessions keep looping (0: until they ran iterations)
+	iterations int           // Loops per session (0: until duration is up)
+	ramp       time.Duration // Sessions start evenly spread over this long
+	target     string        // URL every session navigates to
+	screenshot bool
+}
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "URL of the running server")
+	apiKey := flag.String("api-key", os.Getenv("LOADTEST_API_KEY"), "API key sent in X-API-Key (default $LOADTEST_API_KEY)")
+	sessions := flag.Int("sessions", 10, "Concurrent synthetic sessions")
+	duration := flag.Duration("duration", time.Minute, "How long sessions keep looping (0: until they ran -iterations)")
+	iterations := flag.Int("iterations", 0, "Loops per session (0: until -duration is up)")
+	ramp := flag.Duration("ramp", 0, "Spread session starts evenly over this long")
+	target := flag.String("page", "https://example.com", "URL every session navigates to")
+	screenshot := flag.Bool("screenshot", true, "Capture a screenshot in each loop")
+	timeout := flag.Duration("timeout", 2*time.Minute, "Timeout of each request")
+	jsonOutput := flag.Bool("json", false, "Write the report as JSON")
+	maxErrorRate := flag.Float64("max-error-rate", 1, "Exit with status 1 when the overall error rate is above this fraction")
+	flag.Parse()
+
+	opts := options{
+		sessions:   *sessions,
+		duration:   *duration,
+		iterations: *iterations,
+		ramp:       *ramp,
+		target:     *target,
+		screenshot: *screenshot,
+	}
+	if err := opts.validate(); err != nil {
+		slog.Error("invalid options", "error", err)
+		os.Exit(2)
+	}
+
+	// Ctrl-C stops the run; sessions still get destroyed on the way out
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("load test starting", "server", *server, "sessions", opts.sessions, "duration", opts.duration, "iterations", opts.iterations, "page", opts.target)
+	report := run(ctx, newClient(*server, *apiKey, &http.Client{Timeout: *timeout}), opts)
+
+	var err error
+	if *jsonOutput {
+		err = report.writeJSON(os.Stdout)
+	} else {
+		err = report.writeText(os.Stdout)
+	}
+	if err != nil {
+		slog.Error("failed to write report", "error", err)
+		os.Exit(1)
+	}
+	if report.ErrorRate > *maxErrorRate {
+		slog.Error("error rate above the limit", "error_rate", report.ErrorRate, "max_error_rate", *maxErrorRate)
+		os.Exit(1)
+	}
+}
+
+func (o options) validate() error {
+	switch {
+	case o.sessions < 1:
+		return errors.New("-sessions must be at least 1")
+	case o.duration < 0 || o.iterations < 0 || o.ramp < 0:
+		return errors.New("-duration, -iterations and -ramp must not be negative")
+	case o.duration == 0 && o.iterations == 0:
+		return errors.New("set -duration, -iterations or both")
+	case o.target == "":
+		return errors.New("-page is required")
+	}
+	return nil
+}
+
+// run starts the sessions, waits for all of them to finish and reports what they saw.
+// Canceling ctx stops the loops early.
+func run(ctx context.Context, c *client, opts options) *Report {
+	recorder := newRecorder()
+	var deadline time.Time
+	if opts.duration > 0 {
+		deadline = time.Now().Add(opts.duration)
+	}
+
+	start := time.Now()
+	var iterations atomic.Int64
+	var wg sync.WaitGroup
+	for i := range opts.sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if opts.ramp > 0 && i > 0 {
+				select {
+				case <-time.After(opts.ramp * time.Duration(i) / time.Duration(opts.sessions)):
+				case <-ctx.Done():
+					return
+				}
+			}
+			iterations.Add(int64(runSession(ctx, c, recorder, opts, fmt.Sprintf("loadtest-%d", i), deadline)))
+		}()
+	}
+	wg.Wait()
+
+	return recorder.report(opts.sessions, int(iterations.Load()), time.Since(start))
+}
+
+// runSession creates a session for agentID, loops until the deadline or its iterations
+// are done, and destroys it. It returns the loops it ran.
+func runSession(ctx context.Context, c *client, recorder *recorder, opts options, agentID string, deadline time.Time) int {
+	timed := func(op string, call func() error) error {
+		began := time.Now()
+		err := call()
+		// Requests cut short by an interrupt say nothing about the server
+		if !errors.Is(err, context.Canceled) {
+			recorder.record(op, time.Since(began), err)
+		}
+		return err
+	}
+
+	var sessionID string
+	if err := timed(opCreate, func() (err error) {
+		sessionID, err = c.createSession(ctx, agentID)
+		return err
+	}); err != nil {
+		return 0
+	}
+	defer timed(opDestroy, func() error {
+		return c.destroySession(context.WithoutCancel(ctx), sessionID)
+	})
+
+	loops := 0
+	for ctx.Err() == nil && (opts.iterations == 0 || loops < opts.iterations) && (deadline.IsZero() || time.Now().Before(deadline)) {
+		loops++
+
+		var pageID string
+		if err := timed(opNavigate, func() (err error) {
+			pageID, err = c.navigate(ctx, sessionID, opts.target)
+			return err
+		}); err != nil {
+			// Don't hammer a server turning navigations away
+			select {
+			case <-time.After(failurePause):
+			case <-ctx.Done():
+			}
+			continue
+		}
+		timed(opExtract, func() error { return c.extract(ctx, sessionID, pageID) })
+		if opts.screenshot {
+			timed(opScreenshot, func() error { return c.screenshot(ctx, sessionID, pageID) })
+		}
+		timed(opClose, func() error { return c.closePage(context.WithoutCancel(ctx), sessionID, pageID) })
+	}
+	return loops
+}
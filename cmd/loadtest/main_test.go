@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRun tests the loops sessions run against a fake server, the requests counted per
+// operation and the failures grouped by error code, and that every session is destroyed
+func TestRun(t *testing.T) {
+	var mu sync.Mutex
+	sessions := map[string]bool{}
+	var created, screenshots atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id := "sess_" + string(rune('a'+created.Add(1)))
+		mu.Lock()
+		sessions[id] = true
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"session_id": id})
+	})
+	mux.HandleFunc("POST /sessions/{id}/navigate", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"page_id": "page-1"})
+	})
+	mux.HandleFunc("GET /sessions/{id}/pages/{pageId}/content", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"content": "<html></html>"})
+	})
+	mux.HandleFunc("POST /sessions/{id}/screenshot", func(w http.ResponseWriter, r *http.Request) {
+		// Every other screenshot fails as if the browser were busy
+		if screenshots.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"code": "AT_CAPACITY", "message": "busy"}})
+			return
+		}
+		if r.Header.Get("Accept") != "image/png" {
+			t.Errorf("expected the raw image asked for, got Accept %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	mux.HandleFunc("DELETE /sessions/{id}/pages/{pageId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /sessions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		delete(sessions, r.PathValue("id"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	opts := options{sessions: 3, iterations: 4, target: "https://example.com", screenshot: true}
+	report := run(context.Background(), newClient(server.URL+"/", "key", server.Client()), opts)
+
+	if report.Iterations != 12 || report.Requests != 3+12*4+3 || report.Errors != 6 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	want := map[string]int{opCreate: 3, opNavigate: 12, opExtract: 12, opScreenshot: 12, opClose: 12, opDestroy: 3}
+	for _, op := range report.Operations {
+		if op.Requests != want[op.Operation] {
+			t.Errorf("expected %d %s requests, got %d", want[op.Operation], op.Operation, op.Requests)
+		}
+		if op.Operation == opScreenshot {
+			if op.ErrorsBy["503 AT_CAPACITY"] != 6 || op.ErrorRate != 0.5 {
+				t.Errorf("expected half the screenshots failed with AT_CAPACITY, got %+v", op)
+			}
+		} else if op.Errors != 0 {
+			t.Errorf("expected no %s errors, got %+v", op.Operation, op.ErrorsBy)
+		}
+	}
+	if len(sessions) != 0 {
+		t.Errorf("expected every session destroyed, %d left", len(sessions))
+	}
+
+	var text strings.Builder
+	if err := report.writeText(&text); err != nil {
+		t.Fatalf("writeText failed: %v", err)
+	}
+	if !strings.Contains(text.String(), "screenshot failed with 503 AT_CAPACITY: 6") {
+		t.Errorf("expected the failures listed, got:\n%s", text.String())
+	}
+
+	// Sessions that can't be created run no loops
+	report = run(context.Background(), newClient(server.URL, "wrong", server.Client()), opts)
+	if report.Iterations != 0 || report.Requests != 3 || report.Operations[0].ErrorsBy["401"] != 3 {
+		t.Errorf("expected only failed creates, got %+v", report)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	cases := map[float64]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond}
+	for p, want := range cases {
+		if got := percentile(sorted, p); got != want {
+			t.Errorf("percentile(%v) = %v, want %v", p, got, want)
+		}
+	}
+	if got := percentile(sorted[:1], 99); got != time.Millisecond {
+		t.Errorf("expected the only sample, got %v", got)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Operations, in the order a session performs them
+const (
+	opCreate     = "create"
+	opNavigate   = "navigate"
+	opExtract    = "extract"
+	opScreenshot = "screenshot"
+	opClose      = "close_page"
+	opDestroy    = "destroy"
+)
+
+var operations = []string{opCreate, opNavigate, opExtract, opScreenshot, opClose, opDestroy}
+
+// recorder collects the latency and outcome of every request the sessions send
+type recorder struct {
+	mu  sync.Mutex
+	ops map[string]*opSamples
+}
+
+// opSamples are what the recorder saw of one operation
+type opSamples struct {
+	latencies []time.Duration
+	errors    map[string]int // Error class → requests that failed with it
+}
+
+func newRecorder() *recorder {
+	return &recorder{ops: make(map[string]*opSamples)}
+}
+
+// record counts a request of op that took took, failed when err is not nil
+func (r *recorder) record(op string, took time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	samples := r.ops[op]
+	if samples == nil {
+		samples = &opSamples{errors: make(map[string]int)}
+		r.ops[op] = samples
+	}
+	samples.latencies = append(samples.latencies, took)
+	if err != nil {
+		samples.errors[errorClass(err)]++
+	}
+}
+
+// errorClass groups failures for the report: the status and error code the server
+// answered with, or how the request itself failed
+func errorClass(err error) string {
+	var failure *apiError
+	if errors.As(err, &failure) {
+		if failure.Code == "" {
+			return strconv.Itoa(failure.Status)
+		}
+		return strconv.Itoa(failure.Status) + " " + failure.Code
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	return "transport"
+}
+
+// OpReport summarizes one operation's requests. Latencies cover failed requests too.
+type OpReport struct {
+	Operation  string         `json:"operation"`
+	Requests   int            `json:"requests"`
+	Errors     int            `json:"errors"`
+	ErrorRate  float64        `json:"error_rate"` // 0 to 1
+	RatePerSec float64        `json:"rate_per_sec"`
+	P50Ms      float64        `json:"p50_ms"`
+	P90Ms      float64        `json:"p90_ms"`
+	P99Ms      float64        `json:"p99_ms"`
+	MaxMs      float64        `json:"max_ms"`
+	ErrorsBy   map[string]int `json:"errors_by,omitempty"` // Error class → count
+}
+
+// Report is the outcome of a load test
+type Report struct {
+	Sessions   int        `json:"sessions"`
+	Iterations int        `json:"iterations"` // Loops the sessions ran, together
+	ElapsedMs  float64    `json:"elapsed_ms"`
+	Requests   int        `json:"requests"`
+	Errors     int        `json:"errors"`
+	ErrorRate  float64    `json:"error_rate"`
+	Operations []OpReport `json:"operations"`
+}
+
+// report summarizes what the recorder saw over elapsed
+func (r *recorder) report(sessions, iterations int, elapsed time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{Sessions: sessions, Iterations: iterations, ElapsedMs: milliseconds(elapsed), Operations: []OpReport{}}
+	for _, op := range operations {
+		samples := r.ops[op]
+		if samples == nil {
+			continue
+		}
+		sorted := append([]time.Duration(nil), samples.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		summary := OpReport{
+			Operation: op,
+			Requests:  len(sorted),
+			P50Ms:     milliseconds(percentile(sorted, 50)),
+			P90Ms:     milliseconds(percentile(sorted, 90)),
+			P99Ms:     milliseconds(percentile(sorted, 99)),
+			MaxMs:     milliseconds(sorted[len(sorted)-1]),
+		}
+		for class, count := range samples.errors {
+			summary.Errors += count
+			if summary.ErrorsBy == nil {
+				summary.ErrorsBy = make(map[string]int)
+			}
+			summary.ErrorsBy[class] = count
+		}
+		summary.ErrorRate = float64(summary.Errors) / float64(summary.Requests)
+		if elapsed > 0 {
+			summary.RatePerSec = float64(summary.Requests) / elapsed.Seconds()
+		}
+
+		report.Requests += summary.Requests
+		report.Errors += summary.Errors
+		report.Operations = append(report.Operations, summary)
+	}
+	if report.Requests > 0 {
+		report.ErrorRate = float64(report.Errors) / float64(report.Requests)
+	}
+	return report
+}
+
+// percentile returns the nearest-rank pth percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// writeJSON writes the report as indented JSON
+func (r *Report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// writeText writes the report as a table, with a line per error class under it
+func (r *Report) writeText(w io.Writer) error {
+	fmt.Fprintf(w, "%d sessions, %d iterations in %.1fs: %d requests, %d errors (%.2f%%)\n\n",
+		r.Sessions, r.Iterations, r.ElapsedMs/1000, r.Requests, r.Errors, r.ErrorRate*100)
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "operation\trequests\terrors\terror rate\treq/s\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, op := range r.Operations {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.2f%%\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			op.Operation, op.Requests, op.Errors, op.ErrorRate*100, op.RatePerSec, op.P50Ms, op.P90Ms, op.P99Ms, op.MaxMs)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if r.Errors > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, op := range r.Operations {
+		classes := make([]string, 0, len(op.ErrorsBy))
+		for class := range op.ErrorsBy {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			fmt.Fprintf(w, "%s failed with %s: %d\n", op.Operation, class, op.ErrorsBy[class])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+)
+
+// checkPlacer load balances check sessions over the pool like POST /sessions does,
+// keeping the processes' session counts up to date
+type checkPlacer struct {
+	chromium *pool.LoadBalancer
+	firefox  *pool.LoadBalancer // nil when no Firefox browsers are configured
+}
+
+func (p *checkPlacer) Acquire(opts *session.SessionOptions) (int, error) {
+	balancer := p.chromium
+	if opts != nil && opts.Engine == session.EngineFirefox {
+		if p.firefox == nil {
+			return 0, fmt.Errorf("%w: %s", session.ErrEngineUnavailable, session.EngineFirefox)
+		}
+		balancer = p.firefox
+	}
+
+	process, err := balancer.SelectProcess()
+	if err != nil {
+		return 0, err
+	}
+	process.IncrementSessionCount()
+	return process.GetPort(), nil
+}
+
+func (p *checkPlacer) Release(port int) {
+	for _, balancer := range []*pool.LoadBalancer{p.chromium, p.firefox} {
+		if balancer == nil {
+			continue
+		}
+		for _, process := range balancer.GetProcesses() {
+			if process.GetPort() == port {
+				process.DecrementSessionCount()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+)
+
+// watchDrainSignal starts a drain on SIGUSR1, so a deploy can let running agents finish
+// before the server stops. The server shuts down once manager.Drained is closed.
+func watchDrainSignal(manager *session.Manager) {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+
+	go func() {
+		for range usr1 {
+			if _, started := manager.StartDrain(0); !started {
+				slog.Info("drain already in progress, ignoring SIGUSR1")
+			}
+		}
+	}()
+}
@@ -4,21 +4,28 @@ import (
 	"log/slog"
 	"os"
 	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/config"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/sessionlog"
 )
 
-// Function to initialize the logger
-func InitializeLogger() *slog.Logger {
+// Function to initialize the logger. The level is read from level on every record,
+// so it can be changed after startup.
+func InitializeLogger(level *slog.LevelVar) *slog.Logger {
 	var handler slog.Handler
 
+	level.Set(defaultLogLevel())
+
 	if os.Getenv("ENV") == "production" {
 
 		// Initialize JSON handler for production environment
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{ Level: slog.LevelInfo })
+		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{ Level: level })
 	} else {
 
 		// Initialize Text handler for development environment with better formatting
 		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{ 
-			Level: slog.LevelDebug,
+			Level: level,
 			AddSource: false,
 			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 				// Format timestamp to be more readable
@@ -31,6 +38,46 @@ func InitializeLogger() *slog.Logger {
 		})
 	}
 
+	// Keep each session's recent lines for GET /sessions/{id}/logs, behind the masking
+	handler = sessionlog.NewHandler(handler, sessionlog.Default())
+
+	// Mask secrets (tokens in URLs, vault passwords, ...) before anything is written
+	handler = redact.NewHandler(handler, redact.Default())
+
 	// Create a new logger with the initialized handler
 	return slog.New(handler)
-}
\ No newline at end of file
+}
+
+// defaultLogLevel is used when no log_level is configured: info in production, debug otherwise
+func defaultLogLevel() slog.Level {
+	if os.Getenv("ENV") == "production" {
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+// setLogLevel applies a configured level name, falling back to the ENV-based default for ""
+func setLogLevel(level *slog.LevelVar, name string) error {
+	if name == "" {
+		level.Set(defaultLogLevel())
+		return nil
+	}
+
+	parsed, err := config.ParseLogLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// setSessionLogs applies the per-session log buffer settings to the default store
+func setSessionLogs(cfg *config.Config) error {
+	level, err := config.ParseLogLevel(cfg.SessionLogLevel)
+	if err != nil {
+		return err
+	}
+	sessionlog.Default().SetLevel(level)
+	sessionlog.Default().SetLines(cfg.SessionLogLines)
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -9,15 +10,30 @@ import (
 	"time"
 
 	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/captcha"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/config"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/egress"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pipeline"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/publish"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/ratelimit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/storage"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vault"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/visual"
 )
 
 func main() {
 	// Setup logger
-	logger := InitializeLogger()
+	logLevel := new(slog.LevelVar)
+	logger := InitializeLogger(logLevel)
 	slog.SetDefault(logger)
 
 	// Load configuration
@@ -27,7 +43,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Validated by config.Load, so these cannot fail
+	_ = setLogLevel(logLevel, cfg.LogLevel)
+	_ = setSessionLogs(cfg)
+
+	// Register operator-configured secret patterns with the redactor
+	if err := redact.Default().AddPatterns(cfg.RedactPatterns...); err != nil {
+		slog.Error("invalid redaction pattern", "error", err)
+		os.Exit(1)
+	}
+	if cfg.AdminAPIKey != "" {
+		redact.Default().AddSecret(cfg.AdminAPIKey)
+	}
+
 	slog.Info("configuration loaded",
+		"config_file", cfg.ConfigFile,
 		"chromium_path", cfg.ChromiumPath,
 		"server_port", cfg.ServerPort,
 		"max_browsers", cfg.MaxBrowsers,
@@ -49,34 +79,367 @@ func main() {
 
 	slog.Info("Redis connected", "addr", cfg.RedisAddr)
 
+	// Connect to Postgres for history that must survive restarts (optional)
+	var postgresStore *storage.PostgresStore
+	if cfg.PostgresDSN != "" {
+		postgresStore, err = storage.NewPostgresStore(context.Background(), cfg.PostgresDSN)
+		if err != nil {
+			slog.Error("failed to set up Postgres", "error", err)
+			os.Exit(1)
+		}
+		defer postgresStore.Close()
+
+		slog.Info("Postgres connected", "persist", cfg.PostgresPersist)
+	}
+
 	// Create session repository
 	sessionRepo := storage.NewSessionRepository(redisClient, cfg.SessionTTL)
 
+	// Create credential vault backed by Redis
+	vaultKey, err := loadVaultKey(cfg.VaultKey)
+	if err != nil {
+		slog.Error("invalid vault key", "error", err)
+		os.Exit(1)
+	}
+	credentialVault, err := vault.New(vaultKey, storage.NewCredentialRepository(redisClient))
+	if err != nil {
+		slog.Error("failed to create credential vault", "error", err)
+		os.Exit(1)
+	}
+
+	// Register external CAPTCHA solvers (manual takeover works without any)
+	captchaSolvers := captcha.NewRegistry()
+	if cfg.CaptchaSolverKey != "" {
+		captchaSolvers.Register(captcha.NewTwoCaptchaSolver(cfg.CaptchaSolverKey, cfg.CaptchaSolverURL))
+		slog.Info("captcha solver registered", "solver", "2captcha", "url", cfg.CaptchaSolverURL)
+	}
+
+	// Multimodal model behind the vision-query endpoint
+	var visionModel vision.Model
+	if cfg.VisionAPIKey != "" || cfg.VisionAPIURL != "" {
+		model := vision.NewOpenAIModel(cfg.VisionAPIKey, cfg.VisionAPIURL, cfg.VisionModel)
+		visionModel = model
+		slog.Info("vision model configured", "model", model.Name(), "url", cfg.VisionAPIURL)
+	}
+
+	// Clear out browsers a crashed run left behind before launching new ones
+	cleanupOrphans(cfg)
+
+	// Unpacked extensions browsers may load; the configured defaults go into every one
+	var extensions *browser.ExtensionCatalog
+	if cfg.ExtensionDir != "" {
+		extensions, err = browser.LoadExtensionCatalog(cfg.ExtensionDir, cfg.BrowserExtensions)
+		if err != nil {
+			slog.Error("failed to load browser extensions", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("browser extensions loaded", "dir", cfg.ExtensionDir, "count", len(extensions.List()), "defaults", cfg.BrowserExtensions)
+	}
+	launch := browser.LaunchOptions{
+		StartTimeout: cfg.BrowserStartTimeout,
+		Headless:     browser.HeadlessMode(cfg.BrowserHeadless),
+		Extensions:   extensions.Defaults(),
+		Rendering: browser.Rendering{
+			FontDir:  cfg.RenderFontDir,
+			Locale:   cfg.RenderLocale,
+			Timezone: cfg.RenderTimezone,
+		},
+	}
+	if err := launch.Rendering.Validate(); err != nil {
+		slog.Error("invalid rendering settings", "error", err)
+		os.Exit(1)
+	}
+
+	// Sessions' contexts send their traffic through the egress proxy; browsers accept the
+	// certificates it decrypts HTTPS with by its CA's key. It listens on loopback, so
+	// config.Load refuses it unless browsers are launched on this host.
+	var egressProxy *egress.Proxy
+	if cfg.EgressProxy {
+		egressProxy, err = egress.New(egress.Options{DenyHosts: cfg.EgressDenyHosts, MITM: cfg.EgressMITM})
+		if err != nil {
+			slog.Error("failed to start egress proxy", "error", err)
+			os.Exit(1)
+		}
+		defer egressProxy.Close()
+		if egressProxy.MITM() {
+			launch.TrustedSPKIs = []string{egressProxy.SPKIHash()}
+		}
+		slog.Info("egress proxy enabled", "mitm", egressProxy.MITM(), "deny_hosts", cfg.EgressDenyHosts)
+	}
+
 	// Create process pool
-	processPool, err := pool.NewProcessPool(cfg.ChromiumPath, cfg.MaxBrowsers)
+	processPool, err := newProcessPool(cfg, launch)
 	if err != nil {
 		slog.Error("failed to create process pool", "error", err)
 		os.Exit(1)
 	}
 	defer processPool.Shutdown()
 
-	slog.Info("process pool created", "size", cfg.MaxBrowsers)
+	slog.Info("process pool created", "size", processPool.GetProcessCount(), "launch_mode", cfg.LaunchMode)
+
+	// Sample browser memory, CPU and file descriptors for /status and /metrics
+	processPool.StartResourceMonitor(cfg.ResourceSampleInterval)
 
 	// Create load balancer
 	loadBalancer := pool.NewLoadBalancer(processPool)
+	loadBalancer.SetMaxSessions(cfg.MaxSessionsPerProcess)
 	slog.Info("load balancer initialized")
 
+	// Firefox browsers serve sessions asking for engine "firefox"; they load no extensions
+	var firefoxPool *pool.ProcessPool
+	var firefoxBalancer *pool.LoadBalancer
+	if cfg.FirefoxPath != "" {
+		firefoxLaunch := launch
+		firefoxLaunch.Engine = browser.EngineFirefox
+		firefoxLaunch.Extensions = nil
+		firefoxPool, err = pool.NewProcessPool(cfg.FirefoxPath, cfg.FirefoxBrowsers, firefoxLaunch)
+		if err != nil {
+			slog.Error("failed to create Firefox pool", "error", err)
+			os.Exit(1)
+		}
+		defer firefoxPool.Shutdown()
+		firefoxBalancer = pool.NewLoadBalancer(firefoxPool)
+		slog.Info("Firefox pool created", "size", firefoxPool.GetProcessCount())
+	}
+
 	// Create session manager with Redis repository
 	manager := session.NewManager(sessionRepo)
 	defer manager.Close()
+	manager.SetCommandTimeouts(commandTimeouts(cfg))
+	manager.SetScriptConcurrency(cfg.ScriptConcurrency)
+	manager.SetReadLimit(int64(cfg.CDPReadLimitMB) << 20)
+	manager.SetMaxResponseSize(int64(cfg.MaxResponseMB) << 20)
+	manager.SetMemoryLimits(memoryLimits(cfg))
+	manager.SetModelPricing(modelPricing(cfg))
+	manager.SetModelBudget(cfg.VisionDailyBudget)
+	manager.SetAnswerCache(cfg.VisionCacheTTL, cfg.VisionCacheSize)
+	manager.SetDrainTimeout(cfg.DrainTimeout)
+	manager.SetHibernation(cfg.HibernateAfter, cfg.HibernateKeep)
+	// Remote and containerized browsers launch with their own locale and timezone; pages still get these
+	manager.SetRendering(cfg.RenderLocale, cfg.RenderTimezone, cfg.RenderDisableAnimations)
+	// Sessions name vault credentials for the client certificates they present
+	manager.SetClientCertificateSource(credentialVault)
+	if egressProxy != nil {
+		manager.SetEgressProxy(egressProxy)
+	}
+	manager.SetBandwidthAccounting(cfg.BandwidthAccounting)
+
+	// Give sessions their own directories, clearing out those left by a crash first
+	if err := manager.ConfigureWorkDirs(cfg.WorkDir, int64(cfg.WorkDirQuotaMB)<<20); err != nil {
+		slog.Error("failed to set up session work directories", "error", err)
+		os.Exit(1)
+	}
+	if removed, err := manager.SweepWorkDirs(); err != nil {
+		slog.Warn("failed to sweep orphaned work directories", "error", err)
+	} else if removed > 0 {
+		slog.Info("removed orphaned session work directories", "count", removed)
+	}
+	manager.StartWorkDirWorker()
+
+	// Sessions naming a profile get a browser of their own on its directory
+	var profilePool *pool.ProfilePool
+	if cfg.ProfileDir != "" {
+		profilePool, err = pool.NewProfilePool(cfg.ProfileDir, cfg.ChromiumPath, launch, extensions)
+		if err != nil {
+			slog.Error("failed to set up browser profiles", "error", err)
+			os.Exit(1)
+		}
+		defer profilePool.Shutdown()
+		manager.SetProfileLauncher(profilePool)
+		slog.Info("persistent profiles enabled", "dir", cfg.ProfileDir)
+	}
+
+	// Tell the manager where attached browsers live; local ones are found on localhost
+	for _, process := range processPool.GetProcesses() {
+		if process.IsRemote() {
+			manager.RegisterRemoteBrowser(process.GetPort(), process.Process.Remote)
+		}
+	}
+
+	// Remote browsers outlive a crash, and with them the contexts of its sessions
+	if cfg.OrphanCleanup != config.OrphanCleanupOff {
+		if disposed, err := manager.DisposeStaleContexts(context.Background()); err != nil {
+			slog.Warn("failed to dispose stale browser contexts", "error", err)
+		} else if disposed > 0 {
+			slog.Info("disposed stale browser contexts", "count", disposed)
+		}
+	}
+
+	// Load result pipelines before templates, whose options may name them
+	if cfg.PipelinesFile != "" {
+		count, err := manager.Pipelines().LoadFile(cfg.PipelinesFile)
+		if err != nil {
+			slog.Error("failed to load pipelines", "file", cfg.PipelinesFile, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("pipelines loaded", "file", cfg.PipelinesFile, "count", count)
+	}
+
+	// Load operator-defined session templates
+	if cfg.SessionTemplatesFile != "" {
+		count, err := manager.Templates().LoadFile(cfg.SessionTemplatesFile)
+		if err != nil {
+			slog.Error("failed to load session templates", "file", cfg.SessionTemplatesFile, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("session templates loaded", "file", cfg.SessionTemplatesFile, "count", count)
+	}
+
+	// Load operator-tuned system prompts
+	if cfg.PromptsFile != "" {
+		count, err := manager.Prompts().LoadFile(cfg.PromptsFile)
+		if err != nil {
+			slog.Error("failed to load prompts", "file", cfg.PromptsFile, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("prompts loaded", "file", cfg.PromptsFile, "count", count)
+	}
+
+	// Keep visual baselines on disk when a directory is configured
+	if cfg.VisualBaselineDir != "" {
+		baselines, err := visual.NewStore(cfg.VisualBaselineDir)
+		if err != nil {
+			slog.Error("failed to open visual baselines", "dir", cfg.VisualBaselineDir, "error", err)
+			os.Exit(1)
+		}
+		manager.SetBaselineStore(baselines)
+		slog.Info("visual baselines stored on disk", "dir", cfg.VisualBaselineDir)
+	}
+
+	// Load synthetic checks after the templates they may name
+	if cfg.ChecksFile != "" {
+		count, err := manager.LoadChecksFile(cfg.ChecksFile)
+		if err != nil {
+			slog.Error("failed to load checks", "file", cfg.ChecksFile, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("checks loaded", "file", cfg.ChecksFile, "count", count)
+	}
+
+	// Tenants isolate sessions per API key; without a file every request is the default tenant
+	tenants := tenant.NewRegistry()
+	if cfg.TenantsFile != "" {
+		count, err := tenants.LoadFile(cfg.TenantsFile)
+		if err != nil {
+			slog.Error("failed to load tenants", "file", cfg.TenantsFile, "error", err)
+			os.Exit(1)
+		}
+		for _, t := range tenants.List() {
+			if t.DefaultTemplate == "" {
+				continue
+			}
+			if _, err := manager.Templates().Get(t.ID, t.DefaultTemplate); err != nil {
+				slog.Error("tenant default template not found", "tenant", t.ID, "template", t.DefaultTemplate)
+				os.Exit(1)
+			}
+		}
+		manager.SetTenantQuotas(tenants)
+		slog.Info("tenants loaded", "file", cfg.TenantsFile, "count", count)
+	}
+
+	// Keep browser contexts ready so session creation skips the context round trip
+	if cfg.WarmPoolSize > 0 {
+		ports := make([]int, 0, processPool.GetProcessCount())
+		for _, process := range processPool.GetProcesses() {
+			ports = append(ports, process.GetPort())
+		}
+		warmConfig := session.WarmPoolConfig{
+			Size:     cfg.WarmPoolSize,
+			Template: cfg.WarmPoolTemplate,
+			Preopen:  cfg.WarmPoolPreopen,
+		}
+		if err := manager.StartWarmPool(ports, warmConfig); err != nil {
+			slog.Error("failed to start warm pool", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Restart browsers that served too long, drained onto fresh processes
+	// The recycler also backs the admin API, and limits can be turned on by a reload,
+	// so it always runs; checks are skipped while no limit is set
+	recycler := pool.NewRecycler(processPool, recyclePolicy(cfg), manager)
+	recycler.Start()
+	if cfg.LaunchMode == config.LaunchModeRemote {
+		recycler.WatchRemotes(cfg.RemoteCheckInterval)
+	}
+
+	// Stop placing sessions on a browser failing most of its commands, and restart it
+	processPool.SetBreakerPolicy(breakerPolicy(cfg))
+	manager.OnCommand(func(port int, failed bool) {
+		if process := processPool.GetProcess(port); process != nil {
+			process.RecordCommand(failed)
+		}
+	})
+	recycler.WatchBreakers(pool.DefaultBreakerCheckInterval)
+	defer recycler.Stop()
 
 	// Start cleanup worker (check every 5 min, timeout after 30 min)
 	manager.StartCleanupWorker(5*time.Minute, 30*time.Minute)
 
+	// Run synthetic checks on their schedule, on the same browsers as API sessions
+	placer := &checkPlacer{chromium: loadBalancer, firefox: firefoxBalancer}
+	manager.StartChecks(placer)
+
+	// Web search through a results API, or a results page loaded on the same browsers
+	switch {
+	case cfg.SearchBackend == config.SearchBackendSerpAPI || (cfg.SearchBackend == "" && cfg.SearchAPIKey != ""):
+		serp := search.NewSerpAPI(cfg.SearchAPIKey, cfg.SearchAPIURL, cfg.SearchEngine)
+		manager.SetSearchBackend(serp)
+		slog.Info("search backend configured", "backend", serp.Name(), "url", cfg.SearchAPIURL)
+	case cfg.SearchBackend == config.SearchBackendBrowser || cfg.SearchBackend == "":
+		manager.SetSearchBackend(session.NewBrowserSearch(manager, placer))
+		slog.Info("search backend configured", "backend", "browser", "url", session.SearchPageURL)
+	}
+
+	// Research reads search results on the same browsers and answers with the vision model
+	var researcher *session.Researcher
+	if visionModel != nil {
+		researcher = session.NewResearcher(manager, placer, visionModel)
+	}
+
 	slog.Info("session manager initialized with cleanup worker")
 
+	// Keep session transcripts and pipeline results in Postgres
+	var transcriptRelay *publish.Relay
+	if cfg.Persists(config.PersistTranscripts) {
+		transcriptRelay = publish.NewRelay(manager.Events(), nil, publish.NewTranscriptPublisher(postgresStore))
+	}
+	if cfg.Persists(config.PersistResults) {
+		manager.Pipelines().SetHistory(pipeline.NewPostgresHistory(postgresStore))
+	}
+
+	// Export session events to message brokers
+	eventRelay, err := newEventRelay(cfg, manager)
+	if err != nil {
+		slog.Error("failed to set up event publishing", "error", err)
+		os.Exit(1)
+	}
+
+	// Audit log for mutating API calls, separate from the operational log
+	auditLog, err := newAuditLogger(cfg, redisClient, postgresStore)
+	if err != nil {
+		slog.Error("failed to set up audit log", "error", err)
+		os.Exit(1)
+	}
+
 	// Create and start HTTP API server
-	apiServer := api.NewServer(cfg.ServerPort, manager, loadBalancer)
+	apiServer := api.NewServer(cfg.ServerPort, manager, loadBalancer, firefoxBalancer, credentialVault, captchaSolvers, recycler, profilePool, extensions, cfg.AdminAPIKey, auditLog, tenants, visionModel, researcher)
+	apiServer.SetCompressMinSize(cfg.CompressMinBytes)
+	apiServer.SetMaxRequestBody(int64(cfg.MaxRequestBodyKB) << 10)
+	apiServer.SetAdmissionPolicy(admissionPolicy(cfg))
+
+	// Keep rate limit buckets in Redis when replicas share the limits
+	var rateLimitBackend ratelimit.Backend = ratelimit.NewMemory()
+	if cfg.RateLimitBackend == config.RateLimitBackendRedis {
+		rateLimitBackend = ratelimit.NewRedis(redisClient, "ratelimit:")
+	}
+	apiServer.SetRateLimiter(ratelimit.New(rateLimitPolicy(cfg), rateLimitBackend))
+
+	// Re-read the configuration on SIGHUP
+	watchConfigReload(cfg, logLevel, apiServer, recycler, manager)
+
+	// Stop taking sessions and shut down once they end on SIGUSR1
+	watchDrainSignal(manager)
 
 	// Start HTTP server in goroutine
 	go func() {
@@ -99,9 +462,13 @@ func main() {
 		"status", "press Ctrl+C to shutdown",
 	)
 
-	// Wait for shutdown signal
-	sig := <-quit
-	slog.Info("shutdown initiated", "signal", sig.String())
+	// Wait for a shutdown signal or the end of a drain
+	select {
+	case sig := <-quit:
+		slog.Info("shutdown initiated", "signal", sig.String())
+	case <-manager.Drained():
+		slog.Info("shutdown initiated", "reason", "drain finished", "remaining_sessions", manager.GetSessionCount())
+	}
 
 	// Graceful shutdown with timeout
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -112,20 +479,225 @@ func main() {
 		slog.Error("HTTP server shutdown error", "error", err)
 	}
 
+	// Flush audit records of the requests that just finished
+	if err := auditLog.Close(); err != nil {
+		slog.Error("audit log close error", "error", err)
+	}
+
 	// Close session manager (stops cleanup worker)
 	if err := manager.Close(); err != nil {
 		slog.Error("session manager close error", "error", err)
 	}
 
+	// Publish the events of the shutdown itself before disconnecting from the brokers
+	if eventRelay != nil {
+		if err := eventRelay.Close(); err != nil {
+			slog.Error("event relay close error", "error", err)
+		}
+	}
+	if transcriptRelay != nil {
+		if err := transcriptRelay.Close(); err != nil {
+			slog.Error("transcript relay close error", "error", err)
+		}
+	}
+
 	// Shutdown process pool
 	if err := processPool.Shutdown(); err != nil {
 		slog.Error("process pool shutdown error", "error", err)
 	}
+	if profilePool != nil {
+		profilePool.Shutdown()
+	}
+	if firefoxPool != nil {
+		if err := firefoxPool.Shutdown(); err != nil {
+			slog.Error("Firefox pool shutdown error", "error", err)
+		}
+	}
 
 	// Close Redis connection
 	if err := redisClient.Close(); err != nil {
 		slog.Error("Redis close error", "error", err)
 	}
 
+	// Close Postgres last: the relays and audit log above write to it while they flush
+	if postgresStore != nil {
+		postgresStore.Close()
+	}
+
 	slog.Info("shutdown complete")
-}
\ No newline at end of file
+}
+
+// newAuditLogger builds the audit logger from the configured sinks; nil when none is configured
+func newAuditLogger(cfg *config.Config, redisClient *storage.RedisClient, postgresStore *storage.PostgresStore) (*audit.Logger, error) {
+	sinks := make([]audit.Sink, 0)
+
+	if cfg.AuditLogFile != "" {
+		fileSink, err := audit.NewFileSink(cfg.AuditLogFile)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, fileSink)
+	}
+	if cfg.AuditRedisStream != "" {
+		sinks = append(sinks, audit.NewRedisSink(redisClient, cfg.AuditRedisStream, int64(cfg.AuditRedisMaxLen)))
+	}
+	if len(cfg.AuditKafkaBrokers) > 0 {
+		sinks = append(sinks, audit.NewKafkaSink(cfg.AuditKafkaBrokers, cfg.AuditKafkaTopic))
+	}
+	if cfg.Persists(config.PersistAudit) {
+		sinks = append(sinks, audit.NewPostgresSink(postgresStore))
+	}
+
+	if len(sinks) == 0 {
+		slog.Info("audit log disabled, set AUDIT_LOG_FILE, AUDIT_REDIS_STREAM, AUDIT_KAFKA_BROKERS or POSTGRES_DSN to enable it")
+		return nil, nil
+	}
+
+	slog.Info("audit log enabled",
+		"file", cfg.AuditLogFile,
+		"redis_stream", cfg.AuditRedisStream,
+		"kafka_brokers", cfg.AuditKafkaBrokers,
+		"postgres", cfg.Persists(config.PersistAudit))
+	return audit.NewLogger(sinks...), nil
+}
+
+// newEventRelay starts exporting session events to the configured brokers; nil when none is configured
+func newEventRelay(cfg *config.Config, manager *session.Manager) (*publish.Relay, error) {
+	publishers := make([]publish.Publisher, 0)
+
+	if len(cfg.EventKafkaBrokers) > 0 {
+		publishers = append(publishers, publish.NewKafkaPublisher(cfg.EventKafkaBrokers, cfg.EventKafkaTopic))
+	}
+	if cfg.EventNATSURL != "" {
+		natsPublisher, err := publish.NewNATSPublisher(cfg.EventNATSURL, cfg.EventNATSSubject)
+		if err != nil {
+			return nil, err
+		}
+		publishers = append(publishers, natsPublisher)
+	}
+
+	if len(publishers) == 0 {
+		return nil, nil
+	}
+
+	slog.Info("event publishing enabled",
+		"kafka_brokers", cfg.EventKafkaBrokers,
+		"nats_url", cfg.EventNATSURL,
+		"types", cfg.EventPublishTypes)
+	return publish.NewRelay(manager.Events(), cfg.EventPublishTypes, publishers...), nil
+}
+
+// loadVaultKey decodes the configured vault key, generating an ephemeral one if unset
+func loadVaultKey(encoded string) ([]byte, error) {
+	if encoded == "" {
+		slog.Warn("VAULT_KEY not set, using an ephemeral key - stored credentials will be unreadable after restart")
+		return vault.GenerateKey()
+	}
+
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// recyclePolicy builds the process recycling policy from the configuration
+func recyclePolicy(cfg *config.Config) pool.RecyclePolicy {
+	return pool.RecyclePolicy{
+		MaxSessions:   int64(cfg.RecycleMaxSessions),
+		MaxUptime:     cfg.RecycleMaxUptime,
+		MaxRSSBytes:   uint64(cfg.RecycleMaxRSSMB) << 20,
+		DrainTimeout:  cfg.RecycleDrainTimeout,
+		CheckInterval: cfg.RecycleCheckInterval,
+	}
+}
+
+// admissionPolicy builds the bounds of the queue of sessions waiting for capacity
+func admissionPolicy(cfg *config.Config) pool.AdmissionPolicy {
+	return pool.AdmissionPolicy{Size: cfg.AdmissionQueueSize, MaxWait: cfg.AdmissionMaxWait}
+}
+
+// breakerPolicy builds the browser processes' circuit breaker policy from the configuration
+func breakerPolicy(cfg *config.Config) pool.BreakerPolicy {
+	return pool.BreakerPolicy{
+		FailureRatio: cfg.BreakerFailureRatio,
+		MinCommands:  cfg.BreakerMinCommands,
+		Window:       cfg.BreakerWindow,
+		Cooldown:     cfg.BreakerCooldown,
+	}
+}
+
+// commandTimeouts builds the CDP command timeouts from the configuration
+func commandTimeouts(cfg *config.Config) cdp.Timeouts {
+	return cdp.Timeouts{
+		Default:    cfg.CDPCommandTimeout,
+		Navigation: cfg.CDPNavigationTimeout,
+		Evaluate:   cfg.CDPEvaluateTimeout,
+		Screenshot: cfg.CDPScreenshotTimeout,
+	}
+}
+
+// rateLimitPolicy builds the rate and in-flight limits from the configuration
+func rateLimitPolicy(cfg *config.Config) ratelimit.Policy {
+	return ratelimit.Policy{
+		Global:         ratelimit.Limit{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst},
+		PerKey:         ratelimit.Limit{Rate: cfg.RateLimitKeyRPS, Burst: cfg.RateLimitKeyBurst},
+		GlobalInFlight: cfg.RateLimitInFlight,
+		KeyInFlight:    cfg.RateLimitKeyInFlight,
+	}
+}
+
+// memoryLimits builds the bounds on each session's conversation memory from the configuration
+func memoryLimits(cfg *config.Config) session.MemoryLimits {
+	return session.MemoryLimits{Turns: cfg.MemoryMaxTurns, Bytes: cfg.MemoryMaxKB << 10}
+}
+
+// modelPricing builds the token prices model calls are costed at from the configuration
+func modelPricing(cfg *config.Config) session.ModelPricing {
+	return session.ModelPricing{PromptPerMillion: cfg.VisionPromptPrice, CompletionPerMillion: cfg.VisionCompletionPrice}
+}
+
+// newProcessPool starts the browser pool in the configured launch mode
+// cleanupOrphans kills browsers and removes profiles and containers left by an earlier
+// run that didn't shut down cleanly. Failures are logged; leftovers waste resources but
+// don't stop new browsers from starting.
+func cleanupOrphans(cfg *config.Config) {
+	if cfg.OrphanCleanup == config.OrphanCleanupOff {
+		return
+	}
+
+	switch cfg.LaunchMode {
+	case config.LaunchModeDocker:
+		removed, err := browser.CleanupOrphanContainers(browser.ContainerConfig{DockerHost: cfg.DockerHost})
+		if err != nil {
+			slog.Warn("failed to remove orphaned browser containers", "error", err)
+		}
+		if removed > 0 {
+			slog.Info("removed orphaned browser containers", "count", removed)
+		}
+	case config.LaunchModeLocal:
+		report, err := browser.CleanupOrphans(cfg.OrphanCleanup == config.OrphanCleanupPorts)
+		if err != nil {
+			slog.Warn("orphaned browser cleanup incomplete", "error", err)
+		}
+		if report.Processes > 0 || report.Dirs > 0 || len(report.Ports) > 0 {
+			slog.Info("cleaned up orphaned browsers",
+				"processes_killed", report.Processes,
+				"profiles_removed", report.Dirs,
+				"ports_closed", report.Ports)
+		}
+	}
+}
+
+func newProcessPool(cfg *config.Config, launch browser.LaunchOptions) (*pool.ProcessPool, error) {
+	switch cfg.LaunchMode {
+	case config.LaunchModeDocker:
+		return pool.NewContainerPool(browser.ContainerConfig{
+			Image:      cfg.BrowserImage,
+			DockerHost: cfg.DockerHost,
+			Network:    cfg.BrowserNetwork,
+			CPUs:       cfg.BrowserCPUs,
+			MemoryMB:   cfg.BrowserMemoryMB,
+		}, cfg.MaxBrowsers, launch)
+	case config.LaunchModeRemote:
+		return pool.NewRemotePool(cfg.RemoteBrowsers)
+	default:
+		return pool.NewProcessPool(cfg.ChromiumPath, cfg.MaxBrowsers, launch)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/api"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/config"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+)
+
+// watchConfigReload re-reads the configuration on SIGHUP and applies the fields that
+// can change at runtime. Changes to any other field are logged as needing a restart.
+func watchConfigReload(startup *config.Config, logLevel *slog.LevelVar, apiServer *api.Server, recycler *pool.Recycler, manager *session.Manager) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		applied := startup
+		for range hup {
+			next, err := config.Load()
+			if err != nil {
+				slog.Error("config reload failed, keeping current configuration", "error", err)
+				continue
+			}
+
+			live := applied.Diff(next).Live
+			if err := applyRuntimeConfig(next, live, logLevel, apiServer, recycler, manager); err != nil {
+				slog.Error("config reload failed, keeping current configuration", "error", err)
+				continue
+			}
+			applied = next
+
+			// Compared against startup: those values are still what the service runs with
+			if restart := startup.Diff(next).Restart; len(restart) > 0 {
+				slog.Warn("config changes need a restart to take effect", "fields", restart)
+			}
+			slog.Info("configuration reloaded", "file", next.ConfigFile, "applied", live)
+		}
+	}()
+}
+
+// applyRuntimeConfig pushes the changed live fields of cfg into the running service
+func applyRuntimeConfig(cfg *config.Config, changed []string, logLevel *slog.LevelVar, apiServer *api.Server, recycler *pool.Recycler, manager *session.Manager) error {
+	policyChanged := false
+	timeoutsChanged := false
+
+	for _, field := range changed {
+		switch field {
+		case "log_level":
+			if err := setLogLevel(logLevel, cfg.LogLevel); err != nil {
+				return err
+			}
+		case "session_log_lines", "session_log_level":
+			if err := setSessionLogs(cfg); err != nil {
+				return err
+			}
+		case "redact_patterns":
+			// Patterns are only added; one removed from the config stays active until restart
+			if err := redact.Default().AddPatterns(cfg.RedactPatterns...); err != nil {
+				return err
+			}
+		case "admin_api_key":
+			if cfg.AdminAPIKey != "" {
+				redact.Default().AddSecret(cfg.AdminAPIKey)
+			}
+			apiServer.SetAdminKey(cfg.AdminAPIKey)
+		case "recycle_max_sessions", "recycle_max_uptime", "recycle_max_rss_mb", "recycle_drain_timeout":
+			policyChanged = true
+		case "admission_queue_size", "admission_max_wait":
+			apiServer.SetAdmissionPolicy(admissionPolicy(cfg))
+		case "breaker_failure_ratio", "breaker_min_commands", "breaker_window", "breaker_cooldown":
+			recycler.Pool().SetBreakerPolicy(breakerPolicy(cfg))
+		case "cdp_command_timeout", "cdp_navigation_timeout", "cdp_evaluate_timeout", "cdp_screenshot_timeout":
+			timeoutsChanged = true
+		case "script_concurrency":
+			manager.SetScriptConcurrency(cfg.ScriptConcurrency)
+		case "max_response_mb":
+			manager.SetMaxResponseSize(int64(cfg.MaxResponseMB) << 20)
+		case "compress_min_bytes":
+			apiServer.SetCompressMinSize(cfg.CompressMinBytes)
+		case "max_request_body_kb":
+			apiServer.SetMaxRequestBody(int64(cfg.MaxRequestBodyKB) << 10)
+		case "work_dir_quota_mb":
+			manager.SetWorkDirQuota(int64(cfg.WorkDirQuotaMB) << 20)
+		case "rate_limit_rps", "rate_limit_burst", "rate_limit_key_rps", "rate_limit_key_burst", "rate_limit_in_flight", "rate_limit_key_in_flight":
+			// The backend stays, so callers keep their buckets
+			apiServer.SetRateLimiter(apiServer.RateLimiter().WithPolicy(rateLimitPolicy(cfg)))
+		case "memory_max_turns", "memory_max_kb":
+			manager.SetMemoryLimits(memoryLimits(cfg))
+		case "vision_prompt_price", "vision_completion_price":
+			manager.SetModelPricing(modelPricing(cfg))
+		case "vision_daily_budget":
+			manager.SetModelBudget(cfg.VisionDailyBudget)
+		case "vision_cache_ttl", "vision_cache_size":
+			manager.SetAnswerCache(cfg.VisionCacheTTL, cfg.VisionCacheSize)
+		}
+	}
+
+	// Recycling limits are applied together since the policy is a single value
+	if policyChanged {
+		recycler.SetPolicy(recyclePolicy(cfg))
+	}
+	if timeoutsChanged {
+		manager.SetCommandTimeouts(commandTimeouts(cfg))
+	}
+	return nil
+}
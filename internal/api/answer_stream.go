@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// answerStream sends a model's answer as Server-Sent Events while it is written: a
+// "token" event per piece, then the full response as "result", or an "error" event
+// with the same body as an error response
+type answerStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	mu         sync.Mutex
+	broken     bool // The client went away; later events are dropped
+	stop       chan struct{}
+}
+
+// tokenEvent is the data of a "token" event
+type tokenEvent struct {
+	Text string `json:"text"`
+}
+
+// startAnswerStream sends the stream's headers and keeps it alive with pings until close
+func startAnswerStream(w http.ResponseWriter) *answerStream {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	stream := &answerStream{w: w, controller: http.NewResponseController(w), stop: make(chan struct{})}
+	stream.write(": stream started\n\n")
+
+	// Searching and loading pages can take a while before the first token
+	go func() {
+		ticker := time.NewTicker(eventPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stream.stop:
+				return
+			case <-ticker.C:
+				stream.write(": ping\n\n")
+			}
+		}
+	}()
+	return stream
+}
+
+// token sends a piece of the answer
+func (s *answerStream) token(text string) {
+	s.send("token", tokenEvent{Text: text})
+}
+
+// send writes one event with a JSON payload
+func (s *answerStream) send(event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("failed to encode stream event", "event", event, "error", err)
+		return
+	}
+	s.write(fmt.Sprintf("event: %s\ndata: %s\n\n", event, data))
+}
+
+// write sends a frame unless the client is gone. The server's WriteTimeout would cut the
+// stream, so each write gets its own deadline instead.
+func (s *answerStream) write(frame string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken {
+		return
+	}
+	s.controller.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+	if _, err := io.WriteString(s.w, frame); err != nil {
+		s.broken = true
+		return
+	}
+	if err := s.controller.Flush(); err != nil {
+		s.broken = true
+	}
+}
+
+// close stops the pings; call it once the last event is sent
+func (s *answerStream) close() {
+	close(s.stop)
+}
+
+// errors returns a ResponseWriter for writeError whose response becomes an "error"
+// event, so handlers report failures the same way whether they stream or not
+func (s *answerStream) errors() http.ResponseWriter {
+	return &streamErrorWriter{stream: s, header: make(http.Header)}
+}
+
+// streamErrorWriter turns an error response into an "error" event
+type streamErrorWriter struct {
+	stream *answerStream
+	header http.Header
+}
+
+func (e *streamErrorWriter) Header() http.Header {
+	return e.header
+}
+
+// WriteHeader is dropped; the stream's status was sent with its headers
+func (e *streamErrorWriter) WriteHeader(statusCode int) {}
+
+func (e *streamErrorWriter) Write(body []byte) (int, error) {
+	e.stream.write(fmt.Sprintf("event: error\ndata: %s\n\n", bytes.TrimRight(body, "\n")))
+	return len(body), nil
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultCompressMinSize is the smallest response body gzipped unless configured otherwise.
+// Below about a kilobyte the gzip framing costs more than it saves.
+const DefaultCompressMinSize = 1024
+
+// gzipWriters reuses compressors; each one holds a few hundred KB of state
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	},
+}
+
+// compressibleTypes are the content types worth compressing. Images and PDFs are
+// already compressed, and event streams must reach the client as they are written.
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/html",
+	"text/plain",
+	"text/css",
+}
+
+// CompressionMiddleware gzips responses for clients that accept it once the body
+// reaches minSize bytes. minSize is read per request so it can be changed live; 0
+// turns compression off. Brotli isn't offered: the standard library has no encoder, and
+// clients that accept br accept gzip as well.
+func CompressionMiddleware(minSize func() int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			threshold := minSize()
+			// WebSocket upgrades hijack the connection, so there is no body to compress
+			if threshold <= 0 || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, threshold: threshold}
+			defer cw.finish()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding = strings.TrimSpace(coding); coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if quality, err := strconv.ParseFloat(q, 64); err != nil || quality == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressWriter holds back the start of a body until it knows whether the response
+// is big enough to compress, then either gzips everything or passes it through
+type compressWriter struct {
+	http.ResponseWriter
+	threshold int
+	status    int
+	buffer    []byte
+	decided   bool
+	gz        *gzip.Writer
+}
+
+// WriteHeader delays the status until the encoding, which changes the headers, is chosen
+func (c *compressWriter) WriteHeader(status int) {
+	if c.status != 0 || c.decided {
+		return
+	}
+	c.status = status
+	// Bodiless and partial responses go out as they are
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		c.decide(false)
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if !c.decided {
+		if !c.compressible() {
+			c.decide(false)
+		} else if len(c.buffer)+len(p) < c.threshold {
+			c.buffer = append(c.buffer, p...)
+			return len(p), nil
+		} else {
+			c.decide(true)
+		}
+	}
+
+	if c.gz != nil {
+		return c.gz.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// compressible reports whether the handler's content type is worth compressing
+func (c *compressWriter) compressible() bool {
+	header := c.ResponseWriter.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	for _, candidate := range compressibleTypes {
+		if strings.HasPrefix(contentType, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// decide sends the headers for the chosen encoding, then whatever was held back
+func (c *compressWriter) decide(compress bool) {
+	c.decided = true
+	if compress {
+		header := c.ResponseWriter.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		c.gz = gzipWriters.Get().(*gzip.Writer)
+		c.gz.Reset(c.ResponseWriter)
+	}
+	if c.status != 0 {
+		c.ResponseWriter.WriteHeader(c.status)
+	}
+
+	if len(c.buffer) > 0 {
+		buffered := c.buffer
+		c.buffer = nil
+		if c.gz != nil {
+			c.gz.Write(buffered)
+		} else {
+			c.ResponseWriter.Write(buffered)
+		}
+	}
+}
+
+// finish sends a body that stayed under the threshold as is, or ends the gzip stream
+func (c *compressWriter) finish() {
+	if !c.decided {
+		c.decide(false)
+	}
+	if c.gz != nil {
+		c.gz.Close()
+		c.gz.Reset(nil)
+		gzipWriters.Put(c.gz)
+		c.gz = nil
+	}
+}
+
+// Flush sends what has been written so far, compressed or not
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		c.decide(c.compressible())
+	}
+	if c.gz != nil {
+		c.gz.Flush()
+	}
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer for deadlines
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Hijack passes through connection takeovers that weren't announced with an Upgrade header
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	c.decided = true
+	return http.NewResponseController(c.ResponseWriter).Hijack()
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCompressionMiddleware tests which responses are gzipped, by size, content type,
+// existing encoding and what the client accepts
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"title": "Example Domain"}`, 100)
+	small := `{"ok": true}`
+
+	cases := []struct {
+		name        string
+		accept      string
+		contentType string
+		encoding    string // Set by the handler
+		status      int
+		body        string
+		gzipped     bool
+	}{
+		{"below threshold", "gzip", "application/json", "", http.StatusOK, small, false},
+		{"above threshold", "gzip", "application/json", "", http.StatusOK, large, true},
+		{"html with charset", "br, gzip;q=0.5", "text/html; charset=utf-8", "", http.StatusOK, large, true},
+		{"any coding", "*", "text/plain", "", http.StatusOK, large, true},
+		{"event stream", "gzip", "text/event-stream", "", http.StatusOK, large, false},
+		{"image", "gzip", "image/png", "", http.StatusOK, large, false},
+		{"already encoded", "gzip", "application/json", "br", http.StatusOK, large, false},
+		{"gzip refused", "gzip;q=0", "application/json", "", http.StatusOK, large, false},
+		{"brotli only", "br", "application/json", "", http.StatusOK, large, false},
+		{"no accept-encoding", "", "application/json", "", http.StatusOK, large, false},
+		{"partial content", "gzip", "application/json", "", http.StatusPartialContent, large, false},
+		{"error body", "gzip", "application/json", "", http.StatusNotFound, large, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := CompressionMiddleware(func() int { return DefaultCompressMinSize })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", c.contentType)
+				if c.encoding != "" {
+					w.Header().Set("Content-Encoding", c.encoding)
+				}
+				w.WriteHeader(c.status)
+				// Written in pieces, so the threshold is reached partway through
+				for start := 0; start < len(c.body); start += 100 {
+					io.WriteString(w, c.body[start:min(start+100, len(c.body))])
+				}
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+			if c.accept != "" {
+				r.Header.Set("Accept-Encoding", c.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != c.status {
+				t.Errorf("expected status %d, got %d", c.status, w.Code)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", got)
+			}
+			if gzipped := w.Header().Get("Content-Encoding") == "gzip"; gzipped != c.gzipped {
+				t.Fatalf("expected gzipped %v, got Content-Encoding %q", c.gzipped, w.Header().Get("Content-Encoding"))
+			}
+			body := w.Body.String()
+			if c.gzipped {
+				reader, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("invalid gzip stream: %v", err)
+				}
+				decoded, _ := io.ReadAll(reader)
+				body = string(decoded)
+			}
+			if body != c.body {
+				t.Errorf("body changed: got %d bytes, want %d", len(body), len(c.body))
+			}
+		})
+	}
+
+	// A zero threshold turns compression off altogether
+	handler := CompressionMiddleware(func() int { return 0 })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, large)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != "" {
+		t.Errorf("expected compression off, got headers %v", w.Header())
+	}
+}
+
+// TestCompressionFlush tests that flushed event streams reach the client at once and
+// that flushing a small compressible body starts the gzip stream
+func TestCompressionFlush(t *testing.T) {
+	w := httptest.NewRecorder()
+	var beforeEnd string
+	handler := CompressionMiddleware(func() int { return DefaultCompressMinSize })(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(rw, "data: hello\n\n")
+		http.NewResponseController(rw).Flush()
+		beforeEnd = w.Body.String()
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/sessions/sess_1/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(w, r)
+	if beforeEnd != "data: hello\n\n" || !w.Flushed || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected the event flushed as is, got %q flushed %v", beforeEnd, w.Flushed)
+	}
+
+	w = httptest.NewRecorder()
+	handler = CompressionMiddleware(func() int { return DefaultCompressMinSize })(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		io.WriteString(rw, `{"progress": 1}`)
+		http.NewResponseController(rw).Flush()
+		io.WriteString(rw, `{"progress": 2}`)
+	}))
+	handler.ServeHTTP(w, r)
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil || !w.Flushed {
+		t.Fatalf("expected a flushed gzip stream, got %v flushed %v", err, w.Flushed)
+	}
+	if decoded, _ := io.ReadAll(reader); string(decoded) != `{"progress": 1}{"progress": 2}` {
+		t.Errorf("unexpected body %q", decoded)
+	}
+}
+
+// hijackRecorder is a ResponseRecorder whose connection can be taken over
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+// TestCompressionHijack tests that connection takeovers reach the server's writer
+func TestCompressionHijack(t *testing.T) {
+	w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler := CompressionMiddleware(func() int { return DefaultCompressMinSize })(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, _, err := http.NewResponseController(rw).Hijack(); err != nil {
+			t.Errorf("Hijack failed: %v", err)
+		}
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/sessions/sess_1/pages/p1/takeover", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(w, r)
+	if !w.hijacked || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected the connection hijacked without an encoding, got hijacked %v", w.hijacked)
+	}
+}
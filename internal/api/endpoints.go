@@ -0,0 +1,195 @@
+package api
+
+import (
+	"reflect"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pipeline"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+)
+
+//go:generate go run ../../cmd/clientgen -out ../../clients
+
+// Endpoint describes one route of the public API for client generation.
+// Path parameters are written as in the router ({id}, {pageId}).
+type Endpoint struct {
+	Name     string       // Method name in the generated clients, e.g. "Navigate"
+	Method   string       // HTTP method
+	Path     string       // Route pattern
+	Doc      string       // One-line description, copied into the clients
+	Request  reflect.Type // JSON body; nil when the route takes none
+	Response reflect.Type // JSON body; nil for 204 responses
+	Query    []string     // Optional query parameters
+	Stream   reflect.Type // For WebSocket routes, the type of each message
+	Binary   bool         // The response is raw bytes, such as a PDF, rather than JSON
+}
+
+// typeOf returns the reflect.Type of T
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// Endpoints is the part of the API covered by the generated SDKs and the tool manifest:
+// sessions, pages, their event stream, and the search and research agents start from.
+// Admin, credential and template routes are operator tooling and are left to plain HTTP.
+// Session routes missing from it fail TestEndpointsCoverRoutes unless listed there.
+var Endpoints = []Endpoint{
+	{Name: "CreateSession", Method: "POST", Path: "/sessions", Doc: "Create a session for an agent",
+		Request: typeOf[CreateSessionRequest](), Response: typeOf[CreateSessionResponse]()},
+	{Name: "ListSessions", Method: "GET", Path: "/sessions", Doc: "List active sessions",
+		Response: typeOf[ListSessionsResponse]()},
+	{Name: "DestroySessionsByFilter", Method: "DELETE", Path: "/sessions", Doc: "Destroy the sessions matching a label, age or agent filter",
+		Response: typeOf[DestroySessionsResponse](), Query: []string{"label", "older_than", "agent_id"}},
+	{Name: "DestroySessionBatch", Method: "POST", Path: "/sessions/destroy-batch", Doc: "Destroy a list of sessions",
+		Request: typeOf[DestroySessionBatchRequest](), Response: typeOf[DestroySessionsResponse]()},
+	{Name: "ResumeSession", Method: "POST", Path: "/sessions/resume", Doc: "Resume a session by agent and name, creating it if needed",
+		Request: typeOf[ResumeSessionRequest](), Response: typeOf[ResumeSessionResponse]()},
+	{Name: "GetSession", Method: "GET", Path: "/sessions/{id}", Doc: "Get session details",
+		Response: typeOf[GetSessionResponse]()},
+	{Name: "DestroySession", Method: "DELETE", Path: "/sessions/{id}", Doc: "Destroy a session and its browser context"},
+	{Name: "ResumeSessionByID", Method: "POST", Path: "/sessions/{id}/resume", Doc: "Resume a session by ID, restoring it if it was closed",
+		Response: typeOf[ResumeSessionResponse]()},
+	{Name: "CloseSession", Method: "PUT", Path: "/sessions/{id}/close", Doc: "Close a session's pages, keeping it resumable by name",
+		Response: typeOf[map[string]interface{}]()},
+	{Name: "RenameSession", Method: "PUT", Path: "/sessions/{id}/rename", Doc: "Rename a session",
+		Request: typeOf[RenameSessionRequest](), Response: typeOf[map[string]interface{}]()},
+	{Name: "HibernateSession", Method: "POST", Path: "/sessions/{id}/hibernate", Doc: "Free a session's browser context until its next call",
+		Response: typeOf[map[string]interface{}]()},
+	{Name: "WakeSession", Method: "POST", Path: "/sessions/{id}/wake", Doc: "Wake a hibernated session ahead of its next call",
+		Response: typeOf[map[string]interface{}]()},
+	{Name: "GetMemory", Method: "GET", Path: "/sessions/{id}/memory", Doc: "Show the earlier questions and page content follow-up vision queries draw on",
+		Response: typeOf[MemoryResponse]()},
+	{Name: "ClearMemory", Method: "DELETE", Path: "/sessions/{id}/memory", Doc: "Forget a session's earlier questions and page content"},
+	{Name: "GetStorage", Method: "GET", Path: "/sessions/{id}/storage", Doc: "Read an origin's localStorage, sessionStorage and IndexedDB summary through a page",
+		Query: []string{"page_id", "origin"}, Response: typeOf[StorageResponse]()},
+	{Name: "WriteStorage", Method: "PUT", Path: "/sessions/{id}/storage", Doc: "Set, remove or clear an origin's localStorage or sessionStorage items",
+		Request: typeOf[WriteStorageRequest](), Response: typeOf[StorageResponse]()},
+	{Name: "ListServiceWorkers", Method: "GET", Path: "/sessions/{id}/service-workers", Doc: "List the service worker registrations of a session's browser context",
+		Query: []string{"page_id"}, Response: typeOf[ServiceWorkersResponse]()},
+	{Name: "UnregisterServiceWorkers", Method: "DELETE", Path: "/sessions/{id}/service-workers", Doc: "Unregister the service worker for a scope, or every one",
+		Query: []string{"page_id", "scope_url"}, Response: typeOf[UnregisterServiceWorkersResponse]()},
+	{Name: "GetCacheStorage", Method: "GET", Path: "/sessions/{id}/cache-storage", Doc: "List an origin's CacheStorage caches and how many responses each holds",
+		Query: []string{"page_id", "origin"}, Response: typeOf[CacheStorageResponse]()},
+	{Name: "ClearCacheStorage", Method: "DELETE", Path: "/sessions/{id}/cache-storage", Doc: "Delete one of an origin's CacheStorage caches, or all of them",
+		Query: []string{"page_id", "origin", "cache"}, Response: typeOf[CacheStorageResponse]()},
+	{Name: "CreateCheckpoint", Method: "POST", Path: "/sessions/{id}/checkpoints", Doc: "Save a session's cookies, page URLs and web storage as a checkpoint",
+		Request: typeOf[CreateCheckpointRequest](), Response: typeOf[session.Checkpoint]()},
+	{Name: "ListCheckpoints", Method: "GET", Path: "/sessions/{id}/checkpoints", Doc: "List a session's checkpoints",
+		Response: typeOf[ListCheckpointsResponse]()},
+	{Name: "DeleteCheckpoint", Method: "DELETE", Path: "/sessions/{id}/checkpoints/{checkpointId}", Doc: "Delete a checkpoint"},
+	{Name: "BranchSession", Method: "POST", Path: "/sessions/{id}/checkpoints/{checkpointId}/branch", Doc: "Create a new session from a checkpoint",
+		Request: typeOf[BranchSessionRequest](), Response: typeOf[BranchSessionResponse]()},
+	{Name: "ShareSession", Method: "POST", Path: "/sessions/{id}/share", Doc: "Share a session read-only with another tenant, or transfer it to a new owner",
+		Request: typeOf[ShareSessionRequest](), Response: typeOf[ShareSessionResponse]()},
+	{Name: "ListShares", Method: "GET", Path: "/sessions/{id}/share", Doc: "Show a session's owner and read-only shares",
+		Response: typeOf[ShareSessionResponse]()},
+	{Name: "RevokeShare", Method: "DELETE", Path: "/sessions/{id}/share/{tenantId}", Doc: "Revoke a tenant's read-only access to a session"},
+	{Name: "CreateObserver", Method: "POST", Path: "/sessions/{id}/observers", Doc: "Create a read-only link for watching a session",
+		Request: typeOf[CreateObserverRequest](), Response: typeOf[ObserverResponse]()},
+	{Name: "ListObservers", Method: "GET", Path: "/sessions/{id}/observers", Doc: "List a session's observer links",
+		Response: typeOf[ListObserversResponse]()},
+	{Name: "RevokeObserver", Method: "DELETE", Path: "/sessions/{id}/observers/{observerId}", Doc: "Revoke an observer link"},
+	{Name: "GetTakeover", Method: "GET", Path: "/sessions/{id}/takeover", Doc: "Show whether a human has taken over a session",
+		Response: typeOf[TakeoverStatusResponse]()},
+	{Name: "EndTakeover", Method: "DELETE", Path: "/sessions/{id}/takeover", Doc: "Hand a taken-over session back to the agent"},
+	{Name: "GetSessionLogs", Method: "GET", Path: "/sessions/{id}/logs", Doc: "Show the recent server log lines of a session",
+		Response: typeOf[SessionLogsResponse](), Query: []string{"level", "page_id", "limit"}},
+	{Name: "GetEgressUsage", Method: "GET", Path: "/sessions/{id}/egress", Doc: "Show a session's egress policy and the requests and bytes it sent through the egress proxy",
+		Response: typeOf[EgressResponse]()},
+	{Name: "GetSessionBandwidth", Method: "GET", Path: "/sessions/{id}/bandwidth", Doc: "Show the requests and bytes a session's pages downloaded, in total and per open page",
+		Response: typeOf[BandwidthResponse]()},
+	{Name: "ListWorkFiles", Method: "GET", Path: "/sessions/{id}/files", Doc: "List the downloads and other files in a session's work directory",
+		Response: typeOf[WorkFilesResponse]()},
+	{Name: "Navigate", Method: "POST", Path: "/sessions/{id}/navigate", Doc: "Open a new page at a URL",
+		Request: typeOf[NavigateRequest](), Response: typeOf[NavigateResponse]()},
+	{Name: "Login", Method: "POST", Path: "/sessions/{id}/login", Doc: "Fill and submit a page's login form with a stored credential",
+		Request: typeOf[LoginRequest](), Response: typeOf[LoginResponse]()},
+	{Name: "ExecuteJS", Method: "POST", Path: "/sessions/{id}/execute", Doc: "Run JavaScript on a page",
+		Request: typeOf[ExecuteJSRequest](), Response: typeOf[ExecuteJSResponse]()},
+	{Name: "Extract", Method: "POST", Path: "/sessions/{id}/extract", Doc: "Run a script on a page and pass its result through a pipeline",
+		Request: typeOf[ExtractRequest](), Response: typeOf[pipeline.Result]()},
+	{Name: "Run", Method: "POST", Path: "/sessions/{id}/run", Doc: "Run a script of navigate, wait, click, type, extract, assert and compare steps",
+		Request: typeOf[RunRequest](), Response: typeOf[RunResponse]()},
+	{Name: "CaptureScreenshot", Method: "POST", Path: "/sessions/{id}/screenshot", Doc: "Capture a screenshot of a page",
+		Request: typeOf[ScreenshotRequest](), Response: typeOf[ScreenshotResponse]()},
+	{Name: "AnalyzePage", Method: "POST", Path: "/sessions/{id}/analyze", Doc: "Summarize a page's structure",
+		Request: typeOf[AnalyzePageRequest](), Response: typeOf[AnalyzePageResponse]()},
+	{Name: "GetAccessibilityTree", Method: "POST", Path: "/sessions/{id}/accessibility-tree", Doc: "Get a page's accessibility tree",
+		Request: typeOf[AccessibilityTreeRequest](), Response: typeOf[AccessibilityTreeResponse]()},
+	{Name: "ListPages", Method: "GET", Path: "/sessions/{id}/pages", Doc: "List the live pages of a session",
+		Response: typeOf[ListPagesResponse]()},
+	{Name: "GetPageContent", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/content", Doc: "Get a page's HTML",
+		Response: typeOf[GetPageContentResponse]()},
+	{Name: "ClosePage", Method: "DELETE", Path: "/sessions/{id}/pages/{pageId}", Doc: "Close a page"},
+	{Name: "ActivatePage", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/activate", Doc: "Bring a page to the front"},
+	{Name: "DuplicatePage", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/duplicate", Doc: "Open a copy of a page",
+		Response: typeOf[DuplicatePageResponse]()},
+	{Name: "VisionQuery", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/vision-query", Doc: "Ask a vision model about a page's screenshot",
+		Request: typeOf[VisionQueryRequest](), Response: typeOf[VisionQueryResponse]()},
+	{Name: "Click", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/click", Doc: "Click at a point on a page",
+		Request: typeOf[ClickRequest](), Response: typeOf[ClickResponse]()},
+	{Name: "GetElementBox", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/element/box", Doc: "Get an element's box, visibility and occlusion",
+		Query: []string{"selector", "engine", "match", "object_id"}, Response: typeOf[ElementBoxResponse]()},
+	{Name: "GetElementFingerprint", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/element/fingerprint", Doc: "Fingerprint an element so its selector can be healed later",
+		Query: []string{"selector", "engine", "match"}, Response: typeOf[ElementFingerprintResponse]()},
+	{Name: "HealSelector", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/element/heal", Doc: "Find the element a selector that stopped matching meant, by its fingerprint",
+		Request: typeOf[HealSelectorRequest](), Response: typeOf[HealSelectorResponse]()},
+	{Name: "CaptureCanvas", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/canvas", Doc: "Capture a page's canvases as images, with the data of charts drawn on them",
+		Request: typeOf[CaptureCanvasRequest](), Response: typeOf[CaptureCanvasResponse]()},
+	{Name: "GetPageMedia", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/media", Doc: "Get the CSS media a page is rendered for",
+		Response: typeOf[PageMediaResponse]()},
+	{Name: "SetPageMedia", Method: "PUT", Path: "/sessions/{id}/pages/{pageId}/media", Doc: "Render a page for print media, a color scheme or reduced motion",
+		Request: typeOf[PageMediaRequest](), Response: typeOf[PageMediaResponse]()},
+	{Name: "GetWebSocketTraffic", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/network/websockets", Doc: "List a page's WebSockets and the frames they sent and received",
+		Query: []string{"direction", "url", "min_size", "max_size", "since", "until", "limit"}, Response: typeOf[WebSocketTrafficResponse]()},
+	{Name: "GetStreamTraffic", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/network/streams", Doc: "List a page's event streams and streamed responses and the messages they received",
+		Query: []string{"kind", "event", "url", "min_size", "max_size", "since", "until", "limit"}, Response: typeOf[StreamTrafficResponse]()},
+	{Name: "GetPageSecurity", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/security", Doc: "Get a page's security state, mixed content and certificate",
+		Response: typeOf[PageSecurityResponse]()},
+	{Name: "CreateHandle", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/handles", Doc: "Find an element once and keep a handle to it until the page navigates",
+		Request: typeOf[CreateHandleRequest](), Response: typeOf[HandleResponse]()},
+	{Name: "ListHandles", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/handles", Doc: "List the element handles of a page",
+		Response: typeOf[ListHandlesResponse]()},
+	{Name: "ReleaseHandle", Method: "DELETE", Path: "/sessions/{id}/pages/{pageId}/handles/{handleId}", Doc: "Release an element handle"},
+	{Name: "ClickHandle", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/handles/{handleId}/click", Doc: "Scroll a handle's element into view and click it",
+		Request: typeOf[HandleClickRequest](), Response: typeOf[ClickResponse]()},
+	{Name: "GetHandleText", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/handles/{handleId}/text", Doc: "Get the rendered text of a handle's element",
+		Response: typeOf[HandleTextResponse]()},
+	{Name: "GetHandleAttribute", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/handles/{handleId}/attribute", Doc: "Read an attribute of a handle's element",
+		Query: []string{"name"}, Response: typeOf[HandleAttributeResponse]()},
+	{Name: "GetHandleBox", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/handles/{handleId}/box", Doc: "Get the box, visibility and occlusion of a handle's element",
+		Response: typeOf[ElementBoxResponse]()},
+	{Name: "WatchDOM", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/watch", Doc: "Report changes to matching content as dom_changed events",
+		Request: typeOf[WatchRequest](), Response: typeOf[WatchResponse]()},
+	{Name: "ListWatches", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/watch", Doc: "List the DOM watches of a page",
+		Response: typeOf[ListWatchesResponse]()},
+	{Name: "Unwatch", Method: "DELETE", Path: "/sessions/{id}/pages/{pageId}/watch/{watchId}", Doc: "Remove a DOM watch"},
+	{Name: "Wait", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/wait", Doc: "Wait for a selector, URL or predicate",
+		Request: typeOf[WaitRequest](), Response: typeOf[WaitResponse]()},
+	{Name: "Assert", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/assert", Doc: "Check elements, text, URL, cookies and navigation status of a page",
+		Request: typeOf[AssertRequest](), Response: typeOf[AssertResponse]()},
+	{Name: "VisualDiff", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/visual-diff", Doc: "Compare a screenshot of a page with a stored baseline",
+		Request: typeOf[VisualDiffRequest](), Response: typeOf[VisualDiffResponse]()},
+	{Name: "ContentHash", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/content-hash", Doc: "Hash a page's text and structure, scoring the change since earlier hashes",
+		Request: typeOf[ContentHashRequest](), Response: typeOf[ContentHashResponse]()},
+	{Name: "ListForms", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/forms", Doc: "List a page's forms and their fields",
+		Response: typeOf[ListFormsResponse]()},
+	{Name: "FillForm", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/forms/{formIndex}/fill", Doc: "Fill a form's fields by name, optionally submitting it",
+		Request: typeOf[FillFormRequest](), Response: typeOf[FillFormResponse]()},
+	{Name: "DetectCaptcha", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/captcha", Doc: "Detect a CAPTCHA on a page",
+		Response: typeOf[CaptchaResponse]()},
+	{Name: "SolveCaptcha", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/captcha/solve", Doc: "Solve a page's CAPTCHA through the configured solver",
+		Request: typeOf[SolveCaptchaRequest](), Response: typeOf[SolveCaptchaResponse]()},
+	{Name: "ListResources", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/resources", Doc: "List the resources a page loaded",
+		Response: typeOf[ListResourcesResponse]()},
+	{Name: "DownloadResource", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/resources/download", Doc: "Download a resource a page loaded, base64 encoded",
+		Request: typeOf[DownloadResourceRequest](), Response: typeOf[DownloadResourceResponse]()},
+	{Name: "PrintPDF", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/pdf", Doc: "Print a page to PDF",
+		Request: typeOf[PrintPDFRequest](), Binary: true},
+	{Name: "Search", Method: "POST", Path: "/search", Doc: "Search the web for pages to navigate to",
+		Request: typeOf[SearchRequest](), Response: typeOf[SearchResponse]()},
+	{Name: "Research", Method: "POST", Path: "/research", Doc: "Answer a question from the top search results, citing a source for each claim",
+		Request: typeOf[ResearchRequest](), Response: typeOf[ResearchResponse]()},
+	{Name: "StreamEvents", Method: "GET", Path: "/sessions/{id}/events/ws", Doc: "Stream session events, replaying those after an event ID",
+		Query: []string{"after"}, Stream: typeOf[events.Event]()},
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+// unlistedRoutes are the session routes the generated clients leave out, with why
+var unlistedRoutes = map[string]string{
+	"GET /sessions/{id}/events":                    "server-sent events; clients stream over /events/ws",
+	"GET /sessions/{id}/files/*":                   "wildcard path",
+	"DELETE /sessions/{id}/files/*":                "wildcard path",
+	"GET /sessions/{id}/pages/{pageId}/screencast": "WebSocket of frames for browser viewers",
+	"GET /sessions/{id}/pages/{pageId}/takeover":   "WebSocket for the human taking over",
+}
+
+// TestEndpointsCoverRoutes tests that every session, search and research route is in
+// the endpoint table, or is listed as left out, and that the table names no route the
+// server doesn't have
+func TestEndpointsCoverRoutes(t *testing.T) {
+	registered := routes(t)
+
+	listed := map[string]bool{}
+	for _, endpoint := range Endpoints {
+		route := endpoint.Method + " " + endpoint.Path
+		if !registered[route] {
+			t.Errorf("%s: %s is not a registered route", endpoint.Name, route)
+		}
+		listed[route] = true
+	}
+
+	for route := range registered {
+		path := strings.SplitN(route, " ", 2)[1]
+		public := strings.HasPrefix(path, "/sessions") || path == "/search" || path == "/research"
+		if _, unlisted := unlistedRoutes[route]; public && !listed[route] && !unlisted {
+			t.Errorf("%s is missing from Endpoints; add it, or to unlistedRoutes with the reason", route)
+		}
+	}
+	for route := range unlistedRoutes {
+		if listed[route] || !registered[route] {
+			t.Errorf("%s: unlisted route is listed or not registered", route)
+		}
+	}
+}
@@ -2,12 +2,20 @@ package api
 
 import (
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/captcha"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vault"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -15,45 +23,126 @@ import (
 type Handlers struct {
 	sessionManager *session.Manager
 	loadBalancer   *pool.LoadBalancer
+	firefox        *pool.LoadBalancer // nil when no Firefox browsers are configured
+	vault          *vault.Vault
+	captchaSolvers *captcha.Registry
+	recycler       *pool.Recycler
+	profiles       *pool.ProfilePool         // nil when persistent profiles are disabled
+	extensions     *browser.ExtensionCatalog // nil when no extension directory is configured
+	visionModel    vision.Model              // nil when no vision model is configured
+	researcher     *session.Researcher       // nil when no vision model is configured
+	tenants        *tenant.Registry          // Tenants sessions may be shared with or transferred to
+	admission      *pool.AdmissionQueue      // Session creations waiting for a browser with room
+	startedAt      time.Time
 }
 
 // NewHandlers creates a new Handlers instance
-func NewHandlers(manager *session.Manager, loadBalancer *pool.LoadBalancer) *Handlers {
+func NewHandlers(manager *session.Manager, loadBalancer *pool.LoadBalancer, firefox *pool.LoadBalancer, credentialVault *vault.Vault, captchaSolvers *captcha.Registry, recycler *pool.Recycler, profiles *pool.ProfilePool, extensions *browser.ExtensionCatalog, visionModel vision.Model, researcher *session.Researcher, tenants *tenant.Registry) *Handlers {
 	return &Handlers{
 		sessionManager: manager,
 		loadBalancer:   loadBalancer,
+		firefox:        firefox,
+		vault:          credentialVault,
+		captchaSolvers: captchaSolvers,
+		recycler:       recycler,
+		profiles:       profiles,
+		extensions:     extensions,
+		visionModel:    visionModel,
+		researcher:     researcher,
+		tenants:        tenants,
+		admission:      pool.NewAdmissionQueue(pool.AdmissionPolicy{}),
+		startedAt:      time.Now(),
 	}
 }
 
 // CreateSession handles POST /sessions
 func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 	var req CreateSessionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		// Empty body is acceptable
-		req = CreateSessionRequest{}
+	// Empty body is acceptable
+	if !decodeOptionalJSON(w, r, &req) {
+		return
 	}
 	
-	// Validate agent ID
-	if req.AgentID == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "agent_id is required")
-		return
+	audit.SetActor(r.Context(), req.AgentID)
+
+	// Sessions belong to the tenant the API key authenticated as
+	caller := tenant.FromContext(r.Context())
+	tenantID := tenant.IDFromContext(r.Context())
+	if req.Template == "" && caller != nil {
+		req.Template = caller.DefaultTemplate
+	}
+	// Templates are managed by admins; only inline init scripts come from the caller
+	if req.Options != nil {
+		for i, script := range req.Options.InitScripts {
+			if !allowScript(w, r, fmt.Sprintf("options.init_scripts[%d]", i), script) {
+				return
+			}
+		}
 	}
 	
-	// Select port (use provided or load balance)
-	port := req.BrowserPort
-	if port == 0 {
-		process, err := h.loadBalancer.SelectProcess()
-		if err != nil {
-			writeError(w, http.StatusServiceUnavailable, 
-				ErrCodeInternalError, "No available browsers")
+	// Resolve the template and inline options before touching the browser
+	opts, err := h.sessionManager.ResolveSessionOptions(tenantID, req.Template, req.Options)
+	if err != nil {
+		if errors.Is(err, session.ErrTemplateNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeTemplateNotFound, err.Error())
 			return
 		}
-		port = process.GetPort()
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Waiting for capacity outlives the server's default write timeout
+	queue := h.admission.Policy()
+	if queue.Size > 0 {
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(queue.MaxWait + 15*time.Second)); err != nil {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to extend response deadline")
+			return
+		}
+	}
+
+	// Select port (use provided or load balance); a profile brings its own browser.
+	// While every browser is full the request waits in the admission queue.
+	var sess *session.Session
+	var selectErr error
+	err = h.admission.Admit(r.Context(), func() error {
+		port := req.BrowserPort
+		if port == 0 && (opts == nil || opts.Profile == "") {
+			process, err := h.selectTenantProcess(tenantID, caller, opts)
+			if selectErr = err; err != nil {
+				return err
+			}
+			port = process.GetPort()
+		}
+
+		// Create session with name
+		var err error
+		sess, err = h.sessionManager.CreateSessionWithOptions(r.Context(), tenantID, req.AgentID, req.SessionName, port, req.Template, opts)
+		if errors.Is(err, session.ErrGlobalSessionLimit) {
+			return fmt.Errorf("%w: %v", pool.ErrAtCapacity, err)
+		}
+		return err
+	})
+	if writeCapacityError(w, err) {
+		return
+	}
+	if selectErr != nil {
+		if errors.Is(selectErr, session.ErrEngineUnavailable) {
+			writeError(w, http.StatusServiceUnavailable, ErrCodeEngineUnavailable, selectErr.Error())
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, 
+			ErrCodeInternalError, "No available browsers")
+		return
 	}
-	
-	// Create session with name
-	sess, err := h.sessionManager.CreateSessionWithName(req.AgentID, req.SessionName, port)
 	if err != nil {
+		if errors.Is(err, session.ErrTenantSessionLimit) || errors.Is(err, session.ErrTenantProcessLimit) || errors.Is(err, session.ErrTenantBandwidthLimit) {
+			writeError(w, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+			return
+		}
+		if errors.Is(err, session.ErrDraining) {
+			writeError(w, http.StatusServiceUnavailable, ErrCodeDraining, err.Error())
+			return
+		}
 		// Check for specific errors
 		if err == session.ErrSessionNameConflict {
 			writeError(w, http.StatusConflict, "SESSION_NAME_CONFLICT", 
@@ -64,21 +153,30 @@ func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusTooManyRequests, "SESSION_LIMIT_REACHED", err.Error())
 			return
 		}
+		if writeProfileError(w, err) {
+			return
+		}
+		if errors.Is(err, session.ErrClientCertificate) || errors.Is(err, session.ErrEgressUnavailable) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
 		
 		writeError(w, http.StatusInternalServerError, 
 			ErrCodeSessionCreateFailed, err.Error())
 		return
 	}
 	
-	// Increment session count on process
-	processes := h.loadBalancer.GetProcesses()
+	// Increment session count on process (profile browsers aren't in the pool)
+	processes := h.allProcesses()
 	for _, process := range processes {
-		if process.GetPort() == port {
+		if process.GetPort() == sess.ProcessPort {
 			process.IncrementSessionCount()
 			break
 		}
 	}
 	
+	audit.SetSession(r.Context(), sess.ID, "")
+
 	response := CreateSessionResponse{
 		SessionID:   sess.ID,
 		SessionName: sess.Name,
@@ -90,6 +188,22 @@ func (h *Handlers) CreateSession(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, response)
 }
 
+// writeCapacityError writes the response for a session that found no browser with
+// room, directly or after queueing. It reports whether err was such an error.
+func writeCapacityError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, pool.ErrQueueFull):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, ErrCodeQueueFull, err.Error())
+	case errors.Is(err, pool.ErrQueueTimeout), errors.Is(err, pool.ErrAtCapacity):
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, ErrCodeAtCapacity, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
 // DestroySession handles DELETE /sessions/{id}
 func (h *Handlers) DestroySession(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
@@ -99,6 +213,7 @@ func (h *Handlers) DestroySession(w http.ResponseWriter, r *http.Request) {
 	sess, err := h.sessionManager.GetSession(sessionID)
 	if err == nil {
 		processPort = sess.ProcessPort
+		audit.SetSession(r.Context(), sessionID, sess.AgentID) // Gone from memory once destroyed
 	}
 
 	// Destroy session (works whether in memory or Redis only)
@@ -109,7 +224,7 @@ func (h *Handlers) DestroySession(w http.ResponseWriter, r *http.Request) {
 
 	// Decrement session count on the process if we found it
 	if processPort > 0 {
-		processes := h.loadBalancer.GetProcesses()
+		processes := h.allProcesses()
 		for _, process := range processes {
 			if process.GetPort() == processPort {
 				process.DecrementSessionCount()
@@ -132,16 +247,20 @@ func (h *Handlers) GetSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pageIDs := sess.Pages()
 	response := GetSessionResponse{
 		SessionID:    sess.ID,
 		SessionName:  sess.Name,
 		AgentID:      sess.AgentID,
 		ContextID:    sess.ContextID,
-		PageIDs:      sess.PageIDs,
-		PageCount:    len(sess.PageIDs),
+		PageIDs:      pageIDs,
+		PageCount:    len(pageIDs),
 		CreatedAt:    sess.CreatedAt,
-		LastActivity: sess.LastActivity,
-		Status:       sess.Status,
+		LastActivity: sess.LastActive(),
+		Status:       sess.CurrentStatus(),
+	}
+	if sess.CDPClient != nil {
+		response.Connection = sess.CDPClient.State()
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -149,7 +268,7 @@ func (h *Handlers) GetSession(w http.ResponseWriter, r *http.Request) {
 
 // ListSessions handles GET /sessions
 func (h *Handlers) ListSessions(w http.ResponseWriter, r *http.Request) {
-	sessions := h.sessionManager.ListSessions()
+	sessions := h.sessionManager.ListTenantSessions(tenant.IDFromContext(r.Context()))
 
 	sessionInfos := make([]SessionInfo, 0, len(sessions))
 	for _, sess := range sessions {
@@ -158,10 +277,11 @@ func (h *Handlers) ListSessions(w http.ResponseWriter, r *http.Request) {
 			SessionName:  sess.Name,
 			AgentID:      sess.AgentID,
 			ContextID:    sess.ContextID,
-			PageCount:    len(sess.PageIDs),
+			PageCount:    len(sess.Pages()),
 			CreatedAt:    sess.CreatedAt,
-			LastActivity: sess.LastActivity,
-			Status:       sess.Status,
+			LastActivity: sess.LastActive(),
+			Status:       sess.CurrentStatus(),
+			Labels:       sess.Labels(),
 		})
 	}
 
@@ -173,25 +293,56 @@ func (h *Handlers) ListSessions(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, response)
 }
 
+// navigationErrorCodes maps navigation failure kinds to API error codes
+var navigationErrorCodes = map[string]string{
+	session.NavigationDNS:          ErrCodeNavigationDNS,
+	session.NavigationTLS:          ErrCodeNavigationTLS,
+	session.NavigationConnection:   ErrCodeNavigationConnection,
+	session.NavigationTimeout:      ErrCodeNavigationTimeout,
+	session.NavigationBlocked:      ErrCodeNavigationBlocked,
+	session.NavigationAborted:      ErrCodeNavigationAborted,
+	session.NavigationInterstitial: ErrCodeNavigationInterstitial,
+}
+
+// writeNavigationError reports a navigation that reached no site document. The
+// failure is upstream of this server, so it is a gateway error rather than a 500.
+func writeNavigationError(w http.ResponseWriter, err *session.NavigationError) {
+	code, known := navigationErrorCodes[err.Kind]
+	if !known {
+		code = ErrCodeNavigationNetwork
+	}
+
+	status := http.StatusBadGateway
+	if err.Kind == session.NavigationTimeout {
+		status = http.StatusGatewayTimeout
+	}
+	writeError(w, status, code, err.Error())
+}
+
 // Navigate handles POST /sessions/{id}/navigate
 func (h *Handlers) Navigate(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
 
 	var req NavigateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON body")
-		return
-	}
-
-	if req.URL == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "URL is required")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	pageID, err := h.sessionManager.Navigate(sessionID, req.URL)
+	pageID, err := h.sessionManager.NavigateWithOptions(r.Context(), sessionID, req.URL, session.NavigateOptions{
+		BypassServiceWorker: req.BypassServiceWorker,
+	})
 	if err != nil {
-		if err.Error() == "failed to get session: session not found: "+sessionID {
+		var navErr *session.NavigationError
+		if errors.Is(err, session.ErrSessionNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrTenantBandwidthLimit) {
+			writeError(w, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+		} else if errors.Is(err, session.ErrEngineUnsupported) {
+			writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+		} else if errors.As(err, &navErr) {
+			writeNavigationError(w, navErr)
 		} else {
 			writeError(w, http.StatusInternalServerError, ErrCodeNavigationFailed, err.Error())
 		}
@@ -199,9 +350,28 @@ func (h *Handlers) Navigate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := NavigateResponse{
-		SessionID: sessionID,
-		PageID:    pageID,
-		URL:       req.URL,
+		SessionID:    sessionID,
+		PageID:       pageID,
+		URL:          req.URL,
+		RequestedURL: req.URL,
+		Redirects:    []session.RedirectHop{},
+	}
+
+	if sess, err := h.sessionManager.GetSession(sessionID); err == nil {
+		// Report where the navigation landed, so login walls and error pages are visible
+		if nav := sess.LastNavigation(pageID); nav != nil {
+			response.URL = nav.URL
+			response.Status = nav.Status
+			response.StatusText = nav.StatusText
+			response.Redirects = nav.Redirects
+			response.Failure = nav.Failure
+			response.Security = nav.Security
+		}
+
+		// Surface a CAPTCHA found while loading the page
+		if info := sess.LastCaptcha(pageID); info != nil && info.Detected {
+			response.Captcha = info
+		}
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -212,26 +382,37 @@ func (h *Handlers) ExecuteJS(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
 
 	var req ExecuteJSRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON body")
-		return
-	}
-
-	if req.PageID == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "page_id is required")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	if req.Script == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "script is required")
+	if !allowScript(w, r, "script", req.Script) {
 		return
 	}
 
-	result, err := h.sessionManager.ExecuteJavascript(sessionID, req.PageID, req.Script)
+	var result interface{}
+	var delta *session.PageDelta
+	var err error
+	if req.VerifyChange || req.VerifyScreenshot {
+		result, delta, err = h.sessionManager.ExecuteJavascriptVerified(r.Context(), sessionID, req.PageID, req.Script, req.Args, req.ScriptOptions, req.VerifyScreenshot)
+	} else {
+		result, err = h.sessionManager.RunScript(r.Context(), sessionID, req.PageID, req.Script, req.Args, req.ScriptOptions)
+	}
 	if err != nil {
-		if err.Error() == "failed to get session: session not found: "+sessionID {
+		var scriptErr *session.ScriptError
+		if errors.Is(err, session.ErrSessionNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
-		} else if err.Error() == "page not found in session: "+req.PageID {
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrEngineUnsupported) {
+			writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+		} else if errors.Is(err, session.ErrInvalidSerialization) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else if errors.Is(err, session.ErrResponseTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, ErrCodeResponseTooLarge, err.Error())
+		} else if errors.As(err, &scriptErr) {
+			writeScriptError(w, scriptErr)
 		} else {
 			writeError(w, http.StatusInternalServerError, ErrCodeExecutionFailed, err.Error())
 		}
@@ -239,9 +420,10 @@ func (h *Handlers) ExecuteJS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := ExecuteJSResponse{
-		SessionID: sessionID,
-		PageID:    req.PageID,
-		Result:    result,
+		SessionID:  sessionID,
+		PageID:     req.PageID,
+		Result:     result,
+		PageChange: delta,
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -252,28 +434,55 @@ func (h *Handlers) CaptureScreenshot(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
 
 	var req ScreenshotRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.PageID == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "page_id is required")
+	// Accept: image/png asks for the image itself instead of base64 in JSON
+	raw := prefersMediaType(r, "image/png")
+	w.Header().Add("Vary", "Accept")
+	if raw && req.Annotate {
+		writeError(w, http.StatusNotAcceptable, ErrCodeInvalidRequest, "annotated screenshots return a legend and need a JSON response")
+		return
+	}
+	// Annotating draws the marks into the page for the capture
+	if req.Annotate && isReadOnly(r) {
+		writeError(w, http.StatusForbidden, ErrCodeForbidden, "annotated screenshots change the page, which read-only access doesn't allow")
 		return
 	}
 
-	screenshotBytes, err := h.sessionManager.CaptureScreenshot(sessionID, req.PageID)
+	var screenshotBytes []byte
+	var legend []session.ElementMark
+	var ratio float64
+	var err error
+	if req.Annotate {
+		screenshotBytes, legend, ratio, err = h.sessionManager.CaptureAnnotatedScreenshot(r.Context(), sessionID, req.PageID)
+	} else {
+		screenshotBytes, err = h.sessionManager.CaptureScreenshot(r.Context(), sessionID, req.PageID)
+	}
 	if err != nil {
-		if err.Error() == "failed to get session: session not found: "+sessionID {
+		if errors.Is(err, session.ErrSessionNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
-		} else if err.Error() == "page not found in session: "+req.PageID {
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrResponseTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, ErrCodeResponseTooLarge, err.Error())
 		} else {
 			writeError(w, http.StatusInternalServerError, ErrCodeScreenshotFailed, err.Error())
 		}
 		return
 	}
 
+	if raw {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", strconv.Itoa(len(screenshotBytes)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(screenshotBytes)
+		return
+	}
+
 	encoded := base64.StdEncoding.EncodeToString(screenshotBytes)
 
 	format := req.Format
@@ -282,11 +491,13 @@ func (h *Handlers) CaptureScreenshot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := ScreenshotResponse{
-		SessionID:  sessionID,
-		PageID:     req.PageID,
-		Screenshot: encoded,
-		Format:     format,
-		Size:       len(screenshotBytes),
+		SessionID:        sessionID,
+		PageID:           req.PageID,
+		Screenshot:       encoded,
+		Format:           format,
+		Size:             len(screenshotBytes),
+		Legend:           legend,
+		DevicePixelRatio: ratio,
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -297,18 +508,25 @@ func (h *Handlers) GetPageContent(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
 	pageID := chi.URLParam(r, "pageId")
 
-	content, err := h.sessionManager.GetPageContent(sessionID, pageID)
+	content, err := h.sessionManager.GetPageContent(r.Context(), sessionID, pageID)
 	if err != nil {
-		if err.Error() == "failed to get session: session not found: "+sessionID {
+		if errors.Is(err, session.ErrSessionNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
-		} else if err.Error() == "page not found in session: "+pageID {
+		} else if errors.Is(err, session.ErrPageNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrResponseTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, ErrCodeResponseTooLarge, err.Error())
 		} else {
 			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
 		}
 		return
 	}
 
+	// Pollers send back the ETag and get a bodiless 304 while the HTML is unchanged
+	if writeNotModified(w, r, contentETag(content)) {
+		return
+	}
+
 	response := GetPageContentResponse{
 		SessionID: sessionID,
 		PageID:    pageID,
@@ -324,10 +542,12 @@ func (h *Handlers) ClosePage(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
 	pageID := chi.URLParam(r, "pageId")
 
-	if err := h.sessionManager.ClosePage(sessionID, pageID); err != nil {
-		if err.Error() == "failed to get session: session not found: "+sessionID {
+	if err := h.sessionManager.ClosePage(r.Context(), sessionID, pageID); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
-		} else if err.Error() == "page not found in session: "+pageID {
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
 		} else {
 			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
@@ -343,21 +563,15 @@ func (h *Handlers) AnalyzePage(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
 
 	var req AnalyzePageRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON body")
-		return
-	}
-
-	if req.PageID == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "page_id is required")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	analysis, err := h.sessionManager.AnalyzePage(sessionID, req.PageID)
+	analysis, err := h.sessionManager.AnalyzePage(r.Context(), sessionID, req.PageID)
 	if err != nil {
-		if err.Error() == "failed to get session: session not found: "+sessionID {
+		if errors.Is(err, session.ErrSessionNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
-		} else if err.Error() == "page not found in session: "+req.PageID {
+		} else if errors.Is(err, session.ErrPageNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
 		} else {
 			writeError(w, http.StatusInternalServerError, ErrCodeAnalysisFailed, err.Error())
@@ -366,9 +580,11 @@ func (h *Handlers) AnalyzePage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := AnalyzePageResponse{
-		SessionID: sessionID,
-		PageID:    req.PageID,
-		Analysis:  analysis,
+		SessionID:           sessionID,
+		PageID:              req.PageID,
+		Analysis:            analysis,
+		InstructionLanguage: req.InstructionLanguage,
+		TranslateLabels:     session.LanguagesDiffer(req.InstructionLanguage, analysis.Language),
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -379,21 +595,15 @@ func (h *Handlers) GetAccessibilityTree(w http.ResponseWriter, r *http.Request)
 	sessionID := chi.URLParam(r, "id")
 
 	var req AccessibilityTreeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.PageID == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "page_id is required")
-		return
-	}
-
-	tree, err := h.sessionManager.GetAccessibilityTree(sessionID, req.PageID)
+	tree, err := h.sessionManager.GetAccessibilityTree(r.Context(), sessionID, req.PageID)
 	if err != nil {
-		if err.Error() == "failed to get session: session not found: "+sessionID {
+		if errors.Is(err, session.ErrSessionNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
-		} else if err.Error() == "page not found in session: "+req.PageID {
+		} else if errors.Is(err, session.ErrPageNotFound) {
 			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
 		} else {
 			writeError(w, http.StatusInternalServerError, ErrCodeAccessibilityFailed, err.Error())
@@ -419,7 +629,7 @@ func (h *Handlers) ListAgentSessions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	sessions, err := h.sessionManager.ListAgentSessions(agentID)
+	sessions, err := h.sessionManager.ListAgentSessions(tenant.IDFromContext(r.Context()), agentID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
 		return
@@ -431,10 +641,10 @@ func (h *Handlers) ListAgentSessions(w http.ResponseWriter, r *http.Request) {
 		summaries[i] = SessionSummary{
 			SessionID:    sess.ID,
 			SessionName:  sess.Name,
-			Status:       sess.Status,
-			PageCount:    len(sess.PageIDs),
+			Status:       sess.CurrentStatus(),
+			PageCount:    len(sess.Pages()),
 			CreatedAt:    sess.CreatedAt,
-			LastActivity: sess.LastActivity,
+			LastActivity: sess.LastActive(),
 		}
 	}
 	
@@ -450,23 +660,30 @@ func (h *Handlers) ListAgentSessions(w http.ResponseWriter, r *http.Request) {
 // ResumeSession handles POST /sessions/resume
 func (h *Handlers) ResumeSession(w http.ResponseWriter, r *http.Request) {
 	var req ResumeSessionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 	
-	if req.AgentID == "" || req.SessionName == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, 
-			"agent_id and session_name are required")
-		return
-	}
+	audit.SetActor(r.Context(), req.AgentID)
 	
 	// Resume session by name
-	sess, err := h.sessionManager.ResumeSessionByName(req.AgentID, req.SessionName)
+	sess, err := h.sessionManager.ResumeSessionByName(r.Context(), tenant.IDFromContext(r.Context()), req.AgentID, req.SessionName)
 	if err != nil {
+		if errors.Is(err, session.ErrTenantSessionLimit) || errors.Is(err, session.ErrTenantProcessLimit) || errors.Is(err, session.ErrTenantBandwidthLimit) {
+			writeError(w, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+			return
+		}
+		if errors.Is(err, session.ErrDraining) {
+			writeError(w, http.StatusServiceUnavailable, ErrCodeDraining, err.Error())
+			return
+		}
+		if writeProfileError(w, err) {
+			return
+		}
 		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
 		return
 	}
+	audit.SetSession(r.Context(), sess.ID, "")
 	
 	response := ResumeSessionResponse{
 		SessionID:   sess.ID,
@@ -507,16 +724,10 @@ func (h *Handlers) RenameSession(w http.ResponseWriter, r *http.Request) {
 	sessionID := chi.URLParam(r, "id")
 	
 	var req RenameSessionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON body")
+	if !decodeJSON(w, r, &req) {
 		return
 	}
-	
-	if req.SessionName == "" {
-		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "session_name is required")
-		return
-	}
-	
+
 	// Rename the session
 	if err := h.sessionManager.RenameSession(sessionID, req.SessionName); err != nil {
 		if err.Error() == fmt.Sprintf("session name '%s' already exists", req.SessionName) {
@@ -551,6 +762,8 @@ func (h *Handlers) CloseSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.SetSession(r.Context(), sessionID, sess.AgentID)
+
 	// Close session (keeps in Redis)
 	if err := h.sessionManager.CloseSession(sessionID); err != nil {
 		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
@@ -558,7 +771,7 @@ func (h *Handlers) CloseSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Decrement session count on the process
-	processes := h.loadBalancer.GetProcesses()
+	processes := h.allProcesses()
 	for _, process := range processes {
 		if process.GetPort() == sess.ProcessPort {
 			process.DecrementSessionCount()
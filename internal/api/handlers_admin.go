@@ -0,0 +1,197 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/go-chi/chi/v5"
+)
+
+// adminProcess resolves the {port} URL param to a pool process, writing the error response if it can't
+func (h *Handlers) adminProcess(w http.ResponseWriter, r *http.Request) *pool.ManagedProcess {
+	port, err := strconv.Atoi(chi.URLParam(r, "port"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "port must be a number")
+		return nil
+	}
+
+	process := h.recycler.Pool().GetProcess(port)
+	if process == nil {
+		writeError(w, http.StatusNotFound, ErrCodeProcessNotFound, fmt.Sprintf("no browser process on port %d", port))
+		return nil
+	}
+
+	return process
+}
+
+// AdminListProcesses handles GET /admin/processes
+func (h *Handlers) AdminListProcesses(w http.ResponseWriter, r *http.Request) {
+	processes := h.recycler.Pool().GetProcesses()
+
+	infos := make([]AdminProcessInfo, 0, len(processes))
+	for _, process := range processes {
+		infos = append(infos, AdminProcessInfo{
+			ProcessStatus: ProcessStatus{
+				ProcessMetrics: process.GetMetrics(),
+				Healthy:        process.IsHealthy(),
+			},
+			SessionIDs: h.sessionManager.SessionsOnPort(process.GetPort()),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, AdminListProcessesResponse{
+		Processes: infos,
+		Count:     len(infos),
+	})
+}
+
+// AdminDrainProcess handles POST /admin/processes/{port}/drain
+func (h *Handlers) AdminDrainProcess(w http.ResponseWriter, r *http.Request) {
+	process := h.adminProcess(w, r)
+	if process == nil {
+		return
+	}
+
+	// Existing sessions keep running; only new sessions are steered away
+	process.SetDraining(true)
+	slog.Info("browser process drained by admin", "port", process.GetPort())
+
+	writeJSON(w, http.StatusOK, AdminOperationResponse{Operation: "drain", Status: "done", Port: process.GetPort()})
+}
+
+// AdminUndrainProcess handles DELETE /admin/processes/{port}/drain
+func (h *Handlers) AdminUndrainProcess(w http.ResponseWriter, r *http.Request) {
+	process := h.adminProcess(w, r)
+	if process == nil {
+		return
+	}
+
+	if h.recycler.IsBusy(process) {
+		writeError(w, http.StatusConflict, ErrCodeProcessBusy, pool.ErrProcessBusy.Error())
+		return
+	}
+
+	process.SetDraining(false)
+	slog.Info("browser process returned to rotation by admin", "port", process.GetPort())
+
+	writeJSON(w, http.StatusOK, AdminOperationResponse{Operation: "undrain", Status: "done", Port: process.GetPort()})
+}
+
+// AdminRestartProcess handles POST /admin/processes/{port}/restart
+func (h *Handlers) AdminRestartProcess(w http.ResponseWriter, r *http.Request) {
+	process := h.adminProcess(w, r)
+	if process == nil {
+		return
+	}
+
+	var req AdminRestartRequest
+	// Empty body means a graceful restart
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	if h.recycler.IsBusy(process) {
+		writeError(w, http.StatusConflict, ErrCodeProcessBusy, pool.ErrProcessBusy.Error())
+		return
+	}
+
+	drainTimeout := h.recycler.Policy().DrainTimeout
+	if req.Force {
+		drainTimeout = 0
+	}
+
+	// Draining can take minutes, so the restart outlives the request
+	port := process.GetPort()
+	go func() {
+		if err := h.recycler.Restart(process, drainTimeout, "admin request"); err != nil {
+			slog.Error("admin restart failed", "port", port, "error", err)
+		}
+	}()
+
+	writeJSON(w, http.StatusAccepted, AdminOperationResponse{Operation: "restart", Status: "accepted", Port: port})
+}
+
+// AdminResizePool handles PUT /admin/pool
+func (h *Handlers) AdminResizePool(w http.ResponseWriter, r *http.Request) {
+	var req AdminResizeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Size < pool.MinPoolSize || req.Size > pool.MaxPoolSize {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+			fmt.Sprintf("size must be between %d and %d", pool.MinPoolSize, pool.MaxPoolSize))
+		return
+	}
+
+	drainTimeout := h.recycler.Policy().DrainTimeout
+	if req.Force {
+		drainTimeout = 0
+	}
+
+	// Starting or draining browsers takes a while, so resize in the background
+	go func() {
+		if err := h.recycler.Resize(req.Size, drainTimeout); err != nil {
+			slog.Error("admin pool resize failed", "size", req.Size, "error", err)
+		}
+	}()
+
+	writeJSON(w, http.StatusAccepted, AdminOperationResponse{Operation: "resize", Status: "accepted", Size: req.Size})
+}
+
+// AdminEvictSession handles DELETE /admin/sessions/{id}
+func (h *Handlers) AdminEvictSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	// Eviction ignores takeovers and ownership; it is the operator's escape hatch
+	var processPort int
+	if sess, err := h.sessionManager.GetSession(sessionID); err == nil {
+		processPort = sess.ProcessPort
+	}
+
+	if err := h.sessionManager.DestroySession(sessionID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	if processPort > 0 {
+		if process := h.recycler.Pool().GetProcess(processPort); process != nil {
+			process.DecrementSessionCount()
+		}
+	}
+
+	slog.Info("session evicted by admin", "session_id", sessionID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminStartDrain handles POST /admin/drain
+func (h *Handlers) AdminStartDrain(w http.ResponseWriter, r *http.Request) {
+	var req AdminDrainRequest
+	// Empty body uses the configured drain timeout
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+	if req.TimeoutMS < 0 {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "timeout_ms must not be negative")
+		return
+	}
+
+	// The server shuts down once the drain finishes, so it can't be undone
+	status, started := h.sessionManager.StartDrain(time.Duration(req.TimeoutMS) * time.Millisecond)
+	if !started {
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+
+	slog.Info("drain started by admin")
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// AdminDrainStatus handles GET /admin/drain
+func (h *Handlers) AdminDrainStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.sessionManager.DrainStatus())
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+)
+
+// maxDestroyBatch bounds the session IDs one destroy-batch call may name
+const maxDestroyBatch = 1000
+
+// parseSessionFilter reads label, older_than and agent_id from the query. Each label
+// is key=value, or a bare key to match any value; repeat it to require several.
+func parseSessionFilter(r *http.Request) (session.SessionFilter, error) {
+	query := r.URL.Query()
+	filter := session.SessionFilter{AgentID: query.Get("agent_id")}
+
+	for _, label := range query["label"] {
+		key, value, _ := strings.Cut(label, "=")
+		if key == "" {
+			return filter, fmt.Errorf("label needs a key: %q", label)
+		}
+		if filter.Labels == nil {
+			filter.Labels = make(map[string]string)
+		}
+		filter.Labels[key] = value
+	}
+
+	if olderThan := query.Get("older_than"); olderThan != "" {
+		duration, err := time.ParseDuration(olderThan)
+		if err != nil || duration <= 0 {
+			return filter, fmt.Errorf("older_than must be a positive duration such as 30m or 2h")
+		}
+		filter.OlderThan = duration
+	}
+
+	return filter, nil
+}
+
+// destroySessions destroys sessions concurrently and gives their slots back to the
+// browser processes they ran on
+func (h *Handlers) destroySessions(sessionIDs []string) DestroySessionsResponse {
+	// Ports are looked up first: destroyed sessions are gone from memory
+	ports := make(map[string]int, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		if sess, err := h.sessionManager.GetSession(sessionID); err == nil {
+			ports[sessionID] = sess.ProcessPort
+		}
+	}
+
+	outcomes := h.sessionManager.DestroySessions(sessionIDs)
+
+	response := DestroySessionsResponse{Results: outcomes}
+	processes := h.allProcesses()
+	for _, outcome := range outcomes {
+		if !outcome.Destroyed {
+			response.Failed++
+			continue
+		}
+		response.Destroyed++
+
+		if port := ports[outcome.SessionID]; port > 0 {
+			for _, process := range processes {
+				if process.GetPort() == port {
+					process.DecrementSessionCount()
+					break
+				}
+			}
+		}
+	}
+	return response
+}
+
+// DestroySessionsByFilter handles DELETE /sessions?label=...&older_than=...
+func (h *Handlers) DestroySessionsByFilter(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSessionFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	// An empty filter would match every session of the tenant
+	if filter.AgentID == "" && len(filter.Labels) == 0 && filter.OlderThan == 0 {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "at least one of label, older_than and agent_id is required")
+		return
+	}
+
+	matched := h.sessionManager.FindTenantSessions(tenant.IDFromContext(r.Context()), filter)
+	sessionIDs := make([]string, len(matched))
+	for i, sess := range matched {
+		sessionIDs[i] = sess.ID
+	}
+
+	writeJSON(w, http.StatusOK, h.destroySessions(sessionIDs))
+}
+
+// DestroySessionBatch handles POST /sessions/destroy-batch
+func (h *Handlers) DestroySessionBatch(w http.ResponseWriter, r *http.Request) {
+	var req DestroySessionBatchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.SessionIDs) > maxDestroyBatch {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, fmt.Sprintf("at most %d session_ids per batch", maxDestroyBatch))
+		return
+	}
+
+	// Other tenants' sessions are reported as not found, as they are for single calls
+	tenantID := tenant.IDFromContext(r.Context())
+	seen := make(map[string]bool, len(req.SessionIDs))
+	owned := make([]string, 0, len(req.SessionIDs))
+	rejected := make([]session.DestroyOutcome, 0)
+	for _, sessionID := range req.SessionIDs {
+		if seen[sessionID] {
+			continue
+		}
+		seen[sessionID] = true
+
+		if owner, err := h.sessionManager.SessionTenant(sessionID); err != nil || owner != tenantID {
+			rejected = append(rejected, session.DestroyOutcome{SessionID: sessionID, Error: "session not found: " + sessionID})
+			continue
+		}
+		owned = append(owned, sessionID)
+	}
+
+	response := h.destroySessions(owned)
+	response.Results = append(response.Results, rejected...)
+	response.Failed += len(rejected)
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// CaptureCanvas handles POST /sessions/{id}/pages/{pageId}/canvas
+func (h *Handlers) CaptureCanvas(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req CaptureCanvasRequest
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	canvases, total, err := h.sessionManager.CaptureCanvases(r.Context(), sessionID, pageID, session.CanvasRequest{
+		Selector:       req.Selector,
+		SelectorEngine: req.SelectorEngine,
+		Method:         req.Method,
+		Format:         req.Format,
+		Quality:        req.Quality,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrElementNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeElementNotFound, err.Error())
+		} else if errors.Is(err, session.ErrInvalidCanvas) || errors.Is(err, session.ErrInvalidSelector) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else if errors.Is(err, session.ErrEngineUnsupported) {
+			writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeCanvasFailed, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CaptureCanvasResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		Canvases:  canvases,
+		Count:     len(canvases),
+		Total:     total,
+	})
+}
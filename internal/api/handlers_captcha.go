@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/captcha"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// DetectCaptcha handles GET /sessions/{id}/pages/{pageId}/captcha
+func (h *Handlers) DetectCaptcha(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	info, err := h.sessionManager.DetectCaptcha(r.Context(), sessionID, pageID)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	response := CaptchaResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		Captcha:   info,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// SolveCaptcha handles POST /sessions/{id}/pages/{pageId}/captcha/solve
+func (h *Handlers) SolveCaptcha(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req SolveCaptchaRequest
+	// Empty body is acceptable (default solver)
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		if req.Manual {
+			timeout = session.DefaultManualCaptchaTimeout
+		} else {
+			timeout = session.DefaultCaptchaSolveTimeout
+		}
+	}
+
+	// Solving outlives the server's default write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 15*time.Second)); err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to extend response deadline")
+		return
+	}
+
+	var result *session.CaptchaSolveResult
+	var err error
+
+	if req.Manual {
+		result, err = h.sessionManager.AwaitManualCaptcha(r.Context(), sessionID, pageID, timeout)
+	} else {
+		solver, solverErr := h.captchaSolvers.Get(req.Solver)
+		if solverErr != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeSolverNotFound,
+				"No captcha solver configured with that name (use manual: true for human takeover)")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		result, err = h.sessionManager.SolveCaptcha(ctx, sessionID, pageID, solver)
+	}
+
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrNoCaptcha) {
+			writeError(w, http.StatusConflict, ErrCodeCaptchaNotFound, err.Error())
+		} else if errors.Is(err, captcha.ErrUnsupportedProvider) {
+			writeError(w, http.StatusUnprocessableEntity, ErrCodeCaptchaFailed, err.Error())
+		} else {
+			writeError(w, http.StatusBadGateway, ErrCodeCaptchaFailed, err.Error())
+		}
+		return
+	}
+
+	response := SolveCaptchaResponse{
+		SessionID:          sessionID,
+		PageID:             pageID,
+		CaptchaSolveResult: result,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateCheckpoint handles POST /sessions/{id}/checkpoints
+func (h *Handlers) CreateCheckpoint(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	var req CreateCheckpointRequest
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	checkpoint, err := h.sessionManager.CreateCheckpoint(r.Context(), sessionID, req.Label)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrCheckpointLimit) {
+			writeError(w, http.StatusConflict, ErrCodeCheckpointLimit, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, checkpoint)
+}
+
+// ListCheckpoints handles GET /sessions/{id}/checkpoints
+func (h *Handlers) ListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	sess, err := h.sessionManager.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	checkpoints := make([]session.Checkpoint, 0)
+	for _, checkpoint := range sess.Checkpoints() {
+		checkpoints = append(checkpoints, *checkpoint)
+	}
+	writeJSON(w, http.StatusOK, ListCheckpointsResponse{
+		SessionID:   sessionID,
+		Checkpoints: checkpoints,
+		Count:       len(checkpoints),
+	})
+}
+
+// DeleteCheckpoint handles DELETE /sessions/{id}/checkpoints/{checkpointId}
+func (h *Handlers) DeleteCheckpoint(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if err := h.sessionManager.DeleteCheckpoint(sessionID, chi.URLParam(r, "checkpointId")); err != nil {
+		if errors.Is(err, session.ErrCheckpointNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeCheckpointNotFound, err.Error())
+		} else {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BranchSession handles POST /sessions/{id}/checkpoints/{checkpointId}/branch
+func (h *Handlers) BranchSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	checkpointID := chi.URLParam(r, "checkpointId")
+
+	var req BranchSessionRequest
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	source, err := h.sessionManager.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+	if _, err := source.Checkpoint(checkpointID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeCheckpointNotFound, err.Error())
+		return
+	}
+
+	// The branch lands on the pool like a new session; it can't share the profile's browser
+	port := req.BrowserPort
+	if port == 0 {
+		process, err := h.selectTenantProcess(source.TenantID, tenant.FromContext(r.Context()), session.BranchOptions(source.Options))
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, ErrCodeInternalError, "No available browsers")
+			return
+		}
+		port = process.GetPort()
+	}
+
+	branch, err := h.sessionManager.BranchSession(r.Context(), sessionID, checkpointID, req.SessionName, port)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrTenantSessionLimit), errors.Is(err, session.ErrTenantProcessLimit), errors.Is(err, session.ErrTenantBandwidthLimit):
+			writeError(w, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+		case errors.Is(err, session.ErrDraining):
+			writeError(w, http.StatusServiceUnavailable, ErrCodeDraining, err.Error())
+		case errors.Is(err, session.ErrSessionNameConflict):
+			writeError(w, http.StatusConflict, "SESSION_NAME_CONFLICT",
+				fmt.Sprintf("Session name '%s' already exists", req.SessionName))
+		case errors.Is(err, session.ErrSessionLimitReached):
+			writeError(w, http.StatusTooManyRequests, "SESSION_LIMIT_REACHED", err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, ErrCodeSessionCreateFailed, err.Error())
+		}
+		return
+	}
+
+	for _, process := range h.allProcesses() {
+		if process.GetPort() == branch.ProcessPort {
+			process.IncrementSessionCount()
+			break
+		}
+	}
+
+	audit.SetSession(r.Context(), branch.ID, branch.AgentID)
+
+	writeJSON(w, http.StatusCreated, BranchSessionResponse{
+		SessionID:    branch.ID,
+		SessionName:  branch.Name,
+		AgentID:      branch.AgentID,
+		ContextID:    branch.ContextID,
+		CreatedAt:    branch.CreatedAt,
+		FromSession:  sessionID,
+		CheckpointID: checkpointID,
+		PageIDs:      branch.Pages(),
+	})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// SaveCheck handles POST /checks. The check's sessions belong to the caller's tenant.
+func (h *Handlers) SaveCheck(w http.ResponseWriter, r *http.Request) {
+	var req SaveCheckRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !allowRunScripts(w, r, req.Steps) {
+		return
+	}
+
+	tenantID := tenant.IDFromContext(r.Context())
+	check := &session.Check{
+		Name:        req.Name,
+		Description: req.Description,
+		Steps:       req.Steps,
+		Seed:        req.Seed,
+		Template:    req.Template,
+		Options:     req.Options,
+		IntervalMS:  req.IntervalMS,
+		Alert:       req.Alert,
+		Paused:      req.Paused,
+		TenantID:    tenantID,
+	}
+	if err := h.sessionManager.SaveCheck(check); err != nil {
+		if errors.Is(err, session.ErrInvalidCheck) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	report, err := h.sessionManager.GetCheck(tenantID, check.Name, false)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeCheckNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, report)
+}
+
+// ListChecks handles GET /checks
+func (h *Handlers) ListChecks(w http.ResponseWriter, r *http.Request) {
+	checks := h.sessionManager.ListChecks(tenant.IDFromContext(r.Context()))
+	writeJSON(w, http.StatusOK, ListChecksResponse{
+		Checks: checks,
+		Count:  len(checks),
+	})
+}
+
+// GetCheck handles GET /checks/{name}; ?history=true adds the kept runs
+func (h *Handlers) GetCheck(w http.ResponseWriter, r *http.Request) {
+	report, err := h.sessionManager.GetCheck(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"), r.URL.Query().Get("history") == "true")
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeCheckNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// DeleteCheck handles DELETE /checks/{name}
+func (h *Handlers) DeleteCheck(w http.ResponseWriter, r *http.Request) {
+	if err := h.sessionManager.DeleteCheck(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name")); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeCheckNotFound, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunCheck handles POST /checks/{name}/run, running the check now and returning the run
+func (h *Handlers) RunCheck(w http.ResponseWriter, r *http.Request) {
+	run, err := h.sessionManager.RunCheck(r.Context(), tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrCheckNotFound):
+			writeError(w, http.StatusNotFound, ErrCodeCheckNotFound, err.Error())
+		case errors.Is(err, session.ErrCheckRunning):
+			writeError(w, http.StatusConflict, ErrCodeCheckRunning, err.Error())
+		case errors.Is(err, session.ErrChecksDisabled):
+			writeError(w, http.StatusServiceUnavailable, ErrCodeInternalError, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// GetCheckScreenshot handles GET /checks/{name}/screenshot, the PNG of the last failure
+func (h *Handlers) GetCheckScreenshot(w http.ResponseWriter, r *http.Request) {
+	screenshot, err := h.sessionManager.CheckScreenshot(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeCheckNotFound, err.Error())
+		return
+	}
+	if writeNotModified(w, r, contentETag(screenshot)) {
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(screenshot)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// ContentHash handles POST /sessions/{id}/pages/{pageId}/content-hash
+func (h *Handlers) ContentHash(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req ContentHashRequest
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	hash, err := h.sessionManager.ContentHash(r.Context(), sessionID, pageID, session.ContentHashOptions{
+		Ignore:      req.Ignore,
+		MaskNumbers: req.MaskNumbers,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidSelector) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeExecutionFailed, err.Error())
+		}
+		return
+	}
+
+	response := ContentHashResponse{
+		SessionID:   sessionID,
+		PageID:      pageID,
+		ContentHash: hash,
+	}
+	if req.Previous != nil {
+		change, err := session.CompareContentHashes(req.Previous, hash)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "previous: "+err.Error())
+			return
+		}
+		response.Change = change
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vault"
+	"github.com/go-chi/chi/v5"
+)
+
+// SaveCredential handles POST /credentials
+func (h *Handlers) SaveCredential(w http.ResponseWriter, r *http.Request) {
+	var req SaveCredentialRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	cred := &vault.Credential{
+		Alias:             req.Alias,
+		Username:          req.Username,
+		Password:          req.Password,
+		LoginURL:          req.LoginURL,
+		ClientCertificate: req.ClientCertificate,
+		ClientKey:         req.ClientKey,
+	}
+
+	// Aliases belong to the caller's tenant, so other tenants can't see or use them
+	if err := h.vault.Save(tenant.IDFromContext(r.Context()), cred); err != nil {
+		if errors.Is(err, vault.ErrInvalidAlias) || errors.Is(err, vault.ErrInvalidCertificate) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	// Echo back only the non-secret view
+	response := vault.CredentialInfo{
+		Alias:             cred.Alias,
+		Username:          cred.Username,
+		LoginURL:          cred.LoginURL,
+		CreatedAt:         cred.CreatedAt,
+		ClientCertificate: vault.DescribeCertificate(cred),
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// ListCredentials handles GET /credentials
+func (h *Handlers) ListCredentials(w http.ResponseWriter, r *http.Request) {
+	infos, err := h.vault.List(tenant.IDFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ListCredentialsResponse{
+		Credentials: infos,
+		Count:       len(infos),
+	})
+}
+
+// DeleteCredential handles DELETE /credentials/{alias}
+func (h *Handlers) DeleteCredential(w http.ResponseWriter, r *http.Request) {
+	alias := chi.URLParam(r, "alias")
+
+	if err := h.vault.Delete(tenant.IDFromContext(r.Context()), alias); err != nil {
+		if errors.Is(err, vault.ErrCredentialNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeCredentialNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Login handles POST /sessions/{id}/login
+func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	var req LoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	// Resolve the credential alias among the tenant's own
+	cred, err := h.vault.Get(tenant.IDFromContext(r.Context()), req.Credential)
+	if err != nil {
+		if errors.Is(err, vault.ErrCredentialNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeCredentialNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	opts := session.LoginOptions{
+		FormIndex:          -1,
+		SuccessURLContains: req.SuccessURLContains,
+		SuccessSelector:    req.SuccessSelector,
+		SuccessEngine:      session.SelectorEngine{Engine: req.SuccessEngine, Match: req.SuccessMatch},
+		Timeout:            time.Duration(req.TimeoutMS) * time.Millisecond,
+	}
+	if req.FormIndex != nil {
+		opts.FormIndex = *req.FormIndex
+	}
+
+	result, err := h.sessionManager.Login(r.Context(), sessionID, req.PageID, cred.Username, cred.Password, opts)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidSelector) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else if errors.Is(err, session.ErrLoginFormNotFound) {
+			writeError(w, http.StatusUnprocessableEntity, ErrCodeFormNotFound, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeLoginFailed, err.Error())
+		}
+		return
+	}
+
+	response := LoginResponse{
+		SessionID:   sessionID,
+		PageID:      req.PageID,
+		Credential:  req.Credential,
+		LoginResult: result,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
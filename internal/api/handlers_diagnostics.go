@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminDiagnostics handles GET /admin/diagnostics
+func (h *Handlers) AdminDiagnostics(w http.ResponseWriter, r *http.Request) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	response := DiagnosticsResponse{
+		Goroutines:  runtime.NumGoroutine(),
+		Sessions:    h.sessionManager.GetSessionCount(),
+		Connections: h.sessionManager.ConnectionStats(),
+		Memory: MemoryDiagnostics{
+			HeapAlloc:    stats.HeapAlloc,
+			HeapObjects:  stats.HeapObjects,
+			Sys:          stats.Sys,
+			NextGC:       stats.NextGC,
+			NumGC:        stats.NumGC,
+			PauseTotal:   time.Duration(stats.PauseTotalNs),
+			GCCPUPercent: stats.GCCPUFraction * 100,
+		},
+	}
+	if stats.LastGC > 0 {
+		lastGC := time.Unix(0, int64(stats.LastGC))
+		response.Memory.LastGC = &lastGC
+	}
+
+	bus := h.sessionManager.Events()
+	response.Events.Sessions, response.Events.Subscriptions, response.Events.Dropped = bus.Stats()
+	response.Events.Queued, response.Events.Fullest = bus.Backlog()
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// mountProfiler registers the net/http/pprof handlers under /debug/pprof of r
+func mountProfiler(r chi.Router) {
+	r.Get("/debug/pprof/", pprof.Index)
+	r.Get("/debug/pprof/cmdline", pprof.Cmdline)
+	r.Get("/debug/pprof/symbol", pprof.Symbol)
+	r.Post("/debug/pprof/symbol", pprof.Symbol)
+	r.Get("/debug/pprof/profile", sampledProfile(pprof.Profile))
+	r.Get("/debug/pprof/trace", sampledProfile(pprof.Trace))
+	r.Get("/debug/pprof/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+}
+
+// sampledProfile lets a CPU profile or trace run for its ?seconds= past the server's
+// write timeout. pprof refuses durations over the timeout it finds in the request
+// context, so the server is hidden from it.
+func sampledProfile(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seconds, err := strconv.Atoi(r.URL.Query().Get("seconds"))
+		if err != nil || seconds <= 0 {
+			seconds = 30
+		}
+		duration := time.Duration(seconds) * time.Second
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(duration + 15*time.Second))
+
+		ctx := context.WithValue(r.Context(), http.ServerContextKey, nil)
+		handler(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// eventPingInterval keeps idle event streams alive through proxies
+	eventPingInterval = 30 * time.Second
+
+	// eventWriteTimeout bounds a single frame write to a slow client
+	eventWriteTimeout = 10 * time.Second
+)
+
+// eventUpgrader upgrades event stream requests (CORS is already open to all origins)
+var eventUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// StreamEvents handles GET /sessions/{id}/events/ws
+// Each session event is sent as one JSON text frame. Pass ?after=<event id>
+// to replay retained events newer than that ID before live delivery starts.
+func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if _, err := h.sessionManager.GetSession(sessionID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		return
+	}
+
+	var afterID uint64
+	if after := r.URL.Query().Get("after"); after != "" {
+		parsed, err := strconv.ParseUint(after, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "after must be an event ID")
+			return
+		}
+		afterID = parsed
+	}
+
+	// Subscribe before reading history so nothing published in between is lost
+	bus := h.sessionManager.Events()
+	sub := bus.Subscribe(sessionID, 0)
+	defer sub.Close()
+
+	conn, err := eventUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an HTTP error response
+		slog.Warn("event stream upgrade failed", "session_id", sessionID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Detect client disconnects; clients are not expected to send anything
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Replay retained history first
+	lastSent := afterID
+	if afterID > 0 {
+		for _, event := range bus.History(sessionID, afterID) {
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+			lastSent = event.ID
+		}
+	}
+
+	ticker := time.NewTicker(eventPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+
+		case event, ok := <-sub.C:
+			if !ok {
+				// Session destroyed
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session ended"),
+					time.Now().Add(eventWriteTimeout))
+				return
+			}
+
+			// Skip events already delivered during replay
+			if event.ID <= lastSent {
+				continue
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+			lastSent = event.ID
+
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// StreamEventsSSE handles GET /sessions/{id}/events
+// Server-Sent Events alternative to the WebSocket stream, for clients behind proxies
+// that can't upgrade. Each event is sent with its bus ID as the SSE id, so an
+// EventSource resumes on its own via Last-Event-ID; ?after= works as on the WebSocket.
+// Pass ?types=page_opened,page_closed to receive only those event types.
+func (h *Handlers) StreamEventsSSE(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if _, err := h.sessionManager.GetSession(sessionID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		return
+	}
+
+	// Last-Event-ID is sent by a reconnecting EventSource and wins over ?after=
+	after := r.Header.Get("Last-Event-ID")
+	if after == "" {
+		after = r.URL.Query().Get("after")
+	}
+	var afterID uint64
+	if after != "" {
+		parsed, err := strconv.ParseUint(after, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "after must be an event ID")
+			return
+		}
+		afterID = parsed
+	}
+
+	// Optional type filter; empty means every type
+	var types map[string]bool
+	if list := r.URL.Query().Get("types"); list != "" {
+		types = make(map[string]bool)
+		for _, eventType := range strings.Split(list, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				types[eventType] = true
+			}
+		}
+	}
+
+	// Subscribe before reading history so nothing published in between is lost
+	bus := h.sessionManager.Events()
+	sub := bus.Subscribe(sessionID, 0)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	// The server's WriteTimeout would cut the stream; each write gets its own deadline instead
+	controller := http.NewResponseController(w)
+	write := func(frame string) bool {
+		controller.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+		if _, err := io.WriteString(w, frame); err != nil {
+			return false
+		}
+		return controller.Flush() == nil
+	}
+	send := func(event events.Event) bool {
+		if types != nil && !types[event.Type] {
+			return true
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			slog.Error("failed to encode event", "session_id", sessionID, "error", err)
+			return true
+		}
+		return write(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data))
+	}
+
+	// Tell EventSource how soon to reconnect, and get the headers out before the first event
+	if !write("retry: 3000\n\n") {
+		return
+	}
+
+	// Replay retained history first
+	lastSent := afterID
+	if afterID > 0 {
+		for _, event := range bus.History(sessionID, afterID) {
+			if !send(event) {
+				return
+			}
+			lastSent = event.ID
+		}
+	}
+
+	ticker := time.NewTicker(eventPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-sub.C:
+			if !ok {
+				// Session destroyed; reconnecting gets a 404, which stops an EventSource
+				write("event: end\ndata: {}\n\n")
+				return
+			}
+
+			// Skip events already delivered during replay
+			if event.ID <= lastSent {
+				continue
+			}
+
+			if !send(event) {
+				return
+			}
+			lastSent = event.ID
+
+		case <-ticker.C:
+			// Comment line, ignored by EventSource but keeps proxies from timing out
+			if !write(": ping\n\n") {
+				return
+			}
+		}
+	}
+}
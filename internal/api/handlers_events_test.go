@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/gorilla/websocket"
+)
+
+// newTestSession returns a manager with a session on a fake browser at port 9222, which
+// answers Target.createBrowserContext with a context and every other command with {}
+func newTestSession(t *testing.T) (*session.Manager, *session.Session) {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	browser := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var writeMu sync.Mutex
+		for {
+			var request struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			result := map[string]interface{}{}
+			if request.Method == "Target.createBrowserContext" {
+				result["browserContextId"] = "ctx-1"
+			}
+			writeMu.Lock()
+			conn.WriteJSON(map[string]interface{}{"id": request.ID, "result": result})
+			writeMu.Unlock()
+		}
+	}))
+	t.Cleanup(browser.Close)
+
+	manager := session.NewManager(nil)
+	t.Cleanup(func() { manager.Close() })
+	manager.RegisterRemoteBrowser(9222, "ws"+strings.TrimPrefix(browser.URL, "http"))
+	sess, err := manager.CreateSessionWithOptions(context.Background(), "", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	return manager, sess
+}
+
+// TestStreamEventsSSE tests that the SSE stream resumes after Last-Event-ID, sends only
+// the asked-for types with their bus IDs, and ends when the session is destroyed
+func TestStreamEventsSSE(t *testing.T) {
+	manager, sess := newTestSession(t)
+	server := httptest.NewServer(NewServer("0", manager, nil, nil, nil, nil, nil, nil, nil, "", nil, tenant.NewRegistry(), nil, nil).router)
+	defer server.Close()
+
+	// A malformed resume ID is refused before the stream starts
+	resp, err := http.Get(server.URL + "/sessions/" + sess.ID + "/events?after=latest")
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for after=latest, got %d", resp.StatusCode)
+	}
+
+	bus := manager.Events()
+	opened := bus.Publish(events.Event{SessionID: sess.ID, PageID: "page-1", Type: events.TypePageOpened})
+	bus.Publish(events.Event{SessionID: sess.ID, PageID: "page-1", Type: events.TypeDOMChanged})
+	closed := bus.Publish(events.Event{SessionID: sess.ID, PageID: "page-1", Type: events.TypePageClosed})
+
+	r, _ := http.NewRequest(http.MethodGet, server.URL+"/sessions/"+sess.ID+"/events?after=0&types=page_opened,page_closed", nil)
+	r.Header.Set("Last-Event-ID", fmt.Sprint(opened.ID))
+	resp, err = http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatalf("GET /events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	frame := func() string {
+		t.Helper()
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("stream ended early: %v", err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	if got := frame(); got != "retry: 3000" {
+		t.Errorf("expected the retry hint first, got %q", got)
+	}
+	// The DOM change is filtered out, and the page opened before Last-Event-ID isn't replayed
+	want := fmt.Sprintf("id: %d\nevent: page_closed\ndata: ", closed.ID)
+	if got := frame(); !strings.HasPrefix(got, want) {
+		t.Errorf("expected the page_closed replayed, got %q", got)
+	}
+
+	// Live events come through the same filter
+	bus.Publish(events.Event{SessionID: sess.ID, Type: events.TypeDOMChanged})
+	live := bus.Publish(events.Event{SessionID: sess.ID, PageID: "page-2", Type: events.TypePageOpened})
+	got := frame()
+	var event events.Event
+	if data, ok := strings.CutPrefix(got, fmt.Sprintf("id: %d\nevent: page_opened\ndata: ", live.ID)); !ok || json.Unmarshal([]byte(data), &event) != nil || event.PageID != "page-2" {
+		t.Errorf("expected the live page_opened, got %q", got)
+	}
+
+	if err := manager.DestroySession(sess.ID); err != nil {
+		t.Fatalf("DestroySession failed: %v", err)
+	}
+	for got = frame(); strings.HasPrefix(got, "id: "); got = frame() {
+		// session_destroyed and the like are filtered; anything left must be asked for
+		if !strings.Contains(got, "event: page_") {
+			t.Errorf("unexpected event %q", got)
+		}
+	}
+	if got != "event: end\ndata: {}" {
+		t.Errorf("expected the stream ended, got %q", got)
+	}
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+)
+
+// ListExtensions handles GET /extensions
+func (h *Handlers) ListExtensions(w http.ResponseWriter, r *http.Request) {
+	extensions := h.extensions.List()
+	writeJSON(w, http.StatusOK, ListExtensionsResponse{
+		Extensions: extensions,
+		Count:      len(extensions),
+	})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListWorkFiles handles GET /sessions/{id}/files
+func (h *Handlers) ListWorkFiles(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	info, err := h.sessionManager.WorkDir(sessionID)
+	if err != nil {
+		writeWorkFileError(w, sessionID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, WorkFilesResponse{SessionID: sessionID, WorkDirInfo: *info})
+}
+
+// GetWorkFile handles GET /sessions/{id}/files/*, sending the file as it is
+func (h *Handlers) GetWorkFile(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	name := chi.URLParam(r, "*")
+
+	file, info, err := h.sessionManager.OpenWorkFile(sessionID, name)
+	if err != nil {
+		writeWorkFileError(w, sessionID, err)
+		return
+	}
+	defer file.Close()
+
+	// Downloads are whatever a site sent; never let a browser render them from this origin
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// DeleteWorkFile handles DELETE /sessions/{id}/files/*
+func (h *Handlers) DeleteWorkFile(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if err := h.sessionManager.RemoveWorkFile(sessionID, chi.URLParam(r, "*")); err != nil {
+		writeWorkFileError(w, sessionID, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeWorkFileError maps work directory failures to responses
+func writeWorkFileError(w http.ResponseWriter, sessionID string, err error) {
+	switch {
+	case errors.Is(err, session.ErrSessionNotFound):
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	case errors.Is(err, session.ErrNoWorkDir), errors.Is(err, session.ErrWorkFileNotFound):
+		writeError(w, http.StatusNotFound, ErrCodeFileNotFound, err.Error())
+	case errors.Is(err, session.ErrInvalidWorkPath):
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListForms handles GET /sessions/{id}/pages/{pageId}/forms
+func (h *Handlers) ListForms(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	forms, err := h.sessionManager.DiscoverForms(r.Context(), sessionID, pageID)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	response := ListFormsResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		Forms:     forms,
+		Count:     len(forms),
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// FillForm handles POST /sessions/{id}/pages/{pageId}/forms/{formIndex}/fill
+func (h *Handlers) FillForm(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	formIndex, err := strconv.Atoi(chi.URLParam(r, "formIndex"))
+	if err != nil || formIndex < 0 {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "formIndex must be a non-negative integer")
+		return
+	}
+
+	var req FillFormRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutMS) * time.Millisecond
+
+	result, err := h.sessionManager.FillForm(r.Context(), sessionID, pageID, formIndex, req.Values, req.Submit, timeout)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrFormNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeFormNotFound, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeFormFillFailed, err.Error())
+		}
+		return
+	}
+
+	response := FillFormResponse{
+		SessionID:      sessionID,
+		PageID:         pageID,
+		FormIndex:      formIndex,
+		FormFillResult: result,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
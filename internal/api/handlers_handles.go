@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// writeHandleError maps element handle errors to responses
+func writeHandleError(w http.ResponseWriter, err error, sessionID string, pageID string) {
+	var scriptErr *session.ScriptError
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	} else if errors.Is(err, session.ErrPageNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+	} else if errors.Is(err, session.ErrTakeoverActive) {
+		writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+	} else if errors.Is(err, session.ErrHandleNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeHandleNotFound, err.Error())
+	} else if errors.Is(err, session.ErrHandleStale) {
+		writeError(w, http.StatusGone, ErrCodeHandleStale, err.Error())
+	} else if errors.Is(err, session.ErrElementNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeElementNotFound, err.Error())
+	} else if errors.Is(err, session.ErrInvalidHandle) || errors.Is(err, session.ErrInvalidSelector) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	} else if errors.Is(err, session.ErrInvalidClick) {
+		writeError(w, http.StatusUnprocessableEntity, ErrCodeInvalidRequest, err.Error())
+	} else if errors.Is(err, session.ErrEngineUnsupported) {
+		writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+	} else if errors.As(err, &scriptErr) {
+		writeScriptError(w, scriptErr)
+	} else {
+		writeError(w, http.StatusInternalServerError, ErrCodeHandleFailed, err.Error())
+	}
+}
+
+// CreateHandle handles POST /sessions/{id}/pages/{pageId}/handles
+func (h *Handlers) CreateHandle(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req CreateHandleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	handle, err := h.sessionManager.CreateHandle(r.Context(), sessionID, pageID, req.Selector, req.SelectorEngine)
+	if err != nil {
+		writeHandleError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, HandleResponse{
+		SessionID:     sessionID,
+		ElementHandle: handle,
+	})
+}
+
+// ListHandles handles GET /sessions/{id}/pages/{pageId}/handles
+func (h *Handlers) ListHandles(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	handles, err := h.sessionManager.ListHandles(sessionID, pageID)
+	if err != nil {
+		writeHandleError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ListHandlesResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		Handles:   handles,
+		Count:     len(handles),
+	})
+}
+
+// ReleaseHandle handles DELETE /sessions/{id}/pages/{pageId}/handles/{handleId}
+func (h *Handlers) ReleaseHandle(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+	handleID := chi.URLParam(r, "handleId")
+
+	if err := h.sessionManager.ReleaseHandle(r.Context(), sessionID, pageID, handleID); err != nil {
+		writeHandleError(w, err, sessionID, pageID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClickHandle handles POST /sessions/{id}/pages/{pageId}/handles/{handleId}/click
+func (h *Handlers) ClickHandle(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+	handleID := chi.URLParam(r, "handleId")
+
+	// The body may be omitted for a plain left click
+	var req HandleClickRequest
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	result, err := h.sessionManager.ClickHandle(r.Context(), sessionID, pageID, handleID, session.ClickRequest{
+		Button:     req.Button,
+		ClickCount: req.ClickCount,
+		Modifiers:  req.Modifiers,
+		Verify:     req.VerifyChange,
+	})
+	if err != nil {
+		writeHandleError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ClickResponse{
+		SessionID:   sessionID,
+		PageID:      pageID,
+		ClickResult: result,
+	})
+}
+
+// GetHandleText handles GET /sessions/{id}/pages/{pageId}/handles/{handleId}/text
+func (h *Handlers) GetHandleText(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+	handleID := chi.URLParam(r, "handleId")
+
+	text, err := h.sessionManager.HandleText(r.Context(), sessionID, pageID, handleID)
+	if err != nil {
+		writeHandleError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, HandleTextResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		HandleID:  handleID,
+		Text:      text,
+	})
+}
+
+// GetHandleAttribute handles GET /sessions/{id}/pages/{pageId}/handles/{handleId}/attribute?name=
+func (h *Handlers) GetHandleAttribute(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+	handleID := chi.URLParam(r, "handleId")
+	name := r.URL.Query().Get("name")
+
+	value, err := h.sessionManager.HandleAttribute(r.Context(), sessionID, pageID, handleID, name)
+	if err != nil {
+		writeHandleError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, HandleAttributeResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		HandleID:  handleID,
+		Name:      name,
+		Value:     value,
+	})
+}
+
+// GetHandleBox handles GET /sessions/{id}/pages/{pageId}/handles/{handleId}/box
+func (h *Handlers) GetHandleBox(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+	handleID := chi.URLParam(r, "handleId")
+
+	layout, err := h.sessionManager.HandleLayout(r.Context(), sessionID, pageID, handleID)
+	if err != nil {
+		writeHandleError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ElementBoxResponse{
+		SessionID:     sessionID,
+		PageID:        pageID,
+		ElementLayout: layout,
+	})
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+	"github.com/go-chi/chi/v5"
+)
+
+// writeHealError maps fingerprint and healing errors to responses
+func writeHealError(w http.ResponseWriter, err error, sessionID string, pageID string) {
+	var scriptErr *session.ScriptError
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	} else if errors.Is(err, session.ErrPageNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+	} else if errors.Is(err, session.ErrTakeoverActive) {
+		writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+	} else if errors.Is(err, session.ErrElementNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeElementNotFound, err.Error())
+	} else if errors.Is(err, session.ErrInvalidSelector) || errors.Is(err, session.ErrInvalidFingerprint) ||
+		errors.Is(err, session.ErrInvalidHeal) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	} else if errors.Is(err, session.ErrEngineUnsupported) {
+		writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+	} else if errors.Is(err, vision.ErrQueryFailed) {
+		writeError(w, http.StatusBadGateway, ErrCodeVisionFailed, err.Error())
+	} else if errors.Is(err, session.ErrTenantModelBudget) {
+		writeError(w, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+	} else if errors.Is(err, session.ErrModelBudget) {
+		writeError(w, http.StatusTooManyRequests, ErrCodeModelBudget, err.Error())
+	} else if errors.As(err, &scriptErr) {
+		writeScriptError(w, scriptErr)
+	} else {
+		writeError(w, http.StatusInternalServerError, ErrCodeHealFailed, err.Error())
+	}
+}
+
+// GetElementFingerprint handles GET /sessions/{id}/pages/{pageId}/element/fingerprint
+func (h *Handlers) GetElementFingerprint(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	selector := r.URL.Query().Get("selector")
+	if selector == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "the selector query parameter is required")
+		return
+	}
+	engine := session.SelectorEngine{Engine: r.URL.Query().Get("engine"), Match: r.URL.Query().Get("match")}
+
+	fingerprint, err := h.sessionManager.Fingerprint(r.Context(), sessionID, pageID, selector, engine)
+	if err != nil {
+		writeHealError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ElementFingerprintResponse{
+		SessionID:          sessionID,
+		PageID:             pageID,
+		ElementFingerprint: fingerprint,
+	})
+}
+
+// HealSelector handles POST /sessions/{id}/pages/{pageId}/element/heal
+func (h *Handlers) HealSelector(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req HealSelectorRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx := r.Context()
+	if req.Confirm {
+		if h.visionModel == nil {
+			writeError(w, http.StatusServiceUnavailable, ErrCodeVisionUnavailable,
+				"No vision model configured (set VISION_API_KEY or VISION_API_URL)")
+			return
+		}
+
+		// As with vision queries, the model round trip outlives the default write timeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(session.DefaultVisionTimeout + 15*time.Second)); err != nil {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to extend response deadline")
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, session.DefaultVisionTimeout)
+		defer cancel()
+	}
+
+	result, err := h.sessionManager.HealSelector(ctx, sessionID, pageID, session.HealRequest{
+		Selector:       req.Selector,
+		SelectorEngine: req.SelectorEngine,
+		Fingerprint:    req.Fingerprint,
+		MinScore:       req.MinScore,
+		Confirm:        req.Confirm,
+		NoCache:        req.NoCache,
+	}, h.visionModel)
+	if err != nil {
+		writeHealError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, HealSelectorResponse{
+		SessionID:  sessionID,
+		PageID:     pageID,
+		HealResult: result,
+	})
+}
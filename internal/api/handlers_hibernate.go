@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// HibernateSession handles POST /sessions/{id}/hibernate
+func (h *Handlers) HibernateSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if err := h.sessionManager.HibernateSession(r.Context(), sessionID); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrEngineUnsupported) {
+			writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	sess, err := h.sessionManager.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+	audit.SetSession(r.Context(), sessionID, sess.AgentID)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"session_id": sessionID,
+		"status":     sess.CurrentStatus(),
+		"message":    "Session hibernated. Its pages reopen under new IDs on the next call.",
+	})
+}
+
+// WakeSession handles POST /sessions/{id}/wake. SessionWakeMiddleware has already
+// woken the session by the time it gets here, so this only reports its pages.
+func (h *Handlers) WakeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	sess, err := h.sessionManager.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+	audit.SetSession(r.Context(), sessionID, sess.AgentID)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"session_id": sessionID,
+		"status":     sess.CurrentStatus(),
+		"page_ids":   sess.Pages(),
+	})
+}
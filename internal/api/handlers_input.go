@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// Click handles POST /sessions/{id}/pages/{pageId}/click
+func (h *Handlers) Click(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req ClickRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	result, err := h.sessionManager.Click(r.Context(), sessionID, pageID, session.ClickRequest{
+		X:              req.X,
+		Y:              req.Y,
+		Button:         req.Button,
+		ClickCount:     req.ClickCount,
+		Modifiers:      req.Modifiers,
+		ScrollIntoView: req.ScrollIntoView,
+		Verify:         req.VerifyChange,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidClick) {
+			writeError(w, http.StatusUnprocessableEntity, ErrCodeInvalidRequest, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeClickFailed, err.Error())
+		}
+		return
+	}
+
+	response := ClickResponse{
+		SessionID:   sessionID,
+		PageID:      pageID,
+		ClickResult: result,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetElementBox reports where the element matching the selector query parameter, or
+// named by the object_id one, is and whether a click on it would land
+func (h *Handlers) GetElementBox(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	selector := r.URL.Query().Get("selector")
+	objectID := r.URL.Query().Get("object_id")
+	if (selector == "") == (objectID == "") {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "exactly one of the selector and object_id query parameters is required")
+		return
+	}
+
+	var layout *session.ElementLayout
+	var err error
+	if objectID != "" {
+		layout, err = h.sessionManager.GetObjectLayout(r.Context(), sessionID, pageID, objectID)
+	} else {
+		engine := session.SelectorEngine{Engine: r.URL.Query().Get("engine"), Match: r.URL.Query().Get("match")}
+		layout, err = h.sessionManager.GetElementLayout(r.Context(), sessionID, pageID, selector, engine)
+	}
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrElementNotFound) || errors.Is(err, session.ErrObjectNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeElementNotFound, err.Error())
+		} else if errors.Is(err, session.ErrInvalidSelector) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeElementFailed, err.Error())
+		}
+		return
+	}
+
+	response := ElementBoxResponse{
+		SessionID:     sessionID,
+		PageID:        pageID,
+		ElementLayout: layout,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/sessionlog"
+	"github.com/go-chi/chi/v5"
+)
+
+// GetSessionLogs handles GET /sessions/{id}/logs?level=...&page_id=...&limit=...
+func (h *Handlers) GetSessionLogs(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if _, err := h.sessionManager.GetSession(sessionID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	filter := sessionlog.Filter{Level: slog.LevelDebug, PageID: query.Get("page_id")}
+	if level := query.Get("level"); level != "" {
+		if err := filter.Level.UnmarshalText([]byte(level)); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "level must be debug, info, warn or error")
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "limit must be a positive number")
+			return
+		}
+		filter.Limit = n
+	}
+
+	lines := sessionlog.Default().Lines(sessionID, filter)
+	writeJSON(w, http.StatusOK, SessionLogsResponse{SessionID: sessionID, Lines: lines, Count: len(lines)})
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// writeMediaError maps a media emulation error to its status
+func writeMediaError(w http.ResponseWriter, err error, sessionID, pageID string) {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	} else if errors.Is(err, session.ErrPageNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+	} else if errors.Is(err, session.ErrInvalidMedia) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	} else if errors.Is(err, session.ErrEngineUnsupported) {
+		writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+	} else {
+		writeError(w, http.StatusInternalServerError, ErrCodeMediaFailed, err.Error())
+	}
+}
+
+// GetPageMedia handles GET /sessions/{id}/pages/{pageId}/media
+func (h *Handlers) GetPageMedia(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	media, err := h.sessionManager.PageMedia(sessionID, pageID)
+	if err != nil {
+		writeMediaError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PageMediaResponse{
+		SessionID:      sessionID,
+		PageID:         pageID,
+		MediaEmulation: media,
+	})
+}
+
+// SetPageMedia handles PUT /sessions/{id}/pages/{pageId}/media
+func (h *Handlers) SetPageMedia(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req PageMediaRequest
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	media, err := h.sessionManager.SetPageMedia(r.Context(), sessionID, pageID, session.MediaEmulation{
+		Media:         req.Media,
+		ColorScheme:   req.ColorScheme,
+		ReducedMotion: req.ReducedMotion,
+	})
+	if err != nil {
+		writeMediaError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PageMediaResponse{
+		SessionID:      sessionID,
+		PageID:         pageID,
+		MediaEmulation: media,
+	})
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetMemory handles GET /sessions/{id}/memory
+func (h *Handlers) GetMemory(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	sess, err := h.sessionManager.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, MemoryResponse{SessionID: sessionID, Memory: sess.Memory()})
+}
+
+// ClearMemory handles DELETE /sessions/{id}/memory
+func (h *Handlers) ClearMemory(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if err := h.sessionManager.ClearMemory(sessionID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// writeNetworkError maps a network capture or security error to its status
+func writeNetworkError(w http.ResponseWriter, err error, sessionID, pageID string) {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	} else if errors.Is(err, session.ErrPageNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+	} else if errors.Is(err, session.ErrInvalidNetworkFilter) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	} else if errors.Is(err, session.ErrCaptureDisabled) {
+		writeError(w, http.StatusConflict, ErrCodeCaptureDisabled, err.Error())
+	} else if errors.Is(err, session.ErrEngineUnsupported) {
+		writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+	} else {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+	}
+}
+
+// queryInt reads a non-negative integer query parameter, 0 when absent
+func queryInt(query url.Values, name string) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errors.New(name + " must be a non-negative number")
+	}
+	return n, nil
+}
+
+// queryTime reads an RFC 3339 time query parameter, the zero time when absent
+func queryTime(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New(name + " must be an RFC 3339 time")
+	}
+	return t, nil
+}
+
+// queryTrafficFilter reads the url, size, time and limit query parameters shared by
+// the captured traffic endpoints
+func queryTrafficFilter(query url.Values) (session.TrafficFilter, error) {
+	filter := session.TrafficFilter{URL: query.Get("url")}
+	var errs [5]error
+	filter.MinSize, errs[0] = queryInt(query, "min_size")
+	filter.MaxSize, errs[1] = queryInt(query, "max_size")
+	filter.Limit, errs[2] = queryInt(query, "limit")
+	filter.Since, errs[3] = queryTime(query, "since")
+	filter.Until, errs[4] = queryTime(query, "until")
+	for _, err := range errs {
+		if err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// GetWebSocketTraffic handles GET /sessions/{id}/pages/{pageId}/network/websockets
+func (h *Handlers) GetWebSocketTraffic(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	query := r.URL.Query()
+	traffic, err := queryTrafficFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+	filter := session.WebSocketFilter{TrafficFilter: traffic, Direction: query.Get("direction")}
+
+	frames, err := h.sessionManager.WebSocketTraffic(sessionID, pageID, filter)
+	if err != nil {
+		writeNetworkError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, WebSocketTrafficResponse{
+		SessionID:        sessionID,
+		PageID:           pageID,
+		WebSocketTraffic: frames,
+	})
+}
+
+// GetStreamTraffic handles GET /sessions/{id}/pages/{pageId}/network/streams
+func (h *Handlers) GetStreamTraffic(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	query := r.URL.Query()
+	traffic, err := queryTrafficFilter(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+	filter := session.StreamFilter{TrafficFilter: traffic, Kind: query.Get("kind"), Event: query.Get("event")}
+
+	messages, err := h.sessionManager.StreamTraffic(sessionID, pageID, filter)
+	if err != nil {
+		writeNetworkError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StreamTrafficResponse{
+		SessionID:     sessionID,
+		PageID:        pageID,
+		StreamTraffic: messages,
+	})
+}
+
+// GetEgressUsage handles GET /sessions/{id}/egress
+func (h *Handlers) GetEgressUsage(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	report, err := h.sessionManager.EgressUsage(sessionID)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrEgressUnavailable) {
+			writeError(w, http.StatusConflict, ErrCodeEgressUnavailable, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, EgressResponse{SessionID: sessionID, EgressReport: report})
+}
+
+// GetSessionBandwidth handles GET /sessions/{id}/bandwidth
+func (h *Handlers) GetSessionBandwidth(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	report, err := h.sessionManager.SessionBandwidth(sessionID)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrBandwidthUnmetered) {
+			writeError(w, http.StatusConflict, ErrCodeBandwidthUnmetered, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, BandwidthResponse{SessionID: sessionID, BandwidthReport: report})
+}
+
+// GetPageSecurity handles GET /sessions/{id}/pages/{pageId}/security
+func (h *Handlers) GetPageSecurity(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	security, err := h.sessionManager.PageSecurity(r.Context(), sessionID, pageID)
+	if err != nil {
+		writeNetworkError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, PageSecurityResponse{
+		SessionID:    sessionID,
+		PageID:       pageID,
+		PageSecurity: security,
+	})
+}
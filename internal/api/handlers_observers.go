@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateObserver handles POST /sessions/{id}/observers
+func (h *Handlers) CreateObserver(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	var req CreateObserverRequest
+	// Empty body is acceptable
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	observer, err := h.sessionManager.CreateObserver(sessionID, req.Label)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, toObserverResponse(observer))
+}
+
+// ListObservers handles GET /sessions/{id}/observers
+func (h *Handlers) ListObservers(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if _, err := h.sessionManager.GetSession(sessionID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	observers := h.sessionManager.ListObservers(sessionID)
+	responses := make([]ObserverResponse, 0, len(observers))
+	for _, observer := range observers {
+		responses = append(responses, toObserverResponse(observer))
+	}
+
+	writeJSON(w, http.StatusOK, ListObserversResponse{
+		SessionID: sessionID,
+		Observers: responses,
+		Count:     len(responses),
+	})
+}
+
+// RevokeObserver handles DELETE /sessions/{id}/observers/{observerId}
+func (h *Handlers) RevokeObserver(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	observerID := chi.URLParam(r, "observerId")
+
+	if err := h.sessionManager.RevokeObserver(sessionID, observerID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeObserverNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// toObserverResponse converts a session observer into its API representation
+func toObserverResponse(observer *session.Observer) ObserverResponse {
+	return ObserverResponse{
+		ObserverID: observer.ID,
+		SessionID:  observer.SessionID,
+		Label:      observer.Label,
+		CreatedAt:  observer.CreatedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// pdfCopyBufferSize is how much of a PDF is passed to the client per write
+const pdfCopyBufferSize = 64 << 10
+
+// PrintPDF handles POST /sessions/{id}/pages/{pageId}/pdf. The document is streamed from
+// the browser to the client as it is read, never held whole.
+func (h *Handlers) PrintPDF(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req PrintPDFRequest
+	if !decodeOptionalJSON(w, r, &req) {
+		return
+	}
+
+	// Printing can outlast the server's write timeout; each chunk gets its own deadline below
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		slog.Debug("failed to lift write deadline", "error", err)
+	}
+
+	stream, err := h.sessionManager.PrintToPDF(r.Context(), sessionID, pageID, req.PDFOptions)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidPDFOptions) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodePDFFailed, err.Error())
+		}
+		return
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			slog.Warn("failed to release PDF stream", "session_id", sessionID, "page_id", pageID, "error", err)
+		}
+	}()
+
+	buffer := make([]byte, pdfCopyBufferSize)
+	written := 0
+	for {
+		n, readErr := stream.Read(buffer)
+		if n > 0 {
+			if written == 0 {
+				w.Header().Set("Content-Type", "application/pdf")
+			}
+			controller.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if _, err := w.Write(buffer[:n]); err != nil {
+				slog.Debug("client went away during PDF download", "session_id", sessionID, "error", err)
+				return
+			}
+			written += n
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			return
+		}
+		if readErr != nil {
+			// Once bytes are out the status is sent; all that can be done is cut the response short
+			if written == 0 {
+				writeError(w, http.StatusBadGateway, ErrCodePDFFailed, readErr.Error())
+				return
+			}
+			slog.Warn("PDF stream failed mid-transfer", "session_id", sessionID, "page_id", pageID, "bytes", written, "error", readErr)
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
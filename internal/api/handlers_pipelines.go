@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pipeline"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// SavePipeline handles POST /pipelines
+func (h *Handlers) SavePipeline(w http.ResponseWriter, r *http.Request) {
+	var spec pipeline.Spec
+	if !decodeJSON(w, r, &spec) {
+		return
+	}
+	spec.CreatedAt = time.Time{}
+
+	// Saving under an existing name replaces it, including its dedup state. The
+	// pipeline is the caller's tenant's own.
+	if err := h.sessionManager.Pipelines().Put(tenant.IDFromContext(r.Context()), &spec); err != nil {
+		if errors.Is(err, pipeline.ErrInvalidPipeline) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, &spec)
+}
+
+// ListPipelines handles GET /pipelines
+func (h *Handlers) ListPipelines(w http.ResponseWriter, r *http.Request) {
+	specs := h.sessionManager.Pipelines().List(tenant.IDFromContext(r.Context()))
+
+	writeJSON(w, http.StatusOK, ListPipelinesResponse{
+		Pipelines: specs,
+		Count:     len(specs),
+	})
+}
+
+// GetPipeline handles GET /pipelines/{name}
+func (h *Handlers) GetPipeline(w http.ResponseWriter, r *http.Request) {
+	compiled, err := h.sessionManager.Pipelines().Get(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodePipelineNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, compiled.Spec())
+}
+
+// DeletePipeline handles DELETE /pipelines/{name}
+func (h *Handlers) DeletePipeline(w http.ResponseWriter, r *http.Request) {
+	if err := h.sessionManager.Pipelines().Delete(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name")); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodePipelineNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RunPipeline handles POST /pipelines/{name}/run, for records extracted elsewhere
+func (h *Handlers) RunPipeline(w http.ResponseWriter, r *http.Request) {
+	var req RunPipelineRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	result, err := h.sessionManager.Pipelines().Run(r.Context(), tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"), req.SessionID, req.Records)
+	if err != nil {
+		writePipelineError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Extract handles POST /sessions/{id}/extract
+func (h *Handlers) Extract(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	var req ExtractRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !allowScript(w, r, "script", req.Script) {
+		return
+	}
+
+	result, err := h.sessionManager.Extract(r.Context(), sessionID, req.PageID, req.Script, req.Pipeline)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrNoPipeline) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else if errors.Is(err, pipeline.ErrPipelineNotFound) || errors.Is(err, pipeline.ErrInvalidRecord) || errors.Is(err, pipeline.ErrSinkFailed) {
+			writePipelineError(w, err)
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeExecutionFailed, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// writePipelineError maps pipeline failures to responses: bad input is the caller's
+// fault, a failing sink is a 502 from a dependency
+func writePipelineError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, pipeline.ErrPipelineNotFound):
+		writeError(w, http.StatusNotFound, ErrCodePipelineNotFound, err.Error())
+	case errors.Is(err, pipeline.ErrInvalidRecord):
+		writeError(w, http.StatusUnprocessableEntity, ErrCodePipelineFailed, err.Error())
+	case errors.Is(err, pipeline.ErrSinkFailed):
+		writeError(w, http.StatusBadGateway, ErrCodePipelineFailed, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, ErrCodePipelineFailed, err.Error())
+	}
+}
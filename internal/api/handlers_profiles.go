@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListProfiles handles GET /profiles
+func (h *Handlers) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	if !h.profilesEnabled(w) {
+		return
+	}
+
+	tenantID := tenant.IDFromContext(r.Context())
+	profiles, err := h.profiles.List(tenantID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ListProfilesResponse{
+		Profiles: profiles,
+		Count:    len(profiles),
+	})
+}
+
+// DeleteProfile handles DELETE /profiles/{name}
+func (h *Handlers) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	if !h.profilesEnabled(w) {
+		return
+	}
+
+	if err := h.profiles.Delete(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name")); err != nil {
+		if !writeProfileError(w, err) {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// profilesEnabled writes 503 when there is no profile pool
+func (h *Handlers) profilesEnabled(w http.ResponseWriter) bool {
+	if h.profiles == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeProfilesUnavailable, session.ErrProfilesDisabled.Error())
+		return false
+	}
+	return true
+}
+
+// writeProfileError maps profile errors to responses, reporting whether err was one
+func writeProfileError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, pool.ErrProfileInUse):
+		writeError(w, http.StatusConflict, ErrCodeProfileInUse, err.Error())
+	case errors.Is(err, pool.ErrProfileNotFound):
+		writeError(w, http.StatusNotFound, ErrCodeProfileNotFound, err.Error())
+	case errors.Is(err, session.ErrProfilesDisabled):
+		writeError(w, http.StatusServiceUnavailable, ErrCodeProfilesUnavailable, err.Error())
+	case errors.Is(err, session.ErrProfileOptions), errors.Is(err, browser.ErrInvalidProfileName), errors.Is(err, browser.ErrExtensionNotFound):
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListPrompts handles GET /prompts
+func (h *Handlers) ListPrompts(w http.ResponseWriter, r *http.Request) {
+	prompts := h.sessionManager.Prompts().List(tenant.IDFromContext(r.Context()))
+
+	writeJSON(w, http.StatusOK, ListPromptsResponse{
+		Prompts: prompts,
+		Count:   len(prompts),
+	})
+}
+
+// GetPrompt handles GET /prompts/{name}
+func (h *Handlers) GetPrompt(w http.ResponseWriter, r *http.Request) {
+	prompt, err := h.sessionManager.Prompts().Get(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodePromptNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prompt)
+}
+
+// SavePrompt handles PUT /prompts/{name}, saving a new version that is used from now on
+func (h *Handlers) SavePrompt(w http.ResponseWriter, r *http.Request) {
+	var req SavePromptRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	version, err := h.sessionManager.Prompts().Save(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"), session.PromptVersion{
+		Text:      req.Text,
+		Variables: req.Variables,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrPromptNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePromptNotFound, err.Error())
+		} else if errors.Is(err, session.ErrInvalidPrompt) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, version)
+}
+
+// ResetPrompt handles DELETE /prompts/{name}, dropping the caller's versions
+func (h *Handlers) ResetPrompt(w http.ResponseWriter, r *http.Request) {
+	if err := h.sessionManager.Prompts().Reset(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name")); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodePromptNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// Research handles POST /research, answering a question from the top search results
+func (h *Handlers) Research(w http.ResponseWriter, r *http.Request) {
+	var req ResearchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if h.researcher == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeResearchUnavailable,
+			"No language model configured (set VISION_API_KEY or VISION_API_URL)")
+		return
+	}
+	if h.sessionManager.SearchBackend() == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeSearchUnavailable,
+			"No search backend configured (set SEARCH_BACKEND or SEARCH_API_KEY)")
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = session.DefaultResearchTimeout
+	}
+
+	// Searching, loading pages and the model round trip outlive the default write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 15*time.Second)); err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to extend response deadline")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	out := http.ResponseWriter(w)
+	var onText func(string)
+	var stream *answerStream
+	if req.Stream {
+		stream = startAnswerStream(w)
+		defer stream.close()
+		out = stream.errors()
+		onText = stream.token
+	}
+
+	result, err := h.researcher.Research(ctx, session.ResearchRequest{
+		Question: req.Question,
+		Sources:  req.Sources,
+		Language: req.Language,
+		Region:   req.Region,
+		TenantID: tenant.IDFromContext(r.Context()),
+		OnText:   onText,
+		NoCache:  req.NoCache,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrInvalidQuery):
+			writeError(out, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		case errors.Is(err, search.ErrSearchFailed):
+			writeError(out, http.StatusBadGateway, ErrCodeSearchFailed, err.Error())
+		case errors.Is(err, vision.ErrQueryFailed):
+			writeError(out, http.StatusBadGateway, ErrCodeVisionFailed, err.Error())
+		case errors.Is(err, session.ErrTenantSessionLimit), errors.Is(err, session.ErrTenantProcessLimit), errors.Is(err, session.ErrTenantBandwidthLimit),
+			errors.Is(err, session.ErrTenantModelBudget):
+			writeError(out, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+		case errors.Is(err, session.ErrModelBudget):
+			writeError(out, http.StatusTooManyRequests, ErrCodeModelBudget, err.Error())
+		case errors.Is(err, session.ErrDraining):
+			writeError(out, http.StatusServiceUnavailable, ErrCodeDraining, err.Error())
+		default:
+			writeError(out, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	response := ResearchResponse{ResearchResult: result}
+	if stream != nil {
+		stream.send("result", response)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
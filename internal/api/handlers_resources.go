@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListResources handles GET /sessions/{id}/pages/{pageId}/resources
+func (h *Handlers) ListResources(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	resources, err := h.sessionManager.ListResources(r.Context(), sessionID, pageID)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	response := ListResourcesResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		Resources: resources,
+		Count:     len(resources),
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// DownloadResource handles POST /sessions/{id}/pages/{pageId}/resources/download
+func (h *Handlers) DownloadResource(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req DownloadResourceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	resource, err := h.sessionManager.DownloadResource(r.Context(), sessionID, pageID, req.URL, req.Source, req.MaxBytes)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrResourceNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeResourceNotFound, err.Error())
+		} else if errors.Is(err, session.ErrResourceTooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, ErrCodeResourceTooLarge, err.Error())
+		} else {
+			writeError(w, http.StatusBadGateway, ErrCodeResourceFailed, err.Error())
+		}
+		return
+	}
+
+	// Raw mode streams the bytes with the resource's own content type
+	if req.Raw {
+		contentType := resource.MimeType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(resource.Data)))
+		w.Header().Set("X-Resource-Source", resource.Source)
+		w.WriteHeader(http.StatusOK)
+		w.Write(resource.Data)
+		return
+	}
+
+	response := DownloadResourceResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		URL:       resource.URL,
+		MimeType:  resource.MimeType,
+		Source:    resource.Source,
+		Status:    resource.Status,
+		Data:      base64.StdEncoding.EncodeToString(resource.Data),
+		Size:      len(resource.Data),
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
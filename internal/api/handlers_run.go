@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// Run handles POST /sessions/{id}/run. A run whose steps failed is still a 200; the
+// result says which step stopped it.
+func (h *Handlers) Run(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	var req RunRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !allowRunScripts(w, r, req.Steps) {
+		return
+	}
+
+	// A run may take up to its whole limit, well past the server's default write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(session.MaxRunTimeout + 15*time.Second)); err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to extend response deadline")
+		return
+	}
+
+	result, err := h.sessionManager.Run(r.Context(), sessionID, req.PageID, req.Steps)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidRun) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeExecutionFailed, err.Error())
+		}
+		return
+	}
+
+	if sess, err := h.sessionManager.GetSession(sessionID); err == nil {
+		audit.SetSession(r.Context(), sessionID, sess.AgentID)
+	}
+
+	writeJSON(w, http.StatusOK, RunResponse{
+		SessionID: sessionID,
+		RunResult: result,
+	})
+}
+
+// Assert handles POST /sessions/{id}/pages/{pageId}/assert. Failed checks are a 200
+// with "passed": false, so monitors can tell a broken page from a broken request.
+func (h *Handlers) Assert(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req AssertRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Function != "" && !allowScript(w, r, "function", req.Function) {
+		return
+	}
+
+	result, err := h.sessionManager.Assert(r.Context(), sessionID, pageID, session.Assertion{
+		Selector:    req.Selector,
+		Visible:     req.Visible,
+		Text:        req.Text,
+		TextMatches: req.TextMatches,
+		URL:         req.URL,
+		Cookie:      req.Cookie,
+		Status:      req.Status,
+		Function:    req.Function,
+
+		SelectorEngine: req.SelectorEngine,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidAssertion) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeExecutionFailed, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AssertResponse{
+		SessionID:       sessionID,
+		PageID:          pageID,
+		AssertionResult: result,
+	})
+}
+
+// allowRunScripts checks the scripts of run steps against the tenant's script policy,
+// writing a 403 for the first one refused
+func allowRunScripts(w http.ResponseWriter, r *http.Request, steps []session.RunStep) bool {
+	for i, step := range steps {
+		if step.Function != "" && !allowScript(w, r, fmt.Sprintf("steps[%d].function", i), step.Function) {
+			return false
+		}
+		if step.Script != "" && !allowScript(w, r, fmt.Sprintf("steps[%d].script", i), step.Script) {
+			return false
+		}
+	}
+	return true
+}
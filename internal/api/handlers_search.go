@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+)
+
+// Search handles POST /search, returning results an agent can navigate a session to.
+// A browser backend's throwaway session counts against the caller's tenant quota.
+func (h *Handlers) Search(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	backend := h.sessionManager.SearchBackend()
+	if backend == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeSearchUnavailable,
+			"No search backend configured (set SEARCH_BACKEND or SEARCH_API_KEY)")
+		return
+	}
+
+	query := search.Query{
+		Text:     req.Query,
+		Count:    req.Count,
+		Language: req.Language,
+		Region:   req.Region,
+		TenantID: tenant.IDFromContext(r.Context()),
+	}
+	results, err := backend.Search(r.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, search.ErrInvalidQuery):
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		case errors.Is(err, search.ErrSearchFailed):
+			writeError(w, http.StatusBadGateway, ErrCodeSearchFailed, err.Error())
+		case errors.Is(err, session.ErrTenantSessionLimit), errors.Is(err, session.ErrTenantProcessLimit), errors.Is(err, session.ErrTenantBandwidthLimit):
+			writeError(w, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+		case errors.Is(err, session.ErrDraining):
+			writeError(w, http.StatusServiceUnavailable, ErrCodeDraining, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SearchResponse{
+		Query:   req.Query,
+		Backend: backend.Name(),
+		Results: results,
+		Count:   len(results),
+	})
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/seeds"
+)
+
+// ExpandSeed handles POST /seeds/expand, listing the pages of a sitemap or feed for a
+// crawl to start from
+func (h *Handlers) ExpandSeed(w http.ResponseWriter, r *http.Request) {
+	var req ExpandSeedRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	expansion, err := h.sessionManager.ExpandSeed(r.Context(), req.URL, req.Since)
+	if err != nil {
+		if errors.Is(err, seeds.ErrInvalidSeed) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadGateway, ErrCodeSeedFetchFailed, err.Error())
+		return
+	}
+	if req.Limit > 0 && len(expansion.Targets) > req.Limit {
+		expansion.Targets = expansion.Targets[:req.Limit]
+		expansion.Truncated = true
+	}
+
+	writeJSON(w, http.StatusOK, ExpandSeedResponse{
+		URL:       req.URL,
+		Expansion: expansion,
+		Count:     len(expansion.Targets),
+	})
+}
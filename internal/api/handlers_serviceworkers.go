@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// writeServiceWorkerError maps a service worker or cache storage error to its status
+func writeServiceWorkerError(w http.ResponseWriter, err error, sessionID, pageID string) {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	} else if errors.Is(err, session.ErrPageNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+	} else if errors.Is(err, session.ErrServiceWorkerNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNoServiceWorker, err.Error())
+	} else if errors.Is(err, session.ErrCacheNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeCacheNotFound, err.Error())
+	} else if errors.Is(err, session.ErrInvalidStorage) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	} else if errors.Is(err, session.ErrEngineUnsupported) {
+		writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+	} else {
+		writeError(w, http.StatusInternalServerError, ErrCodeServiceWorkerFailed, err.Error())
+	}
+}
+
+// ListServiceWorkers handles GET /sessions/{id}/service-workers
+func (h *Handlers) ListServiceWorkers(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID, ok := requirePageID(w, r)
+	if !ok {
+		return
+	}
+
+	workers, err := h.sessionManager.ServiceWorkers(r.Context(), sessionID, pageID)
+	if err != nil {
+		writeServiceWorkerError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ServiceWorkersResponse{
+		SessionID:      sessionID,
+		PageID:         pageID,
+		ServiceWorkers: workers,
+		Count:          len(workers),
+	})
+}
+
+// UnregisterServiceWorkers handles DELETE /sessions/{id}/service-workers
+func (h *Handlers) UnregisterServiceWorkers(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID, ok := requirePageID(w, r)
+	if !ok {
+		return
+	}
+
+	unregistered, err := h.sessionManager.UnregisterServiceWorkers(r.Context(), sessionID, pageID, r.URL.Query().Get("scope_url"))
+	if err != nil {
+		writeServiceWorkerError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, UnregisterServiceWorkersResponse{
+		SessionID:    sessionID,
+		PageID:       pageID,
+		Unregistered: unregistered,
+		Count:        len(unregistered),
+	})
+}
+
+// GetCacheStorage handles GET /sessions/{id}/cache-storage
+func (h *Handlers) GetCacheStorage(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID, ok := requirePageID(w, r)
+	if !ok {
+		return
+	}
+
+	storage, err := h.sessionManager.InspectCacheStorage(r.Context(), sessionID, pageID, r.URL.Query().Get("origin"))
+	if err != nil {
+		writeServiceWorkerError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CacheStorageResponse{
+		SessionID:    sessionID,
+		PageID:       pageID,
+		CacheStorage: storage,
+	})
+}
+
+// ClearCacheStorage handles DELETE /sessions/{id}/cache-storage
+func (h *Handlers) ClearCacheStorage(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID, ok := requirePageID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	deleted, err := h.sessionManager.ClearCacheStorage(r.Context(), sessionID, pageID, query.Get("origin"), query.Get("cache"))
+	if err != nil {
+		writeServiceWorkerError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CacheStorageResponse{
+		SessionID:    sessionID,
+		PageID:       pageID,
+		CacheStorage: deleted,
+	})
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// checkShareTenant verifies that a session can be handed to tenantID
+func (h *Handlers) checkShareTenant(tenantID string) error {
+	if !h.tenants.Enabled() {
+		if tenantID != "" {
+			return fmt.Errorf("tenants are not configured, so tenant_id must be empty")
+		}
+		return nil
+	}
+	if h.tenants.Get(tenantID) == nil {
+		return fmt.Errorf("unknown tenant: %q", tenantID)
+	}
+	return nil
+}
+
+// shareResponse describes a session's owner and read-only grants
+func shareResponse(sess *session.Session, mode string) ShareSessionResponse {
+	return ShareSessionResponse{
+		SessionID: sess.ID,
+		Mode:      mode,
+		TenantID:  sess.TenantID,
+		AgentID:   sess.AgentID,
+		Shares:    sess.Shares(),
+	}
+}
+
+// ShareSession handles POST /sessions/{id}/share
+func (h *Handlers) ShareSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	var req ShareSessionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = session.ShareReadOnly
+	}
+
+	sess, err := h.sessionManager.GetSession(sessionID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	// The record names the agent giving the session away, not the one receiving it
+	audit.SetSession(r.Context(), sessionID, sess.AgentID)
+
+	switch req.Mode {
+	case session.ShareReadOnly:
+		if req.AgentID != "" {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "read-only shares are granted to a tenant, not an agent")
+			return
+		}
+		if err := h.checkShareTenant(req.TenantID); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		err = h.sessionManager.ShareSession(sessionID, req.TenantID)
+
+	case session.ShareTransfer:
+		// Unset fields keep the current owner's
+		if req.TenantID == "" {
+			req.TenantID = tenant.IDFromContext(r.Context())
+		}
+		if req.AgentID == "" {
+			req.AgentID = sess.AgentID
+		}
+		if err := h.checkShareTenant(req.TenantID); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		err = h.sessionManager.TransferSession(sessionID, req.TenantID, req.AgentID)
+
+	default:
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "mode must be read_only or transfer")
+		return
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrInvalidShare):
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		case errors.Is(err, session.ErrSessionNameConflict):
+			writeError(w, http.StatusConflict, "SESSION_NAME_CONFLICT",
+				fmt.Sprintf("Session name '%s' already exists for the new owner", sess.Name))
+		case errors.Is(err, session.ErrSessionLimitReached):
+			writeError(w, http.StatusTooManyRequests, "SESSION_LIMIT_REACHED", err.Error())
+		case errors.Is(err, session.ErrTenantSessionLimit) || errors.Is(err, session.ErrTenantProcessLimit) || errors.Is(err, session.ErrTenantBandwidthLimit):
+			writeError(w, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+		case errors.Is(err, session.ErrSessionNotFound):
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	if req.Mode == session.ShareTransfer {
+		slog.Info("session handed to a new owner", "session_id", sessionID, "tenant_id", req.TenantID, "agent_id", req.AgentID)
+	}
+
+	writeJSON(w, http.StatusOK, shareResponse(sess, req.Mode))
+}
+
+// ListShares handles GET /sessions/{id}/share
+func (h *Handlers) ListShares(w http.ResponseWriter, r *http.Request) {
+	sess, err := h.sessionManager.GetSession(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, shareResponse(sess, ""))
+}
+
+// RevokeShare handles DELETE /sessions/{id}/share/{tenantId}
+func (h *Handlers) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	tenantID := chi.URLParam(r, "tenantId")
+
+	if err := h.sessionManager.UnshareSession(sessionID, tenantID); err != nil {
+		if errors.Is(err, session.ErrShareNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeShareNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
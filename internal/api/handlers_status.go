@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+)
+
+// GetStatus handles GET /status
+func (h *Handlers) GetStatus(w http.ResponseWriter, r *http.Request) {
+	processes := h.loadBalancer.GetProcesses()
+
+	response := StatusResponse{
+		Status:    "ok",
+		Uptime:    time.Since(h.startedAt),
+		Sessions:  h.sessionManager.GetSessionCount(),
+		Processes: make([]ProcessStatus, 0, len(processes)),
+		Ports:     browser.PortPoolStats(),
+		WarmPool:  h.sessionManager.WarmPoolStats(),
+	}
+
+	for _, process := range processes {
+		healthy := process.IsHealthy()
+		metrics := process.GetMetrics()
+		if !healthy || (metrics.Breaker != nil && metrics.Breaker.State == pool.BreakerOpen) {
+			response.Status = "degraded"
+		}
+		response.Processes = append(response.Processes, ProcessStatus{
+			ProcessMetrics: metrics,
+			Healthy:        healthy,
+		})
+	}
+
+	// Sessions already running still need this instance, so draining answers 200 too
+	if h.sessionManager.IsDraining() {
+		drain := h.sessionManager.DrainStatus()
+		response.Status = "draining"
+		response.Drain = &drain
+	}
+
+	// Degraded still answers 200: the service is up, just short of capacity
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// writeStorageError maps a storage inspection error to its status
+func writeStorageError(w http.ResponseWriter, err error, sessionID, pageID string) {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	} else if errors.Is(err, session.ErrPageNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+	} else if errors.Is(err, session.ErrInvalidStorage) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	} else if errors.Is(err, session.ErrResponseTooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, ErrCodeResponseTooLarge, err.Error())
+	} else if errors.Is(err, session.ErrEngineUnsupported) {
+		writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported, err.Error())
+	} else {
+		writeError(w, http.StatusInternalServerError, ErrCodeStorageFailed, err.Error())
+	}
+}
+
+// requirePageID reads the page_id query parameter the session-level storage routes act through
+func requirePageID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	pageID := r.URL.Query().Get("page_id")
+	if pageID == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "the page_id query parameter is required")
+		return "", false
+	}
+	return pageID, true
+}
+
+// GetStorage handles GET /sessions/{id}/storage
+func (h *Handlers) GetStorage(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	pageID, ok := requirePageID(w, r)
+	if !ok {
+		return
+	}
+
+	storage, err := h.sessionManager.InspectStorage(r.Context(), sessionID, pageID, r.URL.Query().Get("origin"))
+	if err != nil {
+		writeStorageError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StorageResponse{
+		SessionID:  sessionID,
+		PageID:     pageID,
+		WebStorage: storage,
+	})
+}
+
+// WriteStorage handles PUT /sessions/{id}/storage
+func (h *Handlers) WriteStorage(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	var req WriteStorageRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	storage, err := h.sessionManager.WriteStorage(r.Context(), sessionID, req.PageID, session.StorageWrite{
+		Origin: req.Origin,
+		Area:   req.Area,
+		Set:    req.Set,
+		Remove: req.Remove,
+		Clear:  req.Clear,
+	})
+	if err != nil {
+		writeStorageError(w, err, sessionID, req.PageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StorageResponse{
+		SessionID:  sessionID,
+		PageID:     req.PageID,
+		WebStorage: storage,
+	})
+}
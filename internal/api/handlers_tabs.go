@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListPages handles GET /sessions/{id}/pages
+func (h *Handlers) ListPages(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	pages, err := h.sessionManager.ListPages(r.Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	response := ListPagesResponse{
+		SessionID: sessionID,
+		Pages:     pages,
+		Count:     len(pages),
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// ActivatePage handles POST /sessions/{id}/pages/{pageId}/activate
+func (h *Handlers) ActivatePage(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	if err := h.sessionManager.ActivatePage(r.Context(), sessionID, pageID); err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DuplicatePage handles POST /sessions/{id}/pages/{pageId}/duplicate
+func (h *Handlers) DuplicatePage(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	newPageID, url, err := h.sessionManager.DuplicatePage(r.Context(), sessionID, pageID)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeNavigationFailed, err.Error())
+		}
+		return
+	}
+
+	response := DuplicatePageResponse{
+		SessionID:    sessionID,
+		PageID:       newPageID,
+		SourcePageID: pageID,
+		URL:          url,
+	}
+
+	writeJSON(w, http.StatusCreated, response)
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+// StreamScreencast handles GET /sessions/{id}/pages/{pageId}/screencast
+// A view-only live viewport: frames are pushed, incoming messages are ignored.
+func (h *Handlers) StreamScreencast(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	frames, stop, ok := h.openScreencast(w, r, sessionID, pageID)
+	if !ok {
+		return
+	}
+	defer stop()
+
+	conn, err := eventUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Warn("screencast upgrade failed", "session_id", sessionID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Drain (and discard) client messages so disconnects are noticed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	writeViewport(conn, frames, closed, nil)
+}
+
+// Takeover handles GET /sessions/{id}/pages/{pageId}/takeover
+// Streams the page like StreamScreencast and forwards the operator's mouse and
+// keyboard input until they send {"type":"release"} or disconnect.
+func (h *Handlers) Takeover(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	// Claim control before upgrading so conflicts get a normal HTTP error
+	takeover, err := h.sessionManager.StartTakeover(sessionID, pageID, r.URL.Query().Get("operator"))
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(w, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		}
+		return
+	}
+	defer h.sessionManager.EndTakeover(sessionID, takeover.ID)
+
+	frames, stop, ok := h.openScreencast(w, r, sessionID, pageID)
+	if !ok {
+		return
+	}
+	defer stop()
+
+	conn, err := eventUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Warn("takeover upgrade failed", "session_id", sessionID, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Messages for the operator besides frames (granted notice, input errors)
+	notices := make(chan ViewportMessage, 8)
+	notices <- ViewportMessage{Type: "control", TakeoverID: takeover.ID, State: "granted"}
+
+	// Read operator input until release, disconnect or a forced end
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var message struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(data, &message); err != nil {
+				notify(notices, "invalid message: "+err.Error())
+				continue
+			}
+
+			switch message.Type {
+			case "release":
+				return
+			case "mouse":
+				var input session.MouseInput
+				if err := json.Unmarshal(data, &input); err != nil {
+					notify(notices, "invalid mouse event: "+err.Error())
+					continue
+				}
+				if err := h.sessionManager.DispatchTakeoverMouse(r.Context(), sessionID, takeover.ID, input); err != nil {
+					if errors.Is(err, session.ErrNoTakeover) {
+						return
+					}
+					notify(notices, err.Error())
+				}
+			case "key":
+				var input session.KeyInput
+				if err := json.Unmarshal(data, &input); err != nil {
+					notify(notices, "invalid key event: "+err.Error())
+					continue
+				}
+				if err := h.sessionManager.DispatchTakeoverKey(r.Context(), sessionID, takeover.ID, input); err != nil {
+					if errors.Is(err, session.ErrNoTakeover) {
+						return
+					}
+					notify(notices, err.Error())
+				}
+			default:
+				notify(notices, "unknown message type: "+message.Type)
+			}
+		}
+	}()
+
+	// Stop when the operator releases or control is revoked from elsewhere
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		select {
+		case <-released:
+		case <-takeover.Done():
+		}
+	}()
+
+	writeViewport(conn, frames, finished, notices)
+
+	// Tell the operator control went back to the agent
+	conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+	conn.WriteJSON(ViewportMessage{Type: "control", TakeoverID: takeover.ID, State: "returned"})
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "control returned"),
+		time.Now().Add(eventWriteTimeout))
+}
+
+// GetTakeover handles GET /sessions/{id}/takeover
+func (h *Handlers) GetTakeover(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if _, err := h.sessionManager.GetSession(sessionID); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		return
+	}
+
+	takeover, active := h.sessionManager.GetTakeover(sessionID)
+
+	writeJSON(w, http.StatusOK, TakeoverStatusResponse{
+		SessionID: sessionID,
+		Active:    active,
+		Takeover:  takeover,
+	})
+}
+
+// EndTakeover handles DELETE /sessions/{id}/takeover
+// Forces control back to the agent (e.g. the operator walked away).
+func (h *Handlers) EndTakeover(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if err := h.sessionManager.EndTakeover(sessionID, ""); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeTakeoverNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// openScreencast starts watching a page, writing an HTTP error on failure
+func (h *Handlers) openScreencast(w http.ResponseWriter, r *http.Request, sessionID string, pageID string) (<-chan session.ScreencastFrame, func(), bool) {
+	opts := session.DefaultScreencastOptions()
+	query := r.URL.Query()
+
+	if format := query.Get("format"); format == "png" || format == "jpeg" {
+		opts.Format = format
+	}
+	if quality, err := strconv.Atoi(query.Get("quality")); err == nil && quality > 0 && quality <= 100 {
+		opts.Quality = quality
+	}
+	if maxWidth, err := strconv.Atoi(query.Get("max_width")); err == nil && maxWidth > 0 {
+		opts.MaxWidth = maxWidth
+	}
+	if maxHeight, err := strconv.Atoi(query.Get("max_height")); err == nil && maxHeight > 0 {
+		opts.MaxHeight = maxHeight
+	}
+	if nth, err := strconv.Atoi(query.Get("every_nth_frame")); err == nil && nth > 0 {
+		opts.EveryNthFrame = nth
+	}
+
+	frames, stop, err := h.sessionManager.WatchScreencast(r.Context(), sessionID, pageID, opts)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeScreenshotFailed, err.Error())
+		}
+		return nil, nil, false
+	}
+
+	return frames, stop, true
+}
+
+// writeViewport is the single writer for a viewport socket: frames, notices and pings
+func writeViewport(conn *websocket.Conn, frames <-chan session.ScreencastFrame, done <-chan struct{}, notices <-chan ViewportMessage) {
+	ticker := time.NewTicker(eventPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case frame, ok := <-frames:
+			if !ok {
+				// Screencast stopped (page closed)
+				return
+			}
+			metadata := frame.Metadata
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(ViewportMessage{Type: "frame", Data: frame.Data, Metadata: &metadata}); err != nil {
+				return
+			}
+
+		case notice := <-notices:
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(notice); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// notify queues an error message for the operator without blocking the reader
+func notify(notices chan<- ViewportMessage, message string) {
+	select {
+	case notices <- ViewportMessage{Type: "error", Message: message}:
+	default:
+	}
+}
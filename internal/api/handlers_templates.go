@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// SaveTemplate handles POST /templates
+func (h *Handlers) SaveTemplate(w http.ResponseWriter, r *http.Request) {
+	var req SaveTemplateRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	template := &session.SessionTemplate{
+		Name:        req.Name,
+		Description: req.Description,
+		Options:     req.Options,
+	}
+
+	// Saving under an existing name replaces it; running sessions keep their options.
+	// The template is the caller's tenant's own.
+	if err := h.sessionManager.Templates().Put(tenant.IDFromContext(r.Context()), template); err != nil {
+		if errors.Is(err, session.ErrInvalidTemplateName) || errors.Is(err, session.ErrInvalidSessionOptions) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, template)
+}
+
+// ListTemplates handles GET /templates
+func (h *Handlers) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := h.sessionManager.Templates().List(tenant.IDFromContext(r.Context()))
+
+	writeJSON(w, http.StatusOK, ListTemplatesResponse{
+		Templates: templates,
+		Count:     len(templates),
+	})
+}
+
+// GetTemplate handles GET /templates/{name}
+func (h *Handlers) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.sessionManager.Templates().Get(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeTemplateNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, template)
+}
+
+// DeleteTemplate handles DELETE /templates/{name}
+func (h *Handlers) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := h.sessionManager.Templates().Delete(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name")); err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeTemplateNotFound, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
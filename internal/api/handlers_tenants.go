@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+)
+
+// GetTenant handles GET /tenant
+func (h *Handlers) GetTenant(w http.ResponseWriter, r *http.Request) {
+	tenantID := tenant.IDFromContext(r.Context())
+	response := TenantResponse{
+		TenantID: tenantID,
+		Role:     tenant.RoleFromContext(r.Context()),
+		Usage:    h.sessionManager.TenantUsage(tenantID),
+	}
+	if t := tenant.FromContext(r.Context()); t != nil {
+		response.Name = t.Name
+		response.MaxSessions = t.MaxSessions
+		response.MaxProcesses = t.MaxProcesses
+		response.MaxBandwidthMB = t.MaxBandwidthMB
+		response.MaxModelSpendUSD = t.MaxModelSpendUSD
+		response.DefaultTemplate = t.DefaultTemplate
+		response.ScriptPolicy = t.ScriptPolicy
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// defaultUsageDays is how many days GET /usage reports without ?days=
+const defaultUsageDays = 7
+
+// GetUsage handles GET /usage: tokens and estimated cost of the caller's model calls
+// per UTC day, today first, broken down by operation and session
+func (h *Handlers) GetUsage(w http.ResponseWriter, r *http.Request) {
+	days := defaultUsageDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "days must be a positive number")
+			return
+		}
+		days = n
+	}
+
+	tenantID := tenant.IDFromContext(r.Context())
+	response := UsageResponse{
+		TenantID: tenantID,
+		Days:     h.sessionManager.ModelUsage(tenantID, days),
+	}
+	if t := tenant.FromContext(r.Context()); t != nil {
+		response.MaxModelSpendUSD = t.MaxModelSpendUSD
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// selectTenantProcess load balances a new session over the browsers of the engine opts
+// ask for. A tenant at its process quota is kept on the processes it already uses
+// instead of being refused.
+func (h *Handlers) selectTenantProcess(tenantID string, caller *tenant.Tenant, opts *session.SessionOptions) (*pool.ManagedProcess, error) {
+	balancer := h.loadBalancer
+	if opts != nil && opts.Engine == session.EngineFirefox {
+		if h.firefox == nil {
+			return nil, fmt.Errorf("%w: %s", session.ErrEngineUnavailable, session.EngineFirefox)
+		}
+		balancer = h.firefox
+	}
+
+	if caller != nil && caller.MaxProcesses > 0 {
+		usage := h.sessionManager.TenantUsage(tenantID)
+		if len(usage.Ports) >= caller.MaxProcesses {
+			return balancer.SelectProcessAmong(usage.Ports)
+		}
+	}
+	return balancer.SelectProcess()
+}
+
+// allProcesses returns the browsers of every engine, whose session counts the handlers
+// keep up to date
+func (h *Handlers) allProcesses() []*pool.ManagedProcess {
+	processes := h.loadBalancer.GetProcesses()
+	if h.firefox != nil {
+		processes = append(processes, h.firefox.GetProcesses()...)
+	}
+	return processes
+}
+
+// allowScript checks a caller-supplied script against the tenant's script policy,
+// writing a 403 when it is refused. what names the script's field. It reports whether
+// the script may run.
+func allowScript(w http.ResponseWriter, r *http.Request, what string, script string) bool {
+	t := tenant.FromContext(r.Context())
+	if t == nil {
+		return true
+	}
+	if err := t.ScriptPolicy.Check(what, script); err != nil {
+		writeError(w, http.StatusForbidden, ErrCodeScriptDenied, err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vault"
+	"github.com/go-chi/chi/v5"
+)
+
+// TestReadOnlyAnnotate tests that observers and read-only shares can't annotate a
+// screenshot, which draws into the live page
+func TestReadOnlyAnnotate(t *testing.T) {
+	handlers := &Handlers{}
+
+	r := httptest.NewRequest(http.MethodPost, "/observe/obs_1/screenshot", strings.NewReader(`{"page_id": "page-1", "annotate": true}`))
+	w := httptest.NewRecorder()
+	handlers.CaptureScreenshot(w, withReadOnly(r))
+
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), ErrCodeForbidden) {
+		t.Errorf("expected 403 %s, got %d %s", ErrCodeForbidden, w.Code, w.Body.String())
+	}
+}
+
+// TestTenantResources tests that credentials, templates and pipelines saved by one
+// tenant are invisible to another, which gets the same 404 as for unknown names
+func TestTenantResources(t *testing.T) {
+	key, err := vault.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	credentialVault, err := vault.New(key, vault.NewMemoryStore())
+	if err != nil {
+		t.Fatalf("vault.New failed: %v", err)
+	}
+	manager := session.NewManager(nil)
+	defer manager.Close()
+	handlers := &Handlers{sessionManager: manager, vault: credentialVault}
+
+	router := chi.NewRouter()
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller := &tenant.Tenant{ID: r.Header.Get("X-Tenant")}
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), caller)))
+		})
+	})
+	router.Post("/credentials", handlers.SaveCredential)
+	router.Get("/credentials", handlers.ListCredentials)
+	router.Delete("/credentials/{alias}", handlers.DeleteCredential)
+	router.Post("/sessions/{id}/login", handlers.Login)
+	router.Post("/templates", handlers.SaveTemplate)
+	router.Get("/templates", handlers.ListTemplates)
+	router.Get("/templates/{name}", handlers.GetTemplate)
+	router.Delete("/templates/{name}", handlers.DeleteTemplate)
+	router.Post("/pipelines", handlers.SavePipeline)
+	router.Get("/pipelines", handlers.ListPipelines)
+	router.Get("/pipelines/{name}", handlers.GetPipeline)
+	router.Delete("/pipelines/{name}", handlers.DeletePipeline)
+	router.Post("/pipelines/{name}/run", handlers.RunPipeline)
+	router.Post("/checks", handlers.SaveCheck)
+	router.Get("/checks/{name}", handlers.GetCheck)
+	router.Delete("/checks/{name}", handlers.DeleteCheck)
+
+	call := func(tenantID, method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("X-Tenant", tenantID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, save := range []struct{ path, body string }{
+		{"/credentials", `{"alias": "shop", "username": "bot", "password": "hunter2"}`},
+		{"/templates", `{"name": "stealth", "options": {"user_agent": "acme"}}`},
+		{"/pipelines", `{"name": "leads"}`},
+	} {
+		if w := call("acme", http.MethodPost, save.path, save.body); w.Code != http.StatusCreated {
+			t.Fatalf("saving %s: expected 201, got %d %s", save.path, w.Code, w.Body.String())
+		}
+	}
+
+	// The owner sees its entries, another tenant sees none of them
+	for _, list := range []string{"/credentials", "/templates", "/pipelines"} {
+		var response struct {
+			Count int `json:"count"`
+		}
+		for tenantID, want := range map[string]int{"acme": 1, "globex": 0} {
+			w := call(tenantID, http.MethodGet, list, "")
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode %s: %v", list, err)
+			}
+			if response.Count != want {
+				t.Errorf("%s listing %s: expected %d, got %d", tenantID, list, want, response.Count)
+			}
+		}
+	}
+
+	// Using, reading or deleting another tenant's entry looks like it doesn't exist
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		code   string
+	}{
+		{http.MethodPost, "/sessions/sess_1/login", `{"page_id": "page-1", "credential": "shop"}`, ErrCodeCredentialNotFound},
+		{http.MethodDelete, "/credentials/shop", "", ErrCodeCredentialNotFound},
+		{http.MethodGet, "/templates/stealth", "", ErrCodeTemplateNotFound},
+		{http.MethodDelete, "/templates/stealth", "", ErrCodeTemplateNotFound},
+		{http.MethodGet, "/pipelines/leads", "", ErrCodePipelineNotFound},
+		{http.MethodDelete, "/pipelines/leads", "", ErrCodePipelineNotFound},
+		{http.MethodPost, "/pipelines/leads/run", `{"records": [{"id": 1}]}`, ErrCodePipelineNotFound},
+	}
+	for _, c := range cases {
+		w := call("globex", c.method, c.path, c.body)
+		if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), c.code) {
+			t.Errorf("globex %s %s: expected 404 %s, got %d %s", c.method, c.path, c.code, w.Code, w.Body.String())
+		}
+	}
+
+	// The owner still has everything
+	for _, path := range []string{"/templates/stealth", "/pipelines/leads"} {
+		if w := call("acme", http.MethodGet, path, ""); w.Code != http.StatusOK {
+			t.Errorf("acme GET %s: expected 200, got %d", path, w.Code)
+		}
+	}
+	if w := call("acme", http.MethodDelete, "/credentials/shop", ""); w.Code != http.StatusNoContent {
+		t.Errorf("acme DELETE /credentials/shop: expected 204, got %d", w.Code)
+	}
+
+	// Names are per tenant: both can save a check of the same name, and each keeps its own
+	for _, tenantID := range []string{"acme", "globex"} {
+		body := `{"name": "checkout", "description": "` + tenantID + `", "paused": true, "steps": [{"action": "navigate", "url": "https://example.com"}]}`
+		if w := call(tenantID, http.MethodPost, "/checks", body); w.Code != http.StatusCreated {
+			t.Fatalf("%s saving checkout: expected 201, got %d %s", tenantID, w.Code, w.Body.String())
+		}
+	}
+	if w := call("globex", http.MethodDelete, "/checks/checkout", ""); w.Code != http.StatusNoContent {
+		t.Errorf("globex DELETE /checks/checkout: expected 204, got %d", w.Code)
+	}
+	var check session.CheckReport
+	w := call("acme", http.MethodGet, "/checks/checkout", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &check); err != nil || w.Code != http.StatusOK || check.Description != "acme" {
+		t.Errorf("expected acme's check kept, got %d %s", w.Code, w.Body.String())
+	}
+	if w := call("globex", http.MethodGet, "/checks/checkout", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected globex's check gone, got %d", w.Code)
+	}
+}
+
+// TestGetUsage tests that GET /usage reports the caller's tenant and budget for the
+// days asked for, and refuses a bad day count
+func TestGetUsage(t *testing.T) {
+	manager := session.NewManager(nil)
+	defer manager.Close()
+	handlers := &Handlers{sessionManager: manager}
+
+	r := httptest.NewRequest(http.MethodGet, "/usage?days=3", nil)
+	r = r.WithContext(tenant.WithTenant(r.Context(), &tenant.Tenant{ID: "acme", MaxModelSpendUSD: 5}))
+	w := httptest.NewRecorder()
+	handlers.GetUsage(w, r)
+
+	var response UsageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode usage: %v", err)
+	}
+	if w.Code != http.StatusOK || response.TenantID != "acme" || response.MaxModelSpendUSD != 5 || len(response.Days) != 3 {
+		t.Errorf("unexpected usage: %d %+v", w.Code, response)
+	}
+
+	w = httptest.NewRecorder()
+	handlers.GetUsage(w, httptest.NewRequest(http.MethodGet, "/usage?days=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative day count, got %d", w.Code)
+	}
+}
+
+// solidPNG encodes a small image of one colour
+func solidPNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for x := range 4 {
+		for y := range 4 {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestPNGETag tests that an unchanged PNG is answered with a bodiless 304 and that a
+// changed one is sent again with a new ETag
+func TestPNGETag(t *testing.T) {
+	manager := session.NewManager(nil)
+	defer manager.Close()
+	handlers := &Handlers{sessionManager: manager}
+	router := chi.NewRouter()
+	router.Get("/baselines/{name}", handlers.GetBaseline)
+	router.Put("/baselines/{name}", handlers.PutBaseline)
+
+	call := func(method string, body []byte, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/baselines/home", bytes.NewReader(body))
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	red := solidPNG(t, color.RGBA{R: 255, A: 255})
+	if w := call(http.MethodPut, red, ""); w.Code != http.StatusOK {
+		t.Fatalf("PUT failed: %d %s", w.Code, w.Body.String())
+	}
+	first := call(http.MethodGet, nil, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || !bytes.Equal(first.Body.Bytes(), red) {
+		t.Fatalf("expected the PNG with an ETag, got %d %q", first.Code, etag)
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag} {
+		w := call(http.MethodGet, nil, header)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: expected a bodiless 304, got %d with %d bytes", header, w.Code, w.Body.Len())
+		}
+	}
+
+	// A new image no longer matches the old tag
+	blue := solidPNG(t, color.RGBA{B: 255, A: 255})
+	call(http.MethodPut, blue, "")
+	w := call(http.MethodGet, nil, etag)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), blue) {
+		t.Fatalf("expected the changed PNG, got %d", w.Code)
+	}
+	if changed := w.Header().Get("ETag"); changed == "" || changed == etag {
+		t.Errorf("expected a new ETag, got %q", changed)
+	}
+}
+
+// TestSessionNotFound tests that routes on a session that doesn't exist answer 404
+// SESSION_NOT_FOUND, whatever wording the error they get has
+func TestSessionNotFound(t *testing.T) {
+	manager := session.NewManager(nil)
+	defer manager.Close()
+	s := NewServer("0", manager, nil, nil, nil, nil, nil, nil, nil, "", nil, tenant.NewRegistry(), nil, nil)
+
+	for _, route := range []struct{ method, path, body string }{
+		{http.MethodPost, "/sessions/sess_missing/navigate", `{"url": "https://example.com"}`},
+		{http.MethodGet, "/sessions/sess_missing/pages/page-1/content", ""},
+		{http.MethodGet, "/sessions/sess_missing/pages/page-1/handles", ""},
+		{http.MethodGet, "/sessions/sess_missing/pages/page-1/network/websockets", ""},
+		{http.MethodPost, "/sessions/sess_missing/pages/page-1/wait", `{"selector": "#buy"}`},
+		{http.MethodGet, "/sessions/sess_missing/takeover", ""},
+		{http.MethodPost, "/sessions/sess_missing/share", `{"tenant_id": "globex"}`},
+	} {
+		r := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, r)
+
+		var response ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusNotFound || response.Error.Code != ErrCodeSessionNotFound {
+			t.Errorf("%s %s: expected 404 %s, got %d %s", route.method, route.path, ErrCodeSessionNotFound, w.Code, w.Body.String())
+		}
+	}
+}
+
+// TestCreateSessionWebKit tests that asking for a WebKit session is refused with a 400
+// naming the engine, before any browser is picked
+func TestCreateSessionWebKit(t *testing.T) {
+	manager := session.NewManager(nil)
+	defer manager.Close()
+	s := NewServer("0", manager, nil, nil, nil, nil, nil, nil, nil, "", nil, tenant.NewRegistry(), nil, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/sessions", strings.NewReader(`{"agent_id": "agent-1", "options": {"engine": "webkit"}}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+
+	var response ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusBadRequest || response.Error.Code != ErrCodeInvalidRequest || !strings.Contains(response.Error.Message, `engine "webkit" isn't supported`) {
+		t.Errorf("expected 400 %s naming webkit, got %d %s", ErrCodeInvalidRequest, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+	"github.com/go-chi/chi/v5"
+)
+
+// VisionQuery handles POST /sessions/{id}/pages/{pageId}/vision-query
+func (h *Handlers) VisionQuery(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req VisionQueryRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if h.visionModel == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeVisionUnavailable,
+			"No vision model configured (set VISION_API_KEY or VISION_API_URL)")
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = session.DefaultVisionTimeout
+	}
+
+	// Model round trips outlive the server's default write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 15*time.Second)); err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to extend response deadline")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// Streamed answers arrive as events, and so do the errors after the stream started
+	out := http.ResponseWriter(w)
+	var onText func(string)
+	var stream *answerStream
+	if req.Stream {
+		stream = startAnswerStream(w)
+		defer stream.close()
+		out = stream.errors()
+		onText = stream.token
+	}
+
+	result, err := h.sessionManager.VisionQuery(ctx, sessionID, pageID, h.visionModel, session.VisionRequest{
+		Question: req.Question,
+		Overlay:  req.Overlay,
+		Fresh:    req.Fresh,
+		NoCache:  req.NoCache,
+		OnText:   onText,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(out, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrTakeoverActive) {
+			writeError(out, http.StatusConflict, ErrCodeTakeoverActive, err.Error())
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(out, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, vision.ErrQueryFailed) {
+			writeError(out, http.StatusBadGateway, ErrCodeVisionFailed, err.Error())
+		} else if errors.Is(err, session.ErrTenantModelBudget) {
+			writeError(out, http.StatusTooManyRequests, ErrCodeTenantQuota, err.Error())
+		} else if errors.Is(err, session.ErrModelBudget) {
+			writeError(out, http.StatusTooManyRequests, ErrCodeModelBudget, err.Error())
+		} else {
+			writeError(out, http.StatusInternalServerError, ErrCodeScreenshotFailed, err.Error())
+		}
+		return
+	}
+
+	response := VisionQueryResponse{
+		SessionID:    sessionID,
+		PageID:       pageID,
+		VisionResult: result,
+	}
+
+	if stream != nil {
+		stream.send("result", response)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/visual"
+	"github.com/go-chi/chi/v5"
+)
+
+// VisualDiff handles POST /sessions/{id}/pages/{pageId}/visual-diff
+func (h *Handlers) VisualDiff(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req VisualDiffRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	comparison, err := h.sessionManager.CompareScreenshot(r.Context(), sessionID, pageID, session.VisualCompare{
+		Baseline:       req.Baseline,
+		Threshold:      req.Threshold,
+		MaxDiffPercent: req.MaxDiffPercent,
+		Ignore:         req.Ignore,
+		Update:         req.Update,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidComparison) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else if errors.Is(err, visual.ErrInvalidImage) {
+			// Baselines are checked when they are stored, so this is the screenshot
+			writeError(w, http.StatusBadGateway, ErrCodeScreenshotFailed, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeScreenshotFailed, err.Error())
+		}
+		return
+	}
+
+	response := VisualDiffResponse{
+		SessionID:        sessionID,
+		PageID:           pageID,
+		VisualComparison: comparison,
+	}
+	if comparison.Result != nil {
+		response.DiffImage = base64.StdEncoding.EncodeToString(comparison.Diff)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// ListBaselines handles GET /baselines
+func (h *Handlers) ListBaselines(w http.ResponseWriter, r *http.Request) {
+	baselines, err := h.sessionManager.Baselines().List(tenant.IDFromContext(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ListBaselinesResponse{
+		Baselines: baselines,
+		Count:     len(baselines),
+	})
+}
+
+// GetBaseline handles GET /baselines/{name}, returning the PNG
+func (h *Handlers) GetBaseline(w http.ResponseWriter, r *http.Request) {
+	data, err := h.sessionManager.Baselines().Get(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name"))
+	if err != nil {
+		writeBaselineError(w, err)
+		return
+	}
+	if writeNotModified(w, r, contentETag(data)) {
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(data)
+}
+
+// PutBaseline handles PUT /baselines/{name}, whose body is the PNG to compare against
+func (h *Handlers) PutBaseline(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	store := h.sessionManager.Baselines()
+	tenantID := tenant.IDFromContext(r.Context())
+	name := chi.URLParam(r, "name")
+	if err := store.Put(tenantID, name, data); err != nil {
+		writeBaselineError(w, err)
+		return
+	}
+
+	width, height, _ := visual.Size(data)
+	writeJSON(w, http.StatusOK, visual.Baseline{Name: name, Width: width, Height: height, Bytes: len(data), UpdatedAt: time.Now()})
+}
+
+// DeleteBaseline handles DELETE /baselines/{name}
+func (h *Handlers) DeleteBaseline(w http.ResponseWriter, r *http.Request) {
+	if err := h.sessionManager.Baselines().Delete(tenant.IDFromContext(r.Context()), chi.URLParam(r, "name")); err != nil {
+		writeBaselineError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeBaselineError maps baseline store errors to responses
+func writeBaselineError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, visual.ErrBaselineNotFound):
+		writeError(w, http.StatusNotFound, ErrCodeBaselineNotFound, err.Error())
+	case errors.Is(err, visual.ErrInvalidBaseline), errors.Is(err, visual.ErrInvalidImage):
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+	}
+}
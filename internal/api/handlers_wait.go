@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// Wait handles POST /sessions/{id}/pages/{pageId}/wait
+func (h *Handlers) Wait(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req WaitRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Function != "" && !allowScript(w, r, "function", req.Function) {
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutMS) * time.Millisecond
+	if timeout <= 0 {
+		timeout = session.DefaultWaitTimeout
+	}
+
+	// Long waits outlive the server's default write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 15*time.Second)); err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to extend response deadline")
+		return
+	}
+
+	result, err := h.sessionManager.Wait(r.Context(), sessionID, pageID, session.WaitRequest{
+		Selector:       req.Selector,
+		SelectorEngine: req.SelectorEngine,
+		State:          req.State,
+		URL:            req.URL,
+		Function:       req.Function,
+		Timeout:        timeout,
+		Interval:       time.Duration(req.PollingMS) * time.Millisecond,
+	})
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+		} else if errors.Is(err, session.ErrPageNotFound) {
+			writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+		} else if errors.Is(err, session.ErrInvalidWait) || errors.Is(err, session.ErrInvalidSelector) {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, ErrCodeExecutionFailed, err.Error())
+		}
+		return
+	}
+
+	response := WaitResponse{
+		SessionID:  sessionID,
+		PageID:     pageID,
+		WaitResult: result,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
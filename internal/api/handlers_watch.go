@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/go-chi/chi/v5"
+)
+
+// writeWatchError maps DOM watch errors to responses
+func writeWatchError(w http.ResponseWriter, err error, sessionID string, pageID string) {
+	if errors.Is(err, session.ErrSessionNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+	} else if errors.Is(err, session.ErrPageNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodePageNotFound, "Page not found in session")
+	} else if errors.Is(err, session.ErrWatchNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeWatchNotFound, err.Error())
+	} else if errors.Is(err, session.ErrInvalidWatch) || errors.Is(err, session.ErrInvalidSelector) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+	} else {
+		writeError(w, http.StatusInternalServerError, ErrCodeWatchFailed, err.Error())
+	}
+}
+
+// WatchDOM handles POST /sessions/{id}/pages/{pageId}/watch
+func (h *Handlers) WatchDOM(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	var req WatchRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	watch, err := h.sessionManager.WatchDOM(r.Context(), sessionID, pageID, session.WatchRequest{
+		Selector:       req.Selector,
+		SelectorEngine: req.SelectorEngine,
+		Debounce:       time.Duration(req.DebounceMs) * time.Millisecond,
+		Attributes:     req.Attributes,
+	})
+	if err != nil {
+		writeWatchError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, WatchResponse{
+		SessionID: sessionID,
+		DOMWatch:  watch,
+	})
+}
+
+// ListWatches handles GET /sessions/{id}/pages/{pageId}/watch
+func (h *Handlers) ListWatches(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+
+	watches, err := h.sessionManager.ListWatches(sessionID, pageID)
+	if err != nil {
+		writeWatchError(w, err, sessionID, pageID)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ListWatchesResponse{
+		SessionID: sessionID,
+		PageID:    pageID,
+		Watches:   watches,
+		Count:     len(watches),
+	})
+}
+
+// Unwatch handles DELETE /sessions/{id}/pages/{pageId}/watch/{watchId}
+func (h *Handlers) Unwatch(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+	pageID := chi.URLParam(r, "pageId")
+	watchID := chi.URLParam(r, "watchId")
+
+	if err := h.sessionManager.Unwatch(r.Context(), sessionID, pageID, watchID); err != nil {
+		writeWatchError(w, err, sessionID, pageID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
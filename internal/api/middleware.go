@@ -1,10 +1,26 @@
 package api
 
 import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"path"
 	"runtime/debug"
+	"strings"
 	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/sessionlog"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // LoggingMiddleware logs all HTTP requests
@@ -13,8 +29,11 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		// Record start time
 		startTime := time.Now()
 
+		// Give the request tags for the routes to name its session and page
+		r = r.WithContext(sessionlog.WithTags(r.Context()))
+
 		// Log request start
-		slog.Info("request started",
+		slog.InfoContext(r.Context(), "request started",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote", r.RemoteAddr,
@@ -24,7 +43,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 
 		// Log request completion with duration
-		slog.Info("request completed",
+		slog.InfoContext(r.Context(), "request completed",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"duration", time.Since(startTime),
@@ -38,6 +57,11 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
+				// A handler aborting a response it already started must reach net/http, which drops the connection
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
 				//Logging error with stack trace
 				slog.Error("panic in handler", "error", err, "stack", string(debug.Stack()))
 				
@@ -46,4 +70,456 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 		}()
 		next.ServeHTTP(w, r)
 	})
-}
\ No newline at end of file
+}
+// ObserverMiddleware resolves an observer ID from the URL into the session it watches.
+// The resolved session ID is exposed as the "id" URL param so the regular read-only
+// handlers can serve observer routes unchanged.
+func ObserverMiddleware(manager *session.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			observerID := chi.URLParam(r, "observerId")
+
+			sess, err := manager.ResolveObserver(observerID)
+			if err != nil {
+				writeError(w, http.StatusNotFound, ErrCodeObserverNotFound, err.Error())
+				return
+			}
+
+			// Expose the observed session to downstream handlers
+			chi.RouteContext(r.Context()).URLParams.Add("id", sess.ID)
+
+			audit.SetActor(r.Context(), "observer:"+observerID)
+			next.ServeHTTP(w, withReadOnly(r))
+		})
+	}
+}
+
+// AdminAuthMiddleware only lets requests through that present the admin key,
+// either as "Authorization: Bearer <key>" or in the X-Admin-Key header.
+// adminKey is called per request so the key can be rotated; an empty key rejects everything.
+func AdminAuthMiddleware(adminKey func() string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expected := adminKey()
+			if expected == "" {
+				writeError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Admin API is disabled")
+				return
+			}
+
+			provided := r.Header.Get("X-Admin-Key")
+			if provided == "" {
+				provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+
+			// Constant-time comparison so the key can't be guessed byte by byte
+			if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+				writeError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Valid admin key required")
+				return
+			}
+
+			audit.SetActor(r.Context(), "admin")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TenantMiddleware authenticates the request's tenant from its API key, given as
+// "Authorization: Bearer <key>", in the X-API-Key header or, for browser WebSockets
+// that can't set headers, as the api_key query parameter. With no tenants configured
+// every request belongs to the default tenant and needs no key.
+func TenantMiddleware(tenants *tenant.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !tenants.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			t, role, err := tenants.AuthenticateWithRole(requestAPIKey(r))
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Valid API key required")
+				return
+			}
+
+			audit.SetTenant(r.Context(), t.ID)
+			ctx := tenant.WithRole(tenant.WithTenant(r.Context(), t), role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestAPIKey returns the API key a request carries, or "" without one
+func requestAPIKey(r *http.Request) string {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if key == "" {
+		key = r.URL.Query().Get("api_key")
+	}
+	return key
+}
+
+// routeRoles declares the least role a tenant route needs, by method and a path.Match
+// pattern of the full path. Unlisted GETs need RoleViewer and every other unlisted call
+// RoleOperator, so a new route is closed to viewers unless it is declared here.
+var routeRoles = []struct {
+	method string
+	path   string
+	role   tenant.Role
+}{
+	// Reads that take a body
+	{http.MethodPost, "/sessions/*/screenshot", tenant.RoleViewer},
+	{http.MethodPost, "/sessions/*/analyze", tenant.RoleViewer},
+	{http.MethodPost, "/sessions/*/accessibility-tree", tenant.RoleViewer},
+	{http.MethodPost, "/sessions/*/pages/*/pdf", tenant.RoleViewer},
+	{http.MethodPost, "/sessions/*/pages/*/canvas", tenant.RoleViewer},
+
+	// Takeover hands the page's input to the caller over a GET WebSocket
+	{http.MethodGet, "/sessions/*/pages/*/takeover", tenant.RoleOperator},
+	// Only those who may log in with the credentials need to see which exist
+	{http.MethodGet, "/credentials", tenant.RoleOperator},
+
+	// Destroying sessions or giving them away
+	{http.MethodDelete, "/sessions", tenant.RoleAdmin},
+	{http.MethodDelete, "/sessions/*", tenant.RoleAdmin},
+	{http.MethodPost, "/sessions/destroy-batch", tenant.RoleAdmin},
+	{http.MethodPost, "/sessions/*/share", tenant.RoleAdmin},
+	{http.MethodDelete, "/sessions/*/share/*", tenant.RoleAdmin},
+
+	// Tenant-wide configuration
+	{http.MethodPost, "/credentials", tenant.RoleAdmin},
+	{http.MethodDelete, "/credentials/*", tenant.RoleAdmin},
+	{http.MethodPost, "/templates", tenant.RoleAdmin},
+	{http.MethodDelete, "/templates/*", tenant.RoleAdmin},
+	{http.MethodPost, "/pipelines", tenant.RoleAdmin},
+	{http.MethodDelete, "/pipelines/*", tenant.RoleAdmin},
+	{http.MethodPut, "/prompts/*", tenant.RoleAdmin},
+	{http.MethodDelete, "/prompts/*", tenant.RoleAdmin},
+	{http.MethodDelete, "/profiles/*", tenant.RoleAdmin},
+}
+
+// requiredRole returns the least role that may make the request
+func requiredRole(r *http.Request) tenant.Role {
+	routePath := r.URL.Path
+	if len(routePath) > 1 {
+		routePath = strings.TrimSuffix(routePath, "/")
+	}
+
+	for _, route := range routeRoles {
+		if matched, _ := path.Match(route.path, routePath); matched && route.method == r.Method {
+			return route.role
+		}
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return tenant.RoleViewer
+	}
+	return tenant.RoleOperator
+}
+
+// RoleMiddleware refuses calls the role of the request's API key doesn't cover. It runs
+// after TenantMiddleware, which puts the role in the context.
+func RoleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := tenant.RoleFromContext(r.Context())
+		if required := requiredRole(r); !role.Allows(required) {
+			writeError(w, http.StatusForbidden, ErrCodeForbidden,
+				fmt.Sprintf("%s keys may not call %s %s, which needs the %s role", role, r.Method, r.URL.Path, required))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sessionRoute is a route relative to /sessions/{id} or /observe/{observerId}
+type sessionRoute struct {
+	method string
+	path   string // path.Match pattern
+}
+
+// sharedReadRoutes are the routes, relative to /sessions/{id}, that a tenant holding a
+// read-only share may use. They mirror the /observe routes, and each is open to viewer
+// keys of the owning tenant too: a POST among them needs a RoleViewer entry in routeRoles.
+var sharedReadRoutes = []sessionRoute{
+	{http.MethodGet, "/"},
+	{http.MethodPost, "/screenshot"},
+	{http.MethodPost, "/analyze"},
+	{http.MethodPost, "/accessibility-tree"},
+	{http.MethodGet, "/events"},
+	{http.MethodGet, "/events/ws"},
+	{http.MethodGet, "/pages"},
+	{http.MethodGet, "/pages/*/content"},
+	{http.MethodGet, "/pages/*/forms"},
+	{http.MethodGet, "/pages/*/screencast"},
+	{http.MethodGet, "/pages/*/resources"},
+	{http.MethodGet, "/pages/*/element/box"},
+	{http.MethodGet, "/pages/*/element/fingerprint"},
+	{http.MethodPost, "/pages/*/pdf"},
+	{http.MethodPost, "/pages/*/canvas"},
+}
+
+// firefoxRoutes are the routes a Firefox session offers: those backed by session.Driver
+// or that don't touch the browser
+var firefoxRoutes = []sessionRoute{
+	{http.MethodGet, "/"},
+	{http.MethodDelete, "/"},
+	{http.MethodPut, "/close"},
+	{http.MethodPut, "/rename"},
+	{http.MethodPost, "/navigate"},
+	{http.MethodPost, "/execute"},
+	{http.MethodPost, "/screenshot"},
+	{http.MethodGet, "/events"},
+	{http.MethodGet, "/events/ws"},
+	{http.MethodPost, "/share"},
+	{http.MethodGet, "/share"},
+	{http.MethodDelete, "/share/*"},
+	{http.MethodGet, "/pages/*/content"},
+	{http.MethodPost, "/pages/*/visual-diff"},
+	{http.MethodPost, "/pages/*/content-hash"},
+	{http.MethodDelete, "/pages/*"},
+}
+
+// dormantRoutes are the routes that don't wake a hibernated session: those that only
+// read what the manager keeps, or that end the session anyway
+var dormantRoutes = []sessionRoute{
+	{http.MethodGet, "/"},
+	{http.MethodDelete, "/"},
+	{http.MethodPut, "/close"},
+	{http.MethodPut, "/rename"},
+	{http.MethodPost, "/hibernate"},
+	{http.MethodGet, "/checkpoints"},
+	{http.MethodDelete, "/checkpoints/*"},
+	{http.MethodPost, "/checkpoints/*/branch"},
+	{http.MethodGet, "/events"},
+	{http.MethodGet, "/events/ws"},
+	{http.MethodPost, "/share"},
+	{http.MethodGet, "/share"},
+	{http.MethodDelete, "/share/*"},
+	{http.MethodGet, "/files"},
+	{http.MethodGet, "/files/*"},
+	{http.MethodDelete, "/files/*"},
+	{http.MethodGet, "/logs"},
+}
+
+// matchSessionRoute reports whether a request under a session is one of routes
+func matchSessionRoute(r *http.Request, routes []sessionRoute) bool {
+	routePath := "/"
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		routePath = rctx.RoutePath
+	}
+	if len(routePath) > 1 {
+		routePath = strings.TrimSuffix(routePath, "/")
+	}
+
+	for _, route := range routes {
+		if matched, _ := path.Match(route.path, routePath); matched && route.method == r.Method {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyKey marks the context of a request made through an observer or a read-only share
+type readOnlyKey struct{}
+
+// withReadOnly marks r as made with read-only access. The read routes it may use serve
+// it as usual, except for options that would change the page.
+func withReadOnly(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), readOnlyKey{}, true))
+}
+
+// isReadOnly reports whether r was made through an observer or a read-only share
+func isReadOnly(r *http.Request) bool {
+	readOnly, _ := r.Context().Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// isSharedReadRoute reports whether a request under /sessions/{id} only reads the session
+func isSharedReadRoute(r *http.Request) bool {
+	return matchSessionRoute(r, sharedReadRoutes)
+}
+
+// SessionEngineMiddleware refuses, with 501, the routes a session's browser engine
+// can't serve. It runs after the middleware that puts the session ID in the URL params.
+func SessionEngineMiddleware(manager *session.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sess, err := manager.GetSession(chi.URLParam(r, "id"))
+			if err == nil && sess.Engine() == session.EngineFirefox && !matchSessionRoute(r, firefoxRoutes) {
+				writeError(w, http.StatusNotImplemented, ErrCodeEngineUnsupported,
+					fmt.Sprintf("%s %s is %s (%s)", r.Method, r.URL.Path, session.ErrEngineUnsupported, session.EngineFirefox))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionWakeMiddleware wakes a hibernated session before any route that needs its
+// browser. The caller waits while its context is rebuilt and its pages reload, and
+// should expect new page IDs, which the session_woken event maps from the old ones.
+func SessionWakeMiddleware(manager *session.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !matchSessionRoute(r, dormantRoutes) {
+				sessionID := chi.URLParam(r, "id")
+				if _, err := manager.WakeSession(r.Context(), sessionID); err != nil {
+					if errors.Is(err, session.ErrSessionNotFound) {
+						writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "Session not found")
+					} else if errors.Is(err, pool.ErrProfileInUse) {
+						writeError(w, http.StatusConflict, ErrCodeProfileInUse, err.Error())
+					} else {
+						writeError(w, http.StatusServiceUnavailable, ErrCodeSessionWakeFailed, err.Error())
+					}
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SessionLogMiddleware tags the request with the session in its URL, so the records
+// logged while serving it are kept in the session's log
+func SessionLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := sessionlog.Tag(r.Context(), chi.URLParam(r, "id"), "")
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// PageLogMiddleware adds the page in the URL to the tags of SessionLogMiddleware. The
+// page ID is only routed once the request reaches the page routes.
+func PageLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := sessionlog.Tag(r.Context(), "", chi.URLParam(r, "pageId"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SessionTenantMiddleware hides sessions of other tenants: a session in the URL that
+// belongs to someone else is reported as not found, exactly like a missing one. A tenant
+// the session was shared with may use the read-only routes, and every such call is audited.
+func SessionTenantMiddleware(manager *session.Manager, auditLog *audit.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sessionID := chi.URLParam(r, "id")
+			callerID := tenant.IDFromContext(r.Context())
+
+			// Unknown sessions pass through so handlers report them as usual
+			owner, err := manager.SessionTenant(sessionID)
+			if err != nil || owner == callerID {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sess, err := manager.GetSession(sessionID)
+			if err != nil || !sess.IsSharedWith(callerID) {
+				writeError(w, http.StatusNotFound, ErrCodeSessionNotFound, "session not found: "+sessionID)
+				return
+			}
+			if !isSharedReadRoute(r) {
+				writeError(w, http.StatusForbidden, ErrCodeSharedReadOnly, "session is shared read-only")
+				return
+			}
+			r = withReadOnly(r)
+
+			// Mutating calls are already being audited; reads through a share are too
+			if audit.SetAccess(r.Context(), audit.AccessShared) || auditLog == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			auditCall(auditLog, manager, w, r, next, &audit.Record{
+				Time:      time.Now(),
+				RequestID: middleware.GetReqID(r.Context()),
+				Tenant:    callerID,
+				Remote:    r.RemoteAddr,
+				Access:    audit.AccessShared,
+			})
+		})
+	}
+}
+
+// AuditMiddleware records every mutating call (POST, PUT, PATCH, DELETE) in the audit log.
+// The actor is the agent owning the session in the URL unless a handler or an earlier
+// middleware names it. Other reads are not audited, so their streams are never wrapped.
+func AuditMiddleware(auditLog *audit.Logger, manager *session.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if auditLog == nil || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			auditCall(auditLog, manager, w, r, next, &audit.Record{
+				Time:      time.Now(),
+				RequestID: middleware.GetReqID(r.Context()),
+				Remote:    r.RemoteAddr,
+			})
+		})
+	}
+}
+
+// auditCall serves a request and logs record once it is done, filling in the route,
+// session, actor, status and duration
+func auditCall(auditLog *audit.Logger, manager *session.Manager, w http.ResponseWriter, r *http.Request, next http.Handler, record *audit.Record) {
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	next.ServeHTTP(recorder, r.WithContext(audit.WithPending(r.Context(), record)))
+
+	// Routing is done by now, so the pattern and URL params are known
+	action := r.URL.Path
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			action = pattern
+		}
+		if record.SessionID == "" {
+			record.SessionID = rctx.URLParam("id")
+		}
+	}
+	record.Action = r.Method + " " + action
+
+	// A shared session's agent belongs to the owner, not to whoever is reading it
+	if record.Actor == "" && record.SessionID != "" && record.Access != audit.AccessShared {
+		if sess, err := manager.GetSession(record.SessionID); err == nil {
+			record.Actor = sess.AgentID
+		}
+	}
+	if record.Actor == "" {
+		record.Actor = "anonymous"
+	}
+
+	record.Status = recorder.status
+	record.Outcome = audit.OutcomeSuccess
+	if recorder.status >= http.StatusBadRequest {
+		record.Outcome = audit.OutcomeFailure
+	}
+	record.DurationMS = time.Since(record.Time).Milliseconds()
+
+	auditLog.Log(*record)
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Hijack lets audited WebSocket upgrades take over the connection
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
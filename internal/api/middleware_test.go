@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// routes returns every "METHOD /path" the server registers
+func routes(t *testing.T) map[string]bool {
+	t.Helper()
+	manager := session.NewManager(nil)
+	t.Cleanup(func() { manager.Close() })
+	s := NewServer("0", manager, nil, nil, nil, nil, nil, nil, nil, "", nil, nil, nil, nil)
+
+	registered := map[string]bool{}
+	chi.Walk(s.router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		registered[method+" "+route] = true
+		return nil
+	})
+	return registered
+}
+
+// concrete fills in a route pattern's {params} and path.Match wildcards
+func concrete(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if part == "*" || strings.HasPrefix(part, "{") {
+			parts[i] = "x1"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func TestRequiredRole(t *testing.T) {
+	cases := []struct {
+		method string
+		path   string
+		want   tenant.Role
+	}{
+		// Reads
+		{http.MethodGet, "/sessions", tenant.RoleViewer},
+		{http.MethodGet, "/sessions/sess_1", tenant.RoleViewer},
+		{http.MethodGet, "/sessions/sess_1/pages/p1/content", tenant.RoleViewer},
+		{http.MethodHead, "/sessions/sess_1/files/report.csv", tenant.RoleViewer},
+		{http.MethodPost, "/sessions/sess_1/screenshot", tenant.RoleViewer},
+		{http.MethodPost, "/sessions/sess_1/analyze", tenant.RoleViewer},
+		{http.MethodPost, "/sessions/sess_1/accessibility-tree", tenant.RoleViewer},
+		{http.MethodPost, "/sessions/sess_1/pages/p1/pdf", tenant.RoleViewer},
+		{http.MethodPost, "/sessions/sess_1/pages/p1/canvas", tenant.RoleViewer},
+		{http.MethodGet, "/usage", tenant.RoleViewer},
+		{http.MethodGet, "/prompts/vision", tenant.RoleViewer},
+
+		// Driving a session
+		{http.MethodPost, "/sessions", tenant.RoleOperator},
+		{http.MethodPost, "/sessions/sess_1/navigate", tenant.RoleOperator},
+		{http.MethodPost, "/sessions/sess_1/execute", tenant.RoleOperator},
+		{http.MethodPut, "/sessions/sess_1/close", tenant.RoleOperator},
+		{http.MethodDelete, "/sessions/sess_1/pages/p1", tenant.RoleOperator},
+		{http.MethodGet, "/sessions/sess_1/pages/p1/takeover", tenant.RoleOperator},
+		{http.MethodGet, "/credentials", tenant.RoleOperator},
+		{http.MethodPost, "/search", tenant.RoleOperator},
+
+		// Destroying, sharing and tenant-wide configuration
+		{http.MethodDelete, "/sessions", tenant.RoleAdmin},
+		{http.MethodDelete, "/sessions/sess_1", tenant.RoleAdmin},
+		{http.MethodDelete, "/sessions/sess_1/", tenant.RoleAdmin},
+		{http.MethodPost, "/sessions/destroy-batch", tenant.RoleAdmin},
+		{http.MethodPost, "/sessions/sess_1/share", tenant.RoleAdmin},
+		{http.MethodDelete, "/sessions/sess_1/share/globex", tenant.RoleAdmin},
+		{http.MethodPost, "/credentials", tenant.RoleAdmin},
+		{http.MethodDelete, "/credentials/shop", tenant.RoleAdmin},
+		{http.MethodPost, "/templates", tenant.RoleAdmin},
+		{http.MethodDelete, "/templates/mobile", tenant.RoleAdmin},
+		{http.MethodPost, "/pipelines", tenant.RoleAdmin},
+		{http.MethodDelete, "/pipelines/prices", tenant.RoleAdmin},
+		{http.MethodPut, "/prompts/vision", tenant.RoleAdmin},
+		{http.MethodDelete, "/prompts/vision", tenant.RoleAdmin},
+		{http.MethodDelete, "/profiles/shopper", tenant.RoleAdmin},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		if got := requiredRole(r); got != c.want {
+			t.Errorf("%s %s: expected %s, got %s", c.method, c.path, c.want, got)
+		}
+	}
+}
+
+// TestRoleMiddleware tests refusing calls a key's role doesn't cover
+func TestRoleMiddleware(t *testing.T) {
+	handler := RoleMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	cases := []struct {
+		role   tenant.Role
+		method string
+		path   string
+		want   int
+	}{
+		{tenant.RoleViewer, http.MethodPost, "/sessions/sess_1/screenshot", http.StatusNoContent},
+		{tenant.RoleViewer, http.MethodPost, "/sessions/sess_1/navigate", http.StatusForbidden},
+		{tenant.RoleOperator, http.MethodPost, "/sessions/sess_1/navigate", http.StatusNoContent},
+		{tenant.RoleOperator, http.MethodDelete, "/sessions/sess_1", http.StatusForbidden},
+		{tenant.RoleAdmin, http.MethodDelete, "/sessions/sess_1", http.StatusNoContent},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		r = r.WithContext(tenant.WithRole(r.Context(), c.role))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != c.want {
+			t.Errorf("%s key on %s %s: expected %d, got %d", c.role, c.method, c.path, c.want, w.Code)
+		}
+	}
+
+	// Without tenants there is no key, and no role to refuse
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/sessions/sess_1", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected calls without a role let through, got %d", w.Code)
+	}
+}
+
+// TestSharedReadRoutes tests that the read-only share routes exist, that viewers of the
+// owning tenant may call each one, and which calls under a session count as reads
+func TestSharedReadRoutes(t *testing.T) {
+	registered := routes(t)
+	for _, route := range sharedReadRoutes {
+		full := path.Join("/sessions/{id}", route.path)
+		found := false
+		for existing := range registered {
+			method, pattern, _ := strings.Cut(existing, " ")
+			if matched, _ := path.Match(full, pattern); matched && method == route.method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("shared read route %s %s is not registered", route.method, full)
+		}
+
+		r := httptest.NewRequest(route.method, concrete("/sessions/sess_1"+route.path), nil)
+		if got := requiredRole(r); got != tenant.RoleViewer {
+			t.Errorf("shared read route %s %s needs %s of its owner's keys, expected viewer", route.method, route.path, got)
+		}
+	}
+
+	// Ask a router what it makes of calls under a session
+	router := chi.NewRouter()
+	router.Route("/sessions/{id}", func(r chi.Router) {
+		r.HandleFunc("/*", func(w http.ResponseWriter, r *http.Request) {
+			if isSharedReadRoute(r) {
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+	})
+	cases := []struct {
+		method string
+		path   string
+		read   bool
+	}{
+		{http.MethodPost, "/sessions/sess_1/screenshot", true},
+		{http.MethodGet, "/sessions/sess_1/pages/p1/content", true},
+		{http.MethodPost, "/sessions/sess_1/pages/p1/canvas", true},
+		{http.MethodGet, "/sessions/sess_1/pages/p1/element/box", true},
+		{http.MethodPost, "/sessions/sess_1/navigate", false},
+		{http.MethodPost, "/sessions/sess_1/execute", false},
+		{http.MethodDelete, "/sessions/sess_1/pages/p1", false},
+		{http.MethodPost, "/sessions/sess_1/pages/p1/click", false},
+		{http.MethodGet, "/sessions/sess_1/pages/p1/takeover", false},
+		{http.MethodGet, "/sessions/sess_1/files/secret.txt", false},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if read := w.Code == http.StatusNoContent; read != c.read {
+			t.Errorf("%s %s: expected read %v, got %v", c.method, c.path, c.read, read)
+		}
+	}
+}
+
+// TestObserverRoutes tests that observers reach nothing a read-only share couldn't
+func TestObserverRoutes(t *testing.T) {
+	observed := 0
+	for route := range routes(t) {
+		method, pattern, _ := strings.Cut(route, " ")
+		rest, ok := strings.CutPrefix(pattern, "/observe/{observerId}")
+		if !ok {
+			continue
+		}
+		observed++
+		if rest == "" {
+			rest = "/"
+		}
+
+		r := httptest.NewRequest(method, concrete(pattern), nil)
+		rctx := chi.NewRouteContext()
+		rctx.RoutePath = concrete(rest)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		if !isSharedReadRoute(r) {
+			t.Errorf("observer route %s %s is not a shared read route", method, pattern)
+		}
+	}
+	if observed == 0 {
+		t.Fatal("expected observer routes to be registered")
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/ratelimit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+)
+
+// rateLimitExempt are paths never limited, so health checks and scrapes keep working
+// under load
+var rateLimitExempt = map[string]bool{
+	"/status":  true,
+	"/metrics": true,
+}
+
+// RateLimitMiddleware refuses requests over the limiter's rates or in-flight limits with
+// 429 and reports the nearest bucket in RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers. limiter is read per request so limits can change live; nil
+// or a policy without limits turns limiting off. It runs before TenantMiddleware, so it
+// checks keys against tenants itself.
+func RateLimitMiddleware(limiter func() *ratelimit.Limiter, tenants *tenant.Registry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := limiter()
+			if l == nil || !l.Policy().Enabled() || r.Method == http.MethodOptions || rateLimitExempt[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Streams would hold a slot while open, and a tool call runs inside the
+			// invoke request holding one already
+			holds := !isStreamRequest(r) && !isToolCall(r.Context())
+			admission := l.Admit(r.Context(), rateLimitKey(r, tenants), holds)
+			defer admission.Done()
+
+			if admission.Limit > 0 {
+				w.Header().Set("RateLimit-Limit", strconv.Itoa(admission.Limit))
+				w.Header().Set("RateLimit-Remaining", strconv.Itoa(max(admission.Remaining, 0)))
+				w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(admission.Reset)))
+			}
+
+			switch {
+			case errors.Is(admission.Err, ratelimit.ErrRateLimited):
+				w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(admission.RetryAfter), 1)))
+				writeError(w, http.StatusTooManyRequests, ErrCodeRateLimited, "Rate limit exceeded, retry later")
+				return
+			case errors.Is(admission.Err, ratelimit.ErrTooManyInFlight):
+				w.Header().Set("Retry-After", "1")
+				writeError(w, http.StatusTooManyRequests, ErrCodeRateLimited, "Too many requests in flight, retry once one finishes")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey identifies the caller: a hash of the API key it authenticates with, or its
+// address. A key no tenant has counts as none, so making up keys gets no fresh buckets.
+func rateLimitKey(r *http.Request, tenants *tenant.Registry) string {
+	if key := requestAPIKey(r); key != "" && tenants.Enabled() {
+		if _, _, err := tenants.AuthenticateWithRole(key); err == nil {
+			sum := sha256.Sum256([]byte(key))
+			return "key:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// isStreamRequest reports whether r opens a WebSocket or an event stream
+func isStreamRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// ceilSeconds rounds d up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/ratelimit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+)
+
+// TestRateLimitKeys tests that keys of a tenant get buckets of their own, and that
+// made-up keys share the bucket of the address they come from
+func TestRateLimitKeys(t *testing.T) {
+	tenants := tenant.NewRegistry()
+	if err := tenants.Replace([]*tenant.Tenant{{ID: "acme", APIKeys: []string{"acme-key"}}}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	// Two requests per caller, refilled far slower than the test runs
+	limiter := ratelimit.New(ratelimit.Policy{PerKey: ratelimit.Limit{Rate: 0.001, Burst: 2}}, ratelimit.NewMemory())
+	handler := RateLimitMiddleware(func() *ratelimit.Limiter { return limiter }, tenants)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	call := func(remote string, key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+		r.RemoteAddr = remote
+		if key != "" {
+			r.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Random keys from one address use up that address's bucket together
+	for i := range 2 {
+		if code := call("203.0.113.7:4000", fmt.Sprintf("made-up-%d", i)); code != http.StatusNoContent {
+			t.Fatalf("expected request %d let through, got %d", i, code)
+		}
+	}
+	if code := call("203.0.113.7:4001", "made-up-2"); code != http.StatusTooManyRequests {
+		t.Errorf("expected another made-up key refused, got %d", code)
+	}
+	if code := call("203.0.113.7:4002", ""); code != http.StatusTooManyRequests {
+		t.Errorf("expected the address refused without a key too, got %d", code)
+	}
+
+	// A tenant's key has its own bucket, wherever it comes from
+	if code := call("203.0.113.7:4003", "acme-key"); code != http.StatusNoContent {
+		t.Errorf("expected the tenant's key let through, got %d", code)
+	}
+	if code := call("198.51.100.2:4000", "made-up-3"); code != http.StatusNoContent {
+		t.Errorf("expected another address let through, got %d", code)
+	}
+
+	// Without tenants no key means anything
+	r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	r.RemoteAddr = "192.0.2.1:5000"
+	r.Header.Set("X-API-Key", "acme-key")
+	if key := rateLimitKey(r, tenant.NewRegistry()); key != "ip:192.0.2.1" {
+		t.Errorf("expected the address as the key without tenants, got %q", key)
+	}
+}
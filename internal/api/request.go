@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxRequestBody is the largest request body accepted unless configured otherwise
+const DefaultMaxRequestBody = 1 << 20
+
+// BodyLimitMiddleware caps request bodies at maxBytes, read per request so the limit can
+// be changed live. Reading past it fails, and decodeJSON turns that into a 413.
+func BodyLimitMiddleware(maxBytes func() int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := maxBytes()
+			if r.ContentLength > limit {
+				writeError(w, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge, fmt.Sprintf("request body is %d bytes (max %d)", r.ContentLength, limit))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeJSON reads a required JSON object from the request body into dst and checks
+// its validate tags. Unknown fields, mistyped values and trailing data are refused; the
+// error written to w names the problem. It reports whether dst is ready to use.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeBody(w, r, dst, false)
+}
+
+// decodeOptionalJSON is decodeJSON for requests whose body may be left out, in which
+// case dst keeps its zero value
+func decodeOptionalJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeBody(w, r, dst, true)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, dst interface{}, optional bool) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(dst)
+	if errors.Is(err, io.EOF) && optional {
+		return validateRequest(w, dst)
+	}
+	if err == nil {
+		// Whitespace after the object is fine, anything else is not
+		if _, extra := decoder.Token(); !errors.Is(extra, io.EOF) {
+			err = errTrailingData
+		}
+	}
+	if err == nil {
+		return validateRequest(w, dst)
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return false
+	}
+	writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, describeDecodeError(err))
+	return false
+}
+
+// errTrailingData is returned for a body holding more than one JSON value
+var errTrailingData = errors.New("request body must be a single JSON object")
+
+// describeDecodeError turns a JSON decoding error into a message naming the field or
+// position at fault
+func describeDecodeError(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return "request body is required"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "invalid JSON body: unexpected end of input"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("invalid JSON body at byte %d: %s", syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("request body must be a JSON object, got %s", typeErr.Value)
+		}
+		return fmt.Sprintf("field %q must be %s, got %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind().String()), typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for this one
+		return strings.TrimPrefix(err.Error(), "json: ") + " in request body"
+	case errors.Is(err, errTrailingData):
+		return err.Error()
+	default:
+		return "invalid JSON body: " + err.Error()
+	}
+}
+
+// jsonTypeName describes a Go kind the way a JSON client thinks of it
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "a boolean"
+	case "slice", "array":
+		return "an array"
+	case "map", "struct":
+		return "an object"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "an integer"
+	case "float32", "float64":
+		return "a number"
+	default:
+		return "a " + kind
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// decodeTarget is a request body without validate tags, so only decoding is tested
+type decodeTarget struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Tags  []string
+}
+
+// TestDecodeBody tests what request bodies are refused and the messages naming why
+func TestDecodeBody(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		optional bool
+		status   int
+		message  string
+	}{
+		{"object", `{"name": "a", "count": 2}`, false, http.StatusOK, ""},
+		{"trailing whitespace", "{\"name\": \"a\"}\n\t ", false, http.StatusOK, ""},
+		{"empty optional", "", true, http.StatusOK, ""},
+		{"empty required", "", false, http.StatusBadRequest, "request body is required"},
+		{"unknown field", `{"name": "a", "colour": "red"}`, false, http.StatusBadRequest, `unknown field "colour" in request body`},
+		{"trailing object", `{"name": "a"} {"name": "b"}`, false, http.StatusBadRequest, "request body must be a single JSON object"},
+		{"trailing garbage", `{"name": "a"}x`, true, http.StatusBadRequest, "request body must be a single JSON object"},
+		{"type mismatch", `{"count": "3"}`, false, http.StatusBadRequest, `field "count" must be an integer, got string`},
+		{"nested type mismatch", `{"Tags": [1]}`, false, http.StatusBadRequest, `field "Tags.0" must be a string, got number`},
+		{"not an object", `[1, 2]`, false, http.StatusBadRequest, "request body must be a JSON object, got array"},
+		{"truncated", `{"name": "a"`, false, http.StatusBadRequest, "invalid JSON body: unexpected end of input"},
+		{"syntax", `{"name" "a"}`, false, http.StatusBadRequest, "invalid JSON body at byte 9: invalid character '\"' after object key"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/sessions", strings.NewReader(c.body))
+			var dst decodeTarget
+			ok := decodeBody(w, r, &dst, c.optional)
+			if ok != (c.status == http.StatusOK) {
+				t.Fatalf("expected ok %v, got %v (%s)", c.status == http.StatusOK, ok, w.Body.String())
+			}
+			if ok {
+				return
+			}
+
+			var response ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode error: %v", err)
+			}
+			if w.Code != c.status || response.Error.Code != ErrCodeInvalidRequest || response.Error.Message != c.message {
+				t.Errorf("expected %d %q, got %d %+v", c.status, c.message, w.Code, response.Error)
+			}
+		})
+	}
+}
+
+// TestBodyLimitMiddleware tests that bodies over the limit get a 413, whether their size
+// is announced or only found out while reading
+func TestBodyLimitMiddleware(t *testing.T) {
+	handler := BodyLimitMiddleware(func() int64 { return 64 })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var dst decodeTarget
+		if decodeJSON(w, r, &dst) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	body := `{"name": "` + strings.Repeat("x", 102) + `"}`
+	cases := []struct {
+		name    string
+		body    io.Reader
+		length  int64
+		status  int
+		message string
+	}{
+		{"under the limit", strings.NewReader(`{"name": "checkout"}`), -1, http.StatusNoContent, ""},
+		{"announced", strings.NewReader(body), int64(len(body)), http.StatusRequestEntityTooLarge, "request body is 114 bytes (max 64)"},
+		{"chunked", strings.NewReader(body), -1, http.StatusRequestEntityTooLarge, "request body exceeds 64 bytes"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/sessions/sess_1/execute", c.body)
+		r.ContentLength = c.length
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != c.status {
+			t.Errorf("%s: expected %d, got %d %s", c.name, c.status, w.Code, w.Body.String())
+			continue
+		}
+		if c.message == "" {
+			continue
+		}
+		var response ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Error.Code != ErrCodeRequestTooLarge || response.Error.Message != c.message {
+			t.Errorf("%s: unexpected error %+v", c.name, response.Error)
+		}
+	}
+}
@@ -1,9 +1,17 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
 )
 
 // writeJSON writes a JSON success response
@@ -31,11 +39,11 @@ func writeError(w http.ResponseWriter, statusCode int, code string, message stri
 	// Set HTTP status code (400, 404, 500, etc.)
 	w.WriteHeader(statusCode)
 	
-	// Build error response
+	// Build error response (messages often embed URLs or script errors, so mask secrets)
 	response := ErrorResponse{
 		Error: ErrorDetail{
 			Code:    code,
-			Message: message,
+			Message: redact.String(message),
 		},
 	}
 	
@@ -44,4 +52,83 @@ func writeError(w http.ResponseWriter, statusCode int, code string, message stri
 		// If we can't even write the error response, log it
 		slog.Error("failed to encode error response", "error", err)
 	}
-}
\ No newline at end of file
+}
+
+// writeScriptError reports an exception thrown by the page's script. The script ran and
+// failed, which is the caller's to fix rather than the server's, so it is a 422 and
+// carries the exception with its stack.
+func writeScriptError(w http.ResponseWriter, err *session.ScriptError) {
+	masked := *err
+	masked.Message = redact.String(err.Message)
+	masked.URL = redact.String(err.URL)
+	masked.Stack = make([]session.StackFrame, len(err.Stack))
+	for i, frame := range err.Stack {
+		frame.URL = redact.String(frame.URL)
+		masked.Stack[i] = frame
+	}
+
+	writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+		Error: ErrorDetail{
+			Code:    ErrCodeScriptError,
+			Message: redact.String(err.Error()),
+			Script:  &masked,
+		},
+	})
+}
+
+// prefersMediaType reports whether the request's Accept header ranks mediaType above JSON.
+// Without an Accept header, or on a tie, JSON wins so existing clients see no change.
+func prefersMediaType(r *http.Request, mediaType string) bool {
+	wildcard := strings.SplitN(mediaType, "/", 2)[0] + "/*"
+	wanted, jsonQuality := 0.0, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		accepted, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		switch accepted {
+		case mediaType, wildcard:
+			wanted = max(wanted, quality)
+		case "application/json", "*/*":
+			jsonQuality = max(jsonQuality, quality)
+		}
+	}
+	return wanted > jsonQuality
+}
+
+// contentETag returns a strong ETag for a body derived from content
+func contentETag[T string | []byte](content T) string {
+	sum := sha256.Sum256([]byte(content))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// writeNotModified tags a GET response with etag and, when the request's If-None-Match
+// names it, answers 304 without a body. It reports whether the response was written.
+func writeNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !etagMatches(r, etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches reports whether the request's If-None-Match names etag. The comparison
+// is weak, as RFC 9110 asks for If-None-Match, so a W/ prefix added by a proxy still matches.
+func etagMatches(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -5,10 +5,18 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/dhruvsoni1802/browser-query-ai/internal/audit"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/captcha"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/ratelimit"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vault"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -16,68 +24,342 @@ import (
 
 // Server represents the HTTP API server
 type Server struct {
-	router  *chi.Mux
-	server  *http.Server
-	manager *session.Manager
+	router      *chi.Mux
+	server      *http.Server
+	manager     *session.Manager
+	adminKey    atomic.Pointer[string]
+	compressMin atomic.Int64
+	maxBody     atomic.Int64
+	rateLimiter atomic.Pointer[ratelimit.Limiter]
+	admission   *pool.AdmissionQueue
 }
 
 // NewServer creates a new HTTP server
-func NewServer(port string, manager *session.Manager, loadBalancer *pool.LoadBalancer) *Server {
+func NewServer(port string, manager *session.Manager, loadBalancer *pool.LoadBalancer, firefox *pool.LoadBalancer, credentialVault *vault.Vault, captchaSolvers *captcha.Registry, recycler *pool.Recycler, profiles *pool.ProfilePool, extensions *browser.ExtensionCatalog, adminKey string, auditLog *audit.Logger, tenants *tenant.Registry, visionModel vision.Model, researcher *session.Researcher) *Server {
 	router := chi.NewRouter()
+	s := &Server{router: router, manager: manager}
+	s.SetAdminKey(adminKey)
+	s.SetCompressMinSize(DefaultCompressMinSize)
+	s.SetMaxRequestBody(DefaultMaxRequestBody)
 
 	// Middleware
 	router.Use(RecoveryMiddleware)
 	router.Use(LoggingMiddleware)
 	router.Use(middleware.RequestID)
+	router.Use(AuditMiddleware(auditLog, manager)) // After RequestID so records carry it
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-None-Match", "X-Admin-Key", "X-API-Key"},
+		ExposedHeaders:   []string{"ETag", "Link", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
+	router.Use(RateLimitMiddleware(s.rateLimiter.Load, tenants)) // After CORS so refusals carry its headers
+	router.Use(CompressionMiddleware(s.getCompressMinSize))
+	router.Use(BodyLimitMiddleware(s.maxBody.Load))
 
 	// Create handlers with load balancer
-	handlers := NewHandlers(manager, loadBalancer)
+	handlers := NewHandlers(manager, loadBalancer, firefox, credentialVault, captchaSolvers, recycler, profiles, extensions, visionModel, researcher, tenants)
+	s.admission = handlers.admission
 
 	// Register routes (same as before)
 	router.Route("/sessions", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
 		r.Post("/", handlers.CreateSession)
 		r.Get("/", handlers.ListSessions)
+		r.Delete("/", handlers.DestroySessionsByFilter)
 		r.Post("/resume", handlers.ResumeSession)
+		r.Post("/destroy-batch", handlers.DestroySessionBatch)
 
 		r.Route("/{id}", func(r chi.Router) {
+			r.Use(SessionTenantMiddleware(manager, auditLog))
+			r.Use(SessionLogMiddleware) // After the tenant check, so nobody else's calls land in the log
+			r.Use(SessionEngineMiddleware(manager))
+			r.Use(SessionWakeMiddleware(manager))
+
 			r.Get("/", handlers.GetSession)
 			r.Delete("/", handlers.DestroySession)
 			r.Put("/close", handlers.CloseSession)
 			r.Post("/navigate", handlers.Navigate)
 			r.Post("/execute", handlers.ExecuteJS)
+			r.Post("/extract", handlers.Extract)
+			r.Post("/run", handlers.Run)
 			r.Post("/screenshot", handlers.CaptureScreenshot)
 			r.Post("/analyze", handlers.AnalyzePage)
 			r.Post("/accessibility-tree", handlers.GetAccessibilityTree)
 			r.Post("/resume", handlers.ResumeSessionByID)
 			r.Put("/rename", handlers.RenameSession)
+			r.Post("/hibernate", handlers.HibernateSession)
+			r.Post("/wake", handlers.WakeSession)
+			r.Post("/login", handlers.Login)
+			r.Get("/events", handlers.StreamEventsSSE)
+			r.Get("/events/ws", handlers.StreamEvents)
+			r.Get("/takeover", handlers.GetTakeover)
+			r.Delete("/takeover", handlers.EndTakeover)
+			r.Get("/pages", handlers.ListPages)
+			r.Post("/share", handlers.ShareSession)
+			r.Get("/share", handlers.ListShares)
+			r.Delete("/share/{tenantId}", handlers.RevokeShare)
+			r.Get("/files", handlers.ListWorkFiles)
+			r.Get("/files/*", handlers.GetWorkFile)
+			r.Delete("/files/*", handlers.DeleteWorkFile)
+
+			r.Get("/logs", handlers.GetSessionLogs)
+			r.Get("/egress", handlers.GetEgressUsage)
+			r.Get("/bandwidth", handlers.GetSessionBandwidth)
+
+			r.Get("/memory", handlers.GetMemory)
+			r.Delete("/memory", handlers.ClearMemory)
+
+			r.Get("/storage", handlers.GetStorage)
+			r.Put("/storage", handlers.WriteStorage)
+			r.Get("/service-workers", handlers.ListServiceWorkers)
+			r.Delete("/service-workers", handlers.UnregisterServiceWorkers)
+			r.Get("/cache-storage", handlers.GetCacheStorage)
+			r.Delete("/cache-storage", handlers.ClearCacheStorage)
+
+			r.Route("/checkpoints", func(r chi.Router) {
+				r.Post("/", handlers.CreateCheckpoint)
+				r.Get("/", handlers.ListCheckpoints)
+				r.Delete("/{checkpointId}", handlers.DeleteCheckpoint)
+				r.Post("/{checkpointId}/branch", handlers.BranchSession)
+			})
+
+			r.Route("/observers", func(r chi.Router) {
+				r.Post("/", handlers.CreateObserver)
+				r.Get("/", handlers.ListObservers)
+				r.Delete("/{observerId}", handlers.RevokeObserver)
+			})
 
 			r.Route("/pages/{pageId}", func(r chi.Router) {
+				r.Use(PageLogMiddleware)
+
 				r.Get("/content", handlers.GetPageContent)
 				r.Delete("/", handlers.ClosePage)
+				r.Get("/forms", handlers.ListForms)
+				r.Post("/forms/{formIndex}/fill", handlers.FillForm)
+				r.Get("/captcha", handlers.DetectCaptcha)
+				r.Post("/captcha/solve", handlers.SolveCaptcha)
+				r.Post("/vision-query", handlers.VisionQuery)
+				r.Post("/click", handlers.Click)
+				r.Get("/element/box", handlers.GetElementBox)
+				r.Get("/element/fingerprint", handlers.GetElementFingerprint)
+				r.Post("/element/heal", handlers.HealSelector)
+				r.Post("/canvas", handlers.CaptureCanvas)
+				r.Post("/watch", handlers.WatchDOM)
+				r.Get("/watch", handlers.ListWatches)
+				r.Delete("/watch/{watchId}", handlers.Unwatch)
+				r.Post("/wait", handlers.Wait)
+				r.Post("/assert", handlers.Assert)
+				r.Post("/visual-diff", handlers.VisualDiff)
+				r.Post("/content-hash", handlers.ContentHash)
+				r.Get("/screencast", handlers.StreamScreencast)
+				r.Get("/takeover", handlers.Takeover)
+				r.Post("/activate", handlers.ActivatePage)
+				r.Post("/duplicate", handlers.DuplicatePage)
+				r.Get("/resources", handlers.ListResources)
+				r.Post("/resources/download", handlers.DownloadResource)
+				r.Get("/network/websockets", handlers.GetWebSocketTraffic)
+				r.Get("/network/streams", handlers.GetStreamTraffic)
+				r.Get("/security", handlers.GetPageSecurity)
+				r.Post("/pdf", handlers.PrintPDF)
+				r.Get("/media", handlers.GetPageMedia)
+				r.Put("/media", handlers.SetPageMedia)
+				r.Post("/handles", handlers.CreateHandle)
+				r.Get("/handles", handlers.ListHandles)
+				r.Delete("/handles/{handleId}", handlers.ReleaseHandle)
+				r.Post("/handles/{handleId}/click", handlers.ClickHandle)
+				r.Get("/handles/{handleId}/text", handlers.GetHandleText)
+				r.Get("/handles/{handleId}/attribute", handlers.GetHandleAttribute)
+				r.Get("/handles/{handleId}/box", handlers.GetHandleBox)
 			})
 		})
 	})
 
+	// Observer routes (read-only view of a session, no mutating handlers are mounted here)
+	router.Route("/observe/{observerId}", func(r chi.Router) {
+		r.Use(ObserverMiddleware(manager))
+		r.Use(SessionEngineMiddleware(manager))
+
+		r.Get("/", handlers.GetSession)
+		r.Post("/screenshot", handlers.CaptureScreenshot)
+		r.Post("/analyze", handlers.AnalyzePage)
+		r.Post("/accessibility-tree", handlers.GetAccessibilityTree)
+		r.Get("/pages/{pageId}/content", handlers.GetPageContent)
+		r.Get("/pages/{pageId}/forms", handlers.ListForms)
+		r.Get("/events", handlers.StreamEventsSSE)
+		r.Get("/events/ws", handlers.StreamEvents)
+		r.Get("/pages/{pageId}/screencast", handlers.StreamScreencast)
+		r.Get("/pages/{pageId}/resources", handlers.ListResources)
+		r.Post("/pages/{pageId}/pdf", handlers.PrintPDF)
+		r.Post("/pages/{pageId}/canvas", handlers.CaptureCanvas)
+	})
+
+	// Credential vault routes (secrets are write-only: listings never include passwords)
+	router.Route("/credentials", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Post("/", handlers.SaveCredential)
+		r.Get("/", handlers.ListCredentials)
+		r.Delete("/{alias}", handlers.DeleteCredential)
+	})
+
+	// Synthetic check routes (each run gets a session of its own, destroyed afterwards)
+	router.Route("/checks", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Post("/", handlers.SaveCheck)
+		r.Get("/", handlers.ListChecks)
+		r.Get("/{name}", handlers.GetCheck)
+		r.Delete("/{name}", handlers.DeleteCheck)
+		r.Post("/{name}/run", handlers.RunCheck)
+		r.Get("/{name}/screenshot", handlers.GetCheckScreenshot)
+	})
+
+	// Crawl seed routes (checks take a seed too, see SaveCheckRequest)
+	router.Route("/seeds", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Post("/expand", handlers.ExpandSeed)
+	})
+
+	// Web search, for agents looking for pages to navigate to, and answers researched from it
+	router.With(TenantMiddleware(tenants), RoleMiddleware).Post("/search", handlers.Search)
+	router.With(TenantMiddleware(tenants), RoleMiddleware).Post("/research", handlers.Research)
+
+	// Visual baseline routes (baselines are referenced by name from visual-diff requests and compare steps)
+	router.Route("/baselines", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Get("/", handlers.ListBaselines)
+		r.Get("/{name}", handlers.GetBaseline)
+		r.Put("/{name}", handlers.PutBaseline)
+		r.Delete("/{name}", handlers.DeleteBaseline)
+	})
+
+	// Session template routes (templates are referenced by name in POST /sessions)
+	router.Route("/templates", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Post("/", handlers.SaveTemplate)
+		r.Get("/", handlers.ListTemplates)
+		r.Get("/{name}", handlers.GetTemplate)
+		r.Delete("/{name}", handlers.DeleteTemplate)
+	})
+
+	// System prompt routes (a tenant's versions override the shared ones under /admin/prompts)
+	router.Route("/prompts", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Get("/", handlers.ListPrompts)
+		r.Get("/{name}", handlers.GetPrompt)
+		r.Put("/{name}", handlers.SavePrompt)
+		r.Delete("/{name}", handlers.ResetPrompt)
+	})
+
+	// Result pipeline routes (pipelines are referenced by name from extract requests and session options)
+	router.Route("/pipelines", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Post("/", handlers.SavePipeline)
+		r.Get("/", handlers.ListPipelines)
+		r.Get("/{name}", handlers.GetPipeline)
+		r.Delete("/{name}", handlers.DeletePipeline)
+		r.Post("/{name}/run", handlers.RunPipeline)
+	})
+
+	// Persistent profile routes (profiles are created by the first session that names one)
+	router.Route("/profiles", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Get("/", handlers.ListProfiles)
+		r.Delete("/{name}", handlers.DeleteProfile)
+	})
+
+	// Extensions browsers may load (read from EXTENSION_DIR at startup)
+	router.Route("/extensions", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
+		r.Get("/", handlers.ListExtensions)
+	})
+
 	// Agent routes
 	router.Route("/agents/{agentId}", func(r chi.Router) {
+		r.Use(TenantMiddleware(tenants))
+		r.Use(RoleMiddleware)
+
 		r.Get("/sessions", handlers.ListAgentSessions)
 	})
 
+	// Tool manifest and OpenAI-style tool calls for agent frameworks. Each call is routed
+	// like a request of its own, so the called route's roles apply.
+	tools := newToolbox(Endpoints, router)
+	router.With(TenantMiddleware(tenants)).Get("/tools", tools.ListTools)
+	router.With(TenantMiddleware(tenants)).Post("/tools/invoke", tools.InvokeTools)
+
+	// The caller's tenant, quotas and current usage
+	router.With(TenantMiddleware(tenants), RoleMiddleware).Get("/tenant", handlers.GetTenant)
+
+	// What the caller's model calls used and cost, per day
+	router.With(TenantMiddleware(tenants), RoleMiddleware).Get("/usage", handlers.GetUsage)
+
+	// Admin routes for operating the browser pool, rejected while no key is configured
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(AdminAuthMiddleware(s.getAdminKey))
+
+		r.Get("/processes", handlers.AdminListProcesses)
+		r.Post("/processes/{port}/drain", handlers.AdminDrainProcess)
+		r.Delete("/processes/{port}/drain", handlers.AdminUndrainProcess)
+		r.Post("/processes/{port}/restart", handlers.AdminRestartProcess)
+		r.Put("/pool", handlers.AdminResizePool)
+		r.Delete("/sessions/{id}", handlers.AdminEvictSession)
+		r.Get("/drain", handlers.AdminDrainStatus)
+		r.Post("/drain", handlers.AdminStartDrain)
+
+		// Shared prompt versions, used by every tenant without its own. Admin calls
+		// carry no tenant, so the prompt handlers save them as shared.
+		r.Get("/prompts", handlers.ListPrompts)
+		r.Get("/prompts/{name}", handlers.GetPrompt)
+		r.Put("/prompts/{name}", handlers.SavePrompt)
+		r.Delete("/prompts/{name}", handlers.ResetPrompt)
+
+		// Runtime state and profiles for tracking down leaks
+		r.Get("/diagnostics", handlers.AdminDiagnostics)
+		mountProfiler(r)
+	})
+	if adminKey == "" {
+		slog.Info("admin API disabled, set ADMIN_API_KEY to enable it")
+	}
+
+	// Service and per-process health, including resource usage
+	router.Get("/status", handlers.GetStatus)
+
 	// Add metrics endpoint
 	router.Get("/metrics", func(w http.ResponseWriter, r *http.Request) {
-		metrics := loadBalancer.GetMetrics()
+		metrics := MetricsResponse{
+			PoolMetrics: loadBalancer.GetMetrics(),
+			WarmPool:    manager.WarmPoolStats(),
+			Connections: manager.ConnectionStats(),
+			Admission:   s.admission.Stats(),
+			Bandwidth:   manager.BandwidthStats(),
+			AnswerCache: manager.AnswerCacheStats(),
+		}
 		writeJSON(w, http.StatusOK, metrics)
 	})
 
-	server := &http.Server{
+	s.server = &http.Server{
 		Addr:         ":" + port,
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
@@ -85,11 +367,48 @@ func NewServer(port string, manager *session.Manager, loadBalancer *pool.LoadBal
 		IdleTimeout:  60 * time.Second,
 	}
 
-	return &Server{
-		router:  router,
-		server:  server,
-		manager: manager,
-	}
+	return s
+}
+
+// SetAdminKey replaces the key required by /admin routes; "" disables them
+func (s *Server) SetAdminKey(key string) {
+	s.adminKey.Store(&key)
+}
+
+// getAdminKey returns the current admin key
+func (s *Server) getAdminKey() string {
+	return *s.adminKey.Load()
+}
+
+// SetAdmissionPolicy bounds how many session creations may wait for a browser with room,
+// and for how long; a zero size fails them at capacity straight away
+func (s *Server) SetAdmissionPolicy(policy pool.AdmissionPolicy) {
+	s.admission.SetPolicy(policy)
+}
+
+// SetCompressMinSize sets the smallest response body that is gzipped; 0 turns compression off
+func (s *Server) SetCompressMinSize(bytes int) {
+	s.compressMin.Store(int64(bytes))
+}
+
+// getCompressMinSize returns the current compression threshold
+func (s *Server) getCompressMinSize() int {
+	return int(s.compressMin.Load())
+}
+
+// SetMaxRequestBody sets the largest request body accepted
+func (s *Server) SetMaxRequestBody(bytes int64) {
+	s.maxBody.Store(bytes)
+}
+
+// SetRateLimiter replaces the limiter applied to every request; nil turns limiting off
+func (s *Server) SetRateLimiter(limiter *ratelimit.Limiter) {
+	s.rateLimiter.Store(limiter)
+}
+
+// RateLimiter returns the limiter applied to every request (nil: none)
+func (s *Server) RateLimiter() *ratelimit.Limiter {
+	return s.rateLimiter.Load()
 }
 
 // Start starts the HTTP server
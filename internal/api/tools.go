@@ -0,0 +1,531 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxToolCalls bounds the calls one POST /tools/invoke runs
+const maxToolCalls = 16
+
+// Tool is one API operation described for agent frameworks: an OpenAI function tool,
+// plus the route it calls and example arguments
+type Tool struct {
+	Type     string       `json:"type"` // Always "function"
+	Function ToolFunction `json:"function"`
+	HTTP     ToolRoute    `json:"http"`
+	Examples []ToolArgs   `json:"examples"`
+}
+
+// ToolFunction names a tool and describes its arguments as JSON Schema
+type ToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolRoute is the HTTP call a tool makes
+type ToolRoute struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// ToolArgs are the arguments of one tool call
+type ToolArgs map[string]interface{}
+
+// toolbox builds the tool manifest from the endpoint table and runs tool calls by
+// sending them through the router, so they get the same auth, roles and validation as
+// the HTTP routes they stand for
+type toolbox struct {
+	tools   []Tool
+	routes  map[string]*toolEndpoint // Tool name → what to call
+	handler http.Handler
+}
+
+// toolEndpoint is what a tool call is turned into
+type toolEndpoint struct {
+	endpoint   Endpoint
+	pathParams map[string]string // Argument → route parameter name
+	query      map[string]bool
+}
+
+// newToolbox describes every endpoint that answers with a single JSON response; WebSocket
+// streams and binary downloads don't fit a tool call
+func newToolbox(endpoints []Endpoint, handler http.Handler) *toolbox {
+	t := &toolbox{routes: make(map[string]*toolEndpoint), handler: handler}
+	for _, endpoint := range endpoints {
+		if endpoint.Stream != nil || endpoint.Binary {
+			continue
+		}
+		name := toolName(endpoint.Name)
+		route := &toolEndpoint{endpoint: endpoint, pathParams: make(map[string]string), query: make(map[string]bool)}
+
+		properties := map[string]interface{}{}
+		required := []string{}
+		for _, match := range routeParam.FindAllStringSubmatch(endpoint.Path, -1) {
+			arg := toolParamName(match[1])
+			route.pathParams[arg] = match[1]
+			properties[arg] = map[string]interface{}{"type": "string", "description": toolParamDescription(arg)}
+			required = append(required, arg)
+		}
+		for _, param := range endpoint.Query {
+			route.query[param] = true
+			properties[param] = map[string]interface{}{"type": "string"}
+		}
+		if endpoint.Request != nil {
+			body := jsonSchema(endpoint.Request, map[reflect.Type]bool{})
+			if bodyProperties, ok := body["properties"].(map[string]interface{}); ok {
+				for key, value := range bodyProperties {
+					if _, taken := properties[key]; !taken {
+						properties[key] = value
+					}
+				}
+			}
+			if bodyRequired, ok := body["required"].([]string); ok {
+				required = append(required, bodyRequired...)
+			}
+		}
+
+		parameters := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			parameters["required"] = required
+		}
+		t.tools = append(t.tools, Tool{
+			Type:     "function",
+			Function: ToolFunction{Name: name, Description: endpoint.Doc, Parameters: parameters},
+			HTTP:     ToolRoute{Method: endpoint.Method, Path: endpoint.Path},
+			Examples: []ToolArgs{toolExample(endpoint.Name, properties, required)},
+		})
+		t.routes[name] = route
+	}
+	return t
+}
+
+// ListTools handles GET /tools
+func (t *toolbox) ListTools(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, ToolsResponse{Tools: t.tools, Count: len(t.tools)})
+}
+
+// InvokeTools handles POST /tools/invoke, running each call in order. A failed call is
+// reported in its result and doesn't stop the ones after it.
+func (t *toolbox) InvokeTools(w http.ResponseWriter, r *http.Request) {
+	var req InvokeToolsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	calls := req.ToolCalls
+	if len(calls) == 0 && req.Function.Name != "" {
+		calls = []ToolCall{req.ToolCall}
+	}
+	if len(calls) == 0 {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "tool_calls or a single call's function is required")
+		return
+	}
+	if len(calls) > maxToolCalls {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, fmt.Sprintf("at most %d tool calls are run at once, got %d", maxToolCalls, len(calls)))
+		return
+	}
+
+	results := make([]ToolResult, 0, len(calls))
+	for _, call := range calls {
+		results = append(results, t.invoke(w, r, call))
+	}
+	writeJSON(w, http.StatusOK, InvokeToolsResponse{Results: results})
+}
+
+// invoke runs one call through the router as the caller
+func (t *toolbox) invoke(w http.ResponseWriter, r *http.Request, call ToolCall) ToolResult {
+	result := ToolResult{ToolCallID: call.ID, Role: "tool", Name: call.Function.Name}
+	fail := func(status int, message string) ToolResult {
+		body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{Code: ErrCodeInvalidRequest, Message: message}})
+		result.Status, result.IsError, result.Content = status, true, string(body)
+		return result
+	}
+
+	route, ok := t.routes[call.Function.Name]
+	if !ok {
+		return fail(http.StatusNotFound, fmt.Sprintf("unknown tool: %s", call.Function.Name))
+	}
+	args, err := parseToolArgs(call.Function.Arguments)
+	if err != nil {
+		return fail(http.StatusBadRequest, err.Error())
+	}
+
+	path := route.endpoint.Path
+	for arg, param := range route.pathParams {
+		value, ok := args[arg]
+		if !ok {
+			return fail(http.StatusBadRequest, fmt.Sprintf("missing argument: %s", arg))
+		}
+		path = strings.Replace(path, "{"+param+"}", url.PathEscape(argString(value)), 1)
+		delete(args, arg)
+	}
+	query := url.Values{}
+	for param := range route.query {
+		if value, ok := args[param]; ok {
+			query.Set(param, argString(value))
+			delete(args, param)
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var body []byte
+	if route.endpoint.Request != nil {
+		body, _ = json.Marshal(args)
+	} else if len(args) > 0 {
+		unknown := make([]string, 0, len(args))
+		for arg := range args {
+			unknown = append(unknown, arg)
+		}
+		sort.Strings(unknown)
+		return fail(http.StatusBadRequest, fmt.Sprintf("unknown arguments: %s", strings.Join(unknown, ", ")))
+	}
+
+	ctx := context.WithValue(r.Context(), toolCallContext{}, true)
+	inner, err := http.NewRequestWithContext(ctx, route.endpoint.Method, path, bytes.NewReader(body))
+	if err != nil {
+		return fail(http.StatusBadRequest, err.Error())
+	}
+	for key, values := range r.Header {
+		switch key {
+		case "Content-Length", "Accept-Encoding", "Content-Type":
+			continue
+		}
+		inner.Header[key] = values
+	}
+	if body != nil {
+		inner.Header.Set("Content-Type", "application/json")
+	}
+	inner.RemoteAddr = r.RemoteAddr
+
+	recorder := &toolRecorder{ResponseRecorder: httptest.NewRecorder(), outer: http.NewResponseController(w)}
+	t.handler.ServeHTTP(recorder, inner)
+
+	result.Status = recorder.Code
+	result.IsError = recorder.Code >= 400
+	result.Content = strings.TrimSpace(recorder.Body.String())
+	if result.Content == "" {
+		result.Content = "{}"
+	}
+	return result
+}
+
+// toolCallContext marks the requests of tool calls
+type toolCallContext struct{}
+
+// isToolCall reports whether the request is a tool call run by /tools/invoke
+func isToolCall(ctx context.Context) bool {
+	called, _ := ctx.Value(toolCallContext{}).(bool)
+	return called
+}
+
+// toolRecorder keeps a tool call's response. Long calls extend their write deadline, which
+// is the deadline of the invoke response they end up in.
+type toolRecorder struct {
+	*httptest.ResponseRecorder
+	outer *http.ResponseController
+}
+
+func (t *toolRecorder) SetWriteDeadline(deadline time.Time) error {
+	return t.outer.SetWriteDeadline(deadline)
+}
+
+// parseToolArgs reads a call's arguments, given as an object or, as OpenAI sends them, a
+// string holding one
+func parseToolArgs(raw json.RawMessage) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return args, nil
+	}
+	if raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+		if strings.TrimSpace(text) == "" {
+			return args, nil
+		}
+		raw = json.RawMessage(text)
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return nil, fmt.Errorf("arguments must be a JSON object: %w", err)
+	}
+	return args, nil
+}
+
+// argString is an argument as it goes into a path or query
+func argString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+// routeParam matches the parameters of a route pattern
+var routeParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// toolName is the snake_case name of a tool ("ExecuteJS" → "execute_js")
+func toolName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z'
+			prevUpper := runes[i-1] >= 'A' && runes[i-1] <= 'Z'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || (prevUpper && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		if upper {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toolParamName is the argument a route parameter is passed as; {id} is always a session
+func toolParamName(param string) string {
+	if param == "id" {
+		return "session_id"
+	}
+	return toolName(param)
+}
+
+// toolParamDescription describes a route parameter's argument
+func toolParamDescription(arg string) string {
+	switch arg {
+	case "session_id":
+		return "Session ID, as returned by create_session"
+	case "page_id":
+		return "Page ID, as returned by navigate or list_pages"
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(arg, "_id"), "_", " ") + " ID"
+}
+
+// Example values for the common tools; the others get placeholders built from their schema
+const (
+	exampleSessionID = "sess_PhmTI_Pp7wVoC_YKDR1CJA=="
+	examplePageID    = "F88D081D45FF710195145A522D524699"
+)
+
+var toolExamples = map[string]ToolArgs{
+	"CreateSession":     {"agent_id": "research-agent"},
+	"Navigate":          {"session_id": exampleSessionID, "url": "https://example.com"},
+	"ExecuteJS":         {"session_id": exampleSessionID, "page_id": examplePageID, "script": "document.title"},
+	"CaptureScreenshot": {"session_id": exampleSessionID, "page_id": examplePageID},
+	"GetPageContent":    {"session_id": exampleSessionID, "page_id": examplePageID},
+	"Click":             {"session_id": exampleSessionID, "page_id": examplePageID, "x": 412, "y": 388},
+	"Wait":              {"session_id": exampleSessionID, "page_id": examplePageID, "selector": "#results", "state": "visible"},
+	"VisionQuery":       {"session_id": exampleSessionID, "page_id": examplePageID, "question": "Where is the search box?", "overlay": true},
+	"Search":            {"query": "eiffel tower height", "count": 5},
+	"Research":          {"question": "When was the Eiffel Tower completed?"},
+}
+
+// toolExample returns a tool's example arguments
+func toolExample(endpoint string, properties map[string]interface{}, required []string) ToolArgs {
+	if example, ok := toolExamples[endpoint]; ok {
+		return example
+	}
+	example := ToolArgs{}
+	for _, name := range required {
+		switch name {
+		case "session_id":
+			example[name] = exampleSessionID
+		case "page_id":
+			example[name] = examplePageID
+		default:
+			property, _ := properties[name].(map[string]interface{})
+			example[name] = exampleValue(name, property)
+		}
+	}
+	return example
+}
+
+// exampleValue is a placeholder of the property's type
+func exampleValue(name string, property map[string]interface{}) interface{} {
+	if enum, ok := property["enum"].([]string); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	switch property["type"] {
+	case "integer", "number":
+		if minimum, ok := property["minimum"].(int); ok && minimum > 0 {
+			return minimum
+		}
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []interface{}{}
+	case "object":
+		return map[string]interface{}{}
+	}
+	if property["format"] == "uri" {
+		return "https://example.com"
+	}
+	return "<" + name + ">"
+}
+
+var (
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	durationType      = reflect.TypeFor[time.Duration]()
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+)
+
+// jsonSchema describes how encoding/json reads t, with the limits of its validate tags.
+// Types seen further up are described as plain objects, so recursive types end.
+func jsonSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]interface{}{}
+	case t == durationType:
+		return map[string]interface{}{"type": "integer", "description": "nanoseconds"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]interface{}{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem(), seen)}
+	case reflect.Struct:
+	default:
+		return map[string]interface{}{}
+	}
+
+	if seen[t] {
+		return map[string]interface{}{"type": "object"}
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	addStructFields(t, seen, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addStructFields adds the JSON properties of struct t, flattening untagged embedded structs
+func addStructFields(t reflect.Type, seen map[reflect.Type]bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addStructFields(embedded, seen, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := jsonSchema(field.Type, seen)
+		if applyValidateTag(property, field.Tag.Get("validate")) {
+			*required = append(*required, name)
+		}
+		properties[name] = property
+	}
+}
+
+// schemaLimits are the keywords validate's min and max become, by schema type
+var schemaLimits = map[string][2]string{
+	"integer": {"minimum", "maximum"},
+	"number":  {"minimum", "maximum"},
+	"string":  {"minLength", "maxLength"},
+	"array":   {"minItems", "maxItems"},
+}
+
+// applyValidateTag adds a field's validate limits to its schema and reports whether the
+// field is required
+func applyValidateTag(property map[string]interface{}, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "dive" {
+			break // What follows applies to the elements
+		}
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "url":
+			property["format"] = "uri"
+		case "oneof":
+			property["enum"] = strings.Fields(value)
+		case "min", "max":
+			limit, err := strconv.Atoi(value)
+			keywords, ok := schemaLimits[fmt.Sprint(property["type"])]
+			if err != nil || !ok {
+				continue
+			}
+			if key == "min" {
+				property[keywords[0]] = limit
+			} else {
+				property[keywords[1]] = limit
+			}
+		}
+	}
+	return required
+}
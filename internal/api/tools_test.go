@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/go-chi/chi/v5"
+)
+
+// schemaNode is a recursive request body, as a tree of steps
+type schemaNode struct {
+	Name     string        `json:"name" validate:"required,min=1,max=20"`
+	Children []*schemaNode `json:"children,omitempty" validate:"max=5,dive"`
+}
+
+// schemaPage is embedded into schemaRequest without a tag, so its fields are flattened
+type schemaPage struct {
+	PageID string `json:"page_id" validate:"required"`
+}
+
+type schemaRequest struct {
+	schemaPage
+	Format  string            `json:"format,omitempty" validate:"omitempty,oneof=png jpeg"`
+	Link    string            `json:"link" validate:"required,url"`
+	Count   int               `json:"count,omitempty" validate:"min=0,max=50"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Root    schemaNode        `json:"root"`
+	Ignored string            `json:"-"`
+	secret  string
+}
+
+// TestToolSchema tests that request types are described as encoding/json reads them,
+// with their validate limits, and that recursive types end
+func TestToolSchema(t *testing.T) {
+	schema := jsonSchema(reflect.TypeFor[schemaRequest](), map[reflect.Type]bool{})
+
+	if got := schema["required"]; !reflect.DeepEqual(got, []string{"page_id", "link"}) {
+		t.Errorf("unexpected required fields: %v", got)
+	}
+	properties := schema["properties"].(map[string]interface{})
+	for _, hidden := range []string{"Ignored", "secret", "schemaPage"} {
+		if _, ok := properties[hidden]; ok {
+			t.Errorf("expected %s left out of %v", hidden, properties)
+		}
+	}
+
+	want := map[string]map[string]interface{}{
+		"format": {"type": "string", "enum": []string{"png", "jpeg"}},
+		"link":   {"type": "string", "format": "uri"},
+		"count":  {"type": "integer", "minimum": 0, "maximum": 50},
+		"labels": {"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+	}
+	for name, property := range want {
+		if !reflect.DeepEqual(properties[name], property) {
+			t.Errorf("%s: expected %v, got %v", name, property, properties[name])
+		}
+	}
+
+	// String limits become lengths and stop at dive; the nested node is a plain object
+	root := properties["root"].(map[string]interface{})
+	rootProperties := root["properties"].(map[string]interface{})
+	name := rootProperties["name"].(map[string]interface{})
+	if name["minLength"] != 1 || name["maxLength"] != 20 || !reflect.DeepEqual(root["required"], []string{"name"}) {
+		t.Errorf("unexpected node schema: %v", root)
+	}
+	children := rootProperties["children"].(map[string]interface{})
+	if children["type"] != "array" || children["maxItems"] != 5 {
+		t.Errorf("unexpected children schema: %v", children)
+	}
+	if !reflect.DeepEqual(children["items"], map[string]interface{}{"type": "object"}) {
+		t.Errorf("expected the recursive node described as a plain object, got %v", children["items"])
+	}
+
+	// Route parameters come first among the required arguments of the real table
+	toolbox := newToolbox(Endpoints, nil)
+	for _, tool := range toolbox.tools {
+		switch tool.Function.Name {
+		case "navigate":
+			if got := tool.Function.Parameters["required"]; !reflect.DeepEqual(got, []string{"session_id", "url"}) {
+				t.Errorf("navigate: unexpected required arguments %v", got)
+			}
+		case "capture_screenshot":
+			format := tool.Function.Parameters["properties"].(map[string]interface{})["format"]
+			if !reflect.DeepEqual(format, map[string]interface{}{"type": "string", "enum": []string{"png", "jpeg"}}) {
+				t.Errorf("capture_screenshot: unexpected format %v", format)
+			}
+		}
+	}
+	if _, streamed := toolbox.routes["stream_events"]; streamed {
+		t.Error("expected WebSocket routes left out of the tools")
+	}
+}
+
+// TestToolName tests the snake_case names tools get from endpoint names
+func TestToolName(t *testing.T) {
+	for name, want := range map[string]string{
+		"ExecuteJS":          "execute_js",
+		"CreateSession":      "create_session",
+		"GetHTTPHeaders":     "get_http_headers",
+		"PrintPDF":           "print_pdf",
+		"pageId":             "page_id",
+		"VisionQuery":        "vision_query",
+		"GetElementBox":      "get_element_box",
+		"ListServiceWorkers": "list_service_workers",
+	} {
+		if got := toolName(name); got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+}
+
+// TestInvokeTools tests that calls are turned into their route's path, query and body,
+// and that bad calls are reported without stopping the others
+func TestInvokeTools(t *testing.T) {
+	type seen struct {
+		Path  string `json:"path"`
+		Query string `json:"query"`
+		Body  string `json:"body"`
+	}
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		writeJSON(w, http.StatusOK, seen{Path: r.URL.EscapedPath(), Query: r.URL.RawQuery, Body: string(body)})
+	}
+	router := chi.NewRouter()
+	router.Post("/sessions/{id}/pages/{pageId}/click", echo)
+	router.Get("/sessions/{id}/pages/{pageId}/element/box", echo)
+
+	toolbox := newToolbox([]Endpoint{
+		{Name: "Click", Method: "POST", Path: "/sessions/{id}/pages/{pageId}/click", Request: typeOf[ClickRequest]()},
+		{Name: "GetElementBox", Method: "GET", Path: "/sessions/{id}/pages/{pageId}/element/box", Query: []string{"selector"}},
+	}, router)
+
+	invoke := func(body string) (int, InvokeToolsResponse) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		toolbox.InvokeTools(w, httptest.NewRequest(http.MethodPost, "/tools/invoke", strings.NewReader(body)))
+		var response InvokeToolsResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode results: %v", err)
+			}
+		}
+		return w.Code, response
+	}
+
+	code, response := invoke(`{"tool_calls": [
+		{"id": "call_1", "function": {"name": "click", "arguments": "{\"session_id\": \"sess/1\", \"page_id\": \"P1\", \"x\": 10.5, \"y\": 20}"}},
+		{"id": "call_2", "function": {"name": "get_element_box", "arguments": {"session_id": "sess_1", "page_id": "P1", "selector": "#buy now"}}},
+		{"id": "call_3", "function": {"name": "get_element_box", "arguments": {"session_id": "sess_1", "page_id": "P1", "colour": "red"}}},
+		{"id": "call_4", "function": {"name": "get_element_box", "arguments": {"session_id": "sess_1"}}},
+		{"id": "call_5", "function": {"name": "fly", "arguments": {}}}
+	]}`)
+	if code != http.StatusOK || len(response.Results) != 5 {
+		t.Fatalf("expected 5 results, got %d %+v", code, response)
+	}
+
+	var click, box seen
+	json.Unmarshal([]byte(response.Results[0].Content), &click)
+	if response.Results[0].ToolCallID != "call_1" || click.Path != "/sessions/sess%2F1/pages/P1/click" || click.Body != `{"x":10.5,"y":20}` {
+		t.Errorf("unexpected click call: %+v %+v", response.Results[0], click)
+	}
+	json.Unmarshal([]byte(response.Results[1].Content), &box)
+	if box.Path != "/sessions/sess_1/pages/P1/element/box" || box.Query != "selector=%23buy+now" || box.Body != "" {
+		t.Errorf("unexpected box call: %+v", box)
+	}
+
+	failures := []struct {
+		status  int
+		message string
+	}{
+		{http.StatusBadRequest, "unknown arguments: colour"},
+		{http.StatusBadRequest, "missing argument: page_id"},
+		{http.StatusNotFound, "unknown tool: fly"},
+	}
+	for i, want := range failures {
+		result := response.Results[i+2]
+		if result.Status != want.status || !result.IsError || !strings.Contains(result.Content, want.message) {
+			t.Errorf("%s: expected %d %q, got %+v", result.ToolCallID, want.status, want.message, result)
+		}
+	}
+
+	// Too many calls at once are refused as a whole
+	calls := make([]ToolCall, maxToolCalls+1)
+	for i := range calls {
+		calls[i] = ToolCall{Function: ToolCallFunction{Name: "click"}}
+	}
+	body, _ := json.Marshal(InvokeToolsRequest{ToolCalls: calls})
+	if code, _ := invoke(string(body)); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for %d calls, got %d", len(calls), code)
+	}
+	if code, _ := invoke(`{}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 without calls, got %d", code)
+	}
+}
+
+// TestInvokeToolsRoles tests that tool calls go through the same role checks as the
+// routes they stand for
+func TestInvokeToolsRoles(t *testing.T) {
+	tenants := tenant.NewRegistry()
+	if err := tenants.Replace([]*tenant.Tenant{{ID: "acme", Keys: []tenant.Key{{Key: "viewer-key", Role: tenant.RoleViewer}}}}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	manager := session.NewManager(nil)
+	defer manager.Close()
+	s := NewServer("0", manager, nil, nil, nil, nil, nil, nil, nil, "", nil, tenants, nil, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/tools/invoke", strings.NewReader(
+		`{"function": {"name": "navigate", "arguments": {"session_id": "sess_1", "url": "https://example.com"}}}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer viewer-key")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+
+	var response InvokeToolsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode results: %d %s", w.Code, w.Body.String())
+	}
+	if w.Code != http.StatusOK || len(response.Results) != 1 {
+		t.Fatalf("expected one result, got %d %s", w.Code, w.Body.String())
+	}
+	if result := response.Results[0]; result.Status != http.StatusForbidden || !strings.Contains(result.Content, ErrCodeForbidden) {
+		t.Errorf("expected a viewer's navigate refused with 403, got %+v", result)
+	}
+
+	// Without a key the invoke call itself is refused
+	w = httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tools/invoke", strings.NewReader(`{}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %d", w.Code)
+	}
+}
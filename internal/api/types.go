@@ -1,9 +1,20 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pipeline"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pool"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/seeds"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/session"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/sessionlog"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/tenant"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vault"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/visual"
 )
 
 // Request Types
@@ -15,23 +26,44 @@ type CreateSessionRequest struct {
 	// Optional: Allow client to specify port
 	// If not provided, server/load balancer decides
 	BrowserPort int `json:"browser_port,omitempty"`
+	// Optional: named template and inline overrides layered on top of it
+	Template string                  `json:"template,omitempty"`
+	Options  *session.SessionOptions `json:"options,omitempty"`
 }
 
 // NavigateRequest for POST /sessions/{id}/navigate
 type NavigateRequest struct {
-	URL string `json:"url" validate:"required"`
+	URL                 string `json:"url" validate:"required,url"`
+	BypassServiceWorker bool   `json:"bypass_service_worker,omitempty"` // The page's requests skip service workers
 }
 
 // ExecuteJSRequest for POST /sessions/{id}/execute
 type ExecuteJSRequest struct {
 	PageID string `json:"page_id" validate:"required"`
 	Script string `json:"script" validate:"required"`
+
+	// Optional: call script, a function declaration, with these JSON values as its arguments
+	// ("args": [] calls it with none). Without args script is an expression.
+	Args []json.RawMessage `json:"args,omitempty"`
+
+	// Optional: how the result comes back; deep keeps values JSON can't hold, DOM nodes as object IDs
+	session.ScriptOptions
+
+	// Optional: report whether the script changed the page
+	VerifyChange     bool `json:"verify_change,omitempty"`
+	VerifyScreenshot bool `json:"verify_screenshot,omitempty"` // Also compare screenshot hashes (slower)
 }
 
 // ScreenshotRequest for POST /sessions/{id}/screenshot
 type ScreenshotRequest struct {
-	PageID string `json:"page_id" validate:"required"`
-	Format string `json:"format,omitempty"` // "png" or "jpeg", default "png"
+	PageID   string `json:"page_id" validate:"required"`
+	Format   string `json:"format,omitempty" validate:"omitempty,oneof=png jpeg"` // Default "png"
+	Annotate bool   `json:"annotate,omitempty"`                                   // Number the interactive elements and return a legend
+}
+
+// PrintPDFRequest for POST /sessions/{id}/pages/{pageId}/pdf; the body may be omitted
+type PrintPDFRequest struct {
+	session.PDFOptions
 }
 
 
@@ -48,16 +80,26 @@ type CreateSessionResponse struct {
 
 // NavigateResponse returned after navigation
 type NavigateResponse struct {
-	SessionID string `json:"session_id"`
-	PageID    string `json:"page_id"`
-	URL       string `json:"url"`
+	SessionID    string                `json:"session_id"`
+	PageID       string                `json:"page_id"`
+	URL          string                `json:"url"` // Where the page ended up, after redirects
+	RequestedURL string                `json:"requested_url"`
+	Status       int                   `json:"status,omitempty"` // HTTP status of the final document
+	StatusText   string                `json:"status_text,omitempty"`
+	Redirects    []session.RedirectHop `json:"redirects"`
+	Failure      string                `json:"failure,omitempty"` // http_client_error or http_server_error when the site answered with an error status
+	Captcha      *session.CaptchaInfo  `json:"captcha,omitempty"` // Present when the page is blocked by a CAPTCHA
+
+	// Security is the TLS connection and certificate the final document came over
+	Security *session.SecurityDetails `json:"security,omitempty"`
 }
 
 // ExecuteJSResponse returned after JavaScript execution
 type ExecuteJSResponse struct {
-	SessionID string      `json:"session_id"`
-	PageID    string      `json:"page_id"`
-	Result    interface{} `json:"result"`
+	SessionID  string             `json:"session_id"`
+	PageID     string             `json:"page_id"`
+	Result     interface{}        `json:"result"`
+	PageChange *session.PageDelta `json:"page_change,omitempty"`
 }
 
 // ScreenshotResponse returned after screenshot capture
@@ -67,6 +109,10 @@ type ScreenshotResponse struct {
 	Screenshot string `json:"screenshot"` // base64 encoded PNG/JPEG
 	Format     string `json:"format"`
 	Size       int    `json:"size"` // Size in bytes (before encoding)
+
+	// With annotate: what each number on the image is, boxes in CSS pixels
+	Legend           []session.ElementMark `json:"legend,omitempty"`
+	DevicePixelRatio float64               `json:"device_pixel_ratio,omitempty"` // Image pixels per CSS pixel
 }
 
 // GetPageContentResponse returned with page HTML
@@ -88,6 +134,7 @@ type GetSessionResponse struct {
 	CreatedAt    time.Time             `json:"created_at"`
 	LastActivity time.Time             `json:"last_activity"`
 	Status       session.SessionStatus `json:"status"`
+	Connection   cdp.ConnectionState   `json:"connection,omitempty"` // Browser connection: connected, reconnecting or closed
 }
 
 // ListSessionsResponse returned with all sessions
@@ -106,6 +153,19 @@ type SessionInfo struct {
 	CreatedAt    time.Time             `json:"created_at"`
 	LastActivity time.Time             `json:"last_activity"`
 	Status       session.SessionStatus `json:"status"`
+	Labels       map[string]string     `json:"labels,omitempty"`
+}
+
+// DestroySessionBatchRequest for POST /sessions/destroy-batch
+type DestroySessionBatchRequest struct {
+	SessionIDs []string `json:"session_ids" validate:"required,dive,required"`
+}
+
+// DestroySessionsResponse reports a bulk destroy, one result per session
+type DestroySessionsResponse struct {
+	Results   []session.DestroyOutcome `json:"results"`
+	Destroyed int                      `json:"destroyed"`
+	Failed    int                      `json:"failed"`
 }
 
 // SuccessResponse for operations that just need success confirmation
@@ -125,6 +185,12 @@ type ErrorResponse struct {
 type ErrorDetail struct {
 	Code    string `json:"code"`    // Machine-readable error code
 	Message string `json:"message"` // Human-readable message
+
+	// With VALIDATION_FAILED: every field that broke a rule
+	Violations []Violation `json:"violations,omitempty"`
+
+	// With SCRIPT_ERROR: the exception the page's script threw
+	Script *session.ScriptError `json:"script,omitempty"`
 }
 
 // ListAgentSessionsResponse
@@ -164,16 +230,35 @@ type RenameSessionRequest struct {
 }
 
 
+// ShareSessionRequest for POST /sessions/{id}/share
+type ShareSessionRequest struct {
+	Mode     string `json:"mode,omitempty" validate:"omitempty,oneof=read_only transfer"` // Default read_only
+	TenantID string `json:"tenant_id,omitempty"`
+	AgentID  string `json:"agent_id,omitempty"` // New owning agent; transfer only
+}
+
+// ShareSessionResponse describes who owns a session and who may read it
+type ShareSessionResponse struct {
+	SessionID string                 `json:"session_id"`
+	Mode      string                 `json:"mode,omitempty"`
+	TenantID  string                 `json:"tenant_id,omitempty"`
+	AgentID   string                 `json:"agent_id"`
+	Shares    []session.SessionShare `json:"shares"`
+}
+
 // AnalyzePageRequest for POST /sessions/{id}/analyze
 type AnalyzePageRequest struct {
-	PageID string `json:"page_id" validate:"required"`
+	PageID              string `json:"page_id" validate:"required"`
+	InstructionLanguage string `json:"instruction_language,omitempty" validate:"omitempty,bcp47_language_tag"` // Language the caller's instructions are in
 }
 
 // AnalyzePageResponse returned after page analysis
 type AnalyzePageResponse struct {
-	SessionID string                  `json:"session_id"`
-	PageID    string                  `json:"page_id"`
-	Analysis  *session.PageStructure  `json:"analysis"`
+	SessionID           string                 `json:"session_id"`
+	PageID              string                 `json:"page_id"`
+	Analysis            *session.PageStructure `json:"analysis"`
+	InstructionLanguage string                 `json:"instruction_language,omitempty"`
+	TranslateLabels     bool                   `json:"translate_labels,omitempty"` // The page is in another language than the instructions
 }
 
 // AccessibilityTreeRequest for POST /sessions/{id}/accessibility-tree
@@ -196,8 +281,919 @@ const (
 	ErrCodeSessionCreateFailed = "SESSION_CREATE_FAILED"
 	ErrCodeNavigationFailed    = "NAVIGATION_FAILED"
 	ErrCodeExecutionFailed     = "EXECUTION_FAILED"
+	ErrCodeScriptError         = "SCRIPT_ERROR"
 	ErrCodeScreenshotFailed    = "SCREENSHOT_FAILED"
 	ErrCodeAnalysisFailed      = "ANALYSIS_FAILED"
 	ErrCodeAccessibilityFailed = "ACCESSIBILITY_FAILED"
 	ErrCodeInternalError       = "INTERNAL_ERROR"
-)
\ No newline at end of file
+	ErrCodeObserverNotFound    = "OBSERVER_NOT_FOUND"
+	ErrCodeFormNotFound        = "FORM_NOT_FOUND"
+	ErrCodeFormFillFailed      = "FORM_FILL_FAILED"
+	ErrCodeCredentialNotFound  = "CREDENTIAL_NOT_FOUND"
+	ErrCodeLoginFailed         = "LOGIN_FAILED"
+	ErrCodeCaptchaNotFound     = "CAPTCHA_NOT_FOUND"
+	ErrCodeCaptchaFailed       = "CAPTCHA_FAILED"
+	ErrCodeSolverNotFound      = "SOLVER_NOT_FOUND"
+	ErrCodeTakeoverActive      = "TAKEOVER_ACTIVE"
+	ErrCodeTakeoverNotFound    = "TAKEOVER_NOT_FOUND"
+	ErrCodeResourceNotFound    = "RESOURCE_NOT_FOUND"
+	ErrCodeResourceTooLarge    = "RESOURCE_TOO_LARGE"
+	ErrCodeResourceFailed      = "RESOURCE_FAILED"
+	ErrCodeTemplateNotFound    = "TEMPLATE_NOT_FOUND"
+	ErrCodePromptNotFound      = "PROMPT_NOT_FOUND"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeProcessNotFound     = "PROCESS_NOT_FOUND"
+	ErrCodeProcessBusy         = "PROCESS_BUSY"
+	ErrCodePipelineNotFound    = "PIPELINE_NOT_FOUND"
+	ErrCodePipelineFailed      = "PIPELINE_FAILED"
+	ErrCodeTenantQuota         = "TENANT_QUOTA_EXCEEDED"
+	ErrCodeVisionUnavailable   = "VISION_UNAVAILABLE"
+	ErrCodeVisionFailed        = "VISION_FAILED"
+	ErrCodeModelBudget         = "MODEL_BUDGET_EXCEEDED"
+	ErrCodeClickFailed         = "CLICK_FAILED"
+	ErrCodeElementNotFound     = "ELEMENT_NOT_FOUND"
+	ErrCodeElementFailed       = "ELEMENT_INSPECTION_FAILED"
+	ErrCodeWatchNotFound       = "WATCH_NOT_FOUND"
+	ErrCodeWatchFailed         = "WATCH_FAILED"
+	ErrCodeHandleNotFound      = "HANDLE_NOT_FOUND"
+	ErrCodeHandleStale         = "HANDLE_STALE"
+	ErrCodeHandleFailed        = "HANDLE_FAILED"
+	ErrCodeHealFailed          = "HEAL_FAILED"
+	ErrCodeCanvasFailed        = "CANVAS_FAILED"
+	ErrCodeMediaFailed         = "MEDIA_EMULATION_FAILED"
+	ErrCodeStorageFailed       = "STORAGE_FAILED"
+	ErrCodeServiceWorkerFailed = "SERVICE_WORKER_FAILED"
+	ErrCodeNoServiceWorker     = "SERVICE_WORKER_NOT_FOUND"
+	ErrCodeCacheNotFound       = "CACHE_NOT_FOUND"
+	ErrCodeCaptureDisabled     = "NETWORK_CAPTURE_DISABLED"
+	ErrCodeDraining            = "SERVICE_DRAINING"
+	ErrCodeSharedReadOnly      = "SESSION_SHARED_READ_ONLY"
+	ErrCodeShareNotFound       = "SHARE_NOT_FOUND"
+	ErrCodeResponseTooLarge    = "RESPONSE_TOO_LARGE"
+	ErrCodePDFFailed           = "PDF_FAILED"
+	ErrCodeRequestTooLarge     = "REQUEST_TOO_LARGE"
+	ErrCodeValidationFailed    = "VALIDATION_FAILED"
+	ErrCodeForbidden           = "FORBIDDEN"
+	ErrCodeScriptDenied        = "SCRIPT_NOT_ALLOWED"
+	ErrCodeFileNotFound        = "FILE_NOT_FOUND"
+	ErrCodeProfileInUse        = "PROFILE_IN_USE"
+	ErrCodeProfileNotFound     = "PROFILE_NOT_FOUND"
+	ErrCodeProfilesUnavailable = "PROFILES_UNAVAILABLE"
+	ErrCodeEngineUnsupported   = "ENGINE_UNSUPPORTED"
+	ErrCodeEngineUnavailable   = "ENGINE_UNAVAILABLE"
+	ErrCodeSessionWakeFailed   = "SESSION_WAKE_FAILED"
+	ErrCodeCheckpointNotFound  = "CHECKPOINT_NOT_FOUND"
+	ErrCodeCheckpointLimit     = "CHECKPOINT_LIMIT_REACHED"
+	ErrCodeCheckNotFound       = "CHECK_NOT_FOUND"
+	ErrCodeCheckRunning        = "CHECK_RUNNING"
+	ErrCodeBaselineNotFound    = "BASELINE_NOT_FOUND"
+	ErrCodeSeedFetchFailed     = "SEED_FETCH_FAILED"
+	ErrCodeSearchUnavailable   = "SEARCH_UNAVAILABLE"
+	ErrCodeSearchFailed        = "SEARCH_FAILED"
+	ErrCodeResearchUnavailable = "RESEARCH_UNAVAILABLE"
+	ErrCodeRateLimited         = "RATE_LIMITED"
+	ErrCodeAtCapacity          = "AT_CAPACITY"
+	ErrCodeQueueFull           = "QUEUE_FULL"
+	ErrCodeEgressUnavailable   = "EGRESS_PROXY_UNAVAILABLE"
+	ErrCodeBandwidthUnmetered  = "BANDWIDTH_NOT_METERED"
+
+	// Navigation failures, by session.NavigationError kind
+	ErrCodeNavigationDNS          = "NAVIGATION_DNS_FAILED"
+	ErrCodeNavigationTLS          = "NAVIGATION_TLS_FAILED"
+	ErrCodeNavigationConnection   = "NAVIGATION_CONNECTION_FAILED"
+	ErrCodeNavigationTimeout      = "NAVIGATION_TIMEOUT"
+	ErrCodeNavigationBlocked      = "NAVIGATION_BLOCKED"
+	ErrCodeNavigationAborted      = "NAVIGATION_ABORTED"
+	ErrCodeNavigationInterstitial = "NAVIGATION_INTERSTITIAL"
+	ErrCodeNavigationNetwork      = "NAVIGATION_NETWORK_ERROR"
+)
+// CreateObserverRequest for POST /sessions/{id}/observers
+type CreateObserverRequest struct {
+	Label string `json:"label,omitempty"`
+}
+
+// ObserverResponse describes a read-only observer attached to a session
+type ObserverResponse struct {
+	ObserverID string    `json:"observer_id"`
+	SessionID  string    `json:"session_id"`
+	Label      string    `json:"label,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ListObserversResponse returned with all observers of a session
+type ListObserversResponse struct {
+	SessionID string             `json:"session_id"`
+	Observers []ObserverResponse `json:"observers"`
+	Count     int                `json:"count"`
+}
+
+// ListFormsResponse returned with the forms discovered on a page
+type ListFormsResponse struct {
+	SessionID string             `json:"session_id"`
+	PageID    string             `json:"page_id"`
+	Forms     []session.FormInfo `json:"forms"`
+	Count     int                `json:"count"`
+}
+
+// FillFormRequest for POST /sessions/{id}/pages/{pageId}/forms/{formIndex}/fill
+type FillFormRequest struct {
+	Values    map[string]interface{} `json:"values" validate:"required_without=Submit"`
+	Submit    bool                   `json:"submit,omitempty"`
+	TimeoutMS int                    `json:"timeout_ms,omitempty" validate:"min=0,max=300000"` // Max wait for navigation after submit
+}
+
+// FillFormResponse returned after filling a form
+type FillFormResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	FormIndex int    `json:"form_index"`
+	*session.FormFillResult
+}
+
+// SaveCredentialRequest for POST /credentials
+type SaveCredentialRequest struct {
+	Alias    string `json:"alias" validate:"required"`
+	Username string `json:"username"`
+	Password string `json:"password" validate:"required_without=ClientCertificate"`
+	LoginURL string `json:"login_url,omitempty" validate:"omitempty,url"`
+
+	// PEM client certificate chain and private key, presented by sessions to mutual TLS hosts
+	ClientCertificate string `json:"client_certificate,omitempty" validate:"required_with=ClientKey"`
+	ClientKey         string `json:"client_key,omitempty" validate:"required_with=ClientCertificate"`
+}
+
+// ListCredentialsResponse returned with the non-secret view of stored credentials
+type ListCredentialsResponse struct {
+	Credentials []vault.CredentialInfo `json:"credentials"`
+	Count       int                    `json:"count"`
+}
+
+// LoginRequest for POST /sessions/{id}/login
+type LoginRequest struct {
+	PageID             string `json:"page_id" validate:"required"`
+	Credential         string `json:"credential" validate:"required"` // Vault alias
+	FormIndex          *int   `json:"form_index,omitempty"`
+	SuccessURLContains string `json:"success_url_contains,omitempty"`
+	SuccessSelector    string `json:"success_selector,omitempty"`
+	SuccessEngine      string `json:"success_engine,omitempty"` // How success_selector is read: css (default), xpath or text
+	SuccessMatch       string `json:"success_match,omitempty"`  // Text engine: contains (default), exact or regex
+	TimeoutMS          int    `json:"timeout_ms,omitempty" validate:"min=0,max=300000"`
+}
+
+// LoginResponse returned after a login attempt
+type LoginResponse struct {
+	SessionID  string `json:"session_id"`
+	PageID     string `json:"page_id"`
+	Credential string `json:"credential"`
+	*session.LoginResult
+}
+
+// CaptchaResponse returned with the CAPTCHA detection result for a page
+type CaptchaResponse struct {
+	SessionID string               `json:"session_id"`
+	PageID    string               `json:"page_id"`
+	Captcha   *session.CaptchaInfo `json:"captcha"`
+}
+
+// SolveCaptchaRequest for POST /sessions/{id}/pages/{pageId}/captcha/solve
+type SolveCaptchaRequest struct {
+	Solver    string `json:"solver,omitempty"` // Registered solver name, defaults to the first configured
+	Manual    bool   `json:"manual,omitempty"` // Wait for a human to clear the CAPTCHA instead
+	TimeoutMS int    `json:"timeout_ms,omitempty" validate:"min=0,max=1800000"`
+}
+
+// SolveCaptchaResponse returned after a solve attempt
+type SolveCaptchaResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.CaptchaSolveResult
+}
+
+// VisionQueryRequest for POST /sessions/{id}/pages/{pageId}/vision-query
+type VisionQueryRequest struct {
+	Question  string `json:"question" validate:"required"`
+	Overlay   bool   `json:"overlay,omitempty"` // Number the interactive elements so the model can pick them
+	TimeoutMS int    `json:"timeout_ms,omitempty" validate:"min=0,max=600000"`
+	Stream    bool   `json:"stream,omitempty"` // Send the answer as Server-Sent Events while it is written
+	Fresh     bool   `json:"fresh,omitempty"`    // Ask without the session's memory of earlier questions
+	NoCache   bool   `json:"no_cache,omitempty"` // Ask the model even when the same question was answered on the unchanged page
+}
+
+// VisionQueryResponse returned with the model's answer
+type VisionQueryResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.VisionResult
+}
+
+// ClickRequest for POST /sessions/{id}/pages/{pageId}/click
+type ClickRequest struct {
+	X              float64 `json:"x"` // Viewport CSS pixels (document pixels with scroll_into_view)
+	Y              float64 `json:"y"`
+	Button         string  `json:"button,omitempty" validate:"omitempty,oneof=left middle right"` // Default left
+	ClickCount     int     `json:"click_count,omitempty" validate:"min=0"`
+	Modifiers      int     `json:"modifiers,omitempty" validate:"min=0,max=15"` // Bit field: Alt=1, Ctrl=2, Meta=4, Shift=8
+	ScrollIntoView bool    `json:"scroll_into_view,omitempty"`                  // Scroll the document point into view first
+	VerifyChange   bool    `json:"verify_change,omitempty"`                     // Report whether the page changed
+}
+
+// ClickResponse returned after a click
+type ClickResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.ClickResult
+}
+
+// ElementBoxResponse returned by GET /sessions/{id}/pages/{pageId}/element/box
+type ElementBoxResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.ElementLayout
+}
+
+// ElementFingerprintResponse returned by GET /sessions/{id}/pages/{pageId}/element/fingerprint
+type ElementFingerprintResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.ElementFingerprint
+}
+
+// HealSelectorRequest for POST /sessions/{id}/pages/{pageId}/element/heal
+type HealSelectorRequest struct {
+	Selector    string                      `json:"selector" validate:"required"`
+	Fingerprint *session.ElementFingerprint `json:"fingerprint" validate:"required"`            // Taken while the selector still matched
+	MinScore    float64                     `json:"min_score,omitempty" validate:"min=0,max=1"` // Lowest score healed to, default 0.5
+	Confirm     bool                        `json:"confirm,omitempty"`                          // Let the vision model pick among the candidates
+	NoCache     bool                        `json:"no_cache,omitempty"`                         // Ask the model even when it picked among the same candidates before
+
+	session.SelectorEngine // How selector is read
+}
+
+// HealSelectorResponse returned after a healing attempt, healed or not
+type HealSelectorResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.HealResult
+}
+
+// CaptureCanvasRequest for POST /sessions/{id}/pages/{pageId}/canvas; the body may be omitted
+type CaptureCanvasRequest struct {
+	Selector string `json:"selector,omitempty"`                                                   // Canvases, or elements holding them; default every canvas
+	Method   string `json:"method,omitempty" validate:"omitempty,oneof=auto data_url screenshot"` // Default auto
+	Format   string `json:"format,omitempty" validate:"omitempty,oneof=png jpeg"`                 // Default png
+	Quality  int    `json:"quality,omitempty" validate:"min=0,max=100"`                           // jpeg only
+
+	session.SelectorEngine // How selector is read
+}
+
+// CaptureCanvasResponse returned with a page's canvases
+type CaptureCanvasResponse struct {
+	SessionID string                  `json:"session_id"`
+	PageID    string                  `json:"page_id"`
+	Canvases  []session.CanvasCapture `json:"canvases"`
+	Count     int                     `json:"count"`
+	Total     int                     `json:"total"` // Canvases found, of which at most 20 are captured
+}
+
+// PageMediaRequest for PUT /sessions/{id}/pages/{pageId}/media; omitted fields, or an
+// omitted body, stop emulating them
+type PageMediaRequest struct {
+	Media         string `json:"media,omitempty" validate:"omitempty,oneof=screen print"`
+	ColorScheme   string `json:"color_scheme,omitempty" validate:"omitempty,oneof=light dark no-preference"`
+	ReducedMotion string `json:"reduced_motion,omitempty" validate:"omitempty,oneof=reduce no-preference"`
+}
+
+// PageMediaResponse returned with the media a page is rendered for
+type PageMediaResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.MediaEmulation
+}
+
+// WatchRequest for POST /sessions/{id}/pages/{pageId}/watch
+type WatchRequest struct {
+	Selector   string `json:"selector" validate:"required"`
+	DebounceMs int    `json:"debounce_ms,omitempty" validate:"min=0,max=60000"` // Quiet period before a change is reported, default 250
+	Attributes bool   `json:"attributes,omitempty"`                             // Also report attribute changes
+
+	session.SelectorEngine // How selector is read
+}
+
+// WatchResponse returned when a DOM watch is registered
+type WatchResponse struct {
+	SessionID string `json:"session_id"`
+	*session.DOMWatch
+}
+
+// WaitRequest for POST /sessions/{id}/pages/{pageId}/wait. Set exactly one of
+// selector, url and function.
+type WaitRequest struct {
+	Selector  string `json:"selector,omitempty"`
+	State     string `json:"state,omitempty" validate:"omitempty,oneof=attached visible hidden detached"` // Default attached
+	URL       string `json:"url,omitempty"`                                                               // Glob with *, or a regular expression between slashes
+	Function  string `json:"function,omitempty"`                                                          // JavaScript expression or function that must become truthy
+	TimeoutMS int    `json:"timeout_ms,omitempty" validate:"min=0,max=300000"`
+	PollingMS int    `json:"polling_ms,omitempty" validate:"min=0,max=60000"` // Interval between checks, default 100
+
+	session.SelectorEngine // How selector is read
+}
+
+// WaitResponse returned when a wait ends, satisfied or not
+type WaitResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.WaitResult
+}
+
+// AssertRequest for POST /sessions/{id}/pages/{pageId}/assert. Each field that is set
+// adds a check; set at least one.
+type AssertRequest struct {
+	Selector    string `json:"selector,omitempty"`                                    // An element must match
+	Visible     bool   `json:"visible,omitempty"`                                     // ... and be visible
+	Text        string `json:"text,omitempty"`                                        // The element's text, or the page's, must contain this
+	TextMatches string `json:"text_matches,omitempty"`                                // ... or match this regular expression
+	URL         string `json:"url,omitempty"`                                         // The page URL must match, as for waits
+	Cookie      string `json:"cookie,omitempty"`                                      // A cookie of this name must be set
+	Status      int    `json:"status,omitempty" validate:"omitempty,min=100,max=599"` // HTTP status of the last navigation
+	Function    string `json:"function,omitempty"`                                    // JavaScript expression that must be truthy
+
+	session.SelectorEngine // How selector is read
+}
+
+// AssertResponse returned with the outcome of every check, passed or not
+type AssertResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.AssertionResult
+}
+
+// ContentHashRequest for POST /sessions/{id}/pages/{pageId}/content-hash
+type ContentHashRequest struct {
+	Ignore      []string             `json:"ignore,omitempty" validate:"max=50"` // CSS selectors of elements to leave out, such as timestamps or ads
+	MaskNumbers bool                 `json:"mask_numbers,omitempty"`             // Treat all numbers as equal
+	Previous    *session.ContentHash `json:"previous,omitempty"`                 // Hashes from an earlier call, to score the change against
+}
+
+// ContentHashResponse returned with the page's hashes, and the change since previous when given
+type ContentHashResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.ContentHash
+	Change *session.ContentChange `json:"change,omitempty"`
+}
+
+// CreateHandleRequest for POST /sessions/{id}/pages/{pageId}/handles
+type CreateHandleRequest struct {
+	Selector string `json:"selector" validate:"required"`
+	session.SelectorEngine
+}
+
+// HandleResponse returned when an element handle is created
+type HandleResponse struct {
+	SessionID string `json:"session_id"`
+	*session.ElementHandle
+}
+
+// ListHandlesResponse returned with the element handles of a page
+type ListHandlesResponse struct {
+	SessionID string                  `json:"session_id"`
+	PageID    string                  `json:"page_id"`
+	Handles   []session.ElementHandle `json:"handles"`
+	Count     int                     `json:"count"`
+}
+
+// HandleClickRequest for POST /sessions/{id}/pages/{pageId}/handles/{handleId}/click
+type HandleClickRequest struct {
+	Button       string `json:"button,omitempty" validate:"omitempty,oneof=left middle right"` // Default left
+	ClickCount   int    `json:"click_count,omitempty" validate:"min=0"`
+	Modifiers    int    `json:"modifiers,omitempty" validate:"min=0,max=15"` // Bit field: Alt=1, Ctrl=2, Meta=4, Shift=8
+	VerifyChange bool   `json:"verify_change,omitempty"`                     // Report whether the page changed
+}
+
+// HandleTextResponse returned with the text of a handle's element
+type HandleTextResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	HandleID  string `json:"handle_id"`
+	Text      string `json:"text"`
+}
+
+// HandleAttributeResponse returned with an attribute of a handle's element
+type HandleAttributeResponse struct {
+	SessionID string  `json:"session_id"`
+	PageID    string  `json:"page_id"`
+	HandleID  string  `json:"handle_id"`
+	Name      string  `json:"name"`
+	Value     *string `json:"value"` // null when the element has no such attribute
+}
+
+// ListWatchesResponse returned with the DOM watches of a page
+type ListWatchesResponse struct {
+	SessionID string             `json:"session_id"`
+	PageID    string             `json:"page_id"`
+	Watches   []session.DOMWatch `json:"watches"`
+	Count     int                `json:"count"`
+}
+
+// ViewportMessage is a frame sent on the screencast and takeover WebSockets.
+// Server messages use type "frame", "control" or "error"; operators send
+// "mouse", "key" or "release" (the input fields follow session.MouseInput/KeyInput).
+type ViewportMessage struct {
+	Type       string                      `json:"type"`
+	Data       string                      `json:"data,omitempty"` // Base64 image for frames
+	Metadata   *session.ScreencastMetadata `json:"metadata,omitempty"`
+	TakeoverID string                      `json:"takeover_id,omitempty"`
+	State      string                      `json:"state,omitempty"` // granted, returned
+	Message    string                      `json:"message,omitempty"`
+}
+
+// TakeoverStatusResponse for GET /sessions/{id}/takeover
+type TakeoverStatusResponse struct {
+	SessionID string            `json:"session_id"`
+	Active    bool              `json:"active"`
+	Takeover  *session.Takeover `json:"takeover,omitempty"`
+}
+
+// ListPagesResponse returned with the live pages of a session
+type ListPagesResponse struct {
+	SessionID string            `json:"session_id"`
+	Pages     []session.TabInfo `json:"pages"`
+	Count     int               `json:"count"`
+}
+
+// DuplicatePageResponse returned after duplicating a page
+type DuplicatePageResponse struct {
+	SessionID    string `json:"session_id"`
+	PageID       string `json:"page_id"`
+	SourcePageID string `json:"source_page_id"`
+	URL          string `json:"url"`
+}
+
+// ListResourcesResponse returned with the subresources loaded by a page
+type ListResourcesResponse struct {
+	SessionID string                 `json:"session_id"`
+	PageID    string                 `json:"page_id"`
+	Resources []session.ResourceInfo `json:"resources"`
+	Count     int                    `json:"count"`
+}
+
+// WorkFilesResponse lists the files in a session's work directory
+type WorkFilesResponse struct {
+	SessionID string `json:"session_id"`
+	session.WorkDirInfo
+}
+
+// DownloadResourceRequest for POST /sessions/{id}/pages/{pageId}/resources/download
+type DownloadResourceRequest struct {
+	URL      string `json:"url" validate:"required,url"`
+	Source   string `json:"source,omitempty" validate:"omitempty,oneof=auto cache fetch"` // Default auto
+	MaxBytes int    `json:"max_bytes,omitempty" validate:"min=0"`                         // Defaults to 10 MiB, capped at 50 MiB
+	Raw      bool   `json:"raw,omitempty"`                                                // Return the bytes directly instead of JSON
+}
+
+// DownloadResourceResponse returned with a downloaded resource
+type DownloadResourceResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	URL       string `json:"url"`
+	MimeType  string `json:"mime_type"`
+	Source    string `json:"source"`
+	Status    int    `json:"status,omitempty"`
+	Data      string `json:"data"` // base64 encoded
+	Size      int    `json:"size"`
+}
+
+// SaveTemplateRequest for POST /templates
+type SaveTemplateRequest struct {
+	Name        string                 `json:"name" validate:"required"`
+	Description string                 `json:"description,omitempty"`
+	Options     session.SessionOptions `json:"options"`
+}
+
+// ListTemplatesResponse returned with all session templates
+type ListTemplatesResponse struct {
+	Templates []*session.SessionTemplate `json:"templates"`
+	Count     int                        `json:"count"`
+}
+
+// SavePromptRequest for PUT /prompts/{name}: a new version of the prompt
+type SavePromptRequest struct {
+	Text      string            `json:"text,omitempty"`      // Go template; empty keeps the text being overridden
+	Variables map[string]string `json:"variables,omitempty"` // Read as {{.Vars.name}}
+}
+
+// ListPromptsResponse returned with every system prompt as the caller is asked with it
+type ListPromptsResponse struct {
+	Prompts []*session.Prompt `json:"prompts"`
+	Count   int               `json:"count"`
+}
+
+
+// SaveCheckRequest for POST /checks
+type SaveCheckRequest struct {
+	Name        string                  `json:"name" validate:"required"`
+	Description string                  `json:"description,omitempty"`
+	Steps       []session.RunStep       `json:"steps" validate:"required_without=Seed,max=100"` // As for POST /sessions/{id}/run
+	Seed        *session.CheckSeed      `json:"seed,omitempty"`                                 // Sitemap or feed whose changed pages the steps run on
+	Template    string                  `json:"template,omitempty"`
+	Options     *session.SessionOptions `json:"options,omitempty"`
+	IntervalMS  int                     `json:"interval_ms,omitempty" validate:"min=0"` // Default 5 minutes, at least 30 seconds
+	Alert       *session.CheckAlert     `json:"alert,omitempty"`
+	Paused      bool                    `json:"paused,omitempty"`
+}
+
+// ListChecksResponse returned with the caller's checks
+type ListChecksResponse struct {
+	Checks []*session.CheckReport `json:"checks"`
+	Count  int                    `json:"count"`
+}
+
+
+// VisualDiffRequest for POST /sessions/{id}/pages/{pageId}/visual-diff
+type VisualDiffRequest struct {
+	Baseline       string          `json:"baseline" validate:"required"`                        // Name of the tenant's baseline; the first capture under a name becomes it
+	Threshold      float64         `json:"threshold,omitempty" validate:"min=0,max=1"`          // Per-pixel sensitivity (default 0.1)
+	MaxDiffPercent float64         `json:"max_diff_percent,omitempty" validate:"min=0,max=100"` // Changed pixels allowed for the comparison to pass
+	Ignore         []visual.Region `json:"ignore,omitempty"`                                    // Regions left out of the comparison
+	Update         bool            `json:"update,omitempty"`                                    // Replace the baseline with this capture
+}
+
+// VisualDiffResponse returned with the comparison and, when there was one, the diff image
+type VisualDiffResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.VisualComparison
+	DiffImage string `json:"diff_image,omitempty"` // Base64 PNG: changes in red, ignored regions in blue
+}
+
+// ListBaselinesResponse returned with the caller's visual baselines
+type ListBaselinesResponse struct {
+	Baselines []visual.Baseline `json:"baselines"`
+	Count     int               `json:"count"`
+}
+
+// ExpandSeedRequest for POST /seeds/expand
+type ExpandSeedRequest struct {
+	URL   string     `json:"url" validate:"required"`                    // Sitemap, sitemap index, RSS or Atom feed
+	Since *time.Time `json:"since,omitempty"`                            // Only pages modified after this
+	Limit int        `json:"limit,omitempty" validate:"min=0,max=50000"` // Newest pages kept (0: all)
+}
+
+// ExpandSeedResponse returned with a seed's pages, newest first
+type ExpandSeedResponse struct {
+	URL string `json:"url"`
+	*seeds.Expansion
+	Count int `json:"count"`
+}
+
+// SearchRequest for POST /search
+type SearchRequest struct {
+	Query    string `json:"query" validate:"required,max=2048"`
+	Count    int    `json:"count,omitempty" validate:"min=0,max=50"` // Results wanted (0: 10)
+	Language string `json:"language,omitempty"`                      // Interface language, e.g. "en"
+	Region   string `json:"region,omitempty"`                        // Country the results are for, e.g. "us"
+}
+
+// SearchResponse returned with a query's organic results, best first
+type SearchResponse struct {
+	Query   string          `json:"query"`
+	Backend string          `json:"backend"`
+	Results []search.Result `json:"results"`
+	Count   int             `json:"count"`
+}
+
+// ResearchRequest for POST /research
+type ResearchRequest struct {
+	Question  string `json:"question" validate:"required,max=2048"`
+	Sources   int    `json:"sources,omitempty" validate:"min=0,max=8"` // Search results read (0: 4)
+	Language  string `json:"language,omitempty"`
+	Region    string `json:"region,omitempty"`
+	TimeoutMS int    `json:"timeout_ms,omitempty" validate:"min=0,max=600000"`
+	Stream    bool   `json:"stream,omitempty"`   // Send the answer as Server-Sent Events while it is written
+	NoCache   bool   `json:"no_cache,omitempty"` // Ask the model even when it answered from the same passages before
+}
+
+// ResearchResponse returned with the synthesized answer and its sources
+type ResearchResponse struct {
+	*session.ResearchResult
+}
+
+// ListProfilesResponse returned with the caller's persistent profiles
+type ListProfilesResponse struct {
+	Profiles []pool.ProfileInfo `json:"profiles"`
+	Count    int                `json:"count"`
+}
+
+// ListExtensionsResponse returned with the extensions browsers may load
+type ListExtensionsResponse struct {
+	Extensions []browser.Extension `json:"extensions"`
+	Count      int                 `json:"count"`
+}
+
+// ListPipelinesResponse returned with all result pipelines
+type ListPipelinesResponse struct {
+	Pipelines []*pipeline.Spec `json:"pipelines"`
+	Count     int              `json:"count"`
+}
+
+// ExtractRequest for POST /sessions/{id}/extract
+type ExtractRequest struct {
+	PageID   string `json:"page_id" validate:"required"`
+	Script   string `json:"script" validate:"required"` // Its result (an array is one record per element) feeds the pipeline
+	Pipeline string `json:"pipeline,omitempty"`         // Default: the session's pipeline option
+}
+
+// RunRequest for POST /sessions/{id}/run. Steps run in order on page_id, or on the page
+// the first navigate step opens when it is left out.
+type RunRequest struct {
+	PageID string            `json:"page_id,omitempty"`
+	Steps  []session.RunStep `json:"steps" validate:"required,min=1,max=100"`
+}
+
+// RunResponse returned when a run ends, whether or not every step succeeded
+type RunResponse struct {
+	SessionID string `json:"session_id"`
+	*session.RunResult
+}
+
+// RunPipelineRequest for POST /pipelines/{name}/run
+type RunPipelineRequest struct {
+	SessionID string        `json:"session_id,omitempty"` // Scopes deduplication and labels the batch
+	Records   []interface{} `json:"records" validate:"required"`
+}
+
+// MetricsResponse returned by GET /metrics
+type MetricsResponse struct {
+	pool.PoolMetrics
+	WarmPool *session.WarmPoolStats `json:"warm_pool,omitempty"`
+
+	Connections []session.ConnectionStats `json:"cdp_connections"` // Command queue of each browser connection
+	Admission   pool.AdmissionStats       `json:"admission"`       // Session creations waiting for capacity
+	Bandwidth   session.BandwidthStats    `json:"bandwidth"`       // What metered sessions downloaded since the start
+	AnswerCache session.AnswerCacheStats  `json:"answer_cache"`    // Model answers served from the cache since the start
+}
+
+// StatusResponse returned by GET /status
+type StatusResponse struct {
+	Status    string                 `json:"status"` // ok, degraded when a browser process is down or its circuit is open, or draining
+	Uptime    time.Duration          `json:"uptime"`
+	Sessions  int                    `json:"sessions"`
+	Processes []ProcessStatus        `json:"processes"`
+	Ports     browser.PortStats      `json:"ports"`
+	WarmPool  *session.WarmPoolStats `json:"warm_pool,omitempty"`
+	Drain     *session.DrainStatus   `json:"drain,omitempty"`
+}
+
+// DiagnosticsResponse returned by GET /admin/diagnostics
+type DiagnosticsResponse struct {
+	Goroutines  int                       `json:"goroutines"`
+	Sessions    int                       `json:"sessions"`
+	Connections []session.ConnectionStats `json:"connections"` // Per browser, with pending commands
+	Events      EventBusDiagnostics       `json:"events"`
+	Memory      MemoryDiagnostics         `json:"memory"`
+}
+
+// EventBusDiagnostics describes the session event bus
+type EventBusDiagnostics struct {
+	Sessions      int    `json:"sessions"`      // Sessions with retained history
+	Subscriptions int    `json:"subscriptions"` // Per-session subscriptions
+	Queued        int    `json:"queued"`        // Events delivered but not read yet
+	Fullest       int    `json:"fullest"`       // Of those, the most waiting on one subscription
+	Dropped       uint64 `json:"dropped"`       // Not delivered to slow subscribers
+}
+
+// MemoryDiagnostics reports the Go heap and garbage collector
+type MemoryDiagnostics struct {
+	HeapAlloc    uint64        `json:"heap_alloc"` // Bytes
+	HeapObjects  uint64        `json:"heap_objects"`
+	Sys          uint64        `json:"sys"`      // Bytes obtained from the OS
+	NextGC       uint64        `json:"next_gc"`  // Heap size of the next collection
+	NumGC        uint32        `json:"num_gc"`
+	LastGC       *time.Time    `json:"last_gc,omitempty"`
+	PauseTotal   time.Duration `json:"pause_total"`
+	GCCPUPercent float64       `json:"gc_cpu_percent"` // Share of CPU time spent collecting since startup
+}
+
+// ProcessStatus describes one browser process in GET /status
+type ProcessStatus struct {
+	pool.ProcessMetrics
+	Healthy bool `json:"healthy"`
+}
+
+// AdminProcessInfo describes a browser process in GET /admin/processes
+type AdminProcessInfo struct {
+	ProcessStatus
+	SessionIDs []string `json:"session_ids"`
+}
+
+// AdminListProcessesResponse returned by GET /admin/processes
+type AdminListProcessesResponse struct {
+	Processes []AdminProcessInfo `json:"processes"`
+	Count     int                `json:"count"`
+}
+
+// AdminRestartRequest for POST /admin/processes/{port}/restart
+type AdminRestartRequest struct {
+	Force bool `json:"force,omitempty"` // Migrate sessions immediately instead of draining first
+}
+
+// AdminResizeRequest for PUT /admin/pool
+type AdminResizeRequest struct {
+	Size  int  `json:"size" validate:"required"`
+	Force bool `json:"force,omitempty"` // Migrate sessions off removed processes without draining
+}
+
+// AdminDrainRequest for POST /admin/drain
+type AdminDrainRequest struct {
+	TimeoutMS int `json:"timeout_ms,omitempty" validate:"min=0"` // Wait for sessions this long before shutting down (default DRAIN_TIMEOUT)
+}
+
+// AdminOperationResponse returned when an admin operation is accepted
+type AdminOperationResponse struct {
+	Operation string `json:"operation"`
+	Status    string `json:"status"` // accepted (runs in the background) or done
+	Port      int    `json:"port,omitempty"`
+	Size      int    `json:"size,omitempty"`
+}
+
+// TenantResponse returned by GET /tenant: the caller's tenant, quotas and usage.
+// API keys are never included.
+type TenantResponse struct {
+	TenantID         string               `json:"tenant_id"`
+	Name             string               `json:"name,omitempty"`
+	Role             tenant.Role          `json:"role"`                // What the calling key may do
+	MaxSessions      int                  `json:"max_sessions"`        // 0 means unlimited
+	MaxProcesses     int                  `json:"max_processes"`       // 0 means unlimited
+	MaxBandwidthMB   int                  `json:"max_bandwidth_mb"`    // Per UTC day, 0 means unlimited
+	MaxModelSpendUSD float64              `json:"max_model_spend_usd"` // Per UTC day, 0 means unlimited
+	DefaultTemplate  string               `json:"default_template,omitempty"`
+	ScriptPolicy     *tenant.ScriptPolicy `json:"script_policy,omitempty"`
+	Usage            session.TenantUsage  `json:"usage"`
+}
+
+// UsageResponse returned by GET /usage: what the caller's tenant spent on model calls,
+// today first
+type UsageResponse struct {
+	TenantID         string                  `json:"tenant_id"`
+	MaxModelSpendUSD float64                 `json:"max_model_spend_usd"` // Per UTC day, 0 means unlimited
+	Days             []session.ModelUsageDay `json:"days"`
+}
+
+// CreateCheckpointRequest for POST /sessions/{id}/checkpoints
+type CreateCheckpointRequest struct {
+	Label string `json:"label,omitempty"`
+}
+
+// ListCheckpointsResponse returned with all checkpoints of a session, oldest first
+type ListCheckpointsResponse struct {
+	SessionID   string                `json:"session_id"`
+	Checkpoints []session.Checkpoint `json:"checkpoints"`
+	Count       int                  `json:"count"`
+}
+
+// MemoryResponse returned with what a session remembers for follow-up questions
+type MemoryResponse struct {
+	SessionID string `json:"session_id"`
+	session.Memory
+}
+
+// WriteStorageRequest for PUT /sessions/{id}/storage; clear runs first, then remove, then set
+type WriteStorageRequest struct {
+	PageID string            `json:"page_id" validate:"required"`         // Page the storage is reached through
+	Origin string            `json:"origin,omitempty"`                    // Default the page's
+	Area   string            `json:"area" validate:"oneof=local session"` // sessionStorage is the page's tab's
+	Set    map[string]string `json:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+	Clear  bool              `json:"clear,omitempty"`
+}
+
+// StorageResponse returned with what an origin keeps in a session's browser
+type StorageResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.WebStorage
+}
+
+// ServiceWorkersResponse returned with the service worker registrations of a session's browser context
+type ServiceWorkersResponse struct {
+	SessionID      string                  `json:"session_id"`
+	PageID         string                  `json:"page_id"`
+	ServiceWorkers []session.ServiceWorker `json:"service_workers"`
+	Count          int                     `json:"count"`
+}
+
+// UnregisterServiceWorkersResponse returned with the service workers unregistered
+type UnregisterServiceWorkersResponse struct {
+	SessionID    string                  `json:"session_id"`
+	PageID       string                  `json:"page_id"`
+	Unregistered []session.ServiceWorker `json:"unregistered"`
+	Count        int                     `json:"count"`
+}
+
+// CacheStorageResponse returned with an origin's caches, or with those deleted from it
+type CacheStorageResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.CacheStorage
+}
+
+// WebSocketTrafficResponse returned with a page's WebSocket sockets and the frames matching the filter
+type WebSocketTrafficResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.WebSocketTraffic
+}
+
+// PageSecurityResponse returned with a page's security state and certificate
+type PageSecurityResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.PageSecurity
+}
+
+// StreamTrafficResponse returned with a page's event streams and streamed responses and the messages matching the filter
+type StreamTrafficResponse struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	*session.StreamTraffic
+}
+
+// EgressResponse returned with a session's egress policy and the traffic it sent through the proxy
+type EgressResponse struct {
+	SessionID string `json:"session_id"`
+	*session.EgressReport
+}
+
+// BandwidthResponse returned with what a session's pages downloaded
+type BandwidthResponse struct {
+	SessionID string `json:"session_id"`
+	*session.BandwidthReport
+}
+
+// SessionLogsResponse returned with a session's recent log lines, oldest first
+type SessionLogsResponse struct {
+	SessionID string            `json:"session_id"`
+	Lines     []sessionlog.Line `json:"lines"`
+	Count     int               `json:"count"`
+}
+
+// ToolsResponse returned with the tool manifest
+type ToolsResponse struct {
+	Tools []Tool `json:"tools"`
+	Count int    `json:"count"`
+}
+
+// ToolCall is an OpenAI-style tool call
+type ToolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"` // "function"
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the tool called; arguments are an object or a string holding one
+type ToolCallFunction struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// InvokeToolsRequest for POST /tools/invoke: a list of calls, an assistant message
+// carrying them, or a single call
+type InvokeToolsRequest struct {
+	ToolCalls []ToolCall      `json:"tool_calls,omitempty"`
+	Role      string          `json:"role,omitempty"`    // Set when a whole assistant message is posted
+	Content   json.RawMessage `json:"content,omitempty"` // The assistant message's text, ignored
+	ToolCall
+}
+
+// ToolResult is one call's response, shaped as an OpenAI tool message
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Role       string `json:"role"` // "tool"
+	Name       string `json:"name"`
+	Content    string `json:"content"` // The route's JSON response
+	Status     int    `json:"status"`  // The route's HTTP status
+	IsError    bool   `json:"is_error"`
+}
+
+// InvokeToolsResponse returned with the results of the calls, in order
+type InvokeToolsResponse struct {
+	Results []ToolResult `json:"results"`
+}
+
+// BranchSessionRequest for POST /sessions/{id}/checkpoints/{checkpointId}/branch
+type BranchSessionRequest struct {
+	SessionName string `json:"session_name,omitempty"`
+	BrowserPort int    `json:"browser_port,omitempty"` // Browser to branch onto (default: load balanced)
+}
+
+// BranchSessionResponse returned for a session branched from a checkpoint
+type BranchSessionResponse struct {
+	SessionID    string    `json:"session_id"`
+	SessionName  string    `json:"session_name"`
+	AgentID      string    `json:"agent_id"`
+	ContextID    string    `json:"context_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	FromSession  string    `json:"from_session"`
+	CheckpointID string    `json:"checkpoint_id"`
+	PageIDs      []string  `json:"page_ids"` // The checkpoint's pages, in its order
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// requestValidator checks the validate tags on request types. Fields are reported by
+// their JSON names, which is what clients send.
+var requestValidator = newRequestValidator()
+
+func newRequestValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Violation is one rule a request field broke
+type Violation struct {
+	Field   string `json:"field"`           // JSON path, e.g. "options.viewport_width"
+	Rule    string `json:"rule"`            // Validation tag that failed, e.g. "required" or "oneof"
+	Param   string `json:"param,omitempty"` // The rule's argument, e.g. "png jpeg" for oneof
+	Message string `json:"message"`
+}
+
+// validateRequest checks req against its validate tags, writing a 422 listing every
+// violation when it fails. It reports whether req is valid.
+func validateRequest(w http.ResponseWriter, req interface{}) bool {
+	err := requestValidator.Struct(req)
+	if err == nil {
+		return true
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		// Only a non-struct target gets here, which is a programming error
+		writeError(w, http.StatusInternalServerError, ErrCodeInternalError, err.Error())
+		return false
+	}
+
+	violations := make([]Violation, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		violations = append(violations, newViolation(fieldErr))
+	}
+
+	message := violations[0].Message
+	if len(violations) > 1 {
+		message = fmt.Sprintf("%s (and %d more)", message, len(violations)-1)
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+		Error: ErrorDetail{
+			Code:       ErrCodeValidationFailed,
+			Message:    message,
+			Violations: violations,
+		},
+	})
+	return false
+}
+
+// newViolation describes a failed rule in terms of the request's JSON
+func newViolation(fieldErr validator.FieldError) Violation {
+	// The namespace starts with the Go type name, which means nothing to a client
+	_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+	param := fieldErr.Param()
+
+	var message string
+	switch fieldErr.Tag() {
+	case "required":
+		message = field + " is required"
+	case "required_without":
+		// The parameter is a Go field name; request fields are single words, so lower case is their JSON name
+		message = fmt.Sprintf("%s is required unless %s is set", field, strings.ToLower(param))
+	case "url", "http_url":
+		message = field + " must be an absolute URL"
+	case "oneof":
+		message = fmt.Sprintf("%s must be one of %s", field, strings.ReplaceAll(param, " ", ", "))
+	case "min", "gte":
+		message = fmt.Sprintf("%s must be at least %s%s", field, param, sizeUnit(fieldErr))
+	case "max", "lte":
+		message = fmt.Sprintf("%s must be at most %s%s", field, param, sizeUnit(fieldErr))
+	default:
+		message = fmt.Sprintf("%s failed the %s rule", field, fieldErr.Tag())
+	}
+
+	return Violation{Field: field, Rule: fieldErr.Tag(), Param: param, Message: message}
+}
+
+// sizeUnit says what a min or max counts when it isn't a number's value
+func sizeUnit(fieldErr validator.FieldError) string {
+	switch fieldErr.Kind() {
+	case reflect.String:
+		return " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// validateOptions is nested in validateTarget, so violations carry a JSON path
+type validateOptions struct {
+	Format string   `json:"format,omitempty" validate:"omitempty,oneof=png jpeg"`
+	Labels []string `json:"labels,omitempty" validate:"max=2"`
+}
+
+type validateTarget struct {
+	Name    string          `json:"name" validate:"required"`
+	Query   string          `json:"query,omitempty" validate:"max=8"`
+	Count   int             `json:"count,omitempty" validate:"min=0,max=50"`
+	Steps   []string        `json:"steps" validate:"min=1,dive,required"`
+	Options validateOptions `json:"options"`
+}
+
+// TestValidateRequest tests that every broken rule is listed in the 422 by its JSON path,
+// with a message saying what the field must be
+func TestValidateRequest(t *testing.T) {
+	body := `{
+		"query": "chromium devtools",
+		"count": 51,
+		"steps": ["open", ""],
+		"options": {"format": "gif", "labels": ["a", "b", "c"]}
+	}`
+	w := httptest.NewRecorder()
+	var req validateTarget
+	if decodeJSON(w, httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(body)), &req) {
+		t.Fatal("expected the request refused")
+	}
+
+	var response ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	if w.Code != http.StatusUnprocessableEntity || response.Error.Code != ErrCodeValidationFailed {
+		t.Fatalf("expected 422 %s, got %d %s", ErrCodeValidationFailed, w.Code, w.Body.String())
+	}
+
+	want := []Violation{
+		{Field: "name", Rule: "required", Message: "name is required"},
+		{Field: "query", Rule: "max", Param: "8", Message: "query must be at most 8 characters long"},
+		{Field: "count", Rule: "max", Param: "50", Message: "count must be at most 50"},
+		{Field: "steps[1]", Rule: "required", Message: "steps[1] is required"},
+		{Field: "options.format", Rule: "oneof", Param: "png jpeg", Message: "options.format must be one of png, jpeg"},
+		{Field: "options.labels", Rule: "max", Param: "2", Message: "options.labels must be at most 2 items"},
+	}
+	if !reflect.DeepEqual(response.Error.Violations, want) {
+		t.Errorf("unexpected violations:\n got %+v\nwant %+v", response.Error.Violations, want)
+	}
+	if response.Error.Message != "name is required (and 5 more)" {
+		t.Errorf("unexpected message %q", response.Error.Message)
+	}
+
+	// A minimum on a list counts its items
+	w = httptest.NewRecorder()
+	if decodeJSON(w, httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(`{"name": "a", "steps": []}`)), &validateTarget{}) {
+		t.Fatal("expected an empty list refused")
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Error.Message != "steps must be at least 1 items" || response.Error.Violations[0].Field != "steps" {
+		t.Errorf("unexpected error %+v", response.Error)
+	}
+
+	// Real request types are reported the same way
+	w = httptest.NewRecorder()
+	if decodeJSON(w, httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(`{"query": "", "count": -1}`)), &SearchRequest{}) {
+		t.Fatal("expected the search refused")
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.Error.Violations) != 2 || response.Error.Violations[1].Message != "count must be at least 0" {
+		t.Errorf("unexpected search violations %+v", response.Error.Violations)
+	}
+}
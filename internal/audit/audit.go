@@ -0,0 +1,181 @@
+// Package audit records mutating API calls for compliance. Records are kept apart
+// from operational logs: they go only to the configured sinks and are never dropped.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Outcomes of an audited call
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// AccessShared marks a call made on another tenant's session through a read-only share
+const AccessShared = "shared"
+
+// sinkWriteTimeout bounds a single write to one sink
+const sinkWriteTimeout = 5 * time.Second
+
+// queueSize is how many records may wait for the sinks before Log blocks
+const queueSize = 1024
+
+// Record is one audited API call. Request and response bodies are never included.
+type Record struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Actor      string    `json:"actor"`            // Agent ID, "admin" or "anonymous"
+	Tenant     string    `json:"tenant,omitempty"` // Tenant ID when multi-tenancy is on
+	Remote     string    `json:"remote"`           // Client address
+	Action     string    `json:"action"`           // Route, e.g. "POST /sessions/{id}/navigate"
+	SessionID  string    `json:"session_id,omitempty"`
+	Access     string    `json:"access,omitempty"` // AccessShared when another tenant used a read-only share
+	Status     int       `json:"status"`           // HTTP status code
+	Outcome    string    `json:"outcome"`          // success or failure
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// Fields flattens the record into string pairs, for sinks with flat entries
+func (r Record) Fields() map[string]interface{} {
+	return map[string]interface{}{
+		"time":        r.Time.Format(time.RFC3339Nano),
+		"request_id":  r.RequestID,
+		"actor":       r.Actor,
+		"tenant":      r.Tenant,
+		"remote":      r.Remote,
+		"action":      r.Action,
+		"session_id":  r.SessionID,
+		"access":      r.Access,
+		"status":      strconv.Itoa(r.Status),
+		"outcome":     r.Outcome,
+		"duration_ms": strconv.FormatInt(r.DurationMS, 10),
+	}
+}
+
+// Sink stores audit records. Write is called from a single goroutine.
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+	Close() error
+}
+
+// Logger hands records to its sinks on a background goroutine, so a slow sink delays
+// requests only once the queue is full. A nil *Logger discards everything.
+type Logger struct {
+	sinks   []Sink
+	records chan Record
+	done    chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewLogger starts a logger writing to sinks
+func NewLogger(sinks ...Sink) *Logger {
+	l := &Logger{
+		sinks:   sinks,
+		records: make(chan Record, queueSize),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Log queues a record. It blocks while the queue is full rather than lose the record.
+func (l *Logger) Log(record Record) {
+	if l == nil {
+		return
+	}
+	l.records <- record
+}
+
+// run writes every queued record to every sink
+func (l *Logger) run() {
+	defer close(l.done)
+
+	for record := range l.records {
+		for _, sink := range l.sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), sinkWriteTimeout)
+			if err := sink.Write(ctx, record); err != nil {
+				slog.Error("failed to write audit record",
+					"sink", fmt.Sprintf("%T", sink),
+					"action", record.Action,
+					"error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Close writes out the queued records and closes the sinks. Log must not be called afterwards.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.closeOnce.Do(func() {
+		close(l.records)
+		<-l.done
+
+		errs := make([]error, 0)
+		for _, sink := range l.sinks {
+			if err := sink.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		l.closeErr = errors.Join(errs...)
+	})
+	return l.closeErr
+}
+
+// pendingKey is the context key of the record being built for a request
+type pendingKey struct{}
+
+// WithPending returns a context carrying record, which handlers can fill in with SetSession
+func WithPending(ctx context.Context, record *Record) context.Context {
+	return context.WithValue(ctx, pendingKey{}, record)
+}
+
+// SetActor names who made the current request, when it isn't a session's agent
+func SetActor(ctx context.Context, actor string) {
+	SetSession(ctx, "", actor)
+}
+
+// SetSession names the session and acting agent of the current request's audit record.
+// Handlers call it when the middleware can't work them out, e.g. for a session that was
+// just created or destroyed. It does nothing for unaudited requests.
+func SetSession(ctx context.Context, sessionID string, agentID string) {
+	record, ok := ctx.Value(pendingKey{}).(*Record)
+	if !ok {
+		return
+	}
+	if sessionID != "" {
+		record.SessionID = sessionID
+	}
+	if agentID != "" {
+		record.Actor = agentID
+	}
+}
+
+// SetAccess records how the current request reached its session. It reports false
+// when the request isn't being audited.
+func SetAccess(ctx context.Context, access string) bool {
+	record, ok := ctx.Value(pendingKey{}).(*Record)
+	if ok {
+		record.Access = access
+	}
+	return ok
+}
+
+// SetTenant names the tenant the current request was authenticated as
+func SetTenant(ctx context.Context, tenantID string) {
+	if record, ok := ctx.Value(pendingKey{}).(*Record); ok {
+		record.Tenant = tenantID
+	}
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySink collects records, optionally failing every write
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+	fail    bool
+	closed  bool
+}
+
+func (s *memorySink) Write(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return errors.New("sink unavailable")
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memorySink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// TestLoggerWritesEverySink tests that records reach every sink in order, that a failing
+// sink doesn't stop the others, and that Close flushes the queue
+func TestLoggerWritesEverySink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	fileSink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("failed to open file sink: %v", err)
+	}
+	memory := &memorySink{}
+	broken := &memorySink{fail: true}
+
+	logger := NewLogger(broken, fileSink, memory)
+	for _, action := range []string{"POST /sessions", "DELETE /sessions/{id}"} {
+		logger.Log(Record{Time: time.Now(), Actor: "agent-1", Action: action, Status: 200, Outcome: OutcomeSuccess})
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if len(memory.records) != 2 || memory.records[1].Action != "DELETE /sessions/{id}" {
+		t.Fatalf("unexpected records in memory sink: %+v", memory.records)
+	}
+	if !memory.closed || !broken.closed {
+		t.Error("expected sinks to be closed")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %d is not a record: %v", lines+1, err)
+		}
+		if record.Actor != "agent-1" {
+			t.Errorf("unexpected actor %q", record.Actor)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected 2 lines in the audit log, got %d", lines)
+	}
+
+	// Logging to a nil logger is a no-op
+	var disabled *Logger
+	disabled.Log(Record{})
+	if err := disabled.Close(); err != nil {
+		t.Errorf("closing a nil logger failed: %v", err)
+	}
+}
+
+// TestSetSession tests that handlers can fill in the pending record
+func TestSetSession(t *testing.T) {
+	record := &Record{}
+	ctx := WithPending(context.Background(), record)
+
+	SetActor(ctx, "admin")
+	SetSession(ctx, "sess_1", "")
+	if record.Actor != "admin" || record.SessionID != "sess_1" {
+		t.Errorf("unexpected record: %+v", record)
+	}
+
+	// Unaudited requests carry no record
+	SetSession(context.Background(), "sess_2", "agent-2")
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink publishes each record as a JSON message. Messages are keyed by session ID,
+// so the records of one session stay in order on one partition.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink publishes to topic on brokers, waiting for all in-sync replicas
+func NewKafkaSink(brokers []string, topic string) *KafkaSink {
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond, // Records arrive one at a time
+		},
+	}
+}
+
+// Write publishes one record
+func (s *KafkaSink) Write(ctx context.Context, record Record) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	message := kafka.Message{Value: value, Time: record.Time}
+	// Without a key the balancer spreads records that have no session
+	if record.SessionID != "" {
+		message.Key = []byte(record.SessionID)
+	}
+	if err := s.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to publish audit record: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the connections
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+)
+
+// SQLExecutor runs a statement against a database (implemented by storage.PostgresStore)
+type SQLExecutor interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) error
+}
+
+// PostgresSink inserts each record into the audit_log table
+type PostgresSink struct {
+	db SQLExecutor
+}
+
+// NewPostgresSink writes to the audit_log table created by the store's migrations
+func NewPostgresSink(db SQLExecutor) *PostgresSink {
+	return &PostgresSink{db: db}
+}
+
+// Write inserts one record
+func (s *PostgresSink) Write(ctx context.Context, record Record) error {
+	err := s.db.Exec(ctx, `INSERT INTO audit_log
+		(time, request_id, actor, tenant, remote, action, session_id, access, status, outcome, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		record.Time, record.RequestID, record.Actor, record.Tenant, record.Remote, record.Action,
+		record.SessionID, record.Access, record.Status, record.Outcome, record.DurationMS)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit record: %w", err)
+	}
+	return nil
+}
+
+// Close does nothing; the database pool is shared and closed by its owner
+func (s *PostgresSink) Close() error {
+	return nil
+}
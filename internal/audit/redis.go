@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+)
+
+// StreamAppender adds entries to a Redis stream (implemented by storage.RedisClient)
+type StreamAppender interface {
+	AppendToStream(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) error
+}
+
+// RedisSink adds each record to a Redis stream as a flat entry
+type RedisSink struct {
+	redis  StreamAppender
+	stream string
+	maxLen int64 // Approximate cap on stream length; 0 keeps everything
+}
+
+// NewRedisSink writes to stream, trimming it to about maxLen entries when maxLen > 0
+func NewRedisSink(redis StreamAppender, stream string, maxLen int64) *RedisSink {
+	return &RedisSink{redis: redis, stream: stream, maxLen: maxLen}
+}
+
+// Write adds one record to the stream
+func (s *RedisSink) Write(ctx context.Context, record Record) error {
+	if err := s.redis.AppendToStream(ctx, s.stream, s.maxLen, record.Fields()); err != nil {
+		return fmt.Errorf("failed to add audit record to stream %s: %w", s.stream, err)
+	}
+	return nil
+}
+
+// Close does nothing; the Redis connection is shared and closed by its owner
+func (s *RedisSink) Close() error {
+	return nil
+}
@@ -0,0 +1,276 @@
+// Package bidi is a WebDriver BiDi client, the protocol Firefox speaks where Chromium
+// speaks CDP. It covers the commands sessions of a non-Chromium engine need.
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultTimeout bounds a command whose caller set no deadline
+const DefaultTimeout = 30 * time.Second
+
+// ErrClosed is returned for commands on a client whose connection is gone
+var ErrClosed = errors.New("bidi connection closed")
+
+// Error is an error reported by the browser for a command
+type Error struct {
+	Code    string `json:"error"` // e.g. "no such frame", "invalid argument"
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// command is a message sent to the browser
+type command struct {
+	ID     int                    `json:"id"`
+	Method string                 `json:"method"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// message is anything the browser sends: a response to a command or an event
+type message struct {
+	Type    string          `json:"type"` // "success", "error" or "event"
+	ID      *int            `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Code    string          `json:"error"`
+	Message string          `json:"message"`
+}
+
+// Event is an event the browser sent
+type Event struct {
+	Method string
+	Params json.RawMessage
+}
+
+// Client is a WebDriver BiDi connection to one browser. A browser serves a single BiDi
+// session, so every caller shares the client and isolates itself in a user context.
+type Client struct {
+	conn *websocket.Conn
+
+	writeMu sync.Mutex // The WebSocket allows one writer at a time
+
+	mu        sync.Mutex
+	nextID    int
+	pending   map[int]chan *message
+	listeners map[int]listener
+	closed    bool
+	done      chan struct{} // Closed when the connection ends
+}
+
+// listener receives the events of one method
+type listener struct {
+	method  string
+	handler func(Event)
+}
+
+// Dial connects to a browser's BiDi endpoint, such as ws://localhost:9222/session, and
+// starts a session on it
+func Dial(ctx context.Context, wsURL string) (*Client, error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to BiDi endpoint: %w", err)
+	}
+
+	c := &Client{
+		conn:      conn,
+		pending:   make(map[int]chan *message),
+		listeners: make(map[int]listener),
+		done:      make(chan struct{}),
+	}
+	go c.readLoop()
+
+	if _, err := c.Send(ctx, "session.new", map[string]interface{}{"capabilities": map[string]interface{}{}}); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to start BiDi session: %w", err)
+	}
+
+	slog.Info("BiDi session started", "url", wsURL)
+	return c, nil
+}
+
+// readLoop hands responses to their commands and events to their listeners until the
+// connection ends
+func (c *Client) readLoop() {
+	defer c.shutdown()
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.mu.Lock()
+			closed := c.closed
+			c.mu.Unlock()
+			if !closed {
+				slog.Warn("BiDi connection dropped", "error", err)
+			}
+			return
+		}
+
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			slog.Warn("unreadable BiDi message", "error", err)
+			continue
+		}
+
+		if msg.Type == "event" {
+			c.dispatch(Event{Method: msg.Method, Params: msg.Params})
+			continue
+		}
+		if msg.ID == nil {
+			// Errors the browser can't tie to a command, such as an unparsable one
+			slog.Warn("BiDi error without a command", "error", msg.Code, "message", msg.Message)
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- &msg
+		}
+	}
+}
+
+// dispatch calls the listeners of an event's method
+func (c *Client) dispatch(event Event) {
+	c.mu.Lock()
+	var handlers []func(Event)
+	for _, l := range c.listeners {
+		if l.method == event.Method {
+			handlers = append(handlers, l.handler)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// OnEvent calls handler for every event of method the session is subscribed to, and
+// returns a function that stops it. See Subscribe.
+func (c *Client) OnEvent(method string, handler func(Event)) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	id := c.nextID
+	c.listeners[id] = listener{method: method, handler: handler}
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.listeners, id)
+	}
+}
+
+// Send sends a command and waits for its result, until ctx is done or, without a
+// deadline on ctx, DefaultTimeout passes
+func (c *Client) Send(ctx context.Context, method string, params map[string]interface{}) (json.RawMessage, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%s: %w", method, ErrClosed)
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	data, err := json.Marshal(command{ID: id, Method: method, Params: params})
+	if err != nil {
+		c.drop(id)
+		return nil, fmt.Errorf("failed to marshal %s: %w", method, err)
+	}
+
+	c.writeMu.Lock()
+	err = c.conn.WriteMessage(websocket.TextMessage, data)
+	c.writeMu.Unlock()
+	if err != nil {
+		c.drop(id)
+		return nil, fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", method, ErrClosed)
+		}
+		if msg.Type == "error" {
+			return nil, fmt.Errorf("%s: %w", method, &Error{Code: msg.Code, Message: msg.Message})
+		}
+		return msg.Result, nil
+	case <-ctx.Done():
+		c.drop(id)
+		return nil, fmt.Errorf("%s: %w", method, ctx.Err())
+	}
+}
+
+// drop forgets a command whose response is no longer awaited
+func (c *Client) drop(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, id)
+}
+
+// Done is closed when the connection ends
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// IsConnected reports whether the connection is still up
+func (c *Client) IsConnected() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close ends the connection, and with it the BiDi session
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.Close()
+}
+
+// shutdown fails the commands still waiting once the connection is gone
+func (c *Client) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	close(c.done)
+}
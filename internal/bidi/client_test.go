@@ -0,0 +1,200 @@
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeBrowser serves a BiDi endpoint, answering each command through respond. A nil
+// result from respond leaves the command unanswered.
+func fakeBrowser(t *testing.T, respond func(conn *websocket.Conn, id int, method string, params json.RawMessage) interface{}) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var request struct {
+				ID     int             `json:"id"`
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			if request.Method == "session.new" {
+				conn.WriteJSON(map[string]interface{}{"type": "success", "id": request.ID, "result": map[string]interface{}{"sessionId": "s1"}})
+				continue
+			}
+			if response := respond(conn, request.ID, request.Method, request.Params); response != nil {
+				conn.WriteJSON(response)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/session"
+}
+
+// TestEvaluate tests that script results come back as plain JSON values and that
+// exceptions and protocol errors are told apart
+func TestEvaluate(t *testing.T) {
+	url := fakeBrowser(t, func(conn *websocket.Conn, id int, method string, params json.RawMessage) interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+		switch p.Expression {
+		case "value":
+			return json.RawMessage(`{"type":"success","id":` + itoa(id) + `,"result":{"type":"success","realm":"r1","result":
+				{"type":"object","value":[
+					["title",{"type":"string","value":"Example"}],
+					["count",{"type":"number","value":3}],
+					["ratio",{"type":"number","value":"NaN"}],
+					["tags",{"type":"array","value":[{"type":"string","value":"a"},{"type":"null"}]}],
+					["node",{"type":"node","sharedId":"n1"}]
+				]}}}`)
+		case "throw":
+			return json.RawMessage(`{"type":"success","id":` + itoa(id) + `,"result":{"type":"exception","exceptionDetails":{"text":"Error: boom"}}}`)
+		default:
+			return map[string]interface{}{"type": "error", "id": id, "error": "no such frame", "message": "Browsing context not found"}
+		}
+	})
+
+	client, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	value, err := client.Evaluate(context.Background(), "ctx1", "value")
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"title": "Example",
+		"count": float64(3),
+		"ratio": nil,
+		"tags":  []interface{}{"a", nil},
+		"node":  nil,
+	}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("expected %v, got %v", want, value)
+	}
+
+	var scriptErr *ScriptError
+	if _, err := client.Evaluate(context.Background(), "ctx1", "throw"); !errors.As(err, &scriptErr) || scriptErr.Text != "Error: boom" {
+		t.Errorf("expected the script's exception, got %v", err)
+	}
+
+	var protocolErr *Error
+	if _, err := client.Evaluate(context.Background(), "gone", "other"); !errors.As(err, &protocolErr) || protocolErr.Code != "no such frame" {
+		t.Errorf("expected a protocol error, got %v", err)
+	}
+}
+
+// TestCallFunction tests that arguments are passed as BiDi values and a thrown
+// exception keeps its stack
+func TestCallFunction(t *testing.T) {
+	var arguments []json.RawMessage
+	url := fakeBrowser(t, func(conn *websocket.Conn, id int, method string, params json.RawMessage) interface{} {
+		var p struct {
+			Arguments []json.RawMessage `json:"arguments"`
+		}
+		json.Unmarshal(params, &p)
+		arguments = p.Arguments
+		if len(p.Arguments) == 0 {
+			return json.RawMessage(`{"type":"success","id":` + itoa(id) + `,"result":{"type":"exception","exceptionDetails":{
+				"text":"TypeError: o is undefined","lineNumber":0,"columnNumber":12,
+				"stackTrace":{"callFrames":[{"functionName":"count","url":"","lineNumber":0,"columnNumber":12}]}}}}`)
+		}
+		return json.RawMessage(`{"type":"success","id":` + itoa(id) + `,"result":{"type":"success","realm":"r1","result":{"type":"number","value":2}}}`)
+	})
+
+	client, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	value, err := client.CallFunction(context.Background(), "ctx1", "(o) => o.items.length", []json.RawMessage{json.RawMessage(`{"items":["a",null]}`)})
+	if err != nil || value != float64(2) {
+		t.Fatalf("expected 2, got %v, %v", value, err)
+	}
+	want := `{"type":"object","value":[["items",{"type":"array","value":[{"type":"string","value":"a"},{"type":"null"}]}]]}`
+	if len(arguments) != 1 || string(arguments[0]) != want {
+		t.Errorf("expected %s, got %s", want, arguments)
+	}
+
+	var scriptErr *ScriptError
+	if _, err := client.CallFunction(context.Background(), "ctx1", "function count(o) { return o.length }", nil); !errors.As(err, &scriptErr) {
+		t.Fatalf("expected the script's exception, got %v", err)
+	}
+	if scriptErr.Column != 12 || len(scriptErr.Stack) != 1 || scriptErr.Stack[0].Function != "count" {
+		t.Errorf("unexpected exception: %+v", scriptErr)
+	}
+
+	if _, err := client.CallFunction(context.Background(), "ctx1", "() => 1", []json.RawMessage{json.RawMessage(`{`)}); err == nil {
+		t.Error("expected invalid JSON arguments refused")
+	}
+}
+
+// TestEventsAndClose tests that events reach their listeners and that a dropped
+// connection fails commands still waiting
+func TestEventsAndClose(t *testing.T) {
+	url := fakeBrowser(t, func(conn *websocket.Conn, id int, method string, params json.RawMessage) interface{} {
+		if method == "browsingContext.navigate" {
+			conn.WriteJSON(map[string]interface{}{"type": "event", "method": "browsingContext.load", "params": map[string]interface{}{"context": "ctx1"}})
+			return map[string]interface{}{"type": "success", "id": id, "result": map[string]interface{}{}}
+		}
+		conn.Close() // Drops the connection with the command unanswered
+		return nil
+	})
+
+	client, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	loads := make(chan Event, 1)
+	stop := client.OnEvent("browsingContext.load", func(event Event) { loads <- event })
+	defer stop()
+
+	if err := client.Navigate(context.Background(), "ctx1", "https://example.com"); err != nil {
+		t.Fatalf("Navigate failed: %v", err)
+	}
+	select {
+	case event := <-loads:
+		if !strings.Contains(string(event.Params), "ctx1") {
+			t.Errorf("expected the load event of ctx1, got %s", event.Params)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a load event")
+	}
+
+	if _, err := client.CreateTab(context.Background(), "uc1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed after the connection dropped, got %v", err)
+	}
+	select {
+	case <-client.Done():
+	case <-time.After(time.Second):
+		t.Error("expected Done to be closed")
+	}
+}
+
+func itoa(n int) string {
+	data, _ := json.Marshal(n)
+	return string(data)
+}
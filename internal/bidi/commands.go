@@ -0,0 +1,182 @@
+package bidi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// CreateUserContext creates a user context, BiDi's isolated set of cookies and storage
+// like a CDP browser context, and returns its ID
+func (c *Client) CreateUserContext(ctx context.Context) (string, error) {
+	result, err := c.Send(ctx, "browser.createUserContext", nil)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		UserContext string `json:"userContext"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return "", fmt.Errorf("failed to parse user context: %w", err)
+	}
+	return response.UserContext, nil
+}
+
+// RemoveUserContext closes a user context and every tab in it
+func (c *Client) RemoveUserContext(ctx context.Context, userContext string) error {
+	_, err := c.Send(ctx, "browser.removeUserContext", map[string]interface{}{"userContext": userContext})
+	return err
+}
+
+// CreateTab opens a blank tab in a user context and returns its browsing context ID
+func (c *Client) CreateTab(ctx context.Context, userContext string) (string, error) {
+	params := map[string]interface{}{"type": "tab"}
+	if userContext != "" {
+		params["userContext"] = userContext
+	}
+	result, err := c.Send(ctx, "browsingContext.create", params)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		Context string `json:"context"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return "", fmt.Errorf("failed to parse browsing context: %w", err)
+	}
+	return response.Context, nil
+}
+
+// CloseTab closes a browsing context
+func (c *Client) CloseTab(ctx context.Context, browsingContext string) error {
+	_, err := c.Send(ctx, "browsingContext.close", map[string]interface{}{"context": browsingContext})
+	return err
+}
+
+// Navigate loads url in a browsing context and waits for its load event
+func (c *Client) Navigate(ctx context.Context, browsingContext, url string) error {
+	_, err := c.Send(ctx, "browsingContext.navigate", map[string]interface{}{
+		"context": browsingContext,
+		"url":     url,
+		"wait":    "complete",
+	})
+	return err
+}
+
+// SetViewport sizes the viewport of a browsing context
+func (c *Client) SetViewport(ctx context.Context, browsingContext string, width, height int) error {
+	_, err := c.Send(ctx, "browsingContext.setViewport", map[string]interface{}{
+		"context":  browsingContext,
+		"viewport": map[string]interface{}{"width": width, "height": height},
+	})
+	return err
+}
+
+// CaptureScreenshot returns a PNG of a browsing context's viewport
+func (c *Client) CaptureScreenshot(ctx context.Context, browsingContext string) ([]byte, error) {
+	result, err := c.Send(ctx, "browsingContext.captureScreenshot", map[string]interface{}{"context": browsingContext})
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse screenshot: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(response.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	return data, nil
+}
+
+// ScriptError is a JavaScript exception thrown by an evaluated script
+type ScriptError struct {
+	Text   string
+	Line   int // Zero-based
+	Column int
+	Stack  []StackFrame // Innermost call first
+}
+
+// StackFrame is one call of a ScriptError's stack trace
+type StackFrame struct {
+	Function string `json:"functionName"`
+	URL      string `json:"url"`
+	Line     int    `json:"lineNumber"`
+	Column   int    `json:"columnNumber"`
+}
+
+func (e *ScriptError) Error() string {
+	return "script threw: " + e.Text
+}
+
+// Evaluate runs expression in a browsing context, awaiting a returned promise, and
+// returns the result as plain JSON values. An exception is returned as a *ScriptError.
+func (c *Client) Evaluate(ctx context.Context, browsingContext, expression string) (interface{}, error) {
+	result, err := c.Send(ctx, "script.evaluate", map[string]interface{}{
+		"expression":      expression,
+		"target":          map[string]interface{}{"context": browsingContext},
+		"awaitPromise":    true,
+		"resultOwnership": "none",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scriptResult(result)
+}
+
+// CallFunction calls the function declared by declaration in a browsing context with
+// args, each a JSON value, and returns the result like Evaluate
+func (c *Client) CallFunction(ctx context.Context, browsingContext, declaration string, args []json.RawMessage) (interface{}, error) {
+	arguments := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := LocalValue(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+		arguments[i] = value
+	}
+
+	result, err := c.Send(ctx, "script.callFunction", map[string]interface{}{
+		"functionDeclaration": declaration,
+		"arguments":           arguments,
+		"target":              map[string]interface{}{"context": browsingContext},
+		"awaitPromise":        true,
+		"resultOwnership":     "none",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scriptResult(result)
+}
+
+// scriptResult reads the result of script.evaluate or script.callFunction
+func scriptResult(result json.RawMessage) (interface{}, error) {
+	var response struct {
+		Type             string      `json:"type"` // "success" or "exception"
+		Result           RemoteValue `json:"result"`
+		ExceptionDetails struct {
+			Text         string `json:"text"`
+			LineNumber   int    `json:"lineNumber"`
+			ColumnNumber int    `json:"columnNumber"`
+			StackTrace   struct {
+				CallFrames []StackFrame `json:"callFrames"`
+			} `json:"stackTrace"`
+		} `json:"exceptionDetails"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse script result: %w", err)
+	}
+	if response.Type == "exception" {
+		details := response.ExceptionDetails
+		return nil, &ScriptError{
+			Text:   details.Text,
+			Line:   details.LineNumber,
+			Column: details.ColumnNumber,
+			Stack:  details.StackTrace.CallFrames,
+		}
+	}
+	return response.Result.Value(), nil
+}
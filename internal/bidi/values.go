@@ -0,0 +1,129 @@
+package bidi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// RemoteValue is a serialized JavaScript value, as BiDi returns script results
+type RemoteValue struct {
+	Type string          `json:"type"`
+	Raw  json.RawMessage `json:"value,omitempty"`
+}
+
+// Value converts the serialized value to what json.Unmarshal would produce for the
+// value's JSON form: numbers become float64, arrays []interface{}, objects and maps
+// map[string]interface{}. Values without a JSON form, such as functions and DOM nodes,
+// become nil, as they do from JSON.stringify.
+func (v RemoteValue) Value() interface{} {
+	switch v.Type {
+	case "string", "boolean", "date":
+		var value interface{}
+		json.Unmarshal(v.Raw, &value)
+		return value
+	case "number":
+		return v.number()
+	case "bigint":
+		var digits string
+		json.Unmarshal(v.Raw, &digits)
+		return digits
+	case "array", "set":
+		var items []RemoteValue
+		json.Unmarshal(v.Raw, &items)
+		values := make([]interface{}, len(items))
+		for i, item := range items {
+			values[i] = item.Value()
+		}
+		return values
+	case "object", "map":
+		var entries [][2]json.RawMessage
+		json.Unmarshal(v.Raw, &entries)
+		values := make(map[string]interface{}, len(entries))
+		for _, entry := range entries {
+			var item RemoteValue
+			if json.Unmarshal(entry[1], &item) != nil {
+				continue
+			}
+			values[entryKey(entry[0])] = item.Value()
+		}
+		return values
+	case "regexp":
+		return map[string]interface{}{}
+	default:
+		// null, undefined, and values JSON can't hold
+		return nil
+	}
+}
+
+// number decodes a number, whose special values BiDi sends as strings. Those JSON
+// can't hold become nil, as JSON.stringify makes them null.
+func (v RemoteValue) number() interface{} {
+	var value float64
+	if json.Unmarshal(v.Raw, &value) == nil {
+		return value
+	}
+	var special string
+	json.Unmarshal(v.Raw, &special)
+	if special == "-0" {
+		return math.Copysign(0, -1)
+	}
+	return nil
+}
+
+// entryKey returns a map or object key, which is a plain string for string keys and a
+// serialized value otherwise
+func entryKey(raw json.RawMessage) string {
+	var key string
+	if json.Unmarshal(raw, &key) == nil {
+		return key
+	}
+	var value RemoteValue
+	json.Unmarshal(raw, &value)
+	switch key := value.Value().(type) {
+	case string:
+		return key
+	case float64:
+		return strconv.FormatFloat(key, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(key)
+	default:
+		return value.Type
+	}
+}
+
+// LocalValue serializes a JSON value as BiDi takes script arguments
+func LocalValue(raw json.RawMessage) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("invalid JSON value: %w", err)
+	}
+	return localValue(value), nil
+}
+
+// localValue serializes a value decoded from JSON
+func localValue(value interface{}) map[string]interface{} {
+	switch value := value.(type) {
+	case string:
+		return map[string]interface{}{"type": "string", "value": value}
+	case float64:
+		return map[string]interface{}{"type": "number", "value": value}
+	case bool:
+		return map[string]interface{}{"type": "boolean", "value": value}
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = localValue(item)
+		}
+		return map[string]interface{}{"type": "array", "value": items}
+	case map[string]interface{}:
+		entries := make([]interface{}, 0, len(value))
+		for key, item := range value {
+			entries = append(entries, []interface{}{key, localValue(item)})
+		}
+		return map[string]interface{}{"type": "object", "value": entries}
+	default:
+		return map[string]interface{}{"type": "null"}
+	}
+}
@@ -0,0 +1,346 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Container defaults
+const (
+	DefaultContainerImage   = "chromedp/headless-shell:latest"
+	DefaultDockerHost       = "unix:///var/run/docker.sock"
+	DefaultContainerNetwork = "browser-query-ai"
+	DefaultContainerPort    = 9222
+
+	// containerLabel marks containers created by this service so they can be found later
+	containerLabel = "browser-query-ai.managed"
+
+	containerStopTimeout = 5 // Seconds Docker waits before killing the container
+)
+
+// ContainerConfig runs each browser in its own Docker container instead of as a
+// child process, so page content is isolated from the host.
+type ContainerConfig struct {
+	Image         string  // Image running headless Chromium with DevTools on ContainerPort
+	DockerHost    string  // Docker Engine API endpoint (unix:///path or tcp://host:port)
+	Network       string  // Bridge network the containers join; inter-container traffic is disabled
+	ContainerPort int     // DevTools port inside the container
+	CPUs          float64 // CPU limit in cores (0 is unlimited)
+	MemoryMB      int     // Memory limit in MiB (0 is unlimited)
+}
+
+// withDefaults fills in unset fields
+func (c ContainerConfig) withDefaults() ContainerConfig {
+	if c.Image == "" {
+		c.Image = DefaultContainerImage
+	}
+	if c.DockerHost == "" {
+		c.DockerHost = DefaultDockerHost
+	}
+	if c.Network == "" {
+		c.Network = DefaultContainerNetwork
+	}
+	if c.ContainerPort <= 0 {
+		c.ContainerPort = DefaultContainerPort
+	}
+	return c
+}
+
+// dockerClient speaks the subset of the Docker Engine API needed to run browsers
+type dockerClient struct {
+	http    *http.Client
+	baseURL string
+}
+
+// newDockerClient creates a client for a unix:// or tcp:// Docker host
+func newDockerClient(host string) (*dockerClient, error) {
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	switch parsed.Scheme {
+	case "unix":
+		socket := parsed.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		// The host part is ignored when dialing a socket
+		return &dockerClient{http: &http.Client{Transport: transport}, baseURL: "http://docker"}, nil
+	case "tcp", "http":
+		return &dockerClient{http: &http.Client{}, baseURL: "http://" + parsed.Host}, nil
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q", parsed.Scheme)
+	}
+}
+
+// do sends a request and decodes a JSON response into out (if non-nil).
+// A status outside 2xx is returned as a *dockerError.
+func (d *dockerClient) do(method string, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, d.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := d.http.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to reach docker: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(response.Body).Decode(&apiError)
+		return &dockerError{status: response.StatusCode, message: apiError.Message}
+	}
+
+	if out == nil {
+		// Drain streamed responses (image pulls report progress until done)
+		_, err := io.Copy(io.Discard, response.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse docker response: %w", err)
+	}
+	return nil
+}
+
+// dockerError is a non-2xx response from the Docker API
+type dockerError struct {
+	status  int
+	message string
+}
+
+func (e *dockerError) Error() string {
+	return fmt.Sprintf("docker API returned %d: %s", e.status, e.message)
+}
+
+// isStatus reports whether err is a Docker API error with the given status
+func isStatus(err error, status int) bool {
+	apiErr, ok := err.(*dockerError)
+	return ok && apiErr.status == status
+}
+
+// isPortTaken reports whether Docker refused to publish a host port someone else holds
+func isPortTaken(err error) bool {
+	apiErr, ok := err.(*dockerError)
+	return ok && (strings.Contains(apiErr.message, "port is already allocated") ||
+		strings.Contains(apiErr.message, "address already in use"))
+}
+
+// ensureNetwork creates the browser network if it does not exist yet
+func (d *dockerClient) ensureNetwork(name string) error {
+	err := d.do(http.MethodGet, "/networks/"+url.PathEscape(name), nil, nil)
+	if err == nil {
+		return nil
+	}
+	if !isStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to inspect network: %w", err)
+	}
+
+	// Browsers can reach the internet but not each other
+	request := map[string]interface{}{
+		"Name":   name,
+		"Driver": "bridge",
+		"Options": map[string]string{
+			"com.docker.network.bridge.enable_icc": "false",
+		},
+		"Labels": map[string]string{containerLabel: "true"},
+	}
+	if err := d.do(http.MethodPost, "/networks/create", request, nil); err != nil && !isStatus(err, http.StatusConflict) {
+		return fmt.Errorf("failed to create network: %w", err)
+	}
+	return nil
+}
+
+// pullImage downloads image, waiting for the pull to finish
+func (d *dockerClient) pullImage(image string) error {
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+
+	query := url.Values{"fromImage": {name}, "tag": {tag}}
+	if err := d.do(http.MethodPost, "/images/create?"+query.Encode(), nil, nil); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
+
+// createContainer creates (but does not start) the container for a browser, pulling the image if needed
+func (d *dockerClient) createContainer(name string, request map[string]interface{}, image string) (string, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+
+	path := "/containers/create?" + url.Values{"name": {name}}.Encode()
+	err := d.do(http.MethodPost, path, request, &created)
+	if isStatus(err, http.StatusNotFound) {
+		if err := d.pullImage(image); err != nil {
+			return "", err
+		}
+		err = d.do(http.MethodPost, path, request, &created)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// containerState is the part of a container inspection the pool cares about
+type containerState struct {
+	Running bool `json:"Running"`
+	Pid     int  `json:"Pid"`
+}
+
+// inspectContainer returns the container's run state
+func (d *dockerClient) inspectContainer(id string) (containerState, error) {
+	var inspected struct {
+		State containerState `json:"State"`
+	}
+	if err := d.do(http.MethodGet, "/containers/"+id+"/json", nil, &inspected); err != nil {
+		return containerState{}, err
+	}
+	return inspected.State, nil
+}
+
+// containerRequest builds the create-container body for a browser publishing DevTools on hostPort
+func containerRequest(cfg ContainerConfig, hostPort int) map[string]interface{} {
+	devtools := fmt.Sprintf("%d/tcp", cfg.ContainerPort)
+
+	hostConfig := map[string]interface{}{
+		// DevTools is only reachable from this host, never from the network
+		"PortBindings": map[string]interface{}{
+			devtools: []map[string]string{{"HostIp": "127.0.0.1", "HostPort": strconv.Itoa(hostPort)}},
+		},
+		"NetworkMode": cfg.Network,
+		"CapDrop":     []string{"ALL"},
+		"SecurityOpt": []string{"no-new-privileges"},
+		"ShmSize":     256 << 20, // Chromium needs more shared memory than Docker's 64 MiB default
+	}
+	if cfg.MemoryMB > 0 {
+		hostConfig["Memory"] = int64(cfg.MemoryMB) << 20
+		hostConfig["MemorySwap"] = int64(cfg.MemoryMB) << 20 // No swap on top of the limit
+	}
+	if cfg.CPUs > 0 {
+		hostConfig["NanoCpus"] = int64(cfg.CPUs * 1e9)
+	}
+
+	return map[string]interface{}{
+		"Image":        cfg.Image,
+		"ExposedPorts": map[string]interface{}{devtools: struct{}{}},
+		"Labels": map[string]string{
+			containerLabel:           "true",
+			containerLabel + ".port": strconv.Itoa(hostPort),
+		},
+		"HostConfig": hostConfig,
+	}
+}
+
+// startContainer runs the browser in a new container and waits for DevTools to answer
+func (p *Process) startContainer() error {
+	cfg := p.Container.withDefaults()
+
+	client, err := newDockerClient(cfg.DockerHost)
+	if err != nil {
+		return err
+	}
+	if err := client.ensureNetwork(cfg.Network); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("browser-query-ai-%d", p.DebugPort)
+
+	// A container left behind by a crash would hold the name and the port
+	if err := client.do(http.MethodDelete, "/containers/"+name+"?force=true", nil, nil); err != nil && !isStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to remove stale container: %w", err)
+	}
+
+	id, err := client.createContainer(name, containerRequest(cfg, p.DebugPort), cfg.Image)
+	if err != nil {
+		return err
+	}
+	p.docker = client
+	p.ContainerID = id
+
+	if err := client.do(http.MethodPost, "/containers/"+id+"/start", nil, nil); err != nil {
+		p.removeContainer()
+		if isPortTaken(err) {
+			return fmt.Errorf("%w: %v", ErrPortInUse, err)
+		}
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	// The host PID of the container's init process roots the tree for resource sampling
+	if state, err := client.inspectContainer(id); err == nil {
+		p.containerPID = state.Pid
+	}
+
+	if err := waitForDevTools(p.DebugPort, p.startTimeout(), nil); err != nil {
+		p.removeContainer()
+		return err
+	}
+
+	return nil
+}
+
+// stopContainer stops and removes the browser's container
+func (p *Process) stopContainer() error {
+	if p.docker == nil || p.ContainerID == "" {
+		return fmt.Errorf("process was never started")
+	}
+
+	path := fmt.Sprintf("/containers/%s/stop?t=%d", p.ContainerID, containerStopTimeout)
+	if err := p.docker.do(http.MethodPost, path, nil, nil); err != nil && !isStatus(err, http.StatusNotModified) {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+
+	return p.removeContainer()
+}
+
+// removeContainer deletes the container and its anonymous volumes
+func (p *Process) removeContainer() error {
+	path := "/containers/" + p.ContainerID + "?force=true&v=true"
+	if err := p.docker.do(http.MethodDelete, path, nil, nil); err != nil && !isStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+	p.ContainerID = ""
+	p.containerPID = 0
+	return nil
+}
+
+// containerAlive asks Docker whether the container is still running
+func (p *Process) containerAlive() bool {
+	if p.docker == nil || p.ContainerID == "" {
+		return false
+	}
+	state, err := p.docker.inspectContainer(p.ContainerID)
+	return err == nil && state.Running
+}
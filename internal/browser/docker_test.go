@@ -0,0 +1,132 @@
+package browser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDocker is a minimal Docker Engine API that also answers DevTools discovery,
+// so a containerized Process can be started against it
+type fakeDocker struct {
+	mu       sync.Mutex
+	calls    []string
+	created  map[string]interface{}
+	network  map[string]interface{}
+	running  bool
+	hasImage bool
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+
+	switch {
+	case r.URL.Path == "/json/version":
+		json.NewEncoder(w).Encode(map[string]string{"webSocketDebuggerUrl": "ws://localhost/devtools/browser/1"})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/networks/"):
+		if f.network == nil {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.URL.Path == "/networks/create":
+		json.NewDecoder(r.Body).Decode(&f.network)
+		w.WriteHeader(http.StatusCreated)
+	case r.URL.Path == "/images/create":
+		f.hasImage = true
+	case r.URL.Path == "/containers/create":
+		if !f.hasImage {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "No such image"})
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&f.created)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": "abc123"})
+	case r.URL.Path == "/containers/abc123/start":
+		f.running = true
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/containers/abc123/json":
+		json.NewEncoder(w).Encode(map[string]interface{}{"State": map[string]interface{}{"Running": f.running, "Pid": 4242}})
+	case r.URL.Path == "/containers/abc123/stop":
+		f.running = false
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/containers/"):
+		// The stale-container cleanup targets a name that does not exist
+		if r.URL.Path != "/containers/abc123" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// TestContainerLifecycle tests starting and stopping a browser through the Docker API
+func TestContainerLifecycle(t *testing.T) {
+	fake := &fakeDocker{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+
+	// The fake serves DevTools discovery on the same port the container would be published on
+	process := &Process{
+		DebugPort:   port,
+		UserDataDir: t.TempDir(),
+		Container: &ContainerConfig{
+			DockerHost: "tcp://" + serverURL.Host,
+			CPUs:       1.5,
+			MemoryMB:   512,
+		},
+	}
+
+	if err := process.Start(); err != nil {
+		t.Fatalf("failed to start container: %v", err)
+	}
+
+	if process.ContainerID != "abc123" || process.GetPID() != 4242 {
+		t.Errorf("unexpected container state: id=%q pid=%d", process.ContainerID, process.GetPID())
+	}
+	if !process.IsAlive() {
+		t.Error("expected running container to be alive")
+	}
+
+	// Missing images are pulled, then the create is retried
+	if !fake.hasImage {
+		t.Error("expected the image to be pulled")
+	}
+
+	options, _ := fake.network["Options"].(map[string]interface{})
+	if options["com.docker.network.bridge.enable_icc"] != "false" {
+		t.Errorf("expected inter-container traffic to be disabled, got %v", fake.network)
+	}
+
+	hostConfig := fake.created["HostConfig"].(map[string]interface{})
+	if hostConfig["Memory"] != float64(512<<20) || hostConfig["NanoCpus"] != float64(1.5e9) {
+		t.Errorf("expected resource limits, got memory=%v cpus=%v", hostConfig["Memory"], hostConfig["NanoCpus"])
+	}
+	bindings := hostConfig["PortBindings"].(map[string]interface{})["9222/tcp"].([]interface{})
+	binding := bindings[0].(map[string]interface{})
+	if binding["HostIp"] != "127.0.0.1" || binding["HostPort"] != strconv.Itoa(port) {
+		t.Errorf("expected DevTools published on 127.0.0.1:%d, got %v", port, binding)
+	}
+
+	if err := process.Stop(); err != nil {
+		t.Fatalf("failed to stop container: %v", err)
+	}
+	if process.IsAlive() || process.Status != StatusStopped {
+		t.Errorf("expected stopped container, status %s", process.Status)
+	}
+	if last := fake.calls[len(fake.calls)-1]; last != "DELETE /containers/abc123" {
+		t.Errorf("expected container to be removed last, got %q", last)
+	}
+}
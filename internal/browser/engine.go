@@ -0,0 +1,33 @@
+package browser
+
+import (
+	"fmt"
+)
+
+// Engine is the browser a process runs
+type Engine string
+
+const (
+	EngineChromium Engine = "chromium" // Driven over CDP
+	EngineFirefox  Engine = "firefox"  // Driven over WebDriver BiDi
+)
+
+// firefoxFlags constructs the command-line flags for Firefox. Its remote agent serves
+// WebDriver BiDi at ws://localhost:{port}/session.
+func (p *Process) firefoxFlags() []string {
+	flags := []string{
+		fmt.Sprintf("--remote-debugging-port=%d", p.DebugPort),
+		"--profile", p.UserDataDir, // Fresh profile, so no user's Firefox is touched
+		"--no-remote",    // Don't hand the launch over to a Firefox already running
+		"--new-instance", // Even one with the same binary
+	}
+	if p.Headless != HeadlessOff {
+		flags = append(flags, "--headless")
+	}
+	return flags
+}
+
+// BiDiURL returns the WebDriver BiDi endpoint of the local Firefox on port
+func BiDiURL(port int) string {
+	return fmt.Sprintf("ws://localhost:%d/session", port)
+}
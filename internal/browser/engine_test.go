@@ -0,0 +1,29 @@
+package browser
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestBuildFlagsFirefox tests that a Firefox process gets Firefox's flags, on its own
+// profile, and refuses extensions
+func TestBuildFlagsFirefox(t *testing.T) {
+	process := &Process{DebugPort: 9300, UserDataDir: "/tmp/firefox"}
+	process.Engine = EngineFirefox
+
+	want := []string{"--remote-debugging-port=9300", "--profile", "/tmp/firefox", "--no-remote", "--new-instance", "--headless"}
+	if flags := process.buildFlags(); !slices.Equal(flags, want) {
+		t.Errorf("flags = %v, want %v", flags, want)
+	}
+
+	process.Headless = HeadlessOff
+	if flags := process.buildFlags(); slices.Contains(flags, "--headless") {
+		t.Errorf("expected a visible Firefox to get no headless flag, got %v", flags)
+	}
+
+	process.Extensions = []Extension{{Name: "a", Path: "/ext/a"}}
+	if err := process.startLocal(); !errors.Is(err, ErrExtensionsHeadless) {
+		t.Errorf("expected ErrExtensionsHeadless for Firefox, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package browser
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HeadlessMode selects how a browser runs without a display
+type HeadlessMode string
+
+const (
+	HeadlessNew HeadlessMode = "new" // The full browser without a window; runs extensions
+	HeadlessOld HeadlessMode = "old" // The separate headless shell; faster, but loads no extensions
+	HeadlessOff HeadlessMode = "off" // A visible window, which needs a display such as Xvfb
+)
+
+// LaunchOptions are the settings that shape how a browser is launched
+type LaunchOptions struct {
+	Engine       Engine        // Which browser the binary is ("" is EngineChromium)
+	StartTimeout time.Duration // How long Start waits for DevTools to answer (0 uses DefaultStartTimeout)
+	Headless     HeadlessMode  // How it runs without a display ("" is HeadlessNew)
+	Extensions   []Extension   // Unpacked extensions loaded at launch
+	Rendering    Rendering     // Fonts, locale and timezone fixed for deterministic rendering
+	TrustedSPKIs []string      // Key hashes whose certificates are accepted unverified, e.g. the egress proxy's CA
+}
+
+// extensionNamePattern keeps extension names safe in flags, which separate paths by ","
+var extensionNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ErrExtensionNotFound is returned for an extension name the catalog doesn't hold
+var ErrExtensionNotFound = errors.New("extension not found")
+
+// ErrExtensionsHeadless is returned when extensions are asked of the old headless shell,
+// or of Firefox, which doesn't load unpacked Chromium extensions
+var ErrExtensionsHeadless = errors.New("extensions need Chromium in new headless mode or a visible window")
+
+// Extension is an unpacked Chromium extension, one directory holding a manifest.json
+type Extension struct {
+	Name            string `json:"name"`             // Directory name, how config and sessions refer to it
+	ID              string `json:"id"`               // Chromium's ID for it, as in chrome-extension://{id}/
+	Title           string `json:"title"`            // Name in the manifest
+	Version         string `json:"version"`          // Version in the manifest
+	ManifestVersion int    `json:"manifest_version"` // 2 or 3
+	Default         bool   `json:"default"`          // Loaded into every browser
+	Path            string `json:"-"`                // Absolute path of the directory
+}
+
+// manifest holds the manifest.json fields read from an extension
+type manifest struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	ManifestVersion int    `json:"manifest_version"`
+	Key             string `json:"key"` // Public key that pins the ID; unset derives it from the path
+}
+
+// LoadExtension reads the unpacked extension in dir
+func LoadExtension(dir string) (Extension, error) {
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return Extension{}, fmt.Errorf("failed to resolve extension path: %w", err)
+	}
+	if strings.Contains(path, ",") {
+		return Extension{}, fmt.Errorf("extension path %q must not contain ','", path)
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, "manifest.json"))
+	if err != nil {
+		return Extension{}, fmt.Errorf("failed to read extension manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Extension{}, fmt.Errorf("invalid extension manifest in %s: %w", path, err)
+	}
+	if m.Name == "" || m.Version == "" || m.ManifestVersion == 0 {
+		return Extension{}, fmt.Errorf("extension manifest in %s needs name, version and manifest_version", path)
+	}
+
+	id, err := extensionID(path, m.Key)
+	if err != nil {
+		return Extension{}, err
+	}
+
+	return Extension{
+		Name:            filepath.Base(path),
+		ID:              id,
+		Title:           m.Name,
+		Version:         m.Version,
+		ManifestVersion: m.ManifestVersion,
+		Path:            path,
+	}, nil
+}
+
+// extensionID computes the ID Chromium gives an unpacked extension: a hash of its
+// manifest key if it has one, otherwise of its path, written with the letters a-p
+func extensionID(path, key string) (string, error) {
+	input := []byte(path)
+	if key != "" {
+		der, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return "", fmt.Errorf("invalid key in extension manifest: %w", err)
+		}
+		input = der
+	}
+
+	sum := sha256.Sum256(input)
+	id := make([]byte, 0, 32)
+	for _, b := range sum[:16] {
+		id = append(id, 'a'+b>>4, 'a'+b&0x0f)
+	}
+	return string(id), nil
+}
+
+// ExtensionCatalog holds the unpacked extensions browsers may load, one per
+// subdirectory of its directory
+type ExtensionCatalog struct {
+	extensions map[string]Extension
+}
+
+// LoadExtensionCatalog reads every extension under dir. Those named in defaults are
+// loaded into every browser; the rest only where a session asks for them.
+func LoadExtensionCatalog(dir string, defaults []string) (*ExtensionCatalog, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extension directory: %w", err)
+	}
+
+	catalog := &ExtensionCatalog{extensions: make(map[string]Extension)}
+	for _, entry := range entries {
+		if !entry.IsDir() || !extensionNamePattern.MatchString(entry.Name()) {
+			continue
+		}
+		extension, err := LoadExtension(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		catalog.extensions[extension.Name] = extension
+	}
+
+	for _, name := range defaults {
+		extension, ok := catalog.extensions[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not in %s", ErrExtensionNotFound, name, dir)
+		}
+		extension.Default = true
+		catalog.extensions[name] = extension
+	}
+	return catalog, nil
+}
+
+// List returns every extension in the catalog, sorted by name
+func (c *ExtensionCatalog) List() []Extension {
+	if c == nil {
+		return []Extension{}
+	}
+	extensions := make([]Extension, 0, len(c.extensions))
+	for _, extension := range c.extensions {
+		extensions = append(extensions, extension)
+	}
+	sort.Slice(extensions, func(i, j int) bool { return extensions[i].Name < extensions[j].Name })
+	return extensions
+}
+
+// Defaults returns the extensions every browser loads
+func (c *ExtensionCatalog) Defaults() []Extension {
+	var defaults []Extension
+	for _, extension := range c.List() {
+		if extension.Default {
+			defaults = append(defaults, extension)
+		}
+	}
+	return defaults
+}
+
+// Resolve returns the defaults followed by the named extensions, each once
+func (c *ExtensionCatalog) Resolve(names []string) ([]Extension, error) {
+	resolved := c.Defaults()
+	seen := make(map[string]bool)
+	for _, extension := range resolved {
+		seen[extension.Name] = true
+	}
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		var extension Extension
+		ok := false
+		if c != nil {
+			extension, ok = c.extensions[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrExtensionNotFound, name)
+		}
+		seen[name] = true
+		resolved = append(resolved, extension)
+	}
+	return resolved, nil
+}
+
+// extensionFlags returns the flags that load exts and nothing else
+func extensionFlags(exts []Extension) []string {
+	if len(exts) == 0 {
+		return nil
+	}
+	paths := make([]string, len(exts))
+	for i, extension := range exts {
+		paths[i] = extension.Path
+	}
+	list := strings.Join(paths, ",")
+	return []string{
+		"--disable-extensions-except=" + list,
+		"--load-extension=" + list,
+		// Branded Chrome ignores --load-extension unless this is turned off
+		"--disable-features=DisableLoadExtensionCommandLineSwitch",
+	}
+}
+
+// headlessFlags returns the flags for a headless mode
+func headlessFlags(mode HeadlessMode) []string {
+	switch mode {
+	case HeadlessOff:
+		return nil
+	case HeadlessOld:
+		return []string{"--headless"}
+	default:
+		return []string{"--headless=new"}
+	}
+}
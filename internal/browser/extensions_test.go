@@ -0,0 +1,120 @@
+package browser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeExtension creates an unpacked extension with the given manifest under dir
+func writeExtension(t *testing.T, dir, name, manifest string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "manifest.json"), []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadExtensionCatalog tests reading extensions, marking the defaults and resolving
+// a session's extensions on top of them
+func TestLoadExtensionCatalog(t *testing.T) {
+	dir := t.TempDir()
+	writeExtension(t, dir, "adblock", `{"name": "Ad Block", "version": "1.2.0", "manifest_version": 3}`)
+	writeExtension(t, dir, "recorder", `{"name": "Recorder", "version": "0.1", "manifest_version": 3}`)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("not an extension"), 0o600)
+
+	if _, err := LoadExtensionCatalog(dir, []string{"missing"}); !errors.Is(err, ErrExtensionNotFound) {
+		t.Fatalf("expected ErrExtensionNotFound for an unknown default, got %v", err)
+	}
+
+	catalog, err := LoadExtensionCatalog(dir, []string{"adblock"})
+	if err != nil {
+		t.Fatalf("LoadExtensionCatalog failed: %v", err)
+	}
+	extensions := catalog.List()
+	if len(extensions) != 2 || extensions[0].Name != "adblock" || extensions[1].Name != "recorder" {
+		t.Fatalf("expected adblock and recorder, got %+v", extensions)
+	}
+	if !extensions[0].Default || extensions[1].Default {
+		t.Errorf("expected only adblock to be a default, got %+v", extensions)
+	}
+	if extensions[0].Title != "Ad Block" || extensions[0].Version != "1.2.0" {
+		t.Errorf("expected the manifest's name and version, got %+v", extensions[0])
+	}
+	if !regexp.MustCompile(`^[a-p]{32}$`).MatchString(extensions[0].ID) {
+		t.Errorf("expected a Chromium extension ID, got %q", extensions[0].ID)
+	}
+
+	resolved, err := catalog.Resolve([]string{"recorder", "adblock"})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if len(resolved) != 2 || resolved[0].Name != "adblock" || resolved[1].Name != "recorder" {
+		t.Errorf("expected the default followed by recorder, got %+v", resolved)
+	}
+	if _, err := catalog.Resolve([]string{"missing"}); !errors.Is(err, ErrExtensionNotFound) {
+		t.Errorf("expected ErrExtensionNotFound, got %v", err)
+	}
+
+	var none *ExtensionCatalog
+	if _, err := none.Resolve([]string{"adblock"}); !errors.Is(err, ErrExtensionNotFound) {
+		t.Errorf("expected ErrExtensionNotFound without a catalog, got %v", err)
+	}
+}
+
+// TestExtensionIDFromKey tests that a manifest key, not the path, decides the ID
+func TestExtensionIDFromKey(t *testing.T) {
+	manifest := `{"name": "Pinned", "version": "1", "manifest_version": 3, "key": "TUlJQklqQU5CZ2txaGtpRzl3MEJBUUVGQUFPQ0FROEFNSUlCQ2dLQ0FRRUE="}`
+	first, err := LoadExtension(writeExtension(t, t.TempDir(), "pinned", manifest))
+	if err != nil {
+		t.Fatalf("LoadExtension failed: %v", err)
+	}
+	second, err := LoadExtension(writeExtension(t, t.TempDir(), "pinned", manifest))
+	if err != nil {
+		t.Fatalf("LoadExtension failed: %v", err)
+	}
+	if first.ID != second.ID {
+		t.Errorf("expected the same ID at both paths, got %q and %q", first.ID, second.ID)
+	}
+
+	unpinned, _ := LoadExtension(writeExtension(t, t.TempDir(), "plain", `{"name": "Plain", "version": "1", "manifest_version": 3}`))
+	if unpinned.ID == first.ID {
+		t.Error("expected an extension without a key to get a path-derived ID")
+	}
+}
+
+// TestBuildFlagsExtensions tests the headless and extension flags a launch gets
+func TestBuildFlagsExtensions(t *testing.T) {
+	process := &Process{DebugPort: 9222, UserDataDir: "/tmp/profile"}
+	if flags := process.buildFlags(); !slices.Contains(flags, "--headless=new") || slices.ContainsFunc(flags, isExtensionFlag) {
+		t.Errorf("expected new headless mode and no extensions by default, got %v", flags)
+	}
+
+	process.Headless = HeadlessOff
+	process.Extensions = []Extension{{Name: "a", Path: "/ext/a"}, {Name: "b", Path: "/ext/b"}}
+	flags := process.buildFlags()
+	if slices.ContainsFunc(flags, func(flag string) bool { return strings.HasPrefix(flag, "--headless") }) {
+		t.Errorf("expected a visible browser to get no headless flag, got %v", flags)
+	}
+	if !slices.Contains(flags, "--load-extension=/ext/a,/ext/b") || !slices.Contains(flags, "--disable-extensions-except=/ext/a,/ext/b") {
+		t.Errorf("expected both extensions to be loaded, got %v", flags)
+	}
+
+	process.Headless = HeadlessOld
+	if err := process.startLocal(); !errors.Is(err, ErrExtensionsHeadless) {
+		t.Errorf("expected ErrExtensionsHeadless for the headless shell, got %v", err)
+	}
+}
+
+// isExtensionFlag reports whether flag loads extensions
+func isExtensionFlag(flag string) bool {
+	return strings.HasPrefix(flag, "--load-extension")
+}
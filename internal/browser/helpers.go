@@ -1,11 +1,14 @@
 package browser
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
 	"net"
 	"strconv"
 	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -16,12 +19,23 @@ const (
 var (
     freePortsStack []string
     freePortsSet   map[string]bool // Tracks which ports are available
+    busyPorts      map[string]bool // Free in the pool, but bound by another process
     portStackMutex sync.Mutex
+
+    portCollisions atomic.Int64 // Launches that lost their port to another process
 )
 
+// ErrNoFreePort is returned when every port in the pool is taken
+var ErrNoFreePort = errors.New("no free ports available in pool")
+
+// ErrPortInUse is returned when a browser could not bind its debug port because another
+// process, such as a second instance of the service, took it first
+var ErrPortInUse = errors.New("debug port taken by another process")
+
 // Initialize the port pool at startup
 func init() {
     freePortsSet = make(map[string]bool)
+    busyPorts = make(map[string]bool)
     for i := MinPortRange; i < MaxPortRange; i++ {
         port := strconv.Itoa(i)
         freePortsStack = append(freePortsStack, port)
@@ -30,39 +44,72 @@ func init() {
     slog.Info("port pool initialized", "size", len(freePortsStack))
 }
 
-// IsPortAvailable checks if a port is available by attempting to listen on it
+// IsPortAvailable checks if a port is available by attempting to listen on it.
+// Chromium binds DevTools to loopback, so both loopback and all interfaces are tried:
+// either being taken means a browser can't use the port.
 func IsPortAvailable(port string) bool {
-    listener, err := net.Listen("tcp", ":"+port)
-    if err != nil {
-        return false
+    for _, address := range []string{"127.0.0.1:" + port, ":" + port} {
+        listener, err := net.Listen("tcp", address)
+        if err != nil {
+            return false
+        }
+        listener.Close()
     }
-    listener.Close()
     return true
 }
 
-// GetFreePort retrieves an available port from the pool
+// GetFreePort retrieves an available port from the pool. Ports are tried in random
+// order so several instances on one host don't all reach for the same port first.
+// A port another process holds stays in the pool, to be tried again later.
 func GetFreePort() (string, error) {
     portStackMutex.Lock()
     defer portStackMutex.Unlock()
 
-    // Try ports from the stack until we find an available one
-    for len(freePortsStack) > 0 {
-        // Pop from stack
-        port := freePortsStack[len(freePortsStack)-1]
-        freePortsStack = freePortsStack[:len(freePortsStack)-1]
+    for _, i := range rand.Perm(len(freePortsStack)) {
+        port := freePortsStack[i]
+        if !IsPortAvailable(port) {
+            busyPorts[port] = true
+            slog.Debug("port in use by external process", "port", port)
+            continue
+        }
+        delete(busyPorts, port)
+
+        freePortsStack = append(freePortsStack[:i], freePortsStack[i+1:]...)
         delete(freePortsSet, port)
+        slog.Debug("allocated port from pool", "port", port, "remaining", len(freePortsStack))
+        return port, nil
+    }
 
-        // Verify port is actually available
-        if IsPortAvailable(port) {
-            slog.Debug("allocated port from pool", "port", port, "remaining", len(freePortsStack))
-            return port, nil
-        }
+    if len(freePortsStack) == 0 {
+        return "", ErrNoFreePort
+    }
+    return "", fmt.Errorf("%w: all %d free ports are held by other processes", ErrNoFreePort, len(freePortsStack))
+}
+
+// ClaimPort takes a specific port out of the pool, reporting whether it was free
+func ClaimPort(port string) bool {
+    portStackMutex.Lock()
+    defer portStackMutex.Unlock()
 
-        // Port was in use by another process, try next one
-        slog.Debug("port in use by external process", "port", port)
+    if !freePortsSet[port] {
+        return false
     }
+    if !IsPortAvailable(port) {
+        busyPorts[port] = true
+        return false
+    }
+    delete(busyPorts, port)
+
+    for i, candidate := range freePortsStack {
+        if candidate == port {
+            freePortsStack = append(freePortsStack[:i], freePortsStack[i+1:]...)
+            break
+        }
+    }
+    delete(freePortsSet, port)
 
-    return "", fmt.Errorf("no free ports available in pool")
+    slog.Debug("claimed port from pool", "port", port, "remaining", len(freePortsStack))
+    return true
 }
 
 // ReturnPort returns a port back to the pool for reuse
@@ -93,6 +140,30 @@ func ReturnPort(port string) {
 func GetPoolStats() (total, available int) {
     portStackMutex.Lock()
     defer portStackMutex.Unlock()
-    
+
     return MaxPortRange - MinPortRange, len(freePortsStack)
-}
\ No newline at end of file
+}
+
+// PortStats describes the debug port pool
+type PortStats struct {
+    Total      int   `json:"total"`
+    InUse      int   `json:"in_use"`     // Held by this service's browsers
+    Available  int   `json:"available"`  // In the pool, including busy ones
+    Busy       int   `json:"busy"`       // In the pool but bound by another process when last tried
+    Collisions int64 `json:"collisions"` // Launches retried on another port since startup
+}
+
+// PortPoolStats returns how the debug port pool is used
+func PortPoolStats() PortStats {
+    portStackMutex.Lock()
+    defer portStackMutex.Unlock()
+
+    total := MaxPortRange - MinPortRange
+    return PortStats{
+        Total:      total,
+        InUse:      total - len(freePortsStack),
+        Available:  len(freePortsStack),
+        Busy:       len(busyPorts),
+        Collisions: portCollisions.Load(),
+    }
+}
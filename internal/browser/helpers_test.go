@@ -0,0 +1,63 @@
+package browser
+
+import (
+	"net"
+	"strconv"
+	"testing"
+)
+
+// TestGetFreePortSkipsBusy tests that a port another process holds is skipped but kept
+// in the pool, and that claiming one marks it busy
+func TestGetFreePortSkipsBusy(t *testing.T) {
+	// Hold every free port but one, as a second instance of the service would
+	var kept string
+	var held []net.Listener
+	defer func() {
+		for _, listener := range held {
+			listener.Close()
+		}
+	}()
+	for port := MinPortRange; port < MaxPortRange; port++ {
+		if !isFreeInPool(strconv.Itoa(port)) {
+			continue
+		}
+		if kept == "" {
+			kept = strconv.Itoa(port)
+			continue
+		}
+		listener, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+		if err != nil {
+			continue // Already taken on this host
+		}
+		held = append(held, listener)
+	}
+	if kept == "" || !IsPortAvailable(kept) {
+		t.Skip("no free debug port on this host")
+	}
+
+	before := PortPoolStats()
+	port, err := GetFreePort()
+	if err != nil {
+		t.Fatalf("GetFreePort failed: %v", err)
+	}
+	defer ReturnPort(port)
+	if port != kept {
+		t.Fatalf("expected the only bindable port %s, got %s", kept, port)
+	}
+
+	stats := PortPoolStats()
+	if stats.Available != before.Available-1 || stats.InUse != before.InUse+1 {
+		t.Errorf("expected held ports to stay in the pool, before %+v, after %+v", before, stats)
+	}
+	if len(held) == 0 {
+		return
+	}
+	heldPort := strconv.Itoa(held[0].Addr().(*net.TCPAddr).Port)
+	if ClaimPort(heldPort) {
+		ReturnPort(heldPort)
+		t.Fatalf("expected claiming held port %s to fail", heldPort)
+	}
+	if stats := PortPoolStats(); stats.Busy == 0 {
+		t.Errorf("expected the held port to show up as busy, got %+v", stats)
+	}
+}
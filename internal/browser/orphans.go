@@ -0,0 +1,259 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+const (
+	// userDataPrefix starts the name of every profile directory this service creates,
+	// which is how its browsers are told apart from any other Chromium on the host
+	userDataPrefix = "browser-query-ai-chromium-"
+
+	// ownerFile in a profile directory holds the PID of the service that launched the browser
+	ownerFile = ".browser-query-ai-owner"
+
+	// orphanExitTimeout bounds the wait for killed browsers to exit before their
+	// profiles are removed
+	orphanExitTimeout = 5 * time.Second
+)
+
+// OrphanReport says what a startup sweep cleaned up
+type OrphanReport struct {
+	Processes int   // Browser processes killed
+	Dirs      int   // Profile directories removed
+	Ports     []int // Debug ports whose browser was asked to close
+}
+
+// writeOwner records the running service as the owner of a profile directory
+func writeOwner(userDataDir string) error {
+	return os.WriteFile(filepath.Join(userDataDir, ownerFile), []byte(strconv.Itoa(os.Getpid())), 0o600)
+}
+
+// ownerAlive reports whether the service that created a profile directory is still
+// running. The current process doesn't count: a sweep runs before it launches anything.
+func ownerAlive(userDataDir string) bool {
+	data, err := os.ReadFile(filepath.Join(userDataDir, ownerFile))
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 || pid == os.Getpid() {
+		return false
+	}
+	// Signal 0 checks existence; EPERM means it exists under another user
+	err = syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// CleanupOrphans kills browsers, and removes profiles, left by an earlier run of the
+// service that exited without stopping them, such as after a crash. Browsers of another
+// instance that is still running are left alone. It must run before any browser is
+// launched.
+//
+// Browsers are recognized by their profile directory, read from /proc. Where there is no
+// /proc, or a browser was started some other way, scanPorts also asks whatever DevTools
+// endpoint answers on the local debug port range to close.
+func CleanupOrphans(scanPorts bool) (OrphanReport, error) {
+	var report OrphanReport
+	var errs []error
+
+	killed, err := killOrphanProcesses()
+	report.Processes = len(killed)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	waitForExit(killed, orphanExitTimeout)
+
+	if scanPorts {
+		report.Ports = closeOrphanPorts()
+	}
+
+	dirs, err := removeOrphanDirs()
+	report.Dirs = dirs
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// killOrphanProcesses kills every process running with one of this service's profiles
+// whose owner is gone. Renderers and other children carry the profile flag too.
+func killOrphanProcesses() ([]int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procRoot, err)
+	}
+
+	var killed []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		userDataDir, ok := processUserDataDir(pid)
+		if !ok || ownerAlive(userDataDir) {
+			continue
+		}
+		if err := syscall.Kill(pid, syscall.SIGKILL); err == nil {
+			killed = append(killed, pid)
+		}
+	}
+	return killed, nil
+}
+
+// processUserDataDir returns the profile of a process running with one of this
+// service's profile directories: a temporary one, or a named profile marked with its owner
+func processUserDataDir(pid int) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return "", false // Exited, or another user's process
+	}
+	args := strings.Split(string(data), "\x00")
+	for i, arg := range args {
+		// Chromium takes --user-data-dir=DIR, Firefox --profile DIR
+		dir, ok := strings.CutPrefix(arg, "--user-data-dir=")
+		if arg == "--profile" && i+1 < len(args) {
+			dir, ok = args[i+1], true
+		}
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(filepath.Base(dir), userDataPrefix) {
+			return dir, true
+		}
+		if _, err := os.Stat(filepath.Join(dir, ownerFile)); err == nil {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// waitForExit waits until the processes are gone or timeout passes. Orphans belong to
+// init, which reaps them.
+func waitForExit(pids []int, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for _, pid := range pids {
+		for time.Now().Before(deadline) {
+			if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+}
+
+// removeOrphanDirs deletes profile directories in the temp directory whose owner is gone
+func removeOrphanDirs() (int, error) {
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), userDataPrefix+"*"))
+	if err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	removed := 0
+	for _, dir := range dirs {
+		if ownerAlive(dir) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s: %w", dir, err))
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
+// closeOrphanPorts asks the browser answering on each free port of the local debug range
+// to close, returning the ports that had one. A port already claimed by this process
+// is skipped.
+func closeOrphanPorts() []int {
+	var closed []int
+	for port := MinPortRange; port < MaxPortRange; port++ {
+		if !isFreeInPool(strconv.Itoa(port)) {
+			continue
+		}
+		wsURL, err := cdp.GetWebSocketURL("localhost", strconv.Itoa(port))
+		if err != nil {
+			continue // Nothing listening, or not DevTools
+		}
+		if closeBrowser(wsURL) {
+			closed = append(closed, port)
+		}
+	}
+	return closed
+}
+
+// closeBrowser sends Browser.close over DevTools. The browser may exit before it
+// answers, so having sent the command counts.
+func closeBrowser(wsURL string) bool {
+	if parsed, err := url.Parse(wsURL); err != nil || !isLoopback(parsed.Hostname()) {
+		return false
+	}
+
+	client := cdp.NewClient(wsURL)
+	if err := client.Connect(); err != nil {
+		return false
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client.SendCommand(ctx, "Browser.close", nil)
+	return true
+}
+
+// isLoopback reports whether host names this machine
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// isFreeInPool reports whether port hasn't been handed out from the pool
+func isFreeInPool(port string) bool {
+	portStackMutex.Lock()
+	defer portStackMutex.Unlock()
+	return freePortsSet[port]
+}
+
+// CleanupOrphanContainers removes browser containers left by an earlier run. Container
+// names already tie the service to one instance per Docker host, so every container
+// carrying its label is a leftover when this runs, before the pool starts.
+func CleanupOrphanContainers(cfg ContainerConfig) (int, error) {
+	cfg = cfg.withDefaults()
+	client, err := newDockerClient(cfg.DockerHost)
+	if err != nil {
+		return 0, err
+	}
+
+	filters := url.QueryEscape(fmt.Sprintf(`{"label":["%s=true"]}`, containerLabel))
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	if err := client.do(http.MethodGet, "/containers/json?all=true&filters="+filters, nil, &containers); err != nil {
+		return 0, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var errs []error
+	removed := 0
+	for _, container := range containers {
+		if err := client.do(http.MethodDelete, "/containers/"+container.ID+"?force=true&v=true", nil, nil); err != nil && !isStatus(err, http.StatusNotFound) {
+			errs = append(errs, fmt.Errorf("failed to remove container %s: %w", container.ID, err))
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package browser
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestRemoveOrphanDirs tests that profiles whose owner exited are removed, and those of
+// a running owner are kept
+func TestRemoveOrphanDirs(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// A PID that was just used and is gone
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+
+	profiles := map[string]int{
+		"crashed": exited.Process.Pid,
+		"running": os.Getppid(),
+		"unowned": 0,
+	}
+	for name, owner := range profiles {
+		dir := filepath.Join(os.TempDir(), userDataPrefix+name)
+		if err := os.Mkdir(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if owner != 0 {
+			os.WriteFile(filepath.Join(dir, ownerFile), []byte(strconv.Itoa(owner)), 0o600)
+		}
+	}
+
+	removed, err := removeOrphanDirs()
+	if err != nil {
+		t.Fatalf("removeOrphanDirs failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("expected 2 profiles removed, got %d", removed)
+	}
+	if _, err := os.Stat(filepath.Join(os.TempDir(), userDataPrefix+"running")); err != nil {
+		t.Errorf("expected the running owner's profile to stay, got %v", err)
+	}
+}
+
+// TestKillOrphanProcesses tests that a process using an orphaned profile is killed
+func TestKillOrphanProcesses(t *testing.T) {
+	if _, err := os.Stat(procRoot); err != nil {
+		t.Skip("no procfs")
+	}
+	t.Setenv("TMPDIR", t.TempDir())
+
+	dir, err := os.MkdirTemp("", userDataPrefix+"*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The profile flag becomes $0 of the script, so it shows up in the command line
+	orphan := exec.Command("sh", "-c", "sleep 30", "--user-data-dir="+dir)
+	if err := orphan.Start(); err != nil {
+		t.Skipf("cannot start sh: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		orphan.Wait()
+		close(exited)
+	}()
+
+	killed, err := killOrphanProcesses()
+	if err != nil {
+		t.Fatalf("killOrphanProcesses failed: %v", err)
+	}
+	found := false
+	for _, pid := range killed {
+		found = found || pid == orphan.Process.Pid
+	}
+	if !found {
+		orphan.Process.Signal(syscall.SIGKILL)
+		t.Fatalf("expected pid %d to be killed, got %v", orphan.Process.Pid, killed)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("orphan did not exit")
+	}
+}
@@ -1,10 +1,14 @@
 package browser
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -25,6 +29,16 @@ type Process struct {
 	Cmd         *exec.Cmd     // Command to execute the chromium browser
 	StartedAt   time.Time     // Time when the process started
 	Status      ProcessStatus // Status of the process
+
+	LaunchOptions          // Start timeout, headless mode and extensions
+	Persistent    bool     // UserDataDir is a named profile, kept when the process stops
+	profileLock   *os.File // Held while the browser runs with the profile
+
+	Remote       string           // Endpoint of an external browser this service attached to
+	Container    *ContainerConfig // Run in a Docker container instead of locally (nil runs locally)
+	ContainerID  string           // ID of the running container
+	docker       *dockerClient    // Docker API client used for the container
+	containerPID int              // Host PID of the container's init process
 }
 
 // NewProcess creates a new browser process configuration.
@@ -44,17 +58,39 @@ func NewProcess(binaryPath string) (*Process, error) {
 		return nil, fmt.Errorf("failed to convert port to int: %w", err)
 	}
 
+	return newProcessWithPort(binaryPath, debugPortInt)
+}
+
+// NewProcessOnPort creates a browser process configuration that reuses a specific
+// debug port, so clients that remember the port can reconnect after a restart.
+func NewProcessOnPort(binaryPath string, port int) (*Process, error) {
+	if !ClaimPort(strconv.Itoa(port)) {
+		return nil, fmt.Errorf("port %d is not available", port)
+	}
+
+	return newProcessWithPort(binaryPath, port)
+}
+
+// newProcessWithPort finishes process setup for an already claimed port
+func newProcessWithPort(binaryPath string, debugPort int) (*Process, error) {
 	// Create temporary directory for browser profile
-	userDataDir, err := os.MkdirTemp("", "chromium-*")
+	userDataDir, err := os.MkdirTemp("", userDataPrefix+"*")
 	if err != nil {
 		// Return port since we're failing
-		ReturnPort(debugPort)
+		ReturnPort(strconv.Itoa(debugPort))
 		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
+	// Lets a later run tell this profile's browser apart from a crashed run's
+	if err := writeOwner(userDataDir); err != nil {
+		os.RemoveAll(userDataDir)
+		ReturnPort(strconv.Itoa(debugPort))
+		return nil, fmt.Errorf("failed to mark user data directory: %w", err)
+	}
+
 	return &Process{
 		BinaryPath:  binaryPath,
-		DebugPort:   debugPortInt,
+		DebugPort:   debugPort,
 		UserDataDir: userDataDir,
 		Status:      StatusStarting,
 	}, nil
@@ -62,25 +98,54 @@ func NewProcess(binaryPath string) (*Process, error) {
 
 // buildFlags constructs the command-line flags for Chrome
 func (p *Process) buildFlags() []string {
-	return []string{
-		"--headless=new",                                       // Run in headless mode (no GUI)
+	if p.Engine == EngineFirefox {
+		return p.firefoxFlags()
+	}
+	flags := []string{
 		fmt.Sprintf("--remote-debugging-port=%d", p.DebugPort), // Enable DevTools Protocol on this port
-		"--no-sandbox",                                         // Disable sandbox (needed in containers)
-		"--disable-gpu",                                        // Disable GPU acceleration
-		"--disable-dev-shm-usage",                              // Overcome limited resource problems
-		fmt.Sprintf("--user-data-dir=%s", p.UserDataDir),       // Where browser stores its data
+		"--no-sandbox",            // Disable sandbox (needed in containers)
+		"--disable-gpu",           // Disable GPU acceleration
+		"--disable-dev-shm-usage", // Overcome limited resource problems
+		fmt.Sprintf("--user-data-dir=%s", p.UserDataDir), // Where browser stores its data
+	}
+	flags = append(headlessFlags(p.Headless), flags...)
+	flags = append(flags, renderingFlags(p.Rendering)...)
+	if len(p.TrustedSPKIs) > 0 {
+		flags = append(flags, "--ignore-certificate-errors-spki-list="+strings.Join(p.TrustedSPKIs, ","))
 	}
+	return append(flags, extensionFlags(p.Extensions)...)
 }
 
 // Start launches the browser process with appropriate flags
 func (p *Process) Start() error {
-	// Build command with all flags
-	p.Cmd = exec.Command(p.BinaryPath, p.buildFlags()...)
+	// External browsers are already running; starting only confirms they answer
+	if p.IsRemote() {
+		if !p.probeRemote() {
+			p.Status = StatusFailed
+			return fmt.Errorf("remote browser at %s is not reachable", p.Remote)
+		}
+		p.Status = StatusRunning
+		p.StartedAt = time.Now()
+		return nil
+	}
 
-	// Start the process
-	if err := p.Cmd.Start(); err != nil {
-		p.Status = StatusFailed
-		return fmt.Errorf("failed to start browser process: %w", err)
+	// Containerized browsers use the image's own entrypoint and flags
+	launch, what := p.startLocal, "browser process"
+	if p.Container != nil {
+		launch, what = p.startContainer, "browser container"
+	}
+
+	// Another instance on the host can bind the port between the pool's check and the
+	// launch; the browser then gets another port
+	for attempt := 1; ; attempt++ {
+		err := launch()
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrPortInUse) || attempt == maxPortAttempts || !p.movePort() {
+			p.Status = StatusFailed
+			return fmt.Errorf("failed to start %s: %w", what, err)
+		}
 	}
 
 	// Update process status and timestamp
@@ -90,8 +155,94 @@ func (p *Process) Start() error {
 	return nil
 }
 
+// startLocal runs the browser as a child process and waits for its DevTools to answer
+func (p *Process) startLocal() error {
+	if len(p.Extensions) > 0 && (p.Headless == HeadlessOld || p.Engine == EngineFirefox) {
+		return ErrExtensionsHeadless
+	}
+
+	// A port file left by an earlier attempt would vouch for the wrong browser
+	os.Remove(filepath.Join(p.UserDataDir, activePortFile))
+
+	env, err := renderingEnv(p.Rendering, p.UserDataDir)
+	if err != nil {
+		return err
+	}
+
+	watch := &collisionWatch{}
+	p.Cmd = exec.Command(p.BinaryPath, p.buildFlags()...)
+	p.Cmd.Stderr = watch
+	if len(env) > 0 {
+		p.Cmd.Env = append(os.Environ(), env...)
+	}
+
+	if err := p.Cmd.Start(); err != nil {
+		return err
+	}
+
+	// The process is up well before DevTools listens; sessions need the latter
+	ready := waitForDevTools
+	if p.Engine == EngineFirefox {
+		ready = waitForBiDi
+	}
+	err = ready(p.DebugPort, p.startTimeout(), func() error {
+		if watch.collided.Load() {
+			return ErrPortInUse
+		}
+		if p.exited() {
+			return errExited
+		}
+		return nil
+	})
+	// Whatever answered may be another instance's browser on the same port. Firefox
+	// leaves no port file, so for it the bind check before launch has to do.
+	if err == nil && p.Engine != EngineFirefox && !p.ownsDevToolsPort() {
+		err = fmt.Errorf("%w: port %d answered, but not by this browser", ErrPortInUse, p.DebugPort)
+	}
+	if err != nil {
+		p.Cmd.Process.Kill()
+		p.Cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+// movePort swaps the debug port for another free one after a collision
+func (p *Process) movePort() bool {
+	port, err := GetFreePort()
+	if err != nil {
+		slog.Warn("no port to retry browser launch on", "port", p.DebugPort, "error", err)
+		return false
+	}
+	newPort, err := strconv.Atoi(port)
+	if err != nil {
+		ReturnPort(port)
+		return false
+	}
+
+	slog.Warn("debug port taken by another process, retrying on another", "port", p.DebugPort, "new_port", newPort)
+	portCollisions.Add(1)
+	ReturnPort(strconv.Itoa(p.DebugPort))
+	p.DebugPort = newPort
+	return true
+}
+
 // Stop gracefully terminates the browser process
 func (p *Process) Stop() error {
+	// External browsers are not ours to stop, only to let go of
+	if p.IsRemote() {
+		p.Status = StatusStopped
+		return nil
+	}
+
+	// Containers are stopped through Docker; the rest of the cleanup is shared
+	if p.Container != nil {
+		if err := p.stopContainer(); err != nil {
+			return err
+		}
+		return p.finishStop()
+	}
+
 	// Check if process was ever started
 	if p.Cmd == nil || p.Cmd.Process == nil {
 		return fmt.Errorf("process was never started")
@@ -121,8 +272,21 @@ func (p *Process) Stop() error {
 		}
 	}
 
-	// Clean up the user data directory
-	if err := os.RemoveAll(p.UserDataDir); err != nil {
+	return p.finishStop()
+}
+
+// Discard releases what a process that failed to start still holds: its port and its
+// user data directory, or the lock on its profile
+func (p *Process) Discard() error {
+	return p.finishStop()
+}
+
+// finishStop releases what the process held once it has exited
+func (p *Process) finishStop() error {
+	// Named profiles are kept for the next launch; temporary ones are cleaned up
+	if p.Persistent {
+		p.releaseProfile()
+	} else if err := os.RemoveAll(p.UserDataDir); err != nil {
 		return fmt.Errorf("failed to remove user data directory: %w", err)
 	}
 
@@ -137,6 +301,13 @@ func (p *Process) Stop() error {
 
 // IsAlive checks if the process is still running
 func (p *Process) IsAlive() bool {
+	if p.IsRemote() {
+		return p.probeRemote()
+	}
+	if p.Container != nil {
+		return p.containerAlive()
+	}
+
 	// Check if cmd or process is nil
 	if p.Cmd == nil || p.Cmd.Process == nil {
 		return false
@@ -149,6 +320,9 @@ func (p *Process) IsAlive() bool {
 
 // GetPID returns the process ID if the process is running
 func (p *Process) GetPID() int {
+	if p.Container != nil {
+		return p.containerPID
+	}
 	if p.Cmd != nil && p.Cmd.Process != nil {
 		return p.Cmd.Process.Pid
 	}
@@ -158,4 +332,4 @@ func (p *Process) GetPID() int {
 // GetDebugURL returns the Chrome DevTools Protocol URL
 func (p *Process) GetDebugURL() string {
 	return fmt.Sprintf("http://localhost:%d", p.DebugPort)
-}
\ No newline at end of file
+}
@@ -0,0 +1,20 @@
+package browser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestTrustedSPKIFlag tests launching Chromium to accept certificates signed by given keys
+func TestTrustedSPKIFlag(t *testing.T) {
+	process := &Process{DebugPort: 9222, UserDataDir: "/tmp/chromium"}
+	if flags := process.buildFlags(); slices.ContainsFunc(flags, func(flag string) bool { return strings.HasPrefix(flag, "--ignore-certificate-errors") }) {
+		t.Errorf("expected every certificate verified by default, got %v", flags)
+	}
+
+	process.TrustedSPKIs = []string{"AAAA", "BBBB"}
+	if flags := process.buildFlags(); !slices.Contains(flags, "--ignore-certificate-errors-spki-list=AAAA,BBBB") {
+		t.Errorf("expected the key hashes trusted, got %v", flags)
+	}
+}
@@ -0,0 +1,197 @@
+package browser
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procRoot is where process information is read from (Linux procfs)
+const procRoot = "/proc"
+
+// descendants returns pid and every process below it in the process tree.
+// Chromium runs renderers, GPU and utility processes as children of the browser.
+func descendants(pid int) ([]int, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procRoot, err)
+	}
+
+	// Build parent → children map from /proc/<pid>/stat
+	children := make(map[int][]int)
+	for _, entry := range entries {
+		childPID, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		parentPID, err := readParentPID(childPID)
+		if err != nil {
+			continue // Process exited while scanning
+		}
+		children[parentPID] = append(children[parentPID], childPID)
+	}
+
+	tree := []int{pid}
+	for i := 0; i < len(tree); i++ {
+		tree = append(tree, children[tree[i]]...)
+	}
+
+	return tree, nil
+}
+
+// readStatFields returns the fields of /proc/<pid>/stat after the command name
+func readStatFields(pid int) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The command name is wrapped in parentheses and may itself contain spaces
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+
+	return strings.Fields(stat[end+1:]), nil
+}
+
+// readParentPID returns the parent of pid
+func readParentPID(pid int) (int, error) {
+	fields, err := readStatFields(pid)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+
+	// fields[0] is the state, fields[1] the parent PID
+	return strconv.Atoi(fields[1])
+}
+
+// readRSS returns the resident set size of a single process in bytes
+func readRSS(pid int) (uint64, error) {
+	file, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+
+		// Format: "VmRSS:	  123456 kB"
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			break
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse VmRSS: %w", err)
+		}
+		return kb * 1024, nil
+	}
+
+	// Kernel threads and zombies have no VmRSS line
+	return 0, scanner.Err()
+}
+
+// clockTicksPerSecond is USER_HZ, the unit of CPU times in /proc/<pid>/stat.
+// It is 100 on every mainstream Linux build and cannot be queried without cgo.
+const clockTicksPerSecond = 100
+
+// ProcessStats is a point-in-time reading of the browser's process tree
+type ProcessStats struct {
+	RSSBytes     uint64  // Resident memory, summed over the tree
+	CPUSeconds   float64 // User + system CPU time consumed, summed over the tree
+	OpenFDs      int     // Open file descriptors, summed over the tree
+	ProcessCount int     // Browser, renderer, GPU and utility processes
+	ZombieCount  int     // Exited children the browser has not reaped
+}
+
+// readCPUAndState returns the CPU seconds used by pid and its state letter
+func readCPUAndState(pid int) (float64, string, error) {
+	fields, err := readStatFields(pid)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// After the command name: state is field 0, utime field 11, stime field 12
+	if len(fields) < 13 {
+		return 0, "", fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to parse utime: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to parse stime: %w", err)
+	}
+
+	return float64(utime+stime) / clockTicksPerSecond, fields[0], nil
+}
+
+// countFDs returns how many file descriptors pid has open
+func countFDs(pid int) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(procRoot, strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// Stats samples memory, CPU time, file descriptors and zombies across the process tree
+func (p *Process) Stats() (ProcessStats, error) {
+	pid := p.GetPID()
+	if pid == 0 {
+		return ProcessStats{}, fmt.Errorf("process is not running")
+	}
+
+	tree, err := descendants(pid)
+	if err != nil {
+		return ProcessStats{}, err
+	}
+
+	var stats ProcessStats
+	for _, member := range tree {
+		// Members may exit between the scan and the reads; skip what is gone
+		cpu, state, err := readCPUAndState(member)
+		if err != nil {
+			continue
+		}
+		stats.ProcessCount++
+		stats.CPUSeconds += cpu
+
+		if state == "Z" {
+			stats.ZombieCount++
+			continue
+		}
+
+		if rss, err := readRSS(member); err == nil {
+			stats.RSSBytes += rss
+		}
+		if fds, err := countFDs(member); err == nil {
+			stats.OpenFDs += fds
+		}
+	}
+
+	return stats, nil
+}
+
+// MemoryRSS returns the combined resident memory of the browser and its child processes
+func (p *Process) MemoryRSS() (uint64, error) {
+	stats, err := p.Stats()
+	if err != nil {
+		return 0, err
+	}
+	return stats.RSSBytes, nil
+}
@@ -0,0 +1,59 @@
+package browser
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+// TestProcessTreeMemory tests reading the process tree and RSS from /proc
+func TestProcessTreeMemory(t *testing.T) {
+	if _, err := os.Stat(procRoot); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	pid := os.Getpid()
+
+	// The test binary is a child of the go tool, so its parent's tree includes it
+	tree, err := descendants(os.Getppid())
+	if err != nil {
+		t.Fatalf("descendants failed: %v", err)
+	}
+	if !slices.Contains(tree, pid) {
+		t.Errorf("expected pid %d in parent's process tree %v", pid, tree)
+	}
+
+	rss, err := readRSS(pid)
+	if err != nil {
+		t.Fatalf("readRSS failed: %v", err)
+	}
+	if rss == 0 {
+		t.Error("expected non-zero RSS for the running test")
+	}
+}
+
+// TestReadCPUAndState tests parsing CPU time and state of a live process
+func TestReadCPUAndState(t *testing.T) {
+	if _, err := os.Stat(procRoot); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	cpu, state, err := readCPUAndState(os.Getpid())
+	if err != nil {
+		t.Fatalf("readCPUAndState failed: %v", err)
+	}
+	if state == "" || state == "Z" {
+		t.Errorf("unexpected state for running test: %q", state)
+	}
+	if cpu < 0 {
+		t.Errorf("expected non-negative CPU time, got %f", cpu)
+	}
+
+	fds, err := countFDs(os.Getpid())
+	if err != nil {
+		t.Fatalf("countFDs failed: %v", err)
+	}
+	if fds < 3 {
+		t.Errorf("expected at least stdin/stdout/stderr, got %d", fds)
+	}
+}
@@ -0,0 +1,101 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"syscall"
+)
+
+// profileLockFile is held locked by whichever browser runs with a profile
+const profileLockFile = ".browser-query-ai.lock"
+
+// profileNamePattern keeps profile names safe to use as directory names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ErrInvalidProfileName is returned for profile names that aren't safe directory names
+var ErrInvalidProfileName = errors.New("invalid profile name")
+
+// ErrProfileLocked is returned when another browser, possibly one started by another
+// instance of the service, already runs with the profile
+var ErrProfileLocked = errors.New("profile is locked by another browser")
+
+// ValidateProfileName checks that name can name a profile directory
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("%w %q: use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit", ErrInvalidProfileName, name)
+	}
+	return nil
+}
+
+// NewProfileProcess creates a browser process configuration that uses dir as its user
+// data directory and keeps it after Stop, so cookies, storage, cache and extensions
+// survive restarts. dir stays locked until the process stops; a second process asking
+// for it gets ErrProfileLocked.
+func NewProfileProcess(binaryPath, dir string) (*Process, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+
+	lock, err := lockProfile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := GetFreePort()
+	if err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("failed to get free port: %w", err)
+	}
+	debugPort, err := strconv.Atoi(port)
+	if err != nil {
+		ReturnPort(port)
+		lock.Close()
+		return nil, fmt.Errorf("failed to convert port to int: %w", err)
+	}
+
+	// A crashed run's browser on this profile is then recognized as an orphan
+	if err := writeOwner(dir); err != nil {
+		ReturnPort(port)
+		lock.Close()
+		return nil, fmt.Errorf("failed to mark profile directory: %w", err)
+	}
+
+	return &Process{
+		BinaryPath:  binaryPath,
+		DebugPort:   debugPort,
+		UserDataDir: dir,
+		Status:      StatusStarting,
+		Persistent:  true,
+		profileLock: lock,
+	}, nil
+}
+
+// lockProfile takes the profile's lock without waiting. The kernel drops it when the
+// holder exits, so a crash never leaves a profile locked.
+func lockProfile(dir string) (*os.File, error) {
+	lock, err := os.OpenFile(filepath.Join(dir, profileLockFile), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profile lock: %w", err)
+	}
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lock.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: %s", ErrProfileLocked, dir)
+		}
+		return nil, fmt.Errorf("failed to lock profile: %w", err)
+	}
+	return lock, nil
+}
+
+// releaseProfile gives a persistent profile up once its browser has exited
+func (p *Process) releaseProfile() {
+	os.Remove(filepath.Join(p.UserDataDir, ownerFile))
+	if p.profileLock != nil {
+		p.profileLock.Close()
+		p.profileLock = nil
+	}
+}
@@ -0,0 +1,50 @@
+package browser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNewProfileProcess tests that a profile is locked while in use, and kept with its
+// contents when the process lets it go
+func TestNewProfileProcess(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "work")
+
+	process, err := NewProfileProcess("chromium", dir)
+	if err != nil {
+		t.Fatalf("NewProfileProcess failed: %v", err)
+	}
+	if _, err := NewProfileProcess("chromium", dir); !errors.Is(err, ErrProfileLocked) {
+		t.Errorf("expected ErrProfileLocked for a profile in use, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, "Cookies"), []byte("kept"), 0o600)
+	if err := process.Discard(); err != nil {
+		t.Fatalf("Discard failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Cookies")); err != nil {
+		t.Errorf("expected the profile to be kept, got %v", err)
+	}
+
+	again, err := NewProfileProcess("chromium", dir)
+	if err != nil {
+		t.Fatalf("expected the profile to be free again, got %v", err)
+	}
+	again.Discard()
+}
+
+// TestValidateProfileName tests that profile names can't leave the profile directory
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"work", "agent-7.main", "A_1"} {
+		if err := ValidateProfileName(name); err != nil {
+			t.Errorf("expected %q to be valid, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "..", ".hidden", "a/b", "-x"} {
+		if err := ValidateProfileName(name); !errors.Is(err, ErrInvalidProfileName) {
+			t.Errorf("expected %q to be rejected, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package browser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStartTimeout is how long a launched browser gets to answer on its debug port
+const DefaultStartTimeout = 30 * time.Second
+
+// Readiness probe backoff: quick at first, since a warm machine is ready in well under
+// a second, then spaced out so a slow one isn't hammered
+const (
+	probeInitialDelay = 25 * time.Millisecond
+	probeMaxDelay     = 500 * time.Millisecond
+	probeTimeout      = 2 * time.Second
+)
+
+const (
+	// maxPortAttempts bounds how many ports a launch tries when others keep being taken
+	maxPortAttempts = 3
+
+	// activePortFile is written to the profile by Chromium once DevTools is bound,
+	// holding the port on its first line
+	activePortFile = "DevToolsActivePort"
+)
+
+// portCollisionLog is what Chromium prints when it can't bind its debug port. It keeps
+// running without DevTools, so this is the only quick sign.
+var portCollisionLog = []byte("Cannot start http server for devtools")
+
+// ErrStartTimeout is returned when a browser doesn't answer on its debug port in time
+var ErrStartTimeout = errors.New("browser did not become ready")
+
+// errExited is returned when a local browser exits before it becomes ready
+var errExited = errors.New("browser exited during startup")
+
+// startTimeout returns the readiness deadline for this process
+func (p *Process) startTimeout() time.Duration {
+	if p.StartTimeout > 0 {
+		return p.StartTimeout
+	}
+	return DefaultStartTimeout
+}
+
+// waitForDevTools polls the DevTools version endpoint with backoff until it answers or
+// timeout passes. failed, when not nil, is checked between attempts so a browser that
+// crashed or lost its port on launch fails right away instead of at the deadline.
+func waitForDevTools(port int, timeout time.Duration, failed func() error) error {
+	endpoint := fmt.Sprintf("http://localhost:%d/json/version", port)
+	return waitForEndpoint(endpoint, "DevTools", port, timeout, failed, func(status int) bool {
+		return status == http.StatusOK
+	})
+}
+
+// waitForBiDi is waitForDevTools for Firefox, whose remote agent answers any plain HTTP
+// request, if only with 404, once it listens for WebDriver BiDi
+func waitForBiDi(port int, timeout time.Duration, failed func() error) error {
+	endpoint := fmt.Sprintf("http://localhost:%d/", port)
+	return waitForEndpoint(endpoint, "WebDriver BiDi", port, timeout, failed, func(int) bool {
+		return true
+	})
+}
+
+// waitForEndpoint polls endpoint until ready accepts its status or timeout passes
+func waitForEndpoint(endpoint, protocol string, port int, timeout time.Duration, failed func() error, ready func(status int) bool) error {
+	client := &http.Client{Timeout: probeTimeout}
+	deadline := time.Now().Add(timeout)
+	delay := probeInitialDelay
+
+	for {
+		response, err := client.Get(endpoint)
+		if err == nil {
+			response.Body.Close()
+			if ready(response.StatusCode) {
+				return nil
+			}
+		}
+
+		if failed != nil {
+			if err := failed(); err != nil {
+				return err
+			}
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%w: no %s on port %d within %s", ErrStartTimeout, protocol, port, timeout)
+		}
+		time.Sleep(min(delay, remaining))
+		delay = min(delay*2, probeMaxDelay)
+	}
+}
+
+// exited reports whether the local browser process has already exited. It is not
+// reaped until Stop waits on it, so it shows up as a zombie.
+func (p *Process) exited() bool {
+	if p.Cmd == nil || p.Cmd.Process == nil {
+		return false
+	}
+	_, state, err := readCPUAndState(p.Cmd.Process.Pid)
+	return err == nil && state == "Z"
+}
+
+// ownsDevToolsPort reports whether this browser, rather than some other process, is the
+// one listening on the debug port
+func (p *Process) ownsDevToolsPort() bool {
+	data, err := os.ReadFile(filepath.Join(p.UserDataDir, activePortFile))
+	if err != nil {
+		return false
+	}
+	port, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(port) == strconv.Itoa(p.DebugPort)
+}
+
+// collisionWatch reads a browser's stderr for the sign that its debug port was taken
+type collisionWatch struct {
+	collided atomic.Bool
+	tail     []byte // End of the previous write, in case the message is split
+}
+
+func (w *collisionWatch) Write(b []byte) (int, error) {
+	if !w.collided.Load() {
+		joined := append(w.tail, b...)
+		if bytes.Contains(joined, portCollisionLog) {
+			w.collided.Store(true)
+		}
+		keep := min(len(joined), len(portCollisionLog)-1)
+		w.tail = append(w.tail[:0], joined[len(joined)-keep:]...)
+	}
+	return len(b), nil
+}
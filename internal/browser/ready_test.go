@@ -0,0 +1,128 @@
+package browser
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// listenerPort returns the port of a test server
+func listenerPort(t *testing.T, server *httptest.Server) int {
+	t.Helper()
+	return server.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// TestWaitForDevTools tests that the probe returns once DevTools answers, without waiting
+// out a fixed delay
+func TestWaitForDevTools(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Not ready for the first few polls, as while Chromium is still starting
+		if r.URL.Path != "/json/version" || attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Browser":"HeadlessChrome"}`))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if err := waitForDevTools(listenerPort(t, server), 5*time.Second, nil); err != nil {
+		t.Fatalf("waitForDevTools failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the probe to return soon after DevTools answered, took %s", elapsed)
+	}
+}
+
+// TestWaitForDevToolsTimeout tests that the probe gives up at its deadline
+func TestWaitForDevToolsTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	err := waitForDevTools(listenerPort(t, server), 300*time.Millisecond, nil)
+	if !errors.Is(err, ErrStartTimeout) {
+		t.Fatalf("expected ErrStartTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the probe to stop near its deadline, took %s", elapsed)
+	}
+}
+
+// TestWaitForDevToolsExited tests that a browser exiting during startup fails right away
+func TestWaitForDevToolsExited(t *testing.T) {
+	if _, err := os.Stat(procRoot); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	// A "browser" that exits immediately and never listens
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot run true: %v", err)
+	}
+	defer cmd.Wait()
+	process := &Process{Cmd: cmd}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	start := time.Now()
+	err := waitForDevTools(listenerPort(t, server), 10*time.Second, func() error {
+		if process.exited() {
+			return errExited
+		}
+		return nil
+	})
+	if !errors.Is(err, errExited) {
+		t.Fatalf("expected errExited, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected an early failure, took %s", elapsed)
+	}
+}
+
+// TestOwnsDevToolsPort tests telling this browser's DevTools apart from another's on the
+// same port
+func TestOwnsDevToolsPort(t *testing.T) {
+	process := &Process{UserDataDir: t.TempDir(), DebugPort: 9230}
+	if process.ownsDevToolsPort() {
+		t.Error("expected a browser without a port file not to own the port")
+	}
+
+	path := filepath.Join(process.UserDataDir, activePortFile)
+	os.WriteFile(path, []byte("9231\n/devtools/browser/abc"), 0o600)
+	if process.ownsDevToolsPort() {
+		t.Error("expected a port file naming another port not to count")
+	}
+
+	os.WriteFile(path, []byte("9230\n/devtools/browser/abc"), 0o600)
+	if !process.ownsDevToolsPort() {
+		t.Error("expected the browser to own the port its file names")
+	}
+}
+
+// TestCollisionWatch tests spotting Chromium's bind failure in its stderr, also when the
+// message arrives in pieces
+func TestCollisionWatch(t *testing.T) {
+	watch := &collisionWatch{}
+	watch.Write([]byte("[0101/000000.000:ERROR:socket_posix.cc] bind() failed: Address already in use\n"))
+	if watch.collided.Load() {
+		t.Fatal("expected unrelated output not to count")
+	}
+
+	watch.Write([]byte("[0101/000000.000:ERROR:devtools_http_handler.cc] Cannot start http"))
+	watch.Write([]byte(" server for devtools.\n"))
+	if !watch.collided.Load() {
+		t.Error("expected the split message to be spotted")
+	}
+}
@@ -0,0 +1,110 @@
+package browser
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RemoteHandleBase is where local handles for remote browsers start. Handles stand in
+// for the debug port everywhere a port identifies a browser, and never collide with
+// the local port range.
+const RemoteHandleBase = 40000
+
+// remoteProbeTimeout bounds a single reachability check of a remote browser
+const remoteProbeTimeout = 3 * time.Second
+
+// nextRemoteHandle hands out handles for remote browsers
+var nextRemoteHandle atomic.Int64
+
+// NewRemoteProcess describes an already-running browser reachable at endpoint.
+// endpoint is either a DevTools WebSocket URL (ws:// or wss://) or the host:port
+// (optionally http://host:port) of its DevTools HTTP server.
+func NewRemoteProcess(endpoint string) (*Process, error) {
+	if _, err := remoteAddress(endpoint); err != nil {
+		return nil, err
+	}
+
+	return &Process{
+		DebugPort: RemoteHandleBase + int(nextRemoteHandle.Add(1)) - 1,
+		Remote:    endpoint,
+		Status:    StatusStarting,
+	}, nil
+}
+
+// IsRemote reports whether the process is an external browser this service attached to
+func (p *Process) IsRemote() bool {
+	return p.Remote != ""
+}
+
+// remoteAddress returns the host:port a remote endpoint is reached at
+func remoteAddress(endpoint string) (string, error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid remote browser endpoint %q", endpoint)
+	}
+
+	port := parsed.Port()
+	switch parsed.Scheme {
+	case "ws", "http":
+		if port == "" {
+			port = "80"
+		}
+	case "wss":
+		if port == "" {
+			port = "443"
+		}
+	default:
+		return "", fmt.Errorf("unsupported remote browser scheme %q", parsed.Scheme)
+	}
+
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
+
+// isWebSocketEndpoint reports whether the endpoint is a WebSocket URL rather than a DevTools HTTP server
+func isWebSocketEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://")
+}
+
+// DevToolsAddress returns the host:port of a remote browser's DevTools HTTP server,
+// or "" when the endpoint is a WebSocket URL that is dialed directly.
+func (p *Process) DevToolsAddress() string {
+	if !p.IsRemote() || isWebSocketEndpoint(p.Remote) {
+		return ""
+	}
+	address, _ := remoteAddress(p.Remote)
+	return address
+}
+
+// probeRemote checks that the remote browser answers. DevTools HTTP servers must serve
+// /json/version; WebSocket-only endpoints (e.g. hosted browser services) only need to accept TCP.
+func (p *Process) probeRemote() bool {
+	if address := p.DevToolsAddress(); address != "" {
+		client := &http.Client{Timeout: remoteProbeTimeout}
+		response, err := client.Get("http://" + address + "/json/version")
+		if err != nil {
+			return false
+		}
+		response.Body.Close()
+		return response.StatusCode == http.StatusOK
+	}
+
+	address, err := remoteAddress(p.Remote)
+	if err != nil {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", address, remoteProbeTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
@@ -0,0 +1,92 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRemoteAddress tests resolving the dial address of remote endpoints
+func TestRemoteAddress(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{endpoint: "chrome.internal:9222", want: "chrome.internal:9222"},
+		{endpoint: "http://10.0.0.5:9222", want: "10.0.0.5:9222"},
+		{endpoint: "ws://browserless:3000/?token=abc", want: "browserless:3000"},
+		{endpoint: "wss://chrome.example.com?token=abc", want: "chrome.example.com:443"},
+		{endpoint: "ftp://chrome.internal", wantErr: true},
+		{endpoint: "ws://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := remoteAddress(tt.endpoint)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got %q", tt.endpoint, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: expected %q, got %q (err %v)", tt.endpoint, tt.want, got, err)
+		}
+	}
+}
+
+// TestRemoteProcessLifecycle tests that attaching probes the endpoint and that
+// handles stay clear of the local port range
+func TestRemoteProcessLifecycle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/version" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"webSocketDebuggerUrl": "ws://remote/devtools/browser/1"}`))
+	}))
+	defer server.Close()
+
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	process, err := NewRemoteProcess(address)
+	if err != nil {
+		t.Fatalf("failed to create remote process: %v", err)
+	}
+	if process.DebugPort < RemoteHandleBase {
+		t.Errorf("expected handle above %d, got %d", RemoteHandleBase, process.DebugPort)
+	}
+	if process.DevToolsAddress() != address {
+		t.Errorf("expected DevTools address %q, got %q", address, process.DevToolsAddress())
+	}
+
+	if err := process.Start(); err != nil {
+		t.Fatalf("failed to attach: %v", err)
+	}
+	if !process.IsAlive() {
+		t.Error("expected reachable remote browser to be alive")
+	}
+
+	// WebSocket endpoints are only checked for TCP reachability
+	wsProcess, err := NewRemoteProcess("ws://" + address + "/devtools/browser/1")
+	if err != nil {
+		t.Fatalf("failed to create WebSocket remote process: %v", err)
+	}
+	if wsProcess.DebugPort == process.DebugPort {
+		t.Error("expected distinct handles for distinct remote browsers")
+	}
+	if !wsProcess.IsAlive() {
+		t.Error("expected WebSocket endpoint to be reachable")
+	}
+
+	// Stopping only lets go; the browser belongs to someone else
+	if err := process.Stop(); err != nil || process.Status != StatusStopped {
+		t.Errorf("expected stop to succeed, got %v (status %s)", err, process.Status)
+	}
+
+	server.Close()
+	if process.IsAlive() {
+		t.Error("expected closed endpoint to be reported dead")
+	}
+}
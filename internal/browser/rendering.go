@@ -0,0 +1,123 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// fontConfigFile is written into the user data directory of a browser with a fixed font set
+const fontConfigFile = "fonts.conf"
+
+// localePattern accepts BCP 47 tags such as en, en-US or zh-Hant-TW
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// Rendering fixes what a browser would otherwise take from its host when drawing pages,
+// so the same page renders the same on every host
+type Rendering struct {
+	FontDir  string // The only fonts the browser uses; empty uses the host's
+	Locale   string // BCP 47 locale, e.g. en-US; empty uses the host's
+	Timezone string // IANA timezone, e.g. UTC; empty uses the host's
+}
+
+// ValidateLocale checks that locale is a BCP 47 language tag
+func ValidateLocale(locale string) error {
+	if !localePattern.MatchString(locale) {
+		return fmt.Errorf("invalid locale %q, expected a language tag such as en-US", locale)
+	}
+	return nil
+}
+
+// ValidateTimezone checks that timezone names an IANA timezone
+func ValidateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "" || timezone == "Local" {
+		return fmt.Errorf("invalid timezone %q, expected an IANA name such as Europe/Berlin", timezone)
+	}
+	return nil
+}
+
+// Validate checks the settings before any browser is launched with them
+func (r Rendering) Validate() error {
+	if r.FontDir != "" {
+		info, err := os.Stat(r.FontDir)
+		if err != nil {
+			return fmt.Errorf("font directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("font directory %s is not a directory", r.FontDir)
+		}
+	}
+	if r.Locale != "" {
+		if err := ValidateLocale(r.Locale); err != nil {
+			return err
+		}
+	}
+	if r.Timezone != "" {
+		if err := ValidateTimezone(r.Timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderingFlags returns the Chromium flags for r
+func renderingFlags(r Rendering) []string {
+	var flags []string
+	if r.Locale != "" {
+		flags = append(flags, "--lang="+r.Locale) // UI language and Accept-Language
+	}
+	if r.FontDir != "" {
+		// Hinting follows the host's freetype setup; without it glyphs are placed alike everywhere
+		flags = append(flags, "--font-render-hinting=none", "--disable-font-subpixel-positioning")
+	}
+	return flags
+}
+
+// renderingEnv returns the environment variables a browser launched with r needs on top
+// of the server's, writing the font configuration into userDataDir when there is one
+func renderingEnv(r Rendering, userDataDir string) ([]string, error) {
+	var env []string
+	if r.Timezone != "" {
+		env = append(env, "TZ="+r.Timezone)
+	}
+	if r.Locale != "" {
+		posix := strings.ReplaceAll(r.Locale, "-", "_") + ".UTF-8"
+		env = append(env, "LANG="+posix, "LC_ALL="+posix)
+	}
+	if r.FontDir != "" {
+		path, err := writeFontConfig(r.FontDir, userDataDir)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, "FONTCONFIG_FILE="+path)
+	}
+	return env, nil
+}
+
+// writeFontConfig writes a fontconfig file listing only fontDir, leaving out the host's
+// own configuration and with it every system font
+func writeFontConfig(fontDir, userDataDir string) (string, error) {
+	dir, err := filepath.Abs(fontDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve font directory: %w", err)
+	}
+
+	var conf bytes.Buffer
+	conf.WriteString(xml.Header)
+	conf.WriteString("<!DOCTYPE fontconfig SYSTEM \"fonts.dtd\">\n<fontconfig>\n  <dir>")
+	xml.EscapeText(&conf, []byte(dir))
+	conf.WriteString("</dir>\n  <cachedir>")
+	xml.EscapeText(&conf, []byte(filepath.Join(userDataDir, "fontconfig-cache")))
+	conf.WriteString("</cachedir>\n</fontconfig>\n")
+
+	path := filepath.Join(userDataDir, fontConfigFile)
+	if err := os.WriteFile(path, conf.Bytes(), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write font configuration: %w", err)
+	}
+	return path, nil
+}
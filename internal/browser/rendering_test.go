@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestRenderingLaunch tests the flags, environment and font configuration a browser
+// with fixed rendering is launched with
+func TestRenderingLaunch(t *testing.T) {
+	fonts, profile := t.TempDir(), t.TempDir()
+	process := &Process{DebugPort: 9222, UserDataDir: profile}
+	if flags := process.buildFlags(); slices.ContainsFunc(flags, func(flag string) bool { return strings.HasPrefix(flag, "--lang") }) {
+		t.Errorf("expected no locale flag by default, got %v", flags)
+	}
+	if env, err := renderingEnv(process.Rendering, profile); err != nil || len(env) != 0 {
+		t.Errorf("expected no environment by default, got %v, %v", env, err)
+	}
+
+	process.Rendering = Rendering{FontDir: fonts, Locale: "pt-BR", Timezone: "America/Sao_Paulo"}
+	flags := process.buildFlags()
+	if !slices.Contains(flags, "--lang=pt-BR") || !slices.Contains(flags, "--font-render-hinting=none") {
+		t.Errorf("expected the locale and font flags, got %v", flags)
+	}
+
+	env, err := renderingEnv(process.Rendering, profile)
+	if err != nil {
+		t.Fatalf("renderingEnv failed: %v", err)
+	}
+	conf := filepath.Join(profile, fontConfigFile)
+	for _, want := range []string{"TZ=America/Sao_Paulo", "LANG=pt_BR.UTF-8", "FONTCONFIG_FILE=" + conf} {
+		if !slices.Contains(env, want) {
+			t.Errorf("expected %s in %v", want, env)
+		}
+	}
+
+	// Only the configured directory is listed; the host's configuration isn't included
+	data, err := os.ReadFile(conf)
+	if err != nil {
+		t.Fatalf("expected a font configuration: %v", err)
+	}
+	if !strings.Contains(string(data), "<dir>"+fonts+"</dir>") || strings.Contains(string(data), "<include") {
+		t.Errorf("unexpected font configuration:\n%s", data)
+	}
+}
+
+// TestRenderingValidate tests that bad settings are rejected before any launch
+func TestRenderingValidate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "font.ttf")
+	os.WriteFile(file, nil, 0o600)
+
+	if err := (Rendering{FontDir: t.TempDir(), Locale: "zh-Hant-TW", Timezone: "UTC"}).Validate(); err != nil {
+		t.Errorf("expected valid settings to pass, got %v", err)
+	}
+	for _, invalid := range []Rendering{
+		{FontDir: file},
+		{FontDir: filepath.Join(t.TempDir(), "missing")},
+		{Locale: "en_US.UTF-8"},
+		{Timezone: "Local"},
+		{Timezone: "Nowhere/Special"},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", invalid)
+		}
+	}
+}
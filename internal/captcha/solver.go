@@ -0,0 +1,97 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Challenge kinds recognised by the detector and solvers
+const (
+	ProviderRecaptcha  = "recaptcha"
+	ProviderHCaptcha   = "hcaptcha"
+	ProviderTurnstile  = "turnstile"
+	ProviderCloudflare = "cloudflare_challenge"
+	ProviderArkose     = "arkose"
+)
+
+// Error definitions
+var (
+	ErrSolverNotFound      = errors.New("captcha solver not found")
+	ErrUnsupportedProvider = errors.New("captcha provider not supported by solver")
+	ErrSolveFailed         = errors.New("captcha solve failed")
+)
+
+// Challenge is the information a solver needs to produce a token
+type Challenge struct {
+	Provider string // One of the Provider* constants
+	SiteKey  string // Public site key embedded in the widget
+	PageURL  string // URL of the page hosting the widget
+}
+
+// Solver turns a CAPTCHA challenge into a response token.
+// Implementations typically call an external solving service.
+type Solver interface {
+	// Name identifies the solver in API requests (e.g. "2captcha")
+	Name() string
+
+	// Solve blocks until a token is available, the service fails, or ctx ends
+	Solve(ctx context.Context, challenge Challenge) (string, error)
+}
+
+// Registry holds the solvers available to the API
+type Registry struct {
+	solvers     map[string]Solver
+	defaultName string
+	mu          sync.RWMutex
+}
+
+// NewRegistry creates an empty solver registry
+func NewRegistry() *Registry {
+	return &Registry{
+		solvers: make(map[string]Solver),
+	}
+}
+
+// Register adds a solver. The first registered solver becomes the default.
+func (r *Registry) Register(solver Solver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.solvers[solver.Name()] = solver
+	if r.defaultName == "" {
+		r.defaultName = solver.Name()
+	}
+}
+
+// Get returns a solver by name, or the default solver when name is empty
+func (r *Registry) Get(name string) (Solver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if name == "" {
+		name = r.defaultName
+	}
+
+	solver, exists := r.solvers[name]
+	if !exists {
+		return nil, ErrSolverNotFound
+	}
+
+	return solver, nil
+}
+
+// Names returns the registered solver names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.solvers))
+	for name := range r.solvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,163 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+)
+
+const (
+	// DefaultTwoCaptchaURL is the public 2Captcha API endpoint
+	DefaultTwoCaptchaURL = "https://2captcha.com"
+
+	// twoCaptchaNotReady is returned by res.php while workers are still solving
+	twoCaptchaNotReady = "CAPCHA_NOT_READY"
+)
+
+// TwoCaptchaSolver talks to the 2Captcha in.php/res.php API.
+// Several other services (CapMonster, Anti-Captcha compat mode) expose the
+// same protocol, so the base URL is configurable.
+type TwoCaptchaSolver struct {
+	apiKey       string
+	baseURL      string
+	httpClient   *http.Client
+	pollInterval time.Duration
+	initialDelay time.Duration
+}
+
+// twoCaptchaResponse is the JSON envelope of both in.php and res.php
+type twoCaptchaResponse struct {
+	Status  int    `json:"status"`
+	Request string `json:"request"`
+}
+
+// NewTwoCaptchaSolver creates a solver for a 2Captcha-compatible API
+func NewTwoCaptchaSolver(apiKey string, baseURL string) *TwoCaptchaSolver {
+	if baseURL == "" {
+		baseURL = DefaultTwoCaptchaURL
+	}
+
+	// Keep the API key out of logs and error messages (it travels in query strings)
+	redact.Default().AddSecret(apiKey)
+
+	return &TwoCaptchaSolver{
+		apiKey:       apiKey,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		pollInterval: 5 * time.Second,
+		initialDelay: 15 * time.Second,
+	}
+}
+
+// Name returns the solver name used in API requests
+func (s *TwoCaptchaSolver) Name() string {
+	return "2captcha"
+}
+
+// Solve submits the challenge and polls until a token is ready
+func (s *TwoCaptchaSolver) Solve(ctx context.Context, challenge Challenge) (string, error) {
+	params := url.Values{}
+	params.Set("key", s.apiKey)
+	params.Set("json", "1")
+	params.Set("pageurl", challenge.PageURL)
+
+	// Map the challenge onto the service's method names
+	switch challenge.Provider {
+	case ProviderRecaptcha:
+		params.Set("method", "userrecaptcha")
+		params.Set("googlekey", challenge.SiteKey)
+	case ProviderHCaptcha:
+		params.Set("method", "hcaptcha")
+		params.Set("sitekey", challenge.SiteKey)
+	case ProviderTurnstile:
+		params.Set("method", "turnstile")
+		params.Set("sitekey", challenge.SiteKey)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedProvider, challenge.Provider)
+	}
+
+	if challenge.SiteKey == "" {
+		return "", fmt.Errorf("%w: site key not found on page", ErrSolveFailed)
+	}
+
+	// Submit the task
+	submitted, err := s.call(ctx, http.MethodPost, "/in.php", params)
+	if err != nil {
+		return "", err
+	}
+	if submitted.Status != 1 {
+		return "", fmt.Errorf("%w: %s", ErrSolveFailed, submitted.Request)
+	}
+
+	taskID := submitted.Request
+
+	// Poll for the result, waiting a little before the first check
+	poll := url.Values{}
+	poll.Set("key", s.apiKey)
+	poll.Set("action", "get")
+	poll.Set("id", taskID)
+	poll.Set("json", "1")
+
+	wait := s.initialDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("%w: %w", ErrSolveFailed, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait = s.pollInterval
+
+		result, err := s.call(ctx, http.MethodGet, "/res.php", poll)
+		if err != nil {
+			return "", err
+		}
+
+		if result.Status == 1 {
+			return result.Request, nil
+		}
+		if result.Request != twoCaptchaNotReady {
+			return "", fmt.Errorf("%w: %s", ErrSolveFailed, result.Request)
+		}
+	}
+}
+
+// call performs one API request and decodes the JSON envelope
+func (s *TwoCaptchaSolver) call(ctx context.Context, method string, path string, params url.Values) (*twoCaptchaResponse, error) {
+	var req *http.Request
+	var err error
+
+	if method == http.MethodPost {
+		req, err = http.NewRequestWithContext(ctx, method, s.baseURL+path, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, s.baseURL+path+"?"+params.Encode(), nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to build solver request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach captcha solver: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: solver returned HTTP %d", ErrSolveFailed, resp.StatusCode)
+	}
+
+	var decoded twoCaptchaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode solver response: %w", err)
+	}
+
+	return &decoded, nil
+}
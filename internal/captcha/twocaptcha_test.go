@@ -0,0 +1,101 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestSolver points a solver at a fake API with no polling delays
+func newTestSolver(url string) *TwoCaptchaSolver {
+	solver := NewTwoCaptchaSolver("test-key", url)
+	solver.pollInterval = time.Millisecond
+	solver.initialDelay = time.Millisecond
+	return solver
+}
+
+// TestTwoCaptchaSolve tests submit, a not-ready poll and the final token
+func TestTwoCaptchaSolve(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/in.php":
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("failed to parse form: %v", err)
+			}
+			if r.Form.Get("method") != "hcaptcha" || r.Form.Get("sitekey") != "site-123" {
+				t.Errorf("unexpected submit params: %v", r.Form)
+			}
+			json.NewEncoder(w).Encode(twoCaptchaResponse{Status: 1, Request: "task-1"})
+		case "/res.php":
+			polls++
+			if r.URL.Query().Get("id") != "task-1" {
+				t.Errorf("unexpected task id: %s", r.URL.Query().Get("id"))
+			}
+			if polls == 1 {
+				json.NewEncoder(w).Encode(twoCaptchaResponse{Status: 0, Request: twoCaptchaNotReady})
+				return
+			}
+			json.NewEncoder(w).Encode(twoCaptchaResponse{Status: 1, Request: "token-abc"})
+		}
+	}))
+	defer server.Close()
+
+	token, err := newTestSolver(server.URL).Solve(context.Background(), Challenge{
+		Provider: ProviderHCaptcha,
+		SiteKey:  "site-123",
+		PageURL:  "https://example.com/login",
+	})
+	if err != nil {
+		t.Fatalf("Solve failed: %v", err)
+	}
+	if token != "token-abc" {
+		t.Errorf("expected token-abc, got %s", token)
+	}
+	if polls != 2 {
+		t.Errorf("expected 2 polls, got %d", polls)
+	}
+}
+
+// TestTwoCaptchaSolveErrors tests service errors and unsupported challenges
+func TestTwoCaptchaSolveErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(twoCaptchaResponse{Status: 0, Request: "ERROR_ZERO_BALANCE"})
+	}))
+	defer server.Close()
+
+	solver := newTestSolver(server.URL)
+
+	_, err := solver.Solve(context.Background(), Challenge{Provider: ProviderRecaptcha, SiteKey: "k", PageURL: "https://example.com"})
+	if !errors.Is(err, ErrSolveFailed) {
+		t.Errorf("expected ErrSolveFailed, got %v", err)
+	}
+
+	_, err = solver.Solve(context.Background(), Challenge{Provider: ProviderCloudflare, PageURL: "https://example.com"})
+	if !errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("expected ErrUnsupportedProvider, got %v", err)
+	}
+}
+
+// TestRegistryDefault tests that the first registered solver is the default
+func TestRegistryDefault(t *testing.T) {
+	registry := NewRegistry()
+
+	if _, err := registry.Get(""); !errors.Is(err, ErrSolverNotFound) {
+		t.Errorf("expected ErrSolverNotFound on empty registry, got %v", err)
+	}
+
+	registry.Register(NewTwoCaptchaSolver("key", ""))
+
+	solver, err := registry.Get("")
+	if err != nil {
+		t.Fatalf("Get default failed: %v", err)
+	}
+	if solver.Name() != "2captcha" {
+		t.Errorf("expected default 2captcha, got %s", solver.Name())
+	}
+}
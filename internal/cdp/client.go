@@ -3,10 +3,11 @@ package cdp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"github.com/gorilla/websocket"
 )
@@ -26,12 +27,36 @@ type Client struct {
 	wsURL      string                  // WebSocket URL
 	conn       *websocket.Conn         // WebSocket connection
 	requestID  int                     // Counter for generating unique request IDs
-	pending    map[int]chan *Response  // Pending requests waiting for responses
+	pending    map[int]*pendingRequest // Pending requests waiting for responses
 	targetSessions map[string]string   // Target ID → Session ID ( CDP Session )
-	mu         sync.Mutex              // Protects requestID and pending map
+	listeners  map[int]*eventListener  // Registered event listeners by listener ID
+	nextListenerID int                 // Counter for listener IDs
+	listenersMu sync.RWMutex           // Protects listeners
+	mu         sync.Mutex              // Protects requestID, pending map and closed
+	closed     bool                    // Set by Close; nothing is registered after it
 	ctx        context.Context         // Context for cancellation
 	cancel     context.CancelFunc      // Cancel function
 	closeOnce  sync.Once               // Ensures Close() only runs once
+
+	connMu         sync.RWMutex            // Protects conn, state and the hooks below across reconnects
+	state          ConnectionState         // Current connection state
+	discover       func() (string, error)  // Re-discovers the WebSocket URL before reconnecting (optional)
+	stateListeners []func(ConnectionState) // Notified after every state change
+
+	timeouts atomic.Pointer[Timeouts] // Per-kind command timeouts
+
+	writes     chan *outgoing // Commands waiting for the write pump
+	writeStats writeStats
+	connDone   chan struct{} // Closed when the current connection drops (guarded by connMu)
+
+	readLimit atomic.Int64 // Largest message accepted from the browser (0: no limit)
+
+	observer atomic.Pointer[func(method string, failed bool)] // Told how each command went (optional)
+
+	pendingStats pendingStats
+	sweepOnce    sync.Once // Starts the sweeper of leaked pending entries on first connect
+
+	scripts scriptSlots // Bounds the scripts the browser runs at once
 }
 
 // NewClient creates a new CDP client (doesn't connect yet)
@@ -40,17 +65,23 @@ func NewClient(wsURL string) *Client {
 	//Context is used so that when we close the client, the context is done and we can cancel the background reader loop
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Return the client
-	return &Client{
+	client := &Client{
 		wsURL: wsURL,
 		conn: nil,
 		requestID: 0,
-		pending: make(map[int]chan *Response),
+		pending: make(map[int]*pendingRequest),
 		targetSessions: make(map[string]string),
+		listeners: make(map[int]*eventListener),
 		ctx: ctx,
 		cancel: cancel,
 		closeOnce: sync.Once{},
+		state: StateDisconnected,
+		writes: make(chan *outgoing, writeQueueSize),
 	}
+	client.SetTimeouts(DefaultTimeouts())
+
+	// Return the client
+	return client
 }
 
 // Connect establishes the WebSocket connection and starts the message reader
@@ -64,17 +95,43 @@ func (c *Client) Connect() error {
 	}
 
 	//Set the connection inside the client struct
+	c.connMu.Lock()
+	c.applyReadLimit(conn)
+
 	c.conn = conn
+	c.state = StateConnected
+	done := make(chan struct{})
+	c.connDone = done
+	c.connMu.Unlock()
 
 	//Start the background reader loop which is a goroutine that reads from the Websocket either responses or events
-	go c.readLoop()
+	go c.readLoop(conn)
+
+	// Every write goes through one goroutine, the only writer the WebSocket allows
+	go c.writePump(conn, done)
+
+	// Commands whose waiter is gone would otherwise stay pending forever
+	c.sweepOnce.Do(func() { go c.sweepPending() })
 
 	slog.Info("CDP WebSocket connected successfully")
 	return nil
 }
 
+// SetReadLimit bounds the size of a message from the browser, starting with the next
+// connection. A bigger message drops the connection, which is then re-established.
+func (c *Client) SetReadLimit(limit int64) {
+	c.readLimit.Store(limit)
+}
+
+// applyReadLimit sets the configured read limit on a new connection
+func (c *Client) applyReadLimit(conn *websocket.Conn) {
+	if limit := c.readLimit.Load(); limit > 0 {
+		conn.SetReadLimit(limit)
+	}
+}
+
 // Function to read from the Websocket either responses or events
-func (c *Client) readLoop() {
+func (c *Client) readLoop(conn *websocket.Conn) {
 	// Defer ensures message reader logs when stopped
 	defer func() {
 		slog.Info("message reader stopped")
@@ -88,7 +145,7 @@ func (c *Client) readLoop() {
 			return
 		default:
 			// Read message from WebSocket
-			_, message, err := c.conn.ReadMessage()
+			_, message, err := conn.ReadMessage()
 
 			// If there is an error reading the message
 			if err != nil {
@@ -98,8 +155,13 @@ func (c *Client) readLoop() {
 					// Context cancelled - this is expected during shutdown
 					return
 				default:
-					// Unexpected error - log it
-					slog.Error("error reading WebSocket message", "error", err)
+					// Unexpected error - the browser or network dropped us, so try to get back
+					if errors.Is(err, websocket.ErrReadLimit) {
+						slog.Error("browser sent a message over the read limit, dropping the connection", "limit", c.readLimit.Load())
+					} else {
+						slog.Error("error reading WebSocket message", "error", err)
+					}
+					c.handleDisconnect(conn, err)
 					return
 				}
 			}
@@ -110,17 +172,26 @@ func (c *Client) readLoop() {
 	}
 }
 
-// Function to send a command to the browser and wait for the response
-func (c *Client) SendCommand(method string, params map[string]interface{}) (json.RawMessage, error) {
-	// Generate unique request ID
-	c.mu.Lock()
-	c.requestID++
-	id := c.requestID
-	
-	// Create channel for response
-	responseChan := make(chan *Response, 1)
-	c.pending[id] = responseChan
-	c.mu.Unlock()
+// Function to send a command to the browser and wait for the response.
+// The wait ends early if ctx is done or the command's timeout passes.
+func (c *Client) SendCommand(ctx context.Context, method string, params map[string]interface{}) (json.RawMessage, error) {
+	// Don't send anything for a caller that already gave up
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+
+	// Scripts past the browser's limit wait for one to finish
+	release, err := c.acquireScriptSlot(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Generate unique request ID and the channel for the response
+	id, responseChan, err := c.register(method)
+	if err != nil {
+		return nil, err
+	}
 	
 	// Build command
 	command := Command{
@@ -132,39 +203,29 @@ func (c *Client) SendCommand(method string, params map[string]interface{}) (json
 	// Marshal to JSON
 	data, err := json.Marshal(command)
 	if err != nil {
+		c.dropPending(id)
 		return nil, fmt.Errorf("failed to marshal command: %w", err)
 	}
 	
 	// Send over WebSocket
 	slog.Debug("sending CDP command", "method", method, "id", id)
-	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-		// Remove from pending since we failed to send
-		c.mu.Lock()
-		delete(c.pending, id)
-		c.mu.Unlock()
-		return nil, fmt.Errorf("failed to send command: %w", err)
+	return c.exchange(ctx, id, method, data, responseChan)
+}
+
+// exchange writes a command and waits for its response, bounded by the command's
+// timeout and ctx
+func (c *Client) exchange(ctx context.Context, id int, method string, data []byte, responseChan chan *Response) (json.RawMessage, error) {
+	if err := c.write(ctx, id, method, data); err != nil {
+		c.observe(method, err)
+		return nil, err
 	}
-	
-	// Wait for response with timeout
-	select {
-	case response := <-responseChan:
-		// Check if response has error
-		if response.Error != nil {
-			return nil, fmt.Errorf("CDP error: %s (code %d)", response.Error.Message, response.Error.Code)
-		}
-		return response.Result, nil
-		
-	case <-time.After(10 * time.Second):
-		// Timeout
-		c.mu.Lock()
-		delete(c.pending, id)
-		c.mu.Unlock()
-		return nil, fmt.Errorf("command timeout after 10 seconds")
-		
-	case <-c.ctx.Done():
-		// Client is closing
-		return nil, fmt.Errorf("client closed")
+
+	response, err := c.await(ctx, id, method, responseChan)
+	c.observe(method, err)
+	if err != nil {
+		return nil, err
 	}
+	return response.Result, nil
 }
 
 // Function to close the websocket connection
@@ -173,20 +234,21 @@ func (c *Client) Close() error {
 	c.closeOnce.Do(func() {
 		slog.Info("closing CDP client")
 		
-		// Cancel context (stops message reader)
+		// Cancel context (stops message reader and any reconnect attempts)
 		c.cancel()
 		
 		// Close WebSocket connection
+		c.connMu.Lock()
 		if c.conn != nil {
 			err = c.conn.Close()
 		}
+		c.connMu.Unlock()
+		c.setState(StateClosed)
 		
-		// Clean up pending requests
+		// Clean up pending requests; a command registering after this is refused
 		c.mu.Lock()
-		for id, ch := range c.pending {
-			close(ch)
-			delete(c.pending, id)
-		}
+		c.closed = true
+		c.failPendingLocked()
 		c.mu.Unlock()
 	})
 	
@@ -194,14 +256,14 @@ func (c *Client) Close() error {
 }
 
 // AttachToTarget attaches to a target and returns CDP sessionId
-func (c *Client) AttachToTarget(targetID string) (string, error) {
+func (c *Client) AttachToTarget(ctx context.Context, targetID string) (string, error) {
+	// Check if already attached (lock is released before SendCommand, which takes it again)
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Check if already attached
 	if sessionID, exists := c.targetSessions[targetID]; exists {
+			c.mu.Unlock()
 			return sessionID, nil
 	}
+	c.mu.Unlock()
 
 	// Attach to target
 	params := map[string]interface{}{
@@ -209,7 +271,7 @@ func (c *Client) AttachToTarget(targetID string) (string, error) {
 			"flatten":  true,
 	}
 
-	result, err := c.SendCommand("Target.attachToTarget", params)
+	result, err := c.SendCommand(ctx, "Target.attachToTarget", params)
 	if err != nil {
 			return "", fmt.Errorf("failed to attach to target: %w", err)
 	}
@@ -224,13 +286,24 @@ func (c *Client) AttachToTarget(targetID string) (string, error) {
 	}
 
 	// Store session mapping
+	c.mu.Lock()
 	c.targetSessions[targetID] = response.SessionID
+	c.mu.Unlock()
 
 	return response.SessionID, nil
 }
 
 // SendCommandToTarget sends a command to a specific target (page)
-func (c *Client) SendCommandToTarget(targetID, method string, params map[string]interface{}) (json.RawMessage, error) {
+func (c *Client) SendCommandToTarget(ctx context.Context, targetID, method string, params map[string]interface{}) (json.RawMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", method, err)
+	}
+	release, err := c.acquireScriptSlot(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	c.mu.Lock()
 	
 	// Check if we already have a session for this target
@@ -245,7 +318,7 @@ func (c *Client) SendCommandToTarget(targetID, method string, params map[string]
 			"flatten":  true,
 		}
 		
-		result, err := c.SendCommand("Target.attachToTarget", attachParams)
+		result, err := c.SendCommand(ctx, "Target.attachToTarget", attachParams)
 		if err != nil {
 			return nil, fmt.Errorf("failed to attach to target: %w", err)
 		}
@@ -264,11 +337,11 @@ func (c *Client) SendCommandToTarget(targetID, method string, params map[string]
 	}
 	
 	// Now send command with sessionId
-	c.requestID++
-	id := c.requestID
-	responseChan := make(chan *Response, 1)
-	c.pending[id] = responseChan
+	id, responseChan, err := c.registerLocked(method)
 	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
 
 	// Build command with sessionId
 	command := Command{
@@ -281,6 +354,7 @@ func (c *Client) SendCommandToTarget(targetID, method string, params map[string]
 	// Marshal to JSON
 	data, err := json.Marshal(command)
 	if err != nil {
+		c.dropPending(id)
 		return nil, fmt.Errorf("failed to marshal command: %w", err)
 	}
 
@@ -291,28 +365,12 @@ func (c *Client) SendCommandToTarget(targetID, method string, params map[string]
 		"session", sessionID, 
 		"id", id)
 		
-	if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-		c.mu.Lock()
-		delete(c.pending, id)
-		c.mu.Unlock()
-		return nil, fmt.Errorf("failed to send command: %w", err)
-	}
-
-	// Wait for response with timeout
-	select {
-	case response := <-responseChan:
-		if response.Error != nil {
-			return nil, fmt.Errorf("CDP error: %s (code %d)", response.Error.Message, response.Error.Code)
-		}
-		return response.Result, nil
-
-	case <-time.After(30 * time.Second):
-		c.mu.Lock()
-		delete(c.pending, id)
-		c.mu.Unlock()
-		return nil, fmt.Errorf("command timeout after 30 seconds")
+	return c.exchange(ctx, id, method, data, responseChan)
+}
 
-	case <-c.ctx.Done():
-		return nil, fmt.Errorf("client closed")
-	}
-}
\ No newline at end of file
+// AttachedTargets returns how many targets have a CDP session on this client
+func (c *Client) AttachedTargets() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.targetSessions)
+}
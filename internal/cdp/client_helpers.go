@@ -37,14 +37,16 @@ func (c *Client) handleResponse(response *Response) {
 	defer c.mu.Unlock()
 	
 	// Find the channel waiting for this response
-	ch, exists := c.pending[response.ID]
+	request, exists := c.pending[response.ID]
 	if !exists {
+		// Usually a late answer to a command that timed out or was cancelled
+		c.pendingStats.orphaned.Add(1)
 		slog.Warn("received response for unknown request ID", "id", response.ID)
 		return
 	}
 	
 	// Send response to the waiting channel
-	ch <- response
+	request.ch <- response
 	
 	// Remove from pending map
 	delete(c.pending, response.ID)
@@ -52,7 +54,8 @@ func (c *Client) handleResponse(response *Response) {
 
 // Function to handle the event that was received from the browser
 func (c *Client) handleEvent(event *Event) {
-	// For now, just log events
-	// TODO: Later, we can add event handlers
 	slog.Debug("received CDP event", "method", event.Method)
+
+	// Deliver to registered listeners
+	c.dispatchEvent(event)
 }
\ No newline at end of file
@@ -1,13 +1,14 @@
 package cdp
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
 
 // CreateBrowserContext creates a new isolated browser context
-func (c *Client) CreateBrowserContext() (string, error) {
-	result, err := c.SendCommand("Target.createBrowserContext", nil)
+func (c *Client) CreateBrowserContext(ctx context.Context) (string, error) {
+	result, err := c.SendCommand(ctx, "Target.createBrowserContext", nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create browser context: %w", err)
 	}
@@ -24,13 +25,45 @@ func (c *Client) CreateBrowserContext() (string, error) {
 	return response.BrowserContextID, nil
 }
 
+// BrowserContextOptions configures a browser context at creation time
+type BrowserContextOptions struct {
+	ProxyServer     string // e.g. "http://proxy:3128" or "socks5://proxy:1080"
+	ProxyBypassList string // Comma-separated hosts that skip the proxy
+}
+
+// CreateBrowserContextWithOptions creates a new isolated browser context with its own proxy settings
+func (c *Client) CreateBrowserContextWithOptions(ctx context.Context, opts BrowserContextOptions) (string, error) {
+	params := map[string]interface{}{}
+	if opts.ProxyServer != "" {
+		params["proxyServer"] = opts.ProxyServer
+	}
+	if opts.ProxyBypassList != "" {
+		params["proxyBypassList"] = opts.ProxyBypassList
+	}
+
+	result, err := c.SendCommand(ctx, "Target.createBrowserContext", params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create browser context: %w", err)
+	}
+
+	var response struct {
+		BrowserContextID string `json:"browserContextId"`
+	}
+
+	if err := json.Unmarshal(result, &response); err != nil {
+		return "", fmt.Errorf("failed to parse browser context response: %w", err)
+	}
+
+	return response.BrowserContextID, nil
+}
+
 // DisposeBrowserContext closes and removes a browser context
-func (c *Client) DisposeBrowserContext(contextID string) error {
+func (c *Client) DisposeBrowserContext(ctx context.Context, contextID string) error {
 	params := map[string]interface{}{
 		"browserContextId": contextID,
 	}
 
-	_, err := c.SendCommand("Target.disposeBrowserContext", params)
+	_, err := c.SendCommand(ctx, "Target.disposeBrowserContext", params)
 	if err != nil {
 		return fmt.Errorf("failed to dispose browser context: %w", err)
 	}
@@ -39,7 +72,7 @@ func (c *Client) DisposeBrowserContext(contextID string) error {
 }
 
 // CreateTarget creates a new page in the specified browser context
-func (c *Client) CreateTarget(url string, contextID string) (string, error) {
+func (c *Client) CreateTarget(ctx context.Context, url string, contextID string) (string, error) {
 	params := map[string]interface{}{
 		"url": url,
 	}
@@ -49,7 +82,7 @@ func (c *Client) CreateTarget(url string, contextID string) (string, error) {
 		params["browserContextId"] = contextID
 	}
 
-	result, err := c.SendCommand("Target.createTarget", params)
+	result, err := c.SendCommand(ctx, "Target.createTarget", params)
 	if err != nil {
 		return "", fmt.Errorf("failed to create target: %w", err)
 	}
@@ -67,12 +100,12 @@ func (c *Client) CreateTarget(url string, contextID string) (string, error) {
 }
 
 // CloseTarget closes a page/target
-func (c *Client) CloseTarget(targetID string) error {
+func (c *Client) CloseTarget(ctx context.Context, targetID string) error {
 	params := map[string]interface{}{
 		"targetId": targetID,
 	}
 
-	_, err := c.SendCommand("Target.closeTarget", params)
+	_, err := c.SendCommand(ctx, "Target.closeTarget", params)
 	if err != nil {
 		return fmt.Errorf("failed to close target: %w", err)
 	}
@@ -81,8 +114,8 @@ func (c *Client) CloseTarget(targetID string) error {
 }
 
 // GetBrowserVersion returns browser version information
-func (c *Client) GetBrowserVersion() (map[string]string, error) {
-	result, err := c.SendCommand("Browser.getVersion", nil)
+func (c *Client) GetBrowserVersion(ctx context.Context) (map[string]string, error) {
+	result, err := c.SendCommand(ctx, "Browser.getVersion", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get browser version: %w", err)
 	}
@@ -95,4 +128,52 @@ func (c *Client) GetBrowserVersion() (map[string]string, error) {
 	return version, nil
 }
 
+// GetTargets lists all targets known to the browser
+func (c *Client) GetTargets(ctx context.Context) ([]TargetInfo, error) {
+	result, err := c.SendCommand(ctx, "Target.getTargets", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get targets: %w", err)
+	}
+
+	var response struct {
+		TargetInfos []TargetInfo `json:"targetInfos"`
+	}
+
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse targets response: %w", err)
+	}
+
+	return response.TargetInfos, nil
+}
+
+// GetBrowserContexts lists the IDs of the browser's contexts (the default context is not included)
+func (c *Client) GetBrowserContexts(ctx context.Context) ([]string, error) {
+	result, err := c.SendCommand(ctx, "Target.getBrowserContexts", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get browser contexts: %w", err)
+	}
+
+	var response struct {
+		BrowserContextIDs []string `json:"browserContextIds"`
+	}
 
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse browser contexts response: %w", err)
+	}
+
+	return response.BrowserContextIDs, nil
+}
+
+// ActivateTarget focuses a target's window and tab
+func (c *Client) ActivateTarget(ctx context.Context, targetID string) error {
+	params := map[string]interface{}{
+		"targetId": targetID,
+	}
+
+	_, err := c.SendCommand(ctx, "Target.activateTarget", params)
+	if err != nil {
+		return fmt.Errorf("failed to activate target: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package cdp
+
+// EventHandler receives CDP events.
+// Handlers run on the client's reader goroutine, so they must not block and
+// must not send CDP commands themselves (the response could never be read).
+type EventHandler func(event *Event)
+
+// eventListener is a registered handler with its filters
+type eventListener struct {
+	method    string // Event method to match ("" matches all)
+	sessionID string // CDP session to match ("" matches all, including browser-level events)
+	handler   EventHandler
+}
+
+// OnEvent registers a handler for events matching method and CDP sessionID.
+// Empty filters match everything. The returned function removes the handler.
+func (c *Client) OnEvent(method string, sessionID string, handler EventHandler) func() {
+	c.listenersMu.Lock()
+	c.nextListenerID++
+	id := c.nextListenerID
+	c.listeners[id] = &eventListener{
+		method:    method,
+		sessionID: sessionID,
+		handler:   handler,
+	}
+	c.listenersMu.Unlock()
+
+	return func() {
+		c.listenersMu.Lock()
+		delete(c.listeners, id)
+		c.listenersMu.Unlock()
+	}
+}
+
+// dispatchEvent delivers an event to every matching listener
+func (c *Client) dispatchEvent(event *Event) {
+	// Copy matching handlers so they run without holding the lock
+	c.listenersMu.RLock()
+	handlers := make([]EventHandler, 0)
+	for _, listener := range c.listeners {
+		if listener.method != "" && listener.method != event.Method {
+			continue
+		}
+		if listener.sessionID != "" && listener.sessionID != event.SessionID {
+			continue
+		}
+		handlers = append(handlers, listener.handler)
+	}
+	c.listenersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// Listeners returns how many event handlers are registered
+func (c *Client) Listeners() int {
+	c.listenersMu.RLock()
+	defer c.listenersMu.RUnlock()
+	return len(c.listeners)
+}
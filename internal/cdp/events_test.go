@@ -0,0 +1,35 @@
+package cdp
+
+import (
+	"testing"
+)
+
+// TestDispatchEventFilters tests method and session filtering of listeners
+func TestDispatchEventFilters(t *testing.T) {
+	client := NewClient("ws://unused")
+
+	var all, frames, otherSession int
+	client.OnEvent("", "", func(event *Event) { all++ })
+	unsubscribe := client.OnEvent("Page.screencastFrame", "S1", func(event *Event) { frames++ })
+	client.OnEvent("Page.screencastFrame", "S2", func(event *Event) { otherSession++ })
+
+	client.dispatchEvent(&Event{Method: "Page.screencastFrame", SessionID: "S1"})
+	client.dispatchEvent(&Event{Method: "Target.targetCreated"})
+
+	if all != 2 {
+		t.Errorf("expected catch-all listener to see 2 events, got %d", all)
+	}
+	if frames != 1 {
+		t.Errorf("expected 1 frame for S1, got %d", frames)
+	}
+	if otherSession != 0 {
+		t.Errorf("expected no frames for S2, got %d", otherSession)
+	}
+
+	// Removed listeners stop receiving events
+	unsubscribe()
+	client.dispatchEvent(&Event{Method: "Page.screencastFrame", SessionID: "S1"})
+	if frames != 1 {
+		t.Errorf("expected unsubscribed listener to stay at 1, got %d", frames)
+	}
+}
@@ -0,0 +1,39 @@
+package cdp
+
+import (
+	"context"
+	"errors"
+)
+
+// codeInternalError is the JSON-RPC code of a browser failing inside a command, as
+// opposed to rejecting its parameters
+const codeInternalError = -32603
+
+// IsBrowserFault reports whether err means the browser failed a command: it didn't
+// answer in time, the connection dropped, it fell too far behind to take the command,
+// or it failed internally. Errors the caller caused, such as a selector matching nothing
+// or a cancelled request, are not faults.
+func IsBrowserFault(err error) bool {
+	if errors.Is(err, ErrCommandTimeout) || errors.Is(err, ErrConnectionLost) || errors.Is(err, ErrWriteQueueFull) {
+		return true
+	}
+	var responseErr *ResponseError
+	return errors.As(err, &responseErr) && responseErr.Code == codeInternalError
+}
+
+// OnCommand sets a callback run after every command with whether the browser was at
+// fault (see IsBrowserFault). Commands the caller gave up on and those failed by Close
+// are left out, as they say nothing about the browser. It runs on the caller's goroutine
+// and must be quick.
+func (c *Client) OnCommand(observer func(method string, failed bool)) {
+	c.observer.Store(&observer)
+}
+
+// observe reports a finished command to the OnCommand callback
+func (c *Client) observe(method string, err error) {
+	observer := c.observer.Load()
+	if observer == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrClientClosed) {
+		return
+	}
+	(*observer)(method, IsBrowserFault(err))
+}
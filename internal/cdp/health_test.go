@@ -0,0 +1,83 @@
+package cdp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestOnCommand tests which command outcomes count as browser faults
+func TestOnCommand(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	// Answers by method: errors for DOM.*, an internal error for Page.crash, nothing for Runtime.*
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var command Command
+			if err := conn.ReadJSON(&command); err != nil {
+				return
+			}
+			response := Response{ID: command.ID, Result: []byte(`{}`)}
+			switch {
+			case strings.HasPrefix(command.Method, "DOM."):
+				response = Response{ID: command.ID, Error: &ResponseError{Code: -32000, Message: "No node with given id found"}}
+			case command.Method == "Page.crash":
+				response = Response{ID: command.ID, Error: &ResponseError{Code: codeInternalError, Message: "Internal error"}}
+			case strings.HasPrefix(command.Method, "Runtime."):
+				continue
+			}
+			if err := conn.WriteJSON(response); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+	client.SetTimeouts(Timeouts{Evaluate: 50 * time.Millisecond})
+
+	var mu sync.Mutex
+	outcomes := make(map[string]bool)
+	client.OnCommand(func(method string, failed bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		outcomes[method] = failed
+	})
+
+	ctx := context.Background()
+	client.SendCommand(ctx, "Page.enable", nil)
+	client.SendCommand(ctx, "DOM.querySelector", nil)
+	client.SendCommand(ctx, "Page.crash", nil)
+	client.SendCommand(ctx, "Runtime.evaluate", nil)
+
+	cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	client.SendCommand(cancelled, "Runtime.awaitPromise", nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := map[string]bool{"Page.enable": false, "DOM.querySelector": false, "Page.crash": true, "Runtime.evaluate": true}
+	for method, failed := range want {
+		if got, ok := outcomes[method]; !ok || got != failed {
+			t.Errorf("%s: expected failed=%v, got %v (reported %v)", method, failed, got, ok)
+		}
+	}
+	if _, ok := outcomes["Runtime.awaitPromise"]; ok {
+		t.Error("expected a command the caller gave up on to go unreported")
+	}
+}
@@ -0,0 +1,119 @@
+package cdp
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Pending request bookkeeping
+const (
+	pendingSweepInterval = 30 * time.Second
+	pendingGrace         = 5 * time.Second // Past the longest wait before an entry counts as leaked
+)
+
+// pendingRequest is a command waiting for the browser's response
+type pendingRequest struct {
+	ch     chan *Response
+	method string
+	since  time.Time
+}
+
+// PendingStats describes the commands waiting for the browser's response
+type PendingStats struct {
+	Pending       int           `json:"pending"`        // Commands waiting now
+	OldestPending time.Duration `json:"oldest_pending"` // How long the oldest has waited
+	Swept         uint64        `json:"swept"`          // Entries nobody waited for any more, cancelled by the sweeper
+	Orphaned      uint64        `json:"orphaned"`       // Responses that arrived for no waiting command
+}
+
+// pendingStats are the counters behind PendingStats
+type pendingStats struct {
+	swept    atomic.Uint64
+	orphaned atomic.Uint64
+}
+
+// register adds a pending entry for a new command and returns its ID and the channel
+// its response arrives on. A closed client registers nothing.
+func (c *Client) register(method string) (int, chan *Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.registerLocked(method)
+}
+
+// registerLocked is register for callers holding c.mu
+func (c *Client) registerLocked(method string) (int, chan *Response, error) {
+	if c.closed {
+		return 0, nil, &ConnectionError{Method: method, Err: ErrClientClosed}
+	}
+
+	c.requestID++
+	request := &pendingRequest{ch: make(chan *Response, 1), method: method, since: time.Now()}
+	c.pending[c.requestID] = request
+	return c.requestID, request.ch, nil
+}
+
+// failPendingLocked closes every pending entry's channel, so its waiter reports the
+// connection lost. Callers hold c.mu.
+func (c *Client) failPendingLocked() {
+	for id, request := range c.pending {
+		close(request.ch)
+		delete(c.pending, id)
+	}
+}
+
+// sweepPending cancels leaked entries until the client is closed
+func (c *Client) sweepPending() {
+	ticker := time.NewTicker(pendingSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.sweepStale(time.Now())
+		}
+	}
+}
+
+// sweepStale cancels the entries older than any command may wait: a full write queue
+// first, then the longest command timeout. Every wait drops its entry when it ends, so
+// these lost their waiter and are logged as leaks. It returns how many it cancelled.
+func (c *Client) sweepStale(now time.Time) int {
+	timeouts := c.Timeouts()
+	maxAge := timeouts.Default + timeouts.longest() + pendingGrace
+	url := c.url()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	swept := 0
+	for id, request := range c.pending {
+		age := now.Sub(request.since)
+		if age < maxAge {
+			continue
+		}
+		close(request.ch)
+		delete(c.pending, id)
+		swept++
+		slog.Warn("cancelled a CDP command nobody waits for", "url", url, "id", id, "method", request.method, "age", age)
+	}
+	c.pendingStats.swept.Add(uint64(swept))
+	return swept
+}
+
+// PendingStats reports the commands waiting for the browser's response
+func (c *Client) PendingStats() PendingStats {
+	c.mu.Lock()
+	stats := PendingStats{Pending: len(c.pending)}
+	now := time.Now()
+	for _, request := range c.pending {
+		stats.OldestPending = max(stats.OldestPending, now.Sub(request.since))
+	}
+	c.mu.Unlock()
+
+	stats.Swept = c.pendingStats.swept.Load()
+	stats.Orphaned = c.pendingStats.orphaned.Load()
+	return stats
+}
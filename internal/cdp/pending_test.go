@@ -0,0 +1,68 @@
+package cdp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestSweepStale tests that entries outliving every timeout are cancelled
+func TestSweepStale(t *testing.T) {
+	client := NewClient("ws://127.0.0.1:0")
+	client.SetTimeouts(Timeouts{Default: time.Second, Navigation: 3 * time.Second})
+
+	_, stale, _ := client.register("Page.navigate")
+	_, fresh, _ := client.register("DOM.getDocument")
+	client.mu.Lock()
+	for _, request := range client.pending {
+		if request.ch == stale {
+			request.since = time.Now().Add(-time.Minute)
+		}
+	}
+	client.mu.Unlock()
+
+	if swept := client.sweepStale(time.Now()); swept != 1 {
+		t.Fatalf("expected one entry swept, got %d", swept)
+	}
+	if _, ok := <-stale; ok {
+		t.Error("expected the stale entry's channel closed")
+	}
+	select {
+	case <-fresh:
+		t.Error("expected the fresh entry left waiting")
+	default:
+	}
+
+	stats := client.PendingStats()
+	if stats.Pending != 1 || stats.Swept != 1 || stats.OldestPending <= 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// A late answer to a swept command is counted, not delivered
+	client.handleResponse(&Response{ID: 1})
+	if stats := client.PendingStats(); stats.Orphaned != 1 || stats.Pending != 1 {
+		t.Errorf("expected one orphaned response, got %+v", stats)
+	}
+}
+
+// TestCloseStrandsNothing tests that Close fails waiting commands and refuses new ones
+func TestCloseStrandsNothing(t *testing.T) {
+	client := NewClient("ws://127.0.0.1:0")
+	_, waiting, _ := client.register("DOM.getDocument")
+
+	client.Close()
+	if _, ok := <-waiting; ok {
+		t.Error("expected the waiting command's channel closed")
+	}
+
+	if _, err := client.SendCommand(context.Background(), "Browser.getVersion", nil); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed, got %v", err)
+	}
+	if _, err := client.SendCommandToTarget(context.Background(), "T1", "DOM.getDocument", nil); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed for a target, got %v", err)
+	}
+	if pending := client.PendingStats().Pending; pending != 0 {
+		t.Errorf("expected nothing pending after Close, got %d", pending)
+	}
+}
@@ -0,0 +1,202 @@
+package cdp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ConnectionState describes the client's WebSocket connection
+type ConnectionState string
+
+const (
+	StateDisconnected ConnectionState = "disconnected" // Not connected yet
+	StateConnected    ConnectionState = "connected"
+	StateReconnecting ConnectionState = "reconnecting" // Dropped; retrying with backoff
+	StateClosed       ConnectionState = "closed"       // Closed by Close; never reconnects
+)
+
+// Reconnect backoff bounds
+const (
+	reconnectInitialDelay = 250 * time.Millisecond
+	reconnectMaxDelay     = 30 * time.Second
+)
+
+// ErrConnectionLost is wrapped by ConnectionError when the WebSocket dropped
+var ErrConnectionLost = errors.New("connection to browser lost")
+
+// ErrClientClosed is wrapped by ConnectionError when the client was closed deliberately
+var ErrClientClosed = errors.New("client closed")
+
+// ConnectionError is returned for a command that failed because the connection to the
+// browser was lost. A command that was already sent may or may not have run, so it
+// is never replayed automatically; only commands that are safe to repeat should be retried.
+type ConnectionError struct {
+	Method string // CDP method that failed
+	Err    error  // ErrConnectionLost or ErrClientClosed
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Method, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err means the command may succeed if sent again once
+// the connection is back or the write queue has room. Errors after Close are not retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrWriteQueueFull) {
+		return true
+	}
+	var connErr *ConnectionError
+	return errors.As(err, &connErr) && errors.Is(connErr.Err, ErrConnectionLost)
+}
+
+// State returns the current connection state
+func (c *Client) State() ConnectionState {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.state
+}
+
+// IsConnected reports whether commands can currently be sent
+func (c *Client) IsConnected() bool {
+	return c.State() == StateConnected
+}
+
+// SetDiscovery sets how the WebSocket URL is found again before reconnecting.
+// The browser-level URL contains an ID that changes when the browser restarts.
+func (c *Client) SetDiscovery(discover func() (string, error)) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.discover = discover
+}
+
+// OnStateChange registers a callback for connection state changes. Callbacks run on
+// their own goroutine, so they may send commands.
+func (c *Client) OnStateChange(listener func(ConnectionState)) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	c.stateListeners = append(c.stateListeners, listener)
+}
+
+// setState records a state change and notifies listeners
+func (c *Client) setState(state ConnectionState) {
+	c.connMu.Lock()
+	c.state = state
+	listeners := append([]func(ConnectionState){}, c.stateListeners...)
+	c.connMu.Unlock()
+
+	for _, listener := range listeners {
+		go listener(state)
+	}
+}
+
+// dropPending forgets a request that will never get a response
+func (c *Client) dropPending(id int) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// lostError explains why a command could not complete
+func (c *Client) lostError(method string) error {
+	if c.ctx.Err() != nil {
+		return &ConnectionError{Method: method, Err: ErrClientClosed}
+	}
+	return &ConnectionError{Method: method, Err: ErrConnectionLost}
+}
+
+// handleDisconnect fails in-flight commands and starts reconnecting. conn is the
+// connection whose reader stopped; a stale reader never disturbs a newer connection.
+func (c *Client) handleDisconnect(conn *websocket.Conn, cause error) {
+	c.connMu.Lock()
+	if c.conn != conn || c.state != StateConnected {
+		c.connMu.Unlock()
+		return
+	}
+	url := c.wsURL
+	close(c.connDone)
+	c.connMu.Unlock()
+	conn.Close()
+
+	// Waiting commands get a closed channel and report a retryable error
+	c.mu.Lock()
+	c.failPendingLocked()
+
+	// Attachments (flattened target sessions) belong to the old connection
+	c.targetSessions = make(map[string]string)
+	c.mu.Unlock()
+
+	slog.Warn("CDP connection lost, reconnecting", "url", url, "error", cause)
+	c.setState(StateReconnecting)
+
+	go c.reconnectLoop()
+}
+
+// reconnectLoop redials with exponential backoff until it succeeds or the client is closed
+func (c *Client) reconnectLoop() {
+	delay := reconnectInitialDelay
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		if err := c.redial(); err != nil {
+			slog.Debug("CDP reconnect attempt failed", "attempt", attempt, "error", err)
+			delay = min(delay*2, reconnectMaxDelay)
+			continue
+		}
+
+		slog.Info("CDP connection restored", "attempts", attempt)
+		return
+	}
+}
+
+// redial discovers the WebSocket URL again and connects to it
+func (c *Client) redial() error {
+	c.connMu.RLock()
+	discover := c.discover
+	c.connMu.RUnlock()
+
+	url := c.wsURL
+	if discover != nil {
+		discovered, err := discover()
+		if err != nil {
+			return fmt.Errorf("failed to discover WebSocket URL: %w", err)
+		}
+		url = discovered
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+	}
+	c.applyReadLimit(conn)
+
+	c.connMu.Lock()
+	// Close may have run while dialing
+	if c.ctx.Err() != nil {
+		c.connMu.Unlock()
+		conn.Close()
+		return nil
+	}
+	c.conn = conn
+	c.wsURL = url
+	done := make(chan struct{})
+	c.connDone = done
+	c.connMu.Unlock()
+
+	go c.readLoop(conn)
+	go c.writePump(conn, done)
+	c.setState(StateConnected)
+	return nil
+}
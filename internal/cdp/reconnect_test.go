@@ -0,0 +1,145 @@
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestReconnectAfterDrop tests that a dropped connection fails in-flight commands
+// with a retryable error and that the client reconnects on its own
+func TestReconnectAfterDrop(t *testing.T) {
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		first := connections.Add(1) == 1
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			// The first connection drops as soon as a command arrives
+			if first {
+				return
+			}
+
+			var request struct {
+				ID int `json:"id"`
+			}
+			json.Unmarshal(message, &request)
+			conn.WriteJSON(map[string]interface{}{"id": request.ID, "result": map[string]interface{}{}})
+		}
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	client := NewClient(wsURL)
+
+	var discovered atomic.Int32
+	client.SetDiscovery(func() (string, error) {
+		discovered.Add(1)
+		return wsURL, nil
+	})
+
+	states := make(chan ConnectionState, 4)
+	client.OnStateChange(func(state ConnectionState) { states <- state })
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	_, err := client.SendCommand(context.Background(), "Page.reload", nil)
+	if !IsRetryable(err) {
+		t.Fatalf("expected retryable error for in-flight command, got %v", err)
+	}
+
+	for _, want := range []ConnectionState{StateReconnecting, StateConnected} {
+		select {
+		case state := <-states:
+			if state != want {
+				t.Fatalf("expected state %s, got %s", want, state)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for state %s", want)
+		}
+	}
+
+	if discovered.Load() == 0 {
+		t.Error("expected the WebSocket URL to be re-discovered")
+	}
+	if _, err := client.SendCommand(context.Background(), "Browser.getVersion", nil); err != nil {
+		t.Errorf("expected command to succeed after reconnect, got %v", err)
+	}
+
+	// Errors after Close are final
+	client.Close()
+	if _, err := client.SendCommand(context.Background(), "Browser.getVersion", nil); err == nil || IsRetryable(err) {
+		t.Errorf("expected non-retryable error after Close, got %v", err)
+	}
+}
+
+// TestReadLimit tests that a message over the read limit drops the connection instead
+// of being read into memory
+func TestReadLimit(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var request struct {
+				ID int `json:"id"`
+			}
+			json.Unmarshal(message, &request)
+			conn.WriteJSON(map[string]interface{}{"id": request.ID, "result": map[string]interface{}{"data": strings.Repeat("x", 4096)}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	client.SetReadLimit(1024)
+	states := make(chan ConnectionState, 4)
+	client.OnStateChange(func(state ConnectionState) { states <- state })
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.SendCommand(context.Background(), "Page.captureScreenshot", nil); !IsRetryable(err) {
+		t.Fatalf("expected a retryable error for an oversized response, got %v", err)
+	}
+
+	select {
+	case state := <-states:
+		if state != StateReconnecting {
+			t.Fatalf("expected state %s, got %s", StateReconnecting, state)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the connection to drop")
+	}
+}
@@ -0,0 +1,156 @@
+package cdp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrScriptQueueTimeout is wrapped by the error for a script call that waited its whole
+// evaluate timeout for one of the browser's script slots. The script was never sent.
+var ErrScriptQueueTimeout = fmt.Errorf("%w waiting for a script slot", ErrCommandTimeout)
+
+// ScriptStats describes the client's script slots
+type ScriptStats struct {
+	ScriptLimit     int    `json:"script_limit"` // Script calls the browser may run at once (0: no limit)
+	ScriptsRunning  int    `json:"scripts_running"`
+	ScriptsQueued   int    `json:"scripts_queued"` // Waiting for a slot now
+	MaxScriptsQueue int    `json:"max_scripts_queued"`
+	ScriptsDelayed  uint64 `json:"scripts_delayed"`   // Calls that had to wait for a slot
+	ScriptsTimedOut uint64 `json:"scripts_timed_out"` // Calls that gave up waiting with ErrScriptQueueTimeout
+}
+
+// scriptSlots bounds how many script calls run in the browser at once. Calls past the
+// limit wait their turn in order.
+type scriptSlots struct {
+	mu       sync.Mutex
+	limit    int
+	running  int
+	waiting  []chan struct{} // Closed when the waiter is given a slot
+	maxQueue int
+	delayed  uint64
+	timedOut uint64
+
+	congested bool // Calls have been queueing since the queue was last empty
+}
+
+// isScript reports whether method runs page JavaScript, and so takes a script slot.
+// Runtime.awaitPromise only waits on a script that already ran.
+func isScript(method string) bool {
+	return method == "Runtime.evaluate" || method == "Runtime.callFunctionOn"
+}
+
+// SetScriptLimit bounds how many Runtime.evaluate and Runtime.callFunctionOn calls the
+// browser runs at once; the rest queue. 0 removes the limit.
+func (c *Client) SetScriptLimit(limit int) {
+	c.scripts.mu.Lock()
+	defer c.scripts.mu.Unlock()
+	c.scripts.limit = max(limit, 0)
+	c.scripts.grantLocked()
+}
+
+// ScriptStats reports the state of the client's script slots
+func (c *Client) ScriptStats() ScriptStats {
+	c.scripts.mu.Lock()
+	defer c.scripts.mu.Unlock()
+	return ScriptStats{
+		ScriptLimit:     c.scripts.limit,
+		ScriptsRunning:  c.scripts.running,
+		ScriptsQueued:   len(c.scripts.waiting),
+		MaxScriptsQueue: c.scripts.maxQueue,
+		ScriptsDelayed:  c.scripts.delayed,
+		ScriptsTimedOut: c.scripts.timedOut,
+	}
+}
+
+// acquireScriptSlot waits for a script slot when method runs a script, for up to the
+// evaluate timeout. The returned function gives the slot back once the call is over.
+func (c *Client) acquireScriptSlot(ctx context.Context, method string) (func(), error) {
+	if !isScript(method) {
+		return func() {}, nil
+	}
+	slots := &c.scripts
+
+	slots.mu.Lock()
+	if len(slots.waiting) == 0 && slots.free() {
+		slots.running++
+		slots.mu.Unlock()
+		return slots.release, nil
+	}
+	granted := make(chan struct{})
+	slots.waiting = append(slots.waiting, granted)
+	slots.delayed++
+	slots.maxQueue = max(slots.maxQueue, len(slots.waiting))
+	warn := !slots.congested
+	slots.congested = true
+	limit := slots.limit
+	slots.mu.Unlock()
+
+	if warn {
+		slog.Warn("browser at its script limit, queueing scripts", "url", c.url(), "limit", limit)
+	}
+
+	timeout := c.Timeouts().Evaluate
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	timedOut := false
+	select {
+	case <-granted:
+		return slots.release, nil
+	case <-timer.C:
+		err = fmt.Errorf("%s: %w after %s (limit %d)", method, ErrScriptQueueTimeout, timeout, limit)
+		timedOut = true
+	case <-ctx.Done():
+		err = fmt.Errorf("%s: %w", method, ctx.Err())
+	case <-c.ctx.Done():
+		err = c.lostError(method)
+	}
+
+	slots.mu.Lock()
+	defer slots.mu.Unlock()
+	for i, waiter := range slots.waiting {
+		if waiter == granted {
+			slots.waiting = append(slots.waiting[:i], slots.waiting[i+1:]...)
+			if timedOut {
+				slots.timedOut++
+			}
+			if len(slots.waiting) == 0 {
+				slots.congested = false
+			}
+			return nil, err
+		}
+	}
+	// The slot came through as the wait ended; pass it on
+	slots.running--
+	slots.grantLocked()
+	return nil, err
+}
+
+// free reports whether another script may start. It requires s.mu to be held.
+func (s *scriptSlots) free() bool {
+	return s.limit == 0 || s.running < s.limit
+}
+
+// release gives a slot back, handing it to the longest waiting call
+func (s *scriptSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	s.grantLocked()
+}
+
+// grantLocked gives free slots to waiting calls, oldest first. It requires s.mu to be held.
+func (s *scriptSlots) grantLocked() {
+	for len(s.waiting) > 0 && s.free() {
+		s.running++
+		close(s.waiting[0])
+		s.waiting = s.waiting[1:]
+	}
+	if len(s.waiting) == 0 {
+		s.congested = false
+	}
+}
@@ -0,0 +1,138 @@
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestScriptLimit tests that script calls past the limit queue until one finishes, that
+// other commands never wait for them, and that a queued call gives up after the
+// evaluate timeout without being sent
+func TestScriptLimit(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	hold := make(chan struct{})
+	var running, peak atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var writeMu sync.Mutex
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var request struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+			}
+			json.Unmarshal(message, &request)
+
+			go func() {
+				// Scripts run until the test lets them finish
+				if request.Method == "Runtime.evaluate" {
+					now := running.Add(1)
+					for {
+						if seen := peak.Load(); now <= seen || peak.CompareAndSwap(seen, now) {
+							break
+						}
+					}
+					<-hold
+					running.Add(-1)
+				}
+				writeMu.Lock()
+				defer writeMu.Unlock()
+				conn.WriteJSON(map[string]interface{}{"id": request.ID, "result": map[string]interface{}{}})
+			}()
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+	client.SetScriptLimit(2)
+
+	const scripts = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, scripts)
+	for range scripts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.SendCommand(context.Background(), "Runtime.evaluate", nil); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.ScriptStats().ScriptsQueued != scripts-2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := client.ScriptStats(); stats.ScriptsRunning != 2 || stats.ScriptsQueued != scripts-2 || stats.ScriptLimit != 2 {
+		t.Fatalf("expected 2 scripts running and the rest queued, got %+v", stats)
+	}
+
+	// Other commands go straight through
+	if _, err := client.SendCommand(context.Background(), "Page.enable", nil); err != nil {
+		t.Fatalf("expected a non-script command unaffected, got %v", err)
+	}
+
+	close(hold)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("expected every queued script to run, got %v", err)
+	}
+	stats := client.ScriptStats()
+	if peak.Load() != 2 || stats.ScriptsRunning != 0 || stats.ScriptsDelayed != scripts-2 || stats.MaxScriptsQueue != scripts-2 {
+		t.Errorf("expected at most 2 scripts at once, got peak %d and %+v", peak.Load(), stats)
+	}
+
+	// A queued call gives up after the evaluate timeout, and is never sent
+	client.SetTimeouts(Timeouts{Evaluate: 50 * time.Millisecond})
+	client.SetScriptLimit(1)
+	client.scripts.mu.Lock()
+	client.scripts.running = 1 // A script the browser is busy with
+	client.scripts.mu.Unlock()
+
+	_, err := client.SendCommand(context.Background(), "Runtime.callFunctionOn", nil)
+	if !errors.Is(err, ErrScriptQueueTimeout) || !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("expected ErrScriptQueueTimeout, got %v", err)
+	}
+	if stats := client.ScriptStats(); stats.ScriptsTimedOut != 1 || stats.ScriptsQueued != 0 {
+		t.Errorf("unexpected stats after the timeout: %+v", stats)
+	}
+
+	// Raising the limit lets a queued call through at once
+	done := make(chan error, 1)
+	client.SetTimeouts(Timeouts{Evaluate: 5 * time.Second})
+	go func() {
+		_, err := client.SendCommand(context.Background(), "Runtime.callFunctionOn", nil)
+		done <- err
+	}()
+	for client.ScriptStats().ScriptsQueued != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	client.SetScriptLimit(0)
+	if err := <-done; err != nil {
+		t.Fatalf("expected the queued call to run once the limit went, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package cdp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timeouts bounds how long a command waits for the browser, by kind of command.
+// A deadline on the caller's context applies as well; whichever is sooner wins.
+type Timeouts struct {
+	Default    time.Duration // Any command not covered below
+	Navigation time.Duration // Page.navigate, Page.reload and history navigation
+	Evaluate   time.Duration // Runtime.evaluate, Runtime.callFunctionOn, Runtime.awaitPromise
+	Screenshot time.Duration // Page.captureScreenshot, Page.printToPDF
+}
+
+// DefaultTimeouts returns the timeouts used unless configured otherwise
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Default:    10 * time.Second,
+		Navigation: 30 * time.Second,
+		Evaluate:   30 * time.Second,
+		Screenshot: 30 * time.Second,
+	}
+}
+
+// withDefaults fills unset timeouts from DefaultTimeouts
+func (t Timeouts) withDefaults() Timeouts {
+	defaults := DefaultTimeouts()
+	if t.Default <= 0 {
+		t.Default = defaults.Default
+	}
+	if t.Navigation <= 0 {
+		t.Navigation = defaults.Navigation
+	}
+	if t.Evaluate <= 0 {
+		t.Evaluate = defaults.Evaluate
+	}
+	if t.Screenshot <= 0 {
+		t.Screenshot = defaults.Screenshot
+	}
+	return t
+}
+
+// For returns the timeout that applies to method
+func (t Timeouts) For(method string) time.Duration {
+	switch method {
+	case "Page.navigate", "Page.reload", "Page.navigateToHistoryEntry":
+		return t.Navigation
+	case "Page.captureScreenshot", "Page.printToPDF":
+		return t.Screenshot
+	}
+	if strings.HasPrefix(method, "Runtime.") && method != "Runtime.enable" && method != "Runtime.disable" {
+		return t.Evaluate
+	}
+	return t.Default
+}
+
+// longest returns the longest of the timeouts
+func (t Timeouts) longest() time.Duration {
+	return max(t.Default, t.Navigation, t.Evaluate, t.Screenshot)
+}
+
+// ErrCommandTimeout is wrapped by the error for a command the browser did not answer in time
+var ErrCommandTimeout = errors.New("command timed out")
+
+// SetTimeouts replaces the client's command timeouts; unset fields keep their defaults
+func (c *Client) SetTimeouts(timeouts Timeouts) {
+	timeouts = timeouts.withDefaults()
+	c.timeouts.Store(&timeouts)
+}
+
+// Timeouts returns the client's command timeouts
+func (c *Client) Timeouts() Timeouts {
+	return *c.timeouts.Load()
+}
+
+// await waits for the response to request id. The command's own timeout is applied on top
+// of ctx; cancelling ctx abandons the wait (the browser may still run the command).
+func (c *Client) await(ctx context.Context, id int, method string, responseChan chan *Response) (*Response, error) {
+	timeout := c.Timeouts().For(method)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case response := <-responseChan:
+		// A closed channel means the connection dropped before the browser answered
+		if response == nil {
+			return nil, c.lostError(method)
+		}
+
+		// Check if response has error
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		return response, nil
+
+	case <-timer.C:
+		c.dropPending(id)
+		return nil, fmt.Errorf("%s: %w after %s", method, ErrCommandTimeout, timeout)
+
+	case <-ctx.Done():
+		// The caller gave up (request cancelled or its deadline passed)
+		c.dropPending(id)
+		return nil, fmt.Errorf("%s: %w", method, ctx.Err())
+
+	case <-c.ctx.Done():
+		// Client is closing
+		return nil, c.lostError(method)
+	}
+}
@@ -0,0 +1,92 @@
+package cdp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestTimeoutsFor tests that commands pick up the timeout of their kind
+func TestTimeoutsFor(t *testing.T) {
+	timeouts := Timeouts{Default: 1, Navigation: 2, Evaluate: 3, Screenshot: 4}
+
+	cases := map[string]time.Duration{
+		"Page.navigate":          2,
+		"Page.reload":            2,
+		"Runtime.evaluate":       3,
+		"Runtime.callFunctionOn": 3,
+		"Runtime.enable":         1,
+		"Page.captureScreenshot": 4,
+		"DOM.getDocument":        1,
+	}
+	for method, want := range cases {
+		if got := timeouts.For(method); got != want {
+			t.Errorf("%s: expected %d, got %d", method, want, got)
+		}
+	}
+
+	filled := Timeouts{Evaluate: time.Minute}.withDefaults()
+	if filled.Evaluate != time.Minute || filled.Default != DefaultTimeouts().Default {
+		t.Errorf("expected unset timeouts to take defaults, got %+v", filled)
+	}
+}
+
+// TestSendCommandDeadlines tests that a command ends on its timeout or when the caller cancels
+func TestSendCommandDeadlines(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	// A browser that never answers
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	client.SetTimeouts(Timeouts{Evaluate: 50 * time.Millisecond})
+	if _, err := client.SendCommand(context.Background(), "Runtime.evaluate", nil); !errors.Is(err, ErrCommandTimeout) {
+		t.Errorf("expected ErrCommandTimeout, got %v", err)
+	}
+
+	// The caller's cancellation ends the wait long before the default timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := client.SendCommandToTarget(ctx, "T1", "DOM.getDocument", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected cancellation to end the command quickly, took %s", elapsed)
+	}
+
+	// Nothing is sent for a context that is already done
+	if _, err := client.SendCommand(ctx, "Browser.getVersion", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled for a done context, got %v", err)
+	}
+
+	client.mu.Lock()
+	pending := len(client.pending)
+	client.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("expected abandoned commands to be forgotten, %d still pending", pending)
+	}
+}
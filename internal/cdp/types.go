@@ -1,6 +1,9 @@
 package cdp
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // Command represents a CDP command sent to the browser
 type Command struct {
@@ -24,8 +27,23 @@ type ResponseError struct {
 	Message string `json:"message"`
 }
 
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("CDP error: %s (code %d)", e.Message, e.Code)
+}
+
 // Event represents an unsolicited CDP event from the browser
 type Event struct {
-	Method string          `json:"method"`
-	Params json.RawMessage `json:"params,omitempty"`
-}
\ No newline at end of file
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"` // Set for events from an attached target (flatten mode)
+}
+// TargetInfo describes a target as reported by Target.getTargets and target events
+type TargetInfo struct {
+	TargetID         string `json:"targetId"`
+	Type             string `json:"type"`
+	Title            string `json:"title"`
+	URL              string `json:"url"`
+	Attached         bool   `json:"attached"`
+	OpenerID         string `json:"openerId,omitempty"`
+	BrowserContextID string `json:"browserContextId,omitempty"`
+}
@@ -0,0 +1,194 @@
+package cdp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeQueueSize bounds the commands waiting to be written to the browser
+	writeQueueSize = 256
+
+	// writeDeadline bounds a single WebSocket write; a browser that stops reading
+	// counts as a dropped connection after this long
+	writeDeadline = 10 * time.Second
+)
+
+// errNotWritten fails commands still queued when their connection dropped
+var errNotWritten = errors.New("connection dropped before the command was written")
+
+// ErrWriteQueueFull is returned for a command that could not be queued because the
+// browser wasn't taking commands fast enough. The command was never sent.
+var ErrWriteQueueFull = errors.New("write queue full")
+
+// WriteStats describes the client's outgoing command queue
+type WriteStats struct {
+	QueueDepth    int    `json:"queue_depth"` // Commands waiting to be written now
+	QueueCapacity int    `json:"queue_capacity"`
+	MaxQueueDepth int    `json:"max_queue_depth"` // Deepest the queue has been
+	Written       uint64 `json:"written"`
+	Backpressured uint64 `json:"backpressured"` // Commands that found the queue full and had to wait
+	Rejected      uint64 `json:"rejected"`      // Commands that gave up waiting with ErrWriteQueueFull
+}
+
+// writeStats are the counters behind WriteStats
+type writeStats struct {
+	maxDepth      atomic.Int64
+	written       atomic.Uint64
+	backpressured atomic.Uint64
+	rejected      atomic.Uint64
+	congested     atomic.Bool // The queue filled up and hasn't emptied since
+}
+
+// outgoing is a marshaled command waiting for the write pump
+type outgoing struct {
+	data    []byte
+	written chan error // Receives the result of the write
+}
+
+// WriteStats reports the state of the outgoing command queue
+func (c *Client) WriteStats() WriteStats {
+	return WriteStats{
+		QueueDepth:    len(c.writes),
+		QueueCapacity: cap(c.writes),
+		MaxQueueDepth: int(c.writeStats.maxDepth.Load()),
+		Written:       c.writeStats.written.Load(),
+		Backpressured: c.writeStats.backpressured.Load(),
+		Rejected:      c.writeStats.rejected.Load(),
+	}
+}
+
+// write hands a marshaled command to the write pump and waits until it is on the wire.
+// A full queue holds the caller back for up to the default command timeout.
+func (c *Client) write(ctx context.Context, id int, method string, data []byte) error {
+	c.connMu.RLock()
+	state := c.state
+	c.connMu.RUnlock()
+
+	if state != StateConnected {
+		c.dropPending(id)
+		return c.lostError(method)
+	}
+
+	request := &outgoing{data: data, written: make(chan error, 1)}
+	if err := c.enqueue(ctx, method, request); err != nil {
+		c.dropPending(id)
+		return err
+	}
+
+	select {
+	case err := <-request.written:
+		if err != nil {
+			c.dropPending(id)
+			return &ConnectionError{Method: method, Err: fmt.Errorf("%w: %v", ErrConnectionLost, err)}
+		}
+		return nil
+	case <-ctx.Done():
+		// Still written once its turn comes, but nobody waits for the answer
+		c.dropPending(id)
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	case <-c.ctx.Done():
+		c.dropPending(id)
+		return c.lostError(method)
+	}
+}
+
+// enqueue adds a command to the write queue, waiting for room when it is full
+func (c *Client) enqueue(ctx context.Context, method string, request *outgoing) error {
+	select {
+	case c.writes <- request:
+		c.noteQueueDepth()
+		return nil
+	default:
+	}
+
+	c.writeStats.backpressured.Add(1)
+	if c.writeStats.congested.CompareAndSwap(false, true) {
+		slog.Warn("CDP write queue full, holding back commands", "url", c.url(), "capacity", cap(c.writes))
+	}
+
+	timeout := c.Timeouts().Default
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case c.writes <- request:
+		c.noteQueueDepth()
+		return nil
+	case <-timer.C:
+		c.writeStats.rejected.Add(1)
+		return fmt.Errorf("%s: %w after %s", method, ErrWriteQueueFull, timeout)
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	case <-c.ctx.Done():
+		return c.lostError(method)
+	}
+}
+
+// url returns the WebSocket URL of the current connection
+func (c *Client) url() string {
+	c.connMu.RLock()
+	defer c.connMu.RUnlock()
+	return c.wsURL
+}
+
+// noteQueueDepth records a new high-water mark of the queue
+func (c *Client) noteQueueDepth() {
+	depth := int64(len(c.writes))
+	for {
+		max := c.writeStats.maxDepth.Load()
+		if depth <= max || c.writeStats.maxDepth.CompareAndSwap(max, depth) {
+			return
+		}
+	}
+}
+
+// writePump is the only writer of conn, since gorilla/websocket allows one at a time.
+// It stops when the client closes or done is closed because conn dropped.
+func (c *Client) writePump(conn *websocket.Conn, done <-chan struct{}) {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+
+		case <-done:
+			c.failQueued()
+			return
+
+		case request := <-c.writes:
+			conn.SetWriteDeadline(time.Now().Add(writeDeadline))
+			err := conn.WriteMessage(websocket.TextMessage, request.data)
+			request.written <- err
+
+			if err != nil {
+				// The reader may not notice a connection that only stopped taking writes
+				c.handleDisconnect(conn, err)
+				c.failQueued()
+				return
+			}
+			c.writeStats.written.Add(1)
+
+			if len(c.writes) == 0 && c.writeStats.congested.CompareAndSwap(true, false) {
+				slog.Info("CDP write queue drained", "url", c.url())
+			}
+		}
+	}
+}
+
+// failQueued fails the commands still queued for a connection that dropped
+func (c *Client) failQueued() {
+	for {
+		select {
+		case request := <-c.writes:
+			request.written <- errNotWritten
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestConcurrentSendCommand tests that commands sent from many goroutines at once all
+// go out intact through the write pump
+func TestConcurrentSendCommand(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			// A corrupted frame would fail to parse
+			var request struct {
+				ID int `json:"id"`
+			}
+			if err := json.Unmarshal(message, &request); err != nil {
+				return
+			}
+			conn.WriteJSON(map[string]interface{}{"id": request.ID, "result": map[string]interface{}{}})
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	const senders, commands = 32, 50
+	var wg sync.WaitGroup
+	errs := make(chan error, senders*commands)
+	for range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range commands {
+				if _, err := client.SendCommand(context.Background(), "Runtime.enable", nil); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("expected every command to succeed, got %v", err)
+	}
+
+	stats := client.WriteStats()
+	if stats.Written != senders*commands || stats.QueueDepth != 0 || stats.QueueCapacity != writeQueueSize {
+		t.Errorf("unexpected write stats: %+v", stats)
+	}
+}
+
+// TestWriteQueueFull tests that a command waiting on a full queue gives up with a
+// retryable error once the default timeout passes
+func TestWriteQueueFull(t *testing.T) {
+	// No pump runs, so nothing leaves the queue
+	client := NewClient("ws://unused")
+	client.state = StateConnected
+	client.SetTimeouts(Timeouts{Default: 50 * time.Millisecond})
+	for range writeQueueSize {
+		client.writes <- &outgoing{written: make(chan error, 1)}
+	}
+
+	_, err := client.SendCommand(context.Background(), "Runtime.enable", nil)
+	if !errors.Is(err, ErrWriteQueueFull) || !IsRetryable(err) {
+		t.Fatalf("expected a retryable ErrWriteQueueFull, got %v", err)
+	}
+
+	stats := client.WriteStats()
+	if stats.QueueDepth != writeQueueSize || stats.Backpressured != 1 || stats.Rejected != 1 {
+		t.Errorf("unexpected write stats: %+v", stats)
+	}
+
+	client.mu.Lock()
+	pending := len(client.pending)
+	client.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("expected the rejected command to be forgotten, %d still pending", pending)
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes YAML to a temporary config file and returns its path
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+// TestConfigPrecedence tests that file values replace defaults and env vars replace both
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, `
+server_port: "9090"
+max_browsers: 3
+session_ttl: 2h
+redact_patterns:
+  - "sk-[a-z0-9]+"
+`)
+
+	t.Setenv("MAX_BROWSERS", "7")
+
+	cfg := defaults()
+	if err := cfg.loadFile(path); err != nil {
+		t.Fatalf("failed to load file: %v", err)
+	}
+	cfg.applyEnv()
+
+	if cfg.ServerPort != "9090" {
+		t.Errorf("expected server_port from file, got %q", cfg.ServerPort)
+	}
+	if cfg.MaxBrowsers != 7 {
+		t.Errorf("expected MAX_BROWSERS to override the file, got %d", cfg.MaxBrowsers)
+	}
+	if cfg.SessionTTL != 2*time.Hour {
+		t.Errorf("expected session_ttl 2h, got %s", cfg.SessionTTL)
+	}
+	if len(cfg.RedactPatterns) != 1 || cfg.RedactPatterns[0] != "sk-[a-z0-9]+" {
+		t.Errorf("unexpected redact patterns: %v", cfg.RedactPatterns)
+	}
+
+	// Keys the file leaves out keep their defaults
+	if cfg.RedisAddr != "localhost:6379" {
+		t.Errorf("expected default redis_addr, got %q", cfg.RedisAddr)
+	}
+}
+
+// TestConfigFileRejectsBadInput tests that typos and invalid values fail loudly
+func TestConfigFileRejectsBadInput(t *testing.T) {
+	cfg := defaults()
+	if err := cfg.loadFile(writeConfigFile(t, "max_browser: 3\n")); err == nil {
+		t.Error("expected unknown key to be rejected")
+	}
+
+	cfg = defaults()
+	if err := cfg.loadFile(writeConfigFile(t, "")); err != nil {
+		t.Errorf("expected empty file to be accepted, got %v", err)
+	}
+
+	cfg = defaults()
+	cfg.LogLevel = "loud"
+	if err := cfg.validate(); err == nil {
+		t.Error("expected invalid log level to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.LaunchMode = "vm"
+	if err := cfg.validate(); err == nil {
+		t.Error("expected unknown launch mode to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.RedactPatterns = []string{"("}
+	if err := cfg.validate(); err == nil {
+		t.Error("expected invalid redact pattern to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.CDPEvaluateTimeout = 0
+	if err := cfg.validate(); err == nil {
+		t.Error("expected zero command timeout to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.SearchBackend = SearchBackendSerpAPI
+	if err := cfg.validate(); err == nil {
+		t.Error("expected the serpapi search backend without a key to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.LaunchMode = LaunchModeRemote
+	cfg.RenderFontDir = "/usr/share/fonts/pinned"
+	if err := cfg.validate(); err == nil {
+		t.Error("expected render_font_dir outside local launch mode to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.RenderTimezone = "Atlantis/Capital"
+	if err := cfg.validate(); err == nil {
+		t.Error("expected unknown render_timezone to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.EgressProxy = true
+	cfg.WarmPoolSize = 2
+	if err := cfg.validate(); err == nil {
+		t.Error("expected egress_proxy with a warm pool to be rejected")
+	}
+
+	// The proxy listens on loopback, which containerized and remote browsers can't reach
+	for _, mode := range []string{LaunchModeDocker, LaunchModeRemote} {
+		cfg = defaults()
+		cfg.EgressProxy = true
+		cfg.LaunchMode = mode
+		cfg.RemoteBrowsers = []string{"ws://browsers.internal:9222"}
+		if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "egress_proxy needs browser_launch_mode") {
+			t.Errorf("expected egress_proxy with launch mode %s to be rejected, got %v", mode, err)
+		}
+	}
+
+	cfg = defaults()
+	cfg.EgressMITM = true
+	if err := cfg.validate(); err == nil {
+		t.Error("expected egress_mitm without egress_proxy to be rejected")
+	}
+
+	cfg = defaults()
+	cfg.PostgresPersist = []string{"transcripts", "jobs"}
+	if err := cfg.validate(); err == nil {
+		t.Error("expected unknown postgres_persist entry to be rejected")
+	}
+}
+
+// TestConfigDiff tests that changed fields are split into live and restart-only
+func TestConfigDiff(t *testing.T) {
+	current := defaults()
+	next := defaults()
+	next.LogLevel = "warn"
+	next.AdminAPIKey = "rotated"
+	next.RecycleMaxUptime = 6 * time.Hour
+	next.MaxBrowsers = 8
+
+	changes := current.Diff(next)
+
+	live := map[string]bool{}
+	for _, name := range changes.Live {
+		live[name] = true
+	}
+	for _, name := range []string{"log_level", "admin_api_key", "recycle_max_uptime"} {
+		if !live[name] {
+			t.Errorf("expected %s to be a live change, got %v", name, changes.Live)
+		}
+	}
+	if len(changes.Live) != 3 {
+		t.Errorf("expected 3 live changes, got %v", changes.Live)
+	}
+
+	if len(changes.Restart) != 1 || changes.Restart[0] != "max_browsers" {
+		t.Errorf("expected only max_browsers to need a restart, got %v", changes.Restart)
+	}
+
+	if diff := current.Diff(defaults()); len(diff.Live)+len(diff.Restart) != 0 {
+		t.Errorf("expected no changes between equal configs, got %+v", diff)
+	}
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile reads YAML values from path over the fields already set.
+// Keys the file leaves out keep their current values.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// An empty file decodes to io.EOF and leaves everything as is.
+	// Unknown keys are almost always typos, so reject them instead of ignoring them
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// validate rejects values that would only fail later, deep inside startup
+func (c *Config) validate() error {
+	if _, err := ParseLogLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if _, err := ParseLogLevel(c.SessionLogLevel); err != nil {
+		return fmt.Errorf("session_log_level: %w", err)
+	}
+	if c.SessionLogLines < 0 {
+		return fmt.Errorf("session_log_lines must not be negative, got %d", c.SessionLogLines)
+	}
+	for _, pattern := range c.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+	}
+	switch c.LaunchMode {
+	case LaunchModeLocal, LaunchModeDocker:
+	case LaunchModeRemote:
+		if len(c.RemoteBrowsers) == 0 {
+			return fmt.Errorf("browser_launch_mode %q needs at least one remote_browsers endpoint", LaunchModeRemote)
+		}
+	default:
+		return fmt.Errorf("browser_launch_mode must be %q, %q or %q, got %q", LaunchModeLocal, LaunchModeDocker, LaunchModeRemote, c.LaunchMode)
+	}
+	switch c.OrphanCleanup {
+	case OrphanCleanupOff, OrphanCleanupProfiles, OrphanCleanupPorts:
+	default:
+		return fmt.Errorf("orphan_cleanup must be %q, %q or %q, got %q", OrphanCleanupOff, OrphanCleanupProfiles, OrphanCleanupPorts, c.OrphanCleanup)
+	}
+	if c.MaxBrowsers < 1 {
+		return fmt.Errorf("max_browsers must be at least 1, got %d", c.MaxBrowsers)
+	}
+	for name, timeout := range map[string]time.Duration{
+		"browser_start_timeout":  c.BrowserStartTimeout,
+		"cdp_command_timeout":    c.CDPCommandTimeout,
+		"cdp_navigation_timeout": c.CDPNavigationTimeout,
+		"cdp_evaluate_timeout":   c.CDPEvaluateTimeout,
+		"cdp_screenshot_timeout": c.CDPScreenshotTimeout,
+	} {
+		if timeout <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", name, timeout)
+		}
+	}
+	if c.MemoryMaxTurns < 0 || c.MemoryMaxKB < 0 {
+		return fmt.Errorf("memory_max_turns and memory_max_kb must not be negative")
+	}
+	if c.MaxResponseMB < 1 {
+		return fmt.Errorf("max_response_mb must be at least 1, got %d", c.MaxResponseMB)
+	}
+	if c.CDPReadLimitMB <= c.MaxResponseMB {
+		return fmt.Errorf("cdp_read_limit_mb (%d) must be larger than max_response_mb (%d)", c.CDPReadLimitMB, c.MaxResponseMB)
+	}
+	if c.CompressMinBytes < 0 {
+		return fmt.Errorf("compress_min_bytes must not be negative, got %d", c.CompressMinBytes)
+	}
+	if c.MaxRequestBodyKB < 1 {
+		return fmt.Errorf("max_request_body_kb must be at least 1, got %d", c.MaxRequestBodyKB)
+	}
+	if c.ProfileDir != "" && c.LaunchMode != LaunchModeLocal {
+		return fmt.Errorf("profile_dir needs browser_launch_mode %q, got %q", LaunchModeLocal, c.LaunchMode)
+	}
+	switch c.BrowserHeadless {
+	case HeadlessNew, HeadlessOld, HeadlessOff:
+	default:
+		return fmt.Errorf("browser_headless must be %q, %q or %q, got %q", HeadlessNew, HeadlessOld, HeadlessOff, c.BrowserHeadless)
+	}
+	if len(c.BrowserExtensions) > 0 && c.ExtensionDir == "" {
+		return fmt.Errorf("browser_extensions needs extension_dir")
+	}
+	if c.ExtensionDir != "" {
+		if c.LaunchMode != LaunchModeLocal {
+			return fmt.Errorf("extension_dir needs browser_launch_mode %q, got %q", LaunchModeLocal, c.LaunchMode)
+		}
+		if c.BrowserHeadless == HeadlessOld {
+			return fmt.Errorf("extension_dir needs browser_headless %q or %q; the headless shell loads no extensions", HeadlessNew, HeadlessOff)
+		}
+	}
+	if c.RenderFontDir != "" && c.LaunchMode != LaunchModeLocal {
+		return fmt.Errorf("render_font_dir needs browser_launch_mode %q, got %q", LaunchModeLocal, c.LaunchMode)
+	}
+	if c.RenderTimezone != "" {
+		if _, err := time.LoadLocation(c.RenderTimezone); err != nil {
+			return fmt.Errorf("invalid render_timezone %q: %w", c.RenderTimezone, err)
+		}
+	}
+	if c.EgressMITM && !c.EgressProxy {
+		return fmt.Errorf("egress_mitm needs egress_proxy")
+	}
+	if c.EgressProxy {
+		// Sessions reach the proxy on this host, each through a browser context of its own
+		if c.LaunchMode != LaunchModeLocal {
+			return fmt.Errorf("egress_proxy needs browser_launch_mode %q, got %q", LaunchModeLocal, c.LaunchMode)
+		}
+		if c.WarmPoolSize > 0 || c.ProfileDir != "" || c.FirefoxPath != "" {
+			return fmt.Errorf("egress_proxy can't be combined with warm_pool_size, profile_dir or firefox_path, whose sessions would bypass it")
+		}
+	}
+	if c.FirefoxPath != "" {
+		if c.LaunchMode != LaunchModeLocal {
+			return fmt.Errorf("firefox_path needs browser_launch_mode %q, got %q", LaunchModeLocal, c.LaunchMode)
+		}
+		if c.FirefoxBrowsers < 1 {
+			return fmt.Errorf("firefox_browsers must be at least 1, got %d", c.FirefoxBrowsers)
+		}
+	}
+	if c.HibernateAfter < 0 || c.HibernateKeep < 0 {
+		return fmt.Errorf("hibernate_after and hibernate_keep must not be negative")
+	}
+	if c.ScriptConcurrency < 0 {
+		return fmt.Errorf("script_concurrency must not be negative, got %d", c.ScriptConcurrency)
+	}
+	if c.WorkDirQuotaMB < 0 {
+		return fmt.Errorf("work_dir_quota_mb must not be negative, got %d", c.WorkDirQuotaMB)
+	}
+	if c.MaxSessionsPerProcess < 0 || c.AdmissionQueueSize < 0 || c.AdmissionMaxWait < 0 {
+		return fmt.Errorf("max_sessions_per_process, admission_queue_size and admission_max_wait must not be negative")
+	}
+	if c.BreakerFailureRatio < 0 || c.BreakerFailureRatio > 1 {
+		return fmt.Errorf("breaker_failure_ratio must be between 0 and 1, got %g", c.BreakerFailureRatio)
+	}
+	if c.BreakerMinCommands < 0 || c.BreakerWindow < 0 || c.BreakerCooldown < 0 {
+		return fmt.Errorf("breaker_min_commands, breaker_window and breaker_cooldown must not be negative")
+	}
+	switch c.RateLimitBackend {
+	case RateLimitBackendMemory, RateLimitBackendRedis:
+	default:
+		return fmt.Errorf("rate_limit_backend must be %q or %q, got %q", RateLimitBackendMemory, RateLimitBackendRedis, c.RateLimitBackend)
+	}
+	if c.RateLimitRPS < 0 || c.RateLimitKeyRPS < 0 || c.RateLimitBurst < 0 || c.RateLimitKeyBurst < 0 || c.RateLimitInFlight < 0 || c.RateLimitKeyInFlight < 0 {
+		return fmt.Errorf("rate limits must not be negative")
+	}
+	switch c.SearchBackend {
+	case "", SearchBackendBrowser, SearchBackendOff:
+	case SearchBackendSerpAPI:
+		if c.SearchAPIKey == "" {
+			return fmt.Errorf("search_backend %q needs search_api_key", SearchBackendSerpAPI)
+		}
+	default:
+		return fmt.Errorf("search_backend must be %q, %q or %q, got %q", SearchBackendSerpAPI, SearchBackendBrowser, SearchBackendOff, c.SearchBackend)
+	}
+	if len(c.AuditKafkaBrokers) > 0 && c.AuditKafkaTopic == "" {
+		return fmt.Errorf("audit_kafka_topic is required when audit_kafka_brokers is set")
+	}
+	if len(c.EventKafkaBrokers) > 0 && c.EventKafkaTopic == "" {
+		return fmt.Errorf("event_kafka_topic is required when event_kafka_brokers is set")
+	}
+	if c.EventNATSURL != "" && c.EventNATSSubject == "" {
+		return fmt.Errorf("event_nats_subject is required when event_nats_url is set")
+	}
+	for _, kind := range c.PostgresPersist {
+		if kind != PersistTranscripts && kind != PersistResults && kind != PersistAudit {
+			return fmt.Errorf("postgres_persist entries must be transcripts, results or audit, got %q", kind)
+		}
+	}
+	return nil
+}
+
+// ParseLogLevel converts a configured level name to a slog level; "" is info
+func ParseLogLevel(name string) (level slog.Level, err error) {
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log_level %q: %w", name, err)
+	}
+	return level, nil
+}
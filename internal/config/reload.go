@@ -0,0 +1,41 @@
+package config
+
+import (
+	"reflect"
+)
+
+// Changes lists the fields that differ between two loads of the configuration
+type Changes struct {
+	Live    []string // Applied without a restart
+	Restart []string // Only take effect after a restart
+}
+
+// Diff compares c with a newer configuration, naming fields by their config file key.
+// Secrets are reported by name only, so the result is safe to log.
+func (c *Config) Diff(next *Config) Changes {
+	var changes Changes
+
+	current := reflect.ValueOf(c).Elem()
+	updated := reflect.ValueOf(next).Elem()
+	fields := current.Type()
+
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		name := field.Tag.Get("yaml")
+		if name == "-" || name == "" {
+			continue
+		}
+
+		if reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			continue
+		}
+
+		if field.Tag.Get("reload") == "live" {
+			changes.Live = append(changes.Live, name)
+		} else {
+			changes.Restart = append(changes.Restart, name)
+		}
+	}
+
+	return changes
+}
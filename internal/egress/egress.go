@@ -0,0 +1,680 @@
+// Package egress is a forward proxy that sessions' browser contexts send all their
+// traffic through, so requests DevTools interception misses (workers, CORS preflights)
+// still pass host rules, get counted and carry injected headers. Each session gets a
+// listener of its own, which is how the proxy tells sessions apart. HTTPS is tunnelled
+// unread unless MITM is on; then the proxy decrypts it with certificates from a CA the
+// browsers are launched to trust.
+package egress
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrInvalidRoute is returned for host rules, headers or an upstream the proxy can't use
+	ErrInvalidRoute = errors.New("invalid egress route")
+
+	// ErrClosed is returned when sessions are registered after the proxy was closed
+	ErrClosed = errors.New("egress proxy is closed")
+)
+
+const (
+	// dialTimeout bounds connecting to a site or an upstream proxy
+	dialTimeout = 30 * time.Second
+
+	// headerTimeout bounds reading a request's headers from the browser
+	headerTimeout = 30 * time.Second
+)
+
+// Options configures the proxy for every session
+type Options struct {
+	DenyHosts []string // Hosts no session may reach, on top of each route's own
+	MITM      bool     // Decrypt HTTPS, so headers are injected into it too
+}
+
+// Route is how one session's traffic leaves the server
+type Route struct {
+	Allow    []string          // Hosts the session may reach; empty allows every host not denied
+	Deny     []string          // Hosts the session may not reach
+	Headers  map[string]string // Set on every request the proxy can read
+	Upstream string            // HTTP proxy traffic is forwarded through, as host:port or a URL; empty connects directly
+	Bypass   []string          // Hosts reached directly despite Upstream, in proxy bypass list syntax
+}
+
+// Validate checks the host rules and headers. The upstream is checked on registration.
+func (r Route) Validate() error {
+	for _, host := range append(append([]string(nil), r.Allow...), r.Deny...) {
+		if err := validateHost(host); err != nil {
+			return err
+		}
+	}
+	for name, value := range r.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: header %q", ErrInvalidRoute, name)
+		}
+	}
+	return nil
+}
+
+// validateHost checks a host rule: a name or address, or *.name for its subdomains
+func validateHost(host string) error {
+	name := strings.TrimPrefix(host, "*.")
+	if name == "" || strings.ContainsAny(name, "/:* ") {
+		return fmt.Errorf("%w: host %q", ErrInvalidRoute, host)
+	}
+	return nil
+}
+
+// upstreamURL parses the upstream proxy of a route, nil for none
+func (r Route) upstreamURL() (*url.URL, error) {
+	if r.Upstream == "" {
+		return nil, nil
+	}
+	// The browser takes host:port for an HTTP proxy, so the scheme is optional here too
+	address := r.Upstream
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	upstream, err := url.Parse(address)
+	if err != nil || upstream.Host == "" {
+		return nil, fmt.Errorf("%w: upstream %q", ErrInvalidRoute, r.Upstream)
+	}
+	if upstream.Scheme != "http" {
+		return nil, fmt.Errorf("%w: upstream %q must be an HTTP proxy", ErrInvalidRoute, r.Upstream)
+	}
+	if upstream.Port() == "" {
+		upstream.Host = net.JoinHostPort(upstream.Hostname(), "80")
+	}
+	return upstream, nil
+}
+
+// Usage is what a session's traffic through the proxy amounted to
+type Usage struct {
+	Requests      int64 `json:"requests"`       // Requests forwarded and HTTPS connections tunnelled
+	Blocked       int64 `json:"blocked"`        // Requests and connections refused by host rules
+	BytesSent     int64 `json:"bytes_sent"`     // Request bodies and tunnelled bytes toward sites
+	BytesReceived int64 `json:"bytes_received"` // Response bodies and tunnelled bytes from sites
+}
+
+// Proxy is the forward proxy sessions are registered with
+type Proxy struct {
+	options Options
+	ca      *authority     // Issues the certificates of decrypted hosts; nil without MITM
+	rootCAs *x509.CertPool // Sites are verified against; nil uses the system's
+
+	mu       sync.Mutex
+	sessions map[string]*sessionProxy
+	closed   bool
+}
+
+// New creates a proxy. With MITM on, it generates the CA browsers must trust (see SPKIHash).
+func New(options Options) (*Proxy, error) {
+	for _, host := range options.DenyHosts {
+		if err := validateHost(host); err != nil {
+			return nil, err
+		}
+	}
+	p := &Proxy{options: options, sessions: make(map[string]*sessionProxy)}
+	if options.MITM {
+		ca, err := newAuthority()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create certificate authority: %w", err)
+		}
+		p.ca = ca
+	}
+	return p, nil
+}
+
+// MITM reports whether the proxy decrypts HTTPS
+func (p *Proxy) MITM() bool {
+	return p.ca != nil
+}
+
+// SPKIHash returns the base64 SHA-256 hash of the CA's public key, which Chromium takes
+// in --ignore-certificate-errors-spki-list. It is empty without MITM.
+func (p *Proxy) SPKIHash() string {
+	if p.ca == nil {
+		return ""
+	}
+	return p.ca.spkiHash()
+}
+
+// Register opens a listener for a session's traffic, or updates the route of the one it
+// has, and returns its address
+func (p *Proxy) Register(sessionID string, route Route) (string, error) {
+	if err := route.Validate(); err != nil {
+		return "", err
+	}
+	upstream, err := route.upstreamURL()
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return "", ErrClosed
+	}
+	if existing, exists := p.sessions[sessionID]; exists {
+		existing.setRoute(route, upstream)
+		return existing.listener.Addr().String(), nil
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to listen for session traffic: %w", err)
+	}
+	sp := &sessionProxy{proxy: p, sessionID: sessionID, listener: listener, conns: make(map[net.Conn]struct{})}
+	sp.setRoute(route, upstream)
+	sp.server = &http.Server{
+		Handler:           sp,
+		ReadHeaderTimeout: headerTimeout,
+		ErrorLog:          log.New(io.Discard, "", 0), // Browsers dropping connections isn't worth logging
+	}
+	go sp.server.Serve(listener)
+
+	p.sessions[sessionID] = sp
+	return listener.Addr().String(), nil
+}
+
+// Unregister closes a session's listener and the connections still open through it
+func (p *Proxy) Unregister(sessionID string) {
+	p.mu.Lock()
+	sp, exists := p.sessions[sessionID]
+	delete(p.sessions, sessionID)
+	p.mu.Unlock()
+	if exists {
+		sp.close()
+	}
+}
+
+// Usage returns what a registered session's traffic amounted to so far
+func (p *Proxy) Usage(sessionID string) (Usage, bool) {
+	p.mu.Lock()
+	sp, exists := p.sessions[sessionID]
+	p.mu.Unlock()
+	if !exists {
+		return Usage{}, false
+	}
+	return Usage{
+		Requests:      sp.requests.Load(),
+		Blocked:       sp.blocked.Load(),
+		BytesSent:     sp.sent.Load(),
+		BytesReceived: sp.received.Load(),
+	}, true
+}
+
+// Close unregisters every session and refuses new ones
+func (p *Proxy) Close() {
+	p.mu.Lock()
+	sessions := p.sessions
+	p.sessions = make(map[string]*sessionProxy)
+	p.closed = true
+	p.mu.Unlock()
+	for _, sp := range sessions {
+		sp.close()
+	}
+}
+
+// allows reports whether the host rules let a session reach host
+func (p *Proxy) allows(route Route, host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if matchesAny(p.options.DenyHosts, host) || matchesAny(route.Deny, host) {
+		return false
+	}
+	return len(route.Allow) == 0 || matchesAny(route.Allow, host)
+}
+
+// matchesAny reports whether host is one of patterns, or a subdomain of a *.name one
+func matchesAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if suffix, wildcard := strings.CutPrefix(pattern, "*."); wildcard {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// bypassed reports whether a bypass list sends host around the upstream. Entries are
+// names, *.name or .name for subdomains, and <local> for names without a dot.
+func bypassed(bypass []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range bypass {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "<local>":
+			if !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(host, entry) {
+				return true
+			}
+		case matchesAny([]string{entry}, host):
+			return true
+		}
+	}
+	return false
+}
+
+// sessionProxy serves one session's listener
+type sessionProxy struct {
+	proxy     *Proxy
+	sessionID string
+	listener  net.Listener
+	server    *http.Server
+
+	mu        sync.RWMutex
+	route     Route
+	upstream  *url.URL
+	transport *http.Transport
+	conns     map[net.Conn]struct{} // Tunnels and decrypted connections, which the server no longer tracks
+
+	requests, blocked, sent, received atomic.Int64
+}
+
+// setRoute replaces the route and the transport that follows it
+func (s *sessionProxy) setRoute(route Route, upstream *url.URL) {
+	transport := &http.Transport{
+		Proxy: func(r *http.Request) (*url.URL, error) {
+			if upstream == nil || bypassed(route.Bypass, r.URL.Hostname()) {
+				return nil, nil
+			}
+			return upstream, nil
+		},
+		DialContext:         (&net.Dialer{Timeout: dialTimeout}).DialContext,
+		TLSClientConfig:     &tls.Config{RootCAs: s.proxy.rootCAs},
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		ForceAttemptHTTP2:   true,
+		DisableCompression:  true, // The browser's Accept-Encoding goes through, and so does what the site sends back
+	}
+
+	// Open tunnels were allowed by the old rules, so they end with them
+	s.mu.Lock()
+	previous := s.transport
+	s.route, s.upstream, s.transport = route, upstream, transport
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	if previous != nil {
+		previous.CloseIdleConnections()
+	}
+}
+
+// current returns the route and upstream requests follow now
+func (s *sessionProxy) current() (Route, *url.URL) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.route, s.upstream
+}
+
+// close stops the listener and ends the session's open connections
+func (s *sessionProxy) close() {
+	s.server.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = make(map[net.Conn]struct{})
+	transport := s.transport
+	s.mu.Unlock()
+	transport.CloseIdleConnections()
+}
+
+// track records a hijacked connection until it is closed
+func (s *sessionProxy) track(conns ...net.Conn) func() {
+	s.mu.Lock()
+	for _, conn := range conns {
+		s.conns[conn] = struct{}{}
+	}
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		for _, conn := range conns {
+			delete(s.conns, conn)
+			conn.Close()
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *sessionProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		s.connect(w, r)
+		return
+	}
+	// A forward proxy is sent absolute URLs; HTTPS comes through CONNECT
+	if !r.URL.IsAbs() || r.URL.Scheme != "http" {
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+	s.forward(w, r, "http", r.URL.Host)
+}
+
+// refuse answers a request or connection the host rules don't allow
+func (s *sessionProxy) refuse(w http.ResponseWriter, host string) {
+	s.blocked.Add(1)
+	slog.Debug("egress blocked", "session_id", s.sessionID, "host", host)
+	http.Error(w, "blocked by egress policy: "+host, http.StatusForbidden)
+}
+
+// forward sends a request to host and copies the response back, with the route's headers
+func (s *sessionProxy) forward(w http.ResponseWriter, r *http.Request, scheme, host string) {
+	route, _ := s.current()
+	if !s.proxy.allows(route, hostname(host)) {
+		s.refuse(w, host)
+		return
+	}
+	s.requests.Add(1)
+
+	reverse := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Scheme = scheme
+			pr.Out.URL.Host = host
+			for name, value := range route.Headers {
+				pr.Out.Header.Set(name, value)
+			}
+		},
+		Transport:     s,
+		FlushInterval: -1, // Streamed responses reach the page as they arrive
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			slog.Debug("egress request failed", "session_id", s.sessionID, "host", host, "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	reverse.ServeHTTP(w, r)
+}
+
+// RoundTrip sends a forwarded request with the current transport, counting its body
+// and the response's
+func (s *sessionProxy) RoundTrip(r *http.Request) (*http.Response, error) {
+	s.mu.RLock()
+	transport := s.transport
+	s.mu.RUnlock()
+
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = &countingBody{ReadCloser: r.Body, n: &s.sent}
+	}
+	response, err := transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	// An upgraded connection's body is written to as well, as the reverse proxy expects
+	if upgraded, ok := response.Body.(io.ReadWriteCloser); ok && response.StatusCode == http.StatusSwitchingProtocols {
+		response.Body = &countingUpgrade{ReadWriteCloser: upgraded, sent: &s.sent, received: &s.received}
+	} else {
+		response.Body = &countingBody{ReadCloser: response.Body, n: &s.received}
+	}
+	return response, nil
+}
+
+// connect tunnels, or with MITM decrypts, a CONNECT to host:port
+func (s *sessionProxy) connect(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	route, upstream := s.current()
+	if !s.proxy.allows(route, hostname(host)) {
+		s.refuse(w, host)
+		return
+	}
+
+	var site net.Conn
+	if s.proxy.ca == nil {
+		var err error
+		if site, err = dialTunnel(r.Context(), route, upstream, host); err != nil {
+			slog.Debug("egress tunnel failed", "session_id", s.sessionID, "host", host, "error", err)
+			http.Error(w, "failed to reach "+host, http.StatusBadGateway)
+			return
+		}
+	}
+
+	client, buffered, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		if site != nil {
+			site.Close()
+		}
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		if site != nil {
+			site.Close()
+		}
+		return
+	}
+	// Bytes the browser sent right after CONNECT may already be buffered
+	browser := &bufferedConn{Conn: client, reader: buffered.Reader}
+
+	if site == nil {
+		s.intercept(browser, host)
+		return
+	}
+	s.requests.Add(1)
+	s.pipe(browser, site)
+}
+
+// pipe copies a tunnel both ways until either side closes it
+func (s *sessionProxy) pipe(browser, site net.Conn) {
+	untrack := s.track(browser, site)
+	defer untrack()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(&countingWriter{Writer: site, n: &s.sent}, browser)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(&countingWriter{Writer: browser, n: &s.received}, site)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// intercept decrypts a CONNECT with a certificate for host and forwards the requests
+// read from it
+func (s *sessionProxy) intercept(browser net.Conn, host string) {
+	name := hostname(host)
+	conn := tls.Server(browser, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return s.proxy.ca.leaf(hello.ServerName)
+			}
+			return s.proxy.ca.leaf(name)
+		},
+		NextProtos: []string{"http/1.1"},
+	})
+	untrack := s.track(conn)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.forward(w, r, "https", host)
+		}),
+		ReadHeaderTimeout: headerTimeout,
+		ErrorLog:          log.New(io.Discard, "", 0),
+	}
+	listener := newConnListener(conn, untrack)
+	server.Serve(listener)
+}
+
+// dialTunnel connects to host:port, through the upstream proxy unless there is none or
+// the bypass list names the host
+func dialTunnel(ctx context.Context, route Route, upstream *url.URL, host string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if upstream == nil || bypassed(route.Bypass, hostname(host)) {
+		return dialer.DialContext(ctx, "tcp", host)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", upstream.Host)
+	if err != nil {
+		return nil, err
+	}
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: host},
+		Host:   host,
+		Header: make(http.Header),
+	}
+	if upstream.User != nil {
+		password, _ := upstream.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(upstream.User.Username() + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("upstream proxy answered %s", response.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// hostname strips the port from host:port
+func hostname(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
+
+// bufferedConn reads what a buffered reader holds before reading the connection
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// connListener hands one connection to an http.Server, then blocks until it closes so
+// the server keeps serving it
+type connListener struct {
+	conn    net.Conn
+	once    sync.Once
+	closed  chan struct{}
+	onClose func()
+}
+
+func newConnListener(conn net.Conn, onClose func()) *connListener {
+	l := &connListener{closed: make(chan struct{}), onClose: onClose}
+	l.conn = &closeNotifyConn{Conn: conn, close: l.Close}
+	return l
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	if conn := l.take(); conn != nil {
+		return conn, nil
+	}
+	<-l.closed
+	return nil, net.ErrClosed
+}
+
+// take returns the connection the first time it is called, nil afterwards
+func (l *connListener) take() net.Conn {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	return conn
+}
+
+func (l *connListener) Close() error {
+	select {
+	case <-l.closed:
+	default:
+		close(l.closed)
+		l.onClose()
+	}
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// closeNotifyConn closes its listener along with itself
+type closeNotifyConn struct {
+	net.Conn
+	close func() error
+}
+
+func (c *closeNotifyConn) Close() error {
+	err := c.Conn.Close()
+	c.close()
+	return err
+}
+
+// countingBody counts the bytes read from a body
+type countingBody struct {
+	io.ReadCloser
+	n *atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+// countingUpgrade counts both directions of an upgraded connection, e.g. a WebSocket
+type countingUpgrade struct {
+	io.ReadWriteCloser
+	sent, received *atomic.Int64
+}
+
+func (u *countingUpgrade) Read(p []byte) (int, error) {
+	n, err := u.ReadWriteCloser.Read(p)
+	u.received.Add(int64(n))
+	return n, err
+}
+
+func (u *countingUpgrade) Write(p []byte) (int, error) {
+	n, err := u.ReadWriteCloser.Write(p)
+	u.sent.Add(int64(n))
+	return n, err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	io.Writer
+	n *atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n.Add(int64(n))
+	return n, err
+}
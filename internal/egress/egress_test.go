@@ -0,0 +1,166 @@
+package egress
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// echoHeader answers with the X-Tenant header the request arrived with
+var echoHeader = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, "tenant="+r.Header.Get("X-Tenant"))
+})
+
+// client sends requests through a session's listener, trusting roots for HTTPS
+func client(address string, roots *x509.CertPool) *http.Client {
+	proxyURL, _ := url.Parse("http://" + address)
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+}
+
+// fetch returns the status and body of a GET
+func fetch(t *testing.T, c *http.Client, rawURL string) (int, string) {
+	t.Helper()
+	response, err := c.Get(rawURL)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", rawURL, err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	return response.StatusCode, string(body)
+}
+
+// TestProxy tests host rules, header injection, tunnelling and byte counts per session
+func TestProxy(t *testing.T) {
+	site := httptest.NewServer(echoHeader)
+	defer site.Close()
+	secure := httptest.NewTLSServer(echoHeader)
+	defer secure.Close()
+
+	proxy, err := New(Options{DenyHosts: []string{"169.254.169.254"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer proxy.Close()
+
+	address, err := proxy.Register("session-1", Route{Headers: map[string]string{"X-Tenant": "acme"}})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	c := client(address, secure.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs)
+
+	if status, body := fetch(t, c, site.URL+"/"); status != http.StatusOK || body != "tenant=acme" {
+		t.Errorf("expected the header injected into plain HTTP, got %d %q", status, body)
+	}
+	// Without MITM, HTTPS is tunnelled as it is
+	if status, body := fetch(t, c, secure.URL+"/"); status != http.StatusOK || body != "tenant=" {
+		t.Errorf("expected HTTPS tunnelled untouched, got %d %q", status, body)
+	}
+	if status, _ := fetch(t, c, "http://169.254.169.254/latest/meta-data/"); status != http.StatusForbidden {
+		t.Errorf("expected the server-wide deny list applied, got %d", status)
+	}
+
+	usage, ok := proxy.Usage("session-1")
+	if !ok || usage.Requests != 2 || usage.Blocked != 1 || usage.BytesReceived < int64(len("tenant=acme")) || usage.BytesSent == 0 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+
+	// Registering again replaces the route on the same listener
+	again, err := proxy.Register("session-1", Route{Allow: []string{"*.example.com"}})
+	if err != nil || again != address {
+		t.Fatalf("expected the listener kept, got %q, %v", again, err)
+	}
+	if status, _ := fetch(t, c, site.URL+"/"); status != http.StatusForbidden {
+		t.Errorf("expected a host outside the allow list refused, got %d", status)
+	}
+	if _, err := c.Get(secure.URL + "/"); err == nil {
+		t.Error("expected the CONNECT to a host outside the allow list refused")
+	}
+
+	proxy.Unregister("session-1")
+	if _, ok := proxy.Usage("session-1"); ok {
+		t.Error("expected the usage forgotten with the session")
+	}
+	if conn, err := net.Dial("tcp", address); err == nil {
+		conn.Close()
+		t.Error("expected the listener closed")
+	}
+
+	for _, route := range []Route{
+		{Deny: []string{"https://example.com"}},
+		{Allow: []string{"*"}},
+		{Headers: map[string]string{"X-Bad\r\n": "1"}},
+		{Upstream: "socks5://proxy:1080"},
+	} {
+		if _, err := proxy.Register("session-2", route); !errors.Is(err, ErrInvalidRoute) {
+			t.Errorf("expected %+v rejected, got %v", route, err)
+		}
+	}
+}
+
+// TestProxyMITM tests decrypting HTTPS to inject headers, and forwarding through an
+// upstream proxy with a bypass list
+func TestProxyMITM(t *testing.T) {
+	secure := httptest.NewTLSServer(echoHeader)
+	defer secure.Close()
+
+	upstream, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer upstream.Close()
+	upstreamAddress, err := upstream.Register("upstream", Route{})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	proxy, err := New(Options{MITM: true})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer proxy.Close()
+	proxy.rootCAs = secure.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	if proxy.SPKIHash() == "" || !proxy.MITM() {
+		t.Fatal("expected a CA for the browsers to trust")
+	}
+
+	address, err := proxy.Register("session-1", Route{Headers: map[string]string{"X-Tenant": "acme"}, Upstream: upstreamAddress})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(proxy.ca.certificate)
+	c := client(address, roots)
+
+	if status, body := fetch(t, c, secure.URL+"/"); status != http.StatusOK || body != "tenant=acme" {
+		t.Errorf("expected the header injected into decrypted HTTPS, got %d %q", status, body)
+	}
+	if usage, _ := upstream.Usage("upstream"); usage.Requests != 1 {
+		t.Errorf("expected the request sent through the upstream proxy, got %+v", usage)
+	}
+
+	// The test site is on loopback, so a loopback bypass sends it around the upstream
+	if _, err := proxy.Register("session-1", Route{Upstream: upstreamAddress, Bypass: []string{"127.0.0.1"}}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if status, body := fetch(t, c, secure.URL+"/again"); status != http.StatusOK || !strings.HasPrefix(body, "tenant=") {
+		t.Errorf("unexpected bypassed answer: %d %q", status, body)
+	}
+	if usage, _ := upstream.Usage("upstream"); usage.Requests != 1 {
+		t.Errorf("expected the bypassed host reached directly, got %+v", usage)
+	}
+
+	leaf, err := proxy.ca.leaf("127.0.0.1")
+	if err != nil || len(leaf.Certificate) != 2 || len(leaf.Leaf.IPAddresses) != 1 {
+		t.Errorf("expected an IP certificate chained to the CA, got %+v, %v", leaf, err)
+	}
+}
@@ -0,0 +1,122 @@
+package egress
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// caValidity is how long the CA generated at startup is valid
+	caValidity = 5 * 365 * 24 * time.Hour
+
+	// leafValidity is how long an issued certificate is valid; it is issued again after
+	leafValidity = 30 * 24 * time.Hour
+
+	// maxLeaves bounds the issued certificates kept; the cache starts over past it
+	maxLeaves = 1000
+)
+
+// authority issues the certificates the proxy presents for the hosts it decrypts. It
+// lives as long as the process: browsers trust it by its key's hash, not a trust store.
+type authority struct {
+	certificate *x509.Certificate
+	der         []byte
+	key         *ecdsa.PrivateKey
+	leafKey     *ecdsa.PrivateKey // Shared by issued certificates, which makes issuing cheap
+
+	mu     sync.Mutex
+	leaves map[string]*tls.Certificate
+	serial int64
+}
+
+// newAuthority generates a CA and the key its certificates are issued for
+func newAuthority() (*authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "browser-query-ai egress proxy", Organization: []string{"browser-query-ai"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &authority{certificate: certificate, der: der, key: key, leafKey: leafKey, leaves: make(map[string]*tls.Certificate), serial: 1}, nil
+}
+
+// spkiHash returns the base64 SHA-256 hash of the CA's subject public key info
+func (a *authority) spkiHash() string {
+	sum := sha256.Sum256(a.certificate.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// leaf returns a certificate for host, issuing it on first use. The chain includes the
+// CA, whose key the browser recognizes.
+func (a *authority) leaf(host string) (*tls.Certificate, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if certificate, exists := a.leaves[host]; exists && now.Before(certificate.Leaf.NotAfter.Add(-time.Hour)) {
+		return certificate, nil
+	}
+
+	a.serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(a.serial),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.certificate, &a.leafKey.PublicKey, a.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate for %s: %w", host, err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.leaves) >= maxLeaves {
+		a.leaves = make(map[string]*tls.Certificate)
+	}
+	certificate := &tls.Certificate{Certificate: [][]byte{der, a.der}, PrivateKey: a.leafKey, Leaf: leaf}
+	a.leaves[host] = certificate
+	return certificate, nil
+}
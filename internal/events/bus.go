@@ -0,0 +1,242 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published on the session event stream
+const (
+	TypeSessionCreated     = "session_created"
+	TypeSessionClosed      = "session_closed"
+	TypeSessionDestroyed   = "session_destroyed"
+	TypePageOpened         = "page_opened"
+	TypePageClosed         = "page_closed"
+	TypeCaptchaBlocked     = "captcha_blocked"
+	TypeCaptchaManual      = "captcha_manual_requested"
+	TypeCaptchaSolved      = "captcha_solved"
+	TypeTakeoverStarted    = "takeover_started"
+	TypeTakeoverEnded      = "takeover_ended"
+	TypeSessionMigrated    = "session_migrated"
+	TypeSessionHibernated  = "session_hibernated"
+	TypeSessionWoken       = "session_woken"
+	TypeSessionBranched    = "session_branched"
+	TypeConnectionLost     = "connection_lost"
+	TypeConnectionRestored = "connection_restored"
+	TypeDOMChanged         = "dom_changed"
+	TypeRouteChanged       = "route_changed"
+	TypeWorkDirQuota       = "work_dir_quota_exceeded"
+	TypeElementInteracted  = "element_interacted"
+)
+
+// DefaultHistorySize is how many recent events are retained per session for replay
+const DefaultHistorySize = 256
+
+// Event is a single session event
+type Event struct {
+	ID        uint64      `json:"id"` // Monotonic across the bus, used for resume
+	SessionID string      `json:"session_id"`
+	PageID    string      `json:"page_id,omitempty"`
+	Type      string      `json:"type"`
+	Time      time.Time   `json:"time"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// Bus fans session events out to subscribers and keeps a bounded per-session history
+type Bus struct {
+	nextID      uint64
+	history     map[string][]Event                    // Session ID → recent events (oldest first)
+	subscribers map[string]map[*Subscription]struct{} // Session ID → live subscriptions
+	firehose    map[*Subscription]struct{}            // Subscriptions to every session's events
+	historySize int
+	dropped     uint64 // Events not delivered to slow subscribers
+	mu          sync.Mutex
+}
+
+// Subscription receives events for one session until closed
+type Subscription struct {
+	C         <-chan Event
+	ch        chan Event
+	sessionID string
+	all       bool // Subscribed with SubscribeAll
+	bus       *Bus
+	closeOnce sync.Once
+}
+
+// NewBus creates an event bus keeping historySize events per session
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+
+	return &Bus{
+		history:     make(map[string][]Event),
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		firehose:    make(map[*Subscription]struct{}),
+		historySize: historySize,
+	}
+}
+
+// Publish assigns an ID and timestamp to the event, records it and delivers it.
+// Delivery never blocks: events for subscribers with full buffers are dropped.
+func (b *Bus) Publish(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event.ID = b.nextID
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	// Record in the bounded history
+	history := append(b.history[event.SessionID], event)
+	if len(history) > b.historySize {
+		history = history[len(history)-b.historySize:]
+	}
+	b.history[event.SessionID] = history
+
+	// Fan out to live subscribers
+	for sub := range b.subscribers[event.SessionID] {
+		b.deliver(sub, event)
+	}
+	for sub := range b.firehose {
+		b.deliver(sub, event)
+	}
+
+	return event
+}
+
+// deliver hands event to sub unless its buffer is full. Callers hold b.mu.
+func (b *Bus) deliver(sub *Subscription, event Event) {
+	select {
+	case sub.ch <- event:
+	default:
+		b.dropped++
+	}
+}
+
+// SubscribeAll starts receiving the events of every session, e.g. to export them.
+// It is not closed when a session is forgotten.
+func (b *Bus) SubscribeAll(buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = 64
+	}
+
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, all: true, bus: b}
+
+	b.mu.Lock()
+	b.firehose[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Subscribe starts receiving events for a session
+func (b *Bus) Subscribe(sessionID string, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = 64
+	}
+
+	ch := make(chan Event, buffer)
+	sub := &Subscription{
+		C:         ch,
+		ch:        ch,
+		sessionID: sessionID,
+		bus:       b,
+	}
+
+	b.mu.Lock()
+	if b.subscribers[sessionID] == nil {
+		b.subscribers[sessionID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[sessionID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// Close stops the subscription and closes its channel
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.bus.mu.Lock()
+		defer s.bus.mu.Unlock()
+
+		if s.all {
+			delete(s.bus.firehose, s)
+			close(s.ch)
+			return
+		}
+
+		if subs, exists := s.bus.subscribers[s.sessionID]; exists {
+			if _, subscribed := subs[s]; subscribed {
+				delete(subs, s)
+				close(s.ch)
+			}
+			if len(subs) == 0 {
+				delete(s.bus.subscribers, s.sessionID)
+			}
+		}
+	})
+}
+
+// History returns retained events for a session with an ID greater than afterID
+func (b *Bus) History(sessionID string, afterID uint64) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make([]Event, 0)
+	for _, event := range b.history[sessionID] {
+		if event.ID > afterID {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// Forget drops a session's history and closes its subscriptions (used on destroy)
+func (b *Bus) Forget(sessionID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.history, sessionID)
+	for sub := range b.subscribers[sessionID] {
+		close(sub.ch)
+	}
+	delete(b.subscribers, sessionID)
+}
+
+// Stats returns the number of sessions with history, live subscriptions and dropped events
+func (b *Bus) Stats() (sessions int, subscriptions int, dropped uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subscribers {
+		subscriptions += len(subs)
+	}
+
+	return len(b.history), subscriptions, b.dropped
+}
+
+// Backlog returns how many delivered events subscribers have yet to read, in all and in
+// the fullest subscription's buffer
+func (b *Bus) Backlog() (queued int, fullest int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count := func(sub *Subscription) {
+		queued += len(sub.ch)
+		fullest = max(fullest, len(sub.ch))
+	}
+	for _, subs := range b.subscribers {
+		for sub := range subs {
+			count(sub)
+		}
+	}
+	for sub := range b.firehose {
+		count(sub)
+	}
+	return queued, fullest
+}
@@ -0,0 +1,135 @@
+package events
+
+import (
+	"testing"
+)
+
+// TestPublishSubscribe tests live delivery and per-session isolation
+func TestPublishSubscribe(t *testing.T) {
+	bus := NewBus(10)
+
+	sub := bus.Subscribe("sess_a", 4)
+	defer sub.Close()
+
+	bus.Publish(Event{SessionID: "sess_b", Type: TypePageOpened})
+	published := bus.Publish(Event{SessionID: "sess_a", Type: TypeCaptchaBlocked})
+
+	select {
+	case event := <-sub.C:
+		if event.ID != published.ID || event.Type != TypeCaptchaBlocked {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	default:
+		t.Fatal("expected an event for sess_a")
+	}
+
+	select {
+	case event := <-sub.C:
+		t.Errorf("received event for another session: %+v", event)
+	default:
+	}
+}
+
+// TestHistory tests bounded retention and replay after an ID
+func TestHistory(t *testing.T) {
+	bus := NewBus(3)
+
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, bus.Publish(Event{SessionID: "sess_a", Type: TypePageOpened}).ID)
+	}
+
+	history := bus.History("sess_a", 0)
+	if len(history) != 3 {
+		t.Fatalf("expected 3 retained events, got %d", len(history))
+	}
+	if history[0].ID != ids[2] {
+		t.Errorf("expected oldest retained ID %d, got %d", ids[2], history[0].ID)
+	}
+
+	after := bus.History("sess_a", ids[3])
+	if len(after) != 1 || after[0].ID != ids[4] {
+		t.Errorf("expected only event %d after %d, got %+v", ids[4], ids[3], after)
+	}
+}
+
+// TestForgetClosesSubscriptions tests that destroying a session ends its streams
+func TestForgetClosesSubscriptions(t *testing.T) {
+	bus := NewBus(10)
+	sub := bus.Subscribe("sess_a", 1)
+
+	bus.Publish(Event{SessionID: "sess_a", Type: TypeSessionDestroyed})
+	bus.Forget("sess_a")
+
+	if event, ok := <-sub.C; !ok || event.Type != TypeSessionDestroyed {
+		t.Errorf("expected buffered destroy event, got %+v (open=%v)", event, ok)
+	}
+	if _, ok := <-sub.C; ok {
+		t.Error("expected channel to be closed after Forget")
+	}
+
+	// Closing after Forget must not panic
+	sub.Close()
+
+	if len(bus.History("sess_a", 0)) != 0 {
+		t.Error("expected history to be dropped")
+	}
+}
+
+// TestSlowSubscriberDoesNotBlock tests that full buffers drop instead of blocking
+func TestSlowSubscriberDoesNotBlock(t *testing.T) {
+	bus := NewBus(10)
+	sub := bus.Subscribe("sess_a", 1)
+	defer sub.Close()
+
+	bus.Publish(Event{SessionID: "sess_a", Type: TypePageOpened})
+	bus.Publish(Event{SessionID: "sess_a", Type: TypePageClosed})
+
+	if _, _, dropped := bus.Stats(); dropped != 1 {
+		t.Errorf("expected 1 dropped event, got %d", dropped)
+	}
+}
+
+// TestSubscribeAll tests that a firehose subscription sees every session and outlives Forget
+func TestSubscribeAll(t *testing.T) {
+	bus := NewBus(10)
+	all := bus.SubscribeAll(4)
+
+	bus.Publish(Event{SessionID: "sess_a", Type: TypePageOpened})
+	bus.Publish(Event{SessionID: "sess_b", Type: TypePageOpened})
+	bus.Forget("sess_a")
+	bus.Publish(Event{SessionID: "sess_b", Type: TypePageClosed})
+
+	for _, want := range []string{"sess_a", "sess_b", "sess_b"} {
+		if event := <-all.C; event.SessionID != want {
+			t.Errorf("expected event for %s, got %+v", want, event)
+		}
+	}
+
+	all.Close()
+	if _, ok := <-all.C; ok {
+		t.Error("expected channel to be closed")
+	}
+	all.Close()
+}
+
+// TestBacklog tests counting events subscribers haven't read yet
+func TestBacklog(t *testing.T) {
+	bus := NewBus(10)
+	sub := bus.Subscribe("sess_a", 4)
+	defer sub.Close()
+	all := bus.SubscribeAll(4)
+	defer all.Close()
+
+	bus.Publish(Event{SessionID: "sess_a", Type: TypePageOpened})
+	bus.Publish(Event{SessionID: "sess_b", Type: TypePageOpened})
+	if queued, fullest := bus.Backlog(); queued != 3 || fullest != 2 {
+		t.Errorf("expected 3 queued with 2 on the fullest, got %d and %d", queued, fullest)
+	}
+
+	<-all.C
+	<-all.C
+	if queued, fullest := bus.Backlog(); queued != 1 || fullest != 1 {
+		t.Errorf("expected 1 queued once read, got %d and %d", queued, fullest)
+	}
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// History keeps a record of successful runs, independent of the pipeline's own sink
+type History interface {
+	RecordRun(ctx context.Context, sessionID string, result *Result) error
+}
+
+// SQLExecutor executes SQL without reading rows; storage.PostgresStore satisfies it
+type SQLExecutor interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) error
+}
+
+// PostgresHistory stores runs in pipeline_runs and their records in extraction_results
+type PostgresHistory struct {
+	db SQLExecutor
+}
+
+// NewPostgresHistory writes to the tables created by the store's migrations
+func NewPostgresHistory(db SQLExecutor) *PostgresHistory {
+	return &PostgresHistory{db: db}
+}
+
+// RecordRun inserts the run and its records in one statement, so either both are stored or neither is
+func (h *PostgresHistory) RecordRun(ctx context.Context, sessionID string, result *Result) error {
+	records := result.Records
+	if records == nil {
+		records = []interface{}{}
+	}
+	encodedRecords, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to encode records: %w", err)
+	}
+
+	var encodedErrors []byte
+	if len(result.Errors) > 0 {
+		if encodedErrors, err = json.Marshal(result.Errors); err != nil {
+			return fmt.Errorf("failed to encode errors: %w", err)
+		}
+	}
+
+	err = h.db.Exec(ctx, `WITH run AS (
+			INSERT INTO pipeline_runs (pipeline, session_id, input, output, duplicates, invalid, errors, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		)
+		INSERT INTO extraction_results (run_id, pipeline, session_id, record, created_at)
+		SELECT run.id, $1, $2, r.record, $8
+		FROM run, jsonb_array_elements($9::jsonb) AS r(record)`,
+		result.Pipeline, sessionID, result.Input, result.Output, result.Duplicates, result.Invalid,
+		encodedErrors, time.Now(), encodedRecords)
+	if err != nil {
+		return fmt.Errorf("failed to store pipeline run: %w", err)
+	}
+	return nil
+}
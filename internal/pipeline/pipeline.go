@@ -0,0 +1,228 @@
+// Package pipeline turns extraction results into stored data: records pass through
+// a declared list of transforms (jq expressions, deduplication, schema validation)
+// and are then written to a sink (webhook, S3 or Postgres).
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrPipelineNotFound = errors.New("pipeline not found")
+	ErrInvalidPipeline  = errors.New("invalid pipeline")
+	ErrInvalidRecord    = errors.New("record failed validation")
+	ErrSinkFailed       = errors.New("sink write failed")
+)
+
+// namePattern keeps pipeline names URL- and key-safe
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Transform types
+const (
+	TransformJQ       = "jq"       // Replace each record with the outputs of a jq expression
+	TransformDedup    = "dedup"    // Drop records whose key was already seen
+	TransformValidate = "validate" // Check records against a JSON schema
+)
+
+// Sink types
+const (
+	SinkWebhook  = "webhook"
+	SinkS3       = "s3"
+	SinkPostgres = "postgres"
+)
+
+// Spec declares a pipeline
+type Spec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Transforms  []TransformSpec `json:"transforms,omitempty"` // Applied in order
+	Sink        *SinkSpec       `json:"sink,omitempty"`       // Without a sink results are only returned
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// TransformSpec declares one transform step
+type TransformSpec struct {
+	Type string `json:"type"` // jq, dedup or validate
+
+	// jq: expression run on each record; every output becomes a record (select() drops, .[] splits)
+	Expr string `json:"expr,omitempty"`
+
+	// dedup: jq expression giving a record's identity (default: the whole record), and
+	// whether identities are remembered per session (default) or across the pipeline
+	Key   string `json:"key,omitempty"`
+	Scope string `json:"scope,omitempty"` // session or pipeline
+
+	// validate: JSON schema records must match, and whether failures drop the record
+	// (default) or fail the whole run
+	Schema    json.RawMessage `json:"schema,omitempty"`
+	OnInvalid string          `json:"on_invalid,omitempty"` // drop or fail
+}
+
+// SinkSpec declares where processed records are written. Credentials never live in the
+// spec (specs are readable through the API): they come from the environment.
+type SinkSpec struct {
+	Type string `json:"type"` // webhook, s3 or postgres
+
+	// webhook: records are POSTed as JSON. Header values may reference environment
+	// variables ($TOKEN or ${TOKEN}), which are expanded when sending.
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// s3: one JSON-lines object per run under prefix/<pipeline>/<session>/<date>/.
+	// Credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+	Bucket   string `json:"bucket,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"` // For S3-compatible stores such as MinIO
+	Prefix   string `json:"prefix,omitempty"`
+
+	// postgres: one row per record in table (created if missing), connecting with the
+	// DSN in the environment variable DSNEnv
+	Table  string `json:"table,omitempty"`
+	DSNEnv string `json:"dsn_env,omitempty"`
+}
+
+// Batch is what a sink receives for one run
+type Batch struct {
+	Pipeline  string        `json:"pipeline"`
+	SessionID string        `json:"session_id,omitempty"`
+	Time      time.Time     `json:"time"`
+	Records   []interface{} `json:"records"`
+}
+
+// Sink stores processed records
+type Sink interface {
+	Write(ctx context.Context, batch Batch) error
+	Close() error
+}
+
+// Result reports what a run did with its input
+type Result struct {
+	Pipeline   string        `json:"pipeline"`
+	Input      int           `json:"input"`            // Records received
+	Output     int           `json:"output"`           // Records written (or returned, without a sink)
+	Duplicates int           `json:"duplicates"`       // Dropped by dedup
+	Invalid    int           `json:"invalid"`          // Dropped by validation
+	Errors     []string      `json:"errors,omitempty"` // Why records were invalid (first few)
+	Records    []interface{} `json:"records"`
+}
+
+// maxReportedErrors caps Result.Errors
+const maxReportedErrors = 10
+
+// Pipeline is a compiled, runnable Spec
+type Pipeline struct {
+	spec  *Spec
+	steps []step
+	sink  Sink
+}
+
+// step is one compiled transform. apply may hold back state changes (dedup keys)
+// until commit, so a failed sink write doesn't mark records as seen.
+type step interface {
+	apply(run *run, records []interface{}) ([]interface{}, error)
+}
+
+// run carries the state of one execution through the steps
+type run struct {
+	sessionID string
+	result    *Result
+	commits   []func()
+}
+
+// Compile checks spec and builds its transforms and sink
+func Compile(spec *Spec) (*Pipeline, error) {
+	if !namePattern.MatchString(spec.Name) {
+		return nil, fmt.Errorf("%w: name %q must be 1-64 letters, digits, '.', '_' or '-'", ErrInvalidPipeline, spec.Name)
+	}
+
+	pipeline := &Pipeline{spec: spec}
+	for i, transform := range spec.Transforms {
+		compiled, err := compileStep(transform)
+		if err != nil {
+			return nil, fmt.Errorf("%w: transform %d (%s): %v", ErrInvalidPipeline, i+1, transform.Type, err)
+		}
+		pipeline.steps = append(pipeline.steps, compiled)
+	}
+
+	if spec.Sink != nil {
+		sink, err := newSink(spec.Sink)
+		if err != nil {
+			return nil, fmt.Errorf("%w: sink: %v", ErrInvalidPipeline, err)
+		}
+		pipeline.sink = sink
+	}
+
+	return pipeline, nil
+}
+
+// Spec returns the pipeline's declaration
+func (p *Pipeline) Spec() *Spec {
+	return p.spec
+}
+
+// Run passes records through the transforms and writes the survivors to the sink.
+// sessionID scopes deduplication and is recorded with the batch; it may be empty.
+func (p *Pipeline) Run(ctx context.Context, sessionID string, records []interface{}) (*Result, error) {
+	state := &run{
+		sessionID: sessionID,
+		result:    &Result{Pipeline: p.spec.Name, Input: len(records)},
+	}
+
+	var err error
+	for _, s := range p.steps {
+		if records, err = s.apply(state, records); err != nil {
+			return state.result, err
+		}
+	}
+
+	state.result.Output = len(records)
+	state.result.Records = records
+
+	if p.sink != nil && len(records) > 0 {
+		batch := Batch{Pipeline: p.spec.Name, SessionID: sessionID, Time: time.Now(), Records: records}
+		if err := p.sink.Write(ctx, batch); err != nil {
+			return state.result, fmt.Errorf("%w: %v", ErrSinkFailed, err)
+		}
+	}
+
+	// Only now are the records really processed
+	for _, commit := range state.commits {
+		commit()
+	}
+	return state.result, nil
+}
+
+// forgetSession drops per-session dedup state
+func (p *Pipeline) forgetSession(sessionID string) {
+	for _, s := range p.steps {
+		if d, ok := s.(*dedupStep); ok {
+			d.forget(sessionID)
+		}
+	}
+}
+
+// close releases the sink
+func (p *Pipeline) close() error {
+	if p.sink == nil {
+		return nil
+	}
+	return p.sink.Close()
+}
+
+// Records turns an extraction result into records: an array is one record per element,
+// anything else (other than null) is a single record
+func Records(result interface{}) []interface{} {
+	switch value := result.(type) {
+	case nil:
+		return []interface{}{}
+	case []interface{}:
+		return value
+	default:
+		return []interface{}{value}
+	}
+}
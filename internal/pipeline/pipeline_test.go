@@ -0,0 +1,341 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeSink records batches and fails while failing is set
+type fakeSink struct {
+	batches []Batch
+	failing bool
+}
+
+func (s *fakeSink) Write(ctx context.Context, batch Batch) error {
+	if s.failing {
+		return errors.New("unavailable")
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *fakeSink) Close() error { return nil }
+
+// records decodes a JSON array of test records
+func records(t *testing.T, data string) []interface{} {
+	t.Helper()
+	var out []interface{}
+	if err := json.Unmarshal([]byte(data), &out); err != nil {
+		t.Fatalf("bad test records: %v", err)
+	}
+	return out
+}
+
+func compile(t *testing.T, spec string) *Pipeline {
+	t.Helper()
+	var parsed Spec
+	if err := json.Unmarshal([]byte(spec), &parsed); err != nil {
+		t.Fatalf("bad test spec: %v", err)
+	}
+	compiled, err := Compile(&parsed)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	return compiled
+}
+
+// TestJQTransform tests that expressions can reshape, drop and split records
+func TestJQTransform(t *testing.T) {
+	compiled := compile(t, `{"name": "p", "transforms": [
+		{"type": "jq", "expr": "select(.price != null) | .tags[] as $tag | {sku, tag: $tag}"}
+	]}`)
+
+	result, err := compiled.Run(context.Background(), "", records(t, `[
+		{"sku": "a", "price": 1, "tags": ["x", "y"]},
+		{"sku": "b", "tags": ["z"]}
+	]`))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	got, _ := json.Marshal(result.Records)
+	want := `[{"sku":"a","tag":"x"},{"sku":"a","tag":"y"}]`
+	if string(got) != want || result.Input != 2 || result.Output != 2 {
+		t.Errorf("got %s (input %d, output %d), want %s", got, result.Input, result.Output, want)
+	}
+}
+
+// TestDedupCommitsAfterSink tests that duplicates are dropped within and across runs
+// and that keys from a failed write are not remembered
+func TestDedupCommitsAfterSink(t *testing.T) {
+	compiled := compile(t, `{"name": "p", "transforms": [{"type": "dedup", "key": ".id"}]}`)
+	sink := &fakeSink{failing: true}
+	compiled.sink = sink
+
+	input := records(t, `[{"id": 1}, {"id": 2}, {"id": 1, "again": true}]`)
+	if _, err := compiled.Run(context.Background(), "sess_a", input); !errors.Is(err, ErrSinkFailed) {
+		t.Fatalf("expected ErrSinkFailed, got %v", err)
+	}
+
+	// The retry must write both records again
+	sink.failing = false
+	result, err := compiled.Run(context.Background(), "sess_a", input)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Output != 2 || result.Duplicates != 1 {
+		t.Errorf("expected 2 written and 1 duplicate, got %d and %d", result.Output, result.Duplicates)
+	}
+
+	result, _ = compiled.Run(context.Background(), "sess_a", records(t, `[{"id": 2}, {"id": 3}]`))
+	if result.Output != 1 || result.Duplicates != 1 {
+		t.Errorf("expected only id 3 in the second run, got %v", result.Records)
+	}
+
+	// Another session has its own keys, and forgetting a session resets them
+	result, _ = compiled.Run(context.Background(), "sess_b", records(t, `[{"id": 1}]`))
+	if result.Output != 1 {
+		t.Errorf("expected sess_b to keep id 1, got %v", result.Records)
+	}
+	compiled.forgetSession("sess_a")
+	result, _ = compiled.Run(context.Background(), "sess_a", records(t, `[{"id": 1}]`))
+	if result.Output != 1 {
+		t.Errorf("expected id 1 after forgetting sess_a, got %v", result.Records)
+	}
+}
+
+// TestValidateTransform tests schema checks and the drop and fail modes
+func TestValidateTransform(t *testing.T) {
+	spec := `{"name": "p", "transforms": [{"type": "validate", %s "schema": {
+		"type": "object",
+		"required": ["sku"],
+		"additionalProperties": false,
+		"properties": {
+			"sku": {"type": "string", "minLength": 1},
+			"price": {"type": ["number", "null"], "minimum": 0},
+			"tags": {"type": "array", "items": {"enum": ["new", "sale"]}}
+		}
+	}}]}`
+	input := records(t, `[
+		{"sku": "a", "price": 2.5, "tags": ["new"]},
+		{"sku": "b", "price": null},
+		{"price": 1},
+		{"sku": "c", "price": -1},
+		{"sku": "d", "tags": ["old"]},
+		{"sku": "e", "color": "red"}
+	]`)
+
+	result, err := compile(t, strings.Replace(spec, "%s", "", 1)).Run(context.Background(), "", input)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Output != 2 || result.Invalid != 4 || len(result.Errors) != 4 {
+		t.Fatalf("expected 2 valid and 4 invalid, got %+v", result)
+	}
+	if !strings.Contains(result.Errors[0], `missing required property "sku"`) ||
+		!strings.Contains(result.Errors[1], "$.price: -1 is below the minimum 0") ||
+		!strings.Contains(result.Errors[2], "$.tags[0]") ||
+		!strings.Contains(result.Errors[3], `unexpected property "color"`) {
+		t.Errorf("unexpected errors: %v", result.Errors)
+	}
+
+	failing := compile(t, strings.Replace(spec, "%s", `"on_invalid": "fail",`, 1))
+	if _, err := failing.Run(context.Background(), "", input); !errors.Is(err, ErrInvalidRecord) {
+		t.Errorf("expected ErrInvalidRecord, got %v", err)
+	}
+}
+
+// TestCompileRejectsBadSpecs tests that mistakes are caught when a pipeline is saved
+func TestCompileRejectsBadSpecs(t *testing.T) {
+	specs := []*Spec{
+		{Name: "bad name"},
+		{Name: "p", Transforms: []TransformSpec{{Type: "jq", Expr: "select("}}},
+		{Name: "p", Transforms: []TransformSpec{{Type: "dedup", Scope: "global"}}},
+		{Name: "p", Transforms: []TransformSpec{{Type: "validate", Schema: json.RawMessage(`{"type": "text"}`)}}},
+		{Name: "p", Transforms: []TransformSpec{{Type: "sort"}}},
+		{Name: "p", Sink: &SinkSpec{Type: "webhook", URL: "ftp://example.com"}},
+		{Name: "p", Sink: &SinkSpec{Type: "s3"}},
+		{Name: "p", Sink: &SinkSpec{Type: "postgres", Table: "results; drop table x", DSNEnv: "PATH"}},
+	}
+	for _, spec := range specs {
+		if _, err := Compile(spec); !errors.Is(err, ErrInvalidPipeline) {
+			t.Errorf("expected ErrInvalidPipeline for %+v, got %v", spec, err)
+		}
+	}
+}
+
+// TestWebhookSink tests that batches are posted with expanded headers and that error
+// responses fail the run
+func TestWebhookSink(t *testing.T) {
+	t.Setenv("PIPELINE_TEST_TOKEN", "secret")
+
+	var received Batch
+	var auth string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	compiled := compile(t, `{"name": "hook", "sink": {"type": "webhook", "url": "`+server.URL+`",
+		"headers": {"Authorization": "Bearer ${PIPELINE_TEST_TOKEN}"}}}`)
+	defer compiled.close()
+
+	if _, err := compiled.Run(context.Background(), "sess_a", records(t, `[{"id": 1}]`)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("expected expanded Authorization header, got %q", auth)
+	}
+	if received.Pipeline != "hook" || received.SessionID != "sess_a" || len(received.Records) != 1 {
+		t.Errorf("unexpected batch: %+v", received)
+	}
+
+	status = http.StatusServiceUnavailable
+	if _, err := compiled.Run(context.Background(), "sess_a", records(t, `[{"id": 2}]`)); !errors.Is(err, ErrSinkFailed) {
+		t.Errorf("expected ErrSinkFailed, got %v", err)
+	}
+}
+
+// TestS3Sink tests the object path and that uploads are signed
+func TestS3Sink(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	var path, auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	compiled := compile(t, `{"name": "lake", "sink": {"type": "s3", "bucket": "results", "region": "eu-west-1",
+		"endpoint": "`+server.URL+`", "prefix": "/raw/"}}`)
+	defer compiled.close()
+
+	if _, err := compiled.Run(context.Background(), "sess_a", records(t, `[{"id": 1}, {"id": 2}]`)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !strings.HasPrefix(path, "/results/raw/lake/sess_a/") || !strings.HasSuffix(path, ".jsonl") {
+		t.Errorf("unexpected object path %q", path)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("unexpected Authorization header %q", auth)
+	}
+	if body != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Errorf("unexpected object body %q", body)
+	}
+}
+
+// recordingHistory keeps the runs it was asked to record
+type recordingHistory struct {
+	runs []*Result
+}
+
+func (h *recordingHistory) RecordRun(ctx context.Context, sessionID string, result *Result) error {
+	h.runs = append(h.runs, result)
+	return nil
+}
+
+// TestRegistryRecordsHistory tests that only successful runs reach the history
+func TestRegistryRecordsHistory(t *testing.T) {
+	registry := NewRegistry()
+	defer registry.Close()
+
+	history := &recordingHistory{}
+	registry.SetHistory(history)
+
+	spec := &Spec{Name: "p", Transforms: []TransformSpec{{Type: "validate", OnInvalid: "fail", Schema: json.RawMessage(`{"type": "object"}`)}}}
+	if err := registry.Put("", spec); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	if _, err := registry.Run(context.Background(), "", "p", "sess_a", records(t, `[{"id": 1}]`)); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := registry.Run(context.Background(), "", "p", "sess_a", records(t, `[1]`)); err == nil {
+		t.Fatal("expected the invalid record to fail the run")
+	}
+
+	if len(history.runs) != 1 || history.runs[0].Output != 1 {
+		t.Errorf("expected one recorded run, got %+v", history.runs)
+	}
+}
+
+// TestRegistryTenants tests that a tenant's pipelines are hidden from other tenants and
+// hide shared pipelines of the same name, which tenants can run but not delete
+func TestRegistryTenants(t *testing.T) {
+	registry := NewRegistry()
+	defer registry.Close()
+
+	for _, put := range []struct {
+		tenantID string
+		spec     *Spec
+	}{
+		{"", &Spec{Name: "shared"}},
+		{"", &Spec{Name: "orders"}},
+		{"acme", &Spec{Name: "orders"}},
+		{"acme", &Spec{Name: "leads"}},
+	} {
+		if err := registry.Put(put.tenantID, put.spec); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	acme, err := registry.Get("acme", "orders")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	shared, err := registry.Get("globex", "orders")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if acme == shared {
+		t.Error("expected acme's pipeline to hide the shared one")
+	}
+	if _, err := registry.Get("globex", "leads"); !errors.Is(err, ErrPipelineNotFound) {
+		t.Errorf("expected ErrPipelineNotFound for another tenant's pipeline, got %v", err)
+	}
+	if _, err := registry.Run(context.Background(), "globex", "leads", "sess_g", records(t, `[{"id": 1}]`)); !errors.Is(err, ErrPipelineNotFound) {
+		t.Errorf("expected another tenant's pipeline not to run, got %v", err)
+	}
+	if err := registry.Delete("globex", "leads"); !errors.Is(err, ErrPipelineNotFound) {
+		t.Errorf("expected another tenant's pipeline not to be deleted, got %v", err)
+	}
+	if err := registry.Delete("globex", "shared"); !errors.Is(err, ErrPipelineNotFound) {
+		t.Errorf("expected a shared pipeline not to be deleted by a tenant, got %v", err)
+	}
+
+	names := func(tenantID string) []string {
+		var names []string
+		for _, spec := range registry.List(tenantID) {
+			names = append(names, spec.Name)
+		}
+		return names
+	}
+	if got := names("acme"); !slices.Equal(got, []string{"leads", "orders", "shared"}) {
+		t.Errorf("expected acme to list its pipelines and the shared one, got %v", got)
+	}
+	if got := names("globex"); !slices.Equal(got, []string{"orders", "shared"}) {
+		t.Errorf("expected globex to list the shared pipelines, got %v", got)
+	}
+	for _, spec := range registry.List("acme") {
+		if spec.Name == "orders" && spec != acme.Spec() {
+			t.Error("expected acme to list its own orders pipeline")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultDSNEnv names the environment variable holding the connection string when a
+// sink doesn't declare its own
+const defaultDSNEnv = "PIPELINE_POSTGRES_DSN"
+
+// identifierPattern allows table or schema.table names that need no quoting
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}(\.[A-Za-z_][A-Za-z0-9_]{0,62})?$`)
+
+// PostgresSink inserts one row per record into a table it creates if missing:
+// (id bigserial, pipeline text, session_id text, record jsonb, created_at timestamptz)
+type PostgresSink struct {
+	pool  *pgxpool.Pool
+	table string
+
+	mu    sync.Mutex
+	ready bool // Table exists
+}
+
+func newPostgresSink(spec *SinkSpec) (*PostgresSink, error) {
+	table := spec.Table
+	if table == "" {
+		table = "pipeline_results"
+	}
+	if !identifierPattern.MatchString(table) {
+		return nil, fmt.Errorf("postgres table %q is not a valid identifier", table)
+	}
+
+	dsnEnv := spec.DSNEnv
+	if dsnEnv == "" {
+		dsnEnv = defaultDSNEnv
+	}
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		return nil, fmt.Errorf("environment variable %s holding the postgres DSN is not set", dsnEnv)
+	}
+
+	// Connections are opened on first use
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure postgres: %v", err)
+	}
+	return &PostgresSink{pool: pool, table: table}, nil
+}
+
+// ensureTable creates the table the first time a write succeeds in reaching the database
+func (s *PostgresSink) ensureTable(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ready {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+s.table+` (
+		id BIGSERIAL PRIMARY KEY,
+		pipeline TEXT NOT NULL,
+		session_id TEXT NOT NULL DEFAULT '',
+		record JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %w", s.table, err)
+	}
+	s.ready = true
+	return nil
+}
+
+// Write inserts the batch in one transaction, so a failure stores nothing
+func (s *PostgresSink) Write(ctx context.Context, batch Batch) error {
+	if err := s.ensureTable(ctx); err != nil {
+		return err
+	}
+
+	rows := make([][]interface{}, 0, len(batch.Records))
+	for _, record := range batch.Records {
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+		rows = append(rows, []interface{}{batch.Pipeline, batch.SessionID, encoded, batch.Time})
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO ` + s.table + ` (pipeline, session_id, record, created_at) VALUES ($1, $2, $3, $4)`
+	queued := &pgx.Batch{}
+	for _, row := range rows {
+		queued.Queue(query, row...)
+	}
+	if err := tx.SendBatch(ctx, queued).Close(); err != nil {
+		return fmt.Errorf("failed to insert records: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit records: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection pool
+func (s *PostgresSink) Close() error {
+	s.pool.Close()
+	return nil
+}
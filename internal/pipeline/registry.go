@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry holds the compiled pipelines, referenced by name from sessions and requests.
+// Pipelines saved by a tenant are seen by that tenant only; the ones without a tenant,
+// such as those loaded from the pipelines file, are shared by everyone.
+type Registry struct {
+	pipelines map[key]*Pipeline
+	history   History // Optional record of successful runs
+	mu        sync.RWMutex
+}
+
+// key is a pipeline's name within the tenant it belongs to
+type key struct {
+	tenantID string
+	name     string
+}
+
+// NewRegistry creates an empty pipeline registry
+func NewRegistry() *Registry {
+	return &Registry{
+		pipelines: make(map[key]*Pipeline),
+	}
+}
+
+// Put compiles and adds a tenant's pipeline, replacing (and closing) the tenant's one
+// with the same name. It hides a shared pipeline with that name, without changing it.
+func (r *Registry) Put(tenantID string, spec *Spec) error {
+	if spec.CreatedAt.IsZero() {
+		spec.CreatedAt = time.Now()
+	}
+
+	compiled, err := Compile(spec)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	previous := r.pipelines[key{tenantID, spec.Name}]
+	r.pipelines[key{tenantID, spec.Name}] = compiled
+	r.mu.Unlock()
+
+	if previous != nil {
+		closePipeline(previous)
+	}
+	return nil
+}
+
+// Get returns a pipeline by name: the tenant's own, or else a shared one
+func (r *Registry) Get(tenantID, name string) (*Pipeline, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	compiled, exists := r.pipelines[key{tenantID, name}]
+	if !exists {
+		compiled, exists = r.pipelines[key{"", name}]
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPipelineNotFound, name)
+	}
+	return compiled, nil
+}
+
+// Delete removes a tenant's pipeline by name and closes its sink. Shared pipelines
+// can't be deleted by a tenant.
+func (r *Registry) Delete(tenantID, name string) error {
+	r.mu.Lock()
+	compiled, exists := r.pipelines[key{tenantID, name}]
+	delete(r.pipelines, key{tenantID, name})
+	r.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrPipelineNotFound, name)
+	}
+	closePipeline(compiled)
+	return nil
+}
+
+// List returns the specs of the pipelines a tenant can run, its own and the shared
+// ones it doesn't hide, sorted by name
+func (r *Registry) List(tenantID string) []*Spec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	visible := make(map[string]*Spec)
+	for k, compiled := range r.pipelines {
+		if k.tenantID == tenantID {
+			visible[k.name] = compiled.spec
+		} else if _, hidden := visible[k.name]; k.tenantID == "" && !hidden {
+			visible[k.name] = compiled.spec
+		}
+	}
+
+	specs := make([]*Spec, 0, len(visible))
+	for _, spec := range visible {
+		specs = append(specs, spec)
+	}
+
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// SetHistory records every successful run from now on in history
+func (r *Registry) SetHistory(history History) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.history = history
+}
+
+// Run runs the tenant's named pipeline over records. A failure to record the run in
+// the history is logged, not returned: the records already reached the sink.
+func (r *Registry) Run(ctx context.Context, tenantID, name, sessionID string, records []interface{}) (*Result, error) {
+	compiled, err := r.Get(tenantID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := compiled.Run(ctx, sessionID, records)
+	if err != nil {
+		return result, err
+	}
+
+	r.mu.RLock()
+	history := r.history
+	r.mu.RUnlock()
+	if history != nil {
+		if err := history.RecordRun(ctx, sessionID, result); err != nil {
+			slog.Warn("failed to record pipeline run", "pipeline", name, "session_id", sessionID, "error", err)
+		}
+	}
+	return result, nil
+}
+
+// ForgetSession drops the dedup state every pipeline holds for a session
+func (r *Registry) ForgetSession(sessionID string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, compiled := range r.pipelines {
+		compiled.forgetSession(sessionID)
+	}
+}
+
+// LoadFile registers every pipeline in a JSON file holding an array of specs, as shared
+// pipelines
+func (r *Registry) LoadFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pipelines file: %w", err)
+	}
+
+	var specs []*Spec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return 0, fmt.Errorf("failed to parse pipelines file: %w", err)
+	}
+
+	for _, spec := range specs {
+		if err := r.Put("", spec); err != nil {
+			return 0, err
+		}
+	}
+	return len(specs), nil
+}
+
+// Close closes every pipeline's sink
+func (r *Registry) Close() {
+	r.mu.Lock()
+	pipelines := r.pipelines
+	r.pipelines = make(map[key]*Pipeline)
+	r.mu.Unlock()
+
+	for _, compiled := range pipelines {
+		closePipeline(compiled)
+	}
+}
+
+// closePipeline closes a pipeline's sink, logging failures
+func closePipeline(compiled *Pipeline) {
+	if err := compiled.close(); err != nil {
+		slog.Warn("failed to close pipeline sink", "pipeline", compiled.spec.Name, "error", err)
+	}
+}
@@ -0,0 +1,255 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schema is the subset of JSON Schema the validate transform supports: type (a name or
+// a list of names), required, properties, additionalProperties (boolean), items, enum,
+// minimum/maximum, minLength/maxLength and minItems/maxItems
+type schema struct {
+	Types                []string
+	Required             []string
+	Properties           map[string]*schema
+	AdditionalProperties *bool
+	Items                *schema
+	Enum                 []interface{}
+	Minimum, Maximum     *float64
+	MinLength, MaxLength *int
+	MinItems, MaxItems   *int
+}
+
+// rawSchema mirrors the JSON form
+type rawSchema struct {
+	Type                 json.RawMessage            `json:"type"`
+	Required             []string                   `json:"required"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	AdditionalProperties *bool                      `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	Enum                 []interface{}              `json:"enum"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+}
+
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// parseSchema decodes and checks a schema document
+func parseSchema(data json.RawMessage) (*schema, error) {
+	var raw rawSchema
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	s := &schema{
+		Required:             raw.Required,
+		AdditionalProperties: raw.AdditionalProperties,
+		Enum:                 raw.Enum,
+		Minimum:              raw.Minimum,
+		Maximum:              raw.Maximum,
+		MinLength:            raw.MinLength,
+		MaxLength:            raw.MaxLength,
+		MinItems:             raw.MinItems,
+		MaxItems:             raw.MaxItems,
+	}
+
+	// type is either "string" or ["string", "null"]
+	if len(raw.Type) > 0 {
+		var single string
+		if err := json.Unmarshal(raw.Type, &single); err == nil {
+			s.Types = []string{single}
+		} else if err := json.Unmarshal(raw.Type, &s.Types); err != nil {
+			return nil, fmt.Errorf("type must be a string or a list of strings")
+		}
+		for _, name := range s.Types {
+			if !schemaTypes[name] {
+				return nil, fmt.Errorf("unknown type %q", name)
+			}
+		}
+	}
+
+	if len(raw.Properties) > 0 {
+		s.Properties = make(map[string]*schema, len(raw.Properties))
+		for name, property := range raw.Properties {
+			parsed, err := parseSchema(property)
+			if err != nil {
+				return nil, fmt.Errorf("properties.%s: %v", name, err)
+			}
+			s.Properties[name] = parsed
+		}
+	}
+
+	if len(raw.Items) > 0 {
+		parsed, err := parseSchema(raw.Items)
+		if err != nil {
+			return nil, fmt.Errorf("items: %v", err)
+		}
+		s.Items = parsed
+	}
+
+	return s, nil
+}
+
+// validate reports the first way value breaks the schema; path locates it in the record
+func (s *schema) validate(value interface{}, path string) error {
+	if len(s.Types) > 0 && !s.matchesType(value) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Types, " or "), typeName(value))
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if reflect.DeepEqual(normalizeNumber(allowed), normalizeNumber(value)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value is not one of the allowed values", path)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+
+		// Check properties in a stable order so the reported error is deterministic
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := property.validate(v[name], path+"."+name); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items, got %d", path, *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items, got %d", path, *s.MaxItems, len(v))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s: expected at least %d characters, got %d", path, *s.MinLength, length)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s: expected at most %d characters, got %d", path, *s.MaxLength, length)
+		}
+
+	default:
+		if number, ok := toFloat(value); ok {
+			if s.Minimum != nil && number < *s.Minimum {
+				return fmt.Errorf("%s: %v is below the minimum %v", path, number, *s.Minimum)
+			}
+			if s.Maximum != nil && number > *s.Maximum {
+				return fmt.Errorf("%s: %v is above the maximum %v", path, number, *s.Maximum)
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesType reports whether value is one of the schema's types
+func (s *schema) matchesType(value interface{}) bool {
+	actual := typeName(value)
+	for _, name := range s.Types {
+		if name == actual {
+			return true
+		}
+		// Every integer is also a number
+		if name == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName names value's JSON Schema type
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if number, ok := toFloat(value); ok {
+		if number == math.Trunc(number) && !math.IsInf(number, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return "unknown"
+}
+
+// toFloat converts the numeric types records may hold (JSON decoding and jq output)
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	// jq produces *big.Int for very large integers
+	if stringer, ok := value.(fmt.Stringer); ok {
+		var f float64
+		if _, err := fmt.Sscan(stringer.String(), &f); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// normalizeNumber makes 1 and 1.0 compare equal in enums
+func normalizeNumber(value interface{}) interface{} {
+	if number, ok := toFloat(value); ok {
+		return number
+	}
+	return value
+}
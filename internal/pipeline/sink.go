@@ -0,0 +1,17 @@
+package pipeline
+
+import "fmt"
+
+// newSink builds the sink a spec declares
+func newSink(spec *SinkSpec) (Sink, error) {
+	switch spec.Type {
+	case SinkWebhook:
+		return newWebhookSink(spec)
+	case SinkS3:
+		return newS3Sink(spec)
+	case SinkPostgres:
+		return newPostgresSink(spec)
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", spec.Type)
+	}
+}
@@ -0,0 +1,256 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/itchyny/gojq"
+)
+
+// maxDedupKeys bounds how many identities one dedup scope remembers; the oldest are
+// forgotten first
+const maxDedupKeys = 100000
+
+// compileStep builds the step for one transform
+func compileStep(spec TransformSpec) (step, error) {
+	switch spec.Type {
+	case TransformJQ:
+		if spec.Expr == "" {
+			return nil, fmt.Errorf("expr is required")
+		}
+		code, err := compileJQ(spec.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return &jqStep{code: code}, nil
+
+	case TransformDedup:
+		d := &dedupStep{scopes: make(map[string]*keySet)}
+		switch spec.Scope {
+		case "", "session":
+		case "pipeline":
+			d.global = true
+		default:
+			return nil, fmt.Errorf("scope must be session or pipeline, got %q", spec.Scope)
+		}
+		if spec.Key != "" {
+			code, err := compileJQ(spec.Key)
+			if err != nil {
+				return nil, err
+			}
+			d.key = code
+		}
+		return d, nil
+
+	case TransformValidate:
+		if len(spec.Schema) == 0 {
+			return nil, fmt.Errorf("schema is required")
+		}
+		schema, err := parseSchema(spec.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema: %v", err)
+		}
+		v := &validateStep{schema: schema}
+		switch spec.OnInvalid {
+		case "", "drop":
+		case "fail":
+			v.fail = true
+		default:
+			return nil, fmt.Errorf("on_invalid must be drop or fail, got %q", spec.OnInvalid)
+		}
+		return v, nil
+
+	default:
+		return nil, fmt.Errorf("unknown transform type %q", spec.Type)
+	}
+}
+
+// compileJQ parses and compiles a jq expression
+func compileJQ(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq expression: %v", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq expression: %v", err)
+	}
+	return code, nil
+}
+
+// evalJQ returns every output of code for input
+func evalJQ(code *gojq.Code, input interface{}) ([]interface{}, error) {
+	var outputs []interface{}
+	iter := code.Run(input)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			return outputs, nil
+		}
+		if err, isErr := value.(error); isErr {
+			return nil, err
+		}
+		outputs = append(outputs, value)
+	}
+}
+
+// jqStep replaces each record with the expression's outputs
+type jqStep struct {
+	code *gojq.Code
+}
+
+func (s *jqStep) apply(_ *run, records []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(records))
+	for i, record := range records {
+		outputs, err := evalJQ(s.code, record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: jq failed on record %d: %v", ErrInvalidRecord, i+1, err)
+		}
+		for _, output := range outputs {
+			// null outputs carry nothing to store
+			if output != nil {
+				out = append(out, output)
+			}
+		}
+	}
+	return out, nil
+}
+
+// dedupStep drops records whose identity was already seen in the session (or, with
+// pipeline scope, by any run of the pipeline)
+type dedupStep struct {
+	key    *gojq.Code // nil: the record itself
+	global bool
+
+	mu     sync.Mutex
+	scopes map[string]*keySet
+}
+
+// keySet remembers identities in insertion order so the oldest can be evicted
+type keySet struct {
+	seen  map[string]struct{}
+	order []string
+}
+
+func (s *dedupStep) apply(r *run, records []interface{}) ([]interface{}, error) {
+	scope := r.sessionID
+	if s.global {
+		scope = ""
+	}
+
+	out := make([]interface{}, 0, len(records))
+	fresh := make([]string, 0, len(records))
+	batch := make(map[string]struct{}, len(records))
+
+	s.mu.Lock()
+	set := s.scopes[scope]
+	for i, record := range records {
+		key, err := s.identity(record)
+		if err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("%w: dedup key failed on record %d: %v", ErrInvalidRecord, i+1, err)
+		}
+
+		_, inBatch := batch[key]
+		inSet := false
+		if set != nil {
+			_, inSet = set.seen[key]
+		}
+		if inBatch || inSet {
+			r.result.Duplicates++
+			continue
+		}
+
+		batch[key] = struct{}{}
+		fresh = append(fresh, key)
+		out = append(out, record)
+	}
+	s.mu.Unlock()
+
+	// Remember the keys only once the records have been written
+	r.commits = append(r.commits, func() { s.remember(scope, fresh) })
+	return out, nil
+}
+
+// identity computes a record's dedup key as canonical JSON (map keys are sorted)
+func (s *dedupStep) identity(record interface{}) (string, error) {
+	value := record
+	if s.key != nil {
+		outputs, err := evalJQ(s.key, record)
+		if err != nil {
+			return "", err
+		}
+		value = outputs
+		if len(outputs) == 1 {
+			value = outputs[0]
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// remember adds keys to a scope, evicting the oldest beyond maxDedupKeys
+func (s *dedupStep) remember(scope string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := s.scopes[scope]
+	if set == nil {
+		set = &keySet{seen: make(map[string]struct{})}
+		s.scopes[scope] = set
+	}
+	for _, key := range keys {
+		if _, ok := set.seen[key]; ok {
+			continue
+		}
+		set.seen[key] = struct{}{}
+		set.order = append(set.order, key)
+	}
+	for len(set.order) > maxDedupKeys {
+		delete(set.seen, set.order[0])
+		set.order = set.order[1:]
+	}
+}
+
+// forget drops a session's identities
+func (s *dedupStep) forget(sessionID string) {
+	if s.global {
+		return
+	}
+	s.mu.Lock()
+	delete(s.scopes, sessionID)
+	s.mu.Unlock()
+}
+
+// validateStep checks records against a schema
+type validateStep struct {
+	schema *schema
+	fail   bool
+}
+
+func (s *validateStep) apply(r *run, records []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, 0, len(records))
+	for i, record := range records {
+		if err := s.schema.validate(record, "$"); err != nil {
+			if s.fail {
+				return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidRecord, i+1, err)
+			}
+			r.result.Invalid++
+			if len(r.result.Errors) < maxReportedErrors {
+				r.result.Errors = append(r.result.Errors, fmt.Sprintf("record %d: %v", i+1, err))
+			}
+			continue
+		}
+		out = append(out, record)
+	}
+	return out, nil
+}
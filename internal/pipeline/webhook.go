@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// webhookTimeout bounds one delivery
+const webhookTimeout = 30 * time.Second
+
+// WebhookSink POSTs each batch as a JSON object
+type WebhookSink struct {
+	url        string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+func newWebhookSink(spec *SinkSpec) (*WebhookSink, error) {
+	parsed, err := url.Parse(spec.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("webhook url must be an http or https URL")
+	}
+	return &WebhookSink{
+		url:        spec.URL,
+		headers:    spec.Headers,
+		httpClient: &http.Client{Timeout: webhookTimeout},
+	}, nil
+}
+
+// Write delivers the batch; any non-2xx response is a failure
+func (s *WebhookSink) Write(ctx context.Context, batch Batch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close releases idle connections
+func (s *WebhookSink) Close() error {
+	s.httpClient.CloseIdleConnections()
+	return nil
+}
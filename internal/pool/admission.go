@@ -0,0 +1,236 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default admission queue bounds
+const (
+	DefaultAdmissionQueueSize = 50
+	DefaultAdmissionMaxWait   = 30 * time.Second
+	admissionPollInterval     = 100 * time.Millisecond
+)
+
+var (
+	// ErrAtCapacity is returned when every browser that could take a session already has
+	// as many as it may
+	ErrAtCapacity = errors.New("all browser processes are at capacity")
+
+	// ErrQueueFull is returned when a request found the admission queue full
+	ErrQueueFull = errors.New("admission queue is full")
+
+	// ErrQueueTimeout is returned when a request waited its longest in the queue
+	ErrQueueTimeout = errors.New("timed out waiting for browser capacity")
+)
+
+// AdmissionPolicy bounds the queue of requests waiting for capacity. A zero Size turns
+// queueing off, so requests fail at capacity straight away.
+type AdmissionPolicy struct {
+	Size    int           // Requests that may wait at once
+	MaxWait time.Duration // How long one may wait
+}
+
+// AdmissionStats describes the admission queue
+type AdmissionStats struct {
+	Depth       int           `json:"depth"` // Requests waiting now
+	Size        int           `json:"size"`
+	MaxWait     time.Duration `json:"max_wait"`
+	OldestWait  time.Duration `json:"oldest_wait"`  // How long the request at the front has waited
+	Queued      uint64        `json:"queued"`       // Requests that had to wait
+	Admitted    uint64        `json:"admitted"`     // Of those, the ones that got capacity
+	TimedOut    uint64        `json:"timed_out"`    // Gave up after MaxWait
+	Rejected    uint64        `json:"rejected"`     // Found the queue full
+	Abandoned   uint64        `json:"abandoned"`    // Cancelled by the client while waiting
+	AverageWait time.Duration `json:"average_wait"` // Of the admitted requests
+	LongestWait time.Duration `json:"longest_wait"` // Of the admitted requests
+}
+
+// admissionTicket is one waiting request
+type admissionTicket struct {
+	since time.Time
+	turn  chan struct{} // Closed when the ticket reaches the front
+}
+
+// AdmissionQueue makes requests wait, first come first served, while browsers are at
+// capacity instead of failing them at once
+type AdmissionQueue struct {
+	mu      sync.Mutex
+	policy  AdmissionPolicy
+	tickets []*admissionTicket
+
+	queued    atomic.Uint64
+	admitted  atomic.Uint64
+	timedOut  atomic.Uint64
+	rejected  atomic.Uint64
+	abandoned atomic.Uint64
+	waited    atomic.Int64 // Total wait of admitted requests, in nanoseconds
+	longest   atomic.Int64
+}
+
+// NewAdmissionQueue creates a queue bounded by policy
+func NewAdmissionQueue(policy AdmissionPolicy) *AdmissionQueue {
+	q := &AdmissionQueue{}
+	q.SetPolicy(policy)
+	return q
+}
+
+// SetPolicy changes the queue's bounds. Requests already waiting keep their place.
+func (q *AdmissionQueue) SetPolicy(policy AdmissionPolicy) {
+	if policy.MaxWait <= 0 {
+		policy.MaxWait = DefaultAdmissionMaxWait
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.policy = policy
+}
+
+// Policy returns the queue's bounds
+func (q *AdmissionQueue) Policy() AdmissionPolicy {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.policy
+}
+
+// Admit runs try, which places a request, and returns its error. While try fails with
+// ErrAtCapacity the request waits its turn and try runs again as the queue moves, until
+// it gets through, fails otherwise, waits MaxWait (ErrQueueTimeout) or ctx is done.
+// Requests arriving while others wait join the back of the queue without trying first.
+func (q *AdmissionQueue) Admit(ctx context.Context, try func() error) error {
+	q.mu.Lock()
+	waiting := len(q.tickets) > 0
+	q.mu.Unlock()
+
+	var err error
+	if !waiting {
+		if err = try(); !errors.Is(err, ErrAtCapacity) {
+			return err
+		}
+	}
+
+	ticket, err := q.enqueue(err)
+	if ticket == nil {
+		return err
+	}
+	defer q.dequeue(ticket)
+
+	q.queued.Add(1)
+	timer := time.NewTimer(q.Policy().MaxWait)
+	defer timer.Stop()
+
+	// Wait to reach the front, then keep trying until there is room
+	select {
+	case <-ticket.turn:
+	case <-timer.C:
+		q.timedOut.Add(1)
+		return ErrQueueTimeout
+	case <-ctx.Done():
+		q.abandoned.Add(1)
+		return ctx.Err()
+	}
+
+	ticker := time.NewTicker(admissionPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := try(); !errors.Is(err, ErrAtCapacity) {
+			if err == nil {
+				q.recordWait(time.Since(ticket.since))
+			}
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			q.timedOut.Add(1)
+			return ErrQueueTimeout
+		case <-ctx.Done():
+			q.abandoned.Add(1)
+			return ctx.Err()
+		}
+	}
+}
+
+// enqueue adds a ticket at the back of the queue. Without room it returns nil and the
+// error to fail the request with: capacity's own error when queueing is off.
+func (q *AdmissionQueue) enqueue(capacityErr error) (*admissionTicket, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.policy.Size <= 0 {
+		if capacityErr == nil {
+			capacityErr = ErrAtCapacity
+		}
+		return nil, capacityErr
+	}
+	if len(q.tickets) >= q.policy.Size {
+		q.rejected.Add(1)
+		return nil, fmt.Errorf("%w (%d waiting)", ErrQueueFull, len(q.tickets))
+	}
+
+	ticket := &admissionTicket{since: time.Now(), turn: make(chan struct{})}
+	if len(q.tickets) == 0 {
+		close(ticket.turn)
+	}
+	q.tickets = append(q.tickets, ticket)
+	return ticket, nil
+}
+
+// dequeue removes a ticket, handing the front to the next one
+func (q *AdmissionQueue) dequeue(ticket *admissionTicket) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, candidate := range q.tickets {
+		if candidate != ticket {
+			continue
+		}
+		q.tickets = append(q.tickets[:i], q.tickets[i+1:]...)
+		if i == 0 && len(q.tickets) > 0 {
+			close(q.tickets[0].turn)
+		}
+		return
+	}
+}
+
+// recordWait adds an admitted request's wait to the statistics
+func (q *AdmissionQueue) recordWait(wait time.Duration) {
+	q.admitted.Add(1)
+	q.waited.Add(int64(wait))
+	for {
+		longest := q.longest.Load()
+		if int64(wait) <= longest || q.longest.CompareAndSwap(longest, int64(wait)) {
+			return
+		}
+	}
+}
+
+// Stats returns the queue's depth and wait times
+func (q *AdmissionQueue) Stats() AdmissionStats {
+	q.mu.Lock()
+	stats := AdmissionStats{
+		Depth:   len(q.tickets),
+		Size:    q.policy.Size,
+		MaxWait: q.policy.MaxWait,
+	}
+	if len(q.tickets) > 0 {
+		stats.OldestWait = time.Since(q.tickets[0].since)
+	}
+	q.mu.Unlock()
+
+	stats.Queued = q.queued.Load()
+	stats.Admitted = q.admitted.Load()
+	stats.TimedOut = q.timedOut.Load()
+	stats.Rejected = q.rejected.Load()
+	stats.Abandoned = q.abandoned.Load()
+	stats.LongestWait = time.Duration(q.longest.Load())
+	if stats.Admitted > 0 {
+		stats.AverageWait = time.Duration(q.waited.Load() / int64(stats.Admitted))
+	}
+	return stats
+}
@@ -0,0 +1,95 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAdmissionQueue tests waiting for capacity, in order, within the queue's bounds
+func TestAdmissionQueue(t *testing.T) {
+	ctx := context.Background()
+	var free atomic.Int64
+	try := func() error {
+		if free.Add(-1) < 0 {
+			free.Add(1)
+			return ErrAtCapacity
+		}
+		return nil
+	}
+
+	// Without a queue capacity errors come straight back
+	off := NewAdmissionQueue(AdmissionPolicy{})
+	if err := off.Admit(ctx, try); !errors.Is(err, ErrAtCapacity) {
+		t.Fatalf("expected ErrAtCapacity, got %v", err)
+	}
+	other := errors.New("name taken")
+	if err := off.Admit(ctx, func() error { return other }); err != other {
+		t.Errorf("expected other errors returned as they are, got %v", err)
+	}
+
+	queue := NewAdmissionQueue(AdmissionPolicy{Size: 2, MaxWait: 2 * time.Second})
+	var order []int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := queue.Admit(ctx, try)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				t.Errorf("waiter %d: %v", i, err)
+			}
+			order = append(order, i)
+		}(i)
+		// Let each waiter take its place before the next arrives
+		for queue.Stats().Depth != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if err := queue.Admit(ctx, try); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	free.Store(1)
+	for queue.Stats().Depth != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	free.Store(1)
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != 0 || order[1] != 1 {
+		t.Errorf("expected waiters admitted in order, got %v", order)
+	}
+	stats := queue.Stats()
+	if stats.Depth != 0 || stats.Queued != 2 || stats.Admitted != 2 || stats.Rejected != 1 || stats.AverageWait <= 0 || stats.LongestWait < stats.AverageWait {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestAdmissionQueueGivesUp tests the queue's timeout and a client going away
+func TestAdmissionQueueGivesUp(t *testing.T) {
+	full := func() error { return ErrAtCapacity }
+
+	queue := NewAdmissionQueue(AdmissionPolicy{Size: 5, MaxWait: 150 * time.Millisecond})
+	if err := queue.Admit(context.Background(), full); !errors.Is(err, ErrQueueTimeout) {
+		t.Errorf("expected ErrQueueTimeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := queue.Admit(ctx, full); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the client's deadline, got %v", err)
+	}
+
+	stats := queue.Stats()
+	if stats.Depth != 0 || stats.TimedOut != 1 || stats.Abandoned != 1 || stats.Admitted != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -3,11 +3,13 @@ package pool
 import (
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 )
 
 //The load Balancer struct is responsible for balancing the load between the browser processes
 type LoadBalancer struct {
-	pool *ProcessPool
+	pool        *ProcessPool
+	maxSessions atomic.Int64 // Sessions a process may hold (0: no limit)
 }
 
 // This function creates a new load balancer
@@ -17,11 +19,41 @@ func NewLoadBalancer(pool *ProcessPool) *LoadBalancer {
 	}
 }
 
+// SetMaxSessions sets how many sessions one process may hold; 0 removes the limit
+func (lb *LoadBalancer) SetMaxSessions(max int) {
+	lb.maxSessions.Store(int64(max))
+}
+
+// MaxSessions returns how many sessions one process may hold (0: no limit)
+func (lb *LoadBalancer) MaxSessions() int {
+	return int(lb.maxSessions.Load())
+}
+
 // This function balances the load between the browser processes by selecting the browser process with the least number of sessions
 func (lb *LoadBalancer) SelectProcess() (*ManagedProcess, error) {
 	// 1. Get all the processes from the pool
-	processes := lb.pool.GetProcesses()
+	return lb.selectFrom(lb.pool.GetProcesses())
+}
+
+// SelectProcessAmong is SelectProcess restricted to the processes on ports, e.g. the
+// ones a tenant already uses once it has reached its process quota
+func (lb *LoadBalancer) SelectProcessAmong(ports []int) (*ManagedProcess, error) {
+	allowed := make(map[int]bool, len(ports))
+	for _, port := range ports {
+		allowed[port] = true
+	}
 
+	processes := make([]*ManagedProcess, 0, len(ports))
+	for _, process := range lb.pool.GetProcesses() {
+		if allowed[process.GetPort()] {
+			processes = append(processes, process)
+		}
+	}
+	return lb.selectFrom(processes)
+}
+
+// selectFrom picks the least loaded healthy process of processes
+func (lb *LoadBalancer) selectFrom(processes []*ManagedProcess) (*ManagedProcess, error) {
 	//2. Edge case to check if the pool is empty
 	if len(processes) == 0 {
 		return nil, fmt.Errorf("no processes in the pool")
@@ -30,6 +62,8 @@ func (lb *LoadBalancer) SelectProcess() (*ManagedProcess, error) {
 	// 3. Select the process with the least load
 	var selected *ManagedProcess
 	var minSessions int64 = -1
+	maxSessions := lb.maxSessions.Load()
+	full := false
 
 	// 3a. Iterate through the processes and find the one with the least sessions
 	for _, process := range processes {
@@ -40,6 +74,22 @@ func (lb *LoadBalancer) SelectProcess() (*ManagedProcess, error) {
 			continue
 		}
 
+		//Draining processes only take sessions when nothing else can
+		if process.IsDraining() {
+			continue
+		}
+
+		//An open circuit breaker means most commands are failing there
+		if !process.breaker.allow() {
+			continue
+		}
+
+		//Full processes take nothing more until a session ends
+		if maxSessions > 0 && process.GetSessionCount() >= maxSessions {
+			full = true
+			continue
+		}
+
 		//Then we check if the process has the least number of sessions
 		sessionCount := process.GetSessionCount()
 		if minSessions == -1 || sessionCount < minSessions {
@@ -48,7 +98,26 @@ func (lb *LoadBalancer) SelectProcess() (*ManagedProcess, error) {
 		}
 	}
 
-	//If we didn't find any healthy process, we return an error
+	//Fall back to a draining process rather than refusing the session outright
+	if selected == nil {
+		for _, process := range processes {
+			if !process.IsHealthy() || !process.breaker.allow() {
+				continue
+			}
+			if maxSessions > 0 && process.GetSessionCount() >= maxSessions {
+				full = true
+				continue
+			}
+			if selected == nil || process.GetSessionCount() < selected.GetSessionCount() {
+				selected = process
+			}
+		}
+	}
+
+	//If we didn't find any healthy process, we return an error; a full one means waiting may help
+	if selected == nil && full {
+		return nil, ErrAtCapacity
+	}
 	if selected == nil {
 		return nil, fmt.Errorf("no healthy processes in the pool")
 	}
@@ -0,0 +1,255 @@
+package pool
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Default circuit breaker settings
+const (
+	DefaultBreakerFailureRatio = 0.5
+	DefaultBreakerMinCommands  = 20
+	DefaultBreakerWindow       = 1 * time.Minute
+	DefaultBreakerCooldown     = 30 * time.Second
+	breakerBuckets             = 10 // The window is counted in this many slices
+	breakerProbes              = 5  // Commands a half-open breaker needs to see succeed
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // Sessions are placed on the process as usual
+	BreakerOpen     = "open"      // Too many commands failed; no new sessions until it recovers
+	BreakerHalfOpen = "half_open" // On probation: new sessions again, but one failure reopens it
+)
+
+// BreakerPolicy decides when a process's circuit opens. A zero FailureRatio turns the
+// breaker off.
+type BreakerPolicy struct {
+	FailureRatio float64       // Fraction of failed commands that opens the circuit
+	MinCommands  int           // Commands in the window before the ratio is trusted
+	Window       time.Duration // How far back commands are counted
+	Cooldown     time.Duration // How long the circuit stays open before it is tried again
+}
+
+// Enabled reports whether the breaker can open
+func (p BreakerPolicy) Enabled() bool {
+	return p.FailureRatio > 0
+}
+
+// withDefaults fills unset fields of an enabled policy
+func (p BreakerPolicy) withDefaults() BreakerPolicy {
+	if p.MinCommands <= 0 {
+		p.MinCommands = DefaultBreakerMinCommands
+	}
+	if p.Window <= 0 {
+		p.Window = DefaultBreakerWindow
+	}
+	if p.Cooldown <= 0 {
+		p.Cooldown = DefaultBreakerCooldown
+	}
+	return p
+}
+
+// BreakerStatus is a breaker's state in process metrics
+type BreakerStatus struct {
+	State    string     `json:"state"`
+	Commands int        `json:"commands"`            // Commands counted in the window
+	Failures int        `json:"failures"`            // Of which failed
+	Trips    int        `json:"trips,omitempty"`     // Times opened since it was last closed
+	OpenedAt *time.Time `json:"opened_at,omitempty"` // When it last opened, while not closed
+}
+
+// breakerBucket counts the commands of one slice of the window
+type breakerBucket struct {
+	start    time.Time
+	commands int
+	failures int
+}
+
+// breaker watches how many of a process's DevTools commands fail. The zero value is a
+// closed breaker that never opens.
+type breaker struct {
+	mu        sync.Mutex
+	policy    BreakerPolicy
+	state     string
+	buckets   [breakerBuckets]breakerBucket
+	probes    int       // Successes seen while half-open
+	trips     int       // Times opened since last closed
+	openedAt  time.Time // When the circuit last opened
+	recovered bool      // A recovery was attempted for the current trip
+	now       func() time.Time
+}
+
+// clock returns the current time (overridden in tests)
+func (b *breaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// setPolicy replaces the breaker's policy. Turning the breaker off closes it.
+func (b *breaker) setPolicy(policy BreakerPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if policy.Enabled() {
+		policy = policy.withDefaults()
+	} else {
+		b.closeLocked()
+	}
+	b.policy = policy
+}
+
+// enabled reports whether the breaker has a policy
+func (b *breaker) enabled() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.policy.Enabled()
+}
+
+// record counts the outcome of one command on the browser on port
+func (b *breaker) record(failed bool, port int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.policy.Enabled() {
+		return
+	}
+	now := b.clock()
+	b.advanceLocked(now)
+
+	switch b.state {
+	case BreakerOpen:
+		// Sessions already there keep running; their commands say nothing new
+		return
+	case BreakerHalfOpen:
+		if failed {
+			b.openLocked(now, port, "a command failed on probation")
+			return
+		}
+		if b.probes++; b.probes >= breakerProbes {
+			b.closeLocked()
+			slog.Info("circuit breaker closed", "port", port)
+		}
+		return
+	}
+
+	bucket := b.bucketLocked(now)
+	bucket.commands++
+	if failed {
+		bucket.failures++
+	}
+
+	commands, failures := b.countLocked(now)
+	if commands >= b.policy.MinCommands && float64(failures) >= b.policy.FailureRatio*float64(commands) {
+		b.openLocked(now, port, fmt.Sprintf("%d of %d commands failed", failures, commands))
+	}
+}
+
+// allow reports whether new sessions may be placed on the process
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked(b.clock())
+	return b.state != BreakerOpen
+}
+
+// status returns the breaker's state and counts
+func (b *breaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock()
+	b.advanceLocked(now)
+	commands, failures := b.countLocked(now)
+	status := BreakerStatus{State: b.state, Commands: commands, Failures: failures, Trips: b.trips}
+	if status.State == "" {
+		status.State = BreakerClosed
+	}
+	if b.state == BreakerOpen || b.state == BreakerHalfOpen {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// claimRecovery reports, once per trip, that an open circuit is waiting for recovery
+func (b *breaker) claimRecovery() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != BreakerOpen || b.recovered {
+		return false
+	}
+	b.recovered = true
+	return true
+}
+
+// probation moves an open circuit to half-open, e.g. once its browser was restarted
+func (b *breaker) probation() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		b.state = BreakerHalfOpen
+		b.probes = 0
+	}
+}
+
+// advanceLocked moves an open circuit to half-open once its cooldown has passed
+func (b *breaker) advanceLocked(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.policy.Cooldown {
+		b.state = BreakerHalfOpen
+		b.probes = 0
+	}
+}
+
+// openLocked opens the circuit
+func (b *breaker) openLocked(now time.Time, port int, reason string) {
+	b.state = BreakerOpen
+	b.openedAt = now
+	b.recovered = false
+	b.trips++
+	slog.Warn("circuit breaker opened, no new sessions go to this browser",
+		"port", port,
+		"reason", reason,
+		"trips", b.trips,
+		"cooldown", b.policy.Cooldown)
+}
+
+// closeLocked closes the circuit and forgets the commands counted so far
+func (b *breaker) closeLocked() {
+	b.state = BreakerClosed
+	b.trips = 0
+	b.probes = 0
+	b.buckets = [breakerBuckets]breakerBucket{}
+}
+
+// bucketLocked returns the slice of the window now falls in, emptied if it is stale
+func (b *breaker) bucketLocked(now time.Time) *breakerBucket {
+	width := b.policy.Window / breakerBuckets
+	start := now.Truncate(width)
+	bucket := &b.buckets[int(start.UnixNano()/int64(width))%breakerBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	return bucket
+}
+
+// countLocked sums the commands counted within the window
+func (b *breaker) countLocked(now time.Time) (commands, failures int) {
+	if b.policy.Window <= 0 {
+		return 0, 0
+	}
+	cutoff := now.Add(-b.policy.Window)
+	for _, bucket := range b.buckets {
+		if bucket.start.After(cutoff) {
+			commands += bucket.commands
+			failures += bucket.failures
+		}
+	}
+	return commands, failures
+}
@@ -0,0 +1,82 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBreaker tests opening on a failure ratio, cooling down and probation
+func TestBreaker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := &breaker{now: func() time.Time { return now }}
+
+	// Without a policy nothing opens
+	for i := 0; i < 50; i++ {
+		b.record(true, 9222)
+	}
+	if !b.allow() || b.enabled() {
+		t.Fatal("expected a breaker without a policy to stay closed")
+	}
+
+	b.setPolicy(BreakerPolicy{FailureRatio: 0.5, MinCommands: 10, Window: time.Minute, Cooldown: 30 * time.Second})
+	for i := 0; i < 4; i++ {
+		b.record(true, 9222)
+	}
+	if !b.allow() {
+		t.Fatal("expected the breaker to wait for MinCommands")
+	}
+	for i := 0; i < 6; i++ {
+		b.record(i%2 == 0, 9222)
+	}
+	status := b.status()
+	if b.allow() || status.State != BreakerOpen || status.Failures != 7 || status.Commands != 10 || status.Trips != 1 {
+		t.Fatalf("expected the breaker open at 7 of 10, got %+v", status)
+	}
+
+	// Recovery is claimed once per trip
+	if !b.claimRecovery() || b.claimRecovery() {
+		t.Error("expected exactly one recovery claim")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !b.allow() || b.status().State != BreakerHalfOpen {
+		t.Fatalf("expected half-open after the cooldown, got %+v", b.status())
+	}
+	b.record(true, 9222)
+	if b.allow() || b.status().Trips != 2 {
+		t.Fatalf("expected a failure on probation to reopen, got %+v", b.status())
+	}
+
+	b.probation()
+	for i := 0; i < breakerProbes; i++ {
+		b.record(false, 9222)
+	}
+	if status := b.status(); status.State != BreakerClosed || status.Commands != 0 || status.Trips != 0 {
+		t.Errorf("expected successful probes to close the breaker, got %+v", status)
+	}
+}
+
+// TestBreakerWindow tests that old commands stop counting
+func TestBreakerWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := &breaker{now: func() time.Time { return now }}
+	b.setPolicy(BreakerPolicy{FailureRatio: 0.5, MinCommands: 10, Window: time.Minute})
+
+	for i := 0; i < 9; i++ {
+		b.record(true, 9222)
+	}
+	now = now.Add(2 * time.Minute)
+	b.record(true, 9222)
+	if status := b.status(); !b.allow() || status.Commands != 1 {
+		t.Errorf("expected failures outside the window forgotten, got %+v", status)
+	}
+
+	// Turning the policy off closes an open breaker
+	for i := 0; i < 10; i++ {
+		b.record(true, 9222)
+	}
+	b.setPolicy(BreakerPolicy{})
+	if !b.allow() {
+		t.Error("expected a disabled breaker to close")
+	}
+}
@@ -1,17 +1,27 @@
 package pool
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
 )
 
 // ProcessPool manages a pool of browser processes
 type ProcessPool struct {
-	processes    []*ManagedProcess // Pool of browser processes
-	chromiumPath string            // Path to chromium binary
-	maxProcesses int               // Maximum number of processes
-	mu           sync.RWMutex      // Protects processes slice
+	processes    []*ManagedProcess        // Pool of browser processes
+	chromiumPath string                   // Path to chromium binary
+	container    *browser.ContainerConfig // Launch browsers in containers instead (nil runs them locally)
+	remote       bool                     // Processes are external browsers; none can be started
+	launch       browser.LaunchOptions    // How launched browsers are started
+	breaker      BreakerPolicy            // Circuit breaker applied to every process
+	maxProcesses int                      // Maximum number of processes
+	mu           sync.RWMutex             // Protects processes slice
+	stopMonitor  chan struct{}            // Closed on shutdown to stop the resource monitor
+	stopOnce     sync.Once
 }
 
 // PoolMetrics contains metrics about the entire pool
@@ -21,23 +31,84 @@ type PoolMetrics struct {
 	Processes      []ProcessMetrics `json:"processes"`
 }
 
-// NewProcessPool creates a new process pool
-func NewProcessPool(chromiumPath string, poolSize int) (*ProcessPool, error) {
+// Pool size bounds
+const (
+	MinPoolSize = 1
+	MaxPoolSize = 10
+)
+
+// ErrInvalidPoolSize is returned for pool sizes outside MinPoolSize..MaxPoolSize
+var ErrInvalidPoolSize = errors.New("invalid pool size")
+
+// ErrRemotePool is returned when asking a pool of external browsers to start one
+var ErrRemotePool = errors.New("pool attaches to remote browsers and cannot start new ones")
+
+// NewProcessPool creates a new process pool whose browsers are launched with launch
+func NewProcessPool(chromiumPath string, poolSize int, launch browser.LaunchOptions) (*ProcessPool, error) {
+	return newProcessPool(chromiumPath, nil, poolSize, launch)
+}
+
+// NewContainerPool creates a pool whose browsers each run in their own Docker container.
+// The image chooses the browser's flags, so only launch's start timeout applies.
+func NewContainerPool(container browser.ContainerConfig, poolSize int, launch browser.LaunchOptions) (*ProcessPool, error) {
+	return newProcessPool("", &container, poolSize, launch)
+}
+
+// NewRemotePool attaches to already-running browsers instead of starting any.
+// Endpoints that don't answer yet are kept, reported unhealthy, and used once they come up.
+func NewRemotePool(endpoints []string) (*ProcessPool, error) {
+	if len(endpoints) < MinPoolSize || len(endpoints) > MaxPoolSize {
+		return nil, fmt.Errorf("%w: need between %d and %d remote browsers, got %d", ErrInvalidPoolSize, MinPoolSize, MaxPoolSize, len(endpoints))
+	}
+
+	pool := &ProcessPool{
+		processes:    make([]*ManagedProcess, 0, len(endpoints)),
+		remote:       true,
+		maxProcesses: len(endpoints),
+		stopMonitor:  make(chan struct{}),
+	}
+
+	for i, endpoint := range endpoints {
+		process, err := browser.NewRemoteProcess(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		if err := process.Start(); err != nil {
+			slog.Warn("remote browser not reachable yet", "index", i, "port", process.DebugPort, "error", err)
+		}
+
+		pool.processes = append(pool.processes, &ManagedProcess{
+			Process:     process,
+			startedAt:   time.Now(),
+			lastHealthy: time.Now(),
+		})
+		slog.Info("attached remote browser", "index", i, "port", process.DebugPort)
+	}
+
+	slog.Info("remote process pool initialized", "size", len(endpoints))
+	return pool, nil
+}
+
+// newProcessPool starts poolSize browsers, locally or in containers
+func newProcessPool(chromiumPath string, container *browser.ContainerConfig, poolSize int, launch browser.LaunchOptions) (*ProcessPool, error) {
 	// Validate pool size
-	if poolSize < 1 || poolSize > 10 {
-		return nil, fmt.Errorf("pool size must be between 1 and 10, got %d", poolSize)
+	if poolSize < MinPoolSize || poolSize > MaxPoolSize {
+		return nil, fmt.Errorf("pool size must be between %d and %d, got %d", MinPoolSize, MaxPoolSize, poolSize)
 	}
 
 	// Create process pool
 	pool := &ProcessPool{
 		processes:    make([]*ManagedProcess, 0, poolSize),
 		chromiumPath: chromiumPath,
+		container:    container,
+		launch:       launch,
 		maxProcesses: poolSize,
+		stopMonitor:  make(chan struct{}),
 	}
 
 	// Start managed processes
 	for i := 0; i < poolSize; i++ {
-		process, err := NewManagedProcess(chromiumPath)
+		process, err := newManagedProcess(chromiumPath, container, launch)
 		if err != nil {
 			// Cleanup on failure - stop all processes started so far
 			slog.Error("failed to start process, cleaning up", "index", i, "error", err)
@@ -63,6 +134,77 @@ func (p *ProcessPool) GetProcesses() []*ManagedProcess {
 	return processes
 }
 
+// GetProcess returns the process listening on port, or nil
+func (p *ProcessPool) GetProcess(port int) *ManagedProcess {
+	for _, process := range p.GetProcesses() {
+		if process.GetPort() == port {
+			return process
+		}
+	}
+	return nil
+}
+
+// AddProcess starts one more browser process and adds it to the pool
+func (p *ProcessPool) AddProcess() (*ManagedProcess, error) {
+	if p.remote {
+		return nil, ErrRemotePool
+	}
+
+	// Starting takes seconds, so do it before taking the lock
+	process, err := newManagedProcess(p.chromiumPath, p.container, p.launch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start process: %w", err)
+	}
+
+	p.mu.Lock()
+	process.breaker.setPolicy(p.breaker)
+	p.processes = append(p.processes, process)
+	p.maxProcesses = len(p.processes)
+	p.mu.Unlock()
+
+	slog.Info("started browser process", "port", process.GetPort())
+	return process, nil
+}
+
+// RemoveProcess takes a process out of the pool without stopping it
+func (p *ProcessPool) RemoveProcess(process *ManagedProcess) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.processes) <= MinPoolSize {
+		return fmt.Errorf("%w: cannot remove the last process", ErrInvalidPoolSize)
+	}
+
+	for i, candidate := range p.processes {
+		if candidate == process {
+			p.processes = append(p.processes[:i], p.processes[i+1:]...)
+			p.maxProcesses = len(p.processes)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("process not in pool")
+}
+
+// SetBreakerPolicy changes when the processes' circuit breakers open, including those
+// of processes added later
+func (p *ProcessPool) SetBreakerPolicy(policy BreakerPolicy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.breaker = policy
+	for _, process := range p.processes {
+		process.breaker.setPolicy(policy)
+	}
+}
+
+// BreakerPolicy returns the circuit breaker policy
+func (p *ProcessPool) BreakerPolicy() BreakerPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.breaker
+}
+
 // GetProcessCount returns the number of processes in the pool
 func (p *ProcessPool) GetProcessCount() int {
 	p.mu.RLock()
@@ -70,8 +212,53 @@ func (p *ProcessPool) GetProcessCount() int {
 	return len(p.processes)
 }
 
+// StartResourceMonitor samples every process's resource usage at the given interval
+func (p *ProcessPool) StartResourceMonitor(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			p.sampleResources()
+
+			select {
+			case <-p.stopMonitor:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sampleResources takes one resource sample of every process
+func (p *ProcessPool) sampleResources() {
+	for _, process := range p.GetProcesses() {
+		// Remote browsers run on other hosts where /proc can't be read
+		if process.IsRemote() {
+			continue
+		}
+
+		usage, err := process.SampleResources()
+		if err != nil {
+			slog.Debug("failed to sample browser resources", "port", process.GetPort(), "error", err)
+			continue
+		}
+
+		// Zombies mean the browser is failing to reap crashed renderers
+		if usage.ZombieCount > 0 {
+			slog.Warn("browser has zombie child processes", "port", process.GetPort(), "zombies", usage.ZombieCount)
+		}
+	}
+}
+
 // Shutdown stops all processes in the pool (best effort)
 func (p *ProcessPool) Shutdown() error {
+	p.stopOnce.Do(func() { close(p.stopMonitor) })
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
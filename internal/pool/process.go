@@ -1,6 +1,9 @@
 package pool
 
 import (
+	"fmt"
+	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -9,36 +12,64 @@ import (
 
 // ManagedProcess wraps the actual browser process with session count and other metrics
 type ManagedProcess struct {
-	Process      *browser.Process // The actual browser process
-	sessionCount int64            // Active session count
-	startedAt    time.Time        // When process was started
-	lastHealthy  time.Time        // Last successful health check
+	Process        *browser.Process // The actual browser process
+	sessionCount   int64            // Active session count
+	sessionsServed int64            // Sessions created since the process (re)started
+	draining       atomic.Bool      // Excluded from load balancing while set
+	startedAt      time.Time        // When process was started
+	lastHealthy    time.Time        // Last successful health check
+	mu             sync.RWMutex     // Protects Process and startedAt across restarts
+
+	usage     *ResourceUsage        // Latest resource sample (nil until first sampled)
+	lastStats *browser.ProcessStats // Previous raw sample, for CPU% deltas
+	usageMu   sync.Mutex            // Protects usage and lastStats
+
+	breaker breaker // Opens when too many DevTools commands fail
+}
+
+// ResourceUsage is the latest resource sample of a browser process tree
+type ResourceUsage struct {
+	RSSBytes     uint64    `json:"rss_bytes"`
+	CPUPercent   float64   `json:"cpu_percent"` // Of one core, so a busy tree can exceed 100
+	OpenFDs      int       `json:"open_fds"`
+	ProcessCount int       `json:"process_count"`
+	ZombieCount  int       `json:"zombie_count"`
+	SampledAt    time.Time `json:"sampled_at"`
 }
 
 // ProcessMetrics contains metrics about a managed process
 type ProcessMetrics struct {
-	Port             int           `json:"port"`
-	SessionCount     int64         `json:"session_count"`
-	Uptime           time.Duration `json:"uptime"`
-	LastHealthyCheck time.Time     `json:"last_healthy_check"`
+	Port             int            `json:"port"`
+	SessionCount     int64          `json:"session_count"`
+	SessionsServed   int64          `json:"sessions_served"`
+	Draining         bool           `json:"draining,omitempty"`
+	Remote           bool           `json:"remote,omitempty"`
+	Uptime           time.Duration  `json:"uptime"`
+	LastHealthyCheck time.Time      `json:"last_healthy_check"`
+	Resources        *ResourceUsage `json:"resources,omitempty"`
+	Breaker          *BreakerStatus `json:"breaker,omitempty"` // Only while a breaker policy is set
 }
 
 // NewManagedProcess creates a new managed process
 func NewManagedProcess(chromiumPath string) (*ManagedProcess, error) {
+	return newManagedProcess(chromiumPath, nil, browser.LaunchOptions{})
+}
+
+// newManagedProcess starts a browser locally, or in a container when one is configured
+func newManagedProcess(chromiumPath string, container *browser.ContainerConfig, launch browser.LaunchOptions) (*ManagedProcess, error) {
 	// Create a new browser process
 	process, err := browser.NewProcess(chromiumPath)
 	if err != nil {
 		return nil, err
 	}
+	process.Container = container
+	process.LaunchOptions = launch
 
-	// Start the browser process
+	// Start returns once DevTools answers on the debug port
 	if err := process.Start(); err != nil {
 		return nil, err
 	}
 
-	// Wait for the browser process to be ready
-	time.Sleep(2 * time.Second)
-
 	return &ManagedProcess{
 		Process:      process,
 		sessionCount: 0,
@@ -55,6 +86,84 @@ func (mp *ManagedProcess) GetSessionCount() int64 {
 // IncrementSessionCount increments the session count using atomic operations
 func (mp *ManagedProcess) IncrementSessionCount() {
 	atomic.AddInt64(&mp.sessionCount, 1)
+	atomic.AddInt64(&mp.sessionsServed, 1)
+}
+
+// addSessions moves n sessions' worth of load onto this process (used after a migration)
+func (mp *ManagedProcess) addSessions(n int64) {
+	atomic.AddInt64(&mp.sessionCount, n)
+}
+
+// GetSessionsServed returns how many sessions were created since the process (re)started
+func (mp *ManagedProcess) GetSessionsServed() int64 {
+	return atomic.LoadInt64(&mp.sessionsServed)
+}
+
+// SetDraining stops or resumes new sessions being placed on this process
+func (mp *ManagedProcess) SetDraining(draining bool) {
+	mp.draining.Store(draining)
+}
+
+// IsDraining reports whether the process is being drained
+func (mp *ManagedProcess) IsDraining() bool {
+	return mp.draining.Load()
+}
+
+// GetUptime returns how long the current browser process has been running
+func (mp *ManagedProcess) GetUptime() time.Duration {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+	return time.Since(mp.startedAt)
+}
+
+// GetMemoryRSS returns the resident memory of the browser process tree
+func (mp *ManagedProcess) GetMemoryRSS() (uint64, error) {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+	return mp.Process.MemoryRSS()
+}
+
+// SampleResources reads the process tree's current resource usage.
+// CPU% is averaged over the time since the previous sample.
+func (mp *ManagedProcess) SampleResources() (*ResourceUsage, error) {
+	mp.mu.RLock()
+	stats, err := mp.Process.Stats()
+	mp.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample process resources: %w", err)
+	}
+
+	now := time.Now()
+	usage := &ResourceUsage{
+		RSSBytes:     stats.RSSBytes,
+		OpenFDs:      stats.OpenFDs,
+		ProcessCount: stats.ProcessCount,
+		ZombieCount:  stats.ZombieCount,
+		SampledAt:    now,
+	}
+
+	mp.usageMu.Lock()
+	defer mp.usageMu.Unlock()
+
+	// A restart resets CPU counters, which shows up as time going backwards
+	if mp.lastStats != nil && mp.usage != nil {
+		elapsed := now.Sub(mp.usage.SampledAt).Seconds()
+		used := stats.CPUSeconds - mp.lastStats.CPUSeconds
+		if elapsed > 0 && used >= 0 {
+			usage.CPUPercent = used / elapsed * 100
+		}
+	}
+
+	mp.lastStats = &stats
+	mp.usage = usage
+	return usage, nil
+}
+
+// GetResourceUsage returns the latest resource sample, or nil if none was taken
+func (mp *ManagedProcess) GetResourceUsage() *ResourceUsage {
+	mp.usageMu.Lock()
+	defer mp.usageMu.Unlock()
+	return mp.usage
 }
 
 // DecrementSessionCount decrements the session count using atomic operations
@@ -64,11 +173,22 @@ func (mp *ManagedProcess) DecrementSessionCount() {
 
 // GetPort returns the browser process port
 func (mp *ManagedProcess) GetPort() int {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
 	return mp.Process.DebugPort
 }
 
+// IsRemote reports whether the process is an external browser the pool attached to
+func (mp *ManagedProcess) IsRemote() bool {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+	return mp.Process.IsRemote()
+}
+
 // IsHealthy checks if the browser process is still alive
 func (mp *ManagedProcess) IsHealthy() bool {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
 	if mp.Process.IsAlive() {
 		mp.lastHealthy = time.Now()
 		return true
@@ -78,15 +198,89 @@ func (mp *ManagedProcess) IsHealthy() bool {
 
 // Stop stops the browser process
 func (mp *ManagedProcess) Stop() error {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
 	return mp.Process.Stop()
 }
 
+// Restart stops the browser and starts a fresh one, reusing the debug port when it is
+// still free. The session count carries over because migrated sessions stay on this process.
+func (mp *ManagedProcess) Restart() error {
+	// The slow stop/start happens outside the lock; the old process simply reports unhealthy meanwhile
+	mp.mu.RLock()
+	old := mp.Process
+	mp.mu.RUnlock()
+
+	// There is nothing to respawn for an external browser: restarting it means starting
+	// over with fresh contexts on the same endpoint once it answers
+	if old.IsRemote() {
+		if !old.IsAlive() {
+			return fmt.Errorf("remote browser on port %d is not reachable", old.DebugPort)
+		}
+		mp.mu.Lock()
+		mp.startedAt = time.Now()
+		mp.lastHealthy = time.Now()
+		mp.mu.Unlock()
+		atomic.StoreInt64(&mp.sessionsServed, 0)
+		return nil
+	}
+
+	if err := old.Stop(); err != nil {
+		// The process may already be dead, which is often why it is being restarted
+		slog.Warn("failed to stop browser process before restart", "port", old.DebugPort, "error", err)
+	}
+
+	process, err := browser.NewProcessOnPort(old.BinaryPath, old.DebugPort)
+	if err != nil {
+		slog.Warn("debug port not reusable, allocating a new one", "port", old.DebugPort, "error", err)
+		process, err = browser.NewProcess(old.BinaryPath)
+		if err != nil {
+			return fmt.Errorf("failed to create browser process: %w", err)
+		}
+	}
+	process.Container = old.Container
+	process.LaunchOptions = old.LaunchOptions
+
+	if err := process.Start(); err != nil {
+		return fmt.Errorf("failed to start browser process: %w", err)
+	}
+
+	mp.mu.Lock()
+	mp.Process = process
+	mp.startedAt = time.Now()
+	mp.lastHealthy = time.Now()
+	mp.mu.Unlock()
+	atomic.StoreInt64(&mp.sessionsServed, 0)
+
+	return nil
+}
+
+// RecordCommand counts a DevTools command the browser finished or failed, for its
+// circuit breaker
+func (mp *ManagedProcess) RecordCommand(failed bool) {
+	mp.breaker.record(failed, mp.GetPort())
+}
+
+// BreakerStatus returns the state of the process's circuit breaker
+func (mp *ManagedProcess) BreakerStatus() BreakerStatus {
+	return mp.breaker.status()
+}
+
 // GetMetrics returns the process metrics
 func (mp *ManagedProcess) GetMetrics() ProcessMetrics {
-	return ProcessMetrics{
+	metrics := ProcessMetrics{
 		Port:             mp.GetPort(),
 		SessionCount:     atomic.LoadInt64(&mp.sessionCount),
-		Uptime:           time.Since(mp.startedAt),
+		SessionsServed:   mp.GetSessionsServed(),
+		Draining:         mp.IsDraining(),
+		Remote:           mp.IsRemote(),
+		Uptime:           mp.GetUptime(),
 		LastHealthyCheck: mp.lastHealthy,
+		Resources:        mp.GetResourceUsage(),
+	}
+	if mp.breaker.enabled() {
+		status := mp.breaker.status()
+		metrics.Breaker = &status
 	}
+	return metrics
 }
\ No newline at end of file
@@ -0,0 +1,54 @@
+package pool
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+)
+
+// TestSampleResources tests sampling a live process tree
+func TestSampleResources(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	// Any long-running command stands in for the browser
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start helper process: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	process := &ManagedProcess{Process: &browser.Process{Cmd: cmd}}
+
+	if process.GetResourceUsage() != nil {
+		t.Error("expected no usage before the first sample")
+	}
+
+	first, err := process.SampleResources()
+	if err != nil {
+		t.Fatalf("SampleResources failed: %v", err)
+	}
+	if first.ProcessCount != 1 || first.RSSBytes == 0 || first.OpenFDs == 0 {
+		t.Errorf("unexpected first sample: %+v", first)
+	}
+	if first.CPUPercent != 0 {
+		t.Errorf("expected no CPU%% without a previous sample, got %f", first.CPUPercent)
+	}
+
+	second, err := process.SampleResources()
+	if err != nil {
+		t.Fatalf("SampleResources failed: %v", err)
+	}
+	if second.CPUPercent < 0 {
+		t.Errorf("expected non-negative CPU%%, got %f", second.CPUPercent)
+	}
+	if got := process.GetMetrics().Resources; got != second {
+		t.Error("expected metrics to report the latest sample")
+	}
+}
@@ -0,0 +1,265 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+)
+
+// ErrProfileInUse is returned when a profile's browser is already running, for another
+// session or in another instance of the service on this host
+var ErrProfileInUse = errors.New("profile is in use")
+
+// ErrProfileNotFound is returned for a profile that has never been used
+var ErrProfileNotFound = errors.New("profile not found")
+
+// ProfilePool runs a browser per named profile, each with a user data directory kept
+// under root so cookies, storage, cache and extensions survive restarts. Browsers are
+// started for the one session using the profile and stopped when it ends. They are
+// not part of the process pool, so the load balancer never places other sessions on them.
+type ProfilePool struct {
+	root         string
+	chromiumPath string
+	launch       browser.LaunchOptions
+	extensions   *browser.ExtensionCatalog // Extensions sessions may ask their profile's browser to load
+
+	mu      sync.Mutex
+	running map[string]*ManagedProcess // Profile key → its browser (nil while starting)
+	loaded  map[string][]string        // Profile key → names of the extensions its browser loaded
+}
+
+// ProfileInfo describes a profile on disk
+type ProfileInfo struct {
+	Name       string    `json:"name"`
+	Running    bool      `json:"running"`
+	Port       int       `json:"port,omitempty"`       // Debug port while running
+	Extensions []string  `json:"extensions,omitempty"` // Extensions loaded while running
+	SizeBytes  int64     `json:"size_bytes"`
+	UpdatedAt  time.Time `json:"updated_at"` // Last time the browser wrote to the profile
+}
+
+// NewProfilePool keeps profiles under root, creating it if needed. Browsers load the
+// catalog's default extensions and those their session names; extensions may be nil.
+func NewProfilePool(root, chromiumPath string, launch browser.LaunchOptions, extensions *browser.ExtensionCatalog) (*ProfilePool, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	return &ProfilePool{
+		root:         root,
+		chromiumPath: chromiumPath,
+		launch:       launch,
+		extensions:   extensions,
+		running:      make(map[string]*ManagedProcess),
+		loaded:       make(map[string][]string),
+	}, nil
+}
+
+// tenantDir holds the profiles of a tenant. Profiles without a tenant are shared by
+// every untenanted caller.
+func (p *ProfilePool) tenantDir(tenantID string) string {
+	if tenantID == "" {
+		return filepath.Join(p.root, "shared")
+	}
+	return filepath.Join(p.root, "tenants", tenantID)
+}
+
+// profileKey identifies a tenant's profile in running
+func profileKey(tenantID, name string) string {
+	return tenantID + "/" + name
+}
+
+// Acquire starts the browser for a tenant's profile, creating the profile on first use,
+// and returns its debug port. The browser loads the named extensions on top of the
+// catalog's defaults.
+func (p *ProfilePool) Acquire(tenantID, name string, extensions []string) (int, error) {
+	if err := browser.ValidateProfileName(name); err != nil {
+		return 0, err
+	}
+	launch := p.launch
+	resolved, err := p.extensions.Resolve(extensions)
+	if err != nil {
+		return 0, err
+	}
+	launch.Extensions = resolved
+
+	// Starting takes seconds; the placeholder keeps a second caller out meanwhile
+	key := profileKey(tenantID, name)
+	p.mu.Lock()
+	if _, busy := p.running[key]; busy {
+		p.mu.Unlock()
+		return 0, fmt.Errorf("%w: %s", ErrProfileInUse, name)
+	}
+	p.running[key] = nil
+	p.mu.Unlock()
+
+	process, err := p.start(filepath.Join(p.tenantDir(tenantID), name), launch)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		delete(p.running, key)
+		return 0, err
+	}
+	p.running[key] = process
+	p.loaded[key] = extensionNames(resolved)
+
+	slog.Info("started profile browser", "tenant_id", tenantID, "profile", name, "port", process.GetPort())
+	return process.GetPort(), nil
+}
+
+// start launches a browser on a profile directory
+func (p *ProfilePool) start(dir string, launch browser.LaunchOptions) (*ManagedProcess, error) {
+	process, err := browser.NewProfileProcess(p.chromiumPath, dir)
+	if errors.Is(err, browser.ErrProfileLocked) {
+		return nil, fmt.Errorf("%w: %w", ErrProfileInUse, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	process.LaunchOptions = launch
+
+	if err := process.Start(); err != nil {
+		process.Discard()
+		return nil, err
+	}
+
+	return &ManagedProcess{
+		Process:     process,
+		startedAt:   time.Now(),
+		lastHealthy: time.Now(),
+	}, nil
+}
+
+// Release stops the browser of a tenant's profile. The profile stays on disk.
+func (p *ProfilePool) Release(tenantID, name string) error {
+	key := profileKey(tenantID, name)
+	p.mu.Lock()
+	process := p.running[key]
+	if process == nil {
+		p.mu.Unlock()
+		return nil // Not running, or still starting for a caller that will release it
+	}
+	delete(p.running, key)
+	delete(p.loaded, key)
+	p.mu.Unlock()
+
+	if err := process.Stop(); err != nil {
+		return fmt.Errorf("failed to stop profile browser: %w", err)
+	}
+	slog.Info("stopped profile browser", "tenant_id", tenantID, "profile", name)
+	return nil
+}
+
+// List returns a tenant's profiles, sorted by name
+func (p *ProfilePool) List(tenantID string) ([]ProfileInfo, error) {
+	entries, err := os.ReadDir(p.tenantDir(tenantID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []ProfileInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+
+	profiles := make([]ProfileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || browser.ValidateProfileName(entry.Name()) != nil {
+			continue
+		}
+		profiles = append(profiles, p.describe(tenantID, entry.Name()))
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// describe reports a profile's state and footprint on disk
+func (p *ProfilePool) describe(tenantID, name string) ProfileInfo {
+	info := ProfileInfo{Name: name}
+
+	key := profileKey(tenantID, name)
+	p.mu.Lock()
+	process, running := p.running[key]
+	info.Extensions = p.loaded[key]
+	p.mu.Unlock()
+	info.Running = running
+	if process != nil {
+		info.Port = process.GetPort()
+	}
+
+	// Chromium replaces files as it writes, so it may race with the walk
+	filepath.WalkDir(filepath.Join(p.tenantDir(tenantID), name), func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if stat, err := entry.Info(); err == nil {
+			info.SizeBytes += stat.Size()
+			info.UpdatedAt = latest(info.UpdatedAt, stat.ModTime())
+		}
+		return nil
+	})
+	return info
+}
+
+// extensionNames returns the names of extensions
+func extensionNames(extensions []browser.Extension) []string {
+	names := make([]string, len(extensions))
+	for i, extension := range extensions {
+		names[i] = extension.Name
+	}
+	return names
+}
+
+// latest returns the later of two times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// Delete removes a tenant's profile from disk. A running profile can't be deleted.
+func (p *ProfilePool) Delete(tenantID, name string) error {
+	if err := browser.ValidateProfileName(name); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, busy := p.running[profileKey(tenantID, name)]; busy {
+		return fmt.Errorf("%w: %s", ErrProfileInUse, name)
+	}
+
+	dir := filepath.Join(p.tenantDir(tenantID), name)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove profile: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops every running profile browser
+func (p *ProfilePool) Shutdown() {
+	p.mu.Lock()
+	running := p.running
+	p.running = make(map[string]*ManagedProcess)
+	p.loaded = make(map[string][]string)
+	p.mu.Unlock()
+
+	for key, process := range running {
+		if process == nil {
+			continue
+		}
+		if err := process.Stop(); err != nil {
+			slog.Warn("failed to stop profile browser", "profile", key, "error", err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package pool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+)
+
+// TestProfilePoolListAndDelete tests listing a tenant's profiles and deleting them,
+// except while their browser runs
+func TestProfilePoolListAndDelete(t *testing.T) {
+	profiles, err := NewProfilePool(t.TempDir(), "chromium", browser.LaunchOptions{}, nil)
+	if err != nil {
+		t.Fatalf("NewProfilePool failed: %v", err)
+	}
+
+	for _, name := range []string{"work", "personal"} {
+		dir := filepath.Join(profiles.tenantDir("acme"), name)
+		os.MkdirAll(dir, 0o700)
+		os.WriteFile(filepath.Join(dir, "Cookies"), []byte("12345"), 0o600)
+	}
+	profiles.running[profileKey("acme", "work")] = nil // Starting
+
+	list, err := profiles.List("acme")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "personal" || list[1].Name != "work" {
+		t.Fatalf("unexpected profiles: %+v", list)
+	}
+	if list[0].SizeBytes != 5 || list[0].Running || !list[1].Running {
+		t.Errorf("unexpected profile state: %+v", list)
+	}
+	if other, _ := profiles.List("beta"); len(other) != 0 {
+		t.Errorf("expected another tenant not to see the profiles, got %+v", other)
+	}
+
+	if err := profiles.Delete("acme", "work"); !errors.Is(err, ErrProfileInUse) {
+		t.Errorf("expected ErrProfileInUse for a running profile, got %v", err)
+	}
+	if err := profiles.Delete("acme", "personal"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+	if err := profiles.Delete("acme", "personal"); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("expected ErrProfileNotFound, got %v", err)
+	}
+	if _, err := profiles.Acquire("acme", "work", nil); !errors.Is(err, ErrProfileInUse) {
+		t.Errorf("expected a second Acquire to be refused, got %v", err)
+	}
+	if _, err := profiles.Acquire("acme", "other", []string{"adblock"}); !errors.Is(err, browser.ErrExtensionNotFound) {
+		t.Errorf("expected an unknown extension to be refused, got %v", err)
+	}
+}
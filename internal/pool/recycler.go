@@ -0,0 +1,482 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Default recycler timings
+const (
+	DefaultRecycleCheckInterval = 1 * time.Minute
+	DefaultRecycleDrainTimeout  = 5 * time.Minute
+	recycleDrainPollInterval    = 1 * time.Second
+)
+
+// RecyclePolicy decides when a browser process is restarted. Zero limits are disabled.
+type RecyclePolicy struct {
+	MaxSessions   int64         // Sessions served before a restart
+	MaxUptime     time.Duration // Process lifetime before a restart
+	MaxRSSBytes   uint64        // Resident memory of the process tree before a restart
+	DrainTimeout  time.Duration // How long to wait for sessions to end before migrating them
+	CheckInterval time.Duration // How often processes are checked
+}
+
+// Enabled reports whether any recycling limit is set
+func (p RecyclePolicy) Enabled() bool {
+	return p.MaxSessions > 0 || p.MaxUptime > 0 || p.MaxRSSBytes > 0
+}
+
+// Reason returns why a process with the given stats should be recycled, or "" if it should not
+func (p RecyclePolicy) Reason(sessionsServed int64, uptime time.Duration, rssBytes uint64) string {
+	if p.MaxSessions > 0 && sessionsServed >= p.MaxSessions {
+		return fmt.Sprintf("served %d sessions (limit %d)", sessionsServed, p.MaxSessions)
+	}
+	if p.MaxUptime > 0 && uptime >= p.MaxUptime {
+		return fmt.Sprintf("uptime %s (limit %s)", uptime.Round(time.Second), p.MaxUptime)
+	}
+	if p.MaxRSSBytes > 0 && rssBytes >= p.MaxRSSBytes {
+		return fmt.Sprintf("memory %d MiB (limit %d MiB)", rssBytes>>20, p.MaxRSSBytes>>20)
+	}
+	return ""
+}
+
+// ProcessLifecycle is notified around a process restart so sessions on it can be moved.
+// The session manager implements it.
+type ProcessLifecycle interface {
+	// BeforeRestart detaches everything still using the browser on port
+	BeforeRestart(port int) error
+	// AfterRestart re-creates detached sessions on the restarted browser
+	AfterRestart(oldPort int, newPort int) error
+}
+
+// ConnectionChecker is optionally implemented by a ProcessLifecycle to report browsers
+// whose DevTools connection dropped even though the endpoint still answers
+type ConnectionChecker interface {
+	IsConnected(port int) bool
+}
+
+// DefaultRemoteCheckInterval is how often remote browsers are checked for reconnection
+const DefaultRemoteCheckInterval = 10 * time.Second
+
+// DefaultBreakerCheckInterval is how often open circuit breakers are checked for recovery
+const DefaultBreakerCheckInterval = 5 * time.Second
+
+// ErrProcessBusy is returned when a process is already being restarted or removed
+var ErrProcessBusy = errors.New("process is already being recycled")
+
+// Recycler restarts, adds and removes browser processes, moving sessions as needed.
+// Start runs the automatic policy; the other operations are used by the admin API.
+type Recycler struct {
+	pool      *ProcessPool
+	policy    RecyclePolicy
+	policyMu  sync.RWMutex
+	lifecycle ProcessLifecycle
+	ctx       context.Context
+	cancel    context.CancelFunc
+	busy      map[*ManagedProcess]bool // Processes with an operation in flight
+	busyMu    sync.Mutex
+	resizeMu  sync.Mutex // Serializes pool resizes
+}
+
+// NewRecycler creates a recycler for the pool; call Start to begin checking
+func NewRecycler(pool *ProcessPool, policy RecyclePolicy, lifecycle ProcessLifecycle) *Recycler {
+	if policy.CheckInterval <= 0 {
+		policy.CheckInterval = DefaultRecycleCheckInterval
+	}
+	if policy.DrainTimeout <= 0 {
+		policy.DrainTimeout = DefaultRecycleDrainTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Recycler{
+		pool:      pool,
+		policy:    policy,
+		lifecycle: lifecycle,
+		ctx:       ctx,
+		cancel:    cancel,
+		busy:      make(map[*ManagedProcess]bool),
+	}
+}
+
+// Pool returns the process pool the recycler operates on
+func (r *Recycler) Pool() *ProcessPool {
+	return r.pool
+}
+
+// Policy returns the recycling policy
+func (r *Recycler) Policy() RecyclePolicy {
+	r.policyMu.RLock()
+	defer r.policyMu.RUnlock()
+	return r.policy
+}
+
+// SetPolicy replaces the limits and drain timeout used from the next check on.
+// The check interval is fixed once Start has been called.
+func (r *Recycler) SetPolicy(policy RecyclePolicy) {
+	r.policyMu.Lock()
+	defer r.policyMu.Unlock()
+
+	if policy.DrainTimeout <= 0 {
+		policy.DrainTimeout = DefaultRecycleDrainTimeout
+	}
+	policy.CheckInterval = r.policy.CheckInterval
+	r.policy = policy
+
+	slog.Info("recycle policy updated",
+		"max_sessions", policy.MaxSessions,
+		"max_uptime", policy.MaxUptime,
+		"max_rss_bytes", policy.MaxRSSBytes,
+		"drain_timeout", policy.DrainTimeout)
+}
+
+// acquire marks a process busy, failing if another operation holds it
+func (r *Recycler) acquire(process *ManagedProcess) error {
+	r.busyMu.Lock()
+	defer r.busyMu.Unlock()
+
+	if r.busy[process] {
+		return ErrProcessBusy
+	}
+	r.busy[process] = true
+	return nil
+}
+
+// IsBusy reports whether a restart or removal of process is in flight
+func (r *Recycler) IsBusy(process *ManagedProcess) bool {
+	r.busyMu.Lock()
+	defer r.busyMu.Unlock()
+	return r.busy[process]
+}
+
+// release clears a process's busy mark
+func (r *Recycler) release(process *ManagedProcess) {
+	r.busyMu.Lock()
+	defer r.busyMu.Unlock()
+	delete(r.busy, process)
+}
+
+// Start runs the recycling loop in the background. Checks are skipped while the
+// policy has no limits, so limits can be turned on later with SetPolicy.
+func (r *Recycler) Start() {
+	policy := r.Policy()
+
+	go func() {
+		ticker := time.NewTicker(policy.CheckInterval)
+		defer ticker.Stop()
+
+		slog.Info("process recycler started",
+			"max_sessions", policy.MaxSessions,
+			"max_uptime", policy.MaxUptime,
+			"max_rss_bytes", policy.MaxRSSBytes,
+			"drain_timeout", policy.DrainTimeout)
+
+		for {
+			select {
+			case <-r.ctx.Done():
+				slog.Info("process recycler stopping")
+				return
+			case <-ticker.C:
+				r.check()
+			}
+		}
+	}()
+}
+
+// Stop ends the recycling loop, abandoning any drain in progress
+func (r *Recycler) Stop() {
+	r.cancel()
+}
+
+// check recycles at most one process per pass so capacity never drops by more than one browser
+func (r *Recycler) check() {
+	policy := r.Policy()
+	if !policy.Enabled() {
+		return
+	}
+
+	for _, process := range r.pool.GetProcesses() {
+		// External browsers are not ours to restart; WatchRemotes looks after them
+		if process.IsDraining() || process.IsRemote() {
+			continue
+		}
+
+		// Memory is only sampled when a limit is set; reading /proc is not free
+		var rss uint64
+		if policy.MaxRSSBytes > 0 {
+			var err error
+			if rss, err = process.GetMemoryRSS(); err != nil {
+				slog.Debug("failed to read process memory", "port", process.GetPort(), "error", err)
+			}
+		}
+
+		reason := policy.Reason(process.GetSessionsServed(), process.GetUptime(), rss)
+		if reason == "" {
+			continue
+		}
+
+		if err := r.Recycle(process, reason); err != nil {
+			slog.Error("failed to recycle browser process", "port", process.GetPort(), "error", err)
+		}
+		return
+	}
+}
+
+// Recycle drains a process, waits for its sessions to finish (up to the policy's drain
+// timeout), then restarts it and migrates the sessions that were still running.
+func (r *Recycler) Recycle(process *ManagedProcess, reason string) error {
+	return r.Restart(process, r.Policy().DrainTimeout, reason)
+}
+
+// Restart is Recycle with an explicit drain timeout; 0 migrates sessions immediately
+func (r *Recycler) Restart(process *ManagedProcess, drainTimeout time.Duration, reason string) error {
+	if err := r.acquire(process); err != nil {
+		return err
+	}
+	defer r.release(process)
+
+	oldPort := process.GetPort()
+	slog.Info("recycling browser process", "port", oldPort, "reason", reason)
+
+	process.SetDraining(true)
+	defer process.SetDraining(false)
+
+	if err := r.waitForDrain(process, drainTimeout); err != nil {
+		return err
+	}
+
+	if r.lifecycle != nil {
+		if err := r.lifecycle.BeforeRestart(oldPort); err != nil {
+			return fmt.Errorf("failed to detach sessions: %w", err)
+		}
+	}
+
+	if err := process.Restart(); err != nil {
+		return fmt.Errorf("failed to restart process: %w", err)
+	}
+
+	newPort := process.GetPort()
+	if r.lifecycle != nil {
+		if err := r.lifecycle.AfterRestart(oldPort, newPort); err != nil {
+			return fmt.Errorf("failed to migrate sessions: %w", err)
+		}
+	}
+
+	slog.Info("browser process recycled", "old_port", oldPort, "new_port", newPort)
+	return nil
+}
+
+// waitForDrain lets sessions end on their own for up to timeout
+func (r *Recycler) waitForDrain(process *ManagedProcess, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for process.GetSessionCount() > 0 && time.Now().Before(deadline) {
+		select {
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		case <-time.After(recycleDrainPollInterval):
+		}
+	}
+
+	if remaining := process.GetSessionCount(); remaining > 0 {
+		slog.Info("drain timeout reached, migrating remaining sessions", "port", process.GetPort(), "sessions", remaining)
+	}
+	return nil
+}
+
+// Resize grows or shrinks the pool to size processes. Removed processes are drained
+// for up to drainTimeout, then their sessions move to the least loaded remaining process.
+func (r *Recycler) Resize(size int, drainTimeout time.Duration) error {
+	if size < MinPoolSize || size > MaxPoolSize {
+		return fmt.Errorf("%w: must be between %d and %d, got %d", ErrInvalidPoolSize, MinPoolSize, MaxPoolSize, size)
+	}
+
+	r.resizeMu.Lock()
+	defer r.resizeMu.Unlock()
+
+	current := r.pool.GetProcessCount()
+	for ; current < size; current++ {
+		if _, err := r.pool.AddProcess(); err != nil {
+			return err
+		}
+	}
+
+	for ; current > size; current-- {
+		if err := r.removeOne(drainTimeout); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("process pool resized", "size", r.pool.GetProcessCount())
+	return nil
+}
+
+// removeOne drains and stops the least loaded process, moving its sessions elsewhere
+func (r *Recycler) removeOne(drainTimeout time.Duration) error {
+	// Pick the least loaded process that no other operation holds
+	var victim *ManagedProcess
+	for _, process := range r.pool.GetProcesses() {
+		if victim == nil || process.GetSessionCount() < victim.GetSessionCount() {
+			if r.acquire(process) == nil {
+				if victim != nil {
+					r.release(victim)
+				}
+				victim = process
+			}
+		}
+	}
+	if victim == nil {
+		return ErrProcessBusy
+	}
+	defer r.release(victim)
+
+	oldPort := victim.GetPort()
+	victim.SetDraining(true)
+
+	if err := r.waitForDrain(victim, drainTimeout); err != nil {
+		victim.SetDraining(false)
+		return err
+	}
+
+	// Take it out of rotation before choosing where its sessions go
+	if err := r.pool.RemoveProcess(victim); err != nil {
+		victim.SetDraining(false)
+		return err
+	}
+
+	if r.lifecycle != nil {
+		if err := r.lifecycle.BeforeRestart(oldPort); err != nil {
+			slog.Warn("failed to detach sessions from removed process", "port", oldPort, "error", err)
+		}
+	}
+
+	if err := victim.Stop(); err != nil {
+		slog.Warn("failed to stop removed process", "port", oldPort, "error", err)
+	}
+
+	// Sessions that outlived the drain move to the least loaded survivor
+	target := r.leastLoaded()
+	if target != nil && r.lifecycle != nil {
+		target.addSessions(victim.GetSessionCount())
+		if err := r.lifecycle.AfterRestart(oldPort, target.GetPort()); err != nil {
+			return fmt.Errorf("failed to migrate sessions: %w", err)
+		}
+	}
+
+	slog.Info("browser process removed from pool", "port", oldPort)
+	return nil
+}
+
+// leastLoaded returns the healthy process with the fewest sessions
+func (r *Recycler) leastLoaded() *ManagedProcess {
+	var selected *ManagedProcess
+	for _, process := range r.pool.GetProcesses() {
+		if !process.IsHealthy() {
+			continue
+		}
+		if selected == nil || process.GetSessionCount() < selected.GetSessionCount() {
+			selected = process
+		}
+	}
+	return selected
+}
+
+// WatchRemotes checks remote browsers every interval. One that stopped answering, or
+// whose DevTools connection dropped, is reconnected once it answers again; its sessions
+// are re-created in fresh contexts the same way a restart migrates them.
+func (r *Recycler) WatchRemotes(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRemoteCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		down := make(map[*ManagedProcess]bool)
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+				r.checkRemotes(down)
+			}
+		}
+	}()
+}
+
+// checkRemotes reconnects remote browsers that came back; down tracks the ones still out
+func (r *Recycler) checkRemotes(down map[*ManagedProcess]bool) {
+	checker, _ := r.lifecycle.(ConnectionChecker)
+
+	for _, process := range r.pool.GetProcesses() {
+		if !process.IsRemote() {
+			continue
+		}
+
+		port := process.GetPort()
+		if !process.IsHealthy() {
+			if !down[process] {
+				slog.Warn("remote browser unreachable", "port", port)
+			}
+			down[process] = true
+			continue
+		}
+
+		// A client that reconnected on its own has already restored its sessions
+		if checker == nil || checker.IsConnected(port) {
+			delete(down, process)
+			continue
+		}
+
+		if err := r.Restart(process, 0, "remote browser reconnected"); err != nil {
+			slog.Warn("failed to reconnect remote browser", "port", port, "error", err)
+			continue
+		}
+		delete(down, process)
+	}
+}
+
+// WatchBreakers checks the processes' circuit breakers every interval. A process whose
+// breaker opened is restarted once per trip, its sessions moving over at once since
+// their commands keep failing, and the breaker goes on probation. If the restart fails
+// the breaker stays open until its cooldown passes.
+func (r *Recycler) WatchBreakers(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultBreakerCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+				r.recoverBreakers()
+			}
+		}
+	}()
+}
+
+// recoverBreakers restarts the processes whose breaker opened since the last check
+func (r *Recycler) recoverBreakers() {
+	for _, process := range r.pool.GetProcesses() {
+		if !process.breaker.claimRecovery() {
+			continue
+		}
+
+		status := process.breaker.status()
+		reason := fmt.Sprintf("circuit breaker open, %d of %d commands failed", status.Failures, status.Commands)
+		if err := r.Restart(process, 0, reason); err != nil {
+			slog.Error("failed to recover browser process", "port", process.GetPort(), "error", err)
+			continue
+		}
+		process.breaker.probation()
+	}
+}
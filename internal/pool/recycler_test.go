@@ -0,0 +1,151 @@
+package pool
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecyclePolicyReason tests which limit triggers a recycle
+func TestRecyclePolicyReason(t *testing.T) {
+	policy := RecyclePolicy{
+		MaxSessions: 100,
+		MaxUptime:   6 * time.Hour,
+		MaxRSSBytes: 2 << 30,
+	}
+
+	if !policy.Enabled() {
+		t.Fatal("expected policy with limits to be enabled")
+	}
+	if (RecyclePolicy{DrainTimeout: time.Minute}).Enabled() {
+		t.Error("expected policy without limits to be disabled")
+	}
+
+	tests := []struct {
+		name     string
+		served   int64
+		uptime   time.Duration
+		rss      uint64
+		contains string
+	}{
+		{"within limits", 10, time.Hour, 1 << 30, ""},
+		{"sessions served", 100, time.Hour, 0, "served 100 sessions"},
+		{"uptime", 0, 7 * time.Hour, 0, "uptime"},
+		{"memory", 0, 0, 3 << 30, "memory 3072 MiB"},
+	}
+
+	for _, tt := range tests {
+		reason := policy.Reason(tt.served, tt.uptime, tt.rss)
+		if tt.contains == "" && reason != "" {
+			t.Errorf("%s: expected no recycle, got %q", tt.name, reason)
+		}
+		if tt.contains != "" && !strings.Contains(reason, tt.contains) {
+			t.Errorf("%s: expected reason containing %q, got %q", tt.name, tt.contains, reason)
+		}
+	}
+}
+
+// TestRecyclerBusyAndResizeBounds tests that operations on one process are exclusive
+// and that pool sizes outside the supported range are rejected
+func TestRecyclerBusyAndResizeBounds(t *testing.T) {
+	recycler := NewRecycler(&ProcessPool{}, RecyclePolicy{}, nil)
+	process := &ManagedProcess{}
+
+	if err := recycler.acquire(process); err != nil {
+		t.Fatalf("expected first acquire to succeed, got %v", err)
+	}
+	if !recycler.IsBusy(process) {
+		t.Error("expected process to be busy")
+	}
+	if err := recycler.acquire(process); !errors.Is(err, ErrProcessBusy) {
+		t.Errorf("expected ErrProcessBusy, got %v", err)
+	}
+	recycler.release(process)
+	if recycler.IsBusy(process) {
+		t.Error("expected process to be free after release")
+	}
+
+	for _, size := range []int{MinPoolSize - 1, MaxPoolSize + 1} {
+		if err := recycler.Resize(size, 0); !errors.Is(err, ErrInvalidPoolSize) {
+			t.Errorf("size %d: expected ErrInvalidPoolSize, got %v", size, err)
+		}
+	}
+}
+
+// fakeLifecycle records restart notifications and reports a configurable connection state
+type fakeLifecycle struct {
+	connected bool
+	before    []int
+	after     []int
+}
+
+func (f *fakeLifecycle) BeforeRestart(port int) error {
+	f.before = append(f.before, port)
+	return nil
+}
+
+func (f *fakeLifecycle) AfterRestart(oldPort int, newPort int) error {
+	f.after = append(f.after, newPort)
+	return nil
+}
+
+func (f *fakeLifecycle) IsConnected(port int) bool {
+	return f.connected
+}
+
+// TestCheckRemotesReconnects tests that a remote browser with a dropped connection
+// has its sessions re-created once it answers
+func TestCheckRemotesReconnects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	pool, err := NewRemotePool([]string{strings.TrimPrefix(server.URL, "http://")})
+	if err != nil {
+		t.Fatalf("failed to create remote pool: %v", err)
+	}
+	process := pool.GetProcesses()[0]
+
+	if _, err := pool.AddProcess(); !errors.Is(err, ErrRemotePool) {
+		t.Errorf("expected ErrRemotePool, got %v", err)
+	}
+
+	lifecycle := &fakeLifecycle{connected: true}
+	recycler := NewRecycler(pool, RecyclePolicy{}, lifecycle)
+	down := make(map[*ManagedProcess]bool)
+
+	// Connected and reachable: nothing to do
+	recycler.checkRemotes(down)
+	if len(lifecycle.before) != 0 {
+		t.Fatalf("expected no reconnect, got %v", lifecycle.before)
+	}
+
+	// Connection dropped while the endpoint still answers
+	lifecycle.connected = false
+	recycler.checkRemotes(down)
+	if len(lifecycle.before) != 1 || len(lifecycle.after) != 1 || lifecycle.after[0] != process.GetPort() {
+		t.Errorf("expected one reconnect on port %d, got before=%v after=%v", process.GetPort(), lifecycle.before, lifecycle.after)
+	}
+	if process.IsDraining() {
+		t.Error("expected process back in rotation after reconnecting")
+	}
+
+	// Back up with the connection restored by the client itself: nothing to migrate
+	lifecycle.connected = true
+	down[process] = true
+	recycler.checkRemotes(down)
+	if down[process] || len(lifecycle.before) != 1 {
+		t.Errorf("expected reconnected process cleared without a restart, down=%v before=%v", down[process], lifecycle.before)
+	}
+
+	// An unreachable endpoint is only marked down
+	server.Close()
+	recycler.checkRemotes(down)
+	if !down[process] || len(lifecycle.before) != 1 {
+		t.Errorf("expected process marked down without a reconnect attempt, down=%v before=%v", down[process], lifecycle.before)
+	}
+}
@@ -0,0 +1,54 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher sends each event as a JSON message to one topic. Messages are keyed by
+// session ID, so a session's events stay in order, and carry the event type as a header.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher publishes to topic on brokers
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+// Publish sends one event
+func (p *KafkaPublisher) Publish(ctx context.Context, event events.Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	message := kafka.Message{
+		Key:     []byte(event.SessionID),
+		Value:   value,
+		Time:    event.Time,
+		Headers: []kafka.Header{{Key: "type", Value: []byte(event.Type)}},
+	}
+	if err := p.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("failed to write to Kafka: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the connections
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,56 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher sends each event as a JSON message on "<prefix>.<event type>", so
+// consumers can subscribe to one type (prefix.page_opened) or all (prefix.>).
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSPublisher connects to the NATS server at url. The client reconnects on its own
+// if the server goes away; events published meanwhile are buffered by the client.
+func NewNATSPublisher(url string, prefix string) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url,
+		nats.Name("browser-query-ai"),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &NATSPublisher{conn: conn, prefix: prefix}, nil
+}
+
+// Subject returns the subject an event type is published on
+func (p *NATSPublisher) Subject(eventType string) string {
+	return p.prefix + "." + eventType
+}
+
+// Publish sends one event. NATS publishing is fire-and-forget, so ctx is not used.
+func (p *NATSPublisher) Publish(ctx context.Context, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if err := p.conn.Publish(p.Subject(event.Type), data); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// Close flushes buffered messages and closes the connection
+func (p *NATSPublisher) Close() error {
+	defer p.conn.Close()
+	if err := p.conn.FlushTimeout(publishTimeout); err != nil {
+		return fmt.Errorf("failed to flush NATS connection: %w", err)
+	}
+	return nil
+}
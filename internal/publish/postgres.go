@@ -0,0 +1,50 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// SQLExecutor is the part of storage.PostgresStore the transcript writer needs
+type SQLExecutor interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) error
+}
+
+// TranscriptPublisher stores events in the session_events table, so a session's
+// history can be read back after the in-memory replay buffer is gone
+type TranscriptPublisher struct {
+	db SQLExecutor
+}
+
+// NewTranscriptPublisher writes to the session_events table created by the store's migrations
+func NewTranscriptPublisher(db SQLExecutor) *TranscriptPublisher {
+	return &TranscriptPublisher{db: db}
+}
+
+// Publish inserts one event
+func (p *TranscriptPublisher) Publish(ctx context.Context, event events.Event) error {
+	var data []byte
+	if event.Data != nil {
+		encoded, err := json.Marshal(event.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode event data: %w", err)
+		}
+		data = encoded
+	}
+
+	err := p.db.Exec(ctx, `INSERT INTO session_events (event_id, session_id, page_id, type, data, time)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		int64(event.ID), event.SessionID, event.PageID, event.Type, data, event.Time)
+	if err != nil {
+		return fmt.Errorf("failed to insert event into transcript: %w", err)
+	}
+	return nil
+}
+
+// Close does nothing; main closes the store after the relay
+func (p *TranscriptPublisher) Close() error {
+	return nil
+}
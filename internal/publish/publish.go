@@ -0,0 +1,134 @@
+// Package publish exports session events from the event bus to message brokers,
+// so downstream pipelines can consume browser activity without polling the API.
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+)
+
+// publishTimeout bounds one publish to one broker
+const publishTimeout = 5 * time.Second
+
+// relayBuffer is how many events may wait for the brokers before the bus drops them
+const relayBuffer = 4096
+
+// Publisher sends events to a message broker. Publish is called from a single goroutine,
+// with event data already redacted.
+type Publisher interface {
+	Publish(ctx context.Context, event events.Event) error
+	Close() error
+}
+
+// Relay forwards bus events to publishers on a background goroutine
+type Relay struct {
+	sub        *events.Subscription
+	publishers []Publisher
+	types      map[string]bool // Event types to forward; nil forwards all
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewRelay starts forwarding events from bus. Only events whose type is in types are
+// forwarded; an empty list forwards every type.
+func NewRelay(bus *events.Bus, types []string, publishers ...Publisher) *Relay {
+	relay := &Relay{
+		sub:        bus.SubscribeAll(relayBuffer),
+		publishers: publishers,
+		done:       make(chan struct{}),
+	}
+	if len(types) > 0 {
+		relay.types = make(map[string]bool, len(types))
+		for _, eventType := range types {
+			relay.types[eventType] = true
+		}
+	}
+
+	go relay.run()
+	return relay
+}
+
+// run publishes every event until the subscription is closed
+func (r *Relay) run() {
+	defer close(r.done)
+
+	for event := range r.sub.C {
+		if r.types != nil && !r.types[event.Type] {
+			continue
+		}
+		event = redactEvent(event)
+		for _, publisher := range r.publishers {
+			ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+			if err := publisher.Publish(ctx, event); err != nil {
+				slog.Warn("failed to publish event",
+					"publisher", fmt.Sprintf("%T", publisher),
+					"event_id", event.ID,
+					"type", event.Type,
+					"error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// redactEvent masks secrets in every string of the event's data, such as tokens in page
+// URLs and typed values, with the default redactor and the literals the vault registered
+// with it. Data that doesn't encode is dropped, since publishers couldn't encode it either.
+func redactEvent(event events.Event) events.Event {
+	if event.Data == nil {
+		return event
+	}
+
+	encoded, err := json.Marshal(event.Data)
+	if err != nil {
+		event.Data = nil
+		return event
+	}
+	var data interface{}
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		event.Data = nil
+		return event
+	}
+	event.Data = redactValue(redact.Default(), data)
+	return event
+}
+
+// redactValue masks secrets in the strings of a decoded JSON value
+func redactValue(redactor *redact.Redactor, value interface{}) interface{} {
+	switch value := value.(type) {
+	case string:
+		return redactor.String(value)
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(redactor, item)
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = redactValue(redactor, item)
+		}
+	}
+	return value
+}
+
+// Close stops forwarding, publishes the events already received and closes the publishers
+func (r *Relay) Close() error {
+	var closeErr error
+	r.closeOnce.Do(func() {
+		r.sub.Close()
+		<-r.done
+
+		for _, publisher := range r.publishers {
+			if err := publisher.Close(); err != nil && closeErr == nil {
+				closeErr = err
+			}
+		}
+	})
+	return closeErr
+}
@@ -0,0 +1,109 @@
+package publish
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+)
+
+// recordingPublisher keeps what it was asked to publish
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []events.Event
+	closed bool
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// TestRelayFiltersAndFlushes tests that only the configured types are forwarded and
+// that Close publishes everything received before it
+func TestRelayFiltersAndFlushes(t *testing.T) {
+	bus := events.NewBus(10)
+	publisher := &recordingPublisher{}
+	relay := NewRelay(bus, []string{events.TypePageOpened, events.TypeSessionDestroyed}, publisher)
+
+	bus.Publish(events.Event{SessionID: "sess_a", Type: events.TypePageOpened})
+	bus.Publish(events.Event{SessionID: "sess_a", Type: events.TypeCaptchaBlocked})
+	bus.Publish(events.Event{SessionID: "sess_b", Type: events.TypeSessionDestroyed})
+
+	if err := relay.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if len(publisher.events) != 2 {
+		t.Fatalf("expected 2 published events, got %+v", publisher.events)
+	}
+	if publisher.events[0].Type != events.TypePageOpened || publisher.events[1].SessionID != "sess_b" {
+		t.Errorf("unexpected events: %+v", publisher.events)
+	}
+	if !publisher.closed {
+		t.Error("expected publisher to be closed")
+	}
+}
+
+// recordingExecutor keeps the arguments of every statement it was asked to run
+type recordingExecutor struct {
+	mu   sync.Mutex
+	rows [][]interface{}
+}
+
+func (e *recordingExecutor) Exec(ctx context.Context, sql string, args ...interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rows = append(e.rows, args)
+	return nil
+}
+
+// TestRelayRedacts tests that registered secrets and secret-shaped query parameters
+// never reach the stored transcript
+func TestRelayRedacts(t *testing.T) {
+	const secret = "hunter2-relay-secret"
+	redact.Default().AddSecret(secret)
+
+	bus := events.NewBus(10)
+	db := &recordingExecutor{}
+	relay := NewRelay(bus, nil, NewTranscriptPublisher(db))
+
+	bus.Publish(events.Event{SessionID: "sess_a", Type: events.TypePageOpened, Data: map[string]interface{}{
+		"url":         "https://example.com/callback?token=abc123xyz",
+		"fingerprint": map[string]interface{}{"attributes": map[string]string{"value": secret}},
+		"values":      []string{"typed " + secret},
+	}})
+	if err := relay.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if len(db.rows) != 1 {
+		t.Fatalf("expected one stored row, got %d", len(db.rows))
+	}
+	data := string(db.rows[0][4].([]byte))
+	if strings.Contains(data, secret) || strings.Contains(data, "abc123xyz") {
+		t.Errorf("expected secrets masked, got %s", data)
+	}
+	if strings.Count(data, redact.Mask) != 3 {
+		t.Errorf("expected three masked values, got %s", data)
+	}
+}
+
+func TestNATSSubject(t *testing.T) {
+	publisher := &NATSPublisher{prefix: "browser-query-ai.events"}
+	if got := publisher.Subject(events.TypePageOpened); got != "browser-query-ai.events.page_opened" {
+		t.Errorf("unexpected subject %q", got)
+	}
+}
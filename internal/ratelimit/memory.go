@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memorySweepSize is how many buckets a memory backend holds before it drops the full ones
+const memorySweepSize = 10000
+
+// Memory keeps buckets and in-flight counts in this process
+type Memory struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	inFlight map[string]int
+	now      func() time.Time
+}
+
+// bucket is the state of one token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// NewMemory creates an in-process backend
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket), inFlight: make(map[string]int), now: time.Now}
+}
+
+// refill adds the tokens earned since the last update
+func (b *bucket) refill(now time.Time, limit Limit) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.size()), b.tokens+elapsed*limit.Rate)
+	}
+	b.updated = now
+	b.limit = limit
+}
+
+// Take takes a token from key's bucket
+func (m *Memory) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		if len(m.buckets) >= memorySweepSize {
+			m.sweep(now)
+		}
+		b = &bucket{tokens: float64(limit.size()), updated: now}
+		m.buckets[key] = b
+	}
+	b.refill(now, limit)
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return decide(allowed, b.tokens, limit), nil
+}
+
+// sweep drops the buckets that have filled up again, which behave like new ones
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		b.refill(now, b.limit)
+		if b.tokens >= float64(b.limit.size()) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// Acquire counts a request running under key, unless max already are
+func (m *Memory) Acquire(ctx context.Context, key string, max int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.inFlight[key] >= max {
+		return false, nil
+	}
+	m.inFlight[key]++
+	return true, nil
+}
+
+// Release ends a request counted by Acquire
+func (m *Memory) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.inFlight[key] <= 1 {
+		delete(m.inFlight, key)
+		return nil
+	}
+	m.inFlight[key]--
+	return nil
+}
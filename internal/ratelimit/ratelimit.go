@@ -0,0 +1,185 @@
+// Package ratelimit limits how fast and how much at once the API is used, for the whole
+// service and per API key. Buckets live in memory, or in Redis when several replicas
+// share the limits.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+)
+
+var (
+	// ErrRateLimited is returned when a request rate is used up
+	ErrRateLimited = errors.New("rate limit exceeded")
+
+	// ErrTooManyInFlight is returned when too many requests are already running
+	ErrTooManyInFlight = errors.New("too many requests in flight")
+)
+
+// globalKey is the bucket and in-flight count every request shares; a caller's are
+// under "caller:" and its key
+const globalKey = "global"
+
+// Limit is a token bucket: Rate requests a second on average, in bursts of up to Burst
+type Limit struct {
+	Rate  float64 // 0 disables the limit
+	Burst int     // 0: the rate, rounded up
+}
+
+// Enabled reports whether the limit applies
+func (l Limit) Enabled() bool {
+	return l.Rate > 0
+}
+
+// size is the number of tokens the bucket holds when full
+func (l Limit) size() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Ceil(l.Rate))
+}
+
+// Decision is the state of a bucket after taking a token from it
+type Decision struct {
+	Allowed    bool
+	Limit      int           // Tokens the bucket holds when full
+	Remaining  int           // Tokens left
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next token, when not allowed
+}
+
+// decide builds the decision for a bucket left with tokens
+func decide(allowed bool, tokens float64, limit Limit) Decision {
+	size := limit.size()
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     size,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(size) - tokens) / limit.Rate * float64(time.Second)),
+	}
+	if !allowed {
+		decision.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	return decision
+}
+
+// Backend keeps the buckets and in-flight counts
+type Backend interface {
+	// Take takes a token from key's bucket
+	Take(ctx context.Context, key string, limit Limit) (Decision, error)
+	// Acquire counts a request running under key, unless max already are
+	Acquire(ctx context.Context, key string, max int) (bool, error)
+	// Release ends a request counted by Acquire
+	Release(ctx context.Context, key string) error
+}
+
+// Policy is the set of limits applied to every request
+type Policy struct {
+	Global         Limit // Requests a second across all callers
+	PerKey         Limit // Requests a second per API key (or client address without one)
+	GlobalInFlight int   // Requests running at once across all callers (0: no limit)
+	KeyInFlight    int   // Requests running at once per key (0: no limit)
+}
+
+// Enabled reports whether any limit applies
+func (p Policy) Enabled() bool {
+	return p.Global.Enabled() || p.PerKey.Enabled() || p.GlobalInFlight > 0 || p.KeyInFlight > 0
+}
+
+// Limiter applies a policy on a backend. Backend errors let requests through, so a Redis
+// outage slows nothing down; they are logged.
+type Limiter struct {
+	policy  Policy
+	backend Backend
+}
+
+// New creates a limiter for policy keeping its state in backend
+func New(policy Policy, backend Backend) *Limiter {
+	return &Limiter{policy: policy, backend: backend}
+}
+
+// Policy returns the limits applied
+func (l *Limiter) Policy() Policy {
+	return l.policy
+}
+
+// WithPolicy returns a limiter applying policy on the same backend, so buckets and
+// in-flight counts carry over
+func (l *Limiter) WithPolicy(policy Policy) *Limiter {
+	return &Limiter{policy: policy, backend: l.backend}
+}
+
+// Admission is the outcome of admitting a request
+type Admission struct {
+	Decision       // The bucket closest to running out; Limit is 0 when no rate applies
+	Err      error // ErrRateLimited or ErrTooManyInFlight when the request is refused
+
+	release []string
+	backend Backend
+}
+
+// Done ends an admitted request's in-flight count; call it once the request finishes
+func (a *Admission) Done() {
+	for _, key := range a.release {
+		// The request's own context is usually done by now
+		if err := a.backend.Release(context.Background(), key); err != nil {
+			slog.Warn("failed to release in-flight count", "key", key, "error", err)
+		}
+	}
+	a.release = nil
+}
+
+// Admit takes a token from the global bucket and key's, then, for requests that hold a
+// slot while they run, counts them in flight. Long-lived streams pass holds false: they
+// count against the rates but would keep a slot for as long as they stay open.
+func (l *Limiter) Admit(ctx context.Context, key string, holds bool) *Admission {
+	admission := &Admission{backend: l.backend}
+
+	for _, bucket := range []struct {
+		key   string
+		limit Limit
+	}{{globalKey, l.policy.Global}, {"caller:" + key, l.policy.PerKey}} {
+		if !bucket.limit.Enabled() {
+			continue
+		}
+		decision, err := l.backend.Take(ctx, bucket.key, bucket.limit)
+		if err != nil {
+			slog.Warn("rate limit check failed, letting the request through", "key", bucket.key, "error", err)
+			continue
+		}
+		if admission.Limit == 0 || decision.Remaining < admission.Remaining || !decision.Allowed {
+			admission.Decision = decision
+		}
+		if !decision.Allowed {
+			admission.Err = ErrRateLimited
+			return admission
+		}
+	}
+
+	if !holds {
+		return admission
+	}
+	for _, slot := range []struct {
+		key string
+		max int
+	}{{globalKey, l.policy.GlobalInFlight}, {"caller:" + key, l.policy.KeyInFlight}} {
+		if slot.max <= 0 {
+			continue
+		}
+		ok, err := l.backend.Acquire(ctx, slot.key, slot.max)
+		if err != nil {
+			slog.Warn("in-flight check failed, letting the request through", "key", slot.key, "error", err)
+			continue
+		}
+		if !ok {
+			admission.Done()
+			admission.Err = ErrTooManyInFlight
+			return admission
+		}
+		admission.release = append(admission.release, slot.key)
+	}
+	return admission
+}
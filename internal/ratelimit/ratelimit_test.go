@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestMemoryBucket tests that a bucket allows its burst, then refills at its rate
+func TestMemoryBucket(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	memory := NewMemory()
+	memory.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		decision, _ := memory.Take(ctx, "k", limit)
+		if !decision.Allowed || decision.Remaining != 2-i || decision.Limit != 3 {
+			t.Fatalf("take %d: unexpected decision %+v", i, decision)
+		}
+	}
+	denied, _ := memory.Take(ctx, "k", limit)
+	if denied.Allowed || denied.RetryAfter != 500*time.Millisecond || denied.Reset != 1500*time.Millisecond {
+		t.Errorf("expected the empty bucket to refuse, got %+v", denied)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if decision, _ := memory.Take(ctx, "k", limit); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("expected a token after half a second, got %+v", decision)
+	}
+	if decision, _ := memory.Take(ctx, "other", limit); !decision.Allowed || decision.Remaining != 2 {
+		t.Errorf("expected keys to have their own buckets, got %+v", decision)
+	}
+
+	// Full buckets are dropped when the map is swept
+	now = now.Add(time.Minute)
+	memory.sweep(now)
+	if len(memory.buckets) != 0 {
+		t.Errorf("expected full buckets to be swept, %d left", len(memory.buckets))
+	}
+}
+
+// TestAdmit tests the global and per-key rates and in-flight counts together
+func TestAdmit(t *testing.T) {
+	memory := NewMemory()
+	limiter := New(Policy{PerKey: Limit{Rate: 1}, GlobalInFlight: 2, KeyInFlight: 1}, memory)
+	ctx := context.Background()
+
+	first := limiter.Admit(ctx, "a", true)
+	if first.Err != nil || first.Limit != 1 || first.Remaining != 0 {
+		t.Fatalf("unexpected first admission: %+v", first)
+	}
+	if again := limiter.Admit(ctx, "a", true); !errors.Is(again.Err, ErrRateLimited) || again.RetryAfter <= 0 {
+		t.Errorf("expected the key's rate to be used up, got %+v", again)
+	}
+
+	second := limiter.Admit(ctx, "b", true)
+	if second.Err != nil {
+		t.Fatalf("unexpected refusal: %v", second.Err)
+	}
+	if third := limiter.Admit(ctx, "c", true); !errors.Is(third.Err, ErrTooManyInFlight) {
+		t.Errorf("expected the global in-flight limit, got %+v", third)
+	}
+	if memory.inFlight[globalKey] != 2 || memory.inFlight["caller:c"] != 0 {
+		t.Errorf("expected a refused request to hold no slot, got %v", memory.inFlight)
+	}
+
+	// Streams count against the rate only
+	if stream := limiter.Admit(ctx, "d", false); stream.Err != nil {
+		t.Errorf("expected a stream to skip in-flight limits, got %v", stream.Err)
+	}
+
+	first.Done()
+	second.Done()
+	if len(memory.inFlight) != 0 {
+		t.Errorf("expected every slot released, got %v", memory.inFlight)
+	}
+}
+
+// failingBackend fails every call
+type failingBackend struct{}
+
+func (failingBackend) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	return Decision{}, errors.New("connection refused")
+}
+
+func (failingBackend) Acquire(ctx context.Context, key string, max int) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func (failingBackend) Release(ctx context.Context, key string) error {
+	return errors.New("connection refused")
+}
+
+// TestAdmitFailsOpen tests that backend errors let requests through
+func TestAdmitFailsOpen(t *testing.T) {
+	limiter := New(Policy{Global: Limit{Rate: 1}, GlobalInFlight: 1}, failingBackend{})
+	admission := limiter.Admit(context.Background(), "a", true)
+	if admission.Err != nil || admission.Limit != 0 {
+		t.Errorf("expected the request let through without a bucket, got %+v", admission)
+	}
+	admission.Done()
+}
+
+// scriptReplies answers scripts with canned replies
+type scriptReplies struct {
+	reply interface{}
+	keys  []string
+}
+
+func (s *scriptReplies) RunScript(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	s.keys = keys
+	return s.reply, nil
+}
+
+// TestRedisReplies tests reading the scripts' replies
+func TestRedisReplies(t *testing.T) {
+	runner := &scriptReplies{reply: []interface{}{int64(1), "4.5"}}
+	backend := NewRedis(runner, "ratelimit:")
+
+	decision, err := backend.Take(context.Background(), "global", Limit{Rate: 10})
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	if !decision.Allowed || decision.Limit != 10 || decision.Remaining != 4 || decision.Reset != 550*time.Millisecond {
+		t.Errorf("unexpected decision: %+v", decision)
+	}
+	if runner.keys[0] != "ratelimit:bucket:global" {
+		t.Errorf("unexpected key: %v", runner.keys)
+	}
+
+	runner.reply = int64(0)
+	if ok, err := backend.Acquire(context.Background(), "key:a", 3); ok || err != nil {
+		t.Errorf("expected a refused slot, got %v %v", ok, err)
+	}
+
+	runner.reply = "garbage"
+	if _, err := backend.Take(context.Background(), "global", Limit{Rate: 10}); err == nil {
+		t.Error("expected an error for an unexpected reply")
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// inFlightTTL is how long a Redis in-flight count outlives its last request, so counts a
+// crashed replica never released go away
+const inFlightTTL = 15 * time.Minute
+
+// ScriptRunner runs Lua scripts on Redis (implemented by storage.RedisClient)
+type ScriptRunner interface {
+	RunScript(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// takeScript refills and takes from a bucket stored as a hash. Redis's clock is used so
+// replicas with drifting clocks share one bucket fairly. Tokens are returned as a string
+// because Lua numbers come back truncated to integers.
+const takeScript = `
+local rate = tonumber(ARGV[1])
+local size = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or size
+local ts = tonumber(state[2]) or now
+if now > ts then
+  tokens = math.min(size, tokens + (now - ts) / 1000 * rate)
+end
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(size / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// acquireScript counts a request in flight unless the count is at the maximum
+const acquireScript = `
+local count = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+if count > tonumber(ARGV[1]) then
+  redis.call('DECR', KEYS[1])
+  return 0
+end
+return 1
+`
+
+// releaseScript ends a request's count, dropping the key once none are left
+const releaseScript = `
+local count = redis.call('DECR', KEYS[1])
+if count <= 0 then
+  redis.call('DEL', KEYS[1])
+end
+return count
+`
+
+// Redis keeps buckets and in-flight counts in Redis, shared by every replica using the
+// same prefix
+type Redis struct {
+	redis  ScriptRunner
+	prefix string
+}
+
+// NewRedis creates a backend storing its keys under prefix
+func NewRedis(redis ScriptRunner, prefix string) *Redis {
+	return &Redis{redis: redis, prefix: prefix}
+}
+
+// Take takes a token from key's bucket
+func (r *Redis) Take(ctx context.Context, key string, limit Limit) (Decision, error) {
+	reply, err := r.redis.RunScript(ctx, takeScript, []string{r.prefix + "bucket:" + key}, limit.Rate, limit.size())
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to take a token: %w", err)
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return Decision{}, fmt.Errorf("unexpected reply to taking a token: %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	text, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Decision{}, fmt.Errorf("unexpected token count %q: %w", text, err)
+	}
+	return decide(allowed == 1, math.Max(tokens, 0), limit), nil
+}
+
+// Acquire counts a request running under key, unless max already are
+func (r *Redis) Acquire(ctx context.Context, key string, max int) (bool, error) {
+	reply, err := r.redis.RunScript(ctx, acquireScript, []string{r.prefix + "inflight:" + key}, max, inFlightTTL.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("failed to count the request in flight: %w", err)
+	}
+	acquired, _ := reply.(int64)
+	return acquired == 1, nil
+}
+
+// Release ends a request counted by Acquire
+func (r *Redis) Release(ctx context.Context, key string) error {
+	if _, err := r.redis.RunScript(ctx, releaseScript, []string{r.prefix + "inflight:" + key}); err != nil {
+		return fmt.Errorf("failed to release the in-flight count: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every redacted value
+const Mask = "[REDACTED]"
+
+// minSecretLength avoids masking tiny values (e.g. "1") that would mangle unrelated text
+const minSecretLength = 4
+
+// defaultPatterns catch common secret shapes in URLs, headers, scripts and JSON.
+// A named group "secret" limits masking to that part of the match.
+var defaultPatterns = []string{
+	`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`,
+	`(?i)(?:api[_-]?key|access[_-]?token|refresh[_-]?token|token|secret|password|passwd|pwd|auth|sig|signature)=(?P<secret>[^&\s"']+)`,
+	`(?i)"(?:password|passwd|secret|token|api[_-]?key|access[_-]?token)"\s*:\s*"(?P<secret>[^"]*)"`,
+	`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+}
+
+// Redactor masks secret patterns and registered literal secret values in text
+type Redactor struct {
+	patterns []*regexp.Regexp
+	secrets  map[string]struct{}
+	replacer *strings.Replacer // Rebuilt lazily when secrets change
+	mu       sync.RWMutex
+}
+
+var defaultRedactor = mustNew()
+
+// New creates a redactor with the built-in patterns plus any extra patterns
+func New(extraPatterns ...string) (*Redactor, error) {
+	r := &Redactor{
+		secrets: make(map[string]struct{}),
+	}
+	if err := r.AddPatterns(append(append([]string{}, defaultPatterns...), extraPatterns...)...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// mustNew builds the default redactor (built-in patterns always compile)
+func mustNew() *Redactor {
+	r, err := New()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Default returns the process-wide redactor used by logging and API errors
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// SetDefault replaces the process-wide redactor
+func SetDefault(r *Redactor) {
+	defaultRedactor = r
+}
+
+// String redacts text with the default redactor
+func String(s string) string {
+	return defaultRedactor.String(s)
+}
+
+// AddPatterns compiles and registers additional secret patterns
+func (r *Redactor) AddPatterns(patterns ...string) error {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+
+	r.mu.Lock()
+	r.patterns = append(r.patterns, compiled...)
+	r.mu.Unlock()
+
+	return nil
+}
+
+// AddSecret registers a literal value (e.g. a vault password) to be masked everywhere
+func (r *Redactor) AddSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.secrets[value]; exists {
+		return
+	}
+	r.secrets[value] = struct{}{}
+	r.replacer = nil
+}
+
+// String returns s with all secrets masked
+func (r *Redactor) String(s string) string {
+	if s == "" {
+		return s
+	}
+
+	r.mu.RLock()
+	replacer := r.replacer
+	hasSecrets := len(r.secrets) > 0
+	patterns := r.patterns
+	r.mu.RUnlock()
+
+	// Literal secrets first, longest first so overlapping values mask fully
+	if hasSecrets {
+		if replacer == nil {
+			replacer = r.buildReplacer()
+		}
+		s = replacer.Replace(s)
+	}
+
+	for _, re := range patterns {
+		s = replacePattern(re, s)
+	}
+
+	return s
+}
+
+// buildReplacer rebuilds and caches the literal secret replacer
+func (r *Redactor) buildReplacer() *strings.Replacer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.replacer != nil {
+		return r.replacer
+	}
+
+	values := make([]string, 0, len(r.secrets))
+	for value := range r.secrets {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	pairs := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		pairs = append(pairs, value, Mask)
+	}
+
+	r.replacer = strings.NewReplacer(pairs...)
+	return r.replacer
+}
+
+// replacePattern masks matches of re, limited to the "secret" group when present
+func replacePattern(re *regexp.Regexp, s string) string {
+	group := re.SubexpIndex("secret")
+	if group < 0 {
+		return re.ReplaceAllLiteralString(s, Mask)
+	}
+
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[2*group], match[2*group+1]
+		if start < 0 {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Mask)
+		last = end
+	}
+	b.WriteString(s[last:])
+
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestDefaultPatterns tests the built-in secret shapes
+func TestDefaultPatterns(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	cases := map[string]string{
+		"https://api.example.com/v1?token=abc123&page=2": "https://api.example.com/v1?token=[REDACTED]&page=2",
+		"Authorization: Bearer sk-live-123456":           "Authorization: Bearer [REDACTED]",
+		`{"password": "hunter22", "user": "bob"}`:        `{"password": "[REDACTED]", "user": "bob"}`,
+		"no secrets here":                                "no secrets here",
+	}
+
+	for input, want := range cases {
+		if got := r.String(input); got != want {
+			t.Errorf("String(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	// The query string case must keep the key and the unrelated parameter
+	if got := r.String("x?api_key=s3cr3t&page=2"); got != "x?api_key=[REDACTED]&page=2" {
+		t.Errorf("unexpected redaction: %q", got)
+	}
+}
+
+// TestAddSecret tests literal secret masking
+func TestAddSecret(t *testing.T) {
+	r, _ := New()
+	r.AddSecret("correct-horse-battery")
+	r.AddSecret("ab") // Too short to register
+
+	got := r.String("login failed for correct-horse-battery at ab")
+	if strings.Contains(got, "correct-horse-battery") {
+		t.Errorf("secret not masked: %q", got)
+	}
+	if !strings.Contains(got, " ab") {
+		t.Errorf("short value should not be masked: %q", got)
+	}
+}
+
+// TestHandler tests that log records are redacted
+func TestHandler(t *testing.T) {
+	r, _ := New(`sess-secret-\d+`)
+	r.AddSecret("vault-password")
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, nil), r))
+
+	logger.Info("navigating to https://x.test/?token=t0ken",
+		"script", "login('vault-password')",
+		"error", errors.New("bad id sess-secret-42"),
+		slog.Group("req", "url", "/cb?code=1&secret=zzz"))
+
+	out := buf.String()
+	for _, leaked := range []string{"t0ken", "vault-password", "sess-secret-42", "zzz"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("log output leaked %q: %s", leaked, out)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package research picks the passages of fetched pages that bear on a question and turns
+// a model's answer about them into claims with their sources.
+package research
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minPassageWords drops menu items, bylines and other fragments
+	minPassageWords = 8
+
+	// targetPassageWords is where consecutive short paragraphs stop being merged
+	targetPassageWords = 120
+
+	// maxPassageWords splits walls of text without paragraph breaks
+	maxPassageWords = 250
+
+	// BM25 parameters, the usual defaults
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Passage is a piece of one source's text
+type Passage struct {
+	Source int     `json:"-"` // Index of the source it came from
+	Text   string  `json:"text"`
+	Score  float64 `json:"score"` // Relevance to the question; only comparable within one ranking
+}
+
+// Source is a page the answer may draw on
+type Source struct {
+	URL      string
+	Title    string
+	Passages []Passage
+}
+
+// Claim is one statement of the answer and the sources backing it
+type Claim struct {
+	Text    string   `json:"text"`
+	Sources []string `json:"sources"` // URLs
+}
+
+// Answer is what the model concluded
+type Answer struct {
+	Text   string
+	Claims []Claim
+}
+
+// stopwords carry no signal for ranking
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "do": true, "does": true, "for": true, "from": true, "how": true, "i": true, "in": true,
+	"is": true, "it": true, "its": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "what": true, "when": true, "where": true, "which": true,
+	"who": true, "why": true, "will": true, "with": true,
+}
+
+// Split breaks page text into passages of a few sentences. Short consecutive lines are
+// merged, long ones split, and fragments too short to say anything dropped.
+func Split(text string) []string {
+	passages := make([]string, 0)
+	var current []string
+	flush := func() {
+		if len(current) >= minPassageWords {
+			passages = append(passages, strings.Join(current, " "))
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			continue
+		}
+		for len(words) > 0 {
+			room := maxPassageWords - len(current)
+			take := min(room, len(words))
+			current = append(current, words[:take]...)
+			words = words[take:]
+			if len(current) >= maxPassageWords {
+				flush()
+			}
+		}
+		if len(current) >= targetPassageWords {
+			flush()
+		}
+	}
+	flush()
+	return passages
+}
+
+// terms lowercases text into words, without stopwords
+func terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if !stopwords[word] {
+			kept = append(kept, word)
+		}
+	}
+	return kept
+}
+
+// Rank scores every passage of the sources against the question with BM25 and returns
+// the best limit of them, best first, taking at most perSource from any one source.
+// Passages that share no term with the question are left out.
+func Rank(question string, sources []Source, limit int, perSource int) []Passage {
+	queryTerms := uniqueTerms(terms(question))
+	if len(queryTerms) == 0 {
+		return []Passage{}
+	}
+
+	type document struct {
+		passage Passage
+		counts  map[string]int
+		length  int
+	}
+	documents := make([]document, 0)
+	frequency := make(map[string]int) // Term → passages containing it
+	totalLength := 0
+	for i, source := range sources {
+		for _, passage := range source.Passages {
+			words := terms(passage.Text)
+			counts := make(map[string]int, len(words))
+			for _, word := range words {
+				counts[word]++
+			}
+			for word := range counts {
+				frequency[word]++
+			}
+			passage.Source = i
+			documents = append(documents, document{passage: passage, counts: counts, length: len(words)})
+			totalLength += len(words)
+		}
+	}
+	if len(documents) == 0 {
+		return []Passage{}
+	}
+
+	averageLength := float64(totalLength) / float64(len(documents))
+	ranked := make([]Passage, 0, len(documents))
+	for _, doc := range documents {
+		score := 0.0
+		for _, term := range queryTerms {
+			count := float64(doc.counts[term])
+			if count == 0 {
+				continue
+			}
+			n := float64(frequency[term])
+			idf := math.Log(1 + (float64(len(documents))-n+0.5)/(n+0.5))
+			score += idf * count * (bm25K1 + 1) / (count + bm25K1*(1-bm25B+bm25B*float64(doc.length)/averageLength))
+		}
+		if score > 0 {
+			doc.passage.Score = math.Round(score*1000) / 1000
+			ranked = append(ranked, doc.passage)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	best := make([]Passage, 0, limit)
+	taken := make(map[int]int)
+	for _, passage := range ranked {
+		if taken[passage.Source] >= perSource {
+			continue
+		}
+		taken[passage.Source]++
+		best = append(best, passage)
+		if len(best) == limit {
+			break
+		}
+	}
+	return best
+}
+
+func uniqueTerms(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	unique := make([]string, 0, len(words))
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			unique = append(unique, word)
+		}
+	}
+	return unique
+}
+
+// SystemPrompt tells the model how to answer from the sources
+const SystemPrompt = `You answer research questions using only the numbered sources you are given, not what you knew before.
+Reply with only a JSON object of this shape:
+{"answer": "<a concise answer to the question>", "claims": [{"text": "<one factual statement from your answer>", "sources": [<numbers of the sources that state it>]}]}
+Split the answer into claims, each backed by at least one source. If the sources don't answer the question, say so in the answer and leave claims empty.`
+
+// Prompt lays out the question and the passages, numbered by source
+func Prompt(question string, sources []Source, passages []Passage) string {
+	bySource := make(map[int][]Passage)
+	for _, passage := range passages {
+		bySource[passage.Source] = append(bySource[passage.Source], passage)
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Question: %s\n\nSources:\n", question)
+	for i, source := range sources {
+		if len(bySource[i]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&prompt, "\n[%d] %s (%s)\n", i+1, source.Title, source.URL)
+		for _, passage := range bySource[i] {
+			prompt.WriteString(passage.Text)
+			prompt.WriteString("\n")
+		}
+	}
+	return prompt.String()
+}
+
+// reply is the JSON object the model is asked for
+type reply struct {
+	Answer string `json:"answer"`
+	Claims []struct {
+		Text    string `json:"text"`
+		Sources []int  `json:"sources"`
+	} `json:"claims"`
+}
+
+// ParseAnswer reads the model's reply, resolving source numbers to URLs. Unknown numbers
+// are dropped, along with claims left without a source. A reply that isn't the JSON asked
+// for becomes the answer as it is, without claims.
+func ParseAnswer(text string, sources []Source) Answer {
+	answer := Answer{Text: strings.TrimSpace(text), Claims: []Claim{}}
+
+	// Models like to wrap JSON in a code fence or a sentence, so take the outermost object
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return answer
+	}
+	var decoded reply
+	if err := json.Unmarshal([]byte(text[start:end+1]), &decoded); err != nil {
+		return answer
+	}
+	answer.Text = decoded.Answer
+
+	for _, claim := range decoded.Claims {
+		urls := make([]string, 0, len(claim.Sources))
+		seen := make(map[int]bool)
+		for _, number := range claim.Sources {
+			if number < 1 || number > len(sources) || seen[number] {
+				continue
+			}
+			seen[number] = true
+			urls = append(urls, sources[number-1].URL)
+		}
+		if strings.TrimSpace(claim.Text) == "" || len(urls) == 0 {
+			continue
+		}
+		answer.Claims = append(answer.Claims, Claim{Text: claim.Text, Sources: urls})
+	}
+	return answer
+}
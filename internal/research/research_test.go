@@ -0,0 +1,89 @@
+package research
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	long := strings.Repeat("word ", 600)
+	passages := Split("Home\nAbout\n\n" +
+		"Go was designed at Google in 2007 to improve programming productivity.\n" +
+		"Menu\n" + long)
+
+	if len(passages) != 3 {
+		t.Fatalf("expected 3 passages, got %d: %q", len(passages), passages)
+	}
+	if !strings.HasPrefix(passages[0], "Home About Go was designed") {
+		t.Errorf("expected short lines to be merged into the next, got %q", passages[0])
+	}
+	for _, passage := range passages {
+		if words := len(strings.Fields(passage)); words > maxPassageWords {
+			t.Errorf("expected at most %d words, got %d", maxPassageWords, words)
+		}
+	}
+
+	if passages := Split("Subscribe\nLog in"); len(passages) != 0 {
+		t.Errorf("expected fragments to be dropped, got %q", passages)
+	}
+}
+
+func TestRank(t *testing.T) {
+	sources := []Source{
+		{URL: "https://a.example.com", Passages: []Passage{
+			{Text: "The Eiffel Tower is 330 metres tall and stands in Paris."},
+			{Text: "The tower was the tallest structure in the world until 1930."},
+			{Text: "Tickets can be bought online or at the entrance."},
+		}},
+		{URL: "https://b.example.com", Passages: []Passage{
+			{Text: "Paris is the capital of France."},
+			{Text: "How tall is the Eiffel Tower? It measures 330 metres including antennas."},
+		}},
+	}
+
+	ranked := Rank("How tall is the Eiffel Tower?", sources, 3, 1)
+	if len(ranked) != 2 {
+		t.Fatalf("expected one passage per source, got %+v", ranked)
+	}
+	if ranked[0].Source == ranked[1].Source || ranked[0].Score < ranked[1].Score {
+		t.Errorf("expected the best passage of each source, best first, got %+v", ranked)
+	}
+	for _, passage := range ranked {
+		if !strings.Contains(passage.Text, "330 metres") {
+			t.Errorf("expected passages about the height, got %q", passage.Text)
+		}
+	}
+
+	if ranked := Rank("What is the weather in Tokyo?", sources, 3, 3); len(ranked) != 0 {
+		t.Errorf("expected no passages without shared terms, got %+v", ranked)
+	}
+}
+
+func TestParseAnswer(t *testing.T) {
+	sources := []Source{{URL: "https://a.example.com"}, {URL: "https://b.example.com"}}
+
+	answer := ParseAnswer("```json\n"+`{"answer": "330 metres.", "claims": [
+		{"text": "The tower is 330 metres tall.", "sources": [2, 1, 2]},
+		{"text": "It is red.", "sources": [7]},
+		{"text": "", "sources": [1]}
+	]}`+"\n```", sources)
+	if answer.Text != "330 metres." || len(answer.Claims) != 1 {
+		t.Fatalf("unexpected answer: %+v", answer)
+	}
+	if urls := answer.Claims[0].Sources; len(urls) != 2 || urls[0] != "https://b.example.com" || urls[1] != "https://a.example.com" {
+		t.Errorf("expected source numbers resolved once each, got %v", urls)
+	}
+
+	answer = ParseAnswer("  I could not find that.  ", sources)
+	if answer.Text != "I could not find that." || len(answer.Claims) != 0 {
+		t.Errorf("expected a plain reply to become the answer, got %+v", answer)
+	}
+
+	prompt := Prompt("How tall?", []Source{
+		{URL: "https://a.example.com", Title: "A"},
+		{URL: "https://b.example.com", Title: "B"},
+	}, []Passage{{Source: 1, Text: "330 metres."}})
+	if !strings.Contains(prompt, "[2] B (https://b.example.com)\n330 metres.") || strings.Contains(prompt, "[1]") {
+		t.Errorf("expected only sources with passages in the prompt, got %q", prompt)
+	}
+}
@@ -0,0 +1,98 @@
+// Package search runs web searches for agents, through a SERP API or by driving a
+// search engine's page, and returns the results in one shape.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultCount is how many results a query returns when it doesn't say
+	DefaultCount = 10
+
+	// MaxCount bounds the results of one query
+	MaxCount = 50
+)
+
+// Error definitions
+var (
+	ErrInvalidQuery = errors.New("invalid search query")
+	ErrSearchFailed = errors.New("search failed")
+)
+
+// Query is one search
+type Query struct {
+	Text     string
+	Count    int    // Results wanted (0: DefaultCount)
+	Language string // Interface language, e.g. "en"
+	Region   string // Country the results are for, e.g. "us"
+	TenantID string // Backends that open sessions create them for this tenant
+}
+
+// Result is one organic result
+type Result struct {
+	Position int    `json:"position"` // 1-based rank on the results page
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Domain   string `json:"domain"`
+	Snippet  string `json:"snippet,omitempty"`
+}
+
+// Backend runs queries against one search source
+type Backend interface {
+	// Name identifies the backend in responses (e.g. "serpapi:google")
+	Name() string
+
+	// Search returns up to query.Count results, best first
+	Search(ctx context.Context, query Query) ([]Result, error)
+}
+
+// Validate checks a query and fills in its default count
+func (q *Query) Validate() error {
+	q.Text = strings.TrimSpace(q.Text)
+	if q.Text == "" {
+		return fmt.Errorf("%w: query must not be empty", ErrInvalidQuery)
+	}
+	if len(q.Text) > 2048 {
+		return fmt.Errorf("%w: query must be at most 2048 bytes", ErrInvalidQuery)
+	}
+	if q.Count < 0 || q.Count > MaxCount {
+		return fmt.Errorf("%w: count must be between 0 and %d", ErrInvalidQuery, MaxCount)
+	}
+	if q.Count == 0 {
+		q.Count = DefaultCount
+	}
+	return nil
+}
+
+// Clean drops results without an http or https URL and repeats of one, fills in their
+// domains, numbers them from 1 and keeps at most count
+func Clean(results []Result, count int) []Result {
+	seen := make(map[string]bool, len(results))
+	cleaned := make([]Result, 0, min(len(results), count))
+	for _, result := range results {
+		parsed, err := url.Parse(strings.TrimSpace(result.URL))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			continue
+		}
+		result.URL = parsed.String()
+		if seen[result.URL] {
+			continue
+		}
+		seen[result.URL] = true
+
+		result.Title = strings.Join(strings.Fields(result.Title), " ")
+		result.Snippet = strings.Join(strings.Fields(result.Snippet), " ")
+		result.Domain = strings.TrimPrefix(parsed.Hostname(), "www.")
+		result.Position = len(cleaned) + 1
+		cleaned = append(cleaned, result)
+		if len(cleaned) == count {
+			break
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	results := Clean([]Result{
+		{Title: "  Go\n  Programming ", URL: "https://www.go.dev/"},
+		{Title: "Ad", URL: "javascript:void(0)"},
+		{Title: "Again", URL: "https://www.go.dev/"},
+		{Title: "Docs", URL: "https://pkg.go.dev/std", Snippet: "Standard library"},
+		{Title: "Tour", URL: "https://go.dev/tour"},
+	}, 2)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if results[0].Position != 1 || results[0].Title != "Go Programming" || results[0].Domain != "go.dev" {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if results[1].Position != 2 || results[1].URL != "https://pkg.go.dev/std" {
+		t.Errorf("expected the invalid and repeated results to be dropped, got %+v", results[1])
+	}
+}
+
+func TestSerpAPISearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search.json" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("q") != "golang" || query.Get("engine") != "bing" || query.Get("api_key") != "test-key" || query.Get("num") != "3" || query.Get("gl") != "de" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"organic_results":[
+			{"position":1,"title":"The Go Programming Language","link":"https://go.dev/","snippet":"Build simple, secure software."},
+			{"position":2,"title":"Go - Wikipedia","link":"https://en.wikipedia.org/wiki/Go_(programming_language)"}
+		]}`))
+	}))
+	defer server.Close()
+
+	backend := NewSerpAPI("test-key", server.URL, "bing")
+	if backend.Name() != "serpapi:bing" {
+		t.Errorf("unexpected name: %s", backend.Name())
+	}
+
+	results, err := backend.Search(context.Background(), Query{Text: " golang ", Count: 3, Region: "de"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].URL != "https://go.dev/" || results[1].Domain != "en.wikipedia.org" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	if _, err := backend.Search(context.Background(), Query{Text: "  "}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery for a blank query, got %v", err)
+	}
+}
+
+func TestSerpAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("q") {
+		case "nothing":
+			w.Write([]byte(`{"error":"Google hasn't returned any results for this query."}`))
+		case "quota":
+			w.Write([]byte(`{"error":"Your account has run out of searches."}`))
+		default:
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	backend := NewSerpAPI("test-key", server.URL, "")
+
+	results, err := backend.Search(context.Background(), Query{Text: "nothing"})
+	if err != nil || len(results) != 0 {
+		t.Errorf("expected an empty results page to be no results, got %+v, %v", results, err)
+	}
+	if _, err := backend.Search(context.Background(), Query{Text: "quota"}); !errors.Is(err, ErrSearchFailed) {
+		t.Errorf("expected ErrSearchFailed for an API error, got %v", err)
+	}
+	if _, err := backend.Search(context.Background(), Query{Text: "anything"}); !errors.Is(err, ErrSearchFailed) {
+		t.Errorf("expected ErrSearchFailed for a 401, got %v", err)
+	}
+}
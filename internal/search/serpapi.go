@@ -0,0 +1,128 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+)
+
+const (
+	// DefaultSerpAPIURL is the public SerpApi base URL
+	DefaultSerpAPIURL = "https://serpapi.com"
+
+	// DefaultSerpAPIEngine is used when no engine is configured
+	DefaultSerpAPIEngine = "google"
+
+	// maxErrorBody is how much of a failed response is kept for the error message
+	maxErrorBody = 2048
+)
+
+// SerpAPI queries a SerpApi-compatible results API. The base URL is configurable so a
+// self-hosted proxy speaking the same protocol can stand in.
+type SerpAPI struct {
+	apiKey     string
+	baseURL    string
+	engine     string
+	httpClient *http.Client
+}
+
+// serpResponse is the part of the search.json response we read
+type serpResponse struct {
+	Error          string `json:"error"`
+	OrganicResults []struct {
+		Title   string `json:"title"`
+		Link    string `json:"link"`
+		Snippet string `json:"snippet"`
+	} `json:"organic_results"`
+}
+
+// NewSerpAPI creates a client for a SerpApi-compatible API
+func NewSerpAPI(apiKey string, baseURL string, engine string) *SerpAPI {
+	if baseURL == "" {
+		baseURL = DefaultSerpAPIURL
+	}
+	if engine == "" {
+		engine = DefaultSerpAPIEngine
+	}
+
+	// Keep the API key out of logs and error messages
+	redact.Default().AddSecret(apiKey)
+
+	return &SerpAPI{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		engine:     engine,
+		httpClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Name returns the API and engine, e.g. "serpapi:google"
+func (s *SerpAPI) Name() string {
+	return "serpapi:" + s.engine
+}
+
+// Search runs the query as one GET /search.json
+func (s *SerpAPI) Search(ctx context.Context, query Query) ([]Result, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("engine", s.engine)
+	params.Set("q", query.Text)
+	params.Set("num", strconv.Itoa(query.Count))
+	params.Set("api_key", s.apiKey)
+	if query.Language != "" {
+		params.Set("hl", query.Language)
+	}
+	if query.Region != "" {
+		params.Set("gl", query.Region)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/search.json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		// The URL in the error carries the key; the redactor scrubs it from what's logged
+		return nil, fmt.Errorf("%w: failed to reach search API: %w", ErrSearchFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("%w: search API returned HTTP %d: %s", ErrSearchFailed, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	var decoded serpResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode search response: %w", ErrSearchFailed, err)
+	}
+	if decoded.Error != "" && len(decoded.OrganicResults) == 0 {
+		// SerpApi reports an empty results page as an error with a 200
+		if strings.Contains(decoded.Error, "hasn't returned any results") {
+			return []Result{}, nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrSearchFailed, decoded.Error)
+	}
+
+	results := make([]Result, 0, len(decoded.OrganicResults))
+	for _, organic := range decoded.OrganicResults {
+		results = append(results, Result{
+			Title:   organic.Title,
+			URL:     organic.Link,
+			Snippet: organic.Snippet,
+		})
+	}
+	return Clean(results, query.Count), nil
+}
@@ -0,0 +1,425 @@
+// Package seeds expands sitemaps and RSS or Atom feeds into the pages they list, newest
+// first, so crawls and monitors can start from one URL and revisit only what changed.
+package seeds
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxBytes bounds one sitemap or feed, after decompression. The sitemap
+	// protocol allows 50MB.
+	DefaultMaxBytes = 50 << 20
+
+	// DefaultMaxSitemaps bounds the sitemaps one sitemap index may pull in
+	DefaultMaxSitemaps = 50
+
+	// MaxTargets bounds the targets one expansion returns, as the sitemap protocol bounds
+	// one sitemap
+	MaxTargets = 50000
+
+	// fetchTimeout bounds one document fetch
+	fetchTimeout = 30 * time.Second
+)
+
+// ErrInvalidSeed means a document isn't a sitemap, sitemap index, RSS or Atom feed
+var ErrInvalidSeed = errors.New("invalid seed")
+
+// Formats a seed can be in
+const (
+	FormatSitemap      = "sitemap"
+	FormatSitemapIndex = "sitemap_index"
+	FormatRSS          = "rss"
+	FormatAtom         = "atom"
+)
+
+// Target is a page a seed lists
+type Target struct {
+	URL          string     `json:"url"`
+	LastModified *time.Time `json:"last_modified,omitempty"` // lastmod, pubDate or updated, when the seed gives one
+	Priority     float64    `json:"priority,omitempty"`      // Sitemap priority, 0 to 1
+	Title        string     `json:"title,omitempty"`         // Feed entry title
+}
+
+// Expansion is what a seed expanded into
+type Expansion struct {
+	Format    string   `json:"format"`
+	Targets   []Target `json:"targets"`             // Newest first, see Prioritize
+	Sitemaps  int      `json:"sitemaps,omitempty"`  // Sitemaps fetched for an index
+	Truncated bool     `json:"truncated,omitempty"` // More than MaxTargets were listed
+}
+
+// Fetcher downloads and expands seeds
+type Fetcher struct {
+	client      *http.Client
+	maxBytes    int64
+	maxSitemaps int
+}
+
+// NewFetcher creates a fetcher with the default limits
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		client:      &http.Client{Timeout: fetchTimeout},
+		maxBytes:    DefaultMaxBytes,
+		maxSitemaps: DefaultMaxSitemaps,
+	}
+}
+
+// ValidateURL checks that a seed URL can be fetched
+func ValidateURL(seedURL string) error {
+	parsed, err := url.Parse(seedURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an http or https URL", ErrInvalidSeed)
+	}
+	return nil
+}
+
+// Expand fetches a seed and lists its targets. A sitemap index is followed one level
+// down, to at most DefaultMaxSitemaps sitemaps; sitemaps that fail to load are skipped
+// as long as one loads.
+func (f *Fetcher) Expand(ctx context.Context, seedURL string) (*Expansion, error) {
+	if err := ValidateURL(seedURL); err != nil {
+		return nil, err
+	}
+	data, err := f.fetch(ctx, seedURL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := Parse(data, seedURL)
+	if err != nil {
+		return nil, err
+	}
+
+	expansion := &Expansion{Format: doc.Format, Targets: doc.Targets}
+	if doc.Format == FormatSitemapIndex {
+		var firstErr error
+		for _, sitemap := range doc.Sitemaps[:min(len(doc.Sitemaps), f.maxSitemaps)] {
+			data, err := f.fetch(ctx, sitemap)
+			if err == nil {
+				var child *Document
+				if child, err = Parse(data, sitemap); err == nil && child.Format != FormatSitemap {
+					err = fmt.Errorf("%w: %s is a %s, not a sitemap", ErrInvalidSeed, sitemap, child.Format)
+				}
+				if err == nil {
+					expansion.Targets = append(expansion.Targets, child.Targets...)
+					expansion.Sitemaps++
+				}
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if len(expansion.Targets) > MaxTargets {
+				break
+			}
+		}
+		if expansion.Sitemaps == 0 && firstErr != nil {
+			return nil, firstErr
+		}
+	}
+
+	expansion.Targets = Prioritize(expansion.Targets)
+	if len(expansion.Targets) > MaxTargets {
+		expansion.Targets = expansion.Targets[:MaxTargets]
+		expansion.Truncated = true
+	}
+	return expansion, nil
+}
+
+// fetch downloads a document, decompressing gzipped sitemaps
+func (f *Fetcher) fetch(ctx context.Context, docURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+	}
+	req.Header.Set("Accept", "application/xml, text/xml, application/rss+xml, application/atom+xml, */*;q=0.5")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", docURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", docURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", docURL, err)
+	}
+
+	// Servers send .xml.gz as application/gzip or octet-stream rather than encoded
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSeed, docURL, err)
+		}
+		if data, err = io.ReadAll(io.LimitReader(reader, f.maxBytes+1)); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidSeed, docURL, err)
+		}
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrInvalidSeed, docURL, f.maxBytes)
+	}
+	return data, nil
+}
+
+// Document is a parsed seed. A sitemap index lists sitemaps instead of targets.
+type Document struct {
+	Format   string
+	Targets  []Target
+	Sitemaps []string
+}
+
+// XML shapes of the formats, matched on local names so namespace prefixes don't matter
+type (
+	xmlURLSet struct {
+		URLs []struct {
+			Loc      string `xml:"loc"`
+			LastMod  string `xml:"lastmod"`
+			Priority string `xml:"priority"`
+		} `xml:"url"`
+	}
+	xmlSitemapIndex struct {
+		Sitemaps []struct {
+			Loc string `xml:"loc"`
+		} `xml:"sitemap"`
+	}
+	xmlRSS struct {
+		Items []xmlRSSItem `xml:"channel>item"`
+	}
+	xmlRDF struct {
+		Items []xmlRSSItem `xml:"item"` // RSS 1.0 puts items beside the channel
+	}
+	xmlRSSItem struct {
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		GUID    string `xml:"guid"`
+		PubDate string `xml:"pubDate"`
+		Date    string `xml:"date"` // Dublin Core, common in RSS 1.0
+	}
+	xmlAtom struct {
+		Entries []struct {
+			Title string `xml:"title"`
+			Links []struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"link"`
+			Updated   string `xml:"updated"`
+			Published string `xml:"published"`
+		} `xml:"entry"`
+	}
+)
+
+// Parse reads a sitemap, sitemap index, RSS or Atom document. Relative links resolve
+// against base; entries without a usable http or https link are dropped.
+func Parse(data []byte, base string) (*Document, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, _ := url.Parse(base)
+	resolve := func(link string) string {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			return ""
+		}
+		parsed, err := url.Parse(link)
+		if err != nil {
+			return ""
+		}
+		if baseURL != nil {
+			parsed = baseURL.ResolveReference(parsed)
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ""
+		}
+		parsed.Fragment = ""
+		return parsed.String()
+	}
+
+	doc := &Document{}
+	switch root {
+	case "urlset":
+		var set xmlURLSet
+		if err := xml.Unmarshal(data, &set); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+		}
+		doc.Format = FormatSitemap
+		for _, entry := range set.URLs {
+			target := Target{URL: resolve(entry.Loc), LastModified: parseTime(entry.LastMod)}
+			if priority, err := strconv.ParseFloat(strings.TrimSpace(entry.Priority), 64); err == nil && priority >= 0 && priority <= 1 {
+				target.Priority = priority
+			}
+			doc.Targets = append(doc.Targets, target)
+		}
+
+	case "sitemapindex":
+		var index xmlSitemapIndex
+		if err := xml.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+		}
+		doc.Format = FormatSitemapIndex
+		for _, sitemap := range index.Sitemaps {
+			if loc := resolve(sitemap.Loc); loc != "" {
+				doc.Sitemaps = append(doc.Sitemaps, loc)
+			}
+		}
+
+	case "rss", "RDF":
+		var items []xmlRSSItem
+		if root == "rss" {
+			var feed xmlRSS
+			err = xml.Unmarshal(data, &feed)
+			items = feed.Items
+		} else {
+			var feed xmlRDF
+			err = xml.Unmarshal(data, &feed)
+			items = feed.Items
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+		}
+		doc.Format = FormatRSS
+		for _, item := range items {
+			link := item.Link
+			if link == "" {
+				link = item.GUID // A permalink GUID stands in for a missing link
+			}
+			modified := parseTime(item.PubDate)
+			if modified == nil {
+				modified = parseTime(item.Date)
+			}
+			doc.Targets = append(doc.Targets, Target{URL: resolve(link), LastModified: modified, Title: strings.TrimSpace(item.Title)})
+		}
+
+	case "feed":
+		var feed xmlAtom
+		if err := xml.Unmarshal(data, &feed); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSeed, err)
+		}
+		doc.Format = FormatAtom
+		for _, entry := range feed.Entries {
+			var link string
+			for _, candidate := range entry.Links {
+				if candidate.Rel == "" || candidate.Rel == "alternate" {
+					link = candidate.Href
+					break
+				}
+			}
+			modified := parseTime(entry.Updated)
+			if modified == nil {
+				modified = parseTime(entry.Published)
+			}
+			doc.Targets = append(doc.Targets, Target{URL: resolve(link), LastModified: modified, Title: strings.TrimSpace(entry.Title)})
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: <%s> is not a sitemap, sitemap index, RSS or Atom document", ErrInvalidSeed, root)
+	}
+
+	doc.Targets = dedupe(doc.Targets)
+	return doc, nil
+}
+
+// rootElement returns the local name of a document's root element
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("%w: no root element: %v", ErrInvalidSeed, err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// dedupe drops targets without a URL and repeats of a URL, keeping the newest
+func dedupe(targets []Target) []Target {
+	index := make(map[string]int, len(targets))
+	kept := targets[:0]
+	for _, target := range targets {
+		if target.URL == "" {
+			continue
+		}
+		if i, seen := index[target.URL]; seen {
+			if newer(target.LastModified, kept[i].LastModified) {
+				kept[i] = target
+			}
+			continue
+		}
+		index[target.URL] = len(kept)
+		kept = append(kept, target)
+	}
+	return kept
+}
+
+// Prioritize orders targets for crawling: most recently modified first, then undated
+// ones by sitemap priority, keeping the seed's order among equals
+func Prioritize(targets []Target) []Target {
+	sort.SliceStable(targets, func(i, j int) bool {
+		a, b := targets[i], targets[j]
+		if newer(a.LastModified, b.LastModified) || newer(b.LastModified, a.LastModified) {
+			return newer(a.LastModified, b.LastModified)
+		}
+		return a.Priority > b.Priority
+	})
+	return targets
+}
+
+// newer reports whether a is later than b, where any time is later than none
+func newer(a, b *time.Time) bool {
+	switch {
+	case a == nil:
+		return false
+	case b == nil:
+		return true
+	default:
+		return a.After(*b)
+	}
+}
+
+// timeLayouts are the date formats sitemaps (W3C datetime), RSS (RFC 822 and its
+// variants) and Atom (RFC 3339) use
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// parseTime reads a date in any of timeLayouts, or returns nil
+func parseTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			parsed = parsed.UTC()
+			return &parsed
+		}
+	}
+	return nil
+}
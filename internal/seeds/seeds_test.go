@@ -0,0 +1,143 @@
+package seeds
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const sitemapXML = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://shop.example.com/</loc><priority>1.0</priority></url>
+  <url><loc>https://shop.example.com/old</loc><lastmod>2024-01-05</lastmod></url>
+  <url><loc>/new</loc><lastmod>2026-03-01T10:00:00+02:00</lastmod></url>
+  <url><loc>https://shop.example.com/about</loc><priority>0.3</priority></url>
+  <url><loc>javascript:alert(1)</loc></url>
+</urlset>`
+
+const rssXML = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>News</title>
+  <item><title>First</title><link>https://news.example.com/1</link><pubDate>Mon, 02 Mar 2026 09:00:00 +0000</pubDate></item>
+  <item><title>Second</title><guid>https://news.example.com/2</guid><pubDate>Tue, 03 Mar 2026 09:00:00 GMT</pubDate></item>
+</channel></rss>`
+
+const atomXML = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><title>Post</title><link rel="self" href="https://blog.example.com/feed/1"/><link href="https://blog.example.com/1"/><updated>2026-02-01T00:00:00Z</updated></entry>
+</feed>`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(sitemapXML), "https://shop.example.com/sitemap.xml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.Format != FormatSitemap || len(doc.Targets) != 4 {
+		t.Fatalf("expected 4 sitemap targets, got %s with %+v", doc.Format, doc.Targets)
+	}
+
+	targets := Prioritize(doc.Targets)
+	order := []string{"https://shop.example.com/new", "https://shop.example.com/old", "https://shop.example.com/", "https://shop.example.com/about"}
+	for i, want := range order {
+		if targets[i].URL != want {
+			t.Errorf("target %d: expected %s, got %s", i, want, targets[i].URL)
+		}
+	}
+	if targets[0].LastModified == nil || targets[0].LastModified.Hour() != 8 {
+		t.Errorf("expected lastmod in UTC, got %v", targets[0].LastModified)
+	}
+
+	doc, err = Parse([]byte(rssXML), "https://news.example.com/feed")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.Format != FormatRSS || len(doc.Targets) != 2 || doc.Targets[1].URL != "https://news.example.com/2" || doc.Targets[0].Title != "First" {
+		t.Errorf("unexpected RSS targets: %+v", doc.Targets)
+	}
+	if doc.Targets[1].LastModified == nil {
+		t.Error("expected a pubDate with a zone name to parse")
+	}
+
+	doc, err = Parse([]byte(atomXML), "https://blog.example.com/feed")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.Format != FormatAtom || len(doc.Targets) != 1 || doc.Targets[0].URL != "https://blog.example.com/1" {
+		t.Errorf("expected the alternate link of the Atom entry, got %+v", doc.Targets)
+	}
+
+	if _, err := Parse([]byte("<html><body>hi</body></html>"), ""); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("expected ErrInvalidSeed for HTML, got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	writer.Write([]byte(sitemapXML))
+	writer.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/products.xml.gz</loc></sitemap>
+  <sitemap><loc>/missing.xml</loc></sitemap>
+</sitemapindex>`))
+	})
+	mux.HandleFunc("/products.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(gzipped.Bytes())
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewFetcher()
+	expansion, err := fetcher.Expand(context.Background(), server.URL+"/sitemap_index.xml")
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if expansion.Format != FormatSitemapIndex || expansion.Sitemaps != 1 || len(expansion.Targets) != 4 {
+		t.Errorf("expected 4 targets from the one sitemap that loads, got %+v", expansion)
+	}
+
+	if _, err := fetcher.Expand(context.Background(), server.URL+"/missing.xml"); err == nil {
+		t.Error("expected a 404 seed to fail")
+	}
+	if _, err := fetcher.Expand(context.Background(), "ftp://example.com/sitemap.xml"); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("expected ErrInvalidSeed for an ftp URL, got %v", err)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	doc, _ := Parse([]byte(sitemapXML), "https://shop.example.com/sitemap.xml")
+	tracker := NewTracker()
+
+	if changed := tracker.Changed(doc.Targets); len(changed) != 4 {
+		t.Fatalf("expected every target to be new, got %d", len(changed))
+	}
+	for _, target := range doc.Targets {
+		tracker.Mark(target)
+	}
+	if changed := tracker.Changed(doc.Targets); len(changed) != 0 {
+		t.Errorf("expected nothing to have changed, got %+v", changed)
+	}
+
+	// /old is modified and /about drops out of the sitemap
+	updated, _ := Parse([]byte(`<urlset>
+  <url><loc>https://shop.example.com/</loc></url>
+  <url><loc>https://shop.example.com/old</loc><lastmod>2026-04-01</lastmod></url>
+  <url><loc>https://shop.example.com/new</loc><lastmod>2026-03-01T10:00:00+02:00</lastmod></url>
+</urlset>`), "")
+	changed := tracker.Changed(updated.Targets)
+	if len(changed) != 1 || changed[0].URL != "https://shop.example.com/old" {
+		t.Errorf("expected only the modified target, got %+v", changed)
+	}
+
+	tracker.Retain(updated.Targets)
+	if tracker.Len() != 3 {
+		t.Errorf("expected the dropped target to be forgotten, got %d remembered", tracker.Len())
+	}
+}
@@ -0,0 +1,52 @@
+package seeds
+
+import "time"
+
+// Tracker remembers which targets were crawled and how new they were then, so a
+// re-crawl only covers what was added or modified since. It isn't safe for concurrent
+// use; its owner serializes access.
+type Tracker struct {
+	crawled map[string]*time.Time // URL → last modified when crawled (nil: undated)
+}
+
+// NewTracker creates a tracker that has seen nothing
+func NewTracker() *Tracker {
+	return &Tracker{crawled: make(map[string]*time.Time)}
+}
+
+// Changed returns the targets never crawled, or modified after they were, in their
+// given order. Undated targets are only crawled once.
+func (t *Tracker) Changed(targets []Target) []Target {
+	changed := make([]Target, 0)
+	for _, target := range targets {
+		crawled, seen := t.crawled[target.URL]
+		if !seen || newer(target.LastModified, crawled) {
+			changed = append(changed, target)
+		}
+	}
+	return changed
+}
+
+// Mark records that a target was crawled
+func (t *Tracker) Mark(target Target) {
+	t.crawled[target.URL] = target.LastModified
+}
+
+// Retain forgets targets the seed no longer lists, so the tracker doesn't grow without
+// bound as a feed rolls over
+func (t *Tracker) Retain(targets []Target) {
+	listed := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		listed[target.URL] = true
+	}
+	for url := range t.crawled {
+		if !listed[url] {
+			delete(t.crawled, url)
+		}
+	}
+}
+
+// Len returns how many targets the tracker remembers
+func (t *Tracker) Len() int {
+	return len(t.crawled)
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -45,9 +46,9 @@ type cdpAXProp struct {
 }
 
 // GetAccessibilityTree retrieves the accessibility tree for a page using CDP
-func (s *Session) GetAccessibilityTree(targetID string) (*AccessibilityTree, error) {
+func (s *Session) GetAccessibilityTree(ctx context.Context, targetID string) (*AccessibilityTree, error) {
 	// Call CDP Accessibility.getFullAXTree
-	result, err := s.CDPClient.SendCommandToTarget(targetID, "Accessibility.getFullAXTree", nil)
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Accessibility.getFullAXTree", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get accessibility tree: %w", err)
 	}
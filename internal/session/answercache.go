@@ -0,0 +1,204 @@
+package session
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// AnswerCacheStats counts what the answer cache served since the server started
+type AnswerCacheStats struct {
+	Enabled bool  `json:"enabled"`
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`   // Answers served without asking the model
+	Misses  int64 `json:"misses"` // Queries the model was asked, caching its answer
+}
+
+// cachedAnswer is a model reply kept for identical queries
+type cachedAnswer struct {
+	key     string
+	reply   vision.Reply
+	expires time.Time
+}
+
+// answerCache keeps model replies by query for ttl, dropping the least recently used
+// beyond size entries. A zero ttl or size disables it.
+type answerCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]*list.Element // Key → element of order holding its *cachedAnswer
+	order   *list.List               // Most recently used first
+	hits    int64
+	misses  int64
+}
+
+// enabled reports whether replies are kept
+func (c *answerCache) enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttl > 0 && c.size > 0
+}
+
+// get returns the reply kept under key, counting the hit or miss
+func (c *answerCache) get(key string, now time.Time) (*vision.Reply, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		entry := element.Value.(*cachedAnswer)
+		if now.Before(entry.expires) {
+			c.order.MoveToFront(element)
+			c.hits++
+			reply := entry.reply
+			return &reply, true
+		}
+		c.removeLocked(element)
+	}
+	c.misses++
+	return nil, false
+}
+
+// put keeps a reply under key for the cache's ttl
+func (c *answerCache) put(key string, reply vision.Reply, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 || c.size <= 0 {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+		c.order = list.New()
+	}
+
+	if element, exists := c.entries[key]; exists {
+		c.removeLocked(element)
+	}
+	c.entries[key] = c.order.PushFront(&cachedAnswer{key: key, reply: reply, expires: now.Add(c.ttl)})
+	c.trimLocked()
+}
+
+// configure changes the ttl and size, dropping the entries that no longer fit. Kept
+// entries keep their expiry.
+func (c *answerCache) configure(ttl time.Duration, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl, c.size = ttl, size
+	if ttl <= 0 || size <= 0 {
+		c.entries, c.order = nil, nil
+		return
+	}
+	c.trimLocked()
+}
+
+// trimLocked drops the least recently used entries beyond size. It requires c.mu to be held.
+func (c *answerCache) trimLocked() {
+	for c.order != nil && c.order.Len() > c.size {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// removeLocked drops an entry. It requires c.mu to be held.
+func (c *answerCache) removeLocked(element *list.Element) {
+	delete(c.entries, element.Value.(*cachedAnswer).key)
+	c.order.Remove(element)
+}
+
+// stats returns the cache's counts
+func (c *answerCache) stats() AnswerCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := AnswerCacheStats{Enabled: c.ttl > 0 && c.size > 0, Hits: c.hits, Misses: c.misses}
+	if c.order != nil {
+		stats.Entries = c.order.Len()
+	}
+	return stats
+}
+
+// SetAnswerCache keeps model answers for ttl, so identical queries against unchanged
+// pages are answered without asking the model again. At most size answers are kept;
+// a zero ttl or size turns caching off and drops what was kept.
+func (m *Manager) SetAnswerCache(ttl time.Duration, size int) {
+	m.answers.configure(ttl, size)
+}
+
+// AnswerCacheStats returns how many answers the cache holds and served
+func (m *Manager) AnswerCacheStats() AnswerCacheStats {
+	return m.answers.stats()
+}
+
+// cachedModel answers queries it was asked before from the answer cache, and asks its
+// model otherwise. Answers are kept per tenant, model and prompt; the image is keyed by
+// the page it shows when the caller knows it, and by its bytes otherwise.
+type cachedModel struct {
+	cache    *answerCache
+	model    vision.Model
+	tenantID string
+	imageKey string // Identifies what the image shows, such as the page's content hash
+}
+
+// cacheModel returns model with its answers cached for a tenant, or model itself when
+// the cache is off or bypass is set
+func (m *Manager) cacheModel(model vision.Model, tenantID string, imageKey string, bypass bool) vision.Model {
+	if model == nil || bypass || !m.answers.enabled() {
+		return model
+	}
+	return &cachedModel{cache: &m.answers, model: model, tenantID: tenantID, imageKey: imageKey}
+}
+
+func (cm *cachedModel) Name() string { return cm.model.Name() }
+
+func (cm *cachedModel) Ask(ctx context.Context, query vision.Query) (*vision.Reply, error) {
+	return cm.ask(query, nil, func() (*vision.Reply, error) { return cm.model.Ask(ctx, query) })
+}
+
+// AskStream hands a cached reply to onText in one piece
+func (cm *cachedModel) AskStream(ctx context.Context, query vision.Query, onText func(string)) (*vision.Reply, error) {
+	streamer, ok := cm.model.(vision.Streamer)
+	if !ok {
+		return cm.Ask(ctx, query)
+	}
+	return cm.ask(query, onText, func() (*vision.Reply, error) { return streamer.AskStream(ctx, query, onText) })
+}
+
+// ask serves the query from the cache, or makes it and keeps the reply
+func (cm *cachedModel) ask(query vision.Query, onText func(string), do func() (*vision.Reply, error)) (*vision.Reply, error) {
+	key := cm.key(query)
+	if reply, hit := cm.cache.get(key, time.Now()); hit {
+		// Nothing was spent this time
+		reply.Usage = vision.Usage{}
+		reply.Cached = true
+		if onText != nil {
+			onText(reply.Text)
+		}
+		return reply, nil
+	}
+
+	reply, err := do()
+	if err != nil {
+		return nil, err
+	}
+	cm.cache.put(key, *reply, time.Now())
+	return reply, nil
+}
+
+// key identifies a query within the tenant
+func (cm *cachedModel) key(query vision.Query) string {
+	image := cm.imageKey
+	if image == "" && len(query.Image) > 0 {
+		sum := sha256.Sum256(query.Image)
+		image = hex.EncodeToString(sum[:])
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{cm.tenantID, cm.model.Name(), query.System, query.Prompt, query.MimeType, image} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,177 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// countingModel answers every query the same and counts how often it was asked
+type countingModel struct {
+	asked atomic.Int32
+}
+
+func (c *countingModel) Name() string { return "counting-model" }
+
+func (c *countingModel) Ask(ctx context.Context, query vision.Query) (*vision.Reply, error) {
+	c.asked.Add(1)
+	return &vision.Reply{Text: `{"answer": "It is 330 metres tall."}`, Model: "counting-model-1", Usage: vision.Usage{PromptTokens: 100, CompletionTokens: 10}}, nil
+}
+
+// TestAnswerCache tests that repeated queries of a tenant are answered from the cache
+// without spending tokens, and that other tenants, other pages and bypassed queries
+// still ask the model
+func TestAnswerCache(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	manager.SetModelPricing(ModelPricing{PromptPerMillion: 1000})
+	manager.SetAnswerCache(time.Minute, 10)
+
+	model := &countingModel{}
+	ask := func(tenantID, pageKey string, bypass bool) *vision.Reply {
+		t.Helper()
+		metered := manager.meterModel(model, ModelCall{Operation: ModelOperationVision, TenantID: tenantID})
+		reply, err := manager.cacheModel(metered, tenantID, pageKey, bypass).Ask(context.Background(), vision.Query{Prompt: "How tall?", Image: []byte("png")})
+		if err != nil {
+			t.Fatalf("Ask failed: %v", err)
+		}
+		return reply
+	}
+
+	if reply := ask("acme", "page-a", false); reply.Cached {
+		t.Error("expected the first query asked")
+	}
+	reply := ask("acme", "page-a", false)
+	if !reply.Cached || reply.Usage.PromptTokens != 0 || reply.Model != "counting-model-1" {
+		t.Errorf("expected the repeat served from the cache without tokens, got %+v", reply)
+	}
+	if model.asked.Load() != 1 {
+		t.Errorf("expected the model asked once, got %d", model.asked.Load())
+	}
+	if days := manager.ModelUsage("acme", 1); days[0].Calls != 1 {
+		t.Errorf("expected only the asked query counted, got %d calls", days[0].Calls)
+	}
+
+	// A changed page, another tenant and a bypass each ask again
+	ask("acme", "page-b", false)
+	ask("globex", "page-a", false)
+	if reply := ask("acme", "page-a", true); reply.Cached {
+		t.Error("expected a bypassed query asked")
+	}
+	if model.asked.Load() != 4 {
+		t.Errorf("expected the model asked 4 times, got %d", model.asked.Load())
+	}
+
+	stats := manager.AnswerCacheStats()
+	if !stats.Enabled || stats.Hits != 1 || stats.Misses != 3 || stats.Entries != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// A streamed hit hands the answer over in one piece, once
+	streamer := &chunkModel{pieces: []string{`{"ans`, `wer": "It is `, `330 metres tall."}`}}
+	for i := range 2 {
+		var streamed strings.Builder
+		pieces := 0
+		reply, err := askModel(context.Background(), manager.cacheModel(streamer, "acme", "page-a", false), vision.Query{Prompt: "How tall?"}, func(text string) {
+			pieces++
+			streamed.WriteString(text)
+		})
+		if err != nil {
+			t.Fatalf("askModel %d failed: %v", i, err)
+		}
+		if streamed.String() != "It is 330 metres tall." || reply.Cached != (i == 1) {
+			t.Errorf("query %d: unexpected streamed answer %q, cached %v", i, streamed.String(), reply.Cached)
+		}
+		if i == 1 && pieces != 1 {
+			t.Errorf("expected the cached answer streamed in 1 piece, got %d", pieces)
+		}
+	}
+
+	// Turning the cache off drops what it kept
+	manager.SetAnswerCache(0, 10)
+	if manager.cacheModel(model, "acme", "page-a", false) != vision.Model(model) {
+		t.Error("expected the model itself with the cache off")
+	}
+	if stats := manager.AnswerCacheStats(); stats.Enabled || stats.Entries != 0 {
+		t.Errorf("expected an empty, disabled cache, got %+v", stats)
+	}
+}
+
+// TestAnswerCacheExpiry tests that answers expire after the TTL and that the least
+// recently used go first when the cache is full
+func TestAnswerCacheExpiry(t *testing.T) {
+	cache := &answerCache{}
+	cache.configure(time.Minute, 2)
+	now := time.Now()
+
+	cache.put("a", vision.Reply{Text: "a"}, now)
+	cache.put("b", vision.Reply{Text: "b"}, now)
+	if _, hit := cache.get("a", now); !hit {
+		t.Fatal("expected a kept")
+	}
+	cache.put("c", vision.Reply{Text: "c"}, now)
+	if _, hit := cache.get("b", now); hit {
+		t.Error("expected b, used least recently, dropped")
+	}
+	if _, hit := cache.get("a", now.Add(2*time.Minute)); hit {
+		t.Error("expected a expired")
+	}
+	if stats := cache.stats(); stats.Entries != 1 {
+		t.Errorf("expected only c left, got %+v", stats)
+	}
+
+	// Shrinking drops the oldest answers at once
+	cache.put("d", vision.Reply{Text: "d"}, now)
+	cache.configure(time.Minute, 1)
+	if _, hit := cache.get("d", now); !hit || cache.stats().Entries != 1 {
+		t.Error("expected only the latest answer kept after shrinking")
+	}
+}
+
+// TestAnswerPageKey tests that the cache key of a page changes with its viewport and
+// device scale, not only its content and scroll position
+func TestAnswerPageKey(t *testing.T) {
+	view := "0,0,1280,720,1"
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch {
+		case method != "Runtime.evaluate":
+		case strings.Contains(p.Expression, "innerWidth"):
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": view}}
+		case strings.Contains(p.Expression, "structure"):
+			content := `{"url": "https://example.com/", "text": "Example Domain", "structure": ["0:h1"]}`
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": content}}
+		}
+		return nil
+	})
+	sess, pageID := openTestPage(t, manager, nil, "https://example.com")
+
+	key := func() string {
+		t.Helper()
+		key, err := sess.answerPageKey(context.Background(), manager, pageID)
+		if err != nil {
+			t.Fatalf("answerPageKey failed: %v", err)
+		}
+		return key
+	}
+
+	desktop := key()
+	if again := key(); again != desktop {
+		t.Errorf("expected the same key for an unchanged page, got %q and %q", desktop, again)
+	}
+	for _, changed := range []string{"0,0,390,844,1", "0,0,1280,720,2", "0,300,1280,720,1"} {
+		view = changed
+		if key() == desktop {
+			t.Errorf("expected viewport %s to change the key", changed)
+		}
+	}
+}
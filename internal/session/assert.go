@@ -0,0 +1,274 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/storage"
+)
+
+// Checks an assertion can make, in the order they are reported
+const (
+	CheckExists      = "exists"
+	CheckVisible     = "visible"
+	CheckText        = "text"
+	CheckTextMatches = "text_matches"
+	CheckURL         = "url"
+	CheckCookie      = "cookie"
+	CheckStatus      = "status"
+	CheckFunction    = "function"
+)
+
+// Assertion is a set of checks on a page. Each field that is set adds a check, and the
+// assertion passes when all of them do.
+type Assertion struct {
+	Selector    string `json:"selector,omitempty"`     // An element this selector finds must exist
+	Visible     bool   `json:"visible,omitempty"`      // The selector's element must also be visible
+	Text        string `json:"text,omitempty"`         // Text the element, or the page without a selector, must contain
+	TextMatches string `json:"text_matches,omitempty"` // Regular expression the element's or page's text must match
+	URL         string `json:"url,omitempty"`          // Pattern the page URL must match, as for waits
+	Cookie      string `json:"cookie,omitempty"`       // Name of a cookie that must be set in the session
+	Status      int    `json:"status,omitempty"`       // HTTP status the page's last navigation must have ended with
+	Function    string `json:"function,omitempty"`     // JavaScript expression that must be truthy
+
+	SelectorEngine // How Selector is read
+}
+
+// AssertionCheck is the outcome of one check of an assertion
+type AssertionCheck struct {
+	Check    string      `json:"check"` // One of the Check* names
+	Passed   bool        `json:"passed"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+	Error    string      `json:"error,omitempty"` // Why the check couldn't be made, which fails it
+}
+
+// AssertionResult is the outcome of an assertion
+type AssertionResult struct {
+	Passed bool             `json:"passed"`
+	URL    string           `json:"url,omitempty"` // Page URL when the checks ran
+	Checks []AssertionCheck `json:"checks"`
+}
+
+// Failures describes the checks that failed, for an error message
+func (r *AssertionResult) Failures() string {
+	failures := make([]string, 0, len(r.Checks))
+	for _, check := range r.Checks {
+		switch {
+		case check.Passed:
+		case check.Error != "":
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Check, check.Error))
+		default:
+			failures = append(failures, fmt.Sprintf("%s: expected %v, got %v", check.Check, check.Expected, check.Actual))
+		}
+	}
+	return strings.Join(failures, "; ")
+}
+
+// maxExcerpt bounds the text a failed text check reports back
+const maxExcerpt = 200
+
+// assertElementJS reads what the element checks need of the element an expression
+// finds, or {found: false}
+const assertElementJS = `(function() {
+  var el = %s;
+  if (!el) return JSON.stringify({found: false});
+  var rect = el.getBoundingClientRect(), style = getComputedStyle(el);
+  return JSON.stringify({
+    found: true,
+    text: el.innerText,
+    visible: rect.width > 0 && rect.height > 0 && style.display !== 'none' &&
+      style.visibility !== 'hidden' && Number(style.opacity) > 0
+  });
+})()`
+
+// assertedElement is what assertElementJS reports
+type assertedElement struct {
+	Found   bool   `json:"found"`
+	Text    string `json:"text"`
+	Visible bool   `json:"visible"`
+}
+
+// validate checks that the assertion makes at least one check and that its patterns compile
+func (a *Assertion) validate() error {
+	if a.Selector == "" && a.Text == "" && a.TextMatches == "" && a.URL == "" &&
+		a.Cookie == "" && a.Status == 0 && a.Function == "" {
+		return fmt.Errorf("%w: set at least one of selector, text, text_matches, url, cookie, status and function", ErrInvalidAssertion)
+	}
+	if a.Visible && a.Selector == "" {
+		return fmt.Errorf("%w: visible needs a selector", ErrInvalidAssertion)
+	}
+	if err := a.SelectorEngine.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidAssertion, err)
+	}
+	if a.TextMatches != "" {
+		if _, err := regexp.Compile(a.TextMatches); err != nil {
+			return fmt.Errorf("%w: text_matches: %v", ErrInvalidAssertion, err)
+		}
+	}
+	if a.URL != "" {
+		if _, err := compileURLPattern(a.URL); err != nil {
+			return fmt.Errorf("%w: url pattern: %v", ErrInvalidAssertion, err)
+		}
+	}
+	if a.Status != 0 && (a.Status < 100 || a.Status > 599) {
+		return fmt.Errorf("%w: status must be between 100 and 599", ErrInvalidAssertion)
+	}
+	return nil
+}
+
+// Assert checks a page once, without waiting. A failed check isn't an error: the result
+// reports which checks passed.
+func (m *Manager) Assert(ctx context.Context, sessionID string, pageID string, assertion Assertion) (*AssertionResult, error) {
+	if err := assertion.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	result := session.assert(ctx, pageID, assertion)
+	session.UpdateActivity()
+	return result, nil
+}
+
+// assert runs the checks of a validated assertion
+func (s *Session) assert(ctx context.Context, pageID string, a Assertion) *AssertionResult {
+	result := &AssertionResult{Passed: true}
+	add := func(check AssertionCheck) {
+		result.Passed = result.Passed && check.Passed
+		result.Checks = append(result.Checks, check)
+	}
+
+	url, err := s.GetCurrentURL(ctx, pageID)
+	if err != nil {
+		url = ""
+	}
+	result.URL = url
+
+	if a.Selector != "" || a.Text != "" || a.TextMatches != "" {
+		selector, engine := a.Selector, a.SelectorEngine
+		if selector == "" {
+			selector, engine = "body", SelectorEngine{}
+		}
+		element, err := s.assertedElement(ctx, pageID, selector, engine)
+
+		if a.Selector != "" {
+			check := AssertionCheck{Check: CheckExists, Expected: a.Selector}
+			if err != nil {
+				check.Error = err.Error()
+			} else {
+				check.Passed = element.Found
+				check.Actual = element.Found
+			}
+			add(check)
+		}
+		if a.Visible {
+			check := AssertionCheck{Check: CheckVisible, Expected: true}
+			if err == nil && element.Found {
+				check.Passed = element.Visible
+				check.Actual = element.Visible
+			} else {
+				check.Error = "element not found"
+			}
+			add(check)
+		}
+		for _, want := range []struct {
+			name  string
+			value string
+			holds func(text string) bool
+		}{
+			{CheckText, a.Text, func(text string) bool { return strings.Contains(text, a.Text) }},
+			{CheckTextMatches, a.TextMatches, func(text string) bool { return regexp.MustCompile(a.TextMatches).MatchString(text) }},
+		} {
+			if want.value == "" {
+				continue
+			}
+			check := AssertionCheck{Check: want.name, Expected: want.value}
+			switch {
+			case err != nil:
+				check.Error = err.Error()
+			case !element.Found:
+				check.Error = "nothing matches " + engine.describe(selector)
+			default:
+				check.Passed = want.holds(element.Text)
+				check.Actual = excerpt(element.Text)
+			}
+			add(check)
+		}
+	}
+
+	if a.URL != "" {
+		pattern, _ := compileURLPattern(a.URL)
+		add(AssertionCheck{Check: CheckURL, Expected: a.URL, Actual: url, Passed: url != "" && pattern.MatchString(url)})
+	}
+
+	if a.Cookie != "" {
+		check := AssertionCheck{Check: CheckCookie, Expected: a.Cookie}
+		cookies, err := s.getContextCookies(ctx)
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Passed = slices.ContainsFunc(cookies, func(c storage.Cookie) bool { return c.Name == a.Cookie })
+			check.Actual = check.Passed
+		}
+		add(check)
+	}
+
+	if a.Status != 0 {
+		check := AssertionCheck{Check: CheckStatus, Expected: a.Status}
+		if navigation := s.LastNavigation(pageID); navigation == nil || navigation.Status == 0 {
+			check.Error = "no document response recorded for the page"
+		} else {
+			check.Passed = navigation.Status == a.Status
+			check.Actual = navigation.Status
+		}
+		add(check)
+	}
+
+	if a.Function != "" {
+		check := AssertionCheck{Check: CheckFunction, Expected: true}
+		value, err := s.ExecuteJavascript(ctx, pageID, fmt.Sprintf("!!(%s)", a.Function))
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Passed = value == true
+			check.Actual = value
+		}
+		add(check)
+	}
+
+	return result
+}
+
+// assertedElement reads the first element selector finds
+func (s *Session) assertedElement(ctx context.Context, pageID string, selector string, engine SelectorEngine) (*assertedElement, error) {
+	value, err := s.ExecuteJavascript(ctx, pageID, fmt.Sprintf(assertElementJS, engine.first(selector)))
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := value.(string)
+	var element assertedElement
+	if err := json.Unmarshal([]byte(raw), &element); err != nil {
+		return nil, fmt.Errorf("failed to parse element: %w", err)
+	}
+	return &element, nil
+}
+
+// excerpt shortens text to maxExcerpt runes for a check's result
+func excerpt(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxExcerpt {
+		return text
+	}
+	return string(runes[:maxExcerpt]) + "…"
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestAssert tests that each check of an assertion is reported on its own and that the
+// assertion only passes when all of them do
+func TestAssert(t *testing.T) {
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Storage.getCookies":
+			return map[string]interface{}{"cookies": []map[string]interface{}{
+				{"name": "sid", "value": "42", "domain": "shop.example.com", "path": "/"},
+			}}
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			switch {
+			case p.Expression == "location.href":
+				value = "https://shop.example.com/orders/17"
+			case strings.Contains(p.Expression, `"#banner"`):
+				value = `{"found": true, "visible": false, "text": ""}`
+			case strings.Contains(p.Expression, "getBoundingClientRect"):
+				value = `{"found": true, "visible": true, "text": "Order 17 shipped"}`
+			case strings.HasPrefix(p.Expression, "!!("):
+				value = true
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com/orders/17")
+	sess.mu.Lock()
+	sess.navigations[pageID] = &NavigationInfo{URL: "https://shop.example.com/orders/17", Status: 200}
+	sess.mu.Unlock()
+
+	for _, invalid := range []Assertion{{}, {Visible: true}, {TextMatches: "("}, {Status: 42}} {
+		if _, err := manager.Assert(ctx, sess.ID, pageID, invalid); !errors.Is(err, ErrInvalidAssertion) {
+			t.Errorf("expected ErrInvalidAssertion for %+v, got %v", invalid, err)
+		}
+	}
+
+	result, err := manager.Assert(ctx, sess.ID, pageID, Assertion{
+		Selector:    "h1",
+		Visible:     true,
+		TextMatches: `Order \d+ shipped`,
+		URL:         "https://shop.example.com/orders/*",
+		Cookie:      "sid",
+		Status:      200,
+		Function:    "window.orderLoaded",
+	})
+	if err != nil {
+		t.Fatalf("Assert failed: %v", err)
+	}
+	if !result.Passed || len(result.Checks) != 7 {
+		t.Errorf("expected 7 passing checks, got %+v", result)
+	}
+
+	result, err = manager.Assert(ctx, sess.ID, pageID, Assertion{Selector: "#banner", Visible: true, Cookie: "cart", Status: 201})
+	if err != nil {
+		t.Fatalf("Assert failed: %v", err)
+	}
+	passed := make(map[string]bool)
+	for _, check := range result.Checks {
+		passed[check.Check] = check.Passed
+	}
+	want := map[string]bool{CheckExists: true, CheckVisible: false, CheckCookie: false, CheckStatus: false}
+	if result.Passed || len(passed) != len(want) {
+		t.Fatalf("expected the assertion to fail on its checks, got %+v", result)
+	}
+	for check, ok := range want {
+		if passed[check] != ok {
+			t.Errorf("%s passed = %t, want %t", check, passed[check], ok)
+		}
+	}
+	if failures := result.Failures(); !strings.Contains(failures, "status: expected 201, got 200") {
+		t.Errorf("Failures() = %q", failures)
+	}
+}
@@ -0,0 +1,209 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/egress"
+)
+
+// PageBandwidth is what one open page of a session downloaded
+type PageBandwidth struct {
+	PageID   string `json:"page_id"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"` // Encoded as received, headers included
+}
+
+// BandwidthReport is what a session's pages downloaded since it was created or last
+// resumed. Closed pages still count in the totals.
+type BandwidthReport struct {
+	Requests int64           `json:"requests"`
+	Bytes    int64           `json:"bytes"`
+	Pages    []PageBandwidth `json:"pages"`
+	Egress   *egress.Usage   `json:"egress,omitempty"` // The session's traffic on the egress proxy, when it goes through it
+}
+
+// BandwidthStats totals what every metered session downloaded since the server started
+type BandwidthStats struct {
+	Sessions int   `json:"sessions"` // Live sessions being metered
+	Requests int64 `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// bandwidthLedger totals what metered sessions download, overall and per tenant for
+// the current UTC day
+type bandwidthLedger struct {
+	mu       sync.Mutex
+	all      bool // Every Chromium session is metered, not only those of tenants with a bandwidth quota
+	requests int64
+	bytes    int64
+	day      time.Time        // UTC day tenants are counted for
+	tenants  map[string]int64 // Tenant ID → bytes its sessions downloaded that day
+}
+
+// add counts a finished request of a tenant's session
+func (l *bandwidthLedger) add(tenantID string, bytes int64, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests++
+	l.bytes += bytes
+	l.rollLocked(now)
+	if tenantID != "" {
+		l.tenants[tenantID] += bytes
+	}
+}
+
+// tenantBytes returns what a tenant's sessions downloaded on now's UTC day
+func (l *bandwidthLedger) tenantBytes(tenantID string, now time.Time) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollLocked(now)
+	return l.tenants[tenantID]
+}
+
+// rollLocked starts counting tenants afresh when now is on a new day. It requires l.mu
+// to be held.
+func (l *bandwidthLedger) rollLocked(now time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	if l.tenants == nil || !day.Equal(l.day) {
+		l.day = day
+		l.tenants = make(map[string]int64)
+	}
+}
+
+// bandwidthMeter counts what a session's pages download, adding it to the ledger
+// under the tenant the session was created for
+type bandwidthMeter struct {
+	tenantID string
+	ledger   *bandwidthLedger
+	requests atomic.Int64
+	bytes    atomic.Int64
+}
+
+// add counts a finished request
+func (b *bandwidthMeter) add(bytes int64) {
+	b.requests.Add(1)
+	b.bytes.Add(bytes)
+	b.ledger.add(b.tenantID, bytes, time.Now())
+}
+
+// SetBandwidthAccounting meters what the pages of every Chromium session created from
+// now on download. Sessions of tenants with a bandwidth quota are metered regardless.
+func (m *Manager) SetBandwidthAccounting(enabled bool) {
+	m.bandwidth.mu.Lock()
+	defer m.bandwidth.mu.Unlock()
+	m.bandwidth.all = enabled
+}
+
+// newBandwidthMeter returns the meter of a new tenant's session, nil when it isn't
+// metered. Firefox pages report no network events, so they never are.
+func (m *Manager) newBandwidthMeter(tenantID string, opts *SessionOptions) *bandwidthMeter {
+	if engineOf(opts) == EngineFirefox {
+		return nil
+	}
+	m.bandwidth.mu.Lock()
+	all := m.bandwidth.all
+	m.bandwidth.mu.Unlock()
+	if !all && m.maxTenantBandwidth(tenantID) == 0 {
+		return nil
+	}
+	return &bandwidthMeter{tenantID: tenantID, ledger: &m.bandwidth}
+}
+
+// maxTenantBandwidth returns a tenant's daily bandwidth quota in bytes (0: unlimited)
+func (m *Manager) maxTenantBandwidth(tenantID string) int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.quotas == nil {
+		return 0
+	}
+	return m.quotas.MaxBandwidthBytes(tenantID)
+}
+
+// checkTenantBandwidth fails once a tenant's sessions downloaded its daily quota
+func (m *Manager) checkTenantBandwidth(tenantID string) error {
+	max := m.maxTenantBandwidth(tenantID)
+	if max == 0 {
+		return nil
+	}
+	if used := m.bandwidth.tenantBytes(tenantID, time.Now()); used >= max {
+		return fmt.Errorf("%w: %d of %d bytes downloaded today (UTC)", ErrTenantBandwidthLimit, used, max)
+	}
+	return nil
+}
+
+// meterLoadingFinished counts a request a metered page finished loading
+func (s *Session) meterLoadingFinished(targetID string, event *cdp.Event) {
+	var params struct {
+		EncodedDataLength float64 `json:"encodedDataLength"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil {
+		return
+	}
+	bytes := int64(params.EncodedDataLength)
+
+	s.networkMu.Lock()
+	var meter *bandwidthMeter
+	if network := s.network[targetID]; network != nil && network.meter != nil {
+		network.requests++
+		network.bytes += bytes
+		meter = network.meter
+	}
+	s.networkMu.Unlock()
+
+	if meter != nil {
+		meter.add(bytes)
+	}
+}
+
+// SessionBandwidth reports what a metered session's pages downloaded, and its egress
+// proxy usage when its traffic goes through the proxy
+func (m *Manager) SessionBandwidth(sessionID string) (*BandwidthReport, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.bandwidth == nil {
+		return nil, fmt.Errorf("%w: enable bandwidth accounting or give the tenant a bandwidth quota", ErrBandwidthUnmetered)
+	}
+
+	report := &BandwidthReport{
+		Requests: session.bandwidth.requests.Load(),
+		Bytes:    session.bandwidth.bytes.Load(),
+		Pages:    []PageBandwidth{},
+	}
+	session.networkMu.Lock()
+	for pageID, network := range session.network {
+		report.Pages = append(report.Pages, PageBandwidth{PageID: pageID, Requests: network.requests, Bytes: network.bytes})
+	}
+	session.networkMu.Unlock()
+	sort.Slice(report.Pages, func(i, j int) bool { return report.Pages[i].PageID < report.Pages[j].PageID })
+
+	if proxy := m.egressProxy(); proxy != nil {
+		if usage, registered := proxy.Usage(sessionID); registered {
+			report.Egress = &usage
+		}
+	}
+	return report, nil
+}
+
+// BandwidthStats returns what metered sessions downloaded since the server started
+func (m *Manager) BandwidthStats() BandwidthStats {
+	m.mu.RLock()
+	sessions := 0
+	for _, session := range m.sessions {
+		if session.bandwidth != nil {
+			sessions++
+		}
+	}
+	m.mu.RUnlock()
+
+	m.bandwidth.mu.Lock()
+	defer m.bandwidth.mu.Unlock()
+	return BandwidthStats{Sessions: sessions, Requests: m.bandwidth.requests, Bytes: m.bandwidth.bytes}
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestBandwidthAccounting tests metering what pages download per page, session and
+// tenant, and refusing a tenant's navigations and sessions past its daily quota
+func TestBandwidthAccounting(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		mu.Lock()
+		methods = append(methods, method)
+		mu.Unlock()
+		return nil
+	})
+
+	ctx := context.Background()
+
+	// Unmetered until accounting is on or the tenant has a quota
+	plain, err := manager.CreateSessionWithOptions(ctx, "beta", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	if _, err := manager.SessionBandwidth(plain.ID); !errors.Is(err, ErrBandwidthUnmetered) {
+		t.Errorf("expected ErrBandwidthUnmetered, got %v", err)
+	}
+
+	manager.SetTenantQuotas(fixedQuotas{bandwidth: 1000})
+	sess, err := manager.CreateSessionWithOptions(ctx, "acme", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	pageID, err := manager.Navigate(ctx, sess.ID, "https://shop.example.com")
+	if err != nil {
+		t.Fatalf("Navigate failed: %v", err)
+	}
+	mu.Lock()
+	if slices.Contains(methods, "Network.disable") {
+		t.Errorf("expected the Network domain kept on for metering, got %v", methods)
+	}
+	mu.Unlock()
+
+	finished := func(bytes int) {
+		sess.dispatchNetworkEvent(pageID, &cdp.Event{Method: "Network.loadingFinished", Params: []byte(`{"requestId": "r", "encodedDataLength": ` + strconv.Itoa(bytes) + `}`)})
+	}
+	finished(600)
+	finished(150)
+
+	report, err := manager.SessionBandwidth(sess.ID)
+	if err != nil {
+		t.Fatalf("SessionBandwidth failed: %v", err)
+	}
+	if report.Requests != 2 || report.Bytes != 750 || len(report.Pages) != 1 || report.Pages[0].Bytes != 750 || report.Egress != nil {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if usage := manager.TenantUsage("acme"); usage.BandwidthBytes != 750 {
+		t.Errorf("expected the tenant's bytes counted, got %+v", usage)
+	}
+
+	// Past the quota the tenant loads no more pages and gets no more sessions
+	finished(300)
+	if _, err := manager.Navigate(ctx, sess.ID, "https://shop.example.com/cart"); !errors.Is(err, ErrTenantBandwidthLimit) {
+		t.Errorf("expected ErrTenantBandwidthLimit navigating, got %v", err)
+	}
+	if _, err := manager.CreateSessionWithOptions(ctx, "acme", "agent-1", "", 9222, "", nil); !errors.Is(err, ErrTenantBandwidthLimit) {
+		t.Errorf("expected ErrTenantBandwidthLimit creating, got %v", err)
+	}
+
+	// Closed pages still count for the session
+	sess.stopNetworkCapture(pageID)
+	report, _ = manager.SessionBandwidth(sess.ID)
+	if report.Bytes != 1050 || len(report.Pages) != 0 {
+		t.Errorf("expected the closed page in the totals only, got %+v", report)
+	}
+	if stats := manager.BandwidthStats(); stats.Sessions != 1 || stats.Requests != 3 || stats.Bytes != 1050 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Tenants are counted afresh each UTC day
+	tomorrow := time.Now().UTC().Add(24 * time.Hour)
+	if used := manager.bandwidth.tenantBytes("acme", tomorrow); used != 0 {
+		t.Errorf("expected the next day to start at zero, got %d", used)
+	}
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// bulkDestroyWorkers bounds how many sessions a bulk destroy tears down at once
+const bulkDestroyWorkers = 8
+
+// SessionFilter selects sessions by label and age. Zero fields match everything.
+type SessionFilter struct {
+	AgentID string
+	// Labels must all match; an empty value only requires the key to be present
+	Labels    map[string]string
+	OlderThan time.Duration // Created at least this long ago
+}
+
+// DestroyOutcome is the result of destroying one session in a bulk destroy
+type DestroyOutcome struct {
+	SessionID string `json:"session_id"`
+	Destroyed bool   `json:"destroyed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Labels returns the session's labels
+func (s *Session) Labels() map[string]string {
+	if s.Options == nil {
+		return nil
+	}
+	return s.Options.Labels
+}
+
+// matches reports whether a session passes the filter at time now
+func (f SessionFilter) matches(session *Session, now time.Time) bool {
+	if f.AgentID != "" && session.AgentID != f.AgentID {
+		return false
+	}
+	if f.OlderThan > 0 && now.Sub(session.CreatedAt) < f.OlderThan {
+		return false
+	}
+
+	labels := session.Labels()
+	for key, want := range f.Labels {
+		value, exists := labels[key]
+		if !exists || (want != "" && value != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindTenantSessions returns a tenant's live sessions that pass the filter
+func (m *Manager) FindTenantSessions(tenantID string, filter SessionFilter) []*Session {
+	now := time.Now()
+	sessions := make([]*Session, 0)
+	for _, session := range m.ListTenantSessions(tenantID) {
+		if filter.matches(session, now) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// DestroySessions destroys sessions concurrently, a few at a time, and reports the
+// outcome for each in the order given. One failure doesn't stop the others.
+func (m *Manager) DestroySessions(sessionIDs []string) []DestroyOutcome {
+	outcomes := make([]DestroyOutcome, len(sessionIDs))
+	slots := make(chan struct{}, bulkDestroyWorkers)
+
+	var wg sync.WaitGroup
+	for i, sessionID := range sessionIDs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			outcomes[i] = DestroyOutcome{SessionID: sessionID, Destroyed: true}
+			if err := m.DestroySession(sessionID); err != nil {
+				outcomes[i] = DestroyOutcome{SessionID: sessionID, Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return outcomes
+}
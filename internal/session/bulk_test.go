@@ -0,0 +1,69 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSessionFilter tests selecting sessions by agent, age and labels
+func TestSessionFilter(t *testing.T) {
+	now := time.Now()
+	session := &Session{
+		AgentID:   "agent-1",
+		CreatedAt: now.Add(-2 * time.Hour),
+		Options:   &SessionOptions{Labels: map[string]string{"run": "nightly-42", "team": "checkout"}},
+	}
+
+	tests := []struct {
+		name   string
+		filter SessionFilter
+		want   bool
+	}{
+		{"empty", SessionFilter{}, true},
+		{"label value", SessionFilter{Labels: map[string]string{"run": "nightly-42"}}, true},
+		{"label key only", SessionFilter{Labels: map[string]string{"team": ""}}, true},
+		{"all labels", SessionFilter{Labels: map[string]string{"run": "nightly-42", "team": "search"}}, false},
+		{"missing label", SessionFilter{Labels: map[string]string{"env": ""}}, false},
+		{"old enough", SessionFilter{OlderThan: time.Hour}, true},
+		{"too young", SessionFilter{OlderThan: 3 * time.Hour}, false},
+		{"other agent", SessionFilter{AgentID: "agent-2"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.matches(session, now); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	unlabeled := &Session{CreatedAt: now}
+	if (SessionFilter{Labels: map[string]string{"run": ""}}).matches(unlabeled, now) {
+		t.Error("expected a session without labels not to match a label filter")
+	}
+}
+
+// TestDestroySessionsOutcomes tests that every requested session gets an outcome, in order
+func TestDestroySessionsOutcomes(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	ids := []string{"sess_a", "sess_b", "sess_c"}
+	outcomes := manager.DestroySessions(ids)
+	if len(outcomes) != len(ids) {
+		t.Fatalf("expected %d outcomes, got %+v", len(ids), outcomes)
+	}
+	for i, outcome := range outcomes {
+		if outcome.SessionID != ids[i] || outcome.Destroyed || outcome.Error == "" {
+			t.Errorf("expected a not-found failure for %s, got %+v", ids[i], outcome)
+		}
+	}
+}
+
+// TestWarmClaimIgnoresLabels tests that labels don't keep a session from a warm context
+func TestWarmClaimIgnoresLabels(t *testing.T) {
+	if withoutLabels(&SessionOptions{Labels: map[string]string{"run": "1"}}) != nil {
+		t.Error("expected label-only options to match the default")
+	}
+	stripped := withoutLabels(&SessionOptions{UserAgent: "bot", Labels: map[string]string{"run": "1"}})
+	if stripped == nil || stripped.UserAgent != "bot" || stripped.Labels != nil {
+		t.Errorf("unexpected stripped options: %+v", stripped)
+	}
+}
@@ -0,0 +1,392 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// How canvas contents are captured
+const (
+	CanvasAuto       = "auto"       // Read the canvas, falling back to a screenshot (default)
+	CanvasDataURL    = "data_url"   // Read the canvas's pixels with toDataURL
+	CanvasScreenshot = "screenshot" // Screenshot the page clipped to the canvas's box
+)
+
+const (
+	// maxCanvases bounds the canvases one capture reports
+	maxCanvases = 20
+
+	// maxChartPoints bounds the labels and the points of each series read from a chart
+	maxChartPoints = 1000
+)
+
+// CanvasRequest selects canvases to capture and how
+type CanvasRequest struct {
+	Selector string // Canvases, or elements holding them; every canvas on the page when empty
+	SelectorEngine
+	Method  string // auto (default), data_url or screenshot
+	Format  string // png (default) or jpeg
+	Quality int    // JPEG quality, 0 to 100; the browser's default when zero
+}
+
+// CanvasCapture is one captured canvas
+type CanvasCapture struct {
+	Index    int        `json:"index"`
+	Selector string     `json:"selector"` // Canonical CSS selector
+	Width    int        `json:"width"`    // Drawing buffer size, in canvas pixels
+	Height   int        `json:"height"`
+	Box      *Box       `json:"box,omitempty"`   // Position in page CSS pixels, when rendered
+	Label    string     `json:"label,omitempty"` // aria-label, title or fallback content
+	Method   string     `json:"method,omitempty"`
+	MimeType string     `json:"mime_type,omitempty"`
+	Data     string     `json:"data,omitempty"`    // base64 encoded image
+	Size     int        `json:"size,omitempty"`    // Image bytes before encoding
+	Tainted  bool       `json:"tainted,omitempty"` // Drawn with cross-origin images, so its pixels can't be read
+	Blank    bool       `json:"blank,omitempty"`   // Its pixels read back empty, as WebGL canvases do unless the page keeps the drawing buffer
+	Error    string     `json:"error,omitempty"`   // Why nothing was captured
+	Chart    *ChartData `json:"chart,omitempty"`   // Data of the chart drawn on it, when a known chart library drew it
+}
+
+// ChartData is the data a charting library drew a canvas from, read from the library's
+// own objects rather than the pixels
+type ChartData struct {
+	Library   string        `json:"library"` // chart.js, echarts or plotly
+	Type      string        `json:"type,omitempty"`
+	Title     string        `json:"title,omitempty"`
+	Labels    []interface{} `json:"labels,omitempty"` // Category axis
+	Series    []ChartSeries `json:"series"`
+	Truncated bool          `json:"truncated,omitempty"` // Labels or series were cut at 1000 points
+}
+
+// ChartSeries is one series of a chart
+type ChartSeries struct {
+	Name string        `json:"name,omitempty"`
+	Type string        `json:"type,omitempty"`
+	Data []interface{} `json:"data"` // Numbers, or points such as {"x": 1, "y": 2}, as the library holds them
+}
+
+// validate rejects methods, formats and qualities that don't exist
+func (req *CanvasRequest) validate() error {
+	switch req.Method {
+	case "", CanvasAuto, CanvasDataURL, CanvasScreenshot:
+	default:
+		return fmt.Errorf("%w: unknown method %q (want auto, data_url or screenshot)", ErrInvalidCanvas, req.Method)
+	}
+	switch req.Format {
+	case "", "png", "jpeg":
+	default:
+		return fmt.Errorf("%w: format must be png or jpeg", ErrInvalidCanvas)
+	}
+	if req.Quality < 0 || req.Quality > 100 {
+		return fmt.Errorf("%w: quality must be between 0 and 100", ErrInvalidCanvas)
+	}
+	if req.Quality != 0 && req.Format != "jpeg" {
+		return fmt.Errorf("%w: quality needs the jpeg format", ErrInvalidCanvas)
+	}
+	return req.SelectorEngine.validate()
+}
+
+// canvasesOfJS declares canvasesOf(found): the canvases among or inside the elements
+// found, and how many elements were found
+const canvasesOfJS = `
+  function canvasesOf(found) {
+    var elements = found == null ? [] : (typeof found.length === 'number' ? Array.from(found) : [found]);
+    var canvases = [];
+    elements.forEach(function(el) {
+      var inside = el.tagName === 'CANVAS' ? [el] : Array.from(el.querySelectorAll('canvas'));
+      inside.forEach(function(canvas) { if (canvases.indexOf(canvas) === -1) canvases.push(canvas); });
+    });
+    return {matched: elements.length, canvases: canvases};
+  }
+`
+
+// canvasHelpersJS declares canvasesOf, selectorFor and chartOf(canvas), the data of a
+// chart drawn on a canvas. The chart libraries keep their data on their own objects,
+// found from the canvas or the container around it.
+const canvasHelpersJS = elementHelpersJS + canvasesOfJS + `
+  var truncated = false;
+  function plain(value) {
+    if (value === null || typeof value !== 'object') return typeof value === 'function' || value === undefined ? null : value;
+    if (Array.isArray(value)) return value.slice(0, 4).map(function(part) { return typeof part === 'object' ? null : part; });
+    var copy = {};
+    Object.keys(value).slice(0, 8).forEach(function(key) {
+      if (value[key] === null || typeof value[key] !== 'object' && typeof value[key] !== 'function') copy[key] = value[key];
+    });
+    return copy;
+  }
+  function points(values) {
+    if (!values || typeof values.length !== 'number') return [];
+    if (values.length > limit) truncated = true;
+    return Array.prototype.slice.call(values, 0, limit).map(plain);
+  }
+  function titleText(title) {
+    if (!title) return '';
+    if (Array.isArray(title)) title = title[0];
+    if (typeof title === 'object') title = title.text;
+    return Array.isArray(title) ? title.join(' ') : String(title || '');
+  }
+
+  function chartJS(canvas) {
+    var Chart = window.Chart, chart = null;
+    if (!Chart) return null;
+    if (typeof Chart.getChart === 'function') chart = Chart.getChart(canvas);
+    if (!chart && Chart.instances) {
+      Object.keys(Chart.instances).forEach(function(key) {
+        var instance = Chart.instances[key];
+        if ((instance.canvas || (instance.chart && instance.chart.canvas)) === canvas) chart = instance;
+      });
+    }
+    if (!chart || !chart.data) return null;
+    var options = chart.options || {}, config = chart.config || {};
+    return {
+      library: 'chart.js', type: String(config.type || (config._config && config._config.type) || ''),
+      title: titleText((options.plugins && options.plugins.title) || options.title),
+      labels: points(chart.data.labels),
+      series: (chart.data.datasets || []).map(function(dataset) {
+        return {name: String(dataset.label || ''), type: String(dataset.type || ''), data: points(dataset.data)};
+      })
+    };
+  }
+
+  function echartsOf(node) {
+    if (!window.echarts || typeof echarts.getInstanceByDom !== 'function') return null;
+    var instance = echarts.getInstanceByDom(node);
+    if (!instance) return null;
+    var option = instance.getOption() || {}, axis = [].concat(option.xAxis || [], option.yAxis || []).filter(function(a) { return a && a.data; })[0];
+    return {
+      library: 'echarts', title: titleText(option.title),
+      labels: axis ? points(axis.data) : [],
+      series: [].concat(option.series || []).map(function(series) {
+        return {name: String(series.name || ''), type: String(series.type || ''), data: points(series.data)};
+      })
+    };
+  }
+
+  function plotlyOf(node) {
+    if (!node.classList || !node.classList.contains('js-plotly-plot') || !Array.isArray(node.data)) return null;
+    return {
+      library: 'plotly', title: titleText(node.layout && node.layout.title),
+      series: node.data.map(function(trace) {
+        var xs = trace.x || [], ys = trace.y || [], data = [];
+        for (var i = 0; i < ys.length; i++) data.push(trace.x ? {x: xs[i], y: ys[i]} : ys[i]);
+        return {name: String(trace.name || ''), type: String(trace.type || ''), data: points(data)};
+      })
+    };
+  }
+
+  function chartOf(canvas) {
+    try {
+      var chart = chartJS(canvas);
+      for (var node = canvas.parentElement, depth = 0; !chart && node && depth < 5; node = node.parentElement, depth++) {
+        chart = echartsOf(node) || plotlyOf(node);
+      }
+      if (chart && truncated) chart.truncated = true;
+      truncated = false;
+      return chart;
+    } catch (e) {
+      return null;
+    }
+  }
+`
+
+// canvasListJS describes the canvases found, up to a limit, with the data of any chart
+// drawn on them
+const canvasListJS = `(function(found, limit, max) {` + canvasHelpersJS + `
+  var result = canvasesOf(found);
+  return {
+    matched: result.matched,
+    total: result.canvases.length,
+    canvases: result.canvases.slice(0, max).map(function(canvas, index) {
+      var rect = canvas.getBoundingClientRect(), style = getComputedStyle(canvas), box = null;
+      if (rect.width > 0 && rect.height > 0 && style.visibility !== 'hidden') {
+        box = {x: rect.x + scrollX, y: rect.y + scrollY, width: rect.width, height: rect.height};
+      }
+      var label = canvas.getAttribute('aria-label') || canvas.title || canvas.textContent || '';
+      label = label.replace(/\s+/g, ' ').trim();
+      return {
+        index: index, selector: selectorFor(canvas), width: canvas.width, height: canvas.height, box: box,
+        label: label.length > 200 ? label.slice(0, 200) : label, chart: chartOf(canvas)
+      };
+    })
+  };
+})(%s, %d, %d)`
+
+// canvasDataJS reads one canvas's pixels as a data URL, comparing them to an empty
+// canvas of the same size so a WebGL buffer the browser already cleared shows as blank
+const canvasDataJS = `(function(found, index, type, quality) {` + canvasesOfJS + `
+  var canvas = canvasesOf(found).canvases[index];
+  if (!canvas) return {missing: true};
+  var data;
+  try {
+    data = canvas.toDataURL(type, quality);
+  } catch (e) {
+    return {tainted: true};
+  }
+  if (data === 'data:,') return {blank: true};
+  var empty = document.createElement('canvas');
+  empty.width = canvas.width;
+  empty.height = canvas.height;
+  return {data_url: data, blank: data === empty.toDataURL(type, quality)};
+})(%s, %d, %s, %s)`
+
+// canvasData is what canvasDataJS reports
+type canvasData struct {
+	DataURL string `json:"data_url"`
+	Missing bool   `json:"missing"`
+	Tainted bool   `json:"tainted"`
+	Blank   bool   `json:"blank"`
+}
+
+// captureCanvases captures the canvases req selects, up to maxCanvases. Each image is
+// counted against the response size limit; canvases past it are reported without one.
+func (s *Session) captureCanvases(ctx context.Context, targetID string, req CanvasRequest, maxBytes int64) ([]CanvasCapture, int, error) {
+	selector, engine := req.Selector, req.SelectorEngine
+	if selector == "" {
+		selector, engine = "canvas", SelectorEngine{}
+	}
+	found := engine.all(selector)
+
+	value, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(canvasListJS, found, maxChartPoints, maxCanvases))
+	if err != nil {
+		var scriptErr *ScriptError
+		if errors.As(err, &scriptErr) && req.Selector != "" {
+			return nil, 0, fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+		}
+		return nil, 0, fmt.Errorf("failed to find canvases: %w", err)
+	}
+	encoded, _ := json.Marshal(value)
+	var listed struct {
+		Matched  int             `json:"matched"`
+		Total    int             `json:"total"`
+		Canvases []CanvasCapture `json:"canvases"`
+	}
+	if err := json.Unmarshal(encoded, &listed); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse canvases: %w", err)
+	}
+	if req.Selector != "" && listed.Matched == 0 {
+		return nil, 0, fmt.Errorf("%w: %s", ErrElementNotFound, req.SelectorEngine.describe(req.Selector))
+	}
+
+	format := req.Format
+	if format == "" {
+		format = "png"
+	}
+	method := req.Method
+	if method == "" {
+		method = CanvasAuto
+	}
+
+	remaining := maxBytes
+	for i := range listed.Canvases {
+		canvas := &listed.Canvases[i]
+		image, err := s.captureCanvas(ctx, targetID, canvas, found, method, format, req.Quality)
+		if err != nil {
+			canvas.Error = err.Error()
+			continue
+		}
+		if int64(len(image)) > remaining {
+			canvas.Method = ""
+			canvas.Error = fmt.Sprintf("%d byte image left out: the response would pass %d bytes", len(image), maxBytes)
+			continue
+		}
+		remaining -= int64(len(image))
+		canvas.MimeType = "image/" + format
+		canvas.Data = base64.StdEncoding.EncodeToString(image)
+		canvas.Size = len(image)
+	}
+	return listed.Canvases, listed.Total, nil
+}
+
+// captureCanvas captures one canvas by method, setting how it was captured and what
+// reading its pixels found
+func (s *Session) captureCanvas(ctx context.Context, targetID string, canvas *CanvasCapture, found string, method string, format string, quality int) ([]byte, error) {
+	if method != CanvasScreenshot {
+		qualityJSON := "undefined"
+		if quality != 0 {
+			qualityJSON = fmt.Sprint(float64(quality) / 100)
+		}
+		value, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(canvasDataJS, found, canvas.Index, `"image/`+format+`"`, qualityJSON))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read canvas: %w", err)
+		}
+		encoded, _ := json.Marshal(value)
+		var data canvasData
+		if err := json.Unmarshal(encoded, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse canvas data: %w", err)
+		}
+		if data.Missing {
+			return nil, errors.New("the canvas left the page while it was captured")
+		}
+		canvas.Tainted, canvas.Blank = data.Tainted, data.Blank
+
+		usable := !data.Tainted && !data.Blank
+		if usable || method == CanvasDataURL {
+			if data.DataURL == "" {
+				return nil, errors.New("the canvas's pixels can't be read: it holds cross-origin images")
+			}
+			comma := strings.IndexByte(data.DataURL, ',')
+			image, err := base64.StdEncoding.DecodeString(data.DataURL[comma+1:])
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode canvas data: %w", err)
+			}
+			canvas.Method = CanvasDataURL
+			return image, nil
+		}
+	}
+
+	if canvas.Box == nil {
+		return nil, errors.New("the canvas isn't rendered, so it can't be screenshotted")
+	}
+	params := map[string]interface{}{
+		"format":                format,
+		"captureBeyondViewport": true,
+		"clip": map[string]interface{}{
+			"x": canvas.Box.X, "y": canvas.Box.Y, "width": canvas.Box.Width, "height": canvas.Box.Height, "scale": 1,
+		},
+	}
+	if quality != 0 {
+		params["quality"] = quality
+	}
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.captureScreenshot", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+	var response struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse screenshot response: %w", err)
+	}
+	image, err := base64.StdEncoding.DecodeString(response.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	canvas.Method = CanvasScreenshot
+	return image, nil
+}
+
+// CaptureCanvases captures the contents of a page's canvases, which DOM extraction can't
+// see, and reads the data of charts drawn on them by Chart.js, ECharts or Plotly. It
+// returns the canvases captured and how many were found in all.
+func (m *Manager) CaptureCanvases(ctx context.Context, sessionID string, pageID string, req CanvasRequest) ([]CanvasCapture, int, error) {
+	if err := req.validate(); err != nil {
+		return nil, 0, err
+	}
+
+	session, err := m.devtoolsSession(sessionID, pageID, "canvas capture")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	canvases, total, err := session.captureCanvases(ctx, pageID, req, m.maxResponseSize())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	session.UpdateActivity()
+	return canvases, total, nil
+}
@@ -0,0 +1,112 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestCaptureCanvases tests reading canvases' pixels, falling back to a clipped
+// screenshot for those that can't be read, and the chart data found on them
+func TestCaptureCanvases(t *testing.T) {
+	var mu sync.Mutex
+	var clips []map[string]interface{}
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string                 `json:"expression"`
+			Clip       map[string]interface{} `json:"clip"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Page.captureScreenshot":
+			mu.Lock()
+			clips = append(clips, p.Clip)
+			mu.Unlock()
+			return map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("shot"))}
+		case "Runtime.evaluate":
+			switch {
+			case strings.Contains(p.Expression, `"#missing"`):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{
+					"matched": 0, "total": 0, "canvases": []interface{}{},
+				}}}
+			case strings.Contains(p.Expression, "chartOf(canvas)"):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{
+					"matched": 2, "total": 2, "canvases": []interface{}{
+						map[string]interface{}{"index": 0, "selector": "#sales", "width": 600, "height": 300,
+							"box": map[string]interface{}{"x": 10, "y": 120, "width": 300, "height": 150},
+							"chart": map[string]interface{}{"library": "chart.js", "type": "bar", "labels": []interface{}{"Jan", "Feb"},
+								"series": []interface{}{map[string]interface{}{"name": "2025", "data": []interface{}{4, 7}}}}},
+						map[string]interface{}{"index": 1, "selector": "#map", "width": 800, "height": 400,
+							"box": map[string]interface{}{"x": 0, "y": 400, "width": 800, "height": 400}},
+					},
+				}}}
+			case strings.Contains(p.Expression, "toDataURL"):
+				// The chart reads back; the WebGL map's buffer is already cleared
+				if strings.Contains(p.Expression, `"canvas"), 1, `) {
+					return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{
+						"data_url": "data:image/png;base64,AAAA", "blank": true,
+					}}}
+				}
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{
+					"data_url": "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("pixels")), "blank": false,
+				}}}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://dashboards.example.com")
+
+	canvases, total, err := manager.CaptureCanvases(ctx, sess.ID, pageID, CanvasRequest{})
+	if err != nil {
+		t.Fatalf("CaptureCanvases failed: %v", err)
+	}
+	if total != 2 || len(canvases) != 2 {
+		t.Fatalf("expected 2 canvases, got %d of %d", len(canvases), total)
+	}
+
+	sales, chart := canvases[0], canvases[0].Chart
+	if sales.Method != CanvasDataURL || sales.MimeType != "image/png" || sales.Size != len("pixels") || sales.Data != base64.StdEncoding.EncodeToString([]byte("pixels")) {
+		t.Errorf("expected the chart read from its pixels, got %+v", sales)
+	}
+	if chart == nil || chart.Library != "chart.js" || len(chart.Labels) != 2 || len(chart.Series) != 1 || chart.Series[0].Data[1] != float64(7) {
+		t.Errorf("unexpected chart data: %+v", chart)
+	}
+
+	webgl := canvases[1]
+	if !webgl.Blank || webgl.Method != CanvasScreenshot || webgl.Size != len("shot") {
+		t.Errorf("expected the blank canvas screenshotted, got %+v", webgl)
+	}
+	mu.Lock()
+	if len(clips) != 1 || clips[0]["y"] != float64(400) || clips[0]["width"] != float64(800) {
+		t.Errorf("expected one screenshot clipped to the canvas, got %v", clips)
+	}
+	mu.Unlock()
+
+	// A limit too small for the images leaves them out, and says so
+	manager.SetMaxResponseSize(4)
+	canvases, _, err = manager.CaptureCanvases(ctx, sess.ID, pageID, CanvasRequest{Method: CanvasDataURL})
+	if err != nil {
+		t.Fatalf("CaptureCanvases failed: %v", err)
+	}
+	if canvases[0].Data != "" || !strings.Contains(canvases[0].Error, "left out") {
+		t.Errorf("expected the image left out, got %+v", canvases[0])
+	}
+
+	if _, _, err := manager.CaptureCanvases(ctx, sess.ID, pageID, CanvasRequest{Selector: "#missing"}); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected ErrElementNotFound, got %v", err)
+	}
+	for _, req := range []CanvasRequest{{Method: "webgl"}, {Format: "gif"}, {Format: "png", Quality: 80}, {Format: "jpeg", Quality: 101}} {
+		if _, _, err := manager.CaptureCanvases(ctx, sess.ID, pageID, req); !errors.Is(err, ErrInvalidCanvas) {
+			t.Errorf("expected ErrInvalidCanvas for %+v, got %v", req, err)
+		}
+	}
+}
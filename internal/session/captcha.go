@@ -0,0 +1,188 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultCaptchaSolveTimeout bounds how long an external solver may take
+	DefaultCaptchaSolveTimeout = 2 * time.Minute
+
+	// DefaultManualCaptchaTimeout is how long a human gets to clear a CAPTCHA
+	DefaultManualCaptchaTimeout = 5 * time.Minute
+
+	// CaptchaMethodManual identifies the human takeover flow in results and events
+	CaptchaMethodManual = "manual"
+
+	// manualCaptchaPollInterval is how often the page is re-checked during takeover
+	manualCaptchaPollInterval = time.Second
+)
+
+// CaptchaInfo describes a CAPTCHA challenge detected on a page
+type CaptchaInfo struct {
+	Detected   bool      `json:"detected"`
+	Provider   string    `json:"provider,omitempty"` // recaptcha, hcaptcha, turnstile, cloudflare_challenge, arkose
+	SiteKey    string    `json:"site_key,omitempty"`
+	URL        string    `json:"url"`
+	Signals    []string  `json:"signals,omitempty"` // Which markers matched (iframe, widget, interstitial)
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// CaptchaInjection reports how a solver token was applied to the page
+type CaptchaInjection struct {
+	Fields   int  `json:"fields"`   // Response fields that received the token
+	Callback bool `json:"callback"` // Whether the widget's data-callback was invoked
+}
+
+// CaptchaSolveResult reports the outcome of a solve attempt
+type CaptchaSolveResult struct {
+	Solved    bool              `json:"solved"`
+	Method    string            `json:"method"` // Solver name, or "manual" for human takeover
+	Provider  string            `json:"provider"`
+	Injection *CaptchaInjection `json:"injection,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Duration  string            `json:"duration"`
+}
+
+// captchaDetectJS looks for the iframes, widget containers and interstitials of common CAPTCHA providers.
+// Invisible reCAPTCHA v3 badges are ignored because they do not block the page.
+const captchaDetectJS = `(function() {
+  var signals = [], provider = '', siteKey = '';
+  function found(p, signal) { signals.push(signal); if (!provider) provider = p; }
+  function keyFromSrc(src, param) {
+    var match = new RegExp('[?&#]' + param + '=([^&]+)').exec(src || '');
+    return match ? decodeURIComponent(match[1]) : '';
+  }
+
+  var frames = Array.from(document.querySelectorAll('iframe'));
+  frames.forEach(function(f) {
+    var src = f.src || '';
+    if (/(google\.com|recaptcha\.net)\/recaptcha\//.test(src) && src.indexOf('size=invisible') === -1) {
+      found('recaptcha', 'recaptcha_iframe');
+      siteKey = siteKey || keyFromSrc(src, 'k');
+    } else if (/hcaptcha\.com/.test(src)) {
+      found('hcaptcha', 'hcaptcha_iframe');
+      siteKey = siteKey || keyFromSrc(src, 'sitekey');
+    } else if (/challenges\.cloudflare\.com/.test(src)) {
+      found('turnstile', 'turnstile_iframe');
+    } else if (/(arkoselabs|funcaptcha)\.com/.test(src)) {
+      found('arkose', 'arkose_iframe');
+    }
+  });
+
+  var widgets = [
+    ['.g-recaptcha', 'recaptcha'],
+    ['.h-captcha', 'hcaptcha'],
+    ['.cf-turnstile', 'turnstile']
+  ];
+  widgets.forEach(function(w) {
+    var el = document.querySelector(w[0]);
+    if (el && el.getAttribute('data-size') !== 'invisible') {
+      found(w[1], w[1] + '_widget');
+      siteKey = siteKey || el.getAttribute('data-sitekey') || '';
+    }
+  });
+
+  if (document.querySelector('#challenge-form, #cf-challenge-running, #challenge-running') ||
+      /^just a moment/i.test(document.title)) {
+    found('cloudflare_challenge', 'cloudflare_interstitial');
+  }
+
+  return { detected: signals.length > 0, provider: provider, site_key: siteKey, url: location.href, signals: signals };
+})()`
+
+// captchaInjectJS writes a solver token into the provider's response fields and
+// fires the widget callback so the page continues as if a human had solved it.
+const captchaInjectJS = `(function(provider, token) {
+  var names = {
+    recaptcha: ['g-recaptcha-response'],
+    hcaptcha: ['h-captcha-response', 'g-recaptcha-response'],
+    turnstile: ['cf-turnstile-response']
+  }[provider] || [];
+
+  var fields = 0;
+  names.forEach(function(name) {
+    document.querySelectorAll('textarea[name="' + name + '"], input[name="' + name + '"], #' + name).forEach(function(el) {
+      el.value = token;
+      el.dispatchEvent(new Event('change', { bubbles: true }));
+      fields++;
+    });
+  });
+
+  var callback = false;
+  var widget = document.querySelector('[data-sitekey][data-callback]');
+  if (widget) {
+    var fn = window[widget.getAttribute('data-callback')];
+    if (typeof fn === 'function') { fn(token); callback = true; }
+  }
+
+  return { fields: fields, callback: callback };
+})(%s, %s)`
+
+// DetectCaptcha checks the page for a blocking CAPTCHA
+func (s *Session) DetectCaptcha(ctx context.Context, targetID string) (*CaptchaInfo, error) {
+	result, err := s.ExecuteJavascript(ctx, targetID, captchaDetectJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect captcha: %w", err)
+	}
+
+	rawJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal captcha result: %w", err)
+	}
+
+	var info CaptchaInfo
+	if err := json.Unmarshal(rawJSON, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse captcha result: %w", err)
+	}
+	info.DetectedAt = time.Now()
+
+	// Remember the latest verdict for this page
+	s.mu.Lock()
+	if s.captchaState == nil {
+		s.captchaState = make(map[string]*CaptchaInfo)
+	}
+	s.captchaState[targetID] = &info
+	s.mu.Unlock()
+
+	return &info, nil
+}
+
+// LastCaptcha returns the most recent detection result for a page, if any
+func (s *Session) LastCaptcha(targetID string) *CaptchaInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.captchaState[targetID]
+}
+
+// InjectCaptchaToken applies a solver token for the given provider
+func (s *Session) InjectCaptchaToken(ctx context.Context, targetID string, provider string, token string) (*CaptchaInjection, error) {
+	providerJSON, err := json.Marshal(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal provider: %w", err)
+	}
+	tokenJSON, err := json.Marshal(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	result, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(captchaInjectJS, providerJSON, tokenJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inject captcha token: %w", err)
+	}
+
+	rawJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal injection result: %w", err)
+	}
+
+	var injection CaptchaInjection
+	if err := json.Unmarshal(rawJSON, &injection); err != nil {
+		return nil, fmt.Errorf("failed to parse injection result: %w", err)
+	}
+
+	return &injection, nil
+}
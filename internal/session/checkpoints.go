@@ -0,0 +1,324 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/storage"
+)
+
+// MaxCheckpoints is how many checkpoints a session may hold at once; each keeps a copy
+// of the session's cookies and web storage in memory
+const MaxCheckpoints = 10
+
+// Checkpoint is a saved copy of a session's state that new sessions can branch from.
+// It holds the cookies of the session's context and, for each page, its URL and the
+// localStorage and sessionStorage of its origin.
+type Checkpoint struct {
+	ID        string    `json:"checkpoint_id"` // Unique checkpoint identifier (ckpt_ prefix)
+	SessionID string    `json:"session_id"`    // Session it was taken from
+	Label     string    `json:"label,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	URLs      []string  `json:"urls"`    // Pages open when it was taken, in order
+	Cookies   int       `json:"cookies"` // Cookies captured
+
+	cookies []storage.Cookie
+	pages   []checkpointPage
+}
+
+// checkpointPage is one page of a checkpoint
+type checkpointPage struct {
+	url     string
+	storage pageStorage
+}
+
+// pageStorage is the web storage of a page's origin, as storageCaptureJS reads it
+type pageStorage struct {
+	Origin  string      `json:"origin"`
+	Local   [][2]string `json:"local"`
+	Session [][2]string `json:"session"`
+}
+
+// storageCaptureJS reads a page's localStorage and sessionStorage. Either may be denied,
+// to sandboxed frames for one, which leaves it empty.
+const storageCaptureJS = `(() => {
+	const read = (name) => { try { return Object.entries(window[name]); } catch (e) { return []; } };
+	return JSON.stringify({origin: location.origin, local: read("localStorage"), session: read("sessionStorage")});
+})()`
+
+// storageSeedJS writes captured storage back before the page's own scripts run. It is
+// formatted with the origin and the storage as JSON.
+const storageSeedJS = `(() => {
+	if (location.origin !== %s) return;
+	const state = %s;
+	const write = (name, entries) => { try { for (const [k, v] of entries) window[name].setItem(k, v); } catch (e) {} };
+	write("localStorage", state.local);
+	write("sessionStorage", state.session);
+})()`
+
+// generateCheckpointID creates a unique checkpoint identifier
+func generateCheckpointID() (string, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate checkpoint ID: %w", err)
+	}
+	return "ckpt_" + base64.URLEncoding.EncodeToString(randomBytes), nil
+}
+
+// seedScript returns the script that restores this storage, or "" when there is none
+func (p pageStorage) seedScript() string {
+	if p.Origin == "" || p.Origin == "null" || len(p.Local)+len(p.Session) == 0 {
+		return ""
+	}
+	origin, _ := json.Marshal(p.Origin)
+	state, _ := json.Marshal(p)
+	return fmt.Sprintf(storageSeedJS, origin, state)
+}
+
+// CreateCheckpoint saves the current cookies, page URLs and web storage of a session.
+// Pages keep running; a checkpoint is a copy, not a pause.
+func (m *Manager) CreateCheckpoint(ctx context.Context, sessionID, label string) (*Checkpoint, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.bidi != nil {
+		return nil, fmt.Errorf("%w: checkpoints", ErrEngineUnsupported)
+	}
+
+	session.mu.RLock()
+	full := len(session.checkpoints) >= MaxCheckpoints
+	session.mu.RUnlock()
+	if full {
+		return nil, fmt.Errorf("%w: session holds %d", ErrCheckpointLimit, MaxCheckpoints)
+	}
+
+	id, err := generateCheckpointID()
+	if err != nil {
+		return nil, err
+	}
+
+	cookies, err := session.getContextCookies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture cookies: %w", err)
+	}
+
+	checkpoint := &Checkpoint{
+		ID:        id,
+		SessionID: sessionID,
+		Label:     label,
+		CreatedAt: time.Now(),
+		URLs:      []string{},
+		Cookies:   len(cookies),
+		cookies:   cookies,
+	}
+	for _, pageID := range session.Pages() {
+		url, err := session.GetCurrentURL(ctx, pageID)
+		if err != nil {
+			return nil, err
+		}
+		page := checkpointPage{url: url}
+
+		result, err := session.ExecuteJavascript(ctx, pageID, storageCaptureJS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture web storage: %w", err)
+		}
+		if raw, ok := result.(string); ok {
+			if err := json.Unmarshal([]byte(raw), &page.storage); err != nil {
+				return nil, fmt.Errorf("failed to parse web storage: %w", err)
+			}
+		}
+
+		checkpoint.pages = append(checkpoint.pages, page)
+		checkpoint.URLs = append(checkpoint.URLs, url)
+	}
+
+	session.mu.Lock()
+	if session.checkpoints == nil {
+		session.checkpoints = make(map[string]*Checkpoint)
+	}
+	session.checkpoints[id] = checkpoint
+	session.mu.Unlock()
+	session.UpdateActivity()
+
+	slog.InfoContext(ctx, "checkpoint created",
+		"session_id", sessionID,
+		"checkpoint_id", id,
+		"pages", len(checkpoint.pages),
+		"cookies", len(cookies))
+	return checkpoint, nil
+}
+
+// Checkpoint returns one of the session's checkpoints
+func (s *Session) Checkpoint(checkpointID string) (*Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	checkpoint, exists := s.checkpoints[checkpointID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCheckpointNotFound, checkpointID)
+	}
+	return checkpoint, nil
+}
+
+// Checkpoints returns the session's checkpoints, oldest first
+func (s *Session) Checkpoints() []*Checkpoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	checkpoints := make([]*Checkpoint, 0, len(s.checkpoints))
+	for _, checkpoint := range s.checkpoints {
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.Before(checkpoints[j].CreatedAt)
+	})
+	return checkpoints
+}
+
+// DeleteCheckpoint drops a checkpoint of a session
+func (m *Manager) DeleteCheckpoint(sessionID, checkpointID string) error {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	session.mu.Lock()
+	_, exists := session.checkpoints[checkpointID]
+	delete(session.checkpoints, checkpointID)
+	session.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrCheckpointNotFound, checkpointID)
+	}
+	slog.Info("checkpoint deleted", "session_id", sessionID, "checkpoint_id", checkpointID)
+	return nil
+}
+
+// BranchSession creates a session on port from a checkpoint: same tenant, agent and
+// options, the checkpoint's cookies, and its pages reopened with their web storage. A
+// profile isn't carried over, since only one session may hold it; the cookies are.
+// The new session is destroyed again if its state can't be restored.
+func (m *Manager) BranchSession(ctx context.Context, sessionID, checkpointID, name string, port int) (*Session, error) {
+	source, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	checkpoint, err := source.Checkpoint(checkpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := BranchOptions(source.Options)
+	branch, err := m.CreateSessionWithOptions(ctx, source.TenantID, source.AgentID, name, port, source.Template, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := false
+	defer func() {
+		if !restored {
+			if err := m.DestroySession(branch.ID); err != nil {
+				slog.Warn("failed to destroy branch after restore error", "session_id", branch.ID, "error", err)
+			}
+		}
+	}()
+
+	// Set after the template's seed cookies, which the checkpoint's replace
+	if len(checkpoint.cookies) > 0 {
+		if err := branch.setContextCookies(ctx, checkpoint.cookies); err != nil {
+			return nil, fmt.Errorf("failed to restore cookies: %w", err)
+		}
+	}
+
+	for _, page := range checkpoint.pages {
+		pageID, err := branch.openSeededPage(ctx, page.url, page.storage.seedScript())
+		if err != nil {
+			return nil, fmt.Errorf("failed to reopen %s: %w", page.url, err)
+		}
+		branch.AddPage(pageID)
+		m.trackRoutes(ctx, branch, pageID)
+	}
+	restored = true
+	source.UpdateActivity()
+
+	m.publishEvent(branch.ID, "", events.TypeSessionBranched, map[string]interface{}{
+		"from_session":  sessionID,
+		"checkpoint_id": checkpointID,
+		"pages":         branch.Pages(),
+	})
+	slog.InfoContext(ctx, "session branched",
+		"session_id", branch.ID,
+		"from_session", sessionID,
+		"checkpoint_id", checkpointID,
+		"pages", len(checkpoint.pages))
+	return branch, nil
+}
+
+// BranchOptions returns the options a branch of a session with opts is created with:
+// the same, without a profile
+func BranchOptions(opts *SessionOptions) *SessionOptions {
+	if opts == nil || opts.Profile == "" {
+		return opts
+	}
+	branched := *opts
+	branched.Profile = ""
+	return &branched
+}
+
+// openSeededPage opens a page like openPage, running seed in it before the first
+// document's own scripts. The seed is removed once the page has loaded, so reloads
+// don't repeat it.
+func (s *Session) openSeededPage(ctx context.Context, url, seed string) (string, error) {
+	if seed == "" {
+		return s.openPage(ctx, url)
+	}
+
+	pageID := s.takeWarmPage()
+	if pageID == "" {
+		var err error
+		if pageID, err = s.CDPClient.CreateTarget(ctx, "about:blank", s.targetContextID()); err != nil {
+			return "", fmt.Errorf("failed to create target: %w", err)
+		}
+		if err := s.setupPage(ctx, pageID); err != nil {
+			s.closeFailedPage(ctx, pageID)
+			return "", fmt.Errorf("failed to set up page: %w", err)
+		}
+	}
+	if err := s.startNetworkCapture(ctx, pageID); err != nil {
+		s.closeFailedPage(ctx, pageID)
+		return "", err
+	}
+
+	result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "Page.addScriptToEvaluateOnNewDocument", map[string]interface{}{"source": seed})
+	if err != nil {
+		s.closeFailedPage(ctx, pageID)
+		return "", fmt.Errorf("failed to add storage script: %w", err)
+	}
+	var script struct {
+		Identifier string `json:"identifier"`
+	}
+	json.Unmarshal(result, &script)
+
+	if _, err := s.navigatePage(ctx, pageID, url); err != nil {
+		return "", err
+	}
+
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "Page.removeScriptToEvaluateOnNewDocument", map[string]interface{}{"identifier": script.Identifier}); err != nil {
+		slog.WarnContext(ctx, "failed to remove storage script", "page_id", pageID, "error", err)
+	}
+	return pageID, nil
+}
+
+// closeFailedPage closes a page that couldn't be prepared
+func (s *Session) closeFailedPage(ctx context.Context, pageID string) {
+	if err := s.CDPClient.CloseTarget(ctx, pageID); err != nil {
+		slog.WarnContext(ctx, "failed to close page after setup error", "page_id", pageID, "error", err)
+	}
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestCheckpointBranch tests that a branch gets the checkpoint's cookies and reopens its
+// pages with their web storage seeded before the page loads
+func TestCheckpointBranch(t *testing.T) {
+	var mu sync.Mutex
+	var methods, seeds, navigated, cookieContexts []string
+	targets := 0
+
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			URL              string `json:"url"`
+			Expression       string `json:"expression"`
+			Source           string `json:"source"`
+			BrowserContextID string `json:"browserContextId"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		methods = append(methods, method)
+		switch method {
+		case "Target.createTarget":
+			targets++
+			return map[string]interface{}{"targetId": fmt.Sprintf("page-%d", targets)}
+		case "Page.navigate":
+			navigated = append(navigated, p.URL)
+		case "Page.addScriptToEvaluateOnNewDocument":
+			// Route tracking adds a script to every page too
+			if strings.Contains(p.Source, "localStorage") {
+				seeds = append(seeds, p.Source)
+			}
+			return map[string]interface{}{"identifier": "seed-1"}
+		case "Storage.getCookies":
+			return map[string]interface{}{"cookies": []map[string]interface{}{
+				{"name": "sid", "value": "42", "domain": "app.example.com", "path": "/"},
+			}}
+		case "Storage.setCookies":
+			cookieContexts = append(cookieContexts, p.BrowserContextID)
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			switch {
+			case p.Expression == "location.href":
+				value = "https://app.example.com/cart"
+			case p.Expression == storageCaptureJS:
+				value = `{"origin": "https://app.example.com", "local": [["token", "abc"]], "session": [["step", "2"]]}`
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	source, err := manager.CreateSessionWithOptions(ctx, "acme", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	if _, err := manager.Navigate(ctx, source.ID, "https://app.example.com/login"); err != nil {
+		t.Fatalf("Navigate failed: %v", err)
+	}
+
+	checkpoint, err := manager.CreateCheckpoint(ctx, source.ID, "logged-in")
+	if err != nil {
+		t.Fatalf("CreateCheckpoint failed: %v", err)
+	}
+	if checkpoint.Cookies != 1 || len(checkpoint.URLs) != 1 || checkpoint.URLs[0] != "https://app.example.com/cart" {
+		t.Errorf("unexpected checkpoint: %+v", checkpoint)
+	}
+
+	branch, err := manager.BranchSession(ctx, source.ID, checkpoint.ID, "", 9222)
+	if err != nil {
+		t.Fatalf("BranchSession failed: %v", err)
+	}
+	if branch.ID == source.ID || branch.TenantID != "acme" || branch.AgentID != "agent-1" || len(branch.Pages()) != 1 {
+		t.Errorf("unexpected branch: %s of %s/%s with pages %v", branch.ID, branch.TenantID, branch.AgentID, branch.Pages())
+	}
+
+	mu.Lock()
+	if len(cookieContexts) != 1 || cookieContexts[0] != branch.ContextID {
+		t.Errorf("expected cookies set in the branch's context %s, got %v", branch.ContextID, cookieContexts)
+	}
+	if len(seeds) != 1 || !strings.Contains(seeds[0], `"https://app.example.com"`) || !strings.Contains(seeds[0], `["token","abc"]`) {
+		t.Errorf("expected a storage seed for the page's origin, got %v", seeds)
+	}
+	if navigated[len(navigated)-1] != "https://app.example.com/cart" {
+		t.Errorf("expected the branch to open the checkpoint's URL, got %v", navigated)
+	}
+	if !slices.Contains(methods, "Page.removeScriptToEvaluateOnNewDocument") {
+		t.Error("expected the seed to be removed after loading")
+	}
+	mu.Unlock()
+
+	if err := manager.DeleteCheckpoint(source.ID, checkpoint.ID); err != nil {
+		t.Fatalf("DeleteCheckpoint failed: %v", err)
+	}
+	if _, err := manager.BranchSession(ctx, source.ID, checkpoint.ID, "", 9222); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("expected ErrCheckpointNotFound after deletion, got %v", err)
+	}
+}
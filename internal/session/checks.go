@@ -0,0 +1,645 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/seeds"
+)
+
+const (
+	// DefaultCheckInterval is how often a check runs when it sets no interval
+	DefaultCheckInterval = 5 * time.Minute
+
+	// MinCheckInterval keeps checks from hammering the sites they watch, and the pool
+	MinCheckInterval = 30 * time.Second
+
+	// CheckHistorySize is how many runs of a check are kept; uptime and latency are
+	// computed over them
+	CheckHistorySize = 100
+
+	// checkSchedulerTick is how often the scheduler looks for checks that are due
+	checkSchedulerTick = time.Second
+
+	// checkAlertTimeout bounds one alert delivery
+	checkAlertTimeout = 30 * time.Second
+
+	// DefaultSeedTargets is how many of a seed's changed pages one run covers when the
+	// seed sets no limit
+	DefaultSeedTargets = 10
+
+	// MaxSeedTargets bounds the pages one run covers, which share its session
+	MaxSeedTargets = 100
+)
+
+// Check states in a CheckReport
+const (
+	CheckPending = "pending" // Not run yet
+	CheckPassing = "passing"
+	CheckFailing = "failing"
+	CheckPaused  = "paused"
+
+	CheckRecovered = "recovered" // Sent in alerts when a failing check passes again
+)
+
+// Check is a named flow run on a schedule, each time in a new session that is
+// destroyed afterwards. A run that doesn't pass, whether a step failed or the session
+// couldn't be created, counts as down.
+type Check struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Steps       []RunStep       `json:"steps"`
+	Seed        *CheckSeed      `json:"seed,omitempty"`
+	Template    string          `json:"template,omitempty"` // Session template each run's session is created from
+	Options     *SessionOptions `json:"options,omitempty"`  // Layered on top of the template
+	IntervalMS  int             `json:"interval_ms,omitempty"`
+	Alert       *CheckAlert     `json:"alert,omitempty"`
+	Paused      bool            `json:"paused,omitempty"`    // Kept, but only run on request
+	TenantID    string          `json:"tenant_id,omitempty"` // Tenant the sessions are created for
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// CheckSeed makes a check run on the pages of a sitemap or RSS or Atom feed. Each run
+// loads the pages added or modified since they last passed, newest first, and runs the
+// steps on each; a page that fails is retried on the next run.
+type CheckSeed struct {
+	URL        string `json:"url"`
+	MaxTargets int    `json:"max_targets,omitempty"` // Pages per run (default DefaultSeedTargets)
+}
+
+// CheckAlert is where a check reports that it started failing and that it recovered
+type CheckAlert struct {
+	URL string `json:"url"`
+
+	// Header values may reference environment variables ($TOKEN or ${TOKEN}), expanded
+	// when sending, so secrets stay out of the check
+	Headers map[string]string `json:"headers,omitempty"`
+
+	After int `json:"after,omitempty"` // Consecutive failed runs before alerting (default 1)
+}
+
+// CheckRun is one run of a check
+type CheckRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	Success    bool      `json:"success"`
+	DurationMS int64     `json:"duration_ms"`
+	FailedStep *int      `json:"failed_step,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Screenshot bool      `json:"screenshot,omitempty"` // A screenshot of the failure was taken
+
+	// Seeded checks only
+	Targets   int    `json:"targets,omitempty"`    // Changed pages the run covered
+	Remaining int    `json:"remaining,omitempty"`  // Changed pages left for later runs
+	FailedURL string `json:"failed_url,omitempty"` // First page the steps failed on
+}
+
+// CheckReport is a check with how it has been doing
+type CheckReport struct {
+	*Check
+	State               string     `json:"state"`
+	Runs                int        `json:"runs"`     // Since the check was saved or the server started
+	Failures            int        `json:"failures"` // Failed runs among them
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Uptime              *float64   `json:"uptime,omitempty"`         // Share of the kept runs that passed
+	AvgLatencyMS        int64      `json:"avg_latency_ms,omitempty"` // Over the kept runs that passed
+	P95LatencyMS        int64      `json:"p95_latency_ms,omitempty"`
+	Alerting            bool       `json:"alerting"` // An alert went out and the check hasn't recovered since
+	LastRun             *CheckRun  `json:"last_run,omitempty"`
+	NextRun             *time.Time `json:"next_run,omitempty"`
+	LastResult          *RunResult `json:"last_result,omitempty"` // Step results of the last run
+	History             []CheckRun `json:"history,omitempty"`     // Kept runs, newest first, when asked for
+}
+
+// CheckPlacer picks the browser a check's session is created on, and is told once the
+// session is gone so the pool's session counts stay right
+type CheckPlacer interface {
+	Acquire(opts *SessionOptions) (int, error)
+	Release(port int)
+}
+
+// checkState is a check and what its runs left behind
+type checkState struct {
+	check               *Check
+	history             []CheckRun // Newest last
+	runs                int
+	failures            int
+	consecutiveFailures int
+	alerting            bool
+	lastResult          *RunResult
+	screenshot          []byte         // Of the last failure that had a page
+	tracker             *seeds.Tracker // Seed pages that passed, for seeded checks
+	next                time.Time
+	running             bool
+}
+
+// checks holds the manager's checks
+type checks struct {
+	states map[templateKey]*checkState
+	placer CheckPlacer
+	client *http.Client
+	mu     sync.Mutex
+}
+
+// interval returns how often the check runs
+func (c *Check) interval() time.Duration {
+	if c.IntervalMS == 0 {
+		return DefaultCheckInterval
+	}
+	return time.Duration(c.IntervalMS) * time.Millisecond
+}
+
+// maxTargets returns how many changed pages one run covers
+func (s *CheckSeed) maxTargets() int {
+	if s.MaxTargets == 0 {
+		return DefaultSeedTargets
+	}
+	return s.MaxTargets
+}
+
+// alertAfter returns how many consecutive failures raise an alert
+func (a *CheckAlert) alertAfter() int {
+	if a.After <= 0 {
+		return 1
+	}
+	return a.After
+}
+
+// validateCheck checks a check before it is saved, resolving its session options so a
+// bad template shows up now rather than at the first run
+func (m *Manager) validateCheck(check *Check) error {
+	if !templateNamePattern.MatchString(check.Name) {
+		return fmt.Errorf("%w: name must be 1-64 letters, digits, '_' or '-'", ErrInvalidCheck)
+	}
+	steps := check.Steps
+	if seed := check.Seed; seed != nil {
+		if err := seeds.ValidateURL(seed.URL); err != nil {
+			return fmt.Errorf("%w: seed: %v", ErrInvalidCheck, err)
+		}
+		if seed.MaxTargets < 0 || seed.MaxTargets > MaxSeedTargets {
+			return fmt.Errorf("%w: seed max_targets must be between 0 and %d", ErrInvalidCheck, MaxSeedTargets)
+		}
+		// Each page is loaded by a navigate step ahead of the check's own
+		steps = append([]RunStep{{Action: StepNavigate, URL: seed.URL}}, steps...)
+	}
+	if err := validateRun(steps); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCheck, err)
+	}
+	if check.IntervalMS != 0 && check.interval() < MinCheckInterval {
+		return fmt.Errorf("%w: interval_ms must be at least %d", ErrInvalidCheck, MinCheckInterval.Milliseconds())
+	}
+	if alert := check.Alert; alert != nil {
+		parsed, err := url.Parse(alert.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: alert url must be an http or https URL", ErrInvalidCheck)
+		}
+		if alert.After < 0 {
+			return fmt.Errorf("%w: alert after must not be negative", ErrInvalidCheck)
+		}
+	}
+	if _, err := m.ResolveSessionOptions(check.TenantID, check.Template, check.Options); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCheck, err)
+	}
+	return nil
+}
+
+// SaveCheck adds or replaces a check. A replaced check keeps its history, and the new
+// one is due straight away.
+func (m *Manager) SaveCheck(check *Check) error {
+	if err := m.validateCheck(check); err != nil {
+		return err
+	}
+	if check.CreatedAt.IsZero() {
+		check.CreatedAt = time.Now()
+	}
+
+	m.checks.mu.Lock()
+	defer m.checks.mu.Unlock()
+
+	key := templateKey{check.TenantID, check.Name}
+	state := m.checks.states[key]
+	if state == nil {
+		state = &checkState{}
+		m.checks.states[key] = state
+	}
+	// A new seed starts over; the same one keeps what was already covered
+	if check.Seed == nil {
+		state.tracker = nil
+	} else if state.tracker == nil || state.check.Seed == nil || state.check.Seed.URL != check.Seed.URL {
+		state.tracker = seeds.NewTracker()
+	}
+	state.check = check
+	state.next = time.Now()
+
+	slog.Info("check saved", "check", check.Name, "interval", check.interval(), "paused", check.Paused)
+	return nil
+}
+
+// DeleteCheck removes a tenant's check; a run in progress finishes without being
+// recorded
+func (m *Manager) DeleteCheck(tenantID, name string) error {
+	m.checks.mu.Lock()
+	defer m.checks.mu.Unlock()
+
+	key := templateKey{tenantID, name}
+	if _, exists := m.checks.states[key]; !exists {
+		return fmt.Errorf("%w: %s", ErrCheckNotFound, name)
+	}
+	delete(m.checks.states, key)
+	return nil
+}
+
+// GetCheck reports on one of a tenant's checks, with its kept runs when history is set
+func (m *Manager) GetCheck(tenantID, name string, history bool) (*CheckReport, error) {
+	m.checks.mu.Lock()
+	defer m.checks.mu.Unlock()
+
+	state, exists := m.checks.states[templateKey{tenantID, name}]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCheckNotFound, name)
+	}
+	return state.report(history), nil
+}
+
+// ListChecks reports on every check of a tenant, sorted by name
+func (m *Manager) ListChecks(tenantID string) []*CheckReport {
+	m.checks.mu.Lock()
+	defer m.checks.mu.Unlock()
+
+	reports := make([]*CheckReport, 0, len(m.checks.states))
+	for key, state := range m.checks.states {
+		if key.tenantID == tenantID {
+			reports = append(reports, state.report(false))
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Name < reports[j].Name
+	})
+	return reports
+}
+
+// CheckScreenshot returns the PNG taken at the last failure of a tenant's check
+func (m *Manager) CheckScreenshot(tenantID, name string) ([]byte, error) {
+	m.checks.mu.Lock()
+	defer m.checks.mu.Unlock()
+
+	state, exists := m.checks.states[templateKey{tenantID, name}]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCheckNotFound, name)
+	}
+	if state.screenshot == nil {
+		return nil, fmt.Errorf("%w: %s has no failure screenshot", ErrCheckNotFound, name)
+	}
+	return state.screenshot, nil
+}
+
+// LoadChecksFile saves every check in a JSON file holding an array of checks
+func (m *Manager) LoadChecksFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read checks file: %w", err)
+	}
+
+	var checks []*Check
+	if err := json.Unmarshal(data, &checks); err != nil {
+		return 0, fmt.Errorf("failed to parse checks file: %w", err)
+	}
+
+	for _, check := range checks {
+		if err := m.SaveCheck(check); err != nil {
+			return 0, fmt.Errorf("check %q: %w", check.Name, err)
+		}
+	}
+	return len(checks), nil
+}
+
+// StartChecks runs checks on their schedule, creating their sessions where placer says
+func (m *Manager) StartChecks(placer CheckPlacer) {
+	m.checks.mu.Lock()
+	m.checks.placer = placer
+	m.checks.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(checkSchedulerTick)
+		defer ticker.Stop()
+
+		slog.Info("check scheduler started")
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				m.runDueChecks()
+			}
+		}
+	}()
+}
+
+// runDueChecks starts a run of every check whose time has come that isn't running yet
+func (m *Manager) runDueChecks() {
+	now := time.Now()
+
+	m.checks.mu.Lock()
+	var due []*checkState
+	for _, state := range m.checks.states {
+		if !state.check.Paused && !state.running && !now.Before(state.next) {
+			state.running = true
+			state.next = now.Add(state.check.interval())
+			due = append(due, state)
+		}
+	}
+	m.checks.mu.Unlock()
+
+	for _, state := range due {
+		go m.runCheck(m.ctx, state)
+	}
+}
+
+// RunCheck runs a tenant's check now, paused or not, and returns the run. It fails only
+// when the check is unknown, already running, or checks aren't enabled.
+func (m *Manager) RunCheck(ctx context.Context, tenantID, name string) (*CheckRun, error) {
+	m.checks.mu.Lock()
+	state, exists := m.checks.states[templateKey{tenantID, name}]
+	switch {
+	case !exists:
+		m.checks.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrCheckNotFound, name)
+	case m.checks.placer == nil:
+		m.checks.mu.Unlock()
+		return nil, ErrChecksDisabled
+	case state.running:
+		m.checks.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrCheckRunning, name)
+	}
+	state.running = true
+	m.checks.mu.Unlock()
+
+	return m.runCheck(ctx, state), nil
+}
+
+// runCheck runs a check in a session of its own and records the outcome
+func (m *Manager) runCheck(ctx context.Context, state *checkState) *CheckRun {
+	m.checks.mu.Lock()
+	check := state.check
+	placer := m.checks.placer
+	m.checks.mu.Unlock()
+
+	run := &CheckRun{StartedAt: time.Now()}
+	result, screenshot, err := m.executeCheck(ctx, state, check, placer, run)
+	run.DurationMS = time.Since(run.StartedAt).Milliseconds()
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		run.Success = result.Success
+		run.FailedStep = result.FailedStep
+		if result.FailedStep != nil {
+			run.Error = result.Steps[*result.FailedStep].Error
+		}
+	}
+	run.Screenshot = screenshot != nil
+
+	m.recordCheckRun(state, check, run, result, screenshot)
+	return run
+}
+
+// executeCheck creates the session, runs the steps, takes a screenshot if they failed
+// and destroys the session again. A seeded check runs them once for each changed page,
+// and returns the result of the first page that failed, or of the last one.
+func (m *Manager) executeCheck(ctx context.Context, state *checkState, check *Check, placer CheckPlacer, run *CheckRun) (*RunResult, []byte, error) {
+	var targets []seeds.Target
+	if check.Seed != nil {
+		expansion, err := m.seeds.Expand(ctx, check.Seed.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to expand seed: %w", err)
+		}
+
+		m.checks.mu.Lock()
+		state.tracker.Retain(expansion.Targets)
+		changed := state.tracker.Changed(expansion.Targets)
+		m.checks.mu.Unlock()
+
+		targets = changed[:min(len(changed), check.Seed.maxTargets())]
+		run.Targets = len(targets)
+		run.Remaining = len(changed) - len(targets)
+		if len(targets) == 0 {
+			// Nothing changed, so there is nothing to load
+			return &RunResult{Success: true, Steps: []StepResult{}, Extracted: map[string]interface{}{}, Duration: "0s"}, nil, nil
+		}
+	}
+
+	opts, err := m.ResolveSessionOptions(check.TenantID, check.Template, check.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	port, err := placer.Acquire(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no browser for the check: %w", err)
+	}
+	defer placer.Release(port)
+
+	session, err := m.CreateSessionWithOptions(ctx, check.TenantID, "check:"+check.Name, "", port, check.Template, check.Options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer func() {
+		if err := m.DestroySession(session.ID); err != nil {
+			slog.Warn("failed to destroy check session", "check", check.Name, "session_id", session.ID, "error", err)
+		}
+	}()
+
+	if check.Seed == nil {
+		result, err := m.Run(ctx, session.ID, "", check.Steps)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result, m.checkFailureScreenshot(ctx, check, session.ID, result), nil
+	}
+
+	var outcome *RunResult
+	var screenshot []byte
+	pageID := ""
+	for _, target := range targets {
+		steps := append([]RunStep{{Action: StepNavigate, URL: target.URL}}, check.Steps...)
+		result, err := m.Run(ctx, session.ID, pageID, steps)
+		if err != nil {
+			return nil, nil, err
+		}
+		pageID = result.PageID
+
+		if !result.Success {
+			if run.FailedURL == "" {
+				run.FailedURL = target.URL
+				outcome = result
+				screenshot = m.checkFailureScreenshot(ctx, check, session.ID, result)
+			}
+			continue
+		}
+		m.checks.mu.Lock()
+		state.tracker.Mark(target)
+		m.checks.mu.Unlock()
+		if outcome == nil || outcome.Success {
+			outcome = result
+		}
+	}
+	return outcome, screenshot, nil
+}
+
+// checkFailureScreenshot captures the page a failed run ended on, if it has one
+func (m *Manager) checkFailureScreenshot(ctx context.Context, check *Check, sessionID string, result *RunResult) []byte {
+	if result.Success || result.PageID == "" {
+		return nil
+	}
+	screenshot, err := m.CaptureScreenshot(context.WithoutCancel(ctx), sessionID, result.PageID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to capture check failure", "check", check.Name, "error", err)
+		return nil
+	}
+	return screenshot
+}
+
+// recordCheckRun adds a run to the check's history and raises or clears its alert
+func (m *Manager) recordCheckRun(state *checkState, check *Check, run *CheckRun, result *RunResult, screenshot []byte) {
+	m.checks.mu.Lock()
+	state.running = false
+	if m.checks.states[templateKey{check.TenantID, check.Name}] != state {
+		m.checks.mu.Unlock()
+		return // Deleted while it ran
+	}
+
+	state.history = append(state.history, *run)
+	if len(state.history) > CheckHistorySize {
+		state.history = state.history[len(state.history)-CheckHistorySize:]
+	}
+	state.runs++
+	state.lastResult = result
+
+	var alert string
+	if run.Success {
+		state.consecutiveFailures = 0
+		if state.alerting {
+			state.alerting = false
+			alert = CheckRecovered
+		}
+	} else {
+		state.failures++
+		state.consecutiveFailures++
+		if screenshot != nil {
+			state.screenshot = screenshot
+		}
+		if check.Alert != nil && !state.alerting && state.consecutiveFailures >= check.Alert.alertAfter() {
+			state.alerting = true
+			alert = CheckFailing
+		}
+	}
+	consecutive := state.consecutiveFailures
+	m.checks.mu.Unlock()
+
+	slog.Info("check ran",
+		"check", check.Name,
+		"success", run.Success,
+		"duration_ms", run.DurationMS,
+		"error", run.Error)
+
+	if alert != "" && check.Alert != nil {
+		go m.sendCheckAlert(check, alert, consecutive, run)
+	}
+}
+
+// sendCheckAlert POSTs an alert to the check's webhook. A delivery that fails is logged
+// and not retried; the next state change sends a new one.
+func (m *Manager) sendCheckAlert(check *Check, state string, consecutive int, run *CheckRun) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"check":                check.Name,
+		"state":                state,
+		"consecutive_failures": consecutive,
+		"run":                  run,
+	})
+
+	ctx, cancel := context.WithTimeout(m.ctx, checkAlertTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, check.Alert.URL, bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("failed to create check alert", "check", check.Name, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range check.Alert.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+
+	resp, err := m.checks.client.Do(req)
+	if err != nil {
+		slog.Warn("failed to send check alert", "check", check.Name, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		slog.Warn("check alert refused", "check", check.Name, "status", resp.StatusCode)
+		return
+	}
+	slog.Info("check alert sent", "check", check.Name, "state", state)
+}
+
+// report summarizes the state; the caller holds checks.mu
+func (s *checkState) report(history bool) *CheckReport {
+	report := &CheckReport{
+		Check:               s.check,
+		State:               CheckPending,
+		Runs:                s.runs,
+		Failures:            s.failures,
+		ConsecutiveFailures: s.consecutiveFailures,
+		Alerting:            s.alerting,
+		LastResult:          s.lastResult,
+	}
+
+	if len(s.history) > 0 {
+		last := s.history[len(s.history)-1]
+		report.LastRun = &last
+		report.State = CheckPassing
+		if !last.Success {
+			report.State = CheckFailing
+		}
+
+		var latencies []int64
+		var total int64
+		for _, run := range s.history {
+			if run.Success {
+				latencies = append(latencies, run.DurationMS)
+				total += run.DurationMS
+			}
+		}
+		uptime := float64(len(latencies)) / float64(len(s.history))
+		report.Uptime = &uptime
+		if len(latencies) > 0 {
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			report.AvgLatencyMS = total / int64(len(latencies))
+			report.P95LatencyMS = latencies[(len(latencies)*95+99)/100-1]
+		}
+	}
+
+	if s.check.Paused {
+		report.State = CheckPaused
+	} else {
+		next := s.next
+		report.NextRun = &next
+	}
+
+	if history {
+		report.History = make([]CheckRun, 0, len(s.history))
+		for i := len(s.history) - 1; i >= 0; i-- {
+			report.History = append(report.History, s.history[i])
+		}
+	}
+	return report
+}
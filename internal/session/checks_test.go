@@ -0,0 +1,289 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePlacer puts every check session on one port and counts what it hands out
+type fakePlacer struct {
+	port     int
+	acquired atomic.Int32
+	released atomic.Int32
+}
+
+func (p *fakePlacer) Acquire(opts *SessionOptions) (int, error) {
+	p.acquired.Add(1)
+	return p.port, nil
+}
+
+func (p *fakePlacer) Release(port int) {
+	p.released.Add(1)
+}
+
+// TestCheckRuns tests that check runs get sessions of their own, that failures keep a
+// screenshot, and that the alert webhook hears once when a check starts failing and
+// once when it recovers
+func TestCheckRuns(t *testing.T) {
+	var healthy atomic.Bool
+	targets := 0
+	var mu sync.Mutex
+
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Target.createTarget":
+			mu.Lock()
+			defer mu.Unlock()
+			targets++
+			return map[string]interface{}{"targetId": fmt.Sprintf("page-%d", targets)}
+		case "Page.captureScreenshot":
+			return map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("png"))}
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			if strings.Contains(p.Expression, "getBoundingClientRect") {
+				text := "Service unavailable"
+				if healthy.Load() {
+					text = "All systems go"
+				}
+				value = fmt.Sprintf(`{"found": true, "visible": true, "text": %q}`, text)
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	alerts := make(chan map[string]interface{}, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&alert)
+		if r.Header.Get("Authorization") != "Bearer hook-token" {
+			t.Errorf("alert sent without the expanded header: %q", r.Header.Get("Authorization"))
+		}
+		alerts <- alert
+	}))
+	defer webhook.Close()
+	t.Setenv("CHECK_HOOK_TOKEN", "hook-token")
+
+	ctx := context.Background()
+
+	if err := manager.SaveCheck(&Check{Name: "status", Steps: []RunStep{{Action: StepNavigate, URL: "https://status.example.com"}}, IntervalMS: 1000}); !errors.Is(err, ErrInvalidCheck) {
+		t.Errorf("expected ErrInvalidCheck for a 1s interval, got %v", err)
+	}
+	if _, err := manager.RunCheck(ctx, "", "status"); !errors.Is(err, ErrCheckNotFound) {
+		t.Errorf("expected ErrCheckNotFound, got %v", err)
+	}
+
+	err := manager.SaveCheck(&Check{
+		Name: "status",
+		Steps: []RunStep{
+			{Action: StepNavigate, URL: "https://status.example.com"},
+			{Action: StepAssert, Selector: "#summary", Text: "All systems go"},
+		},
+		Alert:  &CheckAlert{URL: webhook.URL, Headers: map[string]string{"Authorization": "Bearer ${CHECK_HOOK_TOKEN}"}, After: 2},
+		Paused: true, // Only run below, not by the scheduler
+	})
+	if err != nil {
+		t.Fatalf("SaveCheck failed: %v", err)
+	}
+	if _, err := manager.RunCheck(ctx, "", "status"); !errors.Is(err, ErrChecksDisabled) {
+		t.Errorf("expected ErrChecksDisabled before StartChecks, got %v", err)
+	}
+
+	placer := &fakePlacer{port: 9222}
+	manager.StartChecks(placer)
+
+	for i, want := range []bool{false, false, true} {
+		healthy.Store(want)
+		run, err := manager.RunCheck(ctx, "", "status")
+		if err != nil {
+			t.Fatalf("run %d: RunCheck failed: %v", i, err)
+		}
+		if run.Success != want {
+			t.Errorf("run %d: success = %t, want %t (%s)", i, run.Success, want, run.Error)
+		}
+		if !want && (!run.Screenshot || run.FailedStep == nil || *run.FailedStep != 1) {
+			t.Errorf("run %d: expected a screenshot of step 1 failing, got %+v", i, run)
+		}
+	}
+
+	for _, want := range []string{CheckFailing, CheckRecovered} {
+		select {
+		case alert := <-alerts:
+			if alert["state"] != want || alert["check"] != "status" {
+				t.Errorf("alert = %v, want state %s", alert, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s alert", want)
+		}
+	}
+	select {
+	case alert := <-alerts:
+		t.Errorf("unexpected alert %v", alert)
+	default:
+	}
+
+	report, err := manager.GetCheck("", "status", true)
+	if err != nil {
+		t.Fatalf("GetCheck failed: %v", err)
+	}
+	if report.State != CheckPaused || report.Runs != 3 || report.Failures != 2 || report.ConsecutiveFailures != 0 || report.Alerting {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.Uptime == nil || *report.Uptime < 0.33 || *report.Uptime > 0.34 || len(report.History) != 3 || !report.History[0].Success {
+		t.Errorf("expected 1/3 uptime with the passing run first in history, got %v, %+v", report.Uptime, report.History)
+	}
+	if screenshot, err := manager.CheckScreenshot("", "status"); err != nil || string(screenshot) != "png" {
+		t.Errorf("CheckScreenshot = %q, %v", screenshot, err)
+	}
+
+	if placer.acquired.Load() != 3 || placer.released.Load() != 3 || manager.GetSessionCount() != 0 {
+		t.Errorf("expected 3 sessions placed, released and destroyed, got %d, %d, %d left",
+			placer.acquired.Load(), placer.released.Load(), manager.GetSessionCount())
+	}
+}
+
+// TestSeededCheck tests that a seeded check runs its steps on each page of its sitemap,
+// and that later runs only cover pages that are new, modified, or failed before
+func TestSeededCheck(t *testing.T) {
+	var mu sync.Mutex
+	var current string // URL the page was last sent to
+	var visited []string
+	broken := map[string]bool{"https://shop.example.com/b": true}
+
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+			URL        string `json:"url"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "Target.createTarget", "Page.navigate":
+			if p.URL != "" && p.URL != "about:blank" {
+				current = p.URL
+				visited = append(visited, p.URL)
+			}
+			if method == "Target.createTarget" {
+				return map[string]interface{}{"targetId": "page-1"}
+			}
+		case "Page.captureScreenshot":
+			return map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("png"))}
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			if strings.Contains(p.Expression, "getBoundingClientRect") {
+				text := "In stock"
+				if broken[current] {
+					text = "Out of stock"
+				}
+				value = fmt.Sprintf(`{"found": true, "visible": true, "text": %q}`, text)
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	var sitemap atomic.Value
+	sitemap.Store(`<urlset>
+  <url><loc>https://shop.example.com/a</loc><lastmod>2026-01-01</lastmod></url>
+  <url><loc>https://shop.example.com/b</loc><lastmod>2026-02-01</lastmod></url>
+  <url><loc>https://shop.example.com/c</loc></url>
+</urlset>`)
+	seed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sitemap.Load().(string)))
+	}))
+	defer seed.Close()
+
+	manager.StartChecks(&fakePlacer{port: 9222})
+	ctx := context.Background()
+
+	if err := manager.SaveCheck(&Check{Name: "stock", Seed: &CheckSeed{URL: "file:///etc/passwd"}}); !errors.Is(err, ErrInvalidCheck) {
+		t.Errorf("expected ErrInvalidCheck for a file seed, got %v", err)
+	}
+	err := manager.SaveCheck(&Check{
+		Name:   "stock",
+		Seed:   &CheckSeed{URL: seed.URL + "/sitemap.xml", MaxTargets: 2},
+		Steps:  []RunStep{{Action: StepAssert, Selector: ".availability", Text: "In stock"}},
+		Paused: true,
+	})
+	if err != nil {
+		t.Fatalf("SaveCheck failed: %v", err)
+	}
+
+	visitedSince := func(run func()) []string {
+		mu.Lock()
+		visited = nil
+		mu.Unlock()
+		run()
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), visited...)
+	}
+	runCheck := func() *CheckRun {
+		t.Helper()
+		run, err := manager.RunCheck(ctx, "", "stock")
+		if err != nil {
+			t.Fatalf("RunCheck failed: %v", err)
+		}
+		return run
+	}
+
+	// Newest first, two per run: b fails, a passes, c waits
+	var run *CheckRun
+	pages := visitedSince(func() { run = runCheck() })
+	if run.Success || run.Targets != 2 || run.Remaining != 1 || run.FailedURL != "https://shop.example.com/b" {
+		t.Errorf("unexpected first run %+v", run)
+	}
+	if strings.Join(pages, " ") != "https://shop.example.com/b https://shop.example.com/a" {
+		t.Errorf("expected b then a, visited %v", pages)
+	}
+
+	// b is retried and c is new
+	mu.Lock()
+	broken = map[string]bool{}
+	mu.Unlock()
+	pages = visitedSince(func() { run = runCheck() })
+	if !run.Success || run.Targets != 2 || run.Remaining != 0 {
+		t.Errorf("unexpected second run %+v", run)
+	}
+	if strings.Join(pages, " ") != "https://shop.example.com/b https://shop.example.com/c" {
+		t.Errorf("expected b then c, visited %v", pages)
+	}
+
+	// Nothing changed, so nothing is loaded
+	pages = visitedSince(func() { run = runCheck() })
+	if !run.Success || run.Targets != 0 || len(pages) != 0 {
+		t.Errorf("expected an empty run, got %+v after visiting %v", run, pages)
+	}
+
+	// a is modified
+	sitemap.Store(`<urlset>
+  <url><loc>https://shop.example.com/a</loc><lastmod>2026-03-01</lastmod></url>
+  <url><loc>https://shop.example.com/b</loc><lastmod>2026-02-01</lastmod></url>
+  <url><loc>https://shop.example.com/c</loc></url>
+</urlset>`)
+	pages = visitedSince(func() { run = runCheck() })
+	if !run.Success || run.Targets != 1 || strings.Join(pages, " ") != "https://shop.example.com/a" {
+		t.Errorf("expected only a to be revisited, got %+v after visiting %v", run, pages)
+	}
+
+	if manager.GetSessionCount() != 0 {
+		t.Errorf("expected check sessions to be destroyed, %d left", manager.GetSessionCount())
+	}
+}
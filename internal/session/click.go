@@ -0,0 +1,163 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// Mouse buttons accepted for clicks
+var clickButtons = []string{"left", "middle", "right"}
+
+// ClickRequest is a click at a point on the page
+type ClickRequest struct {
+	X, Y       float64 // Viewport CSS pixels, or document CSS pixels with ScrollIntoView
+	Button     string  // left (default), middle or right
+	ClickCount int     // 2 for a double click
+	Modifiers  int     // Bit field: Alt=1, Ctrl=2, Meta=4, Shift=8
+
+	// ScrollIntoView treats X and Y as document coordinates and scrolls the point to
+	// the middle of the viewport before clicking
+	ScrollIntoView bool
+
+	// Verify snapshots the page around the click to report whether it changed
+	Verify bool
+
+	// Selector the point was found by, recorded with the interaction
+	Selector string
+}
+
+// ClickTarget is the element under the clicked point
+type ClickTarget struct {
+	Tag      string `json:"tag"`
+	Text     string `json:"text,omitempty"`
+	Selector string `json:"selector"`
+}
+
+// ClickResult reports where a click landed
+type ClickResult struct {
+	X          float64             `json:"x"` // Viewport point that was clicked, in CSS pixels
+	Y          float64             `json:"y"`
+	ScrollX    float64             `json:"scroll_x"` // Page scroll offset when clicking
+	ScrollY    float64             `json:"scroll_y"`
+	Target     *ClickTarget        `json:"target,omitempty"`  // Element under the point, before the click
+	Element    *ElementFingerprint `json:"element,omitempty"` // Fingerprint of the element clicked, or of the control holding it
+	PageChange *PageDelta          `json:"page_change,omitempty"`
+}
+
+// clickPointJS optionally scrolls a document point into view, then reports the viewport
+// point and the element under it
+const clickPointJS = `(function(x, y, scroll) {
+` + elementHelpersJS + `
+  if (scroll) {
+    window.scrollTo({left: Math.max(x - innerWidth / 2, 0), top: Math.max(y - innerHeight / 2, 0), behavior: 'instant'});
+    x -= scrollX;
+    y -= scrollY;
+  }
+
+  var inside = x >= 0 && y >= 0 && x < innerWidth && y < innerHeight;
+  var el = inside ? document.elementFromPoint(x, y) : null;
+  return {
+    x: x, y: y, scroll_x: scrollX, scroll_y: scrollY, inside: inside,
+    width: innerWidth, height: innerHeight,
+    target: el ? {tag: el.tagName.toLowerCase(), text: labelFor(el), selector: selectorFor(el)} : null
+  };
+})(%s, %s, %t)`
+
+// clickPoint is what clickPointJS reports
+type clickPoint struct {
+	ClickResult
+	Inside bool    `json:"inside"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Click dispatches a real mouse click at a point, such as one returned by a vision query.
+// The mouse moves there first so hover handlers run as they would for a person.
+func (m *Manager) Click(ctx context.Context, sessionID string, pageID string, req ClickRequest) (*ClickResult, error) {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
+	button := req.Button
+	if button == "" {
+		button = "left"
+	}
+	if !slices.Contains(clickButtons, button) {
+		return nil, fmt.Errorf("%w: unsupported button %q", ErrInvalidClick, button)
+	}
+	clickCount := req.ClickCount
+	if clickCount <= 0 {
+		clickCount = 1
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	// Resolve the point first (scrolling if asked) so the click lands inside the viewport
+	xJSON, _ := json.Marshal(req.X)
+	yJSON, _ := json.Marshal(req.Y)
+	result, err := session.ExecuteJavascript(ctx, pageID, fmt.Sprintf(clickPointJS, xJSON, yJSON, req.ScrollIntoView))
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate click point: %w", err)
+	}
+
+	rawJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal click point: %w", err)
+	}
+	var point clickPoint
+	if err := json.Unmarshal(rawJSON, &point); err != nil {
+		return nil, fmt.Errorf("failed to parse click point: %w", err)
+	}
+	if !point.Inside {
+		return nil, fmt.Errorf("%w: (%g, %g) is outside the %gx%g viewport; set scroll_into_view for document coordinates",
+			ErrInvalidClick, point.X, point.Y, point.Width, point.Height)
+	}
+
+	// Fingerprint what is clicked before the click can navigate away from it
+	element := session.fingerprintAt(ctx, pageID, point.X, point.Y)
+
+	var before *PageSnapshot
+	if req.Verify {
+		before, _ = session.SnapshotPage(ctx, pageID, false)
+	}
+
+	events := []MouseInput{
+		{Type: "mouseMoved", X: point.X, Y: point.Y, Modifiers: req.Modifiers},
+		{Type: "mousePressed", X: point.X, Y: point.Y, Button: button, ClickCount: clickCount, Modifiers: req.Modifiers},
+		{Type: "mouseReleased", X: point.X, Y: point.Y, Button: button, ClickCount: clickCount, Modifiers: req.Modifiers},
+	}
+	for _, input := range events {
+		if err := session.DispatchMouseEvent(ctx, pageID, input); err != nil {
+			return nil, err
+		}
+	}
+
+	m.recordInteraction(sessionID, pageID, "click", req.Selector, element)
+
+	click := point.ClickResult
+	click.Element = element
+	if req.Verify {
+		after, _ := session.SnapshotPage(ctx, pageID, false)
+		click.PageChange = ComparePageSnapshots(before, after)
+	}
+
+	// Whatever the click did, the cached analysis can no longer be trusted
+	session.InvalidatePageAnalysis(pageID)
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return &click, nil
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestClickRejectsUnknownButton tests that bad input is refused before touching the page
+func TestClickRejectsUnknownButton(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	_, err := manager.Click(context.Background(), "sess_missing", "PAGE1", ClickRequest{X: 10, Y: 10, Button: "back"})
+	if !errors.Is(err, ErrInvalidClick) {
+		t.Errorf("expected ErrInvalidClick, got %v", err)
+	}
+}
+
+// TestQuadBox tests bounding quads from DOM.getBoxModel
+func TestQuadBox(t *testing.T) {
+	// Corners in order: top-left, top-right, bottom-right, bottom-left
+	box := quadBox([]float64{10, 20, 110, 20, 110, 70, 10, 70})
+	if box == nil || *box != (Box{X: 10, Y: 20, Width: 100, Height: 50}) {
+		t.Errorf("unexpected box: %+v", box)
+	}
+
+	// A rotated quad is bounded by its extremes
+	box = quadBox([]float64{50, 0, 100, 50, 50, 100, 0, 50})
+	if box == nil || *box != (Box{X: 0, Y: 0, Width: 100, Height: 100}) {
+		t.Errorf("unexpected box for rotated quad: %+v", box)
+	}
+
+	if quadBox(nil) != nil {
+		t.Error("expected no box for an empty quad")
+	}
+}
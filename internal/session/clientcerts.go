@@ -0,0 +1,321 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+const (
+	// clientCertRequestTimeout bounds one request sent on a page's behalf with a client certificate
+	clientCertRequestTimeout = 60 * time.Second
+
+	// clientCertBodyLimit is the largest response handed back to a page; the whole body
+	// is held in memory before the browser gets it
+	clientCertBodyLimit = 50 << 20
+)
+
+// ClientCertificate presents a client certificate stored in the credential vault to
+// hosts that require mutual TLS
+type ClientCertificate struct {
+	Credential string   `json:"credential"` // Alias of a vault credential holding a certificate and key
+	Hosts      []string `json:"hosts"`      // Host names; *.example.com matches every subdomain
+}
+
+// ClientCertificateSource resolves the credential aliases sessions name to client
+// certificates. Aliases belong to a tenant, and only its sessions can name them.
+type ClientCertificateSource interface {
+	ClientCertificate(tenantID, alias string) (*tls.Certificate, error)
+}
+
+// SetClientCertificateSource enables sessions with client certificates
+func (m *Manager) SetClientCertificateSource(source ClientCertificateSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clientCertSource = source
+}
+
+// validate checks the credential and host names
+func (c ClientCertificate) validate() error {
+	if c.Credential == "" || len(c.Hosts) == 0 {
+		return fmt.Errorf("client_certificates require a credential and hosts")
+	}
+	for _, host := range c.Hosts {
+		name := strings.TrimPrefix(host, "*.")
+		if name == "" || strings.ContainsAny(name, "/:*") {
+			return fmt.Errorf("invalid client certificate host %q", host)
+		}
+	}
+	return nil
+}
+
+// hostMatches reports whether host is pattern, or a subdomain of it for *.name patterns
+func hostMatches(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if suffix, wildcard := strings.CutPrefix(pattern, "*."); wildcard {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// certificateRule sends the requests to some hosts with one certificate
+type certificateRule struct {
+	hosts  []string
+	client *http.Client
+}
+
+// clientCertificates is how a session's pages reach the hosts that want a certificate
+type clientCertificates struct {
+	rules []certificateRule
+}
+
+// resolveClientCertificates loads the certificates opts name from the tenant's
+// credentials. It returns nil when they name none.
+func (m *Manager) resolveClientCertificates(tenantID string, opts *SessionOptions) (*clientCertificates, error) {
+	if opts == nil || len(opts.ClientCertificates) == 0 {
+		return nil, nil
+	}
+	m.mu.RLock()
+	source := m.clientCertSource
+	m.mu.RUnlock()
+	if source == nil {
+		return nil, fmt.Errorf("%w: no credential vault is configured", ErrClientCertificate)
+	}
+
+	var proxy func(*http.Request) (*url.URL, error)
+	if opts.Proxy != "" {
+		// The browser takes host:port for an HTTP proxy, so the scheme is optional
+		address := opts.Proxy
+		if !strings.Contains(address, "://") {
+			address = "http://" + address
+		}
+		proxyURL, err := url.Parse(address)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("%w: proxy %q can't carry certificate requests", ErrClientCertificate, opts.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	certs := &clientCertificates{}
+	for _, config := range opts.ClientCertificates {
+		certificate, err := source.ClientCertificate(tenantID, config.Credential)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrClientCertificate, config.Credential, err)
+		}
+		transport := &http.Transport{
+			Proxy: proxy,
+			TLSClientConfig: &tls.Config{
+				Certificates: []tls.Certificate{*certificate},
+				// The browser's certificate checks don't apply to these requests, so the session's choice is made here
+				InsecureSkipVerify: opts.IgnoreCertificateErrors,
+			},
+			ForceAttemptHTTP2: true,
+		}
+		certs.rules = append(certs.rules, certificateRule{
+			hosts: config.Hosts,
+			client: &http.Client{
+				Transport: transport,
+				// The page follows redirects itself, so they are handed back to it
+				CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+			},
+		})
+	}
+	return certs, nil
+}
+
+// clientFor returns the client that carries the certificate for host, or nil
+func (c *clientCertificates) clientFor(host string) *http.Client {
+	for _, rule := range c.rules {
+		for _, pattern := range rule.hosts {
+			if hostMatches(pattern, host) {
+				return rule.client
+			}
+		}
+	}
+	return nil
+}
+
+// patterns returns the Fetch.enable patterns that pause requests to the hosts. They
+// can pause a few requests too many; clientFor has the final say.
+func (c *clientCertificates) patterns() []map[string]interface{} {
+	var patterns []map[string]interface{}
+	for _, rule := range c.rules {
+		for _, host := range rule.hosts {
+			for _, pattern := range []string{"https://" + host + "/*", "https://" + host + ":*"} {
+				patterns = append(patterns, map[string]interface{}{"urlPattern": pattern, "requestStage": "Request"})
+			}
+		}
+	}
+	return patterns
+}
+
+// pausedRequest is the part of Fetch.requestPaused we read
+type pausedRequest struct {
+	RequestID string `json:"requestId"`
+	Request   struct {
+		URL             string            `json:"url"`
+		Method          string            `json:"method"`
+		Headers         map[string]string `json:"headers"`
+		PostData        string            `json:"postData"`
+		PostDataEntries []struct {
+			Bytes string `json:"bytes"`
+		} `json:"postDataEntries"`
+	} `json:"request"`
+}
+
+// body returns the request body the page sent
+func (p *pausedRequest) body() []byte {
+	if len(p.Request.PostDataEntries) == 0 {
+		return []byte(p.Request.PostData)
+	}
+	var body []byte
+	for _, entry := range p.Request.PostDataEntries {
+		chunk, err := base64.StdEncoding.DecodeString(entry.Bytes)
+		if err != nil {
+			return []byte(p.Request.PostData)
+		}
+		body = append(body, chunk...)
+	}
+	return body
+}
+
+// dispatchPausedRequest hands a paused request to a goroutine that sends it with the
+// host's certificate, or lets it go on as usual. It runs on the CDP reader, which the
+// commands that answer the browser would wait on.
+func (s *Session) dispatchPausedRequest(targetID string, event *cdp.Event) {
+	var paused pausedRequest
+	if err := json.Unmarshal(event.Params, &paused); err != nil || paused.RequestID == "" {
+		return
+	}
+
+	var client *http.Client
+	if requestURL, err := url.Parse(paused.Request.URL); err == nil && requestURL.Scheme == "https" && s.clientCerts != nil {
+		client = s.clientCerts.clientFor(requestURL.Hostname())
+	}
+	if client == nil {
+		go s.answerPaused(targetID, "Fetch.continueRequest", map[string]interface{}{"requestId": paused.RequestID})
+		return
+	}
+	go s.sendWithCertificate(targetID, client, &paused)
+}
+
+// sendWithCertificate sends a paused request with the client certificate and fulfills
+// it with the response, so the page sees what the host answered
+func (s *Session) sendWithCertificate(targetID string, client *http.Client, paused *pausedRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), clientCertRequestTimeout)
+	defer cancel()
+
+	status, headers, body, err := s.roundTripWithCertificate(ctx, targetID, client, paused)
+	if err != nil {
+		slog.Debug("client certificate request failed", "session_id", s.ID, "page_id", targetID, "url", paused.Request.URL, "error", err)
+		reason := "ConnectionFailed"
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			reason = "TimedOut"
+		}
+		s.answerPaused(targetID, "Fetch.failRequest", map[string]interface{}{"requestId": paused.RequestID, "errorReason": reason})
+		return
+	}
+
+	s.answerPaused(targetID, "Fetch.fulfillRequest", map[string]interface{}{
+		"requestId":       paused.RequestID,
+		"responseCode":    status,
+		"responseHeaders": headers,
+		"body":            base64.StdEncoding.EncodeToString(body),
+	})
+}
+
+// roundTripWithCertificate sends a paused request with the page's cookies and returns
+// the response as Fetch.fulfillRequest takes it
+func (s *Session) roundTripWithCertificate(ctx context.Context, targetID string, client *http.Client, paused *pausedRequest) (int, []map[string]string, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, paused.Request.Method, paused.Request.URL, bytes.NewReader(paused.body()))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for name, value := range paused.Request.Headers {
+		request.Header.Set(name, value)
+	}
+	// The transport asks for and decodes compressed bodies itself, so the page gets them plain
+	request.Header.Del("Accept-Encoding")
+
+	// Requests are paused before the browser adds the cookie header
+	cookies, err := s.cookieHeader(ctx, targetID, paused.Request.URL)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if cookies != "" {
+		request.Header.Set("Cookie", cookies)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, clientCertBodyLimit+1))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if len(body) > clientCertBodyLimit {
+		return 0, nil, nil, fmt.Errorf("response is over %d bytes", clientCertBodyLimit)
+	}
+
+	headers := []map[string]string{}
+	for name, values := range response.Header {
+		if strings.EqualFold(name, "Content-Length") {
+			continue
+		}
+		for _, value := range values {
+			headers = append(headers, map[string]string{"name": name, "value": value})
+		}
+	}
+	return response.StatusCode, headers, body, nil
+}
+
+// cookieHeader returns the Cookie header the browser would send to rawURL
+func (s *Session) cookieHeader(ctx context.Context, targetID, rawURL string) (string, error) {
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Network.getCookies", map[string]interface{}{
+		"urls": []string{rawURL},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get cookies: %w", err)
+	}
+	var response struct {
+		Cookies []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"cookies"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return "", fmt.Errorf("failed to parse cookies response: %w", err)
+	}
+	pairs := make([]string, 0, len(response.Cookies))
+	for _, cookie := range response.Cookies {
+		pairs = append(pairs, cookie.Name+"="+cookie.Value)
+	}
+	return strings.Join(pairs, "; "), nil
+}
+
+// answerPaused tells the browser what to do with a paused request. A page that went
+// away meanwhile can't be answered, which is fine.
+func (s *Session) answerPaused(targetID, method string, params map[string]interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), clientCertRequestTimeout)
+	defer cancel()
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, method, params); err != nil {
+		slog.Debug("failed to answer paused request", "session_id", s.ID, "page_id", targetID, "method", method, "error", err)
+	}
+}
@@ -0,0 +1,163 @@
+package session
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// fakeCertificateSource hands out certificates by alias, all of them owned by the
+// deployment without tenants
+type fakeCertificateSource map[string]*tls.Certificate
+
+func (f fakeCertificateSource) ClientCertificate(tenantID, alias string) (*tls.Certificate, error) {
+	if certificate, exists := f[alias]; exists && tenantID == "" {
+		return certificate, nil
+	}
+	return nil, fmt.Errorf("credential not found")
+}
+
+// TestClientCertificates tests sending the requests of mutual TLS hosts with a vault
+// certificate and the page's cookies, and leaving other requests to the browser
+func TestClientCertificates(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: "agent-1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+
+	site := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("sid")
+		if len(r.TLS.PeerCertificates) == 0 || cookie == nil {
+			http.Error(w, "no certificate or cookie", http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "seen", Value: "1"})
+		fmt.Fprintf(w, "hello %s, %s", r.TLS.PeerCertificates[0].Subject.CommonName, cookie.Value)
+	}))
+	site.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: roots}
+	site.StartTLS()
+	defer site.Close()
+
+	var mu sync.Mutex
+	var methods []string
+	answered := make(chan map[string]interface{}, 4)
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p map[string]interface{}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		methods = append(methods, method)
+		mu.Unlock()
+		switch method {
+		case "Network.getCookies":
+			return map[string]interface{}{"cookies": []interface{}{map[string]interface{}{"name": "sid", "value": "abc123"}}}
+		case "Fetch.fulfillRequest", "Fetch.continueRequest", "Fetch.failRequest":
+			p["method"] = method
+			answered <- p
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	opts := &SessionOptions{
+		ClientCertificates:      []ClientCertificate{{Credential: "corp-mtls", Hosts: []string{"127.0.0.1", "*.corp.example.com"}}},
+		IgnoreCertificateErrors: true, // The test site's certificate is self-signed
+	}
+	if _, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", opts); !errors.Is(err, ErrClientCertificate) {
+		t.Errorf("expected ErrClientCertificate without a vault, got %v", err)
+	}
+	manager.SetClientCertificateSource(fakeCertificateSource{
+		"corp-mtls": {Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf},
+	})
+	missing := &SessionOptions{ClientCertificates: []ClientCertificate{{Credential: "missing", Hosts: []string{"a.example.com"}}}}
+	if _, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", missing); !errors.Is(err, ErrClientCertificate) {
+		t.Errorf("expected ErrClientCertificate for an unknown credential, got %v", err)
+	}
+	if _, err := manager.CreateSessionWithOptions(ctx, "globex", "agent-1", "", 9222, "", opts); !errors.Is(err, ErrClientCertificate) {
+		t.Errorf("expected ErrClientCertificate for another tenant's credential, got %v", err)
+	}
+
+	sess, pageID := openTestPage(t, manager, opts, site.URL)
+	mu.Lock()
+	intercepted, navigated := slices.Index(methods, "Fetch.enable"), slices.Index(methods, "Page.navigate")
+	mu.Unlock()
+	if intercepted < 0 || intercepted > navigated {
+		t.Errorf("expected the hosts intercepted before the page loaded, got %v", methods)
+	}
+
+	answer := func() map[string]interface{} {
+		t.Helper()
+		select {
+		case params := <-answered:
+			return params
+		case <-time.After(5 * time.Second):
+			t.Fatal("the paused request was never answered")
+			return nil
+		}
+	}
+
+	sess.dispatchNetworkEvent(pageID, &cdp.Event{Method: "Fetch.requestPaused", Params: []byte(`{"requestId": "interception-1",
+		"request": {"url": "` + site.URL + `/dashboard", "method": "GET", "headers": {"Accept": "text/html", "Accept-Encoding": "br"}}}`)})
+	fulfilled := answer()
+	if fulfilled["method"] != "Fetch.fulfillRequest" || fulfilled["responseCode"] != float64(http.StatusOK) {
+		t.Fatalf("expected the request fulfilled with the site's answer, got %v", fulfilled)
+	}
+	body, _ := base64.StdEncoding.DecodeString(fmt.Sprint(fulfilled["body"]))
+	if string(body) != "hello agent-1, abc123" {
+		t.Errorf("expected the certificate and cookie presented, got %q", body)
+	}
+	headers, _ := fulfilled["responseHeaders"].([]interface{})
+	if !slices.ContainsFunc(headers, func(header interface{}) bool { return header.(map[string]interface{})["name"] == "Set-Cookie" }) {
+		t.Errorf("expected the site's cookie handed to the page, got %v", headers)
+	}
+
+	// Patterns can pause requests to hosts without a certificate; those go on unchanged
+	sess.dispatchNetworkEvent(pageID, &cdp.Event{Method: "Fetch.requestPaused", Params: []byte(`{"requestId": "interception-2",
+		"request": {"url": "https://evil.example.net/x.corp.example.com/", "method": "GET", "headers": {}}}`)})
+	if continued := answer(); continued["method"] != "Fetch.continueRequest" || continued["requestId"] != "interception-2" {
+		t.Errorf("expected the other host's request continued, got %v", continued)
+	}
+
+	for host, want := range map[string]bool{"api.corp.example.com": true, "corp.example.com": false, "CORP.example.com.evil.net": false, "127.0.0.1": true} {
+		if (sess.clientCerts.clientFor(host) != nil) != want {
+			t.Errorf("clientFor(%q): expected %v", host, want)
+		}
+	}
+	for _, certificate := range []ClientCertificate{{Credential: "corp-mtls"}, {Credential: "corp-mtls", Hosts: []string{"https://corp.example.com"}}} {
+		if err := (&SessionOptions{ClientCertificates: []ClientCertificate{certificate}}).Validate(); err == nil {
+			t.Errorf("expected %+v rejected", certificate)
+		}
+	}
+}
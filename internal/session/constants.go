@@ -21,4 +21,66 @@ var (
 	ErrSessionNameConflict   = fmt.Errorf("session name already exists")
 	ErrInvalidSessionName    = fmt.Errorf("invalid session name")
 	ErrSessionNotFound       = fmt.Errorf("session not found")
+	ErrPageNotFound          = fmt.Errorf("page not found in session")
+	ErrObserverNotFound      = fmt.Errorf("observer not found")
+	ErrFormNotFound          = fmt.Errorf("form not found")
+	ErrLoginFormNotFound     = fmt.Errorf("login form not found")
+	ErrNoCaptcha             = fmt.Errorf("no captcha detected on page")
+	ErrTakeoverActive        = fmt.Errorf("session is under human takeover")
+	ErrNoTakeover            = fmt.Errorf("no active takeover")
+	ErrResourceNotFound      = fmt.Errorf("resource not loaded by page")
+	ErrResourceTooLarge      = fmt.Errorf("resource exceeds size limit")
+	ErrTemplateNotFound      = fmt.Errorf("session template not found")
+	ErrInvalidTemplateName   = fmt.Errorf("invalid template name")
+	ErrInvalidSessionOptions = fmt.Errorf("invalid session options")
+	ErrTenantSessionLimit    = fmt.Errorf("tenant session quota reached")
+	ErrTenantProcessLimit    = fmt.Errorf("tenant browser process quota reached")
+	ErrTenantBandwidthLimit  = fmt.Errorf("tenant daily bandwidth quota reached")
+	ErrTenantModelBudget     = fmt.Errorf("tenant daily model budget reached")
+	ErrModelBudget           = fmt.Errorf("daily model budget reached")
+	ErrPromptNotFound        = fmt.Errorf("prompt not found")
+	ErrInvalidPrompt         = fmt.Errorf("invalid prompt")
+	ErrNoPipeline            = fmt.Errorf("no pipeline given and session has no default pipeline")
+	ErrInvalidClick          = fmt.Errorf("invalid click")
+	ErrElementNotFound       = fmt.Errorf("no element matches selector")
+	ErrInvalidSelector       = fmt.Errorf("invalid selector")
+	ErrNoWorkDir             = fmt.Errorf("session has no work directory")
+	ErrWorkFileNotFound      = fmt.Errorf("file not found in work directory")
+	ErrInvalidWorkPath       = fmt.Errorf("invalid work directory path")
+	ErrInvalidWatch          = fmt.Errorf("invalid watch")
+	ErrWatchNotFound         = fmt.Errorf("watch not found")
+	ErrInvalidWait           = fmt.Errorf("invalid wait")
+	ErrDraining              = fmt.Errorf("server is draining and not accepting new sessions")
+	ErrInvalidShare          = fmt.Errorf("invalid share")
+	ErrShareNotFound         = fmt.Errorf("session is not shared with tenant")
+	ErrResponseTooLarge      = fmt.Errorf("response exceeds size limit")
+	ErrInvalidPDFOptions     = fmt.Errorf("invalid PDF options")
+	ErrCheckpointNotFound    = fmt.Errorf("checkpoint not found")
+	ErrCheckpointLimit       = fmt.Errorf("checkpoint limit reached")
+	ErrInvalidRun            = fmt.Errorf("invalid run")
+	ErrInvalidAssertion      = fmt.Errorf("invalid assertion")
+	ErrInvalidCheck          = fmt.Errorf("invalid check")
+	ErrCheckNotFound         = fmt.Errorf("check not found")
+	ErrCheckRunning          = fmt.Errorf("check is already running")
+	ErrChecksDisabled        = fmt.Errorf("checks are not enabled")
+	ErrInvalidComparison     = fmt.Errorf("invalid visual comparison")
+	ErrSearchDisabled        = fmt.Errorf("search is not configured")
+	ErrGlobalSessionLimit    = fmt.Errorf("global session limit reached")
+	ErrInvalidSerialization  = fmt.Errorf("invalid serialization")
+	ErrObjectNotFound        = fmt.Errorf("object not found; it was released or the page navigated")
+	ErrInvalidHandle         = fmt.Errorf("invalid element handle request")
+	ErrHandleNotFound        = fmt.Errorf("element handle not found")
+	ErrHandleStale           = fmt.Errorf("element handle is stale; the page loaded another document")
+	ErrInvalidFingerprint    = fmt.Errorf("invalid element fingerprint")
+	ErrInvalidHeal           = fmt.Errorf("invalid selector healing request")
+	ErrInvalidCanvas         = fmt.Errorf("invalid canvas capture")
+	ErrInvalidMedia          = fmt.Errorf("invalid media emulation")
+	ErrInvalidStorage        = fmt.Errorf("invalid storage request")
+	ErrServiceWorkerNotFound = fmt.Errorf("no service worker registered for scope")
+	ErrCacheNotFound         = fmt.Errorf("cache not found")
+	ErrInvalidNetworkFilter  = fmt.Errorf("invalid network filter")
+	ErrCaptureDisabled       = fmt.Errorf("network capture is not enabled for the session")
+	ErrClientCertificate     = fmt.Errorf("client certificate unavailable")
+	ErrEgressUnavailable     = fmt.Errorf("egress proxy unavailable")
+	ErrBandwidthUnmetered    = fmt.Errorf("session bandwidth is not metered")
 )
\ No newline at end of file
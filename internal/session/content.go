@@ -0,0 +1,132 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// DefaultMaxResponseSize caps page content and screenshots unless configured otherwise
+	DefaultMaxResponseSize = 64 << 20
+
+	// contentChunkSize is how much page content (in UTF-16 code units) one DevTools
+	// message carries, so a heavy page never arrives as a single huge message
+	contentChunkSize = 1 << 20
+)
+
+// contentStashes numbers the page variables content is staged in, so concurrent
+// reads of one page don't overwrite each other
+var contentStashes atomic.Int64
+
+// contentStashJS serializes the document into a page variable and evaluates to its length
+const contentStashJS = `(function(name) {
+  var doctype = document.doctype ? new XMLSerializer().serializeToString(document.doctype) : '';
+  var root = document.documentElement ? document.documentElement.outerHTML : '';
+  window[name] = doctype + root;
+  return window[name].length;
+})(%q)`
+
+// contentChunkJS evaluates to [end, chunk] for the staged content from offset. A chunk
+// never ends between the two halves of a surrogate pair.
+const contentChunkJS = `(function(name, offset, size) {
+  var s = window[name] || '';
+  var end = Math.min(offset + size, s.length);
+  var last = s.charCodeAt(end - 1);
+  if (end < s.length && last >= 0xD800 && last <= 0xDBFF) end--;
+  return [end, s.slice(offset, end)];
+})(%q, %d, %d)`
+
+// SetMaxResponseSize caps the page content, screenshots and script results returned; 0 restores the default
+func (m *Manager) SetMaxResponseSize(bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxResponse = bytes
+}
+
+// SetReadLimit bounds the DevTools messages accepted from browsers connected from now on.
+// A bigger message drops the connection, so this should sit well above the response size.
+func (m *Manager) SetReadLimit(bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readLimit = bytes
+}
+
+// maxResponseSize returns the configured response cap
+func (m *Manager) maxResponseSize() int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.maxResponse <= 0 {
+		return DefaultMaxResponseSize
+	}
+	return m.maxResponse
+}
+
+// pageContent returns the page's HTML, fetched in chunks and refused once it passes
+// maxBytes. Pages that can't run the chunked read fall back to DOM.getOuterHTML.
+func (s *Session) pageContent(ctx context.Context, targetID string, maxBytes int64) (string, error) {
+	stash := fmt.Sprintf("__bqaContent%d", contentStashes.Add(1))
+
+	result, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(contentStashJS, stash))
+	length, ok := result.(float64)
+	if err != nil || !ok {
+		slog.DebugContext(ctx, "chunked page content unavailable, reading it whole", "page_id", targetID, "error", err)
+		return s.outerHTML(ctx, targetID, maxBytes)
+	}
+	defer func() {
+		// Best effort: a page that navigated away already dropped the variable
+		cleanup := fmt.Sprintf("delete window[%q]", stash)
+		if _, err := s.ExecuteJavascript(context.WithoutCancel(ctx), targetID, cleanup); err != nil {
+			slog.Debug("failed to clear staged page content", "page_id", targetID, "error", err)
+		}
+	}()
+
+	// Every code unit takes at least a byte, so this is refused before anything is sent
+	if int64(length) > maxBytes {
+		return "", fmt.Errorf("%w: page content is at least %d bytes (max %d)", ErrResponseTooLarge, int64(length), maxBytes)
+	}
+
+	content := make([]byte, 0, int(length))
+	for offset := 0; offset < int(length); {
+		result, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(contentChunkJS, stash, offset, contentChunkSize))
+		if err != nil {
+			return "", fmt.Errorf("failed to read page content: %w", err)
+		}
+
+		pair, ok := result.([]interface{})
+		if !ok || len(pair) != 2 {
+			return "", fmt.Errorf("unexpected page content chunk: %T", result)
+		}
+		end, _ := pair[0].(float64)
+		chunk, _ := pair[1].(string)
+		if int(end) <= offset {
+			return "", fmt.Errorf("page content went away while it was read; the page may have navigated")
+		}
+
+		content = append(content, chunk...)
+		if int64(len(content)) > maxBytes {
+			return "", fmt.Errorf("%w: page content is over %d bytes (max %d)", ErrResponseTooLarge, len(content), maxBytes)
+		}
+		offset = int(end)
+	}
+
+	return string(content), nil
+}
+
+// decodeCapped decodes a base64 payload the browser returned, refusing it when the
+// decoded size would pass maxBytes
+func decodeCapped(data string, what string, maxBytes int64) ([]byte, error) {
+	// Padding carries no data
+	size := int64(base64.StdEncoding.DecodedLen(len(data))) - int64(len(data)-len(strings.TrimRight(data, "=")))
+	if size > maxBytes {
+		return nil, fmt.Errorf("%w: %s is %d bytes (max %d)", ErrResponseTooLarge, what, size, maxBytes)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", what, err)
+	}
+	return decoded, nil
+}
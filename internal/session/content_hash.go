@@ -0,0 +1,238 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MaxContentHashIgnore bounds the selectors one content hash may leave out
+const MaxContentHashIgnore = 50
+
+// simhashBits is the width of the similarity hashes; unrelated content differs in
+// about half of them
+const simhashBits = 64
+
+// ContentHashOptions tunes what a content hash covers
+type ContentHashOptions struct {
+	Ignore      []string // CSS selectors of elements left out, such as timestamps or ads
+	MaskNumbers bool     // Hash every run of digits the same, so counters and dates don't count as changes
+}
+
+// ContentHash fingerprints a page's text and element structure. The exact hashes change
+// with any difference; the simhashes of similar pages differ in few bits, which is what
+// ContentChange scores.
+type ContentHash struct {
+	URL              string `json:"url"`
+	TextHash         string `json:"text_hash"`         // SHA-256 of the normalized text
+	StructureHash    string `json:"structure_hash"`    // SHA-256 of the element tree, tags and depth only
+	TextSimhash      string `json:"text_simhash"`      // 64-bit simhash of the text's word shingles
+	StructureSimhash string `json:"structure_simhash"` // 64-bit simhash of the element tree
+	Words            int    `json:"words"`
+	Elements         int    `json:"elements"`
+}
+
+// ContentChange compares two content hashes. Scores run from 0 for the same content to
+// about 1 for unrelated content.
+type ContentChange struct {
+	TextChanged      bool    `json:"text_changed"`
+	StructureChanged bool    `json:"structure_changed"`
+	TextScore        float64 `json:"text_score"`
+	StructureScore   float64 `json:"structure_score"`
+	Score            float64 `json:"score"` // The larger of the two
+}
+
+// contentHashJS collects the page's text and element tree, skipping elements that match
+// an ignored selector and those whose text is never shown
+const contentHashJS = `(function(ignore) {
+  for (var i = 0; i < ignore.length; i++) {
+    try { document.querySelector(ignore[i]); } catch (e) { return JSON.stringify({invalid: ignore[i]}); }
+  }
+  var skip = {SCRIPT: true, STYLE: true, NOSCRIPT: true, TEMPLATE: true};
+  var text = [], structure = [];
+  function ignored(el) {
+    for (var i = 0; i < ignore.length; i++) {
+      if (el.matches(ignore[i])) return true;
+    }
+    return false;
+  }
+  function walk(node, depth) {
+    for (var child = node.firstChild; child; child = child.nextSibling) {
+      if (child.nodeType === 3) {
+        if (child.nodeValue.trim()) text.push(child.nodeValue);
+      } else if (child.nodeType === 1 && !skip[child.tagName] && !ignored(child)) {
+        structure.push(depth + ':' + child.tagName.toLowerCase());
+        walk(child, depth + 1);
+      }
+    }
+  }
+  if (document.body) walk(document.body, 0);
+  return JSON.stringify({url: location.href, text: text.join(' '), structure: structure});
+})(%s)`
+
+// pageContent is what contentHashJS reports
+type pageContent struct {
+	Invalid   string   `json:"invalid"`
+	URL       string   `json:"url"`
+	Text      string   `json:"text"`
+	Structure []string `json:"structure"`
+}
+
+var digitRuns = regexp.MustCompile(`[0-9]+`)
+
+// ContentHash fingerprints a page, so change monitors can keep a few hashes instead of
+// its content
+func (m *Manager) ContentHash(ctx context.Context, sessionID string, pageID string, opts ContentHashOptions) (*ContentHash, error) {
+	if len(opts.Ignore) > MaxContentHashIgnore {
+		return nil, fmt.Errorf("%w: at most %d ignore selectors", ErrInvalidSelector, MaxContentHashIgnore)
+	}
+
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	ignore := opts.Ignore
+	if ignore == nil {
+		ignore = []string{}
+	}
+	ignoreJSON, _ := json.Marshal(ignore)
+	value, err := session.Driver().Evaluate(ctx, pageID, fmt.Sprintf(contentHashJS, ignoreJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page content: %w", err)
+	}
+	raw, _ := value.(string)
+	var content pageContent
+	if err := json.Unmarshal([]byte(raw), &content); err != nil {
+		return nil, fmt.Errorf("failed to parse page content: %w", err)
+	}
+	if content.Invalid != "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSelector, content.Invalid)
+	}
+
+	session.UpdateActivity()
+	return hashContent(&content, opts.MaskNumbers), nil
+}
+
+// hashContent computes the hashes of collected page content
+func hashContent(content *pageContent, maskNumbers bool) *ContentHash {
+	text := strings.ToLower(content.Text)
+	if maskNumbers {
+		text = digitRuns.ReplaceAllString(text, "0")
+	}
+	words := strings.Fields(text)
+	normalized := strings.Join(words, " ")
+
+	// Shingles of three words weigh word order, so moved sentences count as a change
+	shingles := make([]string, 0, len(words))
+	for i := range words {
+		shingles = append(shingles, strings.Join(words[i:min(i+3, len(words))], " "))
+	}
+
+	// Each element with its parent, so a moved subtree counts as a change
+	parents := make([]string, 0, len(content.Structure))
+	openAt := make(map[int]string)
+	for _, element := range content.Structure {
+		depth, tag, _ := strings.Cut(element, ":")
+		level, _ := strconv.Atoi(depth)
+		openAt[level] = tag
+		parents = append(parents, openAt[level-1]+">"+element)
+	}
+
+	textSum := sha256.Sum256([]byte(normalized))
+	structureSum := sha256.Sum256([]byte(strings.Join(content.Structure, "\n")))
+	return &ContentHash{
+		URL:              content.URL,
+		TextHash:         hex.EncodeToString(textSum[:]),
+		StructureHash:    hex.EncodeToString(structureSum[:]),
+		TextSimhash:      fmt.Sprintf("%016x", simhash(shingles)),
+		StructureSimhash: fmt.Sprintf("%016x", simhash(parents)),
+		Words:            len(words),
+		Elements:         len(content.Structure),
+	}
+}
+
+// CompareContentHashes scores how much a page changed between two hashes
+func CompareContentHashes(before, after *ContentHash) (*ContentChange, error) {
+	textScore, err := simhashDistance(before.TextSimhash, after.TextSimhash)
+	if err != nil {
+		return nil, fmt.Errorf("text_simhash: %w", err)
+	}
+	structureScore, err := simhashDistance(before.StructureSimhash, after.StructureSimhash)
+	if err != nil {
+		return nil, fmt.Errorf("structure_simhash: %w", err)
+	}
+
+	change := &ContentChange{
+		TextChanged:      before.TextHash != after.TextHash,
+		StructureChanged: before.StructureHash != after.StructureHash,
+		TextScore:        textScore,
+		StructureScore:   structureScore,
+	}
+	// An exact match overrides a simhash collision, and any change scores above 0
+	if !change.TextChanged {
+		change.TextScore = 0
+	} else if change.TextScore == 0 {
+		change.TextScore = 1.0 / (simhashBits / 2)
+	}
+	if !change.StructureChanged {
+		change.StructureScore = 0
+	} else if change.StructureScore == 0 {
+		change.StructureScore = 1.0 / (simhashBits / 2)
+	}
+	change.Score = max(change.TextScore, change.StructureScore)
+	return change, nil
+}
+
+// simhash combines the FNV-1a hashes of features so similar sets get similar hashes
+func simhash(features []string) uint64 {
+	if len(features) == 0 {
+		return 0
+	}
+	var weights [simhashBits]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := range weights {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
+
+// simhashDistance turns the bits two hex simhashes differ in into a score, where half
+// the bits, as for unrelated content, scores 1
+func simhashDistance(a, b string) (float64, error) {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid simhash %q", a)
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid simhash %q", b)
+	}
+	distance := float64(bits.OnesCount64(x^y)) / (simhashBits / 2)
+	return min(distance, 1), nil
+}
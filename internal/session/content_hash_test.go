@@ -0,0 +1,97 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestContentHash tests that content hashes ignore what they are told to, and that the
+// change score grows with how much of the page changed
+func TestContentHash(t *testing.T) {
+	article := strings.Repeat("the quick brown fox jumps over the lazy dog while the farmer sleeps ", 20)
+	structure := []string{"0:header", "1:h1", "0:main", "1:article", "2:p", "2:p", "0:footer"}
+
+	base := hashContent(&pageContent{Text: "Updated 10:42 " + article, Structure: structure}, true)
+	later := hashContent(&pageContent{Text: "Updated 11:07  " + strings.ToUpper(article), Structure: structure}, true)
+	if base.TextHash != later.TextHash || base.StructureHash != later.StructureHash {
+		t.Errorf("expected masked numbers, case and whitespace not to change the hashes")
+	}
+	if base.Words != 262 || base.Elements != 7 {
+		t.Errorf("expected 262 words and 7 elements, got %d and %d", base.Words, base.Elements)
+	}
+
+	change, err := CompareContentHashes(base, later)
+	if err != nil {
+		t.Fatalf("CompareContentHashes failed: %v", err)
+	}
+	if change.TextChanged || change.StructureChanged || change.Score != 0 {
+		t.Errorf("expected no change, got %+v", change)
+	}
+
+	edited := hashContent(&pageContent{Text: "Updated 10:42 " + article + "and a new sentence at the end", Structure: append(structure, "0:aside")}, true)
+	change, err = CompareContentHashes(base, edited)
+	if err != nil {
+		t.Fatalf("CompareContentHashes failed: %v", err)
+	}
+	if !change.TextChanged || !change.StructureChanged || change.Score <= 0 {
+		t.Errorf("expected a small edit to be detected, got %+v", change)
+	}
+
+	unrelated := hashContent(&pageContent{Text: "Page not found. Return to the home page or search for something else entirely", Structure: []string{"0:div", "1:span"}}, true)
+	rewrite, err := CompareContentHashes(base, unrelated)
+	if err != nil {
+		t.Fatalf("CompareContentHashes failed: %v", err)
+	}
+	if rewrite.TextScore <= change.TextScore || rewrite.Score < 0.5 {
+		t.Errorf("expected unrelated content to score well above a small edit, got %+v and %+v", rewrite, change)
+	}
+
+	if _, err := CompareContentHashes(base, &ContentHash{TextSimhash: "zz"}); err == nil {
+		t.Error("expected an invalid simhash to be refused")
+	}
+
+	// Through the manager, the ignore selectors reach the page and bad ones are refused
+	var expression string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			switch {
+			case strings.Contains(p.Expression, `"[[bad"`):
+				value = `{"invalid": "[[bad"}`
+			case strings.Contains(p.Expression, "structure.push"):
+				expression = p.Expression
+				value = `{"url": "https://news.example.com/", "text": "Headline one", "structure": ["0:main", "1:h1"]}`
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://news.example.com/")
+
+	hash, err := manager.ContentHash(ctx, sess.ID, pageID, ContentHashOptions{Ignore: []string{".ad", "time"}})
+	if err != nil {
+		t.Fatalf("ContentHash failed: %v", err)
+	}
+	if hash.URL != "https://news.example.com/" || hash.Words != 2 || hash.Elements != 2 {
+		t.Errorf("unexpected hash: %+v", hash)
+	}
+	if !strings.Contains(expression, `[".ad","time"]`) {
+		t.Errorf("expected the ignore selectors in the script, got %s", expression)
+	}
+
+	if _, err := manager.ContentHash(ctx, sess.ID, pageID, ContentHashOptions{Ignore: []string{"[[bad"}}); !errors.Is(err, ErrInvalidSelector) {
+		t.Errorf("expected ErrInvalidSelector, got %v", err)
+	}
+}
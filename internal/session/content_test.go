@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// chunkArgs picks the offset and size out of a contentChunkJS call
+var chunkArgs = regexp.MustCompile(`, (\d+), (\d+)\)$`)
+
+// TestPageContentChunks tests that page content is read in chunks and that content
+// over the cap is refused before it is transferred
+func TestPageContentChunks(t *testing.T) {
+	page := strings.Repeat("<p>hello</p>", 250000) // 3 MB, three chunks
+	var chunks atomic.Int32
+
+	browser := newFakeBrowser(0, nil, func(method string, params json.RawMessage) map[string]interface{} {
+		if method == "Target.attachToTarget" {
+			return map[string]interface{}{"sessionId": "cdp-1"}
+		}
+		if method != "Runtime.evaluate" {
+			return nil
+		}
+
+		var evaluate struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &evaluate)
+
+		var value interface{} = true
+		switch {
+		case strings.Contains(evaluate.Expression, "XMLSerializer"):
+			value = len(page)
+		case strings.Contains(evaluate.Expression, "s.slice"):
+			chunks.Add(1)
+			args := chunkArgs.FindStringSubmatch(evaluate.Expression)
+			offset, _ := strconv.Atoi(args[1])
+			size, _ := strconv.Atoi(args[2])
+			end := min(offset+size, len(page))
+			value = []interface{}{end, page[offset:end]}
+		}
+		return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+	})
+	defer browser.server.Close()
+
+	client := cdp.NewClient(browser.wsURL())
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+	session := &Session{ID: "s1", CDPClient: client}
+
+	content, err := session.pageContent(context.Background(), "page-1", DefaultMaxResponseSize)
+	if err != nil {
+		t.Fatalf("failed to read page content: %v", err)
+	}
+	if content != page {
+		t.Errorf("expected %d bytes of content, got %d", len(page), len(content))
+	}
+	if got := chunks.Load(); got != 3 {
+		t.Errorf("expected 3 chunks, got %d", got)
+	}
+
+	chunks.Store(0)
+	_, err = session.pageContent(context.Background(), "page-1", 1<<20)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if got := chunks.Load(); got != 0 {
+		t.Errorf("expected oversized content not to be transferred, got %d chunks", got)
+	}
+}
+
+// TestDecodeCapped tests that base64 payloads over the cap are refused without decoding
+func TestDecodeCapped(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString(make([]byte, 1000))
+
+	decoded, err := decodeCapped(encoded, "screenshot", 1000)
+	if err != nil || len(decoded) != 1000 {
+		t.Fatalf("expected 1000 decoded bytes, got %d, %v", len(decoded), err)
+	}
+
+	if _, err := decodeCapped(encoded, "screenshot", 999); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if _, err := decodeCapped("not base64!", "screenshot", 1000); err == nil || errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected a decode error, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	// DefaultDrainTimeout is how long a drain waits for sessions to end when no
+	// timeout is given
+	DefaultDrainTimeout = 30 * time.Minute
+
+	// drainPollInterval is how often a drain checks the remaining sessions
+	drainPollInterval = time.Second
+)
+
+// DrainStatus reports the progress of a drain
+type DrainStatus struct {
+	Draining          bool      `json:"draining"`
+	Done              bool      `json:"done"`                // Every session ended, or the deadline passed
+	TimedOut          bool      `json:"timed_out,omitempty"` // Sessions were still running at the deadline
+	StartedAt         time.Time `json:"started_at,omitzero"`
+	Deadline          time.Time `json:"deadline,omitzero"`
+	InitialSessions   int       `json:"initial_sessions"`
+	RemainingSessions int       `json:"remaining_sessions"`
+}
+
+// drainState is the state of a drain in progress, guarded by Manager.drainMu
+type drainState struct {
+	startedAt time.Time
+	deadline  time.Time
+	initial   int
+	done      bool
+	timedOut  bool
+}
+
+// SetDrainTimeout sets how long drains started without a timeout wait for sessions
+func (m *Manager) SetDrainTimeout(timeout time.Duration) {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	m.drainTimeout = timeout
+}
+
+// StartDrain stops the manager from accepting new sessions and waits, in the
+// background, for existing ones to end or expire. Drained is closed once none are
+// left or timeout passes; zero uses the configured drain timeout. Starting a drain
+// that is already running changes nothing and reports false.
+func (m *Manager) StartDrain(timeout time.Duration) (DrainStatus, bool) {
+	m.drainMu.Lock()
+	if m.drain != nil {
+		m.drainMu.Unlock()
+		return m.DrainStatus(), false
+	}
+	if timeout <= 0 {
+		timeout = m.drainTimeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	now := time.Now()
+	state := &drainState{startedAt: now, deadline: now.Add(timeout)}
+	m.drain = state
+	m.drainMu.Unlock()
+
+	// Counted after the flag is set: creations check it under m.mu, so any that got
+	// past it already hold a reservation
+	initial := m.drainingSessionCount()
+	m.drainMu.Lock()
+	state.initial = initial
+	m.drainMu.Unlock()
+
+	slog.Info("drain started, no longer accepting sessions",
+		"sessions", initial,
+		"deadline", state.deadline.Format(time.RFC3339))
+
+	go m.waitForDrain(state)
+
+	return m.DrainStatus(), true
+}
+
+// waitForDrain polls the session count until it reaches zero or the deadline passes
+func (m *Manager) waitForDrain(state *drainState) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	lastRemaining := -1
+	for {
+		remaining := m.drainingSessionCount()
+		if remaining == 0 {
+			slog.Info("drain complete, all sessions ended", "duration", time.Since(state.startedAt).String())
+			m.finishDrain(state, false)
+			return
+		}
+		if !time.Now().Before(state.deadline) {
+			slog.Warn("drain deadline passed with sessions still running", "remaining_sessions", remaining)
+			m.finishDrain(state, true)
+			return
+		}
+
+		// Progress is logged as sessions end rather than on every poll
+		if remaining != lastRemaining {
+			slog.Info("draining",
+				"remaining_sessions", remaining,
+				"time_left", time.Until(state.deadline).Round(time.Second).String())
+			lastRemaining = remaining
+		}
+
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// finishDrain marks a drain done and wakes whoever waits on Drained
+func (m *Manager) finishDrain(state *drainState, timedOut bool) {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+
+	state.done = true
+	state.timedOut = timedOut
+	close(m.drained)
+}
+
+// IsDraining reports whether a drain has started; new sessions are then refused
+func (m *Manager) IsDraining() bool {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	return m.drain != nil
+}
+
+// DrainStatus reports the progress of the current drain
+func (m *Manager) DrainStatus() DrainStatus {
+	m.drainMu.Lock()
+	var status DrainStatus
+	if state := m.drain; state != nil {
+		status = DrainStatus{
+			Draining:        true,
+			Done:            state.done,
+			TimedOut:        state.timedOut,
+			StartedAt:       state.startedAt,
+			Deadline:        state.deadline,
+			InitialSessions: state.initial,
+		}
+	}
+	m.drainMu.Unlock()
+
+	status.RemainingSessions = m.drainingSessionCount()
+	return status
+}
+
+// drainingSessionCount returns the sessions a drain waits for: live ones and those
+// still being set up
+func (m *Manager) drainingSessionCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.sessions) + len(m.reserved)
+}
+
+// Drained is closed when a drain finishes, either because every session ended or
+// because its deadline passed
+func (m *Manager) Drained() <-chan struct{} {
+	return m.drained
+}
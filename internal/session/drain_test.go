@@ -0,0 +1,65 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestDrainRefusesSessions tests that a drain refuses new sessions and finishes once none are left
+func TestDrainRefusesSessions(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	if manager.IsDraining() {
+		t.Fatal("expected a new manager not to be draining")
+	}
+
+	status, started := manager.StartDrain(time.Minute)
+	if !started || !status.Draining {
+		t.Fatalf("expected the drain to start, got %+v", status)
+	}
+	if _, started := manager.StartDrain(time.Minute); started {
+		t.Error("expected a second drain not to start")
+	}
+
+	_, err := manager.CreateSessionWithOptions(context.Background(), "", "agent-1", "", 9222, "", nil)
+	if !errors.Is(err, ErrDraining) {
+		t.Errorf("expected ErrDraining, got %v", err)
+	}
+
+	select {
+	case <-manager.Drained():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a drain without sessions to finish")
+	}
+
+	status = manager.DrainStatus()
+	if !status.Done || status.TimedOut || status.RemainingSessions != 0 {
+		t.Errorf("expected a finished drain, got %+v", status)
+	}
+}
+
+// TestDrainDeadline tests that a drain gives up on sessions still running at its deadline
+func TestDrainDeadline(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	manager.sessions["sess_running"] = &Session{ID: "sess_running"}
+
+	status, _ := manager.StartDrain(50 * time.Millisecond)
+	if status.InitialSessions != 1 {
+		t.Errorf("expected 1 initial session, got %d", status.InitialSessions)
+	}
+
+	select {
+	case <-manager.Drained():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the drain to finish at its deadline")
+	}
+
+	status = manager.DrainStatus()
+	if !status.Done || !status.TimedOut || status.RemainingSessions != 1 {
+		t.Errorf("expected a timed out drain with 1 session left, got %+v", status)
+	}
+}
@@ -0,0 +1,114 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/egress"
+)
+
+// EgressPolicy limits what a session's traffic may reach through the built-in egress
+// proxy, and sets headers on it
+type EgressPolicy struct {
+	Allow   []string          `json:"allow,omitempty"`   // Hosts the session may reach; *.example.com matches every subdomain
+	Deny    []string          `json:"deny,omitempty"`    // Hosts the session may not reach, even when allowed
+	Headers map[string]string `json:"headers,omitempty"` // Set on plain HTTP requests, and on HTTPS ones when the proxy decrypts them
+}
+
+// validate checks the host rules and header names
+func (p *EgressPolicy) validate() error {
+	if err := (egress.Route{Allow: p.Allow, Deny: p.Deny, Headers: p.Headers}).Validate(); err != nil {
+		return fmt.Errorf("egress: %w", err)
+	}
+	return nil
+}
+
+// EgressReport is a session's policy on the egress proxy and what its traffic amounted to
+type EgressReport struct {
+	Policy    *EgressPolicy `json:"policy,omitempty"`
+	Decrypted bool          `json:"decrypted"` // HTTPS is decrypted, so headers and counts cover its requests
+	Usage     egress.Usage  `json:"usage"`
+}
+
+// SetEgressProxy sends the traffic of every Chromium session created from now on through proxy
+func (m *Manager) SetEgressProxy(proxy *egress.Proxy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.egress = proxy
+}
+
+// egressProxy returns the proxy sessions' traffic goes through, nil for none
+func (m *Manager) egressProxy() *egress.Proxy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.egress
+}
+
+// egressRoute is how opts has a session's traffic leave the proxy. The session's own
+// proxy becomes the upstream, since the browser context now points at the egress one.
+func egressRoute(opts *SessionOptions) egress.Route {
+	var route egress.Route
+	if opts == nil {
+		return route
+	}
+	route.Upstream = opts.Proxy
+	for _, host := range strings.FieldsFunc(opts.ProxyBypass, func(r rune) bool { return r == ',' || r == ';' }) {
+		if host = strings.TrimSpace(host); host != "" {
+			route.Bypass = append(route.Bypass, host)
+		}
+	}
+	if opts.Egress != nil {
+		route.Allow, route.Deny, route.Headers = opts.Egress.Allow, opts.Egress.Deny, opts.Egress.Headers
+	}
+	return route
+}
+
+// registerEgress opens a session's listener on the egress proxy and returns its address,
+// empty when there is no proxy or the session is a Firefox one
+func (m *Manager) registerEgress(sessionID string, opts *SessionOptions) (string, error) {
+	proxy := m.egressProxy()
+	if proxy == nil {
+		if opts != nil && opts.Egress != nil {
+			return "", fmt.Errorf("%w: no egress proxy is configured", ErrEgressUnavailable)
+		}
+		return "", nil
+	}
+	if engineOf(opts) == EngineFirefox {
+		return "", nil
+	}
+	address, err := proxy.Register(sessionID, egressRoute(opts))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrEgressUnavailable, err)
+	}
+	return address, nil
+}
+
+// unregisterEgress closes a session's listener, if it has one
+func (m *Manager) unregisterEgress(sessionID string) {
+	if proxy := m.egressProxy(); proxy != nil {
+		proxy.Unregister(sessionID)
+	}
+}
+
+// EgressUsage reports a session's egress policy and the traffic it sent through the
+// proxy since it was created or last resumed
+func (m *Manager) EgressUsage(sessionID string) (*EgressReport, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	proxy := m.egressProxy()
+	if proxy == nil {
+		return nil, fmt.Errorf("%w: no egress proxy is configured", ErrEgressUnavailable)
+	}
+	usage, registered := proxy.Usage(sessionID)
+	if !registered {
+		return nil, fmt.Errorf("%w: the session's traffic doesn't go through the egress proxy", ErrEgressUnavailable)
+	}
+
+	report := &EgressReport{Decrypted: proxy.MITM(), Usage: usage}
+	if session.Options != nil {
+		report.Policy = session.Options.Egress
+	}
+	return report, nil
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/egress"
+)
+
+// TestEgressProxy tests pointing a session's browser context at its own egress
+// listener, the policy its traffic follows there and closing the listener with it
+func TestEgressProxy(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-Tenant"))
+	}))
+	defer site.Close()
+
+	var mu sync.Mutex
+	var contexts []map[string]interface{}
+	browser := newFakeBrowser(0, nil, func(method string, params json.RawMessage) map[string]interface{} {
+		if method == "Target.createBrowserContext" {
+			var p map[string]interface{}
+			json.Unmarshal(params, &p)
+			mu.Lock()
+			contexts = append(contexts, p)
+			mu.Unlock()
+		}
+		return nil
+	})
+	defer browser.server.Close()
+
+	manager := NewManager(nil)
+	defer manager.Close()
+	manager.RegisterRemoteBrowser(9222, browser.wsURL())
+	ctx := context.Background()
+
+	opts := &SessionOptions{Egress: &EgressPolicy{Deny: []string{"*.tracker.example"}, Headers: map[string]string{"X-Tenant": "acme"}}}
+	if _, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", opts); !errors.Is(err, ErrEgressUnavailable) {
+		t.Errorf("expected ErrEgressUnavailable without an egress proxy, got %v", err)
+	}
+
+	proxy, err := egress.New(egress.Options{})
+	if err != nil {
+		t.Fatalf("egress.New failed: %v", err)
+	}
+	defer proxy.Close()
+	manager.SetEgressProxy(proxy)
+
+	socks := &SessionOptions{Proxy: "socks5://proxy.internal:1080"}
+	if _, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", socks); !errors.Is(err, ErrEgressUnavailable) {
+		t.Errorf("expected a SOCKS upstream rejected, got %v", err)
+	}
+
+	sess, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", opts)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	mu.Lock()
+	last := contexts[len(contexts)-1]
+	mu.Unlock()
+	if sess.egressAddr == "" || last["proxyServer"] != "http://"+sess.egressAddr || last["proxyBypassList"] != "<-loopback>" {
+		t.Fatalf("expected the context pointed at the session's listener, got %v (listener %q)", last, sess.egressAddr)
+	}
+
+	// What the browser would send through its proxy
+	proxyURL, _ := url.Parse("http://" + sess.egressAddr)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	response, err := client.Get(site.URL)
+	if err != nil {
+		t.Fatalf("request through the listener failed: %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "acme" {
+		t.Errorf("expected the policy's header injected, got %q", body)
+	}
+	response, err = client.Get("http://ads.tracker.example/pixel")
+	if err != nil {
+		t.Fatalf("request through the listener failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the denied host refused, got %d", response.StatusCode)
+	}
+
+	report, err := manager.EgressUsage(sess.ID)
+	if err != nil {
+		t.Fatalf("EgressUsage failed: %v", err)
+	}
+	if report.Usage.Requests != 1 || report.Usage.Blocked != 1 || report.Usage.BytesReceived != int64(len("acme")) || report.Policy != opts.Egress {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	if err := manager.DestroySession(sess.ID); err != nil {
+		t.Fatalf("DestroySession failed: %v", err)
+	}
+	if _, registered := proxy.Usage(sess.ID); registered {
+		t.Error("expected the listener closed with the session")
+	}
+
+	firefox := &SessionOptions{Engine: EngineFirefox, Egress: &EgressPolicy{Allow: []string{"example.com"}}}
+	if err := firefox.Validate(); !errors.Is(err, ErrEngineUnsupported) {
+		t.Errorf("expected egress rejected on Firefox, got %v", err)
+	}
+	if err := (&SessionOptions{Egress: &EgressPolicy{Allow: []string{"https://example.com/"}}}).Validate(); err == nil {
+		t.Error("expected a URL as an egress host rejected")
+	}
+}
@@ -0,0 +1,235 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// ElementLayout is where an element sits on screen and whether a click on it would land
+type ElementLayout struct {
+	Selector string `json:"selector"` // Canonical selector, as used for marks
+	Tag      string `json:"tag"`
+
+	// Boxes from DOM.getBoxModel, in viewport CSS pixels; nil when the element has no
+	// layout, e.g. display: none
+	Box     *Box `json:"box"` // Border box
+	Content *Box `json:"content,omitempty"`
+	Padding *Box `json:"padding,omitempty"`
+	Margin  *Box `json:"margin,omitempty"`
+
+	Visible    bool `json:"visible"`     // Has a size and is not hidden by display, visibility or opacity
+	InViewport bool `json:"in_viewport"` // Some part of the border box is on screen
+	// Occluded means another element is on top at the click point. Only checked for
+	// visible elements in the viewport.
+	Occluded   bool         `json:"occluded"`
+	OccludedBy *ClickTarget `json:"occluded_by,omitempty"`
+	Clickable  bool         `json:"clickable"`             // Visible, on screen, unobscured and accepting pointer events
+	ClickPoint *Point       `json:"click_point,omitempty"` // Where a click would land, when clickable
+
+	Styles map[string]string `json:"styles"` // Computed styles that affect visibility and hit testing
+}
+
+// elementLayoutStyles are the computed style properties reported for an element
+var elementLayoutStyles = []string{
+	"display", "visibility", "opacity", "pointer-events", "position", "z-index",
+	"overflow", "cursor", "transform",
+}
+
+// elementLayoutJS runs with this bound to the element. It hit-tests the middle of the
+// element's on-screen part, which is where a coordinate click would be aimed.
+const elementLayoutJS = `function(properties) {
+` + elementHelpersJS + `
+  var el = this;
+  var style = getComputedStyle(el);
+  var styles = {};
+  for (var i = 0; i < properties.length; i++) styles[properties[i]] = style.getPropertyValue(properties[i]);
+
+  var rect = el.getBoundingClientRect();
+  var visible = rect.width > 0 && rect.height > 0 && style.display !== 'none' &&
+    style.visibility !== 'hidden' && style.visibility !== 'collapse' && Number(style.opacity) > 0;
+
+  var left = Math.max(rect.left, 0), right = Math.min(rect.right, innerWidth);
+  var top = Math.max(rect.top, 0), bottom = Math.min(rect.bottom, innerHeight);
+  var inViewport = right > left && bottom > top;
+
+  var occluded = false, by = null, point = null;
+  if (visible && inViewport) {
+    var x = (left + right) / 2, y = (top + bottom) / 2;
+    var hit = document.elementFromPoint(x, y);
+    if (hit && (hit === el || el.contains(hit))) {
+      point = {x: x, y: y};
+    } else {
+      occluded = true;
+      if (hit) by = {tag: hit.tagName.toLowerCase(), text: labelFor(hit), selector: selectorFor(hit)};
+    }
+  }
+
+  return {
+    selector: selectorFor(el), tag: el.tagName.toLowerCase(),
+    visible: visible, in_viewport: inViewport, occluded: occluded, occluded_by: by,
+    clickable: point !== null && style.pointerEvents !== 'none', click_point: point,
+    styles: styles
+  };
+}`
+
+// boxModel is the part of the DOM.getBoxModel response we read. Each quad is four
+// corners as x1, y1, ... x4, y4.
+type boxModel struct {
+	Model struct {
+		Content []float64 `json:"content"`
+		Padding []float64 `json:"padding"`
+		Border  []float64 `json:"border"`
+		Margin  []float64 `json:"margin"`
+	} `json:"model"`
+}
+
+// quadBox returns the rectangle that bounds a quad, which is the quad itself unless
+// the element is transformed
+func quadBox(quad []float64) *Box {
+	if len(quad) < 8 {
+		return nil
+	}
+	minX, minY, maxX, maxY := quad[0], quad[1], quad[0], quad[1]
+	for i := 2; i+1 < len(quad); i += 2 {
+		minX, maxX = min(minX, quad[i]), max(maxX, quad[i])
+		minY, maxY = min(minY, quad[i+1]), max(maxY, quad[i+1])
+	}
+	return &Box{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// GetElementLayout finds the first element selector finds and reports its box model,
+// visibility and whether anything covers it
+func (s *Session) GetElementLayout(ctx context.Context, targetID string, selector string, engine SelectorEngine) (*ElementLayout, error) {
+	objectID, err := s.findElement(ctx, targetID, selector, engine, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// The handle pins the element in the page until it is released
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		s.CDPClient.SendCommandToTarget(releaseCtx, targetID, "Runtime.releaseObject", map[string]interface{}{
+			"objectId": objectID,
+		})
+	}()
+
+	return s.objectLayout(ctx, targetID, objectID)
+}
+
+// GetObjectLayout reports the layout of the element a remote object ID refers to, such
+// as a node of a deeply serialized script result
+func (s *Session) GetObjectLayout(ctx context.Context, targetID string, objectID string) (*ElementLayout, error) {
+	layout, err := s.objectLayout(ctx, targetID, objectID)
+	var responseErr *cdp.ResponseError
+	if errors.As(err, &responseErr) && staleObject(responseErr) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, objectID)
+	}
+	return layout, err
+}
+
+// staleObject reports whether the browser refused an object ID it no longer holds
+func staleObject(err *cdp.ResponseError) bool {
+	return strings.Contains(err.Message, "Could not find object") || strings.Contains(err.Message, "Invalid remote object id")
+}
+
+// objectLayout inspects the element objectID refers to
+func (s *Session) objectLayout(ctx context.Context, targetID string, objectID string) (*ElementLayout, error) {
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.callFunctionOn", map[string]interface{}{
+		"objectId":            objectID,
+		"functionDeclaration": elementLayoutJS,
+		"arguments":           []map[string]interface{}{{"value": elementLayoutStyles}},
+		"returnByValue":       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect element: %w", err)
+	}
+
+	var inspected struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails interface{} `json:"exceptionDetails,omitempty"`
+	}
+	if err := json.Unmarshal(result, &inspected); err != nil {
+		return nil, fmt.Errorf("failed to parse element inspection: %w", err)
+	}
+	if inspected.ExceptionDetails != nil {
+		return nil, fmt.Errorf("javascript execution error: %v", inspected.ExceptionDetails)
+	}
+
+	var layout ElementLayout
+	if err := json.Unmarshal(inspected.Result.Value, &layout); err != nil {
+		return nil, fmt.Errorf("failed to parse element layout: %w", err)
+	}
+
+	// Chrome refuses a box model for elements that are not rendered; that is an answer, not a failure
+	result, err = s.CDPClient.SendCommandToTarget(ctx, targetID, "DOM.getBoxModel", map[string]interface{}{
+		"objectId": objectID,
+	})
+	if err == nil {
+		var model boxModel
+		if err := json.Unmarshal(result, &model); err == nil {
+			layout.Box = quadBox(model.Model.Border)
+			layout.Content = quadBox(model.Model.Content)
+			layout.Padding = quadBox(model.Model.Padding)
+			layout.Margin = quadBox(model.Model.Margin)
+		}
+	}
+
+	return &layout, nil
+}
+
+// GetElementLayout reports an element's box, visibility and occlusion on a page
+func (m *Manager) GetElementLayout(ctx context.Context, sessionID string, pageID string, selector string, engine SelectorEngine) (*ElementLayout, error) {
+	if err := engine.validate(); err != nil {
+		return nil, err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	layout, err := session.GetElementLayout(ctx, pageID, selector, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return layout, nil
+}
+
+// GetObjectLayout reports the box, visibility and occlusion of the element an object ID
+// from a deep script result refers to
+func (m *Manager) GetObjectLayout(ctx context.Context, sessionID string, pageID string, objectID string) (*ElementLayout, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	layout, err := session.GetObjectLayout(ctx, pageID, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return layout, nil
+}
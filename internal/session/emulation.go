@@ -0,0 +1,284 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/storage"
+)
+
+// DefaultNavigationTimeout is how long Navigate waits for a page to become ready
+const DefaultNavigationTimeout = 10 * time.Second
+
+// disableAnimationsJS adds a stylesheet that ends CSS animations and transitions at once
+// and hides the caret, so a screenshot taken any time after a change shows its end state.
+// Init scripts run before the document has an element to hold it.
+const disableAnimationsJS = `(() => {
+  const css = '*, *::before, *::after { animation-duration: 0s !important; animation-delay: 0s !important; ' +
+    'animation-iteration-count: 1 !important; transition: none !important; caret-color: transparent !important; ' +
+    'scroll-behavior: auto !important; }';
+  const add = () => {
+    const style = document.createElement('style');
+    style.textContent = css;
+    document.documentElement.appendChild(style);
+  };
+  if (document.documentElement) {
+    add();
+    return;
+  }
+  new MutationObserver((_, observer) => {
+    if (document.documentElement) {
+      observer.disconnect();
+      add();
+    }
+  }).observe(document, { childList: true });
+})()`
+
+// SetRendering sets the locale, timezone and animation settings every session starts
+// with; a template or the session's own options may choose another locale or timezone
+func (m *Manager) SetRendering(locale, timezone string, disableAnimations bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if locale == "" && timezone == "" && !disableAnimations {
+		m.rendering = nil
+		return
+	}
+	m.rendering = &SessionOptions{Locale: locale, Timezone: timezone, DisableAnimations: disableAnimations}
+}
+
+// renderingDefaults returns the options every session starts with, nil when there are none
+func (m *Manager) renderingDefaults() *SessionOptions {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rendering
+}
+
+// navigationTimeout returns the session's page readiness timeout
+func (s *Session) navigationTimeout() time.Duration {
+	if s.Options != nil && s.Options.NavigationTimeoutMS > 0 {
+		return time.Duration(s.Options.NavigationTimeoutMS) * time.Millisecond
+	}
+	return DefaultNavigationTimeout
+}
+
+// idleTimeout returns how long the session may sit unused, falling back to the manager-wide value
+func (s *Session) idleTimeout(fallback time.Duration) time.Duration {
+	if s.Options != nil && s.Options.IdleTimeoutMS > 0 {
+		return time.Duration(s.Options.IdleTimeoutMS) * time.Millisecond
+	}
+	return fallback
+}
+
+// applyContextOptions sets up browser-context-wide state such as the initial cookie jar
+func (s *Session) applyContextOptions(ctx context.Context) error {
+	if s.Options == nil || len(s.Options.Cookies) == 0 {
+		return nil
+	}
+
+	return s.setContextCookies(ctx, s.Options.Cookies)
+}
+
+// setContextCookies adds cookies to the session's browser context
+func (s *Session) setContextCookies(ctx context.Context, cookies []storage.Cookie) error {
+	params := make([]map[string]interface{}, 0, len(cookies))
+	for _, cookie := range cookies {
+		param := map[string]interface{}{
+			"name":     cookie.Name,
+			"value":    cookie.Value,
+			"domain":   cookie.Domain,
+			"path":     cookie.Path,
+			"secure":   cookie.Secure,
+			"httpOnly": cookie.HttpOnly,
+		}
+		if param["path"] == "" {
+			param["path"] = "/"
+		}
+		// Omitting expires yields a session cookie; 0 would be an already-expired one
+		if cookie.Expires > 0 {
+			param["expires"] = cookie.Expires
+		}
+		if cookie.SameSite != "" {
+			param["sameSite"] = cookie.SameSite
+		}
+		params = append(params, param)
+	}
+
+	command := s.withContext(map[string]interface{}{
+		"cookies": params,
+	})
+
+	if _, err := s.CDPClient.SendCommand(ctx, "Storage.setCookies", command); err != nil {
+		return fmt.Errorf("failed to set cookies: %w", err)
+	}
+
+	return nil
+}
+
+// getContextCookies returns every cookie in the session's browser context
+func (s *Session) getContextCookies(ctx context.Context) ([]storage.Cookie, error) {
+	result, err := s.CDPClient.SendCommand(ctx, "Storage.getCookies", s.withContext(map[string]interface{}{}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cookies: %w", err)
+	}
+
+	var response struct {
+		Cookies []storage.Cookie `json:"cookies"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse cookies response: %w", err)
+	}
+
+	return response.Cookies, nil
+}
+
+// setupPage applies the session's emulation, blocking and init scripts to a page.
+// It must run before the page loads the document it should affect.
+func (s *Session) setupPage(ctx context.Context, targetID string) error {
+	opts := s.Options
+	if !opts.hasPageSetup() {
+		return nil
+	}
+
+	if opts.Viewport != nil {
+		scale := opts.Viewport.DeviceScaleFactor
+		if scale <= 0 {
+			scale = 1
+		}
+		params := map[string]interface{}{
+			"width":             opts.Viewport.Width,
+			"height":            opts.Viewport.Height,
+			"deviceScaleFactor": scale,
+			"mobile":            opts.Viewport.Mobile,
+		}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Emulation.setDeviceMetricsOverride", params); err != nil {
+			return fmt.Errorf("failed to set viewport: %w", err)
+		}
+	}
+
+	if opts.UserAgent != "" {
+		params := map[string]interface{}{"userAgent": opts.UserAgent}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Emulation.setUserAgentOverride", params); err != nil {
+			return fmt.Errorf("failed to set user agent: %w", err)
+		}
+	}
+
+	if opts.Locale != "" {
+		params := map[string]interface{}{"locale": opts.Locale}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Emulation.setLocaleOverride", params); err != nil {
+			return fmt.Errorf("failed to set locale: %w", err)
+		}
+	}
+
+	if opts.Timezone != "" {
+		params := map[string]interface{}{"timezoneId": opts.Timezone}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Emulation.setTimezoneOverride", params); err != nil {
+			return fmt.Errorf("failed to set timezone: %w", err)
+		}
+	}
+
+	if opts.IgnoreCertificateErrors {
+		if err := s.ignoreCertificateErrors(ctx, targetID); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.BlockedURLs) > 0 {
+		// URL blocking is part of the Network domain and only applies while it is enabled
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Network.enable", nil); err != nil {
+			return fmt.Errorf("failed to enable network domain: %w", err)
+		}
+		params := map[string]interface{}{"urls": opts.BlockedURLs}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Network.setBlockedURLs", params); err != nil {
+			return fmt.Errorf("failed to set blocked URLs: %w", err)
+		}
+	}
+
+	scripts := opts.InitScripts
+	if opts.DisableAnimations {
+		scripts = append([]string{disableAnimationsJS}, scripts...)
+	}
+	for _, script := range scripts {
+		params := map[string]interface{}{"source": script}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.addScriptToEvaluateOnNewDocument", params); err != nil {
+			return fmt.Errorf("failed to add init script: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// openPage creates a page in the session's context and loads url into it.
+// The page starts blank so any page setup is in place, and the navigation is
+// being recorded, before the first real document loads.
+func (s *Session) openPage(ctx context.Context, url string) (string, error) {
+	return s.openPageWith(ctx, url, NavigateOptions{})
+}
+
+// openPageWith is openPage with the options applied to the page before it loads url
+func (s *Session) openPageWith(ctx context.Context, url string, opts NavigateOptions) (string, error) {
+	// A pre-opened warm page already has the setup applied
+	pageID := s.takeWarmPage()
+	if pageID == "" {
+		var err error
+		pageID, err = s.CDPClient.CreateTarget(ctx, "about:blank", s.targetContextID())
+		if err != nil {
+			return "", fmt.Errorf("failed to create target: %w", err)
+		}
+
+		if err := s.setupPage(ctx, pageID); err != nil {
+			s.closeFailedPage(ctx, pageID)
+			return "", fmt.Errorf("failed to set up page: %w", err)
+		}
+	}
+
+	// Capture starts before the first document so its sockets are seen being opened
+	if err := s.startNetworkCapture(ctx, pageID); err != nil {
+		s.closeFailedPage(ctx, pageID)
+		return "", err
+	}
+
+	if opts.BypassServiceWorker {
+		if err := s.bypassServiceWorker(ctx, pageID); err != nil {
+			s.closeFailedPage(ctx, pageID)
+			return "", err
+		}
+	}
+
+	return s.navigatePage(ctx, pageID, url)
+}
+
+// navigatePage loads url into a fresh page, closing the page if the navigation could
+// not be started
+func (s *Session) navigatePage(ctx context.Context, pageID string, url string) (string, error) {
+	if err := s.loadPage(ctx, pageID, url); err != nil {
+		if closeErr := s.CDPClient.CloseTarget(ctx, pageID); closeErr != nil {
+			slog.WarnContext(ctx, "failed to close page after navigation error", "page_id", pageID, "error", closeErr)
+		}
+		return "", err
+	}
+
+	return pageID, nil
+}
+
+// loadPage loads url into an existing page, recording where it ended up
+func (s *Session) loadPage(ctx context.Context, pageID string, url string) error {
+	return s.recordNavigation(ctx, pageID, url, func() (string, string, error) {
+		result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "Page.navigate", map[string]interface{}{"url": url})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to navigate: %w", err)
+		}
+
+		// Blocked or unreachable URLs are reported here rather than as a command error
+		var response struct {
+			LoaderID  string `json:"loaderId"`
+			ErrorText string `json:"errorText"`
+		}
+		if err := json.Unmarshal(result, &response); err == nil && response.ErrorText != "" {
+			slog.Warn("navigation reported an error", "page_id", pageID, "url", url, "error", response.ErrorText)
+		}
+		return response.LoaderID, response.ErrorText, nil
+	})
+}
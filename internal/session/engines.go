@@ -0,0 +1,293 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/bidi"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+)
+
+// Browser engines a session can ask for with SessionOptions.Engine
+const (
+	EngineChromium = string(browser.EngineChromium) // The default, driven over CDP
+	EngineFirefox  = string(browser.EngineFirefox)  // Driven over WebDriver BiDi
+)
+
+// ErrEngineUnsupported is returned for an option or operation the session's engine
+// can't provide. BiDi covers less than CDP, so Firefox sessions only offer the basics.
+var ErrEngineUnsupported = errors.New("not supported by the session's browser engine")
+
+// ErrEngineUnavailable is returned when no browsers of the requested engine are running
+var ErrEngineUnavailable = errors.New("no browsers of the requested engine are running")
+
+// Driver is what the basic page operations need from a session's browser, whichever
+// protocol it speaks. Page IDs are the driver's own: CDP target IDs or BiDi browsing
+// context IDs.
+type Driver interface {
+	OpenPage(ctx context.Context, url string) (string, error)
+	ClosePage(ctx context.Context, pageID string) error
+	Evaluate(ctx context.Context, pageID string, code string) (interface{}, error)
+	Call(ctx context.Context, pageID string, declaration string, args []json.RawMessage) (interface{}, error)
+	Screenshot(ctx context.Context, pageID string, maxBytes int64) ([]byte, error)
+	Content(ctx context.Context, pageID string, maxBytes int64) (string, error)
+}
+
+// Driver returns the driver of the session's browser
+func (s *Session) Driver() Driver {
+	if s.bidi != nil {
+		return s.bidi
+	}
+	return cdpDriver{s: s}
+}
+
+// Engine returns the browser engine the session runs on
+func (s *Session) Engine() string {
+	return engineOf(s.Options)
+}
+
+// engineOf returns the engine opts ask for, defaulting to Chromium
+func engineOf(opts *SessionOptions) string {
+	if opts == nil || opts.Engine == "" {
+		return EngineChromium
+	}
+	return opts.Engine
+}
+
+// validateEngine checks that the engine opts ask for is known and can apply them
+func (o *SessionOptions) validateEngine() error {
+	switch engineOf(o) {
+	case EngineChromium:
+		return nil
+	case EngineFirefox:
+	case "webkit":
+		// Asked for often enough to name: WebDriver BiDi could drive it, but no WebKit
+		// browsers are launched yet
+		return fmt.Errorf("engine %q isn't supported: only %s and %s sessions can be created", o.Engine, EngineChromium, EngineFirefox)
+	default:
+		return fmt.Errorf("unknown engine %q, must be %s or %s", o.Engine, EngineChromium, EngineFirefox)
+	}
+
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{"proxy", o.Proxy != "" || o.ProxyBypass != ""},
+		{"user_agent", o.UserAgent != ""},
+		{"blocked_urls", len(o.BlockedURLs) > 0},
+		{"init_scripts", len(o.InitScripts) > 0},
+		{"cookies", len(o.Cookies) > 0},
+		{"profile", o.Profile != ""},
+		{"extensions", len(o.Extensions) > 0},
+		{"locale", o.Locale != ""},
+		{"timezone", o.Timezone != ""},
+		{"disable_animations", o.DisableAnimations},
+		{"websockets", o.WebSockets != nil},
+		{"streams", o.Streams != nil},
+		{"ignore_certificate_errors", o.IgnoreCertificateErrors},
+		{"client_certificates", len(o.ClientCertificates) > 0},
+		{"egress", o.Egress != nil},
+	}
+	for _, option := range unsupported {
+		if option.set {
+			return fmt.Errorf("%w: %s on %s", ErrEngineUnsupported, option.name, EngineFirefox)
+		}
+	}
+	return nil
+}
+
+// cdpDriver drives a Chromium session's pages over its CDP connection
+type cdpDriver struct {
+	s *Session
+}
+
+func (d cdpDriver) OpenPage(ctx context.Context, url string) (string, error) {
+	return d.s.openPage(ctx, url)
+}
+
+func (d cdpDriver) ClosePage(ctx context.Context, pageID string) error {
+	return d.s.CDPClient.CloseTarget(ctx, pageID)
+}
+
+func (d cdpDriver) Evaluate(ctx context.Context, pageID string, code string) (interface{}, error) {
+	return d.s.ExecuteJavascript(ctx, pageID, code)
+}
+
+func (d cdpDriver) Call(ctx context.Context, pageID string, declaration string, args []json.RawMessage) (interface{}, error) {
+	return d.s.CallFunction(ctx, pageID, declaration, args)
+}
+
+func (d cdpDriver) Screenshot(ctx context.Context, pageID string, maxBytes int64) ([]byte, error) {
+	return d.s.captureScreenshot(ctx, pageID, maxBytes)
+}
+
+func (d cdpDriver) Content(ctx context.Context, pageID string, maxBytes int64) (string, error) {
+	return d.s.pageContent(ctx, pageID, maxBytes)
+}
+
+// bidiDriver drives a session's pages as tabs of a BiDi user context. The client is
+// shared with the other sessions on the same browser.
+type bidiDriver struct {
+	client            *bidi.Client
+	userContext       string
+	viewport          *Viewport
+	navigationTimeout time.Duration
+}
+
+// OpenPage opens a tab, sizes it and loads url. A page still loading once the
+// navigation timeout passes is kept, as Chromium pages are.
+func (d *bidiDriver) OpenPage(ctx context.Context, url string) (string, error) {
+	pageID, err := d.client.CreateTab(ctx, d.userContext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create tab: %w", err)
+	}
+
+	if err := d.load(ctx, pageID, url); err != nil {
+		if closeErr := d.client.CloseTab(context.WithoutCancel(ctx), pageID); closeErr != nil {
+			slog.WarnContext(ctx, "failed to close tab after navigation error", "page_id", pageID, "error", closeErr)
+		}
+		return "", err
+	}
+	return pageID, nil
+}
+
+// load applies the viewport to a fresh tab and navigates it
+func (d *bidiDriver) load(ctx context.Context, pageID, url string) error {
+	if d.viewport != nil {
+		if err := d.client.SetViewport(ctx, pageID, d.viewport.Width, d.viewport.Height); err != nil {
+			return fmt.Errorf("failed to set viewport: %w", err)
+		}
+	}
+
+	navigateCtx, cancel := context.WithTimeout(ctx, d.navigationTimeout)
+	defer cancel()
+	err := d.client.Navigate(navigateCtx, pageID, url)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		slog.WarnContext(ctx, "page did not finish loading before timeout", "page_id", pageID, "timeout", d.navigationTimeout)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to navigate: %w", err)
+	}
+	return nil
+}
+
+func (d *bidiDriver) ClosePage(ctx context.Context, pageID string) error {
+	return d.client.CloseTab(ctx, pageID)
+}
+
+func (d *bidiDriver) Evaluate(ctx context.Context, pageID string, code string) (interface{}, error) {
+	return bidiScriptResult(d.client.Evaluate(ctx, pageID, code))
+}
+
+func (d *bidiDriver) Call(ctx context.Context, pageID string, declaration string, args []json.RawMessage) (interface{}, error) {
+	return bidiScriptResult(d.client.CallFunction(ctx, pageID, declaration, args))
+}
+
+// bidiScriptResult returns a script's exception as a *ScriptError, like CDP's
+func bidiScriptResult(result interface{}, err error) (interface{}, error) {
+	var thrown *bidi.ScriptError
+	if errors.As(err, &thrown) {
+		scriptErr := &ScriptError{Message: thrown.Text, Line: thrown.Line, Column: thrown.Column}
+		for _, frame := range thrown.Stack {
+			scriptErr.Stack = append(scriptErr.Stack, StackFrame(frame))
+		}
+		return nil, scriptErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute javascript: %w", err)
+	}
+	return result, nil
+}
+
+func (d *bidiDriver) Screenshot(ctx context.Context, pageID string, maxBytes int64) ([]byte, error) {
+	data, err := d.client.CaptureScreenshot(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: screenshot is %d bytes (max %d)", ErrResponseTooLarge, len(data), maxBytes)
+	}
+	return data, nil
+}
+
+// Content reads the document in one script result; BiDi has no message size limit to
+// chunk around
+func (d *bidiDriver) Content(ctx context.Context, pageID string, maxBytes int64) (string, error) {
+	result, err := d.Evaluate(ctx, pageID, "document.documentElement.outerHTML")
+	if err != nil {
+		return "", err
+	}
+	content, _ := result.(string)
+	if int64(len(content)) > maxBytes {
+		return "", fmt.Errorf("%w: page content is %d bytes (max %d)", ErrResponseTooLarge, len(content), maxBytes)
+	}
+	return content, nil
+}
+
+// bidiClientForPort returns the BiDi connection to the Firefox on port, connecting
+// under dialMu like clientForPort. A dropped connection is replaced for new sessions;
+// those created on it fail with bidi.ErrClosed.
+func (m *Manager) bidiClientForPort(ctx context.Context, port int) (*bidi.Client, error) {
+	m.dialMu.Lock()
+	defer m.dialMu.Unlock()
+
+	m.mu.RLock()
+	client := m.bidiClients[port]
+	m.mu.RUnlock()
+	if client != nil && client.IsConnected() {
+		return client, nil
+	}
+
+	client, err := bidi.Dial(ctx, browser.BiDiURL(port))
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.bidiClients[port] = client
+	m.mu.Unlock()
+	return client, nil
+}
+
+// newBiDiSession sets up a Firefox session in a user context of its own
+func (m *Manager) newBiDiSession(ctx context.Context, port int, opts *SessionOptions) (*Session, error) {
+	client, err := m.bidiClientForPort(ctx, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Firefox: %w", err)
+	}
+
+	userContext, err := client.CreateUserContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user context: %w", err)
+	}
+
+	session := &Session{
+		ProcessPort:       port,
+		ContextID:         userContext,
+		PageIDs:           []string{},
+		CreatedAt:         time.Now(),
+		LastActivity:      time.Now(),
+		Status:            SessionActive,
+		Options:           opts,
+		pageAnalysisCache: make(map[string]*PageStructure),
+	}
+	session.bidi = &bidiDriver{
+		client:            client,
+		userContext:       userContext,
+		viewport:          opts.Viewport,
+		navigationTimeout: session.navigationTimeout(),
+	}
+	return session, nil
+}
+
+// removeUserContext closes a BiDi session's user context and the tabs left in it
+func (m *Manager) removeUserContext(session *Session) {
+	if err := session.bidi.client.RemoveUserContext(context.Background(), session.ContextID); err != nil {
+		slog.Warn("failed to remove user context", "error", err)
+	}
+}
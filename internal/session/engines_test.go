@@ -0,0 +1,143 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// newFakeFirefox serves a BiDi endpoint that answers the commands Firefox sessions send,
+// recording their methods, and returns its port
+func newFakeFirefox(t *testing.T) (int, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var methods []string
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var request struct {
+				ID     int                    `json:"id"`
+				Method string                 `json:"method"`
+				Params map[string]interface{} `json:"params"`
+			}
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+			mu.Lock()
+			methods = append(methods, request.Method)
+			mu.Unlock()
+
+			result := map[string]interface{}{}
+			switch request.Method {
+			case "browser.createUserContext":
+				result["userContext"] = "uc-1"
+			case "browsingContext.create":
+				result["context"] = "tab-1"
+			case "browsingContext.captureScreenshot":
+				result["data"] = base64.StdEncoding.EncodeToString([]byte("png"))
+			case "script.evaluate":
+				value := map[string]interface{}{"type": "number", "value": 2}
+				if request.Params["expression"] == "document.documentElement.outerHTML" {
+					value = map[string]interface{}{"type": "string", "value": "<html></html>"}
+				}
+				result = map[string]interface{}{"type": "success", "realm": "r1", "result": value}
+			}
+			conn.WriteJSON(map[string]interface{}{"type": "success", "id": request.ID, "result": result})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	address, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(address.Port())
+	return port, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(methods)
+	}
+}
+
+// TestFirefoxSession tests that a Firefox session lives in a user context of its own
+// and that the basic operations go over BiDi
+func TestFirefoxSession(t *testing.T) {
+	port, methods := newFakeFirefox(t)
+	manager := NewManager(nil)
+	defer manager.Close()
+	ctx := context.Background()
+
+	if err := (&SessionOptions{Engine: EngineFirefox, Proxy: "http://proxy:3128"}).Validate(); !errors.Is(err, ErrEngineUnsupported) {
+		t.Errorf("expected ErrEngineUnsupported for a proxy, got %v", err)
+	}
+	if err := (&SessionOptions{Engine: "webkit"}).Validate(); err == nil || !strings.Contains(err.Error(), `engine "webkit" isn't supported`) {
+		t.Errorf("expected WebKit refused by name, got %v", err)
+	}
+	if err := (&SessionOptions{Engine: "edge"}).Validate(); err == nil {
+		t.Error("expected an unknown engine to be refused")
+	}
+
+	opts := &SessionOptions{Engine: EngineFirefox, Viewport: &Viewport{Width: 800, Height: 600}}
+	sess, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", port, "", opts)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	if sess.ContextID != "uc-1" || sess.Engine() != EngineFirefox {
+		t.Errorf("expected a Firefox session in user context uc-1, got %s in %q", sess.Engine(), sess.ContextID)
+	}
+
+	pageID, err := manager.Navigate(ctx, sess.ID, "https://example.com")
+	if err != nil || pageID != "tab-1" {
+		t.Fatalf("Navigate = %q, %v; want tab-1", pageID, err)
+	}
+	if result, err := manager.ExecuteJavascript(ctx, sess.ID, pageID, "1 + 1"); err != nil || result != float64(2) {
+		t.Errorf("ExecuteJavascript = %v, %v; want 2", result, err)
+	}
+	if content, err := manager.GetPageContent(ctx, sess.ID, pageID); err != nil || content != "<html></html>" {
+		t.Errorf("GetPageContent = %q, %v", content, err)
+	}
+	if screenshot, err := manager.CaptureScreenshot(ctx, sess.ID, pageID); err != nil || string(screenshot) != "png" {
+		t.Errorf("CaptureScreenshot = %q, %v", screenshot, err)
+	}
+	if _, _, err := manager.ExecuteJavascriptVerified(ctx, sess.ID, pageID, "1", nil, ScriptOptions{}, false); !errors.Is(err, ErrEngineUnsupported) {
+		t.Errorf("expected ErrEngineUnsupported for verified execution, got %v", err)
+	}
+
+	if err := manager.DestroySession(sess.ID); err != nil {
+		t.Fatalf("DestroySession failed: %v", err)
+	}
+
+	want := []string{
+		"session.new",
+		"browser.createUserContext",
+		"browsingContext.create",
+		"browsingContext.setViewport",
+		"browsingContext.navigate",
+		"script.evaluate",
+		"script.evaluate",
+		"browsingContext.captureScreenshot",
+		"browsingContext.close",
+		"browser.removeUserContext",
+	}
+	if got := methods(); !slices.Equal(got, want) {
+		t.Errorf("commands = %v, want %v", got, want)
+	}
+
+	// The browser was only ever spoken to over BiDi
+	if len(manager.cdpClients) != 0 {
+		t.Error("expected no CDP connection for a Firefox session")
+	}
+}
@@ -0,0 +1,32 @@
+package session
+
+import (
+	"log/slog"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// Events returns the bus carrying session events
+func (m *Manager) Events() *events.Bus {
+	return m.events
+}
+
+// publishEvent emits an event for a session on the manager's bus
+func (m *Manager) publishEvent(sessionID string, pageID string, eventType string, data interface{}) {
+	m.events.Publish(events.Event{
+		SessionID: sessionID,
+		PageID:    pageID,
+		Type:      eventType,
+		Data:      data,
+	})
+}
+
+// publishCaptchaBlocked emits a captcha_blocked event for a page
+func (m *Manager) publishCaptchaBlocked(sessionID string, pageID string, info *CaptchaInfo) {
+	slog.Warn("captcha detected",
+		"session_id", sessionID,
+		"page_id", pageID,
+		"provider", info.Provider)
+
+	m.publishEvent(sessionID, pageID, events.TypeCaptchaBlocked, info)
+}
@@ -0,0 +1,276 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+const (
+	// DefaultStreamMessages is how many stream messages a page keeps unless the options say otherwise
+	DefaultStreamMessages = 1000
+
+	// MaxStreamMessages is the most stream messages a page can be set to keep
+	MaxStreamMessages = 10000
+
+	// streamAttachTimeout bounds asking the browser to hand over a response body as it arrives
+	streamAttachTimeout = 10 * time.Second
+)
+
+// Stream message kinds
+const (
+	StreamEvent = "event" // A message an EventSource dispatched
+	StreamChunk = "chunk" // A piece of a streamed fetch or XHR body, as it arrived
+)
+
+// defaultStreamTypes are the response MIME types whose bodies are captured as they arrive
+var defaultStreamTypes = []string{
+	"text/event-stream",
+	"application/x-ndjson",
+	"application/jsonl",
+	"application/json-seq",
+	"application/stream+json",
+}
+
+// StreamCapture turns on recording of the server-sent events and streamed response
+// bodies of a session's pages
+type StreamCapture struct {
+	MaxMessages     int           `json:"max_messages,omitempty"`      // Kept per page, oldest dropped first; default 1000
+	MaxPayloadBytes int           `json:"max_payload_bytes,omitempty"` // Longer messages are cut; default 64 KiB
+	MIMETypes       []string      `json:"mime_types,omitempty"`        // Fetch responses streamed beyond event streams and NDJSON
+	Masks           []PayloadMask `json:"masks,omitempty"`             // Applied to text messages before they are kept
+}
+
+// validate checks the limits, MIME types and masks
+func (c *StreamCapture) validate() error {
+	for _, mimeType := range c.MIMETypes {
+		if !strings.Contains(mimeType, "/") {
+			return fmt.Errorf("invalid streams MIME type %q", mimeType)
+		}
+	}
+	return validateCapture("streams", "max_messages", c.MaxMessages, MaxStreamMessages, c.MaxPayloadBytes, c.Masks)
+}
+
+// StreamInfo is an EventSource or streamed response a page received
+type StreamInfo struct {
+	ID       string     `json:"id"` // The request ID; messages refer to it
+	URL      string     `json:"url"`
+	Type     string     `json:"type"` // EventSource, Fetch or XHR
+	MIMEType string     `json:"mime_type"`
+	Messages int        `json:"messages"` // Received since capture started
+	OpenedAt time.Time  `json:"opened_at"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	Error    string     `json:"error,omitempty"` // Why the stream failed, if it did
+}
+
+// StreamMessage is one captured server-sent event or body chunk
+type StreamMessage struct {
+	Stream    string    `json:"stream"`
+	URL       string    `json:"url"`
+	Kind      string    `json:"kind"`               // event or chunk
+	Event     string    `json:"event,omitempty"`    // The event's name, "message" unless the server named it
+	EventID   string    `json:"event_id,omitempty"` // The id the server gave the event
+	Data      string    `json:"data"`
+	Size      int       `json:"size"`             // Bytes as received, before masking and cutting
+	Binary    bool      `json:"binary,omitempty"` // Data is a base64 encoded chunk that isn't text
+	Truncated bool      `json:"truncated,omitempty"`
+	Masked    bool      `json:"masked,omitempty"` // A masking rule changed the data
+	Time      time.Time `json:"time"`
+}
+
+// StreamFilter narrows the messages StreamTraffic returns
+type StreamFilter struct {
+	TrafficFilter
+	Kind  string // event or chunk; empty is both
+	Event string // Only events of this name
+}
+
+// validate rejects filters that can't match anything by construction
+func (f StreamFilter) validate() error {
+	if f.Kind != "" && f.Kind != StreamEvent && f.Kind != StreamChunk {
+		return fmt.Errorf("%w: kind must be %s or %s, got %q", ErrInvalidNetworkFilter, StreamEvent, StreamChunk, f.Kind)
+	}
+	return f.TrafficFilter.validate()
+}
+
+// StreamTraffic is what a page's stream capture holds
+type StreamTraffic struct {
+	Streams  []StreamInfo    `json:"streams"`
+	Messages []StreamMessage `json:"messages"`
+	Total    int             `json:"total"`   // Messages matching the filter, before the limit
+	Dropped  int             `json:"dropped"` // Messages dropped to stay within max_messages
+}
+
+// isStreamType reports whether a response of mimeType has its body captured as it arrives
+func (n *pageNetwork) isStreamType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	return slices.Contains(n.streamTypes, strings.TrimSpace(mimeType))
+}
+
+// dispatchStreamEvent applies an event stream or streamed response event of a
+// captured page. It runs on the CDP reader, so streaming a body is asked for on
+// another goroutine.
+func (s *Session) dispatchStreamEvent(targetID string, event *cdp.Event) {
+	var params struct {
+		RequestID string `json:"requestId"`
+		Type      string `json:"type"`
+		Response  struct {
+			URL      string `json:"url"`
+			MIMEType string `json:"mimeType"`
+		} `json:"response"`
+		EventName string `json:"eventName"`
+		EventID   string `json:"eventId"`
+		Data      string `json:"data"`
+		ErrorText string `json:"errorText"`
+		Canceled  bool   `json:"canceled"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.RequestID == "" {
+		return
+	}
+	now := time.Now()
+
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	network := s.network[targetID]
+	if network == nil || network.streamRules == nil {
+		return
+	}
+
+	if event.Method == "Network.responseReceived" {
+		streamed := (params.Type == "Fetch" || params.Type == "XHR") && network.isStreamType(params.Response.MIMEType)
+		if params.Type != "EventSource" && !streamed {
+			return
+		}
+		network.streams.get(params.RequestID, func() *StreamInfo {
+			return &StreamInfo{ID: params.RequestID, URL: params.Response.URL, Type: params.Type, MIMEType: params.Response.MIMEType, OpenedAt: now}
+		})
+		if streamed {
+			go s.streamResponse(targetID, params.RequestID)
+		}
+		return
+	}
+
+	// Everything else is about requests the page made; only streams are of interest
+	stream := network.streams.find(params.RequestID)
+	if stream == nil {
+		return
+	}
+	switch event.Method {
+	case "Network.eventSourceMessageReceived":
+		message := StreamMessage{Kind: StreamEvent, Event: params.EventName, EventID: params.EventID}
+		message.Data, message.Size, message.Truncated, message.Masked = network.streamRules.text(params.Data)
+		network.recordStreamMessage(stream, message, now)
+	case "Network.dataReceived":
+		// Only streamed bodies carry their data; EventSources report theirs as messages
+		if params.Data != "" && stream.Type != "EventSource" {
+			network.recordStreamMessage(stream, network.streamChunk(params.Data), now)
+		}
+	case "Network.loadingFinished":
+		stream.ClosedAt = &now
+	case "Network.loadingFailed":
+		stream.ClosedAt = &now
+		if !params.Canceled {
+			stream.Error = params.ErrorText
+		}
+	}
+}
+
+// streamChunk turns a base64 encoded body chunk into a message, kept as masked text
+// when it is text
+func (n *pageNetwork) streamChunk(data string) StreamMessage {
+	message := StreamMessage{Kind: StreamChunk}
+	if decoded, err := base64.StdEncoding.DecodeString(data); err == nil && utf8.Valid(decoded) {
+		message.Data, message.Size, message.Truncated, message.Masked = n.streamRules.text(string(decoded))
+	} else {
+		message.Data, message.Size, message.Truncated = n.streamRules.binary(data)
+		message.Binary = true
+	}
+	return message
+}
+
+// recordStreamMessage keeps a message of stream
+func (n *pageNetwork) recordStreamMessage(stream *StreamInfo, message StreamMessage, at time.Time) {
+	message.Stream, message.URL, message.Time = stream.ID, stream.URL, at
+	stream.Messages++
+	n.messages.add(message)
+}
+
+// streamResponse asks the browser to report a response's body as it arrives. What
+// arrived before the request is kept as the stream's first chunk.
+func (s *Session) streamResponse(targetID, requestID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), streamAttachTimeout)
+	defer cancel()
+
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Network.streamResourceContent", map[string]interface{}{
+		"requestId": requestID,
+	})
+	if err != nil {
+		slog.Debug("failed to stream response body", "session_id", s.ID, "page_id", targetID, "request_id", requestID, "error", err)
+		return
+	}
+	var streamed struct {
+		BufferedData string `json:"bufferedData"`
+	}
+	if err := json.Unmarshal(result, &streamed); err != nil || streamed.BufferedData == "" {
+		return
+	}
+
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	network := s.network[targetID]
+	if network == nil {
+		return
+	}
+	if stream := network.streams.find(requestID); stream != nil {
+		network.recordStreamMessage(stream, network.streamChunk(streamed.BufferedData), time.Now())
+	}
+}
+
+// streamTraffic returns the page's streams and the messages passing filter
+func (s *Session) streamTraffic(targetID string, filter StreamFilter) *StreamTraffic {
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	traffic := &StreamTraffic{Streams: []StreamInfo{}, Messages: []StreamMessage{}}
+	network := s.network[targetID]
+	if network == nil {
+		return traffic
+	}
+
+	traffic.Streams = network.streams.list(filter.URL, func(stream *StreamInfo) string { return stream.URL })
+	for _, message := range network.messages.ordered() {
+		if (filter.Kind == "" || message.Kind == filter.Kind) && (filter.Event == "" || message.Event == filter.Event) &&
+			filter.matches(message.URL, message.Size, message.Time) {
+			traffic.Messages = append(traffic.Messages, message)
+		}
+	}
+	traffic.Total = len(traffic.Messages)
+	traffic.Messages = newest(traffic.Messages, filter.Limit)
+	traffic.Dropped = network.messages.dropped
+	return traffic
+}
+
+// StreamTraffic returns the server-sent events and streamed response chunks a page
+// received, for sessions created with stream capture on
+func (m *Manager) StreamTraffic(sessionID string, pageID string, filter StreamFilter) (*StreamTraffic, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.capturingSession(sessionID, pageID, "streams", func(opts *SessionOptions) bool { return opts.Streams != nil })
+	if err != nil {
+		return nil, err
+	}
+
+	return session.streamTraffic(pageID, filter), nil
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestStreamCapture tests recording EventSource messages and streamed fetch bodies,
+// leaving other responses alone, and filtering the messages
+func TestStreamCapture(t *testing.T) {
+	var mu sync.Mutex
+	var streamed []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			RequestID string `json:"requestId"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Network.streamResourceContent":
+			mu.Lock()
+			streamed = append(streamed, p.RequestID)
+			mu.Unlock()
+			return map[string]interface{}{"bufferedData": base64.StdEncoding.EncodeToString([]byte(`{"tick":1}` + "\n"))}
+		}
+		return nil
+	})
+
+	sess, pageID := openTestPage(t, manager, &SessionOptions{Streams: &StreamCapture{
+		MaxPayloadBytes: 24,
+		Masks:           []PayloadMask{{Pattern: `user=\w+`}},
+	}}, "https://status.example.com")
+
+	event := func(method, params string) {
+		sess.dispatchNetworkEvent(pageID, &cdp.Event{Method: method, Params: []byte(params)})
+	}
+	event("Network.responseReceived", `{"requestId": "r1", "type": "EventSource", "response": {"url": "https://status.example.com/events", "mimeType": "text/event-stream"}}`)
+	event("Network.eventSourceMessageReceived", `{"requestId": "r1", "eventName": "message", "eventId": "7", "data": "hello user=ada"}`)
+	event("Network.eventSourceMessageReceived", `{"requestId": "r1", "eventName": "incident", "data": "{\"severity\":\"major\",\"region\":\"eu-west\"}"}`)
+	event("Network.dataReceived", `{"requestId": "r1", "dataLength": 40}`)
+	event("Network.responseReceived", `{"requestId": "r2", "type": "Fetch", "response": {"url": "https://status.example.com/feed", "mimeType": "application/x-ndjson; charset=utf-8"}}`)
+	event("Network.responseReceived", `{"requestId": "r3", "type": "Fetch", "response": {"url": "https://status.example.com/api", "mimeType": "application/json"}}`)
+	event("Network.dataReceived", `{"requestId": "r3", "data": "e30="}`)
+
+	// The buffered start of the body arrives from the browser on its own goroutine
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		traffic, _ := manager.StreamTraffic(sess.ID, pageID, StreamFilter{Kind: StreamChunk})
+		if traffic != nil && traffic.Total == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the buffered body recorded, got %+v", traffic)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	event("Network.dataReceived", `{"requestId": "r2", "data": "`+base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe, 0x00})+`"}`)
+	event("Network.loadingFailed", `{"requestId": "r2", "errorText": "net::ERR_CONNECTION_RESET"}`)
+
+	traffic, err := manager.StreamTraffic(sess.ID, pageID, StreamFilter{})
+	if err != nil {
+		t.Fatalf("StreamTraffic failed: %v", err)
+	}
+	mu.Lock()
+	if len(streamed) != 1 || streamed[0] != "r2" {
+		t.Errorf("expected only the NDJSON response streamed, got %v", streamed)
+	}
+	mu.Unlock()
+	if len(traffic.Streams) != 2 || traffic.Streams[0].Messages != 2 || traffic.Streams[1].Error != "net::ERR_CONNECTION_RESET" || traffic.Streams[1].ClosedAt == nil {
+		t.Errorf("unexpected streams: %+v", traffic.Streams)
+	}
+	if len(traffic.Messages) != 4 {
+		t.Fatalf("expected 2 events and 2 chunks, got %+v", traffic.Messages)
+	}
+	hello, incident, tick, binary := traffic.Messages[0], traffic.Messages[1], traffic.Messages[2], traffic.Messages[3]
+	if hello.Data != "hello ***" || !hello.Masked || hello.EventID != "7" || hello.Size != 14 {
+		t.Errorf("expected the user masked, got %+v", hello)
+	}
+	if !incident.Truncated || incident.Data != `{"severity":"major","reg` || incident.Event != "incident" {
+		t.Errorf("expected the incident cut to 24 bytes, got %+v", incident)
+	}
+	if tick.Kind != StreamChunk || tick.Data != `{"tick":1}`+"\n" || tick.Binary {
+		t.Errorf("expected the buffered NDJSON line as text, got %+v", tick)
+	}
+	if !binary.Binary || binary.Size != 3 || binary.URL != "https://status.example.com/feed" {
+		t.Errorf("expected the undecodable chunk kept as base64, got %+v", binary)
+	}
+
+	traffic, _ = manager.StreamTraffic(sess.ID, pageID, StreamFilter{Event: "incident"})
+	if traffic.Total != 1 || traffic.Messages[0].Stream != "r1" {
+		t.Errorf("expected only the incident, got %+v", traffic.Messages)
+	}
+	traffic, _ = manager.StreamTraffic(sess.ID, pageID, StreamFilter{TrafficFilter: TrafficFilter{URL: "/feed", Limit: 1}})
+	if traffic.Total != 2 || len(traffic.Messages) != 1 || !traffic.Messages[0].Binary || len(traffic.Streams) != 1 {
+		t.Errorf("expected the newest feed chunk, got %+v", traffic)
+	}
+	if _, err := manager.StreamTraffic(sess.ID, pageID, StreamFilter{Kind: "frame"}); !errors.Is(err, ErrInvalidNetworkFilter) {
+		t.Errorf("expected ErrInvalidNetworkFilter, got %v", err)
+	}
+	if _, err := manager.WebSocketTraffic(sess.ID, pageID, WebSocketFilter{}); !errors.Is(err, ErrCaptureDisabled) {
+		t.Errorf("expected WebSocket capture off, got %v", err)
+	}
+
+	for _, capture := range []*StreamCapture{{MaxMessages: MaxStreamMessages + 1}, {MIMETypes: []string{"ndjson"}}, {Masks: []PayloadMask{{Pattern: "["}}}} {
+		if err := (&SessionOptions{Streams: capture}).Validate(); err == nil || !strings.Contains(err.Error(), "streams") {
+			t.Errorf("expected %+v rejected, got %v", capture, err)
+		}
+	}
+}
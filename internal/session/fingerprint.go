@@ -0,0 +1,159 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ElementFingerprint describes an element by what a person would recognise it by, so it
+// can be found again after its selector stopped matching
+type ElementFingerprint struct {
+	Tag        string            `json:"tag"`
+	Text       string            `json:"text,omitempty"`       // Visible text, whitespace collapsed, at most 200 characters
+	Attributes map[string]string `json:"attributes,omitempty"` // The identifying ones, such as id, name, class, href and data-testid
+	Role       string            `json:"role,omitempty"`       // Accessibility role
+	Name       string            `json:"name,omitempty"`       // Accessible name
+	Path       string            `json:"path,omitempty"`       // Tags from the body down, e.g. "body > main > form > button"
+	Context    string            `json:"context,omitempty"`    // Text of the nearest ancestor holding more than the element, for where it sits
+	Selector   string            `json:"selector,omitempty"`   // Canonical CSS selector when it was taken
+	Box        *Box              `json:"box,omitempty"`        // Position in page CSS pixels, scroll included
+}
+
+// validate rejects fingerprints too empty to match anything by
+func (f *ElementFingerprint) validate() error {
+	if f == nil || strings.TrimSpace(f.Tag) == "" {
+		return fmt.Errorf("%w: tag is required", ErrInvalidFingerprint)
+	}
+	return nil
+}
+
+// fingerprintHelpersJS declares fingerprintOf(el) and roleOf(el). roleOf is an
+// approximation of the accessibility role for the page side of healing; fingerprints
+// taken through the browser's accessibility tree replace it with the real one.
+const fingerprintHelpersJS = elementHelpersJS + `
+  var fingerprintAttributes = ['id', 'name', 'class', 'type', 'role', 'aria-label', 'placeholder',
+    'title', 'alt', 'href', 'src', 'for', 'value', 'data-testid', 'data-test', 'data-qa', 'data-cy'];
+
+  function clean(text, limit) {
+    text = String(text || '').replace(/\s+/g, ' ').trim();
+    return text.length > limit ? text.slice(0, limit) : text;
+  }
+
+  function textOf(el) {
+    var button = el.tagName === 'INPUT' && /^(button|submit|reset)$/.test(el.type);
+    return clean(button ? el.value : el.innerText, 200);
+  }
+
+  function roleOf(el) {
+    var explicit = el.getAttribute('role');
+    if (explicit) return explicit.split(' ')[0];
+    var tag = el.tagName.toLowerCase(), type = (el.getAttribute('type') || 'text').toLowerCase();
+    if (tag === 'a') return el.hasAttribute('href') ? 'link' : 'generic';
+    if (tag === 'input') {
+      return {checkbox: 'checkbox', radio: 'radio', button: 'button', submit: 'button', reset: 'button',
+        image: 'button', range: 'slider', number: 'spinbutton', search: 'searchbox'}[type] || 'textbox';
+    }
+    if (/^h[1-6]$/.test(tag)) return 'heading';
+    return {button: 'button', select: 'combobox', textarea: 'textbox', img: 'image', nav: 'navigation',
+      main: 'main', form: 'form', li: 'listitem', ul: 'list', ol: 'list', table: 'table', tr: 'row',
+      td: 'cell', th: 'columnheader', option: 'option', label: 'LabelText', p: 'paragraph'}[tag] || 'generic';
+  }
+
+  function fingerprintOf(el) {
+    var attributes = {};
+    for (var i = 0; i < fingerprintAttributes.length; i++) {
+      var value = el.getAttribute(fingerprintAttributes[i]);
+      if (value) attributes[fingerprintAttributes[i]] = clean(value, 200);
+    }
+
+    var path = [], text = textOf(el), context = '';
+    for (var node = el; node && node.nodeType === 1 && node !== document.documentElement; node = node.parentElement) {
+      path.unshift(node.tagName.toLowerCase());
+    }
+    for (var parent = el.parentElement; parent && parent !== document.documentElement; parent = parent.parentElement) {
+      var around = clean(parent.innerText, 1000);
+      if (around.length > text.length) { context = clean(around, 200); break; }
+    }
+
+    var rect = el.getBoundingClientRect(), box = null;
+    if (rect.width > 0 || rect.height > 0) {
+      box = {x: rect.x + scrollX, y: rect.y + scrollY, width: rect.width, height: rect.height};
+    }
+    return {
+      tag: el.tagName.toLowerCase(), text: text, attributes: attributes, role: roleOf(el),
+      name: el.getAttribute('aria-label') || '', path: path.join(' > '), context: context,
+      selector: selectorFor(el), box: box
+    };
+  }
+`
+
+// fingerprintJS runs with this bound to an element and fingerprints it
+const fingerprintJS = `function() {` + fingerprintHelpersJS + `
+  return fingerprintOf(this);
+}`
+
+// fingerprintObject fingerprints the element a remote object refers to, taking its role
+// and name from the accessibility tree
+func (s *Session) fingerprintObject(ctx context.Context, targetID string, objectID string) (*ElementFingerprint, error) {
+	value, err := s.callOnObject(ctx, targetID, objectID, fingerprintJS)
+	if err != nil {
+		return nil, err
+	}
+	encoded, _ := json.Marshal(value)
+	var fingerprint ElementFingerprint
+	if err := json.Unmarshal(encoded, &fingerprint); err != nil {
+		return nil, fmt.Errorf("failed to parse fingerprint: %w", err)
+	}
+
+	// The computed role beats the page-side guess; without it the guess stays
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Accessibility.getPartialAXTree", map[string]interface{}{
+		"objectId":       objectID,
+		"fetchRelatives": false,
+	})
+	if err == nil {
+		var tree struct {
+			Nodes []cdpAXNode `json:"nodes"`
+		}
+		if json.Unmarshal(result, &tree) == nil && len(tree.Nodes) > 0 && !tree.Nodes[0].Ignored {
+			if role := stringValue(tree.Nodes[0].Role); role != "" && role != "<nil>" {
+				fingerprint.Role = role
+			}
+			if tree.Nodes[0].Name != nil {
+				fingerprint.Name = stringValue(*tree.Nodes[0].Name)
+			}
+		}
+	}
+	return &fingerprint, nil
+}
+
+// Fingerprint finds the first element selector finds and fingerprints it, for healing
+// the selector later
+func (m *Manager) Fingerprint(ctx context.Context, sessionID string, pageID string, selector string, engine SelectorEngine) (*ElementFingerprint, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, fmt.Errorf("%w: selector is required", ErrInvalidSelector)
+	}
+	if err := engine.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.devtoolsSession(sessionID, pageID, "element fingerprints")
+	if err != nil {
+		return nil, err
+	}
+
+	objectID, err := session.findElement(ctx, pageID, selector, engine, "")
+	if err != nil {
+		return nil, err
+	}
+	defer session.releaseObject(ctx, pageID, objectID)
+
+	fingerprint, err := session.fingerprintObject(ctx, pageID, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return fingerprint, nil
+}
@@ -0,0 +1,206 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DefaultFormSubmitTimeout is how long FillForm waits for the navigation caused by a submit
+const DefaultFormSubmitTimeout = 10 * time.Second
+
+// FormInfo describes a form discovered on a page
+type FormInfo struct {
+	Index  int         `json:"index"`
+	ID     string      `json:"id,omitempty"`
+	Name   string      `json:"name,omitempty"`
+	Action string      `json:"action,omitempty"`
+	Method string      `json:"method"`
+	Fields []FormField `json:"fields"`
+}
+
+// FormField describes a single fillable control inside a form
+type FormField struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Label    string   `json:"label,omitempty"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"` // Option values for select elements
+}
+
+// FormFillResult reports what happened when a form was filled
+type FormFillResult struct {
+	Filled    []string   `json:"filled"`
+	Missing   []string   `json:"missing"`
+	Submitted bool       `json:"submitted"`
+	Navigated bool       `json:"navigated"`
+	URL       string     `json:"url,omitempty"`
+	Change    *PageDelta `json:"page_change,omitempty"`
+}
+
+// formDiscoveryJS enumerates all forms and their fillable fields
+const formDiscoveryJS = `(function() {
+  function labelFor(el) {
+    if (el.labels && el.labels.length > 0) return el.labels[0].textContent.trim();
+    var aria = el.getAttribute('aria-label');
+    if (aria) return aria.trim();
+    var labelledBy = el.getAttribute('aria-labelledby');
+    if (labelledBy) {
+      var labelEl = document.getElementById(labelledBy);
+      if (labelEl) return labelEl.textContent.trim();
+    }
+    return (el.getAttribute('placeholder') || '').trim();
+  }
+
+  return Array.from(document.forms).map(function(form, index) {
+    var fields = [];
+    Array.from(form.elements).forEach(function(el) {
+      var tag = el.tagName.toLowerCase();
+      if (tag === 'fieldset' || tag === 'object' || tag === 'output') return;
+      var type = tag === 'input' ? (el.type || 'text').toLowerCase() : tag;
+      if (type === 'submit' || type === 'button' || type === 'reset' || type === 'image') return;
+
+      var field = {
+        name: el.name || el.id || '',
+        type: type,
+        label: labelFor(el).substring(0, 100),
+        required: !!el.required
+      };
+      if (tag === 'select') {
+        field.options = Array.from(el.options).slice(0, 50).map(function(o) { return o.value; });
+      }
+      fields.push(field);
+    });
+
+    return {
+      index: index,
+      id: form.id || '',
+      name: form.getAttribute('name') || '',
+      action: form.action || '',
+      method: (form.getAttribute('method') || 'get').toLowerCase(),
+      fields: fields
+    };
+  });
+})()`
+
+// formFillJS fills a form by field name (or id) and optionally submits it.
+// Values are set through the native setters so framework-managed inputs see the change.
+const formFillJS = `(function(formIndex, values, submit) {
+  var form = document.forms[formIndex];
+  if (!form) return { error: 'form_not_found' };
+
+  var filled = [], missing = [];
+  Object.keys(values).forEach(function(name) {
+    var matches = Array.from(form.elements).filter(function(el) { return el.name === name || el.id === name; });
+    if (matches.length === 0) { missing.push(name); return; }
+
+    var value = values[name];
+    var el = matches[0];
+    var type = (el.type || '').toLowerCase();
+
+    if (type === 'checkbox') {
+      el.checked = value === true || value === 'true' || value === 'on' || value === '1';
+    } else if (type === 'radio') {
+      var radio = matches.filter(function(r) { return r.value === String(value); })[0];
+      if (!radio) { missing.push(name); return; }
+      radio.checked = true;
+      el = radio;
+    } else {
+      var proto = el.tagName === 'SELECT' ? HTMLSelectElement.prototype :
+                  el.tagName === 'TEXTAREA' ? HTMLTextAreaElement.prototype : HTMLInputElement.prototype;
+      Object.getOwnPropertyDescriptor(proto, 'value').set.call(el, String(value));
+    }
+
+    el.dispatchEvent(new Event('input', { bubbles: true }));
+    el.dispatchEvent(new Event('change', { bubbles: true }));
+    filled.push(name);
+  });
+
+  var submitted = false;
+  if (submit) {
+    window.` + navigationMarker + ` = true;
+    if (typeof form.requestSubmit === 'function') { form.requestSubmit(); } else { form.submit(); }
+    submitted = true;
+  }
+
+  return { filled: filled, missing: missing, submitted: submitted };
+})(%d, %s, %t)`
+
+// DiscoverForms enumerates the forms on a page with their fields
+func (s *Session) DiscoverForms(ctx context.Context, targetID string) ([]FormInfo, error) {
+	result, err := s.ExecuteJavascript(ctx, targetID, formDiscoveryJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover forms: %w", err)
+	}
+
+	// Marshal back to JSON then unmarshal into our typed struct
+	rawJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal forms result: %w", err)
+	}
+
+	forms := make([]FormInfo, 0)
+	if err := json.Unmarshal(rawJSON, &forms); err != nil {
+		return nil, fmt.Errorf("failed to parse forms result: %w", err)
+	}
+
+	return forms, nil
+}
+
+// FillForm fills the form at formIndex from values and optionally submits it,
+// waiting up to timeout for the resulting navigation.
+func (s *Session) FillForm(ctx context.Context, targetID string, formIndex int, values map[string]interface{}, submit bool, timeout time.Duration) (*FormFillResult, error) {
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	// JSON is a valid JavaScript literal, so values are passed without string concatenation
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal form values: %w", err)
+	}
+
+	result, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(formFillJS, formIndex, valuesJSON, submit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fill form: %w", err)
+	}
+
+	rawJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fill result: %w", err)
+	}
+
+	var response struct {
+		FormFillResult
+		Error string `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(rawJSON, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse fill result: %w", err)
+	}
+
+	if response.Error == "form_not_found" {
+		return nil, fmt.Errorf("%w: index %d", ErrFormNotFound, formIndex)
+	}
+
+	fillResult := response.FormFillResult
+
+	// Wait for the navigation triggered by the submit (SPAs may not navigate at all)
+	if fillResult.Submitted {
+		if timeout <= 0 {
+			timeout = DefaultFormSubmitTimeout
+		}
+		navigated, err := s.WaitForNavigation(ctx, targetID, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed waiting for navigation: %w", err)
+		}
+		fillResult.Navigated = navigated
+	}
+
+	// Report where the page ended up
+	if url, err := s.GetCurrentURL(ctx, targetID); err == nil {
+		fillResult.URL = url
+	}
+
+	return &fillResult, nil
+}
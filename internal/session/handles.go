@@ -0,0 +1,384 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+const (
+	// maxHandlesPerPage bounds the elements one page keeps pinned for handles
+	maxHandlesPerPage = 200
+
+	// handleObjectGroup holds the remote objects behind handles
+	handleObjectGroup = "bqa-handles"
+)
+
+// ElementHandle is an element found once by selector and kept by its remote object, so
+// later operations reach the same element without querying again. It lasts until the
+// page navigates to another document or closes.
+type ElementHandle struct {
+	ID       string `json:"handle_id"`
+	PageID   string `json:"page_id"`
+	Selector string `json:"selector"` // As given when the handle was created
+	SelectorEngine
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+
+	objectID string
+}
+
+// pageHandles are the handles of one page, keyed by handle ID
+type pageHandles map[string]*ElementHandle
+
+// elementHandleJS runs with this bound to the found element and describes it
+const elementHandleJS = `function() { return this.tagName ? this.tagName.toLowerCase() : this.nodeName.toLowerCase(); }`
+
+// handleTextJS returns the element's rendered text, or its text content when it isn't rendered
+const handleTextJS = `function() { return typeof this.innerText === 'string' ? this.innerText : this.textContent; }`
+
+// handleAttributeJS returns an attribute's value, or null when the element lacks it
+const handleAttributeJS = `function(name) { return this.getAttribute(name); }`
+
+// handleScrollJS scrolls the element to the middle of the viewport
+const handleScrollJS = `function() { this.scrollIntoView({block: 'center', inline: 'center', behavior: 'instant'}); }`
+
+// generateHandleID creates an element handle identifier
+func generateHandleID() (string, error) {
+	randomBytes := make([]byte, 9)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate handle ID: %w", err)
+	}
+
+	return "hdl_" + base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// createHandle finds the first element selector finds and pins it behind a new handle
+func (s *Session) createHandle(ctx context.Context, targetID string, selector string, engine SelectorEngine) (*ElementHandle, error) {
+	s.handleMu.Lock()
+	count := len(s.handles[targetID])
+	s.handleMu.Unlock()
+	if count >= maxHandlesPerPage {
+		return nil, fmt.Errorf("%w: page already has %d handles", ErrInvalidHandle, count)
+	}
+
+	handleID, err := generateHandleID()
+	if err != nil {
+		return nil, err
+	}
+
+	objectID, err := s.findElement(ctx, targetID, selector, engine, handleObjectGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	handle := &ElementHandle{
+		ID:             handleID,
+		PageID:         targetID,
+		Selector:       selector,
+		SelectorEngine: engine,
+		CreatedAt:      time.Now(),
+		objectID:       objectID,
+	}
+	tag, err := s.callOnObject(ctx, targetID, handle.objectID, elementHandleJS)
+	if err != nil {
+		s.releaseObject(ctx, targetID, handle.objectID)
+		return nil, err
+	}
+	handle.Tag, _ = tag.(string)
+
+	s.handleMu.Lock()
+	defer s.handleMu.Unlock()
+	if s.handles == nil {
+		s.handles = make(map[string]pageHandles)
+	}
+	if s.handles[targetID] == nil {
+		s.handles[targetID] = make(pageHandles)
+	}
+	s.handles[targetID][handle.ID] = handle
+	return handle, nil
+}
+
+// handle returns a page's handle
+func (s *Session) handle(targetID string, handleID string) (*ElementHandle, error) {
+	s.handleMu.Lock()
+	defer s.handleMu.Unlock()
+
+	handle, ok := s.handles[targetID][handleID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrHandleNotFound, handleID)
+	}
+	return handle, nil
+}
+
+// listHandles returns a page's handles, oldest first
+func (s *Session) listHandles(targetID string) []ElementHandle {
+	s.handleMu.Lock()
+	defer s.handleMu.Unlock()
+
+	handles := make([]ElementHandle, 0, len(s.handles[targetID]))
+	for _, handle := range s.handles[targetID] {
+		handles = append(handles, *handle)
+	}
+	slices.SortFunc(handles, func(a, b ElementHandle) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return handles
+}
+
+// callOnHandle calls declaration with this bound to the handle's element. A handle whose
+// element the page no longer holds is forgotten and reported stale.
+func (s *Session) callOnHandle(ctx context.Context, targetID string, handleID string, declaration string, args ...interface{}) (interface{}, error) {
+	handle, err := s.handle(targetID, handleID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.callOnObject(ctx, targetID, handle.objectID, declaration, args...)
+	return result, s.checkStale(targetID, handleID, err)
+}
+
+// checkStale turns the browser refusing a handle's object into ErrHandleStale, and
+// forgets the handle
+func (s *Session) checkStale(targetID string, handleID string, err error) error {
+	var responseErr *cdp.ResponseError
+	if !errors.As(err, &responseErr) || !staleObject(responseErr) {
+		return err
+	}
+	s.handleMu.Lock()
+	delete(s.handles[targetID], handleID)
+	s.handleMu.Unlock()
+	return fmt.Errorf("%w: %s", ErrHandleStale, handleID)
+}
+
+// callOnObject calls declaration with this bound to a remote object, returning the
+// result by value
+func (s *Session) callOnObject(ctx context.Context, targetID string, objectID string, declaration string, args ...interface{}) (interface{}, error) {
+	arguments := make([]map[string]interface{}, len(args))
+	for i, arg := range args {
+		arguments[i] = map[string]interface{}{"value": arg}
+	}
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.callFunctionOn", map[string]interface{}{
+		"objectId":            objectID,
+		"functionDeclaration": declaration,
+		"arguments":           arguments,
+		"returnByValue":       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call on element: %w", err)
+	}
+	response, err := parseRuntimeResult(result)
+	if err != nil {
+		return nil, err
+	}
+	return response.Result.Value, nil
+}
+
+// releaseObject lets the page drop a remote object, best effort
+func (s *Session) releaseObject(ctx context.Context, targetID string, objectID string) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	s.CDPClient.SendCommandToTarget(releaseCtx, targetID, "Runtime.releaseObject", map[string]interface{}{
+		"objectId": objectID,
+	})
+}
+
+// releaseHandle forgets a handle and unpins its element
+func (s *Session) releaseHandle(ctx context.Context, targetID string, handleID string) error {
+	s.handleMu.Lock()
+	handle, ok := s.handles[targetID][handleID]
+	delete(s.handles[targetID], handleID)
+	s.handleMu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrHandleNotFound, handleID)
+	}
+
+	s.releaseObject(ctx, targetID, handle.objectID)
+	return nil
+}
+
+// forgetHandles drops the handles of a page whose document is gone. The browser already
+// released their objects with it. It runs on the CDP reader goroutine for navigations.
+func (s *Session) forgetHandles(targetID string) {
+	s.handleMu.Lock()
+	defer s.handleMu.Unlock()
+	delete(s.handles, targetID)
+}
+
+// forgetAllHandles drops every handle of the session (used when its pages are torn down)
+func (s *Session) forgetAllHandles() {
+	s.handleMu.Lock()
+	defer s.handleMu.Unlock()
+	s.handles = nil
+}
+
+// CreateHandle finds the first element selector finds on a page and returns a handle
+// later operations can use instead of the selector
+func (m *Manager) CreateHandle(ctx context.Context, sessionID string, pageID string, selector string, engine SelectorEngine) (*ElementHandle, error) {
+	if strings.TrimSpace(selector) == "" {
+		return nil, fmt.Errorf("%w: selector is required", ErrInvalidSelector)
+	}
+	if err := engine.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.handleSession(sessionID, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := session.createHandle(ctx, pageID, selector, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return handle, nil
+}
+
+// ListHandles returns the live handles of a page
+func (m *Manager) ListHandles(sessionID string, pageID string) ([]ElementHandle, error) {
+	session, err := m.handleSession(sessionID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	return session.listHandles(pageID), nil
+}
+
+// ReleaseHandle forgets a handle, letting the page drop its element
+func (m *Manager) ReleaseHandle(ctx context.Context, sessionID string, pageID string, handleID string) error {
+	session, err := m.handleSession(sessionID, pageID)
+	if err != nil {
+		return err
+	}
+	return session.releaseHandle(ctx, pageID, handleID)
+}
+
+// HandleText returns the rendered text of a handle's element
+func (m *Manager) HandleText(ctx context.Context, sessionID string, pageID string, handleID string) (string, error) {
+	session, err := m.handleSession(sessionID, pageID)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := session.callOnHandle(ctx, pageID, handleID, handleTextJS)
+	if err != nil {
+		return "", err
+	}
+
+	session.UpdateActivity()
+	text, _ := result.(string)
+	return text, nil
+}
+
+// HandleAttribute returns an attribute of a handle's element, nil when it has none
+func (m *Manager) HandleAttribute(ctx context.Context, sessionID string, pageID string, handleID string, name string) (*string, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: attribute name is required", ErrInvalidHandle)
+	}
+
+	session, err := m.handleSession(sessionID, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := session.callOnHandle(ctx, pageID, handleID, handleAttributeJS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	value, ok := result.(string)
+	if !ok {
+		return nil, nil
+	}
+	return &value, nil
+}
+
+// HandleLayout reports the box, visibility and occlusion of a handle's element
+func (m *Manager) HandleLayout(ctx context.Context, sessionID string, pageID string, handleID string) (*ElementLayout, error) {
+	session, err := m.handleSession(sessionID, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := session.handle(pageID, handleID)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := session.objectLayout(ctx, pageID, handle.objectID)
+	if err != nil {
+		return nil, session.checkStale(pageID, handleID, err)
+	}
+
+	session.UpdateActivity()
+	return layout, nil
+}
+
+// ClickHandle scrolls a handle's element into view and clicks the middle of its visible
+// part, as Click does for a point. req's coordinates are ignored. An element that is
+// hidden or covered is not clicked.
+func (m *Manager) ClickHandle(ctx context.Context, sessionID string, pageID string, handleID string, req ClickRequest) (*ClickResult, error) {
+	session, err := m.handleSession(sessionID, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := session.callOnHandle(ctx, pageID, handleID, handleScrollJS); err != nil {
+		return nil, err
+	}
+	layout, err := m.HandleLayout(ctx, sessionID, pageID, handleID)
+	if err != nil {
+		return nil, err
+	}
+	if !layout.Clickable || layout.ClickPoint == nil {
+		reason := "it is not visible"
+		if layout.OccludedBy != nil {
+			reason = fmt.Sprintf("it is covered by %s", layout.OccludedBy.Selector)
+		} else if layout.Occluded {
+			reason = "it is covered"
+		} else if layout.Visible && layout.InViewport {
+			reason = "it does not accept pointer events"
+		}
+		return nil, fmt.Errorf("%w: element %s can't be clicked: %s", ErrInvalidClick, handleID, reason)
+	}
+
+	req.X, req.Y = layout.ClickPoint.X, layout.ClickPoint.Y
+	req.ScrollIntoView = false
+	if handle, err := session.handle(pageID, handleID); err == nil {
+		req.Selector = handle.SelectorEngine.describe(handle.Selector)
+	}
+	return m.Click(ctx, sessionID, pageID, req)
+}
+
+// handleSession returns the session of a handle operation, checking it holds the page
+func (m *Manager) handleSession(sessionID string, pageID string) (*Session, error) {
+	// Handles are remote objects of the DevTools protocol
+	return m.devtoolsSession(sessionID, pageID, "element handles")
+}
+
+// devtoolsSession returns the session of an operation the DevTools protocol serves,
+// checking it holds the page and isn't driven over WebDriver BiDi
+func (m *Manager) devtoolsSession(sessionID string, pageID string, feature string) (*Session, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	if session.bidi != nil {
+		return nil, fmt.Errorf("%w: %s", ErrEngineUnsupported, feature)
+	}
+	return session, nil
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestElementHandles tests operating on an element through its handle, and that the
+// handle goes stale with the page's document
+func TestElementHandles(t *testing.T) {
+	var mu sync.Mutex
+	released := map[string]bool{}
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression          string `json:"expression"`
+			ObjectID            string `json:"objectId"`
+			ObjectGroup         string `json:"objectGroup"`
+			FunctionDeclaration string `json:"functionDeclaration"`
+			Arguments           []struct {
+				Value string `json:"value"`
+			} `json:"arguments"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "Runtime.releaseObject":
+			released[p.ObjectID] = true
+		case "Runtime.evaluate":
+			switch {
+			case strings.Contains(p.Expression, `"#missing"`):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "null"}}
+			case strings.HasPrefix(p.Expression, "document.querySelector") && p.ObjectGroup == handleObjectGroup:
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "node", "objectId": "obj-buy"}}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		case "Runtime.callFunctionOn":
+			if released[p.ObjectID] {
+				return map[string]interface{}{"error": map[string]interface{}{"code": -32000, "message": "Could not find object with given id"}}
+			}
+			var value interface{}
+			switch p.FunctionDeclaration {
+			case elementHandleJS:
+				value = "button"
+			case handleTextJS:
+				value = "Buy now"
+			case handleAttributeJS:
+				if p.Arguments[0].Value == "data-sku" {
+					value = "A-17"
+				}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": value}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com")
+
+	if _, err := manager.CreateHandle(ctx, sess.ID, pageID, "#missing", SelectorEngine{}); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("expected ErrElementNotFound, got %v", err)
+	}
+	handle, err := manager.CreateHandle(ctx, sess.ID, pageID, "#buy", SelectorEngine{})
+	if err != nil {
+		t.Fatalf("CreateHandle failed: %v", err)
+	}
+	if handle.Tag != "button" || !strings.HasPrefix(handle.ID, "hdl_") {
+		t.Errorf("unexpected handle: %+v", handle)
+	}
+
+	if text, err := manager.HandleText(ctx, sess.ID, pageID, handle.ID); err != nil || text != "Buy now" {
+		t.Errorf("expected the element's text, got %q, %v", text, err)
+	}
+	if value, err := manager.HandleAttribute(ctx, sess.ID, pageID, handle.ID, "data-sku"); err != nil || value == nil || *value != "A-17" {
+		t.Errorf("expected the attribute, got %v, %v", value, err)
+	}
+	if value, err := manager.HandleAttribute(ctx, sess.ID, pageID, handle.ID, "title"); err != nil || value != nil {
+		t.Errorf("expected no value for a missing attribute, got %v, %v", value, err)
+	}
+	if _, err := manager.HandleText(ctx, sess.ID, pageID, "hdl_unknown"); !errors.Is(err, ErrHandleNotFound) {
+		t.Errorf("expected ErrHandleNotFound, got %v", err)
+	}
+
+	// An element the page dropped makes the handle stale, once
+	mu.Lock()
+	released["obj-buy"] = true
+	mu.Unlock()
+	if _, err := manager.HandleText(ctx, sess.ID, pageID, handle.ID); !errors.Is(err, ErrHandleStale) {
+		t.Errorf("expected ErrHandleStale, got %v", err)
+	}
+	if handles, _ := manager.ListHandles(sess.ID, pageID); len(handles) != 0 {
+		t.Errorf("expected the stale handle forgotten, got %+v", handles)
+	}
+
+	// Navigating the page to another document forgets its handles
+	mu.Lock()
+	delete(released, "obj-buy")
+	mu.Unlock()
+	if _, err := manager.CreateHandle(ctx, sess.ID, pageID, "#buy", SelectorEngine{}); err != nil {
+		t.Fatalf("CreateHandle failed: %v", err)
+	}
+	sess.dispatchFrameNavigated(pageID, &cdp.Event{Method: "Page.frameNavigated", Params: []byte(`{"frame": {"id": "page-1", "url": "https://shop.example.com/cart"}}`)})
+	if handles, _ := manager.ListHandles(sess.ID, pageID); len(handles) != 0 {
+		t.Errorf("expected handles forgotten on navigation, got %+v", handles)
+	}
+
+	// Releasing unpins the element
+	second, err := manager.CreateHandle(ctx, sess.ID, pageID, "#buy", SelectorEngine{})
+	if err != nil {
+		t.Fatalf("CreateHandle failed: %v", err)
+	}
+	if err := manager.ReleaseHandle(ctx, sess.ID, pageID, second.ID); err != nil {
+		t.Fatalf("ReleaseHandle failed: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !released["obj-buy"] {
+		t.Error("expected the element's object released")
+	}
+}
@@ -0,0 +1,310 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+const (
+	// DefaultHealScore is the lowest score a candidate is healed to without a model's say
+	DefaultHealScore = 0.5
+
+	// healMargin is how far the best candidate must lead the next one to be picked alone
+	healMargin = 0.05
+
+	// maxHealCandidates bounds the candidates reported, and shown to a confirming model
+	maxHealCandidates = 5
+)
+
+// HealRequest asks for the element a selector that stopped matching meant, by the
+// fingerprint taken while it still matched
+type HealRequest struct {
+	Selector string
+	SelectorEngine
+	Fingerprint *ElementFingerprint
+	MinScore    float64 // DefaultHealScore when zero
+	Confirm     bool    // Let a model pick among the candidates instead of the scores alone
+	NoCache     bool    // Ask the model even when it picked among the same candidates before
+
+	system string // Prompt confirming asks the model with (healSystemPrompt when empty)
+}
+
+// HealCandidate is an element of the page that resembles the fingerprint
+type HealCandidate struct {
+	Selector    string             `json:"selector"` // Canonical CSS selector
+	Score       float64            `json:"score"`    // 0 to 1: how much of the fingerprint the element matches, weighted
+	Fingerprint ElementFingerprint `json:"fingerprint"`
+}
+
+// HealResult reports what a selector heals to
+type HealResult struct {
+	Matched     bool            `json:"matched"`                // The selector still finds an element; nothing needed healing
+	Healed      bool            `json:"healed"`                 // A replacement was picked
+	Original    string          `json:"original"`               // The selector as given
+	Selector    string          `json:"selector,omitempty"`     // What to use from now on: the original when it matched, else a CSS selector
+	Score       float64         `json:"score,omitempty"`        // The picked candidate's score
+	ConfirmedBy string          `json:"confirmed_by,omitempty"` // Model that picked the candidate
+	Cached      bool            `json:"cached,omitempty"`       // The model's pick came from the cache, spending no tokens
+	Reason      string          `json:"reason,omitempty"`       // Why nothing was healed, or why the model picked what it did
+	Candidates  []HealCandidate `json:"candidates"`             // Best first
+}
+
+// healJS scores the page's rendered elements against a fingerprint. A cheap pass over
+// tag, text, role and attributes shortlists them; the shortlist is then fingerprinted
+// in full so DOM path, nearby text and position count too. Each signal the fingerprint
+// has weighs in; the score is the weighted share matched.
+const healJS = `function(fp, limit) {` + fingerprintHelpersJS + `
+  function words(text) {
+    return String(text || '').toLowerCase().split(/[^\p{L}\p{N}]+/u).filter(Boolean);
+  }
+  function overlap(a, b) {
+    var left = new Set(words(a)), right = new Set(words(b)), shared = 0;
+    if (!left.size || !right.size) return 0;
+    left.forEach(function(word) { if (right.has(word)) shared++; });
+    return shared / (left.size + right.size - shared);
+  }
+  function similar(a, b) {
+    if (!a || !b) return 0;
+    return a.toLowerCase() === b.toLowerCase() ? 1 : overlap(a, b);
+  }
+  function sameURL(a, b) {
+    if (!a || !b) return 0;
+    try {
+      var left = new URL(a, location.href), right = new URL(b, location.href);
+      if (left.pathname !== right.pathname) return 0;
+      return left.search === right.search ? 1 : 0.75;
+    } catch (e) { return a === b ? 1 : 0; }
+  }
+  function samePath(a, b) {
+    var left = String(a || '').split(' > '), right = String(b || '').split(' > '), shared = 0;
+    while (shared < left.length && shared < right.length &&
+      left[left.length - 1 - shared] === right[right.length - 1 - shared]) shared++;
+    return shared / Math.max(left.length, right.length);
+  }
+  function near(a, b) {
+    if (!a || !b) return 0;
+    var dx = (a.x + a.width / 2) - (b.x + b.width / 2), dy = (a.y + a.height / 2) - (b.y + b.height / 2);
+    return 1 - Math.min(Math.sqrt(dx * dx + dy * dy), 1000) / 1000;
+  }
+
+  var want = fp.attributes || {};
+  function score(c) {
+    var earned = 0, possible = 0, have = c.attributes || {};
+    function signal(weight, present, similarity) {
+      if (!present) return;
+      possible += weight;
+      earned += weight * similarity;
+    }
+    signal(1, true, fp.tag === c.tag ? 1 : 0);
+    signal(1.5, fp.role, fp.role === c.role ? 1 : 0);
+    signal(3, fp.text, similar(fp.text, c.text));
+    signal(1.5, fp.name, similar(fp.name, c.name || c.text));
+    ['id', 'data-testid', 'data-test', 'data-qa', 'data-cy'].forEach(function(name) {
+      signal(3, want[name], want[name] === have[name] ? 1 : 0);
+    });
+    ['name', 'type', 'for'].forEach(function(name) {
+      signal(1.5, want[name], want[name] === have[name] ? 1 : 0);
+    });
+    ['aria-label', 'placeholder', 'title', 'alt', 'value'].forEach(function(name) {
+      signal(2, want[name], similar(want[name], have[name]));
+    });
+    ['href', 'src'].forEach(function(name) { signal(1.5, want[name], sameURL(want[name], have[name])); });
+    signal(1, want['class'], overlap(want['class'], have['class']));
+    if (c.path !== undefined) {
+      signal(1, fp.path, samePath(fp.path, c.path));
+      signal(1, fp.context, overlap(fp.context, c.context));
+      signal(1, fp.box, near(fp.box, c.box));
+    }
+    return possible ? earned / possible : 0;
+  }
+
+  var root = document.body || document.documentElement, shortlist = [];
+  var elements = root ? [root].concat(Array.from(root.querySelectorAll('*'))) : [];
+  for (var i = 0; i < elements.length; i++) {
+    var el = elements[i];
+    if (!el.getClientRects().length) continue; // Not rendered
+    var attributes = {};
+    for (var j = 0; j < fingerprintAttributes.length; j++) {
+      var value = el.getAttribute(fingerprintAttributes[j]);
+      if (value) attributes[fingerprintAttributes[j]] = value;
+    }
+    var quick = score({tag: el.tagName.toLowerCase(), text: textOf(el), role: roleOf(el),
+      name: el.getAttribute('aria-label') || '', attributes: attributes});
+    if (quick > 0.2) shortlist.push({el: el, score: quick});
+  }
+  shortlist.sort(function(a, b) { return b.score - a.score; });
+
+  var candidates = shortlist.slice(0, limit * 4).map(function(entry) {
+    var c = fingerprintOf(entry.el);
+    return {selector: c.selector, score: Math.round(score(c) * 1000) / 1000, fingerprint: c};
+  });
+  candidates.sort(function(a, b) { return b.score - a.score; });
+  return candidates.slice(0, limit);
+}`
+
+// healSystemPrompt asks a model to pick the recorded element among the candidates
+const healSystemPrompt = `You match elements of a web page. An element was recorded earlier, and the page has changed since, so its selector no longer finds it.
+Decide which of the numbered candidates is the same element, judging by what it is for: its text, role, attributes and where it sits.
+Reply with only a JSON object: {"candidate": <number of the candidate, or 0 when none of them is the element>, "reason": "<one sentence>"}`
+
+// healReply is the JSON object the model is asked for
+type healReply struct {
+	Candidate int    `json:"candidate"`
+	Reason    string `json:"reason"`
+}
+
+// heal finds the element a selector meant when it no longer finds one. A model, when
+// given and asked to confirm, picks among the candidates; otherwise the best one is
+// picked if it scores well enough and clearly ahead of the rest.
+func (s *Session) heal(ctx context.Context, targetID string, req HealRequest, model vision.Model) (*HealResult, error) {
+	result := &HealResult{Original: req.Selector, Candidates: []HealCandidate{}}
+
+	objectID, err := s.findElement(ctx, targetID, req.Selector, req.SelectorEngine, "")
+	if err == nil {
+		s.releaseObject(ctx, targetID, objectID)
+		result.Matched = true
+		result.Selector = req.Selector
+		return result, nil
+	}
+	if !errors.Is(err, ErrElementNotFound) {
+		return nil, err
+	}
+
+	fingerprintJSON, err := json.Marshal(req.Fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fingerprint: %w", err)
+	}
+	value, err := s.CallFunction(ctx, targetID, healJS, []json.RawMessage{fingerprintJSON, json.RawMessage(fmt.Sprint(maxHealCandidates))})
+	if err != nil {
+		return nil, fmt.Errorf("failed to score candidates: %w", err)
+	}
+	encoded, _ := json.Marshal(value)
+	if err := json.Unmarshal(encoded, &result.Candidates); err != nil {
+		return nil, fmt.Errorf("failed to parse candidates: %w", err)
+	}
+	if len(result.Candidates) == 0 {
+		result.Reason = "nothing on the page resembles the fingerprint"
+		return result, nil
+	}
+
+	pick := 0
+	if req.Confirm {
+		reply, answered, err := confirmHeal(ctx, model, req.system, req.Fingerprint, result.Candidates)
+		if err != nil {
+			return nil, err
+		}
+		result.ConfirmedBy = answered.Model
+		result.Cached = answered.Cached
+		result.Reason = reply.Reason
+		if reply.Candidate < 1 || reply.Candidate > len(result.Candidates) {
+			return result, nil
+		}
+		pick = reply.Candidate - 1
+	} else {
+		minScore := req.MinScore
+		if minScore == 0 {
+			minScore = DefaultHealScore
+		}
+		best := result.Candidates[0]
+		if best.Score < minScore {
+			result.Reason = fmt.Sprintf("the best candidate, %s, scores %.2f, under %.2f", best.Selector, best.Score, minScore)
+			return result, nil
+		}
+		if len(result.Candidates) > 1 && best.Score-result.Candidates[1].Score < healMargin {
+			result.Reason = fmt.Sprintf("%s and %s score alike; confirm with a model to choose", best.Selector, result.Candidates[1].Selector)
+			return result, nil
+		}
+	}
+
+	picked := result.Candidates[pick]
+	result.Healed = true
+	result.Selector = picked.Selector
+	result.Score = picked.Score
+	slog.InfoContext(ctx, "selector healed", "session_id", s.ID, "page_id", targetID,
+		"selector", req.SelectorEngine.describe(req.Selector), "healed", picked.Selector, "score", picked.Score)
+	return result, nil
+}
+
+// confirmHeal asks model which candidate is the fingerprinted element, returning its
+// pick and the reply it was read from
+func confirmHeal(ctx context.Context, model vision.Model, system string, fingerprint *ElementFingerprint, candidates []HealCandidate) (*healReply, *vision.Reply, error) {
+	if system == "" {
+		system = healSystemPrompt
+	}
+
+	recorded, _ := json.Marshal(fingerprint)
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Recorded element:\n%s\n\nCandidates:\n", recorded)
+	for i, candidate := range candidates {
+		described, _ := json.Marshal(candidate.Fingerprint)
+		fmt.Fprintf(&prompt, "%d. %s\n", i+1, described)
+	}
+
+	reply, err := model.Ask(ctx, vision.Query{System: system, Prompt: prompt.String()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to confirm with model: %w", err)
+	}
+
+	// As with vision answers, take the outermost object of whatever the model wrapped it in
+	var picked healReply
+	text := reply.Text
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start || json.Unmarshal([]byte(text[start:end+1]), &picked) != nil {
+		picked = healReply{Reason: "the model's reply wasn't understood: " + excerpt(strings.TrimSpace(text))}
+	}
+	return &picked, reply, nil
+}
+
+// validate checks the request names a selector, a usable fingerprint and a model when
+// it asks for one
+func (req *HealRequest) validate(model vision.Model) error {
+	if strings.TrimSpace(req.Selector) == "" {
+		return fmt.Errorf("%w: selector is required", ErrInvalidSelector)
+	}
+	if err := req.SelectorEngine.validate(); err != nil {
+		return err
+	}
+	if err := req.Fingerprint.validate(); err != nil {
+		return err
+	}
+	if req.MinScore < 0 || req.MinScore > 1 {
+		return fmt.Errorf("%w: min_score must be between 0 and 1", ErrInvalidHeal)
+	}
+	if req.Confirm && model == nil {
+		return fmt.Errorf("%w: confirming needs a model", ErrInvalidHeal)
+	}
+	return nil
+}
+
+// HealSelector finds the element a selector meant after it stopped matching, such as
+// after a redesign, by the fingerprint taken while it matched. The result names the
+// selector to use from now on; a selector that still matches is returned as it is.
+func (m *Manager) HealSelector(ctx context.Context, sessionID string, pageID string, req HealRequest, model vision.Model) (*HealResult, error) {
+	if err := req.validate(model); err != nil {
+		return nil, err
+	}
+
+	session, err := m.devtoolsSession(sessionID, pageID, "selector healing")
+	if err != nil {
+		return nil, err
+	}
+
+	model = m.meterModel(model, ModelCall{Operation: ModelOperationHeal, TenantID: session.TenantID, SessionID: sessionID})
+	model = m.cacheModel(model, session.TenantID, "", req.NoCache)
+	if req.Confirm {
+		req.system = m.prompts.render(session.TenantID, PromptHeal, map[string]interface{}{"Selector": req.Selector})
+	}
+	result, err := session.heal(ctx, pageID, req, model)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return result, nil
+}
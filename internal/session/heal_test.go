@@ -0,0 +1,180 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestFingerprintAndHeal tests fingerprinting an element, healing a selector to the
+// candidate that clearly leads, and declining to heal weak or tied candidates
+func TestFingerprintAndHeal(t *testing.T) {
+	var mu sync.Mutex
+	candidates := []interface{}{}
+	var typed []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression          string `json:"expression"`
+			FunctionDeclaration string `json:"functionDeclaration"`
+			Text                string `json:"text"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "Input.insertText":
+			typed = append(typed, p.Text)
+		case "Accessibility.getPartialAXTree":
+			return map[string]interface{}{"nodes": []interface{}{map[string]interface{}{
+				"nodeId": "1", "ignored": false,
+				"role": map[string]interface{}{"type": "role", "value": "button"},
+				"name": map[string]interface{}{"type": "computedString", "value": "Checkout"},
+			}}}
+		case "Runtime.evaluate":
+			switch {
+			case p.Expression == "globalThis":
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "objectId": "obj-global"}}
+			case strings.Contains(p.Expression, `"#checkout"`), strings.Contains(p.Expression, `"#email"`):
+				if strings.Contains(p.Expression, "el.focus()") {
+					return map[string]interface{}{"result": map[string]interface{}{"type": "boolean", "value": false}}
+				}
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "null"}}
+			case strings.Contains(p.Expression, "el.focus()"):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "boolean", "value": true}}
+			case strings.HasPrefix(p.Expression, "document.querySelector("):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "node", "objectId": "obj-button"}}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		case "Runtime.callFunctionOn":
+			switch p.FunctionDeclaration {
+			case fingerprintJS:
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{
+					"tag": "button", "text": "Checkout", "attributes": map[string]interface{}{"id": "checkout"},
+					"role": "generic", "path": "body > main > button", "selector": "#checkout",
+				}}}
+			case healJS:
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": candidates}}
+			}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com")
+
+	// The accessibility tree's role and name replace the page's guesses
+	fingerprint, err := manager.Fingerprint(ctx, sess.ID, pageID, "button.buy", SelectorEngine{})
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	if fingerprint.Tag != "button" || fingerprint.Role != "button" || fingerprint.Name != "Checkout" || fingerprint.Attributes["id"] != "checkout" {
+		t.Errorf("unexpected fingerprint: %+v", fingerprint)
+	}
+
+	// A selector that still matches needs no healing
+	result, err := manager.HealSelector(ctx, sess.ID, pageID, HealRequest{Selector: "button.buy", Fingerprint: fingerprint}, nil)
+	if err != nil {
+		t.Fatalf("HealSelector failed: %v", err)
+	}
+	if !result.Matched || result.Healed || result.Selector != "button.buy" {
+		t.Errorf("expected the selector to still match, got %+v", result)
+	}
+
+	candidate := func(selector string, score float64) map[string]interface{} {
+		return map[string]interface{}{"selector": selector, "score": score, "fingerprint": map[string]interface{}{"tag": "button"}}
+	}
+	for _, tc := range []struct {
+		name       string
+		candidates []interface{}
+		healed     string
+		reason     string
+	}{
+		{"clear lead", []interface{}{candidate("#go-checkout", 0.64), candidate("#cancel", 0.28)}, "#go-checkout", ""},
+		{"weak", []interface{}{candidate("#go-checkout", 0.31)}, "", "under 0.50"},
+		{"tied", []interface{}{candidate("#go-checkout", 0.64), candidate("#checkout-2", 0.62)}, "", "score alike"},
+		{"nothing", []interface{}{}, "", "nothing on the page"},
+	} {
+		mu.Lock()
+		candidates = tc.candidates
+		mu.Unlock()
+
+		result, err := manager.HealSelector(ctx, sess.ID, pageID, HealRequest{Selector: "#checkout", Fingerprint: fingerprint}, nil)
+		if err != nil {
+			t.Fatalf("%s: HealSelector failed: %v", tc.name, err)
+		}
+		if result.Matched || result.Healed != (tc.healed != "") || result.Selector != tc.healed || !strings.Contains(result.Reason, tc.reason) {
+			t.Errorf("%s: unexpected result %+v", tc.name, result)
+		}
+		if len(result.Candidates) != len(tc.candidates) {
+			t.Errorf("%s: expected %d candidates, got %d", tc.name, len(tc.candidates), len(result.Candidates))
+		}
+	}
+
+	// A model picks among candidates that tie on score
+	mu.Lock()
+	candidates = []interface{}{candidate("#go-checkout", 0.64), candidate("#checkout-2", 0.62)}
+	mu.Unlock()
+	model := &promptModel{reply: "```json\n{\"candidate\": 2, \"reason\": \"same text inside the cart\"}\n```"}
+	result, err = manager.HealSelector(ctx, sess.ID, pageID, HealRequest{Selector: "#checkout", Fingerprint: fingerprint, Confirm: true}, model)
+	if err != nil {
+		t.Fatalf("HealSelector with confirm failed: %v", err)
+	}
+	if !result.Healed || result.Selector != "#checkout-2" || result.ConfirmedBy != "prompt-model-1" || result.Reason != "same text inside the cart" {
+		t.Errorf("expected the model's pick, got %+v", result)
+	}
+	if !strings.Contains(model.prompt, "2. ") || !strings.Contains(model.prompt, `"selector":"#checkout"`) {
+		t.Errorf("expected the prompt to list the recorded element and candidates, got %q", model.prompt)
+	}
+
+	// Type steps carry on with the healed selector
+	mu.Lock()
+	candidates = []interface{}{candidate("#user-email", 0.9)}
+	mu.Unlock()
+	run, err := manager.Run(ctx, sess.ID, pageID, []RunStep{
+		{Action: StepType, Selector: "#email", Text: "ada@example.com", Fingerprint: &ElementFingerprint{Tag: "input"}},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !run.Success || run.Steps[0].Healed == nil || run.Steps[0].Healed.Selector != "#user-email" {
+		t.Errorf("expected the step to heal and pass, got %+v", run.Steps[0])
+	}
+	mu.Lock()
+	if len(typed) != 1 || typed[0] != "ada@example.com" {
+		t.Errorf("typed %v, want the text once", typed)
+	}
+	mu.Unlock()
+}
+
+// TestHealValidation tests that healing requests are checked before the page is asked
+func TestHealValidation(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	ctx := context.Background()
+	fingerprint := &ElementFingerprint{Tag: "button"}
+
+	for _, tc := range []struct {
+		req  HealRequest
+		want error
+	}{
+		{HealRequest{Fingerprint: fingerprint}, ErrInvalidSelector},
+		{HealRequest{Selector: "#a"}, ErrInvalidFingerprint},
+		{HealRequest{Selector: "#a", Fingerprint: &ElementFingerprint{Tag: " "}}, ErrInvalidFingerprint},
+		{HealRequest{Selector: "#a", Fingerprint: fingerprint, MinScore: 1.5}, ErrInvalidHeal},
+		{HealRequest{Selector: "#a", Fingerprint: fingerprint, Confirm: true}, ErrInvalidHeal},
+		{HealRequest{Selector: "#a", SelectorEngine: SelectorEngine{Engine: "css4"}, Fingerprint: fingerprint}, ErrInvalidSelector},
+	} {
+		if _, err := manager.HealSelector(ctx, "session-1", "page-1", tc.req, nil); !errors.Is(err, tc.want) {
+			t.Errorf("%+v: expected %v, got %v", tc.req, tc.want, err)
+		}
+	}
+
+	if _, err := manager.Run(ctx, "session-1", "", []RunStep{{Action: StepExtract, Selector: "h1", Fingerprint: fingerprint}}); !errors.Is(err, ErrInvalidRun) {
+		t.Errorf("expected ErrInvalidRun for a fingerprint on an extract step, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// SetHibernation makes the cleanup worker hibernate sessions left unused for after,
+// instead of keeping their browser context until they expire, and keep hibernated
+// sessions until they have been unused for keep. Zero after disables it.
+func (m *Manager) SetHibernation(after, keep time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hibernateAfter = after
+	m.hibernateKeep = keep
+}
+
+// IsHibernated reports whether the session gave up its browser context and waits to be woken
+func (s *Session) IsHibernated() bool {
+	return s.CurrentStatus() == SessionHibernated
+}
+
+// HibernateSession frees the browser context of a session, keeping what WakeSession
+// needs to bring it back
+func (m *Manager) HibernateSession(ctx context.Context, sessionID string) error {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	return m.hibernate(ctx, session, 0)
+}
+
+// hibernate snapshots a session's cookies and page URLs, then releases its context. With
+// idleFor set, a session used again since it was picked is left alone.
+func (m *Manager) hibernate(ctx context.Context, session *Session, idleFor time.Duration) error {
+	if session.bidi != nil {
+		return fmt.Errorf("%w: hibernation", ErrEngineUnsupported)
+	}
+
+	session.hibernateMu.Lock()
+	defer session.hibernateMu.Unlock()
+
+	if session.IsHibernated() || (idleFor > 0 && !session.IsExpired(idleFor)) {
+		return nil
+	}
+	m.mu.RLock()
+	_, takenOver := m.takeovers[session.ID]
+	m.mu.RUnlock()
+	if takenOver {
+		return ErrTakeoverActive
+	}
+
+	// A profile keeps its cookies on disk; anyone else's go with the context
+	snapshot := &migration{session: session}
+	if profileOf(session.Options) == "" {
+		cookies, err := session.getContextCookies(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to capture cookies: %w", err)
+		}
+		snapshot.cookies = cookies
+	}
+	for _, pageID := range session.Pages() {
+		url, err := session.GetCurrentURL(ctx, pageID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to capture page URL for hibernation", "session_id", session.ID, "page_id", pageID, "error", err)
+			continue
+		}
+		snapshot.pages = append(snapshot.pages, migratedPage{pageID: pageID, url: url})
+	}
+
+	session.stopAllScreencasts()
+	session.stopAllWatches()
+	session.stopAllRoutes()
+	session.stopAllNetworkCapture()
+	session.forgetAllHandles()
+	session.takeWarmPage() // Closed with the context
+
+	// Disposing the context closes its pages; a profile's browser is stopped instead
+	m.releaseContext(session)
+	session.resetPages()
+	session.hibernation = snapshot
+	session.setStatus(SessionHibernated)
+
+	if m.repo != nil {
+		if err := m.repo.UpdateSessionStatus(session.ID, string(SessionHibernated)); err != nil {
+			slog.WarnContext(ctx, "failed to update session status in Redis", "error", err)
+		}
+	}
+
+	m.publishEvent(session.ID, "", events.TypeSessionHibernated, map[string]interface{}{
+		"pages": len(snapshot.pages),
+	})
+	slog.InfoContext(ctx, "session hibernated", "session_id", session.ID, "pages", len(snapshot.pages))
+	return nil
+}
+
+// WakeSession brings a hibernated session back in a fresh browser context with its
+// cookies, reopening its pages at their last URLs under new page IDs. Any other session
+// is returned as it is. Either way the session counts as used, so the cleanup worker
+// doesn't hibernate it again before the caller gets to it.
+func (m *Manager) WakeSession(ctx context.Context, sessionID string) (*Session, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	session.hibernateMu.Lock()
+	defer session.hibernateMu.Unlock()
+
+	session.UpdateActivity()
+	if !session.IsHibernated() {
+		return session, nil
+	}
+
+	started := time.Now()
+	snapshot := session.hibernation
+	port := session.ProcessPort
+
+	// A profile's browser was stopped, so it comes back on a new port
+	restored := false
+	profile := profileOf(session.Options)
+	if profile != "" {
+		if port, err = m.acquireProfile(session.TenantID, session.Options); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if !restored {
+				m.releaseProfile(session.TenantID, profile, port)
+			}
+		}()
+	}
+
+	client, err := m.clientForPort(port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconnect to browser: %w", err)
+	}
+	var contextID string
+	if profile != "" {
+		contextID, err = defaultContextID(ctx, client)
+	} else {
+		contextID, err = client.CreateBrowserContextWithOptions(ctx, contextOptions(session.Options, session.egressAddr))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create browser context: %w", err)
+	}
+
+	m.mu.Lock()
+	session.ProcessPort = port
+	session.CDPClient = client
+	session.ContextID = contextID
+	m.mu.Unlock()
+	restored = true
+	m.setupDownloads(ctx, session, m.isRemote(port))
+
+	// Captured cookies already include the template's seed cookies
+	if len(snapshot.cookies) > 0 {
+		if err := session.setContextCookies(ctx, snapshot.cookies); err != nil {
+			slog.WarnContext(ctx, "failed to restore cookies", "session_id", session.ID, "error", err)
+		}
+	} else if profile == "" {
+		if err := session.applyContextOptions(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to apply session cookies", "session_id", session.ID, "error", err)
+		}
+	}
+
+	// Reopen pages, reporting old → new page IDs to subscribers
+	pageMap := make(map[string]string, len(snapshot.pages))
+	for _, page := range snapshot.pages {
+		pageID, err := session.openPage(ctx, page.url)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to reopen page", "session_id", session.ID, "url", page.url, "error", err)
+			continue
+		}
+		session.AddPage(pageID)
+		m.trackRoutes(ctx, session, pageID)
+		pageMap[page.pageID] = pageID
+	}
+
+	session.hibernation = nil
+	session.setStatus(SessionActive)
+
+	if m.repo != nil {
+		if err := m.repo.SaveSession(m.sessionToState(session)); err != nil {
+			slog.WarnContext(ctx, "failed to persist woken session", "session_id", session.ID, "error", err)
+		}
+	}
+
+	m.publishEvent(session.ID, "", events.TypeSessionWoken, map[string]interface{}{
+		"pages": pageMap,
+	})
+	slog.InfoContext(ctx, "session woken", "session_id", session.ID, "pages", len(pageMap), "took", time.Since(started))
+	return session, nil
+}
+
+// hibernateIdleSessions hibernates the sessions nobody used for the hibernation delay
+func (m *Manager) hibernateIdleSessions() {
+	m.mu.RLock()
+	after := m.hibernateAfter
+	idle := make([]*Session, 0)
+	if after > 0 {
+		for _, session := range m.sessions {
+			if session.bidi == nil && !session.IsHibernated() && session.IsExpired(after) {
+				idle = append(idle, session)
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, session := range idle {
+		if err := m.hibernate(m.ctx, session, after); err != nil {
+			slog.Warn("failed to hibernate idle session", "session_id", session.ID, "error", err)
+		}
+	}
+}
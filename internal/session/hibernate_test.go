@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHibernateAndWake tests that a hibernated session gives its context back and that
+// waking it restores its cookies and reopens its pages under new page IDs
+func TestHibernateAndWake(t *testing.T) {
+	var mu sync.Mutex
+	var disposed []string
+	var restoredCookies []string
+	var navigated []string
+
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			URL              string `json:"url"`
+			Expression       string `json:"expression"`
+			BrowserContextID string `json:"browserContextId"`
+			Cookies          []struct {
+				Name string `json:"name"`
+			} `json:"cookies"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "Target.createTarget":
+			return map[string]interface{}{"targetId": fmt.Sprintf("page-%d", len(navigated)+1)}
+		case "Page.navigate":
+			navigated = append(navigated, p.URL)
+		case "Target.disposeBrowserContext":
+			disposed = append(disposed, p.BrowserContextID)
+		case "Storage.getCookies":
+			return map[string]interface{}{"cookies": []map[string]interface{}{
+				{"name": "sid", "value": "42", "domain": "example.com", "path": "/"},
+			}}
+		case "Storage.setCookies":
+			for _, cookie := range p.Cookies {
+				restoredCookies = append(restoredCookies, cookie.Name)
+			}
+		case "Runtime.evaluate":
+			value := "https://example.com/cart"
+			if p.Expression == "document.readyState" {
+				value = "complete"
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	oldContext := sess.ContextID
+	oldPage, err := manager.Navigate(ctx, sess.ID, "https://example.com/")
+	if err != nil {
+		t.Fatalf("Navigate failed: %v", err)
+	}
+
+	// Sessions used recently are left alone
+	manager.SetHibernation(time.Hour, 0)
+	manager.hibernateIdleSessions()
+	if sess.IsHibernated() {
+		t.Fatal("expected a recently used session not to be hibernated")
+	}
+	sess.mu.Lock()
+	sess.LastActivity = time.Now().Add(-2 * time.Hour)
+	sess.mu.Unlock()
+	manager.hibernateIdleSessions()
+
+	if !sess.IsHibernated() || len(sess.Pages()) != 0 {
+		t.Fatalf("expected a hibernated session without pages, got %s with %v", sess.CurrentStatus(), sess.Pages())
+	}
+	mu.Lock()
+	if len(disposed) != 1 || disposed[0] != oldContext {
+		t.Errorf("expected context %s to be disposed, got %v", oldContext, disposed)
+	}
+	mu.Unlock()
+
+	// Hibernated sessions outlive the usual idle timeout
+	manager.SetHibernation(time.Hour, 24*time.Hour)
+	manager.cleanupExpiredSessions(time.Hour)
+	if _, err := manager.GetSession(sess.ID); err != nil {
+		t.Fatalf("expected the hibernated session to be kept, got %v", err)
+	}
+
+	if _, err := manager.WakeSession(ctx, "sess_missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound waking an unknown session, got %v", err)
+	}
+	woken, err := manager.WakeSession(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("WakeSession failed: %v", err)
+	}
+	if woken.IsHibernated() || woken.ContextID == oldContext {
+		t.Errorf("expected an active session in a new context, got %s in %s", woken.CurrentStatus(), woken.ContextID)
+	}
+	pages := woken.Pages()
+	if len(pages) != 1 || pages[0] == oldPage {
+		t.Fatalf("expected one page under a new ID, got %v", pages)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(navigated) != 2 || navigated[1] != "https://example.com/cart" {
+		t.Errorf("expected the page reopened at its last URL, got %v", navigated)
+	}
+	if strings.Join(restoredCookies, ",") != "sid" {
+		t.Errorf("expected the captured cookie to be restored, got %v", restoredCookies)
+	}
+}
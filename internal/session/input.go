@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Input event types accepted for remote control (names follow Input.dispatch* in CDP)
+var (
+	mouseEventTypes = []string{"mousePressed", "mouseReleased", "mouseMoved", "mouseWheel"}
+	keyEventTypes   = []string{"keyDown", "keyUp", "rawKeyDown", "char"}
+)
+
+// MouseInput is a mouse event in page CSS pixels
+type MouseInput struct {
+	Type       string  `json:"event"` // mousePressed, mouseReleased, mouseMoved, mouseWheel
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Button     string  `json:"button,omitempty"` // none, left, middle, right
+	ClickCount int     `json:"click_count,omitempty"`
+	Modifiers  int     `json:"modifiers,omitempty"` // Bit field: Alt=1, Ctrl=2, Meta=4, Shift=8
+	DeltaX     float64 `json:"delta_x,omitempty"`   // Wheel deltas
+	DeltaY     float64 `json:"delta_y,omitempty"`
+}
+
+// KeyInput is a keyboard event
+type KeyInput struct {
+	Type                  string `json:"event"` // keyDown, keyUp, rawKeyDown, char
+	Key                   string `json:"key,omitempty"`
+	Code                  string `json:"code,omitempty"`
+	Text                  string `json:"text,omitempty"`
+	Modifiers             int    `json:"modifiers,omitempty"`
+	WindowsVirtualKeyCode int    `json:"key_code,omitempty"`
+}
+
+// DispatchMouseEvent forwards a mouse event to the page
+func (s *Session) DispatchMouseEvent(ctx context.Context, targetID string, input MouseInput) error {
+	if !slices.Contains(mouseEventTypes, input.Type) {
+		return fmt.Errorf("unsupported mouse event type: %s", input.Type)
+	}
+
+	params := map[string]interface{}{
+		"type":      input.Type,
+		"x":         input.X,
+		"y":         input.Y,
+		"modifiers": input.Modifiers,
+	}
+
+	// Button defaults to none, which CDP requires for moves and wheels
+	button := input.Button
+	if button == "" {
+		button = "none"
+	}
+	params["button"] = button
+
+	if input.ClickCount > 0 {
+		params["clickCount"] = input.ClickCount
+	} else if input.Type == "mousePressed" || input.Type == "mouseReleased" {
+		params["clickCount"] = 1
+	}
+
+	if input.Type == "mouseWheel" {
+		params["deltaX"] = input.DeltaX
+		params["deltaY"] = input.DeltaY
+	}
+
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Input.dispatchMouseEvent", params); err != nil {
+		return fmt.Errorf("failed to dispatch mouse event: %w", err)
+	}
+
+	return nil
+}
+
+// DispatchKeyEvent forwards a keyboard event to the page
+func (s *Session) DispatchKeyEvent(ctx context.Context, targetID string, input KeyInput) error {
+	if !slices.Contains(keyEventTypes, input.Type) {
+		return fmt.Errorf("unsupported key event type: %s", input.Type)
+	}
+
+	params := map[string]interface{}{
+		"type":      input.Type,
+		"modifiers": input.Modifiers,
+	}
+	if input.Key != "" {
+		params["key"] = input.Key
+	}
+	if input.Code != "" {
+		params["code"] = input.Code
+	}
+	if input.Text != "" {
+		params["text"] = input.Text
+	}
+	if input.WindowsVirtualKeyCode != 0 {
+		params["windowsVirtualKeyCode"] = input.WindowsVirtualKeyCode
+	}
+
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Input.dispatchKeyEvent", params); err != nil {
+		return fmt.Errorf("failed to dispatch key event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// fingerprintTimeout bounds fingerprinting an element being interacted with, so a slow
+// page delays the interaction itself only so much
+const fingerprintTimeout = 2 * time.Second
+
+// Interaction is the data of an element_interacted event: what was done to which element
+type Interaction struct {
+	Action      string              `json:"action"`             // click or type
+	Selector    string              `json:"selector,omitempty"` // The selector the element was found by, prefixed with its engine unless CSS
+	Fingerprint *ElementFingerprint `json:"fingerprint"`
+}
+
+// interactiveAtJS finds the element a click at a viewport point acts on: the element
+// under it, or the control holding it, so clicking a button's icon fingerprints the button
+const interactiveAtJS = `(function(x, y) {
+  var el = document.elementFromPoint(x, y);
+  return el && (el.closest('a[href], button, input, select, textarea, label, summary, option, [role], [onclick], [tabindex]') || el);
+})(%s, %s)`
+
+// fingerprintExpression fingerprints the element expression evaluates to. It is best
+// effort: nil is returned when the expression finds no element or anything fails, as
+// interactions go ahead either way.
+func (s *Session) fingerprintExpression(ctx context.Context, targetID string, expression string) *ElementFingerprint {
+	if s.bidi != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, fingerprintTimeout)
+	defer cancel()
+
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.evaluate", map[string]interface{}{
+		"expression": expression,
+	})
+	if err != nil {
+		return nil
+	}
+	found, err := parseRuntimeResult(result)
+	if err != nil || found.Result.ObjectID == "" {
+		return nil
+	}
+	defer s.releaseObject(ctx, targetID, found.Result.ObjectID)
+
+	fingerprint, err := s.fingerprintObject(ctx, targetID, found.Result.ObjectID)
+	if err != nil {
+		return nil
+	}
+	return fingerprint
+}
+
+// fingerprintAt fingerprints the element a click at a viewport point acts on
+func (s *Session) fingerprintAt(ctx context.Context, targetID string, x float64, y float64) *ElementFingerprint {
+	xJSON, _ := json.Marshal(x)
+	yJSON, _ := json.Marshal(y)
+	return s.fingerprintExpression(ctx, targetID, fmt.Sprintf(interactiveAtJS, xJSON, yJSON))
+}
+
+// recordInteraction publishes an element_interacted event, so the session's transcript
+// holds what each interaction acted on for replays and healing. Interactions whose
+// element couldn't be fingerprinted aren't recorded.
+func (m *Manager) recordInteraction(sessionID string, pageID string, action string, selector string, fingerprint *ElementFingerprint) {
+	if fingerprint == nil {
+		return
+	}
+	m.publishEvent(sessionID, pageID, events.TypeElementInteracted, Interaction{
+		Action:      action,
+		Selector:    selector,
+		Fingerprint: fingerprint,
+	})
+}
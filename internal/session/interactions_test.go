@@ -0,0 +1,88 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// TestInteractionFingerprints tests that clicks and typing report the element they acted
+// on and record it in the session's transcript
+func TestInteractionFingerprints(t *testing.T) {
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression          string `json:"expression"`
+			ObjectID            string `json:"objectId"`
+			FunctionDeclaration string `json:"functionDeclaration"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Runtime.evaluate":
+			switch {
+			case strings.Contains(p.Expression, "inside: inside"):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{
+					"x": 50, "y": 20, "inside": true, "width": 800, "height": 600,
+					"target": map[string]interface{}{"tag": "span", "selector": "#nav-pricing > span"},
+				}}}
+			case strings.Contains(p.Expression, "elementFromPoint(x, y);\n  return el && (el.closest("):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "node", "objectId": "obj-link"}}
+			case p.Expression == "document.activeElement":
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "node", "objectId": "obj-input"}}
+			case strings.Contains(p.Expression, "el.focus()"):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "boolean", "value": true}}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		case "Runtime.callFunctionOn":
+			if p.FunctionDeclaration != fingerprintJS {
+				return nil
+			}
+			fingerprint := map[string]interface{}{"tag": "a", "text": "Pricing", "role": "link", "selector": "#nav-pricing"}
+			if p.ObjectID == "obj-input" {
+				fingerprint = map[string]interface{}{"tag": "input", "attributes": map[string]interface{}{"name": "email"}, "role": "textbox", "selector": "#email"}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": fingerprint}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com")
+
+	// The click lands on the link's text, and the link is what is fingerprinted
+	click, err := manager.Click(ctx, sess.ID, pageID, ClickRequest{X: 50, Y: 20})
+	if err != nil {
+		t.Fatalf("Click failed: %v", err)
+	}
+	if click.Element == nil || click.Element.Tag != "a" || click.Element.Selector != "#nav-pricing" {
+		t.Errorf("unexpected clicked element: %+v", click.Element)
+	}
+
+	run, err := manager.Run(ctx, sess.ID, pageID, []RunStep{{Action: StepType, Selector: "#email", Text: "ada@example.com"}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !run.Success || run.Steps[0].Element == nil || run.Steps[0].Element.Attributes["name"] != "email" {
+		t.Errorf("expected the step to report the input typed into, got %+v", run.Steps[0])
+	}
+
+	var recorded []Interaction
+	for _, event := range manager.Events().History(sess.ID, 0) {
+		if event.Type == events.TypeElementInteracted {
+			recorded = append(recorded, event.Data.(Interaction))
+		}
+	}
+	if len(recorded) != 2 || recorded[0].Action != "click" || recorded[0].Selector != "" ||
+		recorded[1].Action != "type" || recorded[1].Selector != "#email" || recorded[1].Fingerprint.Tag != "input" {
+		t.Errorf("unexpected interactions in the transcript: %+v", recorded)
+	}
+	for _, interaction := range recorded {
+		if encoded, _ := json.Marshal(interaction); strings.Contains(string(encoded), "ada@example.com") {
+			t.Errorf("expected typed text kept out of the transcript, got %s", encoded)
+		}
+	}
+}
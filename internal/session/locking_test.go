@@ -0,0 +1,240 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeBrowser is a DevTools endpoint that answers every command after latency, and
+// Target.createBrowserContext only once hold is closed (nil: right away). respond, if
+// set, supplies the result of other commands; one with an "error" key fails the command.
+type fakeBrowser struct {
+	server   *httptest.Server
+	latency  time.Duration
+	hold     chan struct{}
+	respond  func(method string, params json.RawMessage) map[string]interface{}
+	contexts atomic.Int64
+}
+
+func newFakeBrowser(latency time.Duration, hold chan struct{}, respond func(string, json.RawMessage) map[string]interface{}) *fakeBrowser {
+	browser := &fakeBrowser{latency: latency, hold: hold, respond: respond}
+	upgrader := websocket.Upgrader{}
+
+	browser.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var writeMu sync.Mutex
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			var request struct {
+				ID     int             `json:"id"`
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			json.Unmarshal(message, &request)
+
+			// Commands are answered concurrently, as a browser would
+			go func() {
+				time.Sleep(browser.latency)
+				result := map[string]interface{}{}
+				if browser.respond != nil {
+					if answer := browser.respond(request.Method, request.Params); answer != nil {
+						result = answer
+					}
+				}
+				if request.Method == "Target.createBrowserContext" {
+					if browser.hold != nil {
+						<-browser.hold
+					}
+					result["browserContextId"] = fmt.Sprintf("ctx-%d", browser.contexts.Add(1))
+				}
+
+				writeMu.Lock()
+				defer writeMu.Unlock()
+				if failure, ok := result["error"]; ok {
+					conn.WriteJSON(map[string]interface{}{"id": request.ID, "error": failure})
+					return
+				}
+				conn.WriteJSON(map[string]interface{}{"id": request.ID, "result": result})
+			}()
+		}
+	}))
+	return browser
+}
+
+func (b *fakeBrowser) wsURL() string {
+	return "ws" + strings.TrimPrefix(b.server.URL, "http")
+}
+
+// newTestManager returns a manager with a fake browser on port 9222 that answers what
+// opening a page needs: Target.createTarget makes "page-1", which attaches as
+// "cdp-page-1", and Runtime.evaluate finds the document complete. respond, if set, is
+// asked about every command first; returning nil falls back on those answers. Both are
+// closed when the test ends.
+func newTestManager(t *testing.T, respond func(method string, params json.RawMessage) map[string]interface{}) *Manager {
+	t.Helper()
+	browser := newFakeBrowser(0, nil, func(method string, params json.RawMessage) map[string]interface{} {
+		if respond != nil {
+			if answer := respond(method, params); answer != nil {
+				return answer
+			}
+		}
+		switch method {
+		case "Target.createTarget":
+			return map[string]interface{}{"targetId": "page-1"}
+		case "Target.attachToTarget":
+			var p struct {
+				TargetID string `json:"targetId"`
+			}
+			json.Unmarshal(params, &p)
+			return map[string]interface{}{"sessionId": "cdp-" + p.TargetID}
+		case "Runtime.evaluate":
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		}
+		return nil
+	})
+	t.Cleanup(browser.server.Close)
+
+	manager := NewManager(nil)
+	t.Cleanup(func() { manager.Close() })
+	manager.RegisterRemoteBrowser(9222, browser.wsURL())
+	return manager
+}
+
+// openTestPage creates a session with opts on the manager's browser at 9222 and
+// navigates it to url, failing the test if either fails
+func openTestPage(t *testing.T, manager *Manager, opts *SessionOptions, url string) (*Session, string) {
+	t.Helper()
+	ctx := context.Background()
+	sess, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", opts)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	pageID, err := manager.Navigate(ctx, sess.ID, url)
+	if err != nil {
+		t.Fatalf("Navigate failed: %v", err)
+	}
+	return sess, pageID
+}
+
+// TestCreateSessionOutsideLock tests that a creation waiting on the browser doesn't
+// block other callers, and that its reservation still counts against quotas
+func TestCreateSessionOutsideLock(t *testing.T) {
+	hold := make(chan struct{})
+	browser := newFakeBrowser(0, hold, nil)
+	defer browser.server.Close()
+
+	manager := NewManager(nil)
+	defer manager.Close()
+	manager.SetTenantQuotas(fixedQuotas{sessions: 1})
+	manager.RegisterRemoteBrowser(9222, browser.wsURL())
+	manager.sessions["other"] = &Session{ID: "other", TenantID: "beta", ProcessPort: 9223, LastActivity: time.Now()}
+
+	created := make(chan error, 1)
+	go func() {
+		_, err := manager.CreateSessionWithOptions(context.Background(), "acme", "agent-1", "", 9222, "", nil)
+		created <- err
+	}()
+
+	// Wait for the creation to take its place
+	deadline := time.Now().Add(5 * time.Second)
+	for manager.TenantUsage("acme").Sessions == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the session to be reserved")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The browser hasn't answered, yet the manager still serves other sessions
+	looked := make(chan error, 1)
+	go func() {
+		_, err := manager.GetSession("other")
+		manager.ListSessions()
+		looked <- err
+	}()
+	select {
+	case err := <-looked:
+		if err != nil {
+			t.Errorf("expected to find the other session, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lookups blocked while a session was being created")
+	}
+
+	_, err := manager.CreateSessionWithOptions(context.Background(), "acme", "agent-2", "", 9222, "", nil)
+	if !errors.Is(err, ErrTenantSessionLimit) {
+		t.Errorf("expected a reservation to count against the quota, got %v", err)
+	}
+
+	close(hold)
+	select {
+	case err := <-created:
+		if err != nil {
+			t.Fatalf("expected the session to be created, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the session to be created")
+	}
+
+	if usage := manager.TenantUsage("acme"); usage.Sessions != 1 {
+		t.Errorf("expected 1 acme session once created, got %+v", usage)
+	}
+	if len(manager.reserved) != 0 {
+		t.Errorf("expected no reservations left, got %d", len(manager.reserved))
+	}
+}
+
+// BenchmarkCreateSessionParallel measures session creation and destruction against a
+// browser that takes a few milliseconds per command. Creations overlap their round-trips,
+// so throughput grows with -cpu until the browser's own latency dominates.
+func BenchmarkCreateSessionParallel(b *testing.B) {
+	browser := newFakeBrowser(2*time.Millisecond, nil, nil)
+	defer browser.server.Close()
+
+	manager := NewManager(nil)
+	defer manager.Close()
+	manager.RegisterRemoteBrowser(9222, browser.wsURL())
+	manager.maxTotalSessions = 1 << 20
+
+	// The connection is made once, outside the timing
+	if _, err := manager.clientForPort(9222); err != nil {
+		b.Fatalf("failed to connect: %v", err)
+	}
+
+	var agents atomic.Int64
+	b.SetParallelism(16)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		agentID := fmt.Sprintf("agent-%d", agents.Add(1))
+		for pb.Next() {
+			session, err := manager.CreateSessionWithOptions(context.Background(), "", agentID, "", 9222, "", nil)
+			if err != nil {
+				b.Errorf("failed to create session: %v", err)
+				return
+			}
+			if err := manager.DestroySession(session.ID); err != nil {
+				b.Errorf("failed to destroy session: %v", err)
+				return
+			}
+		}
+	})
+}
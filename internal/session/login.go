@@ -0,0 +1,152 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginOptions controls how a login flow is located and verified
+type LoginOptions struct {
+	FormIndex          int            // Form to use, or -1 to pick the first form with a password field
+	SuccessURLContains string         // Login succeeded if the final URL contains this
+	SuccessSelector    string         // Login succeeded if this selector finds an element after submit
+	SuccessEngine      SelectorEngine // How SuccessSelector is read
+	Timeout            time.Duration  // Max wait for the post-submit navigation
+}
+
+// LoginResult reports the outcome of a login attempt. It never carries secrets.
+type LoginResult struct {
+	Success       bool       `json:"success"`
+	Reason        string     `json:"reason"`
+	URL           string     `json:"url"`
+	Navigated     bool       `json:"navigated"`
+	UsernameField string     `json:"username_field,omitempty"`
+	Change        *PageDelta `json:"page_change,omitempty"`
+}
+
+// loginFillJS locates a login form, fills username and password and submits it.
+// It returns only field names - never values - so nothing secret flows back.
+const loginFillJS = `(function(formIndex, username, password) {
+  var forms = Array.from(document.forms);
+  var form = formIndex >= 0 ? forms[formIndex] :
+    forms.filter(function(f) { return f.querySelector('input[type="password"]'); })[0];
+  var passwordEl = form ? form.querySelector('input[type="password"]') : document.querySelector('input[type="password"]');
+  if (!passwordEl) return { error: 'login_form_not_found' };
+  if (!form) form = passwordEl.form;
+
+  var scope = form || document;
+  var candidates = Array.from(scope.querySelectorAll('input')).filter(function(el) {
+    var t = (el.type || 'text').toLowerCase();
+    return t === 'text' || t === 'email' || t === 'tel';
+  });
+  var userEl = candidates.filter(function(el) {
+    return /user|email|login|account|identifier/i.test((el.name || '') + ' ' + (el.id || '') + ' ' + (el.getAttribute('autocomplete') || ''));
+  })[0] || candidates[0];
+
+  function setValue(el, v) {
+    Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value').set.call(el, v);
+    el.dispatchEvent(new Event('input', { bubbles: true }));
+    el.dispatchEvent(new Event('change', { bubbles: true }));
+  }
+  if (userEl && username) setValue(userEl, username);
+  setValue(passwordEl, password);
+
+  window.` + navigationMarker + ` = true;
+  if (form) {
+    if (typeof form.requestSubmit === 'function') { form.requestSubmit(); } else { form.submit(); }
+  } else {
+    var button = document.querySelector('button[type="submit"], input[type="submit"]');
+    if (!button) return { error: 'login_submit_not_found' };
+    button.click();
+  }
+
+  return { username_field: userEl ? (userEl.name || userEl.id || '') : '' };
+})(%d, %s, %s)`
+
+// loginVerifyJS inspects the page after submit for success signals
+const loginVerifyJS = `(function(selectorFound) {
+  var pw = document.querySelector('input[type="password"]');
+  return {
+    url: location.href,
+    password_visible: !!(pw && pw.offsetParent !== null),
+    selector_found: selectorFound
+  };
+})(%s)`
+
+// Login fills and submits a login form and verifies whether the login succeeded
+func (s *Session) Login(ctx context.Context, targetID string, username string, password string, opts LoginOptions) (*LoginResult, error) {
+	startURL, _ := s.GetCurrentURL(ctx, targetID)
+
+	usernameJSON, _ := json.Marshal(username)
+	passwordJSON, _ := json.Marshal(password)
+
+	// Errors are reported without the script, which contains the secret
+	result, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(loginFillJS, opts.FormIndex, usernameJSON, passwordJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fill login form")
+	}
+
+	fill, _ := result.(map[string]interface{})
+	if errCode, ok := fill["error"].(string); ok {
+		return nil, fmt.Errorf("%w: %s", ErrLoginFormNotFound, errCode)
+	}
+
+	loginResult := &LoginResult{}
+	if field, ok := fill["username_field"].(string); ok {
+		loginResult.UsernameField = field
+	}
+
+	// Wait for the post-submit navigation
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultFormSubmitTimeout
+	}
+	navigated, err := s.WaitForNavigation(ctx, targetID, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed waiting for navigation: %w", err)
+	}
+	loginResult.Navigated = navigated
+
+	// Inspect the resulting page
+	foundJS := "false"
+	if opts.SuccessSelector != "" {
+		foundJS = "!!" + opts.SuccessEngine.first(opts.SuccessSelector)
+	}
+	verifyRaw, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(loginVerifyJS, foundJS))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify login: %w", err)
+	}
+
+	verify, _ := verifyRaw.(map[string]interface{})
+	loginResult.URL, _ = verify["url"].(string)
+	passwordVisible, _ := verify["password_visible"].(bool)
+	selectorFound, _ := verify["selector_found"].(bool)
+
+	// Explicit success criteria win over heuristics
+	switch {
+	case opts.SuccessSelector != "":
+		loginResult.Success = selectorFound
+		loginResult.Reason = "success selector not found"
+		if selectorFound {
+			loginResult.Reason = "success selector matched"
+		}
+	case opts.SuccessURLContains != "":
+		loginResult.Success = strings.Contains(loginResult.URL, opts.SuccessURLContains)
+		loginResult.Reason = "final URL did not match"
+		if loginResult.Success {
+			loginResult.Reason = "final URL matched"
+		}
+	case passwordVisible:
+		loginResult.Reason = "password field still visible"
+	case loginResult.URL == startURL && !navigated:
+		loginResult.Reason = "page did not change after submit"
+	default:
+		loginResult.Success = true
+		loginResult.Reason = "password field gone after submit"
+	}
+
+	return loginResult, nil
+}
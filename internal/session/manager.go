@@ -4,30 +4,106 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log/slog"
-	"strconv"
+	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dhruvsoni1802/browser-query-ai/internal/bidi"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/egress"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pipeline"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/seeds"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/sessionlog"
 	"github.com/dhruvsoni1802/browser-query-ai/internal/storage"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/visual"
 )
 
 // Manager manages all active sessions and CDP connections
 type Manager struct {
 	sessions   map[string]*Session
 	cdpClients map[int]*cdp.Client
+	observers  map[string]*Observer
+	takeovers  map[string]*Takeover // Session ID → active human takeover
 	mu         sync.RWMutex
 	ctx        context.Context
 	cancel     context.CancelFunc
 	repo       *storage.SessionRepository
+	events     *events.Bus
+	templates  *TemplateRegistry
+	prompts    *PromptRegistry    // System prompts models are asked with
+	pipelines  *pipeline.Registry // Result pipelines referenced by sessions and extract requests
+	warm       *warmPool // Pre-created contexts (nil when the warm pool is disabled)
+	migrations map[int][]*migration // Port → sessions waiting for their browser to restart
+	remotes    map[int]string       // Port handle → endpoint of an external browser
+	timeouts   cdp.Timeouts         // Command timeouts applied to every browser connection
+	scripts    int                  // Scripts each browser runs at once (0: no limit)
+	quotas     TenantQuotas         // Per-tenant limits (nil: none)
+	work       workDirs             // Per-session directories on disk
+	profiles   ProfileLauncher      // Browsers for sessions with a persistent profile (nil: disabled)
+	checks     checks               // Scheduled synthetic checks
+	baselines  *visual.Store        // Screenshots pages are compared against
+	seeds      *seeds.Fetcher       // Expands the sitemaps and feeds seeded checks run on
+	searcher   search.Backend       // Runs POST /search queries (nil: disabled)
+	memory     MemoryLimits         // What each session remembers of its conversation
+	rendering  *SessionOptions      // Locale, timezone and animations sessions start with (nil: the browser's)
+	onCommand  atomic.Pointer[func(port int, failed bool)] // Told how each browser command went
+
+	// Port → connection to a Firefox browser, shared by the sessions on it
+	bidiClients map[int]*bidi.Client
+
+	// Vault the client certificates sessions name are read from (nil: disabled)
+	clientCertSource ClientCertificateSource
+
+	// Forward proxy every Chromium session's context sends its traffic through (nil: none)
+	egress *egress.Proxy
+
+	// What metered sessions downloaded, overall and per tenant
+	bandwidth bandwidthLedger
+
+	// What model calls used per tenant and day, and the budgets they are held to
+	modelUsage modelUsageLedger
+
+	// Model answers kept for identical queries against unchanged pages
+	answers answerCache
+
+	// Unused sessions are hibernated after hibernateAfter (0: never), then kept for at
+	// least hibernateKeep of inactivity before they expire
+	hibernateAfter time.Duration
+	hibernateKeep  time.Duration
+
+	// Browser I/O done without mu held
+	reserved map[string]reservation // Session ID → place of a session whose context is being set up
+	dialMu   sync.Mutex             // Serializes connecting to browsers
+
+	// Size limits on what browsers send back
+	readLimit   int64 // Largest DevTools message accepted (0: no limit)
+	maxResponse int64 // Largest page content, screenshot or script result returned (0: DefaultMaxResponseSize)
+
+	// Drain state; new sessions are refused once drain is set
+	drainMu      sync.Mutex
+	drain        *drainState
+	drained      chan struct{} // Closed when the drain finishes
+	drainTimeout time.Duration
 
 	// Session limits
 	maxSessionsPerAgent int 
 	maxTotalSessions    int
 }
 
+// reservation holds a session's place while its browser context is set up. Browser
+// round-trips happen without m.mu, so quotas, limits and drains count reservations
+// alongside live sessions.
+type reservation struct {
+	tenantID string
+	port     int
+}
+
 // NewManager creates a new session manager
 func NewManager(repo *storage.SessionRepository) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -35,11 +111,29 @@ func NewManager(repo *storage.SessionRepository) *Manager {
 	return &Manager{
 		sessions:   make(map[string]*Session),
 		cdpClients: make(map[int]*cdp.Client),
+		bidiClients: make(map[int]*bidi.Client),
+		observers:  make(map[string]*Observer),
+		takeovers:  make(map[string]*Takeover),
 		ctx:        ctx,
 		cancel:     cancel,
 		repo:        repo,
+		events:     events.NewBus(events.DefaultHistorySize),
+		templates:  NewTemplateRegistry(),
+		prompts:    NewPromptRegistry(),
+		pipelines:  pipeline.NewRegistry(),
+		migrations: make(map[int][]*migration),
+		reserved:   make(map[string]reservation),
+		remotes:    make(map[int]string),
+		work:       workDirs{blocked: make(map[string]bool)},
+		baselines:  visual.NewMemoryStore(),
+		seeds:      seeds.NewFetcher(),
+		checks:     checks{states: make(map[templateKey]*checkState), client: &http.Client{Timeout: checkAlertTimeout}},
+		timeouts:   cdp.DefaultTimeouts(),
+		drained:    make(chan struct{}),
+		drainTimeout: DefaultDrainTimeout,
 		maxSessionsPerAgent: MaxSessionsPerAgent,
 		maxTotalSessions: MaxTotalSessions,
+		memory:     MemoryLimits{Turns: DefaultMemoryTurns, Bytes: DefaultMemoryBytes},
 	}
 }
 
@@ -61,7 +155,8 @@ func generateSessionID() (string, error) {
 	return "sess_" + sessionID, nil
 }
 
-// GetOrCreateCDPClient gets existing client or creates new one for a port
+// GetOrCreateCDPClient gets existing client or creates new one for a port. Caller must
+// hold m.mu; code that can let go of the lock while connecting uses clientForPort.
 func (m *Manager) GetOrCreateCDPClient(port int) (*cdp.Client, error) {
 	// Check if the client already exists for this port
 	client, exists := m.cdpClients[port]
@@ -69,44 +164,180 @@ func (m *Manager) GetOrCreateCDPClient(port int) (*cdp.Client, error) {
 		return client, nil
 	}
 
-	// If the client does not exist, discover the WebSocket URL
-	// TODO: Change this later so that we can use something other than localhost such as actual IP address of the machine
-	wsURL, err := cdp.GetWebSocketURL("localhost", strconv.Itoa(port))
+	endpoint, remote := m.remotes[port]
+	client, err := m.dialCDPClient(port, endpoint, remote, m.timeouts, m.readLimit, m.scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the client to the manager
+	m.cdpClients[port] = client
+	return client, nil
+}
+
+// clientForPort returns the connection to the browser on port, connecting without
+// m.mu held so a slow or dead browser doesn't stall requests for other sessions.
+// Connections are made one at a time, so each port still gets a single client.
+func (m *Manager) clientForPort(port int) (*cdp.Client, error) {
+	m.mu.RLock()
+	client, exists := m.cdpClients[port]
+	m.mu.RUnlock()
+	if exists {
+		return client, nil
+	}
+
+	m.dialMu.Lock()
+	defer m.dialMu.Unlock()
+
+	m.mu.RLock()
+	client, exists = m.cdpClients[port]
+	endpoint, remote := m.remotes[port]
+	timeouts, readLimit, scripts := m.timeouts, m.readLimit, m.scripts
+	m.mu.RUnlock()
+	if exists {
+		return client, nil
+	}
+
+	client, err := m.dialCDPClient(port, endpoint, remote, timeouts, readLimit, scripts)
+	if err != nil {
+		return nil, err
+	}
+
+	// A caller holding the lock may have connected in the meantime; keep its client
+	m.mu.Lock()
+	if existing, exists := m.cdpClients[port]; exists {
+		m.mu.Unlock()
+		client.Close()
+		return existing, nil
+	}
+	m.cdpClients[port] = client
+	m.mu.Unlock()
+
+	return client, nil
+}
+
+// dialCDPClient connects to the browser on port and starts watching its connection
+// state and targets. It takes no locks.
+func (m *Manager) dialCDPClient(port int, endpoint string, remote bool, timeouts cdp.Timeouts, readLimit int64, scripts int) (*cdp.Client, error) {
+	// Discover the WebSocket URL (remote browsers may live elsewhere)
+	wsURL, err := resolveWebSocketURL(port, endpoint, remote)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover WebSocket URL: %w", err)
 	}
 
 	// Create a new CDP client and connect to it
-	client = cdp.NewClient(wsURL)
+	client := cdp.NewClient(wsURL)
+	client.SetTimeouts(timeouts)
+	client.SetReadLimit(readLimit)
+	client.SetScriptLimit(scripts)
 	if err := client.Connect(); err != nil {
 		return nil, fmt.Errorf("failed to connect to CDP client: %w", err)
 	}
 
-	// Add the client to the manager
-	m.cdpClients[port] = client
+	// The browser-level URL changes if the browser restarts, so look it up again on reconnect
+	client.SetDiscovery(func() (string, error) {
+		return resolveWebSocketURL(port, endpoint, remote)
+	})
+	client.OnStateChange(func(state cdp.ConnectionState) {
+		m.handleConnectionState(port, client, state)
+	})
+	client.OnCommand(func(method string, failed bool) {
+		if observer := m.onCommand.Load(); observer != nil {
+			(*observer)(port, failed)
+		}
+	})
+
+	// Adopt pages the sites open themselves (popups, target=_blank)
+	if err := m.watchTargets(client); err != nil {
+		slog.Warn("failed to enable target discovery", "port", port, "error", err)
+	}
+
 	return client, nil
 }
 
-// CreateSession creates a new isolated browsing session
-func (m *Manager) CreateSession(port int) (*Session, error) {
-	// Acquire write lock to prevent concurrent access
+// reserveLocked holds a place for a session whose browser context is about to be set
+// up without the lock. Caller must hold m.mu.
+func (m *Manager) reserveLocked(sessionID, tenantID string, port int) {
+	m.reserved[sessionID] = reservation{tenantID: tenantID, port: port}
+}
+
+// releaseReservation gives up the place of a session whose setup failed
+func (m *Manager) releaseReservation(sessionID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	delete(m.reserved, sessionID)
+}
+
+// registerSession replaces a session's reservation with the session itself
+func (m *Manager) registerSession(session *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.reserved, session.ID)
+	m.sessions[session.ID] = session
+}
 
+// SetCommandTimeouts changes the CDP command timeouts of current and future browser connections
+func (m *Manager) SetCommandTimeouts(timeouts cdp.Timeouts) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.timeouts = timeouts
+	for _, client := range m.cdpClients {
+		client.SetTimeouts(timeouts)
+	}
+}
+
+// SetScriptConcurrency bounds how many scripts each current and future browser connection
+// runs at once; scripts past it queue for up to the evaluate timeout. 0 removes the limit.
+func (m *Manager) SetScriptConcurrency(limit int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.scripts = limit
+	for _, client := range m.cdpClients {
+		client.SetScriptLimit(limit)
+	}
+}
+
+// OnCommand sets a callback told, for every DevTools command sent to a browser, its port
+// and whether the browser was at fault (see cdp.IsBrowserFault). It runs on the
+// caller's goroutine and must be quick.
+func (m *Manager) OnCommand(observer func(port int, failed bool)) {
+	m.onCommand.Store(&observer)
+}
+
+// CreateSession creates a new isolated browsing session
+func (m *Manager) CreateSession(ctx context.Context, port int) (*Session, error) {
 	// Generate a unique session ID
 	sessionID, err := generateSessionID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate session ID: %w", err)
 	}
 
+	// Hold the session's place; the browser is only talked to without the lock
+	m.mu.Lock()
+	if m.IsDraining() {
+		m.mu.Unlock()
+		return nil, ErrDraining
+	}
+	m.reserveLocked(sessionID, "", port)
+	m.mu.Unlock()
+
+	registered := false
+	defer func() {
+		if !registered {
+			m.releaseReservation(sessionID)
+		}
+	}()
+
 	// Get or create a CDP client for the given port
-	client, err := m.GetOrCreateCDPClient(port)
+	client, err := m.clientForPort(port)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get or create CDP client: %w", err)
 	}
 
 	// Create a browser context on the browser process
-	contextID, err := client.CreateBrowserContext()
+	contextID, err := client.CreateBrowserContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create browser context: %w", err)
 	}
@@ -125,7 +356,8 @@ func (m *Manager) CreateSession(port int) (*Session, error) {
 	}
 
 	// Add the session to the manager
-	m.sessions[sessionID] = session
+	m.registerSession(session)
+	registered = true
 
 	// Return the session
 	return session, nil
@@ -140,7 +372,7 @@ func (m *Manager) GetSession(sessionID string) (*Session, error) {
 	// Look up session in map
 	session, exists := m.sessions[sessionID]
 	if !exists {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
 	}
 
 	return session, nil
@@ -148,46 +380,66 @@ func (m *Manager) GetSession(sessionID string) (*Session, error) {
 
 // DestroySession cleans up all resources for a session
 func (m *Manager) DestroySession(sessionID string) error {
+	// Take the session out of the manager first, so no new request finds it while its
+	// browser resources are released without the lock
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	session, exists := m.sessions[sessionID]
+	if exists {
+		delete(m.sessions, sessionID)
+		m.removeObserversLocked(sessionID)
+		m.endTakeoverLocked(sessionID)
+	}
+	m.mu.Unlock()
 	
 	// If session is in memory, clean up browser resources
 	if exists {
+		session.stopAllScreencasts()
+		session.stopAllWatches()
+		session.stopAllRoutes()
+		session.stopAllNetworkCapture()
+		session.forgetAllHandles()
+
 		// Close all pages
-		for _, pageID := range session.PageIDs {
-			if err := session.CDPClient.CloseTarget(pageID); err != nil {
+		for _, pageID := range session.Pages() {
+			if err := session.Driver().ClosePage(context.Background(), pageID); err != nil {
 				slog.Warn("failed to close page", "page_id", pageID, "error", err)
 			}
 		}
 
-		// Dispose browser context
-		if err := session.CDPClient.DisposeBrowserContext(session.ContextID); err != nil {
-			slog.Warn("failed to dispose browser context", "error", err)
-			// Don't fail - continue with cleanup
-		}
+		// Dispose browser context (failures don't stop the cleanup)
+		m.releaseContext(session)
+		m.unregisterEgress(sessionID)
 
-		// Mark as closed and remove from memory
-		session.Status = SessionClosed
-		delete(m.sessions, sessionID)
+		// Mark as closed
+		session.setStatus(SessionClosed)
+
+		// Notify subscribers, then drop the session's event history
+		m.publishEvent(sessionID, "", events.TypeSessionDestroyed, nil)
+		m.events.Forget(sessionID)
 	} else {
 		// Session not in memory - might be idle in Redis
 		slog.Info("destroying session not in memory (likely idle)", "session_id", sessionID)
 	}
 
+	// Drop what pipelines remember about the session's records, and its log lines
+	m.pipelines.ForgetSession(sessionID)
+	sessionlog.Default().Forget(sessionID)
+
+	// Downloads and other files go with the session, whether it was live or idle
+	m.removeWorkDir(sessionID)
+
 	// Delete from Redis (works whether session is in memory or not)
 	if m.repo != nil {
 		if err := m.repo.DeleteSession(sessionID); err != nil {
 			slog.Warn("failed to delete session from Redis", "error", err)
 			// If session wasn't in memory and not in Redis, that's an error
 			if !exists {
-				return fmt.Errorf("session not found: %s", sessionID)
+				return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
 			}
 		}
 	} else if !exists {
 		// No Redis and not in memory = truly not found
-		return fmt.Errorf("session not found: %s", sessionID)
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
 	}
 
 	slog.Info("session destroyed", 
@@ -225,6 +477,12 @@ func (m *Manager) Close() error {
 	// Signal cleanup worker to stop
 	m.cancel()
 
+	// Give back contexts nobody claimed
+	m.drainWarmPool(0)
+
+	// Flush and close pipeline sinks
+	m.pipelines.Close()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -235,9 +493,18 @@ func (m *Manager) Close() error {
 		}
 	}
 
+	for port, client := range m.bidiClients {
+		if err := client.Close(); err != nil {
+			slog.Warn("failed to close BiDi client", "port", port, "error", err)
+		}
+	}
+
 	// Clear maps
 	m.sessions = make(map[string]*Session)
 	m.cdpClients = make(map[int]*cdp.Client)
+	m.bidiClients = make(map[int]*bidi.Client)
+	m.observers = make(map[string]*Observer)
+	m.takeovers = make(map[string]*Takeover)
 
 	return nil
 }
@@ -272,7 +539,12 @@ func (m *Manager) cleanupExpiredSessions(timeout time.Duration) {
 	expiredIDs := make([]string, 0)
 	
 	for sessionID, session := range m.sessions {
-		if session.IsExpired(timeout) {
+		// Hibernated sessions cost next to nothing, so they may be kept longer
+		limit := session.idleTimeout(timeout)
+		if session.IsHibernated() && m.hibernateKeep > limit {
+			limit = m.hibernateKeep
+		}
+		if session.IsExpired(limit) {
 			expiredIDs = append(expiredIDs, sessionID)
 		}
 	}
@@ -295,23 +567,39 @@ func (m *Manager) cleanupExpiredSessions(timeout time.Duration) {
 			}
 		}
 	}
+
+	// Phase 3: Free the contexts of sessions idle for a while, but not long enough to expire
+	m.hibernateIdleSessions()
 }
 
 // CreateSessionWithName creates a new session with optional name and agent ID
-func (m *Manager) CreateSessionWithName(agentID, sessionName string, port int) (*Session, error) {
+func (m *Manager) CreateSessionWithName(ctx context.Context, agentID, sessionName string, port int) (*Session, error) {
+	return m.CreateSessionWithOptions(ctx, "", agentID, sessionName, port, "", nil)
+}
+
+// CreateSessionWithOptions creates a new session for a tenant's agent whose pages are
+// configured by opts. templateName is recorded for reference only; callers resolve it
+// with ResolveSessionOptions.
+func (m *Manager) CreateSessionWithOptions(ctx context.Context, tenantID, agentID, sessionName string, port int, templateName string, opts *SessionOptions) (*Session, error) {
 	// Validate agent ID is provided
 	if agentID == "" {
 		return nil, fmt.Errorf("agent_id is required")
 	}
 	
 	// Check session limits
-	if err := m.checkSessionLimits(agentID); err != nil {
+	if err := m.checkSessionLimits(tenantID, agentID); err != nil {
+		return nil, err
+	}
+
+	// Read up front so a missing certificate fails the creation before any browser work
+	clientCerts, err := m.resolveClientCertificates(tenantID, opts)
+	if err != nil {
 		return nil, err
 	}
 	
 	// If name provided, check for conflicts
 	if sessionName != "" && m.repo != nil {
-		exists, err := m.repo.CheckSessionNameExists(agentID, sessionName)
+		exists, err := m.repo.CheckSessionNameExists(tenantID, agentID, sessionName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check session name: %w", err)
 		}
@@ -320,23 +608,98 @@ func (m *Manager) CreateSessionWithName(agentID, sessionName string, port int) (
 		}
 	}
 	
-	// Create the session (existing logic)
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	sessionID, err := generateSessionID()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate session ID: %w", err)
 	}
 
-	client, err := m.GetOrCreateCDPClient(port)
+	// A profile session gets a browser of its own instead of the one it was placed on
+	registered := false
+	profile := profileOf(opts)
+	if profile != "" {
+		if port, err = m.acquireProfile(tenantID, opts); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if !registered {
+				m.releaseProfile(tenantID, profile, port)
+			}
+		}()
+	}
+
+	// Checked together with the reservation so concurrent creations can't overshoot the
+	// quotas, and a drain that just started counts every session
+	m.mu.Lock()
+	if m.IsDraining() {
+		m.mu.Unlock()
+		return nil, ErrDraining
+	}
+	if err := m.checkTenantQuotasLocked(tenantID, port); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	m.reserveLocked(sessionID, tenantID, port)
+	_, remote := m.remotes[port]
+	m.mu.Unlock()
+
+	defer func() {
+		if !registered {
+			m.releaseReservation(sessionID)
+		}
+	}()
+
+	// The context is created pointing at the session's own listener on the egress proxy
+	egressAddr, err := m.registerEgress(sessionID, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get or create CDP client: %w", err)
+		return nil, err
+	}
+	defer func() {
+		if !registered {
+			m.unregisterEgress(sessionID)
+		}
+	}()
+
+	// Firefox sessions are set up over BiDi, without the Chromium-only steps below
+	if engineOf(opts) == EngineFirefox {
+		session, err := m.newBiDiSession(ctx, port, opts)
+		if err != nil {
+			return nil, err
+		}
+		session.ID = sessionID
+		session.Name = sessionName
+		session.AgentID = agentID
+		session.TenantID = tenantID
+		session.Template = templateName
+		m.addCreatedSession(session, false)
+		registered = true
+		return session, nil
 	}
 
-	contextID, err := client.CreateBrowserContext()
+	// From here on the browser is talked to without the lock
+	client, err := m.clientForPort(port)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create browser context: %w", err)
+		return nil, fmt.Errorf("failed to get or create CDP client: %w", err)
+	}
+
+	// Claim a pre-created context when one matches, otherwise create it now. A profile
+	// lives in the browser's default context.
+	var warm *warmContext
+	if profile == "" {
+		warm = m.warm.claim(port, opts)
+	}
+	var contextID string
+	if warm != nil {
+		contextID = warm.contextID
+	} else if profile != "" {
+		contextID, err = defaultContextID(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find profile context: %w", err)
+		}
+	} else {
+		contextID, err = client.CreateBrowserContextWithOptions(ctx, contextOptions(opts, egressAddr))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create browser context: %w", err)
+		}
 	}
 
 	// Create session with name
@@ -344,6 +707,7 @@ func (m *Manager) CreateSessionWithName(agentID, sessionName string, port int) (
 		ID:                sessionID,
 		Name:              sessionName,  // ← ADD (will be auto-generated if empty)
 		AgentID:           agentID,      // ← ADD
+		TenantID:          tenantID,
 		ProcessPort:       port,
 		ContextID:         contextID,
 		PageIDs:           []string{},
@@ -351,16 +715,45 @@ func (m *Manager) CreateSessionWithName(agentID, sessionName string, port int) (
 		CreatedAt:         time.Now(),
 		LastActivity:      time.Now(),
 		Status:            SessionActive,
+		Template:          templateName,
+		Options:           opts,
+		workDir:           m.prepareWorkDir(sessionID),
+		clientCerts:       clientCerts,
+		egressAddr:        egressAddr,
+		bandwidth:         m.newBandwidthMeter(tenantID, opts),
 		pageAnalysisCache: make(map[string]*PageStructure),
 	}
+	m.setupDownloads(ctx, session, remote)
+
+	// Seed the cookie jar before any page exists (warm contexts were seeded when created)
+	if warm != nil {
+		session.warmPageID = warm.pageID
+	} else if err := session.applyContextOptions(ctx); err != nil {
+		if profile == "" {
+			if disposeErr := client.DisposeBrowserContext(ctx, contextID); disposeErr != nil {
+				slog.WarnContext(ctx, "failed to dispose browser context", "error", disposeErr)
+			}
+		}
+		m.removeWorkDir(sessionID)
+		return nil, err
+	}
+
+	m.addCreatedSession(session, warm != nil)
+	registered = true
+
+	return session, nil
+}
 
+// addCreatedSession names a new session if it has no name, registers it in place of its
+// reservation, persists it and announces it
+func (m *Manager) addCreatedSession(session *Session, warm bool) {
 	// Auto-generate name if not provided
 	if session.Name == "" {
 		session.Name = m.generateSessionName(session)
 	}
 
 	// Add to manager
-	m.sessions[sessionID] = session
+	m.registerSession(session)
 
 	// Persist to Redis
 	if m.repo != nil {
@@ -370,29 +763,52 @@ func (m *Manager) CreateSessionWithName(agentID, sessionName string, port int) (
 		}
 	}
 
+	m.publishEvent(session.ID, "", events.TypeSessionCreated, map[string]interface{}{
+		"session_name": session.Name,
+		"agent_id":     session.AgentID,
+	})
+
 	slog.Info("session created", 
 		"session_id", session.ID,
 		"session_name", session.Name,
-		"agent_id", agentID,
-		"port", port)
+		"agent_id", session.AgentID,
+		"tenant_id", session.TenantID,
+		"template", session.Template,
+		"engine", session.Engine(),
+		"warm", warm,
+		"port", session.ProcessPort)
+}
 
-	return session, nil
+// contextOptions extracts the browser-context-level settings from session options.
+// A context with an egress listener sends everything there, loopback included, and the
+// proxy forwards to the session's own proxy.
+func contextOptions(opts *SessionOptions, egressAddr string) cdp.BrowserContextOptions {
+	if egressAddr != "" {
+		return cdp.BrowserContextOptions{ProxyServer: "http://" + egressAddr, ProxyBypassList: "<-loopback>"}
+	}
+	if opts == nil {
+		return cdp.BrowserContextOptions{}
+	}
+	return cdp.BrowserContextOptions{
+		ProxyServer:     opts.Proxy,
+		ProxyBypassList: opts.ProxyBypass,
+	}
 }
 
 // Helper: Check if agent is within session limits
-func (m *Manager) checkSessionLimits(agentID string) error {
+func (m *Manager) checkSessionLimits(tenantID, agentID string) error {
 	// Check total sessions
 	m.mu.RLock()
-	totalSessions := len(m.sessions)
+	totalSessions := len(m.sessions) + len(m.reserved)
 	m.mu.RUnlock()
 	
 	if totalSessions >= m.maxTotalSessions {
-		return fmt.Errorf("global session limit reached (%d)", m.maxTotalSessions)
+		return fmt.Errorf("%w (%d)", ErrGlobalSessionLimit, m.maxTotalSessions)
 	}
 	
 	// Check per-agent limit (from Redis)
 	if m.repo != nil {
-		count, err := m.repo.CountAgentSessions(agentID)
+		count, err := m.repo.CountAgentSessions(tenantID, agentID)
 		if err != nil {
 			slog.Warn("failed to count agent sessions", "error", err)
 			// Don't block on Redis error
@@ -418,29 +834,41 @@ func (m *Manager) generateSessionName(session *Session) string {
 // Helper: Convert Session to SessionState for Redis
 func (m *Manager) sessionToState(s *Session) *storage.SessionState {
 	// Collect page states
-	pages := make([]storage.PageState, len(s.PageIDs))
-	for i, pageID := range s.PageIDs {
+	pageIDs := s.Pages()
+	pages := make([]storage.PageState, len(pageIDs))
+	for i, pageID := range pageIDs {
 		pages[i] = storage.PageState{
 			PageID: pageID,
 			// URL and Title could be fetched if needed
 		}
 	}
 	
+	// Options are persisted so a resurrected session is recreated with the same setup
+	var options json.RawMessage
+	if s.Options != nil {
+		if data, err := json.Marshal(s.Options); err == nil {
+			options = data
+		}
+	}
+
 	return &storage.SessionState{
 		SessionID:    s.ID,
 		SessionName:  s.Name,
 		AgentID:      s.AgentID,
+		TenantID:     s.TenantID,
 		ProcessPort:  s.ProcessPort,
 		ContextID:    s.ContextID,
 		CreatedAt:    s.CreatedAt,
-		LastActivity: s.LastActivity,
-		Status:       string(s.Status),
+		LastActivity: s.LastActive(),
+		Status:       string(s.CurrentStatus()),
+		Template:     s.Template,
+		Options:      options,
 		Pages:        pages,
 	}
 }
 
-// ResumeSessionByName resumes a session by agent ID and session name
-func (m *Manager) ResumeSessionByName(agentID, sessionName string) (*Session, error) {
+// ResumeSessionByName resumes a session by tenant, agent ID and session name
+func (m *Manager) ResumeSessionByName(ctx context.Context, tenantID, agentID, sessionName string) (*Session, error) {
 	if agentID == "" || sessionName == "" {
 		return nil, fmt.Errorf("agent_id and session_name are required")
 	}
@@ -450,7 +878,7 @@ func (m *Manager) ResumeSessionByName(agentID, sessionName string) (*Session, er
 		return nil, fmt.Errorf("Redis not configured")
 	}
 	
-	sessionID, err := m.repo.GetSessionByName(agentID, sessionName)
+	sessionID, err := m.repo.GetSessionByName(tenantID, agentID, sessionName)
 	if err != nil {
 		return nil, fmt.Errorf("session not found: %w", err)
 	}
@@ -467,7 +895,7 @@ func (m *Manager) ResumeSessionByName(agentID, sessionName string) (*Session, er
 			m.repo.UpdateLastActivity(sessionID)
 		}
 		
-		slog.Info("resumed session from memory", 
+		slog.InfoContext(ctx, "resumed session from memory", 
 			"session_id", sessionID,
 			"session_name", sessionName,
 			"agent_id", agentID)
@@ -482,12 +910,12 @@ func (m *Manager) ResumeSessionByName(agentID, sessionName string) (*Session, er
 	}
 	
 	// Resurrect the session
-	session, err = m.resurrectSession(state)
+	session, err = m.resurrectSession(ctx, state)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resurrect session: %w", err)
 	}
 	
-	slog.Info("resurrected session from Redis", 
+	slog.InfoContext(ctx, "resurrected session from Redis", 
 		"session_id", sessionID,
 		"session_name", sessionName,
 		"agent_id", agentID)
@@ -495,18 +923,107 @@ func (m *Manager) ResumeSessionByName(agentID, sessionName string) (*Session, er
 	return session, nil
 }
 
-func (m *Manager) resurrectSession(state *storage.SessionState) (*Session, error) {
+func (m *Manager) resurrectSession(ctx context.Context, state *storage.SessionState) (*Session, error) {
+	// Restore the launch options the session was created with
+	var opts *SessionOptions
+	if len(state.Options) > 0 {
+		opts = &SessionOptions{}
+		if err := json.Unmarshal(state.Options, opts); err != nil {
+			slog.WarnContext(ctx, "failed to parse stored session options", "session_id", state.SessionID, "error", err)
+			opts = nil
+		}
+	}
+
+	// A Firefox session's user context went with it, and BiDi can't restore its tabs
+	if engineOf(opts) == EngineFirefox {
+		return nil, fmt.Errorf("%w: resuming a %s session", ErrEngineUnsupported, EngineFirefox)
+	}
+
+	// The vault may have changed since the session closed, so certificates are read again
+	clientCerts, err := m.resolveClientCertificates(state.TenantID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// A profile's browser was stopped with the session, so it comes back on a new port
+	registered := false
+	profile := profileOf(opts)
+	if profile != "" {
+		m.mu.RLock()
+		session, exists := m.sessions[state.SessionID]
+		m.mu.RUnlock()
+		if exists {
+			return session, nil
+		}
+
+		port, err := m.acquireProfile(state.TenantID, opts)
+		if err != nil {
+			return nil, err
+		}
+		state.ProcessPort = port
+		defer func() {
+			if !registered {
+				m.releaseProfile(state.TenantID, profile, port)
+			}
+		}()
+	}
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+
+	// A draining server takes no sessions back either
+	if m.IsDraining() {
+		m.mu.Unlock()
+		return nil, ErrDraining
+	}
+
+	// Another request may have brought the session back first
+	if session, exists := m.sessions[state.SessionID]; exists {
+		m.mu.Unlock()
+		return session, nil
+	}
+	if _, reserved := m.reserved[state.SessionID]; reserved {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("session is already being resumed: %s", state.SessionID)
+	}
+
+	// A resurrected session is live again, so it counts against the tenant's quotas
+	if err := m.checkTenantQuotasLocked(state.TenantID, state.ProcessPort); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	m.reserveLocked(state.SessionID, state.TenantID, state.ProcessPort)
+	m.mu.Unlock()
+
+	defer func() {
+		if !registered {
+			m.releaseReservation(state.SessionID)
+		}
+	}()
+
+	egressAddr, err := m.registerEgress(state.SessionID, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if !registered {
+			m.unregisterEgress(state.SessionID)
+		}
+	}()
 	
 	// Get or create CDP client for the port
-	client, err := m.GetOrCreateCDPClient(state.ProcessPort)
+	client, err := m.clientForPort(state.ProcessPort)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reconnect to browser: %w", err)
 	}
 	
-	// Create a new browser context (old one was disposed when session was closed)
-	contextID, err := client.CreateBrowserContext()
+	// Create a new browser context (old one was disposed when session was closed); a
+	// profile's default context still holds what the session left in it
+	var contextID string
+	if profile != "" {
+		contextID, err = defaultContextID(ctx, client)
+	} else {
+		contextID, err = client.CreateBrowserContextWithOptions(ctx, contextOptions(opts, egressAddr))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create browser context: %w", err)
 	}
@@ -516,6 +1033,7 @@ func (m *Manager) resurrectSession(state *storage.SessionState) (*Session, error
 		ID:                state.SessionID,
 		Name:              state.SessionName,  // Should not be empty!
 		AgentID:           state.AgentID,
+		TenantID:          state.TenantID,
 		ProcessPort:       state.ProcessPort,
 		ContextID:         contextID,  // Use new context ID
 		PageIDs:           []string{},
@@ -523,37 +1041,52 @@ func (m *Manager) resurrectSession(state *storage.SessionState) (*Session, error
 		CreatedAt:         state.CreatedAt,
 		LastActivity:      time.Now(),
 		Status:            SessionActive,  // ← FIX: Set to ACTIVE when resurrecting
+		Template:          state.Template,
+		Options:           opts,
+		workDir:           m.prepareWorkDir(state.SessionID), // Files from before the close are still there
+		clientCerts:       clientCerts,
+		egressAddr:        egressAddr,
+		bandwidth:         m.newBandwidthMeter(state.TenantID, opts),
 		pageAnalysisCache: make(map[string]*PageStructure),
 	}
+	m.setupDownloads(ctx, session, m.isRemote(state.ProcessPort))
+
+	// A profile kept its cookies; seeding them again would roll back newer values
+	if profile == "" {
+		if err := session.applyContextOptions(ctx); err != nil {
+			slog.WarnContext(ctx, "failed to restore session cookies", "session_id", session.ID, "error", err)
+		}
+	}
 	
 	// Don't restore pages - they were closed when session was closed
 	
 	// Add to manager
-	m.sessions[session.ID] = session
+	m.registerSession(session)
+	registered = true
 	
 	// Update status to ACTIVE in Redis and save new context ID
 	if m.repo != nil {
 		if err := m.repo.UpdateLastActivity(session.ID); err != nil {
-			slog.Warn("failed to update last activity", "error", err)
+			slog.WarnContext(ctx, "failed to update last activity", "error", err)
 		}
 		
 		// Update status to active and save new context ID
 		if err := m.repo.UpdateSessionStatus(session.ID, "active"); err != nil {
-			slog.Warn("failed to update session status", "error", err)
+			slog.WarnContext(ctx, "failed to update session status", "error", err)
 		}
 		
 		// Save updated session state with new context ID
 		updatedState := m.sessionToState(session)
 		if err := m.repo.SaveSession(updatedState); err != nil {
-			slog.Warn("failed to update session context in Redis", "error", err)
+			slog.WarnContext(ctx, "failed to update session context in Redis", "error", err)
 		}
 	}
 	
 	return session, nil
 }
 
-// ListAgentSessions returns all sessions for an agent
-func (m *Manager) ListAgentSessions(agentID string) ([]*Session, error) {
+// ListAgentSessions returns all sessions for a tenant's agent
+func (m *Manager) ListAgentSessions(tenantID, agentID string) ([]*Session, error) {
 	if agentID == "" {
 		return nil, fmt.Errorf("agent_id is required")
 	}
@@ -565,7 +1098,7 @@ func (m *Manager) ListAgentSessions(agentID string) ([]*Session, error) {
 		
 		sessions := make([]*Session, 0)
 		for _, session := range m.sessions {
-			if session.AgentID == agentID {
+			if session.AgentID == agentID && session.TenantID == tenantID {
 				sessions = append(sessions, session)
 			}
 		}
@@ -573,7 +1106,7 @@ func (m *Manager) ListAgentSessions(agentID string) ([]*Session, error) {
 	}
 	
 	// Get from Redis
-	states, err := m.repo.ListAgentSessions(agentID)
+	states, err := m.repo.ListAgentSessions(tenantID, agentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list agent sessions: %w", err)
 	}
@@ -595,6 +1128,7 @@ func (m *Manager) ListAgentSessions(agentID string) ([]*Session, error) {
 				ID:           state.SessionID,
 				Name:         state.SessionName,
 				AgentID:      state.AgentID,
+				TenantID:     state.TenantID,
 				ProcessPort:  state.ProcessPort,
 				ContextID:    state.ContextID,
 				CreatedAt:    state.CreatedAt,
@@ -632,7 +1166,7 @@ func (m *Manager) RenameSession(sessionID, newName string) error {
 	
 	// Update in Redis
 	if m.repo != nil {
-		if err := m.repo.RenameSession(sessionID, session.AgentID, oldName, newName); err != nil {
+		if err := m.repo.RenameSession(sessionID, session.TenantID, session.AgentID, oldName, newName); err != nil {
 			return fmt.Errorf("failed to rename session in Redis: %w", err)
 		}
 	}
@@ -650,30 +1184,40 @@ func (m *Manager) RenameSession(sessionID, newName string) error {
 
 // CloseSession disconnects from browser but keeps in Redis
 func (m *Manager) CloseSession(sessionID string) error {
+	// Remove from memory only; the browser is talked to without the lock
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	session, exists := m.sessions[sessionID]
+	if exists {
+		delete(m.sessions, sessionID)
+		m.endTakeoverLocked(sessionID)
+	}
+	m.mu.Unlock()
+
 	if !exists {
-		return fmt.Errorf("session not found: %s", sessionID)
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
 	}
 
+	session.stopAllScreencasts()
+	session.stopAllWatches()
+	session.stopAllRoutes()
+	session.stopAllNetworkCapture()
+	session.forgetAllHandles()
+
 	// Close all pages
-	for _, pageID := range session.PageIDs {
-		if err := session.CDPClient.CloseTarget(pageID); err != nil {
+	for _, pageID := range session.Pages() {
+		if err := session.Driver().ClosePage(context.Background(), pageID); err != nil {
 			slog.Warn("failed to close page", "page_id", pageID, "error", err)
 		}
 	}
 
-	// Dispose browser context
-	if err := session.CDPClient.DisposeBrowserContext(session.ContextID); err != nil {
-		slog.Warn("failed to dispose browser context", "error", err)
-	}
+	// Dispose browser context; the session gets a new listener when it is resumed
+	m.releaseContext(session)
+	m.unregisterEgress(sessionID)
 
 	// Update status to IDLE in Redis
-	session.Status = SessionIdle
+	session.setStatus(SessionIdle)
 	// Clear pages - they're destroyed with the context and can't be restored
-	session.PageIDs = []string{}
+	session.resetPages()
 	
 	if m.repo != nil {
 		state := m.sessionToState(session)
@@ -688,8 +1232,7 @@ func (m *Manager) CloseSession(sessionID string) error {
 		}
 	}
 
-	// Remove from memory only
-	delete(m.sessions, sessionID)
+	m.publishEvent(sessionID, "", events.TypeSessionClosed, nil)
 
 	slog.Info("session closed (kept in Redis)", 
 		"session_id", sessionID,
@@ -700,6 +1243,6 @@ func (m *Manager) CloseSession(sessionID string) error {
 }
 
 // GetSessionByName is a convenience wrapper
-func (m *Manager) GetSessionByName(agentID, sessionName string) (*Session, error) {
-	return m.ResumeSessionByName(agentID, sessionName)
+func (m *Manager) GetSessionByName(ctx context.Context, tenantID, agentID, sessionName string) (*Session, error) {
+	return m.ResumeSessionByName(ctx, tenantID, agentID, sessionName)
 }
\ No newline at end of file
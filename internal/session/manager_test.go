@@ -1,6 +1,7 @@
 package session
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -108,7 +109,7 @@ func TestCreateSession(t *testing.T) {
 	defer manager.Close()
 
 	// Create session
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
@@ -155,7 +156,7 @@ func TestGetSession(t *testing.T) {
 	defer manager.Close()
 
 	// Create session
-	created, err := manager.CreateSession(proc.DebugPort)
+	created, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
@@ -191,7 +192,7 @@ func TestDestroySession(t *testing.T) {
 	defer manager.Close()
 
 	// Create session
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
@@ -243,7 +244,7 @@ func TestListSessions(t *testing.T) {
 	// Create multiple sessions
 	created := make([]*Session, 3)
 	for i := 0; i < 3; i++ {
-		sess, err := manager.CreateSession(proc.DebugPort)
+		sess, err := manager.CreateSession(context.Background(), proc.DebugPort)
 		if err != nil {
 			t.Fatalf("CreateSession %d failed: %v", i, err)
 		}
@@ -278,13 +279,13 @@ func TestCDPClientPooling(t *testing.T) {
 	defer manager.Close()
 
 	// Create first session
-	sess1, err := manager.CreateSession(proc.DebugPort)
+	sess1, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession 1 failed: %v", err)
 	}
 
 	// Create second session on same port
-	sess2, err := manager.CreateSession(proc.DebugPort)
+	sess2, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession 2 failed: %v", err)
 	}
@@ -320,7 +321,7 @@ func TestConcurrentSessionCreation(t *testing.T) {
 
 	for i := 0; i < concurrency; i++ {
 		go func(n int) {
-			sess, err := manager.CreateSession(proc.DebugPort)
+			sess, err := manager.CreateSession(context.Background(), proc.DebugPort)
 			if err != nil {
 				done <- err
 				return
@@ -364,7 +365,7 @@ func TestSessionActivityTracking(t *testing.T) {
 	defer manager.Close()
 
 	// Create session
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
@@ -0,0 +1,235 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// maxMarks bounds how many elements are numbered on one screenshot
+const maxMarks = 200
+
+// markClearTimeout bounds removing the overlay, which runs even if the request was cancelled
+const markClearTimeout = 5 * time.Second
+
+// Box is a rectangle in CSS pixels relative to the viewport
+type Box struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Center returns the middle of the box, where a click on the element should land
+func (b Box) Center() (float64, float64) {
+	return b.X + b.Width/2, b.Y + b.Height/2
+}
+
+// ElementMark is one numbered interactive element drawn over a screenshot
+type ElementMark struct {
+	Mark     int    `json:"mark"`
+	Tag      string `json:"tag"`
+	Role     string `json:"role,omitempty"`
+	Text     string `json:"text,omitempty"` // Visible text or label, truncated
+	Selector string `json:"selector"`       // CSS selector that finds the element again
+	Box      Box    `json:"box"`
+}
+
+// markOverlay is what marksDrawJS reports about the overlay it drew
+type markOverlay struct {
+	DevicePixelRatio float64       `json:"dpr"`
+	Marks            []ElementMark `json:"marks"`
+}
+
+// elementHelpersJS defines the functions scripts use to describe an element: a CSS
+// selector that finds it again and a short human-readable label
+const elementHelpersJS = `
+  function selectorFor(el) {
+    if (el.id) return '#' + CSS.escape(el.id);
+    var parts = [];
+    while (el && el.nodeType === 1 && el !== document.documentElement) {
+      if (el.id) { parts.unshift('#' + CSS.escape(el.id)); break; }
+      var tag = el.tagName.toLowerCase(), index = 1, sibling = el;
+      while ((sibling = sibling.previousElementSibling)) if (sibling.tagName === el.tagName) index++;
+      parts.unshift(tag + ':nth-of-type(' + index + ')');
+      el = el.parentElement;
+    }
+    return parts.join(' > ');
+  }
+
+  function labelFor(el) {
+    var text = el.getAttribute('aria-label') || el.innerText || el.value || el.placeholder || el.title || el.alt || '';
+    text = String(text).replace(/\s+/g, ' ').trim();
+    return text.length > 80 ? text.slice(0, 77) + '...' : text;
+  }
+`
+
+// marksDrawJS outlines and numbers the visible, unobscured interactive elements in the
+// viewport. The overlay ignores pointer events so the page behaves as before.
+const marksDrawJS = `(function(maxMarks) {
+  var old = document.getElementById('__bqaMarks');
+  if (old) old.remove();
+
+  var candidates = document.querySelectorAll('a[href], button, input:not([type=hidden]), select, textarea, summary, ' +
+    '[role=button], [role=link], [role=checkbox], [role=radio], [role=tab], [role=menuitem], [role=option], ' +
+    '[role=switch], [role=combobox], [contenteditable=""], [contenteditable=true], [onclick], [tabindex]:not([tabindex="-1"])');
+
+` + elementHelpersJS + `
+  var overlay = document.createElement('div');
+  overlay.id = '__bqaMarks';
+  overlay.style.cssText = 'position:fixed;left:0;top:0;width:0;height:0;z-index:2147483647;pointer-events:none';
+
+  var marks = [], seen = new Set();
+  for (var i = 0; i < candidates.length && marks.length < maxMarks; i++) {
+    var el = candidates[i];
+    var rect = el.getBoundingClientRect();
+    if (rect.width < 2 || rect.height < 2) continue;
+    if (rect.bottom <= 0 || rect.right <= 0 || rect.top >= innerHeight || rect.left >= innerWidth) continue;
+
+    var style = getComputedStyle(el);
+    if (style.visibility === 'hidden' || style.display === 'none' || Number(style.opacity) === 0) continue;
+
+    // Skip elements covered by something else at their centre
+    var cx = Math.min(Math.max(rect.left + rect.width / 2, 0), innerWidth - 1);
+    var cy = Math.min(Math.max(rect.top + rect.height / 2, 0), innerHeight - 1);
+    var hit = document.elementFromPoint(cx, cy);
+    if (!hit || !(hit === el || el.contains(hit) || hit.contains(el))) continue;
+
+    // A link wrapping a button is one target, not two
+    var owner = hit === el || el.contains(hit) ? el : hit;
+    if (seen.has(owner)) continue;
+    seen.add(owner);
+
+    var n = marks.length + 1;
+    var box = document.createElement('div');
+    box.style.cssText = 'position:fixed;box-sizing:border-box;border:2px solid #e5133a;' +
+      'left:' + rect.left + 'px;top:' + rect.top + 'px;width:' + rect.width + 'px;height:' + rect.height + 'px';
+    var label = document.createElement('span');
+    label.textContent = n;
+    label.style.cssText = 'position:absolute;left:-2px;top:-2px;background:#e5133a;color:#fff;' +
+      'font:bold 11px/14px sans-serif;padding:0 3px;border-radius:0 0 3px 0';
+    box.appendChild(label);
+    overlay.appendChild(box);
+
+    marks.push({
+      mark: n,
+      tag: el.tagName.toLowerCase(),
+      role: el.getAttribute('role') || '',
+      text: labelFor(el),
+      selector: selectorFor(el),
+      box: {x: rect.left, y: rect.top, width: rect.width, height: rect.height}
+    });
+  }
+
+  document.documentElement.appendChild(overlay);
+  return {dpr: devicePixelRatio || 1, marks: marks};
+})(%d)`
+
+// marksClearJS removes the overlay drawn by marksDrawJS
+const marksClearJS = `(function() {
+  var overlay = document.getElementById('__bqaMarks');
+  if (overlay) overlay.remove();
+  return true;
+})()`
+
+// devicePixelRatioJS reports how many screenshot pixels make up one CSS pixel
+const devicePixelRatioJS = `window.devicePixelRatio || 1`
+
+// drawMarks numbers the page's interactive elements on screen; call clearMarks after capturing
+func (s *Session) drawMarks(ctx context.Context, targetID string) (*markOverlay, error) {
+	result, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(marksDrawJS, maxMarks))
+	if err != nil {
+		return nil, fmt.Errorf("failed to draw element marks: %w", err)
+	}
+
+	rawJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal element marks: %w", err)
+	}
+
+	var overlay markOverlay
+	if err := json.Unmarshal(rawJSON, &overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse element marks: %w", err)
+	}
+	if overlay.Marks == nil {
+		overlay.Marks = []ElementMark{}
+	}
+
+	return &overlay, nil
+}
+
+// captureMarkedScreenshot screenshots the page with its interactive elements numbered,
+// removing the numbers again afterwards
+func (s *Session) captureMarkedScreenshot(ctx context.Context, targetID string) ([]byte, *markOverlay, error) {
+	overlay, err := s.drawMarks(ctx, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	screenshot, err := s.CaptureScreenshot(ctx, targetID)
+
+	// The caller gets the picture; the page shouldn't keep the boxes
+	s.clearMarks(ctx, targetID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return screenshot, overlay, nil
+}
+
+// clearMarks removes the overlay, even when ctx is already done
+func (s *Session) clearMarks(ctx context.Context, targetID string) {
+	clearCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), markClearTimeout)
+	defer cancel()
+
+	if _, err := s.ExecuteJavascript(clearCtx, targetID, marksClearJS); err != nil {
+		// The page keeps a harmless overlay until its next navigation
+		slog.WarnContext(ctx, "failed to remove element marks", "session_id", s.ID, "page_id", targetID, "error", err)
+	}
+}
+
+// devicePixelRatio returns the page's screenshot pixels per CSS pixel
+func (s *Session) devicePixelRatio(ctx context.Context, targetID string) (float64, error) {
+	result, err := s.ExecuteJavascript(ctx, targetID, devicePixelRatioJS)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read device pixel ratio: %w", err)
+	}
+
+	ratio, ok := result.(float64)
+	if !ok || ratio <= 0 {
+		return 1, nil
+	}
+	return ratio, nil
+}
+
+// CaptureAnnotatedScreenshot captures a page with numbered boxes over its interactive
+// elements and returns the legend of what each number is
+func (m *Manager) CaptureAnnotatedScreenshot(ctx context.Context, sessionID string, pageID string) ([]byte, []ElementMark, float64, error) {
+	// The overlay is drawn on the live page, so agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, nil, 0, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	screenshot, overlay, err := session.captureMarkedScreenshot(ctx, pageID)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return screenshot, overlay.Marks, overlay.DevicePixelRatio, nil
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestCaptureAnnotatedScreenshot tests that the marks are drawn before the capture and
+// removed after it, even when the capture fails, and that the legend comes back as drawn
+func TestCaptureAnnotatedScreenshot(t *testing.T) {
+	var mu sync.Mutex
+	var steps []string
+	failCapture := false
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case method == "Page.captureScreenshot":
+			steps = append(steps, "capture")
+			if failCapture {
+				return map[string]interface{}{"error": map[string]interface{}{"code": -32000, "message": "Unable to capture screenshot"}}
+			}
+			return map[string]interface{}{"data": base64.StdEncoding.EncodeToString([]byte("png"))}
+		case method == "Runtime.evaluate" && strings.HasSuffix(p.Expression, "})(200)"):
+			steps = append(steps, "draw")
+			overlay := map[string]interface{}{"dpr": 2, "marks": []map[string]interface{}{
+				{"mark": 1, "tag": "a", "text": "Home", "selector": "#home", "box": map[string]interface{}{"x": 10, "y": 8, "width": 60, "height": 20}},
+				{"mark": 2, "tag": "button", "role": "button", "text": "Add to cart", "selector": "main > button:nth-of-type(1)", "box": map[string]interface{}{"x": 400, "y": 300, "width": 120, "height": 40}},
+			}}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": overlay}}
+		case method == "Runtime.evaluate" && p.Expression == marksClearJS:
+			steps = append(steps, "clear")
+			return map[string]interface{}{"result": map[string]interface{}{"type": "boolean", "value": true}}
+		}
+		return nil
+	})
+	sess, pageID := openTestPage(t, manager, nil, "https://example.com")
+	ctx := context.Background()
+
+	screenshot, legend, ratio, err := manager.CaptureAnnotatedScreenshot(ctx, sess.ID, pageID)
+	if err != nil {
+		t.Fatalf("CaptureAnnotatedScreenshot failed: %v", err)
+	}
+	if string(screenshot) != "png" || ratio != 2 {
+		t.Errorf("unexpected screenshot %q at ratio %v", screenshot, ratio)
+	}
+	if len(legend) != 2 || legend[1].Mark != 2 || legend[1].Selector != "main > button:nth-of-type(1)" || legend[1].Box.Width != 120 {
+		t.Errorf("unexpected legend %+v", legend)
+	}
+	if x, y := legend[1].Box.Center(); x != 460 || y != 320 {
+		t.Errorf("expected the button's centre at 460,320, got %v,%v", x, y)
+	}
+
+	mu.Lock()
+	if !slices.Equal(steps, []string{"draw", "capture", "clear"}) {
+		t.Errorf("expected the marks drawn, captured and cleared, got %v", steps)
+	}
+	steps, failCapture = nil, true
+	mu.Unlock()
+
+	// A failed capture doesn't leave the boxes on the page
+	if _, _, _, err := manager.CaptureAnnotatedScreenshot(ctx, sess.ID, pageID); err == nil {
+		t.Error("expected the failed capture reported")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(steps, []string{"draw", "capture", "clear"}) {
+		t.Errorf("expected the marks cleared after a failed capture, got %v", steps)
+	}
+}
@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Values a page's media emulation accepts; the empty string leaves each to the browser
+var (
+	mediaTypes     = []string{"", "screen", "print"}
+	colorSchemes   = []string{"", "light", "dark", "no-preference"}
+	motionSettings = []string{"", "reduce", "no-preference"}
+)
+
+// MediaEmulation is the CSS media a page is rendered for. The zero value emulates
+// nothing: the page sees the browser's own media and preferences.
+type MediaEmulation struct {
+	Media         string `json:"media,omitempty"`          // screen or print, for @media rules and matchMedia
+	ColorScheme   string `json:"color_scheme,omitempty"`   // prefers-color-scheme: light, dark or no-preference
+	ReducedMotion string `json:"reduced_motion,omitempty"` // prefers-reduced-motion: reduce or no-preference
+}
+
+// validate rejects values CSS media queries don't have
+func (e MediaEmulation) validate() error {
+	if !slices.Contains(mediaTypes, e.Media) {
+		return fmt.Errorf("%w: media must be screen or print, got %q", ErrInvalidMedia, e.Media)
+	}
+	if !slices.Contains(colorSchemes, e.ColorScheme) {
+		return fmt.Errorf("%w: color_scheme must be light, dark or no-preference, got %q", ErrInvalidMedia, e.ColorScheme)
+	}
+	if !slices.Contains(motionSettings, e.ReducedMotion) {
+		return fmt.Errorf("%w: reduced_motion must be reduce or no-preference, got %q", ErrInvalidMedia, e.ReducedMotion)
+	}
+	return nil
+}
+
+// pageMedia returns the media emulated on a page
+func (s *Session) pageMedia(pageID string) MediaEmulation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if media := s.media[pageID]; media != nil {
+		return *media
+	}
+	return MediaEmulation{}
+}
+
+// emulateMedia renders a page for emulation's media. Every field is sent, so an empty
+// one turns its earlier override off.
+func (s *Session) emulateMedia(ctx context.Context, targetID string, emulation MediaEmulation) error {
+	params := map[string]interface{}{
+		"media": emulation.Media,
+		"features": []map[string]string{
+			{"name": "prefers-color-scheme", "value": emulation.ColorScheme},
+			{"name": "prefers-reduced-motion", "value": emulation.ReducedMotion},
+		},
+	}
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Emulation.setEmulatedMedia", params); err != nil {
+		return fmt.Errorf("failed to emulate media: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if emulation == (MediaEmulation{}) {
+		delete(s.media, targetID)
+		return nil
+	}
+	if s.media == nil {
+		s.media = make(map[string]*MediaEmulation)
+	}
+	s.media[targetID] = &emulation
+	return nil
+}
+
+// SetPageMedia renders a page for print media, a color scheme or reduced motion, until
+// changed again or the page closes. Sites' print stylesheets usually drop navigation,
+// ads and banners, which makes extraction and PDFs cleaner. It replaces the page's
+// earlier emulation as a whole.
+func (m *Manager) SetPageMedia(ctx context.Context, sessionID string, pageID string, emulation MediaEmulation) (*MediaEmulation, error) {
+	if err := emulation.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.devtoolsSession(sessionID, pageID, "media emulation")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.emulateMedia(ctx, pageID, emulation); err != nil {
+		return nil, err
+	}
+
+	// The page lays out differently now
+	session.InvalidatePageAnalysis(pageID)
+
+	session.UpdateActivity()
+	return &emulation, nil
+}
+
+// PageMedia returns the media emulated on a page
+func (m *Manager) PageMedia(sessionID string, pageID string) (*MediaEmulation, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "media emulation")
+	if err != nil {
+		return nil, err
+	}
+
+	media := session.pageMedia(pageID)
+	return &media, nil
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestPageMedia tests emulating print media and user preferences on a page, and
+// turning them back off
+func TestPageMedia(t *testing.T) {
+	var mu sync.Mutex
+	var emulated []json.RawMessage
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		switch method {
+		case "Emulation.setEmulatedMedia":
+			mu.Lock()
+			emulated = append(emulated, params)
+			mu.Unlock()
+			return map[string]interface{}{}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://news.example.com/article")
+
+	if _, err := manager.SetPageMedia(ctx, sess.ID, pageID, MediaEmulation{Media: "print", ColorScheme: "dark"}); err != nil {
+		t.Fatalf("SetPageMedia failed: %v", err)
+	}
+	media, err := manager.PageMedia(sess.ID, pageID)
+	if err != nil {
+		t.Fatalf("PageMedia failed: %v", err)
+	}
+	if *media != (MediaEmulation{Media: "print", ColorScheme: "dark"}) {
+		t.Errorf("unexpected page media: %+v", media)
+	}
+
+	// Clearing sends every feature empty, so no earlier override lingers
+	if _, err := manager.SetPageMedia(ctx, sess.ID, pageID, MediaEmulation{}); err != nil {
+		t.Fatalf("SetPageMedia failed: %v", err)
+	}
+	if media, _ := manager.PageMedia(sess.ID, pageID); *media != (MediaEmulation{}) {
+		t.Errorf("expected no media emulated, got %+v", media)
+	}
+
+	mu.Lock()
+	if len(emulated) != 2 {
+		t.Fatalf("expected 2 media emulations, got %d", len(emulated))
+	}
+	var first, second struct {
+		Media    string `json:"media"`
+		Features []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"features"`
+	}
+	json.Unmarshal(emulated[0], &first)
+	json.Unmarshal(emulated[1], &second)
+	mu.Unlock()
+	if first.Media != "print" || len(first.Features) != 2 || first.Features[0].Name != "prefers-color-scheme" ||
+		first.Features[0].Value != "dark" || first.Features[1].Value != "" {
+		t.Errorf("unexpected emulation: %+v", first)
+	}
+	if second.Media != "" || len(second.Features) != 2 || second.Features[0].Value != "" {
+		t.Errorf("expected the emulation cleared, got %+v", second)
+	}
+
+	for _, emulation := range []MediaEmulation{{Media: "tv"}, {ColorScheme: "sepia"}, {ReducedMotion: "yes"}} {
+		if _, err := manager.SetPageMedia(ctx, sess.ID, pageID, emulation); !errors.Is(err, ErrInvalidMedia) {
+			t.Errorf("expected ErrInvalidMedia for %+v, got %v", emulation, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package session
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMemoryTurns is how many answered questions a session remembers
+	DefaultMemoryTurns = 20
+
+	// DefaultMemoryBytes bounds the text a session's memory holds, turns and extracts together
+	DefaultMemoryBytes = 32 << 10
+
+	// memoryExtractBytes caps the text kept from one page's content
+	memoryExtractBytes = 8 << 10
+)
+
+// MemoryLimits bounds a session's memory; the oldest entries are forgotten first
+type MemoryLimits struct {
+	Turns int // Answered questions kept (0: nothing is remembered)
+	Bytes int // Text kept across turns and extracts (0: no limit)
+}
+
+// MemoryTurn is a question asked about a page and the answer it got
+type MemoryTurn struct {
+	Question string        `json:"question"`
+	Answer   string        `json:"answer"`
+	PageID   string        `json:"page_id"`
+	URL      string        `json:"url,omitempty"`
+	Elements []ElementMark `json:"elements"` // Elements the answer referred to
+	At       time.Time     `json:"at"`
+}
+
+// MemoryExtract is the text of page content read earlier, the latest one per page
+type MemoryExtract struct {
+	PageID string    `json:"page_id"`
+	URL    string    `json:"url,omitempty"`
+	Text   string    `json:"text"`
+	At     time.Time `json:"at"`
+}
+
+// Memory is what a session remembers of its conversation so far
+type Memory struct {
+	Turns    []MemoryTurn    `json:"turns"`
+	Extracts []MemoryExtract `json:"extracts"`
+	Bytes    int             `json:"bytes"` // Text held, counted against the limit
+}
+
+// size is what a turn counts against the memory's byte limit
+func (t *MemoryTurn) size() int {
+	n := len(t.Question) + len(t.Answer)
+	for _, element := range t.Elements {
+		n += len(element.Text) + len(element.Selector)
+	}
+	return n
+}
+
+// SetMemoryLimits bounds what sessions remember from now on. Memories already over the
+// new limits shrink on their next turn.
+func (m *Manager) SetMemoryLimits(limits MemoryLimits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.memory = limits
+}
+
+// MemoryLimits returns the bounds on what sessions remember
+func (m *Manager) MemoryLimits() MemoryLimits {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.memory
+}
+
+// ClearMemory makes a session forget its earlier questions and page content
+func (m *Manager) ClearMemory(sessionID string) error {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	session.memoryMu.Lock()
+	session.memory = Memory{}
+	session.memoryMu.Unlock()
+	return nil
+}
+
+// Memory returns a copy of what the session remembers
+func (s *Session) Memory() Memory {
+	s.memoryMu.Lock()
+	defer s.memoryMu.Unlock()
+
+	return Memory{
+		Turns:    append([]MemoryTurn{}, s.memory.Turns...),
+		Extracts: append([]MemoryExtract{}, s.memory.Extracts...),
+		Bytes:    s.memory.Bytes,
+	}
+}
+
+// rememberTurn adds an answered question and forgets what no longer fits
+func (s *Session) rememberTurn(turn MemoryTurn, limits MemoryLimits) {
+	if limits.Turns <= 0 {
+		return
+	}
+	s.memoryMu.Lock()
+	defer s.memoryMu.Unlock()
+
+	s.memory.Turns = append(s.memory.Turns, turn)
+	s.memory.Bytes += turn.size()
+	s.memory.trim(limits)
+}
+
+// rememberExtract keeps the text of a page's content in place of the page's earlier extract
+func (s *Session) rememberExtract(extract MemoryExtract, limits MemoryLimits) {
+	if limits.Turns <= 0 || extract.Text == "" {
+		return
+	}
+	s.memoryMu.Lock()
+	defer s.memoryMu.Unlock()
+
+	for i, earlier := range s.memory.Extracts {
+		if earlier.PageID == extract.PageID {
+			s.memory.Bytes -= len(earlier.Text)
+			s.memory.Extracts = append(s.memory.Extracts[:i], s.memory.Extracts[i+1:]...)
+			break
+		}
+	}
+	s.memory.Extracts = append(s.memory.Extracts, extract)
+	s.memory.Bytes += len(extract.Text)
+	s.memory.trim(limits)
+}
+
+// trim forgets the oldest turns and extracts until the memory fits its limits. The
+// newest entry is always kept, even when it alone is over the byte limit.
+func (mem *Memory) trim(limits MemoryLimits) {
+	for len(mem.Turns) > limits.Turns {
+		mem.Bytes -= mem.Turns[0].size()
+		mem.Turns = mem.Turns[1:]
+	}
+	for limits.Bytes > 0 && mem.Bytes > limits.Bytes && len(mem.Turns)+len(mem.Extracts) > 1 {
+		// Forget whichever of the oldest turn and the oldest extract came first
+		if len(mem.Extracts) == 0 || (len(mem.Turns) > 0 && mem.Turns[0].At.Before(mem.Extracts[0].At)) {
+			mem.Bytes -= mem.Turns[0].size()
+			mem.Turns = mem.Turns[1:]
+		} else {
+			mem.Bytes -= len(mem.Extracts[0].Text)
+			mem.Extracts = mem.Extracts[1:]
+		}
+	}
+}
+
+// memoryPrompt adds what the session remembers about a page to a question, so a follow-up
+// like "what about the second one?" can be answered. It returns the question as is when
+// there is nothing to remember.
+func (mem *Memory) memoryPrompt(pageID string, question string) string {
+	var b strings.Builder
+	for _, extract := range mem.Extracts {
+		if extract.PageID != pageID {
+			continue
+		}
+		fmt.Fprintf(&b, "Text of the page read earlier (%s):\n%s\n\n", extract.URL, extract.Text)
+	}
+	if len(mem.Turns) > 0 {
+		b.WriteString("Earlier questions in this conversation, oldest first:\n")
+		for _, turn := range mem.Turns {
+			fmt.Fprintf(&b, "Q: %s\nA: %s\n", turn.Question, turn.Answer)
+			for _, element := range turn.Elements {
+				fmt.Fprintf(&b, "   referred to <%s> %q (%s)\n", element.Tag, element.Text, element.Selector)
+			}
+		}
+		b.WriteString("\n")
+	}
+	if b.Len() == 0 {
+		return question
+	}
+	b.WriteString("Question: ")
+	b.WriteString(question)
+	return b.String()
+}
+
+var (
+	// hiddenHTML matches elements whose content is never shown
+	hiddenHTML = regexp.MustCompile(`(?is)<(script|style|noscript|template|svg)\b.*?</(script|style|noscript|template|svg)\s*>|<!--.*?-->`)
+	// htmlTag matches any tag
+	htmlTag = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// extractText turns HTML into its text for the memory, capped at memoryExtractBytes
+func extractText(content string) string {
+	text := hiddenHTML.ReplaceAllString(content, " ")
+	text = htmlTag.ReplaceAllString(text, " ")
+	text = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+	if len(text) > memoryExtractBytes {
+		cut := memoryExtractBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return text
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMemoryLimits tests that a session forgets its oldest turns and extracts first
+func TestMemoryLimits(t *testing.T) {
+	session := &Session{}
+	limits := MemoryLimits{Turns: 2, Bytes: 60}
+	start := time.Now()
+
+	session.rememberExtract(MemoryExtract{PageID: "page-1", Text: strings.Repeat("a", 30), At: start}, limits)
+	session.rememberExtract(MemoryExtract{PageID: "page-1", Text: strings.Repeat("b", 20), At: start.Add(time.Second)}, limits)
+	if memory := session.Memory(); len(memory.Extracts) != 1 || memory.Extracts[0].Text[0] != 'b' || memory.Bytes != 20 {
+		t.Fatalf("expected the page's newer extract to replace the older one, got %+v", memory)
+	}
+
+	for i, question := range []string{"first?", "second?", "third?"} {
+		session.rememberTurn(MemoryTurn{Question: question, Answer: "answer", At: start.Add(time.Duration(i+2) * time.Second)}, limits)
+	}
+	memory := session.Memory()
+	if len(memory.Turns) != 2 || memory.Turns[0].Question != "second?" || memory.Turns[1].Question != "third?" {
+		t.Errorf("expected the two newest turns, got %+v", memory.Turns)
+	}
+	if len(memory.Extracts) != 1 || memory.Bytes != 20+len("second?answer")+len("third?answer") {
+		t.Errorf("unexpected extracts or size: %+v", memory)
+	}
+
+	// Over the byte limit, the extract is older than both turns
+	session.rememberTurn(MemoryTurn{Question: "fourth?", Answer: strings.Repeat("c", 30), At: start.Add(10 * time.Second)}, limits)
+	memory = session.Memory()
+	if len(memory.Extracts) != 0 || len(memory.Turns) != 2 || memory.Bytes > limits.Bytes {
+		t.Errorf("expected the extract to be forgotten first, got %+v", memory)
+	}
+
+	// Memory off
+	off := &Session{}
+	off.rememberTurn(MemoryTurn{Question: "q", Answer: "a"}, MemoryLimits{})
+	if memory := off.Memory(); len(memory.Turns) != 0 {
+		t.Errorf("expected nothing remembered without turns, got %+v", memory)
+	}
+}
+
+// TestMemoryPrompt tests that follow-ups carry earlier answers and the page's own extract
+func TestMemoryPrompt(t *testing.T) {
+	memory := Memory{
+		Turns: []MemoryTurn{{
+			Question: "Which plans are listed?",
+			Answer:   "Basic and Pro.",
+			Elements: []ElementMark{{Tag: "a", Text: "Pro", Selector: "#pro"}},
+		}},
+		Extracts: []MemoryExtract{
+			{PageID: "page-1", URL: "https://example.com/pricing", Text: "Basic $5 Pro $12"},
+			{PageID: "page-2", URL: "https://example.com/other", Text: "Unrelated"},
+		},
+	}
+
+	prompt := memory.memoryPrompt("page-1", "What about the second one?")
+	for _, want := range []string{"Basic $5 Pro $12", "Q: Which plans are listed?\nA: Basic and Pro.", `<a> "Pro" (#pro)`, "Question: What about the second one?"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected %q in the prompt, got %q", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Unrelated") {
+		t.Error("expected other pages' extracts to be left out")
+	}
+
+	if empty := (&Memory{}).memoryPrompt("page-1", "Hi?"); empty != "Hi?" {
+		t.Errorf("expected the bare question without memory, got %q", empty)
+	}
+}
+
+// TestExtractText tests reading the text out of page HTML
+func TestExtractText(t *testing.T) {
+	content := `<html><head><style>p { color: red }</style><script>var x = "<p>";</script></head>
+<body><!-- nav --><h1>Plans</h1><p>Basic &amp; Pro</p></body></html>`
+	if text := extractText(content); text != "Plans Basic & Pro" {
+		t.Errorf("unexpected text: %q", text)
+	}
+
+	long := extractText(strings.Repeat("é", memoryExtractBytes))
+	if len(long) > memoryExtractBytes || !strings.HasSuffix(long, "é") {
+		t.Errorf("expected the text cut on a character boundary, got %d bytes", len(long))
+	}
+}
@@ -0,0 +1,277 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// What a model call is made for, as counted in usage reports
+const (
+	ModelOperationVision   = "vision"
+	ModelOperationResearch = "research"
+	ModelOperationHeal     = "heal"
+)
+
+// modelUsageDays is how many UTC days of model usage are kept, today included
+const modelUsageDays = 31
+
+// ModelPricing is what the model costs in US dollars per million tokens
+type ModelPricing struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+// cost estimates what a call that used these tokens cost
+func (p ModelPricing) cost(usage vision.Usage) float64 {
+	return (float64(usage.PromptTokens)*p.PromptPerMillion + float64(usage.CompletionTokens)*p.CompletionPerMillion) / 1e6
+}
+
+// ModelCall names who a model call is made for, so its tokens are counted against them
+type ModelCall struct {
+	Operation string // ModelOperationVision, ModelOperationResearch or ModelOperationHeal
+	TenantID  string
+	SessionID string // Empty for calls made outside a session, such as research answers
+}
+
+// ModelSpend is what a set of model calls used
+type ModelSpend struct {
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"` // Estimated from the configured prices
+}
+
+// add counts one call
+func (s *ModelSpend) add(usage vision.Usage, cost float64) {
+	s.Calls++
+	s.PromptTokens += int64(usage.PromptTokens)
+	s.CompletionTokens += int64(usage.CompletionTokens)
+	s.CostUSD += cost
+}
+
+// SessionModelSpend is what one session's model calls used
+type SessionModelSpend struct {
+	SessionID string `json:"session_id"`
+	ModelSpend
+}
+
+// ModelUsageDay is what a tenant's model calls used on one UTC day
+type ModelUsageDay struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	ModelSpend
+	Operations map[string]ModelSpend `json:"operations"` // Operation → its calls
+	Sessions   []SessionModelSpend   `json:"sessions"`   // Sessions that called the model, most expensive first
+}
+
+// tenantModelDay is a tenant's model calls on one day
+type tenantModelDay struct {
+	total      ModelSpend
+	operations map[string]*ModelSpend
+	sessions   map[string]*ModelSpend
+}
+
+// modelUsageLedger totals model calls per tenant and UTC day, keeping the last
+// modelUsageDays days
+type modelUsageLedger struct {
+	mu      sync.Mutex
+	pricing ModelPricing
+	budget  float64                               // US dollars every tenant together may spend per UTC day (0: unlimited)
+	days    map[string]map[string]*tenantModelDay // Date → tenant ID → its calls that day
+	spent   map[string]float64                    // Date → what every tenant spent that day
+}
+
+// add counts a finished call, returning its estimated cost
+func (l *modelUsageLedger) add(call ModelCall, usage vision.Usage, now time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.days == nil {
+		l.days = make(map[string]map[string]*tenantModelDay)
+		l.spent = make(map[string]float64)
+	}
+
+	date := usageDate(now)
+	tenants := l.days[date]
+	if tenants == nil {
+		tenants = make(map[string]*tenantModelDay)
+		l.days[date] = tenants
+		l.pruneLocked(now)
+	}
+	day := tenants[call.TenantID]
+	if day == nil {
+		day = &tenantModelDay{operations: make(map[string]*ModelSpend), sessions: make(map[string]*ModelSpend)}
+		tenants[call.TenantID] = day
+	}
+
+	cost := l.pricing.cost(usage)
+	day.total.add(usage, cost)
+	if day.operations[call.Operation] == nil {
+		day.operations[call.Operation] = &ModelSpend{}
+	}
+	day.operations[call.Operation].add(usage, cost)
+	if call.SessionID != "" {
+		if day.sessions[call.SessionID] == nil {
+			day.sessions[call.SessionID] = &ModelSpend{}
+		}
+		day.sessions[call.SessionID].add(usage, cost)
+	}
+	l.spent[date] += cost
+	return cost
+}
+
+// pruneLocked forgets days that fell out of the kept window. It requires l.mu to be held.
+func (l *modelUsageLedger) pruneLocked(now time.Time) {
+	oldest := usageDate(now.AddDate(0, 0, 1-modelUsageDays))
+	for date := range l.days {
+		// Dates sort as text
+		if date < oldest {
+			delete(l.days, date)
+			delete(l.spent, date)
+		}
+	}
+}
+
+// tenantSpend returns what a tenant's model calls cost on now's UTC day
+func (l *modelUsageLedger) tenantSpend(tenantID string, now time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if day := l.days[usageDate(now)][tenantID]; day != nil {
+		return day.total.CostUSD
+	}
+	return 0
+}
+
+// report returns a tenant's usage for the days up to now's, newest first. Days without
+// calls are included, with nothing counted.
+func (l *modelUsageLedger) report(tenantID string, days int, now time.Time) []ModelUsageDay {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	report := make([]ModelUsageDay, 0, days)
+	for i := range days {
+		date := usageDate(now.AddDate(0, 0, -i))
+		entry := ModelUsageDay{Date: date, Operations: map[string]ModelSpend{}, Sessions: []SessionModelSpend{}}
+		if day := l.days[date][tenantID]; day != nil {
+			entry.ModelSpend = day.total
+			for operation, spend := range day.operations {
+				entry.Operations[operation] = *spend
+			}
+			for sessionID, spend := range day.sessions {
+				entry.Sessions = append(entry.Sessions, SessionModelSpend{SessionID: sessionID, ModelSpend: *spend})
+			}
+			sort.Slice(entry.Sessions, func(i, j int) bool {
+				if entry.Sessions[i].CostUSD != entry.Sessions[j].CostUSD {
+					return entry.Sessions[i].CostUSD > entry.Sessions[j].CostUSD
+				}
+				return entry.Sessions[i].SessionID < entry.Sessions[j].SessionID
+			})
+		}
+		report = append(report, entry)
+	}
+	return report
+}
+
+// usageDate returns the UTC date of t as YYYY-MM-DD
+func usageDate(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// SetModelPricing sets the token prices model costs are estimated with from now on.
+// Calls already counted keep their cost.
+func (m *Manager) SetModelPricing(pricing ModelPricing) {
+	m.modelUsage.mu.Lock()
+	defer m.modelUsage.mu.Unlock()
+	m.modelUsage.pricing = pricing
+}
+
+// SetModelBudget caps what model calls of every tenant together may cost per UTC day,
+// in US dollars (0: unlimited)
+func (m *Manager) SetModelBudget(dailyUSD float64) {
+	m.modelUsage.mu.Lock()
+	defer m.modelUsage.mu.Unlock()
+	m.modelUsage.budget = dailyUSD
+}
+
+// ModelUsage returns what a tenant's model calls used on each of the last days UTC
+// days, today first. At most modelUsageDays days are kept.
+func (m *Manager) ModelUsage(tenantID string, days int) []ModelUsageDay {
+	return m.modelUsage.report(tenantID, min(max(days, 1), modelUsageDays), time.Now())
+}
+
+// maxTenantModelSpend returns a tenant's daily model budget in US dollars (0: unlimited)
+func (m *Manager) maxTenantModelSpend(tenantID string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.quotas == nil {
+		return 0
+	}
+	return m.quotas.MaxModelSpendUSD(tenantID)
+}
+
+// checkModelBudget fails once today's model calls cost the deployment's or the
+// tenant's daily budget. Calls already in flight may still take spending over it.
+func (m *Manager) checkModelBudget(tenantID string) error {
+	now := time.Now()
+	m.modelUsage.mu.Lock()
+	budget, spent := m.modelUsage.budget, m.modelUsage.spent[usageDate(now)]
+	m.modelUsage.mu.Unlock()
+	if budget > 0 && spent >= budget {
+		return fmt.Errorf("%w: $%.2f of $%.2f spent today (UTC)", ErrModelBudget, spent, budget)
+	}
+
+	if limit := m.maxTenantModelSpend(tenantID); limit > 0 {
+		if used := m.modelUsage.tenantSpend(tenantID, now); used >= limit {
+			return fmt.Errorf("%w: $%.2f of $%.2f spent today (UTC)", ErrTenantModelBudget, used, limit)
+		}
+	}
+	return nil
+}
+
+// meteredModel checks the budgets before each call to its model and counts what the
+// call used
+type meteredModel struct {
+	manager *Manager
+	model   vision.Model
+	call    ModelCall
+}
+
+// meterModel returns model with its calls counted for call, or nil when model is nil
+func (m *Manager) meterModel(model vision.Model, call ModelCall) vision.Model {
+	if model == nil {
+		return nil
+	}
+	return &meteredModel{manager: m, model: model, call: call}
+}
+
+func (mm *meteredModel) Name() string { return mm.model.Name() }
+
+func (mm *meteredModel) Ask(ctx context.Context, query vision.Query) (*vision.Reply, error) {
+	return mm.ask(ctx, func() (*vision.Reply, error) { return mm.model.Ask(ctx, query) })
+}
+
+// AskStream streams when the model can, and otherwise answers in one go without
+// calling onText
+func (mm *meteredModel) AskStream(ctx context.Context, query vision.Query, onText func(string)) (*vision.Reply, error) {
+	streamer, ok := mm.model.(vision.Streamer)
+	if !ok {
+		return mm.Ask(ctx, query)
+	}
+	return mm.ask(ctx, func() (*vision.Reply, error) { return streamer.AskStream(ctx, query, onText) })
+}
+
+// ask makes the call unless a budget is spent, and counts it when it succeeds
+func (mm *meteredModel) ask(ctx context.Context, do func() (*vision.Reply, error)) (*vision.Reply, error) {
+	if err := mm.manager.checkModelBudget(mm.call.TenantID); err != nil {
+		return nil, err
+	}
+	reply, err := do()
+	if err != nil {
+		return nil, err
+	}
+	mm.manager.modelUsage.add(mm.call, reply.Usage, time.Now())
+	return reply, nil
+}
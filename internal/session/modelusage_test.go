@@ -0,0 +1,114 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// TestModelUsage tests that model calls are costed and totalled per tenant, day,
+// operation and session, and that old days are forgotten
+func TestModelUsage(t *testing.T) {
+	ledger := &modelUsageLedger{pricing: ModelPricing{PromptPerMillion: 2, CompletionPerMillion: 8}}
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	usage := vision.Usage{PromptTokens: 1000, CompletionTokens: 250}
+
+	if cost := ledger.add(ModelCall{Operation: ModelOperationVision, TenantID: "acme", SessionID: "s1"}, usage, now); math.Abs(cost-0.004) > 1e-9 {
+		t.Errorf("expected the call to cost $0.004, got %v", cost)
+	}
+	ledger.add(ModelCall{Operation: ModelOperationVision, TenantID: "acme", SessionID: "s2"}, usage, now)
+	ledger.add(ModelCall{Operation: ModelOperationVision, TenantID: "acme", SessionID: "s2"}, usage, now)
+	ledger.add(ModelCall{Operation: ModelOperationResearch, TenantID: "acme"}, usage, now)
+	ledger.add(ModelCall{Operation: ModelOperationVision, TenantID: "globex", SessionID: "s3"}, usage, now)
+
+	// The next UTC day starts afresh
+	tomorrow := now.Add(2 * time.Hour)
+	ledger.add(ModelCall{Operation: ModelOperationHeal, TenantID: "acme", SessionID: "s1"}, usage, tomorrow)
+
+	report := ledger.report("acme", 3, tomorrow)
+	if len(report) != 3 || report[0].Date != "2026-03-11" || report[1].Date != "2026-03-10" {
+		t.Fatalf("expected three days, newest first, got %+v", report)
+	}
+	if report[0].Calls != 1 || report[0].Operations[ModelOperationHeal].Calls != 1 {
+		t.Errorf("expected one heal call today, got %+v", report[0])
+	}
+	day := report[1]
+	if day.Calls != 4 || day.PromptTokens != 4000 || day.CompletionTokens != 1000 || math.Abs(day.CostUSD-0.016) > 1e-9 {
+		t.Errorf("unexpected totals for acme's day: %+v", day.ModelSpend)
+	}
+	if day.Operations[ModelOperationVision].Calls != 3 || day.Operations[ModelOperationResearch].Calls != 1 {
+		t.Errorf("unexpected operations: %+v", day.Operations)
+	}
+	// Research counts for no session, and the costliest session comes first
+	if len(day.Sessions) != 2 || day.Sessions[0].SessionID != "s2" || day.Sessions[0].Calls != 2 || day.Sessions[1].SessionID != "s1" {
+		t.Errorf("unexpected sessions: %+v", day.Sessions)
+	}
+	if report[2].Calls != 0 || len(report[2].Sessions) != 0 {
+		t.Errorf("expected a day without calls to count nothing, got %+v", report[2])
+	}
+	if spent := ledger.tenantSpend("globex", now); math.Abs(spent-0.004) > 1e-9 {
+		t.Errorf("expected globex to have spent $0.004, got %v", spent)
+	}
+
+	// Days older than the kept window are dropped once a new day starts
+	later := now.AddDate(0, 0, modelUsageDays)
+	ledger.add(ModelCall{Operation: ModelOperationVision, TenantID: "acme"}, usage, later)
+	ledger.mu.Lock()
+	_, kept := ledger.days[usageDate(now)]
+	ledger.mu.Unlock()
+	if kept {
+		t.Error("expected the oldest day to be forgotten")
+	}
+}
+
+// TestModelBudgets tests that calls are refused once the tenant's or the deployment's
+// daily budget is spent, and that refused calls count nothing
+func TestModelBudgets(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	manager.SetModelPricing(ModelPricing{PromptPerMillion: 1000, CompletionPerMillion: 1000})
+	manager.SetTenantQuotas(fixedQuotas{spend: 0.25})
+
+	// 120 + 30 tokens at $1000 per million is $0.15 a call
+	model := &promptModel{reply: "ok"}
+	acme := manager.meterModel(model, ModelCall{Operation: ModelOperationVision, TenantID: "acme", SessionID: "s1"})
+	for i := range 2 {
+		if _, err := acme.Ask(context.Background(), vision.Query{Prompt: "hi"}); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	if _, err := acme.Ask(context.Background(), vision.Query{Prompt: "hi"}); !errors.Is(err, ErrTenantModelBudget) {
+		t.Errorf("expected ErrTenantModelBudget, got %v", err)
+	}
+	if usage := manager.TenantUsage("acme"); math.Abs(usage.ModelSpendUSD-0.3) > 1e-9 {
+		t.Errorf("expected $0.30 spent, got %v", usage.ModelSpendUSD)
+	}
+
+	// Streaming goes through the same checks
+	streamed := manager.meterModel(&chunkModel{pieces: []string{`{"answer": "ok"}`}}, ModelCall{Operation: ModelOperationVision, TenantID: "acme"})
+	if _, err := streamed.(vision.Streamer).AskStream(context.Background(), vision.Query{}, func(string) {}); !errors.Is(err, ErrTenantModelBudget) {
+		t.Errorf("expected a streamed call refused too, got %v", err)
+	}
+
+	// Another tenant has a budget of its own, until the deployment's runs out
+	manager.SetModelBudget(0.4)
+	globex := manager.meterModel(model, ModelCall{Operation: ModelOperationResearch, TenantID: "globex"})
+	if _, err := globex.Ask(context.Background(), vision.Query{}); err != nil {
+		t.Fatalf("globex call failed: %v", err)
+	}
+	if _, err := globex.Ask(context.Background(), vision.Query{}); !errors.Is(err, ErrModelBudget) {
+		t.Errorf("expected ErrModelBudget, got %v", err)
+	}
+
+	days := manager.ModelUsage("acme", 1)
+	if len(days) != 1 || days[0].Calls != 2 || len(days[0].Sessions) != 1 || days[0].Sessions[0].Calls != 2 {
+		t.Errorf("expected only acme's two calls counted, got %+v", days)
+	}
+	if manager.meterModel(nil, ModelCall{}) != nil {
+		t.Error("expected no model to stay nil")
+	}
+}
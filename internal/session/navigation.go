@@ -0,0 +1,365 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// Kinds of navigation failure
+const (
+	NavigationDNS          = "dns"               // Host name did not resolve
+	NavigationTLS          = "tls"               // Certificate or TLS handshake error
+	NavigationConnection   = "connection"        // Refused, reset, unreachable or offline
+	NavigationTimeout      = "timeout"           // No response in time
+	NavigationBlocked      = "blocked"           // Blocked by the session's blocked URLs, a policy or the response
+	NavigationAborted      = "aborted"           // Cancelled, e.g. by a download or another navigation
+	NavigationInterstitial = "interstitial"      // Chrome showed its own error page instead of the site
+	NavigationNetwork      = "network"           // Any other net::ERR_* failure
+	NavigationClientError  = "http_client_error" // The document came back 4xx
+	NavigationServerError  = "http_server_error" // The document came back 5xx
+)
+
+// NavigationError is returned when a navigation never produced a document from the
+// site. HTTP error statuses are not errors: the page loaded and carries the site's
+// own error page, so they are reported in NavigationInfo.Failure instead.
+type NavigationError struct {
+	Kind      string // One of the Navigation* kinds
+	URL       string // Requested URL
+	ErrorText string // Chrome's error text, e.g. net::ERR_NAME_NOT_RESOLVED
+	Err       error  // Underlying command error, if the navigation command itself failed
+}
+
+func (e *NavigationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("navigation to %s failed (%s): %v", e.URL, e.Kind, e.Err)
+	}
+	return fmt.Sprintf("navigation to %s failed (%s): %s", e.URL, e.Kind, e.ErrorText)
+}
+
+func (e *NavigationError) Unwrap() error {
+	return e.Err
+}
+
+// classifyNetError maps one of Chrome's net::ERR_* error texts to a failure kind
+func classifyNetError(errorText string) string {
+	code := strings.TrimPrefix(errorText, "net::")
+	switch {
+	case code == "ERR_NAME_NOT_RESOLVED", code == "ERR_NAME_RESOLUTION_FAILED", code == "ERR_DNS_TIMED_OUT":
+		return NavigationDNS
+	case strings.HasPrefix(code, "ERR_CERT_"), strings.HasPrefix(code, "ERR_SSL_"),
+		code == "ERR_BAD_SSL_CLIENT_AUTH_CERT", code == "ERR_INSECURE_RESPONSE":
+		return NavigationTLS
+	case code == "ERR_TIMED_OUT", code == "ERR_CONNECTION_TIMED_OUT":
+		return NavigationTimeout
+	case strings.HasPrefix(code, "ERR_CONNECTION_"), code == "ERR_ADDRESS_UNREACHABLE",
+		code == "ERR_INTERNET_DISCONNECTED", code == "ERR_EMPTY_RESPONSE", code == "ERR_NETWORK_CHANGED":
+		return NavigationConnection
+	case strings.HasPrefix(code, "ERR_BLOCKED_BY_"), code == "ERR_UNSAFE_PORT":
+		return NavigationBlocked
+	case code == "ERR_ABORTED":
+		return NavigationAborted
+	default:
+		return NavigationNetwork
+	}
+}
+
+// classifyNavigation names what went wrong with a navigation, or returns "" when it
+// loaded a document with a successful status
+func classifyNavigation(info *NavigationInfo) string {
+	switch {
+	case info.ErrorText != "":
+		return classifyNetError(info.ErrorText)
+	case strings.HasPrefix(info.URL, "chrome-error://"):
+		return NavigationInterstitial
+	case info.Status >= 500:
+		return NavigationServerError
+	case info.Status >= 400:
+		return NavigationClientError
+	default:
+		return ""
+	}
+}
+
+// navigationFailed returns the error for a navigation that produced no document
+func navigationFailed(info *NavigationInfo) error {
+	if info == nil {
+		return nil
+	}
+	switch info.Failure {
+	case "", NavigationClientError, NavigationServerError:
+		return nil
+	}
+	return &NavigationError{Kind: info.Failure, URL: info.RequestedURL, ErrorText: info.ErrorText}
+}
+
+// RedirectHop is one HTTP redirect on the way to the final document
+type RedirectHop struct {
+	URL      string `json:"url"`
+	Status   int    `json:"status"`
+	Location string `json:"location"` // Where the redirect pointed
+}
+
+// NavigationInfo describes where a navigation ended up
+type NavigationInfo struct {
+	RequestedURL string        `json:"requested_url"`
+	URL          string        `json:"url"`              // Final URL, after redirects
+	Status       int           `json:"status,omitempty"` // HTTP status of the final document; 0 when none was fetched
+	StatusText   string        `json:"status_text,omitempty"`
+	MimeType     string        `json:"mime_type,omitempty"`
+	Redirects    []RedirectHop `json:"redirects"`
+	ErrorText    string        `json:"error_text,omitempty"` // Chrome's net::ERR_* text when the navigation failed
+	Failure      string        `json:"failure,omitempty"`    // Kind of failure, one of the Navigation* kinds
+
+	// Security is the TLS connection and certificate of the final document
+	Security *SecurityDetails `json:"security,omitempty"`
+}
+
+// navigationRequest is what the Network domain reported about one document request
+type navigationRequest struct {
+	redirects  []RedirectHop
+	url        string
+	status     int
+	statusText string
+	mimeType   string
+	failure    string
+	security   *SecurityDetails
+}
+
+// navigationRecorder collects the main frame's document requests while a navigation runs
+type navigationRecorder struct {
+	frameID     string
+	requests    map[string]*navigationRequest // Keyed by request ID, which is the loader ID for navigations
+	order       []string
+	mu          sync.Mutex
+	unsubscribe []func()
+}
+
+// requestWillBeSentEvent is the part of Network.requestWillBeSent we read
+type requestWillBeSentEvent struct {
+	RequestID string `json:"requestId"`
+	FrameID   string `json:"frameId"`
+	Type      string `json:"type"`
+	Request   struct {
+		URL string `json:"url"`
+	} `json:"request"`
+	RedirectResponse *struct {
+		URL    string `json:"url"`
+		Status int    `json:"status"`
+	} `json:"redirectResponse,omitempty"`
+}
+
+// responseReceivedEvent is the part of Network.responseReceived we read
+type responseReceivedEvent struct {
+	RequestID string `json:"requestId"`
+	Type      string `json:"type"`
+	Response  struct {
+		URL        string `json:"url"`
+		Status     int    `json:"status"`
+		StatusText string `json:"statusText"`
+		MimeType   string `json:"mimeType"`
+
+		SecurityState   string                   `json:"securityState"`
+		SecurityDetails *responseSecurityDetails `json:"securityDetails"`
+	} `json:"response"`
+}
+
+// loadingFailedEvent is the part of Network.loadingFailed we read
+type loadingFailedEvent struct {
+	RequestID string `json:"requestId"`
+	Type      string `json:"type"`
+	ErrorText string `json:"errorText"`
+}
+
+// startNavigationRecorder listens for the document requests of a page's main frame.
+// The Network domain must be enabled for the events to arrive.
+func (s *Session) startNavigationRecorder(ctx context.Context, targetID string) (*navigationRecorder, error) {
+	cdpSessionID, err := s.CDPClient.AttachToTarget(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach for navigation: %w", err)
+	}
+
+	// A page target's main frame has the target's ID
+	recorder := &navigationRecorder{
+		frameID:  targetID,
+		requests: make(map[string]*navigationRequest),
+	}
+
+	recorder.unsubscribe = []func(){
+		s.CDPClient.OnEvent("Network.requestWillBeSent", cdpSessionID, recorder.onRequestWillBeSent),
+		s.CDPClient.OnEvent("Network.responseReceived", cdpSessionID, recorder.onResponseReceived),
+		s.CDPClient.OnEvent("Network.loadingFailed", cdpSessionID, recorder.onLoadingFailed),
+	}
+
+	return recorder, nil
+}
+
+// onRequestWillBeSent starts a document request, or adds a hop when it is a redirect
+func (r *navigationRecorder) onRequestWillBeSent(event *cdp.Event) {
+	var params requestWillBeSentEvent
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.Type != "Document" || params.FrameID != r.frameID {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request := r.request(params.RequestID)
+	if params.RedirectResponse != nil {
+		request.redirects = append(request.redirects, RedirectHop{
+			URL:      params.RedirectResponse.URL,
+			Status:   params.RedirectResponse.Status,
+			Location: params.Request.URL,
+		})
+	}
+	request.url = params.Request.URL
+}
+
+// onResponseReceived records the final response of a document request
+func (r *navigationRecorder) onResponseReceived(event *cdp.Event) {
+	var params responseReceivedEvent
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.Type != "Document" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if request, exists := r.requests[params.RequestID]; exists {
+		request.url = params.Response.URL
+		request.status = params.Response.Status
+		request.statusText = params.Response.StatusText
+		request.mimeType = params.Response.MimeType
+		request.security = newSecurityDetails(params.Response.SecurityState, params.Response.SecurityDetails, time.Now())
+	}
+}
+
+// onLoadingFailed records why a document request failed
+func (r *navigationRecorder) onLoadingFailed(event *cdp.Event) {
+	var params loadingFailedEvent
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.Type != "Document" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if request, exists := r.requests[params.RequestID]; exists {
+		request.failure = params.ErrorText
+	}
+}
+
+// request returns the entry for a request ID, creating it. Caller must hold r.mu.
+func (r *navigationRecorder) request(requestID string) *navigationRequest {
+	request, exists := r.requests[requestID]
+	if !exists {
+		request = &navigationRequest{}
+		r.requests[requestID] = request
+		r.order = append(r.order, requestID)
+	}
+	return request
+}
+
+// stop removes the event listeners
+func (r *navigationRecorder) stop() {
+	for _, unsubscribe := range r.unsubscribe {
+		unsubscribe()
+	}
+}
+
+// result builds the navigation report for the request with loaderID, falling back to
+// the most recent document request when the IDs don't line up
+func (r *navigationRecorder) result(requestedURL string, loaderID string, errorText string) *NavigationInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info := &NavigationInfo{
+		RequestedURL: requestedURL,
+		URL:          requestedURL,
+		Redirects:    []RedirectHop{},
+		ErrorText:    errorText,
+	}
+
+	request := r.requests[loaderID]
+	if request == nil && len(r.order) > 0 {
+		request = r.requests[r.order[len(r.order)-1]]
+	}
+	if request == nil {
+		// Nothing was fetched: about:blank, data: URLs and same-document navigations
+		info.Failure = classifyNavigation(info)
+		return info
+	}
+
+	if request.url != "" {
+		info.URL = request.url
+	}
+	info.Status = request.status
+	info.StatusText = request.statusText
+	info.MimeType = request.mimeType
+	info.Security = request.security
+	info.Redirects = append(info.Redirects, request.redirects...)
+	if info.ErrorText == "" {
+		info.ErrorText = request.failure
+	}
+	info.Failure = classifyNavigation(info)
+	return info
+}
+
+// setNavigation records the latest navigation report of a page
+func (s *Session) setNavigation(targetID string, info *NavigationInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.navigations == nil {
+		s.navigations = make(map[string]*NavigationInfo)
+	}
+	s.navigations[targetID] = info
+}
+
+// LastNavigation returns the report of the most recent navigation of a page, if any
+func (s *Session) LastNavigation(targetID string) *NavigationInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.navigations[targetID]
+}
+
+// recordNavigation runs navigate with the page's document requests recorded.
+// navigate returns the Page.navigate loader ID and error text.
+func (s *Session) recordNavigation(ctx context.Context, targetID string, url string, navigate func() (string, string, error)) error {
+	recorder, err := s.startNavigationRecorder(ctx, targetID)
+	if err != nil {
+		return err
+	}
+	defer recorder.stop()
+
+	// Blocking, capture and metering already keep the Network domain on; otherwise it is
+	// only on for the navigation
+	keepNetwork := s.Options.keepsNetwork() || s.bandwidth != nil
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Network.enable", nil); err != nil {
+		return fmt.Errorf("failed to enable network domain: %w", err)
+	}
+	if !keepNetwork {
+		defer func() {
+			if _, err := s.CDPClient.SendCommandToTarget(context.WithoutCancel(ctx), targetID, "Network.disable", nil); err != nil {
+				slog.Debug("failed to disable network domain", "page_id", targetID, "error", err)
+			}
+		}()
+	}
+
+	// Page.navigate answers once the navigation has committed or failed, after the
+	// document's redirects and response have been reported
+	loaderID, errorText, err := navigate()
+	if err != nil {
+		if errors.Is(err, cdp.ErrCommandTimeout) || errors.Is(err, context.DeadlineExceeded) {
+			return &NavigationError{Kind: NavigationTimeout, URL: url, Err: err}
+		}
+		return err
+	}
+
+	s.setNavigation(targetID, recorder.result(url, loaderID, errorText))
+	return nil
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestNavigationRecorder tests assembling a redirect chain from Network events
+func TestNavigationRecorder(t *testing.T) {
+	recorder := &navigationRecorder{frameID: "PAGE1", requests: make(map[string]*navigationRequest)}
+
+	event := func(method string, params string) *cdp.Event {
+		return &cdp.Event{Method: method, Params: []byte(params)}
+	}
+
+	recorder.onRequestWillBeSent(event("Network.requestWillBeSent", `{"requestId": "L1", "frameId": "PAGE1", "type": "Document", "request": {"url": "http://example.com/account"}}`))
+	recorder.onRequestWillBeSent(event("Network.requestWillBeSent", `{"requestId": "L1", "frameId": "PAGE1", "type": "Document", "request": {"url": "https://example.com/account"}, "redirectResponse": {"url": "http://example.com/account", "status": 301}}`))
+	recorder.onRequestWillBeSent(event("Network.requestWillBeSent", `{"requestId": "L1", "frameId": "PAGE1", "type": "Document", "request": {"url": "https://example.com/login"}, "redirectResponse": {"url": "https://example.com/account", "status": 302}}`))
+	// Subresources and iframes are not part of the navigation
+	recorder.onRequestWillBeSent(event("Network.requestWillBeSent", `{"requestId": "R9", "frameId": "PAGE1", "type": "Script", "request": {"url": "https://example.com/app.js"}}`))
+	recorder.onRequestWillBeSent(event("Network.requestWillBeSent", `{"requestId": "L2", "frameId": "FRAME2", "type": "Document", "request": {"url": "https://ads.example.net/"}}`))
+	recorder.onResponseReceived(event("Network.responseReceived", `{"requestId": "L1", "type": "Document", "response": {"url": "https://example.com/login", "status": 200, "statusText": "OK", "mimeType": "text/html"}}`))
+
+	info := recorder.result("http://example.com/account", "L1", "")
+	if info.URL != "https://example.com/login" || info.Status != 200 || info.MimeType != "text/html" {
+		t.Errorf("unexpected final document: %+v", info)
+	}
+	if len(info.Redirects) != 2 {
+		t.Fatalf("expected two redirects, got %+v", info.Redirects)
+	}
+	if hop := info.Redirects[1]; hop.URL != "https://example.com/account" || hop.Status != 302 || hop.Location != "https://example.com/login" {
+		t.Errorf("unexpected second hop: %+v", hop)
+	}
+
+	// A navigation that fetched nothing reports the requested URL
+	empty := &navigationRecorder{frameID: "PAGE1", requests: make(map[string]*navigationRequest)}
+	if info := empty.result("about:blank", "", ""); info.URL != "about:blank" || info.Status != 0 || info.Redirects == nil {
+		t.Errorf("unexpected report for an empty navigation: %+v", info)
+	}
+}
+
+// TestClassifyNavigation tests naming navigation failures
+func TestClassifyNavigation(t *testing.T) {
+	tests := []struct {
+		info NavigationInfo
+		want string
+	}{
+		{NavigationInfo{URL: "https://example.com/", Status: 200}, ""},
+		{NavigationInfo{URL: "about:blank"}, ""},
+		{NavigationInfo{ErrorText: "net::ERR_NAME_NOT_RESOLVED"}, NavigationDNS},
+		{NavigationInfo{ErrorText: "net::ERR_CERT_AUTHORITY_INVALID"}, NavigationTLS},
+		{NavigationInfo{ErrorText: "net::ERR_SSL_PROTOCOL_ERROR"}, NavigationTLS},
+		{NavigationInfo{ErrorText: "net::ERR_CONNECTION_REFUSED"}, NavigationConnection},
+		{NavigationInfo{ErrorText: "net::ERR_CONNECTION_TIMED_OUT"}, NavigationTimeout},
+		{NavigationInfo{ErrorText: "net::ERR_BLOCKED_BY_CLIENT"}, NavigationBlocked},
+		{NavigationInfo{ErrorText: "net::ERR_ABORTED"}, NavigationAborted},
+		{NavigationInfo{ErrorText: "net::ERR_TOO_MANY_REDIRECTS"}, NavigationNetwork},
+		{NavigationInfo{URL: "chrome-error://chromewebdata/"}, NavigationInterstitial},
+		{NavigationInfo{URL: "https://example.com/missing", Status: 404}, NavigationClientError},
+		{NavigationInfo{URL: "https://example.com/", Status: 503}, NavigationServerError},
+	}
+	for _, tt := range tests {
+		if got := classifyNavigation(&tt.info); got != tt.want {
+			t.Errorf("classifyNavigation(%+v) = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+
+	// Only failures without a site document are errors
+	if err := navigationFailed(&NavigationInfo{Status: 404, Failure: NavigationClientError}); err != nil {
+		t.Errorf("expected a 404 to load, got %v", err)
+	}
+	var navErr *NavigationError
+	if err := navigationFailed(&NavigationInfo{RequestedURL: "https://nope.invalid/", ErrorText: "net::ERR_NAME_NOT_RESOLVED", Failure: NavigationDNS}); !errors.As(err, &navErr) || navErr.Kind != NavigationDNS {
+		t.Errorf("expected a dns NavigationError, got %v", err)
+	}
+}
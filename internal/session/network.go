@@ -0,0 +1,585 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+const (
+	// DefaultWebSocketFrames is how many frames a page keeps unless the options say otherwise
+	DefaultWebSocketFrames = 1000
+
+	// MaxWebSocketFrames is the most frames a page can be set to keep
+	MaxWebSocketFrames = 10000
+
+	// DefaultCapturedPayloadBytes is where a captured payload is cut unless the options say otherwise
+	DefaultCapturedPayloadBytes = 64 << 10
+
+	// MaxCapturedPayloadBytes is the longest captured payload the options can ask for
+	MaxCapturedPayloadBytes = 1 << 20
+
+	// maxTrackedConnections bounds the sockets and streams a page remembers; pages that
+	// reconnect forever would otherwise grow the lists without end
+	maxTrackedConnections = 200
+
+	// defaultPayloadMask replaces what a masking rule matches when it names no replacement
+	defaultPayloadMask = "***"
+)
+
+// WebSocket frame directions
+const (
+	FrameSent     = "sent"
+	FrameReceived = "received"
+)
+
+// WebSocketCapture turns on recording of the WebSocket traffic of a session's pages
+type WebSocketCapture struct {
+	MaxFrames       int           `json:"max_frames,omitempty"`        // Kept per page, oldest dropped first; default 1000
+	MaxPayloadBytes int           `json:"max_payload_bytes,omitempty"` // Longer payloads are cut; default 64 KiB
+	Masks           []PayloadMask `json:"masks,omitempty"`             // Applied to text frames before they are kept
+}
+
+// PayloadMask replaces what a regular expression matches in captured payloads, so
+// tokens and personal data are never stored
+type PayloadMask struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement,omitempty"` // Default "***"; may refer to groups as $1
+}
+
+// validate checks the limits and compiles the masks
+func (c *WebSocketCapture) validate() error {
+	return validateCapture("websockets", "max_frames", c.MaxFrames, MaxWebSocketFrames, c.MaxPayloadBytes, c.Masks)
+}
+
+// validateCapture checks the limits and masks of the capture option called option
+func validateCapture(option, entriesField string, entries, maxEntries, payloadBytes int, masks []PayloadMask) error {
+	if entries < 0 || entries > maxEntries {
+		return fmt.Errorf("%s.%s must be between 0 and %d", option, entriesField, maxEntries)
+	}
+	if payloadBytes < 0 || payloadBytes > MaxCapturedPayloadBytes {
+		return fmt.Errorf("%s.max_payload_bytes must be between 0 and %d", option, MaxCapturedPayloadBytes)
+	}
+	for _, mask := range masks {
+		if _, err := regexp.Compile(mask.Pattern); err != nil || mask.Pattern == "" {
+			return fmt.Errorf("invalid %s mask pattern %q", option, mask.Pattern)
+		}
+	}
+	return nil
+}
+
+// payloadRules mask and cut captured payloads
+type payloadRules struct {
+	maxBytes int
+	masks    []*regexp.Regexp
+	replace  []string
+}
+
+// newPayloadRules prepares the rules of a validated capture option
+func newPayloadRules(maxBytes int, masks []PayloadMask) *payloadRules {
+	rules := &payloadRules{maxBytes: maxBytes}
+	if rules.maxBytes == 0 {
+		rules.maxBytes = DefaultCapturedPayloadBytes
+	}
+	for _, mask := range masks {
+		rules.masks = append(rules.masks, regexp.MustCompile(mask.Pattern))
+		replacement := mask.Replacement
+		if replacement == "" {
+			replacement = defaultPayloadMask
+		}
+		rules.replace = append(rules.replace, replacement)
+	}
+	return rules
+}
+
+// text masks a text payload and cuts it, on a character boundary, to the byte limit.
+// size is the payload's length as it arrived.
+func (r *payloadRules) text(data string) (payload string, size int, truncated, masked bool) {
+	size = len(data)
+	for i, mask := range r.masks {
+		replaced := mask.ReplaceAllString(data, r.replace[i])
+		masked = masked || replaced != data
+		data = replaced
+	}
+	if len(data) > r.maxBytes {
+		cut := r.maxBytes
+		for cut > 0 && !utf8.RuneStart(data[cut]) {
+			cut--
+		}
+		data, truncated = data[:cut], true
+	}
+	return data, size, truncated, masked
+}
+
+// binary cuts a base64 encoded payload on its decoded bytes. Masks don't apply.
+func (r *payloadRules) binary(data string) (payload string, size int, truncated bool) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return data, len(data), false
+	}
+	if len(decoded) > r.maxBytes {
+		return base64.StdEncoding.EncodeToString(decoded[:r.maxBytes]), len(decoded), true
+	}
+	return data, len(decoded), false
+}
+
+// ring keeps the newest max items, counting those it let go
+type ring[T any] struct {
+	items   []T
+	next    int // Oldest item once full
+	max     int
+	dropped int
+}
+
+// add keeps item, dropping the oldest once the ring is full
+func (r *ring[T]) add(item T) {
+	if len(r.items) < r.max {
+		r.items = append(r.items, item)
+		return
+	}
+	r.items[r.next] = item
+	r.next = (r.next + 1) % r.max
+	r.dropped++
+}
+
+// ordered returns the kept items, oldest first
+func (r *ring[T]) ordered() []T {
+	items := make([]T, 0, len(r.items))
+	items = append(items, r.items[r.next:]...)
+	return append(items, r.items[:r.next]...)
+}
+
+// connections remembers the most recent sockets or streams of a page by ID
+type connections[T any] struct {
+	byID  map[string]*T
+	order []string // IDs, oldest first
+}
+
+// get returns the connection with id, remembering the one create makes if it is new
+func (c *connections[T]) get(id string, create func() *T) *T {
+	if conn, exists := c.byID[id]; exists {
+		return conn
+	}
+	if c.byID == nil {
+		c.byID = make(map[string]*T)
+	}
+	conn := create()
+	c.byID[id] = conn
+	c.order = append(c.order, id)
+	if len(c.order) > maxTrackedConnections {
+		delete(c.byID, c.order[0])
+		c.order = c.order[1:]
+	}
+	return conn
+}
+
+// find returns the connection with id, or nil
+func (c *connections[T]) find(id string) *T {
+	return c.byID[id]
+}
+
+// list returns copies of the connections whose URL contains urlPart, oldest first
+func (c *connections[T]) list(urlPart string, url func(*T) string) []T {
+	list := []T{}
+	for _, id := range c.order {
+		if conn := c.byID[id]; urlPart == "" || strings.Contains(url(conn), urlPart) {
+			list = append(list, *conn)
+		}
+	}
+	return list
+}
+
+// TrafficFilter narrows captured traffic to some connections, sizes and times. Zero
+// fields don't filter.
+type TrafficFilter struct {
+	URL     string // Substring of the socket or stream URL
+	MinSize int
+	MaxSize int
+	Since   time.Time
+	Until   time.Time
+	Limit   int // Only the newest matching entries
+}
+
+// validate rejects filters that can't match anything by construction
+func (f TrafficFilter) validate() error {
+	if f.MinSize < 0 || f.MaxSize < 0 || f.Limit < 0 {
+		return fmt.Errorf("%w: sizes and limit must not be negative", ErrInvalidNetworkFilter)
+	}
+	if f.MaxSize > 0 && f.MaxSize < f.MinSize {
+		return fmt.Errorf("%w: max_size is below min_size", ErrInvalidNetworkFilter)
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && f.Until.Before(f.Since) {
+		return fmt.Errorf("%w: until is before since", ErrInvalidNetworkFilter)
+	}
+	return nil
+}
+
+// matches reports whether an entry of a connection at url, size bytes long and
+// captured at at, passes the filter
+func (f TrafficFilter) matches(url string, size int, at time.Time) bool {
+	return (f.URL == "" || strings.Contains(url, f.URL)) &&
+		size >= f.MinSize && (f.MaxSize == 0 || size <= f.MaxSize) &&
+		(f.Since.IsZero() || !at.Before(f.Since)) &&
+		(f.Until.IsZero() || !at.After(f.Until))
+}
+
+// newest returns the last limit items, or all of them when limit is 0
+func newest[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[len(items)-limit:]
+	}
+	return items
+}
+
+// WebSocketInfo is a WebSocket a page opened
+type WebSocketInfo struct {
+	ID       string     `json:"id"` // The socket's request ID; frames refer to it
+	URL      string     `json:"url"`
+	Frames   int        `json:"frames"` // Sent and received since capture started
+	OpenedAt time.Time  `json:"opened_at"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	Error    string     `json:"error,omitempty"` // The last frame error the browser reported
+}
+
+// WebSocketFrame is one captured WebSocket message
+type WebSocketFrame struct {
+	Socket    string    `json:"socket"`
+	URL       string    `json:"url"`
+	Direction string    `json:"direction"` // sent or received
+	Opcode    int       `json:"opcode"`    // 1 text, 2 binary (payload base64 encoded)
+	Payload   string    `json:"payload"`
+	Size      int       `json:"size"` // Payload bytes as sent, before masking and cutting
+	Truncated bool      `json:"truncated,omitempty"`
+	Masked    bool      `json:"masked,omitempty"` // A masking rule changed the payload
+	Time      time.Time `json:"time"`
+}
+
+// WebSocketFilter narrows the frames WebSocketTraffic returns
+type WebSocketFilter struct {
+	TrafficFilter
+	Direction string // sent or received; empty is both
+}
+
+// validate rejects filters that can't match anything by construction
+func (f WebSocketFilter) validate() error {
+	if f.Direction != "" && f.Direction != FrameSent && f.Direction != FrameReceived {
+		return fmt.Errorf("%w: direction must be %s or %s, got %q", ErrInvalidNetworkFilter, FrameSent, FrameReceived, f.Direction)
+	}
+	return f.TrafficFilter.validate()
+}
+
+// WebSocketTraffic is what a page's WebSocket capture holds
+type WebSocketTraffic struct {
+	Sockets []WebSocketInfo  `json:"sockets"`
+	Frames  []WebSocketFrame `json:"frames"`
+	Total   int              `json:"total"`   // Frames matching the filter, before the limit
+	Dropped int              `json:"dropped"` // Frames dropped to stay within max_frames
+}
+
+// pageNetwork is the network capture state of one page
+type pageNetwork struct {
+	socketRules *payloadRules // nil when WebSockets aren't captured
+	sockets     connections[WebSocketInfo]
+	frames      ring[WebSocketFrame]
+
+	streamRules *payloadRules // nil when streams aren't captured
+	streamTypes []string      // MIME types of fetch responses whose bodies are streamed
+	streams     connections[StreamInfo]
+	messages    ring[StreamMessage]
+
+	certificates *clientCertificates // nil when no host gets a client certificate
+
+	meter    *bandwidthMeter // nil when the session isn't metered
+	requests int64           // Finished by the page, when metered
+	bytes    int64           // Downloaded by the page, when metered
+
+	unsubscribe func()
+}
+
+// newPageNetwork prepares the capture state opts ask for
+func newPageNetwork(opts *SessionOptions, certificates *clientCertificates, meter *bandwidthMeter) *pageNetwork {
+	network := &pageNetwork{certificates: certificates, meter: meter, unsubscribe: func() {}}
+	if opts == nil {
+		return network
+	}
+	if capture := opts.WebSockets; capture != nil {
+		network.socketRules = newPayloadRules(capture.MaxPayloadBytes, capture.Masks)
+		network.frames.max = capture.MaxFrames
+		if network.frames.max == 0 {
+			network.frames.max = DefaultWebSocketFrames
+		}
+	}
+	if capture := opts.Streams; capture != nil {
+		network.streamRules = newPayloadRules(capture.MaxPayloadBytes, capture.Masks)
+		network.streamTypes = append(append([]string(nil), defaultStreamTypes...), capture.MIMETypes...)
+		network.messages.max = capture.MaxMessages
+		if network.messages.max == 0 {
+			network.messages.max = DefaultStreamMessages
+		}
+	}
+	return network
+}
+
+// events returns the Network events the page's capture listens for
+func (n *pageNetwork) events() []string {
+	var methods []string
+	if n.socketRules != nil {
+		methods = append(methods,
+			"Network.webSocketCreated",
+			"Network.webSocketFrameSent",
+			"Network.webSocketFrameReceived",
+			"Network.webSocketFrameError",
+			"Network.webSocketClosed",
+		)
+	}
+	if n.streamRules != nil {
+		methods = append(methods,
+			"Network.responseReceived",
+			"Network.eventSourceMessageReceived",
+			"Network.dataReceived",
+			"Network.loadingFinished",
+			"Network.loadingFailed",
+		)
+	}
+	if n.meter != nil && n.streamRules == nil {
+		methods = append(methods, "Network.loadingFinished")
+	}
+	if n.certificates != nil {
+		methods = append(methods, "Fetch.requestPaused")
+	}
+	return methods
+}
+
+// startNetworkCapture starts recording a page's WebSocket and stream traffic when the
+// session's options ask for it, metering what it downloads when the session is metered,
+// and sends the requests of client certificate hosts itself. The Network domain stays
+// enabled for capture from then on.
+func (s *Session) startNetworkCapture(ctx context.Context, targetID string) error {
+	if !s.Options.capturesNetwork() && s.clientCerts == nil && s.bandwidth == nil {
+		return nil
+	}
+
+	// Claim the page first, as trackRoutes does, so only one caller sets it up
+	network := newPageNetwork(s.Options, s.clientCerts, s.bandwidth)
+	s.networkMu.Lock()
+	if _, exists := s.network[targetID]; exists {
+		s.networkMu.Unlock()
+		return nil
+	}
+	if s.network == nil {
+		s.network = make(map[string]*pageNetwork)
+	}
+	s.network[targetID] = network
+	s.networkMu.Unlock()
+
+	unsubscribe, err := s.installNetworkCapture(ctx, targetID, network)
+
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	if err != nil {
+		if s.network[targetID] == network {
+			delete(s.network, targetID)
+		}
+		return err
+	}
+
+	// The page went away while capture was being set up
+	if s.network[targetID] != network {
+		unsubscribe()
+		return nil
+	}
+	network.unsubscribe = unsubscribe
+	return nil
+}
+
+// installNetworkCapture subscribes to the page's events and enables the domains that
+// send them, returning the function that unsubscribes
+func (s *Session) installNetworkCapture(ctx context.Context, targetID string, network *pageNetwork) (func(), error) {
+	cdpSessionID, err := s.CDPClient.AttachToTarget(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach for network capture: %w", err)
+	}
+
+	var unsubscribers []func()
+	for _, method := range network.events() {
+		unsubscribers = append(unsubscribers, s.CDPClient.OnEvent(method, cdpSessionID, func(event *cdp.Event) {
+			s.dispatchNetworkEvent(targetID, event)
+		}))
+	}
+	unsubscribe := func() {
+		for _, unsubscribe := range unsubscribers {
+			unsubscribe()
+		}
+	}
+
+	if network.socketRules != nil || network.streamRules != nil || network.meter != nil {
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Network.enable", nil); err != nil {
+			unsubscribe()
+			return nil, fmt.Errorf("failed to enable network domain: %w", err)
+		}
+	}
+	if network.certificates != nil {
+		params := map[string]interface{}{"patterns": network.certificates.patterns()}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Fetch.enable", params); err != nil {
+			unsubscribe()
+			return nil, fmt.Errorf("failed to intercept client certificate hosts: %w", err)
+		}
+	}
+	return unsubscribe, nil
+}
+
+// dispatchNetworkEvent applies a WebSocket, stream or paused request event of a page
+func (s *Session) dispatchNetworkEvent(targetID string, event *cdp.Event) {
+	if event.Method == "Network.loadingFinished" {
+		s.meterLoadingFinished(targetID, event)
+	}
+	switch {
+	case strings.HasPrefix(event.Method, "Network.webSocket"):
+		s.dispatchSocketEvent(targetID, event)
+	case event.Method == "Fetch.requestPaused":
+		s.dispatchPausedRequest(targetID, event)
+	default:
+		s.dispatchStreamEvent(targetID, event)
+	}
+}
+
+// dispatchSocketEvent applies a WebSocket event of a captured page
+func (s *Session) dispatchSocketEvent(targetID string, event *cdp.Event) {
+	var params struct {
+		RequestID    string `json:"requestId"`
+		URL          string `json:"url"`
+		ErrorMessage string `json:"errorMessage"`
+		Response     struct {
+			Opcode      int    `json:"opcode"`
+			PayloadData string `json:"payloadData"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.RequestID == "" {
+		return
+	}
+	now := time.Now()
+
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	network := s.network[targetID]
+	if network == nil || network.socketRules == nil {
+		return
+	}
+	// Sockets opened before capture started are remembered from their first frame
+	socket := network.sockets.get(params.RequestID, func() *WebSocketInfo {
+		return &WebSocketInfo{ID: params.RequestID, URL: params.URL, OpenedAt: now}
+	})
+
+	switch event.Method {
+	case "Network.webSocketFrameSent", "Network.webSocketFrameReceived":
+		direction := FrameReceived
+		if event.Method == "Network.webSocketFrameSent" {
+			direction = FrameSent
+		}
+		frame := WebSocketFrame{Socket: socket.ID, URL: socket.URL, Direction: direction, Opcode: params.Response.Opcode, Time: now}
+		if frame.Opcode == 1 {
+			frame.Payload, frame.Size, frame.Truncated, frame.Masked = network.socketRules.text(params.Response.PayloadData)
+		} else {
+			frame.Payload, frame.Size, frame.Truncated = network.socketRules.binary(params.Response.PayloadData)
+		}
+		socket.Frames++
+		network.frames.add(frame)
+	case "Network.webSocketFrameError":
+		socket.Error = params.ErrorMessage
+	case "Network.webSocketClosed":
+		socket.ClosedAt = &now
+	}
+}
+
+// webSocketTraffic returns the page's sockets and the frames passing filter
+func (s *Session) webSocketTraffic(targetID string, filter WebSocketFilter) *WebSocketTraffic {
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	traffic := &WebSocketTraffic{Sockets: []WebSocketInfo{}, Frames: []WebSocketFrame{}}
+	network := s.network[targetID]
+	if network == nil {
+		return traffic
+	}
+
+	traffic.Sockets = network.sockets.list(filter.URL, func(socket *WebSocketInfo) string { return socket.URL })
+	for _, frame := range network.frames.ordered() {
+		if (filter.Direction == "" || frame.Direction == filter.Direction) && filter.matches(frame.URL, frame.Size, frame.Time) {
+			traffic.Frames = append(traffic.Frames, frame)
+		}
+	}
+	traffic.Total = len(traffic.Frames)
+	traffic.Frames = newest(traffic.Frames, filter.Limit)
+	traffic.Dropped = network.frames.dropped
+	return traffic
+}
+
+// stopNetworkCapture stops capturing a page that is gone
+func (s *Session) stopNetworkCapture(targetID string) {
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	if network, exists := s.network[targetID]; exists {
+		delete(s.network, targetID)
+		network.unsubscribe()
+	}
+}
+
+// stopAllNetworkCapture stops capturing every page of the session (used when its pages are torn down)
+func (s *Session) stopAllNetworkCapture() {
+	s.networkMu.Lock()
+	defer s.networkMu.Unlock()
+
+	for targetID, network := range s.network {
+		delete(s.network, targetID)
+		network.unsubscribe()
+	}
+}
+
+// captureNetwork starts a page's network capture. It is best effort, like route
+// tracking: a page that can't be instrumented still works, its traffic just isn't kept.
+func (m *Manager) captureNetwork(ctx context.Context, session *Session, pageID string) {
+	if session.CDPClient == nil || !session.CDPClient.IsConnected() {
+		return
+	}
+	if err := session.startNetworkCapture(ctx, pageID); err != nil {
+		slog.WarnContext(ctx, "failed to capture page network traffic", "session_id", session.ID, "page_id", pageID, "error", err)
+	}
+}
+
+// capturingSession returns a session whose pages keep the traffic option is about
+func (m *Manager) capturingSession(sessionID string, pageID string, option string, enabled func(*SessionOptions) bool) (*Session, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "network capture")
+	if err != nil {
+		return nil, err
+	}
+	if session.Options == nil || !enabled(session.Options) {
+		return nil, fmt.Errorf("%w: create the session with the %s option", ErrCaptureDisabled, option)
+	}
+	return session, nil
+}
+
+// WebSocketTraffic returns the WebSocket frames a page sent and received, for sessions
+// created with WebSocket capture on
+func (m *Manager) WebSocketTraffic(sessionID string, pageID string, filter WebSocketFilter) (*WebSocketTraffic, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.capturingSession(sessionID, pageID, "websockets", func(opts *SessionOptions) bool { return opts.WebSockets != nil })
+	if err != nil {
+		return nil, err
+	}
+
+	return session.webSocketTraffic(pageID, filter), nil
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestWebSocketCapture tests recording a page's WebSocket frames with masking, cutting
+// and the frame limit, and filtering them
+func TestWebSocketCapture(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		mu.Lock()
+		methods = append(methods, method)
+		mu.Unlock()
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, &SessionOptions{WebSockets: &WebSocketCapture{
+		MaxFrames:       3,
+		MaxPayloadBytes: 16,
+		Masks:           []PayloadMask{{Pattern: `"token":"[^"]*"`, Replacement: `"token":"***"`}},
+	}}, "https://dashboards.example.com")
+	mu.Lock()
+	enabled, navigated := slices.Index(methods, "Network.enable"), slices.Index(methods, "Page.navigate")
+	if enabled < 0 || enabled > navigated || slices.Contains(methods, "Network.disable") {
+		t.Errorf("expected the Network domain enabled before the load and kept on, got %v", methods)
+	}
+	mu.Unlock()
+
+	event := func(method, params string) {
+		sess.dispatchNetworkEvent(pageID, &cdp.Event{Method: method, Params: []byte(params)})
+	}
+	event("Network.webSocketCreated", `{"requestId": "ws1", "url": "wss://dashboards.example.com/live"}`)
+	event("Network.webSocketFrameSent", `{"requestId": "ws1", "response": {"opcode": 1, "payloadData": "{\"token\":\"s3cr3t\"}"}}`)
+	event("Network.webSocketFrameReceived", `{"requestId": "ws1", "response": {"opcode": 1, "payloadData": "{\"price\":101.25,\"symbol\":\"ACME\"}"}}`)
+	binary := base64.StdEncoding.EncodeToString([]byte("0123456789abcdefghij"))
+	event("Network.webSocketFrameReceived", `{"requestId": "ws1", "response": {"opcode": 2, "payloadData": "`+binary+`"}}`)
+	event("Network.webSocketCreated", `{"requestId": "ws2", "url": "wss://chat.example.net/"}`)
+	event("Network.webSocketFrameReceived", `{"requestId": "ws2", "response": {"opcode": 1, "payloadData": "hi"}}`)
+	event("Network.webSocketClosed", `{"requestId": "ws2"}`)
+
+	traffic, err := manager.WebSocketTraffic(sess.ID, pageID, WebSocketFilter{})
+	if err != nil {
+		t.Fatalf("WebSocketTraffic failed: %v", err)
+	}
+	if len(traffic.Sockets) != 2 || traffic.Sockets[0].Frames != 3 || traffic.Sockets[1].ClosedAt == nil {
+		t.Errorf("unexpected sockets: %+v", traffic.Sockets)
+	}
+	// The masked frame was the oldest and made room for the last one
+	if len(traffic.Frames) != 3 || traffic.Dropped != 1 || traffic.Frames[2].Payload != "hi" {
+		t.Fatalf("expected the 3 newest frames, got %+v (dropped %d)", traffic.Frames, traffic.Dropped)
+	}
+	price, data := traffic.Frames[0], traffic.Frames[1]
+	if !price.Truncated || price.Payload != `{"price":101.25,` || price.Size != 32 || price.Direction != FrameReceived {
+		t.Errorf("expected the text frame cut to 16 bytes, got %+v", price)
+	}
+	if decoded, _ := base64.StdEncoding.DecodeString(data.Payload); string(decoded) != "0123456789abcdef" || data.Size != 20 || !data.Truncated {
+		t.Errorf("expected the binary frame cut on its bytes, got %+v", data)
+	}
+
+	// Masking happens before a frame is kept
+	masked, _, truncated, changed := sess.network[pageID].socketRules.text(`{"token":"s3cr3t"}`)
+	if masked != `{"token":"***"}` || truncated || !changed {
+		t.Errorf("expected the token masked, got %q", masked)
+	}
+
+	traffic, _ = manager.WebSocketTraffic(sess.ID, pageID, WebSocketFilter{TrafficFilter: TrafficFilter{URL: "dashboards", MinSize: 25}})
+	if traffic.Total != 1 || traffic.Frames[0].Opcode != 1 || len(traffic.Sockets) != 1 {
+		t.Errorf("expected the large dashboard frame, got %+v", traffic)
+	}
+	traffic, _ = manager.WebSocketTraffic(sess.ID, pageID, WebSocketFilter{TrafficFilter: TrafficFilter{Since: time.Now().Add(time.Minute)}})
+	if traffic.Total != 0 {
+		t.Errorf("expected no frames from the future, got %+v", traffic.Frames)
+	}
+	traffic, _ = manager.WebSocketTraffic(sess.ID, pageID, WebSocketFilter{TrafficFilter: TrafficFilter{Limit: 1}})
+	if traffic.Total != 3 || len(traffic.Frames) != 1 || traffic.Frames[0].Payload != "hi" {
+		t.Errorf("expected only the newest frame, got %+v", traffic)
+	}
+	for _, filter := range []WebSocketFilter{{Direction: "both"}, {TrafficFilter: TrafficFilter{MinSize: 10, MaxSize: 5}}, {TrafficFilter: TrafficFilter{MinSize: -1}}} {
+		if _, err := manager.WebSocketTraffic(sess.ID, pageID, filter); !errors.Is(err, ErrInvalidNetworkFilter) {
+			t.Errorf("expected ErrInvalidNetworkFilter for %+v, got %v", filter, err)
+		}
+	}
+
+	plain, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	plainPage, err := manager.Navigate(ctx, plain.ID, "https://example.com")
+	if err != nil {
+		t.Fatalf("Navigate failed: %v", err)
+	}
+	if _, err := manager.WebSocketTraffic(plain.ID, plainPage, WebSocketFilter{}); !errors.Is(err, ErrCaptureDisabled) {
+		t.Errorf("expected ErrCaptureDisabled, got %v", err)
+	}
+
+	for _, capture := range []*WebSocketCapture{{MaxFrames: MaxWebSocketFrames + 1}, {Masks: []PayloadMask{{Pattern: "("}}}} {
+		if err := (&SessionOptions{WebSockets: capture}).Validate(); err == nil || !strings.Contains(err.Error(), "websockets") {
+			t.Errorf("expected %+v rejected, got %v", capture, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Observer is a read-only handle onto an existing session.
+// Observers can view a session (screenshots, content, analysis) but the
+// API never exposes mutating operations through an observer ID.
+type Observer struct {
+	ID        string    // Unique observer identifier (obs_ prefix)
+	SessionID string    // Session being observed
+	Label     string    // Optional label such as "dashboard" or "supervisor"
+	CreatedAt time.Time // When the observer was created
+}
+
+// generateObserverID creates a unique observer identifier
+func generateObserverID() (string, error) {
+	// Generate 16 random bytes
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate observer ID: %w", err)
+	}
+
+	// Return the observer ID with prefix
+	return "obs_" + base64.URLEncoding.EncodeToString(randomBytes), nil
+}
+
+// CreateObserver attaches a new read-only observer to an active session
+func (m *Manager) CreateObserver(sessionID string, label string) (*Observer, error) {
+	// The observed session must be live in memory
+	if _, err := m.GetSession(sessionID); err != nil {
+		return nil, err
+	}
+
+	observerID, err := generateObserverID()
+	if err != nil {
+		return nil, err
+	}
+
+	observer := &Observer{
+		ID:        observerID,
+		SessionID: sessionID,
+		Label:     label,
+		CreatedAt: time.Now(),
+	}
+
+	m.mu.Lock()
+	m.observers[observerID] = observer
+	m.mu.Unlock()
+
+	slog.Info("observer attached",
+		"observer_id", observerID,
+		"session_id", sessionID,
+		"label", label)
+
+	return observer, nil
+}
+
+// ResolveObserver returns the session an observer is attached to
+func (m *Manager) ResolveObserver(observerID string) (*Session, error) {
+	m.mu.RLock()
+	observer, exists := m.observers[observerID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrObserverNotFound, observerID)
+	}
+
+	return m.GetSession(observer.SessionID)
+}
+
+// ListObservers returns all observers attached to a session
+func (m *Manager) ListObservers(sessionID string) []*Observer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	observers := make([]*Observer, 0)
+	for _, observer := range m.observers {
+		if observer.SessionID == sessionID {
+			observers = append(observers, observer)
+		}
+	}
+
+	return observers
+}
+
+// RevokeObserver detaches an observer from its session
+func (m *Manager) RevokeObserver(sessionID string, observerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	observer, exists := m.observers[observerID]
+	if !exists || observer.SessionID != sessionID {
+		return fmt.Errorf("%w: %s", ErrObserverNotFound, observerID)
+	}
+
+	delete(m.observers, observerID)
+
+	slog.Info("observer revoked",
+		"observer_id", observerID,
+		"session_id", sessionID)
+
+	return nil
+}
+
+// removeObserversLocked drops every observer of a session.
+// Caller must hold m.mu for writing.
+func (m *Manager) removeObserversLocked(sessionID string) {
+	for id, observer := range m.observers {
+		if observer.SessionID == sessionID {
+			delete(m.observers, id)
+		}
+	}
+}
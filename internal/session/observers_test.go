@@ -0,0 +1,63 @@
+package session
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestObserverLifecycle tests attaching, resolving and revoking observers
+func TestObserverLifecycle(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	// Register a session directly (no browser needed for observer bookkeeping)
+	sess := &Session{ID: "sess_test", Status: SessionActive, LastActivity: time.Now()}
+	manager.sessions[sess.ID] = sess
+
+	observer, err := manager.CreateObserver(sess.ID, "dashboard")
+	if err != nil {
+		t.Fatalf("CreateObserver failed: %v", err)
+	}
+
+	if !strings.HasPrefix(observer.ID, "obs_") {
+		t.Errorf("observer ID missing prefix: %s", observer.ID)
+	}
+
+	// Resolving the observer returns the observed session
+	resolved, err := manager.ResolveObserver(observer.ID)
+	if err != nil {
+		t.Fatalf("ResolveObserver failed: %v", err)
+	}
+	if resolved.ID != sess.ID {
+		t.Errorf("expected session %s, got %s", sess.ID, resolved.ID)
+	}
+
+	if got := len(manager.ListObservers(sess.ID)); got != 1 {
+		t.Errorf("expected 1 observer, got %d", got)
+	}
+
+	// Revoking from the wrong session must fail
+	if err := manager.RevokeObserver("sess_other", observer.ID); !errors.Is(err, ErrObserverNotFound) {
+		t.Errorf("expected ErrObserverNotFound, got %v", err)
+	}
+
+	if err := manager.RevokeObserver(sess.ID, observer.ID); err != nil {
+		t.Fatalf("RevokeObserver failed: %v", err)
+	}
+
+	if _, err := manager.ResolveObserver(observer.ID); !errors.Is(err, ErrObserverNotFound) {
+		t.Errorf("expected ErrObserverNotFound after revoke, got %v", err)
+	}
+}
+
+// TestCreateObserverUnknownSession tests that observers require a live session
+func TestCreateObserverUnknownSession(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	if _, err := manager.CreateObserver("nonexistent", ""); err == nil {
+		t.Error("expected error for non-existent session, got nil")
+	}
+}
@@ -1,32 +1,91 @@
 package session
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
-	"slices"
 	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/captcha"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
 )
 
+// NavigateOptions change how Navigate opens its page
+type NavigateOptions struct {
+	// BypassServiceWorker has the page's requests skip service workers for as long as it
+	// is open, so a stale worker can't answer with its cached copy of the site
+	BypassServiceWorker bool
+}
+
 // Navigate navigates to a URL and creates a new page in the session
-func (m *Manager) Navigate(sessionID string, url string) (string, error) {
+func (m *Manager) Navigate(ctx context.Context, sessionID string, url string) (string, error) {
+	return m.NavigateWithOptions(ctx, sessionID, url, NavigateOptions{})
+}
+
+// NavigateWithOptions is Navigate with opts applied to the new page
+func (m *Manager) NavigateWithOptions(ctx context.Context, sessionID string, url string, opts NavigateOptions) (string, error) {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return "", err
+	}
+
 	// Get the session from the manager
 	session, err := m.GetSession(sessionID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get session: %w", err)
 	}
 
+	// A tenant past its bandwidth for the day loads no more pages
+	if err := m.checkTenantBandwidth(session.TenantID); err != nil {
+		return "", err
+	}
+
 	// Create a new target/page in this session's context
-	pageID, err := session.CDPClient.CreateTarget(url, session.ContextID)
+	var pageID string
+	if opts == (NavigateOptions{}) {
+		pageID, err = session.Driver().OpenPage(ctx, url)
+	} else if session.bidi != nil {
+		return "", fmt.Errorf("%w: bypassing service workers", ErrEngineUnsupported)
+	} else {
+		pageID, err = session.openPageWith(ctx, url, opts)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to create target: %w", err)
+		return "", err
+	}
+
+	// The rest relies on CDP; a Firefox page is ready once its load event fired
+	if session.bidi != nil {
+		session.AddPage(pageID)
+		m.publishEvent(sessionID, pageID, events.TypePageOpened, map[string]interface{}{"url": url})
+		return pageID, nil
+	}
+
+	// A page showing Chrome's error page is no use to the agent, so report why instead
+	if err := navigationFailed(session.LastNavigation(pageID)); err != nil {
+		if closeErr := session.CDPClient.CloseTarget(ctx, pageID); closeErr != nil {
+			slog.WarnContext(ctx, "failed to close page after navigation failure", "page_id", pageID, "error", closeErr)
+		}
+		session.RemovePage(pageID)
+		return "", err
 	}
 
 	// Add the page ID to the session
 	session.AddPage(pageID)
+	m.trackRoutes(ctx, session, pageID)
 
 	// Best-effort wait for page readiness
-	if err := session.WaitForReady(pageID, 10*time.Second); err != nil {
-		slog.Warn("page did not reach ready state before timeout", "page_id", pageID, "error", err)
+	if err := session.WaitForReady(ctx, pageID, session.navigationTimeout()); err != nil {
+		slog.WarnContext(ctx, "page did not reach ready state before timeout", "page_id", pageID, "error", err)
+	}
+
+	m.publishEvent(sessionID, pageID, events.TypePageOpened, map[string]interface{}{"url": url})
+
+	// Flag pages that are blocked behind a CAPTCHA so agents can hand off or solve
+	if info, err := session.DetectCaptcha(ctx, pageID); err != nil {
+		slog.WarnContext(ctx, "captcha detection failed", "page_id", pageID, "error", err)
+	} else if info.Detected {
+		m.publishCaptchaBlocked(sessionID, pageID, info)
 	}
 
 	// Return the page ID
@@ -34,7 +93,7 @@ func (m *Manager) Navigate(sessionID string, url string) (string, error) {
 }
 
 // CaptureScreenshot captures a screenshot of a given page
-func (m *Manager) CaptureScreenshot(sessionID string, pageID string) ([]byte, error) {
+func (m *Manager) CaptureScreenshot(ctx context.Context, sessionID string, pageID string) ([]byte, error) {
 	// Get the session from the manager
 	session, err := m.GetSession(sessionID)
 	if err != nil {
@@ -42,12 +101,12 @@ func (m *Manager) CaptureScreenshot(sessionID string, pageID string) ([]byte, er
 	}
 
 	// Verify that the page ID is in the session
-	if !slices.Contains(session.PageIDs, pageID) {
-		return nil, fmt.Errorf("page not found in session: %s", pageID)
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
 	}
 
 	// Capture screenshot of the page
-	screenshot, err := session.CaptureScreenshot(pageID)
+	screenshot, err := session.Driver().Screenshot(ctx, pageID, m.maxResponseSize())
 	if err != nil {
 		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
 	}
@@ -60,7 +119,12 @@ func (m *Manager) CaptureScreenshot(sessionID string, pageID string) ([]byte, er
 }
 
 // ExecuteJavascript executes JavaScript code on a page
-func (m *Manager) ExecuteJavascript(sessionID string, pageID string, code string) (interface{}, error) {
+func (m *Manager) ExecuteJavascript(ctx context.Context, sessionID string, pageID string, code string) (interface{}, error) {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
 	// Get the session from the manager
 	session, err := m.GetSession(sessionID)
 	if err != nil {
@@ -68,12 +132,12 @@ func (m *Manager) ExecuteJavascript(sessionID string, pageID string, code string
 	}
 
 	// Verify that the page ID is in the session
-	if !slices.Contains(session.PageIDs, pageID) {
-		return nil, fmt.Errorf("page not found in session: %s", pageID)
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
 	}
 
 	// Execute the JavaScript code on the page
-	result, err := session.ExecuteJavascript(pageID, code)
+	result, err := session.Driver().Evaluate(ctx, pageID, code)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute javascript: %w", err)
 	}
@@ -85,8 +149,105 @@ func (m *Manager) ExecuteJavascript(sessionID string, pageID string, code string
 	return result, nil
 }
 
+// RunScript runs code on a page, or with args calls it as a function declaration with
+// args, each a JSON value. Like ExecuteJavascript it awaits a returned promise, and a
+// thrown exception comes back as a *ScriptError. opts choose the result's serialization,
+// and a result larger than the response cap is refused.
+func (m *Manager) RunScript(ctx context.Context, sessionID string, pageID string, code string, args []json.RawMessage, opts ScriptOptions) (interface{}, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	var result interface{}
+	switch {
+	case opts.deep() && session.bidi != nil:
+		return nil, fmt.Errorf("%w: deep serialization", ErrEngineUnsupported)
+	case opts.deep():
+		result, err = session.RunScript(ctx, pageID, code, args, opts)
+	case args != nil:
+		result, err = session.Driver().Call(ctx, pageID, code, args)
+	default:
+		result, err = session.Driver().Evaluate(ctx, pageID, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute javascript: %w", err)
+	}
+	if err := checkResultSize(result, m.maxResponseSize()); err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return result, nil
+}
+
+// ExecuteJavascriptVerified executes JavaScript and reports whether the page changed as a result.
+// args and opts are as for RunScript.
+func (m *Manager) ExecuteJavascriptVerified(ctx context.Context, sessionID string, pageID string, code string, args []json.RawMessage, opts ScriptOptions, withScreenshot bool) (interface{}, *PageDelta, error) {
+	if err := opts.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, nil, err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	// Snapshots are taken over CDP
+	if session.bidi != nil {
+		return nil, nil, fmt.Errorf("%w: verified execution", ErrEngineUnsupported)
+	}
+
+	before, _ := session.SnapshotPage(ctx, pageID, withScreenshot)
+
+	// Execute the JavaScript code on the page
+	result, err := session.RunScript(ctx, pageID, code, args, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute javascript: %w", err)
+	}
+	if err := checkResultSize(result, m.maxResponseSize()); err != nil {
+		return nil, nil, err
+	}
+
+	after, _ := session.SnapshotPage(ctx, pageID, withScreenshot)
+	delta := ComparePageSnapshots(before, after)
+
+	// A script that changed the page invalidates the cached analysis
+	if delta != nil && delta.Changed {
+		session.InvalidatePageAnalysis(pageID)
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return result, delta, nil
+}
+
 // GetPageContent gets the HTML content of a page
-func (m *Manager) GetPageContent(sessionID string, pageID string) (string, error) {
+func (m *Manager) GetPageContent(ctx context.Context, sessionID string, pageID string) (string, error) {
 	// Get the session from the manager
 	session, err := m.GetSession(sessionID)
 	if err != nil {
@@ -94,12 +255,12 @@ func (m *Manager) GetPageContent(sessionID string, pageID string) (string, error
 	}
 
 	// Verify that the page ID is in the session
-	if !slices.Contains(session.PageIDs, pageID) {
-		return "", fmt.Errorf("page not found in session: %s", pageID)
+	if !session.HasPage(pageID) {
+		return "", fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
 	}
 
 	// Get the HTML content of the page
-	content, err := session.GetPageContent(pageID)
+	content, err := session.Driver().Content(ctx, pageID, m.maxResponseSize())
 	if err != nil {
 		return "", fmt.Errorf("failed to get page content: %w", err)
 	}
@@ -107,12 +268,19 @@ func (m *Manager) GetPageContent(sessionID string, pageID string) (string, error
 	// Update the last activity time of the session
 	session.UpdateActivity()
 
+	// Follow-up vision queries about the page can draw on what was read
+	extract := MemoryExtract{PageID: pageID, Text: extractText(content), At: time.Now()}
+	if navigation := session.LastNavigation(pageID); navigation != nil {
+		extract.URL = navigation.URL
+	}
+	session.rememberExtract(extract, m.MemoryLimits())
+
 	// Return the content
 	return content, nil
 }
 
 // AnalyzePage extracts the structural overview of a page
-func (m *Manager) AnalyzePage(sessionID string, pageID string) (*PageStructure, error) {
+func (m *Manager) AnalyzePage(ctx context.Context, sessionID string, pageID string) (*PageStructure, error) {
 	// Get the session from the manager
 	session, err := m.GetSession(sessionID)
 	if err != nil {
@@ -120,12 +288,12 @@ func (m *Manager) AnalyzePage(sessionID string, pageID string) (*PageStructure,
 	}
 
 	// Verify that the page ID is in the session
-	if !slices.Contains(session.PageIDs, pageID) {
-		return nil, fmt.Errorf("page not found in session: %s", pageID)
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
 	}
 
 	// Analyze the page structure
-	structure, err := session.AnalyzePage(pageID)
+	structure, err := session.AnalyzePage(ctx, pageID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze page: %w", err)
 	}
@@ -152,7 +320,7 @@ func (m *Manager) InvalidatePageAnalysis(sessionID string, pageID string) error
 }
 
 // GetAccessibilityTree retrieves the accessibility tree for a page
-func (m *Manager) GetAccessibilityTree(sessionID string, pageID string) (*AccessibilityTree, error) {
+func (m *Manager) GetAccessibilityTree(ctx context.Context, sessionID string, pageID string) (*AccessibilityTree, error) {
 	// Get the session from the manager
 	session, err := m.GetSession(sessionID)
 	if err != nil {
@@ -160,12 +328,12 @@ func (m *Manager) GetAccessibilityTree(sessionID string, pageID string) (*Access
 	}
 
 	// Verify that the page ID is in the session
-	if !slices.Contains(session.PageIDs, pageID) {
-		return nil, fmt.Errorf("page not found in session: %s", pageID)
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
 	}
 
 	// Get the accessibility tree
-	tree, err := session.GetAccessibilityTree(pageID)
+	tree, err := session.GetAccessibilityTree(ctx, pageID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get accessibility tree: %w", err)
 	}
@@ -178,7 +346,12 @@ func (m *Manager) GetAccessibilityTree(sessionID string, pageID string) (*Access
 }
 
 // ClosePage closes a specific page in the session
-func (m *Manager) ClosePage(sessionID string, pageID string) error {
+func (m *Manager) ClosePage(ctx context.Context, sessionID string, pageID string) error {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return err
+	}
+
 	// Get the session from the manager
 	session, err := m.GetSession(sessionID)
 	if err != nil {
@@ -186,20 +359,511 @@ func (m *Manager) ClosePage(sessionID string, pageID string) error {
 	}
 
 	// Verify that the page ID is in the session
-	if !slices.Contains(session.PageIDs, pageID) {
-		return fmt.Errorf("page not found in session: %s", pageID)
+	if !session.HasPage(pageID) {
+		return fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
 	}
 
-	// Close the page via CDP
-	if err := session.CDPClient.CloseTarget(pageID); err != nil {
+	// Close the page in the browser
+	if err := session.Driver().ClosePage(ctx, pageID); err != nil {
 		return fmt.Errorf("failed to close page: %w", err)
 	}
 
-	// Remove the page from the session tracking
-	session.RemovePage(pageID)
+	// Remove the page from the session tracking (unless the targetDestroyed event already did)
+	if session.HasPage(pageID) {
+		session.RemovePage(pageID)
+		session.stopScreencast(pageID)
+		session.stopWatches(pageID)
+		session.stopRoutes(pageID)
+		session.stopNetworkCapture(pageID)
+		session.forgetHandles(pageID)
+		m.publishEvent(sessionID, pageID, events.TypePageClosed, nil)
+	}
 
 	// Note: We DO update activity via RemovePage (it calls UpdateActivity)
 	// Note: We do NOT dispose context - other pages might still be open
 
 	return nil
 }
+
+// DiscoverForms enumerates the forms on a page
+func (m *Manager) DiscoverForms(ctx context.Context, sessionID string, pageID string) ([]FormInfo, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	// Discover the forms on the page
+	forms, err := session.DiscoverForms(ctx, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover forms: %w", err)
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return forms, nil
+}
+
+// FillForm fills a form on a page and optionally submits it
+func (m *Manager) FillForm(ctx context.Context, sessionID string, pageID string, formIndex int, values map[string]interface{}, submit bool, timeout time.Duration) (*FormFillResult, error) {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	// Snapshot before acting so the result can say whether anything changed
+	before, _ := session.SnapshotPage(ctx, pageID, false)
+
+	// Fill (and maybe submit) the form
+	result, err := session.FillForm(ctx, pageID, formIndex, values, submit, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	after, _ := session.SnapshotPage(ctx, pageID, false)
+	result.Change = ComparePageSnapshots(before, after)
+
+	// The page content changed, so any cached analysis is stale
+	session.InvalidatePageAnalysis(pageID)
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return result, nil
+}
+
+// Login runs a login flow on a page using the given credentials
+func (m *Manager) Login(ctx context.Context, sessionID string, pageID string, username string, password string, opts LoginOptions) (*LoginResult, error) {
+	if err := opts.SuccessEngine.validate(); err != nil {
+		return nil, err
+	}
+
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	before, _ := session.SnapshotPage(ctx, pageID, false)
+
+	// Run the login flow
+	result, err := session.Login(ctx, pageID, username, password, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	after, _ := session.SnapshotPage(ctx, pageID, false)
+	result.Change = ComparePageSnapshots(before, after)
+
+	// The page content changed, so any cached analysis is stale
+	session.InvalidatePageAnalysis(pageID)
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	slog.InfoContext(ctx, "login flow completed",
+		"session_id", sessionID,
+		"page_id", pageID,
+		"success", result.Success,
+		"reason", result.Reason)
+
+	return result, nil
+}
+
+// DetectCaptcha checks a page for a blocking CAPTCHA and emits captcha_blocked when one is found
+func (m *Manager) DetectCaptcha(ctx context.Context, sessionID string, pageID string) (*CaptchaInfo, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	info, err := session.DetectCaptcha(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Detected {
+		m.publishCaptchaBlocked(sessionID, pageID, info)
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return info, nil
+}
+
+// SolveCaptcha solves the page's CAPTCHA with an external solver and injects the token
+func (m *Manager) SolveCaptcha(ctx context.Context, sessionID string, pageID string, solver captcha.Solver) (*CaptchaSolveResult, error) {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	// Re-detect so the solver gets the current site key and URL
+	info, err := session.DetectCaptcha(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Detected {
+		return nil, ErrNoCaptcha
+	}
+
+	startTime := time.Now()
+
+	token, err := solver.Solve(ctx, captcha.Challenge{
+		Provider: info.Provider,
+		SiteKey:  info.SiteKey,
+		PageURL:  info.URL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve captcha: %w", err)
+	}
+
+	injection, err := session.InjectCaptchaToken(ctx, pageID, info.Provider, token)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CaptchaSolveResult{
+		Solved:    injection.Fields > 0 || injection.Callback,
+		Method:    solver.Name(),
+		Provider:  info.Provider,
+		Injection: injection,
+		URL:       info.URL,
+		Duration:  time.Since(startTime).String(),
+	}
+
+	if result.Solved {
+		m.publishEvent(sessionID, pageID, events.TypeCaptchaSolved, result)
+	}
+
+	// The page content changed, so any cached analysis is stale
+	session.InvalidatePageAnalysis(pageID)
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	slog.InfoContext(ctx, "captcha solve attempted",
+		"session_id", sessionID,
+		"page_id", pageID,
+		"provider", info.Provider,
+		"solver", solver.Name(),
+		"solved", result.Solved)
+
+	return result, nil
+}
+
+// AwaitManualCaptcha requests a human takeover and waits for the CAPTCHA to disappear.
+// The request is announced as a captcha_manual_requested event; the human works the
+// page through the live viewport while this call polls detection.
+func (m *Manager) AwaitManualCaptcha(ctx context.Context, sessionID string, pageID string, timeout time.Duration) (*CaptchaSolveResult, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	info, err := session.DetectCaptcha(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Detected {
+		return nil, ErrNoCaptcha
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultManualCaptchaTimeout
+	}
+
+	m.publishEvent(sessionID, pageID, events.TypeCaptchaManual, map[string]interface{}{
+		"provider": info.Provider,
+		"url":      info.URL,
+		"timeout":  timeout.String(),
+	})
+
+	result := &CaptchaSolveResult{
+		Method:   CaptchaMethodManual,
+		Provider: info.Provider,
+		URL:      info.URL,
+	}
+
+	startTime := time.Now()
+	ticker := time.NewTicker(manualCaptchaPollInterval)
+	defer ticker.Stop()
+
+	deadline := time.After(timeout)
+
+	// Poll until the challenge is gone, the caller gives up, or time runs out
+	for !result.Solved {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("manual captcha wait cancelled: %w", ctx.Err())
+		case <-deadline:
+			result.Duration = time.Since(startTime).String()
+			return result, nil
+		case <-ticker.C:
+			current, err := session.DetectCaptcha(ctx, pageID)
+			if err != nil {
+				// The page may be mid-navigation after the human submits
+				continue
+			}
+			result.Solved = !current.Detected
+			result.URL = current.URL
+		}
+	}
+
+	result.Duration = time.Since(startTime).String()
+	m.publishEvent(sessionID, pageID, events.TypeCaptchaSolved, result)
+
+	// The page content changed, so any cached analysis is stale
+	session.InvalidatePageAnalysis(pageID)
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return result, nil
+}
+
+// WatchScreencast streams live frames of a page until the returned stop function is called
+func (m *Manager) WatchScreencast(ctx context.Context, sessionID string, pageID string, opts ScreencastOptions) (<-chan ScreencastFrame, func(), error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	frames, stop, err := session.WatchScreencast(ctx, pageID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return frames, stop, nil
+}
+
+// ListPages returns the live pages of a session and reconciles PageIDs with them.
+// Pages opened by the site (popups, target=_blank) are adopted into the session and
+// pages the site closed itself are dropped, so nothing leaks on destroy.
+func (m *Manager) ListPages(ctx context.Context, sessionID string) ([]TabInfo, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	tabs, err := session.ListTabs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Adopt live pages the session was not tracking
+	live := make(map[string]bool, len(tabs))
+	for i := range tabs {
+		live[tabs[i].PageID] = true
+		if navigation := session.LastNavigation(tabs[i].PageID); navigation != nil {
+			tabs[i].Security = navigation.Security
+		}
+		if !session.HasPage(tabs[i].PageID) {
+			session.AddPage(tabs[i].PageID)
+			m.trackRoutes(ctx, session, tabs[i].PageID)
+			tabs[i].Adopted = true
+			m.publishEvent(sessionID, tabs[i].PageID, events.TypePageOpened, map[string]interface{}{
+				"url":       tabs[i].URL,
+				"opener_id": tabs[i].OpenerID,
+				"adopted":   true,
+			})
+		}
+	}
+
+	// Forget tracked pages that no longer exist
+	for _, pageID := range session.Pages() {
+		if !live[pageID] {
+			session.RemovePage(pageID)
+			session.stopScreencast(pageID)
+			session.stopWatches(pageID)
+			session.stopRoutes(pageID)
+			session.stopNetworkCapture(pageID)
+			session.forgetHandles(pageID)
+			m.publishEvent(sessionID, pageID, events.TypePageClosed, nil)
+		}
+	}
+
+	return tabs, nil
+}
+
+// ActivatePage brings a page to the front of its window
+func (m *Manager) ActivatePage(ctx context.Context, sessionID string, pageID string) error {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	if err := session.BringToFront(ctx, pageID); err != nil {
+		return err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return nil
+}
+
+// DuplicatePage opens the current URL of a page in a new page of the same session
+func (m *Manager) DuplicatePage(ctx context.Context, sessionID string, pageID string) (string, string, error) {
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return "", "", err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return "", "", fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	url, err := session.GetCurrentURL(ctx, pageID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Open the copy in the same browser context so it shares cookies and storage
+	newPageID, err := session.openPage(ctx, url)
+	if err != nil {
+		return "", "", err
+	}
+
+	session.AddPage(newPageID)
+	m.trackRoutes(ctx, session, newPageID)
+
+	// Best-effort wait for page readiness
+	if err := session.WaitForReady(ctx, newPageID, session.navigationTimeout()); err != nil {
+		slog.WarnContext(ctx, "page did not reach ready state before timeout", "page_id", newPageID, "error", err)
+	}
+
+	m.publishEvent(sessionID, newPageID, events.TypePageOpened, map[string]interface{}{
+		"url":             url,
+		"duplicated_from": pageID,
+	})
+
+	return newPageID, url, nil
+}
+
+// ListResources returns the subresources a page has loaded
+func (m *Manager) ListResources(ctx context.Context, sessionID string, pageID string) ([]ResourceInfo, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	resources, err := session.ListResources(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return resources, nil
+}
+
+// DownloadResource returns the raw bytes of a page subresource
+func (m *Manager) DownloadResource(ctx context.Context, sessionID string, pageID string, url string, source string, maxBytes int) (*Resource, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	resource, err := session.DownloadResource(ctx, pageID, url, source, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return resource, nil
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -53,13 +54,13 @@ func TestNavigate(t *testing.T) {
 	defer cleanup()
 
 	// Create session
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
 	// Navigate to a URL
-	pageID, err := manager.Navigate(session.ID, "https://example.com")
+	pageID, err := manager.Navigate(context.Background(), session.ID, "https://example.com")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
@@ -86,7 +87,7 @@ func TestNavigateMultiplePages(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
@@ -100,7 +101,7 @@ func TestNavigateMultiplePages(t *testing.T) {
 
 	pageIDs := make([]string, len(urls))
 	for i, url := range urls {
-		pageID, err := manager.Navigate(session.ID, url)
+		pageID, err := manager.Navigate(context.Background(), session.ID, url)
 		if err != nil {
 			t.Fatalf("Navigate to %s failed: %v", url, err)
 		}
@@ -129,7 +130,7 @@ func TestNavigateInvalidSession(t *testing.T) {
 	defer cleanup()
 
 	// Try to navigate with non-existent session
-	_, err := manager.Navigate("invalid-session-id", "https://example.com")
+	_, err := manager.Navigate(context.Background(), "invalid-session-id", "https://example.com")
 	if err == nil {
 		t.Error("expected error for invalid session, got nil")
 	}
@@ -144,13 +145,13 @@ func TestCaptureScreenshot(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
 	// Navigate to a page
-	pageID, err := manager.Navigate(session.ID, "https://example.com")
+	pageID, err := manager.Navigate(context.Background(), session.ID, "https://example.com")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
@@ -159,7 +160,7 @@ func TestCaptureScreenshot(t *testing.T) {
 	time.Sleep(2 * time.Second)
 
 	// Capture screenshot
-	screenshot, err := manager.CaptureScreenshot(session.ID, pageID)
+	screenshot, err := manager.CaptureScreenshot(context.Background(), session.ID, pageID)
 	if err != nil {
 		t.Fatalf("CaptureScreenshot failed: %v", err)
 	}
@@ -190,13 +191,13 @@ func TestCaptureScreenshotInvalidPage(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
 	// Try to screenshot non-existent page
-	_, err = manager.CaptureScreenshot(session.ID, "invalid-page-id")
+	_, err = manager.CaptureScreenshot(context.Background(), session.ID, "invalid-page-id")
 	if err == nil {
 		t.Error("expected error for invalid page, got nil")
 	}
@@ -211,12 +212,12 @@ func TestExecuteJavascript(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
-	pageID, err := manager.Navigate(session.ID, "https://example.com")
+	pageID, err := manager.Navigate(context.Background(), session.ID, "https://example.com")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
@@ -225,7 +226,7 @@ func TestExecuteJavascript(t *testing.T) {
 	time.Sleep(2 * time.Second)
 
 	// Test 1: Get page title
-	result, err := manager.ExecuteJavascript(session.ID, pageID, "document.title")
+	result, err := manager.ExecuteJavascript(context.Background(), session.ID, pageID, "document.title")
 	if err != nil {
 		t.Fatalf("ExecuteJavascript failed: %v", err)
 	}
@@ -242,7 +243,7 @@ func TestExecuteJavascript(t *testing.T) {
 	t.Logf("page title: %s", title)
 
 	// Test 2: Simple arithmetic
-	result, err = manager.ExecuteJavascript(session.ID, pageID, "2 + 2")
+	result, err = manager.ExecuteJavascript(context.Background(), session.ID, pageID, "2 + 2")
 	if err != nil {
 		t.Fatalf("ExecuteJavascript failed: %v", err)
 	}
@@ -258,7 +259,7 @@ func TestExecuteJavascript(t *testing.T) {
 	}
 
 	// Test 3: Return object
-	result, err = manager.ExecuteJavascript(session.ID, pageID, "({name: 'test', value: 42})")
+	result, err = manager.ExecuteJavascript(context.Background(), session.ID, pageID, "({name: 'test', value: 42})")
 	if err != nil {
 		t.Fatalf("ExecuteJavascript failed: %v", err)
 	}
@@ -280,13 +281,13 @@ func TestExecuteJavascriptInvalidPage(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
 	// Try to execute on non-existent page
-	_, err = manager.ExecuteJavascript(session.ID, "invalid-page-id", "2 + 2")
+	_, err = manager.ExecuteJavascript(context.Background(), session.ID, "invalid-page-id", "2 + 2")
 	if err == nil {
 		t.Error("expected error for invalid page, got nil")
 	}
@@ -301,12 +302,12 @@ func TestGetPageContent(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
-	pageID, err := manager.Navigate(session.ID, "https://example.com")
+	pageID, err := manager.Navigate(context.Background(), session.ID, "https://example.com")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
@@ -315,7 +316,7 @@ func TestGetPageContent(t *testing.T) {
 	time.Sleep(2 * time.Second)
 
 	// Get page content
-	content, err := manager.GetPageContent(session.ID, pageID)
+	content, err := manager.GetPageContent(context.Background(), session.ID, pageID)
 	if err != nil {
 		t.Fatalf("GetPageContent failed: %v", err)
 	}
@@ -342,13 +343,13 @@ func TestGetPageContentInvalidPage(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
 	// Try to get content from non-existent page
-	_, err = manager.GetPageContent(session.ID, "invalid-page-id")
+	_, err = manager.GetPageContent(context.Background(), session.ID, "invalid-page-id")
 	if err == nil {
 		t.Error("expected error for invalid page, got nil")
 	}
@@ -363,18 +364,18 @@ func TestClosePage(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
 	// Open two pages
-	pageID1, err := manager.Navigate(session.ID, "https://example.com")
+	pageID1, err := manager.Navigate(context.Background(), session.ID, "https://example.com")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
 
-	pageID2, err := manager.Navigate(session.ID, "https://example.org")
+	pageID2, err := manager.Navigate(context.Background(), session.ID, "https://example.org")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
@@ -385,7 +386,7 @@ func TestClosePage(t *testing.T) {
 	}
 
 	// Close first page
-	if err := manager.ClosePage(session.ID, pageID1); err != nil {
+	if err := manager.ClosePage(context.Background(), session.ID, pageID1); err != nil {
 		t.Fatalf("ClosePage failed: %v", err)
 	}
 
@@ -407,13 +408,13 @@ func TestClosePageInvalidPage(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
 
 	// Try to close non-existent page
-	err = manager.ClosePage(session.ID, "invalid-page-id")
+	err = manager.ClosePage(context.Background(), session.ID, "invalid-page-id")
 	if err == nil {
 		t.Error("expected error for invalid page, got nil")
 	}
@@ -429,7 +430,7 @@ func TestCompleteWorkflow(t *testing.T) {
 	defer cleanup()
 
 	// Create session
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
@@ -437,7 +438,7 @@ func TestCompleteWorkflow(t *testing.T) {
 	t.Logf("created session: %s", session.ID)
 
 	// Navigate to page
-	pageID, err := manager.Navigate(session.ID, "https://example.com")
+	pageID, err := manager.Navigate(context.Background(), session.ID, "https://example.com")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
@@ -448,7 +449,7 @@ func TestCompleteWorkflow(t *testing.T) {
 	time.Sleep(2 * time.Second)
 
 	// Get title via JavaScript
-	title, err := manager.ExecuteJavascript(session.ID, pageID, "document.title")
+	title, err := manager.ExecuteJavascript(context.Background(), session.ID, pageID, "document.title")
 	if err != nil {
 		t.Fatalf("ExecuteJavascript failed: %v", err)
 	}
@@ -456,7 +457,7 @@ func TestCompleteWorkflow(t *testing.T) {
 	t.Logf("page title: %v", title)
 
 	// Get page content
-	content, err := manager.GetPageContent(session.ID, pageID)
+	content, err := manager.GetPageContent(context.Background(), session.ID, pageID)
 	if err != nil {
 		t.Fatalf("GetPageContent failed: %v", err)
 	}
@@ -464,7 +465,7 @@ func TestCompleteWorkflow(t *testing.T) {
 	t.Logf("page content: %d bytes", len(content))
 
 	// Take screenshot
-	screenshot, err := manager.CaptureScreenshot(session.ID, pageID)
+	screenshot, err := manager.CaptureScreenshot(context.Background(), session.ID, pageID)
 	if err != nil {
 		t.Fatalf("CaptureScreenshot failed: %v", err)
 	}
@@ -475,7 +476,7 @@ func TestCompleteWorkflow(t *testing.T) {
 	os.WriteFile("test_complete_workflow.png", screenshot, 0644)
 
 	// Close page
-	if err := manager.ClosePage(session.ID, pageID); err != nil {
+	if err := manager.ClosePage(context.Background(), session.ID, pageID); err != nil {
 		t.Fatalf("ClosePage failed: %v", err)
 	}
 
@@ -497,7 +498,7 @@ func TestActivityTracking(t *testing.T) {
 	proc, manager, cleanup := setupTestManager(t)
 	defer cleanup()
 
-	session, err := manager.CreateSession(proc.DebugPort)
+	session, err := manager.CreateSession(context.Background(), proc.DebugPort)
 	if err != nil {
 		t.Fatalf("CreateSession failed: %v", err)
 	}
@@ -508,7 +509,7 @@ func TestActivityTracking(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// Navigate (should update activity via AddPage)
-	pageID, err := manager.Navigate(session.ID, "https://example.com")
+	pageID, err := manager.Navigate(context.Background(), session.ID, "https://example.com")
 	if err != nil {
 		t.Fatalf("Navigate failed: %v", err)
 	}
@@ -522,7 +523,7 @@ func TestActivityTracking(t *testing.T) {
 
 	// Screenshot (should update activity)
 	time.Sleep(2 * time.Second) // Let page load
-	_, err = manager.CaptureScreenshot(session.ID, pageID)
+	_, err = manager.CaptureScreenshot(context.Background(), session.ID, pageID)
 	if err != nil {
 		t.Fatalf("CaptureScreenshot failed: %v", err)
 	}
@@ -535,7 +536,7 @@ func TestActivityTracking(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// ExecuteJS (should update activity)
-	_, err = manager.ExecuteJavascript(session.ID, pageID, "2 + 2")
+	_, err = manager.ExecuteJavascript(context.Background(), session.ID, pageID, "2 + 2")
 	if err != nil {
 		t.Fatalf("ExecuteJavascript failed: %v", err)
 	}
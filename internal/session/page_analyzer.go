@@ -1,8 +1,10 @@
 package session
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // PageStructure represents the analyzed structure of a web page
@@ -10,6 +12,7 @@ type PageStructure struct {
 	PageID    string          `json:"page_id"`
 	URL       string          `json:"url"`
 	Title     string          `json:"title"`
+	Language  string          `json:"language,omitempty"` // Declared page language (BCP 47), if any
 	Structure StructureDetail `json:"structure"`
 }
 
@@ -45,6 +48,7 @@ const pageAnalyzerJS = `(function() {
   var result = {
     url: location.href,
     title: document.title,
+    language: '',
     structure: {
       classes: [],
       ids: [],
@@ -56,6 +60,14 @@ const pageAnalyzerJS = `(function() {
     }
   };
 
+  // Detect the declared page language (html lang, then Content-Language meta)
+  var lang = document.documentElement.getAttribute('lang') || '';
+  if (!lang) {
+    var langMeta = document.querySelector('meta[http-equiv="content-language" i]');
+    if (langMeta) lang = langMeta.getAttribute('content') || '';
+  }
+  result.language = lang.trim().split(',')[0].trim().toLowerCase();
+
   // Extract unique CSS classes
   var classSet = {};
   document.querySelectorAll('[class]').forEach(function(el) {
@@ -171,18 +183,32 @@ const pageAnalyzerJS = `(function() {
   return result;
 })();`
 
+// LanguagesDiffer reports whether instructions in one language name elements of a page
+// in another, so their labels need translating before they are matched. Only primary
+// subtags count ("de" and "de-AT" agree), and an unknown language on either side never
+// differs.
+func LanguagesDiffer(instruction string, page string) bool {
+	primary := func(tag string) string {
+		tag, _, _ = strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+		return strings.ToLower(strings.TrimSpace(tag))
+	}
+	instruction, page = primary(instruction), primary(page)
+	return instruction != "" && page != "" && instruction != page
+}
+
 // AnalyzePage extracts the structural overview of a page.
 // Results are cached per pageID — call InvalidatePageAnalysis to clear.
-func (s *Session) AnalyzePage(targetID string) (*PageStructure, error) {
+func (s *Session) AnalyzePage(ctx context.Context, targetID string) (*PageStructure, error) {
 	// Check cache first
-	if s.pageAnalysisCache != nil {
-		if cached, ok := s.pageAnalysisCache[targetID]; ok {
-			return cached, nil
-		}
+	s.mu.RLock()
+	cached, ok := s.pageAnalysisCache[targetID]
+	s.mu.RUnlock()
+	if ok {
+		return cached, nil
 	}
 
 	// Execute the analyzer JavaScript
-	result, err := s.ExecuteJavascript(targetID, pageAnalyzerJS)
+	result, err := s.ExecuteJavascript(ctx, targetID, pageAnalyzerJS)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze page: %w", err)
 	}
@@ -203,22 +229,26 @@ func (s *Session) AnalyzePage(targetID string) (*PageStructure, error) {
 	structure.PageID = targetID
 
 	// Cache the result
+	s.mu.Lock()
 	if s.pageAnalysisCache == nil {
 		s.pageAnalysisCache = make(map[string]*PageStructure)
 	}
 	s.pageAnalysisCache[targetID] = &structure
+	s.mu.Unlock()
 
 	return &structure, nil
 }
 
 // InvalidatePageAnalysis clears the cached analysis for a specific page
 func (s *Session) InvalidatePageAnalysis(pageID string) {
-	if s.pageAnalysisCache != nil {
-		delete(s.pageAnalysisCache, pageID)
-	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pageAnalysisCache, pageID)
 }
 
 // InvalidateAllPageAnalysis clears all cached page analyses
 func (s *Session) InvalidateAllPageAnalysis() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.pageAnalysisCache = make(map[string]*PageStructure)
 }
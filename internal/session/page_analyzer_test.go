@@ -0,0 +1,67 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLanguagesDiffer(t *testing.T) {
+	cases := []struct {
+		instruction string
+		page        string
+		want        bool
+	}{
+		{"en", "de", true},
+		{"pt-BR", "es", true},
+		{"de", "de-AT", false},
+		{"EN", "en_us", false},
+		{"", "de", false},
+		{"en", "", false},
+	}
+	for _, c := range cases {
+		if got := LanguagesDiffer(c.instruction, c.page); got != c.want {
+			t.Errorf("LanguagesDiffer(%q, %q) = %v, want %v", c.instruction, c.page, got, c.want)
+		}
+	}
+}
+
+// TestAnalyzePageLanguage tests that the language the analyzer script finds reaches the
+// page structure, and that the analysis is then served from the cache
+func TestAnalyzePageLanguage(t *testing.T) {
+	analyses := 0
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+		if method != "Runtime.evaluate" || !strings.Contains(p.Expression, "content-language") {
+			return nil
+		}
+		analyses++
+		return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{
+			"url":       "https://example.de/",
+			"title":     "Willkommen",
+			"language":  "de-at",
+			"structure": map[string]interface{}{"classes": []string{}, "ids": []string{}},
+		}}}
+	})
+	sess, pageID := openTestPage(t, manager, nil, "https://example.de")
+
+	for range 2 {
+		structure, err := sess.AnalyzePage(context.Background(), pageID)
+		if err != nil {
+			t.Fatalf("AnalyzePage failed: %v", err)
+		}
+		if structure.Language != "de-at" || structure.PageID != pageID {
+			t.Errorf("unexpected analysis: %+v", structure)
+		}
+		if !LanguagesDiffer("en", structure.Language) || LanguagesDiffer("de", structure.Language) {
+			t.Errorf("expected English instructions to need translating for %q, German ones not", structure.Language)
+		}
+	}
+	if analyses != 1 {
+		t.Errorf("expected the page analyzed once, got %d", analyses)
+	}
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// PageSnapshot is a lightweight fingerprint of a page's state at a point in time
+type PageSnapshot struct {
+	URL            string `json:"url"`
+	Title          string `json:"title"`
+	DOMHash        string `json:"dom_hash"`
+	TextHash       string `json:"text_hash"`
+	NodeCount      int    `json:"node_count"`
+	ScreenshotHash string `json:"screenshot_hash,omitempty"`
+}
+
+// PageDelta is the verdict of comparing the page before and after an action
+type PageDelta struct {
+	Changed           bool  `json:"changed"`
+	URLChanged        bool  `json:"url_changed"`
+	TitleChanged      bool  `json:"title_changed"`
+	DOMChanged        bool  `json:"dom_changed"`
+	TextChanged       bool  `json:"text_changed"`
+	NodeCountDelta    int   `json:"node_count_delta"`
+	ScreenshotChanged *bool `json:"screenshot_changed,omitempty"` // Only set when both snapshots include a screenshot hash
+}
+
+// pageSnapshotJS hashes the DOM and visible text in-page (FNV-1a) so only a few bytes cross CDP
+const pageSnapshotJS = `(function() {
+  function fnv(str) {
+    var h = 0x811c9dc5;
+    for (var i = 0; i < str.length; i++) {
+      h ^= str.charCodeAt(i);
+      h = Math.imul(h, 0x01000193);
+    }
+    return (h >>> 0).toString(16);
+  }
+  var root = document.documentElement;
+  return {
+    url: location.href,
+    title: document.title,
+    dom_hash: fnv(root ? root.outerHTML : ''),
+    text_hash: fnv(document.body ? document.body.innerText : ''),
+    node_count: document.getElementsByTagName('*').length
+  };
+})()`
+
+// SnapshotPage captures a PageSnapshot, optionally hashing a screenshot as well
+func (s *Session) SnapshotPage(ctx context.Context, targetID string, withScreenshot bool) (*PageSnapshot, error) {
+	result, err := s.ExecuteJavascript(ctx, targetID, pageSnapshotJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot page: %w", err)
+	}
+
+	rawJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	var snapshot PageSnapshot
+	if err := json.Unmarshal(rawJSON, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	if withScreenshot {
+		image, err := s.CaptureScreenshot(ctx, targetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot screenshot: %w", err)
+		}
+		sum := sha256.Sum256(image)
+		snapshot.ScreenshotHash = hex.EncodeToString(sum[:])
+	}
+
+	return &snapshot, nil
+}
+
+// ComparePageSnapshots computes the delta between two snapshots.
+// A nil snapshot on either side yields nil (the verdict is unknown).
+func ComparePageSnapshots(before, after *PageSnapshot) *PageDelta {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	delta := &PageDelta{
+		URLChanged:     before.URL != after.URL,
+		TitleChanged:   before.Title != after.Title,
+		DOMChanged:     before.DOMHash != after.DOMHash,
+		TextChanged:    before.TextHash != after.TextHash,
+		NodeCountDelta: after.NodeCount - before.NodeCount,
+	}
+
+	if before.ScreenshotHash != "" && after.ScreenshotHash != "" {
+		changed := before.ScreenshotHash != after.ScreenshotHash
+		delta.ScreenshotChanged = &changed
+	}
+
+	delta.Changed = delta.URLChanged || delta.TitleChanged || delta.DOMChanged || delta.TextChanged ||
+		(delta.ScreenshotChanged != nil && *delta.ScreenshotChanged)
+
+	return delta
+}
@@ -0,0 +1,45 @@
+package session
+
+import "testing"
+
+// TestComparePageSnapshots tests the changed/unchanged verdict
+func TestComparePageSnapshots(t *testing.T) {
+	before := &PageSnapshot{URL: "https://example.com/", Title: "Example", DOMHash: "a1", TextHash: "b1", NodeCount: 10}
+
+	// Identical snapshots are unchanged
+	same := *before
+	if delta := ComparePageSnapshots(before, &same); delta.Changed {
+		t.Errorf("expected unchanged, got %+v", delta)
+	}
+
+	// A DOM-only change is still a change
+	domChanged := *before
+	domChanged.DOMHash = "a2"
+	domChanged.NodeCount = 12
+	delta := ComparePageSnapshots(before, &domChanged)
+	if !delta.Changed || !delta.DOMChanged || delta.URLChanged {
+		t.Errorf("expected DOM-only change, got %+v", delta)
+	}
+	if delta.NodeCountDelta != 2 {
+		t.Errorf("expected node count delta 2, got %d", delta.NodeCountDelta)
+	}
+
+	// Screenshot comparison only happens when both sides have a hash
+	if delta.ScreenshotChanged != nil {
+		t.Error("expected no screenshot verdict without hashes")
+	}
+
+	withShot := *before
+	withShot.ScreenshotHash = "s1"
+	otherShot := withShot
+	otherShot.ScreenshotHash = "s2"
+	delta = ComparePageSnapshots(&withShot, &otherShot)
+	if delta.ScreenshotChanged == nil || !*delta.ScreenshotChanged || !delta.Changed {
+		t.Errorf("expected screenshot change, got %+v", delta)
+	}
+
+	// Unknown snapshots give no verdict
+	if ComparePageSnapshots(nil, before) != nil {
+		t.Error("expected nil delta for missing snapshot")
+	}
+}
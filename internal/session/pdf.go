@@ -0,0 +1,171 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ioReadChunkSize is how much of a browser-side stream one IO.read asks for
+const ioReadChunkSize = 1 << 20
+
+// PDFOptions controls how a page is printed. Zero values keep Chromium's defaults
+// (portrait US Letter at scale 1, without backgrounds).
+type PDFOptions struct {
+	Landscape       bool    `json:"landscape,omitempty"`
+	PrintBackground bool    `json:"print_background,omitempty"`
+	Scale           float64 `json:"scale,omitempty"`        // 0.1 to 2
+	PaperWidth      float64 `json:"paper_width,omitempty"`  // Inches
+	PaperHeight     float64 `json:"paper_height,omitempty"` // Inches
+	PageRanges      string  `json:"page_ranges,omitempty"`  // e.g. "1-5, 8"; empty prints every page
+}
+
+// validate rejects options Chromium would refuse
+func (o PDFOptions) validate() error {
+	if o.Scale != 0 && (o.Scale < 0.1 || o.Scale > 2) {
+		return fmt.Errorf("%w: scale must be between 0.1 and 2", ErrInvalidPDFOptions)
+	}
+	if o.PaperWidth < 0 || o.PaperHeight < 0 {
+		return fmt.Errorf("%w: paper size must be positive", ErrInvalidPDFOptions)
+	}
+	return nil
+}
+
+// params returns the Page.printToPDF parameters for the options
+func (o PDFOptions) params() map[string]interface{} {
+	params := map[string]interface{}{
+		// The browser keeps the document and hands it out through IO.read
+		"transferMode":    "ReturnAsStream",
+		"landscape":       o.Landscape,
+		"printBackground": o.PrintBackground,
+	}
+	if o.Scale != 0 {
+		params["scale"] = o.Scale
+	}
+	if o.PaperWidth != 0 {
+		params["paperWidth"] = o.PaperWidth
+	}
+	if o.PaperHeight != 0 {
+		params["paperHeight"] = o.PaperHeight
+	}
+	if o.PageRanges != "" {
+		params["pageRanges"] = o.PageRanges
+	}
+	return params
+}
+
+// ioStream reads a stream the browser holds (IO domain) one chunk at a time, so a
+// large document is never held in memory or in a single DevTools message
+type ioStream struct {
+	ctx      context.Context
+	session  *Session
+	targetID string
+	handle   string
+	pending  []byte
+	eof      bool
+}
+
+// Read returns the next bytes of the stream, asking the browser for more when the
+// last chunk has been used up
+func (s *ioStream) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+		if err := s.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// fill reads the next chunk from the browser
+func (s *ioStream) fill() error {
+	params := map[string]interface{}{"handle": s.handle, "size": ioReadChunkSize}
+	result, err := s.session.CDPClient.SendCommandToTarget(s.ctx, s.targetID, "IO.read", params)
+	if err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	var response struct {
+		Base64Encoded bool   `json:"base64Encoded"`
+		Data          string `json:"data"`
+		EOF           bool   `json:"eof"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return fmt.Errorf("failed to parse stream chunk: %w", err)
+	}
+
+	s.eof = response.EOF
+	s.pending = []byte(response.Data)
+	if response.Base64Encoded {
+		s.pending, err = base64.StdEncoding.DecodeString(response.Data)
+		if err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close lets the browser free the stream, even when the caller has gone away
+func (s *ioStream) Close() error {
+	params := map[string]interface{}{"handle": s.handle}
+	if _, err := s.session.CDPClient.SendCommandToTarget(context.WithoutCancel(s.ctx), s.targetID, "IO.close", params); err != nil {
+		return fmt.Errorf("failed to close stream: %w", err)
+	}
+	return nil
+}
+
+// PrintToPDF prints the page and returns the PDF as a stream read from the browser
+// on demand. The caller must close it.
+func (s *Session) PrintToPDF(ctx context.Context, targetID string, opts PDFOptions) (io.ReadCloser, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.printToPDF", opts.params())
+	if err != nil {
+		return nil, fmt.Errorf("failed to print page: %w", err)
+	}
+
+	var response struct {
+		Stream string `json:"stream"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse print response: %w", err)
+	}
+	if response.Stream == "" {
+		return nil, fmt.Errorf("browser returned no PDF stream")
+	}
+
+	return &ioStream{ctx: ctx, session: s, targetID: targetID, handle: response.Stream}, nil
+}
+
+// PrintToPDF prints a page of a session to a PDF stream
+func (m *Manager) PrintToPDF(ctx context.Context, sessionID string, pageID string, opts PDFOptions) (io.ReadCloser, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	stream, err := session.PrintToPDF(ctx, pageID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return stream, nil
+}
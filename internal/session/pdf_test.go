@@ -0,0 +1,82 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestPrintToPDFStream tests that a PDF is read from the browser in chunks and that the
+// stream is released when closed
+func TestPrintToPDFStream(t *testing.T) {
+	chunks := []string{"%PDF-1.7 first half ", "second half %%EOF"}
+	var reads, closes atomic.Int32
+
+	browser := newFakeBrowser(0, nil, func(method string, params json.RawMessage) map[string]interface{} {
+		switch method {
+		case "Target.attachToTarget":
+			return map[string]interface{}{"sessionId": "cdp-1"}
+		case "Page.printToPDF":
+			return map[string]interface{}{"stream": "stream-1"}
+		case "IO.read":
+			n := int(reads.Add(1)) - 1
+			return map[string]interface{}{
+				"base64Encoded": true,
+				"data":          base64.StdEncoding.EncodeToString([]byte(chunks[n])),
+				"eof":           n == len(chunks)-1,
+			}
+		case "IO.close":
+			closes.Add(1)
+		}
+		return nil
+	})
+	defer browser.server.Close()
+
+	client := cdp.NewClient(browser.wsURL())
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.Close()
+	session := &Session{ID: "s1", CDPClient: client}
+
+	stream, err := session.PrintToPDF(context.Background(), "page-1", PDFOptions{Landscape: true})
+	if err != nil {
+		t.Fatalf("failed to print page: %v", err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("failed to read PDF: %v", err)
+	}
+	if got, want := string(data), chunks[0]+chunks[1]; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got := reads.Load(); got != 2 {
+		t.Errorf("expected 2 reads, got %d", got)
+	}
+
+	if err := stream.Close(); err != nil {
+		t.Fatalf("failed to close stream: %v", err)
+	}
+	if got := closes.Load(); got != 1 {
+		t.Errorf("expected the stream to be closed once, got %d", got)
+	}
+}
+
+// TestPDFOptionsValidate tests that options Chromium would refuse are rejected up front
+func TestPDFOptionsValidate(t *testing.T) {
+	if err := (PDFOptions{}).validate(); err != nil {
+		t.Errorf("expected default options to be valid, got %v", err)
+	}
+	if err := (PDFOptions{Scale: 3}).validate(); !errors.Is(err, ErrInvalidPDFOptions) {
+		t.Errorf("expected ErrInvalidPDFOptions for scale 3, got %v", err)
+	}
+	if err := (PDFOptions{PaperWidth: -1}).validate(); !errors.Is(err, ErrInvalidPDFOptions) {
+		t.Errorf("expected ErrInvalidPDFOptions for a negative paper width, got %v", err)
+	}
+}
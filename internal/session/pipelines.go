@@ -0,0 +1,41 @@
+package session
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pipeline"
+)
+
+// Pipelines returns the manager's result pipeline registry
+func (m *Manager) Pipelines() *pipeline.Registry {
+	return m.pipelines
+}
+
+// Extract runs script on a page and passes its result through one of the session's
+// tenant's pipelines. An array result yields one record per element. An empty
+// pipelineName selects the session's default pipeline (SessionOptions.Pipeline).
+func (m *Manager) Extract(ctx context.Context, sessionID, pageID, script, pipelineName string) (*pipeline.Result, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if pipelineName == "" {
+		if session.Options == nil || session.Options.Pipeline == "" {
+			return nil, ErrNoPipeline
+		}
+		pipelineName = session.Options.Pipeline
+	}
+
+	// Resolve the pipeline before touching the page so a bad name has no side effects
+	if _, err := m.pipelines.Get(session.TenantID, pipelineName); err != nil {
+		return nil, err
+	}
+
+	result, err := m.ExecuteJavascript(ctx, sessionID, pageID, script)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.pipelines.Run(ctx, session.TenantID, pipelineName, sessionID, pipeline.Records(result))
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// ErrProfilesDisabled is returned when a session asks for a profile but none can be launched
+var ErrProfilesDisabled = errors.New("persistent profiles are not enabled")
+
+// ErrProfileOptions is returned for options a profile session can't apply. Such a session
+// runs in its browser's default context, which can't have its own proxy.
+var ErrProfileOptions = errors.New("option not supported with a profile")
+
+// ProfileLauncher starts the browser of a tenant's named profile for the session that
+// uses it, with the extensions the session asks for, and stops it when the session
+// ends. The manager only sees the debug port.
+type ProfileLauncher interface {
+	Acquire(tenantID, name string, extensions []string) (int, error)
+	Release(tenantID, name string) error
+}
+
+// SetProfileLauncher enables sessions with persistent profiles
+func (m *Manager) SetProfileLauncher(launcher ProfileLauncher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles = launcher
+}
+
+// profileOf returns the profile opts ask for, if any
+func profileOf(opts *SessionOptions) string {
+	if opts == nil {
+		return ""
+	}
+	return opts.Profile
+}
+
+// acquireProfile starts the browser of a tenant's profile and returns its port
+func (m *Manager) acquireProfile(tenantID string, opts *SessionOptions) (int, error) {
+	m.mu.RLock()
+	launcher := m.profiles
+	m.mu.RUnlock()
+	if launcher == nil {
+		return 0, ErrProfilesDisabled
+	}
+	if opts.Proxy != "" || opts.ProxyBypass != "" {
+		return 0, fmt.Errorf("%w: proxy", ErrProfileOptions)
+	}
+
+	port, err := launcher.Acquire(tenantID, opts.Profile, opts.Extensions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start profile %q: %w", opts.Profile, err)
+	}
+	return port, nil
+}
+
+// releaseProfile stops the profile browser a session ran in, dropping the connection
+// to it first so it isn't reconnected
+func (m *Manager) releaseProfile(tenantID, profile string, port int) {
+	m.mu.Lock()
+	client := m.cdpClients[port]
+	delete(m.cdpClients, port)
+	launcher := m.profiles
+	m.mu.Unlock()
+
+	if client != nil {
+		if err := client.Close(); err != nil {
+			slog.Warn("failed to close CDP client", "port", port, "error", err)
+		}
+	}
+	if launcher == nil {
+		return
+	}
+	if err := launcher.Release(tenantID, profile); err != nil {
+		slog.Warn("failed to stop profile browser", "profile", profile, "port", port, "error", err)
+	}
+}
+
+// releaseContext gives up the browser context of a session leaving memory. A profile
+// session's browser is stopped instead, which writes the profile out to disk.
+func (m *Manager) releaseContext(session *Session) {
+	// A hibernated session gave its context up already
+	if session.IsHibernated() {
+		return
+	}
+	if profile := profileOf(session.Options); profile != "" {
+		m.releaseProfile(session.TenantID, profile, session.ProcessPort)
+		return
+	}
+	if session.bidi != nil {
+		m.removeUserContext(session)
+		return
+	}
+	if err := session.CDPClient.DisposeBrowserContext(context.Background(), session.ContextID); err != nil {
+		slog.Warn("failed to dispose browser context", "error", err)
+	}
+}
+
+// defaultContextID returns the browser's default context, where a profile's cookies and
+// storage persist. Its pages report it; a browser without pages gets one.
+func defaultContextID(ctx context.Context, client *cdp.Client) (string, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		targets, err := client.GetTargets(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list targets: %w", err)
+		}
+		for _, target := range targets {
+			if target.Type == "page" && target.BrowserContextID != "" {
+				return target.BrowserContextID, nil
+			}
+		}
+		if _, err := client.CreateTarget(ctx, "about:blank", ""); err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("browser reports no default context")
+}
+
+// withContext adds the session's browser context to CDP params. The default context of
+// a profile session is selected by leaving it out.
+func (s *Session) withContext(params map[string]interface{}) map[string]interface{} {
+	if profileOf(s.Options) == "" {
+		params["browserContextId"] = s.ContextID
+	}
+	return params
+}
+
+// targetContextID returns the context new pages are created in
+func (s *Session) targetContextID() string {
+	if profileOf(s.Options) != "" {
+		return ""
+	}
+	return s.ContextID
+}
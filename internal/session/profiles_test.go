@@ -0,0 +1,91 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeLauncher hands out a fixed port for any profile and records what was asked of it
+type fakeLauncher struct {
+	port     int
+	mu       sync.Mutex
+	acquired []string
+	released []string
+}
+
+func (l *fakeLauncher) Acquire(tenantID, name string, extensions []string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.acquired = append(l.acquired, tenantID+"/"+name)
+	return l.port, nil
+}
+
+func (l *fakeLauncher) Release(tenantID, name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.released = append(l.released, tenantID+"/"+name)
+	return nil
+}
+
+// TestProfileSession tests that a session with a profile runs in its own browser's
+// default context, and that the browser is let go when the session ends
+func TestProfileSession(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	browser := newFakeBrowser(0, nil, func(method string, params json.RawMessage) map[string]interface{} {
+		mu.Lock()
+		methods = append(methods, method)
+		mu.Unlock()
+		if method == "Target.getTargets" {
+			return map[string]interface{}{"targetInfos": []map[string]interface{}{
+				{"targetId": "tab-1", "type": "page", "url": "about:blank", "browserContextId": "default-ctx"},
+			}}
+		}
+		return nil
+	})
+	defer browser.server.Close()
+
+	manager := NewManager(nil)
+	defer manager.Close()
+	opts := &SessionOptions{Profile: "work"}
+
+	if _, err := manager.CreateSessionWithOptions(context.Background(), "acme", "agent-1", "", 9222, "", opts); !errors.Is(err, ErrProfilesDisabled) {
+		t.Fatalf("expected ErrProfilesDisabled without a launcher, got %v", err)
+	}
+
+	launcher := &fakeLauncher{port: 9300}
+	manager.SetProfileLauncher(launcher)
+	manager.RegisterRemoteBrowser(9300, browser.wsURL())
+
+	if _, err := manager.CreateSessionWithOptions(context.Background(), "acme", "agent-1", "", 9222, "", &SessionOptions{Profile: "work", Proxy: "http://proxy:3128"}); !errors.Is(err, ErrProfileOptions) {
+		t.Errorf("expected ErrProfileOptions for a proxy, got %v", err)
+	}
+
+	sess, err := manager.CreateSessionWithOptions(context.Background(), "acme", "agent-1", "", 9222, "", opts)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	if sess.ProcessPort != 9300 || sess.ContextID != "default-ctx" {
+		t.Errorf("expected the profile browser's default context, got port %d context %q", sess.ProcessPort, sess.ContextID)
+	}
+	if browser.contexts.Load() != 0 {
+		t.Error("expected no browser context to be created for a profile session")
+	}
+
+	if err := manager.DestroySession(sess.ID); err != nil {
+		t.Fatalf("DestroySession failed: %v", err)
+	}
+	if len(launcher.acquired) != 1 || len(launcher.released) != 1 || launcher.released[0] != "acme/work" {
+		t.Errorf("expected the profile to be acquired and released once, got %v and %v", launcher.acquired, launcher.released)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, method := range methods {
+		if method == "Target.disposeBrowserContext" {
+			t.Error("expected the default context not to be disposed")
+		}
+	}
+}
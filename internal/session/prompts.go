@@ -0,0 +1,282 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/research"
+)
+
+// Names of the system prompts models are asked with
+const (
+	PromptVision   = "vision"   // Vision queries
+	PromptResearch = "research" // Research answers
+	PromptHeal     = "heal"     // Confirming a healed selector
+)
+
+// maxPromptVersions is how many versions of a prompt each tenant keeps, oldest dropped first
+const maxPromptVersions = 20
+
+// builtinPrompt is a prompt the service ships with, and the variables it fills in when
+// rendering it. The sample values check that saved templates render.
+type builtinPrompt struct {
+	text   string
+	sample map[string]interface{}
+}
+
+// builtinPrompts are the prompts used until a version is saved
+var builtinPrompts = map[string]builtinPrompt{
+	PromptVision:   {text: visionSystemPrompt, sample: map[string]interface{}{"Width": 1280, "Height": 720, "Overlay": true}},
+	PromptResearch: {text: research.SystemPrompt, sample: map[string]interface{}{"Question": "How tall is it?", "Language": "en"}},
+	PromptHeal:     {text: healSystemPrompt, sample: map[string]interface{}{"Selector": "#checkout"}},
+}
+
+// PromptVersion is one saved version of a prompt
+type PromptVersion struct {
+	Version   int               `json:"version"`
+	Text      string            `json:"text,omitempty"`      // Go template; empty keeps the text this version overrides
+	Variables map[string]string `json:"variables,omitempty"` // Values templates read as {{.Vars.name}}
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Prompt is a prompt as a tenant's model calls are asked with it
+type Prompt struct {
+	Name      string            `json:"name"`
+	Text      string            `json:"text"`      // Template in effect
+	Variables map[string]string `json:"variables"` // Variables in effect, shared ones overlaid by the tenant's
+	Source    string            `json:"source"`    // Where the text comes from: "default", "shared" or "tenant"
+	Builtins  []string          `json:"builtins"`  // Variables the service fills in, read as {{.Name}}
+	Versions  []PromptVersion   `json:"versions"`  // The caller's own versions, oldest first; the last one is in effect
+}
+
+// PromptRegistry holds the saved versions of the system prompts. Versions saved by a
+// tenant override the shared ones, saved without a tenant, which override the
+// built-in prompts.
+type PromptRegistry struct {
+	versions map[templateKey][]PromptVersion
+	mu       sync.RWMutex
+}
+
+// NewPromptRegistry creates a registry where every prompt is the built-in one
+func NewPromptRegistry() *PromptRegistry {
+	return &PromptRegistry{versions: make(map[templateKey][]PromptVersion)}
+}
+
+// Save adds a new version of a tenant's prompt, which is used from now on. It fails
+// when the prompt doesn't exist or the template doesn't render.
+func (r *PromptRegistry) Save(tenantID, name string, version PromptVersion) (*PromptVersion, error) {
+	builtin, exists := builtinPrompts[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+	if strings.TrimSpace(version.Text) == "" && len(version.Variables) == 0 {
+		return nil, fmt.Errorf("%w: text or variables are required", ErrInvalidPrompt)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := templateKey{tenantID, name}
+	saved := r.versions[key]
+	version.Version = 1
+	if len(saved) > 0 {
+		version.Version = saved[len(saved)-1].Version + 1
+	}
+	if version.CreatedAt.IsZero() {
+		version.CreatedAt = time.Now()
+	}
+
+	// Render what the tenant would be asked with, so a broken template is refused now
+	candidate := append(saved[:len(saved):len(saved)], version)
+	text, variables := r.resolveLocked(tenantID, name, candidate)
+	if _, err := renderPrompt(text, builtin.sample, variables); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+	}
+
+	if len(candidate) > maxPromptVersions {
+		candidate = candidate[len(candidate)-maxPromptVersions:]
+	}
+	r.versions[key] = candidate
+	return &version, nil
+}
+
+// Get returns a prompt as a tenant is asked with it, with the tenant's own versions
+func (r *PromptRegistry) Get(tenantID, name string) (*Prompt, error) {
+	builtin, exists := builtinPrompts[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	own := r.versions[templateKey{tenantID, name}]
+	text, variables := r.resolveLocked(tenantID, name, own)
+	prompt := &Prompt{
+		Name:      name,
+		Text:      text,
+		Variables: variables,
+		Source:    r.sourceLocked(tenantID, name, own),
+		Builtins:  make([]string, 0, len(builtin.sample)),
+		Versions:  append([]PromptVersion{}, own...),
+	}
+	for variable := range builtin.sample {
+		prompt.Builtins = append(prompt.Builtins, variable)
+	}
+	sort.Strings(prompt.Builtins)
+	return prompt, nil
+}
+
+// List returns every prompt as a tenant is asked with it, sorted by name
+func (r *PromptRegistry) List(tenantID string) []*Prompt {
+	prompts := make([]*Prompt, 0, len(builtinPrompts))
+	for name := range builtinPrompts {
+		prompt, _ := r.Get(tenantID, name)
+		prompts = append(prompts, prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool {
+		return prompts[i].Name < prompts[j].Name
+	})
+	return prompts
+}
+
+// Reset drops every version a tenant saved of a prompt, going back to the shared or
+// built-in one. Shared versions can't be reset by a tenant.
+func (r *PromptRegistry) Reset(tenantID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := templateKey{tenantID, name}
+	if _, exists := r.versions[key]; !exists {
+		return fmt.Errorf("%w: no saved versions of %s", ErrPromptNotFound, name)
+	}
+	delete(r.versions, key)
+	return nil
+}
+
+// LoadFile saves a shared version of every prompt in a JSON file holding an array of
+// {"name", "text", "variables"} objects
+func (r *PromptRegistry) LoadFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read prompts file: %w", err)
+	}
+
+	var entries []struct {
+		Name string `json:"name"`
+		PromptVersion
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("failed to parse prompts file: %w", err)
+	}
+
+	for _, entry := range entries {
+		if _, err := r.Save("", entry.Name, entry.PromptVersion); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
+
+// render returns a tenant's prompt with the builtin variables in data filled in. A
+// template that stopped rendering, such as one reading a shared variable that was
+// since removed, is logged and replaced by the built-in prompt.
+func (r *PromptRegistry) render(tenantID, name string, data map[string]interface{}) string {
+	r.mu.RLock()
+	text, variables := r.resolveLocked(tenantID, name, r.versions[templateKey{tenantID, name}])
+	r.mu.RUnlock()
+
+	rendered, err := renderPrompt(text, data, variables)
+	if err == nil {
+		return rendered
+	}
+	slog.Warn("failed to render prompt, using the built-in one", "prompt", name, "tenant_id", tenantID, "error", err)
+	rendered, _ = renderPrompt(builtinPrompts[name].text, data, nil)
+	return rendered
+}
+
+// resolveLocked returns the text a tenant with the versions own is asked with, and its
+// variables. The latest version with text wins, looking at the tenant's, then the
+// shared ones, then the built-in prompt; variables of the tenant's latest version are
+// laid over the shared ones. It requires r.mu to be held.
+func (r *PromptRegistry) resolveLocked(tenantID, name string, own []PromptVersion) (string, map[string]string) {
+	scopes := [][]PromptVersion{own}
+	if tenantID != "" {
+		scopes = append(scopes, r.versions[templateKey{"", name}])
+	}
+
+	text := builtinPrompts[name].text
+	for _, versions := range scopes {
+		if latest := latestText(versions); latest != "" {
+			text = latest
+			break
+		}
+	}
+
+	variables := map[string]string{}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if versions := scopes[i]; len(versions) > 0 {
+			maps.Copy(variables, versions[len(versions)-1].Variables)
+		}
+	}
+	return text, variables
+}
+
+// sourceLocked names where a tenant's prompt text comes from. It requires r.mu to be held.
+func (r *PromptRegistry) sourceLocked(tenantID, name string, own []PromptVersion) string {
+	switch {
+	case latestText(own) != "" && tenantID != "":
+		return "tenant"
+	case latestText(own) != "" || latestText(r.versions[templateKey{"", name}]) != "":
+		return "shared"
+	default:
+		return "default"
+	}
+}
+
+// latestText returns the text of the latest version that has one
+func latestText(versions []PromptVersion) string {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Text != "" {
+			return versions[i].Text
+		}
+	}
+	return ""
+}
+
+// renderPrompt executes a prompt template. Builtins are read as {{.Name}} and
+// variables as {{.Vars.name}}; reading one that isn't set fails.
+func renderPrompt(text string, builtins map[string]interface{}, variables map[string]string) (string, error) {
+	parsed, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := maps.Clone(builtins)
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	data["Vars"] = variables
+
+	var rendered strings.Builder
+	if err := parsed.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+	return rendered.String(), nil
+}
+
+// Prompts returns the registry of the system prompts models are asked with
+func (m *Manager) Prompts() *PromptRegistry {
+	return m.prompts
+}
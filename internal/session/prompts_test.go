@@ -0,0 +1,137 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestPromptDefaults tests that the built-in prompts render as they read before they
+// became templates
+func TestPromptDefaults(t *testing.T) {
+	prompts := NewPromptRegistry()
+
+	vision := prompts.render("", PromptVision, map[string]interface{}{"Width": 1280, "Height": 720, "Overlay": false})
+	if !strings.HasPrefix(vision, "You are looking at a 1280x720 pixel screenshot") || strings.Contains(vision, "outlined in red") {
+		t.Errorf("unexpected vision prompt: %q", vision)
+	}
+	overlay := prompts.render("", PromptVision, map[string]interface{}{"Width": 1280, "Height": 720, "Overlay": true})
+	if !strings.HasSuffix(overlay, "when they don't apply.\nInteractive elements are outlined in red and labelled with a number in their top-left corner. Prefer marks over points when the answer is one of them.") {
+		t.Errorf("unexpected overlay prompt: %q", overlay)
+	}
+	if heal := prompts.render("", PromptHeal, map[string]interface{}{"Selector": "#buy"}); heal != healSystemPrompt {
+		t.Errorf("expected the heal prompt unchanged, got %q", heal)
+	}
+}
+
+// TestPromptVersions tests that tenants' versions override the shared ones, which
+// override the built-in prompts, and that variables are laid over each other
+func TestPromptVersions(t *testing.T) {
+	prompts := NewPromptRegistry()
+	data := map[string]interface{}{"Selector": "#buy"}
+
+	shared, err := prompts.Save("", PromptHeal, PromptVersion{
+		Text:      "Match {{.Selector}} in a {{.Vars.tone}} way.",
+		Variables: map[string]string{"tone": "careful"},
+	})
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if shared.Version != 1 || shared.CreatedAt.IsZero() {
+		t.Errorf("expected version 1 with a creation time, got %+v", shared)
+	}
+
+	// A tenant can change only the variables and keep the shared text
+	if _, err := prompts.Save("acme", PromptHeal, PromptVersion{Variables: map[string]string{"tone": "strict"}}); err != nil {
+		t.Fatalf("Save of variables failed: %v", err)
+	}
+	if got := prompts.render("acme", PromptHeal, data); got != "Match #buy in a strict way." {
+		t.Errorf("unexpected acme prompt: %q", got)
+	}
+	if got := prompts.render("globex", PromptHeal, data); got != "Match #buy in a careful way." {
+		t.Errorf("unexpected globex prompt: %q", got)
+	}
+
+	// A new version with text replaces the shared text for the tenant only
+	version, err := prompts.Save("acme", PromptHeal, PromptVersion{Text: "{{.Vars.tone}}: {{.Selector}}"})
+	if err != nil {
+		t.Fatalf("Save of text failed: %v", err)
+	}
+	if version.Version != 2 {
+		t.Errorf("expected version 2, got %d", version.Version)
+	}
+	prompt, err := prompts.Get("acme", PromptHeal)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if prompt.Source != "tenant" || prompt.Text != "{{.Vars.tone}}: {{.Selector}}" || prompt.Variables["tone"] != "careful" || len(prompt.Versions) != 2 {
+		t.Errorf("unexpected acme prompt: %+v", prompt)
+	}
+	if prompt.Builtins[0] != "Selector" {
+		t.Errorf("expected the builtins listed, got %v", prompt.Builtins)
+	}
+
+	// Templates that don't render for the tenant are refused
+	for _, bad := range []PromptVersion{
+		{Text: "{{.Selector"},
+		{Text: "{{.Width}}"},
+		{Text: "{{.Vars.missing}}"},
+		{},
+	} {
+		if _, err := prompts.Save("acme", PromptHeal, bad); !errors.Is(err, ErrInvalidPrompt) {
+			t.Errorf("%+v: expected ErrInvalidPrompt, got %v", bad, err)
+		}
+	}
+	if _, err := prompts.Save("acme", "summarize", PromptVersion{Text: "hi"}); !errors.Is(err, ErrPromptNotFound) {
+		t.Errorf("expected ErrPromptNotFound for an unknown prompt, got %v", err)
+	}
+
+	// Resetting goes back to the shared version, which the tenant can't reset
+	if err := prompts.Reset("acme", PromptHeal); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if err := prompts.Reset("acme", PromptHeal); !errors.Is(err, ErrPromptNotFound) {
+		t.Errorf("expected nothing left to reset, got %v", err)
+	}
+	if prompt, _ := prompts.Get("acme", PromptHeal); prompt.Source != "shared" || len(prompt.Versions) != 0 {
+		t.Errorf("expected the shared prompt after a reset, got %+v", prompt)
+	}
+	if prompt, _ := prompts.Get("acme", PromptVision); prompt.Source != "default" {
+		t.Errorf("expected the built-in vision prompt, got %+v", prompt)
+	}
+
+	// Old versions are dropped past the limit
+	for i := range maxPromptVersions + 5 {
+		if _, err := prompts.Save("globex", PromptResearch, PromptVersion{Text: fmt.Sprintf("Answer %d: {{.Question}}", i)}); err != nil {
+			t.Fatalf("Save %d failed: %v", i, err)
+		}
+	}
+	prompt, _ = prompts.Get("globex", PromptResearch)
+	if len(prompt.Versions) != maxPromptVersions || prompt.Versions[len(prompt.Versions)-1].Version != maxPromptVersions+5 {
+		t.Errorf("expected the latest %d versions kept, got %d ending at %d", maxPromptVersions, len(prompt.Versions), prompt.Versions[len(prompt.Versions)-1].Version)
+	}
+	if len(prompts.List("globex")) != len(builtinPrompts) {
+		t.Errorf("expected every prompt listed")
+	}
+}
+
+// TestPromptFallback tests that a tenant's template that stopped rendering is replaced
+// by the built-in prompt
+func TestPromptFallback(t *testing.T) {
+	prompts := NewPromptRegistry()
+	if _, err := prompts.Save("", PromptHeal, PromptVersion{Text: "x", Variables: map[string]string{"tone": "careful"}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := prompts.Save("acme", PromptHeal, PromptVersion{Text: "{{.Vars.tone}}"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// The shared variable the tenant's text reads goes away
+	if _, err := prompts.Save("", PromptHeal, PromptVersion{Text: "y"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if got := prompts.render("acme", PromptHeal, map[string]interface{}{"Selector": "#buy"}); got != healSystemPrompt {
+		t.Errorf("expected the built-in prompt, got %q", got)
+	}
+}
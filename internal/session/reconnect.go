@@ -0,0 +1,106 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// handleConnectionState tells the sessions on port about their browser connection.
+// It runs on the client's notification goroutine, never on the CDP reader.
+func (m *Manager) handleConnectionState(port int, client *cdp.Client, state cdp.ConnectionState) {
+	switch state {
+	case cdp.StateReconnecting:
+		m.dropConnectionState(port)
+		for _, sessionID := range m.SessionsOnPort(port) {
+			m.publishEvent(sessionID, "", events.TypeConnectionLost, map[string]interface{}{"port": port})
+		}
+	case cdp.StateConnected:
+		m.restoreConnection(port, client)
+	}
+}
+
+// dropConnectionState ends screencasts and takeovers on port. Both are tied to
+// target sessions of the dropped connection and can't survive a reconnect.
+func (m *Manager) dropConnectionState(port int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, session := range m.sessions {
+		if session.ProcessPort == port {
+			session.stopAllScreencasts()
+			session.stopAllWatches()
+			session.stopAllRoutes()
+			session.stopAllNetworkCapture()
+			session.forgetAllHandles()
+			m.endTakeoverLocked(session.ID)
+		}
+	}
+}
+
+// restoreConnection brings sessions back after client reconnected to the browser on port.
+// If the browser itself survived, contexts and pages are still there and nothing moves.
+// If it restarted behind the connection, the sessions are migrated like a recycle would.
+func (m *Manager) restoreConnection(port int, client *cdp.Client) {
+	// Discovery is per connection; the Target event listeners are still registered
+	if _, err := client.SendCommand(context.Background(), "Target.setDiscoverTargets", map[string]interface{}{"discover": true}); err != nil {
+		slog.Warn("failed to re-enable target discovery", "port", port, "error", err)
+	}
+
+	contexts, err := client.GetBrowserContexts(context.Background())
+	if err != nil {
+		slog.Warn("failed to list browser contexts after reconnect", "port", port, "error", err)
+		return
+	}
+
+	m.mu.RLock()
+	current := m.cdpClients[port] == client
+	lost := false
+	for _, session := range m.sessions {
+		// A profile session's default context is never listed, and lives as long as the browser
+		if session.ProcessPort == port && profileOf(session.Options) == "" && !session.IsHibernated() && !slices.Contains(contexts, session.ContextID) {
+			lost = true
+			break
+		}
+	}
+	m.mu.RUnlock()
+
+	// The client was replaced (e.g. by a recycle) while it was reconnecting
+	if !current {
+		return
+	}
+
+	if lost {
+		slog.Warn("browser restarted while disconnected, migrating sessions", "port", port)
+		if err := m.BeforeRestart(port); err != nil {
+			slog.Error("failed to detach sessions after reconnect", "port", port, "error", err)
+			return
+		}
+		if err := m.AfterRestart(port, port); err != nil {
+			slog.Error("failed to migrate sessions after reconnect", "port", port, "error", err)
+		}
+	}
+
+	// Pages that survived lost their route and capture listeners with the old connection;
+	// migrated pages were tracked again when they were reopened
+	if !lost {
+		for _, sessionID := range m.SessionsOnPort(port) {
+			if session, err := m.GetSession(sessionID); err == nil {
+				for _, pageID := range session.Pages() {
+					m.trackRoutes(context.Background(), session, pageID)
+					m.captureNetwork(context.Background(), session, pageID)
+				}
+			}
+		}
+	}
+
+	for _, sessionID := range m.SessionsOnPort(port) {
+		m.publishEvent(sessionID, "", events.TypeConnectionRestored, map[string]interface{}{
+			"port":     port,
+			"migrated": lost,
+		})
+	}
+}
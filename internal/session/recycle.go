@@ -0,0 +1,201 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/storage"
+)
+
+// migration remembers what a session had open before its browser was restarted
+type migration struct {
+	session *Session
+	pages   []migratedPage
+	cookies []storage.Cookie
+}
+
+// migratedPage is a page to reopen after the restart
+type migratedPage struct {
+	pageID string
+	url    string
+}
+
+// SessionsOnPort returns the IDs of the in-memory sessions using the browser on port
+func (m *Manager) SessionsOnPort(port int) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessionIDs := make([]string, 0)
+	for _, session := range m.sessions {
+		if session.ProcessPort == port {
+			sessionIDs = append(sessionIDs, session.ID)
+		}
+	}
+
+	return sessionIDs
+}
+
+// BeforeRestart detaches every session on port from a browser that is about to restart.
+// Sessions stay registered; their pages and cookies are captured so AfterRestart can
+// re-create them in a fresh context.
+func (m *Manager) BeforeRestart(port int) error {
+	// Contexts in the warm pool die with the browser
+	m.drainWarmPool(port)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := make([]*migration, 0)
+	for _, session := range m.sessions {
+		// Hibernated sessions hold nothing in the browser; AfterRestart only moves them
+		if session.ProcessPort != port || session.IsHibernated() {
+			continue
+		}
+
+		session.stopAllScreencasts()
+		session.stopAllWatches()
+		session.stopAllRoutes()
+		session.stopAllNetworkCapture()
+		session.forgetAllHandles()
+		m.endTakeoverLocked(session.ID)
+
+		entry := &migration{session: session}
+
+		// A dropped connection can't be asked anything; waiting on it would only time out
+		if !session.CDPClient.IsConnected() {
+			slog.Warn("browser connection lost, migrating session without its pages or cookies", "session_id", session.ID)
+			pending = append(pending, entry)
+			continue
+		}
+
+		// Capture state best-effort: a wedged browser is a common reason to recycle
+		if cookies, err := session.getContextCookies(context.Background()); err == nil {
+			entry.cookies = cookies
+		} else {
+			slog.Warn("failed to capture cookies for migration", "session_id", session.ID, "error", err)
+		}
+
+		for _, pageID := range session.Pages() {
+			url, err := session.GetCurrentURL(context.Background(), pageID)
+			if err != nil {
+				slog.Warn("failed to capture page URL for migration", "session_id", session.ID, "page_id", pageID, "error", err)
+				continue
+			}
+			entry.pages = append(entry.pages, migratedPage{pageID: pageID, url: url})
+		}
+
+		pending = append(pending, entry)
+	}
+
+	m.migrations[port] = pending
+
+	// The connection to the old browser is useless after the restart
+	if client, exists := m.cdpClients[port]; exists {
+		if err := client.Close(); err != nil {
+			slog.Warn("failed to close CDP client", "port", port, "error", err)
+		}
+		delete(m.cdpClients, port)
+	}
+
+	slog.Info("sessions detached for browser restart", "port", port, "sessions", len(pending))
+	return nil
+}
+
+// AfterRestart re-creates the sessions detached by BeforeRestart on the restarted browser.
+// Session IDs are kept; pages are reopened at their last URLs under new page IDs.
+func (m *Manager) AfterRestart(oldPort int, newPort int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := m.migrations[oldPort]
+	delete(m.migrations, oldPort)
+
+	for _, session := range m.sessions {
+		if session.ProcessPort == oldPort && session.IsHibernated() {
+			session.ProcessPort = newPort
+		}
+	}
+
+	client, err := m.GetOrCreateCDPClient(newPort)
+	if err != nil {
+		return fmt.Errorf("failed to connect to restarted browser: %w", err)
+	}
+
+	failed := 0
+	for _, entry := range pending {
+		session := entry.session
+
+		// The session may have been destroyed while the browser restarted
+		if _, exists := m.sessions[session.ID]; !exists {
+			continue
+		}
+
+		contextID, err := client.CreateBrowserContextWithOptions(context.Background(), contextOptions(session.Options, session.egressAddr))
+		if err != nil {
+			slog.Error("failed to migrate session", "session_id", session.ID, "error", err)
+			failed++
+			continue
+		}
+
+		session.ProcessPort = newPort
+		session.CDPClient = client
+		session.ContextID = contextID
+		session.resetPages()
+		session.takeWarmPage() // The pre-opened page died with the old browser
+		_, remote := m.remotes[newPort]
+		m.setupDownloads(context.Background(), session, remote)
+
+		// Captured cookies already include the template's seed cookies
+		if len(entry.cookies) > 0 {
+			if err := session.setContextCookies(context.Background(), entry.cookies); err != nil {
+				slog.Warn("failed to restore cookies", "session_id", session.ID, "error", err)
+			}
+		} else if err := session.applyContextOptions(context.Background()); err != nil {
+			slog.Warn("failed to apply session cookies", "session_id", session.ID, "error", err)
+		}
+
+		// Reopen pages, reporting old → new page IDs to subscribers
+		pageMap := make(map[string]string, len(entry.pages))
+		for _, page := range entry.pages {
+			pageID, err := session.openPage(context.Background(), page.url)
+			if err != nil {
+				slog.Warn("failed to reopen page", "session_id", session.ID, "url", page.url, "error", err)
+				continue
+			}
+			session.AddPage(pageID)
+			m.trackRoutes(context.Background(), session, pageID)
+			pageMap[page.pageID] = pageID
+		}
+
+		for _, pageID := range session.Pages() {
+			if err := session.WaitForReady(context.Background(), pageID, session.navigationTimeout()); err != nil {
+				slog.Warn("migrated page did not reach ready state before timeout", "page_id", pageID, "error", err)
+			}
+		}
+
+		if m.repo != nil {
+			if err := m.repo.SaveSession(m.sessionToState(session)); err != nil {
+				slog.Warn("failed to persist migrated session", "session_id", session.ID, "error", err)
+			}
+		}
+
+		m.publishEvent(session.ID, "", events.TypeSessionMigrated, map[string]interface{}{
+			"old_port": oldPort,
+			"new_port": newPort,
+			"pages":    pageMap,
+		})
+	}
+
+	slog.Info("sessions migrated to restarted browser",
+		"old_port", oldPort,
+		"new_port", newPort,
+		"sessions", len(pending)-failed,
+		"failed", failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sessions could not be migrated", failed, len(pending))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// RegisterRemoteBrowser makes port a handle for an external browser at endpoint.
+// endpoint is a DevTools WebSocket URL (ws:// or wss://) or a host:port serving /json/version.
+func (m *Manager) RegisterRemoteBrowser(port int, endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remotes[port] = endpoint
+}
+
+// isRemote reports whether port is a handle for an external browser
+func (m *Manager) isRemote(port int) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, remote := m.remotes[port]
+	return remote
+}
+
+// resolveWebSocketURL asks the browser for its WebSocket URL. It takes no locks,
+// so reconnecting clients can call it from their own goroutine.
+func resolveWebSocketURL(port int, endpoint string, remote bool) (string, error) {
+	if !remote {
+		return cdp.GetWebSocketURL("localhost", strconv.Itoa(port))
+	}
+
+	// Hosted browser services hand out a WebSocket URL directly (often with a token in it)
+	if strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://") {
+		return endpoint, nil
+	}
+
+	host, debugPort, err := net.SplitHostPort(strings.TrimPrefix(endpoint, "http://"))
+	if err != nil {
+		return "", err
+	}
+	return cdp.GetWebSocketURL(host, debugPort)
+}
+
+// IsConnected reports whether the CDP connection to the browser on port is up.
+// A port nothing has connected to yet counts as connected: there is nothing to restore.
+func (m *Manager) IsConnected(port int) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, exists := m.cdpClients[port]
+	return !exists || client.IsConnected()
+}
+
+// ConnectionStats describes the connection to the browser on Port
+type ConnectionStats struct {
+	Port      int                 `json:"port"`
+	State     cdp.ConnectionState `json:"state"`
+	Listeners int                 `json:"listeners"` // Registered event handlers
+	Targets   int                 `json:"targets"`   // Attached targets
+	cdp.PendingStats
+	cdp.WriteStats
+	cdp.ScriptStats
+}
+
+// ConnectionStats reports the command queues, pending commands, script slots and event
+// handlers of the manager's browser connections, by port
+func (m *Manager) ConnectionStats() []ConnectionStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := make([]ConnectionStats, 0, len(m.cdpClients))
+	for port, client := range m.cdpClients {
+		stats = append(stats, ConnectionStats{
+			Port:         port,
+			State:        client.State(),
+			Listeners:    client.Listeners(),
+			Targets:      client.AttachedTargets(),
+			PendingStats: client.PendingStats(),
+			WriteStats:   client.WriteStats(),
+			ScriptStats:  client.ScriptStats(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Port < stats[j].Port
+	})
+	return stats
+}
+
+// DisposeStaleContexts disposes the browser contexts that sessions of an earlier run
+// left on remote browsers. Local browsers die with the service, or are killed at
+// startup, taking their contexts with them; a remote browser outlives it and keeps
+// every context open. Only contexts recorded in Redis are touched, since a remote
+// browser may be shared with other clients. Their sessions are marked idle, so
+// resuming one opens a fresh context. It returns how many contexts were disposed.
+func (m *Manager) DisposeStaleContexts(ctx context.Context) (int, error) {
+	if m.repo == nil {
+		return 0, nil
+	}
+
+	m.mu.RLock()
+	ports := make([]int, 0, len(m.remotes))
+	for port := range m.remotes {
+		ports = append(ports, port)
+	}
+	live := make(map[string]bool, len(m.sessions))
+	for _, session := range m.sessions {
+		live[session.ContextID] = true
+	}
+	m.mu.RUnlock()
+	if len(ports) == 0 {
+		return 0, nil
+	}
+
+	sessionIDs, err := m.repo.ListActiveSessions()
+	if err != nil {
+		return 0, err
+	}
+	// Remote handles are numbered in configuration order, which may have changed, so
+	// contexts are matched by ID on every remote browser
+	stale := make(map[string]string) // Context ID → session ID
+	for _, sessionID := range sessionIDs {
+		state, err := m.repo.GetSession(sessionID)
+		if err != nil || state.ContextID == "" || state.Status == string(SessionIdle) || live[state.ContextID] {
+			continue
+		}
+		stale[state.ContextID] = sessionID
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	disposed := 0
+	for _, port := range ports {
+		client, err := m.clientForPort(port)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to connect to remote browser for context cleanup", "port", port, "error", err)
+			continue
+		}
+		contexts, err := client.GetBrowserContexts(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to list browser contexts", "port", port, "error", err)
+			continue
+		}
+		for _, contextID := range contexts {
+			sessionID, ok := stale[contextID]
+			if !ok {
+				continue
+			}
+			if err := client.DisposeBrowserContext(ctx, contextID); err != nil {
+				slog.WarnContext(ctx, "failed to dispose stale browser context", "port", port, "context_id", contextID, "error", err)
+				continue
+			}
+			if err := m.repo.UpdateSessionStatus(sessionID, string(SessionIdle)); err != nil {
+				slog.WarnContext(ctx, "failed to mark session idle", "session_id", sessionID, "error", err)
+			}
+			disposed++
+		}
+	}
+	return disposed, nil
+}
@@ -0,0 +1,247 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/research"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+const (
+	// DefaultResearchTimeout bounds a whole research request: search, pages and model
+	DefaultResearchTimeout = 3 * time.Minute
+
+	// DefaultResearchSources is how many results are read when a request doesn't say
+	DefaultResearchSources = 4
+
+	// MaxResearchSources bounds the pages one request opens in parallel
+	MaxResearchSources = 8
+
+	// researchPageTimeout bounds loading and reading one source, so a slow site costs
+	// its own passages and not the answer
+	researchPageTimeout = 30 * time.Second
+
+	// researchPassages is how many passages go into the prompt, and researchPerSource how
+	// many of them one source may supply
+	researchPassages  = 12
+	researchPerSource = 4
+
+	// researchMaxText caps the text read from one page
+	researchMaxText = 200000
+)
+
+// researchTextJS reads the title and the visible text of the page's main content
+const researchTextJS = `(() => {
+	const root = document.querySelector('article, main, [role="main"]') || document.body;
+	const text = root ? root.innerText : '';
+	return JSON.stringify({title: document.title, text: text.slice(0, %d)});
+})()`
+
+// ResearchRequest is a question answered from the web
+type ResearchRequest struct {
+	Question string
+	Sources  int    // Search results read (0: DefaultResearchSources)
+	Language string // Passed on to the search
+	Region   string
+	TenantID string       // The research session, and a browser search's, belong to this tenant
+	OnText   func(string) // Gets the answer piece by piece as the model writes it (nil: not streamed)
+	NoCache  bool         // Ask the model even when it answered the same question from the same passages before
+}
+
+// ResearchSource is a search result that was read for the answer
+type ResearchSource struct {
+	URL      string             `json:"url"`
+	Title    string             `json:"title"`
+	Position int                `json:"position"`           // Rank in the search results
+	Passages []research.Passage `json:"passages"`           // Those that went into the prompt
+	Error    string             `json:"error,omitempty"`    // Why the page couldn't be read
+	Duration string             `json:"duration,omitempty"` // Loading and reading the page
+}
+
+// ResearchResult is the synthesized answer with the sources it draws on
+type ResearchResult struct {
+	Question string           `json:"question"`
+	Answer   string           `json:"answer"`
+	Claims   []research.Claim `json:"claims"`
+	Sources  []ResearchSource `json:"sources"`
+	Model    string           `json:"model,omitempty"` // Empty when no source could be read
+	Usage    vision.Usage     `json:"usage"`
+	Cached   bool             `json:"cached"` // Answered from the cache, spending no tokens
+	Duration string           `json:"duration"`
+}
+
+// Researcher answers questions by searching, reading the top results in parallel pages
+// of a throwaway session and asking a language model to answer from the passages that
+// bear on the question
+type Researcher struct {
+	manager *Manager
+	placer  CheckPlacer
+	model   vision.Model
+}
+
+// NewResearcher creates a researcher whose sessions go where placer says and whose
+// answers come from model, which is asked without an image
+func NewResearcher(m *Manager, placer CheckPlacer, model vision.Model) *Researcher {
+	return &Researcher{manager: m, placer: placer, model: model}
+}
+
+// validate checks the question and fills in the default source count
+func (req *ResearchRequest) validate() error {
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		return fmt.Errorf("%w: question must not be empty", search.ErrInvalidQuery)
+	}
+	if req.Sources < 0 || req.Sources > MaxResearchSources {
+		return fmt.Errorf("%w: sources must be between 0 and %d", search.ErrInvalidQuery, MaxResearchSources)
+	}
+	if req.Sources == 0 {
+		req.Sources = DefaultResearchSources
+	}
+	return nil
+}
+
+// Research searches for the question, reads the results and synthesizes an answer.
+// Sources that fail to load are reported and skipped; the model is only asked once at
+// least one passage was found.
+func (r *Researcher) Research(ctx context.Context, req ResearchRequest) (*ResearchResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	startTime := time.Now()
+
+	results, err := r.manager.Search(ctx, search.Query{
+		Text:     req.Question,
+		Count:    req.Sources,
+		Language: req.Language,
+		Region:   req.Region,
+		TenantID: req.TenantID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResearchResult{
+		Question: req.Question,
+		Claims:   []research.Claim{},
+		Sources:  make([]ResearchSource, len(results)),
+	}
+	sources := make([]research.Source, len(results))
+	if len(results) > 0 {
+		if err := r.readSources(ctx, req.TenantID, results, result.Sources, sources); err != nil {
+			return nil, err
+		}
+	}
+
+	passages := research.Rank(req.Question, sources, researchPassages, researchPerSource)
+	for _, passage := range passages {
+		source := &result.Sources[passage.Source]
+		source.Passages = append(source.Passages, passage)
+	}
+	if len(passages) == 0 {
+		result.Answer = "None of the search results had anything on the question."
+		result.Duration = time.Since(startTime).String()
+		return result, nil
+	}
+
+	// The sources' session is gone by now, so the answer counts for the tenant alone
+	model := r.manager.meterModel(r.model, ModelCall{Operation: ModelOperationResearch, TenantID: req.TenantID})
+	model = r.manager.cacheModel(model, req.TenantID, "", req.NoCache)
+	reply, err := askModel(ctx, model, vision.Query{
+		System: r.manager.prompts.render(req.TenantID, PromptResearch, map[string]interface{}{"Question": req.Question, "Language": req.Language}),
+		Prompt: research.Prompt(req.Question, sources, passages),
+	}, req.OnText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query model: %w", err)
+	}
+
+	answer := research.ParseAnswer(reply.Text, sources)
+	result.Answer = answer.Text
+	result.Claims = answer.Claims
+	result.Model = reply.Model
+	result.Usage = reply.Usage
+	result.Cached = reply.Cached
+	result.Duration = time.Since(startTime).String()
+	return result, nil
+}
+
+// readSources opens every result in its own page of one session at the same time and
+// splits each page's text into passages. Failures are recorded on the source.
+func (r *Researcher) readSources(ctx context.Context, tenantID string, results []search.Result, reported []ResearchSource, sources []research.Source) error {
+	port, err := r.placer.Acquire(nil)
+	if err != nil {
+		return fmt.Errorf("no browser for the research: %w", err)
+	}
+	defer r.placer.Release(port)
+
+	session, err := r.manager.CreateSessionWithOptions(ctx, tenantID, "research", "", port, "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	defer func() {
+		if err := r.manager.DestroySession(session.ID); err != nil {
+			slog.Warn("failed to destroy research session", "session_id", session.ID, "error", err)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i, found := range results {
+		reported[i] = ResearchSource{URL: found.URL, Title: found.Title, Position: found.Position, Passages: []research.Passage{}}
+		sources[i] = research.Source{URL: found.URL, Title: found.Title}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pageStart := time.Now()
+			title, text, err := r.readPage(ctx, session.ID, reported[i].URL)
+			reported[i].Duration = time.Since(pageStart).String()
+			if err != nil {
+				reported[i].Error = err.Error()
+				return
+			}
+			if title != "" {
+				sources[i].Title = title
+			}
+			for _, passage := range research.Split(text) {
+				sources[i].Passages = append(sources[i].Passages, research.Passage{Text: passage})
+			}
+		}(i)
+	}
+	wg.Wait()
+	return nil
+}
+
+// readPage loads a URL in a new page of the session and returns its title and text
+func (r *Researcher) readPage(ctx context.Context, sessionID string, url string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, researchPageTimeout)
+	defer cancel()
+
+	pageID, err := r.manager.Navigate(ctx, sessionID, url)
+	if err != nil {
+		return "", "", err
+	}
+	session, err := r.manager.GetSession(sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get session: %w", err)
+	}
+
+	value, err := session.Driver().Evaluate(ctx, pageID, fmt.Sprintf(researchTextJS, researchMaxText))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read page text: %w", err)
+	}
+	raw, _ := value.(string)
+	var page struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(raw), &page); err != nil {
+		return "", "", fmt.Errorf("failed to parse page text: %w", err)
+	}
+	return strings.TrimSpace(page.Title), page.Text, nil
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// fixedSearch returns the same results for every query
+type fixedSearch []search.Result
+
+func (f fixedSearch) Name() string { return "fixed" }
+
+func (f fixedSearch) Search(ctx context.Context, query search.Query) ([]search.Result, error) {
+	return f[:min(len(f), query.Count)], nil
+}
+
+// promptModel answers with reply and keeps the prompt it was asked
+type promptModel struct {
+	reply  string
+	mu     sync.Mutex
+	prompt string
+}
+
+func (p *promptModel) Name() string { return "prompt-model" }
+
+func (p *promptModel) Ask(ctx context.Context, query vision.Query) (*vision.Reply, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompt = query.Prompt
+	return &vision.Reply{Text: p.reply, Model: "prompt-model-1", Usage: vision.Usage{PromptTokens: 120, CompletionTokens: 30}}, nil
+}
+
+// TestResearch tests that research reads every result in parallel pages, skips those
+// that fail to load, and cites the sources the model named
+func TestResearch(t *testing.T) {
+	var targets atomic.Int32
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			URL        string `json:"url"`
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Target.createTarget":
+			return map[string]interface{}{"targetId": fmt.Sprintf("page-%d", targets.Add(1))}
+		case "Page.navigate":
+			if strings.Contains(p.URL, "down.example.com") {
+				return map[string]interface{}{"frameId": "frame", "errorText": "net::ERR_NAME_NOT_RESOLVED"}
+			}
+			return map[string]interface{}{"frameId": "frame"}
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			if strings.Contains(p.Expression, "innerText") && strings.Contains(p.Expression, "article, main") {
+				value = `{"title": "Eiffel Tower facts", "text": "Eiffel Tower\nThe Eiffel Tower is 330 metres tall and was completed in 1889 for the World's Fair.\nCookie settings"}`
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	manager.SetSearchBackend(fixedSearch{
+		{Position: 1, Title: "Eiffel Tower", URL: "https://facts.example.com/eiffel"},
+		{Position: 2, Title: "Down", URL: "https://down.example.com/"},
+	})
+	model := &promptModel{reply: `{"answer": "It is 330 metres tall.", "claims": [{"text": "The tower is 330 metres tall.", "sources": [1, 3]}]}`}
+	placer := &fakePlacer{port: 9222}
+	researcher := NewResearcher(manager, placer, model)
+
+	result, err := researcher.Research(context.Background(), ResearchRequest{Question: "How tall is the Eiffel Tower?"})
+	if err != nil {
+		t.Fatalf("Research failed: %v", err)
+	}
+
+	if result.Answer != "It is 330 metres tall." || result.Model != "prompt-model-1" || result.Usage.PromptTokens != 120 {
+		t.Errorf("unexpected answer: %+v", result)
+	}
+	if len(result.Claims) != 1 || len(result.Claims[0].Sources) != 1 || result.Claims[0].Sources[0] != "https://facts.example.com/eiffel" {
+		t.Errorf("expected the claim to cite the source that was read, got %+v", result.Claims)
+	}
+	if len(result.Sources) != 2 {
+		t.Fatalf("expected both results reported, got %+v", result.Sources)
+	}
+	if read := result.Sources[0]; read.Title != "Eiffel Tower" || len(read.Passages) != 1 || read.Error != "" {
+		t.Errorf("unexpected read source: %+v", read)
+	}
+	if failed := result.Sources[1]; failed.Error == "" || len(failed.Passages) != 0 {
+		t.Errorf("expected the unreachable source to carry an error, got %+v", failed)
+	}
+
+	model.mu.Lock()
+	if !strings.Contains(model.prompt, "[1] Eiffel Tower facts (https://facts.example.com/eiffel)") || strings.Contains(model.prompt, "down.example.com") {
+		t.Errorf("expected only the read source in the prompt, got %q", model.prompt)
+	}
+	model.mu.Unlock()
+
+	if len(manager.ListSessions()) != 0 || placer.released.Load() != 1 {
+		t.Error("expected the research session to be destroyed and its browser released")
+	}
+}
@@ -0,0 +1,300 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultMaxResourceBytes caps a resource download unless the caller asks for more
+	DefaultMaxResourceBytes = 10 << 20
+
+	// MaxResourceBytes is the hard ceiling for a single resource download
+	MaxResourceBytes = 50 << 20
+)
+
+// Resource sources for DownloadResource
+const (
+	ResourceSourceAuto  = "auto"  // Page cache first, then an in-page fetch
+	ResourceSourceCache = "cache" // Only what the page already loaded
+	ResourceSourceFetch = "fetch" // Re-request from inside the page (cookies included)
+)
+
+// ResourceInfo describes a subresource the page has loaded
+type ResourceInfo struct {
+	URL         string  `json:"url"`
+	Type        string  `json:"type"` // Document, Stylesheet, Image, Script, Font, XHR, Fetch, ...
+	MimeType    string  `json:"mime_type"`
+	ContentSize float64 `json:"content_size,omitempty"`
+	FrameID     string  `json:"frame_id"`
+
+	// MixedContent is set on a resource fetched over plain HTTP into a secure frame
+	MixedContent bool `json:"mixed_content,omitempty"`
+}
+
+// Resource is the body of a downloaded subresource
+type Resource struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Source   string `json:"source"`           // cache or fetch
+	Status   int    `json:"status,omitempty"` // HTTP status for fetched resources
+	Data     []byte `json:"-"`
+}
+
+// resourceFrameTree mirrors the Page.getResourceTree response
+type resourceFrameTree struct {
+	Frame struct {
+		ID       string `json:"id"`
+		URL      string `json:"url"`
+		MimeType string `json:"mimeType"`
+	} `json:"frame"`
+	Resources []struct {
+		URL         string  `json:"url"`
+		Type        string  `json:"type"`
+		MimeType    string  `json:"mimeType"`
+		ContentSize float64 `json:"contentSize"`
+	} `json:"resources"`
+	ChildFrames []resourceFrameTree `json:"childFrames"`
+}
+
+// resourceFetchJS re-requests a URL from the page so cookies and origin apply.
+// The size cap is enforced before the body is base64 encoded.
+const resourceFetchJS = `(async function(url, maxBytes) {
+  var resp = await fetch(url, { credentials: 'include' });
+  var length = Number(resp.headers.get('content-length') || 0);
+  if (length > maxBytes) return { error: 'too_large', size: length };
+  var bytes = new Uint8Array(await resp.arrayBuffer());
+  if (bytes.length > maxBytes) return { error: 'too_large', size: bytes.length };
+  var binary = '';
+  for (var i = 0; i < bytes.length; i += 0x8000) {
+    binary += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+  }
+  return { url: resp.url, status: resp.status, mime_type: resp.headers.get('content-type') || '', data: btoa(binary) };
+})(%s, %d)`
+
+// ListResources returns every subresource in the page's frame tree
+func (s *Session) ListResources(ctx context.Context, targetID string) ([]ResourceInfo, error) {
+	tree, err := s.getResourceTree(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]ResourceInfo, 0)
+	var walk func(node resourceFrameTree)
+	walk = func(node resourceFrameTree) {
+		resources = append(resources, ResourceInfo{
+			URL:      node.Frame.URL,
+			Type:     "Document",
+			MimeType: node.Frame.MimeType,
+			FrameID:  node.Frame.ID,
+		})
+		secure := strings.HasPrefix(node.Frame.URL, "https:")
+		for _, res := range node.Resources {
+			resources = append(resources, ResourceInfo{
+				URL:          res.URL,
+				Type:         res.Type,
+				MimeType:     res.MimeType,
+				ContentSize:  res.ContentSize,
+				FrameID:      node.Frame.ID,
+				MixedContent: secure && strings.HasPrefix(res.URL, "http:"),
+			})
+		}
+		for _, child := range node.ChildFrames {
+			walk(child)
+		}
+	}
+	walk(*tree)
+
+	return resources, nil
+}
+
+// DownloadResource returns the bytes of a subresource, refusing bodies over maxBytes
+func (s *Session) DownloadResource(ctx context.Context, targetID string, url string, source string, maxBytes int) (*Resource, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResourceBytes
+	}
+	if maxBytes > MaxResourceBytes {
+		maxBytes = MaxResourceBytes
+	}
+
+	switch source {
+	case ResourceSourceCache:
+		return s.resourceFromCache(ctx, targetID, url, maxBytes)
+	case ResourceSourceFetch:
+		return s.resourceFromFetch(ctx, targetID, url, maxBytes)
+	case "", ResourceSourceAuto:
+		resource, err := s.resourceFromCache(ctx, targetID, url, maxBytes)
+		if err == nil {
+			return resource, nil
+		}
+		// A cached body that is too big will not be smaller when re-fetched
+		if errors.Is(err, ErrResourceTooLarge) {
+			return nil, err
+		}
+		return s.resourceFromFetch(ctx, targetID, url, maxBytes)
+	default:
+		return nil, fmt.Errorf("unsupported resource source: %s", source)
+	}
+}
+
+// resourceFromCache reads a resource the page already loaded via Page.getResourceContent
+func (s *Session) resourceFromCache(ctx context.Context, targetID string, url string, maxBytes int) (*Resource, error) {
+	resources, err := s.ListResources(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	var match *ResourceInfo
+	for i := range resources {
+		if resources[i].URL == url {
+			match = &resources[i]
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, url)
+	}
+
+	if match.ContentSize > float64(maxBytes) {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrResourceTooLarge, int(match.ContentSize), maxBytes)
+	}
+
+	params := map[string]interface{}{
+		"frameId": match.FrameID,
+		"url":     url,
+	}
+
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.getResourceContent", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource content: %w", err)
+	}
+
+	var response struct {
+		Content       string `json:"content"`
+		Base64Encoded bool   `json:"base64Encoded"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse resource content: %w", err)
+	}
+
+	data := []byte(response.Content)
+	if response.Base64Encoded {
+		data, err = base64.StdEncoding.DecodeString(response.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode resource content: %w", err)
+		}
+	}
+
+	if len(data) > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrResourceTooLarge, len(data), maxBytes)
+	}
+
+	return &Resource{
+		URL:      url,
+		MimeType: match.MimeType,
+		Source:   ResourceSourceCache,
+		Data:     data,
+	}, nil
+}
+
+// resourceFromFetch re-requests a resource from inside the page context
+func (s *Session) resourceFromFetch(ctx context.Context, targetID string, url string, maxBytes int) (*Resource, error) {
+	urlJSON, err := json.Marshal(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal url: %w", err)
+	}
+
+	value, err := s.evaluatePromise(ctx, targetID, fmt.Sprintf(resourceFetchJS, urlJSON, maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch resource: %w", err)
+	}
+
+	var response struct {
+		URL      string `json:"url"`
+		Status   int    `json:"status"`
+		MimeType string `json:"mime_type"`
+		Data     string `json:"data"`
+		Error    string `json:"error"`
+		Size     int    `json:"size"`
+	}
+	if err := json.Unmarshal(value, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse fetch result: %w", err)
+	}
+
+	if response.Error == "too_large" {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrResourceTooLarge, response.Size, maxBytes)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(response.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode fetched resource: %w", err)
+	}
+
+	return &Resource{
+		URL:      response.URL,
+		MimeType: response.MimeType,
+		Source:   ResourceSourceFetch,
+		Status:   response.Status,
+		Data:     data,
+	}, nil
+}
+
+// getResourceTree fetches the page's frame and resource tree
+func (s *Session) getResourceTree(ctx context.Context, targetID string) (*resourceFrameTree, error) {
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.getResourceTree", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource tree: %w", err)
+	}
+
+	var response struct {
+		FrameTree resourceFrameTree `json:"frameTree"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse resource tree: %w", err)
+	}
+
+	return &response.FrameTree, nil
+}
+
+// evaluatePromise runs an expression that returns a promise and yields its JSON value
+func (s *Session) evaluatePromise(ctx context.Context, targetID string, code string) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"expression":    code,
+		"returnByValue": true,
+		"awaitPromise":  true,
+	}
+
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.evaluate", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Result struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails,omitempty"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse evaluation result: %w", err)
+	}
+
+	if response.ExceptionDetails != nil {
+		message := response.ExceptionDetails.Exception.Description
+		if message == "" {
+			message = response.ExceptionDetails.Text
+		}
+		return nil, fmt.Errorf("javascript execution error: %s", message)
+	}
+
+	return response.Result.Value, nil
+}
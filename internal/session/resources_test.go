@@ -0,0 +1,102 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestDownloadResource tests that resources come from the page cache when the page
+// loaded them and from an in-page fetch otherwise, and that size caps hold on both
+func TestDownloadResource(t *testing.T) {
+	var fetched []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+			URL        string `json:"url"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch {
+		case method == "Page.getResourceTree":
+			return map[string]interface{}{"frameTree": map[string]interface{}{
+				"frame": map[string]interface{}{"id": "frame-main", "url": "https://example.com/", "mimeType": "text/html"},
+				"resources": []map[string]interface{}{
+					{"url": "https://example.com/logo.png", "type": "Image", "mimeType": "image/png", "contentSize": 4},
+					{"url": "http://cdn.example.com/tracker.js", "type": "Script", "mimeType": "text/javascript", "contentSize": 10},
+					{"url": "https://example.com/intro.mp4", "type": "Media", "mimeType": "video/mp4", "contentSize": 20 << 20},
+				},
+				"childFrames": []map[string]interface{}{{
+					"frame": map[string]interface{}{"id": "frame-ad", "url": "https://ads.example.com/", "mimeType": "text/html"},
+				}},
+			}}
+		case method == "Page.getResourceContent":
+			return map[string]interface{}{"content": base64.StdEncoding.EncodeToString([]byte("PNG!")), "base64Encoded": true}
+		case method == "Runtime.evaluate" && strings.Contains(p.Expression, "fetch(url"):
+			fetched = append(fetched, p.Expression[strings.LastIndex(p.Expression, "})("):])
+			if strings.Contains(p.Expression, "huge.json") {
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": map[string]interface{}{"error": "too_large", "size": 99 << 20}}}
+			}
+			value := map[string]interface{}{"url": "https://example.com/api/cart", "status": 200, "mime_type": "application/json", "data": base64.StdEncoding.EncodeToString([]byte(`{"items":2}`))}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": value}}
+		}
+		return nil
+	})
+	sess, pageID := openTestPage(t, manager, nil, "https://example.com")
+	ctx := context.Background()
+
+	resources, err := manager.ListResources(ctx, sess.ID, pageID)
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	if len(resources) != 5 || resources[4].FrameID != "frame-ad" || resources[4].Type != "Document" {
+		t.Fatalf("expected the main frame, its 3 resources and the child frame, got %+v", resources)
+	}
+	if !resources[2].MixedContent || resources[1].MixedContent {
+		t.Errorf("expected only the plain HTTP script marked mixed content, got %+v", resources)
+	}
+
+	// Loaded resources come from the cache without a fetch
+	resource, err := manager.DownloadResource(ctx, sess.ID, pageID, "https://example.com/logo.png", ResourceSourceAuto, 0)
+	if err != nil {
+		t.Fatalf("DownloadResource failed: %v", err)
+	}
+	if string(resource.Data) != "PNG!" || resource.Source != ResourceSourceCache || resource.MimeType != "image/png" || len(fetched) != 0 {
+		t.Errorf("expected the logo from the cache, got %+v after %d fetches", resource, len(fetched))
+	}
+
+	// Others are fetched from inside the page
+	resource, err = manager.DownloadResource(ctx, sess.ID, pageID, "https://example.com/api/cart", ResourceSourceAuto, 0)
+	if err != nil {
+		t.Fatalf("DownloadResource failed: %v", err)
+	}
+	if string(resource.Data) != `{"items":2}` || resource.Source != ResourceSourceFetch || resource.Status != 200 {
+		t.Errorf("expected the cart fetched, got %+v", resource)
+	}
+	if want := fmt.Sprintf(", %d)", DefaultMaxResourceBytes); !strings.HasSuffix(fetched[0], want) {
+		t.Errorf("expected the default cap passed to the fetch, got %q", fetched[0])
+	}
+
+	// A cached body over the cap isn't fetched again, and neither kind gets past it
+	fetches := len(fetched)
+	if _, err := manager.DownloadResource(ctx, sess.ID, pageID, "https://example.com/intro.mp4", ResourceSourceAuto, 0); !errors.Is(err, ErrResourceTooLarge) || len(fetched) != fetches {
+		t.Errorf("expected the video refused from the cache alone, got %v after %d fetches", err, len(fetched)-fetches)
+	}
+	if _, err := manager.DownloadResource(ctx, sess.ID, pageID, "https://example.com/huge.json", ResourceSourceFetch, MaxResourceBytes*2); !errors.Is(err, ErrResourceTooLarge) {
+		t.Errorf("expected the fetched body refused, got %v", err)
+	}
+	if want := fmt.Sprintf(", %d)", MaxResourceBytes); !strings.HasSuffix(fetched[len(fetched)-1], want) {
+		t.Errorf("expected the cap held to the ceiling, got %q", fetched[len(fetched)-1])
+	}
+
+	if _, err := manager.DownloadResource(ctx, sess.ID, pageID, "https://example.com/api/cart", ResourceSourceCache, 0); !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("expected ErrResourceNotFound from the cache alone, got %v", err)
+	}
+	if _, err := manager.DownloadResource(ctx, sess.ID, pageID, "https://example.com/logo.png", "disk", 0); err == nil {
+		t.Error("expected an unknown source refused")
+	}
+}
@@ -0,0 +1,259 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// routeBinding is the page function route changes are reported through
+const routeBinding = "__bqaRoute"
+
+// RouteChange is the data of a route_changed event
+type RouteChange struct {
+	URL         string `json:"url"`
+	PreviousURL string `json:"previous_url,omitempty"`
+	// Kind is "push" or "replace" for the History API, "pop" for back and forward,
+	// "hash" for a fragment change and "navigate" for a full document load
+	Kind string `json:"kind"`
+}
+
+// RouteInfo is the logical location of a page, kept current as a single-page app
+// changes routes without loading a new document
+type RouteInfo struct {
+	URL       string    `json:"url"`
+	Kind      string    `json:"kind"`    // How the page got to URL, as in RouteChange
+	Changes   int       `json:"changes"` // Route changes seen since tracking started
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// pageRoute is the route tracking state of one page
+type pageRoute struct {
+	info        RouteInfo
+	notify      func(RouteChange)
+	unsubscribe func()
+}
+
+// routeJS wraps the History API and listens for popstate and hashchange so route
+// changes are reported through the binding. It runs on the current document and, as
+// an init script, on every later one. It evaluates to the current URL.
+const routeJS = `(function() {
+  if (window.__bqaRoutes) return location.href;
+  window.__bqaRoutes = true;
+
+  var last = location.href;
+  function report(kind) {
+    var url = location.href;
+    if (url === last) return;
+    var previous = last;
+    last = url;
+    if (typeof window.` + routeBinding + ` === 'function') {
+      window.` + routeBinding + `(JSON.stringify({url: url, previous_url: previous, kind: kind}));
+    }
+  }
+
+  ['pushState', 'replaceState'].forEach(function(name) {
+    var original = history[name];
+    history[name] = function() {
+      var result = original.apply(this, arguments);
+      report(name === 'pushState' ? 'push' : 'replace');
+      return result;
+    };
+  });
+  addEventListener('popstate', function() { report('pop'); });
+  addEventListener('hashchange', function() { report('hash'); });
+  return location.href;
+})()`
+
+// trackRoutes starts following a page's logical URL. notify runs on the CDP reader
+// goroutine and must not block. Tracking a page twice is a no-op.
+func (s *Session) trackRoutes(ctx context.Context, targetID string, notify func(RouteChange)) error {
+	// Claim the page first so concurrent callers don't both set it up; the browser
+	// is only talked to without the lock held
+	route := &pageRoute{notify: notify, unsubscribe: func() {}}
+	s.routeMu.Lock()
+	if _, exists := s.routes[targetID]; exists {
+		s.routeMu.Unlock()
+		return nil
+	}
+	if s.routes == nil {
+		s.routes = make(map[string]*pageRoute)
+	}
+	s.routes[targetID] = route
+	s.routeMu.Unlock()
+
+	unsubscribe, url, err := s.installRouteTracking(ctx, targetID)
+
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+
+	if err != nil {
+		if s.routes[targetID] == route {
+			delete(s.routes, targetID)
+		}
+		return err
+	}
+
+	// The page went away while tracking was being set up
+	if s.routes[targetID] != route {
+		unsubscribe()
+		return nil
+	}
+	route.unsubscribe = unsubscribe
+	if route.info.URL == "" {
+		route.info = RouteInfo{URL: url, Kind: "navigate", ChangedAt: time.Now()}
+	}
+	return nil
+}
+
+// installRouteTracking adds the binding, listeners and script, returning the function
+// that removes the listeners and the page's current URL
+func (s *Session) installRouteTracking(ctx context.Context, targetID string) (func(), string, error) {
+	cdpSessionID, err := s.CDPClient.AttachToTarget(ctx, targetID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to attach for route tracking: %w", err)
+	}
+	for _, method := range []string{"Runtime.enable", "Page.enable"} {
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, method, nil); err != nil {
+			return nil, "", fmt.Errorf("failed to send %s: %w", method, err)
+		}
+	}
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.addBinding", map[string]interface{}{"name": routeBinding}); err != nil {
+		return nil, "", fmt.Errorf("failed to add route binding: %w", err)
+	}
+
+	unsubscribeBinding := s.CDPClient.OnEvent("Runtime.bindingCalled", cdpSessionID, func(event *cdp.Event) {
+		s.dispatchRouteEvent(targetID, event)
+	})
+	unsubscribeNavigated := s.CDPClient.OnEvent("Page.frameNavigated", cdpSessionID, func(event *cdp.Event) {
+		s.dispatchFrameNavigated(targetID, event)
+	})
+	unsubscribe := func() {
+		unsubscribeBinding()
+		unsubscribeNavigated()
+	}
+
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.addScriptToEvaluateOnNewDocument", map[string]interface{}{"source": routeJS}); err != nil {
+		unsubscribe()
+		return nil, "", fmt.Errorf("failed to add route script: %w", err)
+	}
+	result, err := s.ExecuteJavascript(ctx, targetID, routeJS)
+	if err != nil {
+		unsubscribe()
+		return nil, "", fmt.Errorf("failed to start route tracking: %w", err)
+	}
+
+	url, _ := result.(string)
+	return unsubscribe, url, nil
+}
+
+// dispatchRouteEvent applies a History API or hash change reported by the page
+func (s *Session) dispatchRouteEvent(targetID string, event *cdp.Event) {
+	var params struct {
+		Name    string `json:"name"`
+		Payload string `json:"payload"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.Name != routeBinding {
+		return
+	}
+
+	var change RouteChange
+	if err := json.Unmarshal([]byte(params.Payload), &change); err != nil || change.URL == "" {
+		slog.Debug("failed to parse route change", "page_id", targetID, "error", err)
+		return
+	}
+	s.applyRouteChange(targetID, change)
+}
+
+// dispatchFrameNavigated applies a full document load of the page's main frame
+func (s *Session) dispatchFrameNavigated(targetID string, event *cdp.Event) {
+	var params struct {
+		Frame struct {
+			ParentID    string `json:"parentId"`
+			URL         string `json:"url"`
+			URLFragment string `json:"urlFragment"`
+		} `json:"frame"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.Frame.ParentID != "" {
+		return
+	}
+	// The old document's objects went with it
+	s.forgetHandles(targetID)
+	s.applyRouteChange(targetID, RouteChange{URL: params.Frame.URL + params.Frame.URLFragment, Kind: "navigate"})
+}
+
+// applyRouteChange records a page's new route and reports it
+func (s *Session) applyRouteChange(targetID string, change RouteChange) {
+	s.routeMu.Lock()
+	route := s.routes[targetID]
+	if route == nil {
+		s.routeMu.Unlock()
+		return
+	}
+	if change.PreviousURL == "" {
+		change.PreviousURL = route.info.URL
+	}
+	route.info = RouteInfo{URL: change.URL, Kind: change.Kind, Changes: route.info.Changes + 1, ChangedAt: time.Now()}
+	notify := route.notify
+	s.routeMu.Unlock()
+
+	notify(change)
+}
+
+// Route returns the logical location of a page, or nil when it isn't tracked
+func (s *Session) Route(targetID string) *RouteInfo {
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+
+	route, exists := s.routes[targetID]
+	if !exists || route.info.URL == "" {
+		return nil
+	}
+	info := route.info
+	return &info
+}
+
+// stopRoutes stops tracking a page that is gone
+func (s *Session) stopRoutes(targetID string) {
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+
+	if route, exists := s.routes[targetID]; exists {
+		delete(s.routes, targetID)
+		route.unsubscribe()
+	}
+}
+
+// stopAllRoutes stops tracking every page of the session (used when its pages are torn down)
+func (s *Session) stopAllRoutes() {
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+
+	for targetID, route := range s.routes {
+		delete(s.routes, targetID)
+		route.unsubscribe()
+	}
+}
+
+// trackRoutes follows a page's logical URL, publishing route_changed events. It is
+// best effort: a page that can't be instrumented still works, its route is just unknown.
+func (m *Manager) trackRoutes(ctx context.Context, session *Session, pageID string) {
+	// Setup over a dropped connection would only time out; restoreConnection tracks the
+	// pages again once it is back
+	if session.CDPClient == nil || !session.CDPClient.IsConnected() {
+		return
+	}
+
+	sessionID := session.ID
+	err := session.trackRoutes(ctx, pageID, func(change RouteChange) {
+		m.publishEvent(sessionID, pageID, events.TypeRouteChanged, change)
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "failed to track page routes", "session_id", sessionID, "page_id", pageID, "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestRouteTracking tests following a page's logical URL through route changes and loads
+func TestRouteTracking(t *testing.T) {
+	var changes []RouteChange
+	unsubscribed := 0
+	session := &Session{
+		routes: map[string]*pageRoute{
+			"PAGE1": {
+				info:        RouteInfo{URL: "https://app.example.com/", Kind: "navigate"},
+				notify:      func(change RouteChange) { changes = append(changes, change) },
+				unsubscribe: func() { unsubscribed++ },
+			},
+		},
+	}
+
+	binding := func(name string, payload string) {
+		params, _ := json.Marshal(map[string]string{"name": name, "payload": payload})
+		session.dispatchRouteEvent("PAGE1", &cdp.Event{Method: "Runtime.bindingCalled", Params: params})
+	}
+	navigated := func(params string) {
+		session.dispatchFrameNavigated("PAGE1", &cdp.Event{Method: "Page.frameNavigated", Params: []byte(params)})
+	}
+
+	binding(routeBinding, `{"url": "https://app.example.com/orders", "previous_url": "https://app.example.com/", "kind": "push"}`)
+	binding(routeBinding, `{"url": "https://app.example.com/orders#42", "previous_url": "https://app.example.com/orders", "kind": "hash"}`)
+	binding(watchBinding, `{"url": "https://app.example.com/ignored", "kind": "push"}`)
+	binding(routeBinding, `not json`)
+	// Iframe loads don't move the page
+	navigated(`{"frame": {"id": "F2", "parentId": "PAGE1", "url": "https://ads.example.net/"}}`)
+	navigated(`{"frame": {"id": "PAGE1", "url": "https://login.example.com/", "urlFragment": "#start"}}`)
+
+	if len(changes) != 3 {
+		t.Fatalf("expected three route changes, got %+v", changes)
+	}
+	if last := changes[2]; last.Kind != "navigate" || last.URL != "https://login.example.com/#start" || last.PreviousURL != "https://app.example.com/orders#42" {
+		t.Errorf("unexpected full navigation: %+v", last)
+	}
+
+	route := session.Route("PAGE1")
+	if route == nil || route.URL != "https://login.example.com/#start" || route.Changes != 3 {
+		t.Errorf("unexpected route: %+v", route)
+	}
+
+	// A closed page stops reporting
+	session.stopRoutes("PAGE1")
+	binding(routeBinding, `{"url": "https://login.example.com/done", "kind": "push"}`)
+	if len(changes) != 3 || unsubscribed != 1 || session.Route("PAGE1") != nil {
+		t.Errorf("expected tracking to stop, got %d changes and %d unsubscribes", len(changes), unsubscribed)
+	}
+}
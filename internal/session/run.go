@@ -0,0 +1,467 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/visual"
+)
+
+const (
+	// MaxRunSteps bounds how many steps one run may hold
+	MaxRunSteps = 100
+
+	// DefaultStepTimeout is how long a step may take when it sets no timeout
+	DefaultStepTimeout = 30 * time.Second
+
+	// MaxRunTimeout bounds a whole run, which holds its request open
+	MaxRunTimeout = 10 * time.Minute
+)
+
+// Actions a run step can take
+const (
+	StepNavigate = "navigate" // Load url into the current page, or a new one
+	StepWait     = "wait"     // Wait for a selector, URL or function, as POST .../wait does
+	StepClick    = "click"    // Click the middle of an element, or a point
+	StepType     = "type"     // Focus an element and type text into it
+	StepExtract  = "extract"  // Evaluate a script, or read an element's text, into the run's output
+	StepAssert   = "assert"   // Fail the run unless an Assertion passes
+	StepCompare  = "compare"  // Fail the run when the page differs from a visual baseline
+)
+
+// Step statuses in a RunResult
+const (
+	StepOK      = "ok"
+	StepFailed  = "failed"
+	StepSkipped = "skipped" // Not reached because an earlier step failed
+)
+
+var runActions = []string{StepNavigate, StepWait, StepClick, StepType, StepExtract, StepAssert, StepCompare}
+
+// RunStep is one action of a run. Which fields apply depends on the action.
+type RunStep struct {
+	Action string `json:"action"`
+
+	URL      string  `json:"url,omitempty"`      // navigate: where to go; wait, assert: URL pattern
+	NewPage  bool    `json:"new_page,omitempty"` // navigate: open a new page even if one is current
+	Selector string  `json:"selector,omitempty"` // wait, click, type, extract, assert: element selector
+	State    string  `json:"state,omitempty"`    // wait: selector state
+	Function string  `json:"function,omitempty"` // wait, assert: JavaScript expression that must be truthy
+	X        float64 `json:"x,omitempty"`        // click: viewport point when no selector is given
+	Y        float64 `json:"y,omitempty"`
+	Text     string  `json:"text,omitempty"`   // type: what to type; assert: text the element or page must contain
+	Clear    bool    `json:"clear,omitempty"`  // type: empty the field first
+	Submit   bool    `json:"submit,omitempty"` // type: press Enter afterwards
+	Script   string  `json:"script,omitempty"` // extract: JavaScript expression whose result is kept
+	As       string  `json:"as,omitempty"`     // extract: output name (default "step_{index}")
+
+	// assert: the rest of an Assertion
+	Visible     bool   `json:"visible,omitempty"`
+	TextMatches string `json:"text_matches,omitempty"`
+	Cookie      string `json:"cookie,omitempty"`
+	Status      int    `json:"status,omitempty"`
+
+	// compare: the baseline, and how much of the page may change
+	Baseline       string          `json:"baseline,omitempty"`
+	MaxDiffPercent float64         `json:"max_diff_percent,omitempty"`
+	Ignore         []visual.Region `json:"ignore,omitempty"`
+
+	SelectorEngine // How Selector is read
+
+	// click, type: when Selector finds nothing, heal it from this and carry on
+	Fingerprint *ElementFingerprint `json:"fingerprint,omitempty"`
+
+	TimeoutMS int  `json:"timeout_ms,omitempty"` // Limit for this step (default 30s)
+	Optional  bool `json:"optional,omitempty"`   // A failure is recorded but doesn't stop the run
+}
+
+// StepResult is how one step went
+type StepResult struct {
+	Index    int         `json:"index"`
+	Action   string      `json:"action"`
+	Status   string      `json:"status"`
+	PageID   string      `json:"page_id,omitempty"` // Page the step ran on
+	Value    interface{} `json:"value,omitempty"`   // What an extract step read, or an assert or compare step's result
+	Error    string      `json:"error,omitempty"`
+	Duration string      `json:"duration,omitempty"`
+	Healed   *HealResult `json:"healed,omitempty"` // How the step's selector was healed, when it needed to be
+
+	// Element is the fingerprint of what a click or type step acted on
+	Element *ElementFingerprint `json:"element,omitempty"`
+}
+
+// RunResult is the outcome of a run. A failed run keeps what its earlier steps did;
+// nothing is rolled back.
+type RunResult struct {
+	Success    bool                   `json:"success"`
+	PageID     string                 `json:"page_id,omitempty"`     // Current page when the run ended
+	FailedStep *int                   `json:"failed_step,omitempty"` // Index of the step that stopped the run
+	Steps      []StepResult           `json:"steps"`
+	Extracted  map[string]interface{} `json:"extracted"` // Extract step outputs by name
+	Duration   string                 `json:"duration"`
+}
+
+// typeFocusJS focuses the element text is typed into, optionally emptying it, and
+// reports whether it was found
+const typeFocusJS = `(function(clear) {
+  var el = %s;
+  if (!el) return false;
+  el.focus();
+  if (clear && 'value' in el) {
+    el.value = '';
+    el.dispatchEvent(new Event('input', {bubbles: true}));
+  }
+  return true;
+})(%t)`
+
+// elementTextJS reads an element's text, or null when nothing matches
+const elementTextJS = `(function() {
+  var el = %s;
+  return el ? el.innerText : null;
+})()`
+
+// validateRun checks every step before any of them runs
+func validateRun(steps []RunStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidRun)
+	}
+	if len(steps) > MaxRunSteps {
+		return fmt.Errorf("%w: at most %d steps", ErrInvalidRun, MaxRunSteps)
+	}
+
+	for i, step := range steps {
+		var missing string
+		switch step.Action {
+		case StepNavigate:
+			if step.URL == "" {
+				missing = "url"
+			}
+		case StepWait:
+			// Checked in full by validateWait when the step runs
+			if step.Selector == "" && step.URL == "" && step.Function == "" {
+				missing = "selector, url or function"
+			}
+		case StepType:
+			if step.Selector == "" {
+				missing = "selector"
+			}
+		case StepExtract:
+			if step.Script == "" && step.Selector == "" {
+				missing = "script or selector"
+			}
+		case StepAssert:
+			assertion := step.assertion()
+			if err := assertion.validate(); err != nil {
+				return fmt.Errorf("%w: step %d: %v", ErrInvalidRun, i, err)
+			}
+		case StepCompare:
+			compare := step.compare()
+			if err := compare.validate(); err != nil {
+				return fmt.Errorf("%w: step %d: %v", ErrInvalidRun, i, err)
+			}
+		case StepClick:
+		default:
+			return fmt.Errorf("%w: step %d: unknown action %q, must be one of %s", ErrInvalidRun, i, step.Action, strings.Join(runActions, ", "))
+		}
+		if missing != "" {
+			return fmt.Errorf("%w: step %d: %s needs %s", ErrInvalidRun, i, step.Action, missing)
+		}
+		if err := step.SelectorEngine.validate(); err != nil {
+			return fmt.Errorf("%w: step %d: %v", ErrInvalidRun, i, err)
+		}
+		if step.Fingerprint != nil {
+			if step.Action != StepClick && step.Action != StepType {
+				return fmt.Errorf("%w: step %d: only click and type steps heal from a fingerprint", ErrInvalidRun, i)
+			}
+			if err := step.Fingerprint.validate(); err != nil {
+				return fmt.Errorf("%w: step %d: %v", ErrInvalidRun, i, err)
+			}
+		}
+		if step.TimeoutMS < 0 || time.Duration(step.TimeoutMS)*time.Millisecond > MaxRunTimeout {
+			return fmt.Errorf("%w: step %d: timeout_ms must be between 0 and %d", ErrInvalidRun, i, MaxRunTimeout.Milliseconds())
+		}
+	}
+	return nil
+}
+
+// Run executes steps in order on a session, starting on pageID (which may be empty for
+// a run that begins by navigating). Each step has its own timeout, and the first failing
+// step that isn't optional stops the run. Errors are only returned for runs that couldn't
+// start; step failures are reported in the result.
+func (m *Manager) Run(ctx context.Context, sessionID string, pageID string, steps []RunStep) (*RunResult, error) {
+	if err := validateRun(steps); err != nil {
+		return nil, err
+	}
+
+	// Agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if pageID != "" && !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, MaxRunTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	result := &RunResult{
+		Success:   true,
+		Steps:     make([]StepResult, len(steps)),
+		Extracted: make(map[string]interface{}),
+	}
+	for i, step := range steps {
+		result.Steps[i] = StepResult{Index: i, Action: step.Action, Status: StepSkipped}
+	}
+
+	for i, step := range steps {
+		stepStart := time.Now()
+		var value interface{}
+		var element *ElementFingerprint
+		pageID, value, element, err = m.runStep(ctx, session, pageID, step)
+		var healed *HealResult
+		if errors.Is(err, ErrElementNotFound) && step.Fingerprint != nil && session.bidi == nil {
+			healed, element, err = m.healStep(ctx, session, pageID, step, err)
+		}
+
+		// Whatever the step did, cached analyses of its page may be stale now
+		if pageID != "" {
+			session.InvalidatePageAnalysis(pageID)
+		}
+
+		outcome := &result.Steps[i]
+		outcome.PageID = pageID
+		outcome.Duration = time.Since(stepStart).String()
+		outcome.Healed = healed
+		outcome.Element = element
+		if value != nil {
+			outcome.Value = value
+		}
+		if err != nil {
+			outcome.Status = StepFailed
+			outcome.Error = err.Error()
+			if step.Optional {
+				continue
+			}
+			result.Success = false
+			result.FailedStep = &i
+			break
+		}
+
+		outcome.Status = StepOK
+		if step.Action == StepExtract {
+			name := step.As
+			if name == "" {
+				name = fmt.Sprintf("step_%d", i)
+			}
+			result.Extracted[name] = value
+		}
+	}
+
+	result.PageID = pageID
+	result.Duration = time.Since(startTime).String()
+	session.UpdateActivity()
+	return result, nil
+}
+
+// runStep runs one step within its timeout, returning the page that is current after it,
+// for extract steps the value read and for click and type steps the element acted on
+func (m *Manager) runStep(ctx context.Context, session *Session, pageID string, step RunStep) (string, interface{}, *ElementFingerprint, error) {
+	timeout := DefaultStepTimeout
+	if step.TimeoutMS > 0 {
+		timeout = time.Duration(step.TimeoutMS) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if step.Action == StepNavigate {
+		pageID, err := m.navigateStep(ctx, session, pageID, step)
+		return pageID, nil, nil, err
+	}
+	if pageID == "" {
+		return "", nil, nil, errors.New("no current page; start with a navigate step or give a page_id")
+	}
+
+	switch step.Action {
+	case StepWait:
+		wait, err := m.Wait(ctx, session.ID, pageID, WaitRequest{
+			Selector:       step.Selector,
+			SelectorEngine: step.SelectorEngine,
+			State:          step.State,
+			URL:            step.URL,
+			Function:       step.Function,
+			Timeout:        timeout,
+		})
+		if err != nil {
+			return pageID, nil, nil, err
+		}
+		if !wait.Satisfied {
+			return pageID, nil, nil, fmt.Errorf("%s condition not met within %s", wait.Condition, timeout)
+		}
+		return pageID, nil, nil, nil
+
+	case StepClick:
+		element, err := m.clickStep(ctx, session, pageID, step)
+		return pageID, nil, element, err
+
+	case StepType:
+		element, err := m.typeStep(ctx, session, pageID, step)
+		return pageID, nil, element, err
+
+	case StepExtract:
+		script := step.Script
+		if script == "" {
+			script = fmt.Sprintf(elementTextJS, step.SelectorEngine.first(step.Selector))
+		}
+		value, err := session.ExecuteJavascript(ctx, pageID, script)
+		return pageID, value, nil, err
+
+	case StepCompare:
+		comparison, err := m.compareScreenshot(ctx, session, pageID, step.compare())
+		if err != nil {
+			return pageID, nil, nil, err
+		}
+		if !comparison.Passed {
+			return pageID, comparison, nil, fmt.Errorf("%.2f%% of the page differs from baseline %s, more than %.2f%%", comparison.ChangePercent, step.Baseline, step.MaxDiffPercent)
+		}
+		return pageID, comparison, nil, nil
+
+	default: // StepAssert
+		result := session.assert(ctx, pageID, step.assertion())
+		if !result.Passed {
+			return pageID, result, nil, fmt.Errorf("assertion failed: %s", result.Failures())
+		}
+		return pageID, result, nil, nil
+	}
+}
+
+// healStep heals the selector of a step that found nothing from the step's fingerprint,
+// and runs the step again with the healed selector. notFound is returned, with the
+// reason, when nothing heals it.
+func (m *Manager) healStep(ctx context.Context, session *Session, pageID string, step RunStep, notFound error) (*HealResult, *ElementFingerprint, error) {
+	healed, err := session.heal(ctx, pageID, HealRequest{
+		Selector:       step.Selector,
+		SelectorEngine: step.SelectorEngine,
+		Fingerprint:    step.Fingerprint,
+	}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !healed.Healed {
+		return healed, nil, fmt.Errorf("%w; not healed: %s", notFound, healed.Reason)
+	}
+
+	step.Selector, step.SelectorEngine = healed.Selector, SelectorEngine{}
+	_, _, element, err := m.runStep(ctx, session, pageID, step)
+	return healed, element, err
+}
+
+// compare returns the VisualCompare a compare step makes
+func (step RunStep) compare() VisualCompare {
+	return VisualCompare{Baseline: step.Baseline, MaxDiffPercent: step.MaxDiffPercent, Ignore: step.Ignore}
+}
+
+// assertion returns the Assertion an assert step makes
+func (step RunStep) assertion() Assertion {
+	return Assertion{
+		Selector:    step.Selector,
+		Visible:     step.Visible,
+		Text:        step.Text,
+		TextMatches: step.TextMatches,
+		URL:         step.URL,
+		Cookie:      step.Cookie,
+		Status:      step.Status,
+		Function:    step.Function,
+
+		SelectorEngine: step.SelectorEngine,
+	}
+}
+
+// navigateStep loads a URL into the current page, or opens a page for it when there is
+// none or the step asks for a new one
+func (m *Manager) navigateStep(ctx context.Context, session *Session, pageID string, step RunStep) (string, error) {
+	if pageID == "" || step.NewPage {
+		newPageID, err := m.Navigate(ctx, session.ID, step.URL)
+		if err != nil {
+			return pageID, err
+		}
+		return newPageID, nil
+	}
+
+	if err := session.loadPage(ctx, pageID, step.URL); err != nil {
+		return pageID, err
+	}
+	if err := navigationFailed(session.LastNavigation(pageID)); err != nil {
+		return pageID, err
+	}
+	if err := session.WaitForReady(ctx, pageID, session.navigationTimeout()); err != nil {
+		return pageID, err
+	}
+	return pageID, nil
+}
+
+// clickStep clicks the middle of the step's element, or its point when it names none,
+// returning the fingerprint of what was clicked
+func (m *Manager) clickStep(ctx context.Context, session *Session, pageID string, step RunStep) (*ElementFingerprint, error) {
+	req := ClickRequest{X: step.X, Y: step.Y}
+	if step.Selector != "" {
+		layout, err := session.GetElementLayout(ctx, pageID, step.Selector, step.SelectorEngine)
+		if err != nil {
+			return nil, err
+		}
+		if !layout.Clickable || layout.ClickPoint == nil {
+			return nil, fmt.Errorf("%w: %s is not clickable (visible: %t, in viewport: %t, occluded: %t)",
+				ErrInvalidClick, step.SelectorEngine.describe(step.Selector), layout.Visible, layout.InViewport, layout.Occluded)
+		}
+		req.X, req.Y = layout.ClickPoint.X, layout.ClickPoint.Y
+		req.Selector = step.SelectorEngine.describe(step.Selector)
+	}
+
+	click, err := m.Click(ctx, session.ID, pageID, req)
+	if err != nil {
+		return nil, err
+	}
+	return click.Element, nil
+}
+
+// typeStep focuses the step's element and inserts its text, as a paste would, returning
+// the fingerprint of the element typed into
+func (m *Manager) typeStep(ctx context.Context, session *Session, pageID string, step RunStep) (*ElementFingerprint, error) {
+	found, err := session.ExecuteJavascript(ctx, pageID, fmt.Sprintf(typeFocusJS, step.SelectorEngine.first(step.Selector), step.Clear))
+	if err != nil {
+		return nil, err
+	}
+	if found != true {
+		return nil, fmt.Errorf("%w: %s", ErrElementNotFound, step.SelectorEngine.describe(step.Selector))
+	}
+
+	// The focused element is what the text goes into; the typed text itself isn't recorded
+	element := session.fingerprintExpression(ctx, pageID, "document.activeElement")
+	m.recordInteraction(session.ID, pageID, "type", step.SelectorEngine.describe(step.Selector), element)
+
+	if step.Text != "" {
+		if _, err := session.CDPClient.SendCommandToTarget(ctx, pageID, "Input.insertText", map[string]interface{}{"text": step.Text}); err != nil {
+			return element, fmt.Errorf("failed to type text: %w", err)
+		}
+	}
+	if step.Submit {
+		for _, event := range []string{"keyDown", "keyUp"} {
+			enter := KeyInput{Type: event, Key: "Enter", Code: "Enter", WindowsVirtualKeyCode: 13}
+			if event == "keyDown" {
+				enter.Text = "\r"
+			}
+			if err := session.DispatchKeyEvent(ctx, pageID, enter); err != nil {
+				return element, err
+			}
+		}
+	}
+	return element, nil
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestRunSteps tests that a run carries its page from step to step, keeps extracted
+// values, and stops at the first failing step that isn't optional
+func TestRunSteps(t *testing.T) {
+	var mu sync.Mutex
+	var navigated, typed []string
+	keys := 0
+	targets := 0
+
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			URL        string `json:"url"`
+			Expression string `json:"expression"`
+			Text       string `json:"text"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "Target.createTarget":
+			targets++
+			return map[string]interface{}{"targetId": fmt.Sprintf("page-%d", targets)}
+		case "Page.navigate":
+			navigated = append(navigated, p.URL)
+		case "Input.insertText":
+			typed = append(typed, p.Text)
+		case "Input.dispatchKeyEvent":
+			keys++
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			switch {
+			case strings.Contains(p.Expression, "el.focus()"):
+				value = !strings.Contains(p.Expression, "#missing")
+			case strings.Contains(p.Expression, "getBoundingClientRect"):
+				value = `{"found": true, "visible": true, "text": "Results for golang"}`
+			case strings.Contains(p.Expression, "innerText"):
+				value = "Results for golang"
+			case p.Expression == "document.title":
+				value = "Search results"
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+
+	if _, err := manager.Run(ctx, sess.ID, "", []RunStep{{Action: "scroll"}}); !errors.Is(err, ErrInvalidRun) {
+		t.Errorf("expected ErrInvalidRun for an unknown action, got %v", err)
+	}
+	if _, err := manager.Run(ctx, sess.ID, "", []RunStep{{Action: StepType, Text: "x"}}); !errors.Is(err, ErrInvalidRun) {
+		t.Errorf("expected ErrInvalidRun for a type step without a selector, got %v", err)
+	}
+
+	result, err := manager.Run(ctx, sess.ID, "", []RunStep{
+		{Action: StepNavigate, URL: "https://search.example.com"},
+		{Action: StepType, Selector: "#q", Text: "golang", Submit: true},
+		{Action: StepType, Selector: "#missing", Text: "x", Optional: true},
+		{Action: StepExtract, Script: "document.title", As: "title"},
+		{Action: StepAssert, Selector: "h1", Text: "golang"},
+		{Action: StepNavigate, URL: "https://search.example.com/page/2"},
+		{Action: StepAssert, Text: "rust"},
+		{Action: StepExtract, Selector: "h1"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if result.Success || result.FailedStep == nil || *result.FailedStep != 6 {
+		t.Fatalf("expected the run to fail at step 6, got success=%t failed_step=%v", result.Success, result.FailedStep)
+	}
+	statuses := make([]string, len(result.Steps))
+	for i, step := range result.Steps {
+		statuses[i] = step.Status
+	}
+	want := []string{StepOK, StepOK, StepFailed, StepOK, StepOK, StepOK, StepFailed, StepSkipped}
+	if !slices.Equal(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if result.PageID != "page-1" || result.Steps[5].PageID != "page-1" {
+		t.Errorf("expected every step on page-1, got run page %q, step 5 page %q", result.PageID, result.Steps[5].PageID)
+	}
+	if failed, ok := result.Steps[6].Value.(*AssertionResult); !ok || failed.Passed || failed.Checks[0].Check != CheckText {
+		t.Errorf("expected step 6 to report its failed text check, got %#v", result.Steps[6].Value)
+	}
+	if result.Extracted["title"] != "Search results" || len(result.Extracted) != 1 {
+		t.Errorf("extracted = %v, want only title", result.Extracted)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(navigated, []string{"https://search.example.com", "https://search.example.com/page/2"}) {
+		t.Errorf("navigated = %v", navigated)
+	}
+	if !slices.Equal(typed, []string{"golang"}) || keys != 2 {
+		t.Errorf("typed %v with %d key events, want golang and Enter down and up", typed, keys)
+	}
+}
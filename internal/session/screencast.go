@@ -0,0 +1,242 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// ScreencastOptions configures the frames produced by Page.startScreencast
+type ScreencastOptions struct {
+	Format        string // "jpeg" or "png"
+	Quality       int    // JPEG quality 0-100
+	MaxWidth      int    // Frames are scaled down to fit
+	MaxHeight     int
+	EveryNthFrame int // Skip frames to reduce bandwidth
+}
+
+// DefaultScreencastOptions returns settings suitable for a remote viewport
+func DefaultScreencastOptions() ScreencastOptions {
+	return ScreencastOptions{
+		Format:        "jpeg",
+		Quality:       70,
+		MaxWidth:      1280,
+		MaxHeight:     800,
+		EveryNthFrame: 1,
+	}
+}
+
+// ScreencastMetadata describes the viewport a frame was captured from.
+// Input coordinates are in the same CSS pixel space as DeviceWidth/DeviceHeight.
+type ScreencastMetadata struct {
+	OffsetTop       float64 `json:"offsetTop"`
+	PageScaleFactor float64 `json:"pageScaleFactor"`
+	DeviceWidth     float64 `json:"deviceWidth"`
+	DeviceHeight    float64 `json:"deviceHeight"`
+	ScrollOffsetX   float64 `json:"scrollOffsetX"`
+	ScrollOffsetY   float64 `json:"scrollOffsetY"`
+	Timestamp       float64 `json:"timestamp,omitempty"`
+}
+
+// ScreencastFrame is one base64-encoded frame of a page screencast
+type ScreencastFrame struct {
+	Data     string             `json:"data"`
+	Metadata ScreencastMetadata `json:"metadata"`
+}
+
+// screencastHub shares one CDP screencast between all viewers of a page.
+// CDP allows a single screencast per target, so viewers are reference counted.
+type screencastHub struct {
+	targetID    string
+	viewers     map[chan ScreencastFrame]struct{}
+	incoming    chan screencastEvent
+	done        chan struct{} // Closed when the screencast stops
+	unsubscribe func()
+	mu          sync.Mutex
+}
+
+// screencastEvent is a raw frame with the ack ID CDP expects back
+type screencastEvent struct {
+	frame ScreencastFrame
+	ackID int
+}
+
+// WatchScreencast streams frames of a page. The first viewer starts the CDP
+// screencast and the last one to call the returned stop function ends it.
+func (s *Session) WatchScreencast(ctx context.Context, targetID string, opts ScreencastOptions) (<-chan ScreencastFrame, func(), error) {
+	s.screencastMu.Lock()
+	defer s.screencastMu.Unlock()
+
+	if s.screencasts == nil {
+		s.screencasts = make(map[string]*screencastHub)
+	}
+
+	hub, exists := s.screencasts[targetID]
+	if !exists {
+		var err error
+		hub, err = s.startScreencastHub(ctx, targetID, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.screencasts[targetID] = hub
+	}
+
+	// Small buffer: viewers that fall behind skip frames rather than queue them
+	viewer := make(chan ScreencastFrame, 2)
+	hub.mu.Lock()
+	hub.viewers[viewer] = struct{}{}
+	hub.mu.Unlock()
+
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() {
+			s.removeScreencastViewer(targetID, hub, viewer)
+		})
+	}
+
+	return viewer, stop, nil
+}
+
+// startScreencastHub subscribes to frame events and starts the CDP screencast.
+// Caller must hold s.screencastMu.
+func (s *Session) startScreencastHub(ctx context.Context, targetID string, opts ScreencastOptions) (*screencastHub, error) {
+	cdpSessionID, err := s.CDPClient.AttachToTarget(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach for screencast: %w", err)
+	}
+
+	hub := &screencastHub{
+		targetID: targetID,
+		viewers:  make(map[chan ScreencastFrame]struct{}),
+		incoming: make(chan screencastEvent, 4),
+		done:     make(chan struct{}),
+	}
+
+	// Frame events arrive on the CDP reader goroutine and are handed off without blocking
+	hub.unsubscribe = s.CDPClient.OnEvent("Page.screencastFrame", cdpSessionID, func(event *cdp.Event) {
+		var params struct {
+			Data      string             `json:"data"`
+			Metadata  ScreencastMetadata `json:"metadata"`
+			SessionID int                `json:"sessionId"` // Frame ack ID, not the CDP session
+		}
+		if err := json.Unmarshal(event.Params, &params); err != nil {
+			slog.Warn("failed to parse screencast frame", "error", err)
+			return
+		}
+
+		select {
+		case <-hub.done:
+		case hub.incoming <- screencastEvent{
+			frame: ScreencastFrame{Data: params.Data, Metadata: params.Metadata},
+			ackID: params.SessionID,
+		}:
+		default:
+			// Relay is behind; the frame is dropped and CDP sends the next one after the pending ack
+		}
+	})
+
+	go s.relayScreencast(hub)
+
+	params := map[string]interface{}{
+		"format":        opts.Format,
+		"quality":       opts.Quality,
+		"maxWidth":      opts.MaxWidth,
+		"maxHeight":     opts.MaxHeight,
+		"everyNthFrame": opts.EveryNthFrame,
+	}
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.startScreencast", params); err != nil {
+		hub.unsubscribe()
+		close(hub.done)
+		return nil, fmt.Errorf("failed to start screencast: %w", err)
+	}
+
+	return hub, nil
+}
+
+// relayScreencast acknowledges frames and fans them out to viewers
+func (s *Session) relayScreencast(hub *screencastHub) {
+	for {
+		select {
+		case <-hub.done:
+			// Hub stopped: release every remaining viewer
+			hub.mu.Lock()
+			for viewer := range hub.viewers {
+				close(viewer)
+				delete(hub.viewers, viewer)
+			}
+			hub.mu.Unlock()
+			return
+
+		case event := <-hub.incoming:
+			// CDP stops sending frames until each one is acknowledged
+			ack := map[string]interface{}{"sessionId": event.ackID}
+			if _, err := s.CDPClient.SendCommandToTarget(context.Background(), hub.targetID, "Page.screencastFrameAck", ack); err != nil {
+				slog.Debug("failed to ack screencast frame", "page_id", hub.targetID, "error", err)
+			}
+
+			hub.mu.Lock()
+			for viewer := range hub.viewers {
+				select {
+				case viewer <- event.frame:
+				default:
+				}
+			}
+			hub.mu.Unlock()
+		}
+	}
+}
+
+// removeScreencastViewer detaches a viewer and stops the screencast when none remain
+func (s *Session) removeScreencastViewer(targetID string, hub *screencastHub, viewer chan ScreencastFrame) {
+	s.screencastMu.Lock()
+	defer s.screencastMu.Unlock()
+
+	hub.mu.Lock()
+	if _, exists := hub.viewers[viewer]; exists {
+		delete(hub.viewers, viewer)
+		close(viewer)
+	}
+	remaining := len(hub.viewers)
+	hub.mu.Unlock()
+
+	if remaining > 0 || s.screencasts[targetID] != hub {
+		return
+	}
+
+	delete(s.screencasts, targetID)
+	hub.unsubscribe()
+	close(hub.done)
+
+	// The page may already be gone, so a failure here is not an error
+	if _, err := s.CDPClient.SendCommandToTarget(context.Background(), targetID, "Page.stopScreencast", nil); err != nil {
+		slog.Debug("failed to stop screencast", "page_id", targetID, "error", err)
+	}
+}
+
+// stopScreencast ends the screencast of a page and disconnects its viewers
+func (s *Session) stopScreencast(targetID string) {
+	s.screencastMu.Lock()
+	defer s.screencastMu.Unlock()
+
+	if hub, exists := s.screencasts[targetID]; exists {
+		delete(s.screencasts, targetID)
+		hub.unsubscribe()
+		close(hub.done)
+	}
+}
+
+// stopAllScreencasts ends every screencast of the session (used when its pages are torn down)
+func (s *Session) stopAllScreencasts() {
+	s.screencastMu.Lock()
+	defer s.screencastMu.Unlock()
+
+	for targetID, hub := range s.screencasts {
+		delete(s.screencasts, targetID)
+		hub.unsubscribe()
+		close(hub.done)
+	}
+}
@@ -0,0 +1,162 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ScriptError is an exception thrown by a page's script, or a promise it returned that
+// rejected. It is the page's own error: the script ran and failed, unlike an error
+// talking to the browser, which is returned as is.
+type ScriptError struct {
+	Name    string       `json:"name,omitempty"` // Error class, e.g. TypeError; empty for a thrown non-Error value
+	Message string       `json:"message"`
+	Line    int          `json:"line"`   // Zero-based, as browsers report them
+	Column  int          `json:"column"` // Zero-based
+	URL     string       `json:"url,omitempty"`
+	Stack   []StackFrame `json:"stack,omitempty"` // Innermost call first
+}
+
+// StackFrame is one call of a ScriptError's stack trace
+type StackFrame struct {
+	Function string `json:"function,omitempty"` // Empty for top-level code
+	URL      string `json:"url,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+func (e *ScriptError) Error() string {
+	if e.Name != "" && !strings.HasPrefix(e.Message, e.Name) {
+		return fmt.Sprintf("javascript execution error: %s: %s", e.Name, e.Message)
+	}
+	return "javascript execution error: " + e.Message
+}
+
+// exceptionDetails is the exceptionDetails of a CDP Runtime response
+type exceptionDetails struct {
+	Text         string `json:"text"`
+	LineNumber   int    `json:"lineNumber"`
+	ColumnNumber int    `json:"columnNumber"`
+	URL          string `json:"url"`
+	StackTrace   *struct {
+		CallFrames []struct {
+			FunctionName string `json:"functionName"`
+			URL          string `json:"url"`
+			LineNumber   int    `json:"lineNumber"`
+			ColumnNumber int    `json:"columnNumber"`
+		} `json:"callFrames"`
+	} `json:"stackTrace"`
+	Exception *struct {
+		ClassName   string      `json:"className"`
+		Description string      `json:"description"`
+		Value       interface{} `json:"value"`
+	} `json:"exception"`
+}
+
+// scriptError converts CDP exception details to a ScriptError
+func (d *exceptionDetails) scriptError() *ScriptError {
+	scriptErr := &ScriptError{
+		Message: d.Text,
+		Line:    d.LineNumber,
+		Column:  d.ColumnNumber,
+		URL:     d.URL,
+	}
+
+	if exception := d.Exception; exception != nil {
+		scriptErr.Name = exception.ClassName
+		switch {
+		case exception.Description != "":
+			// The description is the error's stack: its message, then the frames given below
+			scriptErr.Message, _, _ = strings.Cut(exception.Description, "\n")
+		case exception.Value != nil:
+			scriptErr.Message = fmt.Sprintf("%s %v", d.Text, exception.Value)
+		}
+	}
+
+	if d.StackTrace != nil {
+		for _, frame := range d.StackTrace.CallFrames {
+			scriptErr.Stack = append(scriptErr.Stack, StackFrame{
+				Function: frame.FunctionName,
+				URL:      frame.URL,
+				Line:     frame.LineNumber,
+				Column:   frame.ColumnNumber,
+			})
+		}
+	}
+	return scriptErr
+}
+
+// runtimeResult is the response of Runtime.evaluate and Runtime.callFunctionOn
+type runtimeResult struct {
+	Result struct {
+		Type     string      `json:"type"`
+		Value    interface{} `json:"value"`
+		ObjectID string      `json:"objectId"`
+
+		DeepSerializedValue *deepValue `json:"deepSerializedValue,omitempty"` // With deep serialization
+	} `json:"result"`
+	ExceptionDetails *exceptionDetails `json:"exceptionDetails,omitempty"`
+}
+
+// parseRuntimeResult reads a Runtime response, returning a thrown exception as a *ScriptError
+func parseRuntimeResult(result json.RawMessage) (*runtimeResult, error) {
+	var response runtimeResult
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse execution result: %w", err)
+	}
+	if response.ExceptionDetails != nil {
+		return nil, response.ExceptionDetails.scriptError()
+	}
+	return &response, nil
+}
+
+// CallFunction calls the function declared by declaration on the page, passing it args
+// as JSON values, so callers never splice data into script source. A returned promise
+// is awaited, and the result is returned by value.
+func (s *Session) CallFunction(ctx context.Context, targetID string, declaration string, args []json.RawMessage) (interface{}, error) {
+	response, err := s.callFunction(ctx, targetID, declaration, args, map[string]interface{}{"returnByValue": true})
+	if err != nil {
+		return nil, err
+	}
+	return response.Result.Value, nil
+}
+
+// callFunction calls declaration on the page's global object with args, adding params
+// to choose how the result comes back
+func (s *Session) callFunction(ctx context.Context, targetID string, declaration string, args []json.RawMessage, params map[string]interface{}) (*runtimeResult, error) {
+	// Functions run on an object, so call this one on the page's global object
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.evaluate", map[string]interface{}{
+		"expression": "globalThis",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute javascript: %w", err)
+	}
+	global, err := parseRuntimeResult(result)
+	if err != nil {
+		return nil, err
+	}
+	defer s.CDPClient.SendCommandToTarget(context.WithoutCancel(ctx), targetID, "Runtime.releaseObject", map[string]interface{}{
+		"objectId": global.Result.ObjectID,
+	})
+
+	arguments := make([]map[string]interface{}, len(args))
+	for i, arg := range args {
+		arguments[i] = map[string]interface{}{"value": arg}
+	}
+	call := map[string]interface{}{
+		"functionDeclaration": declaration,
+		"objectId":            global.Result.ObjectID,
+		"arguments":           arguments,
+		"awaitPromise":        true,
+	}
+	for key, value := range params {
+		call[key] = value
+	}
+	result, err = s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.callFunctionOn", call)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute javascript: %w", err)
+	}
+	return parseRuntimeResult(result)
+}
@@ -0,0 +1,198 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// TestCallFunction tests passing JSON arguments to a declared function and reporting
+// what it throws as a ScriptError with its stack
+func TestCallFunction(t *testing.T) {
+	var called struct {
+		FunctionDeclaration string            `json:"functionDeclaration"`
+		ObjectID            string            `json:"objectId"`
+		Arguments           []json.RawMessage `json:"arguments"`
+		AwaitPromise        bool              `json:"awaitPromise"`
+	}
+	released := make(chan string, 1)
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			ObjectID string `json:"objectId"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Runtime.evaluate":
+			// Navigation polls document.readyState, so every value reads complete
+			return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": "complete", "objectId": "global-1"}}
+		case "Runtime.releaseObject":
+			released <- p.ObjectID
+		case "Runtime.callFunctionOn":
+			json.Unmarshal(params, &called)
+			if called.FunctionDeclaration == "(order) => order.items.length" {
+				return map[string]interface{}{"result": map[string]interface{}{"type": "number", "value": 2}}
+			}
+			return map[string]interface{}{
+				"result": map[string]interface{}{"type": "object", "subtype": "error"},
+				"exceptionDetails": map[string]interface{}{
+					"text":         "Uncaught",
+					"lineNumber":   0,
+					"columnNumber": 21,
+					"exception": map[string]interface{}{
+						"className":   "TypeError",
+						"description": "TypeError: Cannot read properties of undefined (reading 'length')\n    at count (<anonymous>:1:22)",
+					},
+					"stackTrace": map[string]interface{}{"callFrames": []map[string]interface{}{
+						{"functionName": "count", "lineNumber": 0, "columnNumber": 21},
+					}},
+				},
+			}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com")
+
+	args := []json.RawMessage{json.RawMessage(`{"items": ["a", "b"]}`)}
+	result, err := manager.RunScript(ctx, sess.ID, pageID, "(order) => order.items.length", args, ScriptOptions{})
+	if err != nil {
+		t.Fatalf("CallFunction failed: %v", err)
+	}
+	if result != float64(2) {
+		t.Errorf("expected 2, got %v", result)
+	}
+	if called.ObjectID != "global-1" || !called.AwaitPromise || len(called.Arguments) != 1 {
+		t.Errorf("unexpected Runtime.callFunctionOn params: %+v", called)
+	}
+	var argument struct {
+		Value struct {
+			Items []string `json:"items"`
+		} `json:"value"`
+	}
+	json.Unmarshal(called.Arguments[0], &argument)
+	if len(argument.Value.Items) != 2 {
+		t.Errorf("expected the argument passed as a value, got %s", called.Arguments[0])
+	}
+	if objectID := <-released; objectID != "global-1" {
+		t.Errorf("expected the global object released, got %q", objectID)
+	}
+
+	_, err = manager.RunScript(ctx, sess.ID, pageID, "function count(o) { return o.length.length }", []json.RawMessage{}, ScriptOptions{})
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) {
+		t.Fatalf("expected a ScriptError, got %v", err)
+	}
+	if scriptErr.Name != "TypeError" || scriptErr.Message != "TypeError: Cannot read properties of undefined (reading 'length')" {
+		t.Errorf("unexpected error: %+v", scriptErr)
+	}
+	if len(scriptErr.Stack) != 1 || scriptErr.Stack[0].Function != "count" || scriptErr.Stack[0].Column != 21 {
+		t.Errorf("unexpected stack: %+v", scriptErr.Stack)
+	}
+}
+
+// TestScriptErrorValue tests that a thrown value that isn't an Error keeps its value
+func TestScriptErrorValue(t *testing.T) {
+	details := exceptionDetails{Text: "Uncaught (in promise)"}
+	details.Exception = &struct {
+		ClassName   string      `json:"className"`
+		Description string      `json:"description"`
+		Value       interface{} `json:"value"`
+	}{Value: "out of stock"}
+
+	scriptErr := details.scriptError()
+	if scriptErr.Name != "" || scriptErr.Message != "Uncaught (in promise) out of stock" {
+		t.Errorf("unexpected error: %+v", scriptErr)
+	}
+	if scriptErr.Error() != "javascript execution error: Uncaught (in promise) out of stock" {
+		t.Errorf("unexpected message: %q", scriptErr.Error())
+	}
+}
+
+// TestRunScriptDeep tests deep serialization: values JSON can't hold are typed, nodes
+// come back with object IDs, and options and result sizes are bounded
+func TestRunScriptDeep(t *testing.T) {
+	var serialization struct {
+		ObjectGroup          string `json:"objectGroup"`
+		ReturnByValue        bool   `json:"returnByValue"`
+		SerializationOptions struct {
+			Serialization string `json:"serialization"`
+			MaxDepth      int    `json:"maxDepth"`
+		} `json:"serializationOptions"`
+	}
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression    string `json:"expression"`
+			BackendNodeID int    `json:"backendNodeId"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "DOM.resolveNode":
+			return map[string]interface{}{"object": map[string]interface{}{"objectId": fmt.Sprintf("node-%d", p.BackendNodeID)}}
+		case "Runtime.evaluate":
+			if p.Expression != "report()" {
+				return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+			}
+			json.Unmarshal(params, &serialization)
+			var deep interface{}
+			json.Unmarshal([]byte(`{"type": "object", "value": [
+				["title", {"type": "string", "value": "Cart"}],
+				["total", {"type": "number", "value": "NaN"}],
+				["id", {"type": "bigint", "value": "9007199254740993"}],
+				["missing", {"type": "undefined"}],
+				["items", {"type": "array", "value": [{"type": "number", "value": 2}, {"type": "object"}]}],
+				["button", {"type": "node", "value": {"nodeType": 1, "localName": "button", "attributes": {"id": "buy"}, "childNodeCount": 1, "backendNodeId": 42}}]
+			]}`), &deep)
+			return map[string]interface{}{"result": map[string]interface{}{"type": "object", "objectId": "result-1", "deepSerializedValue": deep}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com")
+
+	for _, invalid := range []ScriptOptions{{Serialization: "xml"}, {MaxDepth: 2}, {Serialization: SerializationDeep, MaxDepth: MaxSerializationDepth + 1}} {
+		if _, err := manager.RunScript(ctx, sess.ID, pageID, "report()", nil, invalid); !errors.Is(err, ErrInvalidSerialization) {
+			t.Errorf("expected ErrInvalidSerialization for %+v, got %v", invalid, err)
+		}
+	}
+
+	result, err := manager.RunScript(ctx, sess.ID, pageID, "report()", nil, ScriptOptions{Serialization: SerializationDeep})
+	if err != nil {
+		t.Fatalf("RunScript failed: %v", err)
+	}
+	if serialization.SerializationOptions.Serialization != "deep" || serialization.SerializationOptions.MaxDepth != DefaultSerializationDepth ||
+		serialization.ObjectGroup != resultObjectGroup || serialization.ReturnByValue {
+		t.Errorf("unexpected Runtime.evaluate params: %+v", serialization)
+	}
+
+	encoded, _ := json.Marshal(result)
+	var got map[string]interface{}
+	json.Unmarshal(encoded, &got)
+	want := map[string]interface{}{
+		"title":   "Cart",
+		"total":   map[string]interface{}{"$type": "number", "value": "NaN"},
+		"id":      map[string]interface{}{"$type": "bigint", "value": "9007199254740993"},
+		"missing": map[string]interface{}{"$type": "undefined"},
+		"items":   []interface{}{float64(2), map[string]interface{}{"$type": "object"}},
+		"button": map[string]interface{}{
+			"$type": "node", "object_id": "node-42", "node_type": float64(1), "node_name": "button",
+			"attributes": map[string]interface{}{"id": "buy"}, "child_count": float64(1),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	manager.SetMaxResponseSize(64)
+	if _, err := manager.RunScript(ctx, sess.ID, pageID, "report()", nil, ScriptOptions{Serialization: SerializationDeep}); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
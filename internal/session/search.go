@@ -0,0 +1,155 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+)
+
+// SearchPageURL is the results page the browser backend loads. DuckDuckGo's HTML
+// version renders results without scripts and tolerates automated clients.
+const SearchPageURL = "https://html.duckduckgo.com/html/"
+
+// searchResultsJS collects the organic results of a DuckDuckGo HTML results page,
+// skipping ads, and whether the page is a bot challenge instead
+const searchResultsJS = `(() => {
+	const results = [];
+	for (const node of document.querySelectorAll('.result')) {
+		if (node.classList.contains('result--ad')) continue;
+		const link = node.querySelector('a.result__a');
+		if (!link) continue;
+		const snippet = node.querySelector('.result__snippet');
+		results.push({
+			title: link.textContent,
+			url: link.href,
+			snippet: snippet ? snippet.textContent : ''
+		});
+	}
+	const blocked = !!document.querySelector('.anomaly-modal__modal, #challenge-form');
+	return JSON.stringify({results, blocked});
+})()`
+
+// BrowserSearch runs queries by loading a search engine's results page in a throwaway
+// session, for deployments without a SERP API key
+type BrowserSearch struct {
+	manager *Manager
+	placer  CheckPlacer
+}
+
+// NewBrowserSearch creates a backend that searches on browsers the placer picks, such
+// as the one checks use
+func NewBrowserSearch(m *Manager, placer CheckPlacer) *BrowserSearch {
+	return &BrowserSearch{manager: m, placer: placer}
+}
+
+// Name returns "browser"
+func (b *BrowserSearch) Name() string {
+	return "browser"
+}
+
+// Search opens a session for the query's tenant, reads the first results page and
+// destroys the session again. The HTML results page has about 30 results, so a larger
+// count gets what the first page holds.
+func (b *BrowserSearch) Search(ctx context.Context, query search.Query) ([]search.Result, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	port, err := b.placer.Acquire(nil)
+	if err != nil {
+		return nil, fmt.Errorf("no browser for the search: %w", err)
+	}
+	defer b.placer.Release(port)
+
+	session, err := b.manager.CreateSessionWithOptions(ctx, query.TenantID, "search", "", port, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer func() {
+		if err := b.manager.DestroySession(session.ID); err != nil {
+			slog.Warn("failed to destroy search session", "session_id", session.ID, "error", err)
+		}
+	}()
+
+	pageID, err := b.manager.Navigate(ctx, session.ID, resultsURL(query))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load results page: %w", search.ErrSearchFailed, err)
+	}
+
+	value, err := session.Driver().Evaluate(ctx, pageID, searchResultsJS)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read results page: %w", search.ErrSearchFailed, err)
+	}
+	raw, _ := value.(string)
+	var page struct {
+		Results []search.Result `json:"results"`
+		Blocked bool            `json:"blocked"`
+	}
+	if err := json.Unmarshal([]byte(raw), &page); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse results page: %w", search.ErrSearchFailed, err)
+	}
+	if page.Blocked {
+		return nil, fmt.Errorf("%w: the search engine served a bot challenge", search.ErrSearchFailed)
+	}
+
+	for i := range page.Results {
+		page.Results[i].URL = unwrapRedirect(page.Results[i].URL)
+	}
+	return search.Clean(page.Results, query.Count), nil
+}
+
+// resultsURL builds the results page URL for a query. DuckDuckGo's region codes pair a
+// country with a language, such as "us-en".
+func resultsURL(query search.Query) string {
+	params := url.Values{}
+	params.Set("q", query.Text)
+	if query.Region != "" {
+		language := query.Language
+		if language == "" {
+			language = "en"
+		}
+		params.Set("kl", strings.ToLower(query.Region+"-"+language))
+	}
+	return SearchPageURL + "?" + params.Encode()
+}
+
+// unwrapRedirect returns the destination of a DuckDuckGo click-tracking link, or the
+// link itself when it isn't one
+func unwrapRedirect(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil || !strings.HasSuffix(parsed.Hostname(), "duckduckgo.com") || parsed.Path != "/l/" {
+		return link
+	}
+	if target := parsed.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return link
+}
+
+// SearchBackend returns the configured search backend (nil: search is disabled)
+func (m *Manager) SearchBackend() search.Backend {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.searcher
+}
+
+// SetSearchBackend sets the backend POST /search runs queries against
+func (m *Manager) SetSearchBackend(backend search.Backend) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.searcher = backend
+}
+
+// Search runs a query against the configured backend
+func (m *Manager) Search(ctx context.Context, query search.Query) ([]search.Result, error) {
+	backend := m.SearchBackend()
+	if backend == nil {
+		return nil, ErrSearchDisabled
+	}
+	return backend.Search(ctx, query)
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/search"
+)
+
+// TestBrowserSearch tests that browser searches load the results page in a session of
+// their own, unwrap redirect links and clean up after themselves
+func TestBrowserSearch(t *testing.T) {
+	var mu sync.Mutex
+	var opened string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			URL        string `json:"url"`
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Page.navigate":
+			mu.Lock()
+			opened = p.URL
+			mu.Unlock()
+			return map[string]interface{}{"frameId": "frame-1"}
+		case "Runtime.evaluate":
+			var value interface{} = "complete"
+			if strings.Contains(p.Expression, "result__a") {
+				value = `{"blocked": false, "results": [
+					{"title": "The Go Programming Language", "url": "https://duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&rut=abc", "snippet": "Build simple software."},
+					{"title": "Go - Wikipedia", "url": "https://en.wikipedia.org/wiki/Go_(programming_language)", "snippet": ""},
+					{"title": "Go again", "url": "https://duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F", "snippet": ""}
+				]}`
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"value": value}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	if _, err := manager.Search(ctx, search.Query{Text: "golang"}); !errors.Is(err, ErrSearchDisabled) {
+		t.Errorf("expected ErrSearchDisabled without a backend, got %v", err)
+	}
+
+	placer := &fakePlacer{port: 9222}
+	manager.SetSearchBackend(NewBrowserSearch(manager, placer))
+
+	results, err := manager.Search(ctx, search.Query{Text: "golang", Region: "DE", Language: "de"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].URL != "https://go.dev/" || results[0].Domain != "go.dev" || results[1].Position != 2 {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	mu.Lock()
+	if opened != SearchPageURL+"?kl=de-de&q=golang" {
+		t.Errorf("unexpected results page: %s", opened)
+	}
+	mu.Unlock()
+
+	if len(manager.ListSessions()) != 0 {
+		t.Error("expected the search session to be destroyed")
+	}
+	if placer.acquired.Load() != 1 || placer.released.Load() != 1 {
+		t.Errorf("expected one browser acquired and released, got %d and %d", placer.acquired.Load(), placer.released.Load())
+	}
+}
@@ -0,0 +1,212 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// The Security domain reports a page's state as an event once it is enabled; if the
+// event doesn't arrive in this long the page is reported from its last navigation alone
+const securityStateWait = time.Second
+
+// SecurityDetails describes the connection a document was fetched over
+type SecurityDetails struct {
+	State                   string     `json:"state"`                  // secure, neutral, insecure or insecure-broken, as the browser judged it
+	Protocol                string     `json:"protocol,omitempty"`     // e.g. TLS 1.3
+	KeyExchange             string     `json:"key_exchange,omitempty"` // Empty for TLS 1.3, whose key exchange is in the group
+	KeyExchangeGroup        string     `json:"key_exchange_group,omitempty"`
+	Cipher                  string     `json:"cipher,omitempty"`
+	SubjectName             string     `json:"subject_name,omitempty"`
+	Issuer                  string     `json:"issuer,omitempty"`
+	SANs                    []string   `json:"sans,omitempty"`
+	ValidFrom               *time.Time `json:"valid_from,omitempty"`
+	ValidTo                 *time.Time `json:"valid_to,omitempty"`
+	Expired                 bool       `json:"expired,omitempty"`                  // ValidTo had passed when the document was fetched
+	CertificateTransparency string     `json:"certificate_transparency,omitempty"` // compliant, not-compliant or unknown
+}
+
+// responseSecurityDetails is the securityDetails of a Network.Response
+type responseSecurityDetails struct {
+	Protocol                          string   `json:"protocol"`
+	KeyExchange                       string   `json:"keyExchange"`
+	KeyExchangeGroup                  string   `json:"keyExchangeGroup"`
+	Cipher                            string   `json:"cipher"`
+	SubjectName                       string   `json:"subjectName"`
+	SanList                           []string `json:"sanList"`
+	Issuer                            string   `json:"issuer"`
+	ValidFrom                         float64  `json:"validFrom"` // Seconds since the epoch
+	ValidTo                           float64  `json:"validTo"`
+	CertificateTransparencyCompliance string   `json:"certificateTransparencyCompliance"`
+}
+
+// newSecurityDetails builds the details of a response, nil when the browser reported
+// nothing about its security
+func newSecurityDetails(state string, details *responseSecurityDetails, fetched time.Time) *SecurityDetails {
+	if state == "" && details == nil {
+		return nil
+	}
+	security := &SecurityDetails{State: state}
+	if details == nil {
+		return security
+	}
+	security.Protocol = details.Protocol
+	security.KeyExchange = details.KeyExchange
+	security.KeyExchangeGroup = details.KeyExchangeGroup
+	security.Cipher = details.Cipher
+	security.SubjectName = details.SubjectName
+	security.Issuer = details.Issuer
+	security.SANs = details.SanList
+	security.ValidFrom = epochTime(details.ValidFrom)
+	security.ValidTo = epochTime(details.ValidTo)
+	security.Expired = security.ValidTo != nil && fetched.After(*security.ValidTo)
+	security.CertificateTransparency = details.CertificateTransparencyCompliance
+	return security
+}
+
+// epochTime converts CDP seconds since the epoch, nil for none
+func epochTime(seconds float64) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	whole, fraction := math.Modf(seconds)
+	t := time.Unix(int64(whole), int64(fraction*1e9)).UTC()
+	return &t
+}
+
+// PageSecurity is the security state of a page as the browser shows it now, with
+// what its last navigation's document was fetched over
+type PageSecurity struct {
+	URL          string           `json:"url"`
+	State        string           `json:"state"`                       // secure, neutral, insecure, insecure-broken or unknown
+	Issues       []string         `json:"issues"`                      // The browser's security issue IDs, e.g. ran-mixed-content
+	MixedContent []string         `json:"mixed_content"`               // The issues about insecure content on a secure page
+	CertError    string           `json:"certificate_error,omitempty"` // Why the certificate is bad, when its error was ignored
+	Navigation   *SecurityDetails `json:"navigation,omitempty"`
+}
+
+// securityStateWatcher hands over the first state the Security domain reports
+type securityStateWatcher struct {
+	states chan *PageSecurity
+}
+
+func newSecurityStateWatcher() *securityStateWatcher {
+	return &securityStateWatcher{states: make(chan *PageSecurity, 1)}
+}
+
+// onStateChanged keeps a Security.visibleSecurityStateChanged report. It runs on the
+// CDP reader, so a report arriving while one is waiting is dropped rather than blocking.
+func (w *securityStateWatcher) onStateChanged(event *cdp.Event) {
+	var params struct {
+		VisibleSecurityState struct {
+			SecurityState            string `json:"securityState"`
+			CertificateSecurityState *struct {
+				CertificateNetworkError string `json:"certificateNetworkError"`
+			} `json:"certificateSecurityState"`
+			SecurityStateIssueIDs []string `json:"securityStateIssueIds"`
+		} `json:"visibleSecurityState"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil {
+		return
+	}
+	state := params.VisibleSecurityState
+	security := &PageSecurity{State: state.SecurityState, Issues: []string{}, MixedContent: []string{}}
+	for _, issue := range state.SecurityStateIssueIDs {
+		security.Issues = append(security.Issues, issue)
+		if strings.Contains(issue, "mixed") {
+			security.MixedContent = append(security.MixedContent, issue)
+		}
+	}
+	if state.CertificateSecurityState != nil {
+		security.CertError = state.CertificateSecurityState.CertificateNetworkError
+	}
+
+	select {
+	case w.states <- security:
+	default:
+	}
+}
+
+// wait returns the first reported state, or nil if none came in time
+func (w *securityStateWatcher) wait(ctx context.Context) (*PageSecurity, error) {
+	timer := time.NewTimer(securityStateWait)
+	defer timer.Stop()
+
+	select {
+	case security := <-w.states:
+		return security, nil
+	case <-timer.C:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pageSecurity enables the Security domain on a page long enough to read its state
+func (s *Session) pageSecurity(ctx context.Context, pageID string) (*PageSecurity, error) {
+	cdpSessionID, err := s.CDPClient.AttachToTarget(ctx, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach for security state: %w", err)
+	}
+
+	watcher := newSecurityStateWatcher()
+	unsubscribe := s.CDPClient.OnEvent("Security.visibleSecurityStateChanged", cdpSessionID, watcher.onStateChanged)
+	defer unsubscribe()
+
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "Security.enable", nil); err != nil {
+		return nil, fmt.Errorf("failed to enable security domain: %w", err)
+	}
+	defer func() {
+		if _, err := s.CDPClient.SendCommandToTarget(context.WithoutCancel(ctx), pageID, "Security.disable", nil); err != nil {
+			slog.Debug("failed to disable security domain", "page_id", pageID, "error", err)
+		}
+	}()
+
+	security, err := watcher.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if security == nil {
+		security = &PageSecurity{State: "unknown", Issues: []string{}, MixedContent: []string{}}
+	}
+	return security, nil
+}
+
+// PageSecurity reports a page's security state, the mixed content the browser found
+// on it and the connection its last navigation's document came over
+func (m *Manager) PageSecurity(ctx context.Context, sessionID string, pageID string) (*PageSecurity, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "security state")
+	if err != nil {
+		return nil, err
+	}
+
+	security, err := session.pageSecurity(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if navigation := session.LastNavigation(pageID); navigation != nil {
+		security.URL = navigation.URL
+		security.Navigation = navigation.Security
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return security, nil
+}
+
+// ignoreCertificateErrors lets a page load documents whose certificates the browser
+// would reject, for internal and staging hosts with self-signed certificates
+func (s *Session) ignoreCertificateErrors(ctx context.Context, targetID string) error {
+	params := map[string]interface{}{"ignore": true}
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Security.setIgnoreCertificateErrors", params); err != nil {
+		return fmt.Errorf("failed to ignore certificate errors: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestPageSecurity tests reading TLS details from a navigation's document, the
+// Security domain's report, mixed content in resources and ignoring certificate errors
+func TestPageSecurity(t *testing.T) {
+	event := func(method, params string) *cdp.Event {
+		return &cdp.Event{Method: method, Params: []byte(params)}
+	}
+
+	recorder := &navigationRecorder{frameID: "page-1", requests: make(map[string]*navigationRequest)}
+	recorder.onRequestWillBeSent(event("Network.requestWillBeSent", `{"requestId": "L1", "frameId": "page-1", "type": "Document", "request": {"url": "https://staging.example.com/"}}`))
+	recorder.onResponseReceived(event("Network.responseReceived", `{"requestId": "L1", "type": "Document", "response": {"url": "https://staging.example.com/", "status": 200,
+		"securityState": "insecure-broken", "securityDetails": {"protocol": "TLS 1.3", "keyExchange": "", "keyExchangeGroup": "X25519", "cipher": "AES_128_GCM",
+		"subjectName": "staging.example.com", "sanList": ["staging.example.com"], "issuer": "Example Internal CA", "validFrom": 1700000000, "validTo": 1710000000.5,
+		"certificateTransparencyCompliance": "not-compliant"}}}`))
+	info := recorder.result("https://staging.example.com/", "L1", "")
+	security := info.Security
+	if security == nil || security.State != "insecure-broken" || security.Protocol != "TLS 1.3" || security.Issuer != "Example Internal CA" {
+		t.Fatalf("expected the document's TLS details, got %+v", security)
+	}
+	if !security.Expired || security.ValidTo.Unix() != 1710000000 || security.ValidFrom.Unix() != 1700000000 || security.CertificateTransparency != "not-compliant" {
+		t.Errorf("expected the expired certificate's validity, got %+v", security)
+	}
+
+	watcher := newSecurityStateWatcher()
+	watcher.onStateChanged(event("Security.visibleSecurityStateChanged", `{"visibleSecurityState": {"securityState": "neutral",
+		"certificateSecurityState": {"protocol": "TLS 1.3", "certificateNetworkError": "net::ERR_CERT_AUTHORITY_INVALID"},
+		"securityStateIssueIds": ["displayed-mixed-content", "ran-content-with-cert-errors"]}}`))
+	watcher.onStateChanged(event("Security.visibleSecurityStateChanged", `{"visibleSecurityState": {"securityState": "secure"}}`))
+	state, err := watcher.wait(context.Background())
+	if err != nil || state == nil {
+		t.Fatalf("expected the first report, got %v, %v", state, err)
+	}
+	if state.State != "neutral" || len(state.Issues) != 2 || !slices.Equal(state.MixedContent, []string{"displayed-mixed-content"}) ||
+		state.CertError != "net::ERR_CERT_AUTHORITY_INVALID" {
+		t.Errorf("unexpected security state: %+v", state)
+	}
+
+	var mu sync.Mutex
+	var methods []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		mu.Lock()
+		methods = append(methods, method)
+		mu.Unlock()
+		switch method {
+		case "Page.getResourceTree":
+			return map[string]interface{}{"frameTree": map[string]interface{}{
+				"frame": map[string]interface{}{"id": "page-1", "url": "https://staging.example.com/", "mimeType": "text/html"},
+				"resources": []interface{}{
+					map[string]interface{}{"url": "https://staging.example.com/app.js", "type": "Script", "mimeType": "text/javascript"},
+					map[string]interface{}{"url": "http://cdn.example.net/logo.png", "type": "Image", "mimeType": "image/png"},
+				},
+			}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, &SessionOptions{IgnoreCertificateErrors: true}, "https://staging.example.com")
+	mu.Lock()
+	ignored, navigated := slices.Index(methods, "Security.setIgnoreCertificateErrors"), slices.Index(methods, "Page.navigate")
+	mu.Unlock()
+	if ignored < 0 || ignored > navigated {
+		t.Errorf("expected certificate errors ignored before the page loaded, got %v", methods)
+	}
+
+	resources, err := manager.ListResources(ctx, sess.ID, pageID)
+	if err != nil {
+		t.Fatalf("ListResources failed: %v", err)
+	}
+	if len(resources) != 3 || resources[1].MixedContent || !resources[2].MixedContent {
+		t.Errorf("expected only the plain HTTP image marked as mixed content, got %+v", resources)
+	}
+
+	// The fake browser sends no Security events, so the page's state is unknown
+	started := time.Now()
+	page, err := manager.PageSecurity(ctx, sess.ID, pageID)
+	if err != nil {
+		t.Fatalf("PageSecurity failed: %v", err)
+	}
+	if page.State != "unknown" || time.Since(started) < securityStateWait {
+		t.Errorf("expected the state unknown after waiting for a report, got %+v", page)
+	}
+	mu.Lock()
+	if !slices.Contains(methods, "Security.enable") || methods[len(methods)-1] != "Security.disable" {
+		t.Errorf("expected the Security domain enabled and disabled, got %v", methods)
+	}
+	mu.Unlock()
+	if _, err := manager.PageSecurity(ctx, sess.ID, "missing"); err == nil {
+		t.Error("expected an error for a page not in the session")
+	}
+
+	firefox := &SessionOptions{Engine: EngineFirefox, IgnoreCertificateErrors: true}
+	if err := firefox.Validate(); !errors.Is(err, ErrEngineUnsupported) {
+		t.Errorf("expected ignore_certificate_errors rejected on Firefox, got %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/seeds"
+)
+
+// ExpandSeed lists the pages of a sitemap or RSS or Atom feed, newest first. With since
+// set, only pages modified after it are kept; undated pages can't be told apart from
+// unchanged ones, so they are left out too.
+func (m *Manager) ExpandSeed(ctx context.Context, seedURL string, since *time.Time) (*seeds.Expansion, error) {
+	expansion, err := m.seeds.Expand(ctx, seedURL)
+	if err != nil {
+		return nil, err
+	}
+	if since != nil {
+		kept := expansion.Targets[:0]
+		for _, target := range expansion.Targets {
+			if target.LastModified != nil && target.LastModified.After(*since) {
+				kept = append(kept, target)
+			}
+		}
+		expansion.Targets = kept
+	}
+	return expansion, nil
+}
@@ -0,0 +1,158 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Selector engines
+const (
+	EngineCSS   = "css"   // A CSS selector (default)
+	EngineXPath = "xpath" // An XPath expression; only the elements it selects count
+	EngineText  = "text"  // Text to find among the page's visible text
+)
+
+// How the text engine compares an element's visible text to the selector
+const (
+	MatchContains = "contains" // Contains the selector, ignoring case (default)
+	MatchExact    = "exact"    // Is the selector, case and all
+	MatchRegex    = "regex"    // Matches a JavaScript regular expression, bare or as /pattern/flags
+)
+
+// SelectorEngine says how a selector is read. The zero value reads it as CSS.
+//
+// The text engine finds the innermost visible elements whose text matches, so
+// "Sign in" finds the button rather than the form around it. Whitespace runs count
+// as one space and leading and trailing space is ignored.
+type SelectorEngine struct {
+	Engine string `json:"engine,omitempty"` // css (default), xpath or text
+	Match  string `json:"match,omitempty"`  // text only: contains (default), exact or regex
+}
+
+// validate rejects engines and matches that don't exist, and matches on engines
+// other than text
+func (e SelectorEngine) validate() error {
+	switch e.Engine {
+	case "", EngineCSS, EngineXPath:
+		if e.Match != "" {
+			return fmt.Errorf("%w: match needs the text engine", ErrInvalidSelector)
+		}
+	case EngineText:
+		switch e.Match {
+		case "", MatchContains, MatchExact, MatchRegex:
+		default:
+			return fmt.Errorf("%w: unknown match %q (want contains, exact or regex)", ErrInvalidSelector, e.Match)
+		}
+	default:
+		return fmt.Errorf("%w: unknown engine %q (want css, xpath or text)", ErrInvalidSelector, e.Engine)
+	}
+	return nil
+}
+
+// describe names a selector in messages, prefixed with its engine unless it is CSS
+func (e SelectorEngine) describe(selector string) string {
+	if e.Engine == "" || e.Engine == EngineCSS {
+		return selector
+	}
+	return e.Engine + "=" + selector
+}
+
+// first returns a JavaScript expression for the first element selector finds, or null
+func (e SelectorEngine) first(selector string) string {
+	if e.Engine == "" || e.Engine == EngineCSS {
+		selectorJSON, _ := json.Marshal(selector)
+		return fmt.Sprintf("document.querySelector(%s)", selectorJSON)
+	}
+	return e.find("findFirst", selector)
+}
+
+// all returns a JavaScript expression for the elements selector finds, in document order
+func (e SelectorEngine) all(selector string) string {
+	if e.Engine == "" || e.Engine == EngineCSS {
+		selectorJSON, _ := json.Marshal(selector)
+		return fmt.Sprintf("document.querySelectorAll(%s)", selectorJSON)
+	}
+	return e.find("findAll", selector)
+}
+
+// find calls one of the selector helpers on selector. CSS needs no helpers, so its
+// expressions stay plain querySelector calls.
+func (e SelectorEngine) find(helper string, selector string) string {
+	locator, _ := json.Marshal(struct {
+		Selector string `json:"selector"`
+		SelectorEngine
+	}{selector, e})
+	return fmt.Sprintf("(function(locator) {%s  return %s(locator);\n})(%s)", selectorHelpersJS, helper, locator)
+}
+
+// findElement finds the first element selector finds and returns its remote object ID,
+// kept in group when one is given. The caller releases it.
+func (s *Session) findElement(ctx context.Context, targetID string, selector string, engine SelectorEngine, group string) (string, error) {
+	params := map[string]interface{}{"expression": engine.first(selector)}
+	if group != "" {
+		params["objectGroup"] = group
+	}
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.evaluate", params)
+	if err != nil {
+		return "", fmt.Errorf("failed to query selector: %w", err)
+	}
+	found, err := parseRuntimeResult(result)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+	}
+	if found.Result.ObjectID == "" {
+		return "", fmt.Errorf("%w: %s", ErrElementNotFound, engine.describe(selector))
+	}
+	return found.Result.ObjectID, nil
+}
+
+// selectorHelpersJS declares findAll and findFirst for XPath and text locators. Bad
+// expressions and patterns throw a SyntaxError, as bad CSS does in querySelector.
+const selectorHelpersJS = `
+  function findAll(locator) {
+    if (locator.engine === 'xpath') {
+      var snapshot = document.evaluate(locator.selector, document, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null);
+      var elements = [];
+      for (var i = 0; i < snapshot.snapshotLength; i++) {
+        if (snapshot.snapshotItem(i).nodeType === 1) elements.push(snapshot.snapshotItem(i));
+      }
+      return elements;
+    }
+    return findByText(locator);
+  }
+
+  function findFirst(locator) {
+    return findAll(locator)[0] || null;
+  }
+
+  function textMatcher(locator) {
+    var wanted = locator.selector.replace(/\s+/g, ' ').trim();
+    if (locator.match === 'exact') return function(text) { return text === wanted; };
+    if (locator.match === 'regex') {
+      var literal = /^\/(.*)\/([a-z]*)$/.exec(locator.selector);
+      var pattern = literal ? new RegExp(literal[1], literal[2]) : new RegExp(locator.selector);
+      return function(text) { pattern.lastIndex = 0; return pattern.test(text); };
+    }
+    wanted = wanted.toLowerCase();
+    return function(text) { return text.toLowerCase().indexOf(wanted) !== -1; };
+  }
+
+  function findByText(locator) {
+    var matches = textMatcher(locator), root = document.body || document.documentElement;
+    if (!root) return [];
+
+    var found = [], candidates = [root].concat(Array.from(root.querySelectorAll('*')));
+    for (var i = 0; i < candidates.length; i++) {
+      var el = candidates[i];
+      if (!el.getClientRects().length) continue; // Not rendered
+      var button = el.tagName === 'INPUT' && /^(button|submit|reset)$/.test(el.type);
+      var text = String((button ? el.value : el.innerText) || '').replace(/\s+/g, ' ').trim();
+      if (text && matches(text)) found.push(el);
+    }
+    // Keep the innermost: an element holding another match matched through it
+    return found.filter(function(el) {
+      return !found.some(function(other) { return other !== el && el.contains(other); });
+    });
+  }
+`
@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSelectorEngine tests engine validation and the expressions each engine finds with
+func TestSelectorEngine(t *testing.T) {
+	for _, engine := range []SelectorEngine{{}, {Engine: EngineCSS}, {Engine: EngineXPath}, {Engine: EngineText, Match: MatchRegex}} {
+		if err := engine.validate(); err != nil {
+			t.Errorf("expected %+v valid, got %v", engine, err)
+		}
+	}
+	for _, engine := range []SelectorEngine{{Engine: "sizzle"}, {Engine: EngineText, Match: "fuzzy"}, {Engine: EngineXPath, Match: MatchExact}} {
+		if err := engine.validate(); !errors.Is(err, ErrInvalidSelector) {
+			t.Errorf("expected ErrInvalidSelector for %+v, got %v", engine, err)
+		}
+	}
+
+	// CSS stays a plain query, with no helpers sent along
+	if got := (SelectorEngine{}).first(`a[href="/x"]`); got != `document.querySelector("a[href=\"/x\"]")` {
+		t.Errorf("unexpected CSS expression: %s", got)
+	}
+	if got := (SelectorEngine{Engine: EngineCSS}).all("li"); got != `document.querySelectorAll("li")` {
+		t.Errorf("unexpected CSS expression: %s", got)
+	}
+
+	text := SelectorEngine{Engine: EngineText, Match: MatchExact}
+	if got := text.first("Sign in"); !strings.Contains(got, "return findFirst(locator)") ||
+		!strings.HasSuffix(got, `({"selector":"Sign in","engine":"text","match":"exact"})`) {
+		t.Errorf("unexpected text expression: %s", got)
+	}
+	if got := (SelectorEngine{Engine: EngineXPath}).all("//li"); !strings.Contains(got, "return findAll(locator)") {
+		t.Errorf("unexpected XPath expression: %s", got)
+	}
+	if got := text.describe("Sign in"); got != "text=Sign in" {
+		t.Errorf("unexpected description %q", got)
+	}
+}
+
+// TestSelectorEnginesReachPage tests that element endpoints find by the engine asked for
+func TestSelectorEnginesReachPage(t *testing.T) {
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch method {
+		case "Runtime.evaluate":
+			switch {
+			case strings.Contains(p.Expression, `"selector":"Checkout","engine":"text"`):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "node", "objectId": "obj-checkout"}}
+			case strings.Contains(p.Expression, `"engine":"xpath"`):
+				return map[string]interface{}{"result": map[string]interface{}{"type": "object", "subtype": "null"}}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		case "Runtime.callFunctionOn":
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "button"}}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com")
+
+	handle, err := manager.CreateHandle(ctx, sess.ID, pageID, "Checkout", SelectorEngine{Engine: EngineText})
+	if err != nil {
+		t.Fatalf("CreateHandle failed: %v", err)
+	}
+	if handle.Engine != EngineText || handle.Tag != "button" {
+		t.Errorf("unexpected handle: %+v", handle)
+	}
+
+	_, err = manager.CreateHandle(ctx, sess.ID, pageID, "//button[@id='pay']", SelectorEngine{Engine: EngineXPath})
+	if !errors.Is(err, ErrElementNotFound) || !strings.Contains(err.Error(), "xpath=//button") {
+		t.Errorf("expected ErrElementNotFound naming the engine, got %v", err)
+	}
+
+	// Bad engines are refused before anything reaches the page
+	if _, err := manager.Wait(ctx, sess.ID, pageID, WaitRequest{Selector: "Pay", SelectorEngine: SelectorEngine{Engine: "label"}}); !errors.Is(err, ErrInvalidSelector) {
+		t.Errorf("expected ErrInvalidSelector from Wait, got %v", err)
+	}
+	if _, err := manager.Assert(ctx, sess.ID, pageID, Assertion{Selector: "Pay", SelectorEngine: SelectorEngine{Match: MatchExact}}); !errors.Is(err, ErrInvalidAssertion) {
+		t.Errorf("expected ErrInvalidAssertion for a match without the text engine, got %v", err)
+	}
+}
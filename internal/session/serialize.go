@@ -0,0 +1,290 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// How a script's result is serialized
+const (
+	SerializationJSON = "json" // The result's JSON form, as JSON.stringify would give it
+	SerializationDeep = "deep" // Typed, so values JSON can't hold survive and DOM nodes come back as handles
+)
+
+// Deep serialization bounds
+const (
+	DefaultSerializationDepth = 3  // Levels of nested objects serialized unless asked otherwise
+	MaxSerializationDepth     = 10 // Deepest a caller may ask for
+
+	maxResultNodes = 100 // DOM nodes given an object ID per result; the rest are described only
+)
+
+// resultObjectGroup holds the objects handed out in deep results. They live until the
+// page navigates or closes, which drops every object of the old document.
+const resultObjectGroup = "bqa-results"
+
+// ScriptOptions choose how a script's result is returned
+type ScriptOptions struct {
+	Serialization string `json:"serialization,omitempty"` // json (default) or deep
+	MaxDepth      int    `json:"max_depth,omitempty"`     // Deep only: nested object levels kept (default 3, at most 10)
+}
+
+// validate rejects options the browser can't serve
+func (o ScriptOptions) validate() error {
+	switch o.Serialization {
+	case "", SerializationJSON:
+		if o.MaxDepth != 0 {
+			return fmt.Errorf("%w: max_depth needs deep serialization", ErrInvalidSerialization)
+		}
+	case SerializationDeep:
+		if o.MaxDepth < 0 || o.MaxDepth > MaxSerializationDepth {
+			return fmt.Errorf("%w: max_depth must be between 1 and %d", ErrInvalidSerialization, MaxSerializationDepth)
+		}
+	default:
+		return fmt.Errorf("%w: unknown serialization %q (want json or deep)", ErrInvalidSerialization, o.Serialization)
+	}
+	return nil
+}
+
+// deep reports whether the options ask for deep serialization
+func (o ScriptOptions) deep() bool {
+	return o.Serialization == SerializationDeep
+}
+
+// params returns the Runtime parameters that serialize the result deeply
+func (o ScriptOptions) params() map[string]interface{} {
+	depth := o.MaxDepth
+	if depth == 0 {
+		depth = DefaultSerializationDepth
+	}
+	return map[string]interface{}{
+		"objectGroup": resultObjectGroup,
+		"serializationOptions": map[string]interface{}{
+			"serialization": "deep",
+			"maxDepth":      depth,
+			// Nodes are described, not walked; their object IDs reach the rest
+			"additionalParameters": map[string]interface{}{
+				"maxNodeDepth":      0,
+				"includeShadowTree": "none",
+			},
+		},
+	}
+}
+
+// deepValue is a deeply serialized value, in the format WebDriver BiDi uses for script
+// results. Containers past the depth limit, or seen earlier in the result, carry no value.
+type deepValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// deepNode is the value of a serialized DOM node
+type deepNode struct {
+	NodeType       int               `json:"nodeType"`
+	LocalName      string            `json:"localName"`
+	NodeValue      string            `json:"nodeValue"`
+	Attributes     map[string]string `json:"attributes"`
+	ChildNodeCount int               `json:"childNodeCount"`
+	BackendNodeID  int               `json:"backendNodeId"`
+}
+
+// RunScript runs code on the page, or with args calls it as a function declaration. The
+// result is serialized as opts ask: deep results keep every value JSON can't hold as a
+// {"$type": ...} object, DOM nodes with an object_id the element APIs take.
+func (s *Session) RunScript(ctx context.Context, targetID string, code string, args []json.RawMessage, opts ScriptOptions) (interface{}, error) {
+	if !opts.deep() {
+		if args != nil {
+			return s.CallFunction(ctx, targetID, code, args)
+		}
+		return s.ExecuteJavascript(ctx, targetID, code)
+	}
+
+	var response *runtimeResult
+	if args != nil {
+		var err error
+		if response, err = s.callFunction(ctx, targetID, code, args, opts.params()); err != nil {
+			return nil, err
+		}
+	} else {
+		params := opts.params()
+		params["expression"] = code
+		params["awaitPromise"] = true
+		result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.evaluate", params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute javascript: %w", err)
+		}
+		if response, err = parseRuntimeResult(result); err != nil {
+			return nil, err
+		}
+	}
+
+	// The result itself was kept as an object too; only its serialization is wanted
+	if response.Result.ObjectID != "" {
+		defer s.CDPClient.SendCommandToTarget(context.WithoutCancel(ctx), targetID, "Runtime.releaseObject", map[string]interface{}{
+			"objectId": response.Result.ObjectID,
+		})
+	}
+	if response.Result.DeepSerializedValue == nil {
+		return nil, fmt.Errorf("failed to execute javascript: browser returned no deep serialization")
+	}
+
+	converter := &deepConverter{session: s, ctx: ctx, targetID: targetID}
+	return converter.convert(*response.Result.DeepSerializedValue), nil
+}
+
+// deepConverter turns a deep serialization into the result returned to callers
+type deepConverter struct {
+	session  *Session
+	ctx      context.Context
+	targetID string
+	nodes    int // Nodes given an object ID so far
+}
+
+// typed returns the {"$type": ...} object standing for a value JSON can't hold
+func typed(kind string, value interface{}) map[string]interface{} {
+	if value == nil {
+		return map[string]interface{}{"$type": kind}
+	}
+	return map[string]interface{}{"$type": kind, "value": value}
+}
+
+// convert returns the plain JSON form of values JSON can hold, and typed objects for
+// the rest
+func (c *deepConverter) convert(v deepValue) interface{} {
+	switch v.Type {
+	case "null":
+		return nil
+	case "string", "boolean":
+		var value interface{}
+		json.Unmarshal(v.Value, &value)
+		return value
+	case "number":
+		var number float64
+		if json.Unmarshal(v.Value, &number) == nil {
+			return number
+		}
+		// NaN, -0, Infinity and -Infinity come as strings
+		var special string
+		json.Unmarshal(v.Value, &special)
+		return typed("number", special)
+	case "bigint", "date":
+		var text string
+		json.Unmarshal(v.Value, &text)
+		return typed(v.Type, text)
+	case "regexp":
+		var regexp struct {
+			Pattern string `json:"pattern"`
+			Flags   string `json:"flags,omitempty"`
+		}
+		json.Unmarshal(v.Value, &regexp)
+		return typed("regexp", regexp)
+	case "array", "set":
+		if v.Value == nil {
+			return typed(v.Type, nil)
+		}
+		var items []deepValue
+		json.Unmarshal(v.Value, &items)
+		values := make([]interface{}, len(items))
+		for i, item := range items {
+			values[i] = c.convert(item)
+		}
+		if v.Type == "set" {
+			return typed("set", values)
+		}
+		return values
+	case "object":
+		if v.Value == nil {
+			return typed("object", nil)
+		}
+		var entries [][2]json.RawMessage
+		json.Unmarshal(v.Value, &entries)
+		values := make(map[string]interface{}, len(entries))
+		for _, entry := range entries {
+			var key string
+			var item deepValue
+			if json.Unmarshal(entry[0], &key) != nil || json.Unmarshal(entry[1], &item) != nil {
+				continue
+			}
+			values[key] = c.convert(item)
+		}
+		return values
+	case "map":
+		if v.Value == nil {
+			return typed("map", nil)
+		}
+		// Keys may be any value, so entries stay pairs
+		var entries [][2]json.RawMessage
+		json.Unmarshal(v.Value, &entries)
+		pairs := make([]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			var key interface{}
+			var keyValue deepValue
+			if json.Unmarshal(entry[0], &keyValue) == nil && keyValue.Type != "" {
+				key = c.convert(keyValue)
+			} else {
+				json.Unmarshal(entry[0], &key)
+			}
+			var item deepValue
+			json.Unmarshal(entry[1], &item)
+			pairs = append(pairs, []interface{}{key, c.convert(item)})
+		}
+		return typed("map", pairs)
+	case "node":
+		return c.node(v)
+	default:
+		// undefined, functions, symbols, promises, errors, windows and the like
+		return typed(v.Type, nil)
+	}
+}
+
+// node describes a DOM node and, while under maxResultNodes, resolves it to an object ID
+func (c *deepConverter) node(v deepValue) map[string]interface{} {
+	var node deepNode
+	json.Unmarshal(v.Value, &node)
+
+	described := map[string]interface{}{"$type": "node", "node_type": node.NodeType}
+	if node.LocalName != "" {
+		described["node_name"] = node.LocalName
+	}
+	if node.NodeValue != "" {
+		described["node_value"] = node.NodeValue
+	}
+	if len(node.Attributes) > 0 {
+		described["attributes"] = node.Attributes
+	}
+	described["child_count"] = node.ChildNodeCount
+
+	if node.BackendNodeID == 0 || c.nodes >= maxResultNodes {
+		return described
+	}
+	result, err := c.session.CDPClient.SendCommandToTarget(c.ctx, c.targetID, "DOM.resolveNode", map[string]interface{}{
+		"backendNodeId": node.BackendNodeID,
+		"objectGroup":   resultObjectGroup,
+	})
+	if err != nil {
+		return described
+	}
+	var resolved struct {
+		Object struct {
+			ObjectID string `json:"objectId"`
+		} `json:"object"`
+	}
+	if json.Unmarshal(result, &resolved) == nil && resolved.Object.ObjectID != "" {
+		described["object_id"] = resolved.Object.ObjectID
+		c.nodes++
+	}
+	return described
+}
+
+// checkResultSize refuses a script result whose JSON passes maxBytes
+func checkResultSize(result interface{}, maxBytes int64) error {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode script result: %w", err)
+	}
+	if int64(len(encoded)) > maxBytes {
+		return fmt.Errorf("%w: script result is %d bytes (max %d)", ErrResponseTooLarge, len(encoded), maxBytes)
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// The ServiceWorker domain reports registrations as events after it is enabled, and
+// sends none when there are none: the first is waited for this long, and the report
+// is complete once no more arrive for serviceWorkerQuietPeriod
+const (
+	serviceWorkerReportWait  = 500 * time.Millisecond
+	serviceWorkerQuietPeriod = 100 * time.Millisecond
+)
+
+// ServiceWorker is a service worker registration in a session's browser context
+type ServiceWorker struct {
+	RegistrationID string `json:"registration_id"`
+	ScopeURL       string `json:"scope_url"`
+	ScriptURL      string `json:"script_url,omitempty"`
+	Status         string `json:"status,omitempty"`         // Of the newest version: new, installing, installed, activating, activated or redundant
+	RunningStatus  string `json:"running_status,omitempty"` // stopped, starting, running or stopping
+	versionID      int64
+}
+
+// serviceWorkerCollector gathers the registrations and versions the ServiceWorker
+// domain reports
+type serviceWorkerCollector struct {
+	mu            sync.Mutex
+	registrations map[string]*ServiceWorker
+	deleted       map[string]bool
+	updated       chan struct{} // Signalled, without blocking, on every event
+}
+
+func newServiceWorkerCollector() *serviceWorkerCollector {
+	return &serviceWorkerCollector{
+		registrations: make(map[string]*ServiceWorker),
+		deleted:       make(map[string]bool),
+		updated:       make(chan struct{}, 1),
+	}
+}
+
+// registration returns the registration with id, creating it if this is the first event about it
+func (c *serviceWorkerCollector) registration(id string) *ServiceWorker {
+	worker, exists := c.registrations[id]
+	if !exists {
+		worker = &ServiceWorker{RegistrationID: id, versionID: -1}
+		c.registrations[id] = worker
+	}
+	return worker
+}
+
+// notify wakes a waiting settle
+func (c *serviceWorkerCollector) notify() {
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+}
+
+// onRegistrationUpdated records registrations and drops deleted ones
+func (c *serviceWorkerCollector) onRegistrationUpdated(event *cdp.Event) {
+	var params struct {
+		Registrations []struct {
+			RegistrationID string `json:"registrationId"`
+			ScopeURL       string `json:"scopeURL"`
+			IsDeleted      bool   `json:"isDeleted"`
+		} `json:"registrations"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil {
+		return
+	}
+	c.mu.Lock()
+	for _, registration := range params.Registrations {
+		if registration.IsDeleted {
+			c.deleted[registration.RegistrationID] = true
+			continue
+		}
+		delete(c.deleted, registration.RegistrationID)
+		c.registration(registration.RegistrationID).ScopeURL = registration.ScopeURL
+	}
+	c.mu.Unlock()
+	c.notify()
+}
+
+// onVersionUpdated records the script and state of each registration's newest version
+func (c *serviceWorkerCollector) onVersionUpdated(event *cdp.Event) {
+	var params struct {
+		Versions []struct {
+			VersionID      string `json:"versionId"`
+			RegistrationID string `json:"registrationId"`
+			ScriptURL      string `json:"scriptURL"`
+			RunningStatus  string `json:"runningStatus"`
+			Status         string `json:"status"`
+		} `json:"versions"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil {
+		return
+	}
+	c.mu.Lock()
+	for _, version := range params.Versions {
+		versionID, _ := strconv.ParseInt(version.VersionID, 10, 64)
+		worker := c.registration(version.RegistrationID)
+		if versionID < worker.versionID {
+			continue
+		}
+		worker.versionID = versionID
+		worker.ScriptURL = version.ScriptURL
+		worker.Status = version.Status
+		worker.RunningStatus = version.RunningStatus
+	}
+	c.mu.Unlock()
+	c.notify()
+}
+
+// settle waits until the domain has reported every registration
+func (c *serviceWorkerCollector) settle(ctx context.Context) error {
+	wait := serviceWorkerReportWait
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-c.updated:
+			timer.Stop()
+			wait = serviceWorkerQuietPeriod
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// list returns the live registrations by scope. Versions whose registration was
+// never reported are left out.
+func (c *serviceWorkerCollector) list() []ServiceWorker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	workers := make([]ServiceWorker, 0, len(c.registrations))
+	for id, worker := range c.registrations {
+		if worker.ScopeURL != "" && !c.deleted[id] {
+			workers = append(workers, *worker)
+		}
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].ScopeURL < workers[j].ScopeURL })
+	return workers
+}
+
+// withServiceWorkers enables the ServiceWorker domain on a page, hands fn the browser
+// context's registrations and disables the domain again; the domain's commands only
+// work while it is enabled
+func (s *Session) withServiceWorkers(ctx context.Context, pageID string, fn func([]ServiceWorker) error) error {
+	cdpSessionID, err := s.CDPClient.AttachToTarget(ctx, pageID)
+	if err != nil {
+		return fmt.Errorf("failed to attach for service workers: %w", err)
+	}
+
+	collector := newServiceWorkerCollector()
+	unsubscribeRegistrations := s.CDPClient.OnEvent("ServiceWorker.workerRegistrationUpdated", cdpSessionID, collector.onRegistrationUpdated)
+	defer unsubscribeRegistrations()
+	unsubscribeVersions := s.CDPClient.OnEvent("ServiceWorker.workerVersionUpdated", cdpSessionID, collector.onVersionUpdated)
+	defer unsubscribeVersions()
+
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "ServiceWorker.enable", nil); err != nil {
+		return fmt.Errorf("failed to enable service worker inspection: %w", err)
+	}
+	defer func() {
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "ServiceWorker.disable", nil); err != nil {
+			slog.Warn("failed to disable service worker inspection", "page_id", pageID, "error", err)
+		}
+	}()
+
+	if err := collector.settle(ctx); err != nil {
+		return err
+	}
+	return fn(collector.list())
+}
+
+// ServiceWorkers lists the service worker registrations of a session's browser
+// context, read through one of its pages
+func (m *Manager) ServiceWorkers(ctx context.Context, sessionID string, pageID string) ([]ServiceWorker, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "service workers")
+	if err != nil {
+		return nil, err
+	}
+
+	var workers []ServiceWorker
+	err = session.withServiceWorkers(ctx, pageID, func(registrations []ServiceWorker) error {
+		workers = registrations
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return workers, nil
+}
+
+// UnregisterServiceWorkers unregisters the service worker registered for scopeURL, or
+// every one when scopeURL is empty, and returns those unregistered. Pages a worker
+// already controls stay controlled until they load again.
+func (m *Manager) UnregisterServiceWorkers(ctx context.Context, sessionID string, pageID string, scopeURL string) ([]ServiceWorker, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "service workers")
+	if err != nil {
+		return nil, err
+	}
+
+	unregistered := []ServiceWorker{}
+	err = session.withServiceWorkers(ctx, pageID, func(registrations []ServiceWorker) error {
+		for _, worker := range registrations {
+			if scopeURL != "" && worker.ScopeURL != scopeURL {
+				continue
+			}
+			params := map[string]interface{}{"scopeURL": worker.ScopeURL}
+			if _, err := session.CDPClient.SendCommandToTarget(ctx, pageID, "ServiceWorker.unregister", params); err != nil {
+				return fmt.Errorf("failed to unregister service worker for %s: %w", worker.ScopeURL, err)
+			}
+			unregistered = append(unregistered, worker)
+		}
+		if scopeURL != "" && len(unregistered) == 0 {
+			return fmt.Errorf("%w: %s", ErrServiceWorkerNotFound, scopeURL)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return unregistered, nil
+}
+
+// bypassServiceWorker has a page's requests skip service workers, going to the
+// network as if none were registered, for as long as the page is open
+func (s *Session) bypassServiceWorker(ctx context.Context, pageID string) error {
+	params := map[string]interface{}{"bypass": true}
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "Network.setBypassServiceWorker", params); err != nil {
+		return fmt.Errorf("failed to bypass service workers: %w", err)
+	}
+	return nil
+}
+
+// CacheStorage is the CacheStorage of one origin, the caches service workers and pages
+// keep responses in
+type CacheStorage struct {
+	Origin string         `json:"origin"`
+	Caches []StorageCache `json:"caches"`
+}
+
+// StorageCache is one named cache of an origin's CacheStorage
+type StorageCache struct {
+	Name    string `json:"name"`
+	Entries int64  `json:"entries"`
+	id      string
+}
+
+// cacheStorage lists an origin's caches with the number of responses each holds
+func (s *Session) cacheStorage(ctx context.Context, pageID, origin string) (*CacheStorage, error) {
+	result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "CacheStorage.requestCacheNames", map[string]interface{}{
+		"securityOrigin": origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list caches: %w", err)
+	}
+	var response struct {
+		Caches []struct {
+			CacheID   string `json:"cacheId"`
+			CacheName string `json:"cacheName"`
+		} `json:"caches"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse caches: %w", err)
+	}
+
+	storage := &CacheStorage{Origin: origin, Caches: make([]StorageCache, 0, len(response.Caches))}
+	for _, cache := range response.Caches {
+		// One entry is enough to learn the count
+		result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "CacheStorage.requestEntries", map[string]interface{}{
+			"cacheId":   cache.CacheID,
+			"skipCount": 0,
+			"pageSize":  1,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache %s: %w", cache.CacheName, err)
+		}
+		var entries struct {
+			ReturnCount float64 `json:"returnCount"`
+		}
+		if err := json.Unmarshal(result, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse cache %s: %w", cache.CacheName, err)
+		}
+		storage.Caches = append(storage.Caches, StorageCache{Name: cache.CacheName, Entries: int64(entries.ReturnCount), id: cache.CacheID})
+	}
+	return storage, nil
+}
+
+// InspectCacheStorage lists the caches an origin keeps in a session's browser context,
+// read through one of its pages. origin defaults to the page's.
+func (m *Manager) InspectCacheStorage(ctx context.Context, sessionID string, pageID string, origin string) (*CacheStorage, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "cache storage")
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err = session.storageOrigin(ctx, pageID, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := session.cacheStorage(ctx, pageID, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return storage, nil
+}
+
+// ClearCacheStorage deletes the cache called name from an origin's CacheStorage, or
+// every cache when name is empty, and returns the caches deleted
+func (m *Manager) ClearCacheStorage(ctx context.Context, sessionID string, pageID string, origin string, name string) (*CacheStorage, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "cache storage")
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err = session.storageOrigin(ctx, pageID, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := session.cacheStorage(ctx, pageID, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := &CacheStorage{Origin: origin, Caches: []StorageCache{}}
+	for _, cache := range storage.Caches {
+		if name != "" && cache.Name != name {
+			continue
+		}
+		if _, err := session.CDPClient.SendCommandToTarget(ctx, pageID, "CacheStorage.deleteCache", map[string]interface{}{"cacheId": cache.id}); err != nil {
+			return nil, fmt.Errorf("failed to delete cache %s: %w", cache.Name, err)
+		}
+		deleted.Caches = append(deleted.Caches, cache)
+	}
+	if name != "" && len(deleted.Caches) == 0 {
+		return nil, fmt.Errorf("%w: %s has no cache %q", ErrCacheNotFound, origin, name)
+	}
+
+	session.UpdateActivity()
+	return deleted, nil
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestServiceWorkers tests gathering registrations from ServiceWorker events, listing
+// and clearing CacheStorage, and navigating past service workers
+func TestServiceWorkers(t *testing.T) {
+	collector := newServiceWorkerCollector()
+	event := func(method, params string) *cdp.Event {
+		return &cdp.Event{Method: method, Params: []byte(params)}
+	}
+	collector.onVersionUpdated(event("ServiceWorker.workerVersionUpdated", `{"versions": [{"versionId": "4", "registrationId": "1", "scriptURL": "https://shop.example.com/sw.js?v=2", "status": "installed", "runningStatus": "stopped"}]}`))
+	collector.onRegistrationUpdated(event("ServiceWorker.workerRegistrationUpdated", `{"registrations": [{"registrationId": "1", "scopeURL": "https://shop.example.com/"}, {"registrationId": "2", "scopeURL": "https://blog.example.com/"}, {"registrationId": "3", "scopeURL": "https://old.example.com/"}]}`))
+	collector.onVersionUpdated(event("ServiceWorker.workerVersionUpdated", `{"versions": [{"versionId": "3", "registrationId": "1", "scriptURL": "https://shop.example.com/sw.js?v=1", "status": "activated", "runningStatus": "running"}]}`))
+	collector.onRegistrationUpdated(event("ServiceWorker.workerRegistrationUpdated", `{"registrations": [{"registrationId": "3", "scopeURL": "https://old.example.com/", "isDeleted": true}]}`))
+
+	workers := collector.list()
+	if len(workers) != 2 || workers[0].ScopeURL != "https://blog.example.com/" || workers[1].RegistrationID != "1" {
+		t.Fatalf("expected the blog and shop registrations, got %+v", workers)
+	}
+	if shop := workers[1]; shop.ScriptURL != "https://shop.example.com/sw.js?v=2" || shop.Status != "installed" {
+		t.Errorf("expected the newest version of the shop's worker, got %+v", shop)
+	}
+
+	var mu sync.Mutex
+	var methods []string
+	var deleted []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression     string `json:"expression"`
+			SecurityOrigin string `json:"securityOrigin"`
+			CacheID        string `json:"cacheId"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		methods = append(methods, method)
+		switch method {
+		case "Runtime.evaluate":
+			if p.Expression == "location.origin" {
+				return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "https://shop.example.com"}}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		case "CacheStorage.requestCacheNames":
+			if p.SecurityOrigin != "https://shop.example.com" {
+				return map[string]interface{}{"caches": []interface{}{}}
+			}
+			return map[string]interface{}{"caches": []interface{}{
+				map[string]interface{}{"cacheId": "c1", "cacheName": "pages-v1", "securityOrigin": p.SecurityOrigin},
+				map[string]interface{}{"cacheId": "c2", "cacheName": "images", "securityOrigin": p.SecurityOrigin},
+			}}
+		case "CacheStorage.requestEntries":
+			if p.CacheID == "c1" {
+				return map[string]interface{}{"cacheDataEntries": []interface{}{}, "returnCount": 14}
+			}
+			return map[string]interface{}{"cacheDataEntries": []interface{}{}, "returnCount": 3}
+		case "CacheStorage.deleteCache":
+			deleted = append(deleted, p.CacheID)
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	pageID, err := manager.NavigateWithOptions(ctx, sess.ID, "https://shop.example.com", NavigateOptions{BypassServiceWorker: true})
+	if err != nil {
+		t.Fatalf("NavigateWithOptions failed: %v", err)
+	}
+	mu.Lock()
+	bypass, navigate := slices.Index(methods, "Network.setBypassServiceWorker"), slices.Index(methods, "Page.navigate")
+	mu.Unlock()
+	if bypass < 0 || bypass > navigate {
+		t.Errorf("expected service workers bypassed before the page loaded, got %v", methods)
+	}
+
+	storage, err := manager.InspectCacheStorage(ctx, sess.ID, pageID, "")
+	if err != nil {
+		t.Fatalf("InspectCacheStorage failed: %v", err)
+	}
+	if storage.Origin != "https://shop.example.com" || len(storage.Caches) != 2 || storage.Caches[0].Entries != 14 || storage.Caches[1].Name != "images" {
+		t.Errorf("unexpected cache storage: %+v", storage)
+	}
+
+	cleared, err := manager.ClearCacheStorage(ctx, sess.ID, pageID, "", "pages-v1")
+	if err != nil {
+		t.Fatalf("ClearCacheStorage failed: %v", err)
+	}
+	mu.Lock()
+	if len(cleared.Caches) != 1 || len(deleted) != 1 || deleted[0] != "c1" {
+		t.Errorf("expected only pages-v1 deleted, got %+v (%v)", cleared, deleted)
+	}
+	mu.Unlock()
+	if _, err := manager.ClearCacheStorage(ctx, sess.ID, pageID, "", "missing"); !errors.Is(err, ErrCacheNotFound) {
+		t.Errorf("expected ErrCacheNotFound, got %v", err)
+	}
+	if _, err := manager.InspectCacheStorage(ctx, sess.ID, pageID, "file:///etc"); !errors.Is(err, ErrInvalidStorage) {
+		t.Errorf("expected ErrInvalidStorage, got %v", err)
+	}
+
+	// The fake browser reports no registrations, so the scope isn't found; the domain is
+	// turned off again either way
+	if _, err := manager.UnregisterServiceWorkers(ctx, sess.ID, pageID, "https://shop.example.com/"); !errors.Is(err, ErrServiceWorkerNotFound) {
+		t.Errorf("expected ErrServiceWorkerNotFound, got %v", err)
+	}
+	mu.Lock()
+	if !slices.Contains(methods, "ServiceWorker.enable") || methods[len(methods)-1] != "ServiceWorker.disable" {
+		t.Errorf("expected the ServiceWorker domain enabled and disabled, got %v", methods)
+	}
+	mu.Unlock()
+}
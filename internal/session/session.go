@@ -1,9 +1,11 @@
 package session
 
 import (
-	"encoding/base64"
+	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
@@ -13,17 +15,33 @@ import (
 type SessionStatus string
 
 const (
-	SessionActive  SessionStatus = "active"   // Session is running
-	SessionClosed  SessionStatus = "closed"   // Session was explicitly closed
-	SessionIdle    SessionStatus = "idle"     // Session is idle
-	SessionExpired SessionStatus = "expired"  // Session timed out
+	SessionActive     SessionStatus = "active"     // Session is running
+	SessionClosed     SessionStatus = "closed"     // Session was explicitly closed
+	SessionIdle       SessionStatus = "idle"       // Session is idle
+	SessionExpired    SessionStatus = "expired"    // Session timed out
+	SessionHibernated SessionStatus = "hibernated" // Browser context released until the session is next used
 )
 
-// Session represents an AI agent's isolated browsing session
+// Session represents an AI agent's isolated browsing session.
+//
+// Concurrency: a *Session is shared by every request that names it, so
+//   - PageIDs, LastActivity, Status and the per-page caches are guarded by mu.
+//     Outside this file, read them through Pages, HasPage, LastActive and
+//     CurrentStatus; never index or range over PageIDs directly.
+//   - ID, Name, CreatedAt, Template and Options are fixed once the session is
+//     registered with the Manager.
+//   - AgentID and TenantID change only when the session is transferred to another
+//     owner, which happens under the Manager's lock.
+//   - ProcessPort, ContextID and CDPClient change only when the session is
+//     migrated to a restarted browser, which happens under the Manager's lock.
+//
+// Methods that talk to the browser don't hold mu while waiting on it, so two
+// requests may drive different pages of one session at the same time.
 type Session struct {
 	ID           string          // Unique session identifier
 	Name         string          // Session name
 	AgentID      string          // Agent ID
+	TenantID     string          // Tenant the session belongs to ("" is the default tenant)
 	ProcessPort  int             // Which browser process (9222, 9223, etc.)
 	ContextID    string          // CDP browser context ID
 	PageIDs      []string        // List of page IDs in this context
@@ -31,28 +49,104 @@ type Session struct {
 	CreatedAt    time.Time       // When session was created
 	LastActivity time.Time       // Last time session was used
 	Status       SessionStatus   // Current session status
+	Template     string          // Name of the template the session was created from
+	Options      *SessionOptions // Browser environment applied to every page (nil = defaults)
+	workDir      string          // Downloads and other files on disk, fixed once registered ("" = none)
+	bidi         *bidiDriver     // Driver of a Firefox session, fixed once registered (nil = CDP)
 
-	pageAnalysisCache map[string]*PageStructure // Cached page analysis results, keyed by pageID
+	pageAnalysisCache map[string]*PageStructure  // Cached page analysis results, keyed by pageID
+	captchaState      map[string]*CaptchaInfo    // Latest CAPTCHA detection, keyed by pageID
+	navigations       map[string]*NavigationInfo // Latest navigation report, keyed by pageID
+	media             map[string]*MediaEmulation // Emulated CSS media, keyed by pageID
+	screencasts       map[string]*screencastHub  // Active screencasts, keyed by pageID
+	screencastMu      sync.Mutex                 // Protects screencasts
+	watches           map[string]*pageWatches    // DOM watches, keyed by pageID
+	watchMu           sync.Mutex                 // Protects watches; never held while waiting on the browser
+	routes            map[string]*pageRoute      // Route tracking, keyed by pageID
+	routeMu           sync.Mutex                 // Protects routes; never held while waiting on the browser
+	handles           map[string]pageHandles     // Element handles, keyed by pageID
+	handleMu          sync.Mutex                 // Protects handles; never held while waiting on the browser
+	network           map[string]*pageNetwork    // Network capture, keyed by pageID
+	networkMu         sync.Mutex                 // Protects network; never held while waiting on the browser
+	clientCerts       *clientCertificates        // Where pages present client certificates, fixed once registered (nil = nowhere)
+	egressAddr        string                     // Egress proxy listener the session's contexts use, fixed once registered (empty = none)
+	bandwidth         *bandwidthMeter            // Counts what pages download, fixed once registered (nil = not metered)
+	watchSetupMu      sync.Mutex                 // Serializes adding and removing watches
+	warmPageID        string                     // Pre-opened page from the warm pool, used by the first navigation
+	warmMu            sync.Mutex                 // Protects warmPageID
+	sharedWith        map[string]time.Time       // Tenants given read-only access, with when
+	hibernation       *migration                 // What to restore while hibernated; guarded by hibernateMu
+	checkpoints       map[string]*Checkpoint     // Saved states new sessions can branch from
+	hibernateMu       sync.Mutex                 // Serializes hibernating and waking; held while waiting on the browser
+	memory            Memory                     // Earlier questions and page content, for follow-ups
+	memoryMu          sync.Mutex                 // Protects memory
+	mu                sync.RWMutex               // Protects PageIDs, LastActivity, Status, pageAnalysisCache, captchaState, navigations, media, sharedWith and checkpoints
 }
 
 // IsExpired checks if the session has been inactive too long
 func (s *Session) IsExpired(timeout time.Duration) bool {
-	return time.Since(s.LastActivity) > timeout
+	return time.Since(s.LastActive()) > timeout
 }
 
 // UpdateActivity updates the last activity timestamp
 func (s *Session) UpdateActivity() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.LastActivity = time.Now()
 }
 
+// LastActive returns when the session was last used
+func (s *Session) LastActive() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.LastActivity
+}
+
+// CurrentStatus returns the session's status
+func (s *Session) CurrentStatus() SessionStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Status
+}
+
+// setStatus changes the session's status
+func (s *Session) setStatus(status SessionStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Status = status
+}
+
+// Pages returns a copy of the session's page IDs, safe to range over while pages come and go
+func (s *Session) Pages() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.PageIDs)
+}
+
+// HasPage reports whether pageID belongs to the session
+func (s *Session) HasPage(pageID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Contains(s.PageIDs, pageID)
+}
+
 // AddPage tracks a new page in this session
 func (s *Session) AddPage(pageID string) {
-	s.PageIDs = append(s.PageIDs, pageID)
-	s.UpdateActivity()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Pages can be adopted from target events and tracked on creation, so keep it idempotent
+	if !slices.Contains(s.PageIDs, pageID) {
+		s.PageIDs = append(s.PageIDs, pageID)
+	}
+	s.LastActivity = time.Now()
 }
 
 // RemovePage removes a page from tracking
 func (s *Session) RemovePage(pageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	for i, id := range s.PageIDs {
 		if id == pageID {
 			// Remove by swapping with last element and truncating
@@ -61,16 +155,40 @@ func (s *Session) RemovePage(pageID string) {
 			break
 		}
 	}
-	s.UpdateActivity()
+	delete(s.captchaState, pageID)
+	delete(s.navigations, pageID)
+	delete(s.media, pageID)
+	delete(s.pageAnalysisCache, pageID)
+	s.LastActivity = time.Now()
+}
+
+// resetPages forgets every page and everything cached about them (their context is gone)
+func (s *Session) resetPages() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.PageIDs = []string{}
+	s.pageAnalysisCache = make(map[string]*PageStructure)
+	s.captchaState = nil
+	s.navigations = nil
+	s.media = nil
 }
 
-// CaptureScreenshot takes a screenshot of the page
-func (s *Session) CaptureScreenshot(targetID string) ([]byte, error) {
+// CaptureScreenshot takes a screenshot of the page, up to DefaultMaxResponseSize
+func (s *Session) CaptureScreenshot(ctx context.Context, targetID string) ([]byte, error) {
+	return s.captureScreenshot(ctx, targetID, DefaultMaxResponseSize)
+}
+
+// captureScreenshot takes a PNG screenshot, refusing one bigger than maxBytes. Unlike
+// printToPDF, Page.captureScreenshot has no transfer mode or stream handle: the image
+// always comes back inline as base64, so it can't be read through IO.read and the cap
+// is what bounds it.
+func (s *Session) captureScreenshot(ctx context.Context, targetID string, maxBytes int64) ([]byte, error) {
 	params := map[string]interface{}{
 		"format": "png",
 	}
 
-	result, err := s.CDPClient.SendCommandToTarget(targetID, "Page.captureScreenshot", params)
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.captureScreenshot", params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
 	}
@@ -83,50 +201,41 @@ func (s *Session) CaptureScreenshot(targetID string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to parse screenshot response: %w", err)
 	}
 
-	imageBytes, err := base64.StdEncoding.DecodeString(response.Data)
+	imageBytes, err := decodeCapped(response.Data, "screenshot", maxBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+		return nil, err
 	}
 
 	return imageBytes, nil
 }
 
-// ExecuteJavascript executes JavaScript code on the page
-func (s *Session) ExecuteJavascript(targetID string, code string) (interface{}, error) {
+// ExecuteJavascript executes JavaScript code on the page. A promise the code returns is
+// awaited; an exception it throws, or a rejection, is returned as a *ScriptError.
+func (s *Session) ExecuteJavascript(ctx context.Context, targetID string, code string) (interface{}, error) {
 	params := map[string]interface{}{
 		"expression":    code,
 		"returnByValue": true,
+		"awaitPromise":  true,
 	}
 
-	result, err := s.CDPClient.SendCommandToTarget(targetID, "Runtime.evaluate", params)
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.evaluate", params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute javascript: %w", err)
 	}
 
-	var response struct {
-		Result struct {
-			Type  string      `json:"type"`
-			Value interface{} `json:"value"`
-		} `json:"result"`
-		ExceptionDetails interface{} `json:"exceptionDetails,omitempty"`
-	}
-
-	if err := json.Unmarshal(result, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse execution result: %w", err)
-	}
-
-	if response.ExceptionDetails != nil {
-		return nil, fmt.Errorf("javascript execution error: %v", response.ExceptionDetails)
+	response, err := parseRuntimeResult(result)
+	if err != nil {
+		return nil, err
 	}
 
 	return response.Result.Value, nil
 }
 
 // WaitForReady waits until document.readyState is interactive/complete or timeout.
-func (s *Session) WaitForReady(targetID string, timeout time.Duration) error {
+func (s *Session) WaitForReady(ctx context.Context, targetID string, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
-		result, err := s.ExecuteJavascript(targetID, "document.readyState")
+		result, err := s.ExecuteJavascript(ctx, targetID, "document.readyState")
 		if err == nil {
 			if state, ok := result.(string); ok {
 				if state == "interactive" || state == "complete" {
@@ -134,15 +243,35 @@ func (s *Session) WaitForReady(targetID string, timeout time.Duration) error {
 				}
 			}
 		}
-		time.Sleep(200 * time.Millisecond)
+		if err := sleepContext(ctx, 200*time.Millisecond); err != nil {
+			return err
+		}
 	}
 	return fmt.Errorf("page did not reach ready state within %s", timeout)
 }
 
-// GetPageContent gets the HTML content of a page
-func (s *Session) GetPageContent(targetID string) (string, error) {
+// sleepContext pauses between polls, returning early with ctx's error if it is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// GetPageContent gets the HTML content of a page, up to DefaultMaxResponseSize
+func (s *Session) GetPageContent(ctx context.Context, targetID string) (string, error) {
+	return s.pageContent(ctx, targetID, DefaultMaxResponseSize)
+}
+
+// outerHTML reads the whole document in one DevTools message
+func (s *Session) outerHTML(ctx context.Context, targetID string, maxBytes int64) (string, error) {
 	// Step 1: Get document
-	result, err := s.CDPClient.SendCommandToTarget(targetID, "DOM.getDocument", nil)
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "DOM.getDocument", nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to get document: %w", err)
 	}
@@ -162,7 +291,7 @@ func (s *Session) GetPageContent(targetID string) (string, error) {
 		"nodeId": docResponse.Root.NodeID,
 	}
 
-	result, err = s.CDPClient.SendCommandToTarget(targetID, "DOM.getOuterHTML", params)
+	result, err = s.CDPClient.SendCommandToTarget(ctx, targetID, "DOM.getOuterHTML", params)
 	if err != nil {
 		return "", fmt.Errorf("failed to get outer HTML: %w", err)
 	}
@@ -175,5 +304,55 @@ func (s *Session) GetPageContent(targetID string) (string, error) {
 		return "", fmt.Errorf("failed to parse HTML response: %w", err)
 	}
 
+	if int64(len(htmlResponse.OuterHTML)) > maxBytes {
+		return "", fmt.Errorf("%w: page content is %d bytes (max %d)", ErrResponseTooLarge, len(htmlResponse.OuterHTML), maxBytes)
+	}
+
 	return htmlResponse.OuterHTML, nil
 }
+
+// navigationMarker is a window property set before an action that may navigate.
+// A fresh document never has it, so its disappearance signals a completed navigation.
+const navigationMarker = "__bqaNavMarker"
+
+// MarkDocument tags the current document so WaitForNavigation can detect when it is replaced
+func (s *Session) MarkDocument(ctx context.Context, targetID string) error {
+	if _, err := s.ExecuteJavascript(ctx, targetID, "window."+navigationMarker+" = true"); err != nil {
+		return fmt.Errorf("failed to mark document: %w", err)
+	}
+	return nil
+}
+
+// WaitForNavigation waits until the marked document is replaced by a new one and the
+// new document is ready. It returns false (without error) if no navigation happened in time.
+func (s *Session) WaitForNavigation(ctx context.Context, targetID string, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		// Evaluation can fail while the old document is being torn down - just retry
+		result, err := s.ExecuteJavascript(ctx, targetID, "typeof window."+navigationMarker)
+		if err == nil {
+			if kind, ok := result.(string); ok && kind == "undefined" {
+				remaining := time.Until(deadline)
+				if remaining < time.Second {
+					remaining = time.Second
+				}
+				return true, s.WaitForReady(ctx, targetID, remaining)
+			}
+		}
+		if err := sleepContext(ctx, 200*time.Millisecond); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// GetCurrentURL returns the URL of the document currently loaded in a page
+func (s *Session) GetCurrentURL(ctx context.Context, targetID string) (string, error) {
+	result, err := s.ExecuteJavascript(ctx, targetID, "location.href")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current URL: %w", err)
+	}
+
+	url, _ := result.(string)
+	return url, nil
+}
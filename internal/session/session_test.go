@@ -0,0 +1,45 @@
+package session
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSessionConcurrentPages tests that pages can be tracked and read from many
+// goroutines at once (run with -race)
+func TestSessionConcurrentPages(t *testing.T) {
+	sess := &Session{ID: "sess_test", Status: SessionActive, LastActivity: time.Now()}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pageID := fmt.Sprintf("PAGE-%d-%d", worker, j)
+				sess.AddPage(pageID)
+				if !sess.HasPage(pageID) {
+					t.Errorf("expected %s to be tracked", pageID)
+					return
+				}
+				for range sess.Pages() {
+				}
+				sess.InvalidatePageAnalysis(pageID)
+				sess.RemovePage(pageID)
+				sess.IsExpired(time.Minute)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if pages := sess.Pages(); len(pages) != 0 {
+		t.Errorf("expected every page removed, got %v", pages)
+	}
+
+	sess.setStatus(SessionIdle)
+	if sess.CurrentStatus() != SessionIdle {
+		t.Errorf("expected status %s, got %s", SessionIdle, sess.CurrentStatus())
+	}
+}
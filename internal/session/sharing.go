@@ -0,0 +1,156 @@
+package session
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Ways a session can be handed to another owner
+const (
+	ShareReadOnly = "read_only" // Another tenant may look at the session but not drive it
+	ShareTransfer = "transfer"  // The session moves to another tenant or agent
+)
+
+// SessionShare is a read-only grant of a session to another tenant
+type SessionShare struct {
+	TenantID string    `json:"tenant_id"`
+	SharedAt time.Time `json:"shared_at"`
+}
+
+// IsSharedWith reports whether a tenant was given read-only access to the session
+func (s *Session) IsSharedWith(tenantID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, shared := s.sharedWith[tenantID]
+	return shared
+}
+
+// Shares returns the session's read-only grants, sorted by tenant
+func (s *Session) Shares() []SessionShare {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	shares := make([]SessionShare, 0, len(s.sharedWith))
+	for tenantID, sharedAt := range s.sharedWith {
+		shares = append(shares, SessionShare{TenantID: tenantID, SharedAt: sharedAt})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		return shares[i].TenantID < shares[j].TenantID
+	})
+	return shares
+}
+
+// ShareSession gives another tenant read-only access to a live session. Sharing with a
+// tenant twice keeps the first grant.
+func (m *Manager) ShareSession(sessionID, tenantID string) error {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if tenantID == session.TenantID {
+		return fmt.Errorf("%w: the session already belongs to tenant %q", ErrInvalidShare, tenantID)
+	}
+
+	session.mu.Lock()
+	if session.sharedWith == nil {
+		session.sharedWith = make(map[string]time.Time)
+	}
+	if _, shared := session.sharedWith[tenantID]; !shared {
+		session.sharedWith[tenantID] = time.Now()
+	}
+	session.mu.Unlock()
+
+	slog.Info("session shared read-only", "session_id", sessionID, "tenant_id", tenantID)
+	return nil
+}
+
+// UnshareSession revokes a tenant's read-only access to a session
+func (m *Manager) UnshareSession(sessionID, tenantID string) error {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	session.mu.Lock()
+	_, shared := session.sharedWith[tenantID]
+	delete(session.sharedWith, tenantID)
+	session.mu.Unlock()
+
+	if !shared {
+		return fmt.Errorf("%w: %q", ErrShareNotFound, tenantID)
+	}
+
+	slog.Info("session share revoked", "session_id", sessionID, "tenant_id", tenantID)
+	return nil
+}
+
+// TransferSession hands a session to another tenant's agent, who then owns it outright.
+// The previous owner loses access, and the new owner's quotas and name space apply.
+func (m *Manager) TransferSession(sessionID, tenantID, agentID string) error {
+	if agentID == "" {
+		return fmt.Errorf("%w: agent_id is required", ErrInvalidShare)
+	}
+
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fromTenant, fromAgent := session.TenantID, session.AgentID
+	if tenantID == fromTenant && agentID == fromAgent {
+		return fmt.Errorf("%w: the session already belongs to agent %q", ErrInvalidShare, agentID)
+	}
+
+	// The session counts against the new tenant's quotas from now on
+	if tenantID != fromTenant {
+		if err := m.checkTenantQuotasLocked(tenantID, session.ProcessPort); err != nil {
+			return err
+		}
+	}
+
+	if m.repo != nil {
+		count, err := m.repo.CountAgentSessions(tenantID, agentID)
+		if err == nil && count >= m.maxSessionsPerAgent {
+			return fmt.Errorf("%w: agent has %d sessions (max %d)",
+				ErrSessionLimitReached, count, m.maxSessionsPerAgent)
+		}
+
+		if session.Name != "" {
+			exists, err := m.repo.CheckSessionNameExists(tenantID, agentID, session.Name)
+			if err != nil {
+				return fmt.Errorf("failed to check session name: %w", err)
+			}
+			if exists {
+				return ErrSessionNameConflict
+			}
+		}
+
+		if err := m.repo.TransferSession(sessionID, session.Name, fromTenant, fromAgent, tenantID, agentID); err != nil {
+			return fmt.Errorf("failed to transfer session in Redis: %w", err)
+		}
+	}
+
+	// Owner fields only change under the manager's lock, like a migration's
+	session.TenantID = tenantID
+	session.AgentID = agentID
+
+	// A grant to the new owner is redundant now
+	session.mu.Lock()
+	delete(session.sharedWith, tenantID)
+	session.mu.Unlock()
+
+	slog.Info("session ownership transferred",
+		"session_id", sessionID,
+		"from_tenant", fromTenant,
+		"from_agent", fromAgent,
+		"to_tenant", tenantID,
+		"to_agent", agentID)
+
+	return nil
+}
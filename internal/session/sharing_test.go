@@ -0,0 +1,74 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestShareSession tests granting and revoking read-only access to another tenant
+func TestShareSession(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	session := &Session{ID: "s1", TenantID: "acme", AgentID: "crawler", LastActivity: time.Now()}
+	manager.sessions["s1"] = session
+
+	if err := manager.ShareSession("s1", "acme"); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("expected sharing with the owner to fail with ErrInvalidShare, got %v", err)
+	}
+
+	if err := manager.ShareSession("s1", "beta"); err != nil {
+		t.Fatalf("failed to share session: %v", err)
+	}
+	if !session.IsSharedWith("beta") || session.IsSharedWith("gamma") {
+		t.Error("expected the session to be shared with beta only")
+	}
+	if shares := session.Shares(); len(shares) != 1 || shares[0].TenantID != "beta" {
+		t.Errorf("expected one share with beta, got %+v", shares)
+	}
+
+	if err := manager.UnshareSession("s1", "beta"); err != nil {
+		t.Fatalf("failed to revoke share: %v", err)
+	}
+	if session.IsSharedWith("beta") {
+		t.Error("expected the share to be revoked")
+	}
+	if err := manager.UnshareSession("s1", "beta"); !errors.Is(err, ErrShareNotFound) {
+		t.Errorf("expected ErrShareNotFound, got %v", err)
+	}
+}
+
+// TestTransferSession tests handing a session to another tenant's agent
+func TestTransferSession(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	session := &Session{ID: "s1", TenantID: "acme", AgentID: "crawler", ProcessPort: 9222, LastActivity: time.Now()}
+	manager.sessions["s1"] = session
+
+	if err := manager.TransferSession("s1", "acme", "crawler"); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("expected a transfer to the current owner to fail with ErrInvalidShare, got %v", err)
+	}
+
+	if err := manager.ShareSession("s1", "beta"); err != nil {
+		t.Fatalf("failed to share session: %v", err)
+	}
+	if err := manager.TransferSession("s1", "beta", "summarizer"); err != nil {
+		t.Fatalf("failed to transfer session: %v", err)
+	}
+	if tenantID, _ := manager.SessionTenant("s1"); tenantID != "beta" || session.AgentID != "summarizer" {
+		t.Errorf("expected beta/summarizer to own the session, got %s/%s", tenantID, session.AgentID)
+	}
+	if session.IsSharedWith("beta") {
+		t.Error("expected the new owner's read-only share to be dropped")
+	}
+
+	// The new tenant's quotas apply
+	manager.SetTenantQuotas(fixedQuotas{sessions: 1})
+	manager.sessions["s2"] = &Session{ID: "s2", TenantID: "gamma", ProcessPort: 9222, LastActivity: time.Now()}
+	if err := manager.TransferSession("s1", "gamma", "summarizer"); !errors.Is(err, ErrTenantSessionLimit) {
+		t.Errorf("expected ErrTenantSessionLimit, got %v", err)
+	}
+	if session.TenantID != "beta" {
+		t.Errorf("expected a refused transfer to leave the owner alone, got %s", session.TenantID)
+	}
+}
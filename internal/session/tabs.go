@@ -0,0 +1,65 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// TabInfo describes a live page in a session's browser context
+type TabInfo struct {
+	PageID   string `json:"page_id"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	OpenerID string `json:"opener_id,omitempty"` // Page that opened this one (window.open, target=_blank)
+	Adopted  bool   `json:"adopted,omitempty"`   // Was untracked until this listing
+
+	// Route is the page's logical location, kept current through single-page app route changes
+	Route *RouteInfo `json:"route,omitempty"`
+
+	// Security is what the document of the page's last recorded navigation was fetched over
+	Security *SecurityDetails `json:"security,omitempty"`
+}
+
+// ListTabs returns the page targets that live in this session's browser context.
+// The browser is the source of truth: PageIDs may miss pages the site opened itself.
+func (s *Session) ListTabs(ctx context.Context) ([]TabInfo, error) {
+	targets, err := s.CDPClient.GetTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+
+	tabs := make([]TabInfo, 0)
+	for _, target := range targets {
+		if target.Type != "page" || target.BrowserContextID != s.ContextID {
+			continue
+		}
+
+		// The unclaimed warm page is an implementation detail, not a tab the agent opened
+		if s.isWarmPage(target.TargetID) {
+			continue
+		}
+
+		tabs = append(tabs, TabInfo{
+			PageID:   target.TargetID,
+			Title:    target.Title,
+			URL:      target.URL,
+			OpenerID: target.OpenerID,
+			Route:    s.Route(target.TargetID),
+		})
+	}
+
+	return tabs, nil
+}
+
+// BringToFront activates a page so it receives focus and renders at full rate
+func (s *Session) BringToFront(ctx context.Context, targetID string) error {
+	if err := s.CDPClient.ActivateTarget(ctx, targetID); err != nil {
+		return err
+	}
+
+	if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.bringToFront", nil); err != nil {
+		return fmt.Errorf("failed to bring page to front: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// TestListPages tests that listing takes the session's pages live from the browser,
+// adopting popups it wasn't tracking and dropping pages that are gone, and that a
+// duplicate opens the page's current URL as a page of its own
+func TestListPages(t *testing.T) {
+	var created atomic.Int32
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression string `json:"expression"`
+		}
+		json.Unmarshal(params, &p)
+
+		switch {
+		case method == "Target.createTarget" && created.Add(1) > 1:
+			return map[string]interface{}{"targetId": "page-2"}
+		case method == "Target.getTargets":
+			return map[string]interface{}{"targetInfos": []map[string]interface{}{
+				{"targetId": "page-1", "type": "page", "title": "Cart", "url": "https://example.com/cart", "browserContextId": "ctx-1"},
+				{"targetId": "popup-1", "type": "page", "title": "Sign in", "url": "https://example.com/oauth", "openerId": "page-1", "browserContextId": "ctx-1"},
+				{"targetId": "worker-1", "type": "service_worker", "url": "https://example.com/sw.js", "browserContextId": "ctx-1"},
+				{"targetId": "page-9", "type": "page", "url": "https://other.example/", "browserContextId": "ctx-2"},
+			}}
+		case method == "Runtime.evaluate" && strings.Contains(p.Expression, "location.href"):
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "https://example.com/cart"}}
+		}
+		return nil
+	})
+	sess, pageID := openTestPage(t, manager, nil, "https://example.com/cart")
+	ctx := context.Background()
+
+	// A page the session still tracks but the site has closed
+	sess.AddPage("page-gone")
+
+	tabs, err := manager.ListPages(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("ListPages failed: %v", err)
+	}
+	if len(tabs) != 2 || tabs[0].PageID != pageID || tabs[0].Title != "Cart" || tabs[0].Adopted {
+		t.Fatalf("expected the tracked page first, got %+v", tabs)
+	}
+	if popup := tabs[1]; popup.PageID != "popup-1" || popup.OpenerID != pageID || !popup.Adopted {
+		t.Errorf("expected the popup adopted with its opener, got %+v", popup)
+	}
+	if pages := sess.Pages(); !slices.Equal(pages, []string{pageID, "popup-1"}) {
+		t.Errorf("expected the popup tracked and page-gone dropped, got %v", pages)
+	}
+
+	var opened, closed []string
+	for _, event := range manager.Events().History(sess.ID, 0) {
+		switch event.Type {
+		case events.TypePageOpened:
+			opened = append(opened, event.PageID)
+		case events.TypePageClosed:
+			closed = append(closed, event.PageID)
+		}
+	}
+	if !slices.Contains(opened, "popup-1") || !slices.Equal(closed, []string{"page-gone"}) {
+		t.Errorf("expected popup-1 announced opened and page-gone closed, got %v and %v", opened, closed)
+	}
+
+	// Listing again adopts nothing new
+	if tabs, _ := manager.ListPages(ctx, sess.ID); len(tabs) != 2 || tabs[1].Adopted {
+		t.Errorf("expected the popup already tracked, got %+v", tabs)
+	}
+
+	copyID, url, err := manager.DuplicatePage(ctx, sess.ID, pageID)
+	if err != nil {
+		t.Fatalf("DuplicatePage failed: %v", err)
+	}
+	if copyID != "page-2" || url != "https://example.com/cart" || !sess.HasPage(copyID) {
+		t.Errorf("expected page-2 opened at the cart, got %s %s", copyID, url)
+	}
+	if _, _, err := manager.DuplicatePage(ctx, sess.ID, "page-gone"); !errors.Is(err, ErrPageNotFound) {
+		t.Errorf("expected ErrPageNotFound for a dropped page, got %v", err)
+	}
+}
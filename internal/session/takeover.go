@@ -0,0 +1,187 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// Takeover records a human holding interactive control of a session.
+// While a takeover is active the agent-facing mutating operations are refused
+// with ErrTakeoverActive so the human and the agent never fight over the page.
+type Takeover struct {
+	ID        string        `json:"takeover_id"` // Unique takeover identifier (tko_ prefix)
+	SessionID string        `json:"session_id"`
+	PageID    string        `json:"page_id"`
+	Operator  string        `json:"operator,omitempty"` // Who took control, for the audit trail
+	StartedAt time.Time     `json:"started_at"`
+	done      chan struct{} // Closed when control is handed back
+}
+
+// Done is closed when the takeover ends
+func (t *Takeover) Done() <-chan struct{} {
+	return t.done
+}
+
+// generateTakeoverID creates a unique takeover identifier
+func generateTakeoverID() (string, error) {
+	randomBytes := make([]byte, 12)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate takeover ID: %w", err)
+	}
+
+	return "tko_" + base64.URLEncoding.EncodeToString(randomBytes), nil
+}
+
+// StartTakeover hands interactive control of a page to a human operator
+func (m *Manager) StartTakeover(sessionID string, pageID string, operator string) (*Takeover, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	takeoverID, err := generateTakeoverID()
+	if err != nil {
+		return nil, err
+	}
+
+	takeover := &Takeover{
+		ID:        takeoverID,
+		SessionID: sessionID,
+		PageID:    pageID,
+		Operator:  operator,
+		StartedAt: time.Now(),
+		done:      make(chan struct{}),
+	}
+
+	// Only one human may hold control at a time
+	m.mu.Lock()
+	if _, active := m.takeovers[sessionID]; active {
+		m.mu.Unlock()
+		return nil, ErrTakeoverActive
+	}
+	m.takeovers[sessionID] = takeover
+	m.mu.Unlock()
+
+	m.publishEvent(sessionID, pageID, events.TypeTakeoverStarted, takeover)
+
+	slog.Info("takeover started",
+		"session_id", sessionID,
+		"page_id", pageID,
+		"takeover_id", takeoverID,
+		"operator", operator)
+
+	return takeover, nil
+}
+
+// EndTakeover returns control to the agent. An empty takeoverID ends whichever takeover is active.
+func (m *Manager) EndTakeover(sessionID string, takeoverID string) error {
+	m.mu.Lock()
+	takeover, active := m.takeovers[sessionID]
+	if !active || (takeoverID != "" && takeover.ID != takeoverID) {
+		m.mu.Unlock()
+		return ErrNoTakeover
+	}
+	delete(m.takeovers, sessionID)
+	m.mu.Unlock()
+
+	close(takeover.done)
+
+	m.publishEvent(sessionID, takeover.PageID, events.TypeTakeoverEnded, map[string]interface{}{
+		"takeover_id": takeover.ID,
+		"duration":    time.Since(takeover.StartedAt).String(),
+	})
+
+	// The human may have changed anything on the page
+	if session, err := m.GetSession(sessionID); err == nil {
+		session.InvalidatePageAnalysis(takeover.PageID)
+		session.UpdateActivity()
+	}
+
+	slog.Info("takeover ended",
+		"session_id", sessionID,
+		"takeover_id", takeover.ID)
+
+	return nil
+}
+
+// GetTakeover returns the active takeover of a session, if any
+func (m *Manager) GetTakeover(sessionID string) (*Takeover, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	takeover, active := m.takeovers[sessionID]
+	return takeover, active
+}
+
+// DispatchTakeoverMouse forwards a mouse event from the operator holding takeoverID
+func (m *Manager) DispatchTakeoverMouse(ctx context.Context, sessionID string, takeoverID string, input MouseInput) error {
+	session, takeover, err := m.activeTakeover(sessionID, takeoverID)
+	if err != nil {
+		return err
+	}
+
+	session.UpdateActivity()
+	return session.DispatchMouseEvent(ctx, takeover.PageID, input)
+}
+
+// DispatchTakeoverKey forwards a keyboard event from the operator holding takeoverID
+func (m *Manager) DispatchTakeoverKey(ctx context.Context, sessionID string, takeoverID string, input KeyInput) error {
+	session, takeover, err := m.activeTakeover(sessionID, takeoverID)
+	if err != nil {
+		return err
+	}
+
+	session.UpdateActivity()
+	return session.DispatchKeyEvent(ctx, takeover.PageID, input)
+}
+
+// activeTakeover checks that takeoverID currently holds control of the session
+func (m *Manager) activeTakeover(sessionID string, takeoverID string) (*Session, *Takeover, error) {
+	m.mu.RLock()
+	session, sessionExists := m.sessions[sessionID]
+	takeover, active := m.takeovers[sessionID]
+	m.mu.RUnlock()
+
+	if !sessionExists {
+		return nil, nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	if !active || takeover.ID != takeoverID {
+		return nil, nil, ErrNoTakeover
+	}
+
+	return session, takeover, nil
+}
+
+// checkAgentControl refuses agent operations while a human holds the session
+func (m *Manager) checkAgentControl(sessionID string) error {
+	m.mu.RLock()
+	_, active := m.takeovers[sessionID]
+	m.mu.RUnlock()
+
+	if active {
+		return ErrTakeoverActive
+	}
+
+	return nil
+}
+
+// endTakeoverLocked releases a takeover when its session goes away.
+// Caller must hold m.mu for writing.
+func (m *Manager) endTakeoverLocked(sessionID string) {
+	if takeover, active := m.takeovers[sessionID]; active {
+		delete(m.takeovers, sessionID)
+		close(takeover.done)
+	}
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTakeoverLifecycle tests claiming, guarding and returning control
+func TestTakeoverLifecycle(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	// Register a session directly (takeover bookkeeping needs no browser)
+	sess := &Session{ID: "sess_test", PageIDs: []string{"PAGE1"}, Status: SessionActive, LastActivity: time.Now()}
+	manager.sessions[sess.ID] = sess
+
+	takeover, err := manager.StartTakeover(sess.ID, "PAGE1", "alice")
+	if err != nil {
+		t.Fatalf("StartTakeover failed: %v", err)
+	}
+
+	// A second operator cannot grab control
+	if _, err := manager.StartTakeover(sess.ID, "PAGE1", "bob"); !errors.Is(err, ErrTakeoverActive) {
+		t.Errorf("expected ErrTakeoverActive for second takeover, got %v", err)
+	}
+
+	// Agent operations are refused while the human holds the session
+	if _, err := manager.ExecuteJavascript(context.Background(), sess.ID, "PAGE1", "1"); !errors.Is(err, ErrTakeoverActive) {
+		t.Errorf("expected ErrTakeoverActive for agent execute, got %v", err)
+	}
+
+	// Input from a stale takeover ID is rejected
+	if err := manager.DispatchTakeoverKey(context.Background(), sess.ID, "tko_other", KeyInput{Type: "char", Text: "a"}); !errors.Is(err, ErrNoTakeover) {
+		t.Errorf("expected ErrNoTakeover for wrong takeover ID, got %v", err)
+	}
+
+	if err := manager.EndTakeover(sess.ID, takeover.ID); err != nil {
+		t.Fatalf("EndTakeover failed: %v", err)
+	}
+
+	select {
+	case <-takeover.Done():
+	default:
+		t.Error("expected Done to be closed after EndTakeover")
+	}
+
+	if _, active := manager.GetTakeover(sess.ID); active {
+		t.Error("expected no active takeover after EndTakeover")
+	}
+
+	if err := manager.EndTakeover(sess.ID, ""); !errors.Is(err, ErrNoTakeover) {
+		t.Errorf("expected ErrNoTakeover when nothing is active, got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// targetEventQueueSize bounds Target events waiting to be applied to sessions
+const targetEventQueueSize = 256
+
+// targetEvent is a Target domain event queued for processing
+type targetEvent struct {
+	method string
+	info   cdp.TargetInfo
+}
+
+// watchTargets enables target discovery on a browser connection so pages the
+// site opens itself (window.open, target=_blank) are adopted into their session.
+// Events are handed to a worker goroutine: the CDP reader must never wait on m.mu,
+// which is held across CDP calls elsewhere in the manager.
+func (m *Manager) watchTargets(client *cdp.Client) error {
+	queue := make(chan targetEvent, targetEventQueueSize)
+
+	enqueue := func(event targetEvent) {
+		select {
+		case queue <- event:
+		default:
+			slog.Warn("target event queue full, dropping event", "method", event.method, "target_id", event.info.TargetID)
+		}
+	}
+
+	unsubscribeCreated := client.OnEvent("Target.targetCreated", "", func(event *cdp.Event) {
+		var params struct {
+			TargetInfo cdp.TargetInfo `json:"targetInfo"`
+		}
+		if err := json.Unmarshal(event.Params, &params); err != nil {
+			slog.Warn("failed to parse targetCreated event", "error", err)
+			return
+		}
+		enqueue(targetEvent{method: event.Method, info: params.TargetInfo})
+	})
+
+	unsubscribeDestroyed := client.OnEvent("Target.targetDestroyed", "", func(event *cdp.Event) {
+		var params struct {
+			TargetID string `json:"targetId"`
+		}
+		if err := json.Unmarshal(event.Params, &params); err != nil {
+			slog.Warn("failed to parse targetDestroyed event", "error", err)
+			return
+		}
+		enqueue(targetEvent{method: event.Method, info: cdp.TargetInfo{TargetID: params.TargetID}})
+	})
+
+	if _, err := client.SendCommand(context.Background(), "Target.setDiscoverTargets", map[string]interface{}{"discover": true}); err != nil {
+		unsubscribeCreated()
+		unsubscribeDestroyed()
+		return err
+	}
+
+	go func() {
+		defer unsubscribeCreated()
+		defer unsubscribeDestroyed()
+
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case event := <-queue:
+				m.handleTargetEvent(event)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// handleTargetEvent applies a target lifecycle event to the owning session
+func (m *Manager) handleTargetEvent(event targetEvent) {
+	switch event.method {
+	case "Target.targetCreated":
+		// Only pages with an opener were created by the site; our own are tracked on creation
+		if event.info.Type != "page" || event.info.OpenerID == "" {
+			return
+		}
+
+		session := m.sessionForContext(event.info.BrowserContextID)
+		if session == nil || session.HasPage(event.info.TargetID) {
+			return
+		}
+
+		session.AddPage(event.info.TargetID)
+
+		// The popup has already started loading, so this only covers its later navigations
+		if err := session.setupPage(context.Background(), event.info.TargetID); err != nil {
+			slog.Warn("failed to apply session setup to popup", "page_id", event.info.TargetID, "error", err)
+		}
+		m.trackRoutes(context.Background(), session, event.info.TargetID)
+		m.captureNetwork(context.Background(), session, event.info.TargetID)
+
+		m.publishEvent(session.ID, event.info.TargetID, events.TypePageOpened, map[string]interface{}{
+			"url":       event.info.URL,
+			"opener_id": event.info.OpenerID,
+			"popup":     true,
+		})
+
+		slog.Info("popup adopted into session",
+			"session_id", session.ID,
+			"page_id", event.info.TargetID,
+			"opener_id", event.info.OpenerID)
+
+	case "Target.targetDestroyed":
+		session := m.sessionForPage(event.info.TargetID)
+		if session == nil {
+			return
+		}
+
+		session.RemovePage(event.info.TargetID)
+		session.stopScreencast(event.info.TargetID)
+		session.stopWatches(event.info.TargetID)
+		session.stopRoutes(event.info.TargetID)
+		session.stopNetworkCapture(event.info.TargetID)
+		session.forgetHandles(event.info.TargetID)
+		m.publishEvent(session.ID, event.info.TargetID, events.TypePageClosed, nil)
+	}
+}
+
+// sessionForContext finds the in-memory session owning a browser context
+func (m *Manager) sessionForContext(contextID string) *Session {
+	if contextID == "" {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, session := range m.sessions {
+		if session.ContextID == contextID {
+			return session
+		}
+	}
+
+	return nil
+}
+
+// sessionForPage finds the in-memory session tracking a page
+func (m *Manager) sessionForPage(pageID string) *Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, session := range m.sessions {
+		if session.HasPage(pageID) {
+			return session
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// TestPopupAdoption tests that opener-created pages join the owning session
+func TestPopupAdoption(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	sess := &Session{ID: "sess_test", ContextID: "CTX1", PageIDs: []string{"PAGE1"}, Status: SessionActive, LastActivity: time.Now()}
+	manager.sessions[sess.ID] = sess
+
+	// Pages without an opener are ours and tracked on creation
+	manager.handleTargetEvent(targetEvent{
+		method: "Target.targetCreated",
+		info:   cdp.TargetInfo{TargetID: "PAGE2", Type: "page", BrowserContextID: "CTX1"},
+	})
+
+	// Popups from other contexts belong to other sessions
+	manager.handleTargetEvent(targetEvent{
+		method: "Target.targetCreated",
+		info:   cdp.TargetInfo{TargetID: "PAGE3", Type: "page", OpenerID: "X", BrowserContextID: "CTX2"},
+	})
+
+	manager.handleTargetEvent(targetEvent{
+		method: "Target.targetCreated",
+		info:   cdp.TargetInfo{TargetID: "POPUP", Type: "page", OpenerID: "PAGE1", BrowserContextID: "CTX1", URL: "https://example.com/oauth"},
+	})
+
+	if !slices.Equal(sess.PageIDs, []string{"PAGE1", "POPUP"}) {
+		t.Fatalf("expected PAGE1 and POPUP tracked, got %v", sess.PageIDs)
+	}
+
+	history := manager.Events().History(sess.ID, 0)
+	if len(history) != 1 || history[0].Type != events.TypePageOpened || history[0].PageID != "POPUP" {
+		t.Errorf("expected one page_opened event for POPUP, got %+v", history)
+	}
+
+	// The popup closing itself removes it from the session
+	manager.handleTargetEvent(targetEvent{
+		method: "Target.targetDestroyed",
+		info:   cdp.TargetInfo{TargetID: "POPUP"},
+	})
+
+	if !slices.Equal(sess.PageIDs, []string{"PAGE1"}) {
+		t.Errorf("expected only PAGE1 after popup closed, got %v", sess.PageIDs)
+	}
+}
@@ -0,0 +1,391 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/browser"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/storage"
+)
+
+// templateNamePattern restricts template names to URL-safe identifiers
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// labelKeyPattern restricts label keys to what a label=key=value query can carry
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]{1,64}$`)
+
+// Viewport describes the emulated screen of every page in a session
+type Viewport struct {
+	Width             int     `json:"width"`
+	Height            int     `json:"height"`
+	DeviceScaleFactor float64 `json:"device_scale_factor,omitempty"`
+	Mobile            bool    `json:"mobile,omitempty"`
+}
+
+// SessionOptions configures the browser environment of a session.
+// Zero values mean "browser default".
+type SessionOptions struct {
+	Viewport            *Viewport         `json:"viewport,omitempty"`
+	UserAgent           string            `json:"user_agent,omitempty"`
+	Proxy               string            `json:"proxy,omitempty"`        // Proxy server for the session's browser context
+	ProxyBypass         string            `json:"proxy_bypass,omitempty"` // Comma-separated hosts that skip the proxy
+	BlockedURLs         []string          `json:"blocked_urls,omitempty"` // URL patterns (wildcards allowed) never loaded
+	InitScripts         []string          `json:"init_scripts,omitempty"` // Run in every new document before page scripts
+	Cookies             []storage.Cookie  `json:"cookies,omitempty"`      // Set on the browser context at creation
+	NavigationTimeoutMS int               `json:"navigation_timeout_ms,omitempty"`
+	IdleTimeoutMS       int               `json:"idle_timeout_ms,omitempty"`    // Overrides the cleanup worker timeout
+	Pipeline            string            `json:"pipeline,omitempty"`           // Result pipeline extractions run through by default
+	Labels              map[string]string `json:"labels,omitempty"`             // Free-form tags for finding sessions, e.g. for bulk destroy
+	Profile             string            `json:"profile,omitempty"`            // Named profile kept on disk; the session gets its own browser
+	Extensions          []string          `json:"extensions,omitempty"`         // Extensions the profile's browser loads beyond the defaults
+	Engine              string            `json:"engine,omitempty"`             // Browser engine: "chromium" (default) or "firefox"
+	Locale              string            `json:"locale,omitempty"`             // BCP 47 locale pages format dates and numbers for
+	Timezone            string            `json:"timezone,omitempty"`           // IANA timezone pages see, e.g. UTC
+	DisableAnimations   bool              `json:"disable_animations,omitempty"` // Stop CSS animations, transitions and the caret
+	WebSockets          *WebSocketCapture `json:"websockets,omitempty"`         // Record the WebSocket frames of every page
+	Streams             *StreamCapture    `json:"streams,omitempty"`            // Record server-sent events and streamed response bodies
+
+	// IgnoreCertificateErrors loads pages despite bad certificates, for internal and staging hosts
+	IgnoreCertificateErrors bool `json:"ignore_certificate_errors,omitempty"`
+
+	// ClientCertificates are presented to the hosts that require mutual TLS
+	ClientCertificates []ClientCertificate `json:"client_certificates,omitempty"`
+
+	// Egress limits the hosts the session's traffic reaches through the egress proxy
+	Egress *EgressPolicy `json:"egress,omitempty"`
+}
+
+// SessionTemplate is a named, reusable set of session options
+type SessionTemplate struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Options     SessionOptions `json:"options"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// MergeSessionOptions layers override on top of base. Scalar fields in override
+// replace the base value; list fields are appended to the base lists.
+func MergeSessionOptions(base, override *SessionOptions) *SessionOptions {
+	if base == nil && override == nil {
+		return nil
+	}
+
+	merged := &SessionOptions{}
+	for _, opts := range []*SessionOptions{base, override} {
+		if opts == nil {
+			continue
+		}
+		if opts.Viewport != nil {
+			viewport := *opts.Viewport
+			merged.Viewport = &viewport
+		}
+		if opts.UserAgent != "" {
+			merged.UserAgent = opts.UserAgent
+		}
+		if opts.Profile != "" {
+			merged.Profile = opts.Profile
+		}
+		if opts.Engine != "" {
+			merged.Engine = opts.Engine
+		}
+		if opts.Proxy != "" {
+			merged.Proxy = opts.Proxy
+		}
+		if opts.ProxyBypass != "" {
+			merged.ProxyBypass = opts.ProxyBypass
+		}
+		if opts.NavigationTimeoutMS > 0 {
+			merged.NavigationTimeoutMS = opts.NavigationTimeoutMS
+		}
+		if opts.IdleTimeoutMS > 0 {
+			merged.IdleTimeoutMS = opts.IdleTimeoutMS
+		}
+		if opts.Pipeline != "" {
+			merged.Pipeline = opts.Pipeline
+		}
+		if opts.Locale != "" {
+			merged.Locale = opts.Locale
+		}
+		if opts.Timezone != "" {
+			merged.Timezone = opts.Timezone
+		}
+		if opts.DisableAnimations {
+			merged.DisableAnimations = true
+		}
+		if opts.IgnoreCertificateErrors {
+			merged.IgnoreCertificateErrors = true
+		}
+		if opts.WebSockets != nil {
+			capture := *opts.WebSockets
+			capture.Masks = append([]PayloadMask(nil), opts.WebSockets.Masks...)
+			merged.WebSockets = &capture
+		}
+		if opts.Streams != nil {
+			capture := *opts.Streams
+			capture.MIMETypes = append([]string(nil), opts.Streams.MIMETypes...)
+			capture.Masks = append([]PayloadMask(nil), opts.Streams.Masks...)
+			merged.Streams = &capture
+		}
+		if opts.Egress != nil {
+			policy := *opts.Egress
+			policy.Allow = append([]string(nil), opts.Egress.Allow...)
+			policy.Deny = append([]string(nil), opts.Egress.Deny...)
+			policy.Headers = maps.Clone(opts.Egress.Headers)
+			merged.Egress = &policy
+		}
+		merged.BlockedURLs = append(merged.BlockedURLs, opts.BlockedURLs...)
+		merged.InitScripts = append(merged.InitScripts, opts.InitScripts...)
+		merged.Cookies = append(merged.Cookies, opts.Cookies...)
+		merged.Extensions = append(merged.Extensions, opts.Extensions...)
+		merged.ClientCertificates = append(merged.ClientCertificates, opts.ClientCertificates...)
+		for key, value := range opts.Labels {
+			if merged.Labels == nil {
+				merged.Labels = make(map[string]string)
+			}
+			merged.Labels[key] = value
+		}
+	}
+
+	return merged
+}
+
+// Validate checks options that the browser would otherwise reject late
+func (o *SessionOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	if o.Viewport != nil && (o.Viewport.Width <= 0 || o.Viewport.Height <= 0) {
+		return fmt.Errorf("viewport width and height must be positive")
+	}
+	if o.NavigationTimeoutMS < 0 || o.IdleTimeoutMS < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	for _, cookie := range o.Cookies {
+		if cookie.Name == "" || cookie.Domain == "" {
+			return fmt.Errorf("cookies require a name and domain")
+		}
+	}
+	for key := range o.Labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+	}
+	if o.Profile != "" {
+		if err := browser.ValidateProfileName(o.Profile); err != nil {
+			return err
+		}
+	}
+	if o.Locale != "" {
+		if err := browser.ValidateLocale(o.Locale); err != nil {
+			return err
+		}
+	}
+	if o.Timezone != "" {
+		if err := browser.ValidateTimezone(o.Timezone); err != nil {
+			return err
+		}
+	}
+	if o.WebSockets != nil {
+		if err := o.WebSockets.validate(); err != nil {
+			return err
+		}
+	}
+	if o.Streams != nil {
+		if err := o.Streams.validate(); err != nil {
+			return err
+		}
+	}
+	for _, certificate := range o.ClientCertificates {
+		if err := certificate.validate(); err != nil {
+			return err
+		}
+	}
+	if o.Egress != nil {
+		if err := o.Egress.validate(); err != nil {
+			return err
+		}
+	}
+	if len(o.Extensions) > 0 && o.Profile == "" {
+		return fmt.Errorf("extensions need a profile, whose browser is the session's own")
+	}
+	return o.validateEngine()
+}
+
+// hasPageSetup reports whether new pages must be configured before they load
+func (o *SessionOptions) hasPageSetup() bool {
+	return o != nil && (o.Viewport != nil || o.UserAgent != "" || len(o.BlockedURLs) > 0 || len(o.InitScripts) > 0 ||
+		o.Locale != "" || o.Timezone != "" || o.DisableAnimations || o.IgnoreCertificateErrors)
+}
+
+// keepsNetwork reports whether pages need the Network domain on for as long as they are open
+func (o *SessionOptions) keepsNetwork() bool {
+	return o != nil && (len(o.BlockedURLs) > 0 || o.capturesNetwork())
+}
+
+// capturesNetwork reports whether pages keep some of their traffic for later inspection
+func (o *SessionOptions) capturesNetwork() bool {
+	return o != nil && (o.WebSockets != nil || o.Streams != nil)
+}
+
+// TemplateRegistry holds the named session templates known to the manager. Templates
+// saved by a tenant are seen by that tenant only; the ones without a tenant, such as
+// those loaded from the templates file, are shared by everyone.
+type TemplateRegistry struct {
+	templates map[templateKey]*SessionTemplate
+	mu        sync.RWMutex
+}
+
+// templateKey is a template's name within the tenant it belongs to
+type templateKey struct {
+	tenantID string
+	name     string
+}
+
+// NewTemplateRegistry creates an empty template registry
+func NewTemplateRegistry() *TemplateRegistry {
+	return &TemplateRegistry{
+		templates: make(map[templateKey]*SessionTemplate),
+	}
+}
+
+// Put adds or replaces a tenant's template. A tenant's template hides a shared one
+// with the same name, without changing it.
+func (r *TemplateRegistry) Put(tenantID string, template *SessionTemplate) error {
+	if !templateNamePattern.MatchString(template.Name) {
+		return fmt.Errorf("%w: %q", ErrInvalidTemplateName, template.Name)
+	}
+	if err := template.Options.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+	}
+
+	if template.CreatedAt.IsZero() {
+		template.CreatedAt = time.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.templates[templateKey{tenantID, template.Name}] = template
+	return nil
+}
+
+// Get returns a template by name: the tenant's own, or else a shared one
+func (r *TemplateRegistry) Get(tenantID, name string) (*SessionTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, exists := r.templates[templateKey{tenantID, name}]
+	if !exists {
+		template, exists = r.templates[templateKey{"", name}]
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	return template, nil
+}
+
+// Delete removes a tenant's template by name. Shared templates can't be deleted by a
+// tenant.
+func (r *TemplateRegistry) Delete(tenantID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := templateKey{tenantID, name}
+	if _, exists := r.templates[key]; !exists {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	delete(r.templates, key)
+	return nil
+}
+
+// List returns the templates a tenant can use, its own and the shared ones it doesn't
+// hide, sorted by name
+func (r *TemplateRegistry) List(tenantID string) []*SessionTemplate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	visible := make(map[string]*SessionTemplate)
+	for key, template := range r.templates {
+		if key.tenantID == tenantID {
+			visible[key.name] = template
+		} else if _, hidden := visible[key.name]; key.tenantID == "" && !hidden {
+			visible[key.name] = template
+		}
+	}
+
+	templates := make([]*SessionTemplate, 0, len(visible))
+	for _, template := range visible {
+		templates = append(templates, template)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates
+}
+
+// LoadFile registers every template in a JSON file holding an array of templates, as
+// shared templates
+func (r *TemplateRegistry) LoadFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read templates file: %w", err)
+	}
+
+	var templates []*SessionTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return 0, fmt.Errorf("failed to parse templates file: %w", err)
+	}
+
+	for _, template := range templates {
+		if err := r.Put("", template); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(templates), nil
+}
+
+// ResolveSessionOptions combines a tenant's named template with inline overrides
+func (m *Manager) ResolveSessionOptions(tenantID, templateName string, overrides *SessionOptions) (*SessionOptions, error) {
+	var base *SessionOptions
+	if templateName != "" {
+		template, err := m.templates.Get(tenantID, templateName)
+		if err != nil {
+			return nil, err
+		}
+		base = &template.Options
+	}
+
+	opts := MergeSessionOptions(base, overrides)
+	// Firefox browsers take the rendering defaults at launch, having no page setup
+	if defaults := m.renderingDefaults(); defaults != nil && engineOf(opts) != EngineFirefox {
+		opts = MergeSessionOptions(defaults, opts)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+	}
+
+	// Catch a misspelled pipeline now rather than at the first extraction
+	if opts != nil && opts.Pipeline != "" {
+		if _, err := m.pipelines.Get(tenantID, opts.Pipeline); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSessionOptions, err)
+		}
+	}
+
+	return opts, nil
+}
+
+// Templates returns the manager's session template registry
+func (m *Manager) Templates() *TemplateRegistry {
+	return m.templates
+}
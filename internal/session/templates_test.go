@@ -0,0 +1,275 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/pipeline"
+)
+
+// TestMergeSessionOptions tests that overrides replace scalars and extend lists
+func TestMergeSessionOptions(t *testing.T) {
+	base := &SessionOptions{
+		Viewport:            &Viewport{Width: 1280, Height: 720},
+		UserAgent:           "base-agent",
+		BlockedURLs:         []string{"*.png"},
+		NavigationTimeoutMS: 5000,
+	}
+	override := &SessionOptions{
+		UserAgent:   "override-agent",
+		BlockedURLs: []string{"*.woff2"},
+	}
+
+	merged := MergeSessionOptions(base, override)
+
+	if merged.UserAgent != "override-agent" {
+		t.Errorf("expected override user agent, got %q", merged.UserAgent)
+	}
+	if merged.Viewport == nil || merged.Viewport.Width != 1280 {
+		t.Errorf("expected base viewport to be kept, got %+v", merged.Viewport)
+	}
+	if merged.NavigationTimeoutMS != 5000 {
+		t.Errorf("expected base navigation timeout, got %d", merged.NavigationTimeoutMS)
+	}
+	if len(merged.BlockedURLs) != 2 {
+		t.Errorf("expected blocked URLs from both layers, got %v", merged.BlockedURLs)
+	}
+
+	// The merge must not alias the template's viewport
+	merged.Viewport.Width = 1
+	if base.Viewport.Width != 1280 {
+		t.Error("merge modified the base options")
+	}
+
+	if MergeSessionOptions(nil, nil) != nil {
+		t.Error("expected nil when neither layer is set")
+	}
+}
+
+// TestTemplateRegistry tests storing, resolving and deleting templates
+func TestTemplateRegistry(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	registry := manager.Templates()
+
+	if err := registry.Put("", &SessionTemplate{Name: "bad name"}); !errors.Is(err, ErrInvalidTemplateName) {
+		t.Errorf("expected ErrInvalidTemplateName, got %v", err)
+	}
+
+	invalid := &SessionTemplate{Name: "tiny", Options: SessionOptions{Viewport: &Viewport{Width: 0, Height: 10}}}
+	if err := registry.Put("", invalid); !errors.Is(err, ErrInvalidSessionOptions) {
+		t.Errorf("expected ErrInvalidSessionOptions, got %v", err)
+	}
+
+	template := &SessionTemplate{
+		Name:    "mobile",
+		Options: SessionOptions{Viewport: &Viewport{Width: 390, Height: 844, Mobile: true}, IdleTimeoutMS: 60000},
+	}
+	if err := registry.Put("", template); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if template.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt to be set")
+	}
+
+	opts, err := manager.ResolveSessionOptions("", "mobile", &SessionOptions{UserAgent: "custom"})
+	if err != nil {
+		t.Fatalf("ResolveSessionOptions failed: %v", err)
+	}
+	if !opts.Viewport.Mobile || opts.UserAgent != "custom" {
+		t.Errorf("unexpected resolved options: %+v", opts)
+	}
+
+	// The per-session idle timeout overrides the manager-wide one
+	sess := &Session{Options: opts}
+	if got := sess.idleTimeout(30 * time.Minute); got != time.Minute {
+		t.Errorf("expected 1m idle timeout, got %s", got)
+	}
+
+	if _, err := manager.ResolveSessionOptions("", "missing", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+
+	if err := registry.Delete("", "mobile"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(registry.List("")) != 0 {
+		t.Error("expected registry to be empty after delete")
+	}
+}
+
+// TestTemplateTenants tests that a tenant's templates and pipelines are hidden from
+// other tenants, and that shared ones stay usable by every tenant but can't be deleted
+func TestTemplateTenants(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	registry := manager.Templates()
+
+	for _, put := range []struct {
+		tenantID string
+		template *SessionTemplate
+	}{
+		{"", &SessionTemplate{Name: "desktop", Options: SessionOptions{UserAgent: "shared"}}},
+		{"acme", &SessionTemplate{Name: "desktop", Options: SessionOptions{UserAgent: "acme"}}},
+		{"acme", &SessionTemplate{Name: "stealth", Options: SessionOptions{Pipeline: "leads"}}},
+	} {
+		if err := registry.Put(put.tenantID, put.template); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := manager.Pipelines().Put("acme", &pipeline.Spec{Name: "leads"}); err != nil {
+		t.Fatalf("Put pipeline failed: %v", err)
+	}
+
+	for tenantID, want := range map[string]string{"acme": "acme", "globex": "shared", "": "shared"} {
+		opts, err := manager.ResolveSessionOptions(tenantID, "desktop", nil)
+		if err != nil {
+			t.Fatalf("ResolveSessionOptions failed: %v", err)
+		}
+		if opts.UserAgent != want {
+			t.Errorf("expected %q to get the %s template, got %q", tenantID, want, opts.UserAgent)
+		}
+	}
+	if _, err := manager.ResolveSessionOptions("acme", "stealth", nil); err != nil {
+		t.Errorf("expected acme's template and pipeline to resolve, got %v", err)
+	}
+	if _, err := manager.ResolveSessionOptions("globex", "stealth", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound for another tenant's template, got %v", err)
+	}
+	if _, err := manager.ResolveSessionOptions("globex", "", &SessionOptions{Pipeline: "leads"}); !errors.Is(err, ErrInvalidSessionOptions) {
+		t.Errorf("expected another tenant's pipeline refused, got %v", err)
+	}
+
+	if got := len(registry.List("acme")); got != 2 {
+		t.Errorf("expected acme to see 2 templates, got %d", got)
+	}
+	if templates := registry.List("globex"); len(templates) != 1 || templates[0].Options.UserAgent != "shared" {
+		t.Errorf("expected globex to see only the shared template, got %+v", templates)
+	}
+	if err := registry.Delete("globex", "stealth"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected another tenant's template not to be deleted, got %v", err)
+	}
+	if err := registry.Delete("globex", "desktop"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected a shared template not to be deleted by a tenant, got %v", err)
+	}
+	if err := registry.Delete("acme", "desktop"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if template, _ := registry.Get("acme", "desktop"); template == nil || template.Options.UserAgent != "shared" {
+		t.Errorf("expected acme back on the shared template, got %+v", template)
+	}
+}
+
+// TestTemplateRegistryLoadFile tests loading templates from a JSON file
+func TestTemplateRegistryLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	data := `[
+		{"name": "desktop", "options": {"viewport": {"width": 1920, "height": 1080}}},
+		{"name": "no-images", "options": {"blocked_urls": ["*.jpg", "*.png"]}}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write templates file: %v", err)
+	}
+
+	registry := NewTemplateRegistry()
+	count, err := registry.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 templates, got %d", count)
+	}
+
+	templates := registry.List("")
+	if len(templates) != 2 || templates[0].Name != "desktop" {
+		t.Errorf("expected sorted templates starting with desktop, got %+v", templates)
+	}
+}
+
+// TestRenderingDefaults tests that the manager's locale, timezone and animation settings
+// sit beneath templates and session options, and reach every page set up
+func TestRenderingDefaults(t *testing.T) {
+	var mu sync.Mutex
+	commands := map[string]json.RawMessage{}
+	var scripts []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Source string `json:"source"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		commands[method] = params
+		if p.Source != "" {
+			scripts = append(scripts, p.Source)
+		}
+		mu.Unlock()
+		switch method {
+		case "Page.addScriptToEvaluateOnNewDocument":
+			return map[string]interface{}{"identifier": "1"}
+		}
+		return map[string]interface{}{}
+	})
+
+	manager.SetRendering("en-US", "UTC", true)
+	ctx := context.Background()
+
+	if err := manager.Templates().Put("", &SessionTemplate{Name: "berlin", Options: SessionOptions{Timezone: "Europe/Berlin"}}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	opts, err := manager.ResolveSessionOptions("", "berlin", &SessionOptions{Locale: "de-DE"})
+	if err != nil {
+		t.Fatalf("ResolveSessionOptions failed: %v", err)
+	}
+	if opts.Locale != "de-DE" || opts.Timezone != "Europe/Berlin" || !opts.DisableAnimations {
+		t.Errorf("expected the template and session to override the defaults, got %+v", opts)
+	}
+
+	// Firefox sessions have no page setup to carry them
+	firefox, err := manager.ResolveSessionOptions("", "", &SessionOptions{Engine: EngineFirefox})
+	if err != nil {
+		t.Fatalf("ResolveSessionOptions failed for Firefox: %v", err)
+	}
+	if firefox.Locale != "" || firefox.DisableAnimations {
+		t.Errorf("expected no rendering defaults on Firefox, got %+v", firefox)
+	}
+
+	for _, invalid := range []*SessionOptions{{Locale: "english please"}, {Timezone: "Mars/Olympus_Mons"}} {
+		if _, err := manager.ResolveSessionOptions("", "", invalid); !errors.Is(err, ErrInvalidSessionOptions) {
+			t.Errorf("expected ErrInvalidSessionOptions for %+v, got %v", invalid, err)
+		}
+	}
+
+	sess, err := manager.CreateSessionWithOptions(ctx, "", "agent-1", "", 9222, "berlin", opts)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	if _, err := manager.Navigate(ctx, sess.ID, "https://status.example.com"); err != nil {
+		t.Fatalf("Navigate failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var locale struct {
+		Locale string `json:"locale"`
+	}
+	var timezone struct {
+		TimezoneID string `json:"timezoneId"`
+	}
+	json.Unmarshal(commands["Emulation.setLocaleOverride"], &locale)
+	json.Unmarshal(commands["Emulation.setTimezoneOverride"], &timezone)
+	if locale.Locale != "de-DE" || timezone.TimezoneID != "Europe/Berlin" {
+		t.Errorf("expected the page set to de-DE in Europe/Berlin, got %q in %q", locale.Locale, timezone.TimezoneID)
+	}
+	if !slices.Contains(scripts, disableAnimationsJS) {
+		t.Errorf("expected the animation stylesheet injected, got %d other scripts", len(scripts))
+	}
+}
@@ -0,0 +1,140 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TenantQuotas reports per-tenant limits; zero means unlimited
+type TenantQuotas interface {
+	MaxSessions(tenantID string) int
+	MaxProcesses(tenantID string) int
+	MaxBandwidthBytes(tenantID string) int64  // Per UTC day
+	MaxModelSpendUSD(tenantID string) float64 // Estimated cost of model calls per UTC day
+}
+
+// TenantUsage is a tenant's share of the manager's live sessions
+type TenantUsage struct {
+	Sessions int   `json:"sessions"`
+	Ports    []int `json:"ports"` // Browser processes the tenant's sessions run on
+
+	// What the tenant's metered sessions downloaded today (UTC)
+	BandwidthBytes int64 `json:"bandwidth_bytes"`
+
+	// Estimated cost of the tenant's model calls today (UTC), in US dollars
+	ModelSpendUSD float64 `json:"model_spend_usd"`
+}
+
+// SetTenantQuotas installs the per-tenant limits checked when sessions are created
+// or resurrected. Call it before serving requests.
+func (m *Manager) SetTenantQuotas(quotas TenantQuotas) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quotas = quotas
+}
+
+// ListTenantSessions returns the live sessions belonging to a tenant
+func (m *Manager) ListTenantSessions(tenantID string) []*Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*Session, 0)
+	for _, session := range m.sessions {
+		if session.TenantID == tenantID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// TenantUsage counts a tenant's live sessions and the browser processes they use
+func (m *Manager) TenantUsage(tenantID string) TenantUsage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tenantUsageLocked(tenantID)
+}
+
+// SessionTenant returns the tenant that owns a session, whether it is live or only
+// persisted in Redis
+func (m *Manager) SessionTenant(sessionID string) (string, error) {
+	// Read under the lock: a transfer may be changing the owner
+	m.mu.RLock()
+	session, exists := m.sessions[sessionID]
+	var tenantID string
+	if exists {
+		tenantID = session.TenantID
+	}
+	m.mu.RUnlock()
+	if exists {
+		return tenantID, nil
+	}
+
+	if m.repo != nil {
+		if state, err := m.repo.GetSession(sessionID); err == nil {
+			return state.TenantID, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+}
+
+// tenantUsageLocked requires m.mu to be held
+func (m *Manager) tenantUsageLocked(tenantID string) TenantUsage {
+	usage := TenantUsage{Ports: []int{}}
+	seen := make(map[int]bool)
+	for _, session := range m.sessions {
+		if session.TenantID != tenantID {
+			continue
+		}
+		usage.Sessions++
+		if !seen[session.ProcessPort] {
+			seen[session.ProcessPort] = true
+			usage.Ports = append(usage.Ports, session.ProcessPort)
+		}
+	}
+
+	// Sessions still being set up count too, or concurrent creations could overshoot
+	for _, reserved := range m.reserved {
+		if reserved.tenantID != tenantID {
+			continue
+		}
+		usage.Sessions++
+		if !seen[reserved.port] {
+			seen[reserved.port] = true
+			usage.Ports = append(usage.Ports, reserved.port)
+		}
+	}
+	sort.Ints(usage.Ports)
+	usage.BandwidthBytes = m.bandwidth.tenantBytes(tenantID, time.Now())
+	usage.ModelSpendUSD = m.modelUsage.tenantSpend(tenantID, time.Now())
+	return usage
+}
+
+// checkTenantQuotasLocked fails if one more session on port would take the tenant
+// past its session or process quota, or the tenant used up its bandwidth for the day.
+// It requires m.mu to be held.
+func (m *Manager) checkTenantQuotasLocked(tenantID string, port int) error {
+	if m.quotas == nil {
+		return nil
+	}
+
+	usage := m.tenantUsageLocked(tenantID)
+	if max := m.quotas.MaxSessions(tenantID); max > 0 && usage.Sessions >= max {
+		return fmt.Errorf("%w: %d of %d sessions in use", ErrTenantSessionLimit, usage.Sessions, max)
+	}
+	if max := m.quotas.MaxBandwidthBytes(tenantID); max > 0 && usage.BandwidthBytes >= max {
+		return fmt.Errorf("%w: %d of %d bytes downloaded today (UTC)", ErrTenantBandwidthLimit, usage.BandwidthBytes, max)
+	}
+
+	// A session on a process the tenant already uses doesn't count as a new process
+	max := m.quotas.MaxProcesses(tenantID)
+	if max > 0 && len(usage.Ports) >= max {
+		for _, used := range usage.Ports {
+			if used == port {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %d of %d browser processes in use", ErrTenantProcessLimit, len(usage.Ports), max)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fixedQuotas gives every tenant the same limits
+type fixedQuotas struct {
+	sessions  int
+	processes int
+	bandwidth int64
+	spend     float64
+}
+
+func (q fixedQuotas) MaxSessions(string) int          { return q.sessions }
+func (q fixedQuotas) MaxProcesses(string) int         { return q.processes }
+func (q fixedQuotas) MaxBandwidthBytes(string) int64  { return q.bandwidth }
+func (q fixedQuotas) MaxModelSpendUSD(string) float64 { return q.spend }
+
+// TestTenantQuotas tests per-tenant session and process limits
+func TestTenantQuotas(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+	manager.SetTenantQuotas(fixedQuotas{sessions: 3, processes: 1})
+
+	// Register sessions directly (quota bookkeeping needs no browser)
+	manager.sessions["s1"] = &Session{ID: "s1", TenantID: "acme", ProcessPort: 9222, LastActivity: time.Now()}
+	manager.sessions["s2"] = &Session{ID: "s2", TenantID: "beta", ProcessPort: 9223, LastActivity: time.Now()}
+
+	// Another session on the process acme already uses is fine, a new process is not
+	if err := manager.checkTenantQuotasLocked("acme", 9222); err != nil {
+		t.Errorf("expected session on used process to be allowed, got %v", err)
+	}
+	if err := manager.checkTenantQuotasLocked("acme", 9223); !errors.Is(err, ErrTenantProcessLimit) {
+		t.Errorf("expected ErrTenantProcessLimit, got %v", err)
+	}
+
+	manager.sessions["s3"] = &Session{ID: "s3", TenantID: "acme", ProcessPort: 9222, LastActivity: time.Now()}
+	manager.sessions["s4"] = &Session{ID: "s4", TenantID: "acme", ProcessPort: 9222, LastActivity: time.Now()}
+	if err := manager.checkTenantQuotasLocked("acme", 9222); !errors.Is(err, ErrTenantSessionLimit) {
+		t.Errorf("expected ErrTenantSessionLimit, got %v", err)
+	}
+
+	// Other tenants are unaffected
+	if err := manager.checkTenantQuotasLocked("beta", 9223); err != nil {
+		t.Errorf("expected beta to be within quota, got %v", err)
+	}
+
+	usage := manager.TenantUsage("acme")
+	if usage.Sessions != 3 || len(usage.Ports) != 1 || usage.Ports[0] != 9222 {
+		t.Errorf("unexpected acme usage: %+v", usage)
+	}
+	if got := len(manager.ListTenantSessions("beta")); got != 1 {
+		t.Errorf("expected 1 beta session, got %d", got)
+	}
+
+	owner, err := manager.SessionTenant("s2")
+	if err != nil || owner != "beta" {
+		t.Errorf("SessionTenant(s2) = %q, %v; want beta", owner, err)
+	}
+}
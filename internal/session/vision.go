@@ -0,0 +1,224 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/png" // Screenshot dimensions for the prompt
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// DefaultVisionTimeout bounds a vision query, model round trip included
+const DefaultVisionTimeout = 2 * time.Minute
+
+// VisionRequest is a question about what a page looks like
+type VisionRequest struct {
+	Question string
+	Overlay  bool         // Number the interactive elements on the screenshot so the model can name them
+	Fresh    bool         // Ask without the session's memory; the answer is still remembered
+	NoCache  bool         // Ask the model even when an identical query was answered before
+	OnText   func(string) // Gets the answer piece by piece as the model writes it (nil: not streamed)
+}
+
+// Point is a viewport position in CSS pixels, as taken by a coordinate click
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// VisionResult is the model's answer, with any locations it pointed at
+type VisionResult struct {
+	Answer   string        `json:"answer"`
+	Points   []Point       `json:"points"`          // Where the answer is on screen, in CSS pixels
+	Elements []ElementMark `json:"elements"`        // Numbered elements the model picked (overlay only)
+	Marks    []ElementMark `json:"marks,omitempty"` // Every element numbered on the screenshot
+	Model    string        `json:"model"`
+	Usage    vision.Usage  `json:"usage"`
+	Cached   bool          `json:"cached"` // Answered from the cache, spending no tokens
+	Duration string        `json:"duration"`
+}
+
+// visionSystemPrompt tells the model how to answer; the screenshot size is filled in,
+// and the overlay is explained when the screenshot has numbered elements
+const visionSystemPrompt = `You are looking at a {{.Width}}x{{.Height}} pixel screenshot of a web page. Answer the user's question about it.
+Reply with only a JSON object of this shape:
+{"answer": "<your answer>", "marks": [<numbers of the labelled elements your answer refers to>], "points": [{"x": <pixel x>, "y": <pixel y>}]}
+Use points for places on the screenshot your answer refers to, such as where to click. Leave marks and points empty when they don't apply.{{if .Overlay}}
+Interactive elements are outlined in red and labelled with a number in their top-left corner. Prefer marks over points when the answer is one of them.{{end}}`
+
+// visionReply is the JSON object the model is asked for
+type visionReply struct {
+	Answer string  `json:"answer"`
+	Marks  []int   `json:"marks"`
+	Points []Point `json:"points"`
+}
+
+// VisionQuery screenshots a page, optionally with its interactive elements numbered, and
+// asks model a question about it. Marks and points in the answer come back as element
+// boxes and viewport coordinates that can be clicked directly.
+func (m *Manager) VisionQuery(ctx context.Context, sessionID string, pageID string, model vision.Model, req VisionRequest) (*VisionResult, error) {
+	// The overlay touches the page, so agents wait while a human holds the session
+	if err := m.checkAgentControl(sessionID); err != nil {
+		return nil, err
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	startTime := time.Now()
+
+	// Read before the overlay touches the page
+	var pageKey string
+	if !req.NoCache && m.answers.enabled() {
+		pageKey, err = session.answerPageKey(ctx, m, pageID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var screenshot []byte
+	var overlay *markOverlay
+	if req.Overlay {
+		screenshot, overlay, err = session.captureMarkedScreenshot(ctx, pageID)
+	} else {
+		var ratio float64
+		ratio, err = session.devicePixelRatio(ctx, pageID)
+		if err != nil {
+			return nil, err
+		}
+		overlay = &markOverlay{DevicePixelRatio: ratio}
+		screenshot, err = session.CaptureScreenshot(ctx, pageID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+
+	size, _, err := image.DecodeConfig(bytes.NewReader(screenshot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read screenshot size: %w", err)
+	}
+
+	system := m.prompts.render(session.TenantID, PromptVision, map[string]interface{}{
+		"Width":   size.Width,
+		"Height":  size.Height,
+		"Overlay": req.Overlay,
+	})
+
+	// Earlier answers and page content let follow-up questions refer back to them
+	prompt := req.Question
+	if !req.Fresh {
+		memory := session.Memory()
+		prompt = memory.memoryPrompt(pageID, req.Question)
+	}
+
+	model = m.meterModel(model, ModelCall{Operation: ModelOperationVision, TenantID: session.TenantID, SessionID: sessionID})
+	model = m.cacheModel(model, session.TenantID, pageKey, req.NoCache)
+	reply, err := askModel(ctx, model, vision.Query{
+		System:   system,
+		Prompt:   prompt,
+		Image:    screenshot,
+		MimeType: "image/png",
+	}, req.OnText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vision model: %w", err)
+	}
+
+	result := resolveVisionReply(reply.Text, overlay)
+	result.Model = reply.Model
+	result.Usage = reply.Usage
+	result.Cached = reply.Cached
+	result.Duration = time.Since(startTime).String()
+
+	turn := MemoryTurn{Question: req.Question, Answer: result.Answer, PageID: pageID, Elements: result.Elements, At: time.Now()}
+	if navigation := session.LastNavigation(pageID); navigation != nil {
+		turn.URL = navigation.URL
+	}
+	session.rememberTurn(turn, m.MemoryLimits())
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return result, nil
+}
+
+// answerPageKey identifies what a screenshot of the page shows for the answer cache:
+// its content hash, scroll position, viewport size and device scale, so edits to the
+// page, scrolling it or resizing it ask the model again while rendering noise doesn't
+func (s *Session) answerPageKey(ctx context.Context, m *Manager, pageID string) (string, error) {
+	hash, err := m.ContentHash(ctx, s.ID, pageID, ContentHashOptions{})
+	if err != nil {
+		return "", err
+	}
+	view, err := s.Driver().Evaluate(ctx, pageID, "[scrollX, scrollY, innerWidth, innerHeight, devicePixelRatio].join(',')")
+	if err != nil {
+		return "", fmt.Errorf("failed to read viewport: %w", err)
+	}
+	return fmt.Sprintf("%s|%s|%s|%v", hash.URL, hash.TextHash, hash.StructureHash, view), nil
+}
+
+// resolveVisionReply reads the model's JSON answer, falling back to the raw text when it
+// ignored the format. Marks become element boxes and screenshot pixels become CSS pixels.
+func resolveVisionReply(text string, overlay *markOverlay) *VisionResult {
+	result := &VisionResult{
+		Answer:   strings.TrimSpace(text),
+		Points:   []Point{},
+		Elements: []ElementMark{},
+		Marks:    overlay.Marks,
+	}
+
+	// Models like to wrap JSON in a code fence or a sentence, so take the outermost object
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return result
+	}
+	var reply visionReply
+	if err := json.Unmarshal([]byte(text[start:end+1]), &reply); err != nil {
+		return result
+	}
+	result.Answer = reply.Answer
+
+	ratio := overlay.DevicePixelRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	for _, point := range reply.Points {
+		result.Points = append(result.Points, Point{X: point.X / ratio, Y: point.Y / ratio})
+	}
+
+	// Marks are numbered from 1 in overlay order; unknown numbers are ignored
+	for _, mark := range reply.Marks {
+		if mark < 1 || mark > len(overlay.Marks) {
+			continue
+		}
+		element := overlay.Marks[mark-1]
+		result.Elements = append(result.Elements, element)
+		x, y := element.Box.Center()
+		result.Points = append(result.Points, Point{X: x, Y: y})
+	}
+
+	return result
+}
+
+// askModel asks model, streaming the "answer" field of its JSON reply to onText when set.
+// Models that can't stream answer in one go, and onText isn't called.
+func askModel(ctx context.Context, model vision.Model, query vision.Query, onText func(string)) (*vision.Reply, error) {
+	streamer, ok := model.(vision.Streamer)
+	if onText == nil || !ok {
+		return model.Ask(ctx, query)
+	}
+	answer := vision.NewFieldStream("answer", onText)
+	return streamer.AskStream(ctx, query, answer.Write)
+}
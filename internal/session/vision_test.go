@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vision"
+)
+
+// TestResolveVisionReply tests reading marks and points out of model answers
+func TestResolveVisionReply(t *testing.T) {
+	overlay := &markOverlay{
+		DevicePixelRatio: 2,
+		Marks: []ElementMark{
+			{Mark: 1, Tag: "a", Selector: "#home", Box: Box{X: 10, Y: 10, Width: 40, Height: 20}},
+			{Mark: 2, Tag: "button", Selector: "#buy", Box: Box{X: 100, Y: 200, Width: 80, Height: 30}},
+		},
+	}
+
+	// Fenced JSON with a mark, a screenshot point and an unknown mark
+	reply := "```json\n{\"answer\": \"The buy button\", \"marks\": [2, 9], \"points\": [{\"x\": 400, \"y\": 300}]}\n```"
+	result := resolveVisionReply(reply, overlay)
+
+	if result.Answer != "The buy button" {
+		t.Errorf("unexpected answer: %q", result.Answer)
+	}
+	if len(result.Elements) != 1 || result.Elements[0].Selector != "#buy" {
+		t.Errorf("expected the buy button as the only element, got %+v", result.Elements)
+	}
+
+	// Screenshot pixels are halved at 2x; the mark resolves to its centre
+	want := []Point{{X: 200, Y: 150}, {X: 140, Y: 215}}
+	if len(result.Points) != len(want) {
+		t.Fatalf("expected %d points, got %+v", len(want), result.Points)
+	}
+	for i := range want {
+		if result.Points[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, result.Points[i], want[i])
+		}
+	}
+
+	// A plain-text answer is kept as is
+	plain := resolveVisionReply("  It's a login page.  ", &markOverlay{DevicePixelRatio: 1})
+	if plain.Answer != "It's a login page." || len(plain.Points) != 0 || len(plain.Elements) != 0 {
+		t.Errorf("unexpected result for plain text: %+v", plain)
+	}
+}
+
+// chunkModel streams its reply in fixed pieces
+type chunkModel struct {
+	pieces []string
+}
+
+func (c *chunkModel) Name() string { return "chunk-model" }
+
+func (c *chunkModel) Ask(ctx context.Context, query vision.Query) (*vision.Reply, error) {
+	return &vision.Reply{Text: strings.Join(c.pieces, "")}, nil
+}
+
+func (c *chunkModel) AskStream(ctx context.Context, query vision.Query, onText func(string)) (*vision.Reply, error) {
+	for _, piece := range c.pieces {
+		onText(piece)
+	}
+	return c.Ask(ctx, query)
+}
+
+// TestAskModelStreams tests that only the answer field of a streamed JSON reply is passed on
+func TestAskModelStreams(t *testing.T) {
+	model := &chunkModel{pieces: []string{`{"ans`, `wer": "It is `, `330 m\u00e9`, `tres", "marks": [1]}`}}
+
+	var streamed strings.Builder
+	reply, err := askModel(context.Background(), model, vision.Query{Prompt: "How tall?"}, func(text string) {
+		streamed.WriteString(text)
+	})
+	if err != nil {
+		t.Fatalf("askModel failed: %v", err)
+	}
+	if streamed.String() != "It is 330 métres" {
+		t.Errorf("unexpected streamed text: %q", streamed.String())
+	}
+	if !strings.Contains(reply.Text, `"marks": [1]`) {
+		t.Errorf("expected the full reply to be returned, got %q", reply.Text)
+	}
+
+	if _, err := askModel(context.Background(), model, vision.Query{}, nil); err != nil {
+		t.Errorf("askModel without a callback failed: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/visual"
+)
+
+// VisualCompare asks for a page to be compared against a stored baseline
+type VisualCompare struct {
+	Baseline       string          // Name of the baseline, kept per tenant
+	Threshold      float64         // How far apart two pixels must be to differ, 0 to 1 (0: visual.DefaultThreshold)
+	MaxDiffPercent float64         // Share of changed pixels, 0 to 100, the comparison still passes with
+	Ignore         []visual.Region // Regions left out, such as clocks or ads
+	Update         bool            // Make this capture the baseline, whatever it looks like
+}
+
+// VisualComparison is the outcome of comparing a page with its baseline. A capture
+// that creates or replaces the baseline passes without a diff.
+type VisualComparison struct {
+	Baseline string `json:"baseline"`
+	Created  bool   `json:"created,omitempty"` // There was no baseline, so this capture became it
+	Updated  bool   `json:"updated,omitempty"` // The baseline was replaced on request
+	Passed   bool   `json:"passed"`
+	*visual.Result
+}
+
+// validate checks the baseline name and the comparison options
+func (c *VisualCompare) validate() error {
+	if err := visual.ValidateName(c.Baseline); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidComparison, err)
+	}
+	if err := c.options().Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidComparison, err)
+	}
+	if c.MaxDiffPercent < 0 || c.MaxDiffPercent > 100 {
+		return fmt.Errorf("%w: max_diff_percent must be between 0 and 100", ErrInvalidComparison)
+	}
+	return nil
+}
+
+func (c *VisualCompare) options() visual.Options {
+	return visual.Options{Threshold: c.Threshold, Ignore: c.Ignore}
+}
+
+// Baselines returns the store of visual baselines
+func (m *Manager) Baselines() *visual.Store {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.baselines
+}
+
+// SetBaselineStore replaces the in-memory baseline store, such as with one on disk
+func (m *Manager) SetBaselineStore(store *visual.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.baselines = store
+}
+
+// CompareScreenshot captures a page and compares it with the session tenant's baseline
+// of that name. The first capture under a name becomes its baseline.
+func (m *Manager) CompareScreenshot(ctx context.Context, sessionID string, pageID string, req VisualCompare) (*VisualComparison, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	comparison, err := m.compareScreenshot(ctx, session, pageID, req)
+	if err != nil {
+		return nil, err
+	}
+	session.UpdateActivity()
+	return comparison, nil
+}
+
+// compareScreenshot does the capture and comparison of a validated request
+func (m *Manager) compareScreenshot(ctx context.Context, session *Session, pageID string, req VisualCompare) (*VisualComparison, error) {
+	screenshot, err := session.Driver().Screenshot(ctx, pageID, m.maxResponseSize())
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
+	}
+
+	store := m.Baselines()
+	tenantID := session.TenantID
+	comparison := &VisualComparison{Baseline: req.Baseline, Passed: true}
+
+	baseline, err := store.Get(tenantID, req.Baseline)
+	if errors.Is(err, visual.ErrBaselineNotFound) || (err == nil && req.Update) {
+		if err := store.Put(tenantID, req.Baseline, screenshot); err != nil {
+			return nil, err
+		}
+		comparison.Created = baseline == nil
+		comparison.Updated = baseline != nil
+		return comparison, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := visual.Compare(baseline, screenshot, req.options())
+	if err != nil {
+		return nil, err
+	}
+	comparison.Result = result
+	comparison.Passed = result.ChangePercent <= req.MaxDiffPercent
+	return comparison, nil
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/visual"
+)
+
+// TestCompareScreenshot tests that the first capture becomes the baseline, that later
+// ones are compared against it within the allowed change, and that compare run steps
+// fail on a changed page
+func TestCompareScreenshot(t *testing.T) {
+	// The page is white with a banner 10 pixels high when bannerBottom is 10
+	var mu sync.Mutex
+	bannerBottom := 10
+	render := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 20; x++ {
+				c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+				if y < bannerBottom {
+					c = color.RGBA{B: 128, A: 255}
+				}
+				img.SetRGBA(x, y, c)
+			}
+		}
+		var buf bytes.Buffer
+		png.Encode(&buf, img)
+		return base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		switch method {
+		case "Page.captureScreenshot":
+			return map[string]interface{}{"data": render()}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com/")
+
+	comparison, err := manager.CompareScreenshot(ctx, sess.ID, pageID, VisualCompare{Baseline: "home"})
+	if err != nil {
+		t.Fatalf("CompareScreenshot failed: %v", err)
+	}
+	if !comparison.Created || !comparison.Passed || comparison.Result != nil {
+		t.Errorf("expected the first capture to create the baseline, got %+v", comparison)
+	}
+
+	comparison, err = manager.CompareScreenshot(ctx, sess.ID, pageID, VisualCompare{Baseline: "home"})
+	if err != nil {
+		t.Fatalf("CompareScreenshot failed: %v", err)
+	}
+	if comparison.Created || !comparison.Passed || comparison.ChangedPixels != 0 {
+		t.Errorf("expected an unchanged page to pass, got %+v", comparison)
+	}
+
+	// The banner grows by two rows: 40 of 400 pixels change
+	mu.Lock()
+	bannerBottom = 12
+	mu.Unlock()
+
+	comparison, err = manager.CompareScreenshot(ctx, sess.ID, pageID, VisualCompare{Baseline: "home", MaxDiffPercent: 5})
+	if err != nil {
+		t.Fatalf("CompareScreenshot failed: %v", err)
+	}
+	if comparison.Passed || comparison.ChangePercent != 10 || len(comparison.Diff) == 0 {
+		t.Errorf("expected a 10%% change to fail a 5%% limit with a diff image, got %+v", comparison)
+	}
+
+	comparison, err = manager.CompareScreenshot(ctx, sess.ID, pageID, VisualCompare{
+		Baseline: "home",
+		Ignore:   []visual.Region{{X: 0, Y: 10, Width: 20, Height: 2}},
+	})
+	if err != nil {
+		t.Fatalf("CompareScreenshot failed: %v", err)
+	}
+	if !comparison.Passed || comparison.ChangedPixels != 0 {
+		t.Errorf("expected the ignored rows to be left out, got %+v", comparison)
+	}
+
+	// As a run step, the change fails the run
+	run, err := manager.Run(ctx, sess.ID, pageID, []RunStep{{Action: StepCompare, Baseline: "home", MaxDiffPercent: 5}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if run.Success || run.Steps[0].Status != StepFailed {
+		t.Errorf("expected the compare step to fail the run, got %+v", run.Steps[0])
+	}
+
+	// Updating accepts the new look
+	comparison, err = manager.CompareScreenshot(ctx, sess.ID, pageID, VisualCompare{Baseline: "home", Update: true})
+	if err != nil {
+		t.Fatalf("CompareScreenshot failed: %v", err)
+	}
+	if !comparison.Updated {
+		t.Errorf("expected the baseline to be updated, got %+v", comparison)
+	}
+	run, err = manager.Run(ctx, sess.ID, pageID, []RunStep{{Action: StepCompare, Baseline: "home"}})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !run.Success {
+		t.Errorf("expected the page to match the updated baseline, got %+v", run.Steps[0])
+	}
+
+	baselines, err := manager.Baselines().List("")
+	if err != nil || len(baselines) != 1 || baselines[0].Height != 20 {
+		t.Errorf("expected one 20 pixel high baseline, got %+v, %v", baselines, err)
+	}
+
+	if _, err := manager.CompareScreenshot(ctx, sess.ID, pageID, VisualCompare{Baseline: "../home"}); !errors.Is(err, ErrInvalidComparison) {
+		t.Errorf("expected ErrInvalidComparison for a bad name, got %v", err)
+	}
+	if _, err := manager.CompareScreenshot(ctx, sess.ID, pageID, VisualCompare{Baseline: "home", MaxDiffPercent: 101}); !errors.Is(err, ErrInvalidComparison) {
+		t.Errorf("expected ErrInvalidComparison for max_diff_percent over 100, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWaitTimeout is how long a wait lasts when no timeout is given
+	DefaultWaitTimeout = 30 * time.Second
+
+	// MaxWaitTimeout bounds how long a single wait may hold a request open
+	MaxWaitTimeout = 5 * time.Minute
+
+	// DefaultWaitInterval is the polling interval when none is given
+	DefaultWaitInterval = 100 * time.Millisecond
+
+	// minWaitInterval keeps a wait from busy-looping the page
+	minWaitInterval = 20 * time.Millisecond
+)
+
+// Selector states a wait can ask for
+var waitStates = []string{"attached", "visible", "hidden", "detached"}
+
+// WaitRequest is a condition to wait for. Exactly one of Selector, URL and
+// Function is set.
+type WaitRequest struct {
+	Selector string
+	SelectorEngine
+	State string // attached (default), visible, hidden or detached
+
+	// URL is a glob where * matches any run of characters, or a regular expression
+	// between slashes, e.g. /\/orders\/\d+$/
+	URL string
+
+	// Function is a JavaScript expression, or a function to call, whose result must
+	// become truthy. It may return a promise. Errors count as not yet.
+	Function string
+
+	Timeout  time.Duration // DefaultWaitTimeout when zero
+	Interval time.Duration // DefaultWaitInterval when zero
+}
+
+// WaitResult reports how a wait ended
+type WaitResult struct {
+	Satisfied bool        `json:"satisfied"` // False when the timeout passed first
+	Condition string      `json:"condition"` // "selector", "url" or "function"
+	URL       string      `json:"url"`       // Page URL when the wait ended
+	Value     interface{} `json:"value,omitempty"`
+	LastError string      `json:"last_error,omitempty"` // Most recent evaluation error, when not satisfied
+	Polls     int         `json:"polls"`
+	Duration  string      `json:"duration"`
+}
+
+// waitSelectorJS checks the element an expression finds against a state
+const waitSelectorJS = `(function(state) {
+  var el = %s;
+  if (state === 'attached') return {met: !!el};
+  if (state === 'detached') return {met: !el};
+
+  var visible = false;
+  if (el) {
+    var rect = el.getBoundingClientRect(), style = getComputedStyle(el);
+    visible = rect.width > 0 && rect.height > 0 && style.display !== 'none' &&
+      style.visibility !== 'hidden' && Number(style.opacity) > 0;
+  }
+  return {met: state === 'visible' ? visible : !visible};
+})(%s)`
+
+// waitFunctionJS evaluates a predicate; the value is returned only if it survives JSON
+const waitFunctionJS = `Promise.resolve((function() {
+  var value = (%s);
+  return typeof value === 'function' ? value() : value;
+})()).then(function(value) {
+  var copy = null;
+  try { copy = JSON.parse(JSON.stringify(value)); } catch (e) {}
+  return {met: !!value, value: copy === undefined ? null : copy};
+})`
+
+// waitPoll is what the wait scripts report
+type waitPoll struct {
+	Met   bool        `json:"met"`
+	Value interface{} `json:"value"`
+}
+
+// compileURLPattern turns a wait URL pattern into a regular expression
+func compileURLPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return regexp.Compile(pattern[1 : len(pattern)-1])
+	}
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// validateWait fills in defaults and checks that the request names one valid condition
+func validateWait(req *WaitRequest) error {
+	conditions := 0
+	for _, set := range []bool{req.Selector != "", req.URL != "", req.Function != ""} {
+		if set {
+			conditions++
+		}
+	}
+	if conditions != 1 {
+		return fmt.Errorf("%w: set exactly one of selector, url and function", ErrInvalidWait)
+	}
+
+	if req.State == "" {
+		req.State = "attached"
+	}
+	if req.Selector != "" && !slices.Contains(waitStates, req.State) {
+		return fmt.Errorf("%w: unknown state %q", ErrInvalidWait, req.State)
+	}
+	if err := req.SelectorEngine.validate(); err != nil {
+		return err
+	}
+
+	if req.Timeout <= 0 {
+		req.Timeout = DefaultWaitTimeout
+	}
+	if req.Timeout > MaxWaitTimeout {
+		return fmt.Errorf("%w: timeout is limited to %s", ErrInvalidWait, MaxWaitTimeout)
+	}
+	if req.Interval <= 0 {
+		req.Interval = DefaultWaitInterval
+	}
+	req.Interval = max(req.Interval, minWaitInterval)
+
+	return nil
+}
+
+// Wait polls a page until a selector reaches a state, the URL matches a pattern, or a
+// predicate becomes truthy. Running out of time is not an error: the result reports
+// Satisfied false.
+func (m *Manager) Wait(ctx context.Context, sessionID string, pageID string, req WaitRequest) (*WaitResult, error) {
+	if err := validateWait(&req); err != nil {
+		return nil, err
+	}
+
+	var urlPattern *regexp.Regexp
+	if req.URL != "" {
+		pattern, err := compileURLPattern(req.URL)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad url pattern: %w", ErrInvalidWait, err)
+		}
+		urlPattern = pattern
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	var script string
+	result := &WaitResult{}
+	switch {
+	case req.Selector != "":
+		result.Condition = "selector"
+		stateJSON, _ := json.Marshal(req.State)
+		script = fmt.Sprintf(waitSelectorJS, req.SelectorEngine.first(req.Selector), stateJSON)
+	case req.Function != "":
+		result.Condition = "function"
+		script = fmt.Sprintf(waitFunctionJS, req.Function)
+	default:
+		result.Condition = "url"
+	}
+
+	startTime := time.Now()
+	deadline := startTime.Add(req.Timeout)
+
+	for {
+		result.Polls++
+
+		if urlPattern != nil {
+			url, err := session.GetCurrentURL(ctx, pageID)
+			if err == nil {
+				result.URL = url
+				result.Satisfied = urlPattern.MatchString(url)
+			} else {
+				result.LastError = err.Error()
+			}
+		} else {
+			// A predicate's promise may never settle, so no poll outlives the wait
+			pollCtx, cancel := context.WithDeadline(ctx, deadline)
+			raw, err := session.evaluatePromise(pollCtx, pageID, script)
+			cancel()
+			var poll waitPoll
+			if err == nil {
+				err = json.Unmarshal(raw, &poll)
+			}
+			if err != nil {
+				// A broken selector or predicate will never succeed, so don't wait it out
+				if strings.Contains(err.Error(), "SyntaxError") {
+					if req.Selector != "" {
+						return nil, fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+					}
+					return nil, fmt.Errorf("%w: %w", ErrInvalidWait, err)
+				}
+				// Otherwise the page may be navigating or the predicate not ready yet
+				result.LastError = err.Error()
+			} else {
+				result.Satisfied = poll.Met
+				result.Value = poll.Value
+			}
+		}
+
+		// The last poll lands on the deadline rather than an interval short of it
+		remaining := time.Until(deadline)
+		if result.Satisfied || remaining <= 0 {
+			break
+		}
+		if err := sleepContext(ctx, min(req.Interval, remaining)); err != nil {
+			return nil, fmt.Errorf("wait cancelled: %w", err)
+		}
+		if !session.HasPage(pageID) {
+			return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+		}
+	}
+
+	if result.Satisfied {
+		result.LastError = ""
+	}
+	if result.URL == "" {
+		result.URL, _ = session.GetCurrentURL(ctx, pageID)
+	}
+	result.Duration = time.Since(startTime).String()
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestCompileURLPattern tests glob and regular expression URL patterns
+func TestCompileURLPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		url     string
+		match   bool
+	}{
+		{"https://example.com/orders/*", "https://example.com/orders/42", true},
+		{"https://example.com/orders/*", "https://example.com/cart", false},
+		{"*/login?*", "https://example.com/login?next=/", true},
+		{"https://example.com/a.b", "https://example.com/aXb", false}, // Dots are literal in globs
+		{`/\/orders\/\d+$/`, "https://example.com/orders/42", true},
+		{`/\/orders\/\d+$/`, "https://example.com/orders/new", false},
+	}
+
+	for _, tc := range cases {
+		pattern, err := compileURLPattern(tc.pattern)
+		if err != nil {
+			t.Fatalf("failed to compile %q: %v", tc.pattern, err)
+		}
+		if got := pattern.MatchString(tc.url); got != tc.match {
+			t.Errorf("%q matching %q = %v, want %v", tc.pattern, tc.url, got, tc.match)
+		}
+	}
+}
+
+// TestValidateWait tests wait defaults and rejected requests
+func TestValidateWait(t *testing.T) {
+	req := WaitRequest{Selector: "#done", Interval: time.Millisecond}
+	if err := validateWait(&req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.State != "attached" || req.Timeout != DefaultWaitTimeout || req.Interval != minWaitInterval {
+		t.Errorf("unexpected defaults: %+v", req)
+	}
+
+	invalid := []WaitRequest{
+		{},
+		{Selector: "#done", URL: "*/done"},
+		{Selector: "#done", State: "enabled"},
+		{Function: "window.ready", Timeout: 2 * MaxWaitTimeout},
+	}
+	for _, req := range invalid {
+		if err := validateWait(&req); !errors.Is(err, ErrInvalidWait) {
+			t.Errorf("expected ErrInvalidWait for %+v, got %v", req, err)
+		}
+	}
+}
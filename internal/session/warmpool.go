@@ -0,0 +1,282 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultWarmPoolRefillInterval is how often the warm pool is topped up when nothing claimed from it
+const DefaultWarmPoolRefillInterval = 30 * time.Second
+
+// WarmPoolConfig controls the pre-created browser contexts kept ready for new sessions
+type WarmPoolConfig struct {
+	Size           int           // Contexts kept ready per browser port (0 disables the pool)
+	Template       string        // Template whose options warm contexts are created with ("" = defaults)
+	Preopen        bool          // Also keep a blank page with the template's setup applied
+	RefillInterval time.Duration // Periodic top-up interval
+}
+
+// WarmPoolStats reports how many warm contexts are ready on each port
+type WarmPoolStats struct {
+	Template string      `json:"template,omitempty"`
+	Target   int         `json:"target_per_port"`
+	Ready    map[int]int `json:"ready"`
+	Claimed  int64       `json:"claimed"`
+	Missed   int64       `json:"missed"`
+}
+
+// warmContext is a browser context created ahead of demand
+type warmContext struct {
+	port      int
+	contextID string
+	pageID    string // Pre-opened blank page ("" unless Preopen)
+}
+
+// warmPool keeps Size ready contexts per port and refills in the background
+type warmPool struct {
+	config  WarmPoolConfig
+	options *SessionOptions // Resolved options every warm context was created with
+	ports   []int
+	ready   map[int][]*warmContext
+	claimed int64
+	missed  int64
+	refill  chan struct{}
+	mu      sync.Mutex
+}
+
+// StartWarmPool pre-creates browser contexts on the given ports so CreateSession
+// can claim one instead of creating a context per request.
+func (m *Manager) StartWarmPool(ports []int, config WarmPoolConfig) error {
+	if config.Size <= 0 {
+		return nil
+	}
+	if config.RefillInterval <= 0 {
+		config.RefillInterval = DefaultWarmPoolRefillInterval
+	}
+
+	// Warm contexts are claimed by every tenant, so only shared templates apply
+	opts, err := m.ResolveSessionOptions("", config.Template, nil)
+	if err != nil {
+		return fmt.Errorf("failed to resolve warm pool template: %w", err)
+	}
+
+	pool := &warmPool{
+		config:  config,
+		options: opts,
+		ports:   ports,
+		ready:   make(map[int][]*warmContext),
+		refill:  make(chan struct{}, 1),
+	}
+
+	m.mu.Lock()
+	m.warm = pool
+	m.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(config.RefillInterval)
+		defer ticker.Stop()
+
+		slog.Info("warm pool started",
+			"size_per_port", config.Size,
+			"template", config.Template,
+			"preopen", config.Preopen)
+
+		for {
+			m.fillWarmPool(pool)
+
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-pool.refill:
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// fillWarmPool tops every port up to the configured size
+func (m *Manager) fillWarmPool(pool *warmPool) {
+	for _, port := range pool.ports {
+		pool.mu.Lock()
+		missing := pool.config.Size - len(pool.ready[port])
+		pool.mu.Unlock()
+
+		for i := 0; i < missing; i++ {
+			if m.ctx.Err() != nil {
+				return
+			}
+
+			entry, err := m.createWarmContext(pool, port)
+			if err != nil {
+				slog.Warn("failed to create warm context", "port", port, "error", err)
+				break
+			}
+
+			pool.mu.Lock()
+			pool.ready[port] = append(pool.ready[port], entry)
+			pool.mu.Unlock()
+		}
+	}
+}
+
+// createWarmContext creates one context (and optional page) with the pool's options applied
+func (m *Manager) createWarmContext(pool *warmPool, port int) (*warmContext, error) {
+	// Only the client lookup needs the manager lock; context creation runs outside it
+	m.mu.Lock()
+	client, err := m.GetOrCreateCDPClient(port)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create CDP client: %w", err)
+	}
+
+	contextID, err := client.CreateBrowserContextWithOptions(context.Background(), contextOptions(pool.options, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// A throwaway session value reuses the same setup code real sessions go through
+	scratch := &Session{ContextID: contextID, CDPClient: client, Options: pool.options}
+	entry := &warmContext{port: port, contextID: contextID}
+
+	err = scratch.applyContextOptions(context.Background())
+	if err == nil && pool.config.Preopen {
+		entry.pageID, err = client.CreateTarget(context.Background(), "about:blank", contextID)
+		if err == nil {
+			err = scratch.setupPage(context.Background(), entry.pageID)
+		}
+	}
+
+	if err != nil {
+		if disposeErr := client.DisposeBrowserContext(context.Background(), contextID); disposeErr != nil {
+			slog.Warn("failed to dispose warm context", "context_id", contextID, "error", disposeErr)
+		}
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// withoutLabels returns opts with its labels removed, or nil if nothing else is set
+func withoutLabels(opts *SessionOptions) *SessionOptions {
+	if opts == nil || len(opts.Labels) == 0 {
+		return opts
+	}
+	stripped := *opts
+	stripped.Labels = nil
+	if reflect.DeepEqual(stripped, SessionOptions{}) {
+		return nil
+	}
+	return &stripped
+}
+
+// claim hands out a ready context for port if it was warmed with exactly opts; labels
+// don't count since they never reach the browser. It is safe to call on a nil pool.
+func (pool *warmPool) claim(port int, opts *SessionOptions) *warmContext {
+	if pool == nil {
+		return nil
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	// Sessions asking for anything else get a fresh context so their setup is exact
+	entries := pool.ready[port]
+	if len(entries) == 0 || !reflect.DeepEqual(withoutLabels(opts), pool.options) {
+		pool.missed++
+		return nil
+	}
+
+	entry := entries[0]
+	pool.ready[port] = entries[1:]
+	pool.claimed++
+
+	// Wake the refiller without blocking the caller
+	select {
+	case pool.refill <- struct{}{}:
+	default:
+	}
+
+	return entry
+}
+
+// drainWarmPool disposes every ready context on port (all ports if port is 0)
+func (m *Manager) drainWarmPool(port int) {
+	m.mu.RLock()
+	pool := m.warm
+	m.mu.RUnlock()
+	if pool == nil {
+		return
+	}
+
+	pool.mu.Lock()
+	drained := make([]*warmContext, 0)
+	for p, entries := range pool.ready {
+		if port == 0 || p == port {
+			drained = append(drained, entries...)
+			delete(pool.ready, p)
+		}
+	}
+	pool.mu.Unlock()
+
+	for _, entry := range drained {
+		m.mu.RLock()
+		client := m.cdpClients[entry.port]
+		m.mu.RUnlock()
+		if client == nil {
+			continue
+		}
+		if err := client.DisposeBrowserContext(context.Background(), entry.contextID); err != nil {
+			slog.Warn("failed to dispose warm context", "context_id", entry.contextID, "error", err)
+		}
+	}
+}
+
+// WarmPoolStats returns the warm pool's current state, or nil when it is disabled
+func (m *Manager) WarmPoolStats() *WarmPoolStats {
+	m.mu.RLock()
+	pool := m.warm
+	m.mu.RUnlock()
+	if pool == nil {
+		return nil
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	ready := make(map[int]int, len(pool.ports))
+	for _, port := range pool.ports {
+		ready[port] = len(pool.ready[port])
+	}
+
+	return &WarmPoolStats{
+		Template: pool.config.Template,
+		Target:   pool.config.Size,
+		Ready:    ready,
+		Claimed:  pool.claimed,
+		Missed:   pool.missed,
+	}
+}
+
+// takeWarmPage returns the session's pre-opened page once, clearing it
+func (s *Session) takeWarmPage() string {
+	s.warmMu.Lock()
+	defer s.warmMu.Unlock()
+
+	pageID := s.warmPageID
+	s.warmPageID = ""
+	return pageID
+}
+
+// isWarmPage reports whether pageID is the session's unclaimed pre-opened page
+func (s *Session) isWarmPage(pageID string) bool {
+	s.warmMu.Lock()
+	defer s.warmMu.Unlock()
+
+	return pageID != "" && s.warmPageID == pageID
+}
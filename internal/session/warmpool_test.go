@@ -0,0 +1,69 @@
+package session
+
+import (
+	"testing"
+)
+
+// TestWarmPoolClaim tests that warm contexts are handed out only for matching options
+func TestWarmPoolClaim(t *testing.T) {
+	template := &SessionOptions{Viewport: &Viewport{Width: 800, Height: 600}}
+	pool := &warmPool{
+		config:  WarmPoolConfig{Size: 2},
+		options: MergeSessionOptions(template, nil),
+		ports:   []int{9222},
+		ready: map[int][]*warmContext{
+			9222: {{port: 9222, contextID: "ctx-1"}, {port: 9222, contextID: "ctx-2"}},
+		},
+		refill: make(chan struct{}, 1),
+	}
+
+	// Different options never get a context prepared for something else
+	if entry := pool.claim(9222, &SessionOptions{UserAgent: "other"}); entry != nil {
+		t.Errorf("expected miss for mismatched options, got %s", entry.contextID)
+	}
+
+	// Equal options resolved separately still match
+	entry := pool.claim(9222, MergeSessionOptions(template, nil))
+	if entry == nil || entry.contextID != "ctx-1" {
+		t.Fatalf("expected ctx-1, got %+v", entry)
+	}
+
+	select {
+	case <-pool.refill:
+	default:
+		t.Error("expected claim to signal a refill")
+	}
+
+	// Other ports have nothing ready
+	if entry := pool.claim(9223, MergeSessionOptions(template, nil)); entry != nil {
+		t.Errorf("expected miss on empty port, got %s", entry.contextID)
+	}
+
+	if pool.claimed != 1 || pool.missed != 2 {
+		t.Errorf("expected 1 claimed and 2 missed, got %d and %d", pool.claimed, pool.missed)
+	}
+
+	// A disabled pool is a nil pool
+	var disabled *warmPool
+	if disabled.claim(9222, nil) != nil {
+		t.Error("expected nil pool to never return a context")
+	}
+}
+
+// TestWarmPageTakenOnce tests that the pre-opened page is used by a single navigation
+func TestWarmPageTakenOnce(t *testing.T) {
+	sess := &Session{warmPageID: "page-1"}
+
+	if !sess.isWarmPage("page-1") {
+		t.Error("expected page-1 to be the warm page")
+	}
+	if got := sess.takeWarmPage(); got != "page-1" {
+		t.Errorf("expected page-1, got %q", got)
+	}
+	if got := sess.takeWarmPage(); got != "" {
+		t.Errorf("expected warm page to be consumed, got %q", got)
+	}
+	if sess.isWarmPage("page-1") {
+		t.Error("expected page-1 to be an ordinary page after it was taken")
+	}
+}
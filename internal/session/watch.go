@@ -0,0 +1,452 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+const (
+	// DefaultWatchDebounce is how long a watched page must be quiet before a change is reported
+	DefaultWatchDebounce = 250 * time.Millisecond
+
+	// MaxWatchDebounce bounds the debounce an agent can ask for
+	MaxWatchDebounce = time.Minute
+
+	// maxWatchesPerPage bounds the observers one page carries
+	maxWatchesPerPage = 20
+
+	// watchBinding is the page function watchers report through (Runtime.addBinding)
+	watchBinding = "__bqaWatch"
+)
+
+// WatchRequest asks to be told when content matching a selector appears or changes
+type WatchRequest struct {
+	Selector string
+	SelectorEngine
+	Debounce   time.Duration // Quiet period before reporting; DefaultWatchDebounce when zero
+	Attributes bool          // Also report attribute changes, not just content
+}
+
+// DOMWatch is a registered watch on a page. It survives navigations of the page.
+type DOMWatch struct {
+	ID       string `json:"watch_id"`
+	PageID   string `json:"page_id"`
+	Selector string `json:"selector"`
+	SelectorEngine
+	DebounceMs int64     `json:"debounce_ms"`
+	Attributes bool      `json:"attributes"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	scriptID string          // Page.addScriptToEvaluateOnNewDocument identifier
+	notify   func(DOMChange) // Publishes a change on the session stream
+}
+
+// DOMChange is the data of a dom_changed event
+type DOMChange struct {
+	WatchID  string `json:"watch_id"`
+	Selector string `json:"selector"`
+	Change   string `json:"change"` // "appeared", "changed" or "removed"
+	Count    int    `json:"count"`  // Elements matching the selector now
+	Text     string `json:"text"`   // Text of the first match, truncated
+	URL      string `json:"url"`
+}
+
+// pageWatches are the watches of one page and the binding listener they share
+type pageWatches struct {
+	watches     map[string]*DOMWatch
+	unsubscribe func()
+}
+
+// watchJS installs a MutationObserver that reports matching content through the
+// binding. It runs on the current document and, as an init script, on every later one.
+// Reports are debounced, but a page that never goes quiet still reports every
+// maxWait so watchers aren't starved.
+const watchJS = `(function(id, find, debounceMs, attributes) {
+  find(); // Throws now for an invalid selector
+
+  var registry = window.__bqaWatches = window.__bqaWatches || {};
+  if (registry[id]) return true;
+
+  var maxWait = debounceMs * 5, timer = null, firstPending = 0;
+  var lastCount = 0, lastSignature = '0'; // No matches yet
+
+  function check() {
+    timer = null;
+    firstPending = 0;
+    var nodes = find(), signature = String(nodes.length);
+    for (var i = 0; i < nodes.length; i++) {
+      signature += '\u0000' + (attributes ? nodes[i].outerHTML : nodes[i].textContent);
+    }
+    if (signature === lastSignature) return;
+
+    var change = nodes.length === 0 ? 'removed' : lastCount === 0 ? 'appeared' : 'changed';
+    lastSignature = signature;
+    lastCount = nodes.length;
+
+    var text = nodes.length ? String(nodes[0].innerText || nodes[0].textContent || '').replace(/\s+/g, ' ').trim() : '';
+    if (typeof window.` + watchBinding + ` === 'function') {
+      window.` + watchBinding + `(JSON.stringify({
+        watch_id: id, change: change, count: nodes.length,
+        text: text.length > 200 ? text.slice(0, 197) + '...' : text, url: location.href
+      }));
+    }
+  }
+
+  function schedule() {
+    var now = Date.now();
+    if (!firstPending) firstPending = now;
+    if (timer) clearTimeout(timer);
+    timer = setTimeout(check, now - firstPending >= maxWait ? 0 : debounceMs);
+  }
+
+  var observer = new MutationObserver(schedule);
+  observer.observe(document, {childList: true, subtree: true, characterData: true, attributes: attributes});
+  registry[id] = {
+    stop: function() { observer.disconnect(); if (timer) clearTimeout(timer); delete registry[id]; }
+  };
+
+  // Content that is already there counts as having appeared
+  schedule();
+  return true;
+})(%s, function() { return %s; }, %d, %t)`
+
+// unwatchJS stops a watch on the current document
+const unwatchJS = `(function(id) {
+  var watch = window.__bqaWatches && window.__bqaWatches[id];
+  if (watch) watch.stop();
+  return true;
+})(%s)`
+
+// generateWatchID creates a unique watch identifier
+func generateWatchID() (string, error) {
+	randomBytes := make([]byte, 9)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", fmt.Errorf("failed to generate watch ID: %w", err)
+	}
+
+	return "wch_" + base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// addWatch starts watching a page. notify runs on the CDP reader goroutine and must not block.
+func (s *Session) addWatch(ctx context.Context, targetID string, req WatchRequest, notify func(DOMChange)) (*DOMWatch, error) {
+	// Setup talks to the browser, so it is serialized separately from the map lock the
+	// binding listener takes on the CDP reader goroutine
+	s.watchSetupMu.Lock()
+	defer s.watchSetupMu.Unlock()
+
+	s.watchMu.Lock()
+	page := s.watches[targetID]
+	count := 0
+	if page != nil {
+		count = len(page.watches)
+	}
+	s.watchMu.Unlock()
+	if count >= maxWatchesPerPage {
+		return nil, fmt.Errorf("%w: page already has %d watches", ErrInvalidWatch, count)
+	}
+
+	watchID, err := generateWatchID()
+	if err != nil {
+		return nil, err
+	}
+	watch := &DOMWatch{
+		ID:             watchID,
+		PageID:         targetID,
+		Selector:       req.Selector,
+		SelectorEngine: req.SelectorEngine,
+		DebounceMs:     req.Debounce.Milliseconds(),
+		Attributes:     req.Attributes,
+		CreatedAt:      time.Now(),
+		notify:         notify,
+	}
+
+	// The binding and its listener are shared by every watch on the page
+	if page == nil {
+		cdpSessionID, err := s.CDPClient.AttachToTarget(ctx, targetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach for watch: %w", err)
+		}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.enable", nil); err != nil {
+			return nil, fmt.Errorf("failed to enable runtime domain: %w", err)
+		}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.addBinding", map[string]interface{}{"name": watchBinding}); err != nil {
+			return nil, fmt.Errorf("failed to add watch binding: %w", err)
+		}
+		page = &pageWatches{watches: make(map[string]*DOMWatch)}
+		page.unsubscribe = s.CDPClient.OnEvent("Runtime.bindingCalled", cdpSessionID, func(event *cdp.Event) {
+			s.dispatchWatchEvent(targetID, event)
+		})
+	}
+
+	err = s.installWatch(ctx, targetID, watch)
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	if err != nil {
+		// A listener set up for this watch alone isn't kept
+		if s.watches[targetID] != page {
+			page.unsubscribe()
+		}
+		return nil, err
+	}
+
+	if s.watches == nil {
+		s.watches = make(map[string]*pageWatches)
+	}
+	s.watches[targetID] = page
+	page.watches[watch.ID] = watch
+
+	return watch, nil
+}
+
+// installWatch runs the watch script on the current document and registers it for later ones
+func (s *Session) installWatch(ctx context.Context, targetID string, watch *DOMWatch) error {
+	idJSON, _ := json.Marshal(watch.ID)
+	script := fmt.Sprintf(watchJS, idJSON, watch.SelectorEngine.all(watch.Selector), watch.DebounceMs, watch.Attributes)
+
+	// Running it now first also checks the selector
+	result, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Runtime.evaluate", map[string]interface{}{"expression": script})
+	if err != nil {
+		return fmt.Errorf("failed to start watch: %w", err)
+	}
+	if err := scriptException(result); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+	}
+
+	result, err = s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.addScriptToEvaluateOnNewDocument", map[string]interface{}{"source": script})
+	if err != nil {
+		return fmt.Errorf("failed to add watch script: %w", err)
+	}
+
+	var added struct {
+		Identifier string `json:"identifier"`
+	}
+	if err := json.Unmarshal(result, &added); err != nil {
+		return fmt.Errorf("failed to parse watch script identifier: %w", err)
+	}
+	watch.scriptID = added.Identifier
+	return nil
+}
+
+// scriptException returns the exception of a Runtime.evaluate response, if it threw
+func scriptException(result json.RawMessage) error {
+	var response struct {
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails,omitempty"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return fmt.Errorf("failed to parse evaluation result: %w", err)
+	}
+	if response.ExceptionDetails == nil {
+		return nil
+	}
+
+	message := response.ExceptionDetails.Exception.Description
+	if message == "" {
+		message = response.ExceptionDetails.Text
+	}
+	// Keep the first line; the rest is a stack trace into the watch script
+	message, _, _ = strings.Cut(message, "\n")
+	return fmt.Errorf("%s", message)
+}
+
+// dispatchWatchEvent hands a binding call from the page to its watch
+func (s *Session) dispatchWatchEvent(targetID string, event *cdp.Event) {
+	var params struct {
+		Name    string `json:"name"`
+		Payload string `json:"payload"`
+	}
+	if err := json.Unmarshal(event.Params, &params); err != nil || params.Name != watchBinding {
+		return
+	}
+
+	var change DOMChange
+	if err := json.Unmarshal([]byte(params.Payload), &change); err != nil {
+		slog.Debug("failed to parse watch change", "page_id", targetID, "error", err)
+		return
+	}
+
+	s.watchMu.Lock()
+	var watch *DOMWatch
+	if page := s.watches[targetID]; page != nil {
+		watch = page.watches[change.WatchID]
+	}
+	s.watchMu.Unlock()
+
+	// Pages can't report for watches they don't have, or for a stopped one still running in an old document
+	if watch == nil {
+		return
+	}
+	change.Selector = watch.Selector
+	watch.notify(change)
+}
+
+// listWatches returns the watches of a page, oldest first
+func (s *Session) listWatches(targetID string) []DOMWatch {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	watches := make([]DOMWatch, 0)
+	if page := s.watches[targetID]; page != nil {
+		for _, watch := range page.watches {
+			watches = append(watches, *watch)
+		}
+	}
+	slices.SortFunc(watches, func(a, b DOMWatch) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return watches
+}
+
+// removeWatch stops a watch on the page and in its future documents
+func (s *Session) removeWatch(ctx context.Context, targetID string, watchID string) error {
+	// Removing a page's last watch mustn't race a new watch reusing its listener
+	s.watchSetupMu.Lock()
+	defer s.watchSetupMu.Unlock()
+
+	s.watchMu.Lock()
+	page := s.watches[targetID]
+	var watch *DOMWatch
+	if page != nil {
+		watch = page.watches[watchID]
+	}
+	if watch == nil {
+		s.watchMu.Unlock()
+		return fmt.Errorf("%w: %s", ErrWatchNotFound, watchID)
+	}
+	delete(page.watches, watchID)
+	if len(page.watches) == 0 {
+		delete(s.watches, targetID)
+		page.unsubscribe()
+	}
+	s.watchMu.Unlock()
+
+	// The watch no longer reports even if the page cleanup below fails
+	if watch.scriptID != "" {
+		params := map[string]interface{}{"identifier": watch.scriptID}
+		if _, err := s.CDPClient.SendCommandToTarget(ctx, targetID, "Page.removeScriptToEvaluateOnNewDocument", params); err != nil {
+			return fmt.Errorf("failed to remove watch script: %w", err)
+		}
+	}
+	idJSON, _ := json.Marshal(watchID)
+	if _, err := s.ExecuteJavascript(ctx, targetID, fmt.Sprintf(unwatchJS, idJSON)); err != nil {
+		return fmt.Errorf("failed to stop watch: %w", err)
+	}
+	return nil
+}
+
+// stopWatches forgets the watches of a page that is gone
+func (s *Session) stopWatches(targetID string) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	if page, exists := s.watches[targetID]; exists {
+		delete(s.watches, targetID)
+		page.unsubscribe()
+	}
+}
+
+// stopAllWatches forgets every watch of the session (used when its pages are torn down)
+func (s *Session) stopAllWatches() {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+
+	for targetID, page := range s.watches {
+		delete(s.watches, targetID)
+		page.unsubscribe()
+	}
+}
+
+// WatchDOM reports changes to content matching a selector as dom_changed events on
+// the session stream, so agents can wait for asynchronous content without polling
+func (m *Manager) WatchDOM(ctx context.Context, sessionID string, pageID string, req WatchRequest) (*DOMWatch, error) {
+	if strings.TrimSpace(req.Selector) == "" {
+		return nil, fmt.Errorf("%w: selector is required", ErrInvalidWatch)
+	}
+	if err := req.SelectorEngine.validate(); err != nil {
+		return nil, err
+	}
+	if req.Debounce <= 0 {
+		req.Debounce = DefaultWatchDebounce
+	}
+	if req.Debounce > MaxWatchDebounce {
+		return nil, fmt.Errorf("%w: debounce is limited to %s", ErrInvalidWatch, MaxWatchDebounce)
+	}
+
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	watch, err := session.addWatch(ctx, pageID, req, func(change DOMChange) {
+		m.publishEvent(sessionID, pageID, events.TypeDOMChanged, change)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return watch, nil
+}
+
+// ListWatches returns the DOM watches registered on a page
+func (m *Manager) ListWatches(sessionID string, pageID string) ([]DOMWatch, error) {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	return session.listWatches(pageID), nil
+}
+
+// Unwatch removes a DOM watch from a page
+func (m *Manager) Unwatch(ctx context.Context, sessionID string, pageID string, watchID string) error {
+	// Get the session from the manager
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Verify that the page ID is in the session
+	if !session.HasPage(pageID) {
+		return fmt.Errorf("%w: %s", ErrPageNotFound, pageID)
+	}
+
+	if err := session.removeWatch(ctx, pageID, watchID); err != nil {
+		return err
+	}
+
+	// Update the last activity time of the session
+	session.UpdateActivity()
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/cdp"
+)
+
+// TestWatchDOMValidation tests that bad watches are refused before touching the page
+func TestWatchDOMValidation(t *testing.T) {
+	manager := NewManager(nil)
+	defer manager.Close()
+
+	requests := []WatchRequest{
+		{Selector: "  "},
+		{Selector: "#results", Debounce: 2 * MaxWatchDebounce},
+	}
+	for _, req := range requests {
+		if _, err := manager.WatchDOM(context.Background(), "sess_missing", "PAGE1", req); !errors.Is(err, ErrInvalidWatch) {
+			t.Errorf("expected ErrInvalidWatch for %+v, got %v", req, err)
+		}
+	}
+}
+
+// TestDispatchWatchEvent tests routing binding calls from a page to its watches
+func TestDispatchWatchEvent(t *testing.T) {
+	var changes []DOMChange
+	unsubscribed := 0
+	session := &Session{
+		watches: map[string]*pageWatches{
+			"PAGE1": {
+				watches: map[string]*DOMWatch{
+					"wch_1": {ID: "wch_1", Selector: "#results", notify: func(change DOMChange) {
+						changes = append(changes, change)
+					}},
+				},
+				unsubscribe: func() { unsubscribed++ },
+			},
+		},
+	}
+
+	call := func(name string, payload string) {
+		params, _ := json.Marshal(map[string]string{"name": name, "payload": payload})
+		session.dispatchWatchEvent("PAGE1", &cdp.Event{Method: "Runtime.bindingCalled", Params: params})
+	}
+
+	call(watchBinding, `{"watch_id": "wch_1", "change": "appeared", "count": 3, "text": "3 results", "url": "https://example.com/"}`)
+	call(watchBinding, `{"watch_id": "wch_gone", "change": "changed", "count": 1}`)
+	call("someOtherBinding", `{"watch_id": "wch_1"}`)
+	call(watchBinding, `not json`)
+
+	if len(changes) != 1 {
+		t.Fatalf("expected one change, got %+v", changes)
+	}
+	if changes[0].Selector != "#results" || changes[0].Change != "appeared" || changes[0].Count != 3 {
+		t.Errorf("unexpected change: %+v", changes[0])
+	}
+
+	// A closed page's watches stop reporting
+	session.stopWatches("PAGE1")
+	call(watchBinding, `{"watch_id": "wch_1", "change": "removed", "count": 0}`)
+	if len(changes) != 1 || unsubscribed != 1 {
+		t.Errorf("expected no more changes and one unsubscribe, got %d changes and %d unsubscribes", len(changes), unsubscribed)
+	}
+}
@@ -0,0 +1,310 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Web storage areas a write goes to
+const (
+	StorageAreaLocal   = "local"
+	StorageAreaSession = "session"
+)
+
+// WebStorage is what one origin keeps in a session's browser context
+type WebStorage struct {
+	Origin    string            `json:"origin"`
+	Local     map[string]string `json:"local"`
+	Session   map[string]string `json:"session"` // The page's own: every tab has a sessionStorage of its own
+	IndexedDB []IndexedDatabase `json:"indexed_db"`
+}
+
+// IndexedDatabase summarizes an IndexedDB database without reading its records
+type IndexedDatabase struct {
+	Name         string           `json:"name"`
+	Version      float64          `json:"version"`
+	ObjectStores []IndexedDBStore `json:"object_stores"`
+}
+
+// IndexedDBStore is an object store of an IndexedDB database
+type IndexedDBStore struct {
+	Name          string   `json:"name"`
+	KeyPath       string   `json:"key_path,omitempty"` // Compound key paths are joined by ","
+	AutoIncrement bool     `json:"auto_increment,omitempty"`
+	Entries       int64    `json:"entries"`
+	Indexes       []string `json:"indexes,omitempty"`
+}
+
+// StorageWrite changes the localStorage or sessionStorage of an origin. Clear runs
+// first, then Remove, then Set.
+type StorageWrite struct {
+	Origin string            `json:"origin,omitempty"` // Default the page's
+	Area   string            `json:"area"`             // local or session
+	Set    map[string]string `json:"set,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+	Clear  bool              `json:"clear,omitempty"`
+}
+
+// validate rejects writes that would do nothing or contradict themselves
+func (w StorageWrite) validate() error {
+	if w.Area != StorageAreaLocal && w.Area != StorageAreaSession {
+		return fmt.Errorf("%w: area must be %s or %s, got %q", ErrInvalidStorage, StorageAreaLocal, StorageAreaSession, w.Area)
+	}
+	if len(w.Set) == 0 && len(w.Remove) == 0 && !w.Clear {
+		return fmt.Errorf("%w: nothing to set, remove or clear", ErrInvalidStorage)
+	}
+	for _, key := range w.Remove {
+		if _, set := w.Set[key]; set {
+			return fmt.Errorf("%w: %q is both set and removed", ErrInvalidStorage, key)
+		}
+	}
+	return nil
+}
+
+// normalizeOrigin reduces a URL or origin to scheme://host[:port], the form storage is keyed by
+func normalizeOrigin(origin string) (string, error) {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", fmt.Errorf("%w: origin must be an http or https origin, got %q", ErrInvalidStorage, origin)
+	}
+	return parsed.Scheme + "://" + strings.ToLower(parsed.Host), nil
+}
+
+// storageOrigin returns the origin a storage request is about, the page's when none is given
+func (s *Session) storageOrigin(ctx context.Context, pageID, origin string) (string, error) {
+	if origin != "" {
+		return normalizeOrigin(origin)
+	}
+
+	value, err := s.ExecuteJavascript(ctx, pageID, "location.origin")
+	if err != nil {
+		return "", fmt.Errorf("failed to read page origin: %w", err)
+	}
+	pageOrigin, _ := value.(string)
+	if !strings.HasPrefix(pageOrigin, "http") {
+		return "", fmt.Errorf("%w: the page has no web origin (%q), pass one", ErrInvalidStorage, pageOrigin)
+	}
+	return pageOrigin, nil
+}
+
+// storageID names an origin's localStorage or sessionStorage for the DOMStorage domain
+func storageID(origin, area string) map[string]interface{} {
+	return map[string]interface{}{"securityOrigin": origin, "isLocalStorage": area == StorageAreaLocal}
+}
+
+// storageItems reads an origin's localStorage or sessionStorage through the page
+func (s *Session) storageItems(ctx context.Context, pageID, origin, area string) (map[string]string, error) {
+	result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "DOMStorage.getDOMStorageItems", map[string]interface{}{
+		"storageId": storageID(origin, area),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %sStorage: %w", area, err)
+	}
+
+	var response struct {
+		Entries [][]string `json:"entries"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse %sStorage: %w", area, err)
+	}
+
+	items := make(map[string]string, len(response.Entries))
+	for _, entry := range response.Entries {
+		if len(entry) == 2 {
+			items[entry[0]] = entry[1]
+		}
+	}
+	return items, nil
+}
+
+// indexedDatabases summarizes every IndexedDB database of an origin: its object stores,
+// their key paths, indexes and record counts
+func (s *Session) indexedDatabases(ctx context.Context, pageID, origin string) ([]IndexedDatabase, error) {
+	result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "IndexedDB.requestDatabaseNames", map[string]interface{}{
+		"securityOrigin": origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list IndexedDB databases: %w", err)
+	}
+	var names struct {
+		DatabaseNames []string `json:"databaseNames"`
+	}
+	if err := json.Unmarshal(result, &names); err != nil {
+		return nil, fmt.Errorf("failed to parse IndexedDB databases: %w", err)
+	}
+
+	databases := make([]IndexedDatabase, 0, len(names.DatabaseNames))
+	for _, name := range names.DatabaseNames {
+		result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "IndexedDB.requestDatabase", map[string]interface{}{
+			"securityOrigin": origin,
+			"databaseName":   name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read IndexedDB database %s: %w", name, err)
+		}
+
+		var response struct {
+			Database struct {
+				Name         string  `json:"name"`
+				Version      float64 `json:"version"`
+				ObjectStores []struct {
+					Name          string     `json:"name"`
+					KeyPath       idbKeyPath `json:"keyPath"`
+					AutoIncrement bool       `json:"autoIncrement"`
+					Indexes       []struct {
+						Name string `json:"name"`
+					} `json:"indexes"`
+				} `json:"objectStores"`
+			} `json:"databaseWithObjectStores"`
+		}
+		if err := json.Unmarshal(result, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse IndexedDB database %s: %w", name, err)
+		}
+
+		database := IndexedDatabase{Name: name, Version: response.Database.Version, ObjectStores: []IndexedDBStore{}}
+		for _, store := range response.Database.ObjectStores {
+			summary := IndexedDBStore{Name: store.Name, KeyPath: store.KeyPath.joined(), AutoIncrement: store.AutoIncrement}
+			for _, index := range store.Indexes {
+				summary.Indexes = append(summary.Indexes, index.Name)
+			}
+
+			// A count that can't be read, e.g. while the page holds a version change open, is left at 0
+			result, err := s.CDPClient.SendCommandToTarget(ctx, pageID, "IndexedDB.getMetadata", map[string]interface{}{
+				"securityOrigin":  origin,
+				"databaseName":    name,
+				"objectStoreName": store.Name,
+			})
+			if err == nil {
+				var metadata struct {
+					EntriesCount float64 `json:"entriesCount"`
+				}
+				if json.Unmarshal(result, &metadata) == nil {
+					summary.Entries = int64(metadata.EntriesCount)
+				}
+			}
+			database.ObjectStores = append(database.ObjectStores, summary)
+		}
+		databases = append(databases, database)
+	}
+	return databases, nil
+}
+
+// idbKeyPath is an IndexedDB key path as the protocol reports it
+type idbKeyPath struct {
+	Type   string   `json:"type"` // null, string or array
+	String string   `json:"string"`
+	Array  []string `json:"array"`
+}
+
+// joined renders the key path; out-of-line keys have none
+func (p idbKeyPath) joined() string {
+	switch p.Type {
+	case "string":
+		return p.String
+	case "array":
+		return strings.Join(p.Array, ",")
+	}
+	return ""
+}
+
+// readStorage gathers everything one origin keeps, bounded by the response size limit
+func (s *Session) readStorage(ctx context.Context, pageID, origin string, limit int64) (*WebStorage, error) {
+	local, err := s.storageItems(ctx, pageID, origin, StorageAreaLocal)
+	if err != nil {
+		return nil, err
+	}
+	sessionItems, err := s.storageItems(ctx, pageID, origin, StorageAreaSession)
+	if err != nil {
+		return nil, err
+	}
+	databases, err := s.indexedDatabases(ctx, pageID, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int64
+	for _, items := range []map[string]string{local, sessionItems} {
+		for key, value := range items {
+			size += int64(len(key) + len(value))
+		}
+	}
+	if size > limit {
+		return nil, fmt.Errorf("%w: %s keeps %d bytes of web storage, the limit is %d", ErrResponseTooLarge, origin, size, limit)
+	}
+
+	return &WebStorage{Origin: origin, Local: local, Session: sessionItems, IndexedDB: databases}, nil
+}
+
+// InspectStorage returns the localStorage, sessionStorage and IndexedDB summary of an
+// origin in a session, read through one of its pages. origin defaults to the page's;
+// the sessionStorage is the page's tab's.
+func (m *Manager) InspectStorage(ctx context.Context, sessionID string, pageID string, origin string) (*WebStorage, error) {
+	session, err := m.devtoolsSession(sessionID, pageID, "storage inspection")
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err = session.storageOrigin(ctx, pageID, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := session.readStorage(ctx, pageID, origin, m.maxResponseSize())
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return storage, nil
+}
+
+// WriteStorage changes an origin's localStorage or sessionStorage and returns what the
+// origin keeps afterwards
+func (m *Manager) WriteStorage(ctx context.Context, sessionID string, pageID string, write StorageWrite) (*WebStorage, error) {
+	if err := write.validate(); err != nil {
+		return nil, err
+	}
+
+	session, err := m.devtoolsSession(sessionID, pageID, "storage inspection")
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err := session.storageOrigin(ctx, pageID, write.Origin)
+	if err != nil {
+		return nil, err
+	}
+	id := storageID(origin, write.Area)
+
+	if write.Clear {
+		if _, err := session.CDPClient.SendCommandToTarget(ctx, pageID, "DOMStorage.clear", map[string]interface{}{"storageId": id}); err != nil {
+			return nil, fmt.Errorf("failed to clear %sStorage: %w", write.Area, err)
+		}
+	}
+	for _, key := range write.Remove {
+		params := map[string]interface{}{"storageId": id, "key": key}
+		if _, err := session.CDPClient.SendCommandToTarget(ctx, pageID, "DOMStorage.removeDOMStorageItem", params); err != nil {
+			return nil, fmt.Errorf("failed to remove %s from %sStorage: %w", key, write.Area, err)
+		}
+	}
+	for key, value := range write.Set {
+		params := map[string]interface{}{"storageId": id, "key": key, "value": value}
+		if _, err := session.CDPClient.SendCommandToTarget(ctx, pageID, "DOMStorage.setDOMStorageItem", params); err != nil {
+			return nil, fmt.Errorf("failed to set %s in %sStorage: %w", key, write.Area, err)
+		}
+	}
+
+	// What the page renders from storage may have changed
+	session.InvalidatePageAnalysis(pageID)
+
+	storage, err := session.readStorage(ctx, pageID, origin, m.maxResponseSize())
+	if err != nil {
+		return nil, err
+	}
+
+	session.UpdateActivity()
+	return storage, nil
+}
@@ -0,0 +1,134 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestWebStorage tests reading an origin's web storage and IndexedDB summary, and
+// writing its storage items
+func TestWebStorage(t *testing.T) {
+	var mu sync.Mutex
+	local := map[string]string{"cart": `{"items":2}`, "theme": "dark"}
+	var origins []string
+	manager := newTestManager(t, func(method string, params json.RawMessage) map[string]interface{} {
+		var p struct {
+			Expression     string `json:"expression"`
+			SecurityOrigin string `json:"securityOrigin"`
+			StorageID      struct {
+				SecurityOrigin string `json:"securityOrigin"`
+				IsLocalStorage bool   `json:"isLocalStorage"`
+			} `json:"storageId"`
+			Key             string `json:"key"`
+			Value           string `json:"value"`
+			ObjectStoreName string `json:"objectStoreName"`
+		}
+		json.Unmarshal(params, &p)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch method {
+		case "Runtime.evaluate":
+			if p.Expression == "location.origin" {
+				return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "https://shop.example.com"}}
+			}
+			return map[string]interface{}{"result": map[string]interface{}{"type": "string", "value": "complete"}}
+		case "DOMStorage.getDOMStorageItems":
+			origins = append(origins, p.StorageID.SecurityOrigin)
+			entries := [][]string{}
+			if p.StorageID.IsLocalStorage {
+				for key, value := range local {
+					entries = append(entries, []string{key, value})
+				}
+			} else {
+				entries = append(entries, []string{"step", "3"})
+			}
+			return map[string]interface{}{"entries": entries}
+		case "DOMStorage.setDOMStorageItem":
+			local[p.Key] = p.Value
+			return map[string]interface{}{}
+		case "DOMStorage.removeDOMStorageItem":
+			delete(local, p.Key)
+			return map[string]interface{}{}
+		case "DOMStorage.clear":
+			clear(local)
+			return map[string]interface{}{}
+		case "IndexedDB.requestDatabaseNames":
+			return map[string]interface{}{"databaseNames": []string{"shop"}}
+		case "IndexedDB.requestDatabase":
+			return map[string]interface{}{"databaseWithObjectStores": map[string]interface{}{
+				"name": "shop", "version": 3, "objectStores": []interface{}{
+					map[string]interface{}{"name": "orders", "keyPath": map[string]interface{}{"type": "string", "string": "id"}, "autoIncrement": true,
+						"indexes": []interface{}{map[string]interface{}{"name": "by_date"}}},
+					map[string]interface{}{"name": "lines", "keyPath": map[string]interface{}{"type": "array", "array": []string{"order", "sku"}}},
+				},
+			}}
+		case "IndexedDB.getMetadata":
+			if p.ObjectStoreName == "orders" {
+				return map[string]interface{}{"entriesCount": 12, "keyGeneratorValue": 13}
+			}
+			return map[string]interface{}{"entriesCount": 30}
+		}
+		return nil
+	})
+
+	ctx := context.Background()
+
+	sess, pageID := openTestPage(t, manager, nil, "https://shop.example.com/cart")
+
+	storage, err := manager.InspectStorage(ctx, sess.ID, pageID, "")
+	if err != nil {
+		t.Fatalf("InspectStorage failed: %v", err)
+	}
+	if storage.Origin != "https://shop.example.com" || storage.Local["theme"] != "dark" || storage.Session["step"] != "3" {
+		t.Errorf("unexpected web storage: %+v", storage)
+	}
+	if len(storage.IndexedDB) != 1 || len(storage.IndexedDB[0].ObjectStores) != 2 {
+		t.Fatalf("expected one database with two stores, got %+v", storage.IndexedDB)
+	}
+	orders, lines := storage.IndexedDB[0].ObjectStores[0], storage.IndexedDB[0].ObjectStores[1]
+	if storage.IndexedDB[0].Version != 3 || orders.KeyPath != "id" || !orders.AutoIncrement || orders.Entries != 12 || len(orders.Indexes) != 1 {
+		t.Errorf("unexpected orders store: %+v", orders)
+	}
+	if lines.KeyPath != "order,sku" || lines.Entries != 30 {
+		t.Errorf("unexpected lines store: %+v", lines)
+	}
+
+	// An explicit origin is reduced to the form storage is keyed by
+	storage, err = manager.WriteStorage(ctx, sess.ID, pageID, StorageWrite{
+		Origin: "https://Shop.Example.com/account?tab=1",
+		Area:   StorageAreaLocal,
+		Set:    map[string]string{"theme": "light"},
+		Remove: []string{"cart"},
+	})
+	if err != nil {
+		t.Fatalf("WriteStorage failed: %v", err)
+	}
+	if len(storage.Local) != 1 || storage.Local["theme"] != "light" {
+		t.Errorf("expected only the new theme left, got %v", storage.Local)
+	}
+	mu.Lock()
+	if last := origins[len(origins)-1]; last != "https://shop.example.com" {
+		t.Errorf("expected the normalized origin, got %q", last)
+	}
+	mu.Unlock()
+
+	manager.SetMaxResponseSize(4)
+	if _, err := manager.InspectStorage(ctx, sess.ID, pageID, ""); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	for _, write := range []StorageWrite{
+		{Area: "cookies", Clear: true},
+		{Area: StorageAreaLocal},
+		{Area: StorageAreaSession, Set: map[string]string{"a": "1"}, Remove: []string{"a"}},
+		{Area: StorageAreaLocal, Origin: "file:///etc", Clear: true},
+	} {
+		if _, err := manager.WriteStorage(ctx, sess.ID, pageID, write); !errors.Is(err, ErrInvalidStorage) {
+			t.Errorf("expected ErrInvalidStorage for %+v, got %v", write, err)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// DefaultWorkDirQuota is the most disk a session's work directory may use unless configured otherwise
+const DefaultWorkDirQuota = 512 << 20
+
+// workDirCheckInterval is how often work directories are measured against the quota
+const workDirCheckInterval = 15 * time.Second
+
+// workSubdirs are created in every session's work directory. Browser downloads land in
+// downloads; the others hold files the API writes for the session.
+var workSubdirs = []string{"downloads", "uploads", "video", "snapshots"}
+
+// workDirs is the Manager's per-session disk state
+type workDirs struct {
+	mu      sync.Mutex
+	root    string          // Directory holding one subdirectory per session ("" disables work directories)
+	quota   int64           // Most bytes one session may store (0: unlimited)
+	blocked map[string]bool // Session ID → downloads refused for being over quota
+}
+
+// WorkFile is a file in a session's work directory
+type WorkFile struct {
+	Path       string    `json:"path"` // Relative to the work directory, e.g. "downloads/report.csv"
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// WorkDirInfo describes a session's work directory
+type WorkDirInfo struct {
+	UsedBytes  int64      `json:"used_bytes"`
+	QuotaBytes int64      `json:"quota_bytes"` // 0 means unlimited
+	OverQuota  bool       `json:"over_quota"`  // Downloads are refused until files are deleted
+	Files      []WorkFile `json:"files"`
+}
+
+// ConfigureWorkDirs gives every session created from now on a directory under root,
+// holding at most quota bytes (0 is unlimited). A root of "" turns work directories off.
+func (m *Manager) ConfigureWorkDirs(root string, quota int64) error {
+	if root != "" {
+		if err := os.MkdirAll(root, 0o700); err != nil {
+			return fmt.Errorf("failed to create work directory root: %w", err)
+		}
+	}
+
+	m.work.mu.Lock()
+	defer m.work.mu.Unlock()
+	m.work.root = root
+	m.work.quota = quota
+	if m.work.blocked == nil {
+		m.work.blocked = make(map[string]bool)
+	}
+	return nil
+}
+
+// SetWorkDirQuota changes the most bytes a session's work directory may hold. Sessions
+// are measured against the new quota at the next check.
+func (m *Manager) SetWorkDirQuota(quota int64) {
+	m.work.mu.Lock()
+	defer m.work.mu.Unlock()
+	m.work.quota = quota
+}
+
+// workDirPath returns where sessionID's work directory is, or "" when there is none.
+// Session IDs are URL-safe base64, but anything that could climb out of root is refused.
+func (m *Manager) workDirPath(sessionID string) string {
+	m.work.mu.Lock()
+	root := m.work.root
+	m.work.mu.Unlock()
+
+	if root == "" || sessionID == "" || sessionID == "." || sessionID == ".." || strings.ContainsAny(sessionID, `/\`) {
+		return ""
+	}
+	return filepath.Join(root, sessionID)
+}
+
+// prepareWorkDir creates sessionID's work directory, or finds the one it had before it
+// was closed. A failure leaves the session without one rather than failing it.
+func (m *Manager) prepareWorkDir(sessionID string) string {
+	dir := m.workDirPath(sessionID)
+	if dir == "" {
+		return ""
+	}
+	for _, sub := range workSubdirs {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			slog.Warn("failed to create session work directory", "session_id", sessionID, "error", err)
+			return ""
+		}
+	}
+	return dir
+}
+
+// removeWorkDir deletes sessionID's work directory and everything in it
+func (m *Manager) removeWorkDir(sessionID string) {
+	m.work.mu.Lock()
+	delete(m.work.blocked, sessionID)
+	m.work.mu.Unlock()
+
+	dir := m.workDirPath(sessionID)
+	if dir == "" {
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		slog.Warn("failed to remove session work directory", "session_id", sessionID, "error", err)
+	}
+}
+
+// setupDownloads sends the browser's downloads for the session's context to its work
+// directory. A remote browser can't write to this machine's disk, so its downloads are
+// left as they are.
+func (m *Manager) setupDownloads(ctx context.Context, session *Session, remote bool) {
+	if session.workDir == "" || remote {
+		return
+	}
+
+	m.work.mu.Lock()
+	allow := !m.work.blocked[session.ID]
+	m.work.mu.Unlock()
+
+	if err := session.setDownloads(ctx, allow); err != nil {
+		slog.WarnContext(ctx, "failed to direct downloads to the session work directory", "session_id", session.ID, "error", err)
+	}
+}
+
+// setDownloads allows downloads into the work directory, or refuses them all
+func (s *Session) setDownloads(ctx context.Context, allow bool) error {
+	params := s.withContext(map[string]interface{}{
+		"behavior": "deny",
+	})
+	if allow {
+		params["behavior"] = "allow"
+		params["downloadPath"] = filepath.Join(s.workDir, "downloads")
+	}
+	_, err := s.CDPClient.SendCommand(ctx, "Browser.setDownloadBehavior", params)
+	return err
+}
+
+// WorkDir lists the files in a session's work directory
+func (m *Manager) WorkDir(sessionID string) (*WorkDirInfo, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.workDir == "" {
+		return nil, ErrNoWorkDir
+	}
+
+	files, used, err := listWorkFiles(session.workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	m.work.mu.Lock()
+	quota := m.work.quota
+	m.work.mu.Unlock()
+
+	session.UpdateActivity()
+	return &WorkDirInfo{
+		UsedBytes:  used,
+		QuotaBytes: quota,
+		OverQuota:  quota > 0 && used > quota,
+		Files:      files,
+	}, nil
+}
+
+// OpenWorkFile opens a file in a session's work directory. name is relative to the
+// directory and can't leave it. The caller closes the file.
+func (m *Manager) OpenWorkFile(sessionID, name string) (*os.File, fs.FileInfo, error) {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.workDir == "" {
+		return nil, nil, ErrNoWorkDir
+	}
+
+	root, err := os.OpenRoot(session.workDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open work directory: %w", err)
+	}
+	defer root.Close()
+
+	file, err := root.Open(name)
+	if err != nil {
+		return nil, nil, workFileError(name, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, nil, fmt.Errorf("%w: %s", ErrWorkFileNotFound, name)
+	}
+
+	session.UpdateActivity()
+	return file, info, nil
+}
+
+// RemoveWorkFile deletes a file from a session's work directory. The standard
+// subdirectories stay.
+func (m *Manager) RemoveWorkFile(sessionID, name string) error {
+	session, err := m.GetSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.workDir == "" {
+		return ErrNoWorkDir
+	}
+
+	root, err := os.OpenRoot(session.workDir)
+	if err != nil {
+		return fmt.Errorf("failed to open work directory: %w", err)
+	}
+	defer root.Close()
+
+	info, err := root.Stat(name)
+	if err != nil {
+		return workFileError(name, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%w: %s is a directory", ErrInvalidWorkPath, name)
+	}
+	if err := root.Remove(name); err != nil {
+		return workFileError(name, err)
+	}
+
+	session.UpdateActivity()
+	return nil
+}
+
+// workFileError tells a missing file apart from a name that tries to leave the directory
+func workFileError(name string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrWorkFileNotFound, name)
+	}
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && !filepath.IsLocal(name) {
+		return fmt.Errorf("%w: %s", ErrInvalidWorkPath, name)
+	}
+	return err
+}
+
+// listWorkFiles returns the regular files under dir, sorted by path, and their total size
+func listWorkFiles(dir string) ([]WorkFile, int64, error) {
+	files := []WorkFile{}
+	var used int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// A download finishing mid-walk renames its temporary file away
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, WorkFile{Path: filepath.ToSlash(rel), Size: info.Size(), ModifiedAt: info.ModTime()})
+		used += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read work directory: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, used, nil
+}
+
+// SweepWorkDirs deletes work directories left behind by sessions that no longer exist,
+// such as those of a server that crashed. Directories of sessions kept in Redis survive,
+// since the session can still be resumed. It returns how many were removed.
+func (m *Manager) SweepWorkDirs() (int, error) {
+	m.work.mu.Lock()
+	root := m.work.root
+	m.work.mu.Unlock()
+	if root == "" {
+		return 0, nil
+	}
+
+	keep := make(map[string]bool)
+	m.mu.RLock()
+	for sessionID := range m.sessions {
+		keep[sessionID] = true
+	}
+	for sessionID := range m.reserved {
+		keep[sessionID] = true
+	}
+	m.mu.RUnlock()
+
+	if m.repo != nil {
+		// Without the list every directory could belong to a resumable session
+		stored, err := m.repo.ListActiveSessions()
+		if err != nil {
+			return 0, fmt.Errorf("failed to list stored sessions: %w", err)
+		}
+		for _, sessionID := range stored {
+			keep[sessionID] = true
+		}
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read work directory root: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || keep[entry.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			slog.Warn("failed to remove orphaned work directory", "dir", entry.Name(), "error", err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// StartWorkDirWorker starts measuring work directories against the quota. A session over
+// it has downloads refused until files are deleted.
+func (m *Manager) StartWorkDirWorker() {
+	go func() {
+		ticker := time.NewTicker(workDirCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				m.checkWorkDirQuotas()
+			}
+		}
+	}()
+}
+
+// checkWorkDirQuotas refuses downloads for sessions over the quota, and allows them
+// again for sessions back under it
+func (m *Manager) checkWorkDirQuotas() {
+	type candidate struct {
+		session *Session
+		remote  bool
+	}
+
+	m.mu.RLock()
+	candidates := make([]candidate, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		if session.workDir == "" {
+			continue
+		}
+		_, remote := m.remotes[session.ProcessPort]
+		candidates = append(candidates, candidate{session: session, remote: remote})
+	}
+	m.mu.RUnlock()
+
+	for _, c := range candidates {
+		_, used, err := listWorkFiles(c.session.workDir)
+		if err != nil {
+			slog.Warn("failed to measure session work directory", "session_id", c.session.ID, "error", err)
+			continue
+		}
+
+		m.work.mu.Lock()
+		quota := m.work.quota
+		over := quota > 0 && used > quota
+		changed := over != m.work.blocked[c.session.ID]
+		if changed {
+			if over {
+				m.work.blocked[c.session.ID] = true
+			} else {
+				delete(m.work.blocked, c.session.ID)
+			}
+		}
+		m.work.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+		if !c.remote {
+			if err := c.session.setDownloads(context.Background(), !over); err != nil {
+				slog.Warn("failed to change download behavior", "session_id", c.session.ID, "error", err)
+			}
+		}
+		if over {
+			slog.Warn("session work directory over quota, refusing downloads", "session_id", c.session.ID, "used_bytes", used, "quota_bytes", quota)
+			m.publishEvent(c.session.ID, "", events.TypeWorkDirQuota, map[string]interface{}{
+				"used_bytes":  used,
+				"quota_bytes": quota,
+			})
+		} else {
+			slog.Info("session work directory back under quota, allowing downloads", "session_id", c.session.ID, "used_bytes", used)
+		}
+	}
+}
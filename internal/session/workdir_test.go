@@ -0,0 +1,145 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/events"
+)
+
+// TestWorkDirLifecycle tests that a session's work directory is created with it, lists
+// and serves its files, stays inside itself, and is removed when the session is destroyed
+func TestWorkDirLifecycle(t *testing.T) {
+	browser := newFakeBrowser(0, nil, nil)
+	defer browser.server.Close()
+
+	root := t.TempDir()
+	manager := NewManager(nil)
+	defer manager.Close()
+	if err := manager.ConfigureWorkDirs(root, 0); err != nil {
+		t.Fatalf("ConfigureWorkDirs failed: %v", err)
+	}
+	manager.RegisterRemoteBrowser(9222, browser.wsURL())
+
+	sess, err := manager.CreateSessionWithOptions(context.Background(), "", "agent-1", "", 9222, "", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionWithOptions failed: %v", err)
+	}
+	dir := filepath.Join(root, sess.ID)
+	for _, sub := range workSubdirs {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err != nil || !info.IsDir() {
+			t.Errorf("expected %s to be created, got %v", sub, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "downloads", "report.csv"), []byte("a,b\n1,2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := manager.WorkDir(sess.ID)
+	if err != nil {
+		t.Fatalf("WorkDir failed: %v", err)
+	}
+	if len(info.Files) != 1 || info.Files[0].Path != "downloads/report.csv" || info.UsedBytes != 8 {
+		t.Errorf("unexpected listing: %+v", info)
+	}
+
+	file, _, err := manager.OpenWorkFile(sess.ID, "downloads/report.csv")
+	if err != nil {
+		t.Fatalf("OpenWorkFile failed: %v", err)
+	}
+	file.Close()
+
+	outside := filepath.Join(root, "secret.txt")
+	os.WriteFile(outside, []byte("x"), 0o600)
+	if _, _, err := manager.OpenWorkFile(sess.ID, "../secret.txt"); !errors.Is(err, ErrInvalidWorkPath) {
+		t.Errorf("expected ErrInvalidWorkPath for a path leaving the directory, got %v", err)
+	}
+	if _, _, err := manager.OpenWorkFile(sess.ID, "downloads/missing.csv"); !errors.Is(err, ErrWorkFileNotFound) {
+		t.Errorf("expected ErrWorkFileNotFound, got %v", err)
+	}
+	if err := manager.RemoveWorkFile(sess.ID, "downloads"); !errors.Is(err, ErrInvalidWorkPath) {
+		t.Errorf("expected directories to be kept, got %v", err)
+	}
+	if err := manager.RemoveWorkFile(sess.ID, "downloads/report.csv"); err != nil {
+		t.Errorf("RemoveWorkFile failed: %v", err)
+	}
+
+	if err := manager.DestroySession(sess.ID); err != nil {
+		t.Fatalf("DestroySession failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the work directory to be removed, got %v", err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("expected files outside the session's directory to be left alone, got %v", err)
+	}
+}
+
+// TestWorkDirQuota tests that a session over the quota is reported once, and cleared
+// when it goes back under
+func TestWorkDirQuota(t *testing.T) {
+	root := t.TempDir()
+	manager := NewManager(nil)
+	defer manager.Close()
+	if err := manager.ConfigureWorkDirs(root, 4); err != nil {
+		t.Fatalf("ConfigureWorkDirs failed: %v", err)
+	}
+	manager.RegisterRemoteBrowser(9222, "ws://unused")
+
+	sess := &Session{ID: "sess_quota", ProcessPort: 9222, workDir: manager.prepareWorkDir("sess_quota")}
+	manager.sessions[sess.ID] = sess
+	file := filepath.Join(sess.workDir, "downloads", "big.bin")
+	os.WriteFile(file, []byte("0123456789"), 0o600)
+
+	manager.checkWorkDirQuotas()
+	manager.checkWorkDirQuotas()
+	exceeded := 0
+	for _, event := range manager.Events().History(sess.ID, 0) {
+		if event.Type == events.TypeWorkDirQuota {
+			exceeded++
+		}
+	}
+	if exceeded != 1 {
+		t.Errorf("expected one quota event, got %d", exceeded)
+	}
+	if info, _ := manager.WorkDir(sess.ID); !info.OverQuota {
+		t.Errorf("expected the session to be over quota, got %+v", info)
+	}
+
+	os.Remove(file)
+	manager.checkWorkDirQuotas()
+	if manager.work.blocked[sess.ID] {
+		t.Error("expected downloads to be allowed again under the quota")
+	}
+}
+
+// TestSweepWorkDirs tests that directories of unknown sessions are removed at startup
+// and those of live sessions are kept
+func TestSweepWorkDirs(t *testing.T) {
+	root := t.TempDir()
+	manager := NewManager(nil)
+	defer manager.Close()
+	if err := manager.ConfigureWorkDirs(root, 0); err != nil {
+		t.Fatalf("ConfigureWorkDirs failed: %v", err)
+	}
+
+	manager.sessions["sess_live"] = &Session{ID: "sess_live", workDir: manager.prepareWorkDir("sess_live")}
+	manager.prepareWorkDir("sess_crashed")
+
+	removed, err := manager.SweepWorkDirs()
+	if err != nil {
+		t.Fatalf("SweepWorkDirs failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 orphan removed, got %d", removed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "sess_live")); err != nil {
+		t.Errorf("expected the live session's directory to stay, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "sess_crashed")); !os.IsNotExist(err) {
+		t.Errorf("expected the orphan to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package sessionlog
+
+import (
+	"context"
+	"sync"
+)
+
+// tags names the session and page a request works on
+type tags struct {
+	mu        sync.Mutex
+	sessionID string
+	pageID    string
+}
+
+// tagsKey is the context key of a request's tags
+type tagsKey struct{}
+
+// WithTags returns a context carrying empty tags. Tag fills them in further down the
+// request, where the session is known, and records logged with the outer context then
+// carry the session too.
+func WithTags(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tagsKey{}, &tags{})
+}
+
+// Tag names the session and page of ctx's request; empty values leave a name as it is.
+// It fills in the tags from WithTags, or returns a context with new ones when there are none.
+func Tag(ctx context.Context, sessionID string, pageID string) context.Context {
+	t, ok := ctx.Value(tagsKey{}).(*tags)
+	if !ok {
+		t = &tags{}
+		ctx = context.WithValue(ctx, tagsKey{}, t)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if sessionID != "" {
+		t.sessionID = sessionID
+	}
+	if pageID != "" {
+		t.pageID = pageID
+	}
+	return ctx
+}
+
+// FromContext returns the session and page ctx's request was tagged with
+func FromContext(ctx context.Context) (sessionID string, pageID string) {
+	if ctx == nil {
+		return "", ""
+	}
+	t, ok := ctx.Value(tagsKey{}).(*tags)
+	if !ok {
+		return "", ""
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID, t.pageID
+}
@@ -0,0 +1,167 @@
+package sessionlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Attribute keys naming a record's session and page
+const (
+	SessionKey = "session_id"
+	PageKey    = "page_id"
+)
+
+// Handler is a slog.Handler that adds the session and page of the request in the
+// context to each record, and keeps records of a session in a Store on their way to
+// the wrapped handler.
+type Handler struct {
+	next      slog.Handler
+	store     *Store
+	group     string            // Prefix of attribute keys, from WithGroup
+	preset    map[string]string // Attributes from WithAttrs, as kept in lines
+	sessionID string            // From WithAttrs
+	pageID    string
+}
+
+// NewHandler wraps next so records naming a session are kept in store
+func NewHandler(next slog.Handler, store *Store) *Handler {
+	return &Handler{next: next, store: store}
+}
+
+// Enabled reports whether the wrapped handler handles records at level, or whether the
+// store keeps them for the session in ctx
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next.Enabled(ctx, level) {
+		return true
+	}
+	if !h.store.Enabled(level) {
+		return false
+	}
+	if h.sessionID != "" {
+		return true
+	}
+	sessionID, _ := FromContext(ctx)
+	return sessionID != ""
+}
+
+// Handle keeps the record for its session and forwards it. The session and page come
+// from the record's attributes, or else from ctx's tags, which are then added to it.
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	sessionID, pageID := h.sessionID, h.pageID
+	record.Attrs(func(a slog.Attr) bool {
+		if h.group != "" {
+			return true
+		}
+		switch a.Key {
+		case SessionKey:
+			sessionID = a.Value.String()
+		case PageKey:
+			pageID = a.Value.String()
+		}
+		return true
+	})
+
+	tagged, taggedPage := FromContext(ctx)
+	var missing []slog.Attr
+	if sessionID == "" && tagged != "" {
+		sessionID = tagged
+		missing = append(missing, slog.String(SessionKey, tagged))
+	}
+	if pageID == "" && taggedPage != "" {
+		pageID = taggedPage
+		missing = append(missing, slog.String(PageKey, taggedPage))
+	}
+
+	if sessionID != "" && h.store.Enabled(record.Level) {
+		h.store.Add(sessionID, h.line(record, pageID))
+	}
+
+	if !h.next.Enabled(ctx, record.Level) {
+		return nil
+	}
+	if len(missing) > 0 {
+		record = record.Clone()
+		record.AddAttrs(missing...)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs returns a handler with pre-set attributes, which may name the session
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.preset = make(map[string]string, len(h.preset)+len(attrs))
+	for key, value := range h.preset {
+		clone.preset[key] = value
+	}
+	for _, a := range attrs {
+		if h.group == "" {
+			switch a.Key {
+			case SessionKey:
+				clone.sessionID = a.Value.String()
+				continue
+			case PageKey:
+				clone.pageID = a.Value.String()
+				continue
+			}
+		}
+		flatten(clone.preset, h.group, a)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler that nests attributes under name
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// line turns a record into a kept line, its attributes flattened to strings
+func (h *Handler) line(record slog.Record, pageID string) Line {
+	line := Line{
+		Time:    record.Time,
+		Level:   record.Level.String(),
+		Message: record.Message,
+		PageID:  pageID,
+		level:   record.Level,
+	}
+	if len(h.preset) == 0 && record.NumAttrs() == 0 {
+		return line
+	}
+
+	line.Attrs = make(map[string]string, len(h.preset)+record.NumAttrs())
+	for key, value := range h.preset {
+		line.Attrs[key] = value
+	}
+	record.Attrs(func(a slog.Attr) bool {
+		if h.group == "" && (a.Key == SessionKey || a.Key == PageKey) {
+			return true
+		}
+		flatten(line.Attrs, h.group, a)
+		return true
+	})
+	if len(line.Attrs) == 0 {
+		line.Attrs = nil
+	}
+	return line
+}
+
+// flatten adds an attribute to attrs, group members as "group.key"
+func flatten(attrs map[string]string, prefix string, a slog.Attr) {
+	value := a.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		attrs[prefix+a.Key] = value.String()
+		return
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, member := range value.Group() {
+		flatten(attrs, prefix, member)
+	}
+}
@@ -0,0 +1,101 @@
+package sessionlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestHandler tests tagging records from the context and keeping them per session
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	store := NewStore(10, 10)
+	logger := slog.New(NewHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}), store))
+
+	// Tags set further down a request reach records logged with the outer context
+	ctx := WithTags(context.Background())
+	Tag(ctx, "sess-1", "")
+	logger.InfoContext(ctx, "request completed", "path", "/sessions/sess-1/navigate")
+	if !strings.Contains(buf.String(), "session_id=sess-1") {
+		t.Errorf("expected the forwarded record tagged, got %q", buf.String())
+	}
+
+	// Sessions named by attributes are kept without a tagged context
+	logger.Warn("page crashed", "session_id", "sess-1", "page_id", "page-9", "error", fmt.Errorf("boom"))
+	logger.With("session_id", "sess-2").Info("session created")
+
+	// Debug lines of a tagged request are kept though the server doesn't write them
+	buf.Reset()
+	logger.DebugContext(Tag(context.Background(), "sess-1", "page-9"), "selector resolved", slog.Group("node", "id", 7))
+	if buf.Len() != 0 {
+		t.Errorf("expected the debug line left out of the server log, got %q", buf.String())
+	}
+	logger.Debug("untagged debug line")
+
+	lines := store.Lines("sess-1", Filter{Level: slog.LevelDebug})
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines for sess-1, got %+v", lines)
+	}
+	if lines[0].Attrs["path"] != "/sessions/sess-1/navigate" || lines[0].Level != "INFO" {
+		t.Errorf("unexpected first line: %+v", lines[0])
+	}
+	if lines[1].PageID != "page-9" || lines[1].Attrs["error"] != "boom" || lines[1].Attrs["session_id"] != "" {
+		t.Errorf("unexpected second line: %+v", lines[1])
+	}
+	if lines[2].Attrs["node.id"] != "7" {
+		t.Errorf("expected grouped attributes flattened, got %+v", lines[2])
+	}
+	if lines := store.Lines("sess-2", Filter{}); len(lines) != 1 || lines[0].Message != "session created" {
+		t.Errorf("expected the preset session kept, got %+v", lines)
+	}
+
+	if lines := store.Lines("sess-1", Filter{Level: slog.LevelWarn}); len(lines) != 1 {
+		t.Errorf("expected one warning, got %+v", lines)
+	}
+	if lines := store.Lines("sess-1", Filter{Level: slog.LevelDebug, PageID: "page-9", Limit: 1}); len(lines) != 1 || lines[0].Message != "selector resolved" {
+		t.Errorf("expected the newest line of page-9, got %+v", lines)
+	}
+}
+
+// TestStore tests the per-session ring, eviction and forgetting
+func TestStore(t *testing.T) {
+	store := NewStore(3, 2)
+	for i := 0; i < 5; i++ {
+		store.Add("a", Line{Message: fmt.Sprint(i)})
+	}
+	lines := store.Lines("a", Filter{})
+	if len(lines) != 3 || lines[0].Message != "2" || lines[2].Message != "4" {
+		t.Fatalf("expected the newest 3 lines oldest first, got %+v", lines)
+	}
+
+	// Shrinking keeps the newest lines
+	store.SetLines(2)
+	store.Add("a", Line{Message: "5"})
+	if lines := store.Lines("a", Filter{}); len(lines) != 2 || lines[0].Message != "4" || lines[1].Message != "5" {
+		t.Errorf("expected the ring resized, got %+v", lines)
+	}
+
+	// The least recently written session goes first
+	store.Add("b", Line{Message: "b"})
+	store.Add("a", Line{Message: "6"})
+	store.Add("c", Line{Message: "c"})
+	if len(store.Lines("b", Filter{})) != 0 || len(store.Lines("a", Filter{})) != 2 {
+		t.Error("expected b evicted")
+	}
+
+	// Late records of a forgotten session are dropped
+	store.Forget("a")
+	store.Add("a", Line{Message: "session destroyed"})
+	if lines := store.Lines("a", Filter{}); len(lines) != 0 {
+		t.Errorf("expected nothing kept after Forget, got %+v", lines)
+	}
+
+	store.SetLines(0)
+	store.Add("c", Line{Message: "c"})
+	if store.Enabled(slog.LevelError) || len(store.Lines("c", Filter{})) != 0 {
+		t.Error("expected a store without lines to keep nothing")
+	}
+}
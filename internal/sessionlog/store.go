@@ -0,0 +1,205 @@
+package sessionlog
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Default buffer bounds
+const (
+	DefaultLines    = 200  // Lines kept per session
+	DefaultSessions = 1000 // Sessions with a buffer at once; the least recently written go first
+)
+
+// Line is one buffered log record of a session
+type Line struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"` // DEBUG, INFO, WARN or ERROR
+	Message string            `json:"message"`
+	PageID  string            `json:"page_id,omitempty"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+
+	level slog.Level
+}
+
+// Filter narrows the lines returned by Lines
+type Filter struct {
+	Level  slog.Level // Lines below it are left out
+	PageID string     // Only lines of this page, when set
+	Limit  int        // Only the newest Limit lines, when set
+}
+
+// buffer is a ring of one session's newest lines
+type buffer struct {
+	sessionID string
+	lines     []Line
+	start     int
+	count     int
+	forgotten bool // The session is gone, so late records are dropped
+	elem      *list.Element
+}
+
+// Store keeps the recent log lines of each session in memory
+type Store struct {
+	mu       sync.Mutex
+	buffers  map[string]*buffer
+	recent   *list.List // Buffers, most recently written first
+	sessions int
+
+	lines atomic.Int64
+	level atomic.Int64
+}
+
+var defaultStore = NewStore(DefaultLines, DefaultSessions)
+
+// Default returns the process-wide store filled by the logger
+func Default() *Store {
+	return defaultStore
+}
+
+// NewStore creates a store keeping lines per session for at most sessions sessions.
+// Records at every level are kept until SetLevel says otherwise.
+func NewStore(lines int, sessions int) *Store {
+	if sessions <= 0 {
+		sessions = DefaultSessions
+	}
+	s := &Store{
+		buffers:  make(map[string]*buffer),
+		recent:   list.New(),
+		sessions: sessions,
+	}
+	s.lines.Store(int64(lines))
+	s.level.Store(int64(slog.LevelDebug))
+	return s
+}
+
+// SetLines changes how many lines are kept per session. Zero stops buffering and drops
+// what is kept; other values apply to each buffer on its next write.
+func (s *Store) SetLines(lines int) {
+	s.lines.Store(int64(lines))
+	if lines > 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buffers = make(map[string]*buffer)
+	s.recent.Init()
+}
+
+// SetLevel changes the lowest level kept. It can be below the logger's own level, so a
+// session's debug lines are kept when the server only writes info.
+func (s *Store) SetLevel(level slog.Level) {
+	s.level.Store(int64(level))
+}
+
+// Enabled reports whether records at level are kept
+func (s *Store) Enabled(level slog.Level) bool {
+	return s.lines.Load() > 0 && level >= slog.Level(s.level.Load())
+}
+
+// Add appends a line to a session's buffer, overwriting its oldest when full
+func (s *Store) Add(sessionID string, line Line) {
+	limit := int(s.lines.Load())
+	if limit <= 0 || sessionID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buffers[sessionID]
+	if !ok {
+		b = &buffer{sessionID: sessionID}
+		b.elem = s.recent.PushFront(b)
+		s.buffers[sessionID] = b
+		s.evictLocked()
+	} else {
+		s.recent.MoveToFront(b.elem)
+	}
+	if b.forgotten {
+		return
+	}
+
+	if len(b.lines) != limit {
+		b.resize(limit)
+	}
+	if b.count < limit {
+		b.lines[(b.start+b.count)%limit] = line
+		b.count++
+		return
+	}
+	b.lines[b.start] = line
+	b.start = (b.start + 1) % limit
+}
+
+// Lines returns a session's kept lines matching filter, oldest first
+func (s *Store) Lines(sessionID string, filter Filter) []Line {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := []Line{}
+	b, ok := s.buffers[sessionID]
+	if !ok {
+		return lines
+	}
+	for _, line := range b.ordered() {
+		if line.level < filter.Level || (filter.PageID != "" && line.PageID != filter.PageID) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if filter.Limit > 0 && len(lines) > filter.Limit {
+		lines = lines[len(lines)-filter.Limit:]
+	}
+	return lines
+}
+
+// Forget drops a session's lines. Its buffer stays behind, empty, until evicted,
+// so records logged while the session is torn down don't start a new one.
+func (s *Store) Forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buffers[sessionID]
+	if !ok {
+		b = &buffer{sessionID: sessionID}
+		b.elem = s.recent.PushFront(b)
+		s.buffers[sessionID] = b
+		s.evictLocked()
+	}
+	b.forgotten = true
+	b.lines, b.start, b.count = nil, 0, 0
+}
+
+// evictLocked drops the least recently written buffers beyond the session bound
+func (s *Store) evictLocked() {
+	for len(s.buffers) > s.sessions {
+		oldest := s.recent.Back()
+		s.recent.Remove(oldest)
+		delete(s.buffers, oldest.Value.(*buffer).sessionID)
+	}
+}
+
+// ordered returns the buffer's lines, oldest first
+func (b *buffer) ordered() []Line {
+	ordered := make([]Line, 0, b.count)
+	for i := 0; i < b.count; i++ {
+		ordered = append(ordered, b.lines[(b.start+i)%len(b.lines)])
+	}
+	return ordered
+}
+
+// resize changes the ring's length, keeping the newest lines that fit
+func (b *buffer) resize(limit int) {
+	kept := b.ordered()
+	if len(kept) > limit {
+		kept = kept[len(kept)-limit:]
+	}
+	b.lines = make([]Line, limit)
+	copy(b.lines, kept)
+	b.start, b.count = 0, len(kept)
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/vault"
+	"github.com/redis/go-redis/v9"
+)
+
+// CredentialRepository persists sealed vault credentials in Redis.
+// Values are encrypted by the vault before they reach this layer.
+type CredentialRepository struct {
+	redis *RedisClient
+}
+
+// NewCredentialRepository creates a new credential repository
+func NewCredentialRepository(redisClient *RedisClient) *CredentialRepository {
+	return &CredentialRepository{
+		redis: redisClient,
+	}
+}
+
+// Put stores a sealed credential (no TTL - credentials live until deleted)
+func (r *CredentialRepository) Put(alias string, sealed []byte) error {
+	key := fmt.Sprintf("credential:%s", alias)
+
+	if err := r.redis.client.Set(r.redis.ctx, key, sealed, 0).Err(); err != nil {
+		return fmt.Errorf("failed to save credential: %w", err)
+	}
+
+	if err := r.redis.client.SAdd(r.redis.ctx, "credentials", alias).Err(); err != nil {
+		return fmt.Errorf("failed to index credential: %w", err)
+	}
+
+	return nil
+}
+
+// Get retrieves a sealed credential
+func (r *CredentialRepository) Get(alias string) ([]byte, error) {
+	key := fmt.Sprintf("credential:%s", alias)
+
+	data, err := r.redis.client.Get(r.redis.ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: %s", vault.ErrCredentialNotFound, alias)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credential: %w", err)
+	}
+
+	return data, nil
+}
+
+// Delete removes a sealed credential
+func (r *CredentialRepository) Delete(alias string) error {
+	key := fmt.Sprintf("credential:%s", alias)
+
+	deleted, err := r.redis.client.Del(r.redis.ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete credential: %w", err)
+	}
+	r.redis.client.SRem(r.redis.ctx, "credentials", alias)
+
+	if deleted == 0 {
+		return fmt.Errorf("%w: %s", vault.ErrCredentialNotFound, alias)
+	}
+
+	return nil
+}
+
+// List returns all credential aliases
+func (r *CredentialRepository) List() ([]string, error) {
+	aliases, err := r.redis.client.SMembers(r.redis.ctx, "credentials").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+	return aliases, nil
+}
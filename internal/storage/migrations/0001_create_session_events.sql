@@ -0,0 +1,14 @@
+-- Session transcripts: every event published on the session event bus.
+-- event_id restarts with the server, so rows are ordered by id, not event_id.
+CREATE TABLE session_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL,
+    session_id TEXT NOT NULL,
+    page_id TEXT NOT NULL DEFAULT '',
+    type TEXT NOT NULL,
+    data JSONB,
+    time TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX session_events_session_idx ON session_events (session_id, id);
+CREATE INDEX session_events_type_time_idx ON session_events (type, time);
@@ -0,0 +1,17 @@
+-- Audit records of mutating API calls
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    time TIMESTAMPTZ NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL,
+    remote TEXT NOT NULL,
+    action TEXT NOT NULL,
+    session_id TEXT NOT NULL DEFAULT '',
+    status INTEGER NOT NULL,
+    outcome TEXT NOT NULL,
+    duration_ms BIGINT NOT NULL
+);
+
+CREATE INDEX audit_log_time_idx ON audit_log (time);
+CREATE INDEX audit_log_actor_time_idx ON audit_log (actor, time);
+CREATE INDEX audit_log_session_idx ON audit_log (session_id) WHERE session_id <> '';
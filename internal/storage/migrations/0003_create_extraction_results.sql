@@ -0,0 +1,27 @@
+-- Successful pipeline runs and the records each one produced
+CREATE TABLE pipeline_runs (
+    id BIGSERIAL PRIMARY KEY,
+    pipeline TEXT NOT NULL,
+    session_id TEXT NOT NULL DEFAULT '',
+    input INTEGER NOT NULL,
+    output INTEGER NOT NULL,
+    duplicates INTEGER NOT NULL,
+    invalid INTEGER NOT NULL,
+    errors JSONB,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX pipeline_runs_pipeline_time_idx ON pipeline_runs (pipeline, created_at);
+CREATE INDEX pipeline_runs_session_idx ON pipeline_runs (session_id) WHERE session_id <> '';
+
+CREATE TABLE extraction_results (
+    id BIGSERIAL PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES pipeline_runs (id) ON DELETE CASCADE,
+    pipeline TEXT NOT NULL,
+    session_id TEXT NOT NULL DEFAULT '',
+    record JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX extraction_results_run_idx ON extraction_results (run_id);
+CREATE INDEX extraction_results_pipeline_time_idx ON extraction_results (pipeline, created_at);
@@ -0,0 +1,4 @@
+-- Tenant of each audited call ('' for the default tenant)
+ALTER TABLE audit_log ADD COLUMN tenant TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX audit_log_tenant_time_idx ON audit_log (tenant, time) WHERE tenant <> '';
@@ -0,0 +1,2 @@
+-- How each audited call reached its session ('shared' through a read-only share, '' as owner)
+ALTER TABLE audit_log ADD COLUMN access TEXT NOT NULL DEFAULT '';
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,6 +13,7 @@ import (
 type RedisClient struct {
 	client *redis.Client
 	ctx context.Context
+	scripts sync.Map // Script source → *redis.Script
 }
 
 //This function creates a new redis client
@@ -53,3 +55,25 @@ func (r *RedisClient) Ping() error {
 	return r.client.Ping(r.ctx).Err()
 }
 
+
+// AppendToStream adds an entry to a stream, trimming it to about maxLen entries when maxLen > 0
+func (r *RedisClient) AppendToStream(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) error {
+	args := &redis.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}
+	if maxLen > 0 {
+		args.MaxLen = maxLen
+		args.Approx = true
+	}
+	return r.client.XAdd(ctx, args).Err()
+}
+
+// RunScript runs a Lua script, sent once and then called by its SHA
+func (r *RedisClient) RunScript(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	cached, ok := r.scripts.Load(script)
+	if !ok {
+		cached, _ = r.scripts.LoadOrStore(script, redis.NewScript(script))
+	}
+	return cached.(*redis.Script).Run(ctx, r.client, keys, args...).Result()
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -42,6 +43,17 @@ func (r *SessionRepository) SaveSession(state *SessionState) error {
 		"last_activity": state.LastActivity.Format(time.RFC3339),
 		"status":        state.Status,
 	}
+	if state.TenantID != "" {
+		fields["tenant_id"] = state.TenantID
+	}
+
+	// Launch options are stored verbatim so a resumed session gets the same setup
+	if state.Template != "" {
+		fields["template"] = state.Template
+	}
+	if len(state.Options) > 0 {
+		fields["options"] = string(state.Options)
+	}
 
 		slog.Debug("saving session to Redis", 
 		"session_id", state.SessionID,
@@ -66,13 +78,13 @@ func (r *SessionRepository) SaveSession(state *SessionState) error {
 
 	// If agent ID provided, track this session for the agent
 	if state.AgentID != "" {
-		agentKey := fmt.Sprintf("agent:%s:sessions", state.AgentID)
+		agentKey := agentKey(state.TenantID, state.AgentID, "sessions")
 		if err := r.redis.client.SAdd(r.redis.ctx, agentKey, state.SessionID).Err(); err != nil {
 			slog.Warn("failed to add session to agent set", "error", err)
 		}
 
 		// Reserve the session name
-		if err := r.ReserveSessionName(state.AgentID, state.SessionName, state.SessionID); err != nil {
+		if err := r.ReserveSessionName(state.TenantID, state.AgentID, state.SessionName, state.SessionID); err != nil {
 			slog.Warn("failed to reserve session name", "error", err)
 		}
 	}
@@ -125,8 +137,14 @@ func (r *SessionRepository) GetSession(sessionID string) (*SessionState, error)
 		SessionID:    data["session_id"],
 		SessionName:  data["session_name"],
 		AgentID:      data["agent_id"],
+		TenantID:     data["tenant_id"],
 		ContextID:    data["context_id"],
 		Status:       data["status"],
+		Template:     data["template"],
+	}
+
+	if options := data["options"]; options != "" {
+		state.Options = json.RawMessage(options)
 	}
 
 	// Parse port
@@ -177,14 +195,15 @@ func (r *SessionRepository) DeleteSession(sessionID string) error {
 	}
 
 	agentID := data["agent_id"]
+	tenantID := data["tenant_id"]
 	sessionName := data["session_name"]
 	
 	if agentID != "" && sessionName != "" {
-		r.ReleaseSessionName(agentID, sessionName)
+		r.ReleaseSessionName(tenantID, agentID, sessionName)
 	}
 	
 	if agentID != "" {
-		agentKey := fmt.Sprintf("agent:%s:sessions", agentID)
+		agentKey := agentKey(tenantID, agentID, "sessions")
 		r.redis.client.SRem(r.redis.ctx, agentKey, sessionID)
 	}
 
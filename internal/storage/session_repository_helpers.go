@@ -163,9 +163,19 @@ func (r *SessionRepository) GetPages(sessionID string) ([]PageState, error) {
 	return pages, nil
 }
 
-// GetSessionByName retrieves session ID by agent + name
-func (r *SessionRepository) GetSessionByName(agentID, sessionName string) (string, error) {
-	key := fmt.Sprintf("agent:%s:session_names", agentID)
+// agentKey names one of an agent's Redis keys. Agents are scoped to their tenant, so
+// agents of different tenants never share sessions or names even with equal IDs.
+// The default tenant ("") keeps the original key layout.
+func agentKey(tenantID, agentID, kind string) string {
+	if tenantID == "" {
+		return fmt.Sprintf("agent:%s:%s", agentID, kind)
+	}
+	return fmt.Sprintf("tenant:%s:agent:%s:%s", tenantID, agentID, kind)
+}
+
+// GetSessionByName retrieves session ID by tenant + agent + name
+func (r *SessionRepository) GetSessionByName(tenantID, agentID, sessionName string) (string, error) {
+	key := agentKey(tenantID, agentID, "session_names")
 	
 	sessionID, err := r.redis.client.HGet(r.redis.ctx, key, sessionName).Result()
 	if err != nil {
@@ -176,8 +186,8 @@ func (r *SessionRepository) GetSessionByName(agentID, sessionName string) (strin
 }
 
 // CheckSessionNameExists checks if a session name is already taken by an agent
-func (r *SessionRepository) CheckSessionNameExists(agentID, sessionName string) (bool, error) {
-	key := fmt.Sprintf("agent:%s:session_names", agentID)
+func (r *SessionRepository) CheckSessionNameExists(tenantID, agentID, sessionName string) (bool, error) {
+	key := agentKey(tenantID, agentID, "session_names")
 	
 	exists, err := r.redis.client.HExists(r.redis.ctx, key, sessionName).Result()
 	if err != nil {
@@ -188,11 +198,11 @@ func (r *SessionRepository) CheckSessionNameExists(agentID, sessionName string)
 }
 
 // ReserveSessionName atomically reserves a session name for an agent
-func (r *SessionRepository) ReserveSessionName(agentID, sessionName, sessionID string) error {
-	key := fmt.Sprintf("agent:%s:session_names", agentID)
+func (r *SessionRepository) ReserveSessionName(tenantID, agentID, sessionName, sessionID string) error {
+	key := agentKey(tenantID, agentID, "session_names")
 	
 	// Check if name already exists
-	exists, err := r.CheckSessionNameExists(agentID, sessionName)
+	exists, err := r.CheckSessionNameExists(tenantID, agentID, sessionName)
 	if err != nil {
 		return err
 	}
@@ -215,20 +225,20 @@ func (r *SessionRepository) ReserveSessionName(agentID, sessionName, sessionID s
 }
 
 // ReleaseSessionName removes the name mapping when session is deleted
-func (r *SessionRepository) ReleaseSessionName(agentID, sessionName string) error {
+func (r *SessionRepository) ReleaseSessionName(tenantID, agentID, sessionName string) error {
 	if sessionName == "" || agentID == "" {
 		return nil
 	}
 	
-	key := fmt.Sprintf("agent:%s:session_names", agentID)
+	key := agentKey(tenantID, agentID, "session_names")
 	
 	return r.redis.client.HDel(r.redis.ctx, key, sessionName).Err()
 }
 
 // RenameSession updates the session name
-func (r *SessionRepository) RenameSession(sessionID, agentID, oldName, newName string) error {
+func (r *SessionRepository) RenameSession(sessionID, tenantID, agentID, oldName, newName string) error {
 	// Check if new name is already taken
-	exists, err := r.CheckSessionNameExists(agentID, newName)
+	exists, err := r.CheckSessionNameExists(tenantID, agentID, newName)
 	if err != nil {
 		return err
 	}
@@ -237,12 +247,12 @@ func (r *SessionRepository) RenameSession(sessionID, agentID, oldName, newName s
 	}
 	
 	// Remove old name mapping
-	if err := r.ReleaseSessionName(agentID, oldName); err != nil {
+	if err := r.ReleaseSessionName(tenantID, agentID, oldName); err != nil {
 		slog.Warn("failed to release old session name", "error", err)
 	}
 	
 	// Add new name mapping
-	if err := r.ReserveSessionName(agentID, newName, sessionID); err != nil {
+	if err := r.ReserveSessionName(tenantID, agentID, newName, sessionID); err != nil {
 		return err
 	}
 	
@@ -261,8 +271,8 @@ func (r *SessionRepository) RenameSession(sessionID, agentID, oldName, newName s
 }
 
 // CountAgentSessions returns the number of active sessions for an agent
-func (r *SessionRepository) CountAgentSessions(agentID string) (int, error) {
-	key := fmt.Sprintf("agent:%s:sessions", agentID)
+func (r *SessionRepository) CountAgentSessions(tenantID, agentID string) (int, error) {
+	key := agentKey(tenantID, agentID, "sessions")
 	
 	count, err := r.redis.client.SCard(r.redis.ctx, key).Result()
 	if err != nil {
@@ -273,9 +283,9 @@ func (r *SessionRepository) CountAgentSessions(agentID string) (int, error) {
 }
 
 // ListAgentSessions returns all sessions for an agent with details
-func (r *SessionRepository) ListAgentSessions(agentID string) ([]*SessionState, error) {
+func (r *SessionRepository) ListAgentSessions(tenantID, agentID string) ([]*SessionState, error) {
 	// Get all session IDs for this agent
-	key := fmt.Sprintf("agent:%s:sessions", agentID)
+	key := agentKey(tenantID, agentID, "sessions")
 	sessionIDs, err := r.redis.client.SMembers(r.redis.ctx, key).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list agent sessions: %w", err)
@@ -295,4 +305,52 @@ func (r *SessionRepository) ListAgentSessions(agentID string) ([]*SessionState,
 	}
 	
 	return sessions, nil
-}
\ No newline at end of file
+}
+// TransferSession moves a session, its name and its place in the agent's session set
+// to another tenant and agent. A session that was never persisted is left alone.
+func (r *SessionRepository) TransferSession(sessionID, sessionName, fromTenant, fromAgent, toTenant, toAgent string) error {
+	sessionKey := fmt.Sprintf("session:%s", sessionID)
+	exists, err := r.redis.client.Exists(r.redis.ctx, sessionKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check session: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	// The name is claimed by the new owner first so a conflict leaves the old one intact
+	if sessionName != "" {
+		if err := r.ReserveSessionName(toTenant, toAgent, sessionName, sessionID); err != nil {
+			return err
+		}
+		if err := r.ReleaseSessionName(fromTenant, fromAgent, sessionName); err != nil {
+			slog.Warn("failed to release old session name", "error", err)
+		}
+	}
+
+	if fromAgent != "" {
+		r.redis.client.SRem(r.redis.ctx, agentKey(fromTenant, fromAgent, "sessions"), sessionID)
+	}
+	if err := r.redis.client.SAdd(r.redis.ctx, agentKey(toTenant, toAgent, "sessions"), sessionID).Err(); err != nil {
+		slog.Warn("failed to add session to agent set", "error", err)
+	}
+
+	if err := r.redis.client.HSet(r.redis.ctx, sessionKey, "agent_id", toAgent).Err(); err != nil {
+		return fmt.Errorf("failed to update session owner: %w", err)
+	}
+	if toTenant == "" {
+		err = r.redis.client.HDel(r.redis.ctx, sessionKey, "tenant_id").Err()
+	} else {
+		err = r.redis.client.HSet(r.redis.ctx, sessionKey, "tenant_id", toTenant).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update session tenant: %w", err)
+	}
+
+	slog.Info("session transferred",
+		"session_id", sessionID,
+		"from_agent", fromAgent,
+		"to_agent", toAgent)
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -10,11 +11,14 @@ type SessionState struct {
 	SessionID    string            `json:"session_id"`
 	SessionName  string            `json:"session_name"`
 	AgentID      string            `json:"agent_id,omitempty"`
+	TenantID     string            `json:"tenant_id,omitempty"` // Empty for the default tenant
 	ProcessPort  int               `json:"process_port"`
 	ContextID    string            `json:"context_id"`
 	CreatedAt    time.Time         `json:"created_at"`
 	LastActivity time.Time         `json:"last_activity"`
 	Status       string            `json:"status"`
+	Template     string            `json:"template,omitempty"`
+	Options      json.RawMessage   `json:"options,omitempty"` // Launch options, reapplied on resurrect
 	
 	// Browser state
 	Cookies      []Cookie          `json:"cookies,omitempty"`
@@ -0,0 +1,76 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+)
+
+// Role bounds what an API key may do within its tenant
+type Role string
+
+const (
+	// RoleViewer reads sessions and pages: listings, screenshots, content, events
+	RoleViewer Role = "viewer"
+	// RoleOperator also drives sessions: creates them, navigates, runs scripts, fills forms
+	RoleOperator Role = "operator"
+	// RoleAdmin also destroys sessions, shares them and manages credentials, templates and pipelines
+	RoleAdmin Role = "admin"
+)
+
+// roleRanks orders the roles; each includes everything the ones below it may do
+var roleRanks = map[Role]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Allows reports whether r includes the required role
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// Key is an API key with the role it grants
+type Key struct {
+	Key  string `json:"key"` // Plain text or "sha256:<hex digest>", as in api_keys
+	Role Role   `json:"role"`
+}
+
+// keyRole returns the role of one of the tenant's api_keys
+func (t *Tenant) keyRole() Role {
+	if t.Role == "" {
+		return RoleAdmin
+	}
+	return t.Role
+}
+
+// validateRoles checks the tenant's default role and the role of every key
+func (t *Tenant) validateRoles() error {
+	if t.Role != "" && !t.Role.Valid() {
+		return fmt.Errorf("%w: tenant %q has unknown role %q", ErrInvalidTenant, t.ID, t.Role)
+	}
+	for _, key := range t.Keys {
+		if !key.Role.Valid() {
+			return fmt.Errorf("%w: tenant %q has a key with unknown role %q (use viewer, operator or admin)", ErrInvalidTenant, t.ID, key.Role)
+		}
+	}
+	return nil
+}
+
+// roleContextKey is the context key for the role of the request's API key
+type roleContextKey struct{}
+
+// WithRole returns a context carrying the role a request's API key grants
+func WithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, roleContextKey{}, role)
+}
+
+// RoleFromContext returns the role of the request's API key. Requests that needed no
+// key, because no tenants are configured, may do everything.
+func RoleFromContext(ctx context.Context) Role {
+	if role, ok := ctx.Value(roleContextKey{}).(Role); ok {
+		return role
+	}
+	return RoleAdmin
+}
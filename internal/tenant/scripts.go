@@ -0,0 +1,67 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrScriptDenied is returned for a script the tenant's policy doesn't allow
+var ErrScriptDenied = errors.New("script not allowed")
+
+// ScriptPolicy limits the JavaScript a tenant's keys may send: scripts to execute and
+// extract with, wait functions and init scripts. Without a policy any script is allowed.
+type ScriptPolicy struct {
+	// Refuse every script, leaving the structured endpoints (click, forms, wait on a
+	// selector, content, analysis) as the only way to work with a page
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Longest script accepted, in bytes (0 is unlimited)
+	MaxLength int `json:"max_length,omitempty"`
+
+	// Regular expressions of the approved scripts. Each must match a script in full;
+	// when any are given, a script matching none is refused.
+	AllowedPatterns []string `json:"allowed_patterns,omitempty"`
+
+	allowed []*regexp.Regexp
+}
+
+// compile checks the policy and prepares its patterns
+func (p *ScriptPolicy) compile() error {
+	if p.MaxLength < 0 {
+		return fmt.Errorf("max_length must not be negative")
+	}
+
+	p.allowed = make([]*regexp.Regexp, 0, len(p.AllowedPatterns))
+	for _, pattern := range p.AllowedPatterns {
+		compiled, err := regexp.Compile(`^(?:` + pattern + `)$`)
+		if err != nil {
+			return fmt.Errorf("bad allowed pattern %q: %v", pattern, err)
+		}
+		p.allowed = append(p.allowed, compiled)
+	}
+	return nil
+}
+
+// Check returns ErrScriptDenied, with the reason, when the policy refuses script.
+// what names the script in the error, e.g. "script" or "init_scripts[0]".
+func (p *ScriptPolicy) Check(what string, script string) error {
+	if p == nil {
+		return nil
+	}
+	if p.Disabled {
+		return fmt.Errorf("%w: scripts are disabled for this tenant", ErrScriptDenied)
+	}
+	if p.MaxLength > 0 && len(script) > p.MaxLength {
+		return fmt.Errorf("%w: %s is %d bytes (max %d)", ErrScriptDenied, what, len(script), p.MaxLength)
+	}
+	if len(p.allowed) == 0 {
+		return nil
+	}
+	for _, pattern := range p.allowed {
+		if pattern.MatchString(script) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s matches none of the approved scripts", ErrScriptDenied, what)
+}
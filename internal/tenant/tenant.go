@@ -0,0 +1,280 @@
+// Package tenant maps API keys to tenants. A tenant's sessions are invisible to other
+// tenants, and each tenant has its own quotas and default session template.
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrInvalidTenant = errors.New("invalid tenant")
+	ErrUnknownKey    = errors.New("unknown API key")
+)
+
+// idPattern keeps tenant IDs safe to embed in storage keys
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// hashedKeyPrefix marks an API key given as the hex SHA-256 of the key
+const hashedKeyPrefix = "sha256:"
+
+// Tenant is one customer of a shared deployment
+type Tenant struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+
+	// Keys that authenticate as this tenant, in plain text or as "sha256:<hex digest>"
+	// so the file needn't hold the secrets themselves. They grant Role.
+	APIKeys []string `json:"api_keys,omitempty"`
+	Role    Role     `json:"role,omitempty"` // Default admin
+
+	// Keys granting their own role, e.g. read-only keys for dashboards
+	Keys []Key `json:"keys,omitempty"`
+
+	// Quotas (0 means unlimited)
+	MaxSessions  int `json:"max_sessions,omitempty"`  // Live sessions at once
+	MaxProcesses int `json:"max_processes,omitempty"` // Browser processes the tenant's sessions may occupy
+
+	// Megabytes the tenant's pages may download per UTC day; once reached, sessions
+	// can't be created, resumed or navigated until the next day (0 means unlimited)
+	MaxBandwidthMB int `json:"max_bandwidth_mb,omitempty"`
+
+	// US dollars the tenant's vision, research and healing model calls may cost per
+	// UTC day, as estimated from the configured token prices; once reached, further
+	// calls are refused until the next day (0 means unlimited)
+	MaxModelSpendUSD float64 `json:"max_model_spend_usd,omitempty"`
+
+	// Template applied to sessions created without one
+	DefaultTemplate string `json:"default_template,omitempty"`
+
+	// JavaScript the tenant may run in its pages (nil allows any)
+	ScriptPolicy *ScriptPolicy `json:"script_policy,omitempty"`
+}
+
+// Registry holds the configured tenants
+type Registry struct {
+	tenants map[string]*Tenant    // Tenant ID → tenant
+	keys    map[string]registered // Hex SHA-256 of an API key → tenant and role
+	mu      sync.RWMutex
+}
+
+// registered is what an API key authenticates as
+type registered struct {
+	tenant *Tenant
+	role   Role
+}
+
+// NewRegistry creates an empty tenant registry
+func NewRegistry() *Registry {
+	return &Registry{
+		tenants: make(map[string]*Tenant),
+		keys:    make(map[string]registered),
+	}
+}
+
+// keyDigest returns the lookup form of a configured key
+func keyDigest(key string) (string, error) {
+	if digest, hashed := strings.CutPrefix(key, hashedKeyPrefix); hashed {
+		decoded, err := hex.DecodeString(digest)
+		if err != nil || len(decoded) != sha256.Size {
+			return "", fmt.Errorf("%s keys must be 64 hex characters", hashedKeyPrefix)
+		}
+		return strings.ToLower(digest), nil
+	}
+	if key == "" {
+		return "", fmt.Errorf("API keys must not be empty")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Replace swaps the whole tenant set, rejecting it (and keeping the current one) if any
+// tenant is invalid or two tenants share an ID or key
+func (r *Registry) Replace(tenants []*Tenant) error {
+	byID := make(map[string]*Tenant, len(tenants))
+	byKey := make(map[string]registered)
+
+	for _, t := range tenants {
+		if !idPattern.MatchString(t.ID) {
+			return fmt.Errorf("%w: id %q must be 1-64 letters, digits, '_' or '-'", ErrInvalidTenant, t.ID)
+		}
+		if _, exists := byID[t.ID]; exists {
+			return fmt.Errorf("%w: duplicate id %q", ErrInvalidTenant, t.ID)
+		}
+		if len(t.APIKeys) == 0 && len(t.Keys) == 0 {
+			return fmt.Errorf("%w: tenant %q has no api_keys", ErrInvalidTenant, t.ID)
+		}
+		if t.MaxSessions < 0 || t.MaxProcesses < 0 || t.MaxBandwidthMB < 0 || t.MaxModelSpendUSD < 0 {
+			return fmt.Errorf("%w: tenant %q has a negative quota", ErrInvalidTenant, t.ID)
+		}
+		if err := t.validateRoles(); err != nil {
+			return err
+		}
+		if t.ScriptPolicy != nil {
+			if err := t.ScriptPolicy.compile(); err != nil {
+				return fmt.Errorf("%w: tenant %q script_policy: %v", ErrInvalidTenant, t.ID, err)
+			}
+		}
+
+		keys := make([]Key, 0, len(t.APIKeys)+len(t.Keys))
+		for _, key := range t.APIKeys {
+			keys = append(keys, Key{Key: key, Role: t.keyRole()})
+		}
+		for _, key := range append(keys, t.Keys...) {
+			digest, err := keyDigest(key.Key)
+			if err != nil {
+				return fmt.Errorf("%w: tenant %q: %v", ErrInvalidTenant, t.ID, err)
+			}
+			if other, taken := byKey[digest]; taken {
+				if other.tenant == t {
+					return fmt.Errorf("%w: tenant %q lists an API key twice", ErrInvalidTenant, t.ID)
+				}
+				return fmt.Errorf("%w: tenants %q and %q share an API key", ErrInvalidTenant, other.tenant.ID, t.ID)
+			}
+			byKey[digest] = registered{tenant: t, role: key.Role}
+		}
+		byID[t.ID] = t
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tenants = byID
+	r.keys = byKey
+	return nil
+}
+
+// LoadFile replaces the tenants with those in a JSON file holding an array of tenants
+func (r *Registry) LoadFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	var tenants []*Tenant
+	if err := json.Unmarshal(data, &tenants); err != nil {
+		return 0, fmt.Errorf("failed to parse tenants file: %w", err)
+	}
+
+	if err := r.Replace(tenants); err != nil {
+		return 0, err
+	}
+	return len(tenants), nil
+}
+
+// Enabled reports whether any tenant is configured. Without tenants the server is
+// single-tenant: requests need no key and everything belongs to the default tenant.
+func (r *Registry) Enabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.tenants) > 0
+}
+
+// Authenticate returns the tenant an API key belongs to
+func (r *Registry) Authenticate(key string) (*Tenant, error) {
+	t, _, err := r.AuthenticateWithRole(key)
+	return t, err
+}
+
+// AuthenticateWithRole returns the tenant an API key belongs to and the role it grants
+func (r *Registry) AuthenticateWithRole(key string) (*Tenant, Role, error) {
+	if key == "" {
+		return nil, "", ErrUnknownKey
+	}
+
+	// Only digests are kept, so the lookup never compares secrets directly
+	sum := sha256.Sum256([]byte(key))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	found, exists := r.keys[hex.EncodeToString(sum[:])]
+	if !exists {
+		return nil, "", ErrUnknownKey
+	}
+	return found.tenant, found.role, nil
+}
+
+// Get returns a tenant by ID, or nil when there is no such tenant
+func (r *Registry) Get(id string) *Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tenants[id]
+}
+
+// List returns all tenants sorted by ID
+func (r *Registry) List() []*Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]*Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].ID < tenants[j].ID
+	})
+	return tenants
+}
+
+// MaxSessions returns a tenant's live session quota (0 is unlimited)
+func (r *Registry) MaxSessions(tenantID string) int {
+	if t := r.Get(tenantID); t != nil {
+		return t.MaxSessions
+	}
+	return 0
+}
+
+// MaxProcesses returns a tenant's browser process quota (0 is unlimited)
+func (r *Registry) MaxProcesses(tenantID string) int {
+	if t := r.Get(tenantID); t != nil {
+		return t.MaxProcesses
+	}
+	return 0
+}
+
+// MaxBandwidthBytes returns a tenant's daily bandwidth quota in bytes (0 is unlimited)
+func (r *Registry) MaxBandwidthBytes(tenantID string) int64 {
+	if t := r.Get(tenantID); t != nil {
+		return int64(t.MaxBandwidthMB) << 20
+	}
+	return 0
+}
+
+// MaxModelSpendUSD returns a tenant's daily model budget in US dollars (0 is unlimited)
+func (r *Registry) MaxModelSpendUSD(tenantID string) float64 {
+	if t := r.Get(tenantID); t != nil {
+		return t.MaxModelSpendUSD
+	}
+	return 0
+}
+
+// contextKey is the context key for the request's tenant
+type contextKey struct{}
+
+// WithTenant returns a context carrying the tenant a request authenticated as
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the request's tenant, or nil for the default tenant
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(contextKey{}).(*Tenant)
+	return t
+}
+
+// IDFromContext returns the request's tenant ID, "" for the default tenant
+func IDFromContext(ctx context.Context) string {
+	if t := FromContext(ctx); t != nil {
+		return t.ID
+	}
+	return ""
+}
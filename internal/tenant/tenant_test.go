@@ -0,0 +1,198 @@
+package tenant
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAuthenticate tests plain and pre-hashed keys and unknown keys
+func TestAuthenticate(t *testing.T) {
+	sum := sha256.Sum256([]byte("beta-secret"))
+	registry := NewRegistry()
+	err := registry.Replace([]*Tenant{
+		{ID: "acme", APIKeys: []string{"acme-secret"}, MaxSessions: 5},
+		{ID: "beta", APIKeys: []string{"sha256:" + hex.EncodeToString(sum[:])}},
+	})
+	if err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	if !registry.Enabled() {
+		t.Fatal("expected registry with tenants to be enabled")
+	}
+
+	cases := map[string]string{"acme-secret": "acme", "beta-secret": "beta"}
+	for key, want := range cases {
+		got, err := registry.Authenticate(key)
+		if err != nil {
+			t.Errorf("Authenticate(%q) failed: %v", key, err)
+			continue
+		}
+		if got.ID != want {
+			t.Errorf("Authenticate(%q) = %q, want %q", key, got.ID, want)
+		}
+	}
+
+	for _, key := range []string{"", "wrong", "sha256:" + hex.EncodeToString(sum[:])} {
+		if _, err := registry.Authenticate(key); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Authenticate(%q): expected ErrUnknownKey, got %v", key, err)
+		}
+	}
+
+	if got := registry.MaxSessions("acme"); got != 5 {
+		t.Errorf("MaxSessions(acme) = %d, want 5", got)
+	}
+	if got := registry.MaxSessions("missing"); got != 0 {
+		t.Errorf("MaxSessions(missing) = %d, want 0", got)
+	}
+}
+
+// TestReplaceRejectsInvalid tests that a bad tenant set leaves the current one in place
+func TestReplaceRejectsInvalid(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Replace([]*Tenant{{ID: "acme", APIKeys: []string{"k1"}}}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	cases := map[string][]*Tenant{
+		"bad id":       {{ID: "acme:1", APIKeys: []string{"k"}}},
+		"duplicate id": {{ID: "a", APIKeys: []string{"k1"}}, {ID: "a", APIKeys: []string{"k2"}}},
+		"no keys":      {{ID: "a"}},
+		"shared key":   {{ID: "a", APIKeys: []string{"k"}}, {ID: "b", APIKeys: []string{"k"}}},
+		"bad digest":   {{ID: "a", APIKeys: []string{"sha256:abc"}}},
+		"negative":     {{ID: "a", APIKeys: []string{"k"}, MaxProcesses: -1}},
+	}
+	for name, tenants := range cases {
+		if err := registry.Replace(tenants); !errors.Is(err, ErrInvalidTenant) {
+			t.Errorf("%s: expected ErrInvalidTenant, got %v", name, err)
+		}
+	}
+
+	if _, err := registry.Authenticate("k1"); err != nil {
+		t.Errorf("expected original tenants to survive rejected replacements: %v", err)
+	}
+}
+
+// TestLoadFile tests loading a JSON array of tenants
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	data := `[{"id": "acme", "api_keys": ["k"], "default_template": "stealth"}]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewRegistry()
+	count, err := registry.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if count != 1 || registry.Get("acme").DefaultTemplate != "stealth" {
+		t.Errorf("unexpected tenants after load: count=%d %+v", count, registry.Get("acme"))
+	}
+}
+
+// TestContext tests carrying the tenant on a request context
+func TestContext(t *testing.T) {
+	if got := IDFromContext(context.Background()); got != "" {
+		t.Errorf("expected default tenant without one in the context, got %q", got)
+	}
+
+	ctx := WithTenant(context.Background(), &Tenant{ID: "acme"})
+	if got := IDFromContext(ctx); got != "acme" {
+		t.Errorf("IDFromContext = %q, want acme", got)
+	}
+}
+
+// TestKeyRoles tests that api_keys grant the tenant's role, keys grant their own, and
+// unknown roles are rejected
+func TestKeyRoles(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.Replace([]*Tenant{
+		{ID: "acme", APIKeys: []string{"acme-admin"}, Keys: []Key{{Key: "acme-viewer", Role: RoleViewer}}},
+		{ID: "beta", APIKeys: []string{"beta-operator"}, Role: RoleOperator},
+	})
+	if err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	cases := map[string]Role{"acme-admin": RoleAdmin, "acme-viewer": RoleViewer, "beta-operator": RoleOperator}
+	for key, want := range cases {
+		_, role, err := registry.AuthenticateWithRole(key)
+		if err != nil || role != want {
+			t.Errorf("AuthenticateWithRole(%q) = %q, %v; want %q", key, role, err, want)
+		}
+	}
+
+	if !RoleAdmin.Allows(RoleOperator) || !RoleOperator.Allows(RoleViewer) || RoleViewer.Allows(RoleOperator) {
+		t.Error("expected each role to include the ones below it and nothing above")
+	}
+	if RoleFromContext(context.Background()) != RoleAdmin {
+		t.Error("expected requests without a key to have every role")
+	}
+
+	for _, tenants := range [][]*Tenant{
+		{{ID: "acme", APIKeys: []string{"k1"}, Role: "owner"}},
+		{{ID: "acme", Keys: []Key{{Key: "k1", Role: "reader"}}}},
+		{{ID: "acme", APIKeys: []string{"k1"}, Keys: []Key{{Key: "k1", Role: RoleViewer}}}},
+	} {
+		if err := registry.Replace(tenants); !errors.Is(err, ErrInvalidTenant) {
+			t.Errorf("expected ErrInvalidTenant, got %v", err)
+		}
+	}
+}
+
+// TestScriptPolicy tests disabling scripts, the length limit, approved patterns, and
+// that the default is to allow everything
+func TestScriptPolicy(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.Replace([]*Tenant{
+		{ID: "open", APIKeys: []string{"k-open"}},
+		{ID: "locked", APIKeys: []string{"k-locked"}, ScriptPolicy: &ScriptPolicy{Disabled: true}},
+		{ID: "short", APIKeys: []string{"k-short"}, ScriptPolicy: &ScriptPolicy{MaxLength: 10}},
+		{ID: "approved", APIKeys: []string{"k-approved"}, ScriptPolicy: &ScriptPolicy{
+			AllowedPatterns: []string{`document\.title`, `document\.querySelector\("[^"]*"\)\.innerText`},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	cases := []struct {
+		key     string
+		script  string
+		allowed bool
+	}{
+		{"k-open", "fetch('https://example.com').then(r => r.text())", true},
+		{"k-locked", "1 + 1", false},
+		{"k-short", "1 + 1", true},
+		{"k-short", "document.cookie", false},
+		{"k-approved", "document.title", true},
+		{"k-approved", `document.querySelector("h1").innerText`, true},
+		{"k-approved", "document.title; document.cookie", false},
+	}
+	for _, tc := range cases {
+		tenant, err := registry.Authenticate(tc.key)
+		if err != nil {
+			t.Fatalf("Authenticate(%q) failed: %v", tc.key, err)
+		}
+		err = tenant.ScriptPolicy.Check("script", tc.script)
+		if tc.allowed && err != nil {
+			t.Errorf("%s: expected %q to be allowed, got %v", tenant.ID, tc.script, err)
+		}
+		if !tc.allowed && !errors.Is(err, ErrScriptDenied) {
+			t.Errorf("%s: expected %q to be denied, got %v", tenant.ID, tc.script, err)
+		}
+	}
+
+	for _, policy := range []*ScriptPolicy{{MaxLength: -1}, {AllowedPatterns: []string{"("}}} {
+		err := registry.Replace([]*Tenant{{ID: "acme", APIKeys: []string{"k1"}, ScriptPolicy: policy}})
+		if !errors.Is(err, ErrInvalidTenant) {
+			t.Errorf("expected ErrInvalidTenant for %+v, got %v", policy, err)
+		}
+	}
+}
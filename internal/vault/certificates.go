@@ -0,0 +1,59 @@
+package vault
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// CertificateInfo is the non-secret view of a credential's client certificate
+type CertificateInfo struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// parseCertificate pairs a credential's PEM certificate chain with its PEM key
+func parseCertificate(certPEM, keyPEM string) (*tls.Certificate, error) {
+	certificate, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+	if certificate.Leaf == nil {
+		if certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+		}
+	}
+	return &certificate, nil
+}
+
+// DescribeCertificate returns the non-secret view of cred's client certificate, nil
+// when it has none
+func DescribeCertificate(cred *Credential) *CertificateInfo {
+	if cred.ClientCertificate == "" {
+		return nil
+	}
+	certificate, err := parseCertificate(cred.ClientCertificate, cred.ClientKey)
+	if err != nil {
+		return nil
+	}
+	return &CertificateInfo{
+		Subject:  certificate.Leaf.Subject.String(),
+		Issuer:   certificate.Leaf.Issuer.String(),
+		NotAfter: certificate.Leaf.NotAfter,
+	}
+}
+
+// ClientCertificate returns the client certificate stored under the tenant's alias, for
+// sessions that present it to mutual TLS hosts
+func (v *Vault) ClientCertificate(tenantID, alias string) (*tls.Certificate, error) {
+	cred, err := v.Get(tenantID, alias)
+	if err != nil {
+		return nil, err
+	}
+	if cred.ClientCertificate == "" {
+		return nil, fmt.Errorf("%w: %q", ErrNoCertificate, alias)
+	}
+	return parseCertificate(cred.ClientCertificate, cred.ClientKey)
+}
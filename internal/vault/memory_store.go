@@ -0,0 +1,63 @@
+package vault
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemoryStore keeps sealed credentials in process memory (used when Redis is not configured)
+type MemoryStore struct {
+	blobs map[string][]byte
+	mu    sync.RWMutex
+}
+
+// NewMemoryStore creates an empty in-memory credential store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		blobs: make(map[string][]byte),
+	}
+}
+
+// Put stores a sealed credential
+func (s *MemoryStore) Put(alias string, sealed []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[alias] = sealed
+	return nil
+}
+
+// Get returns a sealed credential
+func (s *MemoryStore) Get(alias string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sealed, exists := s.blobs[alias]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrCredentialNotFound, alias)
+	}
+	return sealed, nil
+}
+
+// Delete removes a sealed credential
+func (s *MemoryStore) Delete(alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.blobs[alias]; !exists {
+		return fmt.Errorf("%w: %s", ErrCredentialNotFound, alias)
+	}
+	delete(s.blobs, alias)
+	return nil
+}
+
+// List returns all stored aliases
+func (s *MemoryStore) List() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	aliases := make([]string, 0, len(s.blobs))
+	for alias := range s.blobs {
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
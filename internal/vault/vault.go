@@ -0,0 +1,224 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+)
+
+// KeySize is the required length of the vault encryption key (AES-256)
+const KeySize = 32
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Error definitions
+var (
+	ErrCredentialNotFound = fmt.Errorf("credential not found")
+	ErrInvalidAlias       = fmt.Errorf("invalid credential alias")
+	ErrInvalidCertificate = fmt.Errorf("invalid client certificate")
+	ErrNoCertificate      = fmt.Errorf("credential has no client certificate")
+)
+
+// Credential is a secret referenced by alias. It is only ever stored encrypted.
+type Credential struct {
+	Alias     string    `json:"alias"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password"`
+	LoginURL  string    `json:"login_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// A PEM client certificate chain and its PEM private key, for mutual TLS
+	ClientCertificate string `json:"client_certificate,omitempty"`
+	ClientKey         string `json:"client_key,omitempty"`
+}
+
+// CredentialInfo is the non-secret view of a credential returned by listings
+type CredentialInfo struct {
+	Alias     string    `json:"alias"`
+	Username  string    `json:"username"`
+	LoginURL  string    `json:"login_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ClientCertificate *CertificateInfo `json:"client_certificate,omitempty"`
+}
+
+// Store persists sealed credential blobs by key: the alias, prefixed with the owning
+// tenant's ID and a slash when there is one
+type Store interface {
+	Put(alias string, sealed []byte) error
+	Get(alias string) ([]byte, error) // Returns ErrCredentialNotFound if missing
+	Delete(alias string) error
+	List() ([]string, error)
+}
+
+// Vault encrypts credentials at rest and resolves them by alias. Each tenant has its
+// own aliases; the empty tenant ID is the deployment without tenants.
+type Vault struct {
+	store Store
+	aead  cipher.AEAD
+}
+
+// New creates a vault using a 32-byte key and the given store
+func New(key []byte, store Store) (*Vault, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("vault key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &Vault{
+		store: store,
+		aead:  aead,
+	}, nil
+}
+
+// GenerateKey returns a random vault key (used when no key is configured)
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate vault key: %w", err)
+	}
+	return key, nil
+}
+
+// storeKey is where a tenant's alias is kept. Aliases can't contain a slash, so no two
+// tenants' keys collide.
+func storeKey(tenantID, alias string) string {
+	if tenantID == "" {
+		return alias
+	}
+	return tenantID + "/" + alias
+}
+
+// Save encrypts and stores a credential under the tenant's alias
+func (v *Vault) Save(tenantID string, cred *Credential) error {
+	if !aliasPattern.MatchString(cred.Alias) {
+		return fmt.Errorf("%w: %q", ErrInvalidAlias, cred.Alias)
+	}
+	if cred.ClientCertificate != "" || cred.ClientKey != "" {
+		if _, err := parseCertificate(cred.ClientCertificate, cred.ClientKey); err != nil {
+			return err
+		}
+	}
+	if cred.CreatedAt.IsZero() {
+		cred.CreatedAt = time.Now()
+	}
+
+	// Make sure the secret can never leak through logs or error messages
+	redact.Default().AddSecret(cred.Password)
+	redact.Default().AddSecret(cred.ClientKey)
+
+	plaintext, err := json.Marshal(cred)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credential: %w", err)
+	}
+
+	// Nonce is prepended to the ciphertext; the store key is bound as additional data,
+	// so a blob copied to another tenant's key doesn't decrypt
+	key := storeKey(tenantID, cred.Alias)
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := v.aead.Seal(nonce, nonce, plaintext, []byte(key))
+
+	if err := v.store.Put(key, sealed); err != nil {
+		return fmt.Errorf("failed to store credential: %w", err)
+	}
+
+	return nil
+}
+
+// Get decrypts the credential stored under the tenant's alias
+func (v *Vault) Get(tenantID, alias string) (*Credential, error) {
+	if !aliasPattern.MatchString(alias) {
+		return nil, fmt.Errorf("%w: %s", ErrCredentialNotFound, alias)
+	}
+	key := storeKey(tenantID, alias)
+	sealed, err := v.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := v.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, fmt.Errorf("sealed credential is corrupt")
+	}
+
+	plaintext, err := v.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credential (wrong vault key?)")
+	}
+
+	var cred Credential
+	if err := json.Unmarshal(plaintext, &cred); err != nil {
+		return nil, fmt.Errorf("failed to parse credential: %w", err)
+	}
+
+	// Secrets loaded from a previous run are registered on first use
+	redact.Default().AddSecret(cred.Password)
+	redact.Default().AddSecret(cred.ClientKey)
+
+	return &cred, nil
+}
+
+// Delete removes the tenant's credential
+func (v *Vault) Delete(tenantID, alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: %s", ErrCredentialNotFound, alias)
+	}
+	return v.store.Delete(storeKey(tenantID, alias))
+}
+
+// List returns the non-secret info of the tenant's credentials sorted by alias
+func (v *Vault) List(tenantID string) ([]CredentialInfo, error) {
+	keys, err := v.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credentials: %w", err)
+	}
+
+	var aliases []string
+	for _, key := range keys {
+		owner, alias, found := strings.Cut(key, "/")
+		if !found {
+			owner, alias = "", key
+		}
+		if owner == tenantID {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+
+	infos := make([]CredentialInfo, 0, len(aliases))
+	for _, alias := range aliases {
+		cred, err := v.Get(tenantID, alias)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, CredentialInfo{
+			Alias:             cred.Alias,
+			Username:          cred.Username,
+			LoginURL:          cred.LoginURL,
+			CreatedAt:         cred.CreatedAt,
+			ClientCertificate: DescribeCertificate(cred),
+		})
+	}
+
+	return infos, nil
+}
@@ -0,0 +1,213 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// newTestVault creates a vault with a fresh key and in-memory store
+func newTestVault(t *testing.T, store Store) *Vault {
+	t.Helper()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	v, err := New(key, store)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return v
+}
+
+// TestSaveAndGet tests that credentials round-trip and are encrypted at rest
+func TestSaveAndGet(t *testing.T) {
+	store := NewMemoryStore()
+	v := newTestVault(t, store)
+
+	if err := v.Save("", &Credential{Alias: "github", Username: "bot", Password: "hunter2"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// The stored blob must not contain the plaintext password
+	sealed, err := store.Get("github")
+	if err != nil {
+		t.Fatalf("store.Get failed: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("hunter2")) {
+		t.Error("password stored in plaintext")
+	}
+
+	cred, err := v.Get("", "github")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if cred.Username != "bot" || cred.Password != "hunter2" {
+		t.Errorf("unexpected credential: %+v", cred)
+	}
+
+	infos, err := v.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Alias != "github" {
+		t.Errorf("unexpected listing: %+v", infos)
+	}
+}
+
+// TestWrongKey tests that a different key cannot decrypt stored credentials
+func TestWrongKey(t *testing.T) {
+	store := NewMemoryStore()
+
+	if err := newTestVault(t, store).Save("", &Credential{Alias: "a", Password: "p"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := newTestVault(t, store).Get("", "a"); err == nil {
+		t.Error("expected decrypt error with wrong key, got nil")
+	}
+}
+
+// TestInvalidAlias tests alias validation and missing lookups
+func TestInvalidAlias(t *testing.T) {
+	v := newTestVault(t, NewMemoryStore())
+
+	if err := v.Save("", &Credential{Alias: "bad alias!", Password: "p"}); !errors.Is(err, ErrInvalidAlias) {
+		t.Errorf("expected ErrInvalidAlias, got %v", err)
+	}
+
+	if _, err := v.Get("", "missing"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("expected ErrCredentialNotFound, got %v", err)
+	}
+}
+
+// TestTenants tests that each tenant sees and uses only its own aliases, and that a
+// sealed credential moved to another tenant's key doesn't decrypt
+func TestTenants(t *testing.T) {
+	store := NewMemoryStore()
+	v := newTestVault(t, store)
+
+	if err := v.Save("acme", &Credential{Alias: "shop", Username: "acme-bot", Password: "acme-secret"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := v.Save("globex", &Credential{Alias: "shop", Username: "globex-bot", Password: "globex-secret"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := v.Save("acme", &Credential{Alias: "crm", Username: "acme-crm", Password: "p"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	cred, err := v.Get("globex", "shop")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if cred.Username != "globex-bot" {
+		t.Errorf("expected globex's own credential, got %+v", cred)
+	}
+	for _, lookup := range []struct{ tenantID, alias string }{{"globex", "crm"}, {"", "shop"}, {"", "acme/crm"}} {
+		if _, err := v.Get(lookup.tenantID, lookup.alias); !errors.Is(err, ErrCredentialNotFound) {
+			t.Errorf("expected ErrCredentialNotFound for %q reading %q, got %v", lookup.tenantID, lookup.alias, err)
+		}
+	}
+	if err := v.Delete("globex", "crm"); !errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("expected another tenant's credential not to be deleted, got %v", err)
+	}
+
+	infos, err := v.List("acme")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Alias != "crm" || infos[1].Username != "acme-bot" {
+		t.Errorf("expected acme's two credentials, got %+v", infos)
+	}
+	if infos, _ := v.List(""); len(infos) != 0 {
+		t.Errorf("expected no credentials without a tenant, got %+v", infos)
+	}
+
+	sealed, _ := store.Get("acme/crm")
+	store.Put("globex/crm", sealed)
+	if _, err := v.Get("globex", "crm"); err == nil || errors.Is(err, ErrCredentialNotFound) {
+		t.Errorf("expected a copied credential to fail decryption, got %v", err)
+	}
+}
+
+// selfSignedPEM returns a PEM certificate and key for a client named commonName
+func selfSignedPEM(t *testing.T, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// TestClientCertificate tests storing a client certificate with a credential and
+// reading it back for mutual TLS
+func TestClientCertificate(t *testing.T) {
+	store := NewMemoryStore()
+	v := newTestVault(t, store)
+
+	certPEM, keyPEM := selfSignedPEM(t, "agent-1.corp.example.com")
+	if err := v.Save("", &Credential{Alias: "corp-mtls", ClientCertificate: certPEM, ClientKey: keyPEM}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if sealed, _ := store.Get("corp-mtls"); bytes.Contains(sealed, []byte("PRIVATE KEY")) {
+		t.Error("private key stored in plaintext")
+	}
+
+	certificate, err := v.ClientCertificate("", "corp-mtls")
+	if err != nil {
+		t.Fatalf("ClientCertificate failed: %v", err)
+	}
+	if certificate.Leaf == nil || certificate.Leaf.Subject.CommonName != "agent-1.corp.example.com" {
+		t.Errorf("unexpected certificate: %+v", certificate.Leaf)
+	}
+
+	infos, err := v.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(infos) != 1 || infos[0].ClientCertificate == nil || infos[0].ClientCertificate.Subject != "CN=agent-1.corp.example.com" {
+		t.Errorf("expected the certificate described in listings, got %+v", infos)
+	}
+
+	// A key that doesn't belong to the certificate is refused
+	_, otherKey := selfSignedPEM(t, "someone-else")
+	if err := v.Save("", &Credential{Alias: "broken", ClientCertificate: certPEM, ClientKey: otherKey}); !errors.Is(err, ErrInvalidCertificate) {
+		t.Errorf("expected ErrInvalidCertificate, got %v", err)
+	}
+
+	if err := v.Save("", &Credential{Alias: "github", Username: "bot", Password: "hunter2"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := v.ClientCertificate("", "github"); !errors.Is(err, ErrNoCertificate) {
+		t.Errorf("expected ErrNoCertificate, got %v", err)
+	}
+}
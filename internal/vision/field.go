@@ -0,0 +1,125 @@
+package vision
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// FieldStream follows a JSON reply as it streams in and passes on the text of one of its
+// string fields, decoded, as that text arrives. Models asked for JSON are streamed through
+// one, so clients see the answer being written rather than the JSON around it.
+type FieldStream struct {
+	key     *regexp.Regexp
+	emit    func(string)
+	pending string // Received but not yet decoded
+	state   int
+}
+
+const (
+	fieldSeeking = iota // Looking for the key
+	fieldReading        // Inside the value
+	fieldDone           // Past the closing quote
+)
+
+// NewFieldStream passes the decoded text of the named field to emit
+func NewFieldStream(field string, emit func(string)) *FieldStream {
+	return &FieldStream{
+		key:  regexp.MustCompile(`"` + regexp.QuoteMeta(field) + `"\s*:\s*"`),
+		emit: emit,
+	}
+}
+
+// Write takes the next piece of the reply
+func (f *FieldStream) Write(piece string) {
+	if f.state == fieldDone {
+		return
+	}
+	f.pending += piece
+
+	if f.state == fieldSeeking {
+		match := f.key.FindStringIndex(f.pending)
+		if match == nil {
+			// Keep enough of the tail for a key split across pieces
+			if len(f.pending) > 256 {
+				f.pending = f.pending[len(f.pending)-256:]
+			}
+			return
+		}
+		f.pending = f.pending[match[1]:]
+		f.state = fieldReading
+	}
+
+	var text strings.Builder
+	i := 0
+	for i < len(f.pending) {
+		c := f.pending[i]
+		if c == '"' {
+			f.state = fieldDone
+			break
+		}
+		if c != '\\' {
+			// Stop before a multi-byte character that hasn't fully arrived
+			r, size := utf8.DecodeRuneInString(f.pending[i:])
+			if r == utf8.RuneError && size == 1 && !utf8.FullRuneInString(f.pending[i:]) {
+				break
+			}
+			text.WriteString(f.pending[i : i+size])
+			i += size
+			continue
+		}
+		if i+1 >= len(f.pending) {
+			break // The escape continues in the next piece
+		}
+		switch escaped := f.pending[i+1]; escaped {
+		case 'n':
+			text.WriteByte('\n')
+		case 't':
+			text.WriteByte('\t')
+		case 'r':
+			text.WriteByte('\r')
+		case 'b':
+			text.WriteByte('\b')
+		case 'f':
+			text.WriteByte('\f')
+		case 'u':
+			if i+6 > len(f.pending) {
+				f.flush(&text, i)
+				return
+			}
+			code, _ := strconv.ParseUint(f.pending[i+2:i+6], 16, 32)
+			r := rune(code)
+			if utf16.IsSurrogate(r) {
+				// Characters outside the BMP come as a pair of escapes
+				if i+12 > len(f.pending) {
+					f.flush(&text, i)
+					return
+				}
+				low, _ := strconv.ParseUint(f.pending[i+8:i+12], 16, 32)
+				text.WriteRune(utf16.DecodeRune(r, rune(low)))
+				i += 12
+				continue
+			}
+			text.WriteRune(r)
+			i += 6
+			continue
+		default:
+			text.WriteByte(escaped) // \" \\ \/
+		}
+		i += 2
+	}
+	f.flush(&text, i)
+}
+
+// flush emits the decoded text and keeps what follows consumed
+func (f *FieldStream) flush(text *strings.Builder, consumed int) {
+	f.pending = f.pending[consumed:]
+	if f.state == fieldDone {
+		f.pending = ""
+	}
+	if text.Len() > 0 {
+		f.emit(text.String())
+	}
+}
@@ -0,0 +1,269 @@
+package vision
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dhruvsoni1802/browser-query-ai/internal/redact"
+)
+
+const (
+	// DefaultOpenAIURL is the public OpenAI API base URL
+	DefaultOpenAIURL = "https://api.openai.com/v1"
+
+	// DefaultOpenAIModel is used when no model is configured
+	DefaultOpenAIModel = "gpt-4o-mini"
+
+	// maxReplyTokens bounds the length of an answer
+	maxReplyTokens = 1024
+
+	// maxErrorBody is how much of a failed response is kept for the error message
+	maxErrorBody = 2048
+
+	// maxStreamLine bounds one line of a streamed completion
+	maxStreamLine = 1 << 20
+)
+
+// OpenAIModel talks to an OpenAI-compatible chat completions API.
+// Many providers and local servers (Azure OpenAI, OpenRouter, vLLM, Ollama) expose
+// the same protocol, so the base URL is configurable.
+type OpenAIModel struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// chatRequest is the body of POST /chat/completions
+type chatRequest struct {
+	Model         string         `json:"model"`
+	Messages      []chatMessage  `json:"messages"`
+	MaxTokens     int            `json:"max_tokens"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatMessage content is a string for system and text-only messages, and a list of
+// parts for the image
+type chatMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// chatPart is one piece of a multimodal user message
+type chatPart struct {
+	Type     string    `json:"type"` // "text" or "image_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"` // A data: URL carrying the screenshot
+}
+
+// chatResponse is the part of the completion response we read
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// chatChunk is one event of a streamed completion; usage comes in the last one
+type chatChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewOpenAIModel creates a client for an OpenAI-compatible API
+func NewOpenAIModel(apiKey string, baseURL string, model string) *OpenAIModel {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIURL
+	}
+	if model == "" {
+		model = DefaultOpenAIModel
+	}
+
+	// Keep the API key out of logs and error messages
+	redact.Default().AddSecret(apiKey)
+
+	return &OpenAIModel{
+		apiKey:     apiKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// Name returns the configured model name
+func (m *OpenAIModel) Name() string {
+	return m.model
+}
+
+// Ask sends the image, if any, and question as one chat completion
+func (m *OpenAIModel) Ask(ctx context.Context, query Query) (*Reply, error) {
+	resp, err := m.send(ctx, query, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode vision response: %w", ErrQueryFailed, err)
+	}
+	if len(decoded.Choices) == 0 {
+		return nil, fmt.Errorf("%w: model returned no choices", ErrQueryFailed)
+	}
+
+	reply := &Reply{
+		Text:  decoded.Choices[0].Message.Content,
+		Model: decoded.Model,
+		Usage: Usage{
+			PromptTokens:     decoded.Usage.PromptTokens,
+			CompletionTokens: decoded.Usage.CompletionTokens,
+		},
+	}
+	if reply.Model == "" {
+		reply.Model = m.model
+	}
+	return reply, nil
+}
+
+// AskStream asks for the completion as a stream of server-sent chunks, passing the
+// text of each to onText as it arrives
+func (m *OpenAIModel) AskStream(ctx context.Context, query Query, onText func(string)) (*Reply, error) {
+	resp, err := m.send(ctx, query, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	reply := &Reply{Model: m.model}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		if !found {
+			continue // Blank separators and comments
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("%w: failed to decode vision stream: %w", ErrQueryFailed, err)
+		}
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("%w: %s", ErrQueryFailed, chunk.Error.Message)
+		}
+		if chunk.Model != "" {
+			reply.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			reply.Usage = Usage{PromptTokens: chunk.Usage.PromptTokens, CompletionTokens: chunk.Usage.CompletionTokens}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				onText(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: vision stream broke off: %w", ErrQueryFailed, err)
+	}
+
+	reply.Text = text.String()
+	return reply, nil
+}
+
+// send posts the chat completion request and checks its status
+func (m *OpenAIModel) send(ctx context.Context, query Query, stream bool) (*http.Response, error) {
+	mimeType := query.MimeType
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+
+	messages := make([]chatMessage, 0, 2)
+	if query.System != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: query.System})
+	}
+	if len(query.Image) == 0 {
+		messages = append(messages, chatMessage{Role: "user", Content: query.Prompt})
+	} else {
+		messages = append(messages, chatMessage{Role: "user", Content: []chatPart{
+			{Type: "text", Text: query.Prompt},
+			{Type: "image_url", ImageURL: &imageURL{
+				URL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(query.Image),
+			}},
+		}})
+	}
+
+	request := chatRequest{
+		Model:     m.model,
+		Messages:  messages,
+		MaxTokens: maxReplyTokens,
+		Stream:    stream,
+	}
+	if stream {
+		// Without this the token counts of a streamed completion aren't reported
+		request.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vision request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build vision request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to reach vision model: %w", ErrQueryFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return nil, fmt.Errorf("%w: model returned HTTP %d: %s", ErrQueryFailed, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,145 @@
+package vision
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestOpenAIAsk tests the request shape and reading the answer
+func TestOpenAIAsk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected authorization header: %q", got)
+		}
+
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if req.Model != "test-model" || len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+
+		// The user message carries the question and the screenshot as a data URL
+		parts := req.Messages[1].Content.([]interface{})
+		image := parts[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"].(string)
+		if !strings.HasPrefix(image, "data:image/png;base64,") {
+			t.Errorf("unexpected image url: %.40s", image)
+		}
+
+		w.Write([]byte(`{"model": "test-model-2024", "choices": [{"message": {"content": "A login form"}}],
+			"usage": {"prompt_tokens": 812, "completion_tokens": 9}}`))
+	}))
+	defer server.Close()
+
+	model := NewOpenAIModel("test-key", server.URL+"/v1/", "test-model")
+	reply, err := model.Ask(context.Background(), Query{System: "Be brief", Prompt: "What is this?", Image: []byte{0x89, 'P', 'N', 'G'}})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+
+	if reply.Text != "A login form" || reply.Model != "test-model-2024" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+	if reply.Usage.PromptTokens != 812 || reply.Usage.CompletionTokens != 9 {
+		t.Errorf("unexpected usage: %+v", reply.Usage)
+	}
+}
+
+// TestOpenAIAskError tests that provider errors surface as ErrQueryFailed
+func TestOpenAIAskError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": {"message": "rate limited"}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIModel("test-key", server.URL, "").Ask(context.Background(), Query{Prompt: "?", Image: []byte{1}})
+	if !errors.Is(err, ErrQueryFailed) || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("expected ErrQueryFailed with provider detail, got %v", err)
+	}
+}
+
+// TestOpenAIAskText tests that a query without an image is sent as plain text
+func TestOpenAIAskText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if content, ok := req.Messages[0].Content.(string); !ok || content != "Summarize: hello" {
+			t.Errorf("expected a plain text user message, got %#v", req.Messages[0].Content)
+		}
+		w.Write([]byte(`{"choices": [{"message": {"content": "A greeting"}}]}`))
+	}))
+	defer server.Close()
+
+	reply, err := NewOpenAIModel("", server.URL, "test-model").Ask(context.Background(), Query{Prompt: "Summarize: hello"})
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if reply.Text != "A greeting" || reply.Model != "test-model" {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+}
+
+// TestOpenAIAskStream tests that streamed chunks reach onText and add up to the reply
+func TestOpenAIAskStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("expected a streamed request with usage, got %+v", req)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"model": "test-model-2024", "choices": [{"delta": {"role": "assistant"}}]}`,
+			`{"choices": [{"delta": {"content": "{\"answer\": \"A log"}}]}`,
+			`{"choices": [{"delta": {"content": "in form\"}"}}]}`,
+			`{"choices": [], "usage": {"prompt_tokens": 40, "completion_tokens": 7}}`,
+			`[DONE]`,
+		} {
+			w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	var pieces []string
+	reply, err := NewOpenAIModel("", server.URL, "test-model").AskStream(context.Background(), Query{Prompt: "What is this?"}, func(text string) {
+		pieces = append(pieces, text)
+	})
+	if err != nil {
+		t.Fatalf("AskStream failed: %v", err)
+	}
+	if len(pieces) != 2 || reply.Text != `{"answer": "A login form"}` || reply.Model != "test-model-2024" || reply.Usage.CompletionTokens != 7 {
+		t.Errorf("unexpected reply %+v from pieces %q", reply, pieces)
+	}
+}
+
+// TestFieldStream tests that a field's text is decoded however the reply is split
+func TestFieldStream(t *testing.T) {
+	reply := "```json\n{\"marks\": [], \"answer\": \"Line one\\nsaid \\\"hi\\\" \\u00e9t\\u00e9 \\ud83d\\ude00 café\", \"points\": []}"
+	want := "Line one\nsaid \"hi\" été 😀 café"
+
+	for size := 1; size <= len(reply); size++ {
+		var got strings.Builder
+		stream := NewFieldStream("answer", func(text string) { got.WriteString(text) })
+		for i := 0; i < len(reply); i += size {
+			stream.Write(reply[i:min(i+size, len(reply))])
+		}
+		if got.String() != want {
+			t.Fatalf("split into pieces of %d bytes: expected %q, got %q", size, want, got.String())
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package vision asks multimodal language models questions about page screenshots.
+package vision
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrQueryFailed means the provider was unreachable or refused the query
+var ErrQueryFailed = errors.New("vision query failed")
+
+// Query is one question about an image, or about the text in the prompt when Image is
+// left empty
+type Query struct {
+	System   string // Instructions for the model
+	Prompt   string // The question
+	Image    []byte
+	MimeType string // e.g. "image/png"
+}
+
+// Usage is the token count the provider reported for a query
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Reply is the model's answer
+type Reply struct {
+	Text  string
+	Model string // Model that answered, as reported by the provider
+	Usage Usage
+
+	Cached bool // Served from an earlier identical query instead of asking the model
+}
+
+// Model answers questions about images.
+// Implementations typically call a hosted multimodal model.
+type Model interface {
+	// Name identifies the model in responses and logs
+	Name() string
+
+	// Ask blocks until the model answers, the provider fails, or ctx ends
+	Ask(ctx context.Context, query Query) (*Reply, error)
+}
+
+// Streamer is a Model that can hand over its reply while writing it
+type Streamer interface {
+	Model
+
+	// AskStream is Ask, calling onText with each piece of the reply text as it arrives
+	AskStream(ctx context.Context, query Query, onText func(string)) (*Reply, error)
+}
@@ -0,0 +1,254 @@
+// Package visual compares page screenshots against stored baselines: a pixel diff
+// for how much changed, SSIM for how different it looks, and a diff image showing
+// where.
+package visual
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// DefaultThreshold is how far apart, from 0 to 1, two pixels must be to count as changed.
+// It is high enough to ignore anti-aliasing and compression noise.
+const DefaultThreshold = 0.1
+
+// ErrInvalidImage means a screenshot or baseline isn't a PNG that can be decoded
+var ErrInvalidImage = errors.New("invalid image")
+
+// ssimWindow is the side of the square windows SSIM is averaged over
+const ssimWindow = 8
+
+// SSIM stabilizing constants for 8-bit luminance, (0.01*255)² and (0.03*255)²
+const (
+	ssimC1 = 6.5025
+	ssimC2 = 58.5225
+)
+
+// Colors of the diff image
+var (
+	changedColor = color.RGBA{R: 255, A: 255}
+	ignoredColor = color.RGBA{R: 170, G: 200, B: 255, A: 255}
+)
+
+// Region is a rectangle in page pixels, left out of the comparison
+type Region struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Options tunes a comparison
+type Options struct {
+	Threshold float64  // DefaultThreshold when zero
+	Ignore    []Region // Masked in both images, such as clocks, ads or carousels
+}
+
+// Result is the outcome of a comparison
+type Result struct {
+	Width          int     `json:"width"` // Of the compared area, which spans both images
+	Height         int     `json:"height"`
+	SizeChanged    bool    `json:"size_changed"` // Pixels only one image has count as changed
+	ChangedPixels  int     `json:"changed_pixels"`
+	ComparedPixels int     `json:"compared_pixels"` // Pixels outside the ignored regions
+	ChangePercent  float64 `json:"change_percent"`
+	SSIM           float64 `json:"ssim"` // Structural similarity of the overlap, 1 for identical
+
+	// Diff is a PNG of the baseline in faded grey with changed pixels in red and ignored
+	// regions in blue
+	Diff []byte `json:"-"`
+}
+
+// Validate checks that the regions have a size and the threshold is in range
+func (o Options) Validate() error {
+	if o.Threshold < 0 || o.Threshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1")
+	}
+	for i, region := range o.Ignore {
+		if region.Width <= 0 || region.Height <= 0 {
+			return fmt.Errorf("ignore[%d] must have a positive width and height", i)
+		}
+	}
+	return nil
+}
+
+// Compare diffs a screenshot against a baseline, both PNGs
+func Compare(baseline, current []byte, opts Options) (*Result, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultThreshold
+	}
+
+	before, err := png.Decode(bytes.NewReader(baseline))
+	if err != nil {
+		return nil, fmt.Errorf("%w: baseline: %v", ErrInvalidImage, err)
+	}
+	after, err := png.Decode(bytes.NewReader(current))
+	if err != nil {
+		return nil, fmt.Errorf("%w: screenshot: %v", ErrInvalidImage, err)
+	}
+
+	b, a := before.Bounds(), after.Bounds()
+	width, height := max(b.Dx(), a.Dx()), max(b.Dy(), a.Dy())
+	overlap := image.Rect(0, 0, min(b.Dx(), a.Dx()), min(b.Dy(), a.Dy()))
+
+	result := &Result{
+		Width:       width,
+		Height:      height,
+		SizeChanged: b.Dx() != a.Dx() || b.Dy() != a.Dy(),
+	}
+	ignored := newMask(width, height, opts.Ignore)
+	diff := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// Luminance of the overlap for SSIM, with ignored pixels made equal
+	lumaBefore := make([]float64, overlap.Dx()*overlap.Dy())
+	lumaAfter := make([]float64, len(lumaBefore))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if ignored.at(x, y) {
+				diff.SetRGBA(x, y, ignoredColor)
+				if x < overlap.Dx() && y < overlap.Dy() {
+					l := luma(before.At(b.Min.X+x, b.Min.Y+y))
+					lumaBefore[y*overlap.Dx()+x], lumaAfter[y*overlap.Dx()+x] = l, l
+				}
+				continue
+			}
+			result.ComparedPixels++
+
+			if x >= overlap.Dx() || y >= overlap.Dy() {
+				result.ChangedPixels++
+				diff.SetRGBA(x, y, changedColor)
+				continue
+			}
+
+			pb, pa := before.At(b.Min.X+x, b.Min.Y+y), after.At(a.Min.X+x, a.Min.Y+y)
+			lb, la := luma(pb), luma(pa)
+			lumaBefore[y*overlap.Dx()+x], lumaAfter[y*overlap.Dx()+x] = lb, la
+
+			if distance(pb, pa) > opts.Threshold {
+				result.ChangedPixels++
+				diff.SetRGBA(x, y, changedColor)
+				continue
+			}
+			faded := uint8(255 - (255-lb)/4)
+			diff.SetRGBA(x, y, color.RGBA{R: faded, G: faded, B: faded, A: 255})
+		}
+	}
+
+	if result.ComparedPixels > 0 {
+		result.ChangePercent = math.Round(float64(result.ChangedPixels)/float64(result.ComparedPixels)*10000) / 100
+	}
+	result.SSIM = math.Round(ssim(lumaBefore, lumaAfter, overlap.Dx(), overlap.Dy())*10000) / 10000
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, diff); err != nil {
+		return nil, fmt.Errorf("failed to encode diff image: %w", err)
+	}
+	result.Diff = encoded.Bytes()
+	return result, nil
+}
+
+// Size returns the dimensions of a PNG without decoding its pixels
+func Size(data []byte) (int, int, error) {
+	config, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	return config.Width, config.Height, nil
+}
+
+// mask marks ignored pixels
+type mask struct {
+	width int
+	set   []bool
+}
+
+func newMask(width, height int, regions []Region) mask {
+	m := mask{width: width}
+	if len(regions) == 0 {
+		return m
+	}
+	m.set = make([]bool, width*height)
+	for _, region := range regions {
+		r := image.Rect(region.X, region.Y, region.X+region.Width, region.Y+region.Height).Intersect(image.Rect(0, 0, width, height))
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				m.set[y*width+x] = true
+			}
+		}
+	}
+	return m
+}
+
+func (m mask) at(x, y int) bool {
+	return m.set != nil && m.set[y*m.width+x]
+}
+
+// luma returns the perceived brightness of a pixel, 0 to 255, over a white background
+func luma(c color.Color) float64 {
+	r, g, b := rgb(c)
+	return 0.299*r + 0.587*g + 0.114*b
+}
+
+// distance returns how different two pixels look, 0 to 1, weighting the channels by
+// how much the eye notices them
+func distance(a, b color.Color) float64 {
+	ra, ga, ba := rgb(a)
+	rb, gb, bb := rgb(b)
+	return (0.299*math.Abs(ra-rb) + 0.587*math.Abs(ga-gb) + 0.114*math.Abs(ba-bb)) / 255
+}
+
+// rgb returns a pixel's channels, 0 to 255, composited over white so transparent
+// pixels compare as what a viewer sees
+func rgb(c color.Color) (float64, float64, float64) {
+	r, g, b, a := c.RGBA()
+	white := float64(0xffff - a)
+	scale := func(v uint32) float64 { return (float64(v) + white) / 257 }
+	return scale(r), scale(g), scale(b)
+}
+
+// ssim returns the mean structural similarity of two luminance planes over
+// non-overlapping windows; partial windows at the edges are included
+func ssim(a, b []float64, width, height int) float64 {
+	if width == 0 || height == 0 {
+		return 0
+	}
+
+	var total float64
+	windows := 0
+	for y0 := 0; y0 < height; y0 += ssimWindow {
+		for x0 := 0; x0 < width; x0 += ssimWindow {
+			var sumA, sumB, sumAA, sumBB, sumAB float64
+			n := 0
+			for y := y0; y < min(y0+ssimWindow, height); y++ {
+				for x := x0; x < min(x0+ssimWindow, width); x++ {
+					va, vb := a[y*width+x], b[y*width+x]
+					sumA += va
+					sumB += vb
+					sumAA += va * va
+					sumBB += vb * vb
+					sumAB += va * vb
+					n++
+				}
+			}
+			count := float64(n)
+			meanA, meanB := sumA/count, sumB/count
+			varA := sumAA/count - meanA*meanA
+			varB := sumBB/count - meanB*meanB
+			covariance := sumAB/count - meanA*meanB
+
+			total += ((2*meanA*meanB + ssimC1) * (2*covariance + ssimC2)) /
+				((meanA*meanA + meanB*meanB + ssimC1) * (varA + varB + ssimC2))
+			windows++
+		}
+	}
+	return total / float64(windows)
+}
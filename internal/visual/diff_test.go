@@ -0,0 +1,130 @@
+package visual
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// page draws a white page with a dark box, like a heading that may move
+func page(t *testing.T, width, height int, box image.Rectangle) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			if (image.Point{X: x, Y: y}).In(box) {
+				c = color.RGBA{R: 20, G: 20, B: 60, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompare(t *testing.T) {
+	baseline := page(t, 40, 40, image.Rect(0, 0, 20, 10))
+
+	t.Run("identical", func(t *testing.T) {
+		result, err := Compare(baseline, baseline, Options{})
+		if err != nil {
+			t.Fatalf("Compare failed: %v", err)
+		}
+		if result.ChangedPixels != 0 || result.ChangePercent != 0 || result.SSIM != 1 {
+			t.Errorf("identical images: got %d changed, %v%%, SSIM %v", result.ChangedPixels, result.ChangePercent, result.SSIM)
+		}
+		if result.ComparedPixels != 1600 || result.SizeChanged {
+			t.Errorf("expected 1600 compared pixels and no size change, got %+v", result)
+		}
+	})
+
+	t.Run("changed region", func(t *testing.T) {
+		// The box moves down by 10 pixels: 200 pixels turn white and 200 turn dark
+		moved := page(t, 40, 40, image.Rect(0, 10, 20, 20))
+		result, err := Compare(baseline, moved, Options{})
+		if err != nil {
+			t.Fatalf("Compare failed: %v", err)
+		}
+		if result.ChangedPixels != 400 || result.ChangePercent != 25 {
+			t.Errorf("expected 400 changed pixels (25%%), got %d (%v%%)", result.ChangedPixels, result.ChangePercent)
+		}
+		if result.SSIM >= 1 {
+			t.Errorf("expected SSIM below 1, got %v", result.SSIM)
+		}
+
+		diff, err := png.Decode(bytes.NewReader(result.Diff))
+		if err != nil {
+			t.Fatalf("diff isn't a PNG: %v", err)
+		}
+		if got := color.RGBAModel.Convert(diff.At(5, 15)); got != changedColor {
+			t.Errorf("expected a changed pixel to be red, got %v", got)
+		}
+		if got := color.RGBAModel.Convert(diff.At(30, 30)); got == changedColor {
+			t.Error("expected an unchanged pixel not to be red")
+		}
+
+		// Masking where the box was and where it went leaves nothing changed
+		result, err = Compare(baseline, moved, Options{Ignore: []Region{{X: 0, Y: 0, Width: 20, Height: 20}}})
+		if err != nil {
+			t.Fatalf("Compare failed: %v", err)
+		}
+		if result.ChangedPixels != 0 || result.ComparedPixels != 1200 || result.SSIM != 1 {
+			t.Errorf("expected the ignored region to be left out, got %+v", result)
+		}
+	})
+
+	t.Run("small change under threshold", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		tinted := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		for y := 0; y < 10; y++ {
+			for x := 0; x < 10; x++ {
+				img.SetRGBA(x, y, color.RGBA{R: 200, G: 200, B: 200, A: 255})
+				tinted.SetRGBA(x, y, color.RGBA{R: 205, G: 200, B: 200, A: 255})
+			}
+		}
+		var a, b bytes.Buffer
+		png.Encode(&a, img)
+		png.Encode(&b, tinted)
+
+		result, err := Compare(a.Bytes(), b.Bytes(), Options{})
+		if err != nil {
+			t.Fatalf("Compare failed: %v", err)
+		}
+		if result.ChangedPixels != 0 {
+			t.Errorf("expected noise under the threshold to be ignored, got %d changed", result.ChangedPixels)
+		}
+	})
+
+	t.Run("size change", func(t *testing.T) {
+		taller := page(t, 40, 50, image.Rect(0, 0, 20, 10))
+		result, err := Compare(baseline, taller, Options{})
+		if err != nil {
+			t.Fatalf("Compare failed: %v", err)
+		}
+		if !result.SizeChanged || result.Width != 40 || result.Height != 50 {
+			t.Errorf("expected a 40x50 size-changed result, got %+v", result)
+		}
+		if result.ChangedPixels != 400 || result.ChangePercent != 20 {
+			t.Errorf("expected the 400 extra pixels to count as changed, got %d (%v%%)", result.ChangedPixels, result.ChangePercent)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := Compare([]byte("not a png"), baseline, Options{}); !errors.Is(err, ErrInvalidImage) {
+			t.Errorf("expected ErrInvalidImage, got %v", err)
+		}
+		if _, err := Compare(baseline, baseline, Options{Threshold: 2}); err == nil {
+			t.Error("expected a threshold above 1 to be rejected")
+		}
+		if _, err := Compare(baseline, baseline, Options{Ignore: []Region{{Width: 10}}}); err == nil {
+			t.Error("expected an empty ignore region to be rejected")
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package visual
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBaselineNotFound = errors.New("baseline not found")
+	ErrInvalidBaseline  = errors.New("invalid baseline name")
+)
+
+// namePattern keeps baseline names usable as file names and URL segments
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// defaultTenantDir holds the baselines of requests without a tenant
+const defaultTenantDir = "_default"
+
+// Baseline describes a stored baseline
+type Baseline struct {
+	Name      string    `json:"name"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Bytes     int       `json:"bytes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store keeps baseline PNGs per tenant, in a directory when one is given and in memory
+// otherwise
+type Store struct {
+	dir string
+	mem map[string]map[string]storedBaseline // Tenant → name → baseline
+	mu  sync.RWMutex
+}
+
+// storedBaseline is a baseline kept in memory
+type storedBaseline struct {
+	data      []byte
+	updatedAt time.Time
+}
+
+// NewStore creates a store under dir, creating it if needed. An empty dir keeps
+// baselines in memory, as NewMemoryStore does.
+func NewStore(dir string) (*Store, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create baseline directory: %w", err)
+		}
+	}
+	return &Store{dir: dir, mem: make(map[string]map[string]storedBaseline)}, nil
+}
+
+// NewMemoryStore creates a store that keeps baselines until the server stops
+func NewMemoryStore() *Store {
+	return &Store{mem: make(map[string]map[string]storedBaseline)}
+}
+
+// ValidateName checks that a baseline name can be stored
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q must be 1-128 letters, digits, '_', '.' or '-'", ErrInvalidBaseline, name)
+	}
+	return nil
+}
+
+// tenantDir returns the directory of a tenant's baselines
+func (s *Store) tenantDir(tenantID string) string {
+	if tenantID == "" {
+		return filepath.Join(s.dir, defaultTenantDir)
+	}
+	return filepath.Join(s.dir, url.PathEscape(tenantID))
+}
+
+// Get returns a baseline PNG
+func (s *Store) Get(tenantID, name string) ([]byte, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	if s.dir == "" {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		stored, exists := s.mem[tenantID][name]
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrBaselineNotFound, name)
+		}
+		return stored.data, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.tenantDir(tenantID), name+".png"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBaselineNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	return data, nil
+}
+
+// Put stores a baseline PNG, replacing any of the same name
+func (s *Store) Put(tenantID, name string, data []byte) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if _, _, err := Size(data); err != nil {
+		return err
+	}
+
+	if s.dir == "" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.mem[tenantID] == nil {
+			s.mem[tenantID] = make(map[string]storedBaseline)
+		}
+		s.mem[tenantID][name] = storedBaseline{data: data, updatedAt: time.Now()}
+		return nil
+	}
+
+	dir := s.tenantDir(tenantID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+
+	// Write then rename, so a comparison never reads half a baseline
+	temp, err := os.CreateTemp(dir, ".baseline-*")
+	if err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	if err := os.Rename(temp.Name(), filepath.Join(dir, name+".png")); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a baseline
+func (s *Store) Delete(tenantID, name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	if s.dir == "" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, exists := s.mem[tenantID][name]; !exists {
+			return fmt.Errorf("%w: %s", ErrBaselineNotFound, name)
+		}
+		delete(s.mem[tenantID], name)
+		return nil
+	}
+
+	err := os.Remove(filepath.Join(s.tenantDir(tenantID), name+".png"))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrBaselineNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete baseline: %w", err)
+	}
+	return nil
+}
+
+// List describes a tenant's baselines, sorted by name
+func (s *Store) List(tenantID string) ([]Baseline, error) {
+	baselines := make([]Baseline, 0)
+
+	if s.dir == "" {
+		s.mu.RLock()
+		for name, stored := range s.mem[tenantID] {
+			width, height, _ := Size(stored.data)
+			baselines = append(baselines, Baseline{Name: name, Width: width, Height: height, Bytes: len(stored.data), UpdatedAt: stored.updatedAt})
+		}
+		s.mu.RUnlock()
+	} else {
+		entries, err := os.ReadDir(s.tenantDir(tenantID))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to list baselines: %w", err)
+		}
+		for _, entry := range entries {
+			name, isPNG := strings.CutSuffix(entry.Name(), ".png")
+			if !isPNG || entry.IsDir() || !namePattern.MatchString(name) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			width, height, err := fileSize(filepath.Join(s.tenantDir(tenantID), entry.Name()))
+			if err != nil {
+				continue
+			}
+			baselines = append(baselines, Baseline{Name: name, Width: width, Height: height, Bytes: int(info.Size()), UpdatedAt: info.ModTime()})
+		}
+	}
+
+	sort.Slice(baselines, func(i, j int) bool {
+		return baselines[i].Name < baselines[j].Name
+	})
+	return baselines, nil
+}
+
+// fileSize returns the dimensions of a PNG file, reading only its header
+func fileSize(path string) (int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	config, err := png.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	return config.Width, config.Height, nil
+}
@@ -0,0 +1,57 @@
+package visual
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	shot := page(t, 10, 10, image.Rect(0, 0, 5, 5))
+
+	for name, dir := range map[string]string{"memory": "", "directory": t.TempDir()} {
+		t.Run(name, func(t *testing.T) {
+			store, err := NewStore(dir)
+			if err != nil {
+				t.Fatalf("NewStore failed: %v", err)
+			}
+
+			if _, err := store.Get("acme", "home"); !errors.Is(err, ErrBaselineNotFound) {
+				t.Errorf("expected ErrBaselineNotFound, got %v", err)
+			}
+			if err := store.Put("acme", "home", shot); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			if err := store.Put("acme", "../escape", shot); !errors.Is(err, ErrInvalidBaseline) {
+				t.Errorf("expected ErrInvalidBaseline, got %v", err)
+			}
+			if err := store.Put("acme", "broken", []byte("not a png")); !errors.Is(err, ErrInvalidImage) {
+				t.Errorf("expected ErrInvalidImage, got %v", err)
+			}
+
+			data, err := store.Get("acme", "home")
+			if err != nil || !bytes.Equal(data, shot) {
+				t.Errorf("expected the stored baseline back, got %d bytes, %v", len(data), err)
+			}
+			if _, err := store.Get("other", "home"); !errors.Is(err, ErrBaselineNotFound) {
+				t.Errorf("expected baselines to be kept per tenant, got %v", err)
+			}
+
+			baselines, err := store.List("acme")
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(baselines) != 1 || baselines[0].Name != "home" || baselines[0].Width != 10 || baselines[0].Bytes != len(shot) {
+				t.Errorf("unexpected listing: %+v", baselines)
+			}
+
+			if err := store.Delete("acme", "home"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if err := store.Delete("acme", "home"); !errors.Is(err, ErrBaselineNotFound) {
+				t.Errorf("expected ErrBaselineNotFound on a second delete, got %v", err)
+			}
+		})
+	}
+}